@@ -19,6 +19,7 @@ import (
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clustersv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 	policyv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
 	placementrulev1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
 	appsv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
@@ -33,6 +34,7 @@ import (
 	"github.com/stolostron/multicluster-global-hub/pkg/constants"
 	"github.com/stolostron/multicluster-global-hub/pkg/jobs"
 	commonobjects "github.com/stolostron/multicluster-global-hub/pkg/objects"
+	"github.com/stolostron/multicluster-global-hub/pkg/tracing"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport/producer"
 	"github.com/stolostron/multicluster-global-hub/pkg/utils"
@@ -88,6 +90,17 @@ func doMain(ctx context.Context, restConfig *rest.Config, agentConfig *config.Ag
 		go utils.StartDefaultPprofServer()
 	}
 
+	shutdownTracing, err := tracing.Init(ctx, "multicluster-global-hub-agent", agentConfig.TracingConfig)
+	if err != nil {
+		setupLog.Error(err, "failed to initialize the tracing")
+		return 1
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			setupLog.Error(err, "failed to flush the spans")
+		}
+	}()
+
 	mgr, err := createManager(restConfig, agentConfig)
 	if err != nil {
 		setupLog.Error(err, "failed to create manager")
@@ -105,12 +118,16 @@ func doMain(ctx context.Context, restConfig *rest.Config, agentConfig *config.Ag
 func parseFlags() *config.AgentConfig {
 	agentConfig := &config.AgentConfig{
 		ElectionConfig: &commonobjects.LeaderElectionConfig{},
+		TracingConfig:  &tracing.TracingConfig{},
 		TransportConfig: &transport.TransportConfig{
 			KafkaConfig: &transport.KafkaConfig{
 				Topics:         &transport.ClusterTopic{},
 				ProducerConfig: &transport.KafkaProducerConfig{},
 				ConsumerConfig: &transport.KafkaConsumerConfig{},
 			},
+			GRPCConfig:           &transport.GRPCConfig{},
+			HTTPConfig:           &transport.HTTPConfig{},
+			SchemaRegistryConfig: &transport.SchemaRegistryConfig{},
 		},
 	}
 
@@ -129,20 +146,69 @@ func parseFlags() *config.AgentConfig {
 		"The path of client certificate for kafka bootstrap server.")
 	pflag.StringVar(&agentConfig.TransportConfig.KafkaConfig.ClientKeyPath, "kafka-client-key-path", "",
 		"The path of client key for kafka bootstrap server.")
+	pflag.StringVar(&agentConfig.TransportConfig.KafkaConfig.SASLMechanism, "kafka-sasl-mechanism", "",
+		"The SASL mechanism to authenticate with kafka, e.g. SCRAM-SHA-512. The client certificate isn't used if it's set.")
+	pflag.StringVar(&agentConfig.TransportConfig.KafkaConfig.SASLUsername, "kafka-sasl-username", "",
+		"The SASL username to authenticate with kafka.")
+	pflag.StringVar(&agentConfig.TransportConfig.KafkaConfig.SASLPasswordPath, "kafka-sasl-password-path", "",
+		"The path of SASL password to authenticate with kafka.")
+	pflag.StringVar(&agentConfig.TransportConfig.GRPCConfig.ServerAddress, "grpc-server-address", "",
+		"The address of the grpc gateway of the manager, it's only used by the grpc transport.")
+	pflag.StringVar(&agentConfig.TransportConfig.GRPCConfig.CACertPath, "grpc-ca-cert-path", "",
+		"The path of CA certificate for the grpc gateway.")
+	pflag.StringVar(&agentConfig.TransportConfig.GRPCConfig.CertPath, "grpc-client-cert-path", "",
+		"The path of client certificate for the grpc gateway, the common name is the leaf hub name.")
+	pflag.StringVar(&agentConfig.TransportConfig.GRPCConfig.KeyPath, "grpc-client-key-path", "",
+		"The path of client key for the grpc gateway.")
+	pflag.StringVar(&agentConfig.TransportConfig.HTTPConfig.ServerAddress, "http-server-address", "",
+		"The address of the https gateway of the manager, it's only used by the http transport.")
+	pflag.StringVar(&agentConfig.TransportConfig.HTTPConfig.CACertPath, "http-ca-cert-path", "",
+		"The path of CA certificate for the https gateway.")
+	pflag.StringVar(&agentConfig.TransportConfig.HTTPConfig.CertPath, "http-client-cert-path", "",
+		"The path of client certificate for the https gateway, the common name is the leaf hub name.")
+	pflag.StringVar(&agentConfig.TransportConfig.HTTPConfig.KeyPath, "http-client-key-path", "",
+		"The path of client key for the https gateway.")
+	pflag.StringVar(&agentConfig.TransportConfig.SchemaRegistryConfig.URL, "schema-registry-url", "",
+		"The URL of the schema registry, the bundles are encoded with avro if it's set.")
+	pflag.StringVar(&agentConfig.TransportConfig.SchemaRegistryConfig.Username, "schema-registry-username", "",
+		"The username to authenticate with the schema registry.")
+	pflag.StringVar(&agentConfig.TransportConfig.SchemaRegistryConfig.PasswordPath, "schema-registry-password-path", "",
+		"The path of the password to authenticate with the schema registry.")
+	pflag.StringVar(&agentConfig.TransportConfig.SchemaRegistryConfig.CACertPath, "schema-registry-ca-cert-path", "",
+		"The path of CA certificate for the schema registry.")
 	pflag.StringVar(&agentConfig.TransportConfig.KafkaConfig.ProducerConfig.ProducerID, "kafka-producer-id", "",
 		"Producer Id for the kafka, default is the leaf hub name.")
+	pflag.StringVar(&agentConfig.TransportConfig.KafkaConfig.ProducerConfig.TransactionalID,
+		"kafka-transactional-id", "",
+		"The transactional id of the kafka producer, the bundles are sent in the kafka transactions if it's set.")
 	pflag.StringVar(&agentConfig.TransportConfig.KafkaConfig.Topics.StatusTopic, "kafka-producer-topic",
 		"event", "Topic for the kafka producer.")
 	pflag.IntVar(&agentConfig.TransportConfig.KafkaConfig.ProducerConfig.MessageSizeLimitKB,
 		"kafka-message-size-limit", 940, "The limit for kafka message size in KB.")
+	pflag.Float64Var(&agentConfig.TransportConfig.KafkaConfig.ProducerConfig.MessageRateLimit,
+		"kafka-producer-rate-limit", 0,
+		"The messages per second sent by the kafka producer, the producer isn't throttled if it's 0.")
+	pflag.IntVar(&agentConfig.TransportConfig.KafkaConfig.ProducerConfig.MessageBurst,
+		"kafka-producer-burst", 0,
+		"The messages sent by the kafka producer at once, the default is the rate limit.")
 	pflag.StringVar(&agentConfig.TransportConfig.KafkaConfig.Topics.SpecTopic, "kafka-consumer-topic",
 		"spec", "Topic for the kafka consumer.")
 	pflag.StringVar(&agentConfig.TransportConfig.KafkaConfig.ConsumerConfig.ConsumerID, "kafka-consumer-id",
 		"multicluster-global-hub-agent", "ID for the kafka consumer.")
+	pflag.StringVar(&agentConfig.TransportConfig.KafkaConfig.ConsumerConfig.LegacyConsumerID,
+		"kafka-legacy-consumer-id", "",
+		"The legacy consumer group, the consumer resumes from its offsets if the consumer group hasn't committed any.")
+	pflag.StringVar(&agentConfig.TracingConfig.Endpoint, "tracing-endpoint", "",
+		"The host:port of the OTLP gRPC collector which the spans of the status bundles are exported to, the "+
+			"spans aren't exported if it's empty.")
+	pflag.BoolVar(&agentConfig.TracingConfig.Insecure, "tracing-insecure", false,
+		"Connect to the OTLP collector without TLS.")
+	pflag.IntVar(&agentConfig.TracingConfig.SamplingPercentage, "tracing-sampling-percentage", 10,
+		"The percentage of the status bundles to be traced.")
 	pflag.StringVar(&agentConfig.PodNameSpace, "pod-namespace", constants.GHAgentNamespace,
 		"The agent running namespace, also used as leader election namespace")
 	pflag.StringVar(&agentConfig.TransportConfig.TransportType, "transport-type", "kafka",
-		"The transport type, 'kafka'")
+		"The transport type, 'kafka', 'grpc' or 'http'")
 	pflag.IntVar(&agentConfig.SpecWorkPoolSize, "consumer-worker-pool-size", 10,
 		"The goroutine number to propagate the bundles on managed cluster.")
 	pflag.BoolVar(&agentConfig.SpecEnforceHohRbac, "enforce-hoh-rbac", false,
@@ -150,6 +216,8 @@ func parseFlags() *config.AgentConfig {
 	pflag.StringVar(&agentConfig.TransportConfig.MessageCompressionType,
 		"transport-message-compression-type", "gzip",
 		"The message compression type for transport layer, 'gzip' or 'no-op'.")
+	pflag.StringVar(&agentConfig.TransportConfig.PayloadEncoding, "transport-payload-encoding", "json",
+		"The encoding of the bundles sent to the transport, 'json' or 'protobuf'.")
 	pflag.IntVar(&agentConfig.StatusDeltaCountSwitchFactor,
 		"status-delta-count-switch-factor", 100,
 		"default with 100.")
@@ -192,6 +260,14 @@ func completeConfig(agentConfig *config.AgentConfig) error {
 		return fmt.Errorf("flag kafka-message-size-limit %d must not exceed %d",
 			agentConfig.TransportConfig.KafkaConfig.ProducerConfig.MessageSizeLimitKB, producer.MaxMessageKBLimit)
 	}
+	if agentConfig.TransportConfig.TransportType == string(transport.GRPC) &&
+		agentConfig.TransportConfig.GRPCConfig.ServerAddress == "" {
+		return fmt.Errorf("flag grpc-server-address can't be empty for the grpc transport")
+	}
+	if agentConfig.TransportConfig.TransportType == string(transport.HTTP) &&
+		agentConfig.TransportConfig.HTTPConfig.ServerAddress == "" {
+		return fmt.Errorf("flag http-server-address can't be empty for the http transport")
+	}
 	agentConfig.TransportConfig.KafkaConfig.EnableTLS = true
 	if agentConfig.MetricsAddress == "" {
 		agentConfig.MetricsAddress = fmt.Sprintf("%s:%d", metricsHost, metricsPort)
@@ -262,14 +338,17 @@ func initCache(config *rest.Config, cacheOpts cache.Options) (cache.Cache, error
 		&apiextensionsv1.CustomResourceDefinition{}: {
 			Field: fields.OneTermEqualSelector("metadata.name", "clustermanagers.operator.open-cluster-management.io"),
 		},
-		&policyv1.Policy{}:                  {},
-		&clusterv1.ManagedCluster{}:         {},
-		&clustersv1alpha1.ClusterClaim{}:    {},
-		&routev1.Route{}:                    {},
-		&placementrulev1.PlacementRule{}:    {},
-		&clusterv1beta1.Placement{}:         {},
-		&clusterv1beta1.PlacementDecision{}: {},
-		&appsv1alpha1.SubscriptionReport{}:  {},
+		&policyv1.Policy{}:                         {},
+		&clusterv1.ManagedCluster{}:                {},
+		&clustersv1alpha1.ClusterClaim{}:           {},
+		&clustersv1alpha1.AddOnPlacementScore{}:    {},
+		&routev1.Route{}:                           {},
+		&placementrulev1.PlacementRule{}:           {},
+		&clusterv1beta1.Placement{}:                {},
+		&clusterv1beta1.PlacementDecision{}:        {},
+		&clusterv1beta2.ManagedClusterSet{}:        {},
+		&clusterv1beta2.ManagedClusterSetBinding{}: {},
+		&appsv1alpha1.SubscriptionReport{}:         {},
 		&coordinationv1.Lease{}: {
 			Field: fields.OneTermEqualSelector("metadata.namespace", constants.GHAgentNamespace),
 		},
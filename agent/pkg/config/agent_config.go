@@ -2,6 +2,7 @@ package config
 
 import (
 	commonobjects "github.com/stolostron/multicluster-global-hub/pkg/objects"
+	"github.com/stolostron/multicluster-global-hub/pkg/tracing"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 )
 
@@ -13,6 +14,7 @@ type AgentConfig struct {
 	StatusDeltaCountSwitchFactor int
 	TransportConfig              *transport.TransportConfig
 	ElectionConfig               *commonobjects.LeaderElectionConfig
+	TracingConfig                *tracing.TracingConfig
 	Terminating                  bool
 	MetricsAddress               string
 	EnableGlobalResource         bool
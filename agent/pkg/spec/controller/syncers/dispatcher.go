@@ -64,6 +64,12 @@ func (d *genericDispatcher) dispatch(ctx context.Context) {
 					"syncer", syncer, "event", evt)
 				continue
 			}
+			// the bundle is sent by a manager whose bundle format can't be parsed by this agent
+			if version := transport.GetFormatVersion(evt); !transport.IsFormatVersionSupported(version) {
+				d.log.Info("skip the bundle with an unsupported format version, upgrade the agent and the manager "+
+					"to the same release", "eventType", evt.Type(), "version", version)
+				continue
+			}
 			if err := syncer.Sync(evt.Data()); err != nil {
 				d.log.Error(err, "submit to syncer error", "eventType", evt.Type())
 			}
@@ -70,9 +70,9 @@ func (syncer *genericBundleSyncer) syncObjects(bundleObjects []*unstructured.Uns
 				}
 			}
 
-			// Deprecated: skip the "bindingOverrides" from the placementbinding
+			// the "bindingOverrides" and "subFilter" of the placementbinding are kept, they're pruned by the hubs
+			// whose CRD doesn't have them yet
 			// Reference: https://github.com/open-cluster-management-io/governance-policy-propagator/pull/110
-			delete(unstructuredObject.Object, "bindingOverrides")
 
 			// Deprecated: skip the "spec.decisionStrategy" and "spec.spreadPolicy" from the placement
 			// Reference:
@@ -0,0 +1,111 @@
+package apps
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/config"
+	statusconfig "github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/config"
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/generic"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+var argoApplicationGVK = schema.GroupVersionKind{
+	Group:   "argoproj.io",
+	Version: "v1alpha1",
+	Kind:    "Application",
+}
+
+// the fields of the application which are synced to the global hub, the others, e.g. the managed resources and the
+// sync history, are too large to be sent periodically
+var (
+	argoApplicationSpecFields   = []string{"project", "source", "sources", "destination"}
+	argoApplicationStatusFields = []string{"health", "sync", "operationState", "reconciledAt", "conditions"}
+	// the result of the last operation is dropped, it contains all the synced resources
+	argoApplicationOperationFields = []string{"phase", "message", "startedAt", "finishedAt"}
+)
+
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// LaunchArgoApplicationSyncer syncs the health and the sync status of the ArgoCD applications on the managed hub, it's
+// skipped if the ArgoCD isn't installed on the hub
+func LaunchArgoApplicationSyncer(ctx context.Context, mgr ctrl.Manager, agentConfig *config.AgentConfig,
+	producer transport.Producer,
+) error {
+	installed, err := isKindInstalled(mgr, argoApplicationGVK)
+	if err != nil || !installed {
+		return err
+	}
+
+	// controller config
+	instance := func() client.Object {
+		application := &unstructured.Unstructured{}
+		application.SetGroupVersionKind(argoApplicationGVK)
+		return application
+	}
+	predicate := predicate.NewPredicateFuncs(func(object client.Object) bool { return true })
+
+	// emitter config
+	emitter := generic.ObjectEmitterWrapper(enum.ArgoApplicationType, nil, trimArgoApplication, false)
+
+	// syncer
+	name := "status.argocd_application"
+	syncInterval := statusconfig.GetPolicyDuration
+
+	return generic.LaunchGenericObjectSyncer(
+		name,
+		mgr,
+		generic.NewGenericController(instance, predicate),
+		producer,
+		syncInterval,
+		[]generic.ObjectEmitter{
+			emitter,
+		})
+}
+
+// isKindInstalled returns false if the CRD of the kind isn't found on the hub, the ArgoCD is optional
+func isKindInstalled(mgr ctrl.Manager, gvk schema.GroupVersionKind) (bool, error) {
+	_, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		ctrl.Log.WithName("status.argocd").Info("skip the syncer, the CRD isn't found", "kind", gvk.Kind)
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// trimArgoApplication only keeps the source, the destination and the status of the application
+func trimArgoApplication(object client.Object) {
+	application, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	if annotations := application.GetAnnotations(); annotations != nil {
+		delete(annotations, lastAppliedConfigAnnotation)
+		application.SetAnnotations(annotations)
+	}
+	trimFields(application.Object, argoApplicationSpecFields, "spec")
+	trimFields(application.Object, argoApplicationStatusFields, "status")
+	trimFields(application.Object, argoApplicationOperationFields, "status", "operationState")
+}
+
+// trimFields removes the fields of the nested map except the given ones
+func trimFields(object map[string]interface{}, keep []string, fields ...string) {
+	content, found, err := unstructured.NestedMap(object, fields...)
+	if err != nil || !found {
+		return
+	}
+	trimmed := map[string]interface{}{}
+	for _, key := range keep {
+		if value, found := content[key]; found {
+			trimmed[key] = value
+		}
+	}
+	_ = unstructured.SetNestedMap(object, trimmed, fields...)
+}
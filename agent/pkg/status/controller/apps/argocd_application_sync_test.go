@@ -0,0 +1,81 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTrimArgoApplication(t *testing.T) {
+	application := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata": map[string]interface{}{
+			"name":      "guestbook",
+			"namespace": "openshift-gitops",
+			"annotations": map[string]interface{}{
+				lastAppliedConfigAnnotation: "{}",
+				"owner":                     "team-a",
+			},
+		},
+		"spec": map[string]interface{}{
+			"project":     "default",
+			"source":      map[string]interface{}{"repoURL": "https://github.com/argoproj/argocd-example-apps.git"},
+			"destination": map[string]interface{}{"server": "https://kubernetes.default.svc"},
+			"syncPolicy":  map[string]interface{}{"automated": map[string]interface{}{}},
+		},
+		"status": map[string]interface{}{
+			"health":    map[string]interface{}{"status": "Healthy"},
+			"sync":      map[string]interface{}{"status": "Synced"},
+			"resources": []interface{}{map[string]interface{}{"kind": "Deployment"}},
+			"history":   []interface{}{map[string]interface{}{"id": int64(1)}},
+			"operationState": map[string]interface{}{
+				"phase":      "Succeeded",
+				"syncResult": map[string]interface{}{"resources": []interface{}{}},
+			},
+		},
+	}}
+	trimArgoApplication(application)
+
+	assert.Equal(t, map[string]string{"owner": "team-a"}, application.GetAnnotations())
+	spec, _, _ := unstructured.NestedMap(application.Object, "spec")
+	assert.ElementsMatch(t, []string{"project", "source", "destination"}, keys(spec))
+	status, _, _ := unstructured.NestedMap(application.Object, "status")
+	assert.ElementsMatch(t, []string{"health", "sync", "operationState"}, keys(status))
+	operation, _, _ := unstructured.NestedMap(application.Object, "status", "operationState")
+	assert.Equal(t, map[string]interface{}{"phase": "Succeeded"}, operation)
+}
+
+func TestTrimArgoApplicationSet(t *testing.T) {
+	applicationSet := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "ApplicationSet",
+		"metadata": map[string]interface{}{
+			"name":      "guestbook",
+			"namespace": "openshift-gitops",
+		},
+		"spec": map[string]interface{}{
+			"generators": []interface{}{map[string]interface{}{"list": map[string]interface{}{}}},
+		},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "ResourcesUpToDate"}},
+			"resources":  []interface{}{map[string]interface{}{"name": "guestbook-cluster1"}},
+			"unknown":    "dropped",
+		},
+	}}
+	trimArgoApplicationSet(applicationSet)
+
+	_, found, _ := unstructured.NestedMap(applicationSet.Object, "spec")
+	assert.False(t, found)
+	status, _, _ := unstructured.NestedMap(applicationSet.Object, "status")
+	assert.ElementsMatch(t, []string{"conditions", "resources"}, keys(status))
+}
+
+func keys(m map[string]interface{}) []string {
+	result := []string{}
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+package apps
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/config"
+	statusconfig "github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/config"
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/generic"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/utils"
+)
+
+var argoApplicationSetGVK = schema.GroupVersionKind{
+	Group:   "argoproj.io",
+	Version: "v1alpha1",
+	Kind:    "ApplicationSet",
+}
+
+// the status of the applicationset which is synced to the global hub, the resources are the generated applications
+// with their health and sync status, and the applicationStatus is the progressive sync of the applications
+var argoApplicationSetStatusFields = []string{"conditions", "resources", "applicationStatus"}
+
+// LaunchArgoApplicationSetSyncer syncs the rollout status of the ApplicationSets propagated from the global hub, it's
+// skipped if the ArgoCD isn't installed on the hub
+func LaunchArgoApplicationSetSyncer(ctx context.Context, mgr ctrl.Manager, agentConfig *config.AgentConfig,
+	producer transport.Producer,
+) error {
+	installed, err := isKindInstalled(mgr, argoApplicationSetGVK)
+	if err != nil || !installed {
+		return err
+	}
+
+	// controller config
+	instance := func() client.Object {
+		applicationSet := &unstructured.Unstructured{}
+		applicationSet.SetGroupVersionKind(argoApplicationSetGVK)
+		return applicationSet
+	}
+	predicate := predicate.NewPredicateFuncs(func(object client.Object) bool { return true })
+
+	// emitter config
+	emitter := generic.ObjectEmitterWrapper(enum.ArgoApplicationSetType,
+		func(obj client.Object) bool {
+			return utils.HasAnnotation(obj, constants.OriginOwnerReferenceAnnotation) // global resource
+		},
+		trimArgoApplicationSet, false)
+
+	// syncer
+	name := "status.argocd_applicationset"
+	syncInterval := statusconfig.GetPolicyDuration
+
+	return generic.LaunchGenericObjectSyncer(
+		name,
+		mgr,
+		generic.NewGenericController(instance, predicate),
+		producer,
+		syncInterval,
+		[]generic.ObjectEmitter{
+			emitter,
+		})
+}
+
+// trimArgoApplicationSet only keeps the status of the applicationset, the global hub already has the spec
+func trimArgoApplicationSet(object client.Object) {
+	applicationSet, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	if annotations := applicationSet.GetAnnotations(); annotations != nil {
+		delete(annotations, lastAppliedConfigAnnotation)
+		applicationSet.SetAnnotations(annotations)
+	}
+	unstructured.RemoveNestedField(applicationSet.Object, "spec")
+	trimFields(applicationSet.Object, argoApplicationSetStatusFields, "status")
+}
@@ -38,6 +38,12 @@ func AddControllers(ctx context.Context, mgr ctrl.Manager, agentConfig *config.A
 	if err := managedclusters.LaunchManagedClusterSyncer(ctx, mgr, agentConfig, producer); err != nil {
 		return fmt.Errorf("failed to launch managedcluster syncer: %w", err)
 	}
+	if err := managedclusters.LaunchManagedClusterSetSyncer(ctx, mgr, agentConfig, producer); err != nil {
+		return fmt.Errorf("failed to launch managedclusterset syncer: %w", err)
+	}
+	if err := managedclusters.LaunchManagedClusterSetBindingSyncer(ctx, mgr, agentConfig, producer); err != nil {
+		return fmt.Errorf("failed to launch managedclustersetbinding syncer: %w", err)
+	}
 
 	// event syncer
 	err = event.LaunchEventSyncer(ctx, mgr, agentConfig, producer)
@@ -50,6 +56,12 @@ func AddControllers(ctx context.Context, mgr ctrl.Manager, agentConfig *config.A
 	if err != nil {
 		return fmt.Errorf("failed to launch policy syncer: %w", err)
 	}
+	if err := policies.LaunchPolicySetSyncer(ctx, mgr, agentConfig, producer); err != nil {
+		return fmt.Errorf("failed to launch policyset syncer: %w", err)
+	}
+	if err := policies.LaunchOperatorPolicySyncer(ctx, mgr, agentConfig, producer); err != nil {
+		return fmt.Errorf("failed to launch operatorpolicy syncer: %w", err)
+	}
 
 	// hub cluster info
 	err = hubcluster.LaunchHubClusterInfoSyncer(mgr, producer)
@@ -73,11 +85,20 @@ func AddControllers(ctx context.Context, mgr ctrl.Manager, agentConfig *config.A
 	if err := placement.LaunchPlacementRuleSyncer(ctx, mgr, agentConfig, producer); err != nil {
 		return fmt.Errorf("failed to launch placementRule syncer: %w", err)
 	}
+	if err := placement.LaunchAddOnPlacementScoreSyncer(ctx, mgr, agentConfig, producer); err != nil {
+		return fmt.Errorf("failed to launch addOnPlacementScore syncer: %w", err)
+	}
 
 	// app
 	if err := apps.LaunchSubscriptionReportSyncer(ctx, mgr, agentConfig, producer); err != nil {
 		return fmt.Errorf("failed to launch subscription report syncer: %w", err)
 	}
+	if err := apps.LaunchArgoApplicationSyncer(ctx, mgr, agentConfig, producer); err != nil {
+		return fmt.Errorf("failed to launch argocd application syncer: %w", err)
+	}
+	if err := apps.LaunchArgoApplicationSetSyncer(ctx, mgr, agentConfig, producer); err != nil {
+		return fmt.Errorf("failed to launch argocd applicationset syncer: %w", err)
+	}
 
 	// lunch a time filter, it must be called after filter.RegisterTimeFilter(key)
 	if err := filter.LaunchTimeFilter(ctx, mgr.GetClient(), agentConfig.PodNameSpace,
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	kafka_confluent "github.com/cloudevents/sdk-go/protocol/kafka_confluent/v2"
 	cecontext "github.com/cloudevents/sdk-go/v2/context"
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -92,7 +93,9 @@ func (s *genericEventSyncer) syncEvent() {
 			return
 		}
 
-		ctx := context.TODO()
+		// the bundles of the hub are keyed by the hub, so they are in one partition and handled in order with their
+		// dependencies by the manager replica which consumes the partition
+		ctx := kafka_confluent.WithMessageKey(context.TODO(), s.leafHubName)
 		if s.emitter.Topic() != "" {
 			ctx = cecontext.WithTopic(ctx, s.emitter.Topic())
 		}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	kafka_confluent "github.com/cloudevents/sdk-go/protocol/kafka_confluent/v2"
 	cecontext "github.com/cloudevents/sdk-go/v2/context"
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -155,7 +156,9 @@ func (c *genericObjectSyncer) syncEvents() {
 			}
 			evt.SetSource(c.leafHubName)
 
-			ctx := context.TODO()
+			// the bundles of the hub are keyed by the hub, so they are in one partition and handled in order with their
+			// dependencies by the manager replica which consumes the partition
+			ctx := kafka_confluent.WithMessageKey(context.TODO(), c.leafHubName)
 			if emitter.Topic() != "" {
 				ctx = cecontext.WithTopic(ctx, emitter.Topic())
 			}
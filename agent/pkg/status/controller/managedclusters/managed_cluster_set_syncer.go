@@ -0,0 +1,70 @@
+package managedclusters
+
+import (
+	"context"
+
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/config"
+	statusconfig "github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/config"
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/generic"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+// LaunchManagedClusterSetSyncer syncs the ManagedClusterSets of the hub, the members of the sets are resolved by the
+// global hub with the labels of the managed clusters, so the status of the sets isn't needed
+func LaunchManagedClusterSetSyncer(ctx context.Context, mgr ctrl.Manager, agentConfig *config.AgentConfig,
+	producer transport.Producer,
+) error {
+	// controller config
+	instance := func() client.Object { return &clusterv1beta2.ManagedClusterSet{} }
+	predicate := predicate.NewPredicateFuncs(func(object client.Object) bool { return true })
+
+	// emitter config
+	emitter := generic.ObjectEmitterWrapper(enum.ManagedClusterSetType, func(obj client.Object) bool {
+		return true
+	}, trimClusterSetObject, false)
+
+	return generic.LaunchGenericObjectSyncer(
+		"status.managed_cluster_set",
+		mgr,
+		generic.NewGenericController(instance, predicate),
+		producer,
+		statusconfig.GetManagerClusterDuration,
+		[]generic.ObjectEmitter{
+			emitter,
+		})
+}
+
+// LaunchManagedClusterSetBindingSyncer syncs the ManagedClusterSetBindings of the hub, they're the namespaces where
+// the ManagedClusterSets can be selected by the placements
+func LaunchManagedClusterSetBindingSyncer(ctx context.Context, mgr ctrl.Manager, agentConfig *config.AgentConfig,
+	producer transport.Producer,
+) error {
+	// controller config
+	instance := func() client.Object { return &clusterv1beta2.ManagedClusterSetBinding{} }
+	predicate := predicate.NewPredicateFuncs(func(object client.Object) bool { return true })
+
+	// emitter config
+	emitter := generic.ObjectEmitterWrapper(enum.ManagedClusterSetBindingType, func(obj client.Object) bool {
+		return true
+	}, trimClusterSetObject, false)
+
+	return generic.LaunchGenericObjectSyncer(
+		"status.managed_cluster_set_binding",
+		mgr,
+		generic.NewGenericController(instance, predicate),
+		producer,
+		statusconfig.GetManagerClusterDuration,
+		[]generic.ObjectEmitter{
+			emitter,
+		})
+}
+
+func trimClusterSetObject(object client.Object) {
+	object.SetManagedFields(nil)
+}
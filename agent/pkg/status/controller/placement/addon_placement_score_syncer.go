@@ -0,0 +1,48 @@
+package placement
+
+import (
+	"context"
+
+	clustersv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/config"
+	statusconfig "github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/config"
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/generic"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+// LaunchAddOnPlacementScoreSyncer syncs the AddOnPlacementScores of the managed clusters, they're the scores of the
+// clusters used by the AddOn prioritizers of the placements
+func LaunchAddOnPlacementScoreSyncer(ctx context.Context, mgr ctrl.Manager, agentConfig *config.AgentConfig,
+	producer transport.Producer,
+) error {
+	// controller config
+	instance := func() client.Object { return &clustersv1alpha1.AddOnPlacementScore{} }
+	predicate := predicate.NewPredicateFuncs(func(object client.Object) bool { return true })
+
+	// emitter config
+	addOnPlacementScoreEmitter := generic.ObjectEmitterWrapper(enum.AddOnPlacementScoreType,
+		func(obj client.Object) bool {
+			return true
+		}, func(obj client.Object) {
+			obj.SetManagedFields(nil)
+		}, false)
+
+	// syncer
+	name := "status.addon_placement_score"
+	syncInterval := statusconfig.GetPolicyDuration
+
+	return generic.LaunchGenericObjectSyncer(
+		name,
+		mgr,
+		generic.NewGenericController(instance, predicate),
+		producer,
+		syncInterval,
+		[]generic.ObjectEmitter{
+			addOnPlacementScoreEmitter,
+		})
+}
@@ -25,6 +25,12 @@ func LaunchPlacementDecisionSyncer(ctx context.Context, mgr ctrl.Manager, agentC
 	predicate := predicate.NewPredicateFuncs(func(object client.Object) bool { return true })
 
 	// emitter config
+	localPlacementDecisionEmitter := generic.ObjectEmitterWrapper(enum.LocalPlacementDecisionType,
+		func(obj client.Object) bool {
+			return !utils.HasAnnotation(obj, constants.OriginOwnerReferenceAnnotation) // local resource
+		}, func(obj client.Object) {
+			obj.SetManagedFields(nil)
+		}, false)
 	placementDecisionEmitter := generic.ObjectEmitterWrapper(enum.PlacementDecisionType,
 		func(obj client.Object) bool {
 			return utils.HasAnnotation(obj, constants.OriginOwnerReferenceAnnotation) // global resource
@@ -43,6 +49,7 @@ func LaunchPlacementDecisionSyncer(ctx context.Context, mgr ctrl.Manager, agentC
 		producer,
 		syncInterval,
 		[]generic.ObjectEmitter{
+			localPlacementDecisionEmitter,
 			placementDecisionEmitter,
 		})
 }
@@ -25,6 +25,14 @@ func LaunchPlacementSyncer(ctx context.Context, mgr ctrl.Manager, agentConfig *c
 	predicate := predicate.NewPredicateFuncs(func(object client.Object) bool { return true })
 
 	// emitter config
+	localPlacementEmitter := generic.ObjectEmitterWrapper(enum.LocalPlacementSpecType,
+		func(obj client.Object) bool {
+			return !utils.HasAnnotation(obj, constants.OriginOwnerReferenceAnnotation) // local resource
+		},
+		func(obj client.Object) {
+			obj.SetManagedFields(nil)
+		}, false,
+	)
 	globalPlacementEmitter := generic.ObjectEmitterWrapper(enum.PlacementSpecType,
 		func(obj client.Object) bool {
 			return utils.HasAnnotation(obj, constants.OriginOwnerReferenceAnnotation) // global resource
@@ -45,6 +53,7 @@ func LaunchPlacementSyncer(ctx context.Context, mgr ctrl.Manager, agentConfig *c
 		producer,
 		syncInterval,
 		[]generic.ObjectEmitter{
+			localPlacementEmitter,
 			globalPlacementEmitter,
 		})
 }
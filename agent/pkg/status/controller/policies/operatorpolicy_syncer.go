@@ -0,0 +1,91 @@
+package policies
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/config"
+	statusconfig "github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/config"
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/generic"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/utils"
+)
+
+var operatorPolicyGVK = schema.GroupVersionKind{
+	Group:   "policy.open-cluster-management.io",
+	Version: "v1beta1",
+	Kind:    "OperatorPolicy",
+}
+
+// the status of the operatorpolicy which is synced to the global hub, the relatedObjects are left on the hub
+var operatorPolicyStatusFields = []string{"compliant", "conditions"}
+
+// LaunchOperatorPolicySyncer syncs the compliance of the OperatorPolicies propagated from the global hub, it's skipped
+// if the OperatorPolicy CRD isn't installed on the hub
+func LaunchOperatorPolicySyncer(ctx context.Context, mgr ctrl.Manager, agentConfig *config.AgentConfig,
+	producer transport.Producer,
+) error {
+	_, err := mgr.GetRESTMapper().RESTMapping(operatorPolicyGVK.GroupKind(), operatorPolicyGVK.Version)
+	if meta.IsNoMatchError(err) {
+		ctrl.Log.WithName("status.operatorpolicy").Info("skip the syncer, the CRD isn't found",
+			"kind", operatorPolicyGVK.Kind)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// controller config
+	instance := func() client.Object {
+		operatorPolicy := &unstructured.Unstructured{}
+		operatorPolicy.SetGroupVersionKind(operatorPolicyGVK)
+		return operatorPolicy
+	}
+	predicate := predicate.NewPredicateFuncs(func(object client.Object) bool { return true })
+
+	// emitter config
+	emitter := generic.ObjectEmitterWrapper(enum.OperatorPolicyType,
+		func(obj client.Object) bool {
+			return utils.HasAnnotation(obj, constants.OriginOwnerReferenceAnnotation) // global resource
+		},
+		cleanOperatorPolicy, false)
+
+	return generic.LaunchGenericObjectSyncer(
+		"status.operatorpolicy",
+		mgr,
+		generic.NewGenericController(instance, predicate),
+		producer,
+		statusconfig.GetPolicyDuration,
+		[]generic.ObjectEmitter{
+			emitter,
+		})
+}
+
+// cleanOperatorPolicy only keeps the compliance of the operatorpolicy, the global hub already has the spec
+func cleanOperatorPolicy(object client.Object) {
+	operatorPolicy, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		panic("Wrong instance passed to clean operatorpolicy function, not an OperatorPolicy")
+	}
+	unstructured.RemoveNestedField(operatorPolicy.Object, "spec")
+
+	status, found, err := unstructured.NestedMap(operatorPolicy.Object, "status")
+	if err != nil || !found {
+		return
+	}
+	trimmed := map[string]interface{}{}
+	for _, key := range operatorPolicyStatusFields {
+		if value, found := status[key]; found {
+			trimmed[key] = value
+		}
+	}
+	_ = unstructured.SetNestedMap(operatorPolicy.Object, trimmed, "status")
+}
@@ -0,0 +1,53 @@
+package policies
+
+import (
+	"context"
+
+	policyv1beta1 "open-cluster-management.io/governance-policy-propagator/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/config"
+	statusconfig "github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/config"
+	"github.com/stolostron/multicluster-global-hub/agent/pkg/status/controller/generic"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/utils"
+)
+
+// LaunchPolicySetSyncer syncs the compliance of the PolicySets propagated from the global hub
+func LaunchPolicySetSyncer(ctx context.Context, mgr ctrl.Manager, agentConfig *config.AgentConfig,
+	producer transport.Producer,
+) error {
+	// controller config
+	instance := func() client.Object { return &policyv1beta1.PolicySet{} }
+	predicate := predicate.NewPredicateFuncs(func(object client.Object) bool { return true })
+
+	// emitter config
+	emitter := generic.ObjectEmitterWrapper(enum.PolicySetType,
+		func(obj client.Object) bool {
+			return utils.HasAnnotation(obj, constants.OriginOwnerReferenceAnnotation) // global resource
+		},
+		cleanPolicySet, false)
+
+	return generic.LaunchGenericObjectSyncer(
+		"status.policyset",
+		mgr,
+		generic.NewGenericController(instance, predicate),
+		producer,
+		statusconfig.GetPolicyDuration,
+		[]generic.ObjectEmitter{
+			emitter,
+		})
+}
+
+// cleanPolicySet only keeps the status of the policyset, the global hub already has the spec
+func cleanPolicySet(object client.Object) {
+	policySet, ok := object.(*policyv1beta1.PolicySet)
+	if !ok {
+		panic("Wrong instance passed to clean policyset function, not a PolicySet")
+	}
+	policySet.Spec = policyv1beta1.PolicySetSpec{}
+}
@@ -0,0 +1,48 @@
+#!/bin/bash
+# Copyright (c) 2024 Red Hat, Inc.
+# Copyright Contributors to the Open Cluster Management project
+
+### This script permanently deletes the data of a managed hub or a managed cluster from the global hub database
+### Usage:
+###   ./purge.sh hub <hub-name>
+###   ./purge.sh cluster <hub-name> <cluster-name>
+### Set YES=true to skip the interactive confirmation
+
+set -eo pipefail
+
+NAMESPACE=${NAMESPACE:-"multicluster-global-hub"}
+TOKEN=${TOKEN:-$(oc whoami -t)}
+GLOBAL_HUB_API_HOST=${GLOBAL_HUB_API_HOST:-$(oc -n "$NAMESPACE" get route multicluster-global-hub-manager -o jsonpath={.spec.host})}
+API="https://$GLOBAL_HUB_API_HOST/global-hub-api/v1"
+
+target_type=$1
+hub_name=$2
+cluster_name=$3
+
+if [[ "$target_type" == "hub" && -n "$hub_name" ]]; then
+  body="{\"type\":\"hub\",\"leafHubName\":\"$hub_name\"}"
+elif [[ "$target_type" == "cluster" && -n "$hub_name" && -n "$cluster_name" ]]; then
+  body="{\"type\":\"cluster\",\"leafHubName\":\"$hub_name\",\"clusterName\":\"$cluster_name\"}"
+else
+  echo "Usage: $0 hub <hub-name> | cluster <hub-name> <cluster-name>"
+  exit 1
+fi
+
+response=$(curl -sk --fail-with-body -H "Authorization: Bearer $TOKEN" -H "Content-Type: application/json" \
+  -X POST "$API/purges" -d "$body")
+purge_id=$(echo "$response" | jq -r '.id')
+token=$(echo "$response" | jq -r '.confirmationToken')
+
+echo "The following rows will be permanently deleted:"
+echo "$response" | jq '.rows'
+
+if [[ "$YES" != "true" ]]; then
+  read -r -p "Type the $target_type name '${cluster_name:-$hub_name}' to confirm the purge: " answer
+  if [[ "$answer" != "${cluster_name:-$hub_name}" ]]; then
+    echo "Purge $purge_id is not confirmed, it will expire in 10 minutes"
+    exit 1
+  fi
+fi
+
+curl -sk --fail-with-body -H "Authorization: Bearer $TOKEN" -H "Content-Type: application/json" \
+  -X POST "$API/purge/$purge_id/confirm" -d "{\"token\":\"$token\"}" | jq .
@@ -17,6 +17,7 @@ import (
 	"github.com/spf13/pflag"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -26,18 +27,28 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/archive"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/audit"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/backup"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/complianceslo"
 	managerconfig "github.com/stolostron/multicluster-global-hub/manager/pkg/config"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/cronjob"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/hubmanagement"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/notification"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/searchindexer"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/rollout"
 	statussyncer "github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/throttle"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/transporthealth"
 	mgrwebhook "github.com/stolostron/multicluster-global-hub/manager/pkg/webhook"
 	"github.com/stolostron/multicluster-global-hub/pkg/constants"
 	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/encryption"
 	commonobjects "github.com/stolostron/multicluster-global-hub/pkg/objects"
 	"github.com/stolostron/multicluster-global-hub/pkg/statistics"
+	"github.com/stolostron/multicluster-global-hub/pkg/tracing"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport/producer"
 	"github.com/stolostron/multicluster-global-hub/pkg/utils"
@@ -68,6 +79,7 @@ func init() {
 
 func parseFlags() *managerconfig.ManagerConfig {
 	managerConfig := &managerconfig.ManagerConfig{
+		JobSchedules:   &managerconfig.JobSchedules{},
 		SyncerConfig:   &managerconfig.SyncerConfig{},
 		DatabaseConfig: &managerconfig.DatabaseConfig{},
 		TransportConfig: &transport.TransportConfig{
@@ -77,10 +89,26 @@ func parseFlags() *managerconfig.ManagerConfig {
 				ProducerConfig: &transport.KafkaProducerConfig{},
 				ConsumerConfig: &transport.KafkaConsumerConfig{},
 			},
+			GRPCConfig:           &transport.GRPCConfig{Listen: true},
+			HTTPConfig:           &transport.HTTPConfig{Listen: true},
+			SchemaRegistryConfig: &transport.SchemaRegistryConfig{},
 		},
 		StatisticsConfig:      &statistics.StatisticsConfig{},
 		NonK8sAPIServerConfig: &nonk8sapi.NonK8sAPIServerConfig{},
 		ElectionConfig:        &commonobjects.LeaderElectionConfig{},
+		SearchIndexerConfig:   &searchindexer.SearchIndexerConfig{},
+		ThrottleConfig:        &throttle.ThrottleConfig{},
+		RolloutConfig:         &rollout.RolloutConfig{},
+		TransportProbeConfig:  &transporthealth.ProbeConfig{},
+		AuditConfig:           &audit.AuditConfig{},
+		WebhookConfig:         &notification.WebhookConfig{},
+		ServiceNowConfig:      &notification.ServiceNowConfig{},
+		ChatConfig:            &notification.ChatConfig{},
+		SinkConfig:            &notification.SinkConfig{},
+		SIEMConfig:            &notification.SIEMConfig{},
+		LifecycleStreamConfig: &notification.LifecycleStreamConfig{},
+		ArchiveConfig:         &archive.ArchiveConfig{},
+		TracingConfig:         &tracing.TracingConfig{},
 		LaunchJobNames:        "",
 	}
 
@@ -97,22 +125,48 @@ func parseFlags() *managerconfig.ManagerConfig {
 	pflag.StringVar(&managerConfig.SchedulerInterval, "scheduler-interval", "day",
 		"The job scheduler interval for moving policy compliance history, "+
 			"can be 'month', 'week', 'day', 'hour', 'minute' or 'second', default value is 'day'.")
+	pflag.StringVar(&managerConfig.JobSchedules.ComplianceHistory, "compliance-history-schedule", "",
+		"The cron schedule of moving the policy compliance history, e.g. '30 23 * * *', it overrides the "+
+			"scheduler-interval if it's set.")
+	pflag.StringVar(&managerConfig.JobSchedules.ComplianceRollup, "compliance-rollup-schedule", "",
+		"The cron schedule of rolling up the compliance history, default value is '0 1 * * *'.")
+	pflag.StringVar(&managerConfig.JobSchedules.DataRetention, "data-retention-schedule", "",
+		"The cron schedule of pruning the expired data, default value is '0 0 1,15,28 * *'.")
+	pflag.Float64Var(&managerConfig.ComplianceSLOObjective, "compliance-slo-objective", complianceslo.DefaultObjective,
+		"The percentage of the compliant clusters of each policy and each managed hub, the burn rate of its error "+
+			"budget is exported in the metrics.")
 	pflag.DurationVar(&managerConfig.SyncerConfig.SpecSyncInterval, "spec-sync-interval", 5*time.Second,
 		"The synchronization interval of resources in spec.")
 	pflag.DurationVar(&managerConfig.SyncerConfig.StatusSyncInterval, "status-sync-interval", 5*time.Second,
 		"The synchronization interval of resources in status.")
 	pflag.DurationVar(&managerConfig.SyncerConfig.DeletedLabelsTrimmingInterval, "deleted-labels-trimming-interval",
 		5*time.Second, "The trimming interval of deleted labels.")
+	pflag.DurationVar(&managerConfig.SyncerConfig.EventCoalesceWindow, "event-coalesce-window", 5*time.Minute,
+		"The identical status events reported in the window are written once and counted on the written row, "+
+			"the events aren't coalesced if it's 0.")
+	pflag.BoolVar(&managerConfig.SyncerConfig.EnableStatusSharding, "enable-status-sharding", false,
+		"The replicas split the partitions of the status topic and write the bundles of their hubs, instead of the "+
+			"leader consuming all the partitions. It's only supported by the kafka transport.")
 	pflag.IntVar(&managerConfig.DatabaseConfig.MaxOpenConns, "database-pool-size", 10,
 		"The size of database connection pool for the process user.")
+	pflag.IntVar(&managerConfig.DatabaseConfig.MaxIdleConns, "database-max-idle-conns", 2,
+		"The maximum number of the idle connections in the database connection pool.")
+	pflag.DurationVar(&managerConfig.DatabaseConfig.SlowQueryThreshold, "database-slow-query-threshold",
+		200*time.Millisecond, "The queries which run longer than it are logged as the slow queries.")
 	pflag.StringVar(&managerConfig.DatabaseConfig.ProcessDatabaseURL, "process-database-url", "",
 		"The URL of database server for the process user.")
 	pflag.StringVar(&managerConfig.DatabaseConfig.TransportBridgeDatabaseURL,
 		"transport-bridge-database-url", "", "The URL of database server for the transport-bridge user.")
+	pflag.StringVar(&managerConfig.DatabaseConfig.ReplicaDatabaseURL, "database-replica-url", "",
+		"The URL of the read-only replica, the read-only APIs query the replica if it's set.")
+	pflag.DurationVar(&managerConfig.DatabaseConfig.ProbeInterval, "database-probe-interval", 10*time.Second,
+		"The interval to probe the database, the ingestion is paused while the database is unavailable.")
 	pflag.StringVar(&managerConfig.TransportConfig.TransportType, "transport-type", "kafka",
-		"The transport type, 'kafka'.")
+		"The transport type, 'kafka', 'grpc' or 'http'.")
 	pflag.StringVar(&managerConfig.TransportConfig.MessageCompressionType, "transport-message-compression-type",
 		"gzip", "The message compression type for transport layer, 'gzip' or 'no-op'.")
+	pflag.StringVar(&managerConfig.TransportConfig.PayloadEncoding, "transport-payload-encoding", "json",
+		"The encoding of the bundles sent to the transport, 'json' or 'protobuf'.")
 	pflag.DurationVar(&managerConfig.TransportConfig.CommitterInterval, "transport-committer-interval",
 		40*time.Second, "The committer interval for transport layer.")
 	pflag.StringVar(&managerConfig.TransportConfig.KafkaConfig.BootstrapServer, "kafka-bootstrap-server",
@@ -125,8 +179,50 @@ func parseFlags() *managerconfig.ManagerConfig {
 		"The path of client certificate for kafka bootstrap server.")
 	pflag.StringVar(&managerConfig.TransportConfig.KafkaConfig.ClientKeyPath, "kafka-client-key-path", "",
 		"The path of client key for kafka bootstrap server.")
+	pflag.StringVar(&managerConfig.TransportConfig.KafkaConfig.SASLMechanism, "kafka-sasl-mechanism", "",
+		"The SASL mechanism to authenticate with kafka, e.g. AWS_MSK_IAM. The client certificate isn't used if it's set.")
+	pflag.StringVar(&managerConfig.TransportConfig.KafkaConfig.SASLUsername, "kafka-sasl-username", "",
+		"The SASL username to authenticate with kafka.")
+	pflag.StringVar(&managerConfig.TransportConfig.KafkaConfig.SASLPasswordPath, "kafka-sasl-password-path", "",
+		"The path of SASL password to authenticate with kafka.")
+	pflag.StringVar(&managerConfig.TransportConfig.GRPCConfig.ServerAddress, "grpc-server-address", ":9444",
+		"The address the grpc server listens on for the agents, it's only used by the grpc transport.")
+	pflag.StringVar(&managerConfig.TransportConfig.GRPCConfig.CACertPath, "grpc-ca-cert-path", "",
+		"The path of CA certificate to verify the client certificates of the agents.")
+	pflag.StringVar(&managerConfig.TransportConfig.GRPCConfig.CertPath, "grpc-server-cert-path", "",
+		"The path of server certificate for the grpc server.")
+	pflag.StringVar(&managerConfig.TransportConfig.GRPCConfig.KeyPath, "grpc-server-key-path", "",
+		"The path of server key for the grpc server.")
+	pflag.StringVar(&managerConfig.TransportConfig.HTTPConfig.ServerAddress, "http-server-address", ":9445",
+		"The address the https server listens on for the agents, it's only used by the http transport.")
+	pflag.StringVar(&managerConfig.TransportConfig.HTTPConfig.CACertPath, "http-ca-cert-path", "",
+		"The path of CA certificate to verify the client certificates of the agents.")
+	pflag.StringVar(&managerConfig.TransportConfig.HTTPConfig.CertPath, "http-server-cert-path", "",
+		"The path of server certificate for the https server.")
+	pflag.StringVar(&managerConfig.TransportConfig.HTTPConfig.KeyPath, "http-server-key-path", "",
+		"The path of server key for the https server.")
+	pflag.StringVar(&managerConfig.TransportConfig.SchemaRegistryConfig.URL, "schema-registry-url", "",
+		"The URL of the schema registry, the bundles are encoded with avro if it's set.")
+	pflag.StringVar(&managerConfig.TransportConfig.SchemaRegistryConfig.Username, "schema-registry-username", "",
+		"The username to authenticate with the schema registry.")
+	pflag.StringVar(&managerConfig.TransportConfig.SchemaRegistryConfig.PasswordPath, "schema-registry-password-path", "",
+		"The path of the password to authenticate with the schema registry.")
+	pflag.StringVar(&managerConfig.TransportConfig.SchemaRegistryConfig.CACertPath, "schema-registry-ca-cert-path", "",
+		"The path of CA certificate for the schema registry.")
 	pflag.StringVar(&managerConfig.DatabaseConfig.CACertPath, "postgres-ca-path", "/postgres-ca/ca.crt",
 		"The path of CA certificate for kafka bootstrap server.")
+	pflag.StringVar(&managerConfig.DatabaseConfig.AuthType, "database-auth", database.PasswordAuth,
+		"The authentication of the database: password, aws-iam or azure-ad. The token of the cloud identity is "+
+			"used as the password for the aws-iam and azure-ad.")
+	pflag.StringVar(&managerConfig.DatabaseConfig.EncryptionKeyDir, "payload-encryption-key-dir", "",
+		"The directory of the data keys which encrypt the policy payloads in the database, the 'key' file encrypts "+
+			"the new payloads and the 'key.*' files decrypt the payloads of the previous keys.")
+	pflag.StringVar(&managerConfig.DatabaseConfig.EncryptionVault.Address, "payload-encryption-vault-address", "",
+		"The address of the Vault which unwraps the data keys, the data keys are plain if it isn't set.")
+	pflag.StringVar(&managerConfig.DatabaseConfig.EncryptionVault.TransitMount,
+		"payload-encryption-vault-transit-mount", "transit", "The mount path of the transit secrets engine.")
+	pflag.StringVar(&managerConfig.DatabaseConfig.EncryptionVault.KeyName, "payload-encryption-vault-key-name", "",
+		"The name of the transit key which wraps the data keys.")
 	pflag.StringVar(&managerConfig.TransportConfig.KafkaConfig.ProducerConfig.ProducerID, "kafka-producer-id",
 		"multicluster-global-hub-manager", "ID for the kafka producer.")
 	pflag.StringVar(&managerConfig.TransportConfig.KafkaConfig.Topics.SpecTopic, "kafka-producer-topic",
@@ -135,6 +231,8 @@ func parseFlags() *managerconfig.ManagerConfig {
 		"kafka-message-size-limit", 940, "The limit for kafka message size in KB.")
 	pflag.StringVar(&managerConfig.TransportConfig.KafkaConfig.ConsumerConfig.ConsumerID,
 		"kafka-consumer-id", "multicluster-global-hub-manager", "ID for the kafka consumer.")
+	pflag.BoolVar(&managerConfig.TransportConfig.KafkaConfig.ConsumerConfig.ReadCommitted,
+		"kafka-read-committed", false, "Only read the bundles of the committed kafka transactions.")
 	pflag.StringVar(&managerConfig.TransportConfig.KafkaConfig.Topics.StatusTopic,
 		"kafka-consumer-topic", "event", "Topic for the kafka consumer.")
 	pflag.StringVar(&managerConfig.StatisticsConfig.LogInterval, "statistics-log-interval", "1m",
@@ -145,16 +243,144 @@ func parseFlags() *managerconfig.ManagerConfig {
 		"/var/run/secrets/kubernetes.io/serviceaccount/ca.crt", "The CA bundle path for cluster API.")
 	pflag.StringVar(&managerConfig.NonK8sAPIServerConfig.ServerBasePath, "server-base-path",
 		"/global-hub-api/v1", "The base path for nonK8s API server.")
+	pflag.StringVar(&managerConfig.NonK8sAPIServerConfig.AuthenticationMode, "authentication-mode",
+		nonk8sapi.AuthenticationModeTokenReview, "How the nonK8s API server authenticates the bearer tokens, "+
+			"tokenreview with the TokenReview of the cluster API or oauth with the user API of the OpenShift OAuth server.")
+	pflag.StringSliceVar(&managerConfig.NonK8sAPIServerConfig.TokenReviewAudiences, "token-review-audiences", nil,
+		"The audiences of the TokenReview, the tokens are reviewed for the cluster API if it's empty.")
+	pflag.DurationVar(&managerConfig.NonK8sAPIServerConfig.TokenReviewCacheTTL, "token-review-cache-ttl",
+		time.Minute, "How long the results of the TokenReview are cached, they aren't cached if it's 0.")
+	pflag.BoolVar(&managerConfig.NonK8sAPIServerConfig.EnableAuthorization, "enable-authorization", false,
+		"Authorize the requests of the nonK8s API server with the SubjectAccessReview on the resources of the "+
+			"globalhub.open-cluster-management.io group, the users only get the resources of the allowed hubs and clusters.")
+	pflag.DurationVar(&managerConfig.NonK8sAPIServerConfig.AuthorizationCacheTTL, "authorization-cache-ttl",
+		time.Minute, "How long the hubs and clusters that the users are allowed to access are cached, "+
+			"they aren't cached if it's 0.")
+	pflag.Float64Var(&managerConfig.NonK8sAPIServerConfig.RateLimitConfig.RequestRateLimit, "api-rate-limit", 0,
+		"The number of requests per second of each client of the nonK8s API server, the rate limiting is disabled "+
+			"if it's 0.")
+	pflag.IntVar(&managerConfig.NonK8sAPIServerConfig.RateLimitConfig.RequestBurst, "api-burst", 50,
+		"The number of requests a client can send at once beyond the api-rate-limit.")
+	pflag.StringToStringVar(&managerConfig.NonK8sAPIServerConfig.RateLimitConfig.ClientRateLimits,
+		"api-client-rate-limits", nil, "The requests per second of the clients which override the api-rate-limit, "+
+			"e.g. system:serviceaccount:monitoring:grafana=50, the client isn't limited if it's 0.")
+	pflag.IntVar(&managerConfig.NonK8sAPIServerConfig.GraphQLMaxDepth, "graphql-max-depth", 5,
+		"The maximum nesting depth of the GraphQL queries, it isn't limited if it's 0.")
+	pflag.IntVar(&managerConfig.NonK8sAPIServerConfig.GraphQLMaxComplexity, "graphql-max-complexity", 50000,
+		"The maximum complexity of the GraphQL queries, which is the number of the fields multiplied by the limit of "+
+			"the lists, it isn't limited if it's 0.")
 	pflag.IntVar(&managerConfig.ElectionConfig.LeaseDuration, "lease-duration", 137, "controller leader lease duration")
 	pflag.IntVar(&managerConfig.ElectionConfig.RenewDeadline, "renew-deadline", 107, "controller leader renew deadline")
 	pflag.IntVar(&managerConfig.ElectionConfig.RetryPeriod, "retry-period", 26, "controller leader retry period")
 	pflag.IntVar(&managerConfig.DatabaseConfig.DataRetention, "data-retention", 18,
 		"data retention indicates how many months the expired data will kept in the database")
+	pflag.IntVar(&managerConfig.DatabaseConfig.EventRetention, "event-retention", 0,
+		"how many months the events are kept in the database, it's the data-retention if it's 0")
+	pflag.IntVar(&managerConfig.DatabaseConfig.ComplianceHistoryRetention, "compliance-history-retention", 0,
+		"how many months the compliance history is kept in the database, it's the data-retention if it's 0")
+	pflag.IntVar(&managerConfig.DatabaseConfig.HeartbeatRetention, "heartbeat-retention", 0,
+		"how many months the heartbeats of the inactive hubs are kept, it's the data-retention if it's 0")
+	pflag.IntVar(&managerConfig.DatabaseConfig.ComplianceRollupRetention, "compliance-rollup-retention", 0,
+		"how many months the daily and weekly compliance rollups are kept, it's the data-retention if it's 0")
+	pflag.StringVar(&managerConfig.ArchiveConfig.Bucket, "event-archive-bucket", "",
+		"The bucket which the expired partitions of the events are archived to before they're pruned, the archival "+
+			"is disabled if it's empty. The credentials are the AWS_ACCESS_KEY_ID and the AWS_SECRET_ACCESS_KEY.")
+	pflag.StringVar(&managerConfig.ArchiveConfig.Prefix, "event-archive-prefix", "multicluster-global-hub-events",
+		"The path of the archives of the events in the bucket.")
+	pflag.StringVar(&managerConfig.ArchiveConfig.Endpoint, "event-archive-endpoint", "",
+		"The URL of the S3 compatible object storage of the archives, the AWS S3 is used if it's empty.")
+	pflag.StringVar(&managerConfig.ArchiveConfig.Region, "event-archive-region", "",
+		"The region of the bucket of the archives.")
+	pflag.StringVar(&managerConfig.TracingConfig.Endpoint, "tracing-endpoint", "",
+		"The host:port of the OTLP gRPC collector which the spans of the status bundles are exported to, the "+
+			"spans aren't exported if it's empty.")
+	pflag.BoolVar(&managerConfig.TracingConfig.Insecure, "tracing-insecure", false,
+		"Connect to the OTLP collector without TLS.")
+	pflag.IntVar(&managerConfig.TracingConfig.SamplingPercentage, "tracing-sampling-percentage", 10,
+		"The percentage of the traces started by the manager to be sampled, the status bundles follow the sampling "+
+			"decision of the agents.")
+	pflag.IntVar(&managerConfig.DatabaseConfig.AuditLogRetention, "audit-log-retention", 0,
+		"how many months the audit logs are kept in the database, it's the data-retention if it's 0")
+	pflag.StringVar(&managerConfig.AuditConfig.Backend, "audit-log-backend", audit.BackendDatabase,
+		"The backend of the audit logs of the global resources and the manager API, 'database', 'file' or 'none'.")
+	pflag.StringVar(&managerConfig.AuditConfig.Path, "audit-log-path", "",
+		"The file of the audit logs if the audit-log-backend is 'file', e.g. /dev/stdout.")
+	pflag.StringVar(&managerConfig.WebhookConfig.SecretName, "webhook-secret", "multicluster-global-hub-webhooks",
+		"The secret of the webhooks notified when the policies become non-compliant, the webhooks are disabled if "+
+			"it's empty.")
+	pflag.DurationVar(&managerConfig.WebhookConfig.Timeout, "webhook-timeout", 10*time.Second,
+		"The timeout of each request to the webhooks.")
+	pflag.StringVar(&managerConfig.ServiceNowConfig.SecretName, "servicenow-secret",
+		"multicluster-global-hub-servicenow", "The secret of the ServiceNow instance and the rules of the incidents "+
+			"opened when the policies become non-compliant, the incidents are disabled if it's empty.")
+	pflag.DurationVar(&managerConfig.ServiceNowConfig.Timeout, "servicenow-timeout", 10*time.Second,
+		"The timeout of each request to the ServiceNow.")
+	pflag.StringVar(&managerConfig.ChatConfig.SecretName, "chat-channels-secret", "",
+		"The secret of the Slack and the Microsoft Teams channels notified when the policies become non-compliant.")
+	pflag.StringVar(&managerConfig.ChatConfig.ConfigMapName, "chat-channels-configmap", "",
+		"The config map of the Slack and the Microsoft Teams channels, the channels are disabled if both of the "+
+			"chat-channels-secret and it are empty.")
+	pflag.DurationVar(&managerConfig.ChatConfig.Timeout, "chat-timeout", 10*time.Second,
+		"The timeout of each request to the Slack and the Microsoft Teams channels.")
+	pflag.StringVar(&managerConfig.SinkConfig.URL, "cloudevents-sink", os.Getenv("K_SINK"),
+		"The URL of the CloudEvents sink which receives the events of the managed hubs, e.g. a Knative broker. It "+
+			"defaults to the K_SINK injected by the Knative SinkBinding, the export is disabled if it's empty.")
+	pflag.StringSliceVar(&managerConfig.SinkConfig.EventTypes, "cloudevents-sink-types", nil,
+		"The types of the events exported to the CloudEvents sink, e.g. 'managedcluster.event,policy.noncompliant', "+
+			"all the types are exported if it's empty.")
+	pflag.DurationVar(&managerConfig.SinkConfig.Timeout, "cloudevents-sink-timeout", 10*time.Second,
+		"The timeout of each request to the CloudEvents sink.")
+	pflag.StringVar(&managerConfig.SIEMConfig.Address, "siem-address", "",
+		"The host:port of the syslog collector of the SIEM over TLS, the policy violations and the cluster events "+
+			"aren't streamed if it's empty.")
+	pflag.StringVar(&managerConfig.SIEMConfig.Format, "siem-format", notification.SIEMFormatCEF,
+		"The format of the messages to the SIEM collector, 'cef' or 'rfc5424'.")
+	pflag.StringVar(&managerConfig.SIEMConfig.Framing, "siem-framing", notification.SIEMFramingOctetCounting,
+		"The framing of the messages to the SIEM collector, 'octet-counting' or 'newline'.")
+	pflag.StringVar(&managerConfig.SIEMConfig.TLSSecretName, "siem-tls-secret", "multicluster-global-hub-siem",
+		"The secret of the CA and the client certificate of the SIEM collector, the system roots are used if it "+
+			"doesn't exist.")
+	pflag.DurationVar(&managerConfig.SIEMConfig.Timeout, "siem-timeout", 10*time.Second,
+		"The timeout of connecting and writing to the SIEM collector.")
+	pflag.StringVar(&managerConfig.LifecycleStreamConfig.Topic, "cluster-lifecycle-topic", "",
+		"The kafka topic of the lifecycle events of the managed clusters, the events aren't streamed if it's empty.")
+	pflag.DurationVar(&managerConfig.LifecycleStreamConfig.Interval, "cluster-lifecycle-interval", 5*time.Second,
+		"The interval of sending the new lifecycle events of the managed clusters to the kafka topic.")
+	pflag.IntVar(&managerConfig.LifecycleStreamConfig.BatchSize, "cluster-lifecycle-batch-size", 500,
+		"The maximum number of the lifecycle events read from the database at once.")
 	pflag.BoolVar(&managerConfig.EnableGlobalResource, "enable-global-resource", false,
 		"enable the global resource feature")
 	pflag.BoolVar(&managerConfig.WithACM, "with-acm", false,
 		"run on Red Hat Advanced Cluster Management")
 	pflag.BoolVar(&managerConfig.EnablePprof, "enable-pprof", false, "enable the pprof tool")
+	pflag.StringVar(&managerConfig.SearchIndexerConfig.URL, "search-indexer-url", "",
+		"The URL of the search-v2 indexer, the resources aren't sent to the search indexer if it's empty.")
+	pflag.DurationVar(&managerConfig.SearchIndexerConfig.Interval, "search-indexer-interval", 5*time.Minute,
+		"The interval of sending the resources to the search indexer.")
+	pflag.StringVar(&managerConfig.SearchIndexerConfig.CACertPath, "search-indexer-ca-path",
+		"/var/run/secrets/kubernetes.io/serviceaccount/service-ca.crt", "The CA bundle path for the search indexer.")
+	pflag.StringVar(&managerConfig.SearchIndexerConfig.TokenPath, "search-indexer-token-path",
+		"/var/run/secrets/kubernetes.io/serviceaccount/token", "The token path to access the search indexer.")
+	pflag.Float64Var(&managerConfig.ThrottleConfig.EventRateLimit, "hub-event-rate-limit", 0,
+		"The number of events per second received from each managed hub, the throttling is disabled if it's 0.")
+	pflag.IntVar(&managerConfig.ThrottleConfig.EventBurst, "hub-event-burst", 100,
+		"The number of events a managed hub can send at once beyond the hub-event-rate-limit.")
+	pflag.IntVar(&managerConfig.ThrottleConfig.ParkThreshold, "hub-park-threshold", 100,
+		"The number of consecutive events beyond the rate limit that parks the processing of the managed hub.")
+	pflag.DurationVar(&managerConfig.ThrottleConfig.ParkDuration, "hub-park-duration", time.Minute,
+		"How long the processing of the managed hub is parked when it keeps exceeding the rate limit.")
+	pflag.StringVar(&managerConfig.RolloutConfig.CanarySelector, "canary-hub-selector", "",
+		"The label selector of the managed hubs which receive the spec changes first, disabled if it's empty.")
+	pflag.DurationVar(&managerConfig.RolloutConfig.EvaluationPeriod, "canary-evaluation-period", 10*time.Minute,
+		"How long the canary hubs are evaluated before the spec change is promoted or rolled back.")
+	pflag.IntVar(&managerConfig.RolloutConfig.MaxNonCompliantIncrease, "canary-max-non-compliant-increase", 0,
+		"The number of new non-compliant cluster policy pairs on the canary hubs tolerated by the evaluation.")
+	pflag.StringVar(&managerConfig.TransportProbeConfig.Topic, "transport-probe-topic", "",
+		"The status topic the probe messages are sent to, the kafka transport isn't probed if it's empty.")
+	pflag.DurationVar(&managerConfig.TransportProbeConfig.Interval, "transport-probe-interval", 30*time.Second,
+		"The interval of the probe messages sent and consumed by the manager.")
+	pflag.DurationVar(&managerConfig.TransportProbeConfig.LatencyThreshold, "transport-probe-latency-threshold",
+		10*time.Second, "The round-trip latency of the probe messages over which the transport is degraded.")
 	pflag.Parse()
 	// set zap logger
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
@@ -177,6 +403,12 @@ func completeConfig(managerConfig *managerconfig.ManagerConfig) error {
 		return fmt.Errorf("%w - size must not exceed %d : %s", errFlagParameterIllegalValue,
 			managerConfig.TransportConfig.KafkaConfig.ProducerConfig.MessageSizeLimitKB, "kafka-message-size-limit")
 	}
+	if err := complianceslo.SetObjective(managerConfig.ComplianceSLOObjective); err != nil {
+		return fmt.Errorf("%w - %s", errFlagParameterIllegalValue, err.Error())
+	}
+	// the credentials of the object storage are injected from the secret of the event archive
+	managerConfig.ArchiveConfig.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	managerConfig.ArchiveConfig.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
 	// the specified jobs(concatenate multiple jobs with ',') runs when the container starts
 	val, ok := os.LookupEnv(launchJobNamesEnv)
 	if ok && val != "" {
@@ -185,6 +417,24 @@ func completeConfig(managerConfig *managerconfig.ManagerConfig) error {
 	return nil
 }
 
+// initPayloadEncryption loads the data keys, the policy payloads are encrypted before they're written to the database
+func initPayloadEncryption(ctx context.Context, databaseConfig *managerconfig.DatabaseConfig) error {
+	if databaseConfig.EncryptionKeyDir == "" {
+		return nil
+	}
+	var vault *encryption.VaultConfig
+	if databaseConfig.EncryptionVault.Address != "" {
+		vault = &databaseConfig.EncryptionVault
+	}
+	keyring, err := encryption.LoadKeyring(ctx, databaseConfig.EncryptionKeyDir, vault)
+	if err != nil {
+		return err
+	}
+	encryption.SetKeyring(keyring)
+	setupLog.Info("the policy payloads are encrypted in the database")
+	return nil
+}
+
 func createManager(ctx context.Context,
 	restConfig *rest.Config,
 	managerConfig *managerconfig.ManagerConfig,
@@ -255,6 +505,12 @@ func createManager(ctx context.Context,
 	}
 
 	if managerConfig.EnableGlobalResource {
+		// the auditor records the changes of the global resources and the requests of the manager API
+		auditor, err := audit.AddAuditor(mgr, managerConfig.AuditConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add auditor: %w", err)
+		}
+		managerConfig.NonK8sAPIServerConfig.Auditor = auditor
 		if err := nonk8sapi.AddNonK8sApiServer(mgr, managerConfig.NonK8sAPIServerConfig); err != nil {
 			return nil, fmt.Errorf("failed to add non-k8s-api-server: %w", err)
 		}
@@ -286,6 +542,10 @@ func createManager(ctx context.Context,
 		return nil, fmt.Errorf("failed to add scheduler to manager: %w", err)
 	}
 
+	if err := searchindexer.AddSearchIndexer(mgr, managerConfig.SearchIndexerConfig); err != nil {
+		return nil, fmt.Errorf("failed to add search indexer to manager: %w", err)
+	}
+
 	return mgr, nil
 }
 
@@ -301,34 +561,66 @@ func doMain(ctx context.Context, restConfig *rest.Config) int {
 		go utils.StartDefaultPprofServer()
 	}
 
+	shutdownTracing, err := tracing.Init(ctx, "multicluster-global-hub-manager", managerConfig.TracingConfig)
+	if err != nil {
+		setupLog.Error(err, "failed to initialize the tracing")
+		return 1
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			setupLog.Error(err, "failed to flush the spans")
+		}
+	}()
+
 	utils.PrintVersion(setupLog)
 	databaseConfig := &database.DatabaseConfig{
-		URL:        managerConfig.DatabaseConfig.ProcessDatabaseURL,
-		Dialect:    database.PostgresDialect,
-		CaCertPath: managerConfig.DatabaseConfig.CACertPath,
-		PoolSize:   managerConfig.DatabaseConfig.MaxOpenConns,
+		URL:                managerConfig.DatabaseConfig.ProcessDatabaseURL,
+		ReplicaURL:         managerConfig.DatabaseConfig.ReplicaDatabaseURL,
+		Dialect:            database.PostgresDialect,
+		CaCertPath:         managerConfig.DatabaseConfig.CACertPath,
+		PoolSize:           managerConfig.DatabaseConfig.MaxOpenConns,
+		AuthType:           managerConfig.DatabaseConfig.AuthType,
+		MaxIdleConns:       managerConfig.DatabaseConfig.MaxIdleConns,
+		SlowQueryThreshold: managerConfig.DatabaseConfig.SlowQueryThreshold,
 	}
-	// Init the default gorm instance, it's used to sync data to db
-	err := database.InitGormInstance(databaseConfig)
-	if err != nil {
-		setupLog.Error(err, "failed to initialize GORM instance")
+	if err := initPayloadEncryption(ctx, managerConfig.DatabaseConfig); err != nil {
+		setupLog.Error(err, "failed to load the payload encryption keys")
 		return 1
 	}
-	defer database.CloseGorm(database.GetSqlDb())
-
-	// Init the backup gorm instance, it's used to add lock when backup database
-	_, sqlBackupConn, err := database.NewGormConn(databaseConfig)
+	// Init the default gorm instance, it's used to sync data to db. wait for the database instead of crash-looping,
+	// the manager hasn't consumed any event yet, so it resumes from the committed offsets once the database is up
+	var sqlBackupConn *sql.DB
+	var sqlConn *sql.Conn
+	err = wait.PollUntilContextCancel(ctx, managerConfig.DatabaseConfig.ProbeInterval, true,
+		func(ctx context.Context) (bool, error) {
+			if err := database.InitGormInstance(databaseConfig); err != nil {
+				setupLog.Info("waiting for the database to be available", "error", err.Error())
+				return false, nil
+			}
+			// Init the backup gorm instance, it's used to add lock when backup database
+			if sqlBackupConn == nil {
+				_, backupConn, err := database.NewGormConn(databaseConfig)
+				if err != nil {
+					setupLog.Info("waiting for the database to be available", "error", err.Error())
+					return false, nil
+				}
+				sqlBackupConn = backupConn
+			}
+			conn, err := sqlBackupConn.Conn(ctx)
+			if err != nil {
+				setupLog.Info("waiting for the database connection", "error", err.Error())
+				return false, nil
+			}
+			sqlConn = conn
+			return true, nil
+		})
 	if err != nil {
 		setupLog.Error(err, "failed to initialize GORM instance")
 		return 1
 	}
+	defer database.CloseGorm(database.GetSqlDb())
 	defer database.CloseGorm(sqlBackupConn)
 
-	sqlConn, err := sqlBackupConn.Conn(ctx)
-	if err != nil {
-		setupLog.Error(err, "failed to get db connection")
-		return 1
-	}
 	mgr, err := createManager(ctx, restConfig, managerConfig, sqlConn)
 	if err != nil {
 		setupLog.Error(err, "failed to create manager")
@@ -341,6 +633,9 @@ func doMain(ctx context.Context, restConfig *rest.Config) int {
 		hookServer.Register("/mutating", &webhook.Admission{
 			Handler: mgrwebhook.NewAdmissionHandler(mgr.GetClient(), mgr.GetScheme()),
 		})
+		hookServer.Register("/audit", &webhook.Admission{
+			Handler: mgrwebhook.NewAuditHandler(managerConfig.NonK8sAPIServerConfig.Auditor),
+		})
 	}
 
 	setupLog.Info("Starting the Manager")
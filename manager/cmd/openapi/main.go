@@ -0,0 +1,28 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+// openapi prints the OpenAPI v3 document of the manager API, it's the input of the client generators
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+)
+
+func main() {
+	document, err := nonk8sapi.OpenAPIV3()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to generate the OpenAPI document: %v\n", err)
+		os.Exit(1)
+	}
+	indented := &bytes.Buffer{}
+	if err := json.Indent(indented, document, "", "  "); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to format the OpenAPI document: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(indented.String())
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+const defaultPrefix = "multicluster-global-hub-events"
+
+var ArchivedPartitionsCounterVec = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "multicluster_global_hub_archived_partitions_total",
+		Help: "The number of the expired partitions archived to the object storage before they're pruned, by the " +
+			"table and the result of the archival.",
+	},
+	[]string{"table", "result"},
+)
+
+type ArchiveConfig struct {
+	// Bucket is the bucket of the archives, the archival is disabled if it's empty
+	Bucket string
+	// Prefix is the path of the archives in the bucket
+	Prefix string
+	// Endpoint is the URL of the S3 compatible object storage, the AWS S3 is used if it's empty
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// PartitionArchiver uploads the partitions of the event tables to the object storage before they're pruned by the
+// data retention, so the long-term audits remain possible without keeping the data in the database. Each partition is
+// archived as a gzipped CSV with the header, e.g. "<prefix>/event.local_policies/local_policies_2024_01.csv.gz"
+type PartitionArchiver struct {
+	client *S3Client
+	prefix string
+}
+
+// NewPartitionArchiver returns the archiver of the config, it's nil if the archival is disabled
+func NewPartitionArchiver(config *ArchiveConfig) (*PartitionArchiver, error) {
+	if config == nil || config.Bucket == "" {
+		return nil, nil
+	}
+	client, err := NewS3Client(config)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.Trim(config.Prefix, "/")
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	return &PartitionArchiver{client: client, prefix: prefix}, nil
+}
+
+// Archive uploads the rows of the partition of the table, it returns the key of the archive in the bucket. The
+// partition is overwritten if it's archived again, e.g. the previous prune failed after the archival
+func (a *PartitionArchiver) Archive(ctx context.Context, db *gorm.DB, table, partition string) (string, error) {
+	file, err := os.CreateTemp("", "archive-*.csv.gz")
+	if err != nil {
+		return "", fmt.Errorf("failed to create the archive file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	hash := sha256.New()
+	if err := writePartition(ctx, db, partition, io.MultiWriter(file, hash)); err != nil {
+		ArchivedPartitionsCounterVec.WithLabelValues(table, "failure").Inc()
+		return "", err
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	_, name, _ := strings.Cut(partition, ".")
+	key := path.Join(a.prefix, table, name+".csv.gz")
+	if err := a.client.PutObject(ctx, key, file, size, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		ArchivedPartitionsCounterVec.WithLabelValues(table, "failure").Inc()
+		return "", err
+	}
+	ArchivedPartitionsCounterVec.WithLabelValues(table, "success").Inc()
+	return key, nil
+}
+
+// writePartition writes the rows of the partition as the gzipped CSV, the NULL values are written as the empty fields
+func writePartition(ctx context.Context, db *gorm.DB, partition string, w io.Writer) error {
+	rows, err := db.WithContext(ctx).Raw(fmt.Sprintf("SELECT * FROM %s", partition)).Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query the partition %s: %w", partition, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	gzipWriter := gzip.NewWriter(w)
+	csvWriter := csv.NewWriter(gzipWriter)
+	if err := csvWriter.Write(columns); err != nil {
+		return err
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan the partition %s: %w", partition, err)
+		}
+		for i, value := range values {
+			record[i] = value.String
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query the partition %s: %w", partition, err)
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
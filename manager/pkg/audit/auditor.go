@@ -0,0 +1,177 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+const (
+	// SourceSpec is the source of the audit records of the changes of the global resources
+	SourceSpec = "spec"
+	// SourceAPI is the source of the audit records of the requests of the manager API
+	SourceAPI = "api"
+
+	// BackendDatabase writes the audit records to the history.audit_logs table
+	BackendDatabase = "database"
+	// BackendFile writes the audit records to the file in JSON lines
+	BackendFile = "file"
+	// BackendNone disables the audit logging
+	BackendNone = "none"
+
+	// the records are written in batches of the size, or once the flush interval is over
+	batchSize     = 100
+	flushInterval = time.Second
+	// the records are dropped once the buffer is full, so the requests aren't blocked by a slow backend
+	bufferSize = 10000
+)
+
+var DroppedAuditRecordsCounter = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "multicluster_global_hub_audit_dropped_records_total",
+		Help: "The number of the audit records dropped since the audit log backend can't keep up.",
+	},
+)
+
+type AuditConfig struct {
+	// Backend is database, file or none
+	Backend string
+	// Path is the file of the file backend, e.g. /dev/stdout
+	Path string
+}
+
+// Auditor records who changed the global resources and who requested the manager API. The records are written to the
+// backend asynchronously, so the requests aren't delayed by the database or the file
+type Auditor struct {
+	log     logr.Logger
+	records chan *models.AuditLog
+	write   func(records []*models.AuditLog) error
+}
+
+// NewAuditor returns the auditor of the backend, it's nil if the audit logging is disabled
+func NewAuditor(config *AuditConfig) (*Auditor, error) {
+	auditor := &Auditor{
+		log:     ctrl.Log.WithName("auditor"),
+		records: make(chan *models.AuditLog, bufferSize),
+	}
+	switch config.Backend {
+	case BackendDatabase:
+		auditor.write = writeDatabase
+	case BackendFile:
+		if config.Path == "" {
+			return nil, fmt.Errorf("the path of the audit log file is required")
+		}
+		auditor.write = func(records []*models.AuditLog) error {
+			return writeFile(config.Path, records)
+		}
+	case BackendNone, "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid audit log backend: %s, it should be %s, %s or %s", config.Backend,
+			BackendDatabase, BackendFile, BackendNone)
+	}
+	return auditor, nil
+}
+
+// AddAuditor adds the auditor to the manager, it returns nil if the audit logging is disabled
+func AddAuditor(mgr ctrl.Manager, config *AuditConfig) (*Auditor, error) {
+	auditor, err := NewAuditor(config)
+	if err != nil || auditor == nil {
+		return nil, err
+	}
+	if err := mgr.Add(auditor); err != nil {
+		return nil, fmt.Errorf("failed to add the auditor to the manager: %w", err)
+	}
+	return auditor, nil
+}
+
+// NeedLeaderElection implements the LeaderElectionRunnable interface, the records of the API requests and the
+// admission requests are received by every replica
+func (a *Auditor) NeedLeaderElection() bool {
+	return false
+}
+
+// Record adds the record to the buffer, it's dropped if the buffer is full. It does nothing if the auditor is nil
+func (a *Auditor) Record(record *models.AuditLog) {
+	if a == nil {
+		return
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	select {
+	case a.records <- record:
+	default:
+		DroppedAuditRecordsCounter.Inc()
+	}
+}
+
+// Start writes the buffered records in batches until the context is done, then the remaining records are written
+func (a *Auditor) Start(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.AuditLog, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := a.write(batch); err != nil {
+			a.log.Error(err, "failed to write the audit records", "count", len(batch))
+		}
+		batch = make([]*models.AuditLog, 0, batchSize)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case record := <-a.records:
+					batch = append(batch, record)
+				default:
+					flush()
+					return nil
+				}
+			}
+		case record := <-a.records:
+			batch = append(batch, record)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func writeDatabase(records []*models.AuditLog) error {
+	return database.GetGorm().Create(records).Error
+}
+
+func writeFile(path string, records []*models.AuditLog) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authentication"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+func TestAuditRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditor, err := NewAuditor(&AuditConfig{Backend: BackendFile, Path: path})
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(ginCtx *gin.Context) {
+		ginCtx.Set(authentication.UserKey, "alice")
+		ginCtx.Set(authentication.GroupsKey, []string{"admins"})
+	}, AuditRequests(auditor))
+	router.GET("/managedclusters", func(ginCtx *gin.Context) {
+		ginCtx.String(http.StatusOK, "ok")
+	})
+	router.PATCH("/managedcluster/:clusterID", func(ginCtx *gin.Context) {
+		ginCtx.String(http.StatusForbidden, "forbidden")
+	})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/managedclusters?limit=10", nil),
+		httptest.NewRequest(http.MethodPatch, "/managedcluster/123", nil),
+	} {
+		req.RemoteAddr = "10.0.0.1:12345"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// the remaining records are written once the auditor is stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, auditor.Start(ctx))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2)
+
+	records := make([]models.AuditLog, len(lines))
+	for i, line := range lines {
+		assert.NoError(t, json.Unmarshal([]byte(line), &records[i]))
+	}
+	assert.Equal(t, SourceAPI, records[0].Source)
+	assert.Equal(t, "alice", records[0].Username)
+	assert.JSONEq(t, `["admins"]`, string(records[0].Groups))
+	assert.Equal(t, http.MethodGet, records[0].Verb)
+	assert.Equal(t, "/managedclusters", records[0].Resource)
+	assert.Equal(t, "/managedclusters?limit=10", records[0].RequestURI)
+	assert.Equal(t, http.StatusOK, records[0].StatusCode)
+	assert.Equal(t, "10.0.0.1", records[0].ClientIP)
+	assert.False(t, records[0].CreatedAt.IsZero())
+	assert.Equal(t, "/managedcluster/:clusterID", records[1].Resource)
+	assert.Equal(t, http.StatusForbidden, records[1].StatusCode)
+}
+
+func TestAuditor(t *testing.T) {
+	auditor, err := NewAuditor(&AuditConfig{Backend: BackendNone})
+	assert.NoError(t, err)
+	assert.Nil(t, auditor)
+	// the nil auditor doesn't record anything
+	auditor.Record(&models.AuditLog{Source: SourceSpec})
+
+	_, err = NewAuditor(&AuditConfig{Backend: BackendFile})
+	assert.ErrorContains(t, err, "the path of the audit log file is required")
+	_, err = NewAuditor(&AuditConfig{Backend: "kafka"})
+	assert.ErrorContains(t, err, "invalid audit log backend")
+
+	auditor, err = NewAuditor(&AuditConfig{Backend: BackendDatabase})
+	assert.NoError(t, err)
+	lock := sync.Mutex{}
+	batches := []int{}
+	auditor.write = func(records []*models.AuditLog) error {
+		lock.Lock()
+		defer lock.Unlock()
+		batches = append(batches, len(records))
+		return nil
+	}
+
+	// the records are dropped once the buffer is full
+	for i := 0; i < bufferSize+1; i++ {
+		auditor.Record(&models.AuditLog{Source: SourceSpec, Verb: "CREATE"})
+	}
+	assert.Equal(t, float64(1), testutil.ToFloat64(DroppedAuditRecordsCounter))
+
+	// the records are written in batches
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- auditor.Start(ctx)
+	}()
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(batches) == bufferSize/batchSize
+	}, 5*time.Second, 10*time.Millisecond)
+	for _, size := range batches {
+		assert.Equal(t, batchSize, size)
+	}
+
+	// the record is written after the flush interval even if the batch isn't full
+	auditor.Record(&models.AuditLog{Source: SourceSpec, Verb: "DELETE"})
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(batches) == bufferSize/batchSize+1
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package audit
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authentication"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+// AuditRequests middleware records the user, the route and the response status of the requests of the manager API.
+// It should be used after the authentication, so the user of the request is known.
+func AuditRequests(auditor *Auditor) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		ginCtx.Next()
+		if auditor == nil {
+			return
+		}
+
+		record := &models.AuditLog{
+			Source:     SourceAPI,
+			Username:   ginCtx.GetString(authentication.UserKey),
+			Verb:       ginCtx.Request.Method,
+			Resource:   ginCtx.FullPath(),
+			RequestURI: ginCtx.Request.RequestURI,
+			StatusCode: ginCtx.Writer.Status(),
+			ClientIP:   ginCtx.ClientIP(),
+		}
+		if groups := ginCtx.GetStringSlice(authentication.GroupsKey); len(groups) > 0 {
+			record.Groups, _ = json.Marshal(groups)
+		}
+		auditor.Record(record)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceslo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const (
+	// DefaultObjective is the default percentage of the compliant clusters of each policy and each managed hub
+	DefaultObjective = 95.0
+
+	queryTimeout = 10 * time.Second
+)
+
+// the windows of the burn rate in days, they end on the latest day of the compliance percentages, so the day which
+// isn't rolled up yet doesn't count
+var burnRateWindows = []int{1, 7, 30}
+
+// the compliant and the total clusters of the policies and the managed hubs on the latest day, and over the burn rate
+// windows. The policies and the managed hubs which aren't on the latest day, e.g. they're deleted, are skipped
+const compliancePercentagesSQL = `
+	WITH latest AS (SELECT max(compliance_date) AS day FROM history.compliance_percentages)
+	SELECT c.scope, c.scope_key, COALESCE(max(p.policy_name), ''),
+		SUM(c.compliant) FILTER (WHERE c.compliance_date > latest.day - 1),
+		SUM(c.total) FILTER (WHERE c.compliance_date > latest.day - 1),
+		SUM(c.compliant) FILTER (WHERE c.compliance_date > latest.day - 7),
+		SUM(c.total) FILTER (WHERE c.compliance_date > latest.day - 7),
+		SUM(c.compliant) FILTER (WHERE c.compliance_date > latest.day - 30),
+		SUM(c.total) FILTER (WHERE c.compliance_date > latest.day - 30)
+	FROM history.compliance_percentages c CROSS JOIN latest
+	LEFT JOIN local_spec.policies p ON c.scope = 'policy' AND p.policy_id::text = c.scope_key
+	WHERE c.compliance_date > latest.day - 30
+	GROUP BY c.scope, c.scope_key
+	HAVING count(*) FILTER (WHERE c.compliance_date = latest.day) > 0`
+
+var (
+	policyLabels = []string{"policy_id", "policy_name"}
+	hubLabels    = []string{"hub"}
+
+	objectiveDesc = prometheus.NewDesc("multicluster_global_hub_compliance_slo_objective",
+		"The objective of the percentage of the compliant clusters of each policy and each managed hub.", nil, nil)
+	policyPercentageDesc = prometheus.NewDesc("multicluster_global_hub_policy_compliance_percentage",
+		"The percentage of the compliant clusters of the policy over the managed hubs on the latest rolled up day.",
+		policyLabels, nil)
+	hubPercentageDesc = prometheus.NewDesc("multicluster_global_hub_hub_compliance_percentage",
+		"The percentage of the compliant clusters of the managed hub over its policies on the latest rolled up day.",
+		hubLabels, nil)
+	policyBurnRateDesc = prometheus.NewDesc("multicluster_global_hub_policy_compliance_slo_burn_rate",
+		"The non-compliant percentage of the policy in the window divided by the error budget of the objective, "+
+			"the budget is exhausted in the window if it's greater than 1.", append(policyLabels, "window"), nil)
+	hubBurnRateDesc = prometheus.NewDesc("multicluster_global_hub_hub_compliance_slo_burn_rate",
+		"The non-compliant percentage of the managed hub in the window divided by the error budget of the objective, "+
+			"the budget is exhausted in the window if it's greater than 1.", append(hubLabels, "window"), nil)
+)
+
+// the objective is set once the flags are parsed, before the metrics are served
+var objective = DefaultObjective
+
+// SetObjective sets the objective of the burn rate, it's the percentage of the compliant clusters in [0, 100)
+func SetObjective(value float64) error {
+	if value < 0 || value >= 100 {
+		return fmt.Errorf("the compliance SLO objective %v must be in [0, 100)", value)
+	}
+	objective = value
+	return nil
+}
+
+type compliancePercentage struct {
+	scope      string
+	key        string
+	policyName string
+	// the compliant and the total clusters in each of the burn rate windows
+	compliant []int64
+	total     []int64
+}
+
+// ComplianceSLOCollector exports the percentage of the compliant clusters of each policy and each managed hub, and
+// the burn rate of their error budget over the windows on each scrape, so the downward trend of the compliance can be
+// alerted before the objective is missed. The percentages are computed daily by the compliance rollup job
+type ComplianceSLOCollector struct {
+	query func(ctx context.Context) ([]compliancePercentage, error)
+}
+
+func NewComplianceSLOCollector() *ComplianceSLOCollector {
+	return &ComplianceSLOCollector{query: queryCompliancePercentages}
+}
+
+func (c *ComplianceSLOCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- objectiveDesc
+	ch <- policyPercentageDesc
+	ch <- hubPercentageDesc
+	ch <- policyBurnRateDesc
+	ch <- hubBurnRateDesc
+}
+
+func (c *ComplianceSLOCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(objectiveDesc, prometheus.GaugeValue, objective)
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+	percentages, err := c.query(ctx)
+	if err != nil {
+		ctrl.Log.WithName("compliance-slo-collector").V(2).Info("failed to query the compliance percentages",
+			"error", err)
+		return
+	}
+
+	budget := 100 - objective
+	for _, p := range percentages {
+		percentageDesc, burnRateDesc, labels := hubPercentageDesc, hubBurnRateDesc, []string{p.key}
+		if p.scope == database.ComplianceScopePolicy {
+			percentageDesc, burnRateDesc, labels = policyPercentageDesc, policyBurnRateDesc,
+				[]string{p.key, p.policyName}
+		}
+		for i, window := range burnRateWindows {
+			if p.total[i] == 0 {
+				continue
+			}
+			percentage := 100 * float64(p.compliant[i]) / float64(p.total[i])
+			// the window of the latest day is the current percentage
+			if i == 0 {
+				ch <- prometheus.MustNewConstMetric(percentageDesc, prometheus.GaugeValue, percentage, labels...)
+			}
+			ch <- prometheus.MustNewConstMetric(burnRateDesc, prometheus.GaugeValue, (100-percentage)/budget,
+				append(labels, fmt.Sprintf("%dd", window))...)
+		}
+	}
+}
+
+func queryCompliancePercentages(ctx context.Context) ([]compliancePercentage, error) {
+	db := database.GetSqlDb()
+	if db == nil {
+		return nil, errors.New("the database isn't initialized")
+	}
+	rows, err := db.QueryContext(ctx, compliancePercentagesSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	percentages := []compliancePercentage{}
+	for rows.Next() {
+		p := compliancePercentage{
+			compliant: make([]int64, len(burnRateWindows)),
+			total:     make([]int64, len(burnRateWindows)),
+		}
+		if err := rows.Scan(&p.scope, &p.key, &p.policyName, &p.compliant[0], &p.total[0], &p.compliant[1],
+			&p.total[1], &p.compliant[2], &p.total[2]); err != nil {
+			return nil, err
+		}
+		percentages = append(percentages, p)
+	}
+	return percentages, rows.Err()
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceslo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+func TestComplianceSLOCollector(t *testing.T) {
+	assert.Error(t, SetObjective(100))
+	assert.NoError(t, SetObjective(90))
+	defer func() { objective = DefaultObjective }()
+
+	collector := &ComplianceSLOCollector{
+		query: func(ctx context.Context) ([]compliancePercentage, error) {
+			return []compliancePercentage{
+				{
+					scope: database.ComplianceScopePolicy, key: "policy-1", policyName: "cm-policy",
+					compliant: []int64{8, 63, 280}, total: []int64{10, 70, 300},
+				},
+				{
+					scope: database.ComplianceScopeHub, key: "hub1",
+					compliant: []int64{20, 140, 600}, total: []int64{20, 140, 600},
+				},
+			}, nil
+		},
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	// the value of each metric is keyed by its name and the values of its labels
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			key := family.GetName()
+			for _, label := range metric.GetLabel() {
+				key += "/" + label.GetValue()
+			}
+			values[key] = metric.GetGauge().GetValue()
+		}
+	}
+	assert.Len(t, values, 9)
+	assert.Equal(t, float64(90), values["multicluster_global_hub_compliance_slo_objective"])
+	assert.Equal(t, float64(80), values["multicluster_global_hub_policy_compliance_percentage/policy-1/cm-policy"])
+	assert.Equal(t, float64(100), values["multicluster_global_hub_hub_compliance_percentage/hub1"])
+	// 20% of the clusters are non-compliant on the latest day, it's twice the error budget of the 90% objective
+	burnRate := "multicluster_global_hub_policy_compliance_slo_burn_rate/policy-1/cm-policy/"
+	assert.InDelta(t, 2, values[burnRate+"1d"], 0.001)
+	assert.InDelta(t, 1, values[burnRate+"7d"], 0.001)
+	assert.InDelta(t, 0.667, values[burnRate+"30d"], 0.001)
+	assert.Equal(t, float64(0), values["multicluster_global_hub_hub_compliance_slo_burn_rate/hub1/30d"])
+
+	// only the objective is exported if the database is unavailable
+	collector.query = func(ctx context.Context) ([]compliancePercentage, error) {
+		return nil, errors.New("connection refused")
+	}
+	families, err = registry.Gather()
+	assert.NoError(t, err)
+	assert.Len(t, families, 1)
+}
@@ -6,9 +6,18 @@ package config
 import (
 	"time"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/archive"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/audit"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/notification"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/searchindexer"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/rollout"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/throttle"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/transporthealth"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/encryption"
 	commonobjects "github.com/stolostron/multicluster-global-hub/pkg/objects"
 	"github.com/stolostron/multicluster-global-hub/pkg/statistics"
+	"github.com/stolostron/multicluster-global-hub/pkg/tracing"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 )
 
@@ -16,28 +25,77 @@ type ManagerConfig struct {
 	ManagerNamespace      string
 	WatchNamespace        string
 	SchedulerInterval     string
+	JobSchedules          *JobSchedules
 	SyncerConfig          *SyncerConfig
 	DatabaseConfig        *DatabaseConfig
 	TransportConfig       *transport.TransportConfig
 	StatisticsConfig      *statistics.StatisticsConfig
 	NonK8sAPIServerConfig *nonk8sapi.NonK8sAPIServerConfig
 	ElectionConfig        *commonobjects.LeaderElectionConfig
+	SearchIndexerConfig   *searchindexer.SearchIndexerConfig
+	ThrottleConfig        *throttle.ThrottleConfig
+	RolloutConfig         *rollout.RolloutConfig
+	TransportProbeConfig  *transporthealth.ProbeConfig
+	AuditConfig           *audit.AuditConfig
+	WebhookConfig         *notification.WebhookConfig
+	ServiceNowConfig      *notification.ServiceNowConfig
+	ChatConfig            *notification.ChatConfig
+	SinkConfig            *notification.SinkConfig
+	SIEMConfig            *notification.SIEMConfig
+	LifecycleStreamConfig *notification.LifecycleStreamConfig
+	ArchiveConfig         *archive.ArchiveConfig
+	TracingConfig         *tracing.TracingConfig
 	EnableGlobalResource  bool
 	WithACM               bool
 	LaunchJobNames        string
 	EnablePprof           bool
+	// ComplianceSLOObjective is the percentage of the compliant clusters of each policy and each managed hub, the
+	// burn rate of its error budget is exported in the metrics
+	ComplianceSLOObjective float64
+}
+
+// JobSchedules are the cron schedules of the jobs of the manager, the job runs on its default schedule if it's empty
+type JobSchedules struct {
+	ComplianceHistory string
+	ComplianceRollup  string
+	DataRetention     string
 }
 
 type SyncerConfig struct {
 	SpecSyncInterval              time.Duration
 	StatusSyncInterval            time.Duration
 	DeletedLabelsTrimmingInterval time.Duration
+	// the identical status events in the window are coalesced into one row, they aren't coalesced if it's 0
+	EventCoalesceWindow time.Duration
+	// the partitions of the status topic are split across the replicas of the manager instead of being consumed by
+	// the leader, it's only supported by the kafka transport
+	EnableStatusSharding bool
+}
+
+// StatusShardingEnabled returns whether the status of the managed hubs is received by all the replicas
+func (c *ManagerConfig) StatusShardingEnabled() bool {
+	return c.SyncerConfig != nil && c.SyncerConfig.EnableStatusSharding && c.TransportConfig != nil &&
+		c.TransportConfig.TransportType == string(transport.Kafka)
 }
 
 type DatabaseConfig struct {
 	ProcessDatabaseURL         string
 	TransportBridgeDatabaseURL string
+	ReplicaDatabaseURL         string
 	CACertPath                 string
 	MaxOpenConns               int
+	MaxIdleConns               int
+	SlowQueryThreshold         time.Duration
 	DataRetention              int
+	EventRetention             int
+	ComplianceHistoryRetention int
+	HeartbeatRetention         int
+	AuditLogRetention          int
+	ComplianceRollupRetention  int
+	ProbeInterval              time.Duration
+	AuthType                   string
+	// EncryptionKeyDir is the mounted secret of the data keys which encrypt the policy payloads, the payloads are
+	// stored in plain text if it's empty
+	EncryptionKeyDir string
+	EncryptionVault  encryption.VaultConfig
 }
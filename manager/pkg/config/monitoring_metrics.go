@@ -3,6 +3,20 @@ package config
 import (
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/archive"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/audit"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/complianceslo"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/dbhealth"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/hubmanagement"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/ratelimit"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/notification"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/rollout"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/coalescer"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/throttle"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/transporthealth"
+	"github.com/stolostron/multicluster-global-hub/pkg/statistics"
 )
 
 var GlobalHubCronJobGaugeVec = prometheus.NewGaugeVec(
@@ -18,4 +32,23 @@ var GlobalHubCronJobGaugeVec = prometheus.NewGaugeVec(
 // RegisterMetrics will register metrics with the global prometheus registry
 func RegisterMetrics() {
 	metrics.Registry.MustRegister(GlobalHubCronJobGaugeVec)
+	metrics.Registry.MustRegister(throttle.ThrottledEventsCounterVec, throttle.ParkedHubsCounterVec)
+	metrics.Registry.MustRegister(hubmanagement.HubFormatVersionGaugeVec)
+	metrics.Registry.MustRegister(rollout.SpecRolloutGaugeVec)
+	metrics.Registry.MustRegister(dbhealth.DatabaseAvailableGauge, dbhealth.NewTableBloatCollector())
+	metrics.Registry.MustRegister(transporthealth.TransportProbeLatencyGauge)
+	metrics.Registry.MustRegister(ratelimit.RateLimitedRequestsCounterVec, ratelimit.RateLimitedClientsGauge)
+	metrics.Registry.MustRegister(audit.DroppedAuditRecordsCounter)
+	metrics.Registry.MustRegister(complianceslo.NewComplianceSLOCollector())
+	metrics.Registry.MustRegister(notification.WebhookDeliveriesCounter, notification.DroppedNotificationsCounterVec)
+	metrics.Registry.MustRegister(notification.SinkDeliveriesCounterVec, notification.DroppedSinkEventsCounter)
+	metrics.Registry.MustRegister(notification.SIEMExportedEventsCounterVec)
+	metrics.Registry.MustRegister(notification.StreamedLifecycleEventsCounterVec)
+	metrics.Registry.MustRegister(notification.ServiceNowIncidentsCounterVec)
+	metrics.Registry.MustRegister(notification.ChatDeliveriesCounterVec)
+	metrics.Registry.MustRegister(coalescer.CoalescedEventsCounterVec)
+	metrics.Registry.MustRegister(archive.ArchivedPartitionsCounterVec)
+	metrics.Registry.MustRegister(conflator.AssignedPartitionsGauge)
+	metrics.Registry.MustRegister(statistics.BundleSizeHistogramVec, statistics.BundleDecodeHistogramVec,
+		statistics.BundleDatabaseHistogramVec, statistics.HubDatabaseSecondsCounterVec, statistics.BundleLagGaugeVec)
 }
@@ -0,0 +1,80 @@
+package cronjob
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/dbhealth"
+)
+
+// watch records when the job is started, and reports the status of the jobs once the job is finished, so the next run
+// of the job is refreshed. The start is recorded by the listener since the gocron doesn't set the last run of the job
+// which is launched by the tag
+func (s *GlobalHubJobScheduler) watch(ctx context.Context, name, schedule string, job *gocron.Job) {
+	s.log.Info("set the job", "name", name, "schedule", schedule, "nextRun", job.NextRun())
+	s.schedules[name] = schedule
+	job.SetEventListeners(func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.lastRuns[name] = time.Now()
+	}, func() {
+		if err := s.reportJobStatus(ctx); err != nil {
+			s.log.Error(err, "failed to report the status of the jobs", "name", name)
+		}
+	})
+}
+
+// reportJobStatus sets the schedule, the last run and the next run of the jobs in the status of the
+// MulticlusterGlobalHub. The status is merge patched, so the jobs which finish at the same time don't conflict, and the
+// last run reported before the manager is restarted is kept
+func (s *GlobalHubJobScheduler) reportJobStatus(ctx context.Context) error {
+	if s.client == nil || len(s.schedules) == 0 {
+		return nil
+	}
+	mghList := &unstructured.UnstructuredList{}
+	mghList.SetGroupVersionKind(dbhealth.MulticlusterGlobalHubGVK.GroupVersion().WithKind(
+		dbhealth.MulticlusterGlobalHubGVK.Kind + "List"))
+	if err := s.client.List(ctx, mghList, client.InNamespace(s.namespace)); err != nil {
+		return err
+	}
+	if len(mghList.Items) == 0 {
+		return nil
+	}
+
+	jobs := map[string]interface{}{}
+	for _, job := range s.scheduler.Jobs() {
+		for _, tag := range job.Tags() {
+			schedule, ok := s.schedules[tag]
+			if !ok {
+				continue
+			}
+			status := map[string]interface{}{"schedule": schedule}
+			if lastRun := s.lastRun(tag); !lastRun.IsZero() {
+				status["lastRunTime"] = lastRun.UTC().Format(time.RFC3339)
+			}
+			if nextRun := job.NextRun(); !nextRun.IsZero() {
+				status["nextRunTime"] = nextRun.UTC().Format(time.RFC3339)
+			}
+			jobs[tag] = status
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"jobs": jobs},
+	})
+	if err != nil {
+		return err
+	}
+	return s.client.Status().Patch(ctx, &mghList.Items[0], client.RawPatch(types.MergePatchType, patch))
+}
+
+func (s *GlobalHubJobScheduler) lastRun(name string) time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.lastRuns[name]
+}
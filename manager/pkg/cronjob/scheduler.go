@@ -2,13 +2,17 @@ package cronjob
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron"
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/archive"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/config"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/cronjob/task"
 )
@@ -20,12 +24,23 @@ const (
 	EveryHour   string = "hour"
 	EveryMinute string = "minute"
 	EverySecond string = "second"
+
+	defaultComplianceHistorySchedule = "0 0 * * *"
+	defaultComplianceRollupSchedule  = "0 1 * * *"
+	defaultDataRetentionSchedule     = "0 0 1,15,28 * *"
 )
 
 type GlobalHubJobScheduler struct {
 	log        logr.Logger
 	scheduler  *gocron.Scheduler
 	launchJobs []string
+	// the schedules of the jobs are reported to the status of the MulticlusterGlobalHub in the namespace, it's
+	// skipped if the client is nil
+	client    client.Client
+	namespace string
+	schedules map[string]string
+	lock      sync.Mutex
+	lastRuns  map[string]time.Time
 }
 
 func NewGlobalHubScheduler(scheduler *gocron.Scheduler, launchJobs []string) *GlobalHubJobScheduler {
@@ -43,43 +58,87 @@ func AddSchedulerToManager(ctx context.Context, mgr ctrl.Manager,
 	// Scheduler timezone:
 	// The cluster may be in a different timezones, Here we choose to be consistent with the local GH timezone.
 	scheduler := gocron.NewScheduler(time.Local)
+	jobScheduler := &GlobalHubJobScheduler{
+		log:        log,
+		scheduler:  scheduler,
+		launchJobs: strings.Split(managerConfig.LaunchJobNames, ","),
+		client:     mgr.GetClient(),
+		namespace:  managerConfig.ManagerNamespace,
+		schedules:  map[string]string{},
+		lastRuns:   map[string]time.Time{},
+	}
+	schedules := managerConfig.JobSchedules
+	if schedules == nil {
+		schedules = &config.JobSchedules{}
+	}
 
-	switch managerConfig.SchedulerInterval {
-	case EveryMonth:
-		scheduler = scheduler.Every(1).Month(1)
-	case EveryWeek:
-		scheduler = scheduler.Every(1).Week()
-	case EveryHour:
-		scheduler = scheduler.Every(1).Hour()
-	case EveryMinute:
-		scheduler = scheduler.Every(1).Minute()
-	case EverySecond:
-		scheduler = scheduler.Every(1).Second()
-	default:
-		scheduler = scheduler.Every(1).Day().At("00:00")
+	// the schedule overrides the scheduler interval, which is only used to simulate the compliance history
+	historySchedule := schedules.ComplianceHistory
+	if historySchedule != "" {
+		scheduler = scheduler.Cron(historySchedule)
+	} else {
+		historySchedule = "every " + managerConfig.SchedulerInterval
+		switch managerConfig.SchedulerInterval {
+		case EveryMonth:
+			scheduler = scheduler.Every(1).Month(1)
+		case EveryWeek:
+			scheduler = scheduler.Every(1).Week()
+		case EveryHour:
+			scheduler = scheduler.Every(1).Hour()
+		case EveryMinute:
+			scheduler = scheduler.Every(1).Minute()
+		case EverySecond:
+			scheduler = scheduler.Every(1).Second()
+		default:
+			historySchedule = defaultComplianceHistorySchedule
+			scheduler = scheduler.Cron(historySchedule)
+		}
 	}
 	complianceHistoryJob, err := scheduler.
 		Tag(task.LocalComplianceTaskName).
 		DoWithJobDetails(task.LocalComplianceHistory, ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to schedule the job %s: %w", task.LocalComplianceTaskName, err)
+	}
+	jobScheduler.watch(ctx, task.LocalComplianceTaskName, historySchedule, complianceHistoryJob)
+
+	// roll up the compliance history after it's synced at midnight
+	rollupSchedule := withDefault(schedules.ComplianceRollup, defaultComplianceRollupSchedule)
+	complianceRollupJob, err := scheduler.
+		Cron(rollupSchedule).
+		Tag(task.ComplianceRollupTaskName).
+		DoWithJobDetails(task.ComplianceRollup, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to schedule the job %s: %w", task.ComplianceRollupTaskName, err)
 	}
-	log.Info("set SyncLocalCompliance job", "scheduleAt", complianceHistoryJob.ScheduledAtTime())
+	jobScheduler.watch(ctx, task.ComplianceRollupTaskName, rollupSchedule, complianceRollupJob)
 
+	// archive the expired partitions of the events to the object storage before they're pruned
+	archiver, err := archive.NewPartitionArchiver(managerConfig.ArchiveConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create the archiver of the events: %w", err)
+	}
+	retentionSchedule := withDefault(schedules.DataRetention, defaultDataRetentionSchedule)
 	dataRetentionJob, err := scheduler.
-		Every(1).Month(1, 15, 28).At("00:00").
+		Cron(retentionSchedule).
 		Tag(task.RetentionTaskName).
-		DoWithJobDetails(task.DataRetention, ctx, managerConfig.DatabaseConfig.DataRetention)
+		DoWithJobDetails(task.DataRetention, ctx, task.RetentionPolicy{
+			Default:           managerConfig.DatabaseConfig.DataRetention,
+			Events:            managerConfig.DatabaseConfig.EventRetention,
+			ComplianceHistory: managerConfig.DatabaseConfig.ComplianceHistoryRetention,
+			Heartbeats:        managerConfig.DatabaseConfig.HeartbeatRetention,
+			AuditLogs:         managerConfig.DatabaseConfig.AuditLogRetention,
+			ComplianceRollups: managerConfig.DatabaseConfig.ComplianceRollupRetention,
+			Client:            mgr.GetClient(),
+			Namespace:         managerConfig.ManagerNamespace,
+			Archiver:          archiver,
+		})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to schedule the job %s: %w", task.RetentionTaskName, err)
 	}
-	log.Info("set DataRetention job", "scheduleAt", dataRetentionJob.ScheduledAtTime())
+	jobScheduler.watch(ctx, task.RetentionTaskName, retentionSchedule, dataRetentionJob)
 
-	return mgr.Add(&GlobalHubJobScheduler{
-		log:        log,
-		scheduler:  scheduler,
-		launchJobs: strings.Split(managerConfig.LaunchJobNames, ","),
-	})
+	return mgr.Add(jobScheduler)
 }
 
 func (s *GlobalHubJobScheduler) Start(ctx context.Context) error {
@@ -87,7 +146,11 @@ func (s *GlobalHubJobScheduler) Start(ctx context.Context) error {
 	// Set the status of the job to 0 (success) when the job is started.
 	config.GlobalHubCronJobGaugeVec.WithLabelValues(task.RetentionTaskName).Set(0)
 	config.GlobalHubCronJobGaugeVec.WithLabelValues(task.LocalComplianceTaskName).Set(0)
+	config.GlobalHubCronJobGaugeVec.WithLabelValues(task.ComplianceRollupTaskName).Set(0)
 	s.scheduler.StartAsync()
+	if err := s.reportJobStatus(ctx); err != nil {
+		s.log.Error(err, "failed to report the status of the jobs")
+	}
 	if err := s.ExecJobs(); err != nil {
 		return err
 	}
@@ -99,7 +162,7 @@ func (s *GlobalHubJobScheduler) Start(ctx context.Context) error {
 func (s *GlobalHubJobScheduler) ExecJobs() error {
 	for _, job := range s.launchJobs {
 		switch job {
-		case task.RetentionTaskName:
+		case task.RetentionTaskName, task.ComplianceRollupTaskName:
 			s.log.Info("launch the job", "name", job)
 			if err := s.scheduler.RunByTag(job); err != nil {
 				return err
@@ -110,3 +173,10 @@ func (s *GlobalHubJobScheduler) ExecJobs() error {
 	}
 	return nil
 }
+
+func withDefault(schedule, defaultSchedule string) string {
+	if schedule == "" {
+		return defaultSchedule
+	}
+	return schedule
+}
@@ -0,0 +1,125 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const ComplianceRollupTaskName = "compliance-rollup"
+
+var rollupLog = ctrl.Log.WithName(ComplianceRollupTaskName)
+
+// the daily rollup counts the clusters in each compliance state of the policy on the day
+var dailyRollupSQL = `
+	INSERT INTO history.compliance_rollups (period, period_start, leaf_hub_name, policy_id, compliant,
+		non_compliant, pending, unknown)
+	SELECT 'day', compliance_date, leaf_hub_name, policy_id,
+		count(*) FILTER (WHERE compliance = 'compliant'),
+		count(*) FILTER (WHERE compliance = 'non_compliant'),
+		count(*) FILTER (WHERE compliance = 'pending'),
+		count(*) FILTER (WHERE compliance = 'unknown')
+	FROM history.local_compliance
+	WHERE compliance_date >= ?
+	GROUP BY compliance_date, leaf_hub_name, policy_id
+	ON CONFLICT (period, period_start, leaf_hub_name, policy_id) DO UPDATE SET
+		compliant = EXCLUDED.compliant, non_compliant = EXCLUDED.non_compliant,
+		pending = EXCLUDED.pending, unknown = EXCLUDED.unknown`
+
+// the weekly rollup counts each cluster once by its worst compliance state in the week, i.e. non_compliant, then
+// pending, then unknown, then compliant. The weeks start on Monday
+var weeklyRollupSQL = `
+	INSERT INTO history.compliance_rollups (period, period_start, leaf_hub_name, policy_id, compliant,
+		non_compliant, pending, unknown)
+	SELECT 'week', week, leaf_hub_name, policy_id,
+		count(*) FILTER (WHERE severity = 1),
+		count(*) FILTER (WHERE severity = 4),
+		count(*) FILTER (WHERE severity = 3),
+		count(*) FILTER (WHERE severity = 2)
+	FROM (
+		SELECT date_trunc('week', compliance_date)::date AS week, leaf_hub_name, policy_id, cluster_id,
+			max(CASE compliance WHEN 'non_compliant' THEN 4 WHEN 'pending' THEN 3 WHEN 'unknown' THEN 2
+				ELSE 1 END) AS severity
+		FROM history.local_compliance
+		WHERE compliance_date >= date_trunc('week', ?::date)
+		GROUP BY week, leaf_hub_name, policy_id, cluster_id
+	) clusters
+	GROUP BY week, leaf_hub_name, policy_id
+	ON CONFLICT (period, period_start, leaf_hub_name, policy_id) DO UPDATE SET
+		compliant = EXCLUDED.compliant, non_compliant = EXCLUDED.non_compliant,
+		pending = EXCLUDED.pending, unknown = EXCLUDED.unknown`
+
+// the percentage of the compliant clusters of each policy and each managed hub on the day, it's computed from the
+// daily rollups, so a cluster is counted once for each of its policies
+var compliancePercentageSQL = `
+	INSERT INTO history.compliance_percentages (compliance_date, scope, scope_key, compliant, total, percentage)
+	SELECT compliance_date, scope, scope_key, compliant, total, round(100.0 * compliant / total, 2)
+	FROM (
+		SELECT period_start AS compliance_date, 'policy' AS scope, policy_id::text AS scope_key,
+			SUM(compliant) AS compliant, SUM(compliant + non_compliant + pending + unknown) AS total
+		FROM history.compliance_rollups
+		WHERE period = 'day' AND period_start >= ?
+		GROUP BY period_start, policy_id
+		UNION ALL
+		SELECT period_start, 'hub', leaf_hub_name,
+			SUM(compliant), SUM(compliant + non_compliant + pending + unknown)
+		FROM history.compliance_rollups
+		WHERE period = 'day' AND period_start >= ?
+		GROUP BY period_start, leaf_hub_name
+	) percentages
+	WHERE total > 0
+	ON CONFLICT (compliance_date, scope, scope_key) DO UPDATE SET
+		compliant = EXCLUDED.compliant, total = EXCLUDED.total, percentage = EXCLUDED.percentage`
+
+// ComplianceRollup rolls up the compliance history into the daily and weekly rollups. It recomputes the rollups from
+// the latest daily rollup, so the history which is added after the last run, or the whole history on the first run,
+// is rolled up. Then the daily compliance percentages of the policies and the managed hubs are computed from the
+// daily rollups. The rollups and the percentages are kept for the complianceRollups retention, which can be longer
+// than the history
+func ComplianceRollup(ctx context.Context, job gocron.Job) {
+	var err error
+	defer func() {
+		if err != nil {
+			config.GlobalHubCronJobGaugeVec.WithLabelValues(ComplianceRollupTaskName).Set(1)
+		} else {
+			config.GlobalHubCronJobGaugeVec.WithLabelValues(ComplianceRollupTaskName).Set(0)
+		}
+	}()
+
+	db := database.GetGorm().WithContext(ctx)
+	from := time.Time{}
+	latest := sql.NullTime{}
+	if err = db.Raw("SELECT max(period_start) FROM history.compliance_rollups WHERE period = ?",
+		database.RollupPeriodDay).Row().Scan(&latest); err != nil {
+		rollupLog.Error(err, "failed to get the latest daily rollup")
+		return
+	}
+	if latest.Valid {
+		from = latest.Time
+	}
+
+	daily := db.Exec(dailyRollupSQL, from.Format(DateFormat))
+	if err = daily.Error; err != nil {
+		rollupLog.Error(err, "failed to roll up the daily compliance")
+		return
+	}
+	weekly := db.Exec(weeklyRollupSQL, from.Format(DateFormat))
+	if err = weekly.Error; err != nil {
+		rollupLog.Error(err, "failed to roll up the weekly compliance")
+		return
+	}
+	percentages := db.Exec(compliancePercentageSQL, from.Format(DateFormat), from.Format(DateFormat))
+	if err = percentages.Error; err != nil {
+		rollupLog.Error(err, "failed to compute the compliance percentages")
+		return
+	}
+	rollupLog.Info("finish running", "from", from.Format(DateFormat), "daily", daily.RowsAffected,
+		"weekly", weekly.RowsAffected, "percentages", percentages.RowsAffected,
+		"nextRun", job.NextRun().Format(TimeFormat))
+}
@@ -7,9 +7,13 @@ import (
 	"time"
 
 	"github.com/go-co-op/gocron"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/archive"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/dbhealth"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/hubmanagement"
 	"github.com/stolostron/multicluster-global-hub/pkg/database"
 	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
@@ -18,7 +22,8 @@ import (
 var (
 	// The main tasks of this job are:
 	// 1. create partition tables for days in the future, the partition table for the next month is created
-	// 2. delete partition tables that are no longer needed, the partition table for the previous 18 month is deleted
+	// 2. delete partition tables that are no longer needed, the partition tables before the retention of the data
+	// class are deleted, e.g. the events or the compliance history
 	// 3. completely delete the soft deleted records from database after retainedMonths
 	RetentionTaskName = "data-retention"
 
@@ -40,11 +45,64 @@ var (
 		"event.local_root_policies",
 		"history.local_compliance",
 		"event.managed_clusters",
+		"event.cluster_lifecycle",
+		"history.audit_logs",
 	}
 	retentionLog = ctrl.Log.WithName(RetentionTaskName)
 )
 
-func DataRetention(ctx context.Context, retentionMonth int, job gocron.Job) {
+// the data classes of the retention policy, they're reported as the "<class>LastPruneTime" in the status of the
+// MulticlusterGlobalHub
+const (
+	eventsClass            = "events"
+	complianceHistoryClass = "complianceHistory"
+	heartbeatsClass        = "heartbeats"
+	auditLogsClass         = "auditLogs"
+	complianceRollupsClass = "complianceRollups"
+)
+
+// RetentionPolicy is how many months each class of the data is kept in the database, the class which is 0 is kept for
+// the Default months. The last prune time of each class is reported to the MulticlusterGlobalHub in the Namespace if
+// the Client is set. The expired partitions of the events are uploaded to the object storage before they're pruned if
+// the Archiver is set
+type RetentionPolicy struct {
+	Default           int
+	Events            int
+	ComplianceHistory int
+	Heartbeats        int
+	AuditLogs         int
+	ComplianceRollups int
+	Client            client.Client
+	Namespace         string
+	Archiver          *archive.PartitionArchiver
+}
+
+func (p RetentionPolicy) months(class string) int {
+	months := map[string]int{
+		eventsClass:            p.Events,
+		complianceHistoryClass: p.ComplianceHistory,
+		heartbeatsClass:        p.Heartbeats,
+		auditLogsClass:         p.AuditLogs,
+		complianceRollupsClass: p.ComplianceRollups,
+	}[class]
+	if months > 0 {
+		return months
+	}
+	return p.Default
+}
+
+// partitionTableClass returns the data class of the partition table
+func partitionTableClass(tableName string) string {
+	switch tableName {
+	case "history.local_compliance":
+		return complianceHistoryClass
+	case "history.audit_logs":
+		return auditLogsClass
+	}
+	return eventsClass
+}
+
+func DataRetention(ctx context.Context, policy RetentionPolicy, job gocron.Job) {
 	now := time.Now()
 	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 
@@ -66,10 +124,23 @@ func DataRetention(ctx context.Context, retentionMonth int, job gocron.Job) {
 		}
 	}()
 
+	// the classes are reported once all the tables of them are pruned
+	pruned := map[string]bool{}
+	defer func() {
+		if e := reportLastPruneTime(ctx, policy, pruned, now); e != nil {
+			retentionLog.Error(e, "failed to report the last prune time")
+		}
+	}()
+
 	createMonth := currentMonth.AddDate(0, 1, 0)
-	deleteMonth := currentMonth.AddDate(0, -(retentionMonth + 1), 0)
 	for _, tableName := range PartitionTables {
-		err = updatePartitionTables(tableName, createMonth, deleteMonth)
+		class := partitionTableClass(tableName)
+		deleteMonth := currentMonth.AddDate(0, -(policy.months(class) + 1), 0)
+		var archiver *archive.PartitionArchiver
+		if class == eventsClass {
+			archiver = policy.Archiver
+		}
+		err = updatePartitionTables(ctx, tableName, createMonth, deleteMonth, archiver)
 		if e := traceDataRetentionLog(tableName, currentMonth, err, true); e != nil {
 			retentionLog.Error(e, "failed to trace data retention log")
 		}
@@ -78,9 +149,12 @@ func DataRetention(ctx context.Context, retentionMonth int, job gocron.Job) {
 			return
 		}
 	}
+	pruned[eventsClass] = true
+	pruned[complianceHistoryClass] = true
+	pruned[auditLogsClass] = true
 
 	// delete the soft deleted records from database
-	minTime := currentMonth.AddDate(0, -retentionMonth, 0)
+	minTime := currentMonth.AddDate(0, -policy.Default, 0)
 	for _, tableName := range RetentionTables {
 		err = deleteExpiredRecords(tableName, minTime)
 		if e := traceDataRetentionLog(tableName, currentMonth, err, false); e != nil {
@@ -91,18 +165,48 @@ func DataRetention(ctx context.Context, retentionMonth int, job gocron.Job) {
 			return
 		}
 	}
-	err = db.Where("last_timestamp < ? AND status = ?", minTime, hubmanagement.HubInactive).
+	heartbeatMinTime := currentMonth.AddDate(0, -policy.months(heartbeatsClass), 0)
+	err = db.Where("last_timestamp < ? AND status = ?", heartbeatMinTime, hubmanagement.HubInactive).
 		Delete(&models.LeafHubHeartbeat{}).Error
 	if err != nil {
 		retentionLog.Error(err, "failed to delete the expired leaf hub heartbeat")
 		return
 	}
+	pruned[heartbeatsClass] = true
+
+	rollupMinTime := currentMonth.AddDate(0, -policy.months(complianceRollupsClass), 0)
+	err = db.Where("period_start < ?", rollupMinTime).Delete(&models.ComplianceRollup{}).Error
+	if err != nil {
+		retentionLog.Error(err, "failed to delete the expired compliance rollups")
+		return
+	}
+	err = db.Where("compliance_date < ?", rollupMinTime).Delete(&models.CompliancePercentage{}).Error
+	if err != nil {
+		retentionLog.Error(err, "failed to delete the expired compliance percentages")
+		return
+	}
+	pruned[complianceRollupsClass] = true
 	retentionLog.Info("finish running", "nextRun", job.NextRun().Format(TimeFormat))
 }
 
-func updatePartitionTables(tableName string, createTime, deleteTime time.Time) error {
+// updatePartitionTables creates the partition of the createTime, and deletes the partitions until the deleteTime. The
+// partitions are archived before they're deleted if the archiver isn't nil, and they're kept if the archival fails
+func updatePartitionTables(ctx context.Context, tableName string, createTime, deleteTime time.Time,
+	archiver *archive.PartitionArchiver,
+) error {
 	db := database.GetGorm()
 
+	// the chunks of the timescaledb hypertable are created on demand, and dropped by the retention policy
+	partitioned := false
+	if result := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = ?::regclass)",
+		tableName).Scan(&partitioned); result.Error != nil {
+		return fmt.Errorf("failed to check the partitioned table %s: %w", tableName, result.Error)
+	}
+	if !partitioned {
+		retentionLog.Info("skip the table which isn't partitioned", "table", tableName)
+		return nil
+	}
+
 	// create the partition tables for the next month
 	startTime := time.Date(createTime.Year(), createTime.Month(), 1, 0, 0, 0, 0, createTime.Location())
 	endTime := startTime.AddDate(0, 1, 0)
@@ -116,16 +220,62 @@ func updatePartitionTables(tableName string, createTime, deleteTime time.Time) e
 	retentionLog.Info("create partition table", "table", createPartitionTableName, "start", startTime.Format(DateFormat),
 		"end", endTime.Format(DateFormat))
 
-	// delete the partition tables that are expired
+	// delete the partition tables that are expired, the older ones are left if the retention is shortened
+	tables, err := listPartitionTables(tableName)
+	if err != nil {
+		return err
+	}
 	deletePartitionTableName := fmt.Sprintf("%s_%s", tableName, deleteTime.Format(PartitionDateFormat))
-	deletionSql := fmt.Sprintf("DROP TABLE IF EXISTS %s", deletePartitionTableName)
-	if result := db.Exec(deletionSql); result.Error != nil {
-		return fmt.Errorf("failed to delete partition table %s: %w", tableName, result.Error)
+	for _, table := range tables {
+		partitionTableName := fmt.Sprintf("%s.%s", table.Schema, table.Table)
+		if partitionTableName > deletePartitionTableName {
+			continue
+		}
+		if archiver != nil {
+			key, err := archiver.Archive(ctx, db, tableName, partitionTableName)
+			if err != nil {
+				return fmt.Errorf("failed to archive partition table %s: %w", partitionTableName, err)
+			}
+			retentionLog.Info("archive partition table", "table", partitionTableName, "object", key)
+		}
+		deletionSql := fmt.Sprintf("DROP TABLE IF EXISTS %s", partitionTableName)
+		if result := db.Exec(deletionSql); result.Error != nil {
+			return fmt.Errorf("failed to delete partition table %s: %w", tableName, result.Error)
+		}
+		retentionLog.Info("delete partition table", "table", partitionTableName)
 	}
-	retentionLog.Info("delete partition table", "table", deletePartitionTableName)
 	return nil
 }
 
+// reportLastPruneTime sets the last prune time of the pruned classes in the status of the MulticlusterGlobalHub, the
+// manager doesn't depend on the operator api, so the MulticlusterGlobalHub is updated as an unstructured object
+func reportLastPruneTime(ctx context.Context, policy RetentionPolicy, pruned map[string]bool,
+	pruneTime time.Time,
+) error {
+	if policy.Client == nil || len(pruned) == 0 {
+		return nil
+	}
+	mghList := &unstructured.UnstructuredList{}
+	mghList.SetGroupVersionKind(dbhealth.MulticlusterGlobalHubGVK.GroupVersion().WithKind(
+		dbhealth.MulticlusterGlobalHubGVK.Kind + "List"))
+	if err := policy.Client.List(ctx, mghList, client.InNamespace(policy.Namespace)); err != nil {
+		return err
+	}
+	if len(mghList.Items) == 0 {
+		return nil
+	}
+	mgh := &mghList.Items[0]
+
+	for class := range pruned {
+		err := unstructured.SetNestedField(mgh.Object, pruneTime.UTC().Format(time.RFC3339), "status",
+			"dataRetention", class+"LastPruneTime")
+		if err != nil {
+			return err
+		}
+	}
+	return policy.Client.Status().Update(ctx, mgh)
+}
+
 func deleteExpiredRecords(tableName string, minDate time.Time) error {
 	sql := fmt.Sprintf("DELETE FROM %s WHERE deleted_at < '%s'", tableName, minDate.Format(DateFormat))
 	db := database.GetGorm()
@@ -165,11 +315,24 @@ func traceDataRetentionLog(tableName string, startTime time.Time, err error, par
 }
 
 func getMinMaxPartitions(tableName string) (string, string, error) {
+	tables, err := listPartitionTables(tableName)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get min/max partition table: %w", err)
+	}
+	if len(tables) < 1 {
+		retentionLog.Info("no partition table found", "table", tableName)
+		return "", "", nil
+	}
+	return tables[0].Table, tables[len(tables)-1].Table, nil
+}
+
+// listPartitionTables returns the partition tables of the table, which are ordered by the name
+func listPartitionTables(tableName string) ([]models.Table, error) {
 	db := database.GetGorm()
 
 	schemaTable := strings.Split(tableName, ".")
 	if len(schemaTable) != 2 {
-		return "", "", fmt.Errorf("invalid table name: %s", tableName)
+		return nil, fmt.Errorf("invalid table name: %s", tableName)
 	}
 	sql := fmt.Sprintf(`
 		SELECT
@@ -191,13 +354,9 @@ func getMinMaxPartitions(tableName string) (string, string, error) {
 	var tables []models.Table
 	result := db.Raw(sql).Find(&tables)
 	if result.Error != nil {
-		return "", "", fmt.Errorf("failed to get min/max partition table: %w", result.Error)
-	}
-	if len(tables) < 1 {
-		retentionLog.Info("no partition table found", "table", tableName)
-		return "", "", nil
+		return nil, fmt.Errorf("failed to list the partition tables of %s: %w", tableName, result.Error)
 	}
-	return tables[0].Table, tables[len(tables)-1].Table, nil
+	return tables, nil
 }
 
 func getMinDeletionTime(tableName string) (time.Time, error) {
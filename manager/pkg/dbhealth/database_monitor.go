@@ -0,0 +1,227 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package dbhealth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const (
+	ConditionTypeManagerDegraded = "ManagerDegraded"
+	ReasonDatabaseUnavailable    = "DatabaseUnavailable"
+	ReasonDatabaseAvailable      = "DatabaseAvailable"
+
+	probeTimeout = 5 * time.Second
+)
+
+var DatabaseAvailableGauge = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "multicluster_global_hub_database_available",
+		Help: "Whether the database is reachable from the manager. 1 == available, 0 == unavailable.",
+	},
+)
+
+var MulticlusterGlobalHubGVK = schema.GroupVersionKind{
+	Group:   "operator.open-cluster-management.io",
+	Version: "v1alpha4",
+	Kind:    "MulticlusterGlobalHub",
+}
+
+// DatabaseMonitor probes the database periodically. While the database is unavailable, the status events aren't
+// dispatched to the database workers and the kafka offsets aren't committed, so the events stay in the transport and
+// are consumed again once the database returns. The state is reflected as the ManagerDegraded condition of the
+// MulticlusterGlobalHub, instead of crash-looping the manager.
+type DatabaseMonitor struct {
+	log       logr.Logger
+	client    client.Client
+	namespace string
+	interval  time.Duration
+	probe     func(ctx context.Context) error
+	available atomic.Bool
+	// the availability which has been reflected to the condition, nil if it isn't synced yet
+	synced *bool
+}
+
+func NewDatabaseMonitor(c client.Client, namespace string, interval time.Duration) *DatabaseMonitor {
+	monitor := &DatabaseMonitor{
+		log:       ctrl.Log.WithName("database-monitor"),
+		client:    c,
+		namespace: namespace,
+		interval:  interval,
+		probe: func(ctx context.Context) error {
+			return database.GetSqlDb().PingContext(ctx)
+		},
+	}
+	// the database is reachable when the manager starts
+	monitor.available.Store(true)
+	DatabaseAvailableGauge.Set(1)
+	return monitor
+}
+
+// Available returns whether the database is reachable, it's always true for a nil monitor
+func (m *DatabaseMonitor) Available() bool {
+	return m == nil || m.available.Load()
+}
+
+// WaitAvailable blocks until the database is reachable or the context is canceled
+func (m *DatabaseMonitor) WaitAvailable(ctx context.Context) {
+	if m.Available() {
+		return
+	}
+	_ = wait.PollUntilContextCancel(ctx, time.Second, false, func(ctx context.Context) (bool, error) {
+		return m.Available(), nil
+	})
+}
+
+func (m *DatabaseMonitor) Start(ctx context.Context) error {
+	m.log.Info("starting database monitor", "interval", m.interval)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+// ProbeUntilElected probes the database on the replica which isn't the leader, so the replica also pauses the
+// ingestion and the offset commits while the database is unavailable. The condition is only updated by the monitor
+// of the leader, so the probing stops once the replica is elected and the monitor is started
+func (m *DatabaseMonitor) ProbeUntilElected(elected <-chan struct{}) manager.RunnableFunc {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-elected:
+				return nil
+			case <-ticker.C:
+				m.probeAvailability(ctx)
+			}
+		}
+	}
+}
+
+func (m *DatabaseMonitor) check(ctx context.Context) {
+	available, err := m.probeAvailability(ctx)
+	if m.synced != nil && *m.synced == available {
+		return
+	}
+	if err := m.updateCondition(ctx, available, err); err != nil {
+		m.log.Error(err, "failed to update the degraded condition of the multicluster global hub")
+		return
+	}
+	m.synced = &available
+}
+
+// probeAvailability probes the database and records whether it's available
+func (m *DatabaseMonitor) probeAvailability(ctx context.Context) (bool, error) {
+	probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	err := m.probe(probeCtx)
+	cancel()
+
+	available := err == nil
+	if m.available.Swap(available) != available {
+		if available {
+			m.log.Info("the database is available, resume the ingestion")
+		} else {
+			m.log.Error(err, "the database is unavailable, pause the ingestion and the offset commits")
+		}
+	}
+	if available {
+		DatabaseAvailableGauge.Set(1)
+	} else {
+		DatabaseAvailableGauge.Set(0)
+	}
+	return available, err
+}
+
+func (m *DatabaseMonitor) updateCondition(ctx context.Context, available bool, probeErr error) error {
+	condition := metav1.Condition{
+		Type:    ConditionTypeManagerDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonDatabaseAvailable,
+		Message: "the database is available",
+	}
+	if !available {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonDatabaseUnavailable
+		condition.Message = fmt.Sprintf("the database is unavailable, the ingestion is paused: %v", probeErr)
+	}
+	return SetMGHCondition(ctx, m.client, m.namespace, condition)
+}
+
+// SetMGHCondition sets the condition of the MulticlusterGlobalHub in the namespace, the manager doesn't depend on
+// the operator api, so the MulticlusterGlobalHub is updated as an unstructured object
+func SetMGHCondition(ctx context.Context, c client.Client, namespace string, condition metav1.Condition) error {
+	mghList := &unstructured.UnstructuredList{}
+	mghList.SetGroupVersionKind(MulticlusterGlobalHubGVK.GroupVersion().WithKind(MulticlusterGlobalHubGVK.Kind + "List"))
+	if err := c.List(ctx, mghList, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	if len(mghList.Items) == 0 {
+		return nil
+	}
+	mgh := &mghList.Items[0]
+
+	conditions, err := getConditions(mgh)
+	if err != nil {
+		return err
+	}
+	if !meta.SetStatusCondition(&conditions, condition) {
+		return nil
+	}
+	if err := setConditions(mgh, conditions); err != nil {
+		return err
+	}
+	return c.Status().Update(ctx, mgh)
+}
+
+func getConditions(obj *unstructured.Unstructured) ([]metav1.Condition, error) {
+	conditions := []metav1.Condition{}
+	rawConditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return conditions, err
+	}
+	data, err := json.Marshal(rawConditions)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &conditions)
+	return conditions, err
+}
+
+func setConditions(obj *unstructured.Unstructured, conditions []metav1.Condition) error {
+	data, err := json.Marshal(conditions)
+	if err != nil {
+		return err
+	}
+	rawConditions := []interface{}{}
+	if err := json.Unmarshal(data, &rawConditions); err != nil {
+		return err
+	}
+	return unstructured.SetNestedSlice(obj.Object, rawConditions, "status", "conditions")
+}
@@ -0,0 +1,73 @@
+package dbhealth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDatabaseMonitor(t *testing.T) {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(MulticlusterGlobalHubGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(MulticlusterGlobalHubGVK.GroupVersion().WithKind(MulticlusterGlobalHubGVK.Kind+"List"),
+		&unstructured.UnstructuredList{})
+
+	mgh := &unstructured.Unstructured{}
+	mgh.SetGroupVersionKind(MulticlusterGlobalHubGVK)
+	mgh.SetNamespace("multicluster-global-hub")
+	mgh.SetName("multiclusterglobalhub")
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(mgh).WithStatusSubresource(mgh).Build()
+
+	ctx := context.Background()
+	monitor := NewDatabaseMonitor(fakeClient, "multicluster-global-hub", time.Second)
+	assert.True(t, monitor.Available())
+
+	// the manager is degraded once the database is unavailable
+	monitor.probe = func(ctx context.Context) error { return errors.New("connection refused") }
+	monitor.check(ctx)
+	assert.False(t, monitor.Available())
+	assert.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(mgh), mgh))
+	conditions, err := getConditions(mgh)
+	assert.NoError(t, err)
+	condition := meta.FindStatusCondition(conditions, ConditionTypeManagerDegraded)
+	assert.NotNil(t, condition)
+	assert.Equal(t, ReasonDatabaseUnavailable, condition.Reason)
+
+	// the ingestion is resumed once the database returns
+	monitor.probe = func(ctx context.Context) error { return nil }
+	monitor.check(ctx)
+	assert.True(t, monitor.Available())
+	monitor.WaitAvailable(ctx)
+	assert.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(mgh), mgh))
+	conditions, err = getConditions(mgh)
+	assert.NoError(t, err)
+	assert.True(t, meta.IsStatusConditionFalse(conditions, ConditionTypeManagerDegraded))
+
+	var nilMonitor *DatabaseMonitor
+	assert.True(t, nilMonitor.Available())
+}
+
+func TestProbeUntilElected(t *testing.T) {
+	monitor := NewDatabaseMonitor(nil, "multicluster-global-hub", 10*time.Millisecond)
+	monitor.probe = func(ctx context.Context) error { return errors.New("connection refused") }
+
+	// the replica pauses the ingestion without updating the condition, the client isn't used
+	elected := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- monitor.ProbeUntilElected(elected)(context.Background())
+	}()
+	assert.Eventually(t, func() bool { return !monitor.Available() }, time.Second, 10*time.Millisecond)
+
+	// the probing stops once the replica is elected
+	close(elected)
+	assert.NoError(t, <-done)
+}
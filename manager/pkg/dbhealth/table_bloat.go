@@ -0,0 +1,105 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package dbhealth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+// the statistics of the tables, the chunks of the timescaledb hypertables are skipped to bound the cardinality
+const tableStatsSQL = `SELECT schemaname, relname, n_live_tup, n_dead_tup, pg_total_relation_size(relid),
+	EXTRACT(EPOCH FROM GREATEST(last_vacuum, last_autovacuum))
+	FROM pg_stat_user_tables WHERE schemaname NOT LIKE '\_timescaledb%'`
+
+var (
+	tableLabels = []string{"schema", "table"}
+
+	tableLiveTuplesDesc = prometheus.NewDesc("multicluster_global_hub_database_table_live_tuples",
+		"The estimated number of the live rows of the table.", tableLabels, nil)
+	tableDeadTuplesDesc = prometheus.NewDesc("multicluster_global_hub_database_table_dead_tuples",
+		"The estimated number of the dead rows of the table, which are reclaimed by the vacuum.", tableLabels, nil)
+	tableSizeDesc = prometheus.NewDesc("multicluster_global_hub_database_table_size_bytes",
+		"The disk space used by the table, including its indexes and toast data.", tableLabels, nil)
+	tableLastVacuumDesc = prometheus.NewDesc("multicluster_global_hub_database_table_last_vacuum_timestamp_seconds",
+		"The unix time of the latest manual or auto vacuum of the table.", tableLabels, nil)
+)
+
+type tableStats struct {
+	schema     string
+	table      string
+	liveTuples int64
+	deadTuples int64
+	sizeBytes  int64
+	lastVacuum sql.NullFloat64
+}
+
+// TableBloatCollector exports the live and the dead tuples, the size and the latest vacuum of the database tables
+// on each scrape, so the bloat of the compliance and the event tables can be alerted before it degrades the queries
+type TableBloatCollector struct {
+	query func(ctx context.Context) ([]tableStats, error)
+}
+
+func NewTableBloatCollector() *TableBloatCollector {
+	return &TableBloatCollector{query: queryTableStats}
+}
+
+func (c *TableBloatCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tableLiveTuplesDesc
+	ch <- tableDeadTuplesDesc
+	ch <- tableSizeDesc
+	ch <- tableLastVacuumDesc
+}
+
+func (c *TableBloatCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	stats, err := c.query(ctx)
+	if err != nil {
+		// the availability of the database is reported by the database monitor
+		ctrl.Log.WithName("table-bloat-collector").V(2).Info("failed to query the table statistics", "error", err)
+		return
+	}
+	for _, s := range stats {
+		ch <- prometheus.MustNewConstMetric(tableLiveTuplesDesc, prometheus.GaugeValue, float64(s.liveTuples),
+			s.schema, s.table)
+		ch <- prometheus.MustNewConstMetric(tableDeadTuplesDesc, prometheus.GaugeValue, float64(s.deadTuples),
+			s.schema, s.table)
+		ch <- prometheus.MustNewConstMetric(tableSizeDesc, prometheus.GaugeValue, float64(s.sizeBytes),
+			s.schema, s.table)
+		// the table hasn't been vacuumed yet
+		if s.lastVacuum.Valid {
+			ch <- prometheus.MustNewConstMetric(tableLastVacuumDesc, prometheus.GaugeValue, s.lastVacuum.Float64,
+				s.schema, s.table)
+		}
+	}
+}
+
+func queryTableStats(ctx context.Context) ([]tableStats, error) {
+	db := database.GetSqlDb()
+	if db == nil {
+		return nil, errors.New("the database isn't initialized")
+	}
+	rows, err := db.QueryContext(ctx, tableStatsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stats := []tableStats{}
+	for rows.Next() {
+		s := tableStats{}
+		if err := rows.Scan(&s.schema, &s.table, &s.liveTuples, &s.deadTuples, &s.sizeBytes,
+			&s.lastVacuum); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
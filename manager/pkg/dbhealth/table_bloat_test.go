@@ -0,0 +1,47 @@
+package dbhealth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableBloatCollector(t *testing.T) {
+	collector := &TableBloatCollector{
+		query: func(ctx context.Context) ([]tableStats, error) {
+			return []tableStats{
+				{
+					schema: "history", table: "local_compliance", liveTuples: 1000, deadTuples: 400,
+					sizeBytes: 8192, lastVacuum: sql.NullFloat64{Float64: 1700000000, Valid: true},
+				},
+				{schema: "status", table: "compliance", liveTuples: 10, deadTuples: 0, sizeBytes: 4096},
+			}, nil
+		},
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	metrics := map[string]int{}
+	for _, family := range families {
+		metrics[family.GetName()] = len(family.GetMetric())
+	}
+	assert.Equal(t, 2, metrics["multicluster_global_hub_database_table_live_tuples"])
+	assert.Equal(t, 2, metrics["multicluster_global_hub_database_table_dead_tuples"])
+	assert.Equal(t, 2, metrics["multicluster_global_hub_database_table_size_bytes"])
+	// the status.compliance hasn't been vacuumed
+	assert.Equal(t, 1, metrics["multicluster_global_hub_database_table_last_vacuum_timestamp_seconds"])
+
+	// nothing is exported if the database is unavailable
+	collector.query = func(ctx context.Context) ([]tableStats, error) {
+		return nil, errors.New("connection refused")
+	}
+	families, err = registry.Gather()
+	assert.NoError(t, err)
+	assert.Empty(t, families)
+}
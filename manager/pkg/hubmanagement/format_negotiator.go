@@ -0,0 +1,146 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package hubmanagement
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+const (
+	ConditionTypeDegraded          = "Degraded"
+	ReasonIncompatibleBundleFormat = "IncompatibleBundleFormat"
+	ReasonCompatibleBundleFormat   = "CompatibleBundleFormat"
+	formatNegotiationSyncInterval  = 30 * time.Second
+)
+
+var HubFormatVersionGaugeVec = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "multicluster_global_hub_hub_format_version",
+		Help: "The bundle format version reported by the agent of the managed hub.",
+	},
+	[]string{"hub"},
+)
+
+// FormatNegotiator tracks the bundle format version reported by the agent of each managed hub. The bundles of an
+// unsupported version are skipped instead of failing to be parsed, and the skew is reflected as the Degraded
+// condition of the global hub addon of the managed hub, so that it's visible during a rolling upgrade of the fleet.
+type FormatNegotiator struct {
+	log    logr.Logger
+	client client.Client
+	lock   sync.Mutex
+	// the format version observed from each hub, and the version has been reflected to the addon condition
+	observed map[string]int
+	synced   map[string]int
+}
+
+func NewFormatNegotiator(c client.Client) *FormatNegotiator {
+	return &FormatNegotiator{
+		log:      ctrl.Log.WithName("format-negotiator"),
+		client:   c,
+		observed: map[string]int{},
+		synced:   map[string]int{},
+	}
+}
+
+// Observe records the format version of the event, and returns whether the manager is able to handle the event
+func (n *FormatNegotiator) Observe(evt *cloudevents.Event) bool {
+	version := transport.GetFormatVersion(evt)
+	supported := transport.IsFormatVersionSupported(version)
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if previous, ok := n.observed[evt.Source()]; !ok || previous != version {
+		n.observed[evt.Source()] = version
+		HubFormatVersionGaugeVec.WithLabelValues(evt.Source()).Set(float64(version))
+		if !supported {
+			n.log.Info("skip the bundles with an unsupported format version", "hub", evt.Source(),
+				"version", version, "minVersion", transport.MinFormatVersion, "maxVersion", transport.CurrentFormatVersion)
+		}
+	}
+	return supported
+}
+
+func (n *FormatNegotiator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(formatNegotiationSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			n.sync(ctx)
+		}
+	}
+}
+
+func (n *FormatNegotiator) sync(ctx context.Context) {
+	changed := map[string]int{}
+	n.lock.Lock()
+	for hubName, version := range n.observed {
+		if synced, ok := n.synced[hubName]; !ok || synced != version {
+			changed[hubName] = version
+		}
+	}
+	n.lock.Unlock()
+
+	for hubName, version := range changed {
+		if err := n.updateAddonCondition(ctx, hubName, version); err != nil {
+			n.log.Error(err, "failed to update the format version condition of the addon", "hub", hubName)
+			continue
+		}
+		n.lock.Lock()
+		n.synced[hubName] = version
+		n.lock.Unlock()
+	}
+}
+
+func (n *FormatNegotiator) updateAddonCondition(ctx context.Context, hubName string, version int) error {
+	addon := &addonv1alpha1.ManagedClusterAddOn{}
+	err := n.client.Get(ctx, types.NamespacedName{
+		Namespace: hubName,
+		Name:      constants.GHManagedClusterAddonName,
+	}, addon)
+	if errors.IsNotFound(err) {
+		// the hub isn't managed by the addon, e.g. the agent is installed manually
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	condition := metav1.Condition{
+		Type:    ConditionTypeDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonCompatibleBundleFormat,
+		Message: fmt.Sprintf("the bundle format version %d is supported by the global hub manager", version),
+	}
+	if !transport.IsFormatVersionSupported(version) {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonIncompatibleBundleFormat
+		condition.Message = fmt.Sprintf("the bundle format version %d isn't supported by the global hub manager, "+
+			"the supported versions are %d to %d, upgrade the agent or the global hub to the same release", version,
+			transport.MinFormatVersion, transport.CurrentFormatVersion)
+	}
+
+	if !meta.SetStatusCondition(&addon.Status.Conditions, condition) {
+		return nil
+	}
+	return n.client.Status().Update(ctx, addon)
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package argocd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const serverInternalErrorMsg = "internal error"
+
+// the applications of the managed hubs, the health and the sync status are the generated columns of the payload
+var applicationsSQL = `
+	SELECT id::text, leaf_hub_name, name, namespace, COALESCE(health_status, ''), COALESCE(sync_status, ''),
+		payload -> 'spec', payload -> 'status'
+	FROM status.argocd_applications
+	WHERE (@hub = '' OR leaf_hub_name = @hub)
+		AND (@health = '' OR health_status = @health)
+		AND (@sync = '' OR sync_status = @sync)
+	ORDER BY leaf_hub_name, namespace, name`
+
+type argoApplication struct {
+	ID          string `json:"id"`
+	LeafHubName string `json:"leafHubName"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace"`
+	Project     string `json:"project,omitempty"`
+	// RepoURL, Path and TargetRevision are of the first source of the multi-source applications, the Path is the
+	// chart of the helm repositories
+	RepoURL        string `json:"repoURL,omitempty"`
+	Path           string `json:"path,omitempty"`
+	TargetRevision string `json:"targetRevision,omitempty"`
+	// DestinationServer is the server or the name of the destination cluster
+	DestinationServer    string `json:"destinationServer,omitempty"`
+	DestinationNamespace string `json:"destinationNamespace,omitempty"`
+	// HealthStatus is Healthy, Progressing, Degraded, Suspended, Missing or Unknown
+	HealthStatus string `json:"healthStatus"`
+	// SyncStatus is Synced, OutOfSync or Unknown
+	SyncStatus string `json:"syncStatus"`
+	// Revision is the revision which the application is synced to
+	Revision string `json:"revision,omitempty"`
+	// OperationPhase is the phase of the last sync operation, e.g. Succeeded or Failed
+	OperationPhase   string     `json:"operationPhase,omitempty"`
+	OperationMessage string     `json:"operationMessage,omitempty"`
+	ReconciledAt     *time.Time `json:"reconciledAt,omitempty"`
+}
+
+type argoApplicationList struct {
+	// HealthSummary and SyncSummary are the count of the applications in each health and sync status
+	HealthSummary map[string]int    `json:"healthSummary"`
+	SyncSummary   map[string]int    `json:"syncSummary"`
+	Applications  []argoApplication `json:"applications"`
+}
+
+// the fields of the application that are returned, they're a subset of the ArgoCD Application
+type applicationSource struct {
+	RepoURL        string `json:"repoURL"`
+	Path           string `json:"path"`
+	Chart          string `json:"chart"`
+	TargetRevision string `json:"targetRevision"`
+}
+
+type applicationSpec struct {
+	Project     string              `json:"project"`
+	Source      *applicationSource  `json:"source"`
+	Sources     []applicationSource `json:"sources"`
+	Destination struct {
+		Server    string `json:"server"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"destination"`
+}
+
+type applicationStatus struct {
+	Sync struct {
+		Revision  string   `json:"revision"`
+		Revisions []string `json:"revisions"`
+	} `json:"sync"`
+	OperationState struct {
+		Phase   string `json:"phase"`
+		Message string `json:"message"`
+	} `json:"operationState"`
+	ReconciledAt *time.Time `json:"reconciledAt"`
+}
+
+// ListArgoApplications godoc
+// @summary list ArgoCD applications
+// @description list the health and the sync status of the ArgoCD applications of the managed hubs
+// @accept json
+// @produce json
+// @param        leafHubName    query     string  false  "only return the applications of the managed hub"
+// @param        health         query     string  false  "only return the applications of the health status, e.g. Degraded"
+// @param        sync           query     string  false  "only return the applications of the sync status, e.g. OutOfSync"
+// @success      200  {object}    argoApplicationList
+// @failure      401
+// @failure      403
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /argocdapplications [get]
+func ListArgoApplications() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		args := map[string]interface{}{
+			"hub":    ginCtx.Query("leafHubName"),
+			"health": ginCtx.Query("health"),
+			"sync":   ginCtx.Query("sync"),
+		}
+		fmt.Fprintf(gin.DefaultWriter, "argocd applications query: %v\n", args)
+
+		applications, err := queryApplications(args, authorization.GetScope(ginCtx))
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying argocd applications: %v\n", err)
+			return
+		}
+
+		list := &argoApplicationList{
+			HealthSummary: map[string]int{},
+			SyncSummary:   map[string]int{},
+			Applications:  applications,
+		}
+		for _, application := range applications {
+			list.HealthSummary[application.HealthStatus]++
+			list.SyncSummary[application.SyncStatus]++
+		}
+		ginCtx.JSON(http.StatusOK, list)
+	}
+}
+
+// queryApplications returns the applications of the managed hubs that the user is allowed to access, the
+// applications aren't owned by the managed clusters, so all the clusters of the hub must be allowed
+func queryApplications(args map[string]interface{}, scope *authorization.Scope) ([]argoApplication, error) {
+	rows, err := database.GetReadonlyGorm().Raw(applicationsSQL, args).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applications := []argoApplication{}
+	for rows.Next() {
+		application := argoApplication{}
+		var spec, status []byte
+		if err := rows.Scan(&application.ID, &application.LeafHubName, &application.Name, &application.Namespace,
+			&application.HealthStatus, &application.SyncStatus, &spec, &status); err != nil {
+			return nil, err
+		}
+		if !scope.AllowsHub(application.LeafHubName) {
+			continue
+		}
+		if err := application.setFields(spec, status); err != nil {
+			return nil, fmt.Errorf("failed to parse the application %s/%s of the hub %s: %w",
+				application.Namespace, application.Name, application.LeafHubName, err)
+		}
+		applications = append(applications, application)
+	}
+	return applications, rows.Err()
+}
+
+// setFields sets the source, the destination and the last operation of the application
+func (a *argoApplication) setFields(specJSON, statusJSON []byte) error {
+	spec := &applicationSpec{}
+	if len(specJSON) > 0 {
+		if err := json.Unmarshal(specJSON, spec); err != nil {
+			return err
+		}
+	}
+	status := &applicationStatus{}
+	if len(statusJSON) > 0 {
+		if err := json.Unmarshal(statusJSON, status); err != nil {
+			return err
+		}
+	}
+
+	a.Project = spec.Project
+	source := spec.Source
+	if source == nil && len(spec.Sources) > 0 {
+		source = &spec.Sources[0]
+	}
+	if source != nil {
+		a.RepoURL, a.Path, a.TargetRevision = source.RepoURL, source.Path, source.TargetRevision
+		if a.Path == "" {
+			a.Path = source.Chart
+		}
+	}
+	a.DestinationServer = spec.Destination.Server
+	if a.DestinationServer == "" {
+		a.DestinationServer = spec.Destination.Name
+	}
+	a.DestinationNamespace = spec.Destination.Namespace
+
+	a.Revision = status.Sync.Revision
+	if a.Revision == "" && len(status.Sync.Revisions) > 0 {
+		a.Revision = status.Sync.Revisions[0]
+	}
+	a.OperationPhase = status.OperationState.Phase
+	a.OperationMessage = status.OperationState.Message
+	a.ReconciledAt = status.ReconciledAt
+	return nil
+}
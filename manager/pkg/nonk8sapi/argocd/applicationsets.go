@@ -0,0 +1,184 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package argocd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+// the rollout status of the ApplicationSet on a managed hub
+const (
+	RolloutHealthy     = "Healthy"
+	RolloutProgressing = "Progressing"
+	RolloutDegraded    = "Degraded"
+	RolloutError       = "Error"
+)
+
+// the global ApplicationSets and the status reported by each managed hub, the row of the hub is the ApplicationSet
+// propagated from the global hub, so it has the same id
+var applicationSetsSQL = `
+	SELECT s.id::text, s.payload -> 'metadata' ->> 'name' AS name, s.payload -> 'metadata' ->> 'namespace' AS namespace,
+		COALESCE(h.leaf_hub_name, '') AS leaf_hub_name, h.payload -> 'status'
+	FROM spec.applicationsets s LEFT JOIN status.argocd_applicationsets h
+		ON h.id = s.id AND (@hub = '' OR h.leaf_hub_name = @hub)
+	WHERE s.deleted = false
+	ORDER BY namespace, name, leaf_hub_name`
+
+type argoApplicationSet struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// RolloutSummary is the count of the managed hubs in each rollout status
+	RolloutSummary map[string]int `json:"rolloutSummary"`
+	Hubs           []hubRollout   `json:"hubs"`
+}
+
+type hubRollout struct {
+	LeafHubName string `json:"leafHubName"`
+	// Status is Healthy, Progressing, Degraded or Error
+	Status string `json:"status"`
+	// Applications is the number of the applications generated on the hub, Healthy and Synced are the ones of them
+	// which are healthy and synced
+	Applications int `json:"applications"`
+	Healthy      int `json:"healthy"`
+	Synced       int `json:"synced"`
+	// Message is the error of the ApplicationSet on the hub
+	Message string `json:"message,omitempty"`
+}
+
+// the fields of the ApplicationSet status that are used, they're a subset of the ArgoCD ApplicationSet
+type applicationSetStatus struct {
+	Conditions []struct {
+		Type    string `json:"type"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"conditions"`
+	Resources []struct {
+		Health struct {
+			Status string `json:"status"`
+		} `json:"health"`
+		Status string `json:"status"`
+	} `json:"resources"`
+}
+
+// ListArgoApplicationSets godoc
+// @summary list ArgoCD applicationsets
+// @description list the rollout status of the ApplicationSets propagated from the global hub to the managed hubs
+// @accept json
+// @produce json
+// @param        leafHubName    query     string  false  "only return the rollout status of the managed hub"
+// @success      200  {object}    []argoApplicationSet
+// @failure      401
+// @failure      403
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /argocdapplicationsets [get]
+func ListArgoApplicationSets() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		args := map[string]interface{}{
+			"hub": ginCtx.Query("leafHubName"),
+		}
+		fmt.Fprintf(gin.DefaultWriter, "argocd applicationsets query: %v\n", args)
+
+		applicationSets, err := queryApplicationSets(args, authorization.GetScope(ginCtx))
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying argocd applicationsets: %v\n", err)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, applicationSets)
+	}
+}
+
+// queryApplicationSets returns the global ApplicationSets with the rollout status of the managed hubs that the user
+// is allowed to access
+func queryApplicationSets(args map[string]interface{}, scope *authorization.Scope) ([]*argoApplicationSet, error) {
+	rows, err := database.GetReadonlyGorm().Raw(applicationSetsSQL, args).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applicationSets := []*argoApplicationSet{}
+	var applicationSet *argoApplicationSet
+	for rows.Next() {
+		var id, name, namespace, hub string
+		var status []byte
+		if err := rows.Scan(&id, &name, &namespace, &hub, &status); err != nil {
+			return nil, err
+		}
+		if applicationSet == nil || applicationSet.ID != id {
+			applicationSet = &argoApplicationSet{
+				ID:             id,
+				Name:           name,
+				Namespace:      namespace,
+				RolloutSummary: map[string]int{},
+				Hubs:           []hubRollout{},
+			}
+			applicationSets = append(applicationSets, applicationSet)
+		}
+		// the ApplicationSet isn't reported by any hub yet
+		if hub == "" || !scope.AllowsHub(hub) {
+			continue
+		}
+		rollout, err := newHubRollout(hub, status)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the applicationset %s/%s of the hub %s: %w",
+				namespace, name, hub, err)
+		}
+		applicationSet.Hubs = append(applicationSet.Hubs, *rollout)
+		applicationSet.RolloutSummary[rollout.Status]++
+	}
+	return applicationSets, rows.Err()
+}
+
+// newHubRollout summarizes the generated applications of the ApplicationSet on the hub
+func newHubRollout(hub string, statusJSON []byte) (*hubRollout, error) {
+	status := &applicationSetStatus{}
+	if len(statusJSON) > 0 {
+		if err := json.Unmarshal(statusJSON, status); err != nil {
+			return nil, err
+		}
+	}
+
+	rollout := &hubRollout{LeafHubName: hub, Applications: len(status.Resources)}
+	degraded := false
+	for _, resource := range status.Resources {
+		switch resource.Health.Status {
+		case "Healthy":
+			rollout.Healthy++
+		case "Degraded", "Missing":
+			degraded = true
+		}
+		if resource.Status == "Synced" {
+			rollout.Synced++
+		}
+	}
+	for _, condition := range status.Conditions {
+		if condition.Type == "ErrorOccurred" && condition.Status == "True" {
+			rollout.Message = condition.Message
+		}
+	}
+
+	switch {
+	case rollout.Message != "":
+		rollout.Status = RolloutError
+	case degraded:
+		rollout.Status = RolloutDegraded
+	case rollout.Healthy == rollout.Applications && rollout.Synced == rollout.Applications:
+		rollout.Status = RolloutHealthy
+	default:
+		rollout.Status = RolloutProgressing
+	}
+	return rollout, nil
+}
@@ -23,6 +23,8 @@ const (
 	UserKey = "user"
 	// GroupsKey - the key for groups slice of strings in context.
 	GroupsKey = "groups"
+	// TokenKey - the key for the bearer token of the user in context, it's used to review the rules of the user.
+	TokenKey = "token"
 )
 
 var errUnableToAppendCABundle = errors.New("unable to append CA Bundle")
@@ -123,6 +125,7 @@ func setAuthenticatedUser(ginCtx *gin.Context, authorizationHeader string, clust
 
 	ginCtx.Set(UserKey, user.Name)
 	ginCtx.Set(GroupsKey, user.Groups)
+	ginCtx.Set(TokenKey, strings.TrimSpace(strings.TrimPrefix(authorizationHeader, "Bearer")))
 
 	fmt.Fprintf(gin.DefaultWriter, "got authenticated user: %v\n", user.Name)
 	fmt.Fprintf(gin.DefaultWriter, "user groups: %v\n", user.Groups)
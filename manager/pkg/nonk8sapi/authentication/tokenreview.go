@@ -0,0 +1,137 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package authentication
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+)
+
+// the maximum number of the cached token reviews, the cache is cleared once it's full
+const maxCachedTokenReviews = 10000
+
+type tokenReviewResult struct {
+	authenticated bool
+	user          string
+	groups        []string
+	expiration    time.Time
+}
+
+// tokenReviewCache caches the results of the token reviews by the hash of the token, so a client sending many
+// requests with the same token doesn't create a token review for each of them
+type tokenReviewCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	results map[string]*tokenReviewResult
+}
+
+func (c *tokenReviewCache) get(key string) (*tokenReviewResult, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	result, found := c.results[key]
+	if !found || time.Now().After(result.expiration) {
+		return nil, false
+	}
+	return result, true
+}
+
+func (c *tokenReviewCache) set(key string, result *tokenReviewResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.results) >= maxCachedTokenReviews {
+		c.results = map[string]*tokenReviewResult{}
+	}
+	result.expiration = time.Now().Add(c.ttl)
+	c.results[key] = result
+}
+
+// TokenReviewAuthentication middleware validates the bearer token of the request with the TokenReview of the
+// kubernetes API server, the token is accepted if it's authenticated for any of the audiences or for the API server
+// if the audiences are empty. The results are cached for the cacheTTL, they aren't cached if it's 0
+func TokenReviewAuthentication(tokenReviews authenticationv1client.TokenReviewInterface, audiences []string,
+	cacheTTL time.Duration,
+) gin.HandlerFunc {
+	cache := &tokenReviewCache{ttl: cacheTTL, results: map[string]*tokenReviewResult{}}
+
+	return func(ginCtx *gin.Context) {
+		token := bearerToken(ginCtx)
+		if token == "" {
+			ginCtx.Header("WWW-Authenticate", "Bearer")
+			ginCtx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		tokenHash := sha256.Sum256([]byte(token))
+		cacheKey := hex.EncodeToString(tokenHash[:])
+		result, found := cache.get(cacheKey)
+		if !found {
+			var err error
+			result, err = reviewToken(ginCtx, tokenReviews, token, audiences)
+			if err != nil {
+				fmt.Fprintf(gin.DefaultWriter, "failed to review the token: %v\n", err)
+				ginCtx.AbortWithStatus(http.StatusServiceUnavailable)
+				return
+			}
+			if cacheTTL > 0 {
+				cache.set(cacheKey, result)
+			}
+		}
+
+		if !result.authenticated {
+			ginCtx.Header("WWW-Authenticate", "Bearer")
+			ginCtx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		ginCtx.Set(UserKey, result.user)
+		ginCtx.Set(GroupsKey, result.groups)
+		ginCtx.Set(TokenKey, token)
+		ginCtx.Next()
+	}
+}
+
+func reviewToken(ginCtx *gin.Context, tokenReviews authenticationv1client.TokenReviewInterface, token string,
+	audiences []string,
+) (*tokenReviewResult, error) {
+	review, err := tokenReviews.Create(ginCtx.Request.Context(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: audiences,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if !review.Status.Authenticated {
+		fmt.Fprintf(gin.DefaultWriter, "the token isn't authenticated: %s\n", review.Status.Error)
+		return &tokenReviewResult{}, nil
+	}
+
+	fmt.Fprintf(gin.DefaultWriter, "got authenticated user: %v\n", review.Status.User.Username)
+	return &tokenReviewResult{
+		authenticated: true,
+		user:          review.Status.User.Username,
+		groups:        review.Status.User.Groups,
+	}, nil
+}
+
+// bearerToken returns the token of the Authorization header, or the access token forwarded by the oauth-proxy
+func bearerToken(ginCtx *gin.Context) string {
+	authorizationHeader := ginCtx.GetHeader("Authorization")
+	if token, found := strings.CutPrefix(authorizationHeader, "Bearer "); found {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(ginCtx.GetHeader("X-Forwarded-Access-Token"))
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package authentication
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestTokenReviewAuthentication(t *testing.T) {
+	reviews := 0
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			reviews++
+			review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+			switch review.Spec.Token {
+			case "valid-token":
+				assert.Equal(t, []string{"global-hub"}, review.Spec.Audiences)
+				review.Status = authenticationv1.TokenReviewStatus{
+					Authenticated: true,
+					User: authenticationv1.UserInfo{
+						Username: "alice",
+						Groups:   []string{"admins"},
+					},
+				}
+			case "failed-token":
+				return true, nil, errors.New("the API server is unavailable")
+			default:
+				review.Status = authenticationv1.TokenReviewStatus{Error: "invalid token"}
+			}
+			return true, review, nil
+		})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TokenReviewAuthentication(client.AuthenticationV1().TokenReviews(), []string{"global-hub"},
+		time.Minute))
+	router.GET("/user", func(ginCtx *gin.Context) {
+		ginCtx.JSON(http.StatusOK, gin.H{"user": ginCtx.GetString(UserKey), "groups": ginCtx.GetStringSlice(GroupsKey)})
+	})
+
+	request := func(header, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/user", nil)
+		assert.NoError(t, err)
+		if header != "" {
+			req.Header.Set(header, token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("Authorization", "Bearer valid-token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user":"alice","groups":["admins"]}`, w.Body.String())
+
+	// the token forwarded by the oauth-proxy is accepted, and the review of the same token is cached
+	w = request("X-Forwarded-Access-Token", "valid-token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, reviews)
+
+	w = request("Authorization", "Bearer invalid-token")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = request("", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, 2, reviews)
+
+	w = request("Authorization", "Bearer failed-token")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	w = request("Authorization", "Bearer failed-token")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, 4, reviews)
+}
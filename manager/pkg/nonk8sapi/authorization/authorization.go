@@ -0,0 +1,402 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package authorization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authentication"
+)
+
+// Group is the API group of the virtual resources of the global hub API, they aren't served by the kubernetes API
+// server, but the access to them is granted by the kubernetes RBAC like other resources, e.g.
+//
+//	rules:
+//	- apiGroups: ["globalhub.open-cluster-management.io"]
+//	  resources: ["managedclusters", "compliance"]
+//	  verbs: ["get", "list"]
+//
+// The ClusterRoleBinding grants the access to the resources of all managed hubs, the RoleBinding in the namespace of
+// a managed hub grants the access to the resources of the hub, and the resourceNames of the Role in the namespace of
+// the hub restrict the access to the managed clusters of the names.
+const Group = "globalhub.open-cluster-management.io"
+
+// the virtual resources of the global hub API
+const (
+	ResourceManagedClusters = "managedclusters"
+	ResourcePolicies        = "policies"
+	ResourceSubscriptions   = "subscriptions"
+	ResourceCompliance      = "compliance"
+	ResourceSnapshots       = "snapshots"
+	ResourceGraphQL         = "graphql"
+	ResourcePurges          = "purges"
+	ResourceApplications    = "applications"
+	ResourcePlacements      = "placements"
+)
+
+// ScopeKey - the key for the authorized scope of the request in context.
+const ScopeKey = "authorizationScope"
+
+// the maximum number of the cached scopes, the cache is cleared once it's full
+const maxCachedScopes = 10000
+
+// RulesReviewsFunc returns the client of the SelfSubjectRulesReview which is authenticated by the token of the user
+type RulesReviewsFunc func(token string) (authorizationv1client.SelfSubjectRulesReviewInterface, error)
+
+// HubsFunc returns the names of the managed hubs
+type HubsFunc func(ctx context.Context) ([]string, error)
+
+// Scope is the managed hubs and clusters whose resources the user is allowed to access
+type Scope struct {
+	// All is true if the user is allowed to access the resources of all managed hubs
+	All bool
+	// Hubs are the managed hubs that the user is allowed to access all the managed clusters of
+	Hubs []string
+	// Clusters are the names of the allowed managed clusters of each hub, the user isn't allowed to access the other
+	// managed clusters of the hub
+	Clusters map[string][]string
+}
+
+// GetScope returns the authorized scope of the request, it's nil if the authorization isn't enabled
+func GetScope(ginCtx *gin.Context) *Scope {
+	scope, found := ginCtx.Get(ScopeKey)
+	if !found {
+		return nil
+	}
+	return scope.(*Scope)
+}
+
+// Empty returns true if the user isn't allowed to access any resource
+func (s *Scope) Empty() bool {
+	return s != nil && !s.All && len(s.Hubs) == 0 && len(s.Clusters) == 0
+}
+
+// Allows returns true if the user is allowed to access the managed cluster of the hub, everything is allowed by the
+// nil scope
+func (s *Scope) Allows(hub, cluster string) bool {
+	if s == nil || s.All {
+		return true
+	}
+	for _, allowedHub := range s.Hubs {
+		if allowedHub == hub {
+			return true
+		}
+	}
+	for _, allowedCluster := range s.Clusters[hub] {
+		if allowedCluster == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsHub returns true if the user is allowed to access all the managed clusters of the hub, the aggregates of the
+// hub are only returned to these users
+func (s *Scope) AllowsHub(hub string) bool {
+	if s == nil || s.All {
+		return true
+	}
+	for _, allowedHub := range s.Hubs {
+		if allowedHub == hub {
+			return true
+		}
+	}
+	return false
+}
+
+// Condition returns the SQL condition and its arguments to query the resources of the allowed hubs and clusters, the
+// hubColumn and clusterColumn are the SQL expressions of the managed hub and the managed cluster name
+func (s *Scope) Condition(hubColumn, clusterColumn string) (string, []interface{}) {
+	if s == nil || s.All {
+		return "", []interface{}{}
+	}
+	conditions := []string{}
+	args := []interface{}{}
+	if len(s.Hubs) > 0 {
+		conditions = append(conditions, hubColumn+" IN ?")
+		args = append(args, s.Hubs)
+	}
+	hubs := make([]string, 0, len(s.Clusters))
+	for hub := range s.Clusters {
+		hubs = append(hubs, hub)
+	}
+	sort.Strings(hubs)
+	for _, hub := range hubs {
+		conditions = append(conditions, fmt.Sprintf("(%s = ? AND %s IN ?)", hubColumn, clusterColumn))
+		args = append(args, hub, s.Clusters[hub])
+	}
+	if len(conditions) == 0 {
+		return " AND FALSE", args
+	}
+	return " AND (" + strings.Join(conditions, " OR ") + ")", args
+}
+
+type scopeResult struct {
+	scope      *Scope
+	expiration time.Time
+}
+
+// Authorizer authorizes the requests of the users with the SubjectAccessReview of the kubernetes API server on the
+// virtual resources of the Group. The scopes of the users are cached for the cacheTTL, they aren't cached if it's 0
+type Authorizer struct {
+	subjectAccessReviews authorizationv1client.SubjectAccessReviewInterface
+	rulesReviews         RulesReviewsFunc
+	hubs                 HubsFunc
+	cacheTTL             time.Duration
+
+	mutex  sync.Mutex
+	scopes map[string]*scopeResult
+}
+
+func NewAuthorizer(subjectAccessReviews authorizationv1client.SubjectAccessReviewInterface,
+	rulesReviews RulesReviewsFunc, hubs HubsFunc, cacheTTL time.Duration,
+) *Authorizer {
+	return &Authorizer{
+		subjectAccessReviews: subjectAccessReviews,
+		rulesReviews:         rulesReviews,
+		hubs:                 hubs,
+		cacheTTL:             cacheTTL,
+		scopes:               map[string]*scopeResult{},
+	}
+}
+
+// Authorize middleware sets the scope of the hubs and clusters that the user is allowed to access the resource of the
+// verb, the request is forbidden if the user isn't allowed to access any of them. The handlers of the request filter
+// the returned resources by the scope
+func Authorize(authorizer *Authorizer, resource, verb string) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		scope, err := authorizer.Scope(ginCtx.Request.Context(), ginCtx.GetString(authentication.UserKey),
+			ginCtx.GetStringSlice(authentication.GroupsKey), ginCtx.GetString(authentication.TokenKey),
+			resource, verb)
+		if err != nil {
+			fmt.Fprintf(gin.DefaultWriter, "failed to authorize the user: %v\n", err)
+			ginCtx.AbortWithStatus(http.StatusServiceUnavailable)
+			return
+		}
+		if scope.Empty() {
+			forbid(ginCtx, resource, verb)
+			return
+		}
+		ginCtx.Set(ScopeKey, scope)
+		ginCtx.Next()
+	}
+}
+
+// AuthorizeAll middleware only allows the request if the user is allowed to access the resource of the verb of all
+// managed hubs, it authorizes the requests of the resources which aren't owned by a hub, e.g. the global policies
+func AuthorizeAll(authorizer *Authorizer, resource, verb string) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		allowed, err := authorizer.AllowedAll(ginCtx.Request.Context(), ginCtx.GetString(authentication.UserKey),
+			ginCtx.GetStringSlice(authentication.GroupsKey), resource, verb)
+		if err != nil {
+			fmt.Fprintf(gin.DefaultWriter, "failed to authorize the user: %v\n", err)
+			ginCtx.AbortWithStatus(http.StatusServiceUnavailable)
+			return
+		}
+		if !allowed {
+			forbid(ginCtx, resource, verb)
+			return
+		}
+		ginCtx.Set(ScopeKey, &Scope{All: true})
+		ginCtx.Next()
+	}
+}
+
+func forbid(ginCtx *gin.Context, resource, verb string) {
+	ginCtx.String(http.StatusForbidden, "user %q cannot %s resource %q in API group %q",
+		ginCtx.GetString(authentication.UserKey), verb, resource, Group)
+	ginCtx.Abort()
+}
+
+// Scope returns the hubs and clusters that the user is allowed to access the resource of the verb. The user is
+// allowed to access all hubs if the SubjectAccessReview without the namespace is allowed. Otherwise, the hubs and the
+// clusters are from the SelfSubjectRulesReview of the namespace of each hub: the hub is allowed by the rules without
+// the resourceNames, and the clusters of the hub are the resourceNames of the other rules
+func (a *Authorizer) Scope(ctx context.Context, user string, groups []string, token string, resource, verb string,
+) (*Scope, error) {
+	cacheKey := scopeCacheKey(user, groups, resource, verb, "scope")
+	if scope, found := a.cachedScope(cacheKey); found {
+		return scope, nil
+	}
+
+	scope, err := a.reviewScope(ctx, user, groups, token, resource, verb)
+	if err != nil {
+		return nil, err
+	}
+	if a.cacheTTL > 0 {
+		a.cacheScope(cacheKey, scope)
+	}
+	return scope, nil
+}
+
+// AllowedAll returns true if the user is allowed to access the resource of the verb of all managed hubs
+func (a *Authorizer) AllowedAll(ctx context.Context, user string, groups []string, resource, verb string,
+) (bool, error) {
+	cacheKey := scopeCacheKey(user, groups, resource, verb, "all")
+	if scope, found := a.cachedScope(cacheKey); found {
+		return scope.All, nil
+	}
+
+	allowed, err := a.review(ctx, user, groups, resource, verb, "")
+	if err != nil {
+		return false, err
+	}
+	if a.cacheTTL > 0 {
+		a.cacheScope(cacheKey, &Scope{All: allowed})
+	}
+	return allowed, nil
+}
+
+func (a *Authorizer) reviewScope(ctx context.Context, user string, groups []string, token string,
+	resource, verb string,
+) (*Scope, error) {
+	allowed, err := a.review(ctx, user, groups, resource, verb, "")
+	if err != nil || allowed {
+		return &Scope{All: allowed}, err
+	}
+
+	hubs, err := a.hubs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the managed hubs: %w", err)
+	}
+
+	// the rules can only be reviewed with the token of the user, otherwise each hub is reviewed by the
+	// SubjectAccessReview
+	var rulesReviews authorizationv1client.SelfSubjectRulesReviewInterface
+	if token != "" && a.rulesReviews != nil {
+		rulesReviews, err = a.rulesReviews(token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the client of the SelfSubjectRulesReview: %w", err)
+		}
+	}
+
+	scope := &Scope{Clusters: map[string][]string{}}
+	for _, hub := range hubs {
+		var allowed, complete bool
+		var clusters []string
+		if rulesReviews != nil {
+			allowed, clusters, complete, err = a.reviewRules(ctx, rulesReviews, resource, verb, hub)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// the rules are incomplete if they're evaluated by the other authorizers, e.g. the webhook
+		if !allowed && !complete {
+			allowed, err = a.review(ctx, user, groups, resource, verb, hub)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if allowed {
+			scope.Hubs = append(scope.Hubs, hub)
+			continue
+		}
+		if len(clusters) > 0 {
+			scope.Clusters[hub] = clusters
+		}
+	}
+	return scope, nil
+}
+
+// review returns true if the user is allowed to access the resource of the verb in the namespace, or of all
+// namespaces if the namespace is empty
+func (a *Authorizer) review(ctx context.Context, user string, groups []string, resource, verb, namespace string,
+) (bool, error) {
+	review, err := a.subjectAccessReviews.Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Group:     Group,
+				Resource:  resource,
+			},
+			User:   user,
+			Groups: groups,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to create the SubjectAccessReview: %w", err)
+	}
+	return review.Status.Allowed, nil
+}
+
+// reviewRules reviews the rules of the user in the namespace, it returns true if the user is allowed to access all the
+// resources of the verb in the namespace, the resourceNames of the other rules which allow the resource of the verb,
+// and whether the rules are complete
+func (a *Authorizer) reviewRules(ctx context.Context,
+	rulesReviews authorizationv1client.SelfSubjectRulesReviewInterface, resource, verb, namespace string,
+) (bool, []string, bool, error) {
+	review, err := rulesReviews.Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+		Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, nil, false, fmt.Errorf("failed to create the SelfSubjectRulesReview: %w", err)
+	}
+	if review.Status.Incomplete {
+		fmt.Fprintf(gin.DefaultWriter, "the rules of the namespace %s are incomplete: %s\n", namespace,
+			review.Status.EvaluationError)
+	}
+
+	names := []string{}
+	for _, rule := range review.Status.ResourceRules {
+		if !matches(rule.APIGroups, Group) || !matches(rule.Resources, resource) || !matches(rule.Verbs, verb) {
+			continue
+		}
+		if len(rule.ResourceNames) == 0 {
+			return true, nil, !review.Status.Incomplete, nil
+		}
+		names = append(names, rule.ResourceNames...)
+	}
+	return false, names, !review.Status.Incomplete, nil
+}
+
+func matches(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func scopeCacheKey(user string, groups []string, resource, verb, kind string) string {
+	sortedGroups := append([]string{}, groups...)
+	sort.Strings(sortedGroups)
+	key := sha256.Sum256([]byte(strings.Join([]string{
+		user, strings.Join(sortedGroups, ","), resource, verb, kind,
+	}, "\n")))
+	return hex.EncodeToString(key[:])
+}
+
+func (a *Authorizer) cachedScope(key string) (*Scope, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	result, found := a.scopes[key]
+	if !found || time.Now().After(result.expiration) {
+		return nil, false
+	}
+	return result.scope, true
+}
+
+func (a *Authorizer) cacheScope(key string, scope *Scope) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if len(a.scopes) >= maxCachedScopes {
+		a.scopes = map[string]*scopeResult{}
+	}
+	a.scopes[key] = &scopeResult{scope: scope, expiration: time.Now().Add(a.cacheTTL)}
+}
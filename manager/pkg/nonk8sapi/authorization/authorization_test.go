@@ -0,0 +1,196 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package authorization
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authentication"
+)
+
+func TestAuthorize(t *testing.T) {
+	reviews := 0
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "subjectaccessreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			reviews++
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			attributes := review.Spec.ResourceAttributes
+			assert.Equal(t, Group, attributes.Group)
+			switch review.Spec.User {
+			case "admin":
+				review.Status.Allowed = attributes.Namespace == ""
+			case "hub1-viewer":
+				// the rules of the hub3 are incomplete, so it's allowed by the other authorizers
+				review.Status.Allowed = attributes.Namespace == "hub3" && attributes.Verb == "list"
+			case "failed":
+				return true, nil, errors.New("the API server is unavailable")
+			}
+			return true, review, nil
+		})
+	client.PrependReactor("create", "selfsubjectrulesreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview)
+			switch review.Spec.Namespace {
+			case "hub1":
+				review.Status.ResourceRules = []authorizationv1.ResourceRule{
+					{
+						APIGroups: []string{Group},
+						Resources: []string{ResourceManagedClusters, ResourceCompliance},
+						Verbs:     []string{"get", "list"},
+					},
+				}
+			case "hub2":
+				review.Status.ResourceRules = []authorizationv1.ResourceRule{
+					{
+						APIGroups:     []string{Group},
+						Resources:     []string{ResourceManagedClusters, ResourceCompliance},
+						Verbs:         []string{"get", "list"},
+						ResourceNames: []string{"mc3"},
+					},
+					{
+						APIGroups:     []string{"*"},
+						Resources:     []string{"*"},
+						Verbs:         []string{"*"},
+						ResourceNames: []string{"mc4"},
+					},
+					{
+						APIGroups: []string{""},
+						Resources: []string{"configmaps"},
+						Verbs:     []string{"list"},
+					},
+				}
+			case "hub3":
+				review.Status.Incomplete = true
+				review.Status.EvaluationError = "the webhook authorizer doesn't list the rules"
+			}
+			return true, review, nil
+		})
+
+	authorizer := NewAuthorizer(client.AuthorizationV1().SubjectAccessReviews(),
+		func(token string) (authorizationv1client.SelfSubjectRulesReviewInterface, error) {
+			// the rules are reviewed with the token of the user
+			if token == "hub1-viewer-token" {
+				return client.AuthorizationV1().SelfSubjectRulesReviews(), nil
+			}
+			// the other users don't have any rule
+			otherClient := fake.NewSimpleClientset()
+			otherClient.PrependReactor("create", "selfsubjectrulesreviews",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, action.(k8stesting.CreateAction).GetObject(), nil
+				})
+			return otherClient.AuthorizationV1().SelfSubjectRulesReviews(), nil
+		},
+		func(ctx context.Context) ([]string, error) {
+			return []string{"hub1", "hub2", "hub3"}, nil
+		}, time.Minute)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(ginCtx *gin.Context) {
+		ginCtx.Set(authentication.UserKey, ginCtx.GetHeader("User"))
+		ginCtx.Set(authentication.GroupsKey, []string{"users"})
+		ginCtx.Set(authentication.TokenKey, ginCtx.GetHeader("User")+"-token")
+	})
+	router.GET("/managedclusters", Authorize(authorizer, ResourceManagedClusters, "list"),
+		func(ginCtx *gin.Context) {
+			ginCtx.JSON(http.StatusOK, GetScope(ginCtx))
+		})
+	router.PATCH("/managedcluster", Authorize(authorizer, ResourceManagedClusters, "patch"),
+		func(ginCtx *gin.Context) {
+			ginCtx.JSON(http.StatusOK, GetScope(ginCtx))
+		})
+	router.GET("/policies", AuthorizeAll(authorizer, ResourcePolicies, "list"),
+		func(ginCtx *gin.Context) {
+			ginCtx.JSON(http.StatusOK, GetScope(ginCtx))
+		})
+
+	request := func(method, path, user string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(method, path, nil)
+		assert.NoError(t, err)
+		req.Header.Set("User", user)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "/managedclusters", "admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"All":true,"Hubs":null,"Clusters":null}`, w.Body.String())
+	w = request(http.MethodGet, "/policies", "admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	reviews = 0
+	w = request(http.MethodGet, "/managedclusters", "hub1-viewer")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"All":false,"Hubs":["hub1","hub3"],"Clusters":{"hub2":["mc3","mc4"]}}`, w.Body.String())
+	// the hubs are reviewed by the rules, the SubjectAccessReview is only created for all the hubs and the hub3
+	assert.Equal(t, 2, reviews)
+	// the cluster of the hub2 is allowed by the rules even if the user isn't allowed to patch the clusters of the hub1
+	w = request(http.MethodPatch, "/managedcluster", "hub1-viewer")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"All":false,"Hubs":null,"Clusters":{"hub2":["mc4"]}}`, w.Body.String())
+	w = request(http.MethodGet, "/policies", "hub1-viewer")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the scope of the same user is cached
+	reviews = 0
+	w = request(http.MethodGet, "/managedclusters", "hub1-viewer")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = request(http.MethodGet, "/policies", "hub1-viewer")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, 0, reviews)
+
+	w = request(http.MethodGet, "/managedclusters", "nobody")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `user "nobody" cannot list resource "managedclusters"`)
+
+	w = request(http.MethodGet, "/managedclusters", "failed")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	w = request(http.MethodGet, "/policies", "failed")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestScope(t *testing.T) {
+	var scope *Scope
+	assert.True(t, scope.Allows("hub1", "mc1"))
+	assert.True(t, scope.AllowsHub("hub1"))
+	assert.False(t, scope.Empty())
+	condition, args := scope.Condition("leaf_hub_name", "cluster_name")
+	assert.Equal(t, "", condition)
+	assert.Empty(t, args)
+
+	scope = &Scope{Hubs: []string{"hub1"}, Clusters: map[string][]string{"hub2": {"mc3"}, "hub3": {"mc5"}}}
+	assert.True(t, scope.Allows("hub1", "mc1"))
+	assert.True(t, scope.Allows("hub2", "mc3"))
+	assert.False(t, scope.Allows("hub2", "mc4"))
+	assert.False(t, scope.Allows("hub4", "mc3"))
+	assert.True(t, scope.AllowsHub("hub1"))
+	// the hub isn't allowed if only some of its clusters are allowed
+	assert.False(t, scope.AllowsHub("hub2"))
+	condition, args = scope.Condition("leaf_hub_name", "cluster_name")
+	assert.Equal(t, " AND (leaf_hub_name IN ? OR (leaf_hub_name = ? AND cluster_name IN ?) OR "+
+		"(leaf_hub_name = ? AND cluster_name IN ?))", condition)
+	assert.Equal(t, []interface{}{
+		[]string{"hub1"}, "hub2", []string{"mc3"}, "hub3", []string{"mc5"},
+	}, args)
+
+	scope = &Scope{Clusters: map[string][]string{}}
+	assert.True(t, scope.Empty())
+	condition, _ = scope.Condition("leaf_hub_name", "cluster_name")
+	assert.Equal(t, " AND FALSE", condition)
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package compliance
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/snapshot"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const serverInternalErrorMsg = "internal error"
+
+// the current compliance state of each policy and cluster, the columns are the same as the snapshot.ComplianceSQL
+var complianceSQL = `
+	SELECT c.policy_id, p.policy_name, COALESCE(c.cluster_id::text, ''), c.cluster_name, c.leaf_hub_name,
+		c.compliance::text, NULL::timestamp AS observed_at
+	FROM local_status.compliance c
+	LEFT JOIN local_spec.policies p ON p.policy_id = c.policy_id
+	WHERE (@hub = '' OR c.leaf_hub_name = @hub)
+		AND (@policy = '' OR c.policy_id::text = @policy)
+		AND (@compliance = '' OR c.compliance::text = @compliance)
+	ORDER BY c.leaf_hub_name, p.policy_name, c.cluster_name`
+
+// the policy is found even if it's deleted, so its compliance history is still available
+var policySQL = `
+	SELECT policy_name, leaf_hub_name FROM local_spec.policies WHERE policy_id = @policy`
+
+type clusterCompliance struct {
+	ClusterID   string `json:"clusterId"`
+	ClusterName string `json:"clusterName"`
+	Compliance  string `json:"compliance"`
+	// ObservedAt is the time when the compliance state was reported, it's only set for the historical compliance
+	ObservedAt *time.Time `json:"observedAt,omitempty"`
+}
+
+type policyCompliance struct {
+	PolicyID    string `json:"policyId"`
+	PolicyName  string `json:"policyName"`
+	LeafHubName string `json:"leafHubName"`
+	// Compliance is the overall compliance state of the policy on the clusters
+	Compliance string `json:"compliance"`
+	// Summary is the count of the clusters in each compliance state
+	Summary  map[string]int      `json:"summary"`
+	Clusters []clusterCompliance `json:"clusters,omitempty"`
+}
+
+type policyComplianceList struct {
+	// Time is the point in time of the historical compliance, it's empty for the current compliance
+	Time     *time.Time         `json:"time,omitempty"`
+	Policies []policyCompliance `json:"policies"`
+}
+
+// ListCompliance godoc
+// @summary list policy compliance
+// @description list the compliance state and the cluster summary of every policy, currently or as of the given time
+// @accept json
+// @produce json
+// @param        time           query     string  false  "the point in time in RFC3339 format, e.g. 2024-05-21T02:00:00Z"
+// @param        leafHubName    query     string  false  "only return the policies of the managed hub"
+// @param        compliance     query     string  false  "only count the clusters of the compliance state, e.g. non_compliant"
+// @success      200  {object}    policyComplianceList
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      404
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /compliance [get]
+func ListCompliance() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		pointInTime, ok := parseTime(ginCtx)
+		if !ok {
+			return
+		}
+
+		policies, err := queryCompliance(pointInTime, ginCtx.Query("leafHubName"), "", ginCtx.Query("compliance"),
+			authorization.GetScope(ginCtx))
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying compliance: %v\n", err)
+			return
+		}
+
+		list := &policyComplianceList{Time: pointInTime, Policies: []policyCompliance{}}
+		for _, policy := range policies {
+			policy.Clusters = nil
+			list.Policies = append(list.Policies, *policy)
+		}
+		ginCtx.JSON(http.StatusOK, list)
+	}
+}
+
+// GetPolicyCompliance godoc
+// @summary get policy compliance
+// @description get the compliance state of the policy on each cluster, currently or as of the given time
+// @accept json
+// @produce json
+// @param        policyID       path      string  true   "Policy ID"
+// @param        time           query     string  false  "the point in time in RFC3339 format, e.g. 2024-05-21T02:00:00Z"
+// @param        compliance     query     string  false  "only return the clusters of the compliance state, e.g. non_compliant"
+// @success      200  {object}    policyCompliance
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      404
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /compliance/{policyID} [get]
+func GetPolicyCompliance() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		policyID := ginCtx.Param("policyID")
+		if _, err := uuid.Parse(policyID); err != nil {
+			ginCtx.String(http.StatusBadRequest, "invalid policy ID: %s", policyID)
+			return
+		}
+		pointInTime, ok := parseTime(ginCtx)
+		if !ok {
+			return
+		}
+
+		policies, err := queryCompliance(pointInTime, "", policyID, ginCtx.Query("compliance"),
+			authorization.GetScope(ginCtx))
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying compliance of the policy %s: %v\n", policyID, err)
+			return
+		}
+		if len(policies) > 0 {
+			ginCtx.JSON(http.StatusOK, policies[0])
+			return
+		}
+
+		// the policy isn't applied to any cluster
+		policy := &policyCompliance{PolicyID: policyID, Summary: map[string]int{}, Clusters: []clusterCompliance{}}
+		err = database.GetReadonlyGorm().Raw(policySQL, map[string]interface{}{"policy": policyID}).Row().Scan(
+			&policy.PolicyName, &policy.LeafHubName)
+		if errors.Is(err, sql.ErrNoRows) {
+			ginCtx.String(http.StatusNotFound, "policy %s is not found", policyID)
+			return
+		}
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying policy %s: %v\n", policyID, err)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, policy)
+	}
+}
+
+// parseTime returns the point in time of the historical compliance, it's nil if the current compliance is requested
+func parseTime(ginCtx *gin.Context) (*time.Time, bool) {
+	timeQuery := ginCtx.Query("time")
+	if timeQuery == "" {
+		return nil, true
+	}
+	pointInTime, err := time.Parse(time.RFC3339, timeQuery)
+	if err != nil {
+		ginCtx.String(http.StatusBadRequest, "time must be in RFC3339 format: %s", err.Error())
+		return nil, false
+	}
+	if pointInTime.After(time.Now()) {
+		ginCtx.String(http.StatusBadRequest, "time %s is in the future", pointInTime.Format(time.RFC3339))
+		return nil, false
+	}
+	return &pointInTime, true
+}
+
+// queryCompliance returns the compliance of the policies in the order of the managed hub and the policy name, only
+// the clusters of the scope are returned and counted
+func queryCompliance(pointInTime *time.Time, hub, policyID, complianceState string, scope *authorization.Scope,
+) ([]*policyCompliance, error) {
+	args := map[string]interface{}{
+		"hub":        hub,
+		"policy":     policyID,
+		"compliance": complianceState,
+	}
+	query := complianceSQL
+	if pointInTime != nil {
+		args["time"] = pointInTime.UTC()
+		query = snapshot.ComplianceSQL
+	}
+	fmt.Fprintf(gin.DefaultWriter, "compliance query: %v\n", args)
+
+	rows, err := database.GetReadonlyGorm().Raw(query, args).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []*policyCompliance{}
+	policyIndexes := map[string]int{}
+	for rows.Next() {
+		var policyID, leafHubName string
+		var policyName, clusterName *string
+		cluster := clusterCompliance{}
+		if err := rows.Scan(&policyID, &policyName, &cluster.ClusterID, &clusterName, &leafHubName,
+			&cluster.Compliance, &cluster.ObservedAt); err != nil {
+			return nil, err
+		}
+		if clusterName != nil {
+			cluster.ClusterName = *clusterName
+		}
+		if !scope.Allows(leafHubName, cluster.ClusterName) {
+			continue
+		}
+
+		index, found := policyIndexes[policyID]
+		if !found {
+			policy := &policyCompliance{
+				PolicyID:    policyID,
+				LeafHubName: leafHubName,
+				Summary:     map[string]int{},
+				Clusters:    []clusterCompliance{},
+			}
+			if policyName != nil {
+				policy.PolicyName = *policyName
+			}
+			index = len(policies)
+			policyIndexes[policyID] = index
+			policies = append(policies, policy)
+		}
+		policy := policies[index]
+		policy.Clusters = append(policy.Clusters, cluster)
+		policy.Summary[cluster.Compliance]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, policy := range policies {
+		policy.Compliance = overallCompliance(policy.Summary)
+	}
+	return policies, nil
+}
+
+// overallCompliance is non_compliant if any cluster is non compliant, then pending, then unknown, otherwise compliant
+func overallCompliance(summary map[string]int) string {
+	for _, state := range []string{
+		string(database.NonCompliant), string(database.Pending), string(database.Unknown),
+	} {
+		if summary[state] > 0 {
+			return state
+		}
+	}
+	return string(database.Compliant)
+}
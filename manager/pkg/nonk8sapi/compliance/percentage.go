@@ -0,0 +1,164 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package compliance
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+// the default range of the percentages if the from isn't set
+const defaultPercentageRange = 90 * 24 * time.Hour
+
+// the daily percentages of the policies or the managed hubs, ordered by the policy or the hub, then the day
+var compliancePercentagesSQL = `
+	SELECT c.scope_key, c.compliance_date, c.compliant, c.total, c.percentage
+	FROM history.compliance_percentages c
+	WHERE c.scope = @scope AND c.compliance_date >= @from AND c.compliance_date <= @to
+		AND (@key = '' OR c.scope_key = @key)
+		%s
+	ORDER BY c.scope_key, c.compliance_date`
+
+// the percentage of the policy is summed up over the managed hubs, so it's only allowed if the clusters of all the
+// hubs of the policy on the day are allowed
+var policyScopeCondition = `AND NOT EXISTS (
+		SELECT 1 FROM history.compliance_rollups r
+		WHERE r.period = 'day' AND r.period_start = c.compliance_date AND r.policy_id::text = c.scope_key
+			AND r.leaf_hub_name NOT IN @hubs)`
+
+type compliancePercentagePoint struct {
+	Date string `json:"date"`
+	// Compliant is the number of the compliant clusters, a cluster is counted once for each of its policies
+	Compliant  int     `json:"compliant"`
+	Total      int     `json:"total"`
+	Percentage float64 `json:"percentage"`
+}
+
+type compliancePercentageSeries struct {
+	// Key is the policy ID or the managed hub name
+	Key    string                      `json:"key"`
+	Points []compliancePercentagePoint `json:"points"`
+}
+
+type compliancePercentages struct {
+	GroupBy string                       `json:"groupBy"`
+	From    string                       `json:"from"`
+	To      string                       `json:"to"`
+	Series  []compliancePercentageSeries `json:"series"`
+}
+
+// GetCompliancePercentages godoc
+// @summary get compliance percentages
+// @description get the daily percentage of the compliant clusters of each policy over the managed hubs, or of each
+// @description managed hub over its policies. They're computed by the compliance rollup job
+// @accept json
+// @produce json
+// @param        groupBy        query     string  false  "policy or hub, the default is policy"
+// @param        from           query     string  false  "the first day in YYYY-MM-DD format, the default is 90 days ago"
+// @param        to             query     string  false  "the last day in YYYY-MM-DD format, the default is today"
+// @param        leafHubName    query     string  false  "only get the percentages of the managed hub if the groupBy is hub"
+// @param        policyID       query     string  false  "only get the percentages of the policy if the groupBy is policy"
+// @success      200  {object}    compliancePercentages
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /compliancepercentages [get]
+func GetCompliancePercentages() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		groupBy := ginCtx.DefaultQuery("groupBy", database.ComplianceScopePolicy)
+		key := ""
+		switch groupBy {
+		case database.ComplianceScopePolicy:
+			key = ginCtx.Query("policyID")
+			if key != "" {
+				if _, err := uuid.Parse(key); err != nil {
+					ginCtx.String(http.StatusBadRequest, "invalid policy ID: %s", key)
+					return
+				}
+			}
+		case database.ComplianceScopeHub:
+			key = ginCtx.Query("leafHubName")
+		default:
+			ginCtx.String(http.StatusBadRequest, "groupBy must be %s or %s", database.ComplianceScopePolicy,
+				database.ComplianceScopeHub)
+			return
+		}
+
+		from, to, ok := parseDateRange(ginCtx, defaultPercentageRange)
+		if !ok {
+			return
+		}
+
+		percentages := &compliancePercentages{
+			GroupBy: groupBy,
+			From:    from.Format(dateFormat),
+			To:      to.Format(dateFormat),
+			Series:  []compliancePercentageSeries{},
+		}
+		args := map[string]interface{}{
+			"scope": groupBy,
+			"from":  percentages.From,
+			"to":    percentages.To,
+			"key":   key,
+		}
+		// the percentages are aggregated over the clusters, so only the hubs whose clusters are all allowed are counted
+		scopeCondition := ""
+		if scope := authorization.GetScope(ginCtx); scope != nil && !scope.All {
+			if len(scope.Hubs) == 0 {
+				ginCtx.JSON(http.StatusOK, percentages)
+				return
+			}
+			scopeCondition = "AND c.scope_key IN @hubs"
+			if groupBy == database.ComplianceScopePolicy {
+				scopeCondition = policyScopeCondition
+			}
+			args["hubs"] = scope.Hubs
+		}
+		fmt.Fprintf(gin.DefaultWriter, "compliance percentages query: %v\n", args)
+
+		rows, err := database.GetReadonlyGorm().Raw(fmt.Sprintf(compliancePercentagesSQL, scopeCondition),
+			args).Rows()
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying compliance percentages: %v\n", err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var scopeKey string
+			var date time.Time
+			point := compliancePercentagePoint{}
+			if err := rows.Scan(&scopeKey, &date, &point.Compliant, &point.Total, &point.Percentage); err != nil {
+				ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+				fmt.Fprintf(gin.DefaultWriter, "error in scanning compliance percentages: %v\n", err)
+				return
+			}
+			point.Date = date.Format(dateFormat)
+			last := len(percentages.Series) - 1
+			if last < 0 || percentages.Series[last].Key != scopeKey {
+				percentages.Series = append(percentages.Series, compliancePercentageSeries{Key: scopeKey})
+				last++
+			}
+			percentages.Series[last].Points = append(percentages.Series[last].Points, point)
+		}
+		if err := rows.Err(); err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying compliance percentages: %v\n", err)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, percentages)
+	}
+}
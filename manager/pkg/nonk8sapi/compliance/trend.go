@@ -0,0 +1,178 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package compliance
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const dateFormat = "2006-01-02"
+
+// the default range of the trend if the from isn't set
+var defaultTrendRange = map[string]time.Duration{
+	database.RollupPeriodDay:  90 * 24 * time.Hour,
+	database.RollupPeriodWeek: 52 * 7 * 24 * time.Hour,
+}
+
+// the number of the clusters in each compliance state of the period, they're summed up over the policies
+var complianceTrendSQL = `
+	SELECT period_start, SUM(compliant), SUM(non_compliant), SUM(pending), SUM(unknown)
+	FROM history.compliance_rollups
+	WHERE period = @period AND period_start >= @from AND period_start <= @to
+		AND (@hub = '' OR leaf_hub_name = @hub)
+		AND (@policy = '' OR policy_id::text = @policy)
+		%s
+	GROUP BY period_start
+	ORDER BY period_start`
+
+type complianceTrendPoint struct {
+	// PeriodStart is the first day of the period, e.g. the Monday of the week
+	PeriodStart string `json:"periodStart"`
+	// Summary is the count of the clusters in each compliance state, a cluster of a weekly point is counted once by
+	// its worst compliance state in the week
+	Summary map[string]int `json:"summary"`
+}
+
+type complianceTrend struct {
+	Period string                 `json:"period"`
+	From   string                 `json:"from"`
+	To     string                 `json:"to"`
+	Points []complianceTrendPoint `json:"points"`
+}
+
+// GetComplianceTrend godoc
+// @summary get compliance trend
+// @description get the daily or weekly count of the clusters in each compliance state, it's rolled up from the
+// @description compliance history by the scheduled job, so the trend is kept longer than the history
+// @accept json
+// @produce json
+// @param        period         query     string  false  "the period of the points, day or week, the default is day"
+// @param        from           query     string  false  "the first day in YYYY-MM-DD format, the default is 90 days or 52 weeks ago"
+// @param        to             query     string  false  "the last day in YYYY-MM-DD format, the default is today"
+// @param        leafHubName    query     string  false  "only count the clusters of the managed hub"
+// @param        policyID       query     string  false  "only count the clusters of the policy"
+// @success      200  {object}    complianceTrend
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /compliancetrend [get]
+func GetComplianceTrend() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		period := ginCtx.DefaultQuery("period", database.RollupPeriodDay)
+		if period != database.RollupPeriodDay && period != database.RollupPeriodWeek {
+			ginCtx.String(http.StatusBadRequest, "period must be %s or %s", database.RollupPeriodDay,
+				database.RollupPeriodWeek)
+			return
+		}
+		policyID := ginCtx.Query("policyID")
+		if policyID != "" {
+			if _, err := uuid.Parse(policyID); err != nil {
+				ginCtx.String(http.StatusBadRequest, "invalid policy ID: %s", policyID)
+				return
+			}
+		}
+
+		from, to, ok := parseDateRange(ginCtx, defaultTrendRange[period])
+		if !ok {
+			return
+		}
+
+		trend := &complianceTrend{
+			Period: period,
+			From:   from.Format(dateFormat),
+			To:     to.Format(dateFormat),
+			Points: []complianceTrendPoint{},
+		}
+		args := map[string]interface{}{
+			"period": period,
+			"from":   trend.From,
+			"to":     trend.To,
+			"hub":    ginCtx.Query("leafHubName"),
+			"policy": policyID,
+		}
+		// the rollups are aggregated over the clusters, so only the hubs whose clusters are all allowed are counted
+		scopeCondition := ""
+		if scope := authorization.GetScope(ginCtx); scope != nil && !scope.All {
+			if len(scope.Hubs) == 0 {
+				ginCtx.JSON(http.StatusOK, trend)
+				return
+			}
+			scopeCondition = "AND leaf_hub_name IN @hubs"
+			args["hubs"] = scope.Hubs
+		}
+		fmt.Fprintf(gin.DefaultWriter, "compliance trend query: %v\n", args)
+
+		rows, err := database.GetReadonlyGorm().Raw(fmt.Sprintf(complianceTrendSQL, scopeCondition), args).Rows()
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying compliance trend: %v\n", err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var periodStart time.Time
+			var compliant, nonCompliant, pending, unknown int
+			if err := rows.Scan(&periodStart, &compliant, &nonCompliant, &pending, &unknown); err != nil {
+				ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+				fmt.Fprintf(gin.DefaultWriter, "error in scanning compliance trend: %v\n", err)
+				return
+			}
+			trend.Points = append(trend.Points, complianceTrendPoint{
+				PeriodStart: periodStart.Format(dateFormat),
+				Summary: map[string]int{
+					string(database.Compliant):    compliant,
+					string(database.NonCompliant): nonCompliant,
+					string(database.Pending):      pending,
+					string(database.Unknown):      unknown,
+				},
+			})
+		}
+		if err := rows.Err(); err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying compliance trend: %v\n", err)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, trend)
+	}
+}
+
+// parseDateRange parses the from and the to query of the YYYY-MM-DD days, the to is today and the from is the
+// defaultRange before the to if they aren't set. It responds with 400 and returns false if they're invalid
+func parseDateRange(ginCtx *gin.Context, defaultRange time.Duration) (time.Time, time.Time, bool) {
+	to := time.Now().UTC()
+	if ginCtx.Query("to") != "" {
+		var err error
+		if to, err = time.Parse(dateFormat, ginCtx.Query("to")); err != nil {
+			ginCtx.String(http.StatusBadRequest, "to must be in YYYY-MM-DD format: %s", err.Error())
+			return to, to, false
+		}
+	}
+	from := to.Add(-defaultRange)
+	if ginCtx.Query("from") != "" {
+		var err error
+		if from, err = time.Parse(dateFormat, ginCtx.Query("from")); err != nil {
+			ginCtx.String(http.StatusBadRequest, "from must be in YYYY-MM-DD format: %s", err.Error())
+			return from, to, false
+		}
+	}
+	if from.After(to) {
+		ginCtx.String(http.StatusBadRequest, "from %s is after to %s", from.Format(dateFormat),
+			to.Format(dateFormat))
+		return from, to, false
+	}
+	return from, to, true
+}
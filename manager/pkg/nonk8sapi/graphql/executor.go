@@ -0,0 +1,234 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const typenameField = "__typename"
+
+// validator checks the fields and the arguments of the operation against the schema, and calculates the depth and
+// the complexity of it. The complexity of a field is 1 plus the complexity of its selections, which is multiplied by
+// the limit of the list field, so a query can't load unbounded rows by nesting the list fields
+type validator struct {
+	variables map[string]interface{}
+	// args are the values of the arguments of each field, the defaults are set for the missing arguments
+	args map[*field]map[string]interface{}
+}
+
+func validate(op *operation, variables map[string]interface{}, maxDepth, maxComplexity int,
+) (map[*field]map[string]interface{}, error) {
+	v := &validator{variables: map[string]interface{}{}, args: map[*field]map[string]interface{}{}}
+	for name, defaultValue := range op.variables {
+		v.variables[name] = defaultValue
+		if value, found := variables[name]; found {
+			v.variables[name] = value
+		}
+	}
+
+	depth, complexity, err := v.validateSelections(queryType, op.selections)
+	if err != nil {
+		return nil, err
+	}
+	if maxDepth > 0 && depth > maxDepth {
+		return nil, fmt.Errorf("the depth %d of the query exceeds the limit %d", depth, maxDepth)
+	}
+	if maxComplexity > 0 && complexity > maxComplexity {
+		return nil, fmt.Errorf("the complexity %d of the query exceeds the limit %d, reduce the limit of the lists or "+
+			"the nested fields", complexity, maxComplexity)
+	}
+	return v.args, nil
+}
+
+// validateSelections returns the depth and the complexity of the selections
+func (v *validator) validateSelections(t *objectType, selections []*field) (int, int, error) {
+	depth, complexity := 1, 0
+	for _, f := range selections {
+		if f.name == typenameField {
+			if len(f.arguments) > 0 || len(f.selections) > 0 {
+				return 0, 0, fmt.Errorf("%s can't have the arguments or the selections", typenameField)
+			}
+			continue
+		}
+		definition, found := t.fields[f.name]
+		if !found {
+			return 0, 0, fmt.Errorf("cannot query field %q on type %q", f.name, t.name)
+		}
+		args, err := v.coerceArguments(t, f, definition)
+		if err != nil {
+			return 0, 0, err
+		}
+		v.args[f] = args
+
+		if definition.objectType == nil {
+			if len(f.selections) > 0 {
+				return 0, 0, fmt.Errorf("field %q of type %q is a scalar and can't have the selections", f.name, t.name)
+			}
+			complexity++
+			continue
+		}
+		if len(f.selections) == 0 {
+			return 0, 0, fmt.Errorf("field %q of type %q must have the selections", f.name, t.name)
+		}
+		childDepth, childComplexity, err := v.validateSelections(definition.objectType, f.selections)
+		if err != nil {
+			return 0, 0, err
+		}
+		depth = max(depth, childDepth+1)
+		if definition.list {
+			childComplexity *= args["limit"].(int)
+		}
+		complexity += 1 + childComplexity
+	}
+	return depth, complexity, nil
+}
+
+func (v *validator) coerceArguments(t *objectType, f *field, definition *fieldDefinition,
+) (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+	for name, argValue := range f.arguments {
+		argType, found := definition.arguments[name]
+		if !found {
+			return nil, fmt.Errorf("unknown argument %q on field %q of type %q", name, f.name, t.name)
+		}
+		value := argValue.literal
+		if argValue.variable != "" {
+			var defined bool
+			if value, defined = v.variables[argValue.variable]; !defined {
+				return nil, fmt.Errorf("variable $%s is not defined", argValue.variable)
+			}
+		}
+		if value == nil {
+			continue
+		}
+
+		switch argType {
+		case stringArgument:
+			str, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("argument %q on field %q must be a string", name, f.name)
+			}
+			args[name] = str
+		case intArgument:
+			// the numbers of the JSON variables are float64
+			if number, ok := value.(float64); ok && number == float64(int(number)) {
+				value = int(number)
+			}
+			i, ok := value.(int)
+			if !ok {
+				return nil, fmt.Errorf("argument %q on field %q must be an integer", name, f.name)
+			}
+			args[name] = i
+		}
+	}
+
+	for name, argType := range definition.arguments {
+		if _, found := args[name]; found {
+			continue
+		}
+		switch argType {
+		case stringArgument:
+			args[name] = ""
+		case intArgument:
+			args[name] = defaultLimit
+		}
+	}
+	if limit, found := args["limit"]; found && (limit.(int) < 1 || limit.(int) > maxLimit) {
+		return nil, fmt.Errorf("limit on field %q must be between 1 and %d", f.name, maxLimit)
+	}
+	return args, nil
+}
+
+// execute resolves the selections of the sources, the object fields are resolved for all the sources together
+func execute(ctx context.Context, t *objectType, sources []source, selections []*field,
+	args map[*field]map[string]interface{},
+) ([]*orderedMap, error) {
+	results := make([]*orderedMap, len(sources))
+	for i := range results {
+		results[i] = &orderedMap{values: map[string]interface{}{}}
+	}
+
+	for _, f := range selections {
+		if f.name == typenameField {
+			for _, result := range results {
+				result.set(f.responseKey(), t.name)
+			}
+			continue
+		}
+		definition := t.fields[f.name]
+		if definition.objectType == nil {
+			for i, s := range sources {
+				results[i].set(f.responseKey(), scalarValue(definition, s[f.name]))
+			}
+			continue
+		}
+
+		children, err := definition.resolve(ctx, sources, args[f])
+		if err != nil {
+			return nil, err
+		}
+		allChildren := []source{}
+		for _, sourceChildren := range children {
+			allChildren = append(allChildren, sourceChildren...)
+		}
+		childResults, err := execute(ctx, definition.objectType, allChildren, f.selections, args)
+		if err != nil {
+			return nil, err
+		}
+
+		start := 0
+		for i, sourceChildren := range children {
+			sourceResults := childResults[start : start+len(sourceChildren)]
+			start += len(sourceChildren)
+			switch {
+			case definition.list:
+				results[i].set(f.responseKey(), sourceResults)
+			case len(sourceResults) > 0:
+				results[i].set(f.responseKey(), sourceResults[0])
+			default:
+				results[i].set(f.responseKey(), nil)
+			}
+		}
+	}
+	return results, nil
+}
+
+// orderedMap keeps the fields of the response in the order of the selections
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, found := m.values[key]; !found {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	for i, key := range m.keys {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		keyBytes, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueBytes, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(keyBytes)
+		buffer.WriteString(":")
+		buffer.Write(valueBytes)
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
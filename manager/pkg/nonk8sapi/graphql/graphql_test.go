@@ -0,0 +1,107 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package graphql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	op, err := parseQuery(`
+		# the clusters of the hub
+		query Clusters($hub: String!, $limit: Int = 10) {
+			clusters: managedClusters(hub: $hub, limit: $limit) {
+				name
+				compliance { policy { name } compliance }
+			}
+		}`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Clusters", op.name)
+	assert.Equal(t, map[string]interface{}{"hub": nil, "limit": 10}, op.variables)
+	assert.Len(t, op.selections, 1)
+	clusters := op.selections[0]
+	assert.Equal(t, "clusters", clusters.responseKey())
+	assert.Equal(t, "managedClusters", clusters.name)
+	assert.Equal(t, value{variable: "hub"}, clusters.arguments["hub"])
+	assert.Len(t, clusters.selections, 2)
+	assert.Len(t, clusters.selections[1].selections, 2)
+
+	op, err = parseQuery(`{ policies(name: "p1") { id } } query Other { events { name } }`, "")
+	assert.ErrorContains(t, err, "operationName is required")
+	assert.Nil(t, op)
+	op, err = parseQuery(`{ policies(name: "p1") { id } } query Other { events { name } }`, "Other")
+	assert.NoError(t, err)
+	assert.Equal(t, "events", op.selections[0].name)
+
+	for query, expectedErr := range map[string]string{
+		`mutation { deletePolicy }`:                     "mutation is not supported",
+		`{ managedClusters { ...clusterFields } }`:      "fragments are not supported",
+		`{ managedClusters @include(if: true) { id } }`: "directives are not supported",
+		`{ managedClusters { id }`:                      "expected a name",
+		`{ policies(name: "p) { id } }`:                 "unterminated string",
+		``:                                              "no operation",
+	} {
+		_, err := parseQuery(query, "")
+		assert.ErrorContains(t, err, expectedErr, query)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	op, err := parseQuery(`query ($hub: String, $limit: Int) {
+		managedClusters(hub: $hub, limit: $limit) { __typename name labels compliance(limit: 5) { compliance } }
+	}`, "")
+	assert.NoError(t, err)
+	variables := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"hub": "hub1", "limit": 20}`), &variables))
+	args, err := validate(op, variables, 5, 1000)
+	assert.NoError(t, err)
+	clusters := op.selections[0]
+	assert.Equal(t, map[string]interface{}{"hub": "hub1", "name": "", "limit": 20}, args[clusters])
+	assert.Equal(t, map[string]interface{}{"limit": 5}, args[clusters.selections[3]])
+
+	// the complexity is 1 + 20 * (1 + 1 + 1 + 5 * 1) = 161
+	_, err = validate(op, variables, 5, 160)
+	assert.ErrorContains(t, err, "complexity 161")
+
+	// the default limit of the lists is used for the complexity
+	_, err = validate(op, nil, 5, 800)
+	assert.ErrorContains(t, err, "complexity 801")
+
+	op, err = parseQuery(`{ policies { compliance { cluster { compliance { policy { name } } } } } }`, "")
+	assert.NoError(t, err)
+	_, err = validate(op, nil, 5, 0)
+	assert.ErrorContains(t, err, "depth 6")
+	_, err = validate(op, nil, 0, 0)
+	assert.NoError(t, err)
+
+	for query, expectedErr := range map[string]string{
+		`{ managedClusters { password } }`:                    `cannot query field "password"`,
+		`{ managedClusters(owner: "me") { name } }`:           `unknown argument "owner"`,
+		`{ managedClusters(limit: "10") { name } }`:           `must be an integer`,
+		`{ managedClusters(limit: 5000) { name } }`:           `must be between 1 and 1000`,
+		`{ managedClusters(hub: $hub) { name } }`:             `variable $hub is not defined`,
+		`{ managedClusters { name { first } } }`:              `is a scalar`,
+		`{ managedClusters }`:                                 `must have the selections`,
+		`{ policies { compliance { policy(x: 1) { id } } } }`: `unknown argument "x"`,
+	} {
+		op, err := parseQuery(query, "")
+		assert.NoError(t, err, query)
+		_, err = validate(op, nil, 0, 0)
+		assert.ErrorContains(t, err, expectedErr, query)
+	}
+}
+
+func TestOrderedMap(t *testing.T) {
+	m := &orderedMap{values: map[string]interface{}{}}
+	m.set("name", "mc1")
+	m.set("labels", json.RawMessage(`{"env":"dev"}`))
+	m.set("compliance", []*orderedMap{})
+	m.set("name", "mc2")
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"mc2","labels":{"env":"dev"},"compliance":[]}`, string(data))
+}
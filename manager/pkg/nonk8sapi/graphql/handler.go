@@ -0,0 +1,89 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const serverInternalErrorMsg = "internal error"
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type responseError struct {
+	Message string `json:"message"`
+}
+
+type response struct {
+	Data   *orderedMap     `json:"data"`
+	Errors []responseError `json:"errors,omitempty"`
+}
+
+// Query godoc
+// @summary graphql query
+// @description query the managed clusters, the policies, the compliance and the events in a single round trip with
+// @description the field selection. The depth and the complexity of the query are limited
+// @accept json
+// @produce json
+// @param        query            query     string  false  "the GraphQL query of the GET request"
+// @param        operationName    query     string  false  "the operation to run if the query contains multiple operations"
+// @param        variables        query     string  false  "the JSON encoded variables of the GET request"
+// @success      200
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /graphql [post]
+func Query(maxDepth, maxComplexity int) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		req := &request{}
+		if ginCtx.Request.Method == http.MethodGet {
+			req.Query = ginCtx.Query("query")
+			req.OperationName = ginCtx.Query("operationName")
+			if variables := ginCtx.Query("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					writeError(ginCtx, http.StatusBadRequest, fmt.Sprintf("invalid variables: %v", err))
+					return
+				}
+			}
+		} else if err := ginCtx.ShouldBindJSON(req); err != nil {
+			writeError(ginCtx, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+			return
+		}
+
+		op, err := parseQuery(req.Query, req.OperationName)
+		if err != nil {
+			writeError(ginCtx, http.StatusBadRequest, err.Error())
+			return
+		}
+		args, err := validate(op, req.Variables, maxDepth, maxComplexity)
+		if err != nil {
+			writeError(ginCtx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		results, err := execute(ginCtx.Request.Context(), queryType, []source{{}}, op.selections, args)
+		if err != nil {
+			fmt.Fprintf(gin.DefaultWriter, "error in executing graphql query: %v\n", err)
+			writeError(ginCtx, http.StatusInternalServerError, serverInternalErrorMsg)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, &response{Data: results[0]})
+	}
+}
+
+func writeError(ginCtx *gin.Context, code int, message string) {
+	ginCtx.JSON(code, &response{Errors: []responseError{{Message: message}}})
+}
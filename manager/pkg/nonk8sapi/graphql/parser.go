@@ -0,0 +1,417 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// the parser supports the subset of the GraphQL query language used by the UIs: the query operations with the
+// variables, the aliases and the arguments. The mutations, the subscriptions, the fragments and the directives
+// aren't supported
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type operation struct {
+	name       string
+	variables  map[string]interface{} // the default values of the variables
+	selections []*field
+}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  map[string]value
+	selections []*field
+}
+
+// responseKey is the key of the field in the response, it's the alias if it's set
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// value is an argument value, it's either a literal or a variable
+type value struct {
+	literal  interface{}
+	variable string
+}
+
+type parser struct {
+	tokens []token
+	index  int
+}
+
+// parseQuery parses the query document, it returns the operation of the operationName, which can be empty if the
+// document only contains one operation
+func parseQuery(query, operationName string) (*operation, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+
+	operations := []*operation{}
+	for p.peek().kind != tokenEOF {
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, op)
+	}
+
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("no operation is found in the query")
+	}
+	if operationName == "" {
+		if len(operations) > 1 {
+			return nil, fmt.Errorf("operationName is required for the query with multiple operations")
+		}
+		return operations[0], nil
+	}
+	for _, op := range operations {
+		if op.name == operationName {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("operation %s is not found in the query", operationName)
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{variables: map[string]interface{}{}}
+
+	// the shorthand query is a selection set only
+	if p.peek().kind == tokenName {
+		switch keyword := p.next().value; keyword {
+		case "query":
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s is not supported", keyword)
+		case "fragment":
+			return nil, fmt.Errorf("fragments are not supported")
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", keyword, p.tokens[p.index-1].pos)
+		}
+		if p.peek().kind == tokenName {
+			op.name = p.next().value
+		}
+		if p.isPunctuator("(") {
+			if err := p.parseVariableDefinitions(op); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if p.isPunctuator("@") {
+		return nil, fmt.Errorf("directives are not supported")
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinitions(op *operation) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.isPunctuator(")") {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		op.variables[name] = nil
+		if p.isPunctuator("=") {
+			p.next()
+			defaultValue, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			if defaultValue.variable != "" {
+				return fmt.Errorf("the default value of the variable %s can't be a variable", name)
+			}
+			op.variables[name] = defaultValue.literal
+		}
+	}
+	return p.expect(")")
+}
+
+// skipType skips the type of the variable, the values are validated by the arguments of the fields
+func (p *parser) skipType() error {
+	if p.isPunctuator("[") {
+		p.next()
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.expectName(); err != nil {
+		return err
+	}
+	if p.isPunctuator("!") {
+		p.next()
+	}
+	return nil
+}
+
+func (p *parser) parseSelectionSet() ([]*field, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	selections := []*field{}
+	for !p.isPunctuator("}") {
+		if p.isPunctuator("...") {
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		f, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, f)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("selection set can't be empty at %d", p.peek().pos)
+	}
+	return selections, p.expect("}")
+}
+
+func (p *parser) parseField() (*field, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	f := &field{name: name, arguments: map[string]value{}}
+	if p.isPunctuator(":") {
+		p.next()
+		if f.name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+		f.alias = name
+	}
+
+	if p.isPunctuator("(") {
+		p.next()
+		for !p.isPunctuator(")") {
+			argName, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if f.arguments[argName], err = p.parseValue(); err != nil {
+				return nil, err
+			}
+		}
+		p.next()
+	}
+
+	if p.isPunctuator("@") {
+		return nil, fmt.Errorf("directives are not supported")
+	}
+	if p.isPunctuator("{") {
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) parseValue() (value, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenInt:
+		i, err := strconv.Atoi(t.value)
+		return value{literal: i}, err
+	case tokenFloat:
+		f, err := strconv.ParseFloat(t.value, 64)
+		return value{literal: f}, err
+	case tokenString:
+		return value{literal: t.value}, nil
+	case tokenName:
+		switch t.value {
+		case "true":
+			return value{literal: true}, nil
+		case "false":
+			return value{literal: false}, nil
+		case "null":
+			return value{}, nil
+		default:
+			// the enum values are passed as the strings
+			return value{literal: t.value}, nil
+		}
+	case tokenPunctuator:
+		if t.value == "$" {
+			name, err := p.expectName()
+			return value{variable: name}, err
+		}
+	}
+	return value{}, fmt.Errorf("unexpected %q at %d, only the scalar values and the variables are supported",
+		t.value, t.pos)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.index]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.index]
+	if t.kind != tokenEOF {
+		p.index++
+	}
+	return t
+}
+
+func (p *parser) isPunctuator(punctuator string) bool {
+	t := p.peek()
+	return t.kind == tokenPunctuator && t.value == punctuator
+}
+
+func (p *parser) expect(punctuator string) error {
+	t := p.next()
+	if t.kind != tokenPunctuator || t.value != punctuator {
+		return fmt.Errorf("expected %q but got %q at %d", punctuator, t.value, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) expectName() (string, error) {
+	t := p.next()
+	if t.kind != tokenName {
+		return "", fmt.Errorf("expected a name but got %q at %d", t.value, t.pos)
+	}
+	return t.value, nil
+}
+
+func tokenize(query string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		// the commas are insignificant in GraphQL
+		case unicode.IsSpace(r) || r == ',' || r == '\uFEFF':
+			i++
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' && runes[i] != '\r' {
+				i++
+			}
+		case strings.ContainsRune("{}():$!=@[]", r):
+			tokens = append(tokens, token{kind: tokenPunctuator, value: string(r), pos: i})
+			i++
+		case r == '.':
+			if i+2 >= len(runes) || runes[i+1] != '.' || runes[i+2] != '.' {
+				return nil, fmt.Errorf("unexpected '.' at %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenPunctuator, value: "...", pos: i})
+			i += 3
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenName, value: string(runes[start:i]), pos: start})
+		case r == '-' || unicode.IsDigit(r):
+			start, kind := i, tokenInt
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])) {
+				if !unicode.IsDigit(runes[i]) {
+					kind = tokenFloat
+				}
+				i++
+			}
+			tokens = append(tokens, token{kind: kind, value: string(runes[start:i]), pos: start})
+		case r == '"':
+			start := i
+			str, end, err := readString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: str, pos: start})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", r, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// readString reads the string starting with the quote at the start, it returns the string and the end index
+func readString(runes []rune, start int) (string, int, error) {
+	if start+2 < len(runes) && runes[start+1] == '"' && runes[start+2] == '"' {
+		return "", 0, fmt.Errorf("block strings are not supported at %d", start)
+	}
+	builder := strings.Builder{}
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '"':
+			return builder.String(), i + 1, nil
+		case '\n', '\r':
+			return "", 0, fmt.Errorf("unterminated string at %d", start)
+		case '\\':
+			i++
+			if i >= len(runes) {
+				return "", 0, fmt.Errorf("unterminated string at %d", start)
+			}
+			switch runes[i] {
+			case 'n':
+				builder.WriteRune('\n')
+			case 't':
+				builder.WriteRune('\t')
+			case 'r':
+				builder.WriteRune('\r')
+			case 'b':
+				builder.WriteRune('\b')
+			case 'f':
+				builder.WriteRune('\f')
+			case 'u':
+				if i+4 >= len(runes) {
+					return "", 0, fmt.Errorf("invalid unicode escape at %d", i)
+				}
+				code, err := strconv.ParseUint(string(runes[i+1:i+5]), 16, 32)
+				if err != nil {
+					return "", 0, fmt.Errorf("invalid unicode escape at %d", i)
+				}
+				builder.WriteRune(rune(code))
+				i += 4
+			default:
+				builder.WriteRune(runes[i])
+			}
+		default:
+			builder.WriteRune(runes[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at %d", start)
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const (
+	// defaultLimit is the number of the items returned by a list field if its limit argument isn't set, it's also
+	// used to estimate the complexity of the query
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type argumentType int
+
+const (
+	stringArgument argumentType = iota
+	intArgument
+)
+
+// source is a row of the data model, the scalar fields are read from it by the name
+type source map[string]interface{}
+
+type objectType struct {
+	name   string
+	fields map[string]*fieldDefinition
+}
+
+type fieldDefinition struct {
+	// objectType is the type of the object field, it's nil for the scalar field
+	objectType *objectType
+	list       bool
+	// json is set for the scalar field whose value is a JSON document, e.g. the labels
+	json      bool
+	arguments map[string]argumentType
+	// resolve returns the children of each source for the object field. It's called once for all the sources of a
+	// selection, so the children are loaded by one query instead of one query for each source
+	resolve func(ctx context.Context, sources []source, args map[string]interface{}) ([][]source, error)
+}
+
+var listArguments = map[string]argumentType{"limit": intArgument}
+
+var (
+	queryType          = &objectType{name: "Query"}
+	managedClusterType = &objectType{name: "ManagedCluster"}
+	policyType         = &objectType{name: "Policy"}
+	complianceType     = &objectType{name: "Compliance"}
+	eventType          = &objectType{name: "Event"}
+)
+
+func init() {
+	queryType.fields = map[string]*fieldDefinition{
+		"managedClusters": {
+			objectType: managedClusterType,
+			list:       true,
+			arguments:  map[string]argumentType{"hub": stringArgument, "name": stringArgument, "limit": intArgument},
+			resolve:    rootResolver(managedClustersSQL),
+		},
+		"policies": {
+			objectType: policyType,
+			list:       true,
+			arguments:  map[string]argumentType{"hub": stringArgument, "name": stringArgument, "limit": intArgument},
+			resolve:    rootResolver(policiesSQL),
+		},
+		"events": {
+			objectType: eventType,
+			list:       true,
+			arguments: map[string]argumentType{
+				"hub": stringArgument, "cluster": stringArgument, "limit": intArgument,
+			},
+			resolve: rootResolver(eventsSQL),
+		},
+	}
+
+	managedClusterType.fields = map[string]*fieldDefinition{
+		"id":        {},
+		"name":      {},
+		"hub":       {},
+		"labels":    {json: true},
+		"available": {},
+		"createdAt": {},
+		"compliance": {
+			objectType: complianceType,
+			list:       true,
+			arguments:  listArguments,
+			resolve:    childrenResolver(clusterComplianceSQL, "id", "clusterId"),
+		},
+		"events": {
+			objectType: eventType,
+			list:       true,
+			arguments:  listArguments,
+			resolve:    childrenResolver(clusterEventsSQL, "id", "clusterId"),
+		},
+	}
+
+	policyType.fields = map[string]*fieldDefinition{
+		"id":        {},
+		"name":      {},
+		"namespace": {},
+		"hub":       {},
+		"standard":  {},
+		"category":  {},
+		"control":   {},
+		"createdAt": {},
+		"compliance": {
+			objectType: complianceType,
+			list:       true,
+			arguments:  listArguments,
+			resolve:    childrenResolver(policyComplianceSQL, "id", "policyId"),
+		},
+	}
+
+	complianceType.fields = map[string]*fieldDefinition{
+		"policyId":    {},
+		"clusterId":   {},
+		"clusterName": {},
+		"hub":         {},
+		"compliance":  {},
+		"policy": {
+			objectType: policyType,
+			resolve:    parentResolver(policiesByIDSQL, "policyId"),
+		},
+		"cluster": {
+			objectType: managedClusterType,
+			resolve:    parentResolver(managedClustersByIDSQL, "clusterId"),
+		},
+	}
+
+	eventType.fields = map[string]*fieldDefinition{
+		"name":        {},
+		"namespace":   {},
+		"clusterId":   {},
+		"clusterName": {},
+		"hub":         {},
+		"reason":      {},
+		"message":     {},
+		"type":        {},
+		"createdAt":   {},
+	}
+}
+
+const (
+	managedClusterColumns = `cluster_id::text AS "id", cluster_name AS "name", leaf_hub_name AS "hub",
+		(payload -> 'metadata' -> 'labels')::text AS "labels",
+		COALESCE((SELECT c ->> 'status' FROM jsonb_array_elements(payload -> 'status' -> 'conditions') AS c
+			WHERE c ->> 'type' = 'ManagedClusterConditionAvailable' LIMIT 1), 'Unknown') AS "available",
+		created_at AS "createdAt"`
+	policyColumns = `policy_id::text AS "id", policy_name AS "name",
+		payload -> 'metadata' ->> 'namespace' AS "namespace", leaf_hub_name AS "hub", policy_standard AS "standard",
+		policy_category AS "category", policy_control AS "control", created_at AS "createdAt"`
+	complianceColumns = `policy_id::text AS "policyId", cluster_id::text AS "clusterId", cluster_name AS "clusterName",
+		leaf_hub_name AS "hub", compliance::text AS "compliance"`
+	eventColumns = `event_name AS "name", event_namespace AS "namespace", cluster_id::text AS "clusterId",
+		cluster_name AS "clusterName", leaf_hub_name AS "hub", reason AS "reason", message AS "message",
+		event_type AS "type", created_at AS "createdAt"`
+)
+
+var (
+	managedClustersSQL = `SELECT ` + managedClusterColumns + ` FROM status.managed_clusters
+		WHERE deleted_at IS NULL AND (@hub = '' OR leaf_hub_name = @hub) AND (@name = '' OR cluster_name = @name)
+		ORDER BY leaf_hub_name, cluster_name LIMIT @limit`
+	managedClustersByIDSQL = `SELECT ` + managedClusterColumns + ` FROM status.managed_clusters
+		WHERE deleted_at IS NULL AND cluster_id IN @ids`
+	policiesSQL = `SELECT ` + policyColumns + ` FROM local_spec.policies
+		WHERE deleted_at IS NULL AND (@hub = '' OR leaf_hub_name = @hub) AND (@name = '' OR policy_name = @name)
+		ORDER BY leaf_hub_name, policy_name LIMIT @limit`
+	policiesByIDSQL = `SELECT ` + policyColumns + ` FROM local_spec.policies
+		WHERE deleted_at IS NULL AND policy_id IN @ids`
+	eventsSQL = `SELECT ` + eventColumns + ` FROM event.managed_clusters
+		WHERE (@hub = '' OR leaf_hub_name = @hub) AND (@cluster = '' OR cluster_name = @cluster)
+		ORDER BY created_at DESC LIMIT @limit`
+
+	// the children of each parent are limited by the row number of the window function
+	clusterComplianceSQL = `SELECT * FROM (SELECT ` + complianceColumns + `,
+			row_number() OVER (PARTITION BY cluster_id ORDER BY policy_id) AS row_number
+		FROM local_status.compliance WHERE cluster_id IN @ids) AS compliance WHERE row_number <= @limit`
+	policyComplianceSQL = `SELECT * FROM (SELECT ` + complianceColumns + `,
+			row_number() OVER (PARTITION BY policy_id ORDER BY leaf_hub_name, cluster_name) AS row_number
+		FROM local_status.compliance WHERE policy_id IN @ids) AS compliance WHERE row_number <= @limit`
+	clusterEventsSQL = `SELECT * FROM (SELECT ` + eventColumns + `,
+			row_number() OVER (PARTITION BY cluster_id ORDER BY created_at DESC) AS row_number
+		FROM event.managed_clusters WHERE cluster_id IN @ids) AS events WHERE row_number <= @limit`
+)
+
+// rootResolver returns the resolver of the query fields, the query is called with the arguments of the field
+func rootResolver(query string) func(context.Context, []source, map[string]interface{}) ([][]source, error) {
+	return func(ctx context.Context, sources []source, args map[string]interface{}) ([][]source, error) {
+		rows, err := queryRows(ctx, query, args)
+		if err != nil {
+			return nil, err
+		}
+		return [][]source{rows}, nil
+	}
+}
+
+// childrenResolver returns the resolver of the list fields, the children are grouped to the parents by the
+// childKey of the child and the parentKey of the parent
+func childrenResolver(query, parentKey, childKey string,
+) func(context.Context, []source, map[string]interface{}) ([][]source, error) {
+	return func(ctx context.Context, sources []source, args map[string]interface{}) ([][]source, error) {
+		children := make([][]source, len(sources))
+		ids := keys(sources, parentKey)
+		if len(ids) == 0 {
+			return children, nil
+		}
+		rows, err := queryRows(ctx, query, map[string]interface{}{"ids": ids, "limit": args["limit"]})
+		if err != nil {
+			return nil, err
+		}
+		childrenByKey := map[interface{}][]source{}
+		for _, row := range rows {
+			delete(row, "row_number")
+			childrenByKey[row[childKey]] = append(childrenByKey[row[childKey]], row)
+		}
+		for i, parent := range sources {
+			children[i] = childrenByKey[parent[parentKey]]
+		}
+		return children, nil
+	}
+}
+
+// parentResolver returns the resolver of the single object fields, the object is found by its id in the key of the
+// source
+func parentResolver(query, key string) func(context.Context, []source, map[string]interface{}) ([][]source, error) {
+	return func(ctx context.Context, sources []source, args map[string]interface{}) ([][]source, error) {
+		children := make([][]source, len(sources))
+		ids := keys(sources, key)
+		if len(ids) == 0 {
+			return children, nil
+		}
+		rows, err := queryRows(ctx, query, map[string]interface{}{"ids": ids})
+		if err != nil {
+			return nil, err
+		}
+		rowsByID := map[interface{}]source{}
+		for _, row := range rows {
+			rowsByID[row["id"]] = row
+		}
+		for i, child := range sources {
+			if row, found := rowsByID[child[key]]; found {
+				children[i] = []source{row}
+			}
+		}
+		return children, nil
+	}
+}
+
+// keys returns the distinct non-empty values of the key in the sources
+func keys(sources []source, key string) []interface{} {
+	found := map[interface{}]bool{}
+	values := []interface{}{}
+	for _, s := range sources {
+		if value := s[key]; value != nil && value != "" && !found[value] {
+			found[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func queryRows(ctx context.Context, query string, args map[string]interface{}) ([]source, error) {
+	rows, err := database.GetReadonlyGorm().WithContext(ctx).Raw(query, args).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	results := []source{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := source{}
+		for i, column := range columns {
+			if bytes, ok := values[i].([]byte); ok {
+				values[i] = string(bytes)
+			}
+			row[column] = values[i]
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// scalarValue returns the value of the scalar field in the response
+func scalarValue(definition *fieldDefinition, value interface{}) interface{} {
+	if str, ok := value.(string); ok && definition.json {
+		return json.RawMessage(str)
+	}
+	return value
+}
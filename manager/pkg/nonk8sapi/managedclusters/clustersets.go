@@ -0,0 +1,305 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package managedclusters
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+// the ManagedClusterSets of the managed hubs, the sets of the same name on the different hubs are one fleet-wide set
+var clusterSetsSQL = `
+	SELECT leaf_hub_name, name, payload -> 'spec'
+	FROM status.managed_cluster_sets
+	WHERE (@hub = '' OR leaf_hub_name = @hub) AND (@name = '' OR name = @name)
+	ORDER BY name, leaf_hub_name`
+
+// the namespaces where the ManagedClusterSets are bound on the managed hubs
+var clusterSetBindingsSQL = `
+	SELECT leaf_hub_name, cluster_set, namespace
+	FROM status.managed_cluster_set_bindings
+	WHERE (@hub = '' OR leaf_hub_name = @hub) AND (@name = '' OR cluster_set = @name)
+	ORDER BY namespace`
+
+// the labels of the managed clusters, the members of the sets are selected by them
+var clusterLabelsSQL = `
+	SELECT leaf_hub_name, cluster_name, COALESCE(payload -> 'metadata' -> 'labels', '{}')
+	FROM status.managed_clusters
+	WHERE deleted_at IS NULL AND (@hub = '' OR leaf_hub_name = @hub)
+	ORDER BY cluster_name`
+
+type managedClusterSet struct {
+	Name string `json:"name"`
+	// ClusterCount is the number of the member clusters on all the managed hubs
+	ClusterCount int             `json:"clusterCount"`
+	Hubs         []hubClusterSet `json:"hubs"`
+}
+
+type hubClusterSet struct {
+	LeafHubName string `json:"leafHubName"`
+	// SelectorType is ExclusiveClusterSetLabel or LabelSelector
+	SelectorType  string                `json:"selectorType"`
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// Clusters are the names of the member clusters of the set on the hub
+	Clusters []string `json:"clusters"`
+	// BoundNamespaces are the namespaces where the set is bound by the ManagedClusterSetBindings on the hub
+	BoundNamespaces []string `json:"boundNamespaces"`
+}
+
+// clusterLabels are the labels of the managed clusters of a hub by the cluster names
+type clusterLabels struct {
+	names  []string
+	labels map[string]labels.Set
+}
+
+// ListManagedClusterSets godoc
+// @summary list managed cluster sets
+// @description list the ManagedClusterSets of all the managed hubs, the sets of the same name on the different hubs are aggregated with their member clusters and bound namespaces
+// @accept json
+// @produce json
+// @param        leafHubName    query     string  false  "only return the cluster sets of the managed hub"
+// @success      200  {object}    []managedClusterSet
+// @failure      401
+// @failure      403
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /managedclustersets [get]
+func ListManagedClusterSets() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		args := map[string]interface{}{
+			"hub":  ginCtx.Query("leafHubName"),
+			"name": "",
+		}
+		fmt.Fprintf(gin.DefaultWriter, "managed cluster sets query: %v\n", args)
+
+		clusterSets, err := queryClusterSets(args, authorization.GetScope(ginCtx))
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying managed cluster sets: %v\n", err)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, clusterSets)
+	}
+}
+
+// GetManagedClusterSet godoc
+// @summary get managed cluster set
+// @description get the ManagedClusterSet of the name on all the managed hubs with its member clusters and bound namespaces
+// @accept json
+// @produce json
+// @param        name           path      string  true   "Name of the managed cluster set"
+// @param        leafHubName    query     string  false  "only return the cluster set of the managed hub"
+// @success      200  {object}    managedClusterSet
+// @failure      401
+// @failure      403
+// @failure      404
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /managedclusterset/{name} [get]
+func GetManagedClusterSet() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		name := ginCtx.Param("name")
+		args := map[string]interface{}{
+			"hub":  ginCtx.Query("leafHubName"),
+			"name": name,
+		}
+
+		clusterSets, err := queryClusterSets(args, authorization.GetScope(ginCtx))
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying managed cluster set %s: %v\n", name, err)
+			return
+		}
+		if len(clusterSets) == 0 {
+			ginCtx.String(http.StatusNotFound, "managed cluster set %s is not found", name)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, clusterSets[0])
+	}
+}
+
+// queryClusterSets returns the cluster sets on the managed hubs that the user is allowed to access, the members are
+// only the managed clusters that the user is allowed to access
+func queryClusterSets(args map[string]interface{}, scope *authorization.Scope) ([]*managedClusterSet, error) {
+	db := database.GetReadonlyGorm()
+	rows, err := db.Raw(clusterSetsSQL, args).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clusterSets := []*managedClusterSet{}
+	hubClusterSets := map[string]map[string]*hubClusterSet{}
+	var clusterSet *managedClusterSet
+	for rows.Next() {
+		var hub, name string
+		var specJSON []byte
+		if err := rows.Scan(&hub, &name, &specJSON); err != nil {
+			return nil, err
+		}
+		if !scope.AllowsHub(hub) && len(scope.Clusters[hub]) == 0 {
+			continue
+		}
+		spec := &clusterv1beta2.ManagedClusterSetSpec{}
+		if len(specJSON) > 0 {
+			if err := json.Unmarshal(specJSON, spec); err != nil {
+				return nil, fmt.Errorf("failed to parse the managed cluster set %s of the hub %s: %w", name, hub, err)
+			}
+		}
+		if spec.ClusterSelector.SelectorType == "" {
+			spec.ClusterSelector.SelectorType = clusterv1beta2.ExclusiveClusterSetLabel
+		}
+
+		if clusterSet == nil || clusterSet.Name != name {
+			clusterSet = &managedClusterSet{Name: name, Hubs: []hubClusterSet{}}
+			clusterSets = append(clusterSets, clusterSet)
+		}
+		clusterSet.Hubs = append(clusterSet.Hubs, hubClusterSet{
+			LeafHubName:     hub,
+			SelectorType:    string(spec.ClusterSelector.SelectorType),
+			LabelSelector:   spec.ClusterSelector.LabelSelector,
+			Clusters:        []string{},
+			BoundNamespaces: []string{},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// the hub entries are indexed after all of them are appended, so the pointers are stable
+	for _, clusterSet := range clusterSets {
+		for i := range clusterSet.Hubs {
+			hubSet := &clusterSet.Hubs[i]
+			if hubClusterSets[hubSet.LeafHubName] == nil {
+				hubClusterSets[hubSet.LeafHubName] = map[string]*hubClusterSet{}
+			}
+			hubClusterSets[hubSet.LeafHubName][clusterSet.Name] = hubSet
+		}
+	}
+	if len(clusterSets) == 0 {
+		return clusterSets, nil
+	}
+
+	if err := addBoundNamespaces(args, hubClusterSets); err != nil {
+		return nil, err
+	}
+
+	hubClusters, err := queryClusterLabels(args, scope)
+	if err != nil {
+		return nil, err
+	}
+	for _, clusterSet := range clusterSets {
+		for i := range clusterSet.Hubs {
+			hubSet := &clusterSet.Hubs[i]
+			clusters := hubClusters[hubSet.LeafHubName]
+			if clusters == nil {
+				continue
+			}
+			members, err := hubSet.members(clusterSet.Name, clusters)
+			if err != nil {
+				return nil, fmt.Errorf("failed to select the clusters of the managed cluster set %s of the hub %s: %w",
+					clusterSet.Name, hubSet.LeafHubName, err)
+			}
+			hubSet.Clusters = members
+			clusterSet.ClusterCount += len(members)
+		}
+	}
+	return clusterSets, nil
+}
+
+// addBoundNamespaces adds the namespaces of the ManagedClusterSetBindings to the cluster sets of the hubs
+func addBoundNamespaces(args map[string]interface{}, hubClusterSets map[string]map[string]*hubClusterSet) error {
+	rows, err := database.GetReadonlyGorm().Raw(clusterSetBindingsSQL, args).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hub, name, namespace string
+		if err := rows.Scan(&hub, &name, &namespace); err != nil {
+			return err
+		}
+		// the binding of the set which doesn't exist on the hub doesn't take effect
+		if hubSet, found := hubClusterSets[hub][name]; found {
+			hubSet.BoundNamespaces = append(hubSet.BoundNamespaces, namespace)
+		}
+	}
+	return rows.Err()
+}
+
+// queryClusterLabels returns the labels of the managed clusters that the user is allowed to access by the hubs
+func queryClusterLabels(args map[string]interface{}, scope *authorization.Scope) (map[string]*clusterLabels, error) {
+	rows, err := database.GetReadonlyGorm().Raw(clusterLabelsSQL, args).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hubClusters := map[string]*clusterLabels{}
+	for rows.Next() {
+		var hub, cluster string
+		var labelsJSON []byte
+		if err := rows.Scan(&hub, &cluster, &labelsJSON); err != nil {
+			return nil, err
+		}
+		if !scope.Allows(hub, cluster) {
+			continue
+		}
+		clusterLabelSet := labels.Set{}
+		if err := json.Unmarshal(labelsJSON, &clusterLabelSet); err != nil {
+			return nil, fmt.Errorf("failed to parse the labels of the cluster %s of the hub %s: %w", cluster, hub, err)
+		}
+		clusters, found := hubClusters[hub]
+		if !found {
+			clusters = &clusterLabels{labels: map[string]labels.Set{}}
+			hubClusters[hub] = clusters
+		}
+		clusters.names = append(clusters.names, cluster)
+		clusters.labels[cluster] = clusterLabelSet
+	}
+	return hubClusters, rows.Err()
+}
+
+// members returns the names of the clusters selected by the cluster set
+func (s *hubClusterSet) members(name string, clusters *clusterLabels) ([]string, error) {
+	selector, err := ClusterSetSelector(name, &clusterv1beta2.ManagedClusterSelector{
+		SelectorType:  clusterv1beta2.SelectorType(s.SelectorType),
+		LabelSelector: s.LabelSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	members := []string{}
+	for _, cluster := range clusters.names {
+		if selector.Matches(clusters.labels[cluster]) {
+			members = append(members, cluster)
+		}
+	}
+	return members, nil
+}
+
+// ClusterSetSelector returns the selector of the member clusters of the cluster set in the same way as the hub: the
+// ExclusiveClusterSetLabel set selects the clusters with the label of the set name, and the LabelSelector set selects
+// the clusters matching its label selector
+func ClusterSetSelector(name string, clusterSelector *clusterv1beta2.ManagedClusterSelector) (labels.Selector, error) {
+	if clusterSelector.SelectorType == clusterv1beta2.LabelSelector {
+		return metav1.LabelSelectorAsSelector(clusterSelector.LabelSelector)
+	}
+	return labels.SelectorFromSet(labels.Set{clusterv1beta2.ClusterSetLabel: name}), nil
+}
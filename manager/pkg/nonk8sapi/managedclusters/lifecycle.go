@@ -0,0 +1,92 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package managedclusters
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/dao"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+const (
+	defaultLifecycleLimit = 500
+	maxLifecycleLimit     = 5000
+)
+
+type clusterLifecycleFeed struct {
+	Events []models.ClusterLifecycleEvent `json:"events"`
+	// LastSequence is the cursor of the next request, it's the sequence of the last read event even if the event
+	// isn't returned to the user, so the next request doesn't read it again
+	LastSequence int64 `json:"lastSequence"`
+}
+
+// ListManagedClusterLifecycle godoc
+// @summary list managed cluster lifecycle events
+// @description list the create, import, detach and upgrade events of the managed clusters of all the managed hubs in the order of the sequence, the events of each cluster are in the order they happened. Pass the lastSequence of the response as the after of the next request to read the feed
+// @accept json
+// @produce json
+// @param        after          query     int     false  "only return the events after the sequence"
+// @param        limit          query     int     false  "maximum number of the events to read, defaults to 500"
+// @param        leafHubName    query     string  false  "only return the events of the managed hub"
+// @param        cluster        query     string  false  "only return the events of the managed cluster"
+// @success      200  {object}    clusterLifecycleFeed
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /managedclusterlifecycle [get]
+func ListManagedClusterLifecycle() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		filter := &dao.ClusterLifecycleFilter{
+			Limit:       defaultLifecycleLimit,
+			LeafHubName: ginCtx.Query("leafHubName"),
+			ClusterName: ginCtx.Query("cluster"),
+		}
+		if after := ginCtx.Query("after"); after != "" {
+			var err error
+			if filter.After, err = strconv.ParseInt(after, 10, 64); err != nil || filter.After < 0 {
+				ginCtx.String(http.StatusBadRequest, "invalid after: %s", after)
+				return
+			}
+		}
+		if limit := ginCtx.Query("limit"); limit != "" {
+			var err error
+			if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 ||
+				filter.Limit > maxLifecycleLimit {
+				ginCtx.String(http.StatusBadRequest, "invalid limit: %s, it should be between 1 and %d", limit,
+					maxLifecycleLimit)
+				return
+			}
+		}
+		fmt.Fprintf(gin.DefaultWriter, "managed cluster lifecycle query: %+v\n", *filter)
+
+		// the feed isn't read from the replica, the lag of the replica might be longer than the settle delay
+		events, err := dao.ListClusterLifecycleEvents(database.GetGorm(), filter)
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying managed cluster lifecycle: %v\n", err)
+			return
+		}
+
+		feed := clusterLifecycleFeed{Events: []models.ClusterLifecycleEvent{}, LastSequence: filter.After}
+		scope := authorization.GetScope(ginCtx)
+		for _, event := range events {
+			feed.LastSequence = event.Sequence
+			if scope.Allows(event.LeafHubName, event.ClusterName) {
+				feed.Events = append(feed.Events, event)
+			}
+		}
+		ginCtx.JSON(http.StatusOK, feed)
+	}
+}
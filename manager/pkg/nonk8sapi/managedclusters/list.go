@@ -5,16 +5,15 @@ package managedclusters
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	set "github.com/deckarep/golang-set"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apiextensions-apiserver/pkg/registry/customresource/tableconvertor"
@@ -24,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/util"
 	"github.com/stolostron/multicluster-global-hub/pkg/database"
 )
@@ -36,21 +36,41 @@ const (
 	noRowsAffectedByOptimisticConcurrencyUpdate = "no rows were affected by an optimistic-concurrency update query"
 	optimisticConcurrencyRetryAttempts          = 5
 	crdName                                     = "managedclusters.cluster.open-cluster-management.io"
+
+	// the status of the managed cluster is the status of the ManagedClusterConditionAvailable condition
+	managedClusterStatusAvailable   = "Available"
+	managedClusterStatusUnavailable = "Unavailable"
+	managedClusterStatusUnknown     = "Unknown"
 )
 
+// the managed clusters can be sorted by the name, the creation timestamp and the hub
+var managedClusterListColumns = &util.ListColumns{
+	Name: "(payload -> 'metadata' ->> 'name')",
+	UID:  "cluster_id",
+	Sort: map[string]string{
+		"metadata.creationTimestamp": "COALESCE(payload -> 'metadata' ->> 'creationTimestamp', '')",
+		"leafHubName":                "leaf_hub_name",
+	},
+}
+
 // ListManagedClusters godoc
 // @summary list managed clusters
 // @description list managed clusters
 // @accept json
 // @produce json
 // @param        labelSelector    query     string  false  "list managed clusters by label selector"
+// @param        hub              query     []string  false  "list managed clusters of the hubs"
+// @param        status           query     string  false  "list managed clusters by the status: Available, Unavailable or Unknown"
 // @param        limit            query     int     false  "maximum managed cluster number to receive"
 // @param        continue         query     string  false  "continue token to request next request"
+// @param        sortBy           query     string  false  "sort managed clusters by metadata.name, metadata.creationTimestamp or leafHubName, prefixed with - for the descending order"
+// @param        fields           query     []string  false  "return only the fields of managed clusters, e.g. metadata.name,status.conditions"
 // @success      200  {object}    clusterv1.ManagedClusterList
 // @failure      400
 // @failure      401
 // @failure      403
 // @failure      404
+// @failure      429
 // @failure      500
 // @failure      503
 // @security     ApiKeyAuth
@@ -68,6 +88,7 @@ func ListManagedClusters() gin.HandlerFunc {
 		if labelSelector != "" {
 			selectorInSql, err = util.ParseLabelSelector(labelSelector)
 			if err != nil {
+				ginCtx.String(http.StatusBadRequest, err.Error())
 				fmt.Fprintf(gin.DefaultWriter, "failed to parse label selector: %s\n", err.Error())
 				return
 			}
@@ -75,66 +96,58 @@ func ListManagedClusters() gin.HandlerFunc {
 
 		fmt.Fprintf(gin.DefaultWriter, "parsed selector: %s\n", selectorInSql)
 
-		limit := ginCtx.Query("limit")
-		fmt.Fprintf(gin.DefaultWriter, "limit: %v\n", limit)
+		filterInSql, filterArgs, err := parseManagedClusterFilter(ginCtx.QueryArray("hub"), ginCtx.Query("status"))
+		if err != nil {
+			ginCtx.String(http.StatusBadRequest, err.Error())
+			fmt.Fprintf(gin.DefaultWriter, "failed to parse managed cluster filter: %s\n", err.Error())
+			return
+		}
+		selectorInSql += filterInSql
 
-		lastManagedClusterName := ""
-		lastManagedClusterUID := uuid.MustParse("00000000-0000-0000-0000-000000000000")
+		// only the managed clusters of the hubs and the names that the user is allowed to access are listed
+		scopeInSql, scopeArgs := authorization.GetScope(ginCtx).Condition("leaf_hub_name",
+			managedClusterListColumns.Name)
+		selectorInSql += scopeInSql
+		filterArgs = append(filterArgs, scopeArgs...)
 
-		continueToken := ginCtx.Query("continue")
-		if continueToken != "" {
-			var lastManagedClusterUIDStr string
-			lastManagedClusterName, lastManagedClusterUIDStr, err = util.DecodeContinue(continueToken)
-			if err != nil {
-				fmt.Fprintf(gin.DefaultWriter, "failed to decode continue token: %s\n", err.Error())
-				return
-			}
-			lastManagedClusterUID, err = uuid.Parse(lastManagedClusterUIDStr)
-			if err != nil {
-				fmt.Println("Error parsing UUID:", err)
-				return
-			}
+		listOptions, err := util.ParseListOptions(ginCtx, managedClusterListColumns)
+		if err != nil {
+			ginCtx.String(http.StatusBadRequest, err.Error())
+			fmt.Fprintf(gin.DefaultWriter, "failed to parse list options: %s\n", err.Error())
+			return
 		}
 
-		fmt.Fprintf(gin.DefaultWriter,
-			"last returned managed cluster name: %s, last returned managed cluster UID: %s\n",
-			lastManagedClusterName,
-			lastManagedClusterUID)
+		fmt.Fprintf(gin.DefaultWriter, "limit: %v, sortBy: %v, fields: %v\n", listOptions.Limit,
+			ginCtx.Query("sortBy"), listOptions.Fields)
 
-		// build query condition for paging
-		LastResourceCompareCondition := fmt.Sprintf(
-			"(payload -> 'metadata' ->> 'name', cluster_id) > ('%s', '%s') ",
-			lastManagedClusterName,
-			lastManagedClusterUID)
+		// build query condition for paging, the managed clusters are queried after the last returned one in the order
+		// of the sort key, name and cluster id
+		lastResourceCompareCondition, managedClusterListArgs := listOptions.Condition()
+		limitInSql, limitArgs := listOptions.LimitClause()
+		managedClusterListArgs = append(append(append([]interface{}{}, filterArgs...), managedClusterListArgs...),
+			limitArgs...)
 
-		// managed cluster list query order by name and uid with limit if set
-		managedClusterListQuery := "SELECT payload FROM status.managed_clusters WHERE deleted_at is NULL AND " +
-			LastResourceCompareCondition +
+		managedClusterQuery := "FROM status.managed_clusters WHERE deleted_at is NULL" +
 			selectorInSql +
-			" ORDER BY (payload -> 'metadata' ->> 'name', cluster_id)"
-
-		// add limit
-		if limit != "" {
-			managedClusterListQuery += fmt.Sprintf(" LIMIT %s", limit)
-		}
+			lastResourceCompareCondition +
+			listOptions.OrderBy() +
+			limitInSql
 
-		fmt.Fprintf(gin.DefaultWriter, "managedcluster list query: %v\n", managedClusterListQuery)
+		fmt.Fprintf(gin.DefaultWriter, "managedcluster list query: %v\n", managedClusterQuery)
 
 		if _, watch := ginCtx.GetQuery("watch"); watch {
-			handleRowsForWatch(ginCtx, managedClusterListQuery)
+			handleRowsForWatch(ginCtx, "SELECT payload "+managedClusterQuery, managedClusterListArgs)
 			return
 		}
 
-		// last managed cluster query order by name and cluster id
-		lastManagedClusterQuery := "SELECT payload FROM status.managed_clusters WHERE deleted_at is NULL " +
-			"ORDER BY (payload -> 'metadata' ->> 'name', cluster_id) DESC LIMIT 1"
-
-		handleRows(ginCtx, managedClusterListQuery, lastManagedClusterQuery,
+		managedClusterListQuery := fmt.Sprintf("SELECT payload, cluster_id, %s %s", listOptions.SortColumn(),
+			managedClusterQuery)
+		handleRows(ginCtx, managedClusterListQuery, managedClusterListArgs, listOptions,
 			customResourceColumnDefinitions)
 	}
 }
 
-func handleRowsForWatch(ginCtx *gin.Context, managedClusterListQuery string) {
+func handleRowsForWatch(ginCtx *gin.Context, managedClusterListQuery string, managedClusterListArgs []interface{}) {
 	writer := ginCtx.Writer
 	header := writer.Header()
 	header.Set("Transfer-Encoding", "chunked")
@@ -166,16 +179,17 @@ func handleRowsForWatch(ginCtx *gin.Context, managedClusterListQuery string) {
 				return
 			}
 
-			doHandleRowsForWatch(ctx, writer, managedClusterListQuery, preAddedManagedClusterNames)
+			doHandleRowsForWatch(ctx, writer, managedClusterListQuery, managedClusterListArgs,
+				preAddedManagedClusterNames)
 		}
 	}
 }
 
 func doHandleRowsForWatch(ctx context.Context, writer io.Writer, managedClusterListQuery string,
-	preAddedManagedClusterNames set.Set,
+	managedClusterListArgs []interface{}, preAddedManagedClusterNames set.Set,
 ) {
-	db := database.GetGorm()
-	rows, err := db.Raw(managedClusterListQuery).Rows()
+	db := database.GetReadonlyGorm()
+	rows, err := db.Raw(managedClusterListQuery, managedClusterListArgs...).Rows()
 	if err != nil {
 		fmt.Fprintf(gin.DefaultWriter, "error in quering managed cluster list: %v\n", err)
 	}
@@ -239,34 +253,17 @@ func doHandleRowsForWatch(ctx context.Context, writer io.Writer, managedClusterL
 	writer.(http.Flusher).Flush()
 }
 
-func handleRows(ginCtx *gin.Context, managedClusterListQuery, lastManagedClusterQuery string,
-	customResourceColumnDefinitions []apiextensionsv1.CustomResourceColumnDefinition,
+func handleRows(ginCtx *gin.Context, managedClusterListQuery string, managedClusterListArgs []interface{},
+	listOptions *util.ListOptions, customResourceColumnDefinitions []apiextensionsv1.CustomResourceColumnDefinition,
 ) {
-	db := database.GetGorm()
-
-	// load the lastManaged cluster
-	lastManagedCluster := &clusterv1.ManagedCluster{}
-
-	var payload []byte
-	err := db.Raw(lastManagedClusterQuery).Row().Scan(&payload)
-	if err != nil && err != sql.ErrNoRows {
-		ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
-		fmt.Fprintf(gin.DefaultWriter, "error in querying row: %v\n", err)
-		return
-	}
-	if err == nil {
-		if err := json.Unmarshal(payload, lastManagedCluster); err != nil {
-			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
-			fmt.Fprintf(gin.DefaultWriter, "error to unmarshal payload to lastManagedCluster: %v\n", err)
-			return
-		}
-	}
+	db := database.GetReadonlyGorm()
 
 	// get hte managed cluster list
-	rows, err := db.Raw(managedClusterListQuery).Rows()
+	rows, err := db.Raw(managedClusterListQuery, managedClusterListArgs...).Rows()
 	if err != nil {
 		ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
 		fmt.Fprintf(gin.DefaultWriter, "error in querying managed clusters: %v\n", err)
+		return
 	}
 	defer rows.Close()
 
@@ -277,12 +274,20 @@ func handleRows(ginCtx *gin.Context, managedClusterListQuery, lastManagedCluster
 		},
 		Items: []clusterv1.ManagedCluster{},
 	}
-	lastManagedClusterName, lastManagedClusterUID := "", ""
+	hasNext := false
+	lastManagedClusterName, lastManagedClusterUID, lastSortValue := "", "", ""
 	for rows.Next() {
+		// one more managed cluster than the limit is queried to know if there is a next page
+		if listOptions.HasNext(len(managedClusterList.Items) + 1) {
+			hasNext = true
+			break
+		}
+
 		managedCluster := clusterv1.ManagedCluster{}
 
 		var payloadCluster []byte
-		err := rows.Scan(&payloadCluster)
+		var clusterID, sortValue string
+		err := rows.Scan(&payloadCluster, &clusterID, &sortValue)
 		if err != nil {
 			fmt.Fprintf(gin.DefaultWriter, "error in scanning a managed cluster: %v\n", err)
 			continue
@@ -295,17 +300,11 @@ func handleRows(ginCtx *gin.Context, managedClusterListQuery, lastManagedCluster
 		}
 
 		managedClusterList.Items = append(managedClusterList.Items, managedCluster)
-		lastManagedClusterName = managedCluster.GetName()
-		lastManagedClusterUID = string(managedCluster.GetUID())
+		lastManagedClusterName, lastManagedClusterUID, lastSortValue = managedCluster.GetName(), clusterID, sortValue
 	}
 
-	if lastManagedClusterName != "" &&
-		lastManagedCluster.GetName() != "" &&
-		lastManagedClusterName != lastManagedCluster.GetName() &&
-		lastManagedClusterUID != "" &&
-		string(lastManagedCluster.GetUID()) != "" &&
-		lastManagedClusterUID != string(lastManagedCluster.GetUID()) {
-		continueToken, err := util.EncodeContinue(lastManagedClusterName, lastManagedClusterUID)
+	if hasNext {
+		continueToken, err := listOptions.NextContinue(lastManagedClusterName, lastManagedClusterUID, lastSortValue)
 		if err != nil {
 			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
 			fmt.Fprintf(gin.DefaultWriter, "error in encoding the continue token: %v\n", err)
@@ -343,9 +342,63 @@ func handleRows(ginCtx *gin.Context, managedClusterListQuery, lastManagedCluster
 		return
 	}
 
+	if len(listOptions.Fields) > 0 {
+		selectedList, err := util.SelectFields(managedClusterList, listOptions.Fields)
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in selecting the fields of managed clusters: %v\n", err)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, selectedList)
+		return
+	}
+
 	ginCtx.JSON(http.StatusOK, managedClusterList)
 }
 
+// parseManagedClusterFilter returns the SQL conditions and the arguments of them to filter the managed clusters by the
+// hubs and the status
+func parseManagedClusterFilter(hubs []string, status string) (string, []interface{}, error) {
+	filterInSql := ""
+	filterArgs := []interface{}{}
+
+	if len(hubs) > 0 {
+		hubNames := []string{}
+		for _, hub := range hubs {
+			for _, hubName := range strings.Split(hub, ",") {
+				if hubName = strings.TrimSpace(hubName); hubName != "" {
+					hubNames = append(hubNames, hubName)
+				}
+			}
+		}
+		if len(hubNames) > 0 {
+			filterInSql += " AND leaf_hub_name IN ?"
+			filterArgs = append(filterArgs, hubNames)
+		}
+	}
+
+	availableCondition := func(conditionStatus metav1.ConditionStatus) string {
+		return fmt.Sprintf(`payload -> 'status' -> 'conditions' @> '[{"type": "%s", "status": "%s"}]'`,
+			clusterv1.ManagedClusterConditionAvailable, conditionStatus)
+	}
+
+	switch {
+	case status == "":
+	case strings.EqualFold(status, managedClusterStatusAvailable):
+		filterInSql += " AND " + availableCondition(metav1.ConditionTrue)
+	case strings.EqualFold(status, managedClusterStatusUnavailable):
+		filterInSql += " AND " + availableCondition(metav1.ConditionFalse)
+	case strings.EqualFold(status, managedClusterStatusUnknown):
+		filterInSql += fmt.Sprintf(" AND NOT COALESCE(%s OR %s, false)",
+			availableCondition(metav1.ConditionTrue), availableCondition(metav1.ConditionFalse))
+	default:
+		return "", nil, fmt.Errorf("invalid managed cluster status: %s, it should be one of %s, %s and %s", status,
+			managedClusterStatusAvailable, managedClusterStatusUnavailable, managedClusterStatusUnknown)
+	}
+
+	return filterInSql, filterArgs, nil
+}
+
 func wrapObjectsInList(managedClusters []clusterv1.ManagedCluster) (*corev1.List, error) {
 	list := &corev1.List{
 		TypeMeta: metav1.TypeMeta{
@@ -13,6 +13,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authentication"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
 	"github.com/stolostron/multicluster-global-hub/pkg/database"
 	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
 )
@@ -41,6 +43,7 @@ type patch struct {
 // @failure      401
 // @failure      403
 // @failure      404
+// @failure      429
 // @failure      500
 // @failure      503
 // @security     ApiKeyAuth
@@ -62,6 +65,12 @@ func PatchManagedCluster() gin.HandlerFunc {
 		fmt.Fprintf(gin.DefaultWriter, "patch for managed cluster: %s -leaf hub: %s\n",
 			managedClusterName, leafHubName)
 
+		if !authorization.GetScope(ginCtx).Allows(leafHubName, managedClusterName) {
+			ginCtx.String(http.StatusForbidden, "user %q cannot patch the managed cluster %s of the hub %s",
+				ginCtx.GetString(authentication.UserKey), managedClusterName, leafHubName)
+			return
+		}
+
 		var patches []patch
 
 		err := ginCtx.BindJSON(&patches)
@@ -13,15 +13,36 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-logr/logr"
+	"k8s.io/client-go/kubernetes"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/audit"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/argocd"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authentication"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/compliance"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/graphql"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/managedclusters"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/placements"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/policies"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/purge"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/ratelimit"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/snapshot"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/subscriptions"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
 )
 
-const secondsToFinishOnShutdown = 5
+const (
+	secondsToFinishOnShutdown = 5
+
+	// AuthenticationModeTokenReview authenticates the bearer tokens with the TokenReview of the kubernetes API server
+	AuthenticationModeTokenReview = "tokenreview"
+	// AuthenticationModeOAuth authenticates the bearer tokens with the user API of the OpenShift OAuth server
+	AuthenticationModeOAuth = "oauth"
+)
 
 var errFailedToLoadCertificate = errors.New("failed to load certificate/key")
 
@@ -29,6 +50,28 @@ type NonK8sAPIServerConfig struct {
 	ClusterAPIURL          string
 	ClusterAPICABundlePath string
 	ServerBasePath         string
+	// the limits of the depth and the complexity of the GraphQL queries, they aren't limited if they are 0
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int
+	// AuthenticationMode is tokenreview or oauth, it's oauth if it's empty. The authentication is skipped if the
+	// ClusterAPIURL is empty
+	AuthenticationMode string
+	// the audiences of the TokenReview, and how long the results of the TokenReview are cached
+	TokenReviewAudiences []string
+	TokenReviewCacheTTL  time.Duration
+	// TokenReviews creates the TokenReview, it's created with the config of the manager if it's nil
+	TokenReviews authenticationv1client.TokenReviewInterface
+	// EnableAuthorization authorizes the requests of the users with the SubjectAccessReview on the virtual resources
+	// of the globalhub.open-cluster-management.io group, it's skipped with the authentication
+	EnableAuthorization bool
+	// how long the hubs and clusters that the users are allowed to access are cached
+	AuthorizationCacheTTL time.Duration
+	// Authorizer authorizes the requests, it's created with the config of the manager if it's nil
+	Authorizer *authorization.Authorizer
+	// RateLimitConfig limits the requests per second of each client
+	RateLimitConfig ratelimit.RateLimitConfig
+	// Auditor records the requests of the users, the requests aren't recorded if it's nil
+	Auditor *audit.Auditor
 }
 
 // NeedLeaderElection implements the LeaderElectionRunnable interface, which indicates
@@ -60,6 +103,35 @@ func readCertificateAuthority(nonK8sAPIServerConfig *NonK8sAPIServerConfig) ([]b
 
 // AddNonK8sApiServer adds the non-k8s-api-server to the Manager.
 func AddNonK8sApiServer(mgr ctrl.Manager, nonK8sAPIServerConfig *NonK8sAPIServerConfig) error {
+	if nonK8sAPIServerConfig.AuthenticationMode == AuthenticationModeTokenReview &&
+		nonK8sAPIServerConfig.TokenReviews == nil {
+		kubeClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			return fmt.Errorf("failed to create the kubernetes client for the TokenReview: %w", err)
+		}
+		nonK8sAPIServerConfig.TokenReviews = kubeClient.AuthenticationV1().TokenReviews()
+	}
+
+	if nonK8sAPIServerConfig.EnableAuthorization && nonK8sAPIServerConfig.Authorizer == nil {
+		kubeClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			return fmt.Errorf("failed to create the kubernetes client for the SubjectAccessReview: %w", err)
+		}
+		nonK8sAPIServerConfig.Authorizer = authorization.NewAuthorizer(
+			kubeClient.AuthorizationV1().SubjectAccessReviews(),
+			func(token string) (authorizationv1client.SelfSubjectRulesReviewInterface, error) {
+				// the rules of the user are reviewed by the user itself
+				userConfig := rest.AnonymousClientConfig(mgr.GetConfig())
+				userConfig.BearerToken = token
+				userClient, err := kubernetes.NewForConfig(userConfig)
+				if err != nil {
+					return nil, err
+				}
+				return userClient.AuthorizationV1().SelfSubjectRulesReviews(), nil
+			},
+			listManagedHubs, nonK8sAPIServerConfig.AuthorizationCacheTTL)
+	}
+
 	router, err := SetupRouter(nonK8sAPIServerConfig)
 	if err != nil {
 		return err
@@ -99,28 +171,117 @@ func AddNonK8sApiServer(mgr ctrl.Manager, nonK8sAPIServerConfig *NonK8sAPIServer
 // @description					Authorization with user access token
 func SetupRouter(nonK8sAPIServerConfig *NonK8sAPIServerConfig) (*gin.Engine, error) {
 	router := gin.Default()
-	// add aythentication eith openshift oauth
+	// add authentication with the TokenReview or the openshift oauth
 	// skip authentication middleware if ClusterAPIURL is empty for testing
 	if nonK8sAPIServerConfig.ClusterAPIURL != "" {
-		clusterAPICABundle, err := readCertificateAuthority(nonK8sAPIServerConfig)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read certificates authority: %w", err)
+		switch nonK8sAPIServerConfig.AuthenticationMode {
+		case AuthenticationModeTokenReview:
+			if nonK8sAPIServerConfig.TokenReviews == nil {
+				return nil, errors.New("the TokenReview client is required by the tokenreview authentication")
+			}
+			router.Use(authentication.TokenReviewAuthentication(nonK8sAPIServerConfig.TokenReviews,
+				nonK8sAPIServerConfig.TokenReviewAudiences, nonK8sAPIServerConfig.TokenReviewCacheTTL))
+		case AuthenticationModeOAuth, "":
+			clusterAPICABundle, err := readCertificateAuthority(nonK8sAPIServerConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read certificates authority: %w", err)
+			}
+			router.Use(authentication.Authentication(nonK8sAPIServerConfig.ClusterAPIURL, clusterAPICABundle))
+		default:
+			return nil, fmt.Errorf("invalid authentication mode: %s, it should be %s or %s",
+				nonK8sAPIServerConfig.AuthenticationMode, AuthenticationModeTokenReview, AuthenticationModeOAuth)
+		}
+	}
+
+	// record the requests of the authenticated users, including the requests rejected by the rate limit and the
+	// authorization
+	router.Use(audit.AuditRequests(nonK8sAPIServerConfig.Auditor))
+
+	// limit the requests of each authenticated user, or of each IP address if the authentication is skipped
+	rateLimiter, err := ratelimit.NewRateLimiter(&nonK8sAPIServerConfig.RateLimitConfig)
+	if err != nil {
+		return nil, err
+	}
+	router.Use(rateLimiter.RateLimit())
+
+	// authorize the requests with the SubjectAccessReview on the virtual resources, the resources of the managed hubs
+	// are filtered by the hubs and clusters that the user is allowed to access, the other resources require the access
+	// to all hubs
+	authorize, authorizeAll := skipAuthorization, skipAuthorization
+	if nonK8sAPIServerConfig.EnableAuthorization && nonK8sAPIServerConfig.ClusterAPIURL != "" {
+		authorizer := nonK8sAPIServerConfig.Authorizer
+		if authorizer == nil {
+			return nil, errors.New("the authorizer is required by the authorization")
+		}
+		authorize = func(resource, verb string) gin.HandlerFunc {
+			return authorization.Authorize(authorizer, resource, verb)
+		}
+		authorizeAll = func(resource, verb string) gin.HandlerFunc {
+			return authorization.AuthorizeAll(authorizer, resource, verb)
 		}
-		router.Use(authentication.Authentication(nonK8sAPIServerConfig.ClusterAPIURL, clusterAPICABundle))
 	}
 
 	routerGroup := router.Group(nonK8sAPIServerConfig.ServerBasePath)
-	routerGroup.GET("/managedclusters", managedclusters.ListManagedClusters())
-	routerGroup.PATCH("/managedcluster/:clusterID",
+	routerGroup.GET("/managedclusters", authorize(authorization.ResourceManagedClusters, "list"),
+		managedclusters.ListManagedClusters())
+	routerGroup.PATCH("/managedcluster/:clusterID", authorize(authorization.ResourceManagedClusters, "patch"),
 		managedclusters.PatchManagedCluster())
-	routerGroup.GET("/policies", policies.ListPolicies())
-	routerGroup.GET("/policy/:policyID/status", policies.GetPolicyStatus())
-	routerGroup.GET("/subscriptions", subscriptions.ListSubscriptions())
-	routerGroup.GET("/subscriptionreport/:subscriptionID", subscriptions.GetSubscriptionReport())
+	routerGroup.GET("/managedclustersets", authorize(authorization.ResourceManagedClusters, "list"),
+		managedclusters.ListManagedClusterSets())
+	routerGroup.GET("/managedclusterset/:name", authorize(authorization.ResourceManagedClusters, "get"),
+		managedclusters.GetManagedClusterSet())
+	routerGroup.GET("/managedclusterlifecycle", authorize(authorization.ResourceManagedClusters, "list"),
+		managedclusters.ListManagedClusterLifecycle())
+	routerGroup.GET("/placement/:namespace/:name/explanation", authorize(authorization.ResourcePlacements, "get"),
+		placements.ExplainPlacement())
+	routerGroup.GET("/policies", authorizeAll(authorization.ResourcePolicies, "list"), policies.ListPolicies())
+	routerGroup.GET("/policy/:policyID/status", authorizeAll(authorization.ResourcePolicies, "get"),
+		policies.GetPolicyStatus())
+	routerGroup.GET("/policysets", authorize(authorization.ResourcePolicies, "list"), policies.ListPolicySets())
+	routerGroup.GET("/operatorpolicies", authorize(authorization.ResourcePolicies, "list"),
+		policies.ListOperatorPolicies())
+	routerGroup.GET("/subscriptions", authorizeAll(authorization.ResourceSubscriptions, "list"),
+		subscriptions.ListSubscriptions())
+	routerGroup.GET("/subscriptionreport/:subscriptionID", authorizeAll(authorization.ResourceSubscriptions, "get"),
+		subscriptions.GetSubscriptionReport())
+	routerGroup.GET("/argocdapplications", authorize(authorization.ResourceApplications, "list"),
+		argocd.ListArgoApplications())
+	routerGroup.GET("/argocdapplicationsets", authorize(authorization.ResourceApplications, "list"),
+		argocd.ListArgoApplicationSets())
+	routerGroup.GET("/compliance", authorize(authorization.ResourceCompliance, "list"), compliance.ListCompliance())
+	routerGroup.GET("/compliance/:policyID", authorize(authorization.ResourceCompliance, "get"),
+		compliance.GetPolicyCompliance())
+	routerGroup.GET("/compliancetrend", authorize(authorization.ResourceCompliance, "list"),
+		compliance.GetComplianceTrend())
+	routerGroup.GET("/compliancepercentages", authorize(authorization.ResourceCompliance, "list"),
+		compliance.GetCompliancePercentages())
+	routerGroup.GET("/snapshot", authorizeAll(authorization.ResourceSnapshots, "get"), snapshot.GetFleetSnapshot())
+	graphqlHandler := graphql.Query(nonK8sAPIServerConfig.GraphQLMaxDepth, nonK8sAPIServerConfig.GraphQLMaxComplexity)
+	routerGroup.GET("/graphql", authorizeAll(authorization.ResourceGraphQL, "get"), graphqlHandler)
+	routerGroup.POST("/graphql", authorizeAll(authorization.ResourceGraphQL, "get"), graphqlHandler)
+	routerGroup.GET("/openapi/v3", GetOpenAPIV3())
+	routerGroup.POST("/purges", authorizeAll(authorization.ResourcePurges, "create"), purge.RequestPurge())
+	routerGroup.GET("/purge/:purgeID", authorizeAll(authorization.ResourcePurges, "get"), purge.GetPurge())
+	routerGroup.POST("/purge/:purgeID/confirm", authorizeAll(authorization.ResourcePurges, "update"),
+		purge.ConfirmPurge())
 
 	return router, nil
 }
 
+func skipAuthorization(_, _ string) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		ginCtx.Next()
+	}
+}
+
+// listManagedHubs returns the managed hubs which have reported the heartbeats, their namespaces are authorized
+func listManagedHubs(ctx context.Context) ([]string, error) {
+	hubs := []string{}
+	err := database.GetReadonlyGorm().WithContext(ctx).Raw(
+		"SELECT leaf_hub_name FROM status.leaf_hub_heartbeats ORDER BY leaf_hub_name").Scan(&hubs).Error
+	return hubs, err
+}
+
 // Start runs the non-k8s-api server within given context
 func (s *nonK8sApiServer) Start(ctx context.Context) error {
 	idleConnsClosed := make(chan struct{})
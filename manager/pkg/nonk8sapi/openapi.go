@@ -0,0 +1,209 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package nonk8sapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed swagger.yaml
+var swaggerV2 []byte
+
+// the fields of the swagger 2.0 parameter which are moved to the schema of the OpenAPI v3 parameter
+var parameterSchemaFields = []string{
+	"type", "format", "items", "enum", "default", "maximum", "minimum", "maxLength", "minLength", "pattern",
+}
+
+// OpenAPIV3 returns the OpenAPI v3 document of the API in JSON. It's converted from the swagger 2.0 document, which
+// is generated from the godoc of the handlers, so the two documents don't drift
+func OpenAPIV3() ([]byte, error) {
+	swagger := map[string]interface{}{}
+	if err := yaml.Unmarshal(swaggerV2, &swagger); err != nil {
+		return nil, fmt.Errorf("failed to parse the swagger document: %w", err)
+	}
+	openapi, err := convertToOpenAPIV3(swagger)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(openapi)
+}
+
+// GetOpenAPIV3 godoc
+// @summary get the OpenAPI v3 document
+// @description get the OpenAPI v3 document of the API, which is used to generate the client libraries
+// @produce json
+// @success      200
+// @failure      401
+// @failure      429
+// @failure      500
+// @security     ApiKeyAuth
+// @router /openapi/v3 [get]
+func GetOpenAPIV3() gin.HandlerFunc {
+	document, err := OpenAPIV3()
+	return func(ginCtx *gin.Context) {
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, "internal error")
+			fmt.Fprintf(gin.DefaultWriter, "error in converting the OpenAPI document: %v\n", err)
+			return
+		}
+		ginCtx.Data(http.StatusOK, "application/json", document)
+	}
+}
+
+func convertToOpenAPIV3(swagger map[string]interface{}) (map[string]interface{}, error) {
+	openapi := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    swagger["info"],
+		"servers": []interface{}{map[string]interface{}{"url": swagger["basePath"]}},
+	}
+	if tags, found := swagger["tags"]; found {
+		openapi["tags"] = tags
+	}
+
+	components := map[string]interface{}{}
+	if definitions, found := swagger["definitions"]; found {
+		components["schemas"] = definitions
+	}
+	if securityDefinitions, found := swagger["securityDefinitions"]; found {
+		components["securitySchemes"] = securityDefinitions
+	}
+	openapi["components"] = components
+
+	paths, _ := swagger["paths"].(map[string]interface{})
+	for path, pathItem := range paths {
+		operations, ok := pathItem.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid path %s", path)
+		}
+		for method, op := range operations {
+			operation, ok := op.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid operation %s %s", method, path)
+			}
+			if err := convertOperation(operation); err != nil {
+				return nil, fmt.Errorf("failed to convert the operation %s %s: %w", method, path, err)
+			}
+		}
+	}
+	openapi["paths"] = paths
+
+	return replaceReferences(openapi).(map[string]interface{}), nil
+}
+
+func convertOperation(operation map[string]interface{}) error {
+	produces := contentTypes(operation["produces"])
+	consumes := contentTypes(operation["consumes"])
+	delete(operation, "produces")
+	delete(operation, "consumes")
+
+	parameters, _ := operation["parameters"].([]interface{})
+	convertedParameters := []interface{}{}
+	for _, p := range parameters {
+		parameter, ok := p.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid parameter %v", p)
+		}
+		if parameter["in"] == "body" {
+			requestBody := map[string]interface{}{"content": content(consumes, parameter["schema"])}
+			for _, field := range []string{"description", "required"} {
+				if value, found := parameter[field]; found {
+					requestBody[field] = value
+				}
+			}
+			operation["requestBody"] = requestBody
+			continue
+		}
+
+		schema := map[string]interface{}{}
+		for _, field := range parameterSchemaFields {
+			if value, found := parameter[field]; found {
+				schema[field] = value
+				delete(parameter, field)
+			}
+		}
+		parameter["schema"] = schema
+		if collectionFormat, found := parameter["collectionFormat"]; found {
+			// the csv array is "a,b" and the multi array is "?a=x&a=y" in the query
+			parameter["style"], parameter["explode"] = "form", collectionFormat == "multi"
+			delete(parameter, "collectionFormat")
+		}
+		convertedParameters = append(convertedParameters, parameter)
+	}
+	if len(convertedParameters) > 0 {
+		operation["parameters"] = convertedParameters
+	} else {
+		delete(operation, "parameters")
+	}
+
+	responses, _ := operation["responses"].(map[string]interface{})
+	for code, r := range responses {
+		response, ok := r.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid response %s", code)
+		}
+		if schema, found := response["schema"]; found {
+			response["content"] = content(produces, schema)
+			delete(response, "schema")
+		}
+		// the description is required by the OpenAPI v3
+		if _, found := response["description"]; !found {
+			response["description"] = http.StatusText(httpStatus(code))
+		}
+	}
+	return nil
+}
+
+func contentTypes(value interface{}) []string {
+	types := []string{}
+	values, _ := value.([]interface{})
+	for _, v := range values {
+		if contentType, ok := v.(string); ok {
+			types = append(types, contentType)
+		}
+	}
+	if len(types) == 0 {
+		types = append(types, "application/json")
+	}
+	return types
+}
+
+func content(contentTypes []string, schema interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, contentType := range contentTypes {
+		result[contentType] = map[string]interface{}{"schema": schema}
+	}
+	return result
+}
+
+func httpStatus(code string) int {
+	status := 0
+	_, _ = fmt.Sscanf(code, "%d", &status)
+	return status
+}
+
+// replaceReferences replaces the references of the swagger definitions with the references of the components
+func replaceReferences(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				v[key] = strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+				continue
+			}
+			v[key] = replaceReferences(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = replaceReferences(child)
+		}
+	}
+	return value
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package nonk8sapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIV3(t *testing.T) {
+	document, err := OpenAPIV3()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(document), "#/definitions/")
+
+	openapi := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(document, &openapi))
+	assert.Equal(t, "3.0.3", openapi["openapi"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"url": "/global-hub-api/v1"}}, openapi["servers"])
+	components := openapi["components"].(map[string]interface{})
+	assert.Contains(t, components["schemas"], "ManagedClusterList")
+	assert.Contains(t, components["securitySchemes"], "ApiKeyAuth")
+
+	paths := openapi["paths"].(map[string]interface{})
+	list := paths["/managedclusters"].(map[string]interface{})["get"].(map[string]interface{})
+	assert.NotContains(t, list, "produces")
+	for _, p := range list["parameters"].([]interface{}) {
+		parameter := p.(map[string]interface{})
+		assert.NotContains(t, parameter, "type")
+		assert.Contains(t, parameter, "schema")
+		if parameter["name"] == "hub" {
+			assert.Equal(t, "form", parameter["style"])
+			assert.Equal(t, false, parameter["explode"])
+		}
+	}
+	ok := list["responses"].(map[string]interface{})["200"].(map[string]interface{})
+	assert.Equal(t, "#/components/schemas/ManagedClusterList",
+		ok["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})["$ref"])
+
+	// the body parameter is the request body
+	for path, pathItem := range paths {
+		for method, op := range pathItem.(map[string]interface{}) {
+			operation := op.(map[string]interface{})
+			if parameters, found := operation["parameters"]; found {
+				for _, p := range parameters.([]interface{}) {
+					assert.NotEqual(t, "body", p.(map[string]interface{})["in"], method+" "+path)
+				}
+			}
+			for code, response := range operation["responses"].(map[string]interface{}) {
+				assert.Contains(t, response, "description", method+" "+path+" "+code)
+			}
+			if strings.HasPrefix(path, "/managedcluster/") && method == "patch" {
+				assert.Contains(t, operation, "requestBody")
+			}
+		}
+	}
+}
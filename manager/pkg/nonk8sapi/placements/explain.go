@@ -0,0 +1,339 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package placements
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clustersv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/managedclusters"
+)
+
+// the scheduling steps of the hub, a cluster is only selected if it passes all of them
+const (
+	StepClusterSets = "ClusterSets"
+	StepPredicates  = "Predicates"
+	StepTolerations = "Tolerations"
+)
+
+// the built-in prioritizers which are enabled with the weight 1 in the Additive mode
+var defaultPrioritizers = []string{"Balance", "Steady"}
+
+type placementExplanation struct {
+	LeafHubName string `json:"leafHubName"`
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	// Global is true if the placement is propagated from the global hub
+	Global   bool   `json:"global"`
+	Cluster  string `json:"cluster"`
+	Selected bool   `json:"selected"`
+	// Reason is why the cluster is or isn't selected by the placement
+	Reason string `json:"reason"`
+	// Steps are the results of the scheduling steps for the cluster in the order of the hub scheduler
+	Steps []schedulingStep `json:"steps"`
+	// Scores are the scores of the cluster for the prioritizers of the placement, the scores of the built-in
+	// prioritizers are calculated by the hub scheduler and aren't reported
+	Scores []prioritizerScore `json:"scores"`
+}
+
+type schedulingStep struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+type prioritizerScore struct {
+	// Prioritizer is the name of the built-in prioritizer or the resourceName/scoreName of the AddOn prioritizer
+	Prioritizer string `json:"prioritizer"`
+	Weight      int32  `json:"weight"`
+	// Score is the AddOnPlacementScore of the cluster, it's empty if the score isn't reported or expired
+	Score *int32 `json:"score,omitempty"`
+}
+
+// hubPlacement is the state of the hub that the placement is scheduled with
+type hubPlacement struct {
+	leafHubName string
+	global      bool
+	placement   *clusterv1beta1.Placement
+	cluster     *clusterv1.ManagedCluster
+	// clusterSets are the cluster selectors of the ManagedClusterSets of the hub by the names
+	clusterSets map[string]*clusterv1beta2.ManagedClusterSelector
+	// boundClusterSets are the names of the cluster sets bound to the namespace of the placement
+	boundClusterSets []string
+	// decided is true if the cluster is in the PlacementDecisions of the placement
+	decided bool
+	scores  []clustersv1alpha1.AddOnPlacementScore
+}
+
+// explain replays the scheduling steps of the hub for the cluster, so it answers why the cluster is or isn't selected
+// by the placement. The result might differ from the decisions until the hub reschedules the placement
+func (h *hubPlacement) explain(now time.Time) *placementExplanation {
+	explanation := &placementExplanation{
+		LeafHubName: h.leafHubName,
+		Namespace:   h.placement.Namespace,
+		Name:        h.placement.Name,
+		Global:      h.global,
+		Cluster:     h.cluster.Name,
+		Selected:    h.decided,
+		Steps: []schedulingStep{
+			h.clusterSetsStep(),
+			h.predicatesStep(),
+			h.tolerationsStep(now),
+		},
+		Scores: h.prioritizerScores(now),
+	}
+
+	var failed *schedulingStep
+	for i := range explanation.Steps {
+		if !explanation.Steps[i].Passed {
+			failed = &explanation.Steps[i]
+			break
+		}
+	}
+
+	misconfigured := meta.FindStatusCondition(h.placement.Status.Conditions,
+		clusterv1beta1.PlacementConditionMisconfigured)
+	numberOfClusters := h.placement.Spec.NumberOfClusters
+	switch {
+	case h.decided && failed == nil:
+		explanation.Reason = "the cluster is selected by the placement"
+	case h.decided:
+		explanation.Reason = fmt.Sprintf("the cluster is selected by the placement, but it doesn't pass the %s "+
+			"step now, it's removed once the hub reschedules the placement", failed.Name)
+	case failed != nil:
+		explanation.Reason = failed.Message
+	case misconfigured != nil && misconfigured.Status == metav1.ConditionTrue:
+		explanation.Reason = "the placement is misconfigured: " + misconfigured.Message
+	case numberOfClusters != nil && h.placement.Status.NumberOfSelectedClusters >= *numberOfClusters:
+		explanation.Reason = fmt.Sprintf("the cluster passes all the steps, but the placement only selects %d "+
+			"clusters, the ones with the higher scores of the prioritizers are selected", *numberOfClusters)
+	default:
+		explanation.Reason = "the cluster passes all the steps, but it isn't selected by the hub yet"
+	}
+	return explanation
+}
+
+// clusterSetsStep checks the cluster is a member of the cluster sets which are bound to the namespace of the placement,
+// and are in the clusterSets of the placement if they're set
+func (h *hubPlacement) clusterSetsStep() schedulingStep {
+	step := schedulingStep{Name: StepClusterSets}
+	eligible := []string{}
+	for _, name := range h.boundClusterSets {
+		if _, found := h.clusterSets[name]; !found {
+			continue
+		}
+		if len(h.placement.Spec.ClusterSets) > 0 && !contains(h.placement.Spec.ClusterSets, name) {
+			continue
+		}
+		eligible = append(eligible, name)
+	}
+	sort.Strings(eligible)
+
+	if len(eligible) == 0 {
+		if len(h.placement.Spec.ClusterSets) > 0 {
+			step.Message = fmt.Sprintf("none of the cluster sets %s is bound to the namespace %s",
+				strings.Join(h.placement.Spec.ClusterSets, ", "), h.placement.Namespace)
+		} else {
+			step.Message = fmt.Sprintf("no cluster set is bound to the namespace %s", h.placement.Namespace)
+		}
+		return step
+	}
+
+	members := []string{}
+	for _, name := range eligible {
+		selector, err := managedclusters.ClusterSetSelector(name, h.clusterSets[name])
+		if err != nil {
+			continue // the hub doesn't select any cluster with the invalid selector either
+		}
+		if selector.Matches(labels.Set(h.cluster.Labels)) {
+			members = append(members, name)
+		}
+	}
+	if len(members) == 0 {
+		step.Message = fmt.Sprintf("the cluster isn't a member of the cluster sets %s", strings.Join(eligible, ", "))
+		return step
+	}
+	step.Passed = true
+	step.Message = fmt.Sprintf("the cluster is a member of the cluster sets %s", strings.Join(members, ", "))
+	return step
+}
+
+// predicatesStep checks the cluster matches any of the predicates of the placement
+func (h *hubPlacement) predicatesStep() schedulingStep {
+	step := schedulingStep{Name: StepPredicates}
+	if len(h.placement.Spec.Predicates) == 0 {
+		step.Passed = true
+		step.Message = "the placement doesn't have any predicate"
+		return step
+	}
+
+	claims := labels.Set{}
+	for _, claim := range h.cluster.Status.ClusterClaims {
+		claims[claim.Name] = claim.Value
+	}
+
+	mismatches := []string{}
+	for i, predicate := range h.placement.Spec.Predicates {
+		mismatch, err := matchClusterSelector(&predicate.RequiredClusterSelector, labels.Set(h.cluster.Labels), claims)
+		if err != nil {
+			mismatches = append(mismatches, fmt.Sprintf("predicate %d is invalid: %v", i, err))
+			continue
+		}
+		if mismatch == "" {
+			step.Passed = true
+			step.Message = fmt.Sprintf("the cluster matches the predicate %d", i)
+			return step
+		}
+		mismatches = append(mismatches, fmt.Sprintf("predicate %d %s", i, mismatch))
+	}
+	step.Message = "the cluster doesn't match any predicate: " + strings.Join(mismatches, "; ")
+	return step
+}
+
+// matchClusterSelector returns the mismatched selector of the cluster, it's empty if the cluster matches both the
+// label selector and the claim selector
+func matchClusterSelector(clusterSelector *clusterv1beta1.ClusterSelector, clusterLabels, claims labels.Set,
+) (string, error) {
+	labelSelector, err := metav1.LabelSelectorAsSelector(&clusterSelector.LabelSelector)
+	if err != nil {
+		return "", err
+	}
+	if !labelSelector.Matches(clusterLabels) {
+		return fmt.Sprintf("requires the labels %s", labelSelector.String()), nil
+	}
+	claimSelector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchExpressions: clusterSelector.ClaimSelector.MatchExpressions,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !claimSelector.Matches(claims) {
+		return fmt.Sprintf("requires the claims %s", claimSelector.String()), nil
+	}
+	return "", nil
+}
+
+// tolerationsStep checks the NoSelect taints of the cluster are tolerated by the placement, the NoSelectIfNew taints
+// only prevent the clusters which aren't selected yet, and the PreferNoSelect taints don't prevent any cluster
+func (h *hubPlacement) tolerationsStep(now time.Time) schedulingStep {
+	step := schedulingStep{Name: StepTolerations}
+	untolerated := []string{}
+	for _, taint := range h.cluster.Spec.Taints {
+		switch taint.Effect {
+		case clusterv1.TaintEffectPreferNoSelect:
+			continue
+		case clusterv1.TaintEffectNoSelectIfNew:
+			if h.decided {
+				continue
+			}
+		}
+		if !isTolerated(taint, h.placement.Spec.Tolerations, now) {
+			untolerated = append(untolerated, fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect))
+		}
+	}
+	if len(untolerated) > 0 {
+		step.Message = fmt.Sprintf("the taints of the cluster aren't tolerated: %s", strings.Join(untolerated, ", "))
+		return step
+	}
+	step.Passed = true
+	if len(h.cluster.Spec.Taints) == 0 {
+		step.Message = "the cluster doesn't have any taint"
+	} else {
+		step.Message = "the taints of the cluster are tolerated"
+	}
+	return step
+}
+
+func isTolerated(taint clusterv1.Taint, tolerations []clusterv1beta1.Toleration, now time.Time) bool {
+	for _, toleration := range tolerations {
+		if toleration.Effect != "" && toleration.Effect != taint.Effect {
+			continue
+		}
+		switch toleration.Operator {
+		case clusterv1beta1.TolerationOpExists:
+			if toleration.Key != "" && toleration.Key != taint.Key {
+				continue
+			}
+		default: // Equal
+			if toleration.Key != taint.Key || toleration.Value != taint.Value {
+				continue
+			}
+		}
+		// the toleration expires after the tolerationSeconds since the taint is added
+		if toleration.TolerationSeconds != nil &&
+			now.After(taint.TimeAdded.Add(time.Duration(*toleration.TolerationSeconds)*time.Second)) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// prioritizerScores returns the prioritizers of the placement with the AddOnPlacementScores of the cluster
+func (h *hubPlacement) prioritizerScores(now time.Time) []prioritizerScore {
+	policy := h.placement.Spec.PrioritizerPolicy
+	scores := []prioritizerScore{}
+	configured := map[string]bool{}
+	for _, config := range policy.Configurations {
+		coordinate := config.ScoreCoordinate
+		if coordinate == nil {
+			continue
+		}
+		if coordinate.Type == clusterv1beta1.ScoreCoordinateTypeAddOn && coordinate.AddOn != nil {
+			scores = append(scores, prioritizerScore{
+				Prioritizer: coordinate.AddOn.ResourceName + "/" + coordinate.AddOn.ScoreName,
+				Weight:      config.Weight,
+				Score:       h.addOnScore(coordinate.AddOn, now),
+			})
+			continue
+		}
+		configured[coordinate.BuiltIn] = true
+		scores = append(scores, prioritizerScore{Prioritizer: coordinate.BuiltIn, Weight: config.Weight})
+	}
+	if policy.Mode != clusterv1beta1.PrioritizerPolicyModeExact {
+		for _, prioritizer := range defaultPrioritizers {
+			if !configured[prioritizer] {
+				scores = append(scores, prioritizerScore{Prioritizer: prioritizer, Weight: 1})
+			}
+		}
+	}
+	return scores
+}
+
+func (h *hubPlacement) addOnScore(addOn *clusterv1beta1.AddOnScore, now time.Time) *int32 {
+	for _, score := range h.scores {
+		if score.Name != addOn.ResourceName {
+			continue
+		}
+		if score.Status.ValidUntil != nil && now.After(score.Status.ValidUntil.Time) {
+			return nil
+		}
+		for _, item := range score.Status.Scores {
+			if item.Name == addOn.ScoreName {
+				value := item.Value
+				return &value
+			}
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
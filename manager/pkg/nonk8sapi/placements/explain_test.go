@@ -0,0 +1,136 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package placements
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clustersv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+)
+
+func TestExplain(t *testing.T) {
+	now := time.Now()
+	numberOfClusters := int32(1)
+	newHubPlacement := func() *hubPlacement {
+		return &hubPlacement{
+			leafHubName: "hub1",
+			placement: &clusterv1beta1.Placement{
+				ObjectMeta: metav1.ObjectMeta{Name: "placement1", Namespace: "app"},
+				Spec: clusterv1beta1.PlacementSpec{
+					ClusterSets:      []string{"production"},
+					NumberOfClusters: &numberOfClusters,
+					Predicates: []clusterv1beta1.ClusterPredicate{{
+						RequiredClusterSelector: clusterv1beta1.ClusterSelector{
+							LabelSelector: metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+						},
+					}},
+					PrioritizerPolicy: clusterv1beta1.PrioritizerPolicy{
+						Configurations: []clusterv1beta1.PrioritizerConfig{{
+							ScoreCoordinate: &clusterv1beta1.ScoreCoordinate{
+								Type:  clusterv1beta1.ScoreCoordinateTypeAddOn,
+								AddOn: &clusterv1beta1.AddOnScore{ResourceName: "resource-usage", ScoreName: "cpu"},
+							},
+							Weight: 2,
+						}},
+					},
+				},
+				Status: clusterv1beta1.PlacementStatus{NumberOfSelectedClusters: 1},
+			},
+			cluster: &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Labels: map[string]string{
+					clusterv1beta2.ClusterSetLabel: "production",
+					"env":                          "prod",
+				}},
+			},
+			clusterSets: map[string]*clusterv1beta2.ManagedClusterSelector{
+				"production": {SelectorType: clusterv1beta2.ExclusiveClusterSetLabel},
+				"global":     {SelectorType: clusterv1beta2.LabelSelector, LabelSelector: &metav1.LabelSelector{}},
+			},
+			boundClusterSets: []string{"production", "global"},
+			scores: []clustersv1alpha1.AddOnPlacementScore{{
+				ObjectMeta: metav1.ObjectMeta{Name: "resource-usage", Namespace: "cluster1"},
+				Status: clustersv1alpha1.AddOnPlacementScoreStatus{
+					Scores: []clustersv1alpha1.AddOnPlacementScoreItem{{Name: "cpu", Value: 80}},
+				},
+			}},
+		}
+	}
+
+	// the cluster passes all the steps, but the placement already selects enough clusters
+	explanation := newHubPlacement().explain(now)
+	assert.False(t, explanation.Selected)
+	for _, step := range explanation.Steps {
+		assert.True(t, step.Passed, step.Name)
+	}
+	assert.Equal(t, "the cluster is a member of the cluster sets production", explanation.Steps[0].Message)
+	assert.Contains(t, explanation.Reason, "the placement only selects 1 clusters")
+	assert.Len(t, explanation.Scores, 3)
+	assert.Equal(t, "resource-usage/cpu", explanation.Scores[0].Prioritizer)
+	assert.Equal(t, int32(2), explanation.Scores[0].Weight)
+	assert.Equal(t, int32(80), *explanation.Scores[0].Score)
+	assert.Equal(t, "Balance", explanation.Scores[1].Prioritizer)
+	assert.Nil(t, explanation.Scores[1].Score)
+
+	// the expired score isn't used
+	hub := newHubPlacement()
+	hub.scores[0].Status.ValidUntil = &metav1.Time{Time: now.Add(-time.Minute)}
+	assert.Nil(t, hub.explain(now).Scores[0].Score)
+
+	// the cluster isn't in the cluster sets of the placement
+	hub = newHubPlacement()
+	hub.cluster.Labels[clusterv1beta2.ClusterSetLabel] = "dev"
+	explanation = hub.explain(now)
+	assert.False(t, explanation.Steps[0].Passed)
+	assert.Equal(t, "the cluster isn't a member of the cluster sets production", explanation.Reason)
+
+	// the cluster set isn't bound to the namespace of the placement
+	hub = newHubPlacement()
+	hub.boundClusterSets = []string{"global"}
+	assert.Equal(t, "none of the cluster sets production is bound to the namespace app", hub.explain(now).Reason)
+
+	// the cluster doesn't match the predicate
+	hub = newHubPlacement()
+	hub.cluster.Labels["env"] = "dev"
+	explanation = hub.explain(now)
+	assert.False(t, explanation.Steps[1].Passed)
+	assert.Equal(t, "the cluster doesn't match any predicate: predicate 0 requires the labels env=prod",
+		explanation.Reason)
+
+	// the cluster has the taint which isn't tolerated
+	hub = newHubPlacement()
+	hub.cluster.Spec.Taints = []clusterv1.Taint{{
+		Key:       clusterv1.ManagedClusterTaintUnreachable,
+		Effect:    clusterv1.TaintEffectNoSelect,
+		TimeAdded: metav1.Time{Time: now.Add(-time.Hour)},
+	}}
+	explanation = hub.explain(now)
+	assert.False(t, explanation.Steps[2].Passed)
+	assert.Contains(t, explanation.Reason, "the taints of the cluster aren't tolerated")
+
+	// the toleration is expired after the tolerationSeconds
+	tolerationSeconds := int64(60)
+	hub.placement.Spec.Tolerations = []clusterv1beta1.Toleration{{
+		Key:               clusterv1.ManagedClusterTaintUnreachable,
+		Operator:          clusterv1beta1.TolerationOpExists,
+		TolerationSeconds: &tolerationSeconds,
+	}}
+	assert.False(t, hub.explain(now).Steps[2].Passed)
+	hub.placement.Spec.Tolerations[0].TolerationSeconds = nil
+	assert.True(t, hub.explain(now).Steps[2].Passed)
+
+	// the selected cluster doesn't match the placement after its labels are changed
+	hub = newHubPlacement()
+	hub.decided = true
+	assert.Equal(t, "the cluster is selected by the placement", hub.explain(now).Reason)
+	hub.cluster.Labels["env"] = "dev"
+	explanation = hub.explain(now)
+	assert.True(t, explanation.Selected)
+	assert.Contains(t, explanation.Reason, "it doesn't pass the Predicates step now")
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package placements
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clustersv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const serverInternalErrorMsg = "internal error"
+
+// the placements of the namespace and the name on the managed hubs, the global placements are only stored if the global
+// resource is enabled
+var (
+	localPlacementsSQL = `
+		SELECT leaf_hub_name, payload, false FROM local_spec.placements
+		WHERE namespace = @namespace AND name = @name AND (@hub = '' OR leaf_hub_name = @hub)`
+	globalPlacementsSQL = `
+		SELECT leaf_hub_name, payload, true FROM status.placements
+		WHERE payload -> 'metadata' ->> 'namespace' = @namespace AND payload -> 'metadata' ->> 'name' = @name
+			AND (@hub = '' OR leaf_hub_name = @hub)`
+)
+
+// the PlacementDecisions of the placement on the hub
+var (
+	localDecisionsSQL = `
+		SELECT payload -> 'status' -> 'decisions' FROM local_status.placementdecisions
+		WHERE leaf_hub_name = @hub AND namespace = @namespace AND placement_name = @name`
+	globalDecisionsSQL = `
+		SELECT payload -> 'status' -> 'decisions' FROM status.placementdecisions
+		WHERE leaf_hub_name = @hub AND payload -> 'metadata' ->> 'namespace' = @namespace
+			AND payload -> 'metadata' -> 'labels' ->> 'cluster.open-cluster-management.io/placement' = @name`
+)
+
+var (
+	clusterSQL = `
+		SELECT payload FROM status.managed_clusters
+		WHERE leaf_hub_name = @hub AND cluster_name = @cluster AND deleted_at IS NULL`
+	clusterSetsSQL = `
+		SELECT name, payload -> 'spec' -> 'clusterSelector' FROM status.managed_cluster_sets
+		WHERE leaf_hub_name = @hub`
+	clusterSetBindingsSQL = `
+		SELECT cluster_set FROM status.managed_cluster_set_bindings
+		WHERE leaf_hub_name = @hub AND namespace = @namespace`
+	scoresSQL = `
+		SELECT payload FROM status.addon_placement_scores
+		WHERE leaf_hub_name = @hub AND cluster_name = @cluster`
+)
+
+// ExplainPlacement godoc
+// @summary explain placement
+// @description explain why the managed cluster is or isn't selected by the placement of the namespace and the name on each managed hub, with the results of the scheduling steps and the AddOnPlacementScores of the cluster
+// @accept json
+// @produce json
+// @param        namespace      path      string  true   "Namespace of the placement"
+// @param        name           path      string  true   "Name of the placement"
+// @param        cluster        query     string  true   "Name of the managed cluster"
+// @param        leafHubName    query     string  false  "only explain the placement of the managed hub"
+// @success      200  {object}    []placementExplanation
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      404
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /placement/{namespace}/{name}/explanation [get]
+func ExplainPlacement() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		args := map[string]interface{}{
+			"namespace": ginCtx.Param("namespace"),
+			"name":      ginCtx.Param("name"),
+			"cluster":   ginCtx.Query("cluster"),
+			"hub":       ginCtx.Query("leafHubName"),
+		}
+		if args["cluster"] == "" {
+			ginCtx.String(http.StatusBadRequest, "the cluster is required")
+			return
+		}
+		fmt.Fprintf(gin.DefaultWriter, "placement explanation query: %v\n", args)
+
+		placementFound, explanations, err := explainPlacement(args, authorization.GetScope(ginCtx))
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in explaining placement: %v\n", err)
+			return
+		}
+		if !placementFound {
+			ginCtx.String(http.StatusNotFound, "placement %s/%s is not found", args["namespace"], args["name"])
+			return
+		}
+		if len(explanations) == 0 {
+			ginCtx.String(http.StatusNotFound, "cluster %s is not found on the hubs of the placement", args["cluster"])
+			return
+		}
+		ginCtx.JSON(http.StatusOK, explanations)
+	}
+}
+
+// explainPlacement returns whether the placement is found, and the explanations of the hubs where the cluster is
+// managed, only the clusters that the user is allowed to access are explained
+func explainPlacement(args map[string]interface{}, scope *authorization.Scope,
+) (bool, []*placementExplanation, error) {
+	db := database.GetReadonlyGorm()
+	global, err := tableExists(db, "status.placements")
+	if err != nil {
+		return false, nil, err
+	}
+	placementsSQL, decisionsSQL := localPlacementsSQL, localDecisionsSQL
+	if global {
+		placementsSQL += " UNION ALL " + globalPlacementsSQL
+		decisionsSQL += " UNION ALL " + globalDecisionsSQL
+	}
+
+	hubPlacements, err := queryPlacements(db, placementsSQL, args)
+	if err != nil {
+		return false, nil, err
+	}
+
+	explanations := []*placementExplanation{}
+	now := time.Now()
+	for _, hub := range hubPlacements {
+		if !scope.Allows(hub.leafHubName, args["cluster"].(string)) {
+			continue
+		}
+		hubArgs := map[string]interface{}{
+			"hub":       hub.leafHubName,
+			"namespace": args["namespace"],
+			"name":      args["name"],
+			"cluster":   args["cluster"],
+		}
+		found, err := hub.load(db, decisionsSQL, hubArgs)
+		if err != nil {
+			return true, nil, fmt.Errorf("failed to query the placement of the hub %s: %w", hub.leafHubName, err)
+		}
+		if found {
+			explanations = append(explanations, hub.explain(now))
+		}
+	}
+	return len(hubPlacements) > 0, explanations, nil
+}
+
+func tableExists(db *gorm.DB, table string) (bool, error) {
+	var exists bool
+	err := db.Raw("SELECT to_regclass(?) IS NOT NULL", table).Row().Scan(&exists)
+	return exists, err
+}
+
+func queryPlacements(db *gorm.DB, placementsSQL string, args map[string]interface{}) ([]*hubPlacement, error) {
+	rows, err := db.Raw(placementsSQL+" ORDER BY 1", args).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hubPlacements := []*hubPlacement{}
+	for rows.Next() {
+		hub := &hubPlacement{placement: &clusterv1beta1.Placement{}}
+		var payload []byte
+		if err := rows.Scan(&hub.leafHubName, &payload, &hub.global); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, hub.placement); err != nil {
+			return nil, fmt.Errorf("failed to parse the placement of the hub %s: %w", hub.leafHubName, err)
+		}
+		hubPlacements = append(hubPlacements, hub)
+	}
+	return hubPlacements, rows.Err()
+}
+
+// load loads the cluster, the cluster sets, the decisions and the scores of the hub, it returns false if the cluster
+// isn't managed by the hub
+func (h *hubPlacement) load(db *gorm.DB, decisionsSQL string, args map[string]interface{}) (bool, error) {
+	var clusterPayload []byte
+	err := db.Raw(clusterSQL, args).Row().Scan(&clusterPayload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	h.cluster = &clusterv1.ManagedCluster{}
+	if err := json.Unmarshal(clusterPayload, h.cluster); err != nil {
+		return false, fmt.Errorf("failed to parse the cluster: %w", err)
+	}
+
+	if err := h.loadClusterSets(db, args); err != nil {
+		return false, err
+	}
+	if err := h.loadDecisions(db, decisionsSQL, args); err != nil {
+		return false, err
+	}
+	return true, h.loadScores(db, args)
+}
+
+func (h *hubPlacement) loadClusterSets(db *gorm.DB, args map[string]interface{}) error {
+	rows, err := db.Raw(clusterSetsSQL, args).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	h.clusterSets = map[string]*clusterv1beta2.ManagedClusterSelector{}
+	for rows.Next() {
+		var name string
+		var selectorJSON []byte
+		if err := rows.Scan(&name, &selectorJSON); err != nil {
+			return err
+		}
+		selector := &clusterv1beta2.ManagedClusterSelector{}
+		if len(selectorJSON) > 0 {
+			if err := json.Unmarshal(selectorJSON, selector); err != nil {
+				return fmt.Errorf("failed to parse the cluster set %s: %w", name, err)
+			}
+		}
+		h.clusterSets[name] = selector
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return db.Raw(clusterSetBindingsSQL, args).Scan(&h.boundClusterSets).Error
+}
+
+func (h *hubPlacement) loadDecisions(db *gorm.DB, decisionsSQL string, args map[string]interface{}) error {
+	rows, err := db.Raw(decisionsSQL, args).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var decisionsJSON []byte
+		if err := rows.Scan(&decisionsJSON); err != nil {
+			return err
+		}
+		if len(decisionsJSON) == 0 {
+			continue
+		}
+		decisions := []clusterv1beta1.ClusterDecision{}
+		if err := json.Unmarshal(decisionsJSON, &decisions); err != nil {
+			return fmt.Errorf("failed to parse the decisions: %w", err)
+		}
+		for _, decision := range decisions {
+			if decision.ClusterName == h.cluster.Name {
+				h.decided = true
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (h *hubPlacement) loadScores(db *gorm.DB, args map[string]interface{}) error {
+	rows, err := db.Raw(scoresSQL, args).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var payload []byte
+		if err := rows.Scan(&payload); err != nil {
+			return err
+		}
+		score := clustersv1alpha1.AddOnPlacementScore{}
+		if err := json.Unmarshal(payload, &score); err != nil {
+			return fmt.Errorf("failed to parse the addon placement score: %w", err)
+		}
+		h.scores = append(h.scores, score)
+	}
+	return rows.Err()
+}
@@ -5,7 +5,6 @@ package policies
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
@@ -34,6 +33,7 @@ import (
 // @failure      401
 // @failure      403
 // @failure      404
+// @failure      429
 // @failure      500
 // @failure      503
 // @security     ApiKeyAuth
@@ -184,14 +184,14 @@ func queryPolicyStatus(policyID, policyQuery, policyMappingQuery,
 		return &unstructured.Unstructured{}, err
 	}
 
-	db := database.GetGorm()
+	db := database.GetReadonlyGorm()
 	var payload []byte
 	err = db.Raw(policyQuery, policyID).Row().Scan(&payload)
 	if err != nil {
 		fmt.Fprintf(gin.DefaultWriter, QueryPolicyFailureFormatMsg, err)
 		return &unstructured.Unstructured{}, err
 	}
-	err = json.Unmarshal(payload, policy)
+	err = unmarshalPolicy(payload, policy)
 	if err != nil {
 		return &unstructured.Unstructured{}, err
 	}
@@ -5,7 +5,6 @@ package policies
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -26,6 +25,7 @@ import (
 
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/util"
 	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/encryption"
 	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
 )
 
@@ -48,6 +48,15 @@ type policyMatch struct {
 var (
 	policyMatches                   = []*policyMatch{}
 	customResourceColumnDefinitions = util.GetCustomResourceColumnDefinitions(crdName, policyv1.GroupVersion.Version)
+
+	// the policies can be sorted by the name and the creation timestamp
+	policyListColumns = &util.ListColumns{
+		Name: "(payload -> 'metadata' ->> 'name')",
+		UID:  "id",
+		Sort: map[string]string{
+			"metadata.creationTimestamp": "COALESCE(payload -> 'metadata' ->> 'creationTimestamp', '')",
+		},
+	}
 )
 
 // ListPolicies godoc
@@ -58,11 +67,14 @@ var (
 // @param        labelSelector    query     string  false  "list policies by label selector"
 // @param        limit            query     int     false  "maximum policy number to receive"
 // @param        continue         query     string  false  "continue token to request next request"
+// @param        sortBy           query     string  false  "sort policies by metadata.name or metadata.creationTimestamp, prefixed with - for the descending order"
+// @param        fields           query     []string  false  "return only the fields of policies, e.g. metadata.name,status.complianceState"
 // @success      200  {object}    policyv1.PolicyList
 // @failure      400
 // @failure      401
 // @failure      403
 // @failure      404
+// @failure      429
 // @failure      500
 // @failure      503
 // @security     ApiKeyAuth
@@ -76,6 +88,7 @@ func ListPolicies() gin.HandlerFunc {
 			var err error
 			selectorInSql, err = util.ParseLabelSelector(labelSelector)
 			if err != nil {
+				ginCtx.String(http.StatusBadRequest, err.Error())
 				fmt.Fprintf(gin.DefaultWriter, "failed to parse label selector: %s\n", err.Error())
 				return
 			}
@@ -83,66 +96,46 @@ func ListPolicies() gin.HandlerFunc {
 
 		fmt.Fprintf(gin.DefaultWriter, "parsed selector: %s\n", selectorInSql)
 
-		limit := ginCtx.Query("limit")
-		fmt.Fprintf(gin.DefaultWriter, "limit: %v\n", limit)
-
-		continueToken := ginCtx.Query("continue")
-
-		lastPolicyName, lastPolicyUID := "", ""
-		if continueToken != "" {
-			fmt.Fprintf(gin.DefaultWriter, "continue: %v\n", continueToken)
-
-			var err error
-			lastPolicyName, lastPolicyUID, err = util.DecodeContinue(continueToken)
-			if err != nil {
-				fmt.Fprintf(gin.DefaultWriter, "failed to decode continue token: %s\n", err.Error())
-				return
-			}
+		listOptions, err := util.ParseListOptions(ginCtx, policyListColumns)
+		if err != nil {
+			ginCtx.String(http.StatusBadRequest, err.Error())
+			fmt.Fprintf(gin.DefaultWriter, "failed to parse list options: %s\n", err.Error())
+			return
 		}
 
-		fmt.Fprintf(gin.DefaultWriter,
-			"last returned policy name: %s, last returned policy] UID: %s\n",
-			lastPolicyName,
-			lastPolicyUID)
+		fmt.Fprintf(gin.DefaultWriter, "limit: %v, sortBy: %v, fields: %v\n", listOptions.Limit,
+			ginCtx.Query("sortBy"), listOptions.Fields)
 
 		// build query condition for paging
-		LastResourceCompareCondition := fmt.Sprintf(
-			"(payload -> 'metadata' ->> 'name', payload -> 'metadata' ->> 'uid') > ('%s', '%s') ",
-			lastPolicyName,
-			lastPolicyUID)
-
-		// policy list query order by name and uid
-		policyListQuery := "SELECT id, payload FROM spec.policies WHERE deleted = FALSE AND " +
-			LastResourceCompareCondition +
-			selectorInSql +
-			" ORDER BY (payload -> 'metadata' ->> 'name', payload -> 'metadata' ->> 'uid')"
-
-		// add limit
-		if limit != "" {
-			policyListQuery += fmt.Sprintf(" LIMIT %s", limit)
-		}
+		lastResourceCompareCondition, policyListArgs := listOptions.Condition()
+		limitInSql, limitArgs := listOptions.LimitClause()
+		policyListArgs = append(policyListArgs, limitArgs...)
 
-		// last policy order by name and uid query
-		lastPolicyQuery := "SELECT id, payload FROM spec.policies WHERE deleted = FALSE " +
-			"ORDER BY (payload -> 'metadata' ->> 'name', payload -> 'metadata' ->> 'uid') DESC LIMIT 1"
+		// policy list query order by the sort key, name and id
+		policyQuery := "FROM spec.policies WHERE deleted = FALSE" +
+			selectorInSql +
+			lastResourceCompareCondition +
+			listOptions.OrderBy() +
+			limitInSql
 
-		fmt.Fprintf(gin.DefaultWriter, "last policy query: %v\n", lastPolicyQuery)
-		fmt.Fprintf(gin.DefaultWriter, "policy list query: %v\n", policyListQuery)
+		fmt.Fprintf(gin.DefaultWriter, "policy list query: %v\n", policyQuery)
 		fmt.Fprintf(gin.DefaultWriter, "policy compliance query with policy ID: %v\n", policyComplianceQuery)
 		fmt.Fprintf(gin.DefaultWriter, "policy&placementbinding&placementrule mapping query: %v\n", policyMappingQuery)
 
 		if _, watch := ginCtx.GetQuery("watch"); watch {
-			handlePoliciesForWatch(ginCtx, policyListQuery, policyMappingQuery, policyComplianceQuery)
+			handlePoliciesForWatch(ginCtx, "SELECT id, payload "+policyQuery, policyListArgs, policyMappingQuery,
+				policyComplianceQuery)
 			return
 		}
 
-		handlePolicies(ginCtx, policyListQuery, lastPolicyQuery, policyMappingQuery,
+		policyListQuery := fmt.Sprintf("SELECT id, payload, %s %s", listOptions.SortColumn(), policyQuery)
+		handlePolicies(ginCtx, policyListQuery, policyListArgs, listOptions, policyMappingQuery,
 			policyComplianceQuery, customResourceColumnDefinitions)
 	}
 }
 
-func handlePoliciesForWatch(ginCtx *gin.Context, policyListQuery, policyMappingQuery,
-	policyComplianceQuery string,
+func handlePoliciesForWatch(ginCtx *gin.Context, policyListQuery string, policyListArgs []interface{},
+	policyMappingQuery, policyComplianceQuery string,
 ) {
 	writer := ginCtx.Writer
 	header := writer.Header()
@@ -172,22 +165,22 @@ func handlePoliciesForWatch(ginCtx *gin.Context, policyListQuery, policyMappingQ
 				return
 			}
 
-			doHandlePoliciesForWatch(ctx, writer, policyListQuery, policyMappingQuery,
+			doHandlePoliciesForWatch(ctx, writer, policyListQuery, policyListArgs, policyMappingQuery,
 				policyComplianceQuery, preAddedPolicies)
 		}
 	}
 }
 
-func doHandlePoliciesForWatch(ctx context.Context, writer gin.ResponseWriter,
-	policyListQuery, policyMappingQuery, policyComplianceQuery string, preAddedPolicies set.Set,
+func doHandlePoliciesForWatch(ctx context.Context, writer gin.ResponseWriter, policyListQuery string,
+	policyListArgs []interface{}, policyMappingQuery, policyComplianceQuery string, preAddedPolicies set.Set,
 ) {
 	var err error
 	policyMatches, err = getPolicyMatches(policyMappingQuery)
 	if err != nil {
 		fmt.Fprintf(gin.DefaultWriter, QueryPolicyMappingFailureFormatMsg, err)
 	}
-	db := database.GetGorm()
-	policyRows, err := db.Raw(policyListQuery).Rows()
+	db := database.GetReadonlyGorm()
+	policyRows, err := db.Raw(policyListQuery, policyListArgs...).Rows()
 	if err != nil {
 		fmt.Fprintf(gin.DefaultWriter, QueryPoliciesFailureFormatMsg, err)
 	}
@@ -203,7 +196,7 @@ func doHandlePoliciesForWatch(ctx context.Context, writer gin.ResponseWriter,
 			continue
 		}
 
-		if err := json.Unmarshal(payload, policy); err != nil {
+		if err := unmarshalPolicy(payload, policy); err != nil {
 			fmt.Fprintf(gin.DefaultWriter, "error in scanning a policyPayload: %v\n", err)
 			continue
 		}
@@ -292,38 +285,25 @@ func sendPolicyWatchEvent(writer io.Writer, policy *policyv1.Policy, eventType,
 	}, writer)
 }
 
-func handlePolicies(ginCtx *gin.Context, policyListQuery, lastPolicyQuery,
-	policyMappingQuery, policyComplianceQuery string,
+func handlePolicies(ginCtx *gin.Context, policyListQuery string, policyListArgs []interface{},
+	listOptions *util.ListOptions, policyMappingQuery, policyComplianceQuery string,
 	customResourceColumnDefinitions []apiextensionsv1.CustomResourceColumnDefinition,
 ) {
-	db := database.GetGorm()
-	lastPolicy := &policyv1.Policy{}
-	lastPolicyID := ""
-	var lastPolicyPayload []byte
-	err := db.Raw(lastPolicyQuery).Row().Scan(&lastPolicyID, &lastPolicyPayload)
-	if err != nil && err != sql.ErrNoRows {
-		ginCtx.String(http.StatusInternalServerError, ServerInternalErrorMsg)
-		fmt.Fprintf(gin.DefaultWriter, "error in querying last policy: %v\n", err)
-		return
-	}
-
-	if err == nil {
-		if e := json.Unmarshal(lastPolicyPayload, lastPolicy); e != nil {
-			fmt.Fprintf(gin.DefaultWriter, "error in querying last policy payload: %v\n", e)
-			return
-		}
-	}
+	db := database.GetReadonlyGorm()
 
+	var err error
 	policyMatches, err = getPolicyMatches(policyMappingQuery)
 	if err != nil {
 		ginCtx.String(http.StatusInternalServerError, ServerInternalErrorMsg)
 		fmt.Fprintf(gin.DefaultWriter, QueryPolicyMappingFailureFormatMsg, err)
+		return
 	}
 
-	policyRows, err := db.Raw(policyListQuery).Rows()
+	policyRows, err := db.Raw(policyListQuery, policyListArgs...).Rows()
 	if err != nil {
 		ginCtx.String(http.StatusInternalServerError, ServerInternalErrorMsg)
 		fmt.Fprintf(gin.DefaultWriter, QueryPoliciesFailureFormatMsg, err)
+		return
 	}
 	defer policyRows.Close()
 
@@ -334,15 +314,23 @@ func handlePolicies(ginCtx *gin.Context, policyListQuery, lastPolicyQuery,
 		},
 		Items: []unstructured.Unstructured{},
 	}
-	policyName, policyUID := "", ""
+	hasNext := false
+	lastPolicyName, lastPolicyUID, lastSortValue := "", "", ""
 	for policyRows.Next() {
+		// one more policy than the limit is queried to know if there is a next page
+		if listOptions.HasNext(len(unstrPolicyList.Items) + 1) {
+			hasNext = true
+			break
+		}
+
+		var policyUID, sortValue string
 		var policyPayload []byte
-		if err := policyRows.Scan(&policyUID, &policyPayload); err != nil {
+		if err := policyRows.Scan(&policyUID, &policyPayload, &sortValue); err != nil {
 			fmt.Fprintf(gin.DefaultWriter, "error in scanning a policy: %v\n", err)
 			continue
 		}
 		policy := &policyv1.Policy{}
-		if err := json.Unmarshal(policyPayload, policy); err != nil {
+		if err := unmarshalPolicy(policyPayload, policy); err != nil {
 			fmt.Fprintf(gin.DefaultWriter, "error in Unmarshal a policyPayload : %v\n", err)
 			continue
 		}
@@ -361,17 +349,13 @@ func handlePolicies(ginCtx *gin.Context, policyListQuery, lastPolicyQuery,
 		}
 
 		unstrPolicyList.Items = append(unstrPolicyList.Items, unstrPolicy)
-		policyName = policy.GetName()
+		lastPolicyName, lastPolicyUID, lastSortValue = policy.GetName(), policyUID, sortValue
 	}
 
-	if policyUID != "" &&
-		lastPolicyID != "" &&
-		policyUID != lastPolicyID &&
-		policyName != "" &&
-		lastPolicy.GetName() != "" &&
-		policyName != lastPolicy.GetName() {
-		continueToken, err := util.EncodeContinue(policyName, policyUID)
+	if hasNext {
+		continueToken, err := listOptions.NextContinue(lastPolicyName, lastPolicyUID, lastSortValue)
 		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, ServerInternalErrorMsg)
 			fmt.Fprintf(gin.DefaultWriter, "error in encoding the continue token: %v\n", err)
 			return
 		}
@@ -407,6 +391,17 @@ func handlePolicies(ginCtx *gin.Context, policyListQuery, lastPolicyQuery,
 		return
 	}
 
+	if len(listOptions.Fields) > 0 {
+		selectedList, err := util.SelectFields(unstrPolicyList, listOptions.Fields)
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, ServerInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in selecting the fields of policies: %v\n", err)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, selectedList)
+		return
+	}
+
 	ginCtx.JSON(http.StatusOK, unstrPolicyList)
 }
 
@@ -414,7 +409,7 @@ func handlePolicies(ginCtx *gin.Context, policyListQuery, lastPolicyQuery,
 func getPolicyMatches(policyMappingQuery string) ([]*policyMatch, error) {
 	policyMatches := []*policyMatch{}
 
-	db := database.GetGorm()
+	db := database.GetReadonlyGorm()
 	policyMatchRows, err := db.Raw(policyMappingQuery).Rows()
 	if err != nil {
 		return policyMatches,
@@ -444,7 +439,7 @@ func getComplianceStatus(policyComplianceQuery, policyID string,
 	compliancePerClusterStatuses := []*policyv1.CompliancePerClusterStatus{}
 	hasNonCompliantClusters := false
 
-	db := database.GetGorm()
+	db := database.GetReadonlyGorm()
 	var statusCompliances []models.StatusCompliance
 	err := db.Where(&models.StatusCompliance{
 		PolicyID: policyID,
@@ -533,6 +528,15 @@ func assemblePolicyStatus(policy *policyv1.Policy, policyMatches []*policyMatch,
 	return unstrPolicy, nil
 }
 
+// unmarshalPolicy decrypts the payload of the policy if it's encrypted
+func unmarshalPolicy(payload []byte, policy *policyv1.Policy) error {
+	payload, err := encryption.DecryptPayload(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, policy)
+}
+
 func wrapObjectsInList(uns []unstructured.Unstructured) (*corev1.List, error) {
 	list := &corev1.List{
 		TypeMeta: metav1.TypeMeta{
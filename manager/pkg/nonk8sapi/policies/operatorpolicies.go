@@ -0,0 +1,56 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package policies
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+// the fields of the OperatorPolicy status that are used, the message is the one of the Compliant condition
+type operatorPolicyStatus struct {
+	ComplianceState string `json:"compliant"`
+	Conditions      []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"conditions"`
+}
+
+// ListOperatorPolicies godoc
+// @summary list operatorpolicies
+// @description list the compliance of the OperatorPolicies propagated from the global hub to the managed hubs
+// @accept json
+// @produce json
+// @param        leafHubName    query     string  false  "only return the compliance of the managed hub"
+// @success      200  {object}    []aggregatedPolicy
+// @failure      401
+// @failure      403
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /operatorpolicies [get]
+func ListOperatorPolicies() gin.HandlerFunc {
+	return listAggregatedPolicies(database.OperatorPoliciesTableName, newOperatorPolicyCompliance)
+}
+
+// newOperatorPolicyCompliance returns the compliance of the OperatorPolicy on the hub
+func newOperatorPolicyCompliance(hub string, statusJSON []byte) (*hubCompliance, error) {
+	status := &operatorPolicyStatus{}
+	if len(statusJSON) > 0 {
+		if err := json.Unmarshal(statusJSON, status); err != nil {
+			return nil, err
+		}
+	}
+	compliance := &hubCompliance{LeafHubName: hub, Compliant: complianceState(status.ComplianceState)}
+	for _, condition := range status.Conditions {
+		if condition.Type == "Compliant" {
+			compliance.Message = condition.Message
+		}
+	}
+	return compliance, nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package policies
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	policyv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authorization"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+// the global resources and the status reported by each managed hub, the row of the hub is the resource propagated
+// from the global hub, so it has the same id
+const aggregatedStatusSQL = `
+	SELECT s.id::text, s.payload -> 'metadata' ->> 'name' AS name, s.payload -> 'metadata' ->> 'namespace' AS namespace,
+		COALESCE(h.leaf_hub_name, '') AS leaf_hub_name, h.payload -> 'status'
+	FROM spec.%[1]s s LEFT JOIN status.%[1]s h
+		ON h.id = s.id AND (@hub = '' OR h.leaf_hub_name = @hub)
+	WHERE s.deleted = false
+	ORDER BY namespace, name, leaf_hub_name`
+
+type aggregatedPolicy struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// ComplianceSummary is the count of the managed hubs in each compliance state
+	ComplianceSummary map[string]int  `json:"complianceSummary"`
+	Hubs              []hubCompliance `json:"hubs"`
+}
+
+type hubCompliance struct {
+	LeafHubName string `json:"leafHubName"`
+	// Compliant is Compliant, NonCompliant or Pending
+	Compliant string `json:"compliant"`
+	// Message is the status message of the resource on the hub
+	Message string `json:"message,omitempty"`
+}
+
+// the fields of the PolicySet status that are used
+type policySetStatus struct {
+	Compliant     string `json:"compliant"`
+	StatusMessage string `json:"statusMessage"`
+}
+
+// ListPolicySets godoc
+// @summary list policysets
+// @description list the compliance of the PolicySets propagated from the global hub to the managed hubs
+// @accept json
+// @produce json
+// @param        leafHubName    query     string  false  "only return the compliance of the managed hub"
+// @success      200  {object}    []aggregatedPolicy
+// @failure      401
+// @failure      403
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /policysets [get]
+func ListPolicySets() gin.HandlerFunc {
+	return listAggregatedPolicies(database.PolicySetsTableName, newPolicySetCompliance)
+}
+
+// newPolicySetCompliance returns the compliance of the PolicySet on the hub
+func newPolicySetCompliance(hub string, statusJSON []byte) (*hubCompliance, error) {
+	status := &policySetStatus{}
+	if len(statusJSON) > 0 {
+		if err := json.Unmarshal(statusJSON, status); err != nil {
+			return nil, err
+		}
+	}
+	return &hubCompliance{
+		LeafHubName: hub,
+		Compliant:   complianceState(status.Compliant),
+		Message:     status.StatusMessage,
+	}, nil
+}
+
+// listAggregatedPolicies returns the handler which lists the global resources of the table with the compliance of the
+// managed hubs, the parse function converts the status of a hub to its compliance
+func listAggregatedPolicies(table string,
+	parse func(hub string, statusJSON []byte) (*hubCompliance, error),
+) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		args := map[string]interface{}{
+			"hub": ginCtx.Query("leafHubName"),
+		}
+		fmt.Fprintf(gin.DefaultWriter, "%s query: %v\n", table, args)
+
+		policies, err := queryAggregatedPolicies(table, parse, args, authorization.GetScope(ginCtx))
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, ServerInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying %s: %v\n", table, err)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, policies)
+	}
+}
+
+// queryAggregatedPolicies returns the global resources with the compliance of the managed hubs that the user is
+// allowed to access
+func queryAggregatedPolicies(table string, parse func(hub string, statusJSON []byte) (*hubCompliance, error),
+	args map[string]interface{}, scope *authorization.Scope,
+) ([]*aggregatedPolicy, error) {
+	rows, err := database.GetReadonlyGorm().Raw(fmt.Sprintf(aggregatedStatusSQL, table), args).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []*aggregatedPolicy{}
+	var policy *aggregatedPolicy
+	for rows.Next() {
+		var id, name, namespace, hub string
+		var status []byte
+		if err := rows.Scan(&id, &name, &namespace, &hub, &status); err != nil {
+			return nil, err
+		}
+		if policy == nil || policy.ID != id {
+			policy = &aggregatedPolicy{
+				ID:                id,
+				Name:              name,
+				Namespace:         namespace,
+				ComplianceSummary: map[string]int{},
+				Hubs:              []hubCompliance{},
+			}
+			policies = append(policies, policy)
+		}
+		// the resource isn't reported by any hub yet
+		if hub == "" || !scope.AllowsHub(hub) {
+			continue
+		}
+		compliance, err := parse(hub, status)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the status of %s/%s of the hub %s: %w", namespace, name, hub, err)
+		}
+		policy.Hubs = append(policy.Hubs, *compliance)
+		policy.ComplianceSummary[compliance.Compliant]++
+	}
+	return policies, rows.Err()
+}
+
+// complianceState returns Pending if the resource isn't evaluated on the hub yet
+func complianceState(compliant string) string {
+	if compliant == "" {
+		return string(policyv1.Pending)
+	}
+	return compliant
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package policies
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubCompliance(t *testing.T) {
+	cases := []struct {
+		name     string
+		parse    func(string, []byte) (*hubCompliance, error)
+		status   string
+		expected hubCompliance
+	}{
+		{
+			name:     "policyset",
+			parse:    newPolicySetCompliance,
+			status:   `{"compliant":"NonCompliant","statusMessage":"Disabled policies: p1"}`,
+			expected: hubCompliance{LeafHubName: "hub1", Compliant: "NonCompliant", Message: "Disabled policies: p1"},
+		},
+		{
+			name:     "policyset isn't evaluated",
+			parse:    newPolicySetCompliance,
+			expected: hubCompliance{LeafHubName: "hub1", Compliant: "Pending"},
+		},
+		{
+			name:  "operatorpolicy",
+			parse: newOperatorPolicyCompliance,
+			status: `{"compliant":"Compliant","conditions":[{"type":"InstallPlanCurrent","message":"no upgrade"},` +
+				`{"type":"Compliant","message":"the Subscription matches"}],"relatedObjects":[]}`,
+			expected: hubCompliance{LeafHubName: "hub1", Compliant: "Compliant", Message: "the Subscription matches"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var status []byte
+			if c.status != "" {
+				status = []byte(c.status)
+			}
+			compliance, err := c.parse("hub1", status)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, *compliance)
+		})
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package purge
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authentication"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+const (
+	serverInternalErrorMsg = "internal error"
+
+	TargetCluster = "cluster"
+	TargetHub     = "hub"
+
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+
+	// the confirmation token must be sent back within the duration, otherwise the request is expired
+	confirmationTimeout = 10 * time.Minute
+	tokenBytes          = 16
+)
+
+type purgeRequest struct {
+	// Type is the purge target, "cluster" or "hub"
+	Type        string `json:"type" binding:"required"`
+	LeafHubName string `json:"leafHubName" binding:"required"`
+	// ClusterName is required when the type is "cluster"
+	ClusterName string `json:"clusterName"`
+}
+
+type purgeConfirmation struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type purgeResponse struct {
+	ID                string           `json:"id"`
+	Type              string           `json:"type"`
+	LeafHubName       string           `json:"leafHubName"`
+	ClusterName       string           `json:"clusterName,omitempty"`
+	Status            string           `json:"status"`
+	ConfirmationToken string           `json:"confirmationToken,omitempty"`
+	ExpiresAt         *time.Time       `json:"expiresAt,omitempty"`
+	Rows              map[string]int64 `json:"rows,omitempty"`
+	Error             string           `json:"error,omitempty"`
+}
+
+// RequestPurge godoc
+// @summary request a data purge
+// @description create a pending purge for all the data of a managed cluster or managed hub, the response contains
+// @description the rows to be deleted and a confirmation token which is required to confirm the purge,
+// @description the audit logs of the API calls on the target are purged too
+// @accept json
+// @produce json
+// @param        purge        body    purgeRequest     true    "The cluster or hub to purge"
+// @success      202  {object}    purgeResponse
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      404
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /purges [post]
+func RequestPurge() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		request := &purgeRequest{}
+		if err := ginCtx.BindJSON(request); err != nil {
+			fmt.Fprintf(gin.DefaultWriter, "failed to bind: %s\n", err.Error())
+			return
+		}
+		if err := validate(request); err != nil {
+			ginCtx.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		db := database.GetGorm()
+		rows, err := countRows(db, request.Type, request.LeafHubName, request.ClusterName)
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "failed to count the purge rows: %v\n", err)
+			return
+		}
+		if total(rows) == 0 {
+			ginCtx.String(http.StatusNotFound, "no data found for the %s", request.Type)
+			return
+		}
+
+		token, err := newConfirmationToken()
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "failed to generate the confirmation token: %v\n", err)
+			return
+		}
+
+		purgeLog := &models.DataPurgeLog{
+			ID:                uuid.New().String(),
+			TargetType:        request.Type,
+			LeafHubName:       request.LeafHubName,
+			ClusterName:       request.ClusterName,
+			ConfirmationToken: token,
+			Status:            StatusPending,
+			RequestedBy:       ginCtx.GetString(authentication.UserKey),
+			RequestedAt:       time.Now(),
+		}
+		if err := db.Create(purgeLog).Error; err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "failed to create the purge record: %v\n", err)
+			return
+		}
+
+		fmt.Fprintf(gin.DefaultWriter, "purge %s requested by %s for %s %s/%s\n", purgeLog.ID,
+			purgeLog.RequestedBy, request.Type, request.LeafHubName, request.ClusterName)
+
+		response := toResponse(purgeLog)
+		expiresAt := purgeLog.RequestedAt.Add(confirmationTimeout)
+		response.ConfirmationToken = token
+		response.ExpiresAt = &expiresAt
+		response.Rows = rows
+		ginCtx.JSON(http.StatusAccepted, response)
+	}
+}
+
+// ConfirmPurge godoc
+// @summary confirm a data purge
+// @description permanently delete the data of the pending purge, the token must match the confirmation token
+// @accept json
+// @produce json
+// @param        purgeID      path    string              true    "Purge ID"
+// @param        confirm      body    purgeConfirmation   true    "The confirmation token of the purge"
+// @success      200  {object}    purgeResponse
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      404
+// @failure      409
+// @failure      410
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /purge/{purgeID}/confirm [post]
+func ConfirmPurge() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		purgeID := ginCtx.Param("purgeID")
+		confirmation := &purgeConfirmation{}
+		if err := ginCtx.BindJSON(confirmation); err != nil {
+			fmt.Fprintf(gin.DefaultWriter, "failed to bind: %s\n", err.Error())
+			return
+		}
+
+		db := database.GetGorm()
+		purgeLog := &models.DataPurgeLog{}
+		if err := db.Where("id = ?", purgeID).First(purgeLog).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ginCtx.String(http.StatusNotFound, "purge %s not found", purgeID)
+				return
+			}
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "failed to get the purge record: %v\n", err)
+			return
+		}
+
+		if purgeLog.Status != StatusPending {
+			ginCtx.String(http.StatusConflict, "purge %s is already %s", purgeID, purgeLog.Status)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(purgeLog.ConfirmationToken), []byte(confirmation.Token)) != 1 {
+			ginCtx.String(http.StatusForbidden, "the confirmation token doesn't match")
+			return
+		}
+		if time.Since(purgeLog.RequestedAt) > confirmationTimeout {
+			ginCtx.String(http.StatusGone, "purge %s is expired, please request a new one", purgeID)
+			return
+		}
+
+		// claim the purge in a single statement, so the concurrent confirmations don't run it twice
+		claim := db.Model(&models.DataPurgeLog{}).
+			Where("id = ? AND confirmation_token = ? AND status = ?", purgeID, confirmation.Token, StatusPending).
+			Update("status", StatusRunning)
+		if claim.Error != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "failed to claim the purge %s: %v\n", purgeID, claim.Error)
+			return
+		}
+		if claim.RowsAffected == 0 {
+			ginCtx.String(http.StatusConflict, "purge %s is already confirmed", purgeID)
+			return
+		}
+
+		// hold the lock so that the purge won't run in the middle of a backup
+		conn := database.GetConn()
+		if err := database.Lock(conn); err != nil {
+			// the claimed purge isn't left running, it has to be requested again
+			if e := db.Model(purgeLog).Updates(map[string]interface{}{
+				"status": StatusFailed, "error": err.Error(),
+			}).Error; e != nil {
+				fmt.Fprintf(gin.DefaultWriter, "failed to update the purge record %s: %v\n", purgeID, e)
+			}
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "failed to lock the database: %v\n", err)
+			return
+		}
+		defer database.Unlock(conn)
+
+		var rows map[string]int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var e error
+			rows, e = deleteRows(tx, purgeLog.TargetType, purgeLog.LeafHubName, purgeLog.ClusterName)
+			return e
+		})
+
+		now := time.Now()
+		purgeLog.ConfirmedBy = ginCtx.GetString(authentication.UserKey)
+		purgeLog.CompletedAt = &now
+		purgeLog.Status = StatusCompleted
+		if err != nil {
+			purgeLog.Status = StatusFailed
+			purgeLog.Error = err.Error()
+		} else if payload, e := json.Marshal(rows); e == nil {
+			purgeLog.DeletedRows = payload
+		}
+		if e := db.Save(purgeLog).Error; e != nil {
+			fmt.Fprintf(gin.DefaultWriter, "failed to update the purge record %s: %v\n", purgeID, e)
+		}
+
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "failed to purge the data for %s: %v\n", purgeID, err)
+			return
+		}
+
+		fmt.Fprintf(gin.DefaultWriter, "purge %s confirmed by %s, deleted rows: %v\n", purgeID,
+			purgeLog.ConfirmedBy, rows)
+
+		response := toResponse(purgeLog)
+		response.Rows = rows
+		ginCtx.JSON(http.StatusOK, response)
+	}
+}
+
+// GetPurge godoc
+// @summary get a data purge
+// @description get the audit record of the data purge
+// @accept json
+// @produce json
+// @param        purgeID      path    string    true    "Purge ID"
+// @success      200  {object}    purgeResponse
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      404
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /purge/{purgeID} [get]
+func GetPurge() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		purgeID := ginCtx.Param("purgeID")
+
+		purgeLog := &models.DataPurgeLog{}
+		if err := database.GetGorm().Where("id = ?", purgeID).First(purgeLog).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ginCtx.String(http.StatusNotFound, "purge %s not found", purgeID)
+				return
+			}
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "failed to get the purge record: %v\n", err)
+			return
+		}
+
+		response := toResponse(purgeLog)
+		if len(purgeLog.DeletedRows) > 0 {
+			if err := json.Unmarshal(purgeLog.DeletedRows, &response.Rows); err != nil {
+				fmt.Fprintf(gin.DefaultWriter, "failed to unmarshal the deleted rows: %v\n", err)
+			}
+		}
+		ginCtx.JSON(http.StatusOK, response)
+	}
+}
+
+func validate(request *purgeRequest) error {
+	switch request.Type {
+	case TargetHub:
+		if request.ClusterName != "" {
+			return fmt.Errorf("clusterName must be empty when purging a hub")
+		}
+	case TargetCluster:
+		if request.ClusterName == "" {
+			return fmt.Errorf("clusterName is required when purging a cluster")
+		}
+	default:
+		return fmt.Errorf("unsupported purge type %q, must be %q or %q", request.Type, TargetCluster, TargetHub)
+	}
+	return nil
+}
+
+func newConfirmationToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func toResponse(purgeLog *models.DataPurgeLog) *purgeResponse {
+	return &purgeResponse{
+		ID:          purgeLog.ID,
+		Type:        purgeLog.TargetType,
+		LeafHubName: purgeLog.LeafHubName,
+		ClusterName: purgeLog.ClusterName,
+		Status:      purgeLog.Status,
+		Error:       purgeLog.Error,
+	}
+}
+
+func total(rows map[string]int64) int64 {
+	var sum int64
+	for _, count := range rows {
+		sum += count
+	}
+	return sum
+}
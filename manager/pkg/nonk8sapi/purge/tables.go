@@ -0,0 +1,188 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package purge
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// purgeTable is a table which stores the data reported by the managed hubs, most of them have the leaf_hub_name column
+type purgeTable struct {
+	name string
+	// hubCondition selects the rows of the hub if the table doesn't have the leaf_hub_name column
+	hubCondition string
+	// clusterCondition selects the rows of a managed cluster within the hub, the table is only purged with the hub
+	// if it's empty
+	clusterCondition string
+}
+
+// the audit logs of the API calls on the clusters of the hub, e.g. patching the labels of the cluster
+const auditedClusterCondition = "source = 'api' AND resource LIKE '%%/managedcluster/:clusterID' AND " +
+	"EXISTS (SELECT 1 FROM status.managed_clusters c WHERE c.leaf_hub_name = @hub%s AND " +
+	"split_part(request_uri, '?', 1) LIKE '%%/' || c.cluster_id)"
+
+// the order matters: the rows referring to the cluster id must be deleted before the status.managed_clusters. The
+// tables of the global resources only exist if the global resources are enabled, the missing tables are skipped. The
+// audit logs of the global resources aren't owned by a managed hub, so only the audit logs of the API calls on the
+// target are purged
+var purgeTables = []purgeTable{
+	{
+		name:             "event.managed_clusters",
+		clusterCondition: "cluster_name = @cluster",
+	},
+	{
+		name:             "event.local_policies",
+		clusterCondition: "cluster_name = @cluster",
+	},
+	{
+		name:             "event.cluster_lifecycle",
+		clusterCondition: "cluster_name = @cluster",
+	},
+	{
+		name: "history.local_compliance",
+		clusterCondition: "cluster_id IN (SELECT cluster_id FROM status.managed_clusters " +
+			"WHERE leaf_hub_name = @hub AND cluster_name = @cluster)",
+	},
+	{
+		name: "history.audit_logs",
+		// the API calls on the clusters of the hub, and the queries filtered by the hub
+		hubCondition: "(" + fmt.Sprintf(auditedClusterCondition, "") + ") OR (source = 'api' AND " +
+			"'&' || split_part(request_uri, '?', 2) || '&' LIKE '%&leafHubName=' || @hub || '&%')",
+		clusterCondition: fmt.Sprintf(auditedClusterCondition, " AND c.cluster_name = @cluster"),
+	},
+	{
+		name:             "local_status.compliance",
+		clusterCondition: "cluster_name = @cluster",
+	},
+	{
+		name:             "status.managed_clusters",
+		clusterCondition: "cluster_name = @cluster",
+	},
+	{name: "event.local_root_policies"},
+	{name: "history.compliance_rollups"},
+	{
+		name:         "history.compliance_percentages",
+		hubCondition: "scope = 'hub' AND scope_key = @hub",
+	},
+	{name: "local_spec.policies"},
+	{name: "status.argocd_applications"},
+	{name: "status.managed_cluster_sets"},
+	{name: "status.managed_cluster_set_bindings"},
+	{name: "local_spec.placements"},
+	{name: "local_status.placementdecisions"},
+	{
+		name:             "status.addon_placement_scores",
+		clusterCondition: "cluster_name = @cluster",
+	},
+	{
+		name:             "status.compliance",
+		clusterCondition: "cluster_name = @cluster",
+	},
+	{name: "status.aggregated_compliance"},
+	{name: "status.placementdecisions"},
+	{name: "status.placements"},
+	{name: "status.placementrules"},
+	{name: "status.subscription_reports"},
+	{name: "status.subscription_statuses"},
+	{name: "status.policysets"},
+	{name: "status.operatorpolicies"},
+	{name: "status.argocd_applicationsets"},
+	{name: "local_spec.placementrules"},
+	{
+		name:             "spec.managed_clusters_labels",
+		clusterCondition: "managed_cluster_name = @cluster",
+	},
+	{name: "spec.managed_cluster_sets_tracking"},
+	{name: "status.leaf_hubs"},
+	{name: "status.leaf_hub_heartbeats"},
+}
+
+// tablesFor returns the tables and the where clause of each table for the purge target
+func tablesFor(targetType string) map[string]string {
+	conditions := map[string]string{}
+	for _, table := range purgeTables {
+		switch targetType {
+		case TargetHub:
+			conditions[table.name] = "leaf_hub_name = @hub"
+			if table.hubCondition != "" {
+				conditions[table.name] = table.hubCondition
+			}
+		case TargetCluster:
+			if table.clusterCondition == "" {
+				continue
+			}
+			conditions[table.name] = "leaf_hub_name = @hub AND " + table.clusterCondition
+			if table.hubCondition != "" {
+				conditions[table.name] = "(" + table.hubCondition + ") AND " + table.clusterCondition
+			}
+		}
+	}
+	return conditions
+}
+
+func countRows(db *gorm.DB, targetType, hubName, clusterName string) (map[string]int64, error) {
+	args := map[string]interface{}{"hub": hubName, "cluster": clusterName}
+	conditions := tablesFor(targetType)
+
+	rows := map[string]int64{}
+	for _, table := range purgeTables {
+		condition, ok := conditions[table.name]
+		if !ok {
+			continue
+		}
+		exists, err := tableExists(db, table.name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		var count int64
+		err = db.Raw(fmt.Sprintf("SELECT count(*) FROM %s WHERE %s", table.name, condition), args).
+			Scan(&count).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to count the rows of %s: %w", table.name, err)
+		}
+		rows[table.name] = count
+	}
+	return rows, nil
+}
+
+// deleteRows permanently deletes the rows of the target, including the soft deleted ones
+func deleteRows(tx *gorm.DB, targetType, hubName, clusterName string) (map[string]int64, error) {
+	args := map[string]interface{}{"hub": hubName, "cluster": clusterName}
+	conditions := tablesFor(targetType)
+
+	rows := map[string]int64{}
+	for _, table := range purgeTables {
+		condition, ok := conditions[table.name]
+		if !ok {
+			continue
+		}
+		exists, err := tableExists(tx, table.name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		ret := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", table.name, condition), args)
+		if ret.Error != nil {
+			return nil, fmt.Errorf("failed to delete the rows of %s: %w", table.name, ret.Error)
+		}
+		rows[table.name] = ret.RowsAffected
+	}
+	return rows, nil
+}
+
+// tableExists returns false if the table isn't created, e.g. the tables of the global resources
+func tableExists(db *gorm.DB, name string) (bool, error) {
+	var missing bool
+	if err := db.Raw("SELECT to_regclass(?) IS NULL", name).Scan(&missing).Error; err != nil {
+		return false, fmt.Errorf("failed to check the table %s: %w", name, err)
+	}
+	return !missing, nil
+}
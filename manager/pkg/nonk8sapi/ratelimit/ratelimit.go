@@ -0,0 +1,160 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authentication"
+)
+
+// the limiters of the clients which have been idle for the interval and have refilled their burst are removed
+const cleanupInterval = 10 * time.Minute
+
+var (
+	RateLimitedRequestsCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "multicluster_global_hub_api_rate_limited_requests_total",
+			Help: "The number of the requests of the client rejected by the rate limit of the global hub API.",
+		},
+		[]string{"client"},
+	)
+	RateLimitedClientsGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "multicluster_global_hub_api_rate_limited_clients",
+			Help: "The number of the clients tracked by the rate limit of the global hub API.",
+		},
+	)
+)
+
+type RateLimitConfig struct {
+	// RequestRateLimit is the sustained number of requests per second of each client, the rate limiting is disabled
+	// if it isn't greater than 0
+	RequestRateLimit float64
+	RequestBurst     int
+	// ClientRateLimits are the requests per second of the clients which override the RequestRateLimit, the key is the
+	// user name of the client, and the client isn't limited if the value is 0
+	ClientRateLimits map[string]string
+}
+
+// RateLimiter limits the requests of each client of the global hub API with a token bucket, so a runaway dashboard or
+// script can't overload the database. The client is the authenticated user, or the IP address of the request if the
+// authentication is skipped.
+type RateLimiter struct {
+	config       *RateLimitConfig
+	clientLimits map[string]float64
+	lock         sync.Mutex
+	clients      map[string]*clientState
+	lastCleanup  time.Time
+	now          func() time.Time
+}
+
+type clientState struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewRateLimiter(config *RateLimitConfig) (*RateLimiter, error) {
+	clientLimits := map[string]float64{}
+	for client, value := range config.ClientRateLimits {
+		limit, err := strconv.ParseFloat(value, 64)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid rate limit of the client %s: %s", client, value)
+		}
+		clientLimits[client] = limit
+	}
+	return &RateLimiter{
+		config:       config,
+		clientLimits: clientLimits,
+		clients:      map[string]*clientState{},
+		lastCleanup:  time.Now(),
+		now:          time.Now,
+	}, nil
+}
+
+func (l *RateLimiter) enabled() bool {
+	return l != nil && (l.config.RequestRateLimit > 0 || len(l.clientLimits) > 0)
+}
+
+// RateLimit middleware rejects the request with 429 and the Retry-After header if the client exceeds its rate limit
+func (l *RateLimiter) RateLimit() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		if !l.enabled() {
+			ginCtx.Next()
+			return
+		}
+
+		client := ginCtx.GetString(authentication.UserKey)
+		if client == "" {
+			client = ginCtx.ClientIP()
+		}
+
+		if delay := l.reserve(client); delay > 0 {
+			RateLimitedRequestsCounterVec.WithLabelValues(client).Inc()
+			ginCtx.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			ginCtx.String(http.StatusTooManyRequests, "too many requests of the client %q, retry after %s",
+				client, delay.Round(time.Millisecond))
+			ginCtx.Abort()
+			return
+		}
+		ginCtx.Next()
+	}
+}
+
+// reserve takes a token of the client, it returns how long the client should wait if there is no token
+func (l *RateLimiter) reserve(client string) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	state, ok := l.clients[client]
+	if !ok {
+		limit, found := l.clientLimits[client]
+		if !found {
+			limit = l.config.RequestRateLimit
+		}
+		if limit <= 0 {
+			return 0
+		}
+		// the burst is at least 1, otherwise no request is allowed
+		state = &clientState{limiter: rate.NewLimiter(rate.Limit(limit), max(l.config.RequestBurst, 1))}
+		l.clients[client] = state
+		RateLimitedClientsGauge.Set(float64(len(l.clients)))
+	}
+	state.lastSeen = now
+
+	reservation := state.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		// the rejected request doesn't consume the token
+		reservation.CancelAt(now)
+	}
+	return delay
+}
+
+// cleanup removes the limiters of the idle clients, it doesn't change the limits since their buckets are full
+func (l *RateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+	for client, state := range l.clients {
+		if now.Sub(state.lastSeen) >= cleanupInterval &&
+			state.limiter.TokensAt(now) >= float64(state.limiter.Burst()) {
+			delete(l.clients, client)
+		}
+	}
+	RateLimitedClientsGauge.Set(float64(len(l.clients)))
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi/authentication"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Now()
+	limiter, err := NewRateLimiter(&RateLimitConfig{
+		RequestRateLimit: 1,
+		RequestBurst:     2,
+		ClientRateLimits: map[string]string{"dashboard": "10", "admin": "0"},
+	})
+	assert.NoError(t, err)
+	limiter.now = func() time.Time { return now }
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(ginCtx *gin.Context) {
+		if user := ginCtx.GetHeader("User"); user != "" {
+			ginCtx.Set(authentication.UserKey, user)
+		}
+	}, limiter.RateLimit())
+	router.GET("/managedclusters", func(ginCtx *gin.Context) {
+		ginCtx.String(http.StatusOK, "ok")
+	})
+
+	request := func(user string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/managedclusters", nil)
+		assert.NoError(t, err)
+		req.Header.Set("User", user)
+		req.RemoteAddr = "10.0.0.1:12345"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// the burst is allowed, then the client has to wait for the next token
+	assert.Equal(t, http.StatusOK, request("alice").Code)
+	assert.Equal(t, http.StatusOK, request("alice").Code)
+	w := request("alice")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(RateLimitedRequestsCounterVec.WithLabelValues("alice")))
+
+	// the other clients have their own buckets
+	assert.Equal(t, http.StatusOK, request("bob").Code)
+	assert.Equal(t, http.StatusOK, request("").Code)
+	assert.Equal(t, http.StatusOK, request("").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("").Code)
+	assert.Equal(t, float64(1), testutil.ToFloat64(RateLimitedRequestsCounterVec.WithLabelValues("10.0.0.1")))
+
+	// the rejected requests don't consume the tokens
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, request("alice").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("alice").Code)
+
+	// the rate limit is overridden for the clients
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, http.StatusOK, request("admin").Code)
+	}
+	assert.Equal(t, http.StatusOK, request("dashboard").Code)
+	assert.Equal(t, http.StatusOK, request("dashboard").Code)
+	w = request("dashboard")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// the limiters of the idle clients are removed
+	assert.Len(t, limiter.clients, 4)
+	now = now.Add(cleanupInterval)
+	assert.Equal(t, http.StatusOK, request("alice").Code)
+	assert.Len(t, limiter.clients, 1)
+	assert.Equal(t, float64(1), testutil.ToFloat64(RateLimitedClientsGauge))
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	limiter, err := NewRateLimiter(&RateLimitConfig{})
+	assert.NoError(t, err)
+	assert.False(t, limiter.enabled())
+
+	_, err = NewRateLimiter(&RateLimitConfig{ClientRateLimits: map[string]string{"alice": "fast"}})
+	assert.ErrorContains(t, err, "invalid rate limit of the client alice")
+}
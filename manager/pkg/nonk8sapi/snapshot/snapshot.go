@@ -0,0 +1,193 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package snapshot
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const serverInternalErrorMsg = "internal error"
+
+// the managed clusters which exist at the time, the soft deleted clusters are included if they were deleted after it
+var clustersSQL = `
+	SELECT cluster_id, cluster_name, leaf_hub_name, created_at
+	FROM status.managed_clusters
+	WHERE created_at <= @time AND (deleted_at IS NULL OR deleted_at > @time)
+		AND (@hub = '' OR leaf_hub_name = @hub)
+	ORDER BY leaf_hub_name, cluster_name`
+
+// the latest compliance state of each policy and cluster at the time. The daily history of the day is updated by the
+// policy events of the whole day, so the baseline of each policy and cluster is its latest daily history before the day
+// of the time, and its policy events from the next day of the baseline up to the time override the changed states. The
+// pairs without the daily history are rebuilt from their policy events. It's also used by the compliance API for the
+// compliance of a policy at the time
+var ComplianceSQL = `
+	WITH baseline AS (
+		SELECT DISTINCT ON (h.policy_id, h.cluster_id) h.policy_id, h.cluster_id, h.leaf_hub_name, h.compliance,
+			h.compliance_date
+		FROM history.local_compliance h
+		WHERE h.compliance_date < @time::date
+			AND (@hub = '' OR h.leaf_hub_name = @hub)
+			AND (@policy = '' OR h.policy_id::text = @policy)
+		ORDER BY h.policy_id, h.cluster_id, h.compliance_date DESC
+	)
+	SELECT states.policy_id, p.policy_name, states.cluster_id, states.cluster_name, states.leaf_hub_name,
+		states.compliance, states.observed_at
+	FROM (
+		SELECT DISTINCT ON (policy_id, cluster_id) policy_id, cluster_id, cluster_name, leaf_hub_name,
+			compliance, observed_at
+		FROM (
+			SELECT e.policy_id, e.cluster_id, e.cluster_name, e.leaf_hub_name, e.compliance::text,
+				e.created_at AS observed_at
+			FROM event.local_policies e
+			LEFT JOIN baseline b ON b.policy_id = e.policy_id AND b.cluster_id = e.cluster_id
+			WHERE e.created_at <= @time
+				AND e.created_at >= COALESCE(b.compliance_date + 1, '-infinity'::date)
+				AND (@hub = '' OR e.leaf_hub_name = @hub)
+				AND (@policy = '' OR e.policy_id::text = @policy)
+			UNION ALL
+			SELECT b.policy_id, b.cluster_id, c.cluster_name, b.leaf_hub_name, b.compliance::text,
+				b.compliance_date::timestamp AS observed_at
+			FROM baseline b
+			LEFT JOIN status.managed_clusters c ON c.cluster_id = b.cluster_id
+		) AS all_states
+		ORDER BY policy_id, cluster_id, observed_at DESC
+	) AS states
+	LEFT JOIN local_spec.policies p ON p.policy_id = states.policy_id
+	WHERE (@compliance = '' OR states.compliance = @compliance)
+	ORDER BY states.leaf_hub_name, p.policy_name, states.cluster_name`
+
+type managedCluster struct {
+	ClusterID   string    `json:"clusterId"`
+	ClusterName string    `json:"clusterName"`
+	LeafHubName string    `json:"leafHubName"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type compliance struct {
+	PolicyID    string    `json:"policyId"`
+	PolicyName  string    `json:"policyName"`
+	ClusterID   string    `json:"clusterId"`
+	ClusterName string    `json:"clusterName"`
+	LeafHubName string    `json:"leafHubName"`
+	Compliance  string    `json:"compliance"`
+	ObservedAt  time.Time `json:"observedAt"`
+}
+
+type fleetSnapshot struct {
+	Time            time.Time        `json:"time"`
+	ManagedClusters []managedCluster `json:"managedClusters"`
+	Compliance      []compliance     `json:"compliance"`
+	// Summary is the count of the cluster policy pairs in each compliance state
+	Summary map[string]int `json:"summary"`
+}
+
+// GetFleetSnapshot godoc
+// @summary get fleet snapshot
+// @description get the managed clusters and the compliance states of the fleet as of the given time
+// @accept json
+// @produce json
+// @param        time           query     string  true   "the point in time in RFC3339 format, e.g. 2024-05-21T02:00:00Z"
+// @param        leafHubName    query     string  false  "only return the state of the managed hub"
+// @param        compliance     query     string  false  "only return the given compliance state, e.g. non_compliant"
+// @success      200  {object}    fleetSnapshot
+// @failure      400
+// @failure      401
+// @failure      403
+// @failure      404
+// @failure      429
+// @failure      500
+// @failure      503
+// @security     ApiKeyAuth
+// @router /snapshot [get]
+func GetFleetSnapshot() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		pointInTime, err := time.Parse(time.RFC3339, ginCtx.Query("time"))
+		if err != nil {
+			ginCtx.String(http.StatusBadRequest, "time must be in RFC3339 format: %s", err.Error())
+			return
+		}
+		if pointInTime.After(time.Now()) {
+			ginCtx.String(http.StatusBadRequest, "time %s is in the future", pointInTime.Format(time.RFC3339))
+			return
+		}
+
+		args := map[string]interface{}{
+			"time":       pointInTime.UTC(),
+			"hub":        ginCtx.Query("leafHubName"),
+			"compliance": ginCtx.Query("compliance"),
+			"policy":     "",
+		}
+
+		snapshot := &fleetSnapshot{
+			Time:            pointInTime,
+			ManagedClusters: []managedCluster{},
+			Compliance:      []compliance{},
+			Summary:         map[string]int{},
+		}
+
+		db := database.GetReadonlyGorm()
+		clusterRows, err := db.Raw(clustersSQL, args).Rows()
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying managed clusters: %v\n", err)
+			return
+		}
+		defer clusterRows.Close()
+		for clusterRows.Next() {
+			cluster := managedCluster{}
+			if err := clusterRows.Scan(&cluster.ClusterID, &cluster.ClusterName, &cluster.LeafHubName,
+				&cluster.CreatedAt); err != nil {
+				ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+				fmt.Fprintf(gin.DefaultWriter, "error in scanning a managed cluster: %v\n", err)
+				return
+			}
+			snapshot.ManagedClusters = append(snapshot.ManagedClusters, cluster)
+		}
+		if err := clusterRows.Err(); err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in reading managed clusters: %v\n", err)
+			return
+		}
+
+		complianceRows, err := db.Raw(ComplianceSQL, args).Rows()
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in querying compliance: %v\n", err)
+			return
+		}
+		defer complianceRows.Close()
+		for complianceRows.Next() {
+			var policyName, clusterName *string
+			state := compliance{}
+			if err := complianceRows.Scan(&state.PolicyID, &policyName, &state.ClusterID, &clusterName,
+				&state.LeafHubName, &state.Compliance, &state.ObservedAt); err != nil {
+				ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+				fmt.Fprintf(gin.DefaultWriter, "error in scanning a compliance: %v\n", err)
+				return
+			}
+			if policyName != nil {
+				state.PolicyName = *policyName
+			}
+			if clusterName != nil {
+				state.ClusterName = *clusterName
+			}
+			snapshot.Compliance = append(snapshot.Compliance, state)
+			snapshot.Summary[state.Compliance]++
+		}
+		if err := complianceRows.Err(); err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in reading compliance: %v\n", err)
+			return
+		}
+
+		ginCtx.JSON(http.StatusOK, snapshot)
+	}
+}
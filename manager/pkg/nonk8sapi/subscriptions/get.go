@@ -44,6 +44,7 @@ var subReportCustomResourceColumnDefinitions = util.GetCustomResourceColumnDefin
 // @failure      401
 // @failure      403
 // @failure      404
+// @failure      429
 // @failure      500
 // @failure      503
 // @security     ApiKeyAuth
@@ -102,7 +103,7 @@ func getAggregatedSubscriptionReport(subscriptionID, subscriptionQuery,
 ) (*appsv1alpha1.SubscriptionReport, error) {
 	var subscriptionReport *appsv1alpha1.SubscriptionReport
 	var subName, subNamespace string
-	db := database.GetGorm()
+	db := database.GetReadonlyGorm()
 	err := db.Raw(subscriptionQuery, subscriptionID).Row().Scan(&subName, &subNamespace)
 	if err != nil {
 		fmt.Fprintf(gin.DefaultWriter, "error in querying subscription with subscription ID(%s): %v\n", subscriptionID, err)
@@ -5,7 +5,6 @@ package subscriptions
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -36,6 +35,15 @@ const (
 var customResourceColumnDefinitions = util.GetCustomResourceColumnDefinitions(crdName,
 	appsv1.SchemeGroupVersion.Version)
 
+// the subscriptions can be sorted by the name and the creation timestamp
+var subscriptionListColumns = &util.ListColumns{
+	Name: "(payload -> 'metadata' ->> 'name')",
+	UID:  "(payload -> 'metadata' ->> 'uid')",
+	Sort: map[string]string{
+		"metadata.creationTimestamp": "COALESCE(payload -> 'metadata' ->> 'creationTimestamp', '')",
+	},
+}
+
 // ListSubscriptions godoc
 // @summary list application subscriptions
 // @description list application subscriptions
@@ -44,11 +52,14 @@ var customResourceColumnDefinitions = util.GetCustomResourceColumnDefinitions(cr
 // @param        labelSelector    query     string  false  "list application subscriptions by label selector"
 // @param        limit            query     int     false  "maximum application subscription number to receive"
 // @param        continue         query     string  false  "continue token to request next request"
+// @param        sortBy           query     string  false  "sort application subscriptions by metadata.name or metadata.creationTimestamp, prefixed with - for the descending order"
+// @param        fields           query     []string  false  "return only the fields of application subscriptions, e.g. metadata.name,spec.channel"
 // @success      200  {object}    appsv1.SubscriptionList
 // @failure      400
 // @failure      401
 // @failure      403
 // @failure      404
+// @failure      429
 // @failure      500
 // @failure      503
 // @security     ApiKeyAuth
@@ -63,6 +74,7 @@ func ListSubscriptions() gin.HandlerFunc {
 			var err error
 			selectorInSql, err = util.ParseLabelSelector(labelSelector)
 			if err != nil {
+				ginCtx.String(http.StatusBadRequest, err.Error())
 				fmt.Fprintf(gin.DefaultWriter, "failed to parse label selector: %s\n", err.Error())
 				return
 			}
@@ -70,61 +82,43 @@ func ListSubscriptions() gin.HandlerFunc {
 
 		fmt.Fprintf(gin.DefaultWriter, "parsed selector: %s\n", selectorInSql)
 
-		limit := ginCtx.Query("limit")
-		fmt.Fprintf(gin.DefaultWriter, "limit: %v\n", limit)
-
-		lastSubscriptionName, lastSubscriptionUID := "", ""
-
-		continueToken := ginCtx.Query("continue")
-		if continueToken != "" {
-			fmt.Fprintf(gin.DefaultWriter, "continue: %v\n", continueToken)
-
-			var err error
-			lastSubscriptionName, lastSubscriptionUID, err = util.DecodeContinue(continueToken)
-			if err != nil {
-				fmt.Fprintf(gin.DefaultWriter, "failed to decode continue token: %s\n", err.Error())
-				return
-			}
+		listOptions, err := util.ParseListOptions(ginCtx, subscriptionListColumns)
+		if err != nil {
+			ginCtx.String(http.StatusBadRequest, err.Error())
+			fmt.Fprintf(gin.DefaultWriter, "failed to parse list options: %s\n", err.Error())
+			return
 		}
 
-		fmt.Fprintf(gin.DefaultWriter,
-			"last returned subscription name: %s, last returned subscription UID: %s\n",
-			lastSubscriptionName,
-			lastSubscriptionUID)
+		fmt.Fprintf(gin.DefaultWriter, "limit: %v, sortBy: %v, fields: %v\n", listOptions.Limit,
+			ginCtx.Query("sortBy"), listOptions.Fields)
 
 		// build query condition for paging
-		LastResourceCompareCondition := fmt.Sprintf(
-			"(payload -> 'metadata' ->> 'name', payload -> 'metadata' ->> 'uid') > ('%s', '%s') ",
-			lastSubscriptionName,
-			lastSubscriptionUID)
-
-		// the last subscription query order by subscription name and uid
-		lastSubscriptionQuery := "SELECT payload FROM spec.subscriptions WHERE deleted = FALSE " +
-			"ORDER BY (payload -> 'metadata' ->> 'name', payload -> 'metadata' ->> 'uid') DESC LIMIT 1"
+		lastResourceCompareCondition, subscriptionListArgs := listOptions.Condition()
+		limitInSql, limitArgs := listOptions.LimitClause()
+		subscriptionListArgs = append(subscriptionListArgs, limitArgs...)
 
 		// subscrition list query
-		subscriptionListQuery := "SELECT payload FROM spec.subscriptions WHERE deleted = FALSE AND " +
-			LastResourceCompareCondition +
+		subscriptionQuery := "FROM spec.subscriptions WHERE deleted = FALSE" +
 			selectorInSql +
-			" ORDER BY (payload -> 'metadata' ->> 'name', payload -> 'metadata' ->> 'uid')"
-
-		// add limit
-		if limit != "" {
-			subscriptionListQuery += fmt.Sprintf(" LIMIT %s", limit)
-		}
+			lastResourceCompareCondition +
+			listOptions.OrderBy() +
+			limitInSql
 
-		fmt.Fprintf(gin.DefaultWriter, "subscription list query: %v\n", subscriptionListQuery)
+		fmt.Fprintf(gin.DefaultWriter, "subscription list query: %v\n", subscriptionQuery)
 
 		if _, watch := ginCtx.GetQuery("watch"); watch {
-			handleSubscriptionListForWatch(ginCtx, subscriptionListQuery)
+			handleSubscriptionListForWatch(ginCtx, "SELECT payload "+subscriptionQuery, subscriptionListArgs)
 			return
 		}
 
-		handleRows(ginCtx, subscriptionListQuery, lastSubscriptionQuery, customResourceColumnDefinitions)
+		subscriptionListQuery := fmt.Sprintf("SELECT payload, %s %s", listOptions.SortColumn(), subscriptionQuery)
+		handleRows(ginCtx, subscriptionListQuery, subscriptionListArgs, listOptions, customResourceColumnDefinitions)
 	}
 }
 
-func handleSubscriptionListForWatch(ginCtx *gin.Context, subscriptionListQuery string) {
+func handleSubscriptionListForWatch(ginCtx *gin.Context, subscriptionListQuery string,
+	subscriptionListArgs []interface{},
+) {
 	writer := ginCtx.Writer
 	header := writer.Header()
 
@@ -154,16 +148,16 @@ func handleSubscriptionListForWatch(ginCtx *gin.Context, subscriptionListQuery s
 				return
 			}
 
-			doHandleRowsForWatch(ctx, writer, subscriptionListQuery, preAddedSubscriptions)
+			doHandleRowsForWatch(ctx, writer, subscriptionListQuery, subscriptionListArgs, preAddedSubscriptions)
 		}
 	}
 }
 
 func doHandleRowsForWatch(ctx context.Context, writer io.Writer, subscriptionListQuery string,
-	preAddedSubscriptions set.Set,
+	subscriptionListArgs []interface{}, preAddedSubscriptions set.Set,
 ) {
-	db := database.GetGorm()
-	rows, err := db.Raw(subscriptionListQuery).Rows()
+	db := database.GetReadonlyGorm()
+	rows, err := db.Raw(subscriptionListQuery, subscriptionListArgs...).Rows()
 	if err != nil {
 		fmt.Fprintf(gin.DefaultWriter, "error in quering subscription list: %v\n", err)
 	}
@@ -226,33 +220,17 @@ func doHandleRowsForWatch(ctx context.Context, writer io.Writer, subscriptionLis
 	writer.(http.Flusher).Flush()
 }
 
-func handleRows(ginCtx *gin.Context, subscriptionListQuery, lastSubscriptionQuery string,
-	customResourceColumnDefinitions []apiextensionsv1.CustomResourceColumnDefinition,
+func handleRows(ginCtx *gin.Context, subscriptionListQuery string, subscriptionListArgs []interface{},
+	listOptions *util.ListOptions, customResourceColumnDefinitions []apiextensionsv1.CustomResourceColumnDefinition,
 ) {
-	db := database.GetGorm()
-	lastSubscription := &appsv1.Subscription{}
-	var payload []byte
-	err := db.Raw(lastSubscriptionQuery).Row().Scan(&payload)
-	if err != nil && err != sql.ErrNoRows {
-		ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
-		fmt.Fprintf(gin.DefaultWriter, "error in querying last subscription: %v\n", err)
-		return
-	}
-
-	if err == nil {
-		err = json.Unmarshal(payload, lastSubscription)
-		if err != nil {
-			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
-			fmt.Fprintf(gin.DefaultWriter, "error in querying last subscription payload: %v\n", err)
-			return
-		}
-	}
-
-	rows, err := db.Raw(subscriptionListQuery).Rows()
+	db := database.GetReadonlyGorm()
+	rows, err := db.Raw(subscriptionListQuery, subscriptionListArgs...).Rows()
 	if err != nil {
 		ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
 		fmt.Fprintf(gin.DefaultWriter, "error in querying subscriptions: %v\n", err)
+		return
 	}
+	defer rows.Close()
 
 	subscriptionList := &appsv1.SubscriptionList{
 		TypeMeta: metav1.TypeMeta{
@@ -261,11 +239,19 @@ func handleRows(ginCtx *gin.Context, subscriptionListQuery, lastSubscriptionQuer
 		},
 		Items: []appsv1.Subscription{},
 	}
-	lastSubscriptionName, lastSubscriptionUID := "", ""
+	hasNext := false
+	lastSubscriptionName, lastSubscriptionUID, lastSortValue := "", "", ""
 	for rows.Next() {
+		// one more subscription than the limit is queried to know if there is a next page
+		if listOptions.HasNext(len(subscriptionList.Items) + 1) {
+			hasNext = true
+			break
+		}
+
 		subscription := appsv1.Subscription{}
 		var payload []byte
-		err := rows.Scan(&payload)
+		var sortValue string
+		err := rows.Scan(&payload, &sortValue)
 		if err != nil {
 			fmt.Fprintf(gin.DefaultWriter, "error in scanning a subscription: %v\n", err)
 			continue
@@ -276,17 +262,12 @@ func handleRows(ginCtx *gin.Context, subscriptionListQuery, lastSubscriptionQuer
 			continue
 		}
 		subscriptionList.Items = append(subscriptionList.Items, subscription)
-		lastSubscriptionName = subscription.GetName()
-		lastSubscriptionUID = string(subscription.GetUID())
+		lastSubscriptionName, lastSubscriptionUID = subscription.GetName(), string(subscription.GetUID())
+		lastSortValue = sortValue
 	}
 
-	if lastSubscriptionName != "" &&
-		lastSubscription.GetName() != "" &&
-		lastSubscriptionName != lastSubscription.GetName() &&
-		lastSubscriptionUID != "" &&
-		string(lastSubscription.GetUID()) != "" &&
-		lastSubscriptionUID != string(lastSubscription.GetUID()) {
-		continueToken, err := util.EncodeContinue(lastSubscriptionName, lastSubscriptionUID)
+	if hasNext {
+		continueToken, err := listOptions.NextContinue(lastSubscriptionName, lastSubscriptionUID, lastSortValue)
 		if err != nil {
 			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
 			fmt.Fprintf(gin.DefaultWriter, "error in encoding the continue token: %v\n", err)
@@ -324,6 +305,17 @@ func handleRows(ginCtx *gin.Context, subscriptionListQuery, lastSubscriptionQuer
 		return
 	}
 
+	if len(listOptions.Fields) > 0 {
+		selectedList, err := util.SelectFields(subscriptionList, listOptions.Fields)
+		if err != nil {
+			ginCtx.String(http.StatusInternalServerError, serverInternalErrorMsg)
+			fmt.Fprintf(gin.DefaultWriter, "error in selecting the fields of subscriptions: %v\n", err)
+			return
+		}
+		ginCtx.JSON(http.StatusOK, selectedList)
+		return
+	}
+
 	ginCtx.JSON(http.StatusOK, subscriptionList)
 }
 
@@ -8,35 +8,52 @@ import (
 	"encoding/json"
 )
 
-// continueToken is a simple structured object for encoding the state of a continue token
-// since the resource name may not be unique, resource uid is combined to check last returned resource
-type continueToken struct {
-	LastName string `json:"lastName"`
-	LastUID  string `json:"lastUID"`
+// ContinueToken is a simple structured object for encoding the state of a continue token
+// since the resource name may not be unique, resource uid is combined to check last returned resource.
+// The sortBy and the sort value of the last returned resource are set if the resources aren't sorted by the name
+type ContinueToken struct {
+	LastName      string `json:"lastName"`
+	LastUID       string `json:"lastUID"`
+	SortBy        string `json:"sortBy,omitempty"`
+	LastSortValue string `json:"lastSortValue,omitempty"`
 }
 
-// DecodeContinue decodes the continue token and get last resoource name and uid
-func DecodeContinue(continueStr string) (string, string, error) {
-	lastName, lastUID := "", ""
+// DecodeContinueToken decodes the continue token
+func DecodeContinueToken(continueStr string) (*ContinueToken, error) {
 	decodedContinue, err := base64.RawURLEncoding.DecodeString(continueStr)
 	if err != nil {
-		return lastName, lastUID, err
+		return nil, err
 	}
 
-	ct := &continueToken{}
+	ct := &ContinueToken{}
 	if err := json.Unmarshal(decodedContinue, ct); err != nil {
-		return lastName, lastUID, err
+		return nil, err
 	}
 
-	return ct.LastName, ct.LastUID, nil
+	return ct, nil
 }
 
-// EncodeContinue encodes the continue token with last resource name and uid
-func EncodeContinue(lastName, lastUID string) (string, error) {
-	ct, err := json.Marshal(&continueToken{LastName: lastName, LastUID: lastUID})
+// EncodeContinueToken encodes the continue token
+func EncodeContinueToken(ct *ContinueToken) (string, error) {
+	encodedContinue, err := json.Marshal(ct)
 	if err != nil {
 		return "", err
 	}
 
-	return base64.RawURLEncoding.EncodeToString(ct), nil
+	return base64.RawURLEncoding.EncodeToString(encodedContinue), nil
+}
+
+// DecodeContinue decodes the continue token and get last resoource name and uid
+func DecodeContinue(continueStr string) (string, string, error) {
+	ct, err := DecodeContinueToken(continueStr)
+	if err != nil {
+		return "", "", err
+	}
+
+	return ct.LastName, ct.LastUID, nil
+}
+
+// EncodeContinue encodes the continue token with last resource name and uid
+func EncodeContinue(lastName, lastUID string) (string, error) {
+	return EncodeContinueToken(&ContinueToken{LastName: lastName, LastUID: lastUID})
 }
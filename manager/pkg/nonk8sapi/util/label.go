@@ -77,10 +77,11 @@ func ParseLabelSelector(labelSelector string) (string, error) {
 		// 	selectorInSql += ")"
 		case strings.HasPrefix(selector, "!"):
 			key := strings.TrimSpace(strings.TrimPrefix(selector, "!"))
-			selectorInSql += fmt.Sprintf(" AND NOT (payload -> 'metadata' -> 'labels' ? '%s')", key)
+			// jsonb_exists is the ? operator, which can't be used in the queries with the ? placeholders
+			selectorInSql += fmt.Sprintf(" AND NOT jsonb_exists(payload -> 'metadata' -> 'labels', '%s')", key)
 		default:
 			key := strings.TrimSpace(selector)
-			selectorInSql += fmt.Sprintf(" AND payload -> 'metadata' -> 'labels' ? '%s'", key)
+			selectorInSql += fmt.Sprintf(" AND jsonb_exists(payload -> 'metadata' -> 'labels', '%s')", key)
 		}
 	}
 
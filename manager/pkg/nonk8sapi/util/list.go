@@ -0,0 +1,209 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SortByName is the default sort key of the list APIs, the name and the uid also break the ties of other sort keys
+const SortByName = "metadata.name"
+
+// ListColumns are the SQL expressions of the name, the uid and the other sortable fields of the listed resources
+type ListColumns struct {
+	Name string
+	UID  string
+	// Sort are the SQL expressions of the sortBy fields, they must be text and not null for the keyset comparison
+	Sort map[string]string
+}
+
+// ListOptions are the paging, sorting and field selection parameters of the list APIs. The pages are queried by the
+// keyset of the sort key, the name and the uid of the last returned resource instead of the offset, so a page is as
+// fast as the first one and no resource is skipped or repeated if the resources are changed between the requests
+type ListOptions struct {
+	// Limit is the maximum number of the returned resources, it's not limited if it's 0
+	Limit int
+	// SortBy is the field to sort the resources by, it's in the descending order if Desc is set
+	SortBy string
+	Desc   bool
+	// Fields are the paths of the fields returned for each resource, e.g. metadata.name, all fields are returned if
+	// it's empty
+	Fields   []string
+	Continue *ContinueToken
+
+	columns    *ListColumns
+	sortColumn string
+}
+
+// ParseListOptions parses the limit, sortBy, fields and continue parameters of the request. The sortBy is the field
+// name with the "-" prefix for the descending order, e.g. -metadata.creationTimestamp
+func ParseListOptions(ginCtx *gin.Context, columns *ListColumns) (*ListOptions, error) {
+	opts := &ListOptions{SortBy: SortByName, columns: columns, sortColumn: columns.Name}
+
+	if limit := ginCtx.Query("limit"); limit != "" {
+		var err error
+		if opts.Limit, err = strconv.Atoi(limit); err != nil || opts.Limit < 0 {
+			return nil, fmt.Errorf("invalid limit: %s", limit)
+		}
+	}
+
+	if sortBy := ginCtx.Query("sortBy"); sortBy != "" {
+		opts.SortBy, opts.Desc = strings.TrimPrefix(sortBy, "-"), strings.HasPrefix(sortBy, "-")
+	}
+	if opts.SortBy != SortByName {
+		sortColumn, found := columns.Sort[opts.SortBy]
+		if !found {
+			sortable := []string{SortByName}
+			for sortBy := range columns.Sort {
+				sortable = append(sortable, sortBy)
+			}
+			sort.Strings(sortable)
+			return nil, fmt.Errorf("invalid sortBy: %s, it should be one of %s", opts.SortBy,
+				strings.Join(sortable, ", "))
+		}
+		opts.sortColumn = sortColumn
+	}
+
+	for _, field := range strings.Split(ginCtx.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			opts.Fields = append(opts.Fields, field)
+		}
+	}
+
+	if continueToken := ginCtx.Query("continue"); continueToken != "" {
+		token, err := DecodeContinueToken(continueToken)
+		if err != nil {
+			return nil, fmt.Errorf("invalid continue token: %w", err)
+		}
+		// the token of the previous versions doesn't have the sortBy, it's sorted by the name
+		if token.SortBy == "" {
+			token.SortBy = SortByName
+		}
+		if token.SortBy != opts.sortBy() {
+			return nil, fmt.Errorf("the continue token is for the sortBy %s", token.SortBy)
+		}
+		opts.Continue = token
+	}
+
+	return opts, nil
+}
+
+// SortColumn is the SQL expression of the sort key, it's selected by the list query for the continue token
+func (o *ListOptions) SortColumn() string {
+	return o.sortColumn
+}
+
+// Condition returns the SQL condition and its arguments to query the resources after the continue token
+func (o *ListOptions) Condition() (string, []interface{}) {
+	if o.Continue == nil {
+		return "", []interface{}{}
+	}
+	operator := ">"
+	if o.Desc {
+		operator = "<"
+	}
+	if o.SortBy == SortByName {
+		return fmt.Sprintf(" AND (%s, %s) %s (?, ?)", o.columns.Name, o.columns.UID, operator),
+			[]interface{}{o.Continue.LastName, o.Continue.LastUID}
+	}
+	return fmt.Sprintf(" AND (%s, %s, %s) %s (?, ?, ?)", o.sortColumn, o.columns.Name, o.columns.UID, operator),
+		[]interface{}{o.Continue.LastSortValue, o.Continue.LastName, o.Continue.LastUID}
+}
+
+// OrderBy returns the ORDER BY clause of the sort key, the name and the uid
+func (o *ListOptions) OrderBy() string {
+	order := ""
+	if o.Desc {
+		order = " DESC"
+	}
+	keys := []string{o.columns.Name + order, o.columns.UID + order}
+	if o.SortBy != SortByName {
+		keys = append([]string{o.sortColumn + order}, keys...)
+	}
+	return " ORDER BY " + strings.Join(keys, ", ")
+}
+
+// LimitClause returns the LIMIT clause and its arguments, one more resource than the limit is queried to know if
+// there is a next page
+func (o *ListOptions) LimitClause() (string, []interface{}) {
+	if o.Limit == 0 {
+		return "", []interface{}{}
+	}
+	return " LIMIT ?", []interface{}{o.Limit + 1}
+}
+
+// HasNext returns true if the number of the queried resources is more than the limit, then the resources after the
+// limit are dropped and the continue token of the last returned resource is set to the list
+func (o *ListOptions) HasNext(count int) bool {
+	return o.Limit > 0 && count > o.Limit
+}
+
+// NextContinue encodes the continue token of the last returned resource
+func (o *ListOptions) NextContinue(lastName, lastUID, lastSortValue string) (string, error) {
+	token := &ContinueToken{LastName: lastName, LastUID: lastUID, SortBy: o.sortBy()}
+	if o.SortBy != SortByName {
+		token.LastSortValue = lastSortValue
+	}
+	return EncodeContinueToken(token)
+}
+
+func (o *ListOptions) sortBy() string {
+	if o.Desc {
+		return "-" + o.SortBy
+	}
+	return o.SortBy
+}
+
+// SelectFields returns the list with only the selected fields of the items, the apiVersion, kind and metadata of
+// the list and the apiVersion and kind of the items are always returned
+func SelectFields(list runtime.Object, fields []string) (map[string]interface{}, error) {
+	// the list is converted by the JSON since the status of the unstructured policies isn't JSON compatible
+	listData, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	unstructuredList := map[string]interface{}{}
+	if err := json.Unmarshal(listData, &unstructuredList); err != nil {
+		return nil, err
+	}
+	items, _, err := unstructured.NestedSlice(unstructuredList, "items")
+	if err != nil {
+		return nil, err
+	}
+
+	selectedItems := []interface{}{}
+	for _, i := range items {
+		item, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		selected := map[string]interface{}{}
+		for _, key := range []string{"apiVersion", "kind"} {
+			if value, found := item[key]; found {
+				selected[key] = value
+			}
+		}
+		for _, field := range fields {
+			path := strings.Split(field, ".")
+			value, found, err := unstructured.NestedFieldNoCopy(item, path...)
+			if err != nil || !found {
+				continue
+			}
+			if err := unstructured.SetNestedField(selected, value, path...); err != nil {
+				return nil, err
+			}
+		}
+		selectedItems = append(selectedItems, selected)
+	}
+	unstructuredList["items"] = selectedItems
+	return unstructuredList, nil
+}
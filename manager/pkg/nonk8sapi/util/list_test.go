@@ -0,0 +1,118 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+var testListColumns = &ListColumns{
+	Name: "name",
+	UID:  "id",
+	Sort: map[string]string{"metadata.creationTimestamp": "created"},
+}
+
+func parseTestListOptions(t *testing.T, query string) (*ListOptions, error) {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	req, err := http.NewRequest(http.MethodGet, "/list?"+query, nil)
+	assert.NoError(t, err)
+	ginCtx.Request = req
+	return ParseListOptions(ginCtx, testListColumns)
+}
+
+func TestListOptions(t *testing.T) {
+	opts, err := parseTestListOptions(t, "limit=2&fields=metadata.name,%20status")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, opts.Limit)
+	assert.Equal(t, []string{"metadata.name", "status"}, opts.Fields)
+	condition, args := opts.Condition()
+	assert.Equal(t, "", condition)
+	assert.Empty(t, args)
+	assert.Equal(t, " ORDER BY name, id", opts.OrderBy())
+	limit, args := opts.LimitClause()
+	assert.Equal(t, " LIMIT ?", limit)
+	assert.Equal(t, []interface{}{3}, args)
+	assert.False(t, opts.HasNext(2))
+	assert.True(t, opts.HasNext(3))
+
+	// the continue token of the previous versions is sorted by the name
+	continueToken, err := EncodeContinue("mc1", "uid1")
+	assert.NoError(t, err)
+	opts, err = parseTestListOptions(t, "continue="+continueToken)
+	assert.NoError(t, err)
+	condition, args = opts.Condition()
+	assert.Equal(t, " AND (name, id) > (?, ?)", condition)
+	assert.Equal(t, []interface{}{"mc1", "uid1"}, args)
+	limit, _ = opts.LimitClause()
+	assert.Equal(t, "", limit)
+	assert.False(t, opts.HasNext(100))
+
+	opts, err = parseTestListOptions(t, "sortBy=-metadata.creationTimestamp&limit=1")
+	assert.NoError(t, err)
+	assert.Equal(t, "created", opts.SortColumn())
+	assert.Equal(t, " ORDER BY created DESC, name DESC, id DESC", opts.OrderBy())
+	continueToken, err = opts.NextContinue("mc2", "uid2", "2024-01-01T00:00:00Z")
+	assert.NoError(t, err)
+	token, err := DecodeContinueToken(continueToken)
+	assert.NoError(t, err)
+	assert.Equal(t, &ContinueToken{
+		LastName:      "mc2",
+		LastUID:       "uid2",
+		SortBy:        "-metadata.creationTimestamp",
+		LastSortValue: "2024-01-01T00:00:00Z",
+	}, token)
+
+	opts, err = parseTestListOptions(t, "sortBy=-metadata.creationTimestamp&continue="+continueToken)
+	assert.NoError(t, err)
+	condition, args = opts.Condition()
+	assert.Equal(t, " AND (created, name, id) < (?, ?, ?)", condition)
+	assert.Equal(t, []interface{}{"2024-01-01T00:00:00Z", "mc2", "uid2"}, args)
+
+	for query, expectedErr := range map[string]string{
+		"limit=-1":                     "invalid limit",
+		"sortBy=spec.hubAcceptsClient": "it should be one of metadata.creationTimestamp, metadata.name",
+		"continue=" + continueToken:    "the continue token is for the sortBy -metadata.creationTimestamp",
+		"continue=invalid":             "invalid continue token",
+	} {
+		_, err := parseTestListOptions(t, query)
+		assert.ErrorContains(t, err, expectedErr, query)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	list := &clusterv1.ManagedClusterList{
+		TypeMeta: metav1.TypeMeta{Kind: "ManagedClusterList", APIVersion: "cluster.open-cluster-management.io/v1"},
+		ListMeta: metav1.ListMeta{Continue: "token"},
+		Items: []clusterv1.ManagedCluster{{
+			TypeMeta: metav1.TypeMeta{Kind: "ManagedCluster", APIVersion: "cluster.open-cluster-management.io/v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "mc1",
+				Labels: map[string]string{"env": "dev"},
+			},
+			Spec: clusterv1.ManagedClusterSpec{HubAcceptsClient: true},
+		}},
+	}
+
+	selectedList, err := SelectFields(list, []string{"metadata.name", "spec.hubAcceptsClient", "status.version"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"kind":       "ManagedClusterList",
+		"apiVersion": "cluster.open-cluster-management.io/v1",
+		"metadata":   map[string]interface{}{"continue": "token"},
+		"items": []interface{}{map[string]interface{}{
+			"kind":       "ManagedCluster",
+			"apiVersion": "cluster.open-cluster-management.io/v1",
+			"metadata":   map[string]interface{}{"name": "mc1"},
+			"spec":       map[string]interface{}{"hubAcceptsClient": true},
+			"status":     map[string]interface{}{"version": map[string]interface{}{}},
+		}},
+	}, selectedList)
+}
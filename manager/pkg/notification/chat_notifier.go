@@ -0,0 +1,305 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ChannelsKey is the key of the chat channels in the secret and the config map
+	ChannelsKey = "channels.yaml"
+
+	ChannelTypeSlack = "slack"
+	ChannelTypeTeams = "teams"
+
+	// the severity of the events whose policies don't set the severity
+	unknownSeverity = "unknown"
+
+	defaultChannelTemplate = "Policy {{.Policy}} is non-compliant on the cluster {{.ClusterName}} of the managed hub " +
+		"{{.LeafHubName}}, the severity is {{.Severity}}."
+)
+
+// the colors of the Microsoft Teams cards by the severity
+var teamsThemeColors = map[string]string{
+	"critical": "D13438",
+	"high":     "FF8C00",
+	"medium":   "FFB900",
+	"low":      "0078D7",
+}
+
+var ChatDeliveriesCounterVec = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "multicluster_global_hub_chat_deliveries_total",
+		Help: "The number of the messages delivered to each Slack or Microsoft Teams channel, by the result of " +
+			"the delivery.",
+	},
+	[]string{"channel", "result"},
+)
+
+type ChatConfig struct {
+	// SecretName is the secret of the channels in the namespace of the manager, the URLs of the incoming webhooks are
+	// the credentials, so they're supposed to be in the secret
+	SecretName string
+	// ConfigMapName is the config map of the channels in the namespace of the manager, the channels of both are
+	// notified. The channels are disabled if both of them are empty
+	ConfigMapName string
+	// Timeout is the timeout of each request to the channels
+	Timeout time.Duration
+}
+
+// ChatChannel is the incoming webhook of a Slack or a Microsoft Teams channel, which receives the messages of the
+// events of its severities
+type ChatChannel struct {
+	Name string `json:"name"`
+	// Type is the ChannelTypeSlack or the ChannelTypeTeams
+	Type string `json:"type"`
+	URL  string `json:"url"`
+	// Severities are the severities of the policies routed to the channel, e.g. "critical", the events whose policies
+	// don't set the severity are "unknown". All the events are routed to the channel if it's empty
+	Severities []string `json:"severities,omitempty"`
+	// Template is the text/template of the message, the fields of the ChatMessage can be used
+	Template string `json:"template,omitempty"`
+
+	template *template.Template
+}
+
+// ChatMessage is the data of the templates of the messages
+type ChatMessage struct {
+	ComplianceEvent
+	// Policy is the "namespace/name" of the policy, or its ID if the name isn't resolved
+	Policy string
+}
+
+// ChatNotifier posts a message to the Slack and the Microsoft Teams channels whenever a policy becomes non-compliant
+// on a cluster, the events are routed to the channels by the severities of the policies, so the teams which don't run
+// the Grafana alerting are notified of the violations. The events are queued by the status handlers and delivered
+// asynchronously, the deliveries to each channel are in the order of the events
+type ChatNotifier struct {
+	complianceQueue
+	log        logr.Logger
+	client     client.Reader
+	namespace  string
+	config     *ChatConfig
+	httpClient *http.Client
+	// the channels loaded from the secret and the config map, they're only accessed by the delivery loop
+	channels []ChatChannel
+}
+
+// AddChatNotifier adds the notifier to the manager, it returns nil if the channels are disabled
+func AddChatNotifier(mgr ctrl.Manager, namespace string, config *ChatConfig) (*ChatNotifier, error) {
+	if config == nil || (config.SecretName == "" && config.ConfigMapName == "") {
+		return nil, nil
+	}
+	notifier := NewChatNotifier(mgr.GetAPIReader(), namespace, config)
+	if err := mgr.Add(notifier); err != nil {
+		return nil, fmt.Errorf("failed to add the chat notifier to the manager: %w", err)
+	}
+	return notifier, nil
+}
+
+func NewChatNotifier(c client.Reader, namespace string, config *ChatConfig) *ChatNotifier {
+	return &ChatNotifier{
+		complianceQueue: newComplianceQueue("chat"),
+		log:             ctrl.Log.WithName("chat-notifier"),
+		client:          c,
+		namespace:       namespace,
+		config:          config,
+		httpClient:      &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Notify queues the events, they're dropped if the buffer is full. It does nothing if the notifier is nil
+func (n *ChatNotifier) Notify(events ...*ComplianceEvent) {
+	if n != nil {
+		n.enqueueCopies(events)
+	}
+}
+
+func (n *ChatNotifier) Start(ctx context.Context) error {
+	n.log.Info("starting chat notifier", "secret", n.config.SecretName, "configMap", n.config.ConfigMapName)
+	return n.run(ctx, n.loadChannels, n.deliver)
+}
+
+// loadChannels reloads the channels from the secret and the config map, the previous channels are kept if any of them
+// is invalid
+func (n *ChatNotifier) loadChannels(ctx context.Context) {
+	data := [][]byte{}
+	if n.config.SecretName != "" {
+		secret := &corev1.Secret{}
+		err := n.client.Get(ctx, types.NamespacedName{Namespace: n.namespace, Name: n.config.SecretName}, secret)
+		if err != nil && !errors.IsNotFound(err) {
+			n.log.Error(err, "failed to get the chat channels secret", "name", n.config.SecretName)
+			return
+		}
+		data = append(data, secret.Data[ChannelsKey])
+	}
+	if n.config.ConfigMapName != "" {
+		configMap := &corev1.ConfigMap{}
+		err := n.client.Get(ctx, types.NamespacedName{Namespace: n.namespace, Name: n.config.ConfigMapName},
+			configMap)
+		if err != nil && !errors.IsNotFound(err) {
+			n.log.Error(err, "failed to get the chat channels config map", "name", n.config.ConfigMapName)
+			return
+		}
+		data = append(data, []byte(configMap.Data[ChannelsKey]))
+	}
+	channels, err := parseChatChannels(data...)
+	if err != nil {
+		n.log.Error(err, "invalid chat channels", "secret", n.config.SecretName, "configMap", n.config.ConfigMapName)
+		return
+	}
+	n.channels = channels
+}
+
+func parseChatChannels(data ...[]byte) ([]ChatChannel, error) {
+	channels := []ChatChannel{}
+	for _, d := range data {
+		parsed := []ChatChannel{}
+		if err := yaml.Unmarshal(d, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the %s: %w", ChannelsKey, err)
+		}
+		channels = append(channels, parsed...)
+	}
+	names := map[string]bool{}
+	for i := range channels {
+		channel := &channels[i]
+		if channel.Name == "" || names[channel.Name] {
+			return nil, fmt.Errorf("the name of the channel %q is empty or duplicated", channel.Name)
+		}
+		names[channel.Name] = true
+		if channel.Type != ChannelTypeSlack && channel.Type != ChannelTypeTeams {
+			return nil, fmt.Errorf("invalid type of the channel %s: %q", channel.Name, channel.Type)
+		}
+		u, err := url.Parse(channel.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid url of the channel %s", channel.Name)
+		}
+		for j, severity := range channel.Severities {
+			channel.Severities[j] = strings.ToLower(severity)
+		}
+		text := channel.Template
+		if text == "" {
+			text = defaultChannelTemplate
+		}
+		if channel.template, err = template.New(channel.Name).Parse(text); err != nil {
+			return nil, fmt.Errorf("invalid template of the channel %s: %w", channel.Name, err)
+		}
+	}
+	return channels, nil
+}
+
+// deliver posts the events to the channels concurrently, the events of each channel are posted in order
+func (n *ChatNotifier) deliver(ctx context.Context, events []*ComplianceEvent) {
+	if len(n.channels) == 0 {
+		return
+	}
+	if err := n.resolvePolicies(ctx, events); err != nil {
+		n.log.Error(err, "failed to get the names of the policies, the events are delivered without them")
+	}
+
+	var wg sync.WaitGroup
+	for i := range n.channels {
+		channel := n.channels[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, evt := range events {
+				if !channel.routes(evt) {
+					continue
+				}
+				if err := n.post(ctx, &channel, evt); err != nil {
+					ChatDeliveriesCounterVec.WithLabelValues(channel.Name, "failure").Inc()
+					n.log.Error(err, "failed to deliver the event to the channel", "channel", channel.Name,
+						"policy", evt.PolicyID, "cluster", evt.ClusterName)
+					continue
+				}
+				ChatDeliveriesCounterVec.WithLabelValues(channel.Name, "success").Inc()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// routes returns whether the event is routed to the channel by the severity of its policy
+func (c *ChatChannel) routes(evt *ComplianceEvent) bool {
+	return len(c.Severities) == 0 || contains(c.Severities, severity(evt))
+}
+
+func (n *ChatNotifier) post(ctx context.Context, channel *ChatChannel, evt *ComplianceEvent) error {
+	body, err := channel.payload(evt)
+	if err != nil {
+		return err
+	}
+	return retry(ctx, func() (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.URL, bytes.NewReader(body))
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := n.httpClient.Do(req)
+		if err != nil {
+			return true, err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return false, nil
+		}
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retryable, fmt.Errorf("the channel responded with %s", resp.Status)
+	})
+}
+
+// payload renders the message of the event, and returns the body of the incoming webhook of the channel
+func (c *ChatChannel) payload(evt *ComplianceEvent) ([]byte, error) {
+	message := ChatMessage{ComplianceEvent: *evt, Policy: policyName(evt)}
+	message.Severity = severity(evt)
+	text := strings.Builder{}
+	if err := c.template.Execute(&text, message); err != nil {
+		return nil, fmt.Errorf("failed to render the message of the channel %s: %w", c.Name, err)
+	}
+
+	if c.Type == ChannelTypeSlack {
+		return json.Marshal(map[string]string{"text": text.String()})
+	}
+	// the legacy actionable message card, which is accepted by the incoming webhooks of the Microsoft Teams
+	themeColor, ok := teamsThemeColors[message.Severity]
+	if !ok {
+		themeColor = teamsThemeColors["low"]
+	}
+	return json.Marshal(map[string]string{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    fmt.Sprintf("Policy %s is non-compliant on the cluster %s", message.Policy, evt.ClusterName),
+		"themeColor": themeColor,
+		"title":      fmt.Sprintf("Policy %s is non-compliant", message.Policy),
+		"text":       text.String(),
+	})
+}
+
+// severity returns the severity of the policy of the event, or "unknown" if the policy doesn't set it
+func severity(evt *ComplianceEvent) string {
+	if evt.Severity == "" {
+		return unknownSeverity
+	}
+	return evt.Severity
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseChatChannels(t *testing.T) {
+	channels, err := parseChatChannels([]byte(`
+- name: sre
+  type: slack
+  url: https://hooks.slack.com/services/T000/B000/XXX
+  severities: [Critical, high]
+`), []byte(`
+- name: security
+  type: teams
+  url: https://example.webhook.office.com/webhookb2/xxx
+  template: "{{.Policy}} on {{.ClusterName}}"
+`))
+	assert.NoError(t, err)
+	assert.Len(t, channels, 2)
+	assert.Equal(t, []string{"critical", "high"}, channels[0].Severities)
+	assert.True(t, channels[0].routes(&ComplianceEvent{Severity: "critical"}))
+	assert.False(t, channels[0].routes(&ComplianceEvent{Severity: "low"}))
+	assert.False(t, channels[0].routes(&ComplianceEvent{}))
+	assert.True(t, channels[1].routes(&ComplianceEvent{}))
+
+	body, err := channels[1].payload(&ComplianceEvent{
+		PolicyID: "p1", PolicyName: "policy-config", PolicyNamespace: "default", ClusterName: "cluster1",
+		Severity: "critical",
+	})
+	assert.NoError(t, err)
+	card := map[string]string{}
+	assert.NoError(t, json.Unmarshal(body, &card))
+	assert.Equal(t, "MessageCard", card["@type"])
+	assert.Equal(t, "D13438", card["themeColor"])
+	assert.Equal(t, "default/policy-config on cluster1", card["text"])
+
+	_, err = parseChatChannels([]byte(`
+- name: sre
+  type: discord
+  url: https://hooks.slack.com/services/T000/B000/XXX
+`))
+	assert.ErrorContains(t, err, "invalid type")
+
+	_, err = parseChatChannels([]byte(`
+- name: sre
+  type: slack
+  url: https://hooks.slack.com/services/T000/B000/XXX
+`), []byte(`
+- name: sre
+  type: teams
+  url: https://example.webhook.office.com/webhookb2/xxx
+`))
+	assert.ErrorContains(t, err, "duplicated")
+
+	_, err = parseChatChannels([]byte(`
+- name: sre
+  type: slack
+  url: https://hooks.slack.com/services/T000/B000/XXX
+  template: "{{.Policy"
+`))
+	assert.ErrorContains(t, err, "invalid template")
+}
+
+func TestChatNotifier(t *testing.T) {
+	var lock sync.Mutex
+	received := map[string][]map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		message := map[string]string{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		received[r.URL.Path] = append(received[r.URL.Path], message)
+	}))
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "default"},
+		Data: map[string][]byte{
+			ChannelsKey: []byte(`
+- name: sre
+  type: slack
+  url: ` + server.URL + `/slack
+  severities: [critical]
+`),
+		},
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "default"},
+		Data: map[string]string{
+			ChannelsKey: `
+- name: compliance
+  type: teams
+  url: ` + server.URL + `/teams
+`,
+		},
+	}
+	notifier := NewChatNotifier(fake.NewClientBuilder().WithObjects(secret, configMap).Build(), "default",
+		&ChatConfig{SecretName: "chat", ConfigMapName: "chat", Timeout: time.Second})
+	notifier.getPolicies = func(ctx context.Context, policyIDs []string) ([]policyMetadata, error) {
+		return []policyMetadata{
+			{PolicyID: "p1", PolicyName: "policy-config", PolicyNamespace: "default", Severity: "critical"},
+			{PolicyID: "p2", PolicyName: "policy-other", PolicyNamespace: "default"},
+		}, nil
+	}
+
+	startNotifier(t, notifier)
+
+	now := time.Now()
+	notifier.Notify(&ComplianceEvent{
+		PolicyID: "p1", LeafHubName: "hub1", ClusterName: "cluster1", Compliance: "non_compliant", Time: now,
+	}, &ComplianceEvent{
+		PolicyID: "p2", LeafHubName: "hub1", ClusterName: "cluster2", Compliance: "non_compliant", Time: now,
+	})
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received["/slack"]) == 1 && len(received["/teams"]) == 2
+	}, 10*time.Second, 100*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, "Policy default/policy-config is non-compliant on the cluster cluster1 of the managed hub hub1, "+
+		"the severity is critical.", received["/slack"][0]["text"])
+	assert.Equal(t, "Policy default/policy-other is non-compliant", received["/teams"][1]["title"])
+	assert.Contains(t, received["/teams"][1]["text"], "the severity is unknown")
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+// Exporter forwards the events of the managed hubs to an external system once they're written to the database. The
+// exporters queue the events without blocking the status handlers, and the events are dropped if they can't keep up
+type Exporter interface {
+	ExportClusterEvents(events []models.ManagedClusterEvent)
+	ExportRootPolicyEvents(leafHubName string, events []models.LocalRootPolicyEvent)
+	ExportReplicatedPolicyEvents(leafHubName string, events []models.LocalReplicatedPolicyEvent)
+	// ExportComplianceEvents exports the events of the policies which become non-compliant on the clusters, the
+	// events are shared with the other exporters and the webhook notifier, so they must not be changed
+	ExportComplianceEvents(events []*ComplianceEvent)
+}
+
+// Exporters forwards the events to each of the exporters, the exporters which are nil are disabled
+type Exporters []Exporter
+
+func (e Exporters) ExportClusterEvents(events []models.ManagedClusterEvent) {
+	for _, exporter := range e {
+		exporter.ExportClusterEvents(events)
+	}
+}
+
+func (e Exporters) ExportRootPolicyEvents(leafHubName string, events []models.LocalRootPolicyEvent) {
+	for _, exporter := range e {
+		exporter.ExportRootPolicyEvents(leafHubName, events)
+	}
+}
+
+func (e Exporters) ExportReplicatedPolicyEvents(leafHubName string, events []models.LocalReplicatedPolicyEvent) {
+	for _, exporter := range e {
+		exporter.ExportReplicatedPolicyEvents(leafHubName, events)
+	}
+}
+
+func (e Exporters) ExportComplianceEvents(events []*ComplianceEvent) {
+	for _, exporter := range e {
+		exporter.ExportComplianceEvents(events)
+	}
+}
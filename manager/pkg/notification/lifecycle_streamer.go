@@ -0,0 +1,201 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	kafka_confluent "github.com/cloudevents/sdk-go/protocol/kafka_confluent/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/dao"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport/producer"
+)
+
+// ClusterLifecycleEventType is the type of the CloudEvents of the lifecycle events of the managed clusters
+const ClusterLifecycleEventType = enum.EventTypePrefix + "managedcluster.lifecycle"
+
+var StreamedLifecycleEventsCounterVec = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "multicluster_global_hub_cluster_lifecycle_streamed_events_total",
+		Help: "The number of the lifecycle events of the managed clusters sent to the kafka topic, by the result of " +
+			"the send.",
+	},
+	[]string{"result"},
+)
+
+type LifecycleStreamConfig struct {
+	// Topic is the kafka topic of the lifecycle events of the managed clusters, the stream is disabled if it's empty
+	Topic string
+	// Interval is the interval of reading the new lifecycle events from the database
+	Interval time.Duration
+	// BatchSize is the maximum number of the events read from the database in each interval
+	BatchSize int
+}
+
+// lifecycleCursor is the sequence of the last event sent to the topic, it's saved in the status.transport table with
+// the name of the topic, so the stream is resumed from it after the manager is restarted
+type lifecycleCursor struct {
+	Sequence int64 `json:"sequence"`
+}
+
+// LifecycleStreamer sends the lifecycle events of the managed clusters to a kafka topic in the order of their
+// sequence. It reads the events from the database instead of queuing them, so no event is dropped and the stream is
+// resumed after the failures. The message key is the cluster id, so the events of a cluster are in the same partition
+// and consumed in order. The events are sent at least once, the consumers skip the duplicates by the sequence
+type LifecycleStreamer struct {
+	log      logr.Logger
+	producer transport.Producer
+	config   *LifecycleStreamConfig
+	cursor   int64
+}
+
+// AddLifecycleStreamer adds the streamer to the manager, it returns nil if the stream is disabled
+func AddLifecycleStreamer(mgr ctrl.Manager, transportConfig *transport.TransportConfig,
+	config *LifecycleStreamConfig,
+) (*LifecycleStreamer, error) {
+	if config == nil || config.Topic == "" {
+		return nil, nil
+	}
+	if transportConfig.TransportType != string(transport.Kafka) {
+		return nil, fmt.Errorf("the lifecycle events of the managed clusters can only be streamed to kafka")
+	}
+	// the events are read by the external consumers, e.g. the CMDB, so they're always encoded with JSON
+	streamConfig := *transportConfig
+	streamConfig.PayloadEncoding = transport.JSONPayloadEncoding
+	streamConfig.SchemaRegistryConfig = nil
+	lifecycleProducer, err := producer.NewGenericProducer(&streamConfig, config.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the producer of the cluster lifecycle topic: %w", err)
+	}
+	streamer := NewLifecycleStreamer(lifecycleProducer, config)
+	if err := mgr.Add(streamer); err != nil {
+		return nil, fmt.Errorf("failed to add the lifecycle streamer to the manager: %w", err)
+	}
+	return streamer, nil
+}
+
+func NewLifecycleStreamer(p transport.Producer, config *LifecycleStreamConfig) *LifecycleStreamer {
+	return &LifecycleStreamer{
+		log:      ctrl.Log.WithName("lifecycle-streamer"),
+		producer: p,
+		config:   config,
+	}
+}
+
+func (s *LifecycleStreamer) Start(ctx context.Context) error {
+	s.log.Info("starting cluster lifecycle streamer", "topic", s.config.Topic)
+	cursor, err := s.loadCursor(database.GetGorm())
+	if err != nil {
+		return fmt.Errorf("failed to load the cursor of the cluster lifecycle topic: %w", err)
+	}
+	s.cursor = cursor
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.stream(ctx, database.GetGorm()); err != nil {
+				s.log.Error(err, "failed to stream the cluster lifecycle events", "sequence", s.cursor)
+			}
+		}
+	}
+}
+
+// stream sends the events after the cursor until there is no more settled event, the cursor is saved after each batch.
+// It stops at the first failed event, which is sent again in the next interval
+func (s *LifecycleStreamer) stream(ctx context.Context, db *gorm.DB) error {
+	for {
+		events, err := dao.ListClusterLifecycleEvents(db, &dao.ClusterLifecycleFilter{
+			After: s.cursor,
+			Limit: s.config.BatchSize,
+		})
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		sent := s.cursor
+		for i := range events {
+			if err := s.send(ctx, &events[i]); err != nil {
+				StreamedLifecycleEventsCounterVec.WithLabelValues("failure").Inc()
+				return errors.Join(err, s.saveCursor(db, sent))
+			}
+			StreamedLifecycleEventsCounterVec.WithLabelValues("success").Inc()
+			sent = events[i].Sequence
+		}
+		if err := s.saveCursor(db, sent); err != nil {
+			return err
+		}
+		if len(events) < s.config.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *LifecycleStreamer) send(ctx context.Context, event *models.ClusterLifecycleEvent) error {
+	cloudEvent := cloudevents.NewEvent()
+	// the sequence is the id, so the duplicates of the at-least-once delivery are identified by it
+	cloudEvent.SetID(strconv.FormatInt(event.Sequence, 10))
+	cloudEvent.SetType(ClusterLifecycleEventType)
+	cloudEvent.SetSource(event.LeafHubName)
+	cloudEvent.SetSubject(event.ClusterName)
+	cloudEvent.SetTime(event.CreatedAt)
+	if err := cloudEvent.SetData(cloudevents.ApplicationJSON, event); err != nil {
+		return err
+	}
+	// the cluster id is kept when the cluster is moved to another hub, so all its events are in one partition
+	return s.producer.SendEvent(kafka_confluent.WithMessageKey(ctx, event.ClusterID), cloudEvent)
+}
+
+func (s *LifecycleStreamer) loadCursor(db *gorm.DB) (int64, error) {
+	saved := models.Transport{}
+	err := db.Where("name = ?", s.config.Topic).Limit(1).Find(&saved).Error
+	if err != nil || len(saved.Payload) == 0 {
+		return 0, err
+	}
+	cursor := lifecycleCursor{}
+	if err := json.Unmarshal(saved.Payload, &cursor); err != nil {
+		return 0, err
+	}
+	return cursor.Sequence, nil
+}
+
+func (s *LifecycleStreamer) saveCursor(db *gorm.DB, sequence int64) error {
+	if sequence == s.cursor {
+		return nil
+	}
+	payload, err := json.Marshal(lifecycleCursor{Sequence: sequence})
+	if err != nil {
+		return err
+	}
+	err = db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&models.Transport{
+		Name:    s.config.Topic,
+		Payload: payload,
+	}).Error
+	if err != nil {
+		return err
+	}
+	s.cursor = sequence
+	return nil
+}
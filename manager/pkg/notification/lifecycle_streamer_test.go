@@ -0,0 +1,58 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kafka_confluent "github.com/cloudevents/sdk-go/protocol/kafka_confluent/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+type fakeLifecycleProducer struct {
+	keys   []string
+	events []cloudevents.Event
+}
+
+func (p *fakeLifecycleProducer) SendEvent(ctx context.Context, evt cloudevents.Event) error {
+	p.keys = append(p.keys, kafka_confluent.MessageKeyFrom(ctx))
+	p.events = append(p.events, evt)
+	return nil
+}
+
+func TestLifecycleStreamerSend(t *testing.T) {
+	p := &fakeLifecycleProducer{}
+	streamer := NewLifecycleStreamer(p, &LifecycleStreamConfig{Topic: "gh-cluster-lifecycle"})
+
+	createdAt := time.Date(2024, 5, 21, 2, 0, 0, 0, time.UTC)
+	err := streamer.send(context.Background(), &models.ClusterLifecycleEvent{
+		Sequence:        1024,
+		LeafHubName:     "hub1",
+		ClusterID:       "3f406177-34b2-4852-88dd-ff2809680335",
+		ClusterName:     "cluster1",
+		EventType:       models.ClusterUpgraded,
+		Version:         "4.15.2",
+		PreviousVersion: "4.14.10",
+		CreatedAt:       createdAt,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3f406177-34b2-4852-88dd-ff2809680335"}, p.keys)
+
+	evt := p.events[0]
+	assert.Equal(t, "1024", evt.ID())
+	assert.Equal(t, ClusterLifecycleEventType, evt.Type())
+	assert.Equal(t, "hub1", evt.Source())
+	assert.Equal(t, "cluster1", evt.Subject())
+	assert.Equal(t, createdAt, evt.Time())
+
+	event := models.ClusterLifecycleEvent{}
+	assert.NoError(t, evt.DataAs(&event))
+	assert.Equal(t, models.ClusterUpgraded, event.EventType)
+	assert.Equal(t, "4.14.10", event.PreviousVersion)
+}
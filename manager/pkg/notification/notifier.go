@@ -0,0 +1,21 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+// Notifier notifies the incident tooling when the policies become non-compliant on the clusters. The notifiers queue
+// the events without blocking the status handlers, and the events are dropped if they can't keep up
+type Notifier interface {
+	// Notify queues the events, which are shared with the other notifiers and the exporters, the notifier copies the
+	// events before changing them
+	Notify(events ...*ComplianceEvent)
+}
+
+// Notifiers notifies each of the notifiers, the notifiers which are nil are disabled
+type Notifiers []Notifier
+
+func (n Notifiers) Notify(events ...*ComplianceEvent) {
+	for _, notifier := range n {
+		notifier.Notify(events...)
+	}
+}
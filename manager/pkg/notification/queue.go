@@ -0,0 +1,130 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var DroppedNotificationsCounterVec = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "multicluster_global_hub_notification_dropped_events_total",
+		Help: "The number of the events dropped since the notifier can't keep up, by the notifier.",
+	},
+	[]string{"notifier"},
+)
+
+// eventQueue buffers the events of a notifier, so the status handlers aren't blocked by a slow endpoint. The events
+// are dropped once the buffer is full, and they're handled in batches by the run loop of the notifier
+type eventQueue[T any] struct {
+	// name is the notifier label of the dropped events, e.g. "webhook"
+	name   string
+	events chan T
+}
+
+func newEventQueue[T any](name string) *eventQueue[T] {
+	return &eventQueue[T]{
+		name:   name,
+		events: make(chan T, bufferSize),
+	}
+}
+
+// enqueue queues the event, it's dropped if the buffer is full
+func (q *eventQueue[T]) enqueue(evt T) {
+	select {
+	case q.events <- evt:
+	default:
+		DroppedNotificationsCounterVec.WithLabelValues(q.name).Inc()
+	}
+}
+
+// run hands the queued events to the handle in batches until the context is done. The reload is called once it starts
+// and then in the resync interval, e.g. to reload the endpoints from the secret, it's skipped if it's nil
+func (q *eventQueue[T]) run(ctx context.Context, reload func(ctx context.Context),
+	handle func(ctx context.Context, events []T),
+) error {
+	ticker := time.NewTicker(resyncInterval)
+	defer ticker.Stop()
+
+	if reload != nil {
+		reload(ctx)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if reload != nil {
+				reload(ctx)
+			}
+		case evt := <-q.events:
+			batch := []T{evt}
+			for len(batch) < batchSize && len(q.events) > 0 {
+				batch = append(batch, <-q.events)
+			}
+			handle(ctx, batch)
+		}
+	}
+}
+
+// complianceQueue is the queue of the notifiers of the compliance events, the events are copied before they're
+// queued, since they're shared with the other notifiers and the exporters, and the names of the policies are set on
+// the copies
+type complianceQueue struct {
+	*eventQueue[*ComplianceEvent]
+	policyResolver
+}
+
+func newComplianceQueue(name string) complianceQueue {
+	return complianceQueue{
+		eventQueue:     newEventQueue[*ComplianceEvent](name),
+		policyResolver: policyResolver{getPolicies: getPolicies},
+	}
+}
+
+// enqueueCopies queues the copies of the events
+func (q *complianceQueue) enqueueCopies(events []*ComplianceEvent) {
+	for _, evt := range events {
+		copied := *evt
+		q.enqueue(&copied)
+	}
+}
+
+// policyResolver sets the names, the namespaces and the severities of the policies of the compliance events, so they
+// can be filtered and routed by the policies
+type policyResolver struct {
+	// getPolicies returns the metadata of the policies by their IDs, it's replaced by the tests
+	getPolicies func(ctx context.Context, policyIDs []string) ([]policyMetadata, error)
+}
+
+// resolvePolicies sets the metadata of the policies of the events which don't have the names
+func (r *policyResolver) resolvePolicies(ctx context.Context, events []*ComplianceEvent) error {
+	policyIDs := []string{}
+	for _, evt := range events {
+		if evt.PolicyName == "" {
+			policyIDs = append(policyIDs, evt.PolicyID)
+		}
+	}
+	if len(policyIDs) == 0 {
+		return nil
+	}
+	policies, err := r.getPolicies(ctx, policyIDs)
+	if err != nil {
+		return err
+	}
+	metadata := map[string]policyMetadata{}
+	for _, policy := range policies {
+		metadata[policy.PolicyID] = policy
+	}
+	for _, evt := range events {
+		if policy, ok := metadata[evt.PolicyID]; ok {
+			evt.PolicyName, evt.PolicyNamespace, evt.Severity = policy.PolicyName, policy.PolicyNamespace,
+				policy.Severity
+		}
+	}
+	return nil
+}
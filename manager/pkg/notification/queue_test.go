@@ -0,0 +1,95 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+// startNotifier runs the notifier until the test ends
+func startNotifier(t *testing.T, notifier manager.Runnable) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		assert.NoError(t, notifier.Start(ctx))
+	}()
+}
+
+func TestEventQueue(t *testing.T) {
+	queue := newEventQueue[int]("test")
+	// the events beyond the buffer are dropped
+	for i := 0; i < bufferSize+2; i++ {
+		queue.enqueue(i)
+	}
+	assert.Equal(t, float64(2), testutil.ToFloat64(DroppedNotificationsCounterVec.WithLabelValues("test")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded, handled := 0, []int{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, queue.run(ctx, func(ctx context.Context) {
+			reloaded++
+		}, func(ctx context.Context, events []int) {
+			assert.LessOrEqual(t, len(events), batchSize)
+			handled = append(handled, events...)
+			if len(handled) == bufferSize {
+				cancel()
+			}
+		}))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the queued events aren't handled")
+	}
+	// the events are handled in order, and the reload is called once it starts
+	assert.Equal(t, 1, reloaded)
+	assert.Len(t, handled, bufferSize)
+	assert.Equal(t, 0, handled[0])
+	assert.Equal(t, bufferSize-1, handled[bufferSize-1])
+}
+
+func TestComplianceQueue(t *testing.T) {
+	queue := newComplianceQueue("test")
+	queue.getPolicies = func(ctx context.Context, policyIDs []string) ([]policyMetadata, error) {
+		assert.Equal(t, []string{"p1"}, policyIDs)
+		return []policyMetadata{
+			{PolicyID: "p1", PolicyName: "policy-config", PolicyNamespace: "default", Severity: "high"},
+		}, nil
+	}
+
+	shared := &ComplianceEvent{PolicyID: "p1", LeafHubName: "hub1", ClusterName: "cluster1"}
+	queue.enqueueCopies([]*ComplianceEvent{shared})
+	copied := <-queue.events
+	assert.NotSame(t, shared, copied)
+
+	// the policies are resolved on the copies, the shared events aren't changed
+	assert.NoError(t, queue.resolvePolicies(context.Background(), []*ComplianceEvent{copied}))
+	assert.Equal(t, "policy-config", copied.PolicyName)
+	assert.Equal(t, "default", copied.PolicyNamespace)
+	assert.Equal(t, "high", copied.Severity)
+	assert.Empty(t, shared.PolicyName)
+}
+
+func TestDisabledNotifiers(t *testing.T) {
+	// the notifiers and the exporters are nil if they're disabled
+	notifiers := Notifiers{(*WebhookNotifier)(nil), (*ServiceNowNotifier)(nil), (*ChatNotifier)(nil)}
+	assert.NotPanics(t, func() {
+		notifiers.Notify(&ComplianceEvent{PolicyID: "p1"})
+	})
+
+	exporters := Exporters{(*SIEMExporter)(nil)}
+	assert.NotPanics(t, func() {
+		exporters.ExportClusterEvents([]models.ManagedClusterEvent{{ClusterName: "cluster1"}})
+		exporters.ExportComplianceEvents([]*ComplianceEvent{{PolicyID: "p1"}})
+	})
+}
@@ -0,0 +1,329 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const (
+	// ServiceNowKey is the key of the settings of the ServiceNow in the secret
+	ServiceNowKey = "servicenow.yaml"
+
+	defaultServiceNowTable = "incident"
+	// the incidents are deduplicated by the policy and the cluster by default, the correlation ID of the ServiceNow
+	// is limited to 100 characters
+	defaultServiceNowDedupKey        = "{{.PolicyID}}/{{.ClusterName}}"
+	defaultServiceNowClusterSelector = "environment=production"
+	serviceNowCorrelationDisplay     = "Multicluster Global Hub"
+)
+
+var ServiceNowIncidentsCounterVec = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "multicluster_global_hub_servicenow_incidents_total",
+		Help: "The number of the ServiceNow incidents opened or updated for the non-compliant policies, by the " +
+			"action, 'created', 'updated' or 'failed'.",
+	},
+	[]string{"action"},
+)
+
+type ServiceNowConfig struct {
+	// SecretName is the secret of the ServiceNow settings in the namespace of the manager, the incidents are disabled
+	// if it's empty. The settings are reloaded once the secret is changed, without restarting the manager
+	SecretName string
+	// Timeout is the timeout of each request to the ServiceNow
+	Timeout time.Duration
+}
+
+// ServiceNowSettings are the instance, the credentials and the rules of the incidents
+type ServiceNowSettings struct {
+	// Instance is the URL of the ServiceNow instance, e.g. https://example.service-now.com
+	Instance string `json:"instance"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Table is the table of the incidents, it's "incident" by default
+	Table string `json:"table,omitempty"`
+	// AssignmentGroup is the name or the sys_id of the group which the incidents are assigned to
+	AssignmentGroup string `json:"assignmentGroup,omitempty"`
+	// DedupKey is the template of the correlation ID of the incidents, the events with the same key update the open
+	// incident instead of opening a new one. The fields of the ComplianceEvent can be used, it's
+	// "{{.PolicyID}}/{{.ClusterName}}" by default
+	DedupKey string `json:"dedupKey,omitempty"`
+	// Policies are the names, or the "namespace/name", of the policies which open the incidents, all the policies
+	// open the incidents if it's empty
+	Policies []string `json:"policies,omitempty"`
+	// ClusterSelector is the label selector of the clusters which open the incidents, it's "environment=production"
+	// by default
+	ClusterSelector string `json:"clusterSelector,omitempty"`
+
+	dedupKey        *template.Template
+	clusterSelector labels.Selector
+}
+
+// ServiceNowNotifier opens an incident in the ServiceNow whenever a configured policy becomes non-compliant on a
+// production cluster, or updates the open incident of the same dedup key, so the violations are tracked by the
+// operations teams without the duplicates. The events are queued by the status handlers and handled asynchronously
+type ServiceNowNotifier struct {
+	complianceQueue
+	log        logr.Logger
+	client     client.Reader
+	namespace  string
+	config     *ServiceNowConfig
+	httpClient *http.Client
+	// the settings loaded from the secret, they're only accessed by the notification loop
+	settings *ServiceNowSettings
+	// getClusterLabels returns the labels of the cluster of the managed hub
+	getClusterLabels func(ctx context.Context, leafHubName, clusterName string) (map[string]string, error)
+}
+
+// AddServiceNowNotifier adds the notifier to the manager, it returns nil if the incidents are disabled
+func AddServiceNowNotifier(mgr ctrl.Manager, namespace string, config *ServiceNowConfig) (*ServiceNowNotifier,
+	error,
+) {
+	if config == nil || config.SecretName == "" {
+		return nil, nil
+	}
+	notifier := NewServiceNowNotifier(mgr.GetAPIReader(), namespace, config)
+	if err := mgr.Add(notifier); err != nil {
+		return nil, fmt.Errorf("failed to add the ServiceNow notifier to the manager: %w", err)
+	}
+	return notifier, nil
+}
+
+func NewServiceNowNotifier(c client.Reader, namespace string, config *ServiceNowConfig) *ServiceNowNotifier {
+	return &ServiceNowNotifier{
+		complianceQueue:  newComplianceQueue("servicenow"),
+		log:              ctrl.Log.WithName("servicenow-notifier"),
+		client:           c,
+		namespace:        namespace,
+		config:           config,
+		httpClient:       &http.Client{Timeout: config.Timeout},
+		getClusterLabels: getClusterLabels,
+	}
+}
+
+// Notify queues the events, they're dropped if the buffer is full. It does nothing if the notifier is nil
+func (n *ServiceNowNotifier) Notify(events ...*ComplianceEvent) {
+	if n != nil {
+		n.enqueueCopies(events)
+	}
+}
+
+func (n *ServiceNowNotifier) Start(ctx context.Context) error {
+	n.log.Info("starting ServiceNow notifier", "secret", n.config.SecretName)
+	return n.run(ctx, n.loadSettings, n.notify)
+}
+
+// loadSettings reloads the settings from the secret, the previous settings are kept if the secret is invalid
+func (n *ServiceNowNotifier) loadSettings(ctx context.Context) {
+	secret := &corev1.Secret{}
+	err := n.client.Get(ctx, types.NamespacedName{Namespace: n.namespace, Name: n.config.SecretName}, secret)
+	if errors.IsNotFound(err) {
+		n.settings = nil
+		return
+	}
+	if err != nil {
+		n.log.Error(err, "failed to get the ServiceNow secret", "name", n.config.SecretName)
+		return
+	}
+	settings, err := parseServiceNowSettings(secret.Data[ServiceNowKey])
+	if err != nil {
+		n.log.Error(err, "invalid ServiceNow settings in the secret", "name", n.config.SecretName)
+		return
+	}
+	n.settings = settings
+}
+
+func parseServiceNowSettings(data []byte) (*ServiceNowSettings, error) {
+	settings := &ServiceNowSettings{}
+	if err := yaml.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the %s: %w", ServiceNowKey, err)
+	}
+	u, err := url.Parse(settings.Instance)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url of the ServiceNow instance: %q", settings.Instance)
+	}
+	settings.Instance = strings.TrimSuffix(settings.Instance, "/")
+	if settings.Username == "" || settings.Password == "" {
+		return nil, fmt.Errorf("the credentials of the ServiceNow aren't set")
+	}
+	if settings.Table == "" {
+		settings.Table = defaultServiceNowTable
+	}
+	if settings.DedupKey == "" {
+		settings.DedupKey = defaultServiceNowDedupKey
+	}
+	settings.dedupKey, err = template.New("dedupKey").Parse(settings.DedupKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dedup key %q: %w", settings.DedupKey, err)
+	}
+	if settings.ClusterSelector == "" {
+		settings.ClusterSelector = defaultServiceNowClusterSelector
+	}
+	settings.clusterSelector, err = labels.Parse(settings.ClusterSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cluster selector %q: %w", settings.ClusterSelector, err)
+	}
+	return settings, nil
+}
+
+// notify opens or updates the incidents of the events which match the policies and the cluster selector
+func (n *ServiceNowNotifier) notify(ctx context.Context, events []*ComplianceEvent) {
+	settings := n.settings
+	if settings == nil {
+		return
+	}
+	if err := n.resolvePolicies(ctx, events); err != nil {
+		n.log.Error(err, "failed to get the names of the policies, the events are notified without them")
+	}
+
+	filters := &WebhookFilters{Policies: settings.Policies}
+	// the labels of the clusters in the batch, by the "<leaf hub>/<cluster>"
+	clusterLabels := map[string]labels.Set{}
+	for _, evt := range events {
+		if !filters.matches(evt) {
+			continue
+		}
+		key := evt.LeafHubName + "/" + evt.ClusterName
+		if _, ok := clusterLabels[key]; !ok {
+			clusterLabelMap, err := n.getClusterLabels(ctx, evt.LeafHubName, evt.ClusterName)
+			if err != nil {
+				n.log.Error(err, "failed to get the labels of the cluster", "hub", evt.LeafHubName,
+					"cluster", evt.ClusterName)
+			}
+			clusterLabels[key] = clusterLabelMap
+		}
+		if !settings.clusterSelector.Matches(clusterLabels[key]) {
+			continue
+		}
+
+		action, err := n.openIncident(ctx, settings, evt)
+		if err != nil {
+			ServiceNowIncidentsCounterVec.WithLabelValues("failed").Inc()
+			n.log.Error(err, "failed to open the ServiceNow incident", "policy", evt.PolicyID,
+				"cluster", evt.ClusterName)
+			continue
+		}
+		ServiceNowIncidentsCounterVec.WithLabelValues(action).Inc()
+	}
+}
+
+// openIncident updates the open incident of the dedup key, or opens a new one if there isn't. It returns whether the
+// incident is "created" or "updated"
+func (n *ServiceNowNotifier) openIncident(ctx context.Context, settings *ServiceNowSettings,
+	evt *ComplianceEvent,
+) (string, error) {
+	dedupKey := strings.Builder{}
+	if err := settings.dedupKey.Execute(&dedupKey, evt); err != nil {
+		return "", fmt.Errorf("failed to render the dedup key: %w", err)
+	}
+
+	// the "^" is the separator of the encoded query, it's escaped by doubling it
+	query := url.Values{}
+	query.Set("sysparm_query", "active=true^correlation_id="+strings.ReplaceAll(dedupKey.String(), "^", "^^"))
+	query.Set("sysparm_fields", "sys_id")
+	query.Set("sysparm_limit", "1")
+	found := struct {
+		Result []struct {
+			SysID string `json:"sys_id"`
+		} `json:"result"`
+	}{}
+	if err := n.request(ctx, settings, http.MethodGet, "?"+query.Encode(), nil, &found); err != nil {
+		return "", err
+	}
+
+	policy := policyName(evt)
+	if len(found.Result) > 0 {
+		return "updated", n.request(ctx, settings, http.MethodPatch, "/"+found.Result[0].SysID, map[string]string{
+			"work_notes": fmt.Sprintf("The policy %s is non-compliant on the cluster %s of the managed hub %s again "+
+				"at %s.", policy, evt.ClusterName, evt.LeafHubName, evt.Time.UTC().Format(time.RFC3339)),
+		}, nil)
+	}
+
+	incident := map[string]string{
+		"short_description": fmt.Sprintf("Policy %s is non-compliant on the cluster %s", policy, evt.ClusterName),
+		"description": fmt.Sprintf("Policy: %s\nPolicy ID: %s\nManaged hub: %s\nCluster: %s\nCompliance: %s\n"+
+			"Previous compliance: %s\nTime: %s", policy, evt.PolicyID, evt.LeafHubName, evt.ClusterName,
+			evt.Compliance, evt.PreviousCompliance, evt.Time.UTC().Format(time.RFC3339)),
+		"correlation_id":      dedupKey.String(),
+		"correlation_display": serviceNowCorrelationDisplay,
+	}
+	if settings.AssignmentGroup != "" {
+		incident["assignment_group"] = settings.AssignmentGroup
+	}
+	return "created", n.request(ctx, settings, http.MethodPost, "", incident, nil)
+}
+
+// request sends the request to the table API of the ServiceNow, the failed requests are retried with the exponential
+// backoff. The response is decoded into the result if it isn't nil
+func (n *ServiceNowNotifier) request(ctx context.Context, settings *ServiceNowSettings, method, path string,
+	body, result interface{},
+) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	endpoint := settings.Instance + "/api/now/table/" + url.PathEscape(settings.Table) + path
+	return retry(ctx, func() (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return false, err
+		}
+		req.SetBasicAuth(settings.Username, settings.Password)
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := n.httpClient.Do(req)
+		if err != nil {
+			return true, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+			return retryable, fmt.Errorf("the ServiceNow responded with %s %s", resp.Status, message)
+		}
+		if result == nil {
+			return false, nil
+		}
+		return false, json.NewDecoder(resp.Body).Decode(result)
+	})
+}
+
+func getClusterLabels(ctx context.Context, leafHubName, clusterName string) (map[string]string, error) {
+	payloads := []string{}
+	err := database.GetGorm().WithContext(ctx).Raw(`SELECT payload -> 'metadata' -> 'labels'
+		FROM status.managed_clusters WHERE leaf_hub_name = ? AND cluster_name = ? AND deleted_at IS NULL`,
+		leafHubName, clusterName).Scan(&payloads).Error
+	clusterLabels := map[string]string{}
+	if err != nil || len(payloads) == 0 || payloads[0] == "" {
+		return clusterLabels, err
+	}
+	return clusterLabels, json.Unmarshal([]byte(payloads[0]), &clusterLabels)
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseServiceNowSettings(t *testing.T) {
+	settings, err := parseServiceNowSettings([]byte(`
+instance: https://example.service-now.com/
+username: admin
+password: s3cr3t
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.service-now.com", settings.Instance)
+	assert.Equal(t, "incident", settings.Table)
+	assert.Equal(t, "{{.PolicyID}}/{{.ClusterName}}", settings.DedupKey)
+	assert.Equal(t, "environment=production", settings.ClusterSelector)
+
+	_, err = parseServiceNowSettings([]byte(`
+instance: example.service-now.com
+username: admin
+password: s3cr3t
+`))
+	assert.ErrorContains(t, err, "invalid url")
+
+	_, err = parseServiceNowSettings([]byte(`
+instance: https://example.service-now.com
+username: admin
+password: s3cr3t
+clusterSelector: environment in (production
+`))
+	assert.ErrorContains(t, err, "invalid cluster selector")
+
+	_, err = parseServiceNowSettings([]byte(`
+instance: https://example.service-now.com
+`))
+	assert.ErrorContains(t, err, "credentials")
+}
+
+func TestServiceNowNotifier(t *testing.T) {
+	var lock sync.Mutex
+	// the open incidents by the correlation ID
+	incidents := map[string]map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "admin:s3cr3t", username+":"+password)
+		assert.True(t, strings.HasPrefix(r.URL.Path, "/api/now/table/u_compliance_incident"))
+
+		switch r.Method {
+		case http.MethodGet:
+			result := []map[string]string{}
+			correlationID := strings.TrimPrefix(r.URL.Query().Get("sysparm_query"), "active=true^correlation_id=")
+			if incident, ok := incidents[correlationID]; ok {
+				result = append(result, map[string]string{"sys_id": incident["sys_id"]})
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{"result": result}))
+		case http.MethodPost:
+			incident := map[string]string{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&incident))
+			incident["sys_id"] = fmt.Sprintf("sys%d", len(incidents)+1)
+			incidents[incident["correlation_id"]] = incident
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodPatch:
+			update := map[string]string{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&update))
+			for _, incident := range incidents {
+				if r.URL.Path == "/api/now/table/u_compliance_incident/"+incident["sys_id"] {
+					incident["work_notes"] = update["work_notes"]
+				}
+			}
+		}
+	}))
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "servicenow", Namespace: "default"},
+		Data: map[string][]byte{
+			ServiceNowKey: []byte(`
+instance: ` + server.URL + `
+username: admin
+password: s3cr3t
+table: u_compliance_incident
+assignmentGroup: Platform Operations
+dedupKey: "{{.PolicyNamespace}}/{{.PolicyName}}@{{.LeafHubName}}/{{.ClusterName}}"
+policies: [default/policy-config]
+`),
+		},
+	}
+	notifier := NewServiceNowNotifier(fake.NewClientBuilder().WithObjects(secret).Build(), "default",
+		&ServiceNowConfig{SecretName: "servicenow", Timeout: time.Second})
+	notifier.getPolicies = func(ctx context.Context, policyIDs []string) ([]policyMetadata, error) {
+		return []policyMetadata{
+			{PolicyID: "p1", PolicyName: "policy-config", PolicyNamespace: "default"},
+			{PolicyID: "p2", PolicyName: "policy-other", PolicyNamespace: "default"},
+		}, nil
+	}
+	notifier.getClusterLabels = func(ctx context.Context, leafHubName, clusterName string) (map[string]string,
+		error,
+	) {
+		if clusterName == "cluster1" {
+			return map[string]string{"environment": "production"}, nil
+		}
+		return map[string]string{"environment": "dev"}, nil
+	}
+
+	startNotifier(t, notifier)
+
+	now := time.Now()
+	notifier.Notify(&ComplianceEvent{
+		PolicyID: "p1", LeafHubName: "hub1", ClusterName: "cluster1", Compliance: "non_compliant",
+		PreviousCompliance: "compliant", Time: now,
+	}, &ComplianceEvent{
+		// the policy isn't configured
+		PolicyID: "p2", LeafHubName: "hub1", ClusterName: "cluster1", Compliance: "non_compliant", Time: now,
+	}, &ComplianceEvent{
+		// the cluster isn't a production cluster
+		PolicyID: "p1", LeafHubName: "hub1", ClusterName: "cluster2", Compliance: "non_compliant", Time: now,
+	})
+
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(incidents) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// the incident is updated once the policy becomes non-compliant again
+	notifier.Notify(&ComplianceEvent{
+		PolicyID: "p1", LeafHubName: "hub1", ClusterName: "cluster1", Compliance: "non_compliant",
+		PreviousCompliance: "compliant", Time: now.Add(time.Hour),
+	})
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return incidents["default/policy-config@hub1/cluster1"]["work_notes"] != ""
+	}, 10*time.Second, 100*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Len(t, incidents, 1)
+	incident := incidents["default/policy-config@hub1/cluster1"]
+	assert.Equal(t, "Policy default/policy-config is non-compliant on the cluster cluster1",
+		incident["short_description"])
+	assert.Equal(t, "Platform Operations", incident["assignment_group"])
+	assert.Equal(t, "Multicluster Global Hub", incident["correlation_display"])
+	assert.Contains(t, incident["description"], "Managed hub: hub1")
+}
@@ -0,0 +1,441 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+const (
+	// SIEMFormatCEF is the ArcSight Common Event Format in the message of the syslog, e.g. the Splunk Add-on for CEF
+	SIEMFormatCEF = "cef"
+	// SIEMFormatRFC5424 is the syslog with the fields of the events in the structured data
+	SIEMFormatRFC5424 = "rfc5424"
+	// SIEMFramingOctetCounting prefixes each message with its length, it's the framing of the RFC5425
+	SIEMFramingOctetCounting = "octet-counting"
+	// SIEMFramingNewline terminates each message with a newline, e.g. the TCP inputs of the Splunk
+	SIEMFramingNewline = "newline"
+
+	// the keys of the TLS secret, the system roots are used to verify the collector if the CA isn't set, and the
+	// client certificate is only presented if it's set
+	SIEMCAKey   = "ca.crt"
+	SIEMCertKey = "tls.crt"
+	SIEMKeyKey  = "tls.key"
+
+	siemAppName = "multicluster-global-hub"
+	// the private enterprise number of Red Hat, it qualifies the ID of the structured data
+	siemSDID = "globalhub@2312"
+	// the facility of the messages is the local0, the priority is the facility * 8 + the severity
+	siemFacility      = 16
+	siemWarning       = 4
+	siemInformational = 6
+
+	cefVendor  = "Red Hat"
+	cefProduct = "Multicluster Global Hub"
+	// cefVersion is the version of the fields of the CEF events, it's changed if the fields are changed
+	cefVersion = "1"
+
+	clusterEventMsgID       = "cluster-event"
+	policyNonCompliantMsgID = "policy-noncompliant"
+)
+
+var SIEMExportedEventsCounterVec = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "multicluster_global_hub_siem_exported_events_total",
+		Help: "The number of the events exported to the SIEM collector, by the type of the event and the result " +
+			"of the export.",
+	},
+	[]string{"type", "result"},
+)
+
+type SIEMConfig struct {
+	// Address is the "host:port" of the syslog collector over TLS, the export is disabled if it's empty
+	Address string
+	// Format is the format of the messages, either the SIEMFormatCEF or the SIEMFormatRFC5424
+	Format string
+	// Framing is the framing of the messages in the stream, either the SIEMFramingOctetCounting or the
+	// SIEMFramingNewline
+	Framing string
+	// TLSSecretName is the secret of the CA and the client certificate in the namespace of the manager
+	TLSSecretName string
+	// Timeout is the timeout of connecting and writing to the collector
+	Timeout time.Duration
+}
+
+// SIEMExporter streams the policy violations and the cluster lifecycle events of the managed hubs to a SIEM
+// collector, e.g. the Splunk, in the syslog over TLS. The events are queued by the status handlers once they're written
+// to the database, and written in order over a long-lived connection, which is reconnected once a write fails
+type SIEMExporter struct {
+	*eventQueue[*siemEvent]
+	policyResolver
+	log       logr.Logger
+	client    client.Reader
+	namespace string
+	config    *SIEMConfig
+	conn      net.Conn
+}
+
+// siemEvent is either the event of a managed cluster or the violation of a policy
+type siemEvent struct {
+	cluster    *models.ManagedClusterEvent
+	compliance *ComplianceEvent
+}
+
+// AddSIEMExporter adds the exporter to the manager, it returns nil if the export is disabled
+func AddSIEMExporter(mgr ctrl.Manager, namespace string, config *SIEMConfig) (*SIEMExporter, error) {
+	if config == nil || config.Address == "" {
+		return nil, nil
+	}
+	exporter, err := NewSIEMExporter(mgr.GetAPIReader(), namespace, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Add(exporter); err != nil {
+		return nil, fmt.Errorf("failed to add the SIEM exporter to the manager: %w", err)
+	}
+	return exporter, nil
+}
+
+func NewSIEMExporter(c client.Reader, namespace string, config *SIEMConfig) (*SIEMExporter, error) {
+	if _, _, err := net.SplitHostPort(config.Address); err != nil {
+		return nil, fmt.Errorf("invalid address of the SIEM collector: %q", config.Address)
+	}
+	if config.Format != SIEMFormatCEF && config.Format != SIEMFormatRFC5424 {
+		return nil, fmt.Errorf("invalid format of the SIEM messages: %q", config.Format)
+	}
+	if config.Framing != SIEMFramingOctetCounting && config.Framing != SIEMFramingNewline {
+		return nil, fmt.Errorf("invalid framing of the SIEM messages: %q", config.Framing)
+	}
+	return &SIEMExporter{
+		eventQueue:     newEventQueue[*siemEvent]("siem"),
+		policyResolver: policyResolver{getPolicies: getPolicies},
+		log:            ctrl.Log.WithName("siem-exporter"),
+		client:         c,
+		namespace:      namespace,
+		config:         config,
+	}, nil
+}
+
+// ExportClusterEvents queues the events of the managed clusters. It does nothing if the exporter is nil
+func (e *SIEMExporter) ExportClusterEvents(events []models.ManagedClusterEvent) {
+	if e == nil {
+		return
+	}
+	for i := range events {
+		evt := events[i]
+		e.enqueue(&siemEvent{cluster: &evt})
+	}
+}
+
+// ExportRootPolicyEvents does nothing, the violations are exported by the ExportComplianceEvents
+func (e *SIEMExporter) ExportRootPolicyEvents(leafHubName string, events []models.LocalRootPolicyEvent) {
+}
+
+// ExportReplicatedPolicyEvents does nothing, the violations are exported by the ExportComplianceEvents
+func (e *SIEMExporter) ExportReplicatedPolicyEvents(leafHubName string,
+	events []models.LocalReplicatedPolicyEvent,
+) {
+}
+
+// ExportComplianceEvents queues the events of the policies which become non-compliant on the clusters. It does
+// nothing if the exporter is nil
+func (e *SIEMExporter) ExportComplianceEvents(events []*ComplianceEvent) {
+	if e == nil {
+		return
+	}
+	for _, evt := range events {
+		// the events are shared with the webhook notifier, which sets the names of the policies
+		copied := *evt
+		e.enqueue(&siemEvent{compliance: &copied})
+	}
+}
+
+func (e *SIEMExporter) Start(ctx context.Context) error {
+	e.log.Info("starting SIEM exporter", "address", e.config.Address, "format", e.config.Format)
+	defer e.close()
+	return e.run(ctx, nil, e.export)
+}
+
+func (e *SIEMExporter) export(ctx context.Context, events []*siemEvent) {
+	violations := []*ComplianceEvent{}
+	for _, evt := range events {
+		if evt.compliance != nil {
+			violations = append(violations, evt.compliance)
+		}
+	}
+	if err := e.resolvePolicies(ctx, violations); err != nil {
+		e.log.Error(err, "failed to get the names of the policies, the events are exported without them")
+	}
+
+	for _, evt := range events {
+		msgID := evt.msgID()
+		if err := e.write(ctx, e.frame(e.format(evt))); err != nil {
+			SIEMExportedEventsCounterVec.WithLabelValues(msgID, "failure").Inc()
+			e.log.Error(err, "failed to export the event to the SIEM collector", "type", msgID)
+			continue
+		}
+		SIEMExportedEventsCounterVec.WithLabelValues(msgID, "success").Inc()
+	}
+}
+
+// write writes the message to the collector, the connection is reopened and the message is retried with the
+// exponential backoff if the write fails
+func (e *SIEMExporter) write(ctx context.Context, message []byte) error {
+	return retry(ctx, func() (bool, error) {
+		return true, e.writeOnce(ctx, message)
+	})
+}
+
+func (e *SIEMExporter) writeOnce(ctx context.Context, message []byte) error {
+	if e.conn == nil {
+		conn, err := e.dial(ctx)
+		if err != nil {
+			return err
+		}
+		e.conn = conn
+	}
+	if err := e.conn.SetWriteDeadline(time.Now().Add(e.config.Timeout)); err != nil {
+		e.close()
+		return err
+	}
+	if _, err := e.conn.Write(message); err != nil {
+		e.close()
+		return fmt.Errorf("failed to write to the SIEM collector: %w", err)
+	}
+	return nil
+}
+
+// dial connects to the collector, the TLS secret is reloaded on each connection, so the rotated certificates are used
+// once the connection is reopened
+func (e *SIEMExporter) dial(ctx context.Context) (net.Conn, error) {
+	tlsConfig, err := e.loadTLSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: e.config.Timeout}, Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", e.config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the SIEM collector %s: %w", e.config.Address, err)
+	}
+	return conn, nil
+}
+
+func (e *SIEMExporter) close() {
+	if e.conn != nil {
+		_ = e.conn.Close()
+		e.conn = nil
+	}
+}
+
+func (e *SIEMExporter) loadTLSConfig(ctx context.Context) (*tls.Config, error) {
+	host, _, _ := net.SplitHostPort(e.config.Address)
+	tlsConfig := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	if e.config.TLSSecretName == "" {
+		return tlsConfig, nil
+	}
+	secret := &corev1.Secret{}
+	err := e.client.Get(ctx, types.NamespacedName{Namespace: e.namespace, Name: e.config.TLSSecretName}, secret)
+	if errors.IsNotFound(err) {
+		return tlsConfig, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the SIEM TLS secret %s: %w", e.config.TLSSecretName, err)
+	}
+	if ca, ok := secret.Data[SIEMCAKey]; ok {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid %s in the SIEM TLS secret %s", SIEMCAKey, e.config.TLSSecretName)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if _, ok := secret.Data[SIEMCertKey]; ok {
+		cert, err := tls.X509KeyPair(secret.Data[SIEMCertKey], secret.Data[SIEMKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate in the SIEM TLS secret %s: %w",
+				e.config.TLSSecretName, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// frame returns the message with the framing of the stream
+func (e *SIEMExporter) frame(message string) []byte {
+	if e.config.Framing == SIEMFramingNewline {
+		return []byte(message + "\n")
+	}
+	return []byte(strconv.Itoa(len(message)) + " " + message)
+}
+
+// format returns the syslog message of the event, e.g.
+// <132>1 2024-01-02T03:04:05.000Z hub1 multicluster-global-hub - policy-noncompliant - CEF:0|Red Hat|...
+func (e *SIEMExporter) format(evt *siemEvent) string {
+	severity, hostname, timestamp := siemInformational, "", time.Time{}
+	if evt.compliance != nil {
+		severity, hostname, timestamp = siemWarning, evt.compliance.LeafHubName, evt.compliance.Time
+	} else {
+		if evt.cluster.EventType == corev1.EventTypeWarning {
+			severity = siemWarning
+		}
+		hostname, timestamp = evt.cluster.LeafHubName, evt.cluster.CreatedAt
+	}
+
+	structuredData, message := "-", ""
+	if e.config.Format == SIEMFormatCEF {
+		message = evt.cef()
+	} else {
+		structuredData, message = evt.structuredData(), evt.message()
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s - %s %s %s", siemFacility*8+severity,
+		timestamp.UTC().Format("2006-01-02T15:04:05.000Z07:00"), syslogHeader(hostname), siemAppName,
+		evt.msgID(), structuredData, message)
+}
+
+func (evt *siemEvent) msgID() string {
+	if evt.compliance != nil {
+		return policyNonCompliantMsgID
+	}
+	return clusterEventMsgID
+}
+
+func (evt *siemEvent) message() string {
+	if evt.compliance != nil {
+		return fmt.Sprintf("policy %s is %s on the cluster %s", policyName(evt.compliance),
+			evt.compliance.Compliance, evt.compliance.ClusterName)
+	}
+	return evt.cluster.Message
+}
+
+// fields returns the fields of the event in order, the empty fields are omitted
+func (evt *siemEvent) fields() [][2]string {
+	fields := [][2]string{}
+	if evt.compliance != nil {
+		c := evt.compliance
+		fields = append(fields, [2]string{"leafHubName", c.LeafHubName}, [2]string{"clusterName", c.ClusterName},
+			[2]string{"policyId", c.PolicyID}, [2]string{"policyName", c.PolicyName},
+			[2]string{"policyNamespace", c.PolicyNamespace}, [2]string{"compliance", c.Compliance},
+			[2]string{"previousCompliance", c.PreviousCompliance})
+	} else {
+		c := evt.cluster
+		fields = append(fields, [2]string{"leafHubName", c.LeafHubName}, [2]string{"clusterName", c.ClusterName},
+			[2]string{"clusterId", c.ClusterID}, [2]string{"eventName", c.EventName},
+			[2]string{"eventType", c.EventType}, [2]string{"reason", c.Reason},
+			[2]string{"reportingController", c.ReportingController})
+	}
+	nonEmpty := [][2]string{}
+	for _, field := range fields {
+		if field[1] != "" {
+			nonEmpty = append(nonEmpty, field)
+		}
+	}
+	return nonEmpty
+}
+
+// structuredData returns the SD-ELEMENT of the fields, the '"', '\' and ']' in the values are escaped
+func (evt *siemEvent) structuredData() string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	builder := strings.Builder{}
+	builder.WriteString("[" + siemSDID)
+	for _, field := range evt.fields() {
+		builder.WriteString(fmt.Sprintf(` %s="%s"`, field[0], escaper.Replace(field[1])))
+	}
+	builder.WriteString("]")
+	return builder.String()
+}
+
+// cef returns the event in the Common Event Format, the fields which don't have the CEF keys are in the custom strings
+func (evt *siemEvent) cef() string {
+	headerEscaper := strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	extensionEscaper := strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	extensions := []string{}
+	// the empty fields are omitted
+	extension := func(key, value string) {
+		if value != "" {
+			extensions = append(extensions, key+"="+extensionEscaper.Replace(value))
+		}
+	}
+	customString := func(index int, label, value string) {
+		if value != "" {
+			extension(fmt.Sprintf("cs%dLabel", index), label)
+			extension(fmt.Sprintf("cs%d", index), value)
+		}
+	}
+
+	var signatureID, name string
+	var severity int
+	if evt.compliance != nil {
+		c := evt.compliance
+		signatureID, name, severity = NonCompliantEventType, "Policy is non-compliant", 7
+		extension("rt", strconv.FormatInt(c.Time.UnixMilli(), 10))
+		extension("dvchost", c.LeafHubName)
+		extension("dhost", c.ClusterName)
+		extension("msg", evt.message())
+		customString(1, "policyId", c.PolicyID)
+		customString(2, "policy", policyName(c))
+		customString(3, "compliance", c.Compliance)
+		customString(4, "previousCompliance", c.PreviousCompliance)
+	} else {
+		c := evt.cluster
+		signatureID, name, severity = ClusterEventType, "Managed cluster event", 3
+		if c.Reason != "" {
+			name = c.Reason
+		}
+		if c.EventType == corev1.EventTypeWarning {
+			severity = 5
+		}
+		extension("rt", strconv.FormatInt(c.CreatedAt.UnixMilli(), 10))
+		extension("dvchost", c.LeafHubName)
+		extension("dhost", c.ClusterName)
+		extension("msg", c.Message)
+		extension("reason", c.Reason)
+		customString(1, "clusterId", c.ClusterID)
+		customString(2, "eventName", c.EventName)
+		customString(3, "reportingController", c.ReportingController)
+	}
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s", cefVendor, cefProduct, cefVersion,
+		headerEscaper.Replace(signatureID), headerEscaper.Replace(name), severity, strings.Join(extensions, " "))
+}
+
+// policyName returns the "namespace/name" of the policy, or its ID if the name isn't resolved
+func policyName(evt *ComplianceEvent) string {
+	if evt.PolicyName == "" {
+		return evt.PolicyID
+	}
+	if evt.PolicyNamespace == "" {
+		return evt.PolicyName
+	}
+	return evt.PolicyNamespace + "/" + evt.PolicyName
+}
+
+// syslogHeader returns the value of the header field, which is the printable ASCII without the spaces, or "-" if it's
+// empty
+func syslogHeader(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, value)
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+func TestSIEMFormat(t *testing.T) {
+	eventTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	violation := &siemEvent{compliance: &ComplianceEvent{
+		PolicyID: "p1", PolicyName: "policy-config", PolicyNamespace: "default", LeafHubName: "hub1",
+		ClusterName: "cluster1", Compliance: "non_compliant", Time: eventTime,
+	}}
+	clusterEvent := &siemEvent{cluster: &models.ManagedClusterEvent{
+		EventName: "cluster1.17a", ClusterName: "cluster1", LeafHubName: "hub 1", EventType: "Warning",
+		Reason: "AvailableUnknown", Message: `the lease "cluster1" isn't updated=true]`, CreatedAt: eventTime,
+	}}
+
+	exporter, err := NewSIEMExporter(nil, "default", &SIEMConfig{
+		Address: "siem.example.com:6514", Format: SIEMFormatCEF, Framing: SIEMFramingOctetCounting,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "<132>1 2024-01-02T03:04:05.000Z hub1 multicluster-global-hub - policy-noncompliant - "+
+		"CEF:0|Red Hat|Multicluster Global Hub|1|io.open-cluster-management.operator.multiclusterglobalhubs."+
+		"policy.noncompliant|Policy is non-compliant|7|rt=1704164645000 dvchost=hub1 dhost=cluster1 "+
+		"msg=policy default/policy-config is non_compliant on the cluster cluster1 cs1Label=policyId cs1=p1 "+
+		"cs2Label=policy cs2=default/policy-config cs3Label=compliance cs3=non_compliant",
+		exporter.format(violation))
+	assert.Equal(t, "<132>1 2024-01-02T03:04:05.000Z hub1 multicluster-global-hub - cluster-event - "+
+		"CEF:0|Red Hat|Multicluster Global Hub|1|io.open-cluster-management.operator.multiclusterglobalhubs."+
+		`managedcluster.event|AvailableUnknown|5|rt=1704164645000 dvchost=hub 1 dhost=cluster1 `+
+		`msg=the lease "cluster1" isn't updated\=true] reason=AvailableUnknown cs2Label=eventName cs2=cluster1.17a`,
+		exporter.format(clusterEvent))
+	assert.Equal(t, "5 hello", string(exporter.frame("hello")))
+
+	exporter, err = NewSIEMExporter(nil, "default", &SIEMConfig{
+		Address: "siem.example.com:6514", Format: SIEMFormatRFC5424, Framing: SIEMFramingNewline,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "<132>1 2024-01-02T03:04:05.000Z hub1 multicluster-global-hub - cluster-event "+
+		`[globalhub@2312 leafHubName="hub 1" clusterName="cluster1" eventName="cluster1.17a" eventType="Warning" `+
+		`reason="AvailableUnknown"] the lease "cluster1" isn't updated=true]`, exporter.format(clusterEvent))
+	assert.Equal(t, "hello\n", string(exporter.frame("hello")))
+
+	_, err = NewSIEMExporter(nil, "default", &SIEMConfig{Address: "siem.example.com", Format: SIEMFormatCEF})
+	assert.ErrorContains(t, err, "invalid address")
+	_, err = NewSIEMExporter(nil, "default", &SIEMConfig{Address: "siem.example.com:6514", Format: "leef"})
+	assert.ErrorContains(t, err, "invalid format")
+}
+
+func TestSIEMExporter(t *testing.T) {
+	// reuse the certificate of the test server, which is valid for the 127.0.0.1
+	server := httptest.NewTLSServer(nil)
+	cert := server.TLS.Certificates[0]
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	server.Close()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	var lock sync.Mutex
+	received := []string{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lock.Lock()
+					received = append(received, scanner.Text())
+					lock.Unlock()
+				}
+			}()
+		}
+	}()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "siem", Namespace: "default"},
+		Data:       map[string][]byte{SIEMCAKey: ca},
+	}
+	exporter, err := NewSIEMExporter(fake.NewClientBuilder().WithObjects(secret).Build(), "default", &SIEMConfig{
+		Address:       listener.Addr().String(),
+		Format:        SIEMFormatRFC5424,
+		Framing:       SIEMFramingNewline,
+		TLSSecretName: "siem",
+		Timeout:       time.Second,
+	})
+	assert.NoError(t, err)
+	exporter.getPolicies = func(ctx context.Context, policyIDs []string) ([]policyMetadata, error) {
+		return []policyMetadata{{PolicyID: "p1", PolicyName: "policy-config", PolicyNamespace: "default"}}, nil
+	}
+
+	startNotifier(t, exporter)
+
+	now := time.Now()
+	exporter.ExportClusterEvents([]models.ManagedClusterEvent{{
+		ClusterName: "cluster1", LeafHubName: "hub1", EventType: "Normal", Message: "cluster1 is joined",
+		CreatedAt: now,
+	}})
+	exporter.ExportRootPolicyEvents("hub1", []models.LocalRootPolicyEvent{{}})
+	exporter.ExportComplianceEvents([]*ComplianceEvent{{
+		PolicyID: "p1", LeafHubName: "hub1", ClusterName: "cluster1", Compliance: "non_compliant", Time: now,
+	}})
+
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received) == 2
+	}, 10*time.Second, 100*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Contains(t, received[0], "<134>1 ")
+	assert.Contains(t, received[0], "cluster-event")
+	assert.Contains(t, received[0], "cluster1 is joined")
+	assert.Contains(t, received[1], "<132>1 ")
+	assert.Contains(t, received[1], `policyName="policy-config"`)
+	assert.Contains(t, received[1], "policy default/policy-config is non_compliant on the cluster cluster1")
+}
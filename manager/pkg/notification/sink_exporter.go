@@ -0,0 +1,226 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+const (
+	// ClusterEventType is the type of the CloudEvents of the events of the managed clusters
+	ClusterEventType = enum.EventTypePrefix + "managedcluster.event"
+	// RootPolicyEventType is the type of the CloudEvents of the events of the root policies
+	RootPolicyEventType = enum.EventTypePrefix + "policy.event"
+	// ReplicatedPolicyEventType is the type of the CloudEvents of the events of the policies on the managed clusters
+	ReplicatedPolicyEventType = enum.EventTypePrefix + "policy.clusterevent"
+)
+
+// SinkEventTypes are the types of the events which can be exported to the sink
+var SinkEventTypes = []string{
+	ClusterEventType,
+	RootPolicyEventType,
+	ReplicatedPolicyEventType,
+	NonCompliantEventType,
+}
+
+var (
+	SinkDeliveriesCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "multicluster_global_hub_sink_deliveries_total",
+			Help: "The number of the events delivered to the CloudEvents sink, by the type of the event and the " +
+				"result of the delivery.",
+		},
+		[]string{"type", "result"},
+	)
+	DroppedSinkEventsCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "multicluster_global_hub_sink_dropped_events_total",
+			Help: "The number of the events dropped since the CloudEvents sink can't keep up.",
+		},
+	)
+)
+
+type SinkConfig struct {
+	// URL is the address of the CloudEvents sink, e.g. a Knative broker or service, the export is disabled if it's
+	// empty
+	URL string
+	// EventTypes are the types of the exported events, the prefix of the types can be omitted. All the types are
+	// exported if it's empty
+	EventTypes []string
+	// Timeout is the timeout of each request to the sink
+	Timeout time.Duration
+}
+
+// SinkExporter forwards the selected events of the managed hubs to a CloudEvents sink, so the downstream automation,
+// e.g. the functions and the pipelines, can react to the changes of the clusters and the policies. The events are
+// queued by the status handlers once they're written to the database, and posted in order asynchronously
+type SinkExporter struct {
+	log        logr.Logger
+	url        string
+	eventTypes map[string]bool
+	httpClient *http.Client
+	events     chan *sinkEvent
+}
+
+type sinkEvent struct {
+	eventType string
+	source    string
+	subject   string
+	time      time.Time
+	data      interface{}
+}
+
+// AddSinkExporter adds the exporter to the manager, it returns nil if the export is disabled
+func AddSinkExporter(mgr ctrl.Manager, config *SinkConfig) (*SinkExporter, error) {
+	if config == nil || config.URL == "" {
+		return nil, nil
+	}
+	exporter, err := NewSinkExporter(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Add(exporter); err != nil {
+		return nil, fmt.Errorf("failed to add the sink exporter to the manager: %w", err)
+	}
+	return exporter, nil
+}
+
+func NewSinkExporter(config *SinkConfig) (*SinkExporter, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url of the CloudEvents sink: %q", config.URL)
+	}
+	eventTypes, err := parseSinkEventTypes(config.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	return &SinkExporter{
+		log:        ctrl.Log.WithName("sink-exporter"),
+		url:        config.URL,
+		eventTypes: eventTypes,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		events:     make(chan *sinkEvent, bufferSize),
+	}, nil
+}
+
+// parseSinkEventTypes returns the selected types with the prefix, all the types are selected if it's empty
+func parseSinkEventTypes(selected []string) (map[string]bool, error) {
+	eventTypes := map[string]bool{}
+	for _, eventType := range selected {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "" {
+			continue
+		}
+		if !strings.HasPrefix(eventType, enum.EventTypePrefix) {
+			eventType = enum.EventTypePrefix + eventType
+		}
+		if !contains(SinkEventTypes, eventType) {
+			return nil, fmt.Errorf("the event type %s can't be exported to the CloudEvents sink", eventType)
+		}
+		eventTypes[eventType] = true
+	}
+	if len(eventTypes) == 0 {
+		for _, eventType := range SinkEventTypes {
+			eventTypes[eventType] = true
+		}
+	}
+	return eventTypes, nil
+}
+
+// ExportClusterEvents queues the events of the managed clusters. It does nothing if the exporter is nil
+func (e *SinkExporter) ExportClusterEvents(events []models.ManagedClusterEvent) {
+	if !e.selected(ClusterEventType) {
+		return
+	}
+	for i := range events {
+		e.enqueue(ClusterEventType, events[i].LeafHubName, events[i].ClusterName, events[i].CreatedAt, events[i])
+	}
+}
+
+// ExportRootPolicyEvents queues the events of the root policies. It does nothing if the exporter is nil
+func (e *SinkExporter) ExportRootPolicyEvents(leafHubName string, events []models.LocalRootPolicyEvent) {
+	if !e.selected(RootPolicyEventType) {
+		return
+	}
+	for i := range events {
+		e.enqueue(RootPolicyEventType, leafHubName, events[i].PolicyID, events[i].CreatedAt, events[i])
+	}
+}
+
+// ExportReplicatedPolicyEvents queues the events of the policies on the managed clusters. It does nothing if the
+// exporter is nil
+func (e *SinkExporter) ExportReplicatedPolicyEvents(leafHubName string, events []models.LocalReplicatedPolicyEvent) {
+	if !e.selected(ReplicatedPolicyEventType) {
+		return
+	}
+	for i := range events {
+		e.enqueue(ReplicatedPolicyEventType, leafHubName, events[i].ClusterName, events[i].CreatedAt, events[i])
+	}
+}
+
+// ExportComplianceEvents queues the events of the policies which become non-compliant on the clusters. It does
+// nothing if the exporter is nil
+func (e *SinkExporter) ExportComplianceEvents(events []*ComplianceEvent) {
+	if !e.selected(NonCompliantEventType) {
+		return
+	}
+	for _, evt := range events {
+		// the events are shared with the webhook notifier, which sets the names of the policies
+		copied := *evt
+		e.enqueue(NonCompliantEventType, evt.LeafHubName, evt.ClusterName, evt.Time, &copied)
+	}
+}
+
+func (e *SinkExporter) selected(eventType string) bool {
+	return e != nil && e.eventTypes[eventType]
+}
+
+// enqueue queues the event, it's dropped if the buffer is full, so the status handlers aren't blocked by a slow sink
+func (e *SinkExporter) enqueue(eventType, source, subject string, eventTime time.Time, data interface{}) {
+	select {
+	case e.events <- &sinkEvent{
+		eventType: eventType, source: source, subject: subject, time: eventTime, data: data,
+	}:
+	default:
+		DroppedSinkEventsCounter.Inc()
+	}
+}
+
+func (e *SinkExporter) Start(ctx context.Context) error {
+	e.log.Info("starting CloudEvents sink exporter", "url", e.url)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case item := <-e.events:
+			if err := e.export(ctx, item); err != nil {
+				SinkDeliveriesCounterVec.WithLabelValues(item.eventType, "failure").Inc()
+				e.log.Error(err, "failed to export the event to the sink", "type", item.eventType,
+					"source", item.source, "subject", item.subject)
+				continue
+			}
+			SinkDeliveriesCounterVec.WithLabelValues(item.eventType, "success").Inc()
+		}
+	}
+}
+
+func (e *SinkExporter) export(ctx context.Context, item *sinkEvent) error {
+	cloudEvent, err := newCloudEvent(item.eventType, item.source, item.subject, item.time, item.data)
+	if err != nil {
+		return err
+	}
+	return postCloudEvent(ctx, e.httpClient, e.url, "", cloudEvent)
+}
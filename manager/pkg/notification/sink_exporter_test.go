@@ -0,0 +1,106 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+func TestParseSinkEventTypes(t *testing.T) {
+	eventTypes, err := parseSinkEventTypes(nil)
+	assert.NoError(t, err)
+	assert.Len(t, eventTypes, len(SinkEventTypes))
+
+	eventTypes, err = parseSinkEventTypes([]string{"managedcluster.event", " " + NonCompliantEventType})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{ClusterEventType: true, NonCompliantEventType: true}, eventTypes)
+
+	_, err = parseSinkEventTypes([]string{"managedhub.heartbeat"})
+	assert.ErrorContains(t, err, "can't be exported")
+
+	_, err = NewSinkExporter(&SinkConfig{URL: "broker-ingress.knative-eventing"})
+	assert.ErrorContains(t, err, "invalid url")
+}
+
+func TestSinkExporter(t *testing.T) {
+	var lock sync.Mutex
+	received := []cloudevents.Event{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, cloudevents.ApplicationCloudEventsJSON, r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		evt := cloudevents.NewEvent()
+		assert.NoError(t, json.Unmarshal(body, &evt))
+		received = append(received, evt)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	exporter, err := NewSinkExporter(&SinkConfig{
+		URL:        server.URL,
+		EventTypes: []string{"managedcluster.event", "policy.noncompliant"},
+		Timeout:    time.Second,
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		assert.NoError(t, exporter.Start(ctx))
+	}()
+
+	now := time.Now()
+	exporter.ExportClusterEvents([]models.ManagedClusterEvent{{
+		EventName: "cluster1.17cd5c3642c43a8a", ClusterName: "cluster1", LeafHubName: "hub1",
+		Reason: "AvailableUnknown", CreatedAt: now,
+	}})
+	// the root policy events aren't selected
+	exporter.ExportRootPolicyEvents("hub1", []models.LocalRootPolicyEvent{{}})
+	complianceEvent := &ComplianceEvent{
+		PolicyID: "p1", LeafHubName: "hub1", ClusterName: "cluster2", Compliance: "non_compliant", Time: now,
+	}
+	exporter.ExportComplianceEvents([]*ComplianceEvent{complianceEvent})
+	// the exported event isn't changed by the webhook notifier
+	complianceEvent.PolicyName = "policy-config"
+
+	// a nil exporter is disabled
+	var disabled *SinkExporter
+	disabled.ExportClusterEvents([]models.ManagedClusterEvent{{}})
+
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received) == 2
+	}, 10*time.Second, 100*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, ClusterEventType, received[0].Type())
+	assert.Equal(t, "hub1", received[0].Source())
+	assert.Equal(t, "cluster1", received[0].Subject())
+	clusterEvent := &models.ManagedClusterEvent{}
+	assert.NoError(t, received[0].DataAs(clusterEvent))
+	assert.Equal(t, "AvailableUnknown", clusterEvent.Reason)
+
+	assert.Equal(t, NonCompliantEventType, received[1].Type())
+	assert.Equal(t, "cluster2", received[1].Subject())
+	data := &ComplianceEvent{}
+	assert.NoError(t, received[1].DataAs(data))
+	assert.Equal(t, "p1", data.PolicyID)
+	assert.Empty(t, data.PolicyName)
+}
@@ -0,0 +1,413 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/encryption"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+const (
+	// NonCompliantEventType is the type of the CloudEvents posted when a policy becomes non-compliant on a cluster
+	NonCompliantEventType = enum.EventTypePrefix + "policy.noncompliant"
+	// WebhooksKey is the key of the webhooks in the secret
+	WebhooksKey = "webhooks.yaml"
+	// SignatureHeader is the HMAC-SHA256 of the request body with the secret of the webhook, in the hex format
+	// prefixed with "sha256="
+	SignatureHeader = "X-Global-Hub-Signature-256"
+
+	// the events are dropped once the buffer is full, so the status handlers aren't blocked by a slow endpoint
+	bufferSize = 10000
+	batchSize  = 100
+	// the webhooks are reloaded from the secret in the interval
+	resyncInterval = time.Minute
+	// the failed deliveries are retried with the exponential backoff, the event is dropped after the attempts
+	maxAttempts  = 3
+	retryBackoff = time.Second
+)
+
+var (
+	WebhookDeliveriesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "multicluster_global_hub_webhook_deliveries_total",
+			Help: "The number of the events delivered to each webhook, by the result of the delivery.",
+		},
+		[]string{"webhook", "result"},
+	)
+)
+
+type WebhookConfig struct {
+	// SecretName is the secret of the webhooks in the namespace of the manager, the webhooks are disabled if it's
+	// empty. The webhooks are added, updated and removed once the secret is changed, without restarting the manager
+	SecretName string
+	// Timeout is the timeout of each request to the webhook
+	Timeout time.Duration
+}
+
+// Webhook is the endpoint which receives the events matching its filters
+type Webhook struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Secret signs the request body in the SignatureHeader, the requests aren't signed if it's empty
+	Secret  string         `json:"secret,omitempty"`
+	Filters WebhookFilters `json:"filters,omitempty"`
+}
+
+// WebhookFilters selects the events of the webhook, an empty filter matches all the events
+type WebhookFilters struct {
+	// Policies are the names, or the "namespace/name", of the policies
+	Policies []string `json:"policies,omitempty"`
+	LeafHubs []string `json:"leafHubs,omitempty"`
+	Clusters []string `json:"clusters,omitempty"`
+}
+
+// ComplianceEvent is the data of the event when the compliance of a policy on a cluster is changed
+type ComplianceEvent struct {
+	PolicyID        string `json:"policyId"`
+	PolicyName      string `json:"policyName,omitempty"`
+	PolicyNamespace string `json:"policyNamespace,omitempty"`
+	LeafHubName     string `json:"leafHubName"`
+	ClusterName     string `json:"clusterName"`
+	Compliance      string `json:"compliance"`
+	// PreviousCompliance is empty if the compliance of the cluster isn't reported before
+	PreviousCompliance string `json:"previousCompliance,omitempty"`
+	// Severity is the highest severity of the templates of the policy, e.g. "critical", it's empty if the templates
+	// don't set the severity
+	Severity string    `json:"severity,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+type policyMetadata struct {
+	PolicyID        string
+	PolicyName      string
+	PolicyNamespace string
+	Severity        string
+}
+
+// WebhookNotifier posts a CloudEvent to the registered webhooks whenever a policy becomes non-compliant on a cluster,
+// so the incident tooling can react to the violations without polling the manager API. The events are queued by the
+// status handlers and delivered asynchronously, the deliveries to each webhook are in the order of the events
+type WebhookNotifier struct {
+	complianceQueue
+	log        logr.Logger
+	client     client.Reader
+	namespace  string
+	config     *WebhookConfig
+	httpClient *http.Client
+	// the webhooks loaded from the secret, they're only accessed by the delivery loop
+	webhooks []Webhook
+}
+
+// AddWebhookNotifier adds the notifier to the manager, it returns nil if the webhooks are disabled
+func AddWebhookNotifier(mgr ctrl.Manager, namespace string, config *WebhookConfig) (*WebhookNotifier, error) {
+	if config == nil || config.SecretName == "" {
+		return nil, nil
+	}
+	notifier := NewWebhookNotifier(mgr.GetAPIReader(), namespace, config)
+	if err := mgr.Add(notifier); err != nil {
+		return nil, fmt.Errorf("failed to add the webhook notifier to the manager: %w", err)
+	}
+	return notifier, nil
+}
+
+func NewWebhookNotifier(c client.Reader, namespace string, config *WebhookConfig) *WebhookNotifier {
+	return &WebhookNotifier{
+		complianceQueue: newComplianceQueue("webhook"),
+		log:             ctrl.Log.WithName("webhook-notifier"),
+		client:          c,
+		namespace:       namespace,
+		config:          config,
+		httpClient:      &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Notify queues the events, they're dropped if the buffer is full. It does nothing if the notifier is nil
+func (n *WebhookNotifier) Notify(events ...*ComplianceEvent) {
+	if n != nil {
+		n.enqueueCopies(events)
+	}
+}
+
+func (n *WebhookNotifier) Start(ctx context.Context) error {
+	n.log.Info("starting webhook notifier", "secret", n.config.SecretName)
+	return n.run(ctx, n.loadWebhooks, n.deliver)
+}
+
+// loadWebhooks reloads the webhooks from the secret, the previous webhooks are kept if the secret is invalid
+func (n *WebhookNotifier) loadWebhooks(ctx context.Context) {
+	secret := &corev1.Secret{}
+	err := n.client.Get(ctx, types.NamespacedName{Namespace: n.namespace, Name: n.config.SecretName}, secret)
+	if errors.IsNotFound(err) {
+		n.webhooks = nil
+		return
+	}
+	if err != nil {
+		n.log.Error(err, "failed to get the webhooks secret", "name", n.config.SecretName)
+		return
+	}
+	webhooks, err := parseWebhooks(secret.Data[WebhooksKey])
+	if err != nil {
+		n.log.Error(err, "invalid webhooks in the secret", "name", n.config.SecretName)
+		return
+	}
+	n.webhooks = webhooks
+}
+
+func parseWebhooks(data []byte) ([]Webhook, error) {
+	webhooks := []Webhook{}
+	if err := yaml.Unmarshal(data, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the %s: %w", WebhooksKey, err)
+	}
+	names := map[string]bool{}
+	for _, webhook := range webhooks {
+		if webhook.Name == "" || names[webhook.Name] {
+			return nil, fmt.Errorf("the name of the webhook %q is empty or duplicated", webhook.Name)
+		}
+		names[webhook.Name] = true
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid url of the webhook %s: %q", webhook.Name, webhook.URL)
+		}
+	}
+	return webhooks, nil
+}
+
+// deliver posts the events to the webhooks concurrently, the events of each webhook are posted in order
+func (n *WebhookNotifier) deliver(ctx context.Context, events []*ComplianceEvent) {
+	if len(n.webhooks) == 0 {
+		return
+	}
+	if err := n.resolvePolicies(ctx, events); err != nil {
+		n.log.Error(err, "failed to get the names of the policies, the events are delivered without them")
+	}
+
+	var wg sync.WaitGroup
+	for i := range n.webhooks {
+		webhook := n.webhooks[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, evt := range events {
+				if !webhook.Filters.matches(evt) {
+					continue
+				}
+				if err := n.post(ctx, &webhook, evt); err != nil {
+					WebhookDeliveriesCounter.WithLabelValues(webhook.Name, "failure").Inc()
+					n.log.Error(err, "failed to deliver the event to the webhook", "webhook", webhook.Name,
+						"policy", evt.PolicyID, "cluster", evt.ClusterName)
+					continue
+				}
+				WebhookDeliveriesCounter.WithLabelValues(webhook.Name, "success").Inc()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (n *WebhookNotifier) post(ctx context.Context, webhook *Webhook, evt *ComplianceEvent) error {
+	cloudEvent, err := newCloudEvent(NonCompliantEventType, evt.LeafHubName, evt.ClusterName, evt.Time, evt)
+	if err != nil {
+		return err
+	}
+	return postCloudEvent(ctx, n.httpClient, webhook.URL, webhook.Secret, cloudEvent)
+}
+
+func newCloudEvent(eventType, source, subject string, eventTime time.Time, data interface{}) (
+	*cloudevents.Event, error,
+) {
+	cloudEvent := cloudevents.NewEvent()
+	cloudEvent.SetID(uuid.New().String())
+	cloudEvent.SetType(eventType)
+	cloudEvent.SetSource(source)
+	cloudEvent.SetSubject(subject)
+	cloudEvent.SetTime(eventTime)
+	if err := cloudEvent.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return nil, err
+	}
+	return &cloudEvent, nil
+}
+
+// postCloudEvent posts the event to the endpoint, the failed requests are retried with the exponential backoff. The body
+// is signed if the secret isn't empty
+func postCloudEvent(ctx context.Context, httpClient *http.Client, endpoint, secret string,
+	cloudEvent *cloudevents.Event,
+) error {
+	body, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return err
+	}
+
+	return retry(ctx, func() (bool, error) {
+		return send(ctx, httpClient, endpoint, secret, body)
+	})
+}
+
+// retry calls the fn until it succeeds, the error isn't retryable or the attempts are used up, the attempts are
+// delayed with the exponential backoff
+func retry(ctx context.Context, fn func() (bool, error)) error {
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		retryable, err := fn()
+		if err == nil || !retryable || attempt == maxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// send posts the event in the structured mode of the CloudEvents, it returns whether the failed request can be retried
+func send(ctx context.Context, httpClient *http.Client, endpoint, secret string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", cloudevents.ApplicationCloudEventsJSON)
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("the endpoint responded with %s", resp.Status)
+}
+
+// Sign returns the signature of the body with the secret, the receivers verify the requests by comparing it with
+// the SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (f *WebhookFilters) matches(evt *ComplianceEvent) bool {
+	if len(f.LeafHubs) > 0 && !contains(f.LeafHubs, evt.LeafHubName) {
+		return false
+	}
+	if len(f.Clusters) > 0 && !contains(f.Clusters, evt.ClusterName) {
+		return false
+	}
+	if len(f.Policies) > 0 && !contains(f.Policies, evt.PolicyName) &&
+		!contains(f.Policies, evt.PolicyNamespace+"/"+evt.PolicyName) {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// policySeverities are the severities of the policy templates from the highest to the lowest
+var policySeverities = []string{"critical", "high", "medium", "low"}
+
+// policyPayload is the part of the policy payload which the names and the severities are resolved from
+type policyPayload struct {
+	Metadata struct {
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		PolicyTemplates []struct {
+			ObjectDefinition struct {
+				Spec struct {
+					Severity string `json:"severity"`
+				} `json:"spec"`
+			} `json:"objectDefinition"`
+		} `json:"policy-templates"`
+	} `json:"spec"`
+}
+
+// getPolicies loads the payloads of the policies and resolves them after they're decrypted, the templates can't be
+// read by the query once the payloads are encrypted
+func getPolicies(ctx context.Context, policyIDs []string) ([]policyMetadata, error) {
+	rows, err := database.GetGorm().WithContext(ctx).Raw(`SELECT policy_id, policy_name, payload
+		FROM local_spec.policies WHERE policy_id IN ?`, policyIDs).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	policies := []policyMetadata{}
+	for rows.Next() {
+		policy := policyMetadata{}
+		var payload []byte
+		if err := rows.Scan(&policy.PolicyID, &policy.PolicyName, &payload); err != nil {
+			return nil, err
+		}
+		if payload, err = encryption.DecryptPayload(payload); err != nil {
+			return nil, err
+		}
+		if policy.PolicyNamespace, policy.Severity, err = parsePolicyPayload(payload); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+// parsePolicyPayload returns the namespace of the policy and the highest severity of its templates, e.g. the
+// ConfigurationPolicy. The severity is empty if none of the templates has it
+func parsePolicyPayload(payload []byte) (string, string, error) {
+	policy := &policyPayload{}
+	if err := json.Unmarshal(payload, policy); err != nil {
+		return "", "", err
+	}
+	severity, rank := "", len(policySeverities)
+	for _, template := range policy.Spec.PolicyTemplates {
+		templateSeverity := strings.ToLower(template.ObjectDefinition.Spec.Severity)
+		if templateSeverity == "" {
+			continue
+		}
+		templateRank := len(policySeverities)
+		for i, s := range policySeverities {
+			if s == templateSeverity {
+				templateRank = i
+				break
+			}
+		}
+		if severity == "" || templateRank < rank {
+			severity, rank = templateSeverity, templateRank
+		}
+	}
+	return policy.Metadata.Namespace, severity, nil
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database/encryption"
+)
+
+func TestParseWebhooks(t *testing.T) {
+	webhooks, err := parseWebhooks([]byte(`
+- name: incidents
+  url: https://events.example.com/global-hub
+  secret: s3cr3t
+  filters:
+    policies: [default/policy-config]
+    leafHubs: [hub1]
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Webhook{{
+		Name:   "incidents",
+		URL:    "https://events.example.com/global-hub",
+		Secret: "s3cr3t",
+		Filters: WebhookFilters{
+			Policies: []string{"default/policy-config"},
+			LeafHubs: []string{"hub1"},
+		},
+	}}, webhooks)
+
+	_, err = parseWebhooks([]byte(`[{name: a, url: "http://a"}, {name: a, url: "http://b"}]`))
+	assert.ErrorContains(t, err, "duplicated")
+	_, err = parseWebhooks([]byte(`[{name: a, url: "ftp://a"}]`))
+	assert.ErrorContains(t, err, "invalid url")
+}
+
+func TestWebhookFilters(t *testing.T) {
+	evt := &ComplianceEvent{
+		PolicyName: "policy-config", PolicyNamespace: "default", LeafHubName: "hub1", ClusterName: "cluster1",
+	}
+	assert.True(t, (&WebhookFilters{}).matches(evt))
+	assert.True(t, (&WebhookFilters{Policies: []string{"policy-config"}}).matches(evt))
+	assert.True(t, (&WebhookFilters{Policies: []string{"default/policy-config"}, LeafHubs: []string{"hub1"}}).
+		matches(evt))
+	assert.False(t, (&WebhookFilters{Policies: []string{"other/policy-config"}}).matches(evt))
+	assert.False(t, (&WebhookFilters{Clusters: []string{"cluster2"}}).matches(evt))
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var lock sync.Mutex
+	requests := 0
+	received := []cloudevents.Event{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests++
+		// the first request fails, so it's retried
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, Sign("s3cr3t", body), r.Header.Get(SignatureHeader))
+		evt := cloudevents.NewEvent()
+		assert.NoError(t, json.Unmarshal(body, &evt))
+		received = append(received, evt)
+	}))
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhooks", Namespace: "default"},
+		Data: map[string][]byte{
+			WebhooksKey: []byte(`
+- name: incidents
+  url: ` + server.URL + `
+  secret: s3cr3t
+  filters:
+    policies: [default/policy-config]
+`),
+		},
+	}
+	notifier := NewWebhookNotifier(fake.NewClientBuilder().WithObjects(secret).Build(), "default",
+		&WebhookConfig{SecretName: "webhooks", Timeout: time.Second})
+	notifier.getPolicies = func(ctx context.Context, policyIDs []string) ([]policyMetadata, error) {
+		return []policyMetadata{
+			{PolicyID: "p1", PolicyName: "policy-config", PolicyNamespace: "default"},
+			{PolicyID: "p2", PolicyName: "policy-other", PolicyNamespace: "default"},
+		}, nil
+	}
+
+	startNotifier(t, notifier)
+
+	now := time.Now()
+	notifier.Notify(&ComplianceEvent{
+		PolicyID: "p1", LeafHubName: "hub1", ClusterName: "cluster1", Compliance: "non_compliant",
+		PreviousCompliance: "compliant", Time: now,
+	}, &ComplianceEvent{
+		PolicyID: "p2", LeafHubName: "hub1", ClusterName: "cluster1", Compliance: "non_compliant", Time: now,
+	})
+
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received) == 1
+	}, 10*time.Second, 100*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	evt := received[0]
+	assert.Equal(t, NonCompliantEventType, evt.Type())
+	assert.Equal(t, "hub1", evt.Source())
+	assert.Equal(t, "cluster1", evt.Subject())
+	data := &ComplianceEvent{}
+	assert.NoError(t, evt.DataAs(data))
+	assert.Equal(t, "policy-config", data.PolicyName)
+	assert.Equal(t, "compliant", data.PreviousCompliance)
+	// the event of the p2 isn't matched by the filters
+	assert.Equal(t, 2, requests)
+}
+
+func TestParsePolicyPayload(t *testing.T) {
+	payload := []byte(`{"metadata": {"name": "policy1", "namespace": "default"}, "spec": {"policy-templates": [
+		{"objectDefinition": {"spec": {"severity": "low"}}},
+		{"objectDefinition": {"spec": {"severity": "High"}}},
+		{"objectDefinition": {"spec": {}}}]}}`)
+
+	// the severity is resolved from the decrypted payload, the templates are sealed in the stored one
+	keyring, err := encryption.NewKeyring(bytes.Repeat([]byte{1}, encryption.KeySize))
+	assert.NoError(t, err)
+	encryption.SetKeyring(keyring)
+	defer encryption.SetKeyring(nil)
+	encrypted, err := encryption.EncryptPayload(payload)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "severity")
+	decrypted, err := encryption.DecryptPayload(encrypted)
+	assert.NoError(t, err)
+
+	namespace, severity, err := parsePolicyPayload(decrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "default", namespace)
+	assert.Equal(t, "high", severity)
+
+	_, severity, err = parsePolicyPayload([]byte(`{"metadata": {"name": "policy2"}, "spec": {}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "", severity)
+}
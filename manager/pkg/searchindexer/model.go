@@ -0,0 +1,81 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package searchindexer
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// The following types are compatible with the sync API of the search-v2 indexer:
+// https://github.com/stolostron/search-indexer/blob/main/pkg/model/sync.go
+
+// SyncEvent is the request body sent to the indexer, the ClearAll resets all the resources of the cluster
+type SyncEvent struct {
+	ClearAll        bool                  `json:"clearAll,omitempty"`
+	AddResources    []Resource            `json:"addResources,omitempty"`
+	UpdateResources []Resource            `json:"updateResources,omitempty"`
+	DeleteResources []DeleteResourceEvent `json:"deleteResources,omitempty"`
+	AddEdges        []Edge                `json:"addEdges,omitempty"`
+	DeleteEdges     []Edge                `json:"deleteEdges,omitempty"`
+	RequestId       int                   `json:"requestId,omitempty"`
+}
+
+type Resource struct {
+	Kind           string                 `json:"kind"`
+	UID            string                 `json:"uid"`
+	ResourceString string                 `json:"resourceString"`
+	Properties     map[string]interface{} `json:"properties"`
+}
+
+type DeleteResourceEvent struct {
+	UID string `json:"uid,omitempty"`
+}
+
+type Edge struct {
+	SourceUID  string `json:"SourceUID"`
+	DestUID    string `json:"DestUID"`
+	EdgeType   string `json:"EdgeType"`
+	SourceKind string `json:"SourceKind"`
+	DestKind   string `json:"DestKind"`
+}
+
+// SyncResponse is the response of the indexer
+type SyncResponse struct {
+	Version          string `json:"version"`
+	TotalAdded       int    `json:"totalAdded"`
+	TotalUpdated     int    `json:"totalUpdated"`
+	TotalDeleted     int    `json:"totalDeleted"`
+	TotalResources   int    `json:"totalResources"`
+	TotalEdgesAdded  int    `json:"totalEdgesAdded"`
+	TotalEdges       int    `json:"totalEdges"`
+	RequestId        int    `json:"requestId"`
+	UpdatedTimestamp string `json:"updatedTimestamp"`
+}
+
+// argoApplication is the subset of the ArgoCD Application which is indexed, the ArgoCD API isn't vendored
+type argoApplication struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		Source      *argoApplicationSource  `json:"source"`
+		Sources     []argoApplicationSource `json:"sources"`
+		Destination struct {
+			Server    string `json:"server"`
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"destination"`
+	} `json:"spec"`
+	Status struct {
+		Health struct {
+			Status string `json:"status"`
+		} `json:"health"`
+		Sync struct {
+			Status string `json:"status"`
+		} `json:"sync"`
+	} `json:"status"`
+}
+
+type argoApplicationSource struct {
+	RepoURL        string `json:"repoURL"`
+	Path           string `json:"path"`
+	Chart          string `json:"chart"`
+	TargetRevision string `json:"targetRevision"`
+}
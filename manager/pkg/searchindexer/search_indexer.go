@@ -0,0 +1,435 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package searchindexer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"gorm.io/gorm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	policyv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/encryption"
+)
+
+const (
+	syncPath = "/aggregator/clusters/%s/sync"
+	// the inventory of a managed hub is indexed under a cluster prefixed with it, the ':' isn't allowed in the
+	// cluster names, so that it doesn't collide with the resources collected from the managed hub cluster itself
+	indexerClusterPrefix = "global-hub:"
+)
+
+type SearchIndexerConfig struct {
+	// URL is the address of the search-v2 indexer, the search indexer is disabled if it's empty
+	URL        string
+	Interval   time.Duration
+	CACertPath string
+	TokenPath  string
+}
+
+// SearchIndexer periodically sends the aggregated inventory of each managed hub to the search-v2 indexer,
+// so that the console search on the global hub returns the resources across all the managed hubs
+type SearchIndexer struct {
+	log        logr.Logger
+	config     *SearchIndexerConfig
+	httpClient *http.Client
+	// the hubs have been sent to the indexer, used to clear the resources of the removed hubs
+	syncedHubs map[string]struct{}
+}
+
+func AddSearchIndexer(mgr ctrl.Manager, config *SearchIndexerConfig) error {
+	if config.URL == "" {
+		return nil
+	}
+	httpClient, err := newHTTPClient(config.CACertPath)
+	if err != nil {
+		return fmt.Errorf("failed to create the search indexer client: %w", err)
+	}
+	return mgr.Add(&SearchIndexer{
+		log:        ctrl.Log.WithName("search-indexer"),
+		config:     config,
+		httpClient: httpClient,
+		syncedHubs: map[string]struct{}{},
+	})
+}
+
+func newHTTPClient(caCertPath string) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caCertPath != "" {
+		caCert, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs := x509.NewCertPool()
+		if ok := rootCAs.AppendCertsFromPEM(caCert); !ok {
+			return nil, fmt.Errorf("failed to append the CA certificate %s", caCertPath)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		Timeout:   time.Minute,
+	}, nil
+}
+
+func (s *SearchIndexer) Start(ctx context.Context) error {
+	s.log.Info("starting search indexer", "url", s.config.URL, "interval", s.config.Interval)
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := s.sync(ctx); err != nil {
+			s.log.Error(err, "failed to sync resources to the search indexer")
+		}
+		select {
+		case <-ctx.Done():
+			s.log.Info("search indexer is stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *SearchIndexer) sync(ctx context.Context) error {
+	hubResources, err := listHubResources(s.log)
+	if err != nil {
+		return err
+	}
+
+	// clear the resources of the hubs which are removed from the global hub
+	for hubName := range s.syncedHubs {
+		if _, ok := hubResources[hubName]; !ok {
+			hubResources[hubName] = []Resource{}
+		}
+	}
+
+	for hubName, resources := range hubResources {
+		if err := s.send(ctx, hubName, &SyncEvent{ClearAll: true, AddResources: resources}); err != nil {
+			s.log.Error(err, "failed to send resources to the search indexer", "hub", hubName)
+			continue
+		}
+		if len(resources) == 0 {
+			delete(s.syncedHubs, hubName)
+		} else {
+			s.syncedHubs[hubName] = struct{}{}
+		}
+		s.log.V(2).Info("synced resources to the search indexer", "hub", hubName, "count", len(resources))
+	}
+	return nil
+}
+
+func (s *SearchIndexer) send(ctx context.Context, hubName string, event *SyncEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(s.config.URL, "/") + fmt.Sprintf(syncPath, indexerCluster(hubName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.TokenPath != "" {
+		token, err := os.ReadFile(s.config.TokenPath)
+		if err != nil {
+			return fmt.Errorf("failed to read the token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// indexerCluster returns the cluster name of the indexer which the inventory of the managed hub is synced to
+func indexerCluster(hubName string) string {
+	return indexerClusterPrefix + hubName
+}
+
+// hubResourceLister appends the search resources of a kind to their managed hubs, the rows which can't be decoded are
+// logged and skipped, so that they don't block the sync of the other resources
+type hubResourceLister func(log logr.Logger, db *gorm.DB, hubResources map[string][]Resource) error
+
+// the kinds of the aggregated inventory of the managed hubs which are sent to the indexer
+var hubResourceListers = []hubResourceLister{
+	listClusters,
+	listPolicies,
+	listPlacements,
+	listClusterSets,
+	listApplications,
+}
+
+// listHubResources returns the search resources grouped by the managed hub name
+func listHubResources(log logr.Logger) (map[string][]Resource, error) {
+	db := database.GetGorm()
+	hubResources := map[string][]Resource{}
+	for _, list := range hubResourceListers {
+		if err := list(log, db, hubResources); err != nil {
+			return nil, err
+		}
+	}
+	return hubResources, nil
+}
+
+// listPayloads calls the fn with the hub name and the payload of each row of the query, the row is skipped if the fn
+// fails to decode the payload
+func listPayloads(log logr.Logger, db *gorm.DB, kind, query string,
+	fn func(hubName string, payload []byte) error,
+) error {
+	rows, err := db.Raw(query).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hubName string
+		var payload []byte
+		if err := rows.Scan(&hubName, &payload); err != nil {
+			return err
+		}
+		if err := fn(hubName, payload); err != nil {
+			log.Error(err, "skip the invalid resource", "kind", kind, "hub", hubName)
+		}
+	}
+	return rows.Err()
+}
+
+func listClusters(log logr.Logger, db *gorm.DB, hubResources map[string][]Resource) error {
+	err := listPayloads(log, db, "ManagedCluster", `SELECT leaf_hub_name, payload FROM status.managed_clusters WHERE deleted_at IS NULL`,
+		func(hubName string, payload []byte) error {
+			cluster := &clusterv1.ManagedCluster{}
+			if err := json.Unmarshal(payload, cluster); err != nil {
+				return err
+			}
+			hubResources[hubName] = append(hubResources[hubName], clusterResource(hubName, cluster))
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list managed clusters: %w", err)
+	}
+	return nil
+}
+
+func listPolicies(log logr.Logger, db *gorm.DB, hubResources map[string][]Resource) error {
+	policyRows, err := db.Raw(`SELECT p.leaf_hub_name, p.payload,
+			count(c.cluster_name) FILTER (WHERE c.compliance = 'non_compliant') AS non_compliant
+		FROM local_spec.policies p
+		LEFT JOIN local_status.compliance c ON c.policy_id = p.policy_id
+		WHERE p.deleted_at IS NULL
+		GROUP BY p.policy_id`).Rows()
+	if err != nil {
+		return fmt.Errorf("failed to list policies: %w", err)
+	}
+	defer policyRows.Close()
+	for policyRows.Next() {
+		var hubName string
+		var payload []byte
+		var nonCompliant int
+		if err := policyRows.Scan(&hubName, &payload, &nonCompliant); err != nil {
+			return err
+		}
+		if payload, err = encryption.DecryptPayload(payload); err != nil {
+			log.Error(err, "skip the policy which can't be decrypted", "hub", hubName)
+			continue
+		}
+		policy := &policyv1.Policy{}
+		if err := json.Unmarshal(payload, policy); err != nil {
+			log.Error(err, "skip the invalid resource", "kind", "Policy", "hub", hubName)
+			continue
+		}
+		hubResources[hubName] = append(hubResources[hubName], policyResource(hubName, policy, nonCompliant))
+	}
+	return policyRows.Err()
+}
+
+func listPlacements(log logr.Logger, db *gorm.DB, hubResources map[string][]Resource) error {
+	err := listPayloads(log, db, "Placement", `SELECT leaf_hub_name, payload FROM local_spec.placements`,
+		func(hubName string, payload []byte) error {
+			placement := &clusterv1beta1.Placement{}
+			if err := json.Unmarshal(payload, placement); err != nil {
+				return err
+			}
+			hubResources[hubName] = append(hubResources[hubName], placementResource(hubName, placement))
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list placements: %w", err)
+	}
+	return nil
+}
+
+func listClusterSets(log logr.Logger, db *gorm.DB, hubResources map[string][]Resource) error {
+	err := listPayloads(log, db, "ManagedClusterSet", `SELECT leaf_hub_name, payload FROM status.managed_cluster_sets`,
+		func(hubName string, payload []byte) error {
+			clusterSet := &clusterv1beta2.ManagedClusterSet{}
+			if err := json.Unmarshal(payload, clusterSet); err != nil {
+				return err
+			}
+			hubResources[hubName] = append(hubResources[hubName], clusterSetResource(hubName, clusterSet))
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list managed cluster sets: %w", err)
+	}
+	return nil
+}
+
+func listApplications(log logr.Logger, db *gorm.DB, hubResources map[string][]Resource) error {
+	err := listPayloads(log, db, "Application", `SELECT leaf_hub_name, payload FROM status.argocd_applications`,
+		func(hubName string, payload []byte) error {
+			application := &argoApplication{}
+			if err := json.Unmarshal(payload, application); err != nil {
+				return err
+			}
+			hubResources[hubName] = append(hubResources[hubName], applicationResource(hubName, application))
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list argocd applications: %w", err)
+	}
+	return nil
+}
+
+func clusterResource(hubName string, cluster *clusterv1.ManagedCluster) Resource {
+	properties := commonProperties(hubName, &cluster.ObjectMeta)
+	properties["kind"] = "ManagedCluster"
+	properties["kind_plural"] = "managedclusters"
+	properties["apigroup"] = clusterv1.GroupVersion.Group
+	properties["apiversion"] = clusterv1.GroupVersion.Version
+	for _, condition := range cluster.Status.Conditions {
+		if condition.Type == clusterv1.ManagedClusterConditionAvailable {
+			properties[clusterv1.ManagedClusterConditionAvailable] = string(condition.Status)
+		}
+	}
+	return Resource{
+		Kind:       "ManagedCluster",
+		UID:        fmt.Sprintf("%s/%s", indexerCluster(hubName), cluster.UID),
+		Properties: properties,
+	}
+}
+
+func policyResource(hubName string, policy *policyv1.Policy, nonCompliant int) Resource {
+	properties := commonProperties(hubName, &policy.ObjectMeta)
+	properties["kind"] = "Policy"
+	properties["kind_plural"] = "policies"
+	properties["apigroup"] = policyv1.GroupVersion.Group
+	properties["apiversion"] = policyv1.GroupVersion.Version
+	properties["disabled"] = policy.Spec.Disabled
+	properties["remediationAction"] = string(policy.Spec.RemediationAction)
+	properties["compliant"] = string(policy.Status.ComplianceState)
+	properties["numNonCompliantClusters"] = nonCompliant
+	return Resource{
+		Kind:       "Policy",
+		UID:        fmt.Sprintf("%s/%s", indexerCluster(hubName), policy.UID),
+		Properties: properties,
+	}
+}
+
+func placementResource(hubName string, placement *clusterv1beta1.Placement) Resource {
+	properties := commonProperties(hubName, &placement.ObjectMeta)
+	properties["kind"] = "Placement"
+	properties["kind_plural"] = "placements"
+	properties["apigroup"] = clusterv1beta1.GroupVersion.Group
+	properties["apiversion"] = clusterv1beta1.GroupVersion.Version
+	properties["numberOfSelectedClusters"] = int(placement.Status.NumberOfSelectedClusters)
+	return Resource{
+		Kind:       "Placement",
+		UID:        fmt.Sprintf("%s/%s", indexerCluster(hubName), placement.UID),
+		Properties: properties,
+	}
+}
+
+func clusterSetResource(hubName string, clusterSet *clusterv1beta2.ManagedClusterSet) Resource {
+	properties := commonProperties(hubName, &clusterSet.ObjectMeta)
+	properties["kind"] = "ManagedClusterSet"
+	properties["kind_plural"] = "managedclustersets"
+	properties["apigroup"] = clusterv1beta2.GroupVersion.Group
+	properties["apiversion"] = clusterv1beta2.GroupVersion.Version
+	properties["selectorType"] = string(clusterSet.Spec.ClusterSelector.SelectorType)
+	return Resource{
+		Kind:       "ManagedClusterSet",
+		UID:        fmt.Sprintf("%s/%s", indexerCluster(hubName), clusterSet.UID),
+		Properties: properties,
+	}
+}
+
+// applicationResource has the same properties as the ArgoCD applications collected by the search collector
+func applicationResource(hubName string, application *argoApplication) Resource {
+	properties := commonProperties(hubName, &application.ObjectMeta)
+	properties["kind"] = "Application"
+	properties["kind_plural"] = "applications"
+	properties["apigroup"] = "argoproj.io"
+	properties["apiversion"] = "v1alpha1"
+	source := application.Spec.Source
+	if source == nil && len(application.Spec.Sources) > 0 {
+		source = &application.Spec.Sources[0]
+	}
+	if source != nil {
+		setProperty(properties, "repoURL", source.RepoURL)
+		setProperty(properties, "path", source.Path)
+		setProperty(properties, "chart", source.Chart)
+		setProperty(properties, "targetRevision", source.TargetRevision)
+	}
+	setProperty(properties, "destinationName", application.Spec.Destination.Name)
+	setProperty(properties, "destinationServer", application.Spec.Destination.Server)
+	setProperty(properties, "destinationNamespace", application.Spec.Destination.Namespace)
+	setProperty(properties, "healthStatus", application.Status.Health.Status)
+	setProperty(properties, "syncStatus", application.Status.Sync.Status)
+	for _, owner := range application.OwnerReferences {
+		if owner.Kind == "ApplicationSet" {
+			properties["applicationSet"] = owner.Name
+		}
+	}
+	return Resource{
+		Kind:       "Application",
+		UID:        fmt.Sprintf("%s/%s", indexerCluster(hubName), application.UID),
+		Properties: properties,
+	}
+}
+
+func setProperty(properties map[string]interface{}, key, value string) {
+	if value != "" {
+		properties[key] = value
+	}
+}
+
+func commonProperties(hubName string, meta *metav1.ObjectMeta) map[string]interface{} {
+	properties := map[string]interface{}{
+		"name":    meta.Name,
+		"cluster": hubName,
+		"created": meta.CreationTimestamp.UTC().Format(time.RFC3339),
+		// mark the resources are indexed by the global hub rather than the search collector
+		"_globalHub": true,
+	}
+	if meta.Namespace != "" {
+		properties["namespace"] = meta.Namespace
+	}
+	if len(meta.Labels) > 0 {
+		properties["label"] = meta.Labels
+	}
+	return properties
+}
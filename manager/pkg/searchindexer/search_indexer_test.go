@@ -0,0 +1,100 @@
+package searchindexer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestClusterResource(t *testing.T) {
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cluster1",
+			UID:    "b8e9d5c5-4ffb-4c0f-9b6e-5d5e7a9f5c1a",
+			Labels: map[string]string{"env": "prod"},
+		},
+		Status: clusterv1.ManagedClusterStatus{
+			Conditions: []metav1.Condition{
+				{Type: clusterv1.ManagedClusterConditionAvailable, Status: metav1.ConditionTrue},
+			},
+		},
+	}
+
+	resource := clusterResource("hub1", cluster)
+	assert.Equal(t, "global-hub:hub1/b8e9d5c5-4ffb-4c0f-9b6e-5d5e7a9f5c1a", resource.UID)
+	assert.Equal(t, "ManagedCluster", resource.Kind)
+	assert.Equal(t, "cluster1", resource.Properties["name"])
+	assert.Equal(t, "hub1", resource.Properties["cluster"])
+	assert.Equal(t, "True", resource.Properties[clusterv1.ManagedClusterConditionAvailable])
+	assert.Equal(t, map[string]string{"env": "prod"}, resource.Properties["label"])
+}
+
+func TestApplicationResource(t *testing.T) {
+	application := &argoApplication{}
+	err := json.Unmarshal([]byte(`{
+		"metadata": {
+			"name": "guestbook",
+			"namespace": "openshift-gitops",
+			"uid": "2c8a4c0e-7d1f-4c1e-9a3e-0f5c3b8e6d21",
+			"ownerReferences": [{"apiVersion": "argoproj.io/v1alpha1", "kind": "ApplicationSet", "name": "guestbook",
+				"uid": "5d0c1a8e-3b4f-4e6a-8c2d-9f1e7a6b5c43"}]
+		},
+		"spec": {
+			"sources": [{"repoURL": "https://github.com/argoproj/argocd-example-apps", "path": "guestbook"}],
+			"destination": {"server": "https://kubernetes.default.svc", "namespace": "guestbook"}
+		},
+		"status": {"health": {"status": "Degraded"}, "sync": {"status": "OutOfSync"}}
+	}`), application)
+	assert.NoError(t, err)
+
+	resource := applicationResource("hub1", application)
+	assert.Equal(t, "global-hub:hub1/2c8a4c0e-7d1f-4c1e-9a3e-0f5c3b8e6d21", resource.UID)
+	assert.Equal(t, "Application", resource.Kind)
+	assert.Equal(t, "argoproj.io", resource.Properties["apigroup"])
+	assert.Equal(t, "https://github.com/argoproj/argocd-example-apps", resource.Properties["repoURL"])
+	assert.Equal(t, "guestbook", resource.Properties["path"])
+	assert.Equal(t, "guestbook", resource.Properties["applicationSet"])
+	assert.Equal(t, "Degraded", resource.Properties["healthStatus"])
+	assert.Equal(t, "OutOfSync", resource.Properties["syncStatus"])
+	assert.NotContains(t, resource.Properties, "chart")
+	assert.NotContains(t, resource.Properties, "destinationName")
+}
+
+func TestSend(t *testing.T) {
+	var requestPath, authorization string
+	event := &SyncEvent{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestPath = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(event)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tokenPath := t.TempDir() + "/token"
+	assert.NoError(t, os.WriteFile(tokenPath, []byte("test-token\n"), 0o600))
+
+	indexer := &SearchIndexer{
+		log:        ctrl.Log.WithName("search-indexer-test"),
+		config:     &SearchIndexerConfig{URL: server.URL + "/", TokenPath: tokenPath},
+		httpClient: server.Client(),
+		syncedHubs: map[string]struct{}{},
+	}
+	err := indexer.send(context.Background(), "hub1", &SyncEvent{
+		ClearAll:     true,
+		AddResources: []Resource{{Kind: "Policy", UID: "global-hub:hub1/123"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "/aggregator/clusters/global-hub:hub1/sync", requestPath)
+	assert.Equal(t, "Bearer test-token", authorization)
+	assert.True(t, event.ClearAll)
+	assert.Len(t, event.AddResources, 1)
+}
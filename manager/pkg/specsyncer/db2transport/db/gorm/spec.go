@@ -12,6 +12,7 @@ import (
 
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/bundle"
 	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/encryption"
 )
 
 var errQueryTableFailedTemplate = "failed to query table spec.%s - %w"
@@ -55,6 +56,9 @@ func (p *gormSpecDB) QuerySpecObject(ctx context.Context, tableName, objUID stri
 	if err != nil {
 		return err
 	}
+	if payload, err = encryption.DecryptPayload(payload); err != nil {
+		return err
+	}
 	return json.Unmarshal(payload, &object)
 }
 
@@ -69,7 +73,7 @@ func (p *gormSpecDB) InsertSpecObject(ctx context.Context, tableName, objUID str
 	}
 	defer database.Unlock(conn)
 	query := fmt.Sprintf("INSERT INTO spec.%s (id, payload) values(?, ?)", tableName)
-	payload, err := json.Marshal(object)
+	payload, err := marshalPayload(tableName, object)
 	if err != nil {
 		return err
 	}
@@ -86,7 +90,7 @@ func (p *gormSpecDB) UpdateSpecObject(ctx context.Context, tableName, objUID str
 		return err
 	}
 	defer database.Unlock(conn)
-	payload, err := json.Marshal(object)
+	payload, err := marshalPayload(tableName, object)
 	if err != nil {
 		return err
 	}
@@ -94,6 +98,18 @@ func (p *gormSpecDB) UpdateSpecObject(ctx context.Context, tableName, objUID str
 	return db.Exec(query, payload, objUID).Error
 }
 
+// marshalPayload marshals the object, the payload is encrypted if the table may contain the credentials
+func marshalPayload(tableName string, object *client.Object) ([]byte, error) {
+	payload, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	if !encryption.IsEncryptedTable("spec", tableName) {
+		return payload, nil
+	}
+	return encryption.EncryptPayload(payload)
+}
+
 // DeleteSpecObject deletes object with name and namespace from given table
 func (p *gormSpecDB) DeleteSpecObject(ctx context.Context, tableName, name, namespace string) error {
 	db := database.GetGorm()
@@ -144,6 +160,9 @@ func (p *gormSpecDB) GetObjectsBundle(ctx context.Context, tableName string, cre
 		if err := rows.Scan(&objID, &payload, &deleted); err != nil {
 			return nil, fmt.Errorf("error reading from table spec.%s - %w", tableName, err)
 		}
+		if payload, err = encryption.DecryptPayload(payload); err != nil {
+			return nil, fmt.Errorf("error decrypting payload from table spec.%s - %w", tableName, err)
+		}
 		if err := json.Unmarshal(payload, &object); err != nil {
 			return nil, fmt.Errorf("error reading unmarshal payload from table spec.%s - %w", tableName, err)
 		}
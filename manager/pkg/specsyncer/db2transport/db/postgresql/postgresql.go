@@ -32,7 +32,7 @@ type PostgreSQL struct {
 // NewSpecPostgreSQL creates a new instance of PostgreSQL object.
 func NewSpecPostgreSQL(ctx context.Context, dataConfig *managerconfig.DatabaseConfig) (*PostgreSQL, error) {
 	pool, err := database.PostgresConnPool(ctx, dataConfig.ProcessDatabaseURL, dataConfig.CACertPath,
-		int32(dataConfig.MaxOpenConns))
+		int32(dataConfig.MaxOpenConns), dataConfig.AuthType)
 	if err != nil {
 		return nil, err
 	}
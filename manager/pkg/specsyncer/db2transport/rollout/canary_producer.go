@@ -0,0 +1,260 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+const (
+	RolloutPromoted   = 0
+	RolloutCanary     = 1
+	RolloutRolledBack = 2
+
+	evaluateInterval = 30 * time.Second
+)
+
+var SpecRolloutGaugeVec = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "multicluster_global_hub_spec_rollout_status",
+		Help: "The rollout status of the spec bundle. 0 == promoted, 1 == canary, 2 == rolled back.",
+	},
+	[]string{"type"},
+)
+
+type RolloutConfig struct {
+	// CanarySelector is the label selector of the managed hubs which receive the spec changes first, the canary
+	// rollout is disabled if it's empty
+	CanarySelector string
+	// EvaluationPeriod is how long the canary hubs are evaluated before the change is promoted or rolled back
+	EvaluationPeriod time.Duration
+	// MaxNonCompliantIncrease is the number of the new non-compliant cluster policy pairs on the canary hubs that
+	// is tolerated during the evaluation period
+	MaxNonCompliantIncrease int
+}
+
+// CanaryProducer wraps the spec producer. The broadcast bundles are sent to the canary hubs first, once the canary
+// hubs stay healthy for the evaluation period, the bundle is promoted to all the managed hubs. Otherwise the canary
+// hubs are rolled back to the previous bundle, and the change isn't sent to the rest of the fleet.
+type CanaryProducer struct {
+	log      logr.Logger
+	client   client.Client
+	producer transport.Producer
+	config   *RolloutConfig
+	selector labels.Selector
+	lock     sync.Mutex
+	rollouts map[string]*bundleRollout // map from the event type to its rollout
+}
+
+type bundleRollout struct {
+	// promoted is the bundle which has been sent to all the managed hubs
+	promoted *cloudevents.Event
+	// canary is the bundle which is being evaluated on the canary hubs
+	canary     *cloudevents.Event
+	canaryHubs []string
+	startedAt  time.Time
+	// baseline is the number of the non-compliant cluster policy pairs on the canary hubs before the rollout
+	baseline int64
+}
+
+// NewCanaryProducer returns the producer itself if the canary rollout isn't enabled
+func NewCanaryProducer(c client.Client, producer transport.Producer, config *RolloutConfig,
+) (transport.Producer, *CanaryProducer, error) {
+	if config == nil || config.CanarySelector == "" {
+		return producer, nil, nil
+	}
+	selector, err := labels.Parse(config.CanarySelector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid canary selector %s: %w", config.CanarySelector, err)
+	}
+	canaryProducer := &CanaryProducer{
+		log:      ctrl.Log.WithName("canary-rollout"),
+		client:   c,
+		producer: producer,
+		config:   config,
+		selector: selector,
+		rollouts: map[string]*bundleRollout{},
+	}
+	return canaryProducer, canaryProducer, nil
+}
+
+func (p *CanaryProducer) SendEvent(ctx context.Context, evt cloudevents.Event) error {
+	if evt.Source() != transport.Broadcast {
+		return p.producer.SendEvent(ctx, evt)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	rollout, ok := p.rollouts[evt.Type()]
+	if !ok {
+		rollout = &bundleRollout{}
+		p.rollouts[evt.Type()] = rollout
+	}
+	if rollout.promoted == nil {
+		// the hubs already have the bundles before the manager starts, so the initial bundle is sent to all of them
+		return p.promote(ctx, evt.Type(), &evt)
+	}
+
+	canaryHubs, err := p.listCanaryHubs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(canaryHubs) == 0 {
+		p.log.Info("no canary hub is found, send the bundle to all the hubs", "type", evt.Type())
+		return p.promote(ctx, evt.Type(), &evt)
+	}
+
+	baseline, err := countNonCompliant(canaryHubs)
+	if err != nil {
+		return err
+	}
+	for _, hub := range canaryHubs {
+		if err := p.sendTo(ctx, &evt, hub); err != nil {
+			return err
+		}
+	}
+	// a newer change supersedes the change being evaluated
+	rollout.canary = &evt
+	rollout.canaryHubs = canaryHubs
+	rollout.startedAt = time.Now()
+	rollout.baseline = baseline
+	SpecRolloutGaugeVec.WithLabelValues(evt.Type()).Set(RolloutCanary)
+	p.log.Info("send the bundle to the canary hubs", "type", evt.Type(), "hubs", canaryHubs,
+		"evaluationPeriod", p.config.EvaluationPeriod)
+	return nil
+}
+
+func (p *CanaryProducer) Start(ctx context.Context) error {
+	p.log.Info("starting canary rollout", "selector", p.config.CanarySelector,
+		"evaluationPeriod", p.config.EvaluationPeriod)
+	ticker := time.NewTicker(evaluateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			p.evaluate(ctx)
+		}
+	}
+}
+
+// evaluate promotes or rolls back the bundles which have been evaluated on the canary hubs for the period
+func (p *CanaryProducer) evaluate(ctx context.Context) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for eventType, rollout := range p.rollouts {
+		if rollout.canary == nil || time.Since(rollout.startedAt) < p.config.EvaluationPeriod {
+			continue
+		}
+		reason, err := p.unhealthyReason(rollout)
+		if err != nil {
+			p.log.Error(err, "failed to evaluate the canary hubs", "type", eventType)
+			continue
+		}
+		if reason == "" {
+			if err := p.promote(ctx, eventType, rollout.canary); err != nil {
+				p.log.Error(err, "failed to promote the bundle", "type", eventType)
+			}
+			continue
+		}
+		p.log.Info("roll back the canary hubs", "type", eventType, "hubs", rollout.canaryHubs, "reason", reason)
+		if err := p.rollback(ctx, eventType, rollout); err != nil {
+			p.log.Error(err, "failed to roll back the canary hubs", "type", eventType)
+		}
+	}
+}
+
+// unhealthyReason returns why the canary hubs are unhealthy, or an empty string if they're healthy
+func (p *CanaryProducer) unhealthyReason(rollout *bundleRollout) (string, error) {
+	var activeHubs int64
+	err := database.GetGorm().Raw(`SELECT count(*) FROM status.leaf_hub_heartbeats
+		WHERE leaf_hub_name IN @hubs AND status = 'active' AND last_timestamp > @since`,
+		map[string]interface{}{"hubs": rollout.canaryHubs, "since": rollout.startedAt}).Scan(&activeHubs).Error
+	if err != nil {
+		return "", err
+	}
+	if activeHubs < int64(len(rollout.canaryHubs)) {
+		return fmt.Sprintf("only %d of %d canary hubs sent the heartbeat", activeHubs, len(rollout.canaryHubs)), nil
+	}
+
+	nonCompliant, err := countNonCompliant(rollout.canaryHubs)
+	if err != nil {
+		return "", err
+	}
+	if increase := nonCompliant - rollout.baseline; increase > int64(p.config.MaxNonCompliantIncrease) {
+		return fmt.Sprintf("the non-compliant cluster policy pairs increased by %d", increase), nil
+	}
+	return "", nil
+}
+
+func (p *CanaryProducer) promote(ctx context.Context, eventType string, evt *cloudevents.Event) error {
+	if err := p.producer.SendEvent(ctx, *evt); err != nil {
+		return err
+	}
+	rollout := p.rollouts[eventType]
+	rollout.promoted = evt
+	rollout.canary = nil
+	rollout.canaryHubs = nil
+	SpecRolloutGaugeVec.WithLabelValues(eventType).Set(RolloutPromoted)
+	p.log.V(2).Info("send the bundle to all the hubs", "type", eventType)
+	return nil
+}
+
+func (p *CanaryProducer) rollback(ctx context.Context, eventType string, rollout *bundleRollout) error {
+	for _, hub := range rollout.canaryHubs {
+		if err := p.sendTo(ctx, rollout.promoted, hub); err != nil {
+			return err
+		}
+	}
+	rollout.canary = nil
+	rollout.canaryHubs = nil
+	SpecRolloutGaugeVec.WithLabelValues(eventType).Set(RolloutRolledBack)
+	return nil
+}
+
+func (p *CanaryProducer) sendTo(ctx context.Context, evt *cloudevents.Event, hubName string) error {
+	hubEvt := evt.Clone()
+	hubEvt.SetSource(hubName)
+	if err := p.producer.SendEvent(ctx, hubEvt); err != nil {
+		return fmt.Errorf("failed to send the bundle %s to the hub %s: %w", evt.Type(), hubName, err)
+	}
+	return nil
+}
+
+func (p *CanaryProducer) listCanaryHubs(ctx context.Context) ([]string, error) {
+	clusters := &clusterv1.ManagedClusterList{}
+	if err := p.client.List(ctx, clusters, &client.ListOptions{LabelSelector: p.selector}); err != nil {
+		return nil, fmt.Errorf("failed to list the canary hubs: %w", err)
+	}
+	hubs := make([]string, 0, len(clusters.Items))
+	for _, cluster := range clusters.Items {
+		hubs = append(hubs, cluster.Name)
+	}
+	return hubs, nil
+}
+
+func countNonCompliant(hubs []string) (int64, error) {
+	var count int64
+	err := database.GetGorm().Raw(`SELECT count(*) FROM local_status.compliance
+		WHERE leaf_hub_name IN @hubs AND compliance = 'non_compliant'`,
+		map[string]interface{}{"hubs": hubs}).Scan(&count).Error
+	return count, err
+}
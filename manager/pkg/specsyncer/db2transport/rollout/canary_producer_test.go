@@ -0,0 +1,60 @@
+package rollout
+
+import (
+	"context"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+type recordProducer struct {
+	events []cloudevents.Event
+}
+
+func (p *recordProducer) SendEvent(ctx context.Context, evt cloudevents.Event) error {
+	p.events = append(p.events, evt)
+	return nil
+}
+
+func TestNewCanaryProducer(t *testing.T) {
+	producer := &recordProducer{}
+	specProducer, canaryProducer, err := NewCanaryProducer(nil, producer, &RolloutConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, canaryProducer)
+	assert.Equal(t, producer, specProducer)
+
+	_, _, err = NewCanaryProducer(nil, producer, &RolloutConfig{CanarySelector: "canary in (true"})
+	assert.Error(t, err)
+}
+
+func TestCanaryProducerWithoutCanaryHubs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clusterv1.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	producer := &recordProducer{}
+	specProducer, _, err := NewCanaryProducer(fakeClient, producer, &RolloutConfig{CanarySelector: "canary=true"})
+	assert.NoError(t, err)
+
+	evt := cloudevents.NewEvent()
+	evt.SetType("Policies")
+	evt.SetSource(transport.Broadcast)
+
+	// the initial bundle is sent to all the hubs
+	assert.NoError(t, specProducer.SendEvent(context.Background(), evt))
+	// the change is sent to all the hubs if there is no canary hub
+	assert.NoError(t, specProducer.SendEvent(context.Background(), evt))
+	assert.Len(t, producer.events, 2)
+	assert.Equal(t, transport.Broadcast, producer.events[1].Source())
+
+	// the bundle to the specific hub isn't changed
+	evt.SetSource("hub1")
+	assert.NoError(t, specProducer.SendEvent(context.Background(), evt))
+	assert.Equal(t, "hub1", producer.events[2].Source())
+}
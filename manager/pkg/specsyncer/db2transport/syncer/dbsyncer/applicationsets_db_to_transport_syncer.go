@@ -0,0 +1,232 @@
+package dbsyncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/bundle"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/db"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/intervalpolicy"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/utils"
+)
+
+const (
+	applicationSetsTableName = "applicationsets"
+	applicationSetsMsgKey    = "ApplicationSets"
+
+	// managedHubParameter is substituted with the name of the managed hub, e.g. $(managedHub)
+	managedHubParameter = "managedHub"
+)
+
+// AddApplicationSetsDBToTransportSyncer adds applicationsets db to transport syncer to the manager. Unlike the other
+// global resources, the ApplicationSets aren't broadcast: each managed hub receives its own bundle, which only contains
+// the ApplicationSets selecting the hub, with the parameters of the hub substituted.
+func AddApplicationSetsDBToTransportSyncer(mgr ctrl.Manager, specDB db.SpecDB, producer transport.Producer,
+	specSyncInterval time.Duration,
+) error {
+	syncer := &applicationSetsSyncer{
+		log:      ctrl.Log.WithName("db-to-transport-syncer-applicationsets"),
+		client:   mgr.GetClient(),
+		specDB:   specDB,
+		producer: producer,
+	}
+
+	if err := mgr.Add(&genericDBToTransportSyncer{
+		log:            syncer.log,
+		intervalPolicy: intervalpolicy.NewExponentialBackoffPolicy(specSyncInterval),
+		syncBundleFunc: syncer.sync,
+	}); err != nil {
+		return fmt.Errorf("failed to add applicationsets db to transport syncer - %w", err)
+	}
+
+	return nil
+}
+
+type applicationSetsSyncer struct {
+	log               logr.Logger
+	client            client.Client
+	specDB            db.SpecDB
+	producer          transport.Producer
+	lastSyncTimestamp time.Time
+	// lastHubs is the names and the labels of the managed hubs which the bundles were sent to, the bundles are sent
+	// again once a hub joins or the labels of a hub are changed
+	lastHubs string
+}
+
+// sync returns true if the bundles were committed to transport, otherwise false.
+func (s *applicationSetsSyncer) sync(ctx context.Context) (bool, error) {
+	hubs := &clusterv1.ManagedClusterList{}
+	if err := s.client.List(ctx, hubs); err != nil {
+		return false, fmt.Errorf("failed to list the managed hubs - %w", err)
+	}
+	hubsFingerprint := fingerprint(hubs.Items)
+
+	lastUpdateTimestamp, err := s.specDB.GetLastUpdateTimestamp(ctx, applicationSetsTableName, true)
+	if err != nil {
+		return false, fmt.Errorf("unable to sync bundle - %w", err)
+	}
+
+	// sync only if something has changed
+	if !lastUpdateTimestamp.After(s.lastSyncTimestamp) && hubsFingerprint == s.lastHubs {
+		return false, nil
+	}
+
+	applicationSets := &applicationSetsBundle{}
+	lastUpdateTimestamp, err = s.specDB.GetObjectsBundle(ctx, applicationSetsTableName,
+		func() metav1.Object { return &unstructured.Unstructured{} }, applicationSets)
+	if err != nil {
+		return false, fmt.Errorf("unable to sync bundle - %w", err)
+	}
+
+	for i := range hubs.Items {
+		hub := &hubs.Items[i]
+		payloadBytes, err := json.Marshal(applicationSets.bundleFor(hub, s.log))
+		if err != nil {
+			return false, fmt.Errorf("failed to sync marshal bundle(%s)", applicationSetsMsgKey)
+		}
+
+		evt := utils.ToCloudEvent(applicationSetsMsgKey, hub.Name, payloadBytes)
+		if err := s.producer.SendEvent(ctx, evt); err != nil {
+			return false, fmt.Errorf("failed to sync message(%s) from table(%s) to destination(%s) - %w",
+				applicationSetsMsgKey, applicationSetsTableName, hub.Name, err)
+		}
+	}
+
+	s.lastSyncTimestamp = *lastUpdateTimestamp
+	s.lastHubs = hubsFingerprint
+	return true, nil
+}
+
+// applicationSetsBundle collects the ApplicationSets from the database, and then builds the bundle of each hub.
+type applicationSetsBundle struct {
+	objects        []*unstructured.Unstructured
+	uids           []string
+	deletedObjects []metav1.Object
+}
+
+// AddObject adds an object to the bundle.
+func (b *applicationSetsBundle) AddObject(object metav1.Object, objectUID string) {
+	applicationSet, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	b.objects = append(b.objects, applicationSet)
+	b.uids = append(b.uids, objectUID)
+}
+
+// AddDeletedObject adds a deleted object to the bundle.
+func (b *applicationSetsBundle) AddDeletedObject(object metav1.Object) {
+	b.deletedObjects = append(b.deletedObjects, object)
+}
+
+// bundleFor returns the bundle of the hub. The ApplicationSets which don't select the hub are deleted from it. If the
+// selector or the parameters of an ApplicationSet are invalid, it's left as it is on the hub until they're corrected.
+func (b *applicationSetsBundle) bundleFor(hub *clusterv1.ManagedCluster, log logr.Logger) bundle.ObjectsBundle {
+	hubBundle := bundle.NewBaseObjectsBundle()
+	for i, object := range b.objects {
+		selected, err := selectsHub(object, hub)
+		if err != nil {
+			log.Error(err, "invalid managed hub selector", "namespace", object.GetNamespace(),
+				"name", object.GetName())
+			continue
+		}
+		if !selected {
+			hubBundle.AddDeletedObject(object)
+			continue
+		}
+		hubObject, err := substituteParameters(object, hub.Name)
+		if err != nil {
+			log.Error(err, "invalid managed hub parameters", "namespace", object.GetNamespace(),
+				"name", object.GetName())
+			continue
+		}
+		hubBundle.AddObject(hubObject, b.uids[i])
+	}
+	for _, object := range b.deletedObjects {
+		hubBundle.AddDeletedObject(object)
+	}
+	return hubBundle
+}
+
+// selectsHub returns true if the managed hub selector of the object matches the labels of the hub, the object selects
+// all the hubs if it doesn't have the selector
+func selectsHub(object *unstructured.Unstructured, hub *clusterv1.ManagedCluster) (bool, error) {
+	selector, err := labels.Parse(object.GetAnnotations()[constants.ManagedHubSelectorAnnotation])
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(hub.Labels)), nil
+}
+
+// substituteParameters returns a copy of the object whose $(parameter) in the string values are replaced with the
+// parameters of the hub. The unknown parameters are left as they are, e.g. the ones of the ApplicationSet generators.
+func substituteParameters(object *unstructured.Unstructured, hubName string) (*unstructured.Unstructured, error) {
+	parameters := map[string]map[string]string{}
+	if value, found := object.GetAnnotations()[constants.ManagedHubParametersAnnotation]; found {
+		if err := json.Unmarshal([]byte(value), &parameters); err != nil {
+			return nil, err
+		}
+	}
+
+	oldNew := []string{fmt.Sprintf("$(%s)", managedHubParameter), hubName}
+	for key, value := range parameters[hubName] {
+		oldNew = append(oldNew, fmt.Sprintf("$(%s)", key), value)
+	}
+	replacer := strings.NewReplacer(oldNew...)
+
+	hubObject := object.DeepCopy()
+	// the hub doesn't need the propagation settings of the other hubs
+	annotations := hubObject.GetAnnotations()
+	delete(annotations, constants.ManagedHubSelectorAnnotation)
+	delete(annotations, constants.ManagedHubParametersAnnotation)
+	hubObject.SetAnnotations(annotations)
+
+	// the metadata isn't substituted, the ApplicationSet must have the same name on all the hubs to be deleted
+	for key, value := range hubObject.Object {
+		if key == "metadata" {
+			continue
+		}
+		hubObject.Object[key] = replaceStrings(value, replacer)
+	}
+	return hubObject, nil
+}
+
+func replaceStrings(value interface{}, replacer *strings.Replacer) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return replacer.Replace(typed)
+	case map[string]interface{}:
+		for key, item := range typed {
+			typed[key] = replaceStrings(item, replacer)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = replaceStrings(item, replacer)
+		}
+	}
+	return value
+}
+
+// fingerprint returns the names and the labels of the hubs
+func fingerprint(hubs []clusterv1.ManagedCluster) string {
+	hubLabels := make([]string, 0, len(hubs))
+	for _, hub := range hubs {
+		hubLabels = append(hubLabels, fmt.Sprintf("%s{%s}", hub.Name, labels.Set(hub.Labels).String()))
+	}
+	sort.Strings(hubLabels)
+	return strings.Join(hubLabels, ",")
+}
@@ -0,0 +1,111 @@
+package dbsyncer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+func TestApplicationSetsBundleFor(t *testing.T) {
+	newApplicationSet := func(name string, annotations map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "ApplicationSet",
+			"metadata": map[string]interface{}{
+				"name":        name,
+				"namespace":   "openshift-gitops",
+				"annotations": annotations,
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "{{name}}-$(managedHub)"},
+					"spec": map[string]interface{}{
+						"source": map[string]interface{}{
+							"repoURL":        "https://github.com/argoproj/argocd-example-apps.git",
+							"targetRevision": "$(revision)",
+						},
+						"destination": map[string]interface{}{"namespace": "$(namespace)"},
+					},
+				},
+			},
+		}}
+	}
+
+	applicationSets := &applicationSetsBundle{}
+	applicationSets.AddObject(newApplicationSet("guestbook", map[string]interface{}{
+		constants.ManagedHubSelectorAnnotation:   "env=prod",
+		constants.ManagedHubParametersAnnotation: `{"hub1": {"revision": "v1.0.0"}, "hub2": {"revision": "v2.0.0"}}`,
+	}), "uid-guestbook")
+	applicationSets.AddObject(newApplicationSet("invalid", map[string]interface{}{
+		constants.ManagedHubSelectorAnnotation: "env in prod",
+	}), "uid-invalid")
+	applicationSets.AddDeletedObject(newApplicationSet("deleted", nil))
+
+	hub1 := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name: "hub1", Labels: map[string]string{"env": "prod"},
+	}}
+	hub3 := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name: "hub3", Labels: map[string]string{"env": "dev"},
+	}}
+
+	// the selected hub receives the applicationset with its parameters
+	hubBundle := bundleOf(t, applicationSets.bundleFor(hub1, logr.Discard()))
+	assert.Len(t, hubBundle.Objects, 1)
+	assert.Len(t, hubBundle.DeletedObjects, 1)
+	object := hubBundle.Objects[0]
+	assert.Equal(t, "guestbook", object.GetName())
+	assert.Equal(t, map[string]string{constants.OriginOwnerReferenceAnnotation: "uid-guestbook"},
+		object.GetAnnotations())
+	name, _, _ := unstructured.NestedString(object.Object, "spec", "template", "metadata", "name")
+	assert.Equal(t, "{{name}}-hub1", name)
+	revision, _, _ := unstructured.NestedString(object.Object, "spec", "template", "spec", "source",
+		"targetRevision")
+	assert.Equal(t, "v1.0.0", revision)
+	// the unknown parameter is left as it is
+	namespace, _, _ := unstructured.NestedString(object.Object, "spec", "template", "spec", "destination",
+		"namespace")
+	assert.Equal(t, "$(namespace)", namespace)
+
+	// the applicationset is deleted from the hub which isn't selected
+	hubBundle = bundleOf(t, applicationSets.bundleFor(hub3, logr.Discard()))
+	assert.Empty(t, hubBundle.Objects)
+	assert.Len(t, hubBundle.DeletedObjects, 2)
+	assert.Equal(t, "guestbook", hubBundle.DeletedObjects[0].GetName())
+
+	// the original applicationset isn't changed by the substitution
+	name, _, _ = unstructured.NestedString(applicationSets.objects[0].Object, "spec", "template", "metadata",
+		"name")
+	assert.Equal(t, "{{name}}-$(managedHub)", name)
+}
+
+func TestFingerprint(t *testing.T) {
+	hubs := []clusterv1.ManagedCluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "hub2", Labels: map[string]string{"env": "dev"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "hub1", Labels: map[string]string{"env": "prod", "region": "us"}}},
+	}
+	assert.Equal(t, "hub1{env=prod,region=us},hub2{env=dev}", fingerprint(hubs))
+
+	hubs[0].Labels["env"] = "prod"
+	assert.NotEqual(t, "hub1{env=prod,region=us},hub2{env=dev}", fingerprint(hubs))
+}
+
+type unstructuredBundle struct {
+	Objects        []*unstructured.Unstructured `json:"objects"`
+	DeletedObjects []*unstructured.Unstructured `json:"deletedObjects"`
+}
+
+// bundleOf decodes the bundle in the same way as the agent
+func bundleOf(t *testing.T, hubBundle interface{}) *unstructuredBundle {
+	payload, err := json.Marshal(hubBundle)
+	assert.NoError(t, err)
+	decoded := &unstructuredBundle{}
+	assert.NoError(t, json.Unmarshal(payload, decoded))
+	return decoded
+}
@@ -0,0 +1,43 @@
+package dbsyncer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/bundle"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/db"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/intervalpolicy"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+const (
+	operatorPoliciesTableName = "operatorpolicies"
+	operatorPoliciesMsgKey    = "OperatorPolicies"
+)
+
+// AddOperatorPoliciesDBToTransportSyncer adds operator policies db to transport syncer to the manager. The
+// OperatorPolicies are unstructured, the managed hubs without the OperatorPolicy CRD fail to apply them.
+func AddOperatorPoliciesDBToTransportSyncer(mgr ctrl.Manager, specDB db.SpecDB, producer transport.Producer,
+	specSyncInterval time.Duration,
+) error {
+	createObjFunc := func() metav1.Object { return &unstructured.Unstructured{} }
+	lastSyncTimestampPtr := &time.Time{}
+
+	if err := mgr.Add(&genericDBToTransportSyncer{
+		log:            ctrl.Log.WithName("db-to-transport-syncer-operatorpolicy"),
+		intervalPolicy: intervalpolicy.NewExponentialBackoffPolicy(specSyncInterval),
+		syncBundleFunc: func(ctx context.Context) (bool, error) {
+			return syncObjectsBundle(ctx, producer, operatorPoliciesMsgKey, specDB, operatorPoliciesTableName,
+				createObjFunc, bundle.NewBaseObjectsBundle, lastSyncTimestampPtr)
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to add operator policies db to transport syncer - %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package dbsyncer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policyv1beta1 "open-cluster-management.io/governance-policy-propagator/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/bundle"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/db"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/intervalpolicy"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+const (
+	policySetsTableName = "policysets"
+	policySetsMsgKey    = "PolicySets"
+)
+
+// AddPolicySetsDBToTransportSyncer adds policy sets db to transport syncer to the manager.
+func AddPolicySetsDBToTransportSyncer(mgr ctrl.Manager, specDB db.SpecDB, producer transport.Producer,
+	specSyncInterval time.Duration,
+) error {
+	createObjFunc := func() metav1.Object { return &policyv1beta1.PolicySet{} }
+	lastSyncTimestampPtr := &time.Time{}
+
+	if err := mgr.Add(&genericDBToTransportSyncer{
+		log:            ctrl.Log.WithName("db-to-transport-syncer-policyset"),
+		intervalPolicy: intervalpolicy.NewExponentialBackoffPolicy(specSyncInterval),
+		syncBundleFunc: func(ctx context.Context) (bool, error) {
+			return syncObjectsBundle(ctx, producer, policySetsMsgKey, specDB, policySetsTableName,
+				createObjFunc, bundle.NewBaseObjectsBundle, lastSyncTimestampPtr)
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to add policy sets db to transport syncer - %w", err)
+	}
+
+	return nil
+}
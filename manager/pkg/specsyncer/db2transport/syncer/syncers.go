@@ -22,7 +22,10 @@ func AddDB2TransportSyncers(mgr ctrl.Manager, managerConfig *config.ManagerConfi
 		dbsyncer.AddPoliciesDBToTransportSyncer,
 		dbsyncer.AddPlacementRulesDBToTransportSyncer,
 		dbsyncer.AddPlacementBindingsDBToTransportSyncer,
+		dbsyncer.AddPolicySetsDBToTransportSyncer,
+		dbsyncer.AddOperatorPoliciesDBToTransportSyncer,
 		dbsyncer.AddApplicationsDBToTransportSyncer,
+		dbsyncer.AddApplicationSetsDBToTransportSyncer,
 		dbsyncer.AddSubscriptionsDBToTransportSyncer,
 		dbsyncer.AddChannelsDBToTransportSyncer,
 		dbsyncer.AddManagedClusterLabelsDBToTransportSyncer,
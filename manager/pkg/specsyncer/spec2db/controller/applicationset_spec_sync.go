@@ -0,0 +1,86 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/db"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+var applicationSetGVK = schema.GroupVersionKind{
+	Group:   "argoproj.io",
+	Version: "v1alpha1",
+	Kind:    "ApplicationSet",
+}
+
+// AddApplicationSetController syncs the global ApplicationSets to the database, it's skipped if the OpenShift GitOps
+// isn't installed on the global hub
+func AddApplicationSetController(mgr ctrl.Manager, specDB db.SpecDB) error {
+	_, err := mgr.GetRESTMapper().RESTMapping(applicationSetGVK.GroupKind(), applicationSetGVK.Version)
+	if meta.IsNoMatchError(err) {
+		ctrl.Log.WithName("applicationsets-spec-syncer").Info("skip the controller, the ApplicationSet CRD isn't found")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the mapping of the ApplicationSet: %w", err)
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(newApplicationSet()).
+		WithEventFilter(GlobalResourcePredicate()).
+		Complete(&genericSpecToDBReconciler{
+			client:         mgr.GetClient(),
+			specDB:         specDB,
+			log:            ctrl.Log.WithName("applicationsets-spec-syncer"),
+			tableName:      "applicationsets",
+			finalizerName:  constants.GlobalHubCleanupFinalizer,
+			createInstance: func() client.Object { return newApplicationSet() },
+			cleanObject:    cleanApplicationSetStatus,
+			areEqual:       areApplicationSetsEqual,
+		}); err != nil {
+		return fmt.Errorf("failed to add applicationset controller to the manager: %w", err)
+	}
+
+	return nil
+}
+
+// the ApplicationSet is unstructured so the global hub doesn't depend on the API of the ArgoCD
+func newApplicationSet() *unstructured.Unstructured {
+	applicationSet := &unstructured.Unstructured{}
+	applicationSet.SetGroupVersionKind(applicationSetGVK)
+	return applicationSet
+}
+
+func cleanApplicationSetStatus(instance client.Object) {
+	applicationSet, ok := instance.(*unstructured.Unstructured)
+	if !ok {
+		panic("wrong instance passed to cleanApplicationSetStatus: not an ApplicationSet")
+	}
+
+	unstructured.RemoveNestedField(applicationSet.Object, "status")
+}
+
+func areApplicationSetsEqual(instance1, instance2 client.Object) bool {
+	applicationSet1, ok1 := instance1.(*unstructured.Unstructured)
+	applicationSet2, ok2 := instance2.(*unstructured.Unstructured)
+
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	specMatch := equality.Semantic.DeepEqual(applicationSet1.Object["spec"], applicationSet2.Object["spec"])
+	annotationsMatch := equality.Semantic.DeepEqual(instance1.GetAnnotations(), instance2.GetAnnotations())
+	labelsMatch := equality.Semantic.DeepEqual(instance1.GetLabels(), instance2.GetLabels())
+
+	return specMatch && annotationsMatch && labelsMatch
+}
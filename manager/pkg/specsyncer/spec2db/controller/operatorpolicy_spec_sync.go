@@ -0,0 +1,86 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/db"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+var operatorPolicyGVK = schema.GroupVersionKind{
+	Group:   "policy.open-cluster-management.io",
+	Version: "v1beta1",
+	Kind:    "OperatorPolicy",
+}
+
+// AddOperatorPolicyController syncs the global OperatorPolicies to the database, it's skipped if the OperatorPolicy
+// CRD isn't installed on the global hub
+func AddOperatorPolicyController(mgr ctrl.Manager, specDB db.SpecDB) error {
+	_, err := mgr.GetRESTMapper().RESTMapping(operatorPolicyGVK.GroupKind(), operatorPolicyGVK.Version)
+	if meta.IsNoMatchError(err) {
+		ctrl.Log.WithName("operatorpolicies-spec-syncer").Info("skip the controller, the OperatorPolicy CRD isn't found")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the mapping of the OperatorPolicy: %w", err)
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(newOperatorPolicy()).
+		WithEventFilter(GlobalResourcePredicate()).
+		Complete(&genericSpecToDBReconciler{
+			client:         mgr.GetClient(),
+			specDB:         specDB,
+			log:            ctrl.Log.WithName("operatorpolicies-spec-syncer"),
+			tableName:      "operatorpolicies",
+			finalizerName:  constants.GlobalHubCleanupFinalizer,
+			createInstance: func() client.Object { return newOperatorPolicy() },
+			cleanObject:    cleanOperatorPolicyStatus,
+			areEqual:       areOperatorPoliciesEqual,
+		}); err != nil {
+		return fmt.Errorf("failed to add operatorpolicy controller to the manager: %w", err)
+	}
+
+	return nil
+}
+
+// the OperatorPolicy is unstructured since its API isn't vendored by the global hub
+func newOperatorPolicy() *unstructured.Unstructured {
+	operatorPolicy := &unstructured.Unstructured{}
+	operatorPolicy.SetGroupVersionKind(operatorPolicyGVK)
+	return operatorPolicy
+}
+
+func cleanOperatorPolicyStatus(instance client.Object) {
+	operatorPolicy, ok := instance.(*unstructured.Unstructured)
+	if !ok {
+		panic("wrong instance passed to cleanOperatorPolicyStatus: not an OperatorPolicy")
+	}
+
+	unstructured.RemoveNestedField(operatorPolicy.Object, "status")
+}
+
+func areOperatorPoliciesEqual(instance1, instance2 client.Object) bool {
+	operatorPolicy1, ok1 := instance1.(*unstructured.Unstructured)
+	operatorPolicy2, ok2 := instance2.(*unstructured.Unstructured)
+
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	specMatch := equality.Semantic.DeepEqual(operatorPolicy1.Object["spec"], operatorPolicy2.Object["spec"])
+	annotationsMatch := equality.Semantic.DeepEqual(instance1.GetAnnotations(), instance2.GetAnnotations())
+	labelsMatch := equality.Semantic.DeepEqual(instance1.GetLabels(), instance2.GetLabels())
+
+	return specMatch && annotationsMatch && labelsMatch
+}
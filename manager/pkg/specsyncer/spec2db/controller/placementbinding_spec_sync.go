@@ -55,8 +55,10 @@ func arePlacementBindingsEqual(instance1, instance2 client.Object) bool {
 
 	placementRefMatch := equality.Semantic.DeepEqual(placementBinding1.PlacementRef, placementBinding2.PlacementRef)
 	subjectsMatch := equality.Semantic.DeepEqual(placementBinding1.Subjects, placementBinding2.Subjects)
+	overridesMatch := equality.Semantic.DeepEqual(placementBinding1.BindingOverrides,
+		placementBinding2.BindingOverrides) && placementBinding1.SubFilter == placementBinding2.SubFilter
 	annotationsMatch := equality.Semantic.DeepEqual(instance1.GetAnnotations(), instance2.GetAnnotations())
 	labelsMatch := equality.Semantic.DeepEqual(instance1.GetLabels(), instance2.GetLabels())
 
-	return placementRefMatch && subjectsMatch && annotationsMatch && labelsMatch
+	return placementRefMatch && subjectsMatch && overridesMatch && annotationsMatch && labelsMatch
 }
@@ -0,0 +1,61 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	policyv1beta1 "open-cluster-management.io/governance-policy-propagator/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/db"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+func AddPolicySetController(mgr ctrl.Manager, specDB db.SpecDB) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&policyv1beta1.PolicySet{}).
+		WithEventFilter(GlobalResourcePredicate()).
+		Complete(&genericSpecToDBReconciler{
+			client:         mgr.GetClient(),
+			specDB:         specDB,
+			log:            ctrl.Log.WithName("policysets-spec-syncer"),
+			tableName:      "policysets",
+			finalizerName:  constants.GlobalHubCleanupFinalizer,
+			createInstance: func() client.Object { return &policyv1beta1.PolicySet{} },
+			cleanObject:    cleanPolicySetStatus,
+			areEqual:       arePolicySetsEqual,
+		}); err != nil {
+		return fmt.Errorf("failed to add policy set controller to the manager: %w", err)
+	}
+
+	return nil
+}
+
+func cleanPolicySetStatus(instance client.Object) {
+	policySet, ok := instance.(*policyv1beta1.PolicySet)
+
+	if !ok {
+		panic("wrong instance passed to cleanPolicySetStatus: not a PolicySet")
+	}
+
+	policySet.Status = policyv1beta1.PolicySetStatus{}
+}
+
+func arePolicySetsEqual(instance1, instance2 client.Object) bool {
+	policySet1, ok1 := instance1.(*policyv1beta1.PolicySet)
+	policySet2, ok2 := instance2.(*policyv1beta1.PolicySet)
+
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	specMatch := equality.Semantic.DeepEqual(policySet1.Spec, policySet2.Spec)
+	annotationsMatch := equality.Semantic.DeepEqual(instance1.GetAnnotations(), instance2.GetAnnotations())
+	labelsMatch := equality.Semantic.DeepEqual(instance1.GetLabels(), instance2.GetLabels())
+
+	return specMatch && annotationsMatch && labelsMatch
+}
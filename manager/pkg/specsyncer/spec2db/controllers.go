@@ -19,7 +19,10 @@ func AddSpec2DBControllers(mgr ctrl.Manager) error {
 		controller.AddPolicyController,
 		controller.AddPlacementRuleController,
 		controller.AddPlacementBindingController,
+		controller.AddPolicySetController,
+		controller.AddOperatorPolicyController,
 		controller.AddApplicationController,
+		controller.AddApplicationSetController,
 		controller.AddSubscriptionController,
 		controller.AddChannelController,
 		controller.AddManagedClusterSetController,
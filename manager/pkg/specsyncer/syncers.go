@@ -6,6 +6,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/rollout"
 	specsyncer "github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/db2transport/syncer"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/specsyncer/spec2db"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
@@ -19,7 +20,19 @@ func AddGlobalResourceSpecSyncers(mgr ctrl.Manager,
 		return fmt.Errorf("failed to add spec-to-db controllers: %w", err)
 	}
 
-	if err := specsyncer.AddDB2TransportSyncers(mgr, managerConfig, producer); err != nil {
+	// send the spec changes to the canary hubs before the whole fleet
+	specProducer, canaryProducer, err := rollout.NewCanaryProducer(mgr.GetClient(), producer,
+		managerConfig.RolloutConfig)
+	if err != nil {
+		return err
+	}
+	if canaryProducer != nil {
+		if err := mgr.Add(canaryProducer); err != nil {
+			return fmt.Errorf("failed to add the canary rollout: %w", err)
+		}
+	}
+
+	if err := specsyncer.AddDB2TransportSyncers(mgr, managerConfig, specProducer); err != nil {
 		return fmt.Errorf("failed to add db-to-transport syncers: %w", err)
 	}
 
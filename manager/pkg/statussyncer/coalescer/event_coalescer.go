@@ -0,0 +1,146 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package coalescer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var CoalescedEventsCounterVec = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "multicluster_global_hub_coalesced_events_total",
+		Help: "The number of the status events which aren't written since they're identical to an event written " +
+			"in the coalescing window.",
+	},
+	[]string{"table"},
+)
+
+// Coalesced is the number of the identical events coalesced into the written row of the key
+type Coalesced struct {
+	Key   []interface{}
+	Count int
+}
+
+// EventCoalescer drops the status events whose content is identical to an event written in the window, e.g. the
+// events of a flapping policy during a reconcile storm, so each content is written once in the window. The dropped
+// events are counted on the written row once the window is over. The counts are kept in memory, so they're best
+// effort, and the counts of the window which isn't over when the manager restarts are lost
+type EventCoalescer struct {
+	window time.Duration
+	lock   sync.Mutex
+	// the written events of each table in the window, keyed by the hash of the content
+	tables map[string]map[string]*writtenEvent
+	now    func() time.Time
+}
+
+type writtenEvent struct {
+	key       []interface{}
+	writtenAt time.Time
+	coalesced int
+}
+
+// NewEventCoalescer returns the coalescer of the window, it's nil if the window isn't greater than 0
+func NewEventCoalescer(window time.Duration) *EventCoalescer {
+	if window <= 0 {
+		return nil
+	}
+	return &EventCoalescer{
+		window: window,
+		tables: map[string]map[string]*writtenEvent{},
+		now:    time.Now,
+	}
+}
+
+// Hash returns the hash of the content of the event, the fields which change on each occurrence of the event, e.g.
+// the name, the count and the creation time, shouldn't be in the content
+func Hash(content ...interface{}) string {
+	h := sha256.New()
+	for _, field := range content {
+		fmt.Fprintf(h, "%v\x00", field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Filter returns the rows whose contents aren't written in the window, the others are coalesced into the written
+// rows. The identical rows in the same batch are coalesced into the first one. The commit must be called once the
+// returned rows are written, so the later identical rows are coalesced into them. All the rows are returned with a
+// no-op commit if the coalescer is nil
+func Filter[T any](c *EventCoalescer, table string, rows []T, hash func(T) string, key func(T) []interface{}) (
+	[]T, func(),
+) {
+	if c == nil {
+		return rows, func() {}
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	written := c.tables[table]
+	kept := []T{}
+	// the rows kept in the batch, they're recorded as written by the commit
+	pending := map[string]*writtenEvent{}
+	for _, row := range rows {
+		h := hash(row)
+		if event, ok := written[h]; ok && now.Sub(event.writtenAt) < c.window {
+			event.coalesced++
+			CoalescedEventsCounterVec.WithLabelValues(table).Inc()
+			continue
+		}
+		if event, ok := pending[h]; ok {
+			event.coalesced++
+			CoalescedEventsCounterVec.WithLabelValues(table).Inc()
+			continue
+		}
+		pending[h] = &writtenEvent{key: key(row), writtenAt: now}
+		kept = append(kept, row)
+	}
+
+	return kept, func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if c.tables[table] == nil {
+			c.tables[table] = map[string]*writtenEvent{}
+		}
+		for h, event := range pending {
+			// the counts of the replaced event, whose window is over, are returned by the next flush
+			if previous, ok := c.tables[table][h]; ok && previous.coalesced > 0 {
+				c.tables[table][expiredHash(h, previous)] = previous
+			}
+			c.tables[table][h] = event
+		}
+	}
+}
+
+// Flush returns the counts of the written rows of the table whose window is over, and forgets the rows
+func (c *EventCoalescer) Flush(table string) []Coalesced {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	coalesced := []Coalesced{}
+	for h, event := range c.tables[table] {
+		if now.Sub(event.writtenAt) < c.window {
+			continue
+		}
+		if event.coalesced > 0 {
+			coalesced = append(coalesced, Coalesced{Key: event.key, Count: event.coalesced})
+		}
+		delete(c.tables[table], h)
+	}
+	return coalesced
+}
+
+// expiredHash is the key of the expired event which is replaced by a new written event of the same content
+func expiredHash(h string, event *writtenEvent) string {
+	return fmt.Sprintf("%s/%d", h, event.writtenAt.UnixNano())
+}
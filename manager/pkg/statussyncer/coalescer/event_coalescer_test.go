@@ -0,0 +1,71 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package coalescer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEvent struct {
+	name    string
+	message string
+}
+
+func filter(c *EventCoalescer, events ...testEvent) ([]testEvent, func()) {
+	return Filter(c, "event.test", events,
+		func(e testEvent) string { return Hash(e.message) },
+		func(e testEvent) []interface{} { return []interface{}{e.name} })
+}
+
+func TestEventCoalescer(t *testing.T) {
+	now := time.Now()
+	c := NewEventCoalescer(time.Minute)
+	c.now = func() time.Time { return now }
+
+	// the identical events in the same batch are coalesced into the first one
+	kept, commit := filter(c, testEvent{"e1", "non-compliant"}, testEvent{"e2", "non-compliant"},
+		testEvent{"e3", "compliant"})
+	assert.Equal(t, []testEvent{{"e1", "non-compliant"}, {"e3", "compliant"}}, kept)
+	commit()
+
+	// the identical events in the window are coalesced into the written one
+	now = now.Add(30 * time.Second)
+	kept, commit = filter(c, testEvent{"e4", "non-compliant"}, testEvent{"e5", "pending"})
+	assert.Equal(t, []testEvent{{"e5", "pending"}}, kept)
+	commit()
+	assert.Empty(t, c.Flush("event.test"))
+
+	// the events written before the window aren't flushed until their window is over
+	now = now.Add(40 * time.Second)
+	assert.Equal(t, []Coalesced{{Key: []interface{}{"e1"}, Count: 2}}, c.Flush("event.test"))
+	assert.Empty(t, c.Flush("event.test"))
+
+	// the event is written again once the window is over, the counts of the replaced one are kept
+	kept, commit = filter(c, testEvent{"e6", "degraded"}, testEvent{"e7", "degraded"})
+	assert.Equal(t, []testEvent{{"e6", "degraded"}}, kept)
+	commit()
+	now = now.Add(61 * time.Second)
+	kept, commit = filter(c, testEvent{"e8", "degraded"})
+	assert.Equal(t, []testEvent{{"e8", "degraded"}}, kept)
+	commit()
+	assert.Equal(t, []Coalesced{{Key: []interface{}{"e6"}, Count: 1}}, c.Flush("event.test"))
+
+	// the events aren't coalesced if the commit isn't called, e.g. they're failed to write
+	kept, _ = filter(c, testEvent{"e9", "unknown"})
+	assert.Len(t, kept, 1)
+	kept, _ = filter(c, testEvent{"e10", "unknown"})
+	assert.Len(t, kept, 1)
+}
+
+func TestDisabledEventCoalescer(t *testing.T) {
+	c := NewEventCoalescer(0)
+	assert.Nil(t, c)
+	kept, commit := filter(c, testEvent{"e1", "compliant"}, testEvent{"e2", "compliant"})
+	commit()
+	assert.Len(t, kept, 2)
+	assert.Nil(t, c.Flush("event.test"))
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/go-logr/logr"
 	"gorm.io/gorm/clause"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -27,16 +29,34 @@ type ConflationCommitter struct {
 	log                  logr.Logger
 	retrieveMetadataFunc MetadataFunc
 	committedPositions   map[string]int64
+	// the offsets aren't committed while the database is unavailable, so the manager resumes from them
+	databaseAvailable func() bool
+	// commitOffsets commits the offsets to the kafka consumer group instead of the database, it's set when the status
+	// is sharded across the replicas of the manager
+	commitOffsets func([]kafka.TopicPartition) error
+	lock          sync.Mutex
 }
 
-func NewKafkaConflationCommitter(metadataFunc MetadataFunc) *ConflationCommitter {
+func NewKafkaConflationCommitter(metadataFunc MetadataFunc, databaseAvailable func() bool) *ConflationCommitter {
 	return &ConflationCommitter{
 		log:                  ctrl.Log.WithName("kafka-conflation-committer"),
 		retrieveMetadataFunc: metadataFunc,
 		committedPositions:   map[string]int64{},
+		databaseAvailable:    databaseAvailable,
 	}
 }
 
+// NewConsumerGroupCommitter commits the offsets of the bundles which have been written to the database to the kafka
+// consumer group, it's used by the replicas which split the partitions of the status topic
+func NewConsumerGroupCommitter(metadataFunc MetadataFunc, databaseAvailable func() bool,
+	commitOffsets func([]kafka.TopicPartition) error,
+) *ConflationCommitter {
+	committer := NewKafkaConflationCommitter(metadataFunc, databaseAvailable)
+	committer.log = ctrl.Log.WithName("consumer-group-committer")
+	committer.commitOffsets = commitOffsets
+	return committer
+}
+
 func (k *ConflationCommitter) Start(ctx context.Context) error {
 	go func() {
 		ticker := time.NewTicker(time.Second * 5)
@@ -46,7 +66,7 @@ func (k *ConflationCommitter) Start(ctx context.Context) error {
 		for {
 			select {
 			case <-ticker.C: // wait for next time interval
-				err := k.commit()
+				err := k.Commit()
 				if err != nil {
 					k.log.Info("failed to commit offset", "error", err)
 				}
@@ -60,20 +80,65 @@ func (k *ConflationCommitter) Start(ctx context.Context) error {
 	return nil
 }
 
-func (k *ConflationCommitter) commit() error {
+// Commit commits the offsets of the processed bundles, it's called periodically and before the partitions are revoked
+func (k *ConflationCommitter) Commit() error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.databaseAvailable != nil && !k.databaseAvailable() {
+		k.log.V(2).Info("the database is unavailable, skip committing the offsets")
+		return nil
+	}
+
 	// get metadata (both pending and processed)
 	transportMetadatas := k.retrieveMetadataFunc()
 
 	transPositions := metadataToCommit(transportMetadatas)
 
-	databaseTransports := []models.Transport{}
+	positions := map[string]*transport.EventPosition{}
 	for key, transPosition := range transPositions {
 		// skip request if already committed this offset
 		committedOffset, found := k.committedPositions[key]
 		if found && committedOffset >= int64(transPosition.Offset) {
 			continue
 		}
+		positions[key] = transPosition
+	}
+
+	var err error
+	if k.commitOffsets != nil {
+		err = k.commitToConsumerGroup(positions)
+	} else {
+		err = k.commitToDatabase(positions)
+	}
+	if err != nil {
+		return err
+	}
+	// only the offsets which have been persisted are skipped by the next commit
+	for key, transPosition := range positions {
+		k.committedPositions[key] = int64(transPosition.Offset)
+	}
+	return nil
+}
 
+func (k *ConflationCommitter) commitToConsumerGroup(positions map[string]*transport.EventPosition) error {
+	offsets := []kafka.TopicPartition{}
+	for key, transPosition := range positions {
+		k.log.V(2).Info("commit offset to consumer group", "topic@partition", key, "offset", transPosition.Offset)
+		offsets = append(offsets, kafka.TopicPartition{
+			Topic:     &transPosition.Topic,
+			Partition: transPosition.Partition,
+			Offset:    kafka.Offset(transPosition.Offset),
+		})
+	}
+	if len(offsets) == 0 {
+		return nil
+	}
+	return k.commitOffsets(offsets)
+}
+
+func (k *ConflationCommitter) commitToDatabase(positions map[string]*transport.EventPosition) error {
+	databaseTransports := []models.Transport{}
+	for key, transPosition := range positions {
 		k.log.V(2).Info("commit offset to database", "topic@partition", key, "offset", transPosition.Offset)
 		payload, err := json.Marshal(transport.EventPosition{
 			OwnerIdentity: transPosition.OwnerIdentity,
@@ -88,7 +153,6 @@ func (k *ConflationCommitter) commit() error {
 			Name:    transPosition.Topic,
 			Payload: payload,
 		})
-		k.committedPositions[key] = int64(transPosition.Offset)
 	}
 
 	db := database.GetGorm()
@@ -3,6 +3,7 @@ package conflator
 import (
 	"testing"
 
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator/metadata"
@@ -29,6 +30,29 @@ func TestCommitOffset(t *testing.T) {
 	assert.Equal(t, metadatas[positionKey("topic3", 0)].Offset, int64(6))
 }
 
+func TestConsumerGroupCommitter(t *testing.T) {
+	transportMetadatas := getTransportMetadatas("topic1", []int64{1, 2}, []int64{3})
+	available := false
+	committed := []kafka.TopicPartition{}
+	committer := NewConsumerGroupCommitter(func() []ConflationMetadata { return transportMetadatas },
+		func() bool { return available }, func(offsets []kafka.TopicPartition) error {
+			committed = append(committed, offsets...)
+			return nil
+		})
+
+	// the offsets aren't committed while the database is unavailable
+	assert.NoError(t, committer.Commit())
+	assert.Empty(t, committed)
+
+	// the pending bundle is consumed again, and the committed offset isn't committed twice
+	available = true
+	assert.NoError(t, committer.Commit())
+	assert.NoError(t, committer.Commit())
+	assert.Len(t, committed, 1)
+	assert.Equal(t, "topic1", *committed[0].Topic)
+	assert.Equal(t, kafka.Offset(3), committed[0].Offset)
+}
+
 func getTransportMetadatas(topic string, processedOffsets []int64, unprocessedOffsets []int64) []ConflationMetadata {
 	transportMetadatas := make([]ConflationMetadata, len(unprocessedOffsets)+len(processedOffsets))
 	for _, offset := range unprocessedOffsets {
@@ -1,10 +1,14 @@
 package conflator
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/wait"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator/metadata"
@@ -12,6 +16,12 @@ import (
 	"github.com/stolostron/multicluster-global-hub/pkg/transport/consumer"
 )
 
+// AssignedPartitionsGauge is the number of the partitions of the status topic consumed by the replica of the manager
+var AssignedPartitionsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "multicluster_global_hub_manager_assigned_partitions",
+	Help: "The number of the partitions of the status topic assigned to the replica of the manager.",
+})
+
 // ConflationManager implements conflation units management.
 type ConflationManager struct {
 	log             logr.Logger
@@ -88,3 +98,47 @@ func (cm *ConflationManager) getConflationUnit(leafHubName string) *ConflationUn
 func (cm *ConflationManager) GetReadyQueue() *ConflationReadyQueue {
 	return cm.readyQueue
 }
+
+// WaitForPartitions blocks until the bundles received from the partitions, which are "topic@partition" keys, are
+// processed or the timeout expires. It's called before the partitions are revoked from the replica, so their bundles
+// aren't written to the database after the partitions are consumed by another replica. The first check is delayed by
+// the interval, so the events which have been polled but not inserted yet are counted
+func (cm *ConflationManager) WaitForPartitions(ctx context.Context, partitions map[string]bool,
+	timeout time.Duration,
+) error {
+	return wait.PollUntilContextTimeout(ctx, 100*time.Millisecond, timeout, false,
+		func(ctx context.Context) (bool, error) {
+			return pendingBundles(cm.LockedMetadatas(), partitions) == 0, nil
+		})
+}
+
+// LockedMetadatas is the GetMetadatas for the callers which run concurrently with the insertion of the hubs
+func (cm *ConflationManager) LockedMetadatas() []ConflationMetadata {
+	cm.lock.Lock()
+	conflationUnits := make([]*ConflationUnit, 0, len(cm.conflationUnits))
+	for _, cu := range cm.conflationUnits {
+		conflationUnits = append(conflationUnits, cu)
+	}
+	cm.lock.Unlock()
+
+	metadatas := make([]ConflationMetadata, 0)
+	for _, cu := range conflationUnits {
+		metadatas = append(metadatas, cu.getMetadatas()...)
+	}
+	return metadatas
+}
+
+// pendingBundles returns the number of the bundles of the partitions which haven't been processed
+func pendingBundles(metadatas []ConflationMetadata, partitions map[string]bool) int {
+	pending := 0
+	for _, metadata := range metadatas {
+		if metadata == nil || metadata.TransportPosition() == nil || metadata.Processed() {
+			continue
+		}
+		position := metadata.TransportPosition()
+		if partitions[positionKey(position.Topic, position.Partition)] {
+			pending++
+		}
+	}
+	return pending
+}
@@ -0,0 +1,42 @@
+package conflator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator/metadata"
+	"github.com/stolostron/multicluster-global-hub/pkg/statistics"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+func TestPendingBundles(t *testing.T) {
+	transportMetadatas := append(getTransportMetadatas("topic1", []int64{1, 2}, []int64{3, 4}),
+		getTransportMetadatas("topic2", nil, []int64{5})...)
+
+	assert.Equal(t, 2, pendingBundles(transportMetadatas, map[string]bool{positionKey("topic1", 0): true}))
+	assert.Equal(t, 3, pendingBundles(transportMetadatas, map[string]bool{
+		positionKey("topic1", 0): true,
+		positionKey("topic2", 0): true,
+	}))
+	assert.Equal(t, 0, pendingBundles(transportMetadatas, map[string]bool{positionKey("topic1", 1): true}))
+}
+
+func TestWaitForPartitions(t *testing.T) {
+	cm := NewConflationManager(statistics.NewStatistics(&statistics.StatisticsConfig{}))
+	partitions := map[string]bool{positionKey("topic1", 0): true}
+	assert.NoError(t, cm.WaitForPartitions(context.Background(), partitions, time.Second))
+
+	pending := metadata.NewThresholdMetadataFromPosition(3, &transport.EventPosition{Topic: "topic1", Offset: 1})
+	cm.conflationUnits["hub1"] = &ConflationUnit{
+		ElementPriorityQueue: []ConflationElement{&completeElement{metadata: pending}},
+	}
+	assert.Error(t, cm.WaitForPartitions(context.Background(), partitions, 300*time.Millisecond))
+	assert.NoError(t, cm.WaitForPartitions(context.Background(), map[string]bool{positionKey("topic1", 1): true},
+		time.Second))
+
+	time.AfterFunc(200*time.Millisecond, pending.MarkAsProcessed)
+	assert.NoError(t, cm.WaitForPartitions(context.Background(), partitions, 5*time.Second))
+}
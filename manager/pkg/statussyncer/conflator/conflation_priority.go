@@ -15,12 +15,20 @@ const (
 	LocalEventRootPolicyPriority       ConflationPriority = iota
 	LocalReplicatedPolicyEventPriority ConflationPriority = iota
 	LocalPlacementRulesSpecPriority    ConflationPriority = iota
+	ArgoApplicationPriority            ConflationPriority = iota
+	ManagedClusterSetPriority          ConflationPriority = iota
+	ManagedClusterSetBindingPriority   ConflationPriority = iota
+	LocalPlacementSpecPriority         ConflationPriority = iota
+	LocalPlacementDecisionPriority     ConflationPriority = iota
+	AddOnPlacementScorePriority        ConflationPriority = iota
 
 	// enable global resource
 	CompliancePriority         ConflationPriority = iota
 	CompleteCompliancePriority ConflationPriority = iota
 	DeltaCompliancePriority    ConflationPriority = iota
 	MinimalCompliancePriority  ConflationPriority = iota
+	PolicySetPriority          ConflationPriority = iota
+	OperatorPolicyPriority     ConflationPriority = iota
 
 	PlacementRulePriority     ConflationPriority = iota
 	PlacementPriority         ConflationPriority = iota
@@ -28,4 +36,5 @@ const (
 
 	SubscriptionStatusPriority ConflationPriority = iota
 	SubscriptionReportPriority ConflationPriority = iota
+	ArgoApplicationSetPriority ConflationPriority = iota
 )
@@ -14,6 +14,7 @@ import (
 
 // ConflationUnit abstracts the conflation of prioritized multiple bundles with dependencies between them.
 type ConflationUnit struct {
+	name                 string
 	log                  logr.Logger
 	ElementPriorityQueue []ConflationElement
 	eventTypeToPriority  map[string]ConflationPriority
@@ -28,6 +29,7 @@ func newConflationUnit(name string, readyQueue *ConflationReadyQueue,
 	registrations map[string]*ConflationRegistration, statistics *statistics.Statistics,
 ) *ConflationUnit {
 	conflationUnit := &ConflationUnit{
+		name:                 name,
 		log:                  ctrl.Log.WithName(name),
 		ElementPriorityQueue: make([]ConflationElement, len(registrations)),
 		eventTypeToPriority:  make(map[string]ConflationPriority),
@@ -52,6 +54,11 @@ func newConflationUnit(name string, readyQueue *ConflationReadyQueue,
 	return conflationUnit
 }
 
+// Name returns the name of the leaf hub which the conflation unit belongs to.
+func (cu *ConflationUnit) Name() string {
+	return cu.name
+}
+
 // insert is an internal function, new bundles are inserted only via conflation manager.
 func (cu *ConflationUnit) insert(event *cloudevents.Event, eventMetadata ConflationMetadata) {
 	cu.lock.Lock()
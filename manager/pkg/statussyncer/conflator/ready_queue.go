@@ -2,14 +2,16 @@ package conflator
 
 import (
 	"github.com/stolostron/multicluster-global-hub/pkg/statistics"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 )
 
 // NewConflationReadyQueue creates a new instance of ConflationReadyQueue.
 func NewConflationReadyQueue(statistics *statistics.Statistics) *ConflationReadyQueue {
 	return &ConflationReadyQueue{
-		statistics:         statistics,
-		DeltaEventJobChan:  make(chan *ConflationJob, 1000),
-		ConflationUnitChan: make(chan *ConflationUnit, 100),
+		statistics:                    statistics,
+		DeltaEventJobChan:             make(chan *ConflationJob, 1000),
+		HighPriorityDeltaEventJobChan: make(chan *ConflationJob, 1000),
+		ConflationUnitChan:            make(chan *ConflationUnit, 100),
 	}
 }
 
@@ -17,6 +19,17 @@ func NewConflationReadyQueue(statistics *statistics.Statistics) *ConflationReady
 type ConflationReadyQueue struct {
 	statistics *statistics.Statistics
 	// create a Job chan for the detal event
-	DeltaEventJobChan  chan *ConflationJob
-	ConflationUnitChan chan *ConflationUnit
+	DeltaEventJobChan chan *ConflationJob
+	// the delta events with the high priority, e.g. the delta compliance, are dispatched ahead of the bulk events
+	HighPriorityDeltaEventJobChan chan *ConflationJob
+	ConflationUnitChan            chan *ConflationUnit
+}
+
+// addDeltaEventJob adds the job to the lane of its priority
+func (rq *ConflationReadyQueue) addDeltaEventJob(job *ConflationJob) {
+	if transport.GetPriority(job.Event) == transport.PriorityHigh {
+		rq.HighPriorityDeltaEventJobChan <- job
+		return
+	}
+	rq.DeltaEventJobChan <- job
 }
@@ -5,18 +5,20 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
 	"github.com/stolostron/multicluster-global-hub/pkg/database"
 	"github.com/stolostron/multicluster-global-hub/pkg/statistics"
+	"github.com/stolostron/multicluster-global-hub/pkg/tracing"
 )
 
 // NewWorker creates a new instance of DBWorker.
 // jobsQueue is initialized with capacity of 1. this is done in order to make sure dispatcher isn't blocked when calling
 // to RunAsync, otherwise it will yield cpu to other go routines.
 func NewWorker(log logr.Logger, workerID int32, dbWorkersPool chan *Worker,
-	statistics *statistics.Statistics,
+	statistics *statistics.Statistics, lockHubs bool,
 ) *Worker {
 	return &Worker{
 		log:        log,
@@ -24,6 +26,7 @@ func NewWorker(log logr.Logger, workerID int32, dbWorkersPool chan *Worker,
 		workers:    dbWorkersPool,
 		jobsQueue:  make(chan *conflator.ConflationJob, 1),
 		statistics: statistics,
+		lockHubs:   lockHubs,
 	}
 }
 
@@ -34,6 +37,9 @@ type Worker struct {
 	workers    chan *Worker
 	jobsQueue  chan *conflator.ConflationJob
 	statistics *statistics.Statistics
+	// lockHubs takes the advisory lock of the hub before handling its job, the hubs are moved between the replicas
+	// of the manager when the partitions of the status topic are rebalanced
+	lockHubs bool
 }
 
 // RunAsync runs DBJob and reports status to the given CU. once the job processing is finished worker returns to the
@@ -75,10 +81,24 @@ func (worker *Worker) handleJob(ctx context.Context, job *conflator.ConflationJo
 		return
 	}
 
+	if worker.lockHubs {
+		unlock, err := database.LockHub(ctx, job.Event.Source())
+		if err != nil {
+			worker.log.Error(err, "failed to lock the hub", "LF", job.Event.Source(), "WorkerID", worker.workerID)
+			job.Metadata.MarkAsUnprocessed()
+			job.Reporter.ReportResult(job.Metadata, err)
+			return
+		}
+		defer unlock()
+	}
+
+	// the span covers the retries of the handler, the handler receives it in the context to add its own spans
+	_, span := tracing.StartCommitSpan(ctx, job.Event)
+
 	// handle the event until it's metadata is marked as processed
 	err = wait.PollUntilContextTimeout(ctx, 2*time.Second, 5*time.Minute, true,
 		func(ctx context.Context) (bool, error) {
-			err = job.Handle(ctx, job.Event) // db connection released to pool when done
+			err = job.Handle(trace.ContextWithSpan(ctx, span), job.Event) // db connection released to pool when done
 			if err != nil {
 				job.Metadata.MarkAsUnprocessed()
 				worker.log.Error(err, "failed to handle event", "type", job.Event.Type())
@@ -93,6 +113,7 @@ func (worker *Worker) handleJob(ctx context.Context, job *conflator.ConflationJo
 			return job.Metadata.Processed(), err
 		})
 
+	tracing.EndSpan(span, err)
 	worker.statistics.AddDatabaseMetrics(job.Event, time.Since(startTime), err)
 
 	job.Reporter.ReportResult(job.Metadata, err)
@@ -17,13 +17,16 @@ type DBWorkerPool struct {
 	log        logr.Logger
 	statistics *statistics.Statistics
 	workers    chan *Worker // A pool of workers that are registered within the workers pool
+	lockHubs   bool
 }
 
-// NewDBWorkerPool returns a new db workers pool dispatcher.
-func NewDBWorkerPool(statistics *statistics.Statistics) (*DBWorkerPool, error) {
+// NewDBWorkerPool returns a new db workers pool dispatcher. The workers take the advisory locks of the hubs if
+// lockHubs is true, it's required when the status is sharded across the replicas of the manager.
+func NewDBWorkerPool(statistics *statistics.Statistics, lockHubs bool) (*DBWorkerPool, error) {
 	return &DBWorkerPool{
 		log:        ctrl.Log.WithName("worker-pool"),
 		statistics: statistics,
+		lockHubs:   lockHubs,
 	}, nil
 }
 
@@ -41,6 +44,13 @@ func (pool *DBWorkerPool) Start(ctx context.Context) error {
 	if workSize < 5 {
 		workSize = 5
 	}
+	// each worker holds a connection for the lock of the hub, so the other half is left for the handlers
+	if pool.lockHubs {
+		workSize = stats.MaxOpenConnections / 2
+		if workSize < 1 {
+			workSize = 1
+		}
+	}
 
 	// initialize workers pool
 	pool.workers = make(chan *Worker, workSize)
@@ -48,7 +58,7 @@ func (pool *DBWorkerPool) Start(ctx context.Context) error {
 	// start workers and register them within the workers pool
 	var i int32
 	for i = 1; i <= int32(workSize); i++ {
-		worker := NewWorker(pool.log, i, pool.workers, pool.statistics)
+		worker := NewWorker(pool.log, i, pool.workers, pool.statistics, pool.lockHubs)
 		go worker.start(ctx) // each worker adds itself to the pool inside start function
 	}
 
@@ -10,27 +10,35 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/dbhealth"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator/workerpool"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/throttle"
 	"github.com/stolostron/multicluster-global-hub/pkg/statistics"
 )
 
 // NewConflationDispatcher creates a new instance of Dispatcher.
 func NewConflationDispatcher(log logr.Logger, conflationReadyQueue *conflator.ConflationReadyQueue,
-	dbWorkerPool *workerpool.DBWorkerPool,
+	dbWorkerPool *workerpool.DBWorkerPool, throttler *throttle.HubThrottler, monitor *dbhealth.DatabaseMonitor,
 ) *ConflationDispatcher {
 	return &ConflationDispatcher{
 		log:                  log,
 		conflationReadyQueue: conflationReadyQueue,
 		dbWorkerPool:         dbWorkerPool,
+		throttler:            throttler,
+		monitor:              monitor,
+		parkedHubs:           map[string]*parkedHub{},
+		resumeChan:           make(chan string),
 	}
 }
 
 func AddConflationDispatcher(mgr ctrl.Manager, conflationManager *conflator.ConflationManager,
-	managerConfig *config.ManagerConfig, stats *statistics.Statistics,
+	managerConfig *config.ManagerConfig, stats *statistics.Statistics, throttler *throttle.HubThrottler,
+	monitor *dbhealth.DatabaseMonitor,
 ) error {
 	// add work pool: database layer initialization - worker pool + connection pool
-	dbWorkerPool, err := workerpool.NewDBWorkerPool(stats)
+	// the replicas lock the hubs, so the bundles of a hub aren't written by two replicas while it's rebalanced
+	dbWorkerPool, err := workerpool.NewDBWorkerPool(stats, managerConfig.StatusShardingEnabled())
 	if err != nil {
 		return fmt.Errorf("failed to initialize DBWorkerPool: %w", err)
 	}
@@ -39,11 +47,8 @@ func AddConflationDispatcher(mgr ctrl.Manager, conflationManager *conflator.Conf
 	}
 
 	// conflation dispatcher -> work pool
-	conflationDispatcher := &ConflationDispatcher{
-		log:                  ctrl.Log.WithName("conflation-dispatcher"),
-		conflationReadyQueue: conflationManager.GetReadyQueue(),
-		dbWorkerPool:         dbWorkerPool,
-	}
+	conflationDispatcher := NewConflationDispatcher(ctrl.Log.WithName("conflation-dispatcher"),
+		conflationManager.GetReadyQueue(), dbWorkerPool, throttler, monitor)
 	if err := mgr.Add(conflationDispatcher); err != nil {
 		return fmt.Errorf("failed to add conflation dispatcher: %w", err)
 	}
@@ -56,6 +61,20 @@ type ConflationDispatcher struct {
 	log                  logr.Logger
 	conflationReadyQueue *conflator.ConflationReadyQueue
 	dbWorkerPool         *workerpool.DBWorkerPool
+	throttler            *throttle.HubThrottler
+	monitor              *dbhealth.DatabaseMonitor
+	// the jobs of the parked hubs, they're dispatched once the hub is resumed. only accessed by the dispatch loop
+	parkedHubs map[string]*parkedHub
+	resumeChan chan string
+}
+
+// the parked hub keeps its delta events until it's resumed. If the hub has its own status topic, the partition is paused
+// by the transport dispatcher, so it only keeps the delta events received before the partition is paused. Otherwise,
+// the shared partition is still consumed, and the delta events of the hub are bounded by its kafka quota and the
+// duration of the parking
+type parkedHub struct {
+	conflationUnit *conflator.ConflationUnit
+	deltaEventJobs []*conflator.ConflationJob
 }
 
 // Start starts the dispatcher.
@@ -71,27 +90,110 @@ func (dispatcher *ConflationDispatcher) Start(ctx context.Context) error {
 }
 
 func (dispatcher *ConflationDispatcher) dispatch(ctx context.Context) {
+	readyQueue := dispatcher.conflationReadyQueue
 	for {
+		// the conflation units and the high priority delta events go first. the complete bundles are conflated, so
+		// they can't starve the bulk events, and the health of the hubs is visible during an ingestion storm
 		select {
 		case <-ctx.Done(): // if dispatcher was stopped do not process more bundles
 			return
+		case deltaEventJob := <-readyQueue.HighPriorityDeltaEventJobChan:
+			dispatcher.dispatchDeltaEventJob(ctx, deltaEventJob)
+			continue
+		case conflationUnit := <-readyQueue.ConflationUnitChan:
+			dispatcher.dispatchConflationUnit(ctx, conflationUnit)
+			continue
+		default:
+		}
 
-		case deltaEventJob := <-dispatcher.conflationReadyQueue.DeltaEventJobChan:
-			worker := dispatcher.getBlockingWorker(ctx)
-			worker.RunAsync(deltaEventJob)
-		case conflationUnit := <-dispatcher.conflationReadyQueue.ConflationUnitChan:
-			eventJob, err := conflationUnit.GetNext()
-			if err != nil {
-				dispatcher.log.Info(err.Error()) // don't need to throw the error when bundle is not ready
+		select {
+		case <-ctx.Done():
+			return
+		case deltaEventJob := <-readyQueue.HighPriorityDeltaEventJobChan:
+			dispatcher.dispatchDeltaEventJob(ctx, deltaEventJob)
+		case conflationUnit := <-readyQueue.ConflationUnitChan:
+			dispatcher.dispatchConflationUnit(ctx, conflationUnit)
+		case deltaEventJob := <-readyQueue.DeltaEventJobChan:
+			dispatcher.dispatchDeltaEventJob(ctx, deltaEventJob)
+		case hubName := <-dispatcher.resumeChan:
+			parked := dispatcher.parkedHubs[hubName]
+			delete(dispatcher.parkedHubs, hubName)
+			if dispatcher.park(ctx, hubName) != nil {
+				// the hub is parked again, keep the jobs in the order they are received
+				dispatcher.parkedHubs[hubName] = parked
 				continue
 			}
-			worker := dispatcher.getBlockingWorker(ctx)
-			worker.RunAsync(eventJob)
+			dispatcher.log.Info("resume the parked hub", "hub", hubName, "deltaEventJobs", len(parked.deltaEventJobs))
+			for _, job := range parked.deltaEventJobs {
+				dispatcher.runDeltaEventJob(ctx, job)
+			}
+			if parked.conflationUnit != nil {
+				dispatcher.runConflationUnit(ctx, parked.conflationUnit)
+			}
+		}
+	}
+}
+
+func (dispatcher *ConflationDispatcher) dispatchDeltaEventJob(ctx context.Context, job *conflator.ConflationJob) {
+	if parked := dispatcher.park(ctx, job.Event.Source()); parked != nil {
+		parked.deltaEventJobs = append(parked.deltaEventJobs, job)
+		return
+	}
+	dispatcher.runDeltaEventJob(ctx, job)
+}
+
+func (dispatcher *ConflationDispatcher) dispatchConflationUnit(ctx context.Context,
+	conflationUnit *conflator.ConflationUnit,
+) {
+	if parked := dispatcher.park(ctx, conflationUnit.Name()); parked != nil {
+		// the unit keeps conflating the bundles of the hub while it's parked
+		parked.conflationUnit = conflationUnit
+		return
+	}
+	dispatcher.runConflationUnit(ctx, conflationUnit)
+}
+
+// park returns the parked hub if the hub is parked by the throttler, and schedules to resume it once it expires
+func (dispatcher *ConflationDispatcher) park(ctx context.Context, hubName string) *parkedHub {
+	if parked, ok := dispatcher.parkedHubs[hubName]; ok {
+		return parked
+	}
+	parkedUntil := dispatcher.throttler.ParkedUntil(hubName)
+	if parkedUntil.IsZero() {
+		return nil
+	}
+	parked := &parkedHub{}
+	dispatcher.parkedHubs[hubName] = parked
+	time.AfterFunc(time.Until(parkedUntil), func() {
+		select {
+		case <-ctx.Done():
+		case dispatcher.resumeChan <- hubName:
 		}
+	})
+	return parked
+}
+
+func (dispatcher *ConflationDispatcher) runDeltaEventJob(ctx context.Context, job *conflator.ConflationJob) {
+	worker := dispatcher.getBlockingWorker(ctx)
+	worker.RunAsync(job)
+}
+
+func (dispatcher *ConflationDispatcher) runConflationUnit(ctx context.Context,
+	conflationUnit *conflator.ConflationUnit,
+) {
+	eventJob, err := conflationUnit.GetNext()
+	if err != nil {
+		dispatcher.log.Info(err.Error()) // don't need to throw the error when bundle is not ready
+		return
 	}
+	worker := dispatcher.getBlockingWorker(ctx)
+	worker.RunAsync(eventJob)
 }
 
 func (dispatcher *ConflationDispatcher) getBlockingWorker(ctx context.Context) (worker *workerpool.Worker) {
+	// hold the jobs while the database is unavailable, the ready queue and then the transport consumer are blocked,
+	// so the events are kept in the transport instead of failing the jobs
+	dispatcher.monitor.WaitAvailable(ctx)
 	_ = wait.PollUntilContextCancel(ctx, 10*time.Second, true, func(ctx context.Context) (done bool, err error) {
 		worker, err = dispatcher.dbWorkerPool.Acquire()
 		if err != nil {
@@ -3,33 +3,80 @@ package dispatcher
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	kafka_confluent "github.com/cloudevents/sdk-go/protocol/kafka_confluent/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/dbhealth"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/hubmanagement"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/throttle"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/transporthealth"
 	"github.com/stolostron/multicluster-global-hub/pkg/statistics"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 	genericconsumer "github.com/stolostron/multicluster-global-hub/pkg/transport/consumer"
 )
 
+// revokeTimeout is the maximum time of waiting for the bundles of the revoked partitions
+const revokeTimeout = 30 * time.Second
+
 // Get message from transport, convert it to bundle and forward it to conflation manager.
 type TransportDispatcher struct {
 	log               logr.Logger
 	consumer          transport.Consumer
 	conflationManager *conflator.ConflationManager
 	statistic         *statistics.Statistics
+	throttler         *throttle.HubThrottler
+	negotiator        *hubmanagement.FormatNegotiator
+	probe             *transporthealth.TransportProbe
+	// commits the offsets to the consumer group when the status is sharded
+	committer *conflator.ConflationCommitter
+	// pauses the kafka partitions of the parked hubs, it's nil if the transport isn't kafka or the status topic is
+	// shared by the hubs
+	pauser partitionPauser
+}
+
+type partitionPauser interface {
+	PausePartitions(partitions []kafka.TopicPartition) error
+	ResumePartitions(partitions []kafka.TopicPartition) error
 }
 
 func AddTransportDispatcher(mgr ctrl.Manager, managerConfig *config.ManagerConfig,
-	conflationManager *conflator.ConflationManager, stats *statistics.Statistics,
+	conflationManager *conflator.ConflationManager, stats *statistics.Statistics, throttler *throttle.HubThrottler,
+	negotiator *hubmanagement.FormatNegotiator, probe *transporthealth.TransportProbe,
+	monitor *dbhealth.DatabaseMonitor,
 ) error {
+	transportDispatcher := &TransportDispatcher{
+		log:               ctrl.Log.WithName("conflation-dispatcher"),
+		conflationManager: conflationManager,
+		statistic:         stats,
+		throttler:         throttler,
+		negotiator:        negotiator,
+		probe:             probe,
+	}
+
+	// the replicas split the partitions by the consumer group, so the offsets are committed to kafka by each replica
+	// instead of the database. otherwise the leader consumes all the partitions from the offsets of the database
+	sharded := managerConfig.StatusShardingEnabled()
+	consumeOptions := []genericconsumer.GenericConsumeOption{genericconsumer.EnableDatabaseOffset(true)}
+	if sharded {
+		consumeOptions = []genericconsumer.GenericConsumeOption{
+			genericconsumer.WithRebalanceCallback(transportDispatcher.rebalance),
+			genericconsumer.EnableManualCommit(),
+		}
+	}
+
 	// start a consumer
 	topics := managerConfig.TransportConfig.KafkaConfig.Topics
 	consumer, err := genericconsumer.NewGenericConsumer(managerConfig.TransportConfig,
-		[]string{topics.StatusTopic},
-		genericconsumer.EnableDatabaseOffset(true))
+		[]string{topics.StatusTopic}, consumeOptions...)
 	if err != nil {
 		return fmt.Errorf("failed to initialize transport consumer: %w", err)
 	}
@@ -37,11 +84,19 @@ func AddTransportDispatcher(mgr ctrl.Manager, managerConfig *config.ManagerConfi
 		return fmt.Errorf("failed to add transport consumer to manager: %w", err)
 	}
 
-	transportDispatcher := &TransportDispatcher{
-		log:               ctrl.Log.WithName("conflation-dispatcher"),
-		consumer:          consumer,
-		conflationManager: conflationManager,
-		statistic:         stats,
+	// the offsets are committed once the bundles are written to the database, and they aren't committed while the
+	// database is unavailable, so the bundles are consumed again once the database returns
+	if sharded {
+		transportDispatcher.committer = conflator.NewConsumerGroupCommitter(conflationManager.LockedMetadatas,
+			monitor.Available, consumer.CommitOffsets)
+		if err := mgr.Add(transportDispatcher.committer); err != nil {
+			return fmt.Errorf("failed to start the offset committer: %w", err)
+		}
+	}
+
+	transportDispatcher.consumer = consumer
+	if managerConfig.TransportConfig.TransportType == string(transport.Kafka) && perHubStatusTopic(topics.StatusTopic) {
+		transportDispatcher.pauser = consumer
 	}
 	if err := mgr.Add(transportDispatcher); err != nil {
 		return fmt.Errorf("failed to add transport dispatcher to runtime manager: %w", err)
@@ -49,6 +104,43 @@ func AddTransportDispatcher(mgr ctrl.Manager, managerConfig *config.ManagerConfi
 	return nil
 }
 
+// perHubStatusTopic returns true if each hub reports to its own status topic, the manager subscribes them by the regex,
+// e.g. "^gh-status.*"
+func perHubStatusTopic(statusTopic string) bool {
+	return strings.HasPrefix(statusTopic, "^")
+}
+
+// rebalance waits for the bundles of the revoked partitions before they're consumed by another replica, so the
+// bundles of a hub aren't written by two replicas out of order. The wait is shorter than the max poll interval,
+// otherwise the replica is removed from the consumer group
+func (d *TransportDispatcher) rebalance(c *kafka.Consumer, event kafka.Event) error {
+	switch e := event.(type) {
+	case kafka.AssignedPartitions:
+		d.log.Info("assigned the partitions of the status topic", "partitions", e.Partitions)
+		conflator.AssignedPartitionsGauge.Add(float64(len(e.Partitions)))
+	case kafka.RevokedPartitions:
+		d.log.Info("revoking the partitions of the status topic", "partitions", e.Partitions)
+		partitions := map[string]bool{}
+		for _, tp := range e.Partitions {
+			if tp.Topic != nil {
+				partitions[fmt.Sprintf("%s%s%d", *tp.Topic, conflator.KafkaPartitionDelimiter, tp.Partition)] = true
+			}
+		}
+		if err := d.conflationManager.WaitForPartitions(context.Background(), partitions,
+			revokeTimeout); err != nil {
+			d.log.Info("the bundles of the revoked partitions aren't processed in time, they're kept in order by the "+
+				"locks of the hubs", "partitions", e.Partitions, "error", err)
+		}
+		// commit the offsets of the processed bundles, so the next replica resumes from them
+		if err := d.committer.Commit(); err != nil {
+			d.log.Info("failed to commit the offsets of the revoked partitions", "partitions", e.Partitions,
+				"error", err)
+		}
+		conflator.AssignedPartitionsGauge.Sub(float64(len(e.Partitions)))
+	}
+	return nil
+}
+
 // Start function starts bundles status syncer.
 func (d *TransportDispatcher) Start(ctx context.Context) error {
 	d.log.Info("transport dispatcher starts dispatching received events...")
@@ -67,9 +159,67 @@ func (d *TransportDispatcher) dispatch(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case evt := <-d.consumer.EventChan():
+			if d.probe.Observe(evt) {
+				continue
+			}
 			d.statistic.ReceivedEvent(evt)
+			if !d.negotiator.Observe(evt) {
+				continue
+			}
+			if d.throttler.Record(evt.Source()) {
+				d.pausePartition(ctx, evt)
+			}
 			d.log.V(2).Info("forward received event to conflation", "event type", evt.Type())
 			d.conflationManager.Insert(evt)
 		}
 	}
 }
+
+// pausePartition pauses the kafka partition of the parked hub, so the events of the hub are kept in kafka instead of
+// the memory of the manager until the hub is resumed. It's only paused if the hub has its own status topic, the
+// partitions of the shared status topic are consumed, and the events of the parked hub are kept by the conflation
+// dispatcher, so the other hubs aren't stalled
+func (d *TransportDispatcher) pausePartition(ctx context.Context, evt *cloudevents.Event) {
+	if d.pauser == nil {
+		return
+	}
+	hubName := evt.Source()
+	topic, err := types.ToString(evt.Extensions()[kafka_confluent.KafkaTopicKey])
+	if err != nil {
+		d.log.Info("failed to get the topic of the parked hub", "hub", hubName, "error", err)
+		return
+	}
+	partition, err := types.ToInteger(evt.Extensions()[kafka_confluent.KafkaPartitionKey])
+	if err != nil {
+		d.log.Info("failed to get the partition of the parked hub", "hub", hubName, "error", err)
+		return
+	}
+	partitions := []kafka.TopicPartition{{Topic: &topic, Partition: partition}}
+	if err := d.pauser.PausePartitions(partitions); err != nil {
+		d.log.Info("failed to pause the partition of the parked hub", "hub", hubName, "partitions", partitions,
+			"error", err)
+		return
+	}
+	d.log.Info("paused the partition of the parked hub", "hub", hubName, "partitions", partitions)
+	d.resumePartition(ctx, hubName, partitions)
+}
+
+// resumePartition resumes the paused partition once the parking of the hub expires
+func (d *TransportDispatcher) resumePartition(ctx context.Context, hubName string, partitions []kafka.TopicPartition) {
+	time.AfterFunc(time.Until(d.throttler.ParkedUntil(hubName)), func() {
+		if ctx.Err() != nil {
+			return
+		}
+		// the hub might be parked again by the events received before the partition is paused
+		if !d.throttler.ParkedUntil(hubName).IsZero() {
+			d.resumePartition(ctx, hubName, partitions)
+			return
+		}
+		if err := d.pauser.ResumePartitions(partitions); err != nil {
+			d.log.Info("failed to resume the partition of the parked hub", "hub", hubName, "partitions", partitions,
+				"error", err)
+			return
+		}
+		d.log.Info("resumed the partition of the parked hub", "hub", hubName, "partitions", partitions)
+	})
+}
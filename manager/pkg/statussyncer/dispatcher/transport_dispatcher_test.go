@@ -0,0 +1,83 @@
+package dispatcher
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	kafka_confluent "github.com/cloudevents/sdk-go/protocol/kafka_confluent/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/throttle"
+)
+
+type fakePauser struct {
+	lock   sync.Mutex
+	paused map[int32]bool
+}
+
+func (p *fakePauser) PausePartitions(partitions []kafka.TopicPartition) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, tp := range partitions {
+		p.paused[tp.Partition] = true
+	}
+	return nil
+}
+
+func (p *fakePauser) ResumePartitions(partitions []kafka.TopicPartition) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, tp := range partitions {
+		delete(p.paused, tp.Partition)
+	}
+	return nil
+}
+
+func (p *fakePauser) isPaused(partition int32) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.paused[partition]
+}
+
+func TestPausePartitionOfParkedHub(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	throttler := throttle.NewHubThrottler(&throttle.ThrottleConfig{
+		EventRateLimit: 1,
+		EventBurst:     1,
+		ParkThreshold:  1,
+		ParkDuration:   100 * time.Millisecond,
+	})
+	pauser := &fakePauser{paused: map[int32]bool{}}
+	d := &TransportDispatcher{
+		log:       ctrl.Log.WithName("transport-dispatcher-test"),
+		throttler: throttler,
+		pauser:    pauser,
+	}
+
+	evt := cloudevents.NewEvent()
+	evt.SetSource("hub1")
+	evt.SetExtension(kafka_confluent.KafkaTopicKey, "gh-status.hub1")
+	evt.SetExtension(kafka_confluent.KafkaPartitionKey, 2)
+
+	// the first event is allowed by the burst, the second one parks the hub
+	assert.False(t, throttler.Record("hub1"))
+	assert.True(t, throttler.Record("hub1"))
+	d.pausePartition(ctx, &evt)
+	assert.True(t, pauser.isPaused(2))
+
+	// the partition is resumed once the parking expires
+	assert.Eventually(t, func() bool { return !pauser.isPaused(2) }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestPerHubStatusTopic(t *testing.T) {
+	// the partitions of the shared status topic aren't paused, since they're shared by the hubs
+	assert.False(t, perHubStatusTopic("gh-status"))
+	assert.True(t, perHubStatusTopic("^gh-status.*"))
+}
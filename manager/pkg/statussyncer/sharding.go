@@ -0,0 +1,25 @@
+package statussyncer
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// replicaManager adds the runnables to all the replicas of the manager instead of the leader, it's used when the
+// partitions of the status topic are split across the replicas, so each replica handles the bundles of its hubs
+type replicaManager struct {
+	ctrl.Manager
+}
+
+func (m *replicaManager) Add(runnable manager.Runnable) error {
+	return m.Manager.Add(&replicaRunnable{runnable})
+}
+
+type replicaRunnable struct {
+	manager.Runnable
+}
+
+// NeedLeaderElection implements the LeaderElectionRunnable interface, the runnable is started by all the replicas
+func (r *replicaRunnable) NeedLeaderElection() bool {
+	return false
+}
@@ -6,15 +6,37 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/dbhealth"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/hubmanagement"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/notification"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/coalescer"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/dispatcher"
 	dbsyncer "github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/syncers"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/throttle"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/transporthealth"
 	"github.com/stolostron/multicluster-global-hub/pkg/statistics"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport/producer"
 )
 
 // AddStatusSyncers performs the initial setup required before starting the runtime manager.
 // adds controllers and/or runnables to the manager, registers handler to conflation manager
 func AddStatusSyncers(mgr ctrl.Manager, managerConfig *config.ManagerConfig) error {
+	// the lifecycle events are streamed from the database, so it's only run by the leader
+	if _, err := notification.AddLifecycleStreamer(mgr, managerConfig.TransportConfig,
+		managerConfig.LifecycleStreamConfig); err != nil {
+		return err
+	}
+
+	// all the replicas receive the status of the hubs of their partitions when the status is sharded. The runnables
+	// which update the shared resources, e.g. the conditions, are still added to the leaderMgr
+	leaderMgr := mgr
+	sharded := managerConfig.StatusShardingEnabled()
+	if sharded {
+		mgr = &replicaManager{mgr}
+	}
+
 	// create statistics
 	stats := statistics.NewStatistics(managerConfig.StatisticsConfig)
 	if err := mgr.Add(stats); err != nil {
@@ -23,42 +45,150 @@ func AddStatusSyncers(mgr ctrl.Manager, managerConfig *config.ManagerConfig) err
 
 	// manage all Conflation Units and handlers
 	conflationManager := conflator.NewConflationManager(stats)
-	registerHandler(conflationManager, managerConfig.EnableGlobalResource)
+
+	// the notifiers and the exporters are run by all the replicas, since the events are queued by the replica which
+	// handles the hub. Each of them only reads the endpoints from its secret
+	// post the events to the webhooks when the policies become non-compliant on the clusters
+	webhookNotifier, err := notification.AddWebhookNotifier(mgr, managerConfig.ManagerNamespace,
+		managerConfig.WebhookConfig)
+	if err != nil {
+		return err
+	}
+	// open the ServiceNow incidents when the configured policies become non-compliant on the production clusters
+	serviceNowNotifier, err := notification.AddServiceNowNotifier(mgr, managerConfig.ManagerNamespace,
+		managerConfig.ServiceNowConfig)
+	if err != nil {
+		return err
+	}
+	// post the messages to the Slack and the Microsoft Teams channels by the severities of the policies
+	chatNotifier, err := notification.AddChatNotifier(mgr, managerConfig.ManagerNamespace,
+		managerConfig.ChatConfig)
+	if err != nil {
+		return err
+	}
+	notifier := notification.Notifiers{webhookNotifier, serviceNowNotifier, chatNotifier}
+	// forward the selected events to the CloudEvents sink
+	sinkExporter, err := notification.AddSinkExporter(mgr, managerConfig.SinkConfig)
+	if err != nil {
+		return err
+	}
+	// stream the policy violations and the cluster events to the SIEM collector
+	siemExporter, err := notification.AddSIEMExporter(mgr, managerConfig.ManagerNamespace,
+		managerConfig.SIEMConfig)
+	if err != nil {
+		return err
+	}
+	exporter := notification.Exporters{sinkExporter, siemExporter}
+
+	// coalesce the identical events of the reconcile storms, e.g. a flapping policy
+	var eventCoalescer *coalescer.EventCoalescer
+	if managerConfig.SyncerConfig != nil {
+		eventCoalescer = coalescer.NewEventCoalescer(managerConfig.SyncerConfig.EventCoalesceWindow)
+	}
+	registerHandler(conflationManager, managerConfig.EnableGlobalResource, notifier, exporter, eventCoalescer)
+
+	// limit the ingestion rate of each hub, so a noisy hub can't starve the processing of the others
+	throttler := throttle.NewHubThrottler(managerConfig.ThrottleConfig)
+
+	// skip the bundles of the unsupported format versions, and report the version skew of the hubs
+	negotiator := hubmanagement.NewFormatNegotiator(mgr.GetClient())
+	if err := leaderMgr.Add(negotiator); err != nil {
+		return fmt.Errorf("failed to add the format negotiator: %w", err)
+	}
+
+	// pause the ingestion and the offset commits while the database is unavailable
+	monitor := dbhealth.NewDatabaseMonitor(mgr.GetClient(), managerConfig.ManagerNamespace,
+		managerConfig.DatabaseConfig.ProbeInterval)
+	if err := leaderMgr.Add(monitor); err != nil {
+		return fmt.Errorf("failed to add the database monitor: %w", err)
+	}
+	// the other replicas only probe the database, the degraded condition is updated by the leader
+	if sharded {
+		if err := mgr.Add(monitor.ProbeUntilElected(leaderMgr.Elected())); err != nil {
+			return fmt.Errorf("failed to add the database probe of the replica: %w", err)
+		}
+	}
+
+	// measure the round-trip latency of the kafka transport. it's disabled when the status is sharded, the probe
+	// message might be consumed by another replica
+	var probe *transporthealth.TransportProbe
+	if !sharded {
+		probe, err = addTransportProbe(mgr, managerConfig)
+		if err != nil {
+			return err
+		}
+	}
 
 	// start consume message from transport to conflation manager
-	if err := dispatcher.AddTransportDispatcher(mgr, managerConfig, conflationManager, stats); err != nil {
+	if err := dispatcher.AddTransportDispatcher(mgr, managerConfig, conflationManager, stats, throttler,
+		negotiator, probe, monitor); err != nil {
 		return err
 	}
 
 	// start persist event from conflation manager to database with registered handlers
-	if err := dispatcher.AddConflationDispatcher(mgr, conflationManager, managerConfig, stats); err != nil {
+	if err := dispatcher.AddConflationDispatcher(mgr, conflationManager, managerConfig, stats,
+		throttler, monitor); err != nil {
 		return err
 	}
 
+	// the offsets are committed to the consumer group by each replica when the status is sharded
+	if sharded {
+		return nil
+	}
 	// add kafka offset to the database periodically
-	committer := conflator.NewKafkaConflationCommitter(conflationManager.GetMetadatas)
+	committer := conflator.NewKafkaConflationCommitter(conflationManager.GetMetadatas, monitor.Available)
 	if err := mgr.Add(committer); err != nil {
 		return fmt.Errorf("failed to start the offset committer: %w", err)
 	}
 	return nil
 }
 
-func registerHandler(cmr *conflator.ConflationManager, enableGlobalResource bool) {
+// addTransportProbe adds the probe of the status topic, it's nil if the probe topic isn't set
+func addTransportProbe(mgr ctrl.Manager, managerConfig *config.ManagerConfig) (*transporthealth.TransportProbe, error) {
+	probeConfig := managerConfig.TransportProbeConfig
+	if probeConfig == nil || probeConfig.Topic == "" ||
+		managerConfig.TransportConfig.TransportType != string(transport.Kafka) {
+		return nil, nil
+	}
+	probeProducer, err := producer.NewGenericProducer(managerConfig.TransportConfig, probeConfig.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the producer of the transport probe: %w", err)
+	}
+	probe := transporthealth.NewTransportProbe(mgr.GetClient(), managerConfig.ManagerNamespace, probeConfig,
+		probeProducer)
+	if err := mgr.Add(probe); err != nil {
+		return nil, fmt.Errorf("failed to add the transport probe: %w", err)
+	}
+	return probe, nil
+}
+
+func registerHandler(cmr *conflator.ConflationManager, enableGlobalResource bool,
+	notifier notification.Notifier, exporter notification.Exporter,
+	eventCoalescer *coalescer.EventCoalescer,
+) {
 	dbsyncer.NewHubClusterHeartbeatHandler().RegisterHandler(cmr)
 	dbsyncer.NewHubClusterInfoHandler().RegisterHandler(cmr)
 	dbsyncer.NewManagedClusterHandler().RegisterHandler(cmr)
-	dbsyncer.NewManagedClusterEventHandler().RegisterHandler(cmr)
+	dbsyncer.NewManagedClusterEventHandler(eventCoalescer, exporter).RegisterHandler(cmr)
 	dbsyncer.NewLocalPolicySpecHandler().RegisterHandler(cmr)
-	dbsyncer.NewLocalPolicyComplianceHandler().RegisterHandler(cmr)
-	dbsyncer.NewLocalPolicyCompleteHandler().RegisterHandler(cmr)
-	dbsyncer.NewLocalRootPolicyEventHandler().RegisterHandler(cmr)
-	dbsyncer.NewLocalReplicatedPolicyEventHandler().RegisterHandler(cmr)
+	dbsyncer.NewLocalPolicyComplianceHandler(notifier, exporter).RegisterHandler(cmr)
+	dbsyncer.NewLocalPolicyCompleteHandler(notifier, exporter).RegisterHandler(cmr)
+	dbsyncer.NewLocalRootPolicyEventHandler(eventCoalescer, exporter).RegisterHandler(cmr)
+	dbsyncer.NewLocalReplicatedPolicyEventHandler(eventCoalescer, exporter).RegisterHandler(cmr)
 	dbsyncer.NewLocalPlacementRuleSpecHandler().RegisterHandler(cmr)
+	dbsyncer.NewArgoApplicationHandler().RegisterHandler(cmr)
+	dbsyncer.NewManagedClusterSetHandler().RegisterHandler(cmr)
+	dbsyncer.NewManagedClusterSetBindingHandler().RegisterHandler(cmr)
+	dbsyncer.NewLocalPlacementSpecHandler().RegisterHandler(cmr)
+	dbsyncer.NewLocalPlacementDecisionHandler().RegisterHandler(cmr)
+	dbsyncer.NewAddOnPlacementScoreHandler().RegisterHandler(cmr)
 	if enableGlobalResource {
 		dbsyncer.NewPolicyComplianceHandler().RegisterHandler(cmr)
 		dbsyncer.NewPolicyCompleteHandler().RegisterHandler(cmr)
 		dbsyncer.NewPolicyDeltaComplianceHandler().RegisterHandler(cmr)
 		dbsyncer.NewPolicyMiniComplianceHandler().RegisterHandler(cmr)
+		dbsyncer.NewPolicySetHandler().RegisterHandler(cmr)
+		dbsyncer.NewOperatorPolicyHandler().RegisterHandler(cmr)
 
 		dbsyncer.NewPlacementRuleHandler().RegisterHandler(cmr)
 		dbsyncer.NewPlacementHandler().RegisterHandler(cmr)
@@ -66,5 +196,7 @@ func registerHandler(cmr *conflator.ConflationManager, enableGlobalResource bool
 
 		dbsyncer.NewSubscriptionReportHandler().RegisterHandler(cmr)
 		dbsyncer.NewSubscriptionStatusHandler().RegisterHandler(cmr)
+
+		dbsyncer.NewArgoApplicationSetHandler().RegisterHandler(cmr)
 	}
 }
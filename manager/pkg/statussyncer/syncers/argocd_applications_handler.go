@@ -0,0 +1,21 @@
+package dbsyncer
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// NewArgoApplicationHandler syncs the ArgoCD applications of the managed hubs, they're unstructured so the global hub
+// doesn't depend on the API of the ArgoCD
+func NewArgoApplicationHandler() conflator.Handler {
+	return NewGenericHandler[*unstructured.Unstructured](
+		string(enum.ArgoApplicationType),
+		conflator.ArgoApplicationPriority,
+		enum.CompleteStateMode,
+		fmt.Sprintf("%s.%s", database.StatusSchema, database.ArgoApplicationsTableName))
+}
@@ -0,0 +1,21 @@
+package dbsyncer
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// NewArgoApplicationSetHandler syncs the rollout status of the ApplicationSets propagated from the global hub, the id
+// of the row is the uid of the ApplicationSet on the global hub
+func NewArgoApplicationSetHandler() conflator.Handler {
+	return NewGenericHandler[*unstructured.Unstructured](
+		string(enum.ArgoApplicationSetType),
+		conflator.ArgoApplicationSetPriority,
+		enum.CompleteStateMode,
+		fmt.Sprintf("%s.%s", database.StatusSchema, database.ArgoApplicationSetsTableName))
+}
@@ -82,40 +82,38 @@ func (h *genericObjectHandler[T]) handleEvent(ctx context.Context, evt *cloudeve
 		return fmt.Errorf("failed fetching leaf hub '%s' IDs from db - %w", h.table, err)
 	}
 
-	// using the transaction for now. once the unique is fixed, try to upInsert with batch operations
-	err = db.Transaction(func(tx *gorm.DB) error {
-		genericDao := dao.NewGenericDao(tx, h.table)
-
-		for _, object := range data {
-			specificObj := object
-			uid := getGenericObjectUID(specificObj)
-			resourceVersionFromDB, objExistsInDB := idToVersionMapFromDB[uid]
-
-			if !objExistsInDB { // object not found in the db table
-				if e := genericDao.Insert(leafHubName, uid, object); e != nil {
-					return e
-				}
-				continue
-			}
-
-			delete(idToVersionMapFromDB, uid)
+	// accumulate the objects of the bundle, and write them with the multi-row statements in one transaction. the
+	// table hasn't the unique constraint for the upsert, so the inserted and the updated objects are written separately
+	insertedObjects := map[string]interface{}{}
+	updatedObjects := map[string]interface{}{}
+	for _, object := range data {
+		specificObj := object
+		uid := getGenericObjectUID(specificObj)
+		resourceVersionFromDB, objExistsInDB := idToVersionMapFromDB[uid]
+
+		if !objExistsInDB { // object not found in the db table
+			insertedObjects[uid] = object
+			continue
+		}
 
-			if specificObj.GetResourceVersion() == resourceVersionFromDB {
-				continue // update object in db only if what we got is a different (newer) version of the resource.
-			}
+		delete(idToVersionMapFromDB, uid)
 
-			if e := genericDao.Update(leafHubName, uid, object); e != nil {
-				return e
-			}
+		if specificObj.GetResourceVersion() == resourceVersionFromDB {
+			continue // update object in db only if what we got is a different (newer) version of the resource.
 		}
+		updatedObjects[uid] = object
+	}
 
-		// delete objects that in the db but were not sent in the bundle (leaf hub sends only living resources).
-		for uid := range idToVersionMapFromDB {
-			if e := genericDao.Delete(leafHubName, uid); e != nil {
-				return e
-			}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		genericDao := dao.NewGenericDao(tx, h.table)
+		if e := genericDao.BatchInsert(leafHubName, insertedObjects); e != nil {
+			return e
+		}
+		if e := genericDao.BatchUpdate(leafHubName, updatedObjects); e != nil {
+			return e
 		}
-		return nil
+		// delete objects that in the db but were not sent in the bundle (leaf hub sends only living resources).
+		return genericDao.BatchDelete(leafHubName, mapKeys(idToVersionMapFromDB))
 	})
 	if err != nil {
 		return err
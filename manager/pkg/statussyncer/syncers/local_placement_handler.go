@@ -0,0 +1,38 @@
+package dbsyncer
+
+import (
+	"fmt"
+
+	clustersv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	clustersv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+func NewLocalPlacementSpecHandler() conflator.Handler {
+	return NewGenericHandler[*clustersv1beta1.Placement](
+		string(enum.LocalPlacementSpecType),
+		conflator.LocalPlacementSpecPriority,
+		enum.CompleteStateMode,
+		fmt.Sprintf("%s.%s", database.LocalSpecSchema, database.PlacementsTableName))
+}
+
+func NewLocalPlacementDecisionHandler() conflator.Handler {
+	return NewGenericHandler[*clustersv1beta1.PlacementDecision](
+		string(enum.LocalPlacementDecisionType),
+		conflator.LocalPlacementDecisionPriority,
+		enum.CompleteStateMode,
+		fmt.Sprintf("%s.%s", database.LocalStatusSchema, database.PlacementDecisionsTableName))
+}
+
+// NewAddOnPlacementScoreHandler syncs the scores of the managed clusters, they explain the decisions of the placements
+// with the AddOn prioritizers
+func NewAddOnPlacementScoreHandler() conflator.Handler {
+	return NewGenericHandler[*clustersv1alpha1.AddOnPlacementScore](
+		string(enum.AddOnPlacementScoreType),
+		conflator.AddOnPlacementScorePriority,
+		enum.CompleteStateMode,
+		fmt.Sprintf("%s.%s", database.StatusSchema, database.AddOnPlacementScoresTableName))
+}
@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/notification"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator/dependency"
 	"github.com/stolostron/multicluster-global-hub/pkg/bundle/grc"
@@ -25,9 +26,13 @@ type localPolicyCompleteHandler struct {
 	dependencyType string
 	eventSyncMode  enum.EventSyncMode
 	eventPriority  conflator.ConflationPriority
+	notifier       notification.Notifier
+	exporter       notification.Exporter
 }
 
-func NewLocalPolicyCompleteHandler() conflator.Handler {
+func NewLocalPolicyCompleteHandler(notifier notification.Notifier,
+	exporter notification.Exporter,
+) conflator.Handler {
 	eventType := string(enum.LocalCompleteComplianceType)
 	logName := strings.Replace(eventType, enum.EventTypePrefix, "", -1)
 	return &localPolicyCompleteHandler{
@@ -36,6 +41,8 @@ func NewLocalPolicyCompleteHandler() conflator.Handler {
 		dependencyType: string(enum.LocalComplianceType),
 		eventSyncMode:  enum.CompleteStateMode,
 		eventPriority:  conflator.LocalCompleteCompliancePriority,
+		notifier:       notifier,
+		exporter:       exporter,
 	}
 }
 
@@ -51,10 +58,12 @@ func (h *localPolicyCompleteHandler) RegisterHandler(conflationManager *conflato
 }
 
 func (h *localPolicyCompleteHandler) handleEventWrapper(ctx context.Context, evt *cloudevents.Event) error {
-	return handleCompleteCompliance(h.log, ctx, evt)
+	return handleCompleteCompliance(h.log, ctx, evt, h.notifier, h.exporter)
 }
 
-func handleCompleteCompliance(log logr.Logger, ctx context.Context, evt *cloudevents.Event) error {
+func handleCompleteCompliance(log logr.Logger, ctx context.Context, evt *cloudevents.Event,
+	notifier notification.Notifier, exporter notification.Exporter,
+) error {
 	version := evt.Extensions()[eventversion.ExtVersion]
 	leafHub := evt.Source()
 	log.V(2).Info(startMessage, "type", evt.Type(), "LH", evt.Source(), "version", version)
@@ -73,6 +82,10 @@ func handleCompleteCompliance(log logr.Logger, ctx context.Context, evt *cloudev
 		return err
 	}
 
+	// accumulate the updates of the bundle, and write them in one transaction
+	updates := complianceUpdates{}
+	// the clusters which become non-compliant, they're notified once the bundle is written
+	nonCompliantEvents := []*notification.ComplianceEvent{}
 	for _, eventCompliance := range data { // every object in bundle is policy compliance status
 
 		policyID := eventCompliance.PolicyID
@@ -84,18 +97,15 @@ func handleCompleteCompliance(log logr.Logger, ctx context.Context, evt *cloudev
 		}
 
 		allNonComplianceCluster := nonComplianceClusterSetsFromDB.GetAllClusters()
-		batchLocalCompliance := []models.LocalStatusCompliance{}
+
+		// only the clusters which aren't compliant are in the database sets, the others are compliant
+		nonCompliantEvents = appendNonCompliantEvents(nonCompliantEvents, leafHub, policyID,
+			eventCompliance.NonCompliantClusters, nonComplianceClusterSetsFromDB, database.Compliant)
 
 		// nonCompliant: go over the non compliant clusters from event
 		for _, eventCluster := range eventCompliance.NonCompliantClusters {
 			if !nonComplianceClusterSetsFromDB.GetClusters(database.NonCompliant).Contains(eventCluster) {
-				batchLocalCompliance = append(batchLocalCompliance, models.LocalStatusCompliance{
-					PolicyID:    policyID,
-					LeafHubName: leafHub,
-					ClusterName: eventCluster,
-					Compliance:  database.NonCompliant,
-					Error:       database.ErrorNone,
-				})
+				updates.add(policyID, eventCluster, database.NonCompliant)
 			}
 			allNonComplianceCluster.Remove(eventCluster) // mark cluster as handled
 		}
@@ -103,13 +113,7 @@ func handleCompleteCompliance(log logr.Logger, ctx context.Context, evt *cloudev
 		// pending: go over the pending clusters from event
 		for _, eventCluster := range eventCompliance.PendingComplianceClusters {
 			if !nonComplianceClusterSetsFromDB.GetClusters(database.Pending).Contains(eventCluster) {
-				batchLocalCompliance = append(batchLocalCompliance, models.LocalStatusCompliance{
-					PolicyID:    policyID,
-					LeafHubName: leafHub,
-					ClusterName: eventCluster,
-					Compliance:  database.Pending,
-					Error:       database.ErrorNone,
-				})
+				updates.add(policyID, eventCluster, database.Pending)
 			}
 			allNonComplianceCluster.Remove(eventCluster) // mark cluster as handled
 		}
@@ -117,13 +121,7 @@ func handleCompleteCompliance(log logr.Logger, ctx context.Context, evt *cloudev
 		// unknown: go over the unknown clusters from event
 		for _, eventCluster := range eventCompliance.UnknownComplianceClusters {
 			if !nonComplianceClusterSetsFromDB.GetClusters(database.Unknown).Contains(eventCluster) {
-				batchLocalCompliance = append(batchLocalCompliance, models.LocalStatusCompliance{
-					PolicyID:    policyID,
-					LeafHubName: leafHub,
-					ClusterName: eventCluster,
-					Compliance:  database.Unknown,
-					Error:       database.ErrorNone,
-				})
+				updates.add(policyID, eventCluster, database.Unknown)
 			}
 			allNonComplianceCluster.Remove(eventCluster) // mark cluster as handled
 		}
@@ -134,40 +132,25 @@ func handleCompleteCompliance(log logr.Logger, ctx context.Context, evt *cloudev
 			if !ok {
 				continue
 			}
-			batchLocalCompliance = append(batchLocalCompliance, models.LocalStatusCompliance{
-				PolicyID:    policyID,
-				LeafHubName: leafHub,
-				ClusterName: clusterName,
-				Compliance:  database.Compliant,
-				Error:       database.ErrorNone,
-			})
-		}
-
-		err = db.Transaction(func(tx *gorm.DB) error {
-			for _, compliance := range batchLocalCompliance {
-				e := tx.Updates(compliance).Error
-				if e != nil {
-					return e
-				}
-			}
-			return nil
-		})
-		if err != nil {
-			return fmt.Errorf("failed to update compliances by complete event - %w", err)
+			updates.add(policyID, clusterName, database.Compliant)
 		}
 
 		// for policies that are found in the db but not in the bundle - all clusters are Compliant (implicitly)
 		delete(allCompleteRowsFromDB, policyID)
 	}
 
-	// update policies not in the event - all is Compliant
 	err = db.Transaction(func(tx *gorm.DB) error {
-		for policyID := range allCompleteRowsFromDB {
-			err := tx.Model(&models.LocalStatusCompliance{}).
-				Where("policy_id = ? AND leaf_hub_name = ?", policyID, leafHub).
+		if e := updates.apply(tx, &models.LocalStatusCompliance{}, leafHub); e != nil {
+			return fmt.Errorf("failed to update compliances by complete event - %w", e)
+		}
+
+		// update policies not in the event - all is Compliant
+		for _, policyIDs := range database.Chunk(mapKeys(allCompleteRowsFromDB)) {
+			e := tx.Model(&models.LocalStatusCompliance{}).
+				Where("leaf_hub_name = ? AND policy_id IN ?", leafHub, policyIDs).
 				Updates(&models.LocalStatusCompliance{Compliance: database.Compliant}).Error
-			if err != nil {
-				return err
+			if e != nil {
+				return e
 			}
 		}
 		return nil
@@ -175,6 +158,8 @@ func handleCompleteCompliance(log logr.Logger, ctx context.Context, evt *cloudev
 	if err != nil {
 		return fmt.Errorf("failed deleting compliances from local complainces - %w", err)
 	}
+	exporter.ExportComplianceEvents(nonCompliantEvents)
+	notifier.Notify(nonCompliantEvents...)
 
 	log.V(2).Info(finishMessage, "type", evt.Type(), "LH", evt.Source(), "version", version)
 	return nil
@@ -4,14 +4,15 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	set "github.com/deckarep/golang-set"
 	"github.com/go-logr/logr"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/notification"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
 	"github.com/stolostron/multicluster-global-hub/pkg/bundle/grc"
 	eventversion "github.com/stolostron/multicluster-global-hub/pkg/bundle/version"
@@ -25,9 +26,13 @@ type localPolicyComplianceHandler struct {
 	eventType     string
 	eventSyncMode enum.EventSyncMode
 	eventPriority conflator.ConflationPriority
+	notifier      notification.Notifier
+	exporter      notification.Exporter
 }
 
-func NewLocalPolicyComplianceHandler() conflator.Handler {
+func NewLocalPolicyComplianceHandler(notifier notification.Notifier,
+	exporter notification.Exporter,
+) conflator.Handler {
 	eventType := string(enum.LocalComplianceType)
 	logName := strings.Replace(eventType, enum.EventTypePrefix, "", -1)
 	return &localPolicyComplianceHandler{
@@ -35,6 +40,8 @@ func NewLocalPolicyComplianceHandler() conflator.Handler {
 		eventType:     eventType,
 		eventSyncMode: enum.CompleteStateMode,
 		eventPriority: conflator.LocalCompliancePriority,
+		notifier:      notifier,
+		exporter:      exporter,
 	}
 }
 
@@ -48,10 +55,12 @@ func (h *localPolicyComplianceHandler) RegisterHandler(conflationManager *confla
 }
 
 func (h *localPolicyComplianceHandler) handleEventWrapper(ctx context.Context, evt *cloudevents.Event) error {
-	return handleCompliance(h.log, ctx, evt)
+	return handleCompliance(h.log, ctx, evt, h.notifier, h.exporter)
 }
 
-func handleCompliance(log logr.Logger, ctx context.Context, evt *cloudevents.Event) error {
+func handleCompliance(log logr.Logger, ctx context.Context, evt *cloudevents.Event,
+	notifier notification.Notifier, exporter notification.Exporter,
+) error {
 	version := evt.Extensions()[eventversion.ExtVersion]
 	leafHub := evt.Source()
 	log.V(2).Info(startMessage, "type", evt.Type(), "LH", evt.Source(), "version", version)
@@ -68,6 +77,11 @@ func handleCompliance(log logr.Logger, ctx context.Context, evt *cloudevents.Eve
 		return err
 	}
 
+	// accumulate the rows of the bundle, and write them in one transaction
+	batchLocalCompliances := []models.LocalStatusCompliance{}
+	deletedCompliances := [][]interface{}{}
+	// the clusters which become non-compliant, they're notified once the bundle is written
+	nonCompliantEvents := []*notification.ComplianceEvent{}
 	for _, eventCompliance := range data { // every object is clusters list per policy with full state
 
 		policyID := eventCompliance.PolicyID
@@ -78,6 +92,9 @@ func handleCompliance(log logr.Logger, ctx context.Context, evt *cloudevents.Eve
 
 		allClustersOnDB := complianceClustersFromDB.GetAllClusters()
 
+		nonCompliantEvents = appendNonCompliantEvents(nonCompliantEvents, leafHub, policyID,
+			eventCompliance.NonCompliantClusters, complianceClustersFromDB, "")
+
 		// handle compliant clusters of the policy
 		compliantCompliances := newLocalCompliances(leafHub, policyID, database.Compliant,
 			eventCompliance.CompliantClusters, allClustersOnDB)
@@ -94,53 +111,39 @@ func handleCompliance(log logr.Logger, ctx context.Context, evt *cloudevents.Eve
 		pendingCompliances := newLocalCompliances(leafHub, policyID, database.Pending,
 			eventCompliance.PendingComplianceClusters, allClustersOnDB)
 
-		batchLocalCompliances := []models.LocalStatusCompliance{}
 		batchLocalCompliances = append(batchLocalCompliances, compliantCompliances...)
 		batchLocalCompliances = append(batchLocalCompliances, nonCompliantCompliances...)
 		batchLocalCompliances = append(batchLocalCompliances, unknownCompliances...)
 		batchLocalCompliances = append(batchLocalCompliances, pendingCompliances...)
 
+		// the clusters of the policy which aren't in the bundle
+		deletedCompliances = appendDeletedCompliances(deletedCompliances, policyID, allClustersOnDB)
+
+		// keep this policy in db, should remove from db only policies that were not sent in the bundle
+		delete(allComplianceClustersFromDB, policyID)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
 		// batch upsert
-		err = db.Clauses(clause.OnConflict{
-			UpdateAll: true,
-		}).CreateInBatches(batchLocalCompliances, 100).Error
-		if err != nil {
-			return err
+		if e := database.UpsertInBatches(tx, batchLocalCompliances); e != nil {
+			return e
 		}
 
 		// delete
-		err = db.Transaction(func(tx *gorm.DB) error {
-			for _, name := range allClustersOnDB.ToSlice() {
-				clusterName, ok := name.(string)
-				if !ok {
-					continue
-				}
-				err := tx.Where(&models.LocalStatusCompliance{
-					LeafHubName: leafHub,
-					PolicyID:    policyID,
-					ClusterName: clusterName,
-				}).Delete(&models.LocalStatusCompliance{}).Error
-				if err != nil {
-					return err
-				}
+		for _, compliances := range database.Chunk(deletedCompliances) {
+			e := tx.Where("leaf_hub_name = ? AND (policy_id, cluster_name) IN ?", leafHub, compliances).
+				Delete(&models.LocalStatusCompliance{}).Error
+			if e != nil {
+				return e
 			}
-			return nil
-		})
-		if err != nil {
-			return fmt.Errorf("failed to handle clusters per policy bundle - %w", err)
 		}
-		// keep this policy in db, should remove from db only policies that were not sent in the bundle
-		delete(allComplianceClustersFromDB, policyID)
-	}
 
-	// delete the policy isn't contained on the bundle
-	err = db.Transaction(func(tx *gorm.DB) error {
-		for policyID := range allComplianceClustersFromDB {
-			err := tx.Where(&models.LocalStatusCompliance{
-				PolicyID: policyID,
-			}).Delete(&models.LocalStatusCompliance{}).Error
-			if err != nil {
-				return err
+		// delete the policy isn't contained on the bundle
+		for _, policyIDs := range database.Chunk(mapKeys(allComplianceClustersFromDB)) {
+			e := tx.Where("leaf_hub_name = ? AND policy_id IN ?", leafHub, policyIDs).
+				Delete(&models.LocalStatusCompliance{}).Error
+			if e != nil {
+				return e
 			}
 		}
 		return nil
@@ -148,6 +151,8 @@ func handleCompliance(log logr.Logger, ctx context.Context, evt *cloudevents.Eve
 	if err != nil {
 		return fmt.Errorf("failed to handle local compliance event - %w", err)
 	}
+	exporter.ExportComplianceEvents(nonCompliantEvents)
+	notifier.Notify(nonCompliantEvents...)
 
 	log.V(2).Info(finishMessage, "type", evt.Type(), "LH", evt.Source(), "version", version)
 	return nil
@@ -190,3 +195,29 @@ func getLocalComplianceClusterSets(db *gorm.DB, query interface{}, args ...inter
 	}
 	return allPolicyComplianceRowsFromDB, nil
 }
+
+// appendNonCompliantEvents appends the events of the clusters which weren't non-compliant in the database. The
+// previous compliance of the clusters which aren't in the database is the defaultPrevious
+func appendNonCompliantEvents(events []*notification.ComplianceEvent, leafHub, policyID string,
+	nonCompliantClusters []string, clusterSetsFromDB *PolicyClustersSets, defaultPrevious database.ComplianceStatus,
+) []*notification.ComplianceEvent {
+	now := time.Now()
+	for _, cluster := range nonCompliantClusters {
+		previous, ok := clusterSetsFromDB.GetCompliance(cluster)
+		if !ok {
+			previous = defaultPrevious
+		}
+		if previous == database.NonCompliant {
+			continue
+		}
+		events = append(events, &notification.ComplianceEvent{
+			PolicyID:           policyID,
+			LeafHubName:        leafHub,
+			ClusterName:        cluster,
+			Compliance:         string(database.NonCompliant),
+			PreviousCompliance: string(previous),
+			Time:               now,
+		})
+	}
+	return events
+}
@@ -8,9 +8,12 @@ import (
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/go-logr/logr"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/notification"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/coalescer"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
 	"github.com/stolostron/multicluster-global-hub/pkg/bundle/event"
 	eventversion "github.com/stolostron/multicluster-global-hub/pkg/bundle/version"
@@ -25,9 +28,12 @@ type localReplicatedPolicyEventHandler struct {
 	eventType     string
 	eventSyncMode enum.EventSyncMode
 	eventPriority conflator.ConflationPriority
+	coalescer     *coalescer.EventCoalescer
+	exporter      notification.Exporter
 }
 
-func NewLocalReplicatedPolicyEventHandler() conflator.Handler {
+func NewLocalReplicatedPolicyEventHandler(c *coalescer.EventCoalescer, exporter notification.Exporter,
+) conflator.Handler {
 	eventType := string(enum.LocalReplicatedPolicyEventType)
 	logName := strings.Replace(eventType, enum.EventTypePrefix, "", -1)
 	return &localReplicatedPolicyEventHandler{
@@ -35,6 +41,8 @@ func NewLocalReplicatedPolicyEventHandler() conflator.Handler {
 		eventType:     eventType,
 		eventSyncMode: enum.DeltaStateMode,
 		eventPriority: conflator.LocalReplicatedPolicyEventPriority,
+		coalescer:     c,
+		exporter:      exporter,
 	}
 }
 
@@ -87,15 +95,52 @@ func (h *localReplicatedPolicyEventHandler) handleEvent(ctx context.Context, evt
 		return nil
 	}
 
+	// the events identical to the ones written in the coalescing window are counted on the written rows
+	table := models.LocalReplicatedPolicyEvent{}.TableName()
+	batchLocalPolicyEvents, commit := coalescer.Filter(h.coalescer, table, batchLocalPolicyEvents,
+		func(e models.LocalReplicatedPolicyEvent) string {
+			return coalescer.Hash(e.LeafHubName, e.PolicyID, e.ClusterID, e.Compliance, e.Reason, e.Message)
+		},
+		func(e models.LocalReplicatedPolicyEvent) []interface{} {
+			return []interface{}{e.EventName, e.Count, e.CreatedAt}
+		})
+
 	db := database.GetGorm()
-	err := db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "event_name"}, {Name: "count"}, {Name: "created_at"}},
-		DoNothing: true,
-	}).CreateInBatches(batchLocalPolicyEvents, 100).Error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(batchLocalPolicyEvents) > 0 {
+			e := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "event_name"}, {Name: "count"}, {Name: "created_at"}},
+				DoNothing: true,
+			}).CreateInBatches(batchLocalPolicyEvents, 100).Error
+			if e != nil {
+				return e
+			}
+		}
+		return applyCoalescedEvents(tx, table, policyEventKeyColumns, h.coalescer.Flush(table))
+	})
 	if err != nil {
 		return fmt.Errorf("failed handling leaf hub LocalPolicyStatusEvent event - %w", err)
 	}
+	commit()
+	h.exporter.ExportReplicatedPolicyEvents(leafHubName, batchLocalPolicyEvents)
 
 	h.log.V(2).Info(finishMessage, "type", evt.Type(), "LH", evt.Source(), "version", version)
 	return nil
 }
+
+// the unique key of the rows of the policy events
+var policyEventKeyColumns = []string{"event_name", "count", "created_at"}
+
+// applyCoalescedEvents adds the numbers of the coalesced events to the written rows of the table, the keyColumns are
+// the unique key of the rows
+func applyCoalescedEvents(tx *gorm.DB, table string, keyColumns []string, coalesced []coalescer.Coalesced) error {
+	condition := strings.Join(keyColumns, " = ? AND ") + " = ?"
+	for _, c := range coalesced {
+		err := tx.Table(table).Where(condition, c.Key...).
+			UpdateColumn("coalesced_count", gorm.Expr("coalesced_count + ?", c.Count)).Error
+		if err != nil {
+			return fmt.Errorf("failed to update the coalesced events of %s - %w", table, err)
+		}
+	}
+	return nil
+}
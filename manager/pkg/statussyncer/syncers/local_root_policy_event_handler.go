@@ -8,9 +8,12 @@ import (
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/go-logr/logr"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/notification"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/coalescer"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
 	"github.com/stolostron/multicluster-global-hub/pkg/bundle/event"
 	eventversion "github.com/stolostron/multicluster-global-hub/pkg/bundle/version"
@@ -25,9 +28,12 @@ type localRootPolicyEventHandler struct {
 	eventType     string
 	eventSyncMode enum.EventSyncMode
 	eventPriority conflator.ConflationPriority
+	coalescer     *coalescer.EventCoalescer
+	exporter      notification.Exporter
 }
 
-func NewLocalRootPolicyEventHandler() conflator.Handler {
+func NewLocalRootPolicyEventHandler(c *coalescer.EventCoalescer, exporter notification.Exporter,
+) conflator.Handler {
 	eventType := string(enum.LocalRootPolicyEventType)
 	logName := strings.Replace(eventType, enum.EventTypePrefix, "", -1)
 	return &localRootPolicyEventHandler{
@@ -35,6 +41,8 @@ func NewLocalRootPolicyEventHandler() conflator.Handler {
 		eventType:     eventType,
 		eventSyncMode: enum.DeltaStateMode,
 		eventPriority: conflator.LocalEventRootPolicyPriority,
+		coalescer:     c,
+		exporter:      exporter,
 	}
 }
 
@@ -86,14 +94,34 @@ func (h *localRootPolicyEventHandler) handleEvent(ctx context.Context, evt *clou
 		})
 	}
 
+	// the events identical to the ones written in the coalescing window are counted on the written rows
+	table := models.LocalRootPolicyEvent{}.TableName()
+	localRootPolicyEvents, commit := coalescer.Filter(h.coalescer, table, localRootPolicyEvents,
+		func(e models.LocalRootPolicyEvent) string {
+			return coalescer.Hash(e.LeafHubName, e.PolicyID, e.Compliance, e.Reason, e.Message)
+		},
+		func(e models.LocalRootPolicyEvent) []interface{} {
+			return []interface{}{e.EventName, e.Count, e.CreatedAt}
+		})
+
 	db := database.GetGorm()
-	err := db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "event_name"}, {Name: "count"}, {Name: "created_at"}},
-		UpdateAll: true,
-	}).CreateInBatches(localRootPolicyEvents, 100).Error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(localRootPolicyEvents) > 0 {
+			e := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "event_name"}, {Name: "count"}, {Name: "created_at"}},
+				UpdateAll: true,
+			}).CreateInBatches(localRootPolicyEvents, 100).Error
+			if e != nil {
+				return e
+			}
+		}
+		return applyCoalescedEvents(tx, table, policyEventKeyColumns, h.coalescer.Flush(table))
+	})
 	if err != nil {
 		return fmt.Errorf("failed to handle the event to database %v", err)
 	}
+	commit()
+	h.exporter.ExportRootPolicyEvents(leafHubName, localRootPolicyEvents)
 	h.log.V(2).Info(finishMessage, "type", evt.Type(), "LH", evt.Source(), "version", version)
 	return nil
 }
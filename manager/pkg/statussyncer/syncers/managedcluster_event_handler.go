@@ -7,13 +7,17 @@ import (
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/go-logr/logr"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/notification"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/coalescer"
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
 	"github.com/stolostron/multicluster-global-hub/pkg/bundle/event"
 	eventversion "github.com/stolostron/multicluster-global-hub/pkg/bundle/version"
 	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
 	"github.com/stolostron/multicluster-global-hub/pkg/enum"
 )
 
@@ -22,9 +26,12 @@ type managedClusterEventHandler struct {
 	eventType     string
 	eventSyncMode enum.EventSyncMode
 	eventPriority conflator.ConflationPriority
+	coalescer     *coalescer.EventCoalescer
+	exporter      notification.Exporter
 }
 
-func NewManagedClusterEventHandler() conflator.Handler {
+func NewManagedClusterEventHandler(c *coalescer.EventCoalescer, exporter notification.Exporter,
+) conflator.Handler {
 	eventType := string(enum.ManagedClusterEventType)
 	logName := strings.Replace(eventType, enum.EventTypePrefix, "", -1)
 	return &managedClusterEventHandler{
@@ -32,6 +39,8 @@ func NewManagedClusterEventHandler() conflator.Handler {
 		eventType:     eventType,
 		eventSyncMode: enum.DeltaStateMode,
 		eventPriority: conflator.ManagedClusterEventPriority,
+		coalescer:     c,
+		exporter:      exporter,
 	}
 }
 
@@ -63,14 +72,35 @@ func (h *managedClusterEventHandler) handleEvent(ctx context.Context, evt *cloud
 		return nil
 	}
 
+	// the events identical to the ones written in the coalescing window are counted on the written rows
+	table := models.ManagedClusterEvent{}.TableName()
+	events, commit := coalescer.Filter(h.coalescer, table, managedClusterEvents,
+		func(e models.ManagedClusterEvent) string {
+			return coalescer.Hash(e.LeafHubName, e.ClusterID, e.EventType, e.Reason, e.Message)
+		},
+		func(e models.ManagedClusterEvent) []interface{} {
+			return []interface{}{e.LeafHubName, e.EventName, e.CreatedAt}
+		})
+
 	db := database.GetGorm()
-	err := db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "leaf_hub_name"}, {Name: "event_name"}, {Name: "created_at"}},
-		DoNothing: true,
-	}).CreateInBatches(managedClusterEvents, 100).Error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(events) > 0 {
+			e := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "leaf_hub_name"}, {Name: "event_name"}, {Name: "created_at"}},
+				DoNothing: true,
+			}).CreateInBatches(events, 100).Error
+			if e != nil {
+				return e
+			}
+		}
+		return applyCoalescedEvents(tx, table, []string{"leaf_hub_name", "event_name", "created_at"},
+			h.coalescer.Flush(table))
+	})
 	if err != nil {
 		return fmt.Errorf("failed handling leaf hub LocalPolicyStatusEvent event - %w", err)
 	}
+	commit()
+	h.exporter.ExportClusterEvents(events)
 
 	h.log.V(2).Info(finishMessage, "type", evt.Type(), "LH", evt.Source(), "version", version)
 	return nil
@@ -9,7 +9,6 @@ import (
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/go-logr/logr"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -62,9 +61,14 @@ func (h *managedClusterHandler) handleEvent(ctx context.Context, evt *cloudevent
 	if err != nil {
 		return fmt.Errorf("failed fetching leaf hub managed clusters from db - %w", err)
 	}
+	clusterIdToLifecycle, err := getClusterLifecycles(db, leafHubName)
+	if err != nil {
+		return fmt.Errorf("failed fetching the lifecycle of the managed clusters from db - %w", err)
+	}
 
 	// batch update/insert managed clusters
 	batchManagedClusters := []models.ManagedCluster{}
+	bundleClusters := map[string]*clusterv1.ManagedCluster{}
 	for _, object := range data {
 		cluster := object
 
@@ -79,6 +83,7 @@ func (h *managedClusterHandler) handleEvent(ctx context.Context, evt *cloudevent
 		if clusterId == "" {
 			continue
 		}
+		bundleClusters[clusterId] = &cluster
 
 		payload, err := json.Marshal(cluster)
 		if err != nil {
@@ -110,29 +115,31 @@ func (h *managedClusterHandler) handleEvent(ctx context.Context, evt *cloudevent
 			Error:       database.ErrorNone,
 		})
 	}
-	err = db.Clauses(clause.OnConflict{
-		UpdateAll: true,
-	}).CreateInBatches(batchManagedClusters, 100).Error
-	if err != nil {
-		return err
-	}
+	lifecycleEvents := clusterLifecycleEvents(leafHubName, bundleClusters, clusterIdToLifecycle)
 
-	// delete objects that in the db but were not sent in the bundle (leaf hub sends only living resources).
+	// write the bundle in one transaction: upsert the clusters with the multi-row statements, and delete objects that
+	// in the db but were not sent in the bundle (leaf hub sends only living resources).
 	// https://gorm.io/docs/delete.html#Soft-Delete
+	// the lifecycle events are written in the same transaction, so they're in the same order as the changes of the
+	// clusters
 	err = db.Transaction(func(tx *gorm.DB) error {
-		for clusterId := range clusterIdToVersionMapFromDB {
-			e := tx.Where(&models.ManagedCluster{
-				LeafHubName: leafHubName,
-				ClusterID:   clusterId,
-			}).Delete(&models.ManagedCluster{}).Error
+		if e := database.UpsertInBatches(tx, batchManagedClusters); e != nil {
+			return e
+		}
+		for _, clusterIds := range database.Chunk(mapKeys(clusterIdToVersionMapFromDB)) {
+			e := tx.Where("leaf_hub_name = ? AND cluster_id IN ?", leafHubName, clusterIds).
+				Delete(&models.ManagedCluster{}).Error
 			if e != nil {
 				return e
 			}
 		}
+		if len(lifecycleEvents) > 0 {
+			return tx.CreateInBatches(lifecycleEvents, database.BatchSize).Error
+		}
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("failed deleting managed clusters - %w", err)
+		return fmt.Errorf("failed syncing managed clusters - %w", err)
 	}
 
 	h.log.V(2).Info(finishMessage, "type", evt.Type(), "LH", evt.Source(), "version", version)
@@ -0,0 +1,118 @@
+package dbsyncer
+
+import (
+	"sort"
+
+	"gorm.io/gorm"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+const (
+	// createdViaAnnotation is set by the managed hub to how the cluster joined the hub, e.g. "hive" or "other"
+	createdViaAnnotation = "open-cluster-management/created-via"
+	// versionClaim is the version of the OpenShift clusters, the other clusters report the kubernetes version
+	versionClaim = "version.openshift.io"
+)
+
+// the clusters of these sources are provisioned by the managed hub, the others are imported
+var createdViaProvisioning = map[string]bool{
+	"hive":               true,
+	"hypershift":         true,
+	"assisted-installer": true,
+}
+
+// the lifecycle of the managed clusters of the hub in the database, the version is the same as the clusterVersion
+var clusterLifecyclesSQL = `
+	SELECT cluster_id, cluster_name, COALESCE(
+		(SELECT claim ->> 'value' FROM jsonb_array_elements(payload -> 'status' -> 'clusterClaims') AS claim
+			WHERE claim ->> 'name' = '` + versionClaim + `' LIMIT 1),
+		payload -> 'status' -> 'version' ->> 'kubernetes', '') AS version
+	FROM status.managed_clusters
+	WHERE leaf_hub_name = ? AND deleted_at IS NULL`
+
+type clusterLifecycle struct {
+	ClusterID   string `gorm:"column:cluster_id"`
+	ClusterName string `gorm:"column:cluster_name"`
+	Version     string `gorm:"column:version"`
+}
+
+func getClusterLifecycles(db *gorm.DB, leafHubName string) (map[string]clusterLifecycle, error) {
+	var lifecycles []clusterLifecycle
+	if err := db.Raw(clusterLifecyclesSQL, leafHubName).Scan(&lifecycles).Error; err != nil {
+		return nil, err
+	}
+	clusterIdToLifecycle := make(map[string]clusterLifecycle, len(lifecycles))
+	for _, lifecycle := range lifecycles {
+		clusterIdToLifecycle[lifecycle.ClusterID] = lifecycle
+	}
+	return clusterIdToLifecycle, nil
+}
+
+// clusterLifecycleEvents compares the clusters in the bundle with the ones in the database: the new clusters are
+// created or imported, the clusters which aren't in the bundle are detached, and the clusters of the different
+// versions are upgraded
+func clusterLifecycleEvents(leafHubName string, clusters map[string]*clusterv1.ManagedCluster,
+	clusterIdToLifecycle map[string]clusterLifecycle,
+) []models.ClusterLifecycleEvent {
+	events := []models.ClusterLifecycleEvent{}
+	for clusterId, cluster := range clusters {
+		version := clusterVersion(cluster)
+		previous, exist := clusterIdToLifecycle[clusterId]
+		if !exist {
+			createdVia := cluster.GetAnnotations()[createdViaAnnotation]
+			eventType := models.ClusterImported
+			if createdViaProvisioning[createdVia] {
+				eventType = models.ClusterCreated
+			}
+			events = append(events, models.ClusterLifecycleEvent{
+				LeafHubName: leafHubName,
+				ClusterID:   clusterId,
+				ClusterName: cluster.Name,
+				EventType:   eventType,
+				Version:     version,
+				CreatedVia:  createdVia,
+			})
+			continue
+		}
+		// the version isn't reported until the cluster is available, it isn't an upgrade
+		if previous.Version != "" && version != "" && previous.Version != version {
+			events = append(events, models.ClusterLifecycleEvent{
+				LeafHubName:     leafHubName,
+				ClusterID:       clusterId,
+				ClusterName:     cluster.Name,
+				EventType:       models.ClusterUpgraded,
+				Version:         version,
+				PreviousVersion: previous.Version,
+			})
+		}
+	}
+	for clusterId, previous := range clusterIdToLifecycle {
+		if _, exist := clusters[clusterId]; exist {
+			continue
+		}
+		events = append(events, models.ClusterLifecycleEvent{
+			LeafHubName: leafHubName,
+			ClusterID:   clusterId,
+			ClusterName: previous.ClusterName,
+			EventType:   models.ClusterDetached,
+			Version:     previous.Version,
+		})
+	}
+	// each cluster has one event in a bundle at most, so the order is only for the readers of the feed
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ClusterName < events[j].ClusterName
+	})
+	return events
+}
+
+// clusterVersion returns the OpenShift version of the cluster, or the kubernetes version if it isn't OpenShift
+func clusterVersion(cluster *clusterv1.ManagedCluster) string {
+	for _, claim := range cluster.Status.ClusterClaims {
+		if claim.Name == versionClaim {
+			return claim.Value
+		}
+	}
+	return cluster.Status.Version.Kubernetes
+}
@@ -0,0 +1,30 @@
+package dbsyncer
+
+import (
+	"fmt"
+
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// NewManagedClusterSetHandler syncs the ManagedClusterSets of the managed hubs, the sets of the same name on the
+// different hubs are aggregated by the API to the fleet-wide view
+func NewManagedClusterSetHandler() conflator.Handler {
+	return NewGenericHandler[*clusterv1beta2.ManagedClusterSet](
+		string(enum.ManagedClusterSetType),
+		conflator.ManagedClusterSetPriority,
+		enum.CompleteStateMode,
+		fmt.Sprintf("%s.%s", database.StatusSchema, database.ManagedClusterSetsTableName))
+}
+
+// NewManagedClusterSetBindingHandler syncs the ManagedClusterSetBindings of the managed hubs
+func NewManagedClusterSetBindingHandler() conflator.Handler {
+	return NewGenericHandler[*clusterv1beta2.ManagedClusterSetBinding](
+		string(enum.ManagedClusterSetBindingType),
+		conflator.ManagedClusterSetBindingPriority,
+		enum.CompleteStateMode,
+		fmt.Sprintf("%s.%s", database.StatusSchema, database.ManagedClusterSetBindingsTableName))
+}
@@ -0,0 +1,21 @@
+package dbsyncer
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// NewOperatorPolicyHandler syncs the compliance of the OperatorPolicies propagated from the global hub, the id of the
+// row is the uid of the OperatorPolicy on the global hub
+func NewOperatorPolicyHandler() conflator.Handler {
+	return NewGenericHandler[*unstructured.Unstructured](
+		string(enum.OperatorPolicyType),
+		conflator.OperatorPolicyPriority,
+		enum.CompleteStateMode,
+		fmt.Sprintf("%s.%s", database.StatusSchema, database.OperatorPoliciesTableName))
+}
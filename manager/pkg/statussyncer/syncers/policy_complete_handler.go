@@ -68,6 +68,8 @@ func (h *policyCompleteHandler) handleEvent(ctx context.Context, evt *cloudevent
 		return err
 	}
 
+	// accumulate the updates of the bundle, and write them in one transaction
+	updates := complianceUpdates{}
 	for _, eventCompliance := range data { // every object in bundle is policy compliance status
 
 		policyID := eventCompliance.PolicyID
@@ -79,18 +81,11 @@ func (h *policyCompleteHandler) handleEvent(ctx context.Context, evt *cloudevent
 		}
 
 		allNonComplianceCluster := nonComplianceClusterSetsFromDB.GetAllClusters()
-		batchCompliance := []models.StatusCompliance{}
 
 		// nonCompliant: go over the non compliant clusters from event
 		for _, eventCluster := range eventCompliance.NonCompliantClusters {
 			if !nonComplianceClusterSetsFromDB.GetClusters(database.NonCompliant).Contains(eventCluster) {
-				batchCompliance = append(batchCompliance, models.StatusCompliance{
-					PolicyID:    policyID,
-					LeafHubName: leafHub,
-					ClusterName: eventCluster,
-					Compliance:  database.NonCompliant,
-					Error:       database.ErrorNone,
-				})
+				updates.add(policyID, eventCluster, database.NonCompliant)
 			}
 			allNonComplianceCluster.Remove(eventCluster) // mark cluster as handled
 		}
@@ -98,13 +93,7 @@ func (h *policyCompleteHandler) handleEvent(ctx context.Context, evt *cloudevent
 		// pending: go over the pending clusters from event
 		for _, eventCluster := range eventCompliance.PendingComplianceClusters {
 			if !nonComplianceClusterSetsFromDB.GetClusters(database.Pending).Contains(eventCluster) {
-				batchCompliance = append(batchCompliance, models.StatusCompliance{
-					PolicyID:    policyID,
-					LeafHubName: leafHub,
-					ClusterName: eventCluster,
-					Compliance:  database.Pending,
-					Error:       database.ErrorNone,
-				})
+				updates.add(policyID, eventCluster, database.Pending)
 			}
 			allNonComplianceCluster.Remove(eventCluster) // mark cluster as handled
 		}
@@ -112,13 +101,7 @@ func (h *policyCompleteHandler) handleEvent(ctx context.Context, evt *cloudevent
 		// unknown: go over the unknown clusters from event
 		for _, eventCluster := range eventCompliance.UnknownComplianceClusters {
 			if !nonComplianceClusterSetsFromDB.GetClusters(database.Unknown).Contains(eventCluster) {
-				batchCompliance = append(batchCompliance, models.StatusCompliance{
-					PolicyID:    policyID,
-					LeafHubName: leafHub,
-					ClusterName: eventCluster,
-					Compliance:  database.Unknown,
-					Error:       database.ErrorNone,
-				})
+				updates.add(policyID, eventCluster, database.Unknown)
 			}
 			allNonComplianceCluster.Remove(eventCluster) // mark cluster as handled
 		}
@@ -129,40 +112,25 @@ func (h *policyCompleteHandler) handleEvent(ctx context.Context, evt *cloudevent
 			if !ok {
 				continue
 			}
-			batchCompliance = append(batchCompliance, models.StatusCompliance{
-				PolicyID:    policyID,
-				LeafHubName: leafHub,
-				ClusterName: clusterName,
-				Compliance:  database.Compliant,
-				Error:       database.ErrorNone,
-			})
-		}
-
-		err = db.Transaction(func(tx *gorm.DB) error {
-			for _, compliance := range batchCompliance {
-				e := tx.Updates(compliance).Error
-				if e != nil {
-					return e
-				}
-			}
-			return nil
-		})
-		if err != nil {
-			return fmt.Errorf("failed to update compliances by complete event - %w", err)
+			updates.add(policyID, clusterName, database.Compliant)
 		}
 
 		// for policies that are found in the db but not in the bundle - all clusters are Compliant (implicitly)
 		delete(allCompleteRowsFromDB, policyID)
 	}
 
-	// update policies not in the event - all is Compliant
 	err = db.Transaction(func(tx *gorm.DB) error {
-		for policyID := range allCompleteRowsFromDB {
-			err := tx.Model(&models.StatusCompliance{}).
-				Where("policy_id = ? AND leaf_hub_name = ?", policyID, leafHub).
+		if e := updates.apply(tx, &models.StatusCompliance{}, leafHub); e != nil {
+			return fmt.Errorf("failed to update compliances by complete event - %w", e)
+		}
+
+		// update policies not in the event - all is Compliant
+		for _, policyIDs := range database.Chunk(mapKeys(allCompleteRowsFromDB)) {
+			e := tx.Model(&models.StatusCompliance{}).
+				Where("leaf_hub_name = ? AND policy_id IN ?", leafHub, policyIDs).
 				Updates(&models.StatusCompliance{Compliance: database.Compliant}).Error
-			if err != nil {
-				return err
+			if e != nil {
+				return e
 			}
 		}
 		return nil
@@ -174,3 +142,31 @@ func (h *policyCompleteHandler) handleEvent(ctx context.Context, evt *cloudevent
 	h.log.V(2).Info(finishMessage, "type", evt.Type(), "LH", evt.Source(), "version", version)
 	return nil
 }
+
+// complianceUpdates groups the clusters by the policy and the compliance status, so the compliance of the clusters is
+// updated by the multi-row statements instead of a statement per cluster
+type complianceUpdates map[string]map[database.ComplianceStatus][]string
+
+func (u complianceUpdates) add(policyID, clusterName string, compliance database.ComplianceStatus) {
+	if _, ok := u[policyID]; !ok {
+		u[policyID] = map[database.ComplianceStatus][]string{}
+	}
+	u[policyID][compliance] = append(u[policyID][compliance], clusterName)
+}
+
+// apply updates the compliance of the clusters on the table of the model
+func (u complianceUpdates) apply(tx *gorm.DB, model interface{}, leafHub string) error {
+	for policyID, complianceClusters := range u {
+		for compliance, clusters := range complianceClusters {
+			for _, clusterNames := range database.Chunk(clusters) {
+				err := tx.Model(model).
+					Where("leaf_hub_name = ? AND policy_id = ? AND cluster_name IN ?", leafHub, policyID, clusterNames).
+					Updates(map[string]interface{}{"compliance": compliance, "error": database.ErrorNone}).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
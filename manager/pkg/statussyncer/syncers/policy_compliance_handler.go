@@ -9,7 +9,6 @@ import (
 	set "github.com/deckarep/golang-set"
 	"github.com/go-logr/logr"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
@@ -63,19 +62,16 @@ func (h *policyComplianceHandler) handleEvent(ctx context.Context, evt *cloudeve
 		return err
 	}
 
-	var compliancesFromDB []models.StatusCompliance
 	db := database.GetGorm()
-	err := db.Where(&models.StatusCompliance{LeafHubName: leafHubName}).Find(&compliancesFromDB).Error
-	if err != nil {
-		return err
-	}
-
 	// policyID: { compliance: (cluster1, cluster2), nonCompliance: (cluster3, cluster4), unknowns: (cluster5) }
 	allComplianceClustersFromDB, err := getComplianceClusterSets(db, "leaf_hub_name = ?", leafHubName)
 	if err != nil {
 		return err
 	}
 
+	// accumulate the rows of the bundle, and write them in one transaction
+	batchCompliances := []models.StatusCompliance{}
+	deletedCompliances := [][]interface{}{}
 	for _, eventCompliance := range data { // every object is clusters list per policy with full state
 
 		policyID := eventCompliance.PolicyID
@@ -101,53 +97,39 @@ func (h *policyComplianceHandler) handleEvent(ctx context.Context, evt *cloudeve
 		pendingCompliances := newCompliances(leafHubName, policyID, database.Pending,
 			eventCompliance.PendingComplianceClusters, allClustersOnDB)
 
-		batchCompliances := []models.StatusCompliance{}
 		batchCompliances = append(batchCompliances, compliantCompliances...)
 		batchCompliances = append(batchCompliances, nonCompliantCompliances...)
 		batchCompliances = append(batchCompliances, unknownCompliances...)
 		batchCompliances = append(batchCompliances, pendingCompliances...)
 
+		// the clusters of the policy which aren't in the bundle
+		deletedCompliances = appendDeletedCompliances(deletedCompliances, policyID, allClustersOnDB)
+
+		// keep this policy in db, should remove from db only policies that were not sent in the bundle
+		delete(allComplianceClustersFromDB, policyID)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
 		// batch upsert
-		err = db.Clauses(clause.OnConflict{
-			UpdateAll: true,
-		}).CreateInBatches(batchCompliances, 100).Error
-		if err != nil {
-			return err
+		if e := database.UpsertInBatches(tx, batchCompliances); e != nil {
+			return e
 		}
 
 		// delete
-		err = db.Transaction(func(tx *gorm.DB) error {
-			for _, name := range allClustersOnDB.ToSlice() {
-				clusterName, ok := name.(string)
-				if !ok {
-					continue
-				}
-				err := tx.Where(&models.StatusCompliance{
-					LeafHubName: leafHubName,
-					PolicyID:    policyID,
-					ClusterName: clusterName,
-				}).Delete(&models.StatusCompliance{}).Error
-				if err != nil {
-					return err
-				}
+		for _, compliances := range database.Chunk(deletedCompliances) {
+			e := tx.Where("leaf_hub_name = ? AND (policy_id, cluster_name) IN ?", leafHubName, compliances).
+				Delete(&models.StatusCompliance{}).Error
+			if e != nil {
+				return e
 			}
-			return nil
-		})
-		if err != nil {
-			return fmt.Errorf("failed to handle clusters per policy bundle - %w", err)
 		}
-		// keep this policy in db, should remove from db only policies that were not sent in the bundle
-		delete(allComplianceClustersFromDB, policyID)
-	}
 
-	// delete the policy isn't contained on the bundle
-	err = db.Transaction(func(tx *gorm.DB) error {
-		for policyID := range allComplianceClustersFromDB {
-			err := tx.Where(&models.StatusCompliance{
-				PolicyID: policyID,
-			}).Delete(&models.StatusCompliance{}).Error
-			if err != nil {
-				return err
+		// delete the policy isn't contained on the bundle
+		for _, policyIDs := range database.Chunk(mapKeys(allComplianceClustersFromDB)) {
+			e := tx.Where("leaf_hub_name = ? AND policy_id IN ?", leafHubName, policyIDs).
+				Delete(&models.StatusCompliance{}).Error
+			if e != nil {
+				return e
 			}
 		}
 		return nil
@@ -177,6 +159,26 @@ func newCompliances(leafHub, policyID string, compliance database.ComplianceStat
 	return compliances
 }
 
+// appendDeletedCompliances appends the (policy_id, cluster_name) of the clusters for the batch delete
+func appendDeletedCompliances(compliances [][]interface{}, policyID string, clusters set.Set) [][]interface{} {
+	for _, name := range clusters.ToSlice() {
+		clusterName, ok := name.(string)
+		if !ok {
+			continue
+		}
+		compliances = append(compliances, []interface{}{policyID, clusterName})
+	}
+	return compliances
+}
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
 func getComplianceClusterSets(db *gorm.DB, query interface{}, args ...interface{}) (
 	map[string]*PolicyClustersSets, error,
 ) {
@@ -231,3 +233,13 @@ func (sets *PolicyClustersSets) GetAllClusters() set.Set {
 func (sets *PolicyClustersSets) GetClusters(complianceStatus database.ComplianceStatus) set.Set {
 	return sets.complianceToSetMap[complianceStatus]
 }
+
+// GetCompliance returns the compliance status of the cluster, it's false if the cluster isn't in any set.
+func (sets *PolicyClustersSets) GetCompliance(clusterName string) (database.ComplianceStatus, bool) {
+	for complianceStatus, clusters := range sets.complianceToSetMap {
+		if clusters.Contains(clusterName) {
+			return complianceStatus, true
+		}
+	}
+	return "", false
+}
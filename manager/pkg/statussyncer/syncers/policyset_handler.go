@@ -0,0 +1,21 @@
+package dbsyncer
+
+import (
+	"fmt"
+
+	policyv1beta1 "open-cluster-management.io/governance-policy-propagator/api/v1beta1"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/statussyncer/conflator"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// NewPolicySetHandler syncs the compliance of the PolicySets propagated from the global hub, the id of the row is the
+// uid of the PolicySet on the global hub
+func NewPolicySetHandler() conflator.Handler {
+	return NewGenericHandler[*policyv1beta1.PolicySet](
+		string(enum.PolicySetType),
+		conflator.PolicySetPriority,
+		enum.CompleteStateMode,
+		fmt.Sprintf("%s.%s", database.StatusSchema, database.PolicySetsTableName))
+}
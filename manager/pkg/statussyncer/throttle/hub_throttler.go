@@ -0,0 +1,124 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package throttle
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var (
+	ThrottledEventsCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "multicluster_global_hub_hub_throttled_events_total",
+			Help: "The number of the events received from the managed hub beyond the ingestion rate limit.",
+		},
+		[]string{"hub"},
+	)
+	ParkedHubsCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "multicluster_global_hub_hub_parked_total",
+			Help: "The number of times the processing of the managed hub is parked by the circuit breaker.",
+		},
+		[]string{"hub"},
+	)
+)
+
+type ThrottleConfig struct {
+	// EventRateLimit is the sustained number of events per second received from a managed hub, the throttling is
+	// disabled if it isn't greater than 0
+	EventRateLimit float64
+	EventBurst     int
+	// ParkThreshold is the number of consecutive events beyond the rate limit which trips the circuit breaker
+	ParkThreshold int
+	// ParkDuration is how long the processing of the hub is parked once the circuit breaker is tripped
+	ParkDuration time.Duration
+}
+
+// HubThrottler limits the ingestion rate of each managed hub. When a hub keeps exceeding the limit, the circuit
+// breaker parks the hub for a while, the kafka partition of the parked hub isn't consumed and the received events
+// aren't processed until the parking expires, so that a runaway agent can't starve the processing of the other hubs.
+type HubThrottler struct {
+	log    logr.Logger
+	config *ThrottleConfig
+	lock   sync.Mutex
+	hubs   map[string]*hubState
+	now    func() time.Time
+}
+
+type hubState struct {
+	limiter     *rate.Limiter
+	violations  int
+	parkedUntil time.Time
+}
+
+func NewHubThrottler(config *ThrottleConfig) *HubThrottler {
+	return &HubThrottler{
+		log:    ctrl.Log.WithName("hub-throttler"),
+		config: config,
+		hubs:   map[string]*hubState{},
+		now:    time.Now,
+	}
+}
+
+func (t *HubThrottler) enabled() bool {
+	return t != nil && t.config != nil && t.config.EventRateLimit > 0
+}
+
+// Record records an event received from the hub, and parks the hub if it keeps exceeding the rate limit. It returns
+// true if the hub is parked by the event
+func (t *HubThrottler) Record(hubName string) bool {
+	if !t.enabled() {
+		return false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	state, ok := t.hubs[hubName]
+	if !ok {
+		state = &hubState{
+			limiter: rate.NewLimiter(rate.Limit(t.config.EventRateLimit), t.config.EventBurst),
+		}
+		t.hubs[hubName] = state
+	}
+
+	if state.limiter.AllowN(now, 1) {
+		state.violations = 0
+		return false
+	}
+
+	ThrottledEventsCounterVec.WithLabelValues(hubName).Inc()
+	state.violations++
+	if state.violations < t.config.ParkThreshold {
+		return false
+	}
+
+	state.violations = 0
+	state.parkedUntil = now.Add(t.config.ParkDuration)
+	ParkedHubsCounterVec.WithLabelValues(hubName).Inc()
+	t.log.Info("park the hub which exceeds the event rate limit", "hub", hubName,
+		"rateLimit", t.config.EventRateLimit, "parkedUntil", state.parkedUntil)
+	return true
+}
+
+// ParkedUntil returns the time until which the hub is parked, the zero time means the hub isn't parked
+func (t *HubThrottler) ParkedUntil(hubName string) time.Time {
+	if !t.enabled() {
+		return time.Time{}
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	state, ok := t.hubs[hubName]
+	if !ok || !state.parkedUntil.After(t.now()) {
+		return time.Time{}
+	}
+	return state.parkedUntil
+}
@@ -0,0 +1,46 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubThrottler(t *testing.T) {
+	now := time.Now()
+	throttler := NewHubThrottler(&ThrottleConfig{
+		EventRateLimit: 1,
+		EventBurst:     2,
+		ParkThreshold:  3,
+		ParkDuration:   time.Minute,
+	})
+	throttler.now = func() time.Time { return now }
+
+	// the burst and the first violations don't park the hub
+	for i := 0; i < 4; i++ {
+		assert.False(t, throttler.Record("hub1"))
+	}
+	assert.True(t, throttler.ParkedUntil("hub1").IsZero())
+
+	// the hub is parked once the consecutive violations reach the threshold
+	assert.True(t, throttler.Record("hub1"))
+	assert.Equal(t, now.Add(time.Minute), throttler.ParkedUntil("hub1"))
+	assert.True(t, throttler.ParkedUntil("hub2").IsZero())
+
+	// the hub is resumed once the parking expires
+	now = now.Add(2 * time.Minute)
+	assert.True(t, throttler.ParkedUntil("hub1").IsZero())
+}
+
+func TestHubThrottlerDisabled(t *testing.T) {
+	throttler := NewHubThrottler(&ThrottleConfig{})
+	for i := 0; i < 1000; i++ {
+		assert.False(t, throttler.Record("hub1"))
+	}
+	assert.True(t, throttler.ParkedUntil("hub1").IsZero())
+
+	var nilThrottler *HubThrottler
+	assert.False(t, nilThrottler.Record("hub1"))
+	assert.True(t, nilThrottler.ParkedUntil("hub1").IsZero())
+}
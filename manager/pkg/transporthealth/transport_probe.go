@@ -0,0 +1,192 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package transporthealth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/dbhealth"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+const (
+	ConditionTypeTransportDegraded = "TransportDegraded"
+	ReasonTransportHealthy         = "TransportHealthy"
+	ReasonTransportSlow            = "TransportLatencyExceeded"
+	ReasonTransportUnavailable     = "TransportUnavailable"
+
+	// ProbeEventType is the type of the probe messages, they're consumed by the probe instead of the conflation
+	ProbeEventType = enum.EventTypePrefix + "transport.probe"
+	probeSource    = "global-hub-manager"
+	sendTimeout    = 10 * time.Second
+)
+
+var TransportProbeLatencyGauge = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "multicluster_global_hub_transport_probe_latency_seconds",
+		Help: "The round-trip latency of the last probe message sent and consumed by the manager.",
+	},
+)
+
+type ProbeConfig struct {
+	// Topic is where the probe messages are sent, it must be consumed by the manager. The probe is disabled if it's
+	// empty
+	Topic string
+	// Interval is the period of the probe messages, a probe message which isn't consumed in the interval is lost
+	Interval time.Duration
+	// LatencyThreshold is the round-trip latency over which the transport is degraded
+	LatencyThreshold time.Duration
+}
+
+// TransportProbe sends a probe message to the status topic periodically, and measures the latency until it's consumed
+// by the manager. The transport is reported as the TransportDegraded condition of the MulticlusterGlobalHub if the
+// latency exceeds the threshold, or the probe message isn't consumed before the next one is sent.
+type TransportProbe struct {
+	log       logr.Logger
+	client    client.Client
+	namespace string
+	config    *ProbeConfig
+	producer  transport.Producer
+
+	mutex sync.Mutex
+	// the probe message in flight, the id is empty once it's consumed
+	pendingID string
+	sentTime  time.Time
+	latency   time.Duration
+	probed    bool
+	// the condition which has been reflected to the mgh, nil if it isn't synced yet
+	synced *metav1.Condition
+}
+
+func NewTransportProbe(c client.Client, namespace string, config *ProbeConfig,
+	producer transport.Producer,
+) *TransportProbe {
+	return &TransportProbe{
+		log:       ctrl.Log.WithName("transport-probe"),
+		client:    c,
+		namespace: namespace,
+		config:    config,
+		producer:  producer,
+	}
+}
+
+func (p *TransportProbe) Start(ctx context.Context) error {
+	p.log.Info("starting transport probe", "topic", p.config.Topic, "interval", p.config.Interval,
+		"latencyThreshold", p.config.LatencyThreshold)
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		p.check(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Observe records the latency of the probe message, it returns true if the event is a probe message, so it isn't
+// dispatched to the conflation. It's always false for a nil probe
+func (p *TransportProbe) Observe(evt *cloudevents.Event) bool {
+	if p == nil || evt.Type() != ProbeEventType {
+		return false
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	// the stale probe messages, e.g. consumed again after the manager restarts, are dropped
+	if evt.ID() != p.pendingID {
+		return true
+	}
+	p.latency = time.Since(p.sentTime)
+	p.pendingID = ""
+	TransportProbeLatencyGauge.Set(p.latency.Seconds())
+	p.log.V(2).Info("received the probe message", "latency", p.latency)
+	return true
+}
+
+// check reflects the result of the previous probe message to the condition, and sends a new one
+func (p *TransportProbe) check(ctx context.Context) {
+	if condition := p.evaluate(); condition != nil {
+		p.updateCondition(ctx, *condition)
+	}
+
+	evt := cloudevents.NewEvent()
+	evt.SetID(uuid.New().String())
+	evt.SetType(ProbeEventType)
+	evt.SetSource(probeSource)
+	_ = evt.SetData(cloudevents.ApplicationJSON, map[string]string{"sentTime": time.Now().Format(time.RFC3339Nano)})
+
+	p.mutex.Lock()
+	p.pendingID, p.sentTime, p.probed = evt.ID(), time.Now(), true
+	p.mutex.Unlock()
+
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	if err := p.producer.SendEvent(sendCtx, evt); err != nil {
+		p.log.Error(err, "failed to send the probe message")
+		p.mutex.Lock()
+		p.pendingID = ""
+		p.mutex.Unlock()
+		p.updateCondition(ctx, metav1.Condition{
+			Type:    ConditionTypeTransportDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  ReasonTransportUnavailable,
+			Message: fmt.Sprintf("failed to send the probe message to the topic %s: %v", p.config.Topic, err),
+		})
+	}
+}
+
+// evaluate returns the condition of the previous probe message, it's nil if the probe message isn't sent yet
+func (p *TransportProbe) evaluate() *metav1.Condition {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.probed {
+		return nil
+	}
+	condition := &metav1.Condition{
+		Type:    ConditionTypeTransportDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonTransportHealthy,
+		Message: fmt.Sprintf("the round-trip latency of the transport is below %s", p.config.LatencyThreshold),
+	}
+	switch {
+	case p.pendingID != "":
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonTransportUnavailable
+		condition.Message = fmt.Sprintf("the probe message isn't consumed in %s",
+			time.Since(p.sentTime).Round(time.Millisecond))
+	case p.latency > p.config.LatencyThreshold:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ReasonTransportSlow
+		condition.Message = fmt.Sprintf("the round-trip latency of the transport is %s, which exceeds %s",
+			p.latency.Round(time.Millisecond), p.config.LatencyThreshold)
+	}
+	return condition
+}
+
+func (p *TransportProbe) updateCondition(ctx context.Context, condition metav1.Condition) {
+	if p.synced != nil && p.synced.Reason == condition.Reason && p.synced.Message == condition.Message {
+		return
+	}
+	if condition.Status == metav1.ConditionTrue {
+		p.log.Info("the transport is degraded", "reason", condition.Reason, "message", condition.Message)
+	}
+	if err := dbhealth.SetMGHCondition(ctx, p.client, p.namespace, condition); err != nil {
+		p.log.Error(err, "failed to update the transport condition of the multicluster global hub")
+		return
+	}
+	p.synced = &condition
+}
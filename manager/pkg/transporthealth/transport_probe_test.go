@@ -0,0 +1,95 @@
+package transporthealth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/dbhealth"
+)
+
+type fakeProducer struct {
+	events []cloudevents.Event
+}
+
+func (p *fakeProducer) SendEvent(ctx context.Context, evt cloudevents.Event) error {
+	p.events = append(p.events, evt)
+	return nil
+}
+
+func getCondition(t *testing.T, c client.Client, mgh *unstructured.Unstructured) map[string]interface{} {
+	assert.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(mgh), mgh))
+	conditions, _, err := unstructured.NestedSlice(mgh.Object, "status", "conditions")
+	assert.NoError(t, err)
+	for _, condition := range conditions {
+		if c, ok := condition.(map[string]interface{}); ok && c["type"] == ConditionTypeTransportDegraded {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestTransportProbe(t *testing.T) {
+	gvk := dbhealth.MulticlusterGlobalHubGVK
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+
+	mgh := &unstructured.Unstructured{}
+	mgh.SetGroupVersionKind(gvk)
+	mgh.SetNamespace("multicluster-global-hub")
+	mgh.SetName("multiclusterglobalhub")
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(mgh).WithStatusSubresource(mgh).Build()
+
+	ctx := context.Background()
+	producer := &fakeProducer{}
+	probe := NewTransportProbe(fakeClient, "multicluster-global-hub", &ProbeConfig{
+		Topic:            "gh-status.global-hub",
+		Interval:         time.Second,
+		LatencyThreshold: time.Second,
+	}, producer)
+
+	// the condition isn't reported before the first probe message
+	probe.check(ctx)
+	assert.Len(t, producer.events, 1)
+	assert.Nil(t, getCondition(t, fakeClient, mgh))
+
+	// the probe message is consumed in time
+	evt := producer.events[0]
+	assert.True(t, probe.Observe(&evt))
+	probe.check(ctx)
+	condition := getCondition(t, fakeClient, mgh)
+	assert.Equal(t, "False", condition["status"])
+	assert.Equal(t, ReasonTransportHealthy, condition["reason"])
+
+	// the probe message isn't consumed before the next one
+	probe.check(ctx)
+	condition = getCondition(t, fakeClient, mgh)
+	assert.Equal(t, "True", condition["status"])
+	assert.Equal(t, ReasonTransportUnavailable, condition["reason"])
+
+	// the latency exceeds the threshold
+	probe.sentTime = time.Now().Add(-2 * time.Second)
+	evt = producer.events[2]
+	assert.True(t, probe.Observe(&evt))
+	probe.check(ctx)
+	condition = getCondition(t, fakeClient, mgh)
+	assert.Equal(t, ReasonTransportSlow, condition["reason"])
+
+	// the stale probe message is dropped, and the other events are dispatched
+	evt = producer.events[0]
+	assert.True(t, probe.Observe(&evt))
+	other := cloudevents.NewEvent()
+	other.SetType("other")
+	assert.False(t, probe.Observe(&other))
+
+	var nilProbe *TransportProbe
+	assert.False(t, nilProbe.Observe(&evt))
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/audit"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+// NewAuditHandler is to record who creates, updates or deletes the global resources, the request is always allowed
+func NewAuditHandler(auditor *audit.Auditor) admission.Handler {
+	return &auditHandler{auditor: auditor}
+}
+
+type auditHandler struct {
+	auditor *audit.Auditor
+}
+
+func (a *auditHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	// the dry run requests don't change the resources
+	if req.DryRun != nil && *req.DryRun {
+		return admission.Allowed("")
+	}
+
+	log.V(2).Info("audit webhook is called", "name", req.Name, "namespace", req.Namespace,
+		"kind", req.Kind.Kind, "operation", req.Operation, "user", req.UserInfo.Username)
+
+	record := &models.AuditLog{
+		Source:    audit.SourceSpec,
+		Username:  req.UserInfo.Username,
+		Verb:      string(req.Operation),
+		Resource:  req.Resource.Resource,
+		Namespace: req.Namespace,
+		Name:      req.Name,
+	}
+	if req.Resource.Group != "" {
+		record.Resource = req.Resource.Resource + "." + req.Resource.Group
+	}
+	if len(req.UserInfo.Groups) > 0 {
+		record.Groups, _ = json.Marshal(req.UserInfo.Groups)
+	}
+	a.auditor.Record(record)
+
+	return admission.Allowed("")
+}
@@ -18,6 +18,7 @@ package v1alpha4
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -109,7 +110,7 @@ type AdvancedConfig struct {
 
 	// Kafka specifies the desired state of kafka
 	// +optional
-	Kafka *CommonSpec `json:"kafka,omitempty"`
+	Kafka *KafkaCommonSpec `json:"kafka,omitempty"`
 
 	// Zookeeper specifies the desired state of zookeeper
 	// +optional
@@ -117,15 +118,39 @@ type AdvancedConfig struct {
 
 	// Postgres specifies the desired state of postgres
 	// +optional
-	Postgres *CommonSpec `json:"postgres,omitempty"`
+	Postgres *PostgresCommonSpec `json:"postgres,omitempty"`
 
 	// Manager specifies the desired state of multicluster global hub manager
 	// +optional
-	Manager *CommonSpec `json:"manager,omitempty"`
+	Manager *ManagerCommonSpec `json:"manager,omitempty"`
 
 	// Agent specifies the desired state of multicluster global hub agent
 	// +optional
 	Agent *CommonSpec `json:"agent,omitempty"`
+
+	// Tracing exports the OpenTelemetry spans of the status bundles from the agents and the manager to the OTLP
+	// collector
+	// +optional
+	Tracing *TracingConfig `json:"tracing,omitempty"`
+}
+
+// TracingConfig specifies the OTLP collector of the OpenTelemetry spans
+type TracingConfig struct {
+	// Endpoint is the host:port of the OTLP gRPC receiver of the collector, e.g.
+	// otel-collector.observability.svc:4317. It must be reachable from the managed hubs as well
+	// +kubebuilder:validation:Required
+	Endpoint string `json:"endpoint"`
+
+	// Insecure disables the TLS of the connection to the collector
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// SamplingPercentage is the percentage of the status bundles which are traced, the sampling is
+	// decided by the agent and followed by the manager. The default value is 100
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	SamplingPercentage *int32 `json:"samplingPercentage,omitempty"`
 }
 
 type CommonSpec struct {
@@ -134,6 +159,193 @@ type CommonSpec struct {
 	Resources *ResourceRequirements `json:"resources,omitempty"`
 }
 
+// KafkaCommonSpec specifies the desired state of the built-in kafka
+type KafkaCommonSpec struct {
+	CommonSpec `json:",inline"`
+
+	// Autoscaling scales the resources of the kafka brokers by the consumer lag of the manager, the resources of
+	// the kafka are the lower bound
+	// +optional
+	Autoscaling *KafkaAutoscaling `json:"autoscaling,omitempty"`
+
+	// SpecOverride is merged onto the spec of the rendered Kafka resource with the JSON merge patch, e.g.
+	// {"kafka": {"config": {"num.io.threads": 16}}}, so the settings which aren't modeled by the global hub can be
+	// tuned. The fields set by it take precedence over the ones rendered by the operator
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +optional
+	SpecOverride *apiextensionsv1.JSON `json:"specOverride,omitempty"`
+}
+
+// ManagerCommonSpec specifies the desired state of the manager
+type ManagerCommonSpec struct {
+	CommonSpec `json:",inline"`
+
+	// Database tunes the connection pool of the manager and the timeouts of its queries
+	// +optional
+	Database *ManagerDatabase `json:"database,omitempty"`
+
+	// Jobs are the schedules of the summarization and the pruning jobs of the manager
+	// +optional
+	Jobs *ManagerJobs `json:"jobs,omitempty"`
+
+	// ComplianceSLO is the objective of the compliance of the policies and the managed hubs, the burn rate of its error
+	// budget is exported in the metrics and alerted if the metrics are enabled
+	// +optional
+	ComplianceSLO *ComplianceSLO `json:"complianceSLO,omitempty"`
+
+	// Notifications are the Slack and the Microsoft Teams channels notified when the policies become non-compliant on
+	// the clusters
+	// +optional
+	Notifications *ManagerNotifications `json:"notifications,omitempty"`
+
+	// Replicas is the number of the manager pods. Only the leader handles the status of the managed hubs unless the
+	// statusSharding is enabled. The default value is 1, or 2 if the availabilityConfig is High
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// StatusSharding splits the partitions of the status topic across the replicas with the kafka consumer group, so
+	// the status of the managed hubs is handled by all of them. It requires more than one replica and the kafka
+	// transport
+	// +optional
+	StatusSharding bool `json:"statusSharding,omitempty"`
+}
+
+// ManagerNotifications references the channels of the notifications in the "channels.yaml" key of the secret and the
+// config map in the namespace of the global hub, the channels of both are notified
+type ManagerNotifications struct {
+	// SecretName is the secret of the channels, the URLs of the incoming webhooks are the credentials, so they're
+	// supposed to be in the secret
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// ConfigMapName is the config map of the channels
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+// ComplianceSLO is the service level objective of the compliance
+type ComplianceSLO struct {
+	// Objective is the percentage of the compliant clusters of each policy and each managed hub, e.g. "99.5". It must be
+	// less than 100. The default value is "95"
+	// +kubebuilder:validation:Pattern=`^[0-9]{1,2}(\.[0-9]+)?$`
+	// +optional
+	Objective string `json:"objective,omitempty"`
+}
+
+// ManagerJobs are the schedules of the jobs of the manager in the standard cron format, e.g. "30 2 * * *". The
+// schedules are in the time zone of the manager, which is UTC unless the TZ environment variable is set
+type ManagerJobs struct {
+	// ComplianceHistorySchedule summarizes the local compliance of the day into the history. The default value is
+	// "0 0 * * *", or the interval of the mgh-scheduler-interval annotation if it's set
+	// +kubebuilder:validation:Pattern=`^(\S+ +){4}\S+$`
+	// +optional
+	ComplianceHistorySchedule string `json:"complianceHistorySchedule,omitempty"`
+
+	// ComplianceRollupSchedule rolls up the compliance history daily and weekly. The default value is "0 1 * * *"
+	// +kubebuilder:validation:Pattern=`^(\S+ +){4}\S+$`
+	// +optional
+	ComplianceRollupSchedule string `json:"complianceRollupSchedule,omitempty"`
+
+	// DataRetentionSchedule prunes the data which is older than the retention. The default value is "0 0 1,15,28 * *"
+	// +kubebuilder:validation:Pattern=`^(\S+ +){4}\S+$`
+	// +optional
+	DataRetentionSchedule string `json:"dataRetentionSchedule,omitempty"`
+}
+
+// ManagerDatabase is the connection pool of the manager and the timeouts of its queries
+type ManagerDatabase struct {
+	// PoolSize is the maximum number of the open connections of the manager, the default value is 10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PoolSize int32 `json:"poolSize,omitempty"`
+
+	// MaxIdleConnections is the maximum number of the idle connections kept in the pool, it's capped by the PoolSize.
+	// The default value is 2
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxIdleConnections int32 `json:"maxIdleConnections,omitempty"`
+
+	// StatementTimeout aborts the statements of the manager which run longer than it, e.g. "30s". It's set on the
+	// database role of the manager, so it also holds behind a pooler. The statements aren't limited if it isn't set
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h))+$`
+	// +optional
+	StatementTimeout string `json:"statementTimeout,omitempty"`
+
+	// SlowQueryThreshold logs the queries of the manager which run longer than it, e.g. "500ms". The default value
+	// is "200ms"
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h))+$`
+	// +optional
+	SlowQueryThreshold string `json:"slowQueryThreshold,omitempty"`
+}
+
+// PostgresCommonSpec specifies the desired state of the built-in postgres
+type PostgresCommonSpec struct {
+	CommonSpec `json:",inline"`
+
+	// ConnectionPooler deploys the pgBouncer in front of the built-in postgres, grafana connects to the pooler instead
+	// of the postgres, so its connections don't exhaust the max_connections of the postgres. The manager still connects
+	// to the postgres, since it holds the advisory locks in its sessions
+	// +optional
+	ConnectionPooler *PostgresConnectionPooler `json:"connectionPooler,omitempty"`
+}
+
+// PostgresConnectionPooler defines the pgBouncer in front of the built-in postgres
+type PostgresConnectionPooler struct {
+	// Replicas is the number of the pgBouncer pods
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// PoolMode is when the server connection is released back to the pool, after each transaction or when the client
+	// disconnects
+	// +kubebuilder:validation:Enum=transaction;session
+	// +kubebuilder:default:=transaction
+	// +optional
+	PoolMode PostgresPoolMode `json:"poolMode,omitempty"`
+
+	// DefaultPoolSize is the number of the server connections of each user, so the connections of the postgres are at
+	// most the pool size multiplied by the users and the replicas of the pooler
+	// +kubebuilder:default:=20
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DefaultPoolSize int32 `json:"defaultPoolSize,omitempty"`
+
+	// MaxClientConnections is the number of the client connections each pgBouncer pod accepts
+	// +kubebuilder:default:=1000
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxClientConnections int32 `json:"maxClientConnections,omitempty"`
+
+	// Compute Resources required by the pgBouncer
+	// +optional
+	Resources *ResourceRequirements `json:"resources,omitempty"`
+}
+
+// KafkaAutoscaling defines how the kafka brokers are scaled by the consumer lag of the manager on the status topics
+type KafkaAutoscaling struct {
+	// LagThreshold is the total consumer lag of the manager on the status topics. The resources of the brokers are
+	// doubled when the lag exceeds it, and halved when the lag drops below half of it
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Required
+	LagThreshold int64 `json:"lagThreshold"`
+
+	// MaxResources is the upper bound of the broker resources, the resources which aren't in it aren't scaled
+	// +kubebuilder:validation:Required
+	MaxResources *ResourceRequirements `json:"maxResources"`
+
+	// CooldownPeriod is the minimum interval between two scaling decisions, e.g. "30m". The default is "15m"
+	// +optional
+	CooldownPeriod string `json:"cooldownPeriod,omitempty"`
+
+	// Rebalance requests a rebalance with the cruise control when the resources reach the upper bound and the lag
+	// still exceeds the threshold. It requires the cruise control of the kafka
+	// +optional
+	Rebalance bool `json:"rebalance,omitempty"`
+}
+
 // ResourceRequirements copied from corev1.ResourceRequirements
 // We do not need to support ResourceClaim
 type ResourceRequirements struct {
@@ -160,6 +372,52 @@ type DataLayerConfig struct {
 	// StorageClass specifies the class for storage
 	// +optional
 	StorageClass string `json:"storageClass,omitempty"`
+	// Nats uses the NATS JetStream as the transport instead of kafka, it's for the edge deployments which can't
+	// afford the kafka brokers. The kafka settings are ignored if it's set
+	// +optional
+	Nats *NatsConfig `json:"nats,omitempty"`
+	// GRPC streams the bundles between the agents and the manager over a gRPC gateway instead of kafka, it's for the
+	// environments which prohibit exposing kafka to the managed hubs. The kafka settings are ignored if it's set
+	// +optional
+	GRPC *GRPCConfig `json:"grpc,omitempty"`
+	// HTTP posts and long polls the bundles on an HTTPS gateway of the manager instead of kafka, it's for the managed
+	// hubs which only have the egress through the HTTPS proxies. The kafka settings are ignored if it's set
+	// +optional
+	HTTP *HTTPConfig `json:"http,omitempty"`
+}
+
+// GRPCConfig defines the gRPC transport, the operator exposes the manager with a passthrough route, and issues the
+// mTLS certificates of the manager and the managed hubs
+type GRPCConfig struct {
+	// Host is the host of the gateway route for the managed hubs, it's generated by the router if it isn't set
+	// +optional
+	Host string `json:"host,omitempty"`
+}
+
+// HTTPConfig defines the HTTP transport, the operator exposes the manager with a passthrough route, and issues the
+// mTLS certificates of the manager and the managed hubs
+type HTTPConfig struct {
+	// Host is the host of the gateway route for the managed hubs, it's generated by the router if it isn't set
+	// +optional
+	Host string `json:"host,omitempty"`
+}
+
+// NatsConfig defines the NATS JetStream transport, the streams are provisioned by the NATS JetStream controller
+type NatsConfig struct {
+	// TransportSecretName is the name of the secret which contains the connection of the NATS server, the keys are
+	// "url" and the optional "ca.crt". The secret must be in the namespace of the global hub
+	// +kubebuilder:validation:Required
+	TransportSecretName string `json:"transportSecretName"`
+
+	// StreamReplicas is the number of the replicas of the streams, the default value is 1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	StreamReplicas int32 `json:"streamReplicas,omitempty"`
+
+	// MaxAge is the maximum age of the messages in the streams, e.g. "24h". The messages are kept until the
+	// stream limits are reached if it isn't set
+	// +optional
+	MaxAge string `json:"maxAge,omitempty"`
 }
 
 // PostgresConfig defines the desired state of postgres
@@ -172,9 +430,339 @@ type PostgresConfig struct {
 	// +kubebuilder:default:="18m"
 	Retention string `json:"retention,omitempty"`
 
+	// RetentionPolicy overrides the Retention for each class of the data, the classes which aren't set are kept for
+	// the Retention. The data is pruned by the data retention job of the manager
+	// +optional
+	RetentionPolicy *DataRetentionPolicy `json:"retentionPolicy,omitempty"`
+
 	// StorageSize specifies the size for storage
 	// +optional
 	StorageSize string `json:"storageSize,omitempty"`
+
+	// Backend provisions the built-in postgres, the "statefulset" is a single instance, while the "crunchy" and the
+	// "cloudnativepg" provision a highly available cluster with the Crunchy PGO or the CloudNativePG operator. The
+	// CloudNativePG operator must be installed, while the Crunchy PGO is installed by the global hub operator
+	// +kubebuilder:validation:Enum=statefulset;crunchy;cloudnativepg
+	// +kubebuilder:default:=statefulset
+	// +optional
+	Backend PostgresBackendType `json:"backend,omitempty"`
+
+	// Instances is the number of the postgres instances of the "crunchy" and the "cloudnativepg" backends, one of them
+	// is the primary and the others are the streaming replicas for the failover. The default value is 3
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Instances int32 `json:"instances,omitempty"`
+
+	// External connects the data layer to an existing postgres instead of the built-in one, the built-in postgres
+	// isn't deployed if it's set. The database schema is still initialized and upgraded by the operator, and the data
+	// retention still runs against it
+	// +optional
+	External *ExternalPostgres `json:"external,omitempty"`
+
+	// TimescaleDB converts the event and the compliance history tables to the hypertables of the timescaledb
+	// extension, the chunks are dropped by the retention policy of the extension instead of the monthly partitions.
+	// The extension must be available in the postgres, and the tables can't be converted back once it's enabled
+	// +optional
+	TimescaleDB *PostgresTimescaleDB `json:"timescaleDB,omitempty"`
+
+	// Backup takes the scheduled logical backups of the database with the pg_dump, and uploads them to an S3
+	// compatible bucket. The result of the latest backup is reported as the DatabaseBackup condition
+	// +optional
+	Backup *PostgresBackup `json:"backup,omitempty"`
+
+	// WALArchive archives the WAL of the "crunchy" and the "cloudnativepg" backends to an S3 compatible bucket with the
+	// scheduled base backups, so the database can be restored to a point in time. The state of the archive and of the
+	// restore is reported as the DatabaseWALArchive condition
+	// +optional
+	WALArchive *PostgresWALArchive `json:"walArchive,omitempty"`
+
+	// EventArchive uploads the expired partitions of the policy and the managed cluster events to an S3 compatible
+	// bucket as the gzipped CSV before the data retention prunes them, so the long-term audits remain possible without
+	// keeping the events in the database. A partition isn't pruned until it's archived
+	// +optional
+	EventArchive *PostgresEventArchive `json:"eventArchive,omitempty"`
+
+	// Maintenance runs the VACUUM, the ANALYZE and the REINDEX of the database in a scheduled window, the compliance
+	// and the event tables bloat after months of the updates and the deletes
+	// +optional
+	Maintenance *PostgresMaintenance `json:"maintenance,omitempty"`
+
+	// Tenants grants the tenant teams the read access to the data of their managed hubs only. Each tenant gets a
+	// database role, and the row-level security of the tables filters out the rows of the other managed hubs
+	// +optional
+	Tenants []PostgresTenant `json:"tenants,omitempty"`
+
+	// TLS replaces the certificate of the built-in postgres and its connection pooler, which is signed by the
+	// service CA by default. The manager and grafana verify the certificate and the host name of the postgres
+	// +optional
+	TLS *PostgresTLS `json:"tls,omitempty"`
+
+	// Encryption encrypts the payloads of the policies before the manager stores them in the database, the policy
+	// templates may contain the credentials. The metadata of the policies is kept in plain text for the dashboards
+	// +optional
+	Encryption *PostgresEncryption `json:"encryption,omitempty"`
+}
+
+// PostgresEncryption is the data key which encrypts the policy payloads in the database
+type PostgresEncryption struct {
+	// KeySecretName is the name of the secret in the namespace of the global hub which contains the data key in the
+	// "key" field. The previous keys are kept in the "key.<suffix>" fields to decrypt the existing payloads after the
+	// key is rotated. A random key is generated if the secret doesn't exist and the Vault isn't set
+	// +kubebuilder:default:="multicluster-global-hub-postgres-encryption"
+	// +optional
+	KeySecretName string `json:"keySecretName,omitempty"`
+
+	// Vault unwraps the data keys with the transit secrets engine of the HashiCorp Vault, the keys of the secret are
+	// the wrapped data keys instead of the plain ones, and the secret contains the Vault token in the "vault-token"
+	// field and the optional CA of the Vault in the "vault-ca.crt" field
+	// +optional
+	Vault *PostgresEncryptionVault `json:"vault,omitempty"`
+}
+
+// PostgresEncryptionVault is the transit secrets engine of the HashiCorp Vault which wraps the data keys
+type PostgresEncryptionVault struct {
+	// Address is the URL of the Vault, e.g. "https://vault.vault.svc:8200"
+	// +kubebuilder:validation:Required
+	Address string `json:"address"`
+
+	// TransitMount is the mount path of the transit secrets engine
+	// +kubebuilder:default:="transit"
+	// +optional
+	TransitMount string `json:"transitMount,omitempty"`
+
+	// KeyName is the name of the transit key which wraps the data keys
+	// +kubebuilder:validation:Required
+	KeyName string `json:"keyName"`
+}
+
+// PostgresTLS is the certificate of the built-in postgres
+type PostgresTLS struct {
+	// CertificateSecretName is the name of the secret in the namespace of the global hub, which contains the
+	// "tls.crt", the "tls.key" and the "ca.crt". The certificate must be valid for the
+	// "multicluster-global-hub-postgres.<namespace>.svc" and the "multicluster-global-hub-postgres-pooler.<namespace>.svc".
+	// The postgres reloads the certificate once the secret is updated
+	// +kubebuilder:validation:Required
+	CertificateSecretName string `json:"certificateSecretName"`
+}
+
+// PostgresBackup is the schedule and the object storage of the database backups
+type PostgresBackup struct {
+	// Schedule is the cron schedule of the backups, the default value is every day at 02:00
+	// +kubebuilder:default:="0 2 * * *"
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// Bucket is the name of the bucket to upload the backups
+	// +kubebuilder:validation:Required
+	Bucket string `json:"bucket"`
+
+	// Prefix is the prefix of the backup objects in the bucket, the default value is "multicluster-global-hub"
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Endpoint is the URL of the S3 compatible object storage, e.g. the MinIO or the OpenShift Data Foundation. The
+	// AWS S3 is used if it isn't set
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the region of the bucket
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecretName is the name of the secret in the namespace of the global hub, which contains the
+	// "aws_access_key_id" and the "aws_secret_access_key" of the object storage
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName"`
+
+	// RetainedBackups is the number of the latest backups kept in the bucket, the older ones are deleted after each
+	// backup. The default value is 7
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RetainedBackups int32 `json:"retainedBackups,omitempty"`
+}
+
+// PostgresWALArchive is the continuous archiving of the WAL and the base backups for the point-in-time recovery
+type PostgresWALArchive struct {
+	// Bucket is the name of the bucket to archive the WAL and the base backups
+	// +kubebuilder:validation:Required
+	Bucket string `json:"bucket"`
+
+	// Prefix is the path of the archive in the bucket, the default value is "multicluster-global-hub-wal"
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Endpoint is the URL of the S3 compatible object storage, the AWS S3 is used if it isn't set
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the region of the bucket
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecretName is the name of the secret in the namespace of the global hub, which contains the
+	// "aws_access_key_id" and the "aws_secret_access_key" of the object storage
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName"`
+
+	// BaseBackupSchedule is the cron schedule of the base backups, the WAL is replayed from the latest base backup
+	// before the restore target. The default value is every day at 01:00
+	// +kubebuilder:default:="0 1 * * *"
+	// +optional
+	BaseBackupSchedule string `json:"baseBackupSchedule,omitempty"`
+
+	// RetentionDays is the number of the days the base backups and the WAL are kept in the bucket, the database can't
+	// be restored to a time older than it. The default value is 7
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RetentionDays int32 `json:"retentionDays,omitempty"`
+
+	// RestoreTargetTime restores the database to the time, e.g. "2024-06-15T08:00:00Z", with the base backup and the
+	// WAL of the archive. The restore runs once for each target time, and the data written after the target time is
+	// lost. The "cloudnativepg" backend recreates the postgres cluster from the archive, while the "crunchy" backend
+	// restores it in place
+	// +kubebuilder:validation:Format=date-time
+	// +optional
+	RestoreTargetTime string `json:"restoreTargetTime,omitempty"`
+}
+
+// PostgresEventArchive is the object storage of the archives of the expired events
+type PostgresEventArchive struct {
+	// Bucket is the name of the bucket to upload the archives
+	// +kubebuilder:validation:Required
+	Bucket string `json:"bucket"`
+
+	// Prefix is the path of the archives in the bucket, the default value is "multicluster-global-hub-events"
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Endpoint is the URL of the S3 compatible object storage, e.g. the MinIO or the OpenShift Data Foundation. The
+	// AWS S3 is used if it isn't set
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the region of the bucket
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecretName is the name of the secret in the namespace of the global hub, which contains the
+	// "aws_access_key_id" and the "aws_secret_access_key" of the object storage
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// PostgresTenant is the database role of a tenant team and the managed hubs it can read
+type PostgresTenant struct {
+	// Name is the name of the tenant. The database role is "tenant_<name>" with the hyphens replaced by the underscores,
+	// and its connection URI is saved in the "multicluster-global-hub-postgres-tenant-<name>" secret of the global hub
+	// namespace
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=40
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// ManagedHubs are the names of the managed hubs whose data the tenant can read
+	// +kubebuilder:validation:Required
+	ManagedHubs []string `json:"managedHubs"`
+}
+
+// PostgresMaintenanceTask is the maintenance operation on the tables of the database
+// +kubebuilder:validation:Enum=vacuum;analyze;reindex
+type PostgresMaintenanceTask string
+
+const (
+	// PostgresMaintenanceVacuum reclaims the storage of the dead tuples
+	PostgresMaintenanceVacuum PostgresMaintenanceTask = "vacuum"
+	// PostgresMaintenanceAnalyze updates the statistics of the query planner
+	PostgresMaintenanceAnalyze PostgresMaintenanceTask = "analyze"
+	// PostgresMaintenanceReindex rebuilds the bloated indexes concurrently, it doesn't block the writes
+	PostgresMaintenanceReindex PostgresMaintenanceTask = "reindex"
+)
+
+// PostgresMaintenance is the window and the tasks of the database maintenance
+type PostgresMaintenance struct {
+	// Schedule is the cron schedule of the start of the maintenance window, the default value is every Sunday at 03:00
+	// +kubebuilder:default:="0 3 * * 0"
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// Duration is the length of the maintenance window, e.g. "2h" or "90m". The maintenance is stopped once the window
+	// is over, and the remaining tables are processed in the next window. The default value is "2h"
+	// +kubebuilder:validation:Pattern=`^([0-9]+h)?([0-9]+m)?$`
+	// +kubebuilder:default:="2h"
+	// +optional
+	Duration string `json:"duration,omitempty"`
+
+	// Tasks are the maintenance operations run in the window, the default value is the vacuum and the analyze
+	// +optional
+	Tasks []PostgresMaintenanceTask `json:"tasks,omitempty"`
+}
+
+// DataRetentionPolicy is the retention of each class of the data, it's a duration string of the months and the
+// years, such as "6m" or "1y6m"
+type DataRetentionPolicy struct {
+	// Events is how long to keep the policy and the managed cluster events
+	// +kubebuilder:validation:Pattern=`^[0-9][0-9my]*[m|y]$`
+	// +optional
+	Events string `json:"events,omitempty"`
+
+	// ComplianceHistory is how long to keep the daily compliance history of the policies
+	// +kubebuilder:validation:Pattern=`^[0-9][0-9my]*[m|y]$`
+	// +optional
+	ComplianceHistory string `json:"complianceHistory,omitempty"`
+
+	// ComplianceRollups is how long to keep the daily and weekly compliance rollups of the policies, it's usually
+	// longer than the ComplianceHistory for the long-term reporting
+	// +kubebuilder:validation:Pattern=`^[0-9][0-9my]*[m|y]$`
+	// +optional
+	ComplianceRollups string `json:"complianceRollups,omitempty"`
+
+	// Heartbeats is how long to keep the heartbeats of the inactive managed hubs
+	// +kubebuilder:validation:Pattern=`^[0-9][0-9my]*[m|y]$`
+	// +optional
+	Heartbeats string `json:"heartbeats,omitempty"`
+
+	// AuditLogs is how long to keep the audit logs of the global resources and the manager API
+	// +kubebuilder:validation:Pattern=`^[0-9][0-9my]*[m|y]$`
+	// +optional
+	AuditLogs string `json:"auditLogs,omitempty"`
+}
+
+// PostgresTimescaleDB is the hypertable configuration of the time-series tables
+type PostgresTimescaleDB struct {
+	// ChunkTimeInterval is the time range of each chunk of the hypertables, it's a postgres interval, e.g. "1 day"
+	// +kubebuilder:validation:Pattern=`^[1-9][0-9]* (hour|day|week|month)s?$`
+	// +kubebuilder:default:="7 days"
+	// +optional
+	ChunkTimeInterval string `json:"chunkTimeInterval,omitempty"`
+}
+
+// ExternalPostgres references the secrets of an existing postgres, the secrets must be in the namespace of the
+// global hub
+type ExternalPostgres struct {
+	// ConnectionSecretName is the name of the secret which contains the connection URIs of the postgres, the keys are
+	// "database_uri" of the user who owns the database, and "database_uri_with_readonlyuser" of the read-only user
+	// for grafana. The optional "replica_database_uri" and "replica_database_uri_with_readonlyuser" connect to a
+	// read-only replica, grafana and the read-only APIs of the manager query the replica if they're set
+	// +kubebuilder:validation:Required
+	ConnectionSecretName string `json:"connectionSecretName"`
+
+	// CASecretName is the name of the secret which contains the "ca.crt" of the postgres server. The "ca.crt" of the
+	// connection secret is used if it isn't set
+	// +optional
+	CASecretName string `json:"caSecretName,omitempty"`
+
+	// Authentication is how the manager authenticates to the postgres. The "aws-iam" and "azure-ad" use the short-lived
+	// tokens of the cloud identity instead of the password of the database_uri, the tokens are refreshed for each new
+	// connection of the pool
+	// +kubebuilder:validation:Enum=password;aws-iam;azure-ad
+	// +kubebuilder:default:=password
+	// +optional
+	Authentication PostgresAuthenticationType `json:"authentication,omitempty"`
+
+	// Identity is the cloud identity of the manager to get the tokens, it's the IAM role ARN for the "aws-iam", or the
+	// client ID of the managed identity for the "azure-ad". It's annotated on the service account of the manager
+	// +optional
+	Identity string `json:"identity,omitempty"`
 }
 
 // KafkaConfig defines the desired state of kafka
@@ -186,6 +774,627 @@ type KafkaConfig struct {
 	// StorageSize specifies the size for storage
 	// +optional
 	StorageSize string `json:"storageSize,omitempty"`
+
+	// HubQuotas specifies the quotas applied to the kafka user of each managed hub, it protects the brokers and the
+	// manager from a managed hub which sends an unexpected amount of data
+	// +optional
+	HubQuotas *KafkaUserQuotas `json:"hubQuotas,omitempty"`
+
+	// HubRateLimit throttles the status messages sent by the agent of each managed hub, so a managed hub which replays
+	// its events after reconnecting doesn't saturate the transport. The limit of a managed hub can be overridden with
+	// the "global-hub.open-cluster-management.io/status-rate-limit" annotation of its managed cluster
+	// +optional
+	HubRateLimit *KafkaHubRateLimit `json:"hubRateLimit,omitempty"`
+
+	// HubAuthentication specifies how the agents of the managed hubs authenticate to the built-in kafka, the value
+	// can be "tls" or "scram-sha-512". The default is "tls", the scram-sha-512 uses the username and password
+	// instead of the client certificate
+	// +kubebuilder:validation:Enum=tls;scram-sha-512
+	// +optional
+	HubAuthentication KafkaAuthenticationType `json:"hubAuthentication,omitempty"`
+
+	// TransportSecretName is the name of the secret which contains the connection of an existing kafka cluster,
+	// the keys are "bootstrap_server", "ca.crt", "client.crt" and "client.key". If the secret exists, the
+	// global hub uses the kafka cluster instead of installing the built-in kafka. The secret must be in the
+	// namespace of the global hub, and the default value is "multicluster-global-hub-transport"
+	// +optional
+	TransportSecretName string `json:"transportSecretName,omitempty"`
+
+	// ExternalListener specifies how the built-in kafka is exposed to the managed hubs. The default is an OpenShift
+	// route, use the loadbalancer, nodeport or ingress type when the routes aren't available
+	// +optional
+	ExternalListener *KafkaExternalListener `json:"externalListener,omitempty"`
+
+	// CruiseControl deploys the cruise control with the built-in kafka, so the partitions are rebalanced across
+	// the brokers when the managed hubs are added
+	// +optional
+	CruiseControl *KafkaCruiseControl `json:"cruiseControl,omitempty"`
+
+	// SpecTopicConfig specifies the retention and cleanup policy of the spec topic
+	// +optional
+	SpecTopicConfig *KafkaTopicConfig `json:"specTopicConfig,omitempty"`
+
+	// StatusTopicConfig specifies the retention and cleanup policy of the status topics
+	// +optional
+	StatusTopicConfig *KafkaTopicConfig `json:"statusTopicConfig,omitempty"`
+
+	// TopicDriftPolicy is how the kafka topics and the kafka users of the managed hubs are handled when they're
+	// changed out of band, e.g. the config or the partitions of a topic, or the acls of a user. They're compared with
+	// the desired ones periodically, the "repair" restores them, and the "report" only reports the differences in the
+	// TopicConfigDrift condition. The default is "repair"
+	// +kubebuilder:validation:Enum=repair;report
+	// +kubebuilder:default:=repair
+	// +optional
+	TopicDriftPolicy KafkaTopicDriftPolicy `json:"topicDriftPolicy,omitempty"`
+
+	// OrphanPolicy is how the kafka users and the status topics of the managed hubs are handled once the managed
+	// clusters of them no longer exist. They're swept periodically, the "delete" removes them, and the "dryRun" only
+	// reports them in the KafkaOrphans condition. The default is "delete"
+	// +kubebuilder:validation:Enum=delete;dryRun
+	// +kubebuilder:default:=delete
+	// +optional
+	OrphanPolicy KafkaOrphanPolicy `json:"orphanPolicy,omitempty"`
+
+	// TieredStorage offloads the log segments of the status topics to the remote storage, e.g. a S3 compatible
+	// object store, so the history of the events doesn't require the ever-growing kafka volumes
+	// +optional
+	TieredStorage *KafkaTieredStorage `json:"tieredStorage,omitempty"`
+
+	// SchemaRegistry is the schema registry, e.g. Apicurio or Confluent, the manager and the agents register the
+	// avro schema of the bundles and encode the bundles with avro, instead of JSON
+	// +optional
+	SchemaRegistry *KafkaSchemaRegistry `json:"schemaRegistry,omitempty"`
+
+	// PayloadEncoding is the encoding of the bundles sent by the manager and the agents, the value can be "json" or
+	// "protobuf". The protobuf bundles are smaller, the consumers decode the bundles by the content type header of
+	// the messages, so the manager and the agents can be switched independently. It's ignored if the schemaRegistry
+	// is set. The default is "json"
+	// +kubebuilder:validation:Enum=json;protobuf
+	// +optional
+	PayloadEncoding string `json:"payloadEncoding,omitempty"`
+
+	// DeliveryMode is the delivery guarantee of the status bundles, the value can be "at-least-once" or
+	// "exactly-once". The agents send the status bundles in the kafka transactions with the idempotent producers,
+	// and the manager only reads the committed bundles, so the bundles aren't duplicated after the reconnections.
+	// The default is "at-least-once"
+	// +kubebuilder:validation:Enum=at-least-once;exactly-once
+	// +optional
+	DeliveryMode KafkaDeliveryMode `json:"deliveryMode,omitempty"`
+
+	// CertManager delegates the cluster CA and the clients CA of the built-in kafka to the cert-manager issuers,
+	// instead of the CAs generated by the strimzi operator. The listener certificates are signed by the cluster CA,
+	// and the client certificates of the manager and the managed hubs are signed by the clients CA
+	// +optional
+	CertManager *KafkaCertManager `json:"certManager,omitempty"`
+
+	// ZoneAware spreads the kafka brokers and the zookeeper nodes across the availability zones, and configures the
+	// broker racks by the "topology.kubernetes.io/zone" label of the nodes, so the replicas of each partition are
+	// placed in different zones
+	// +optional
+	ZoneAware bool `json:"zoneAware,omitempty"`
+
+	// Stretched places the built-in kafka across two sites and a tie-breaker site, so the transport survives the loss
+	// of a site. Each site runs 2 of the 4 brokers and 2 of the 5 zookeeper nodes, and the tie-breaker site only runs
+	// a zookeeper node to keep the quorum. The partitions are replicated to both sites. It takes precedence over the
+	// zoneAware, and it doesn't support the node pools. It should be set before the topics are created, since the
+	// replicas of the existing topics aren't changed
+	// +optional
+	Stretched *KafkaStretchedCluster `json:"stretched,omitempty"`
+
+	// DisruptionBudget limits the brokers and the zookeeper nodes which are evicted at the same time, e.g. when the
+	// nodes are drained by a cluster upgrade. The strimzi operator renders the pod disruption budgets with it
+	// +optional
+	DisruptionBudget *KafkaDisruptionBudget `json:"disruptionBudget,omitempty"`
+
+	// TLSOnly removes the plaintext listener on the port 9092 of the built-in kafka, and adds the internal listener
+	// with the mutual tls on the port 9095 instead. The manager connects to the internal tls listener with its
+	// client certificate, so no traffic to the brokers is unencrypted
+	// +optional
+	TLSOnly bool `json:"tlsOnly,omitempty"`
+
+	// StatusLagAlert specifies the thresholds of the alerts on the consumer lag of the manager on the status topic
+	// of each managed hub. The alerts are rendered with the kafka exporter if the metrics are enabled
+	// +optional
+	StatusLagAlert *KafkaStatusLagAlert `json:"statusLagAlert,omitempty"`
+
+	// NodePools replaces the 3 brokers of the built-in kafka with the strimzi node pools, so the brokers of
+	// different sizes and storage classes are mixed, and each pool is scaled independently. The existing brokers
+	// are migrated to the pool named "kafka". The node pools can't be disabled once they're enabled
+	// +optional
+	NodePools []KafkaNodePool `json:"nodePools,omitempty"`
+
+	// Volumes adds the volumes to the JBOD storage of each broker, besides the volume 0 with the storageSize. The
+	// partitions are spread across the volumes of the broker. The volumes removed from the list are kept on the
+	// brokers, since removing them deletes the partitions on them
+	// +optional
+	Volumes []KafkaVolume `json:"volumes,omitempty"`
+
+	// ExistingCluster reuses a kafka cluster of an existing Strimzi or AMQ Streams operator, instead of installing the
+	// operator and the built-in kafka. The global hub only manages its topics and users on the cluster, the settings
+	// of the kafka cluster itself, e.g. the storage, the listeners and the node pools, are ignored
+	// +optional
+	ExistingCluster *KafkaExistingCluster `json:"existingCluster,omitempty"`
+
+	// Subscription specifies the operator subscription of the built-in kafka, e.g. the channel and the catalog source
+	// mirrored for the disconnected cluster. It's ignored if the existingCluster is set
+	// +optional
+	Subscription *KafkaSubscription `json:"subscription,omitempty"`
+
+	// Connect deploys the kafka connect with the connectors, e.g. the S3 sink or the Elasticsearch sink, so the events
+	// of the status topics are streamed to the external systems without the custom consumers. It isn't supported for
+	// the existingCluster
+	// +optional
+	Connect *KafkaConnect `json:"connect,omitempty"`
+
+	// Bridge deploys the strimzi kafka bridge, so the external tools publish and inspect the global hub topics with
+	// the http api. The bridge is exposed by a route behind the openshift oauth proxy, only the users and the service
+	// accounts which can get the kafkabridges in the global hub namespace are allowed. It isn't supported for the
+	// existingCluster
+	// +optional
+	Bridge *KafkaBridge `json:"bridge,omitempty"`
+}
+
+// KafkaBridge defines the strimzi kafka bridge
+type KafkaBridge struct {
+	// Replicas is the number of the kafka bridge pods
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+}
+
+// KafkaConnect defines the kafka connect cluster and the connectors running on it
+type KafkaConnect struct {
+	// Replicas is the number of the kafka connect workers, the tasks of the connectors are spread across them
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Image is the kafka connect image with the plugins of the connectors, the default kafka image of the strimzi
+	// operator only contains the file connectors
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// SecretNames are the secrets in the global hub namespace which are mounted to the kafka connect workers, the
+	// connector config refers to the keys of them with
+	// "${directory:/opt/kafka/external-configuration/<secret-name>:<key>}", e.g. the credentials of the sink
+	// +optional
+	SecretNames []string `json:"secretNames,omitempty"`
+
+	// Connectors are the connectors deployed to the kafka connect, the connectors removed from the list are deleted
+	// +optional
+	Connectors []KafkaConnector `json:"connectors,omitempty"`
+}
+
+// KafkaConnector defines a connector of the kafka connect
+type KafkaConnector struct {
+	// Name is the name of the connector
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Class is the class of the connector plugin, e.g. "io.confluent.connect.s3.S3SinkConnector"
+	// +kubebuilder:validation:Required
+	Class string `json:"class"`
+
+	// TasksMax is the maximum number of the tasks of the connector
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TasksMax int32 `json:"tasksMax,omitempty"`
+
+	// Topics is the comma separated topics consumed by the sink connector, the default is all the status topics
+	// +optional
+	Topics string `json:"topics,omitempty"`
+
+	// Config is the config of the connector plugin, e.g. the bucket of the S3 sink
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
+}
+
+// KafkaSubscription defines the olm subscription of the AMQ Streams or Strimzi operator, the unset fields are the
+// defaults of the AMQ Streams, or the Strimzi in the community mode
+type KafkaSubscription struct {
+	// Channel is the channel of the operator package, e.g. "amq-streams-2.7.x"
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9]([-._a-zA-Z0-9]*[a-zA-Z0-9])?$`
+	// +optional
+	Channel string `json:"channel,omitempty"`
+
+	// CatalogSource is the name of the catalog source which provides the operator package, e.g. the catalog source
+	// of the mirrored registry. The default is "redhat-operators"
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	CatalogSource string `json:"catalogSource,omitempty"`
+
+	// CatalogSourceNamespace is the namespace of the catalog source, the default is "openshift-marketplace"
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	CatalogSourceNamespace string `json:"catalogSourceNamespace,omitempty"`
+
+	// InstallPlanApproval is the approval of the install plans of the operator, the value can be "Automatic" or
+	// "Manual". The default is "Automatic"
+	// +kubebuilder:validation:Enum=Automatic;Manual
+	// +optional
+	InstallPlanApproval string `json:"installPlanApproval,omitempty"`
+
+	// ApprovedVersions is the semver range of the operator versions which are approved by the global hub operator
+	// with the manual install plan approval, e.g. ">=2.7.0 <2.8.0". The release suffix of the versions is ignored, so
+	// "2.7.0-2" is in the range. The install plans out of the range are pending until they're approved manually
+	// +optional
+	ApprovedVersions string `json:"approvedVersions,omitempty"`
+}
+
+// KafkaStretchedCluster defines the failure domains of the stretched kafka cluster, they're the values of the node
+// label of the topology key
+type KafkaStretchedCluster struct {
+	// TopologyKey is the node label of the sites, the default is "topology.kubernetes.io/zone"
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+
+	// Sites are the two sites which run the brokers and the zookeeper nodes
+	// +kubebuilder:validation:MinItems=2
+	// +kubebuilder:validation:MaxItems=2
+	// +kubebuilder:validation:Required
+	Sites []string `json:"sites"`
+
+	// TieBreakerSite is the site which only runs a zookeeper node, so the zookeeper keeps the quorum once either of
+	// the sites is lost
+	// +kubebuilder:validation:Required
+	TieBreakerSite string `json:"tieBreakerSite"`
+}
+
+// KafkaVolume defines a persistent volume of the JBOD storage of the brokers
+type KafkaVolume struct {
+	// ID is the id of the volume in the JBOD storage, it can't be changed once the volume is added. The id 0 is the
+	// volume with the storageSize of the kafka
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Required
+	ID int32 `json:"id"`
+
+	// Size is the size of the volume, e.g. "100Gi"
+	// +kubebuilder:validation:Required
+	Size string `json:"size"`
+
+	// StorageClass is the class of the volume, it's the storageClass of the data layer if it isn't set
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+// KafkaDisruptionBudget defines the max unavailable pods of the pod disruption budgets of the built-in kafka
+type KafkaDisruptionBudget struct {
+	// KafkaMaxUnavailable is the number of the brokers which can be evicted at the same time, the default is 1. The
+	// value 0 blocks the eviction of the brokers, so the nodes can't be drained until the value is increased
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KafkaMaxUnavailable *int32 `json:"kafkaMaxUnavailable,omitempty"`
+
+	// ZookeeperMaxUnavailable is the number of the zookeeper nodes which can be evicted at the same time, the
+	// default is 1. It should be less than half of the zookeeper nodes to keep the quorum
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ZookeeperMaxUnavailable *int32 `json:"zookeeperMaxUnavailable,omitempty"`
+}
+
+// KafkaExistingCluster references the Kafka resource of an existing Strimzi or AMQ Streams operator. The entity
+// operator must be enabled to manage the topics and the users, and the listener must authenticate the clients with
+// the tls certificates
+type KafkaExistingCluster struct {
+	// Name is the name of the Kafka resource
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Kafka resource, the topics and the users are created in it
+	// +kubebuilder:validation:Required
+	Namespace string `json:"namespace"`
+
+	// ListenerName is the name of the listener for the manager and the managed hubs, the default is "tls"
+	// +optional
+	ListenerName string `json:"listenerName,omitempty"`
+}
+
+// KafkaStatusLagAlert defines the thresholds of the consumer lag alerts of the status topics
+type KafkaStatusLagAlert struct {
+	// WarningThreshold is the lag of the status topic to fire the warning alert, the default is 1000
+	// +kubebuilder:default=1000
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	WarningThreshold int64 `json:"warningThreshold,omitempty"`
+
+	// CriticalThreshold is the lag of the status topic to fire the critical alert, the default is 10000
+	// +kubebuilder:default=10000
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CriticalThreshold int64 `json:"criticalThreshold,omitempty"`
+
+	// For is how long the lag exceeds the threshold before the alert fires, e.g. "10m". The default is "5m"
+	// +kubebuilder:default="5m"
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h))+$`
+	// +optional
+	For string `json:"for,omitempty"`
+}
+
+// KafkaNodePool defines a group of the kafka brokers with the same configuration
+type KafkaNodePool struct {
+	// Name is the name of the node pool, the brokers are named "kafka-<name>-<id>"
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Replicas is the number of the brokers in the pool
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Required
+	Replicas int32 `json:"replicas"`
+
+	// StorageSize is the size of the volume of each broker, it's the storageSize of the kafka if it isn't set
+	// +optional
+	StorageSize string `json:"storageSize,omitempty"`
+
+	// StorageClass is the class of the volume of each broker, it's the storageClass of the data layer if it isn't
+	// set
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// Resources is the compute resources of each broker, it's the kafka resources of the advanced config if it
+	// isn't set
+	// +optional
+	Resources *ResourceRequirements `json:"resources,omitempty"`
+}
+
+// KafkaCertManager defines the cert-manager issuers of the kafka CAs
+type KafkaCertManager struct {
+	// IssuerRef is the issuer of the cluster CA, it's also the issuer of the clients CA if the clientsIssuerRef
+	// isn't set
+	// +kubebuilder:validation:Required
+	IssuerRef CertManagerIssuerRef `json:"issuerRef"`
+
+	// ClientsIssuerRef is the issuer of the clients CA
+	// +optional
+	ClientsIssuerRef *CertManagerIssuerRef `json:"clientsIssuerRef,omitempty"`
+
+	// Duration is the lifetime of the CA certificates, e.g. "8760h". It's the default of the cert-manager if it
+	// isn't set
+	// +optional
+	Duration string `json:"duration,omitempty"`
+
+	// RenewBefore is how long before the expiry the CA certificates are renewed by the cert-manager, e.g. "720h"
+	// +optional
+	RenewBefore string `json:"renewBefore,omitempty"`
+}
+
+// CertManagerIssuerRef references a cert-manager issuer
+type CertManagerIssuerRef struct {
+	// Name is the name of the issuer
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Kind is the kind of the issuer, the value can be "Issuer" or "ClusterIssuer". The issuer must be in the
+	// namespace of the global hub. The default is "Issuer"
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Group is the API group of the issuer, the default is "cert-manager.io"
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// KafkaSchemaRegistry defines the endpoint of an existing schema registry
+type KafkaSchemaRegistry struct {
+	// URL is the Confluent compatible API of the schema registry, which must be reachable from the global hub
+	// and the managed hubs, e.g. "https://registry.example.com/apis/ccompat/v7" for Apicurio
+	// +kubebuilder:validation:Required
+	URL string `json:"url"`
+
+	// CredentialsSecretName is the name of the secret in the global hub namespace, the keys are the optional
+	// "username", "password" and "ca.crt" of the schema registry
+	// +optional
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+}
+
+// KafkaTieredStorage defines the remote storage manager of the kafka tiered storage
+type KafkaTieredStorage struct {
+	// ClassName is the class of the remote storage manager plugin, e.g.
+	// "io.aiven.kafka.tieredstorage.RemoteStorageManager"
+	// +kubebuilder:validation:Required
+	ClassName string `json:"className"`
+
+	// ClassPath is the class path of the remote storage manager plugin in the kafka image
+	// +optional
+	ClassPath string `json:"classPath,omitempty"`
+
+	// Config is the config of the remote storage manager plugin, e.g. the bucket, the region and the endpoint
+	// of the object store. The keys are prefixed with "rsm.config." by the strimzi operator
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
+
+	// CredentialsSecretName is the name of the secret in the global hub namespace, the keys of the secret are
+	// exposed as the environment variables of the brokers, e.g. "AWS_ACCESS_KEY_ID" and "AWS_SECRET_ACCESS_KEY"
+	// +optional
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+
+	// LocalRetentionMs is the "local.retention.ms" of the status topics, which is how long the log segments are
+	// kept in the kafka volumes before they're only available from the remote storage
+	// +kubebuilder:validation:Minimum=-2
+	// +optional
+	LocalRetentionMs *int64 `json:"localRetentionMs,omitempty"`
+}
+
+// KafkaTopicConfig defines the config of the kafka topics, the changes are applied to the existing topics
+type KafkaTopicConfig struct {
+	// CleanupPolicy is the "cleanup.policy" of the topic, the value can be "compact", "delete" or "compact,delete".
+	// The default value is "compact" for the spec topic, and "delete" for the status topics
+	// +kubebuilder:validation:Enum=compact;delete;"compact,delete"
+	// +optional
+	CleanupPolicy string `json:"cleanupPolicy,omitempty"`
+
+	// RetentionMs is the "retention.ms" of the topic, -1 means no time limit. The default value of the status topics
+	// with the "delete" cleanup policy is 7 days
+	// +kubebuilder:validation:Minimum=-1
+	// +optional
+	RetentionMs *int64 `json:"retentionMs,omitempty"`
+
+	// RetentionBytes is the "retention.bytes" of each partition of the topic, -1 means no size limit
+	// +kubebuilder:validation:Minimum=-1
+	// +optional
+	RetentionBytes *int64 `json:"retentionBytes,omitempty"`
+
+	// SegmentBytes is the "segment.bytes" of the topic
+	// +kubebuilder:validation:Minimum=14
+	// +optional
+	SegmentBytes *int64 `json:"segmentBytes,omitempty"`
+
+	// Partitions is the number of the partitions of the topic, the default value is 1. The partitions of the
+	// existing topics are only increased, kafka doesn't support decreasing them
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Partitions *int32 `json:"partitions,omitempty"`
+}
+
+// KafkaCruiseControl defines the cruise control of the built-in kafka
+type KafkaCruiseControl struct {
+	// RebalanceHubThreshold is the number of the managed hubs joined since the last rebalance to trigger a new
+	// rebalance, the rebalance proposal is approved automatically. The rebalance is only triggered by the
+	// "mgh-kafka-rebalance" annotation if it isn't set
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RebalanceHubThreshold int32 `json:"rebalanceHubThreshold,omitempty"`
+}
+
+// KafkaListenerType is the type of the kafka listener which exposes the brokers outside the cluster
+type KafkaListenerType string
+
+const (
+	KafkaListenerRoute        KafkaListenerType = "route"
+	KafkaListenerLoadBalancer KafkaListenerType = "loadbalancer"
+	KafkaListenerNodePort     KafkaListenerType = "nodeport"
+	KafkaListenerIngress      KafkaListenerType = "ingress"
+)
+
+// KafkaExternalListener defines the external listener of the built-in kafka
+type KafkaExternalListener struct {
+	// Type is the type of the listener, the value can be "route", "loadbalancer", "nodeport" or "ingress"
+	// +kubebuilder:validation:Enum=route;loadbalancer;nodeport;ingress
+	// +kubebuilder:default:=route
+	Type KafkaListenerType `json:"type,omitempty"`
+
+	// Annotations are added to the bootstrap and the broker services, routes or ingresses, e.g. the annotations of
+	// the cloud load balancer or the external DNS
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// NodePort is the first port of the node port range used by the nodeport type. The bootstrap service uses
+	// the port, and the broker N uses the port + N + 1. The ports are assigned by kubernetes if it isn't set
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	NodePort int32 `json:"nodePort,omitempty"`
+
+	// IngressClass is the class of the ingresses used by the ingress type, the ingress controller must support
+	// the TLS passthrough
+	// +optional
+	IngressClass string `json:"ingressClass,omitempty"`
+
+	// Host is the bootstrap host of the ingress type or the route type. It's required by the ingress type, and
+	// the broker N is exposed with the host "broker-N-<host>"
+	// +optional
+	Host string `json:"host,omitempty"`
+}
+
+// KafkaAuthenticationType is the authentication of the kafka client
+type KafkaAuthenticationType string
+
+const (
+	KafkaAuthenticationTLS         KafkaAuthenticationType = "tls"
+	KafkaAuthenticationScramSha512 KafkaAuthenticationType = "scram-sha-512"
+)
+
+// KafkaDeliveryMode is the delivery guarantee of the kafka messages
+type KafkaDeliveryMode string
+
+const (
+	KafkaDeliveryAtLeastOnce KafkaDeliveryMode = "at-least-once"
+	KafkaDeliveryExactlyOnce KafkaDeliveryMode = "exactly-once"
+)
+
+// KafkaTopicSharingMode is the layout of the status topics of the managed hubs
+type KafkaTopicSharingMode string
+
+const (
+	KafkaTopicPerHub KafkaTopicSharingMode = "per-hub"
+	KafkaTopicShared KafkaTopicSharingMode = "shared"
+)
+
+// KafkaTopicDriftPolicy is how the drift of the kafka topics and the kafka users is handled
+type KafkaTopicDriftPolicy string
+
+const (
+	KafkaTopicDriftRepair KafkaTopicDriftPolicy = "repair"
+	KafkaTopicDriftReport KafkaTopicDriftPolicy = "report"
+)
+
+// KafkaOrphanPolicy is how the kafka resources of the removed managed hubs are handled
+type KafkaOrphanPolicy string
+
+const (
+	KafkaOrphanDelete KafkaOrphanPolicy = "delete"
+	KafkaOrphanDryRun KafkaOrphanPolicy = "dryRun"
+)
+
+// PostgresBackendType is how the built-in postgres is provisioned
+type PostgresBackendType string
+
+const (
+	PostgresStatefulSetBackend   PostgresBackendType = "statefulset"
+	PostgresCrunchyBackend       PostgresBackendType = "crunchy"
+	PostgresCloudNativePGBackend PostgresBackendType = "cloudnativepg"
+)
+
+// PostgresPoolMode is the pool mode of the pgBouncer
+type PostgresPoolMode string
+
+const (
+	PostgresTransactionPool PostgresPoolMode = "transaction"
+	PostgresSessionPool     PostgresPoolMode = "session"
+)
+
+// PostgresAuthenticationType is how the manager authenticates to the external postgres
+type PostgresAuthenticationType string
+
+const (
+	PostgresPasswordAuth PostgresAuthenticationType = "password"
+	PostgresAWSIAMAuth   PostgresAuthenticationType = "aws-iam"
+	PostgresAzureADAuth  PostgresAuthenticationType = "azure-ad"
+)
+
+// KafkaHubRateLimit defines the rate of the status messages sent by the agent of a managed hub
+type KafkaHubRateLimit struct {
+	// MessagesPerSecond is the sustained number of the status messages sent by the agent per second
+	// +kubebuilder:validation:Minimum=1
+	MessagesPerSecond int32 `json:"messagesPerSecond"`
+
+	// Burst is the number of the status messages the agent sends at once, the default is the messagesPerSecond
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// KafkaUserQuotas defines the kafka quotas of a client
+type KafkaUserQuotas struct {
+	// ProducerByteRate is the maximum bytes per-second that a managed hub can publish to the broker
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ProducerByteRate *int32 `json:"producerByteRate,omitempty"`
+
+	// ConsumerByteRate is the maximum bytes per-second that a managed hub can fetch from the broker
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ConsumerByteRate *int32 `json:"consumerByteRate,omitempty"`
+
+	// RequestPercentage is the maximum percentage of the broker request handler and network threads time that
+	// a managed hub can use
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RequestPercentage *int32 `json:"requestPercentage,omitempty"`
 }
 
 // KafkaTopics is the transport topics for the manager and agent to communicate to one another
@@ -201,6 +1410,14 @@ type KafkaTopics struct {
 	// managed hubs is "gh-event"
 	// +kubebuilder:default="gh-event.*"
 	StatusTopic string `json:"statusTopic,omitempty"`
+
+	// SharingMode specifies whether the managed hubs report to their own status topics or to a shared one. It's
+	// derived from the statusTopic if it isn't set. In the "per-hub" mode, the statusTopic must end with an asterisk
+	// (*); in the "shared" mode, the asterisk is removed from the default statusTopic, e.g. "gh-event". The BYO
+	// kafka only supports the "shared" mode. The topics of the previous layout are removed once the mode is changed
+	// +kubebuilder:validation:Enum=per-hub;shared
+	// +optional
+	SharingMode KafkaTopicSharingMode `json:"sharingMode,omitempty"`
 }
 
 // MulticlusterGlobalHubStatus defines the observed state of multicluster global hub
@@ -208,6 +1425,58 @@ type MulticlusterGlobalHubStatus struct {
 	// Conditions represents the latest available observations of the current state
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// DataRetention is the last time when each class of the data is pruned, it's reported by the manager
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	DataRetention *DataRetentionStatus `json:"dataRetention,omitempty"`
+
+	// Database is the version of the database schema, it's reported by the operator once the migrations are applied
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Database *DatabaseStatus `json:"database,omitempty"`
+
+	// Jobs are the schedule, the last run and the next run of the jobs of the manager, the key is the name of the
+	// job, e.g. data-retention. It's reported by the manager
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Jobs map[string]ManagerJobStatus `json:"jobs,omitempty"`
+}
+
+// ManagerJobStatus is the schedule of the job of the manager and when it runs
+type ManagerJobStatus struct {
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// +optional
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+	// +optional
+	NextRunTime *metav1.Time `json:"nextRunTime,omitempty"`
+}
+
+// DatabaseStatus is the version of the database schema and the migrations which aren't applied yet
+type DatabaseStatus struct {
+	// SchemaVersion is the version of the latest migration applied to the database
+	// +optional
+	SchemaVersion int64 `json:"schemaVersion,omitempty"`
+
+	// PendingMigrations are the migrations which aren't applied yet, e.g. the migration failed or the schema is newer
+	// than the operator
+	// +optional
+	PendingMigrations []string `json:"pendingMigrations,omitempty"`
+}
+
+// DataRetentionStatus is the last time when each class of the data is pruned from the database
+type DataRetentionStatus struct {
+	// +optional
+	EventsLastPruneTime *metav1.Time `json:"eventsLastPruneTime,omitempty"`
+	// +optional
+	ComplianceHistoryLastPruneTime *metav1.Time `json:"complianceHistoryLastPruneTime,omitempty"`
+	// +optional
+	ComplianceRollupsLastPruneTime *metav1.Time `json:"complianceRollupsLastPruneTime,omitempty"`
+	// +optional
+	HeartbeatsLastPruneTime *metav1.Time `json:"heartbeatsLastPruneTime,omitempty"`
+	// +optional
+	AuditLogsLastPruneTime *metav1.Time `json:"auditLogsLastPruneTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
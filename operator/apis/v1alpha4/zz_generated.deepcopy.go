@@ -22,6 +22,7 @@ package v1alpha4
 
 import (
 	"k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -36,105 +37,886 @@ func (in *AdvancedConfig) DeepCopyInto(out *AdvancedConfig) {
 	}
 	if in.Kafka != nil {
 		in, out := &in.Kafka, &out.Kafka
+		*out = new(KafkaCommonSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Zookeeper != nil {
+		in, out := &in.Zookeeper, &out.Zookeeper
 		*out = new(CommonSpec)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.Zookeeper != nil {
-		in, out := &in.Zookeeper, &out.Zookeeper
-		*out = new(CommonSpec)
-		(*in).DeepCopyInto(*out)
+	if in.Postgres != nil {
+		in, out := &in.Postgres, &out.Postgres
+		*out = new(PostgresCommonSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Manager != nil {
+		in, out := &in.Manager, &out.Manager
+		*out = new(ManagerCommonSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Agent != nil {
+		in, out := &in.Agent, &out.Agent
+		*out = new(CommonSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tracing != nil {
+		in, out := &in.Tracing, &out.Tracing
+		*out = new(TracingConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdvancedConfig.
+func (in *AdvancedConfig) DeepCopy() *AdvancedConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AdvancedConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerIssuerRef) DeepCopyInto(out *CertManagerIssuerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerIssuerRef.
+func (in *CertManagerIssuerRef) DeepCopy() *CertManagerIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CommonSpec) DeepCopyInto(out *CommonSpec) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommonSpec.
+func (in *CommonSpec) DeepCopy() *CommonSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CommonSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComplianceSLO) DeepCopyInto(out *ComplianceSLO) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComplianceSLO.
+func (in *ComplianceSLO) DeepCopy() *ComplianceSLO {
+	if in == nil {
+		return nil
+	}
+	out := new(ComplianceSLO)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataLayerConfig) DeepCopyInto(out *DataLayerConfig) {
+	*out = *in
+	in.Kafka.DeepCopyInto(&out.Kafka)
+	in.Postgres.DeepCopyInto(&out.Postgres)
+	if in.Nats != nil {
+		in, out := &in.Nats, &out.Nats
+		*out = new(NatsConfig)
+		**out = **in
+	}
+	if in.GRPC != nil {
+		in, out := &in.GRPC, &out.GRPC
+		*out = new(GRPCConfig)
+		**out = **in
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPConfig)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataLayerConfig.
+func (in *DataLayerConfig) DeepCopy() *DataLayerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DataLayerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataRetentionPolicy) DeepCopyInto(out *DataRetentionPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataRetentionPolicy.
+func (in *DataRetentionPolicy) DeepCopy() *DataRetentionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DataRetentionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataRetentionStatus) DeepCopyInto(out *DataRetentionStatus) {
+	*out = *in
+	if in.EventsLastPruneTime != nil {
+		in, out := &in.EventsLastPruneTime, &out.EventsLastPruneTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ComplianceHistoryLastPruneTime != nil {
+		in, out := &in.ComplianceHistoryLastPruneTime, &out.ComplianceHistoryLastPruneTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ComplianceRollupsLastPruneTime != nil {
+		in, out := &in.ComplianceRollupsLastPruneTime, &out.ComplianceRollupsLastPruneTime
+		*out = (*in).DeepCopy()
+	}
+	if in.HeartbeatsLastPruneTime != nil {
+		in, out := &in.HeartbeatsLastPruneTime, &out.HeartbeatsLastPruneTime
+		*out = (*in).DeepCopy()
+	}
+	if in.AuditLogsLastPruneTime != nil {
+		in, out := &in.AuditLogsLastPruneTime, &out.AuditLogsLastPruneTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataRetentionStatus.
+func (in *DataRetentionStatus) DeepCopy() *DataRetentionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataRetentionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseStatus) DeepCopyInto(out *DatabaseStatus) {
+	*out = *in
+	if in.PendingMigrations != nil {
+		in, out := &in.PendingMigrations, &out.PendingMigrations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseStatus.
+func (in *DatabaseStatus) DeepCopy() *DatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalPostgres) DeepCopyInto(out *ExternalPostgres) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalPostgres.
+func (in *ExternalPostgres) DeepCopy() *ExternalPostgres {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalPostgres)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GRPCConfig) DeepCopyInto(out *GRPCConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GRPCConfig.
+func (in *GRPCConfig) DeepCopy() *GRPCConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GRPCConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPConfig) DeepCopyInto(out *HTTPConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPConfig.
+func (in *HTTPConfig) DeepCopy() *HTTPConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaAutoscaling) DeepCopyInto(out *KafkaAutoscaling) {
+	*out = *in
+	if in.MaxResources != nil {
+		in, out := &in.MaxResources, &out.MaxResources
+		*out = new(ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaAutoscaling.
+func (in *KafkaAutoscaling) DeepCopy() *KafkaAutoscaling {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaAutoscaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaBridge) DeepCopyInto(out *KafkaBridge) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaBridge.
+func (in *KafkaBridge) DeepCopy() *KafkaBridge {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaBridge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaCertManager) DeepCopyInto(out *KafkaCertManager) {
+	*out = *in
+	out.IssuerRef = in.IssuerRef
+	if in.ClientsIssuerRef != nil {
+		in, out := &in.ClientsIssuerRef, &out.ClientsIssuerRef
+		*out = new(CertManagerIssuerRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaCertManager.
+func (in *KafkaCertManager) DeepCopy() *KafkaCertManager {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaCertManager)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaCommonSpec) DeepCopyInto(out *KafkaCommonSpec) {
+	*out = *in
+	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(KafkaAutoscaling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SpecOverride != nil {
+		in, out := &in.SpecOverride, &out.SpecOverride
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaCommonSpec.
+func (in *KafkaCommonSpec) DeepCopy() *KafkaCommonSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaCommonSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaConfig) DeepCopyInto(out *KafkaConfig) {
+	*out = *in
+	out.KafkaTopics = in.KafkaTopics
+	if in.HubQuotas != nil {
+		in, out := &in.HubQuotas, &out.HubQuotas
+		*out = new(KafkaUserQuotas)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HubRateLimit != nil {
+		in, out := &in.HubRateLimit, &out.HubRateLimit
+		*out = new(KafkaHubRateLimit)
+		**out = **in
+	}
+	if in.ExternalListener != nil {
+		in, out := &in.ExternalListener, &out.ExternalListener
+		*out = new(KafkaExternalListener)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CruiseControl != nil {
+		in, out := &in.CruiseControl, &out.CruiseControl
+		*out = new(KafkaCruiseControl)
+		**out = **in
+	}
+	if in.SpecTopicConfig != nil {
+		in, out := &in.SpecTopicConfig, &out.SpecTopicConfig
+		*out = new(KafkaTopicConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StatusTopicConfig != nil {
+		in, out := &in.StatusTopicConfig, &out.StatusTopicConfig
+		*out = new(KafkaTopicConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TieredStorage != nil {
+		in, out := &in.TieredStorage, &out.TieredStorage
+		*out = new(KafkaTieredStorage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SchemaRegistry != nil {
+		in, out := &in.SchemaRegistry, &out.SchemaRegistry
+		*out = new(KafkaSchemaRegistry)
+		**out = **in
+	}
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(KafkaCertManager)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Stretched != nil {
+		in, out := &in.Stretched, &out.Stretched
+		*out = new(KafkaStretchedCluster)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DisruptionBudget != nil {
+		in, out := &in.DisruptionBudget, &out.DisruptionBudget
+		*out = new(KafkaDisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StatusLagAlert != nil {
+		in, out := &in.StatusLagAlert, &out.StatusLagAlert
+		*out = new(KafkaStatusLagAlert)
+		**out = **in
+	}
+	if in.NodePools != nil {
+		in, out := &in.NodePools, &out.NodePools
+		*out = make([]KafkaNodePool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]KafkaVolume, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExistingCluster != nil {
+		in, out := &in.ExistingCluster, &out.ExistingCluster
+		*out = new(KafkaExistingCluster)
+		**out = **in
+	}
+	if in.Subscription != nil {
+		in, out := &in.Subscription, &out.Subscription
+		*out = new(KafkaSubscription)
+		**out = **in
+	}
+	if in.Connect != nil {
+		in, out := &in.Connect, &out.Connect
+		*out = new(KafkaConnect)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Bridge != nil {
+		in, out := &in.Bridge, &out.Bridge
+		*out = new(KafkaBridge)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConfig.
+func (in *KafkaConfig) DeepCopy() *KafkaConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaConnect) DeepCopyInto(out *KafkaConnect) {
+	*out = *in
+	if in.SecretNames != nil {
+		in, out := &in.SecretNames, &out.SecretNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Connectors != nil {
+		in, out := &in.Connectors, &out.Connectors
+		*out = make([]KafkaConnector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConnect.
+func (in *KafkaConnect) DeepCopy() *KafkaConnect {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaConnect)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaConnector) DeepCopyInto(out *KafkaConnector) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConnector.
+func (in *KafkaConnector) DeepCopy() *KafkaConnector {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaConnector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaCruiseControl) DeepCopyInto(out *KafkaCruiseControl) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaCruiseControl.
+func (in *KafkaCruiseControl) DeepCopy() *KafkaCruiseControl {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaCruiseControl)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaDisruptionBudget) DeepCopyInto(out *KafkaDisruptionBudget) {
+	*out = *in
+	if in.KafkaMaxUnavailable != nil {
+		in, out := &in.KafkaMaxUnavailable, &out.KafkaMaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ZookeeperMaxUnavailable != nil {
+		in, out := &in.ZookeeperMaxUnavailable, &out.ZookeeperMaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaDisruptionBudget.
+func (in *KafkaDisruptionBudget) DeepCopy() *KafkaDisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaDisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaExistingCluster) DeepCopyInto(out *KafkaExistingCluster) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaExistingCluster.
+func (in *KafkaExistingCluster) DeepCopy() *KafkaExistingCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaExistingCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaExternalListener) DeepCopyInto(out *KafkaExternalListener) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaExternalListener.
+func (in *KafkaExternalListener) DeepCopy() *KafkaExternalListener {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaExternalListener)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaHubRateLimit) DeepCopyInto(out *KafkaHubRateLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaHubRateLimit.
+func (in *KafkaHubRateLimit) DeepCopy() *KafkaHubRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaHubRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaNodePool) DeepCopyInto(out *KafkaNodePool) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaNodePool.
+func (in *KafkaNodePool) DeepCopy() *KafkaNodePool {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaNodePool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaSchemaRegistry) DeepCopyInto(out *KafkaSchemaRegistry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaSchemaRegistry.
+func (in *KafkaSchemaRegistry) DeepCopy() *KafkaSchemaRegistry {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaSchemaRegistry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaStatusLagAlert) DeepCopyInto(out *KafkaStatusLagAlert) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaStatusLagAlert.
+func (in *KafkaStatusLagAlert) DeepCopy() *KafkaStatusLagAlert {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaStatusLagAlert)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaStretchedCluster) DeepCopyInto(out *KafkaStretchedCluster) {
+	*out = *in
+	if in.Sites != nil {
+		in, out := &in.Sites, &out.Sites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaStretchedCluster.
+func (in *KafkaStretchedCluster) DeepCopy() *KafkaStretchedCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaStretchedCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaSubscription) DeepCopyInto(out *KafkaSubscription) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaSubscription.
+func (in *KafkaSubscription) DeepCopy() *KafkaSubscription {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaSubscription)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTieredStorage) DeepCopyInto(out *KafkaTieredStorage) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LocalRetentionMs != nil {
+		in, out := &in.LocalRetentionMs, &out.LocalRetentionMs
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTieredStorage.
+func (in *KafkaTieredStorage) DeepCopy() *KafkaTieredStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTieredStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTopicConfig) DeepCopyInto(out *KafkaTopicConfig) {
+	*out = *in
+	if in.RetentionMs != nil {
+		in, out := &in.RetentionMs, &out.RetentionMs
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RetentionBytes != nil {
+		in, out := &in.RetentionBytes, &out.RetentionBytes
+		*out = new(int64)
+		**out = **in
+	}
+	if in.SegmentBytes != nil {
+		in, out := &in.SegmentBytes, &out.SegmentBytes
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Partitions != nil {
+		in, out := &in.Partitions, &out.Partitions
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopicConfig.
+func (in *KafkaTopicConfig) DeepCopy() *KafkaTopicConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTopicConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTopics) DeepCopyInto(out *KafkaTopics) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopics.
+func (in *KafkaTopics) DeepCopy() *KafkaTopics {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTopics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaUserQuotas) DeepCopyInto(out *KafkaUserQuotas) {
+	*out = *in
+	if in.ProducerByteRate != nil {
+		in, out := &in.ProducerByteRate, &out.ProducerByteRate
+		*out = new(int32)
+		**out = **in
 	}
-	if in.Postgres != nil {
-		in, out := &in.Postgres, &out.Postgres
-		*out = new(CommonSpec)
-		(*in).DeepCopyInto(*out)
+	if in.ConsumerByteRate != nil {
+		in, out := &in.ConsumerByteRate, &out.ConsumerByteRate
+		*out = new(int32)
+		**out = **in
 	}
-	if in.Manager != nil {
-		in, out := &in.Manager, &out.Manager
-		*out = new(CommonSpec)
-		(*in).DeepCopyInto(*out)
+	if in.RequestPercentage != nil {
+		in, out := &in.RequestPercentage, &out.RequestPercentage
+		*out = new(int32)
+		**out = **in
 	}
-	if in.Agent != nil {
-		in, out := &in.Agent, &out.Agent
-		*out = new(CommonSpec)
-		(*in).DeepCopyInto(*out)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaUserQuotas.
+func (in *KafkaUserQuotas) DeepCopy() *KafkaUserQuotas {
+	if in == nil {
+		return nil
 	}
+	out := new(KafkaUserQuotas)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdvancedConfig.
-func (in *AdvancedConfig) DeepCopy() *AdvancedConfig {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaVolume) DeepCopyInto(out *KafkaVolume) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaVolume.
+func (in *KafkaVolume) DeepCopy() *KafkaVolume {
 	if in == nil {
 		return nil
 	}
-	out := new(AdvancedConfig)
+	out := new(KafkaVolume)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CommonSpec) DeepCopyInto(out *CommonSpec) {
+func (in *ManagerCommonSpec) DeepCopyInto(out *ManagerCommonSpec) {
 	*out = *in
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(ResourceRequirements)
-		(*in).DeepCopyInto(*out)
+	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(ManagerDatabase)
+		**out = **in
+	}
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = new(ManagerJobs)
+		**out = **in
+	}
+	if in.ComplianceSLO != nil {
+		in, out := &in.ComplianceSLO, &out.ComplianceSLO
+		*out = new(ComplianceSLO)
+		**out = **in
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = new(ManagerNotifications)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommonSpec.
-func (in *CommonSpec) DeepCopy() *CommonSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagerCommonSpec.
+func (in *ManagerCommonSpec) DeepCopy() *ManagerCommonSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CommonSpec)
+	out := new(ManagerCommonSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DataLayerConfig) DeepCopyInto(out *DataLayerConfig) {
+func (in *ManagerDatabase) DeepCopyInto(out *ManagerDatabase) {
 	*out = *in
-	out.Kafka = in.Kafka
-	out.Postgres = in.Postgres
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataLayerConfig.
-func (in *DataLayerConfig) DeepCopy() *DataLayerConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagerDatabase.
+func (in *ManagerDatabase) DeepCopy() *ManagerDatabase {
 	if in == nil {
 		return nil
 	}
-	out := new(DataLayerConfig)
+	out := new(ManagerDatabase)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KafkaConfig) DeepCopyInto(out *KafkaConfig) {
+func (in *ManagerJobStatus) DeepCopyInto(out *ManagerJobStatus) {
 	*out = *in
-	out.KafkaTopics = in.KafkaTopics
+	if in.LastRunTime != nil {
+		in, out := &in.LastRunTime, &out.LastRunTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRunTime != nil {
+		in, out := &in.NextRunTime, &out.NextRunTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConfig.
-func (in *KafkaConfig) DeepCopy() *KafkaConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagerJobStatus.
+func (in *ManagerJobStatus) DeepCopy() *ManagerJobStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(KafkaConfig)
+	out := new(ManagerJobStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KafkaTopics) DeepCopyInto(out *KafkaTopics) {
+func (in *ManagerJobs) DeepCopyInto(out *ManagerJobs) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopics.
-func (in *KafkaTopics) DeepCopy() *KafkaTopics {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagerJobs.
+func (in *ManagerJobs) DeepCopy() *ManagerJobs {
 	if in == nil {
 		return nil
 	}
-	out := new(KafkaTopics)
+	out := new(ManagerJobs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagerNotifications) DeepCopyInto(out *ManagerNotifications) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagerNotifications.
+func (in *ManagerNotifications) DeepCopy() *ManagerNotifications {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagerNotifications)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -215,7 +997,7 @@ func (in *MulticlusterGlobalHubSpec) DeepCopyInto(out *MulticlusterGlobalHubSpec
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	out.DataLayer = in.DataLayer
+	in.DataLayer.DeepCopyInto(&out.DataLayer)
 	if in.AdvancedConfig != nil {
 		in, out := &in.AdvancedConfig, &out.AdvancedConfig
 		*out = new(AdvancedConfig)
@@ -243,6 +1025,23 @@ func (in *MulticlusterGlobalHubStatus) DeepCopyInto(out *MulticlusterGlobalHubSt
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.DataRetention != nil {
+		in, out := &in.DataRetention, &out.DataRetention
+		*out = new(DataRetentionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(DatabaseStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = make(map[string]ManagerJobStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MulticlusterGlobalHubStatus.
@@ -255,9 +1054,112 @@ func (in *MulticlusterGlobalHubStatus) DeepCopy() *MulticlusterGlobalHubStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsConfig) DeepCopyInto(out *NatsConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsConfig.
+func (in *NatsConfig) DeepCopy() *NatsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresBackup) DeepCopyInto(out *PostgresBackup) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresBackup.
+func (in *PostgresBackup) DeepCopy() *PostgresBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresCommonSpec) DeepCopyInto(out *PostgresCommonSpec) {
+	*out = *in
+	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
+	if in.ConnectionPooler != nil {
+		in, out := &in.ConnectionPooler, &out.ConnectionPooler
+		*out = new(PostgresConnectionPooler)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresCommonSpec.
+func (in *PostgresCommonSpec) DeepCopy() *PostgresCommonSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresCommonSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgresConfig) DeepCopyInto(out *PostgresConfig) {
 	*out = *in
+	if in.RetentionPolicy != nil {
+		in, out := &in.RetentionPolicy, &out.RetentionPolicy
+		*out = new(DataRetentionPolicy)
+		**out = **in
+	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalPostgres)
+		**out = **in
+	}
+	if in.TimescaleDB != nil {
+		in, out := &in.TimescaleDB, &out.TimescaleDB
+		*out = new(PostgresTimescaleDB)
+		**out = **in
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(PostgresBackup)
+		**out = **in
+	}
+	if in.WALArchive != nil {
+		in, out := &in.WALArchive, &out.WALArchive
+		*out = new(PostgresWALArchive)
+		**out = **in
+	}
+	if in.EventArchive != nil {
+		in, out := &in.EventArchive, &out.EventArchive
+		*out = new(PostgresEventArchive)
+		**out = **in
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(PostgresMaintenance)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tenants != nil {
+		in, out := &in.Tenants, &out.Tenants
+		*out = make([]PostgresTenant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(PostgresTLS)
+		**out = **in
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(PostgresEncryption)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresConfig.
@@ -270,6 +1172,161 @@ func (in *PostgresConfig) DeepCopy() *PostgresConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresConnectionPooler) DeepCopyInto(out *PostgresConnectionPooler) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresConnectionPooler.
+func (in *PostgresConnectionPooler) DeepCopy() *PostgresConnectionPooler {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresConnectionPooler)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresEncryption) DeepCopyInto(out *PostgresEncryption) {
+	*out = *in
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(PostgresEncryptionVault)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresEncryption.
+func (in *PostgresEncryption) DeepCopy() *PostgresEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresEncryptionVault) DeepCopyInto(out *PostgresEncryptionVault) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresEncryptionVault.
+func (in *PostgresEncryptionVault) DeepCopy() *PostgresEncryptionVault {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresEncryptionVault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresEventArchive) DeepCopyInto(out *PostgresEventArchive) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresEventArchive.
+func (in *PostgresEventArchive) DeepCopy() *PostgresEventArchive {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresEventArchive)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresMaintenance) DeepCopyInto(out *PostgresMaintenance) {
+	*out = *in
+	if in.Tasks != nil {
+		in, out := &in.Tasks, &out.Tasks
+		*out = make([]PostgresMaintenanceTask, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresMaintenance.
+func (in *PostgresMaintenance) DeepCopy() *PostgresMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresTLS) DeepCopyInto(out *PostgresTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresTLS.
+func (in *PostgresTLS) DeepCopy() *PostgresTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresTenant) DeepCopyInto(out *PostgresTenant) {
+	*out = *in
+	if in.ManagedHubs != nil {
+		in, out := &in.ManagedHubs, &out.ManagedHubs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresTenant.
+func (in *PostgresTenant) DeepCopy() *PostgresTenant {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresTenant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresTimescaleDB) DeepCopyInto(out *PostgresTimescaleDB) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresTimescaleDB.
+func (in *PostgresTimescaleDB) DeepCopy() *PostgresTimescaleDB {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresTimescaleDB)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresWALArchive) DeepCopyInto(out *PostgresWALArchive) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresWALArchive.
+func (in *PostgresWALArchive) DeepCopy() *PostgresWALArchive {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresWALArchive)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRequirements) DeepCopyInto(out *ResourceRequirements) {
 	*out = *in
@@ -298,3 +1355,23 @@ func (in *ResourceRequirements) DeepCopy() *ResourceRequirements {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TracingConfig) DeepCopyInto(out *TracingConfig) {
+	*out = *in
+	if in.SamplingPercentage != nil {
+		in, out := &in.SamplingPercentage, &out.SamplingPercentage
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TracingConfig.
+func (in *TracingConfig) DeepCopy() *TracingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TracingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
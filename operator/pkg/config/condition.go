@@ -96,6 +96,107 @@ const (
 	CONDITION_MESSAGE_BACKUP_DISABLED = "Backup Disabled In RHACM"
 )
 
+// NOTE: the status of KafkaUpgraded is False while the built-in kafka is upgrading or the upgrade is blocked
+const (
+	CONDITION_TYPE_KAFKA_UPGRADED          = "KafkaUpgraded"
+	CONDITION_REASON_KAFKA_UPGRADED        = "KafkaUpgraded"
+	CONDITION_REASON_KAFKA_UPGRADING       = "KafkaUpgrading"
+	CONDITION_REASON_KAFKA_UPGRADE_BLOCKED = "KafkaUpgradeBlocked"
+)
+
+// NOTE: the status of KafkaTopology is False while either of the sites of the stretched kafka has no running brokers,
+// or any of the failure domains has no running zookeeper nodes
+const (
+	CONDITION_TYPE_KAFKA_TOPOLOGY        = "KafkaTopology"
+	CONDITION_REASON_KAFKA_STRETCHED     = "KafkaStretched"
+	CONDITION_REASON_KAFKA_SITE_DEGRADED = "KafkaSiteDegraded"
+)
+
+// NOTE: the status of KafkaStorageExpanded is False while the kafka volumes are expanding, or the size of the volumes
+// can't be changed, e.g. the storage class doesn't allow the volume expansion
+const (
+	CONDITION_TYPE_KAFKA_STORAGE_EXPANDED    = "KafkaStorageExpanded"
+	CONDITION_REASON_KAFKA_STORAGE_EXPANDED  = "KafkaStorageExpanded"
+	CONDITION_REASON_KAFKA_STORAGE_EXPANDING = "KafkaStorageExpanding"
+	CONDITION_REASON_KAFKA_STORAGE_BLOCKED   = "KafkaStorageExpansionBlocked"
+)
+
+// NOTE: the status of DatabaseSchema is False while a migration of the database schema fails, or the schema has been
+// migrated by a newer operator, the older operator doesn't change the database in that case
+const (
+	CONDITION_TYPE_DATABASE_SCHEMA            = "DatabaseSchema"
+	CONDITION_REASON_DATABASE_SCHEMA_MIGRATED = "SchemaMigrated"
+	CONDITION_REASON_DATABASE_SCHEMA_FAILED   = "MigrationFailed"
+	CONDITION_REASON_DATABASE_SCHEMA_BLOCKED  = "SchemaDowngradeBlocked"
+)
+
+// NOTE: the status of PostgresStorageExpanded is False while the volume of the built-in postgres is expanding, or its
+// size can't be changed, e.g. the storage class doesn't allow the volume expansion
+const (
+	CONDITION_TYPE_POSTGRES_STORAGE_EXPANDED    = "PostgresStorageExpanded"
+	CONDITION_REASON_POSTGRES_STORAGE_EXPANDED  = "PostgresStorageExpanded"
+	CONDITION_REASON_POSTGRES_STORAGE_EXPANDING = "PostgresStorageExpanding"
+	CONDITION_REASON_POSTGRES_STORAGE_BLOCKED   = "PostgresStorageExpansionBlocked"
+)
+
+// NOTE: the status of TopicConfigDrift is True while the kafka topics or the kafka users of the managed hubs are
+// changed out of band, and the drift isn't repaired since the topicDriftPolicy is "report"
+const (
+	CONDITION_TYPE_TOPIC_CONFIG_DRIFT      = "TopicConfigDrift"
+	CONDITION_REASON_TOPIC_CONFIG_IN_SYNC  = "TopicConfigInSync"
+	CONDITION_REASON_TOPIC_CONFIG_REPAIRED = "TopicConfigRepaired"
+	CONDITION_REASON_TOPIC_CONFIG_DRIFTED  = "TopicConfigDrifted"
+)
+
+// NOTE: the status of KafkaOrphans is True while the kafka users or the status topics of the removed managed hubs are
+// left, and they aren't deleted since the orphanPolicy is "dryRun"
+const (
+	CONDITION_TYPE_KAFKA_ORPHANS           = "KafkaOrphans"
+	CONDITION_REASON_KAFKA_NO_ORPHANS      = "KafkaNoOrphans"
+	CONDITION_REASON_KAFKA_ORPHANS_DELETED = "KafkaOrphansDeleted"
+	CONDITION_REASON_KAFKA_ORPHANS_FOUND   = "KafkaOrphansFound"
+)
+
+// NOTE: the PendingInstallPlanApproval is only reported while the install plan of the kafka operator is waiting for
+// the manual approval
+const (
+	CONDITION_TYPE_PENDING_INSTALL_PLAN   = "PendingInstallPlanApproval"
+	CONDITION_REASON_PENDING_INSTALL_PLAN = "InstallPlanNotApproved"
+)
+
+// NOTE: the DatabaseBackup is only reported while the database backup is enabled, it's the result of the latest
+// finished backup job
+const (
+	CONDITION_TYPE_DATABASE_BACKUP               = "DatabaseBackup"
+	CONDITION_REASON_DATABASE_BACKUP_SUCCEEDED   = "BackupSucceeded"
+	CONDITION_REASON_DATABASE_BACKUP_FAILED      = "BackupFailed"
+	CONDITION_REASON_DATABASE_BACKUP_PENDING     = "BackupPending"
+	CONDITION_REASON_DATABASE_BACKUP_UNSUPPORTED = "BackupUnsupported"
+)
+
+// NOTE: the DatabaseWALArchive is only reported while the WAL archive is enabled, it's the state of the archive, or of
+// the restore while the database is being restored to the target time
+const (
+	CONDITION_TYPE_DATABASE_WAL_ARCHIVE               = "DatabaseWALArchive"
+	CONDITION_REASON_DATABASE_WAL_ARCHIVE_ENABLED     = "WALArchiveEnabled"
+	CONDITION_REASON_DATABASE_WAL_ARCHIVE_PENDING     = "WALArchivePending"
+	CONDITION_REASON_DATABASE_WAL_ARCHIVE_UNSUPPORTED = "WALArchiveUnsupported"
+	CONDITION_REASON_DATABASE_RESTORE_IN_PROGRESS     = "RestoreInProgress"
+	CONDITION_REASON_DATABASE_RESTORE_SUCCEEDED       = "RestoreSucceeded"
+	CONDITION_REASON_DATABASE_RESTORE_FAILED          = "RestoreFailed"
+)
+
+// NOTE: the DatabaseMaintenance is only reported while the database maintenance is enabled, it's the result of the
+// latest finished maintenance job
+const (
+	CONDITION_TYPE_DATABASE_MAINTENANCE               = "DatabaseMaintenance"
+	CONDITION_REASON_DATABASE_MAINTENANCE_SUCCEEDED   = "MaintenanceSucceeded"
+	CONDITION_REASON_DATABASE_MAINTENANCE_FAILED      = "MaintenanceFailed"
+	CONDITION_REASON_DATABASE_MAINTENANCE_INCOMPLETE  = "MaintenanceWindowExceeded"
+	CONDITION_REASON_DATABASE_MAINTENANCE_PENDING     = "MaintenancePending"
+	CONDITION_REASON_DATABASE_MAINTENANCE_UNSUPPORTED = "MaintenanceUnsupported"
+)
+
 // SetConditionFunc is function type that receives the concrete condition method
 type SetConditionFunc func(ctx context.Context, c client.Client,
 	mgh *globalhubv1alpha4.MulticlusterGlobalHub,
@@ -139,6 +240,73 @@ func SetConditionManagerAvailable(ctx context.Context, c client.Client, mgh *glo
 		CONDITION_REASON_MANAGER_AVAILABLE, CONDITION_MESSAGE_MANAGER_AVAILABLE)
 }
 
+func SetConditionKafkaUpgraded(ctx context.Context, c client.Client, mgh *globalhubv1alpha4.MulticlusterGlobalHub,
+	status metav1.ConditionStatus, reason, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_KAFKA_UPGRADED, status, reason, msg)
+}
+
+func SetConditionKafkaTopology(ctx context.Context, c client.Client, mgh *globalhubv1alpha4.MulticlusterGlobalHub,
+	status metav1.ConditionStatus, reason, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_KAFKA_TOPOLOGY, status, reason, msg)
+}
+
+func SetConditionKafkaStorageExpanded(ctx context.Context, c client.Client,
+	mgh *globalhubv1alpha4.MulticlusterGlobalHub, status metav1.ConditionStatus, reason, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_KAFKA_STORAGE_EXPANDED, status, reason, msg)
+}
+
+func SetConditionTopicConfigDrift(ctx context.Context, c client.Client,
+	mgh *globalhubv1alpha4.MulticlusterGlobalHub, status metav1.ConditionStatus, reason, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_TOPIC_CONFIG_DRIFT, status, reason, msg)
+}
+
+func SetConditionKafkaOrphans(ctx context.Context, c client.Client,
+	mgh *globalhubv1alpha4.MulticlusterGlobalHub, status metav1.ConditionStatus, reason, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_KAFKA_ORPHANS, status, reason, msg)
+}
+
+func SetConditionDatabaseBackup(ctx context.Context, c client.Client,
+	mgh *globalhubv1alpha4.MulticlusterGlobalHub, status metav1.ConditionStatus, reason, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_DATABASE_BACKUP, status, reason, msg)
+}
+
+func SetConditionDatabaseWALArchive(ctx context.Context, c client.Client,
+	mgh *globalhubv1alpha4.MulticlusterGlobalHub, status metav1.ConditionStatus, reason, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_DATABASE_WAL_ARCHIVE, status, reason, msg)
+}
+
+func SetConditionDatabaseMaintenance(ctx context.Context, c client.Client,
+	mgh *globalhubv1alpha4.MulticlusterGlobalHub, status metav1.ConditionStatus, reason, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_DATABASE_MAINTENANCE, status, reason, msg)
+}
+
+func SetConditionPendingInstallPlan(ctx context.Context, c client.Client,
+	mgh *globalhubv1alpha4.MulticlusterGlobalHub, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_PENDING_INSTALL_PLAN, CONDITION_STATUS_TRUE,
+		CONDITION_REASON_PENDING_INSTALL_PLAN, msg)
+}
+
+func SetConditionDatabaseSchema(ctx context.Context, c client.Client, mgh *globalhubv1alpha4.MulticlusterGlobalHub,
+	status metav1.ConditionStatus, reason, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_DATABASE_SCHEMA, status, reason, msg)
+}
+
+func SetConditionPostgresStorageExpanded(ctx context.Context, c client.Client,
+	mgh *globalhubv1alpha4.MulticlusterGlobalHub, status metav1.ConditionStatus, reason, msg string,
+) error {
+	return SetCondition(ctx, c, mgh, CONDITION_TYPE_POSTGRES_STORAGE_EXPANDED, status, reason, msg)
+}
+
 func SetConditionLeafHubDeployed(ctx context.Context, c client.Client, mgh *globalhubv1alpha4.MulticlusterGlobalHub,
 	clusterName string, status metav1.ConditionStatus,
 ) error {
@@ -21,15 +21,20 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	imagev1client "github.com/openshift/client-go/image/clientset/versioned/typed/image/v1"
+	"github.com/robfig/cron/v3"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
 	"open-cluster-management.io/addon-framework/pkg/addonmanager"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -58,12 +63,20 @@ const (
 	GrafanaImageKey              = "grafana"
 	PostgresImageKey             = "postgresql"
 	PostgresExporterImageKey     = "postgres_exporter"
+	PgBouncerImageKey            = "pgbouncer"
+	AWSCLIImageKey               = "aws_cli"
 	GHPostgresDefaultStorageSize = "25Gi"
+	GHPostgresDefaultInstances   = 3
 	// default values for the global hub configured by the operator
 	// We may expose these as CRD fields in the future
 	AggregationLevel       = "full"
 	EnableLocalPolicies    = "true"
 	AgentHeartbeatInterval = "60s"
+	// the defaults of the database connection pool of the manager
+	defaultManagerDatabasePoolSize           = 10
+	defaultManagerDatabaseMaxIdleConnections = 2
+	defaultManagerSlowQueryThreshold         = "200ms"
+	defaultComplianceSLOObjective            = "95"
 )
 
 var (
@@ -77,6 +90,8 @@ var (
 		GrafanaImageKey:          "quay.io/redhat-user-workloads/acm-multicluster-glo-tenant/release-globalhub-1-3/glo-grafana-globalhub-1-3@sha256:c73fb10b1230c5e678d51fc609a5cfb8fb02ca2f4c12e4639cf7ad483f6a47a0",
 		PostgresImageKey:         "quay.io/stolostron/postgresql-13:1-101",
 		PostgresExporterImageKey: "quay.io/prometheuscommunity/postgres-exporter:v0.15.0",
+		PgBouncerImageKey:        "registry.developers.crunchydata.com/crunchydata/crunchy-pgbouncer:ubi8-1.22-1",
+		AWSCLIImageKey:           "public.ecr.aws/aws-cli/aws-cli:2.15.30",
 	}
 	statisticLogInterval  = "1m"
 	metricsScrapeInterval = "1m"
@@ -156,6 +171,26 @@ func GetInstallCrunchyOperator(mgh *v1alpha4.MulticlusterGlobalHub) bool {
 	return false
 }
 
+// GetPostgresBackend returns how the built-in postgres is provisioned, the crunchy annotation takes precedence, so the
+// existing installations keep the crunchy postgres
+func GetPostgresBackend(mgh *v1alpha4.MulticlusterGlobalHub) v1alpha4.PostgresBackendType {
+	if GetInstallCrunchyOperator(mgh) {
+		return v1alpha4.PostgresCrunchyBackend
+	}
+	if mgh.Spec.DataLayer.Postgres.Backend == "" {
+		return v1alpha4.PostgresStatefulSetBackend
+	}
+	return mgh.Spec.DataLayer.Postgres.Backend
+}
+
+// GetPostgresInstances returns the number of the postgres instances of the highly available backends
+func GetPostgresInstances(mgh *v1alpha4.MulticlusterGlobalHub) int32 {
+	if mgh.Spec.DataLayer.Postgres.Instances > 0 {
+		return mgh.Spec.DataLayer.Postgres.Instances
+	}
+	return GHPostgresDefaultInstances
+}
+
 // GetLaunchJobNames returns the jobs concatenated using "," wchich will run once the constainer is started
 func GetLaunchJobNames(mgh *v1alpha4.MulticlusterGlobalHub) string {
 	return getAnnotation(mgh, operatorconstants.AnnotationLaunchJobNames)
@@ -223,6 +258,228 @@ func GetMetricsScrapeInterval(mgh *v1alpha4.MulticlusterGlobalHub) string {
 	return interval
 }
 
+// GetSearchIndexerURL returns the URL of the search-v2 indexer, or an empty string if it's not enabled
+func GetSearchIndexerURL(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	searchIndexer := getAnnotation(mgh, operatorconstants.AnnotationSearchIndexer)
+	if strings.EqualFold(searchIndexer, "true") {
+		return operatorconstants.DefaultSearchIndexerURL
+	}
+	if strings.HasPrefix(searchIndexer, "https://") || strings.HasPrefix(searchIndexer, "http://") {
+		return searchIndexer
+	}
+	return ""
+}
+
+// GetHubEventRateLimit returns the events per second the manager processes from each managed hub,
+// or an empty string if the throttling isn't enabled
+func GetHubEventRateLimit(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	rateLimit := getAnnotation(mgh, operatorconstants.AnnotationHubEventRateLimit)
+	if val, err := strconv.ParseFloat(rateLimit, 64); err != nil || val <= 0 {
+		return ""
+	}
+	return rateLimit
+}
+
+// GetEventCoalesceWindow returns the window in which the manager coalesces the identical status events, or an empty
+// string to use the default window of the manager
+func GetEventCoalesceWindow(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	window := getAnnotation(mgh, operatorconstants.AnnotationEventCoalesceWindow)
+	if val, err := time.ParseDuration(window); err != nil || val < 0 {
+		return ""
+	}
+	return window
+}
+
+// GetCloudEventsSink returns the URL of the CloudEvents sink, or an empty string if the export isn't enabled
+func GetCloudEventsSink(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	sink := getAnnotation(mgh, operatorconstants.AnnotationCloudEventsSink)
+	if strings.HasPrefix(sink, "https://") || strings.HasPrefix(sink, "http://") {
+		return sink
+	}
+	return ""
+}
+
+// GetCloudEventsSinkTypes returns the types of the events exported to the CloudEvents sink
+func GetCloudEventsSinkTypes(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	return strings.ReplaceAll(getAnnotation(mgh, operatorconstants.AnnotationCloudEventsSinkTypes), " ", "")
+}
+
+// GetSIEMAddress returns the "host:port" of the SIEM collector, or an empty string if the export isn't enabled
+func GetSIEMAddress(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	address := getAnnotation(mgh, operatorconstants.AnnotationSIEMAddress)
+	if _, port, err := net.SplitHostPort(address); err != nil || port == "" {
+		return ""
+	}
+	return address
+}
+
+// GetSIEMFormat returns the format of the messages to the SIEM collector, or an empty string if it's the default
+func GetSIEMFormat(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	format := strings.ToLower(getAnnotation(mgh, operatorconstants.AnnotationSIEMFormat))
+	if format == "cef" || format == "rfc5424" {
+		return format
+	}
+	return ""
+}
+
+// GetSIEMFraming returns the framing of the messages to the SIEM collector, or an empty string if it's the default
+func GetSIEMFraming(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	framing := strings.ToLower(getAnnotation(mgh, operatorconstants.AnnotationSIEMFraming))
+	if framing == "octet-counting" || framing == "newline" {
+		return framing
+	}
+	return ""
+}
+
+// GetAPIRateLimit returns the requests per second of each client of the manager API, or an empty string if the rate
+// limiting isn't enabled
+func GetAPIRateLimit(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	rateLimit := getAnnotation(mgh, operatorconstants.AnnotationAPIRateLimit)
+	if val, err := strconv.ParseFloat(rateLimit, 64); err != nil || val <= 0 {
+		return ""
+	}
+	return rateLimit
+}
+
+// GetAuditLogBackend returns the backend of the audit logs, it's "database" if the annotation isn't "file" or "none"
+func GetAuditLogBackend(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	backend := strings.ToLower(getAnnotation(mgh, operatorconstants.AnnotationAuditLogBackend))
+	if backend == "file" || backend == "none" {
+		return backend
+	}
+	return "database"
+}
+
+// GetCanaryHubSelector returns the label selector of the canary hubs, or an empty string if the canary rollout
+// isn't enabled
+func GetCanaryHubSelector(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	return getAnnotation(mgh, operatorconstants.AnnotationCanaryHubSelector)
+}
+
+// GetKafkaRebalanceTrigger returns the value of the rebalance annotation, the kafka is rebalanced when it's changed
+func GetKafkaRebalanceTrigger(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	return getAnnotation(mgh, operatorconstants.AnnotationKafkaRebalance)
+}
+
+// GetClusterLifecycleTopic returns the kafka topic of the lifecycle events of the managed clusters, or an empty string
+// if the stream isn't enabled. The topic is also the name of its KafkaTopic, so it must be a valid kubernetes name
+func GetClusterLifecycleTopic(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	topic := getAnnotation(mgh, operatorconstants.AnnotationClusterLifecycleTopic)
+	if strings.Contains(topic, "*") || !isValidKafkaTopicName(topic) ||
+		len(validation.IsDNS1123Subdomain(topic)) > 0 {
+		return ""
+	}
+	return topic
+}
+
+// IsAPIOAuthProxyEnabled returns true if the oauth-proxy is deployed in front of the manager API
+func IsAPIOAuthProxyEnabled(mgh *v1alpha4.MulticlusterGlobalHub) bool {
+	return !strings.EqualFold(getAnnotation(mgh, operatorconstants.AnnotationAPIOAuthProxy), "false")
+}
+
+// IsAPIAuthorizationEnabled returns true if the requests of the manager API are authorized with the RBAC
+func IsAPIAuthorizationEnabled(mgh *v1alpha4.MulticlusterGlobalHub) bool {
+	return strings.EqualFold(getAnnotation(mgh, operatorconstants.AnnotationAPIAuthorization), "true")
+}
+
+// GetManagerDatabase returns the connection pool and the query timeouts of the manager, the fields which aren't set
+// in the mgh are the defaults of the manager
+func GetManagerDatabase(mgh *v1alpha4.MulticlusterGlobalHub) *v1alpha4.ManagerDatabase {
+	database := &v1alpha4.ManagerDatabase{}
+	if mgh.Spec.AdvancedConfig != nil && mgh.Spec.AdvancedConfig.Manager != nil &&
+		mgh.Spec.AdvancedConfig.Manager.Database != nil {
+		database = mgh.Spec.AdvancedConfig.Manager.Database.DeepCopy()
+	}
+	if database.PoolSize == 0 {
+		database.PoolSize = defaultManagerDatabasePoolSize
+	}
+	if database.MaxIdleConnections == 0 {
+		database.MaxIdleConnections = defaultManagerDatabaseMaxIdleConnections
+	}
+	database.MaxIdleConnections = min(database.MaxIdleConnections, database.PoolSize)
+	if database.SlowQueryThreshold == "" {
+		database.SlowQueryThreshold = defaultManagerSlowQueryThreshold
+	}
+	return database
+}
+
+// GetManagerJobs returns the schedules of the jobs of the manager, the jobs which aren't scheduled in the mgh run on
+// the defaults of the manager
+func GetManagerJobs(mgh *v1alpha4.MulticlusterGlobalHub) (*v1alpha4.ManagerJobs, error) {
+	jobs := &v1alpha4.ManagerJobs{}
+	if mgh.Spec.AdvancedConfig != nil && mgh.Spec.AdvancedConfig.Manager != nil &&
+		mgh.Spec.AdvancedConfig.Manager.Jobs != nil {
+		jobs = mgh.Spec.AdvancedConfig.Manager.Jobs.DeepCopy()
+	}
+	for _, job := range []struct{ name, schedule string }{
+		{"complianceHistorySchedule", jobs.ComplianceHistorySchedule},
+		{"complianceRollupSchedule", jobs.ComplianceRollupSchedule},
+		{"dataRetentionSchedule", jobs.DataRetentionSchedule},
+	} {
+		if job.schedule == "" {
+			continue
+		}
+		if _, err := cron.ParseStandard(job.schedule); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", job.name, job.schedule, err)
+		}
+	}
+	return jobs, nil
+}
+
+// GetComplianceSLOObjective returns the percentage of the compliant clusters which the compliance SLO of the policies
+// and the managed hubs is measured against
+func GetComplianceSLOObjective(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	if mgh.Spec.AdvancedConfig != nil && mgh.Spec.AdvancedConfig.Manager != nil &&
+		mgh.Spec.AdvancedConfig.Manager.ComplianceSLO != nil &&
+		mgh.Spec.AdvancedConfig.Manager.ComplianceSLO.Objective != "" {
+		return mgh.Spec.AdvancedConfig.Manager.ComplianceSLO.Objective
+	}
+	return defaultComplianceSLOObjective
+}
+
+// GetManagerNotifications returns the secret and the config map of the chat channels, they're empty if the channels
+// aren't configured
+func GetManagerNotifications(mgh *v1alpha4.MulticlusterGlobalHub) *v1alpha4.ManagerNotifications {
+	if mgh.Spec.AdvancedConfig != nil && mgh.Spec.AdvancedConfig.Manager != nil &&
+		mgh.Spec.AdvancedConfig.Manager.Notifications != nil {
+		return mgh.Spec.AdvancedConfig.Manager.Notifications.DeepCopy()
+	}
+	return &v1alpha4.ManagerNotifications{}
+}
+
+// GetManagerReplicas returns the number of the manager pods, it's 2 for the high availability if it isn't set
+func GetManagerReplicas(mgh *v1alpha4.MulticlusterGlobalHub) int32 {
+	if mgh.Spec.AdvancedConfig != nil && mgh.Spec.AdvancedConfig.Manager != nil &&
+		mgh.Spec.AdvancedConfig.Manager.Replicas != nil && *mgh.Spec.AdvancedConfig.Manager.Replicas > 0 {
+		return *mgh.Spec.AdvancedConfig.Manager.Replicas
+	}
+	if mgh.Spec.AvailabilityConfig == v1alpha4.HAHigh {
+		return 2
+	}
+	return 1
+}
+
+// GetTracingConfig returns the OTLP collector of the manager and the agents with the defaulted sampling percentage, or
+// nil if the tracing isn't enabled
+func GetTracingConfig(mgh *v1alpha4.MulticlusterGlobalHub) *v1alpha4.TracingConfig {
+	if mgh.Spec.AdvancedConfig == nil || mgh.Spec.AdvancedConfig.Tracing == nil ||
+		mgh.Spec.AdvancedConfig.Tracing.Endpoint == "" {
+		return nil
+	}
+	tracing := mgh.Spec.AdvancedConfig.Tracing.DeepCopy()
+	if tracing.SamplingPercentage == nil {
+		tracing.SamplingPercentage = ptr.To(int32(100))
+	}
+	return tracing
+}
+
+// GetManagerStatusSharding returns whether the status of the managed hubs is split across the replicas of the
+// manager, it's only enabled explicitly, since more replicas are also used for the high availability
+func GetManagerStatusSharding(mgh *v1alpha4.MulticlusterGlobalHub) bool {
+	return mgh.Spec.AdvancedConfig != nil && mgh.Spec.AdvancedConfig.Manager != nil &&
+		mgh.Spec.AdvancedConfig.Manager.StatusSharding
+}
+
 func GetPostgresStorageSize(mgh *v1alpha4.MulticlusterGlobalHub) string {
 	if mgh.Spec.DataLayer.Postgres.StorageSize != "" {
 		return mgh.Spec.DataLayer.Postgres.StorageSize
@@ -230,6 +487,14 @@ func GetPostgresStorageSize(mgh *v1alpha4.MulticlusterGlobalHub) string {
 	return GHPostgresDefaultStorageSize
 }
 
+// GetPostgresConnectionPooler returns the connection pooler of the built-in postgres, or nil if it isn't enabled
+func GetPostgresConnectionPooler(mgh *v1alpha4.MulticlusterGlobalHub) *v1alpha4.PostgresConnectionPooler {
+	if mgh.Spec.AdvancedConfig == nil || mgh.Spec.AdvancedConfig.Postgres == nil {
+		return nil
+	}
+	return mgh.Spec.AdvancedConfig.Postgres.ConnectionPooler
+}
+
 func SetImagePullSecretName(mgh *v1alpha4.MulticlusterGlobalHub) {
 	if mgh.Spec.ImagePullSecret != imagePullSecretName {
 		imagePullSecretName = mgh.Spec.ImagePullSecret
@@ -257,7 +522,7 @@ func GetMulticlusterGlobalHub(ctx context.Context, req ctrl.Request,
 	if err != nil {
 		return nil, err
 	}
-	err = SetPostgresType(ctx, c, mgh.GetNamespace())
+	err = SetPostgresType(ctx, c, mgh)
 	if err != nil {
 		return nil, err
 	}
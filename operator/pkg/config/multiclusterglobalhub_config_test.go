@@ -19,6 +19,7 @@ package config
 import (
 	"context"
 	"reflect"
+	"strings"
 	"testing"
 
 	imagev1 "github.com/openshift/api/image/v1"
@@ -186,3 +187,272 @@ func TestSetMulticlusterGlobalHubConfig(t *testing.T) {
 		t.Fatalf("oauth proxy image is not expected one")
 	}
 }
+
+func TestGetManagerDatabase(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	expected := &globalhubv1alpha4.ManagerDatabase{
+		PoolSize:           10,
+		MaxIdleConnections: 2,
+		SlowQueryThreshold: "200ms",
+	}
+	if database := GetManagerDatabase(mgh); !reflect.DeepEqual(database, expected) {
+		t.Fatalf("expected the default database config %v, got %v", expected, database)
+	}
+
+	mgh.Spec.AdvancedConfig = &globalhubv1alpha4.AdvancedConfig{
+		Manager: &globalhubv1alpha4.ManagerCommonSpec{
+			Database: &globalhubv1alpha4.ManagerDatabase{
+				PoolSize:           1,
+				MaxIdleConnections: 5,
+				StatementTimeout:   "30s",
+			},
+		},
+	}
+	expected = &globalhubv1alpha4.ManagerDatabase{
+		PoolSize:           1,
+		MaxIdleConnections: 1,
+		StatementTimeout:   "30s",
+		SlowQueryThreshold: "200ms",
+	}
+	if database := GetManagerDatabase(mgh); !reflect.DeepEqual(database, expected) {
+		t.Fatalf("expected the database config %v, got %v", expected, database)
+	}
+	// the mgh isn't changed by the defaults
+	if mgh.Spec.AdvancedConfig.Manager.Database.SlowQueryThreshold != "" {
+		t.Fatalf("the database config of the mgh shouldn't be changed")
+	}
+}
+
+func TestGetManagerJobs(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	jobs, err := GetManagerJobs(mgh)
+	if err != nil {
+		t.Fatalf("failed to get the default jobs: %v", err)
+	}
+	if !reflect.DeepEqual(jobs, &globalhubv1alpha4.ManagerJobs{}) {
+		t.Fatalf("expected the jobs on the defaults of the manager, got %v", jobs)
+	}
+
+	mgh.Spec.AdvancedConfig = &globalhubv1alpha4.AdvancedConfig{
+		Manager: &globalhubv1alpha4.ManagerCommonSpec{
+			Jobs: &globalhubv1alpha4.ManagerJobs{
+				ComplianceHistorySchedule: "30 23 * * *",
+				DataRetentionSchedule:     "0 3 * * 0",
+			},
+		},
+	}
+	jobs, err = GetManagerJobs(mgh)
+	if err != nil {
+		t.Fatalf("failed to get the jobs: %v", err)
+	}
+	if !reflect.DeepEqual(jobs, mgh.Spec.AdvancedConfig.Manager.Jobs) {
+		t.Fatalf("expected the jobs %v, got %v", mgh.Spec.AdvancedConfig.Manager.Jobs, jobs)
+	}
+
+	mgh.Spec.AdvancedConfig.Manager.Jobs.ComplianceRollupSchedule = "0 25 * * *"
+	if _, err = GetManagerJobs(mgh); err == nil || !strings.Contains(err.Error(), "invalid complianceRollupSchedule") {
+		t.Fatalf("expected the invalid complianceRollupSchedule error, got %v", err)
+	}
+}
+
+func TestGetComplianceSLOObjective(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if objective := GetComplianceSLOObjective(mgh); objective != "95" {
+		t.Fatalf("expected the default objective 95, got %s", objective)
+	}
+	mgh.Spec.AdvancedConfig = &globalhubv1alpha4.AdvancedConfig{
+		Manager: &globalhubv1alpha4.ManagerCommonSpec{
+			ComplianceSLO: &globalhubv1alpha4.ComplianceSLO{Objective: "99.5"},
+		},
+	}
+	if objective := GetComplianceSLOObjective(mgh); objective != "99.5" {
+		t.Fatalf("expected the objective 99.5, got %s", objective)
+	}
+}
+
+func TestIsAPIOAuthProxyEnabled(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if !IsAPIOAuthProxyEnabled(mgh) {
+		t.Fatalf("the oauth-proxy should be enabled by default")
+	}
+	mgh.SetAnnotations(map[string]string{operatorconstants.AnnotationAPIOAuthProxy: "False"})
+	if IsAPIOAuthProxyEnabled(mgh) {
+		t.Fatalf("the oauth-proxy should be disabled by the annotation")
+	}
+}
+
+func TestGetAuditLogBackend(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if backend := GetAuditLogBackend(mgh); backend != "database" {
+		t.Fatalf("the audit logs should be written to the database by default, but got %s", backend)
+	}
+	for value, expected := range map[string]string{"File": "file", "none": "none", "kafka": "database"} {
+		mgh.SetAnnotations(map[string]string{operatorconstants.AnnotationAuditLogBackend: value})
+		if backend := GetAuditLogBackend(mgh); backend != expected {
+			t.Fatalf("the backend of the annotation %s should be %s, but got %s", value, expected, backend)
+		}
+	}
+}
+
+func TestIsAPIAuthorizationEnabled(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if IsAPIAuthorizationEnabled(mgh) {
+		t.Fatalf("the authorization should be disabled by default")
+	}
+	mgh.SetAnnotations(map[string]string{operatorconstants.AnnotationAPIAuthorization: "true"})
+	if !IsAPIAuthorizationEnabled(mgh) {
+		t.Fatalf("the authorization should be enabled by the annotation")
+	}
+}
+
+func TestGetEventCoalesceWindow(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if window := GetEventCoalesceWindow(mgh); window != "" {
+		t.Fatalf("the default window of the manager should be used, but got %s", window)
+	}
+	for value, expected := range map[string]string{"10m": "10m", "0": "0", "-1m": "", "ten": ""} {
+		mgh.SetAnnotations(map[string]string{operatorconstants.AnnotationEventCoalesceWindow: value})
+		if window := GetEventCoalesceWindow(mgh); window != expected {
+			t.Fatalf("the window of the annotation %s should be %q, but got %s", value, expected, window)
+		}
+	}
+}
+
+func TestGetCloudEventsSink(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if sink := GetCloudEventsSink(mgh); sink != "" {
+		t.Fatalf("the export should be disabled by default, but got the sink %s", sink)
+	}
+	mgh.SetAnnotations(map[string]string{
+		operatorconstants.AnnotationCloudEventsSink:      "http://broker-ingress.knative-eventing/global-hub/default",
+		operatorconstants.AnnotationCloudEventsSinkTypes: "managedcluster.event, policy.noncompliant",
+	})
+	if sink := GetCloudEventsSink(mgh); sink != "http://broker-ingress.knative-eventing/global-hub/default" {
+		t.Fatalf("unexpected sink %s", sink)
+	}
+	if types := GetCloudEventsSinkTypes(mgh); types != "managedcluster.event,policy.noncompliant" {
+		t.Fatalf("unexpected types %s", types)
+	}
+	mgh.SetAnnotations(map[string]string{operatorconstants.AnnotationCloudEventsSink: "broker-ingress"})
+	if sink := GetCloudEventsSink(mgh); sink != "" {
+		t.Fatalf("the invalid sink should be ignored, but got %s", sink)
+	}
+}
+
+func TestGetSIEMConfig(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if address := GetSIEMAddress(mgh); address != "" {
+		t.Fatalf("the export should be disabled by default, but got the address %s", address)
+	}
+	mgh.SetAnnotations(map[string]string{
+		operatorconstants.AnnotationSIEMAddress: "splunk.example.com:6514",
+		operatorconstants.AnnotationSIEMFormat:  "RFC5424",
+		operatorconstants.AnnotationSIEMFraming: "newline",
+	})
+	if address := GetSIEMAddress(mgh); address != "splunk.example.com:6514" {
+		t.Fatalf("unexpected address %s", address)
+	}
+	if format := GetSIEMFormat(mgh); format != "rfc5424" {
+		t.Fatalf("unexpected format %s", format)
+	}
+	if framing := GetSIEMFraming(mgh); framing != "newline" {
+		t.Fatalf("unexpected framing %s", framing)
+	}
+	mgh.SetAnnotations(map[string]string{
+		operatorconstants.AnnotationSIEMAddress: "splunk.example.com",
+		operatorconstants.AnnotationSIEMFormat:  "leef",
+	})
+	if address := GetSIEMAddress(mgh); address != "" {
+		t.Fatalf("the address without the port should be ignored, but got %s", address)
+	}
+	if format := GetSIEMFormat(mgh); format != "" {
+		t.Fatalf("the invalid format should be ignored, but got %s", format)
+	}
+}
+
+func TestGetClusterLifecycleTopic(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if topic := GetClusterLifecycleTopic(mgh); topic != "" {
+		t.Fatalf("the stream should be disabled by default, but got the topic %s", topic)
+	}
+	for topic, expected := range map[string]string{
+		"gh-cluster-lifecycle": "gh-cluster-lifecycle",
+		"cmdb.clusters":        "cmdb.clusters",
+		"gh-cluster-*":         "",
+		"CMDB_clusters":        "",
+		"..":                   "",
+	} {
+		mgh.SetAnnotations(map[string]string{operatorconstants.AnnotationClusterLifecycleTopic: topic})
+		if actual := GetClusterLifecycleTopic(mgh); actual != expected {
+			t.Fatalf("expected the topic %q of the annotation %q, but got %q", expected, topic, actual)
+		}
+	}
+}
+
+func TestGetManagerReplicas(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if replicas := GetManagerReplicas(mgh); replicas != 1 {
+		t.Fatalf("expected 1 replica by default, but got %d", replicas)
+	}
+	mgh.Spec.AvailabilityConfig = globalhubv1alpha4.HAHigh
+	if replicas := GetManagerReplicas(mgh); replicas != 2 {
+		t.Fatalf("expected 2 replicas for the high availability, but got %d", replicas)
+	}
+	replicas := int32(4)
+	mgh.Spec.AdvancedConfig = &globalhubv1alpha4.AdvancedConfig{
+		Manager: &globalhubv1alpha4.ManagerCommonSpec{Replicas: &replicas},
+	}
+	if replicas := GetManagerReplicas(mgh); replicas != 4 {
+		t.Fatalf("expected the 4 replicas of the advanced config, but got %d", replicas)
+	}
+}
+
+func TestGetTracingConfig(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if tracing := GetTracingConfig(mgh); tracing != nil {
+		t.Fatalf("the tracing shouldn't be enabled by default, but got %v", tracing)
+	}
+	mgh.Spec.AdvancedConfig = &globalhubv1alpha4.AdvancedConfig{
+		Tracing: &globalhubv1alpha4.TracingConfig{Endpoint: "otel-collector.observability.svc:4317"},
+	}
+	tracing := GetTracingConfig(mgh)
+	if tracing == nil || tracing.Endpoint != "otel-collector.observability.svc:4317" {
+		t.Fatalf("expected the endpoint of the collector, but got %v", tracing)
+	}
+	if *tracing.SamplingPercentage != 100 {
+		t.Fatalf("expected all the bundles to be sampled by default, but got %d", *tracing.SamplingPercentage)
+	}
+	if mgh.Spec.AdvancedConfig.Tracing.SamplingPercentage != nil {
+		t.Fatalf("the sampling percentage of the spec shouldn't be defaulted")
+	}
+}
+
+func TestGetManagerStatusSharding(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.AvailabilityConfig = globalhubv1alpha4.HAHigh
+	if GetManagerStatusSharding(mgh) {
+		t.Fatalf("expected the status sharding to be disabled for the high availability")
+	}
+	mgh.Spec.AdvancedConfig = &globalhubv1alpha4.AdvancedConfig{
+		Manager: &globalhubv1alpha4.ManagerCommonSpec{StatusSharding: true},
+	}
+	if !GetManagerStatusSharding(mgh) {
+		t.Fatalf("expected the status sharding of the advanced config to be enabled")
+	}
+}
+
+func TestGetManagerNotifications(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	if notifications := GetManagerNotifications(mgh); notifications.SecretName != "" ||
+		notifications.ConfigMapName != "" {
+		t.Fatalf("the channels shouldn't be configured by default, but got %v", notifications)
+	}
+	mgh.Spec.AdvancedConfig = &globalhubv1alpha4.AdvancedConfig{
+		Manager: &globalhubv1alpha4.ManagerCommonSpec{
+			Notifications: &globalhubv1alpha4.ManagerNotifications{SecretName: "chat-channels"},
+		},
+	}
+	if notifications := GetManagerNotifications(mgh); notifications.SecretName != "chat-channels" {
+		t.Fatalf("expected the secret chat-channels, got %s", notifications.SecretName)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
 	"github.com/stolostron/multicluster-global-hub/pkg/constants"
 	"github.com/stolostron/multicluster-global-hub/pkg/utils"
 )
@@ -23,14 +24,22 @@ var (
 	PostgresSuperUser           = "postgres"
 	PostgresSuperUserSecretName = PostgresName + "-" + "pguser" + "-" + PostgresSuperUser
 	PostgresCertName            = PostgresName + "-cluster-cert"
+	// the secret of the data keys which encrypt the policy payloads
+	DefaultPayloadEncryptionKeySecretName = "multicluster-global-hub-postgres-encryption"
 
 	// need append "?sslmode=verify-ca" to the end of the uri to access postgres
 	PostgresURIWithSslmode = "?sslmode=verify-ca"
 
 	// the storage states
-	isBYOPostgres = false
-	databaseReady = false
-	postgresConn  *PostgresConnection
+	isBYOPostgres    = false
+	databaseReady    = false
+	postgresConn     *PostgresConnection
+	externalPostgres *v1alpha4.ExternalPostgres
+	postgresTLS      *v1alpha4.PostgresTLS
+	// the encryption of the policy payloads, and the hash of its data keys which restarts the manager once they're
+	// rotated
+	postgresEncryption *v1alpha4.PostgresEncryption
+	encryptionKeysHash string
 )
 
 type PostgresConnection struct {
@@ -42,14 +51,58 @@ type PostgresConnection struct {
 	ReadonlyUserDatabaseURI string
 	// ca certificate
 	CACert []byte
+	// the connections of the pgBouncer in front of the built-in postgres, grafana uses them if they're set. The manager
+	// and the operator still connect to the postgres directly, since they require the session, e.g. the advisory locks
+	PoolerSuperuserDatabaseURI    string
+	PoolerReadonlyUserDatabaseURI string
+	// the connections of the read-only replica, grafana and the read-only APIs of the manager use them if they're set,
+	// so the queries of the dashboards don't contend with the writes of the manager
+	ReplicaDatabaseURI             string
+	ReplicaReadonlyUserDatabaseURI string
 }
 
-// SetPostgresType assert the current storage is BYO or built-in, and cache the state to memeory
-func SetPostgresType(ctx context.Context, runtimeClient client.Client, namespace string) error {
+// ClientSuperuserDatabaseURI returns the super user connection of the clients which don't require the session, e.g.
+// grafana, it's the connection of the pooler if the pooler is deployed
+func (c *PostgresConnection) ClientSuperuserDatabaseURI() string {
+	if c.PoolerSuperuserDatabaseURI != "" {
+		return c.PoolerSuperuserDatabaseURI
+	}
+	return c.SuperuserDatabaseURI
+}
+
+// ClientReadonlyUserDatabaseURI returns the readonly user connection of the clients, e.g. grafana, it's the
+// connection of the pooler if the pooler is deployed
+func (c *PostgresConnection) ClientReadonlyUserDatabaseURI() string {
+	if c.PoolerReadonlyUserDatabaseURI != "" {
+		return c.PoolerReadonlyUserDatabaseURI
+	}
+	return c.ReadonlyUserDatabaseURI
+}
+
+// ClientReplicaReadonlyUserDatabaseURI returns the readonly user connection of grafana, it's the connection of the
+// read-only replica if the replica is set
+func (c *PostgresConnection) ClientReplicaReadonlyUserDatabaseURI() string {
+	if c.ReplicaReadonlyUserDatabaseURI != "" {
+		return c.ReplicaReadonlyUserDatabaseURI
+	}
+	return c.ClientReadonlyUserDatabaseURI()
+}
+
+// SetPostgresType assert the current storage is BYO or built-in, and cache the state to memeory. The storage is BYO if
+// the external postgres is specified in the mgh, or the storage secret is created
+func SetPostgresType(ctx context.Context, runtimeClient client.Client, mgh *v1alpha4.MulticlusterGlobalHub) error {
+	externalPostgres = mgh.Spec.DataLayer.Postgres.External
+	postgresTLS = mgh.Spec.DataLayer.Postgres.TLS
+	postgresEncryption = mgh.Spec.DataLayer.Postgres.Encryption
+	if externalPostgres != nil {
+		isBYOPostgres = true
+		return nil
+	}
+
 	pgSecret := &corev1.Secret{}
 	err := runtimeClient.Get(ctx, types.NamespacedName{
 		Name:      constants.GHStorageSecretName,
-		Namespace: namespace,
+		Namespace: mgh.GetNamespace(),
 	}, pgSecret)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
@@ -66,6 +119,63 @@ func IsBYOPostgres() bool {
 	return isBYOPostgres
 }
 
+// IsExternalPostgresSecret returns true if the secret is referenced by the external postgres of the mgh
+func IsExternalPostgresSecret(name string) bool {
+	return externalPostgres != nil &&
+		(name == externalPostgres.ConnectionSecretName || name == externalPostgres.CASecretName)
+}
+
+// IsPostgresCertificateSecret returns true if the secret is the user-supplied certificate of the built-in postgres
+func IsPostgresCertificateSecret(name string) bool {
+	return postgresTLS != nil && name == postgresTLS.CertificateSecretName
+}
+
+// IsPayloadEncryptionKeySecret returns true if the secret contains the data keys of the policy payloads
+func IsPayloadEncryptionKeySecret(name string) bool {
+	return postgresEncryption != nil && name == GetPayloadEncryptionKeySecretName(postgresEncryption)
+}
+
+// GetPayloadEncryptionKeySecretName returns the secret of the data keys, the default secret is generated by the
+// operator
+func GetPayloadEncryptionKeySecretName(encryption *v1alpha4.PostgresEncryption) string {
+	if encryption.KeySecretName == "" {
+		return DefaultPayloadEncryptionKeySecretName
+	}
+	return encryption.KeySecretName
+}
+
+// SetEncryptionKeysHash caches the hash of the data keys, it's empty if the payloads aren't encrypted
+func SetEncryptionKeysHash(hash string) {
+	encryptionKeysHash = hash
+}
+
+func GetEncryptionKeysHash() string {
+	return encryptionKeysHash
+}
+
+// GetPostgresAuthentication returns how the global hub authenticates to the postgres, the tokens of the cloud identity
+// are only supported by the external postgres
+func GetPostgresAuthentication() string {
+	if externalPostgres == nil || externalPostgres.Authentication == "" {
+		return string(v1alpha4.PostgresPasswordAuth)
+	}
+	return string(externalPostgres.Authentication)
+}
+
+// IsPostgresMetricsEnabled returns true if the postgres is monitored by the postgres_exporter, it's the sidecar of the
+// built-in statefulset, or the standalone exporter of the other postgres. The exporter requires the password
+func IsPostgresMetricsEnabled(mgh *v1alpha4.MulticlusterGlobalHub) bool {
+	return mgh.Spec.EnableMetrics && GetPostgresAuthentication() == string(v1alpha4.PostgresPasswordAuth)
+}
+
+// GetPostgresIdentity returns the cloud identity of the manager to get the tokens of the external postgres
+func GetPostgresIdentity() string {
+	if externalPostgres == nil || GetPostgresAuthentication() == string(v1alpha4.PostgresPasswordAuth) {
+		return ""
+	}
+	return externalPostgres.Identity
+}
+
 func SetDatabaseReady(ready bool) {
 	databaseReady = ready
 }
@@ -87,9 +197,53 @@ func GetPGConnectionFromGHStorageSecret(ctx context.Context, client client.Clien
 		return nil, err
 	}
 	return &PostgresConnection{
-		SuperuserDatabaseURI:    string(pgSecret.Data["database_uri"]),
-		ReadonlyUserDatabaseURI: string(pgSecret.Data["database_uri_with_readonlyuser"]),
-		CACert:                  pgSecret.Data["ca.crt"],
+		SuperuserDatabaseURI:           string(pgSecret.Data["database_uri"]),
+		ReadonlyUserDatabaseURI:        string(pgSecret.Data["database_uri_with_readonlyuser"]),
+		CACert:                         pgSecret.Data["ca.crt"],
+		ReplicaDatabaseURI:             string(pgSecret.Data["replica_database_uri"]),
+		ReplicaReadonlyUserDatabaseURI: string(pgSecret.Data["replica_database_uri_with_readonlyuser"]),
+	}, nil
+}
+
+// GetPGConnectionFromExternalPostgres returns the postgres connection from the secrets of the external postgres, the
+// ca certificate is loaded from the connection secret if the ca secret isn't specified
+func GetPGConnectionFromExternalPostgres(ctx context.Context, client client.Client,
+	external *v1alpha4.ExternalPostgres,
+) (*PostgresConnection, error) {
+	connSecret := &corev1.Secret{}
+	err := client.Get(ctx, types.NamespacedName{
+		Name:      external.ConnectionSecretName,
+		Namespace: utils.GetDefaultNamespace(),
+	}, connSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the connection secret %s of the external postgres: %w",
+			external.ConnectionSecretName, err)
+	}
+	if len(connSecret.Data["database_uri"]) == 0 {
+		return nil, fmt.Errorf("the database_uri isn't found in the connection secret %s of the external postgres",
+			external.ConnectionSecretName)
+	}
+
+	caCert := connSecret.Data["ca.crt"]
+	if external.CASecretName != "" {
+		caSecret := &corev1.Secret{}
+		err = client.Get(ctx, types.NamespacedName{
+			Name:      external.CASecretName,
+			Namespace: utils.GetDefaultNamespace(),
+		}, caSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the ca secret %s of the external postgres: %w",
+				external.CASecretName, err)
+		}
+		caCert = caSecret.Data["ca.crt"]
+	}
+
+	return &PostgresConnection{
+		SuperuserDatabaseURI:           string(connSecret.Data["database_uri"]),
+		ReadonlyUserDatabaseURI:        string(connSecret.Data["database_uri_with_readonlyuser"]),
+		CACert:                         caCert,
+		ReplicaDatabaseURI:             string(connSecret.Data["replica_database_uri"]),
+		ReplicaReadonlyUserDatabaseURI: string(connSecret.Data["replica_database_uri_with_readonlyuser"]),
 	}, nil
 }
 
@@ -152,3 +306,65 @@ func SetStorageConnection(conn *PostgresConnection) bool {
 func GetStorageConnection() *PostgresConnection {
 	return postgresConn
 }
+
+// DataRetentionMonths is how many months each class of the data is kept in the database
+type DataRetentionMonths struct {
+	Default           int
+	Events            int
+	ComplianceHistory int
+	ComplianceRollups int
+	Heartbeats        int
+	AuditLogs         int
+}
+
+// GetDataRetentionMonths parses the retention of the postgres, and the retention policy of each class of the data.
+// The classes which aren't set in the policy are kept for the default retention. The retention should at least be 1
+// month, otherwise it will delete the current month partitions and records
+func GetDataRetentionMonths(mgh *v1alpha4.MulticlusterGlobalHub) (*DataRetentionMonths, error) {
+	parse := func(retention string, defaultMonths int) (int, error) {
+		if retention == "" {
+			return defaultMonths, nil
+		}
+		months, err := utils.ParseRetentionMonth(retention)
+		if err != nil {
+			return -1, err
+		}
+		if months < 1 {
+			months = 1
+		}
+		return months, nil
+	}
+
+	defaultMonths, err := parse(mgh.Spec.DataLayer.Postgres.Retention, 18)
+	if err != nil {
+		return nil, err
+	}
+	retentionMonths := &DataRetentionMonths{
+		Default:           defaultMonths,
+		Events:            defaultMonths,
+		ComplianceHistory: defaultMonths,
+		ComplianceRollups: defaultMonths,
+		Heartbeats:        defaultMonths,
+		AuditLogs:         defaultMonths,
+	}
+	policy := mgh.Spec.DataLayer.Postgres.RetentionPolicy
+	if policy == nil {
+		return retentionMonths, nil
+	}
+	if retentionMonths.Events, err = parse(policy.Events, defaultMonths); err != nil {
+		return nil, fmt.Errorf("invalid events retention: %w", err)
+	}
+	if retentionMonths.ComplianceHistory, err = parse(policy.ComplianceHistory, defaultMonths); err != nil {
+		return nil, fmt.Errorf("invalid compliance history retention: %w", err)
+	}
+	if retentionMonths.ComplianceRollups, err = parse(policy.ComplianceRollups, defaultMonths); err != nil {
+		return nil, fmt.Errorf("invalid compliance rollups retention: %w", err)
+	}
+	if retentionMonths.Heartbeats, err = parse(policy.Heartbeats, defaultMonths); err != nil {
+		return nil, fmt.Errorf("invalid heartbeats retention: %w", err)
+	}
+	if retentionMonths.AuditLogs, err = parse(policy.AuditLogs, defaultMonths); err != nil {
+		return nil, fmt.Errorf("invalid audit logs retention: %w", err)
+	}
+	return retentionMonths, nil
+}
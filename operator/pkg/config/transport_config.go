@@ -3,6 +3,7 @@ package config
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"regexp"
 	"strings"
@@ -36,6 +37,9 @@ var (
 	acmResourceReady    = false
 	clientCAKey         []byte
 	clientCACert        []byte
+	transportSecretName = ""
+	// the kafka cluster of an existing strimzi operator, it's nil for the built-in kafka
+	existingKafkaCluster *v1alpha4.KafkaExistingCluster
 )
 
 func SetTransporterConn(conn *transport.KafkaConnCredential) {
@@ -78,11 +82,48 @@ func GetKafkaStorageSize(mgh *v1alpha4.MulticlusterGlobalHub) string {
 	return defaultKafkaStorageSize
 }
 
+// IsExactlyOnceDelivery returns true if the status bundles are sent in the kafka transactions
+func IsExactlyOnceDelivery(mgh *v1alpha4.MulticlusterGlobalHub) bool {
+	return mgh.Spec.DataLayer.Kafka.DeliveryMode == v1alpha4.KafkaDeliveryExactlyOnce
+}
+
+// GetPayloadEncoding returns the encoding of the bundles sent by the manager and the agents, the default is json
+func GetPayloadEncoding(mgh *v1alpha4.MulticlusterGlobalHub) string {
+	if mgh.Spec.DataLayer.Kafka.PayloadEncoding != "" {
+		return mgh.Spec.DataLayer.Kafka.PayloadEncoding
+	}
+	return transport.JSONPayloadEncoding
+}
+
 // SetTransportConfig sets the kafka type, protocol and topics
 func SetTransportConfig(ctx context.Context, runtimeClient client.Client, mgh *v1alpha4.MulticlusterGlobalHub) error {
-	if err := SetKafkaType(ctx, runtimeClient, mgh.Namespace); err != nil {
-		return err
+	if mgh.Spec.DataLayer.Nats != nil {
+		// the streams of the NATS JetStream are provisioned by the operator, and there isn't a built-in kafka to
+		// sign the client certificates or to prune
+		transportSecretName = mgh.Spec.DataLayer.Nats.TransportSecretName
+		transporterProtocol = transport.NatsTransporter
+		isBYOKafka = true
+	} else if mgh.Spec.DataLayer.GRPC != nil {
+		// the agents stream the bundles to the manager, the certificates are issued by the grpc transporter instead
+		// of the built-in kafka
+		transporterProtocol = transport.GRPCTransporter
+		isBYOKafka = true
+	} else if mgh.Spec.DataLayer.HTTP != nil {
+		// the agents post and poll the bundles on the manager, the certificates are issued by the http transporter
+		transporterProtocol = transport.HTTPTransporter
+		isBYOKafka = true
+	} else if mgh.Spec.DataLayer.Kafka.ExistingCluster != nil {
+		// the topics and the users are managed on the existing kafka cluster, instead of installing the strimzi
+		// operator and the built-in kafka
+		transporterProtocol = transport.StrimziTransporter
+		isBYOKafka = false
+	} else {
+		transportSecretName = mgh.Spec.DataLayer.Kafka.TransportSecretName
+		if err := SetKafkaType(ctx, runtimeClient, mgh.Namespace); err != nil {
+			return err
+		}
 	}
+	existingKafkaCluster = mgh.Spec.DataLayer.Kafka.ExistingCluster
 
 	// set the topic
 	specTopic = mgh.Spec.DataLayer.Kafka.KafkaTopics.SpecTopic
@@ -98,7 +139,10 @@ func SetTransportConfig(ctx context.Context, runtimeClient client.Client, mgh *v
 	// BYO Case:
 	// 1. change the default status topic from 'gh-event.*' to 'gh-event'
 	// 2. ensure the status topic must not contain '*'
-	if isBYOKafka {
+	if transporterProtocol == transport.SecretTransporter {
+		if mgh.Spec.DataLayer.Kafka.KafkaTopics.SharingMode == v1alpha4.KafkaTopicPerHub {
+			return fmt.Errorf("the BYO kafka only supports the %s status topic", v1alpha4.KafkaTopicShared)
+		}
 		if statusTopic == DEFAULT_STATUS_TOPIC {
 			mgh.Spec.DataLayer.Kafka.KafkaTopics.StatusTopic = DEFAULT_SHARED_STATUS_TOPIC
 			statusTopic = DEFAULT_SHARED_STATUS_TOPIC
@@ -111,8 +155,31 @@ func SetTransportConfig(ctx context.Context, runtimeClient client.Client, mgh *v
 		if strings.Contains(statusTopic, "*") {
 			return fmt.Errorf("status topic(%s) must not contain '*'", statusTopic)
 		}
+		return nil
 	}
-	return nil
+
+	var err error
+	statusTopic, err = getSharingStatusTopic(statusTopic, mgh.Spec.DataLayer.Kafka.KafkaTopics.SharingMode)
+	return err
+}
+
+// getSharingStatusTopic returns the status topic of the sharing mode, the default status topic is shared by removing
+// the asterisk, so the mode can be switched without changing the topic
+func getSharingStatusTopic(rawStatusTopic string, mode v1alpha4.KafkaTopicSharingMode) (string, error) {
+	switch mode {
+	case v1alpha4.KafkaTopicShared:
+		if rawStatusTopic == DEFAULT_STATUS_TOPIC {
+			return DEFAULT_SHARED_STATUS_TOPIC, nil
+		}
+		if strings.Contains(rawStatusTopic, "*") {
+			return "", fmt.Errorf("the shared status topic(%s) must not contain '*'", rawStatusTopic)
+		}
+	case v1alpha4.KafkaTopicPerHub:
+		if !strings.Contains(rawStatusTopic, "*") {
+			return "", fmt.Errorf("the per-hub status topic(%s) must end with '*'", rawStatusTopic)
+		}
+	}
+	return rawStatusTopic, nil
 }
 
 // isValidKafkaTopicName validates the Kafka topic name based on common rules.
@@ -148,6 +215,11 @@ func GetRawStatusTopic() string {
 	return statusTopic
 }
 
+// IsSharedStatusTopic returns true if all the managed hubs report to the same status topic
+func IsSharedStatusTopic() bool {
+	return !strings.Contains(statusTopic, "*")
+}
+
 // ManagerStatusTopic return the regex topic with fuzzy matching, like '^gh-event.*'
 func ManagerStatusTopic() string {
 	if strings.Contains(statusTopic, "*") {
@@ -156,14 +228,34 @@ func ManagerStatusTopic() string {
 	return statusTopic
 }
 
+// StatusPlaceholderTopic returns the status topic created for the manager, it's the placeholder of the status topics
+// of the managed hubs, like 'gh-event.global-hub', or the shared status topic
+func StatusPlaceholderTopic() string {
+	return strings.Replace(statusTopic, "*", "global-hub", -1)
+}
+
+// GetTransportSecretName returns the name of the secret which contains the connection of the BYO kafka, it's specified
+// in the mgh, otherwise it's the default transport secret
+func GetTransportSecretName() string {
+	if transportSecretName != "" {
+		return transportSecretName
+	}
+	return constants.GHTransportSecretName
+}
+
 // SetKafkaType will assert whether it's a BYO case and also set the related transport protocol
 func SetKafkaType(ctx context.Context, runtimeClient client.Client, namespace string) error {
 	kafkaSecret := &corev1.Secret{}
 	err := runtimeClient.Get(ctx, types.NamespacedName{
-		Name:      constants.GHTransportSecretName,
+		Name:      GetTransportSecretName(),
 		Namespace: namespace,
 	}, kafkaSecret)
 	if err != nil {
+		// the secret referenced by the mgh must exist, the strimzi kafka isn't installed as a fallback
+		if apierrors.IsNotFound(err) && transportSecretName != "" {
+			return fmt.Errorf("the transport secret %s/%s referenced by the mgh is not found", namespace,
+				transportSecretName)
+		}
 		if apierrors.IsNotFound(err) {
 			transporterProtocol = transport.StrimziTransporter
 			isBYOKafka = false
@@ -180,16 +272,34 @@ func IsBYOKafka() bool {
 	return isBYOKafka
 }
 
+// GetExistingKafkaCluster returns the kafka cluster of an existing strimzi operator, it's nil if the global hub
+// installs the built-in kafka
+func GetExistingKafkaCluster() *v1alpha4.KafkaExistingCluster {
+	return existingKafkaCluster
+}
+
 func TransporterProtocol() transport.TransportProtocol {
 	return transporterProtocol
 }
 
+// TransportType returns the transport type of the manager and the agents, it's grpc or http for the gateway
+// transporters, otherwise kafka
+func TransportType() string {
+	switch transporterProtocol {
+	case transport.GRPCTransporter:
+		return string(transport.GRPC)
+	case transport.HTTPTransporter:
+		return string(transport.HTTP)
+	}
+	return string(transport.Kafka)
+}
+
 // GetClientCA the raw([]byte) of client ca key and ca cert
 func GetClientCA() ([]byte, []byte) {
 	return clientCAKey, clientCACert
 }
 
-func SetClientCA(ctx context.Context, namespace, name string, c client.Client) error {
+func SetClientCA(ctx context.Context, namespace, name string, c client.Reader) error {
 	clientCAKeySecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-clients-ca", name),
@@ -228,3 +338,54 @@ func SetClientCA(ctx context.Context, namespace, name string, c client.Client) e
 func GetKafkaUserName(clusterName string) string {
 	return fmt.Sprintf("%s-kafka-user", clusterName)
 }
+
+// GetClusterNameByKafkaUser gives the cluster name of the kafkaUser, it's reverse of the GetKafkaUserName
+func GetClusterNameByKafkaUser(kafkaUserName string) string {
+	return strings.TrimSuffix(kafkaUserName, "-kafka-user")
+}
+
+// GetConsumerGroupID gives the consumer group of the agent on the managed hub, the kafka user of the managed hub is
+// only allowed to read it
+func GetConsumerGroupID(clusterName string) string {
+	return fmt.Sprintf("global-hub-agent-%s", clusterName)
+}
+
+// SchemaRegistryCredential is the schema registry passed to the manager and the agents, the password and the ca
+// certificate are base64 encoded for the secrets
+type SchemaRegistryCredential struct {
+	URL      string
+	Username string
+	Password string
+	CACert   string
+}
+
+// GetSchemaRegistryCredential returns the schema registry of the mgh, it's nil if the schema registry isn't set
+func GetSchemaRegistryCredential(ctx context.Context, runtimeClient client.Client,
+	mgh *v1alpha4.MulticlusterGlobalHub,
+) (*SchemaRegistryCredential, error) {
+	schemaRegistry := mgh.Spec.DataLayer.Kafka.SchemaRegistry
+	if schemaRegistry == nil || schemaRegistry.URL == "" {
+		return nil, nil
+	}
+	credential := &SchemaRegistryCredential{URL: schemaRegistry.URL}
+	if schemaRegistry.CredentialsSecretName == "" {
+		return credential, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := runtimeClient.Get(ctx, types.NamespacedName{
+		Name:      schemaRegistry.CredentialsSecretName,
+		Namespace: mgh.Namespace,
+	}, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the schema registry credentials: %w", err)
+	}
+	credential.Username = string(secret.Data["username"])
+	if password, ok := secret.Data["password"]; ok {
+		credential.Password = base64.StdEncoding.EncodeToString(password)
+	}
+	if caCert, ok := secret.Data["ca.crt"]; ok {
+		credential.CACert = base64.StdEncoding.EncodeToString(caCert)
+	}
+	return credential, nil
+}
@@ -1,8 +1,18 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 )
 
 // TestIsValidKafkaTopicName tests the isValidKafkaTopicName function.
@@ -35,3 +45,91 @@ func TestIsValidKafkaTopicName(t *testing.T) {
 		})
 	}
 }
+
+func TestSetKafkaTypeWithTransportSecretName(t *testing.T) {
+	defer func() {
+		transportSecretName = ""
+		transporterProtocol = transport.StrimziTransporter
+		isBYOKafka = false
+	}()
+	ctx := context.Background()
+	fakeClient := fake.NewClientBuilder().WithScheme(GetRuntimeScheme()).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "external-kafka", Namespace: "default"},
+	}).Build()
+
+	// the strimzi kafka is installed without the default transport secret
+	assert.NoError(t, SetKafkaType(ctx, fakeClient, "default"))
+	assert.Equal(t, constants.GHTransportSecretName, GetTransportSecretName())
+	assert.Equal(t, transport.StrimziTransporter, TransporterProtocol())
+
+	// the secret referenced by the mgh
+	transportSecretName = "external-kafka"
+	assert.NoError(t, SetKafkaType(ctx, fakeClient, "default"))
+	assert.Equal(t, "external-kafka", GetTransportSecretName())
+	assert.Equal(t, transport.SecretTransporter, TransporterProtocol())
+	assert.True(t, IsBYOKafka())
+
+	// the referenced secret doesn't exist
+	transportSecretName = "missing-kafka"
+	assert.Error(t, SetKafkaType(ctx, fakeClient, "default"))
+}
+
+func TestSetTransportConfigWithNats(t *testing.T) {
+	defer func() {
+		transportSecretName = ""
+		transporterProtocol = transport.StrimziTransporter
+		isBYOKafka = false
+		specTopic = ""
+		statusTopic = ""
+	}()
+	mgh := &v1alpha4.MulticlusterGlobalHub{
+		ObjectMeta: metav1.ObjectMeta{Name: "mgh", Namespace: "default"},
+		Spec: v1alpha4.MulticlusterGlobalHubSpec{
+			DataLayer: v1alpha4.DataLayerConfig{
+				Kafka: v1alpha4.KafkaConfig{
+					KafkaTopics: v1alpha4.KafkaTopics{
+						SpecTopic:   DEFAULT_SPEC_TOPIC,
+						StatusTopic: DEFAULT_STATUS_TOPIC,
+					},
+				},
+				Nats: &v1alpha4.NatsConfig{TransportSecretName: "nats"},
+			},
+		},
+	}
+	// the nats secret isn't required to set the transport config
+	fakeClient := fake.NewClientBuilder().WithScheme(GetRuntimeScheme()).Build()
+
+	assert.NoError(t, SetTransportConfig(context.Background(), fakeClient, mgh))
+	assert.Equal(t, transport.NatsTransporter, TransporterProtocol())
+	assert.Equal(t, "nats", GetTransportSecretName())
+	assert.True(t, IsBYOKafka())
+	// the status topic is kept for the individual managed hubs
+	assert.Equal(t, "gh-event.hub1", GetStatusTopic("hub1"))
+}
+
+func TestGetSharingStatusTopic(t *testing.T) {
+	// the mode is derived from the status topic if it isn't set
+	topic, err := getSharingStatusTopic(DEFAULT_STATUS_TOPIC, "")
+	assert.NoError(t, err)
+	assert.Equal(t, DEFAULT_STATUS_TOPIC, topic)
+
+	// the default status topic is shared by removing the asterisk
+	topic, err = getSharingStatusTopic(DEFAULT_STATUS_TOPIC, v1alpha4.KafkaTopicShared)
+	assert.NoError(t, err)
+	assert.Equal(t, DEFAULT_SHARED_STATUS_TOPIC, topic)
+
+	_, err = getSharingStatusTopic("gh-status.*", v1alpha4.KafkaTopicShared)
+	assert.Error(t, err)
+
+	topic, err = getSharingStatusTopic("gh-status.*", v1alpha4.KafkaTopicPerHub)
+	assert.NoError(t, err)
+	assert.Equal(t, "gh-status.*", topic)
+
+	_, err = getSharingStatusTopic("gh-status", v1alpha4.KafkaTopicPerHub)
+	assert.Error(t, err)
+}
+
+func TestGetClusterNameByKafkaUser(t *testing.T) {
+	assert.Equal(t, "hub1", GetClusterNameByKafkaUser(GetKafkaUserName("hub1")))
+	assert.Equal(t, "hub-kafka-user1", GetClusterNameByKafkaUser(GetKafkaUserName("hub-kafka-user1")))
+}
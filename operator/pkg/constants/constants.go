@@ -60,6 +60,51 @@ const (
 	AnnotationStatisticInterval = "mgh-statistic-interval"
 	// AnnotationMetricsScrapeInterval to set the scrape interval for metrics
 	AnnotationMetricsScrapeInterval = "mgh-metrics-scrape-interval"
+	// AnnotationSearchIndexer sends the resources of the managed hubs to the search-v2 indexer, the value can be
+	// "true" to use the indexer of the ACM search, or the URL of the indexer
+	AnnotationSearchIndexer = "mgh-search-indexer"
+	// DefaultSearchIndexerURL is the search-v2 indexer service installed by the ACM
+	DefaultSearchIndexerURL = "https://search-indexer.open-cluster-management.svc:3010"
+	// AnnotationHubEventRateLimit limits the events per second the manager processes from each managed hub,
+	// the hub which keeps exceeding the limit is parked for a while
+	AnnotationHubEventRateLimit = "mgh-hub-event-rate-limit"
+	// AnnotationEventCoalesceWindow is the window in which the identical status events are written once, e.g. "10m",
+	// the events aren't coalesced if it's "0"
+	AnnotationEventCoalesceWindow = "mgh-event-coalesce-window"
+	// AnnotationCloudEventsSink is the URL of the CloudEvents sink which receives the events of the managed hubs,
+	// e.g. a Knative broker
+	AnnotationCloudEventsSink = "mgh-cloudevents-sink"
+	// AnnotationCloudEventsSinkTypes are the comma separated types of the events exported to the CloudEvents sink,
+	// all the types are exported if it isn't set
+	AnnotationCloudEventsSinkTypes = "mgh-cloudevents-sink-types"
+	// AnnotationSIEMAddress is the "host:port" of the syslog collector of the SIEM, e.g. the Splunk, which receives
+	// the policy violations and the cluster events over TLS
+	AnnotationSIEMAddress = "mgh-siem-address"
+	// AnnotationSIEMFormat is the format of the messages to the SIEM collector, "cef" or "rfc5424"
+	AnnotationSIEMFormat = "mgh-siem-format"
+	// AnnotationSIEMFraming is the framing of the messages to the SIEM collector, "octet-counting" or "newline"
+	AnnotationSIEMFraming = "mgh-siem-framing"
+	// AnnotationCanaryHubSelector is the label selector of the managed hubs which receive the spec changes first,
+	// the changes are promoted to the other managed hubs once the canary hubs stay healthy
+	AnnotationCanaryHubSelector = "mgh-canary-hub-selector"
+	// AnnotationKafkaRebalance triggers a rebalance of the built-in kafka with the cruise control, changing the value
+	// triggers a new rebalance
+	AnnotationKafkaRebalance = "mgh-kafka-rebalance"
+	// AnnotationClusterLifecycleTopic is the kafka topic which receives the lifecycle events of the managed clusters,
+	// e.g. for the CMDB, the topic is created in the built-in kafka
+	AnnotationClusterLifecycleTopic = "mgh-cluster-lifecycle-topic"
+	// AnnotationAPIOAuthProxy deploys the oauth-proxy in front of the manager API unless it's "false", the API
+	// authenticates the bearer tokens with the TokenReview without the oauth-proxy
+	AnnotationAPIOAuthProxy = "mgh-api-oauth-proxy"
+	// AnnotationAPIAuthorization authorizes the requests of the manager API with the SubjectAccessReview if it's
+	// "true", the users only get the resources of the hubs and clusters which they are allowed to access
+	AnnotationAPIAuthorization = "mgh-api-authorization"
+	// AnnotationAPIRateLimit limits the requests per second of each client of the manager API, the requests beyond
+	// the limit are rejected with 429
+	AnnotationAPIRateLimit = "mgh-api-rate-limit"
+	// AnnotationAuditLogBackend is where the manager writes the audit logs of the global resources and the manager
+	// API, "database" by default, "file" for the stdout of the manager, or "none" to disable the audit logging
+	AnnotationAuditLogBackend = "mgh-audit-log-backend"
 	// AnnotationONMulticlusterHub indicates the addons are running on a hub cluster
 	AnnotationONMulticlusterHub = "addon.open-cluster-management.io/on-multicluster-hub"
 	// AnnotationPolicyONMulticlusterHub indicates the policy spec sync is running on a hub cluster
@@ -99,6 +144,12 @@ const (
 	ClusterDeployModeHosted                    = "Hosted"
 	ClusterDeployModeDefault                   = "Default"
 
+	// AnnotationClusterStatusRateLimit overrides the status messages per second sent by the agent of the managed hub,
+	// the value "0" disables the throttling of the managed hub
+	AnnotationClusterStatusRateLimit = "global-hub.open-cluster-management.io/status-rate-limit"
+	// AnnotationClusterProducerByteRate overrides the producer byte rate quota of the kafka user of the managed hub
+	AnnotationClusterProducerByteRate = "global-hub.open-cluster-management.io/producer-byte-rate"
+
 	// GHAgentDeployModeLabelKey is to indicate which deploy mode the agent is installed.
 	GHAgentDeployModeLabelKey = "global-hub.open-cluster-management.io/agent-deploy-mode"
 	// GHAgentDeployModeHosted is to install agent in Hosted mode
@@ -144,6 +195,12 @@ const (
 	PostgresMemoryRequest = "128Mi"
 	PostgresCPURequest    = "25m"
 
+	// default resources for the connection pooler of postgres
+	PostgresPooler              = "postgres-pooler"
+	PostgresPoolerMemoryLimit   = "256Mi"
+	PostgresPoolerMemoryRequest = "32Mi"
+	PostgresPoolerCPURequest    = "10m"
+
 	// default resources for manager
 	Manager              = "manager"
 	ManagerMemoryLimit   = "600Mi"
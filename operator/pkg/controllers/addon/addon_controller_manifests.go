@@ -2,6 +2,7 @@ package addon
 
 import (
 	"context"
+	"crypto/sha256"
 	"embed"
 	"encoding/base64"
 	"encoding/json"
@@ -25,6 +26,7 @@ import (
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
 	operatorconstants "github.com/stolostron/multicluster-global-hub/operator/pkg/constants"
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/addon/certificates"
+	operatortrans "github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/hubofhubs/transporter/protocol"
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
 	"github.com/stolostron/multicluster-global-hub/pkg/constants"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
@@ -51,9 +53,27 @@ type ManifestsConfig struct {
 	KafkaClientCert        string
 	KafkaClientKey         string
 	KafkaClientCertSecret  string
+	KafkaSASLMechanism     string
+	KafkaSASLUsername      string
+	KafkaSASLPassword      string
+	KafkaScramSecret       string
+	KafkaScramHash         string
+	SchemaRegistryURL      string
+	SchemaRegistryUsername string
+	SchemaRegistryPassword string
+	SchemaRegistryCACert   string
 	KafkaConsumerTopic     string
+	KafkaConsumerGroup     string
+	KafkaLegacyGroup       string
 	KafkaProducerTopic     string
 	MessageCompressionType string
+	PayloadEncoding        string
+	KafkaTransactional     bool
+	StatusRateLimit        string
+	StatusBurst            int
+	TracingEndpoint        string
+	TracingInsecure        bool
+	TracingSampling        int32
 	InstallACMHub          bool
 	Channel                string
 	CurrentCSV             string
@@ -147,6 +167,30 @@ func (a *HohAgentAddon) setInstallHostedMode(cluster *clusterv1.ManagedCluster,
 	manifestsConfig.KlusterletWorkSA = fmt.Sprintf("klusterlet-%s-work-sa", cluster.GetName())
 }
 
+// getStatusRateLimit returns the status messages per second and the burst of the agent, the rate limit of the mgh is
+// overridden by the annotation of the managed cluster, and the burst is reset to the default with the annotation.
+// The rate limit is empty if the agent isn't throttled
+func (a *HohAgentAddon) getStatusRateLimit(mgh *globalhubv1alpha4.MulticlusterGlobalHub,
+	cluster *clusterv1.ManagedCluster,
+) (string, int) {
+	rateLimit, burst := float64(0), 0
+	if hubRateLimit := mgh.Spec.DataLayer.Kafka.HubRateLimit; hubRateLimit != nil {
+		rateLimit, burst = float64(hubRateLimit.MessagesPerSecond), int(hubRateLimit.Burst)
+	}
+	if val, ok := cluster.GetAnnotations()[operatorconstants.AnnotationClusterStatusRateLimit]; ok {
+		override, err := strconv.ParseFloat(val, 64)
+		if err != nil || override < 0 {
+			a.log.Info("ignore the invalid status rate limit", "cluster", cluster.Name, "value", val)
+		} else {
+			rateLimit, burst = override, 0
+		}
+	}
+	if rateLimit <= 0 {
+		return "", 0
+	}
+	return strconv.FormatFloat(rateLimit, 'f', -1, 64), burst
+}
+
 func (a *HohAgentAddon) setACMPackageConfigs(manifestsConfig *ManifestsConfig) error {
 	pm, err := GetPackageManifestConfig(a.ctx, a.dynamicClient)
 	if err != nil {
@@ -191,26 +235,39 @@ func (a *HohAgentAddon) GetValues(cluster *clusterv1.ManagedCluster,
 	}
 	transporter := config.GetTransporter()
 
-	// will block until the credential is ready
-	kafkaConnection, err := transporter.GetConnCredential(cluster.Name)
-	if err != nil {
-		return nil, err
-	}
 	clusterTopic, err := transporter.EnsureTopic(cluster.Name)
 	if err != nil {
 		return nil, err
 	}
 	// this controller might be triggered by global hub controller(like the topics changes), so we also need to
-	// update the authz for the topic
+	// update the authz for the topic. the user is ensured before loading the credential, since the credential
+	// might contain the password of the user
 	_, err = transporter.EnsureUser(cluster.Name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update the kafkauser for the cluster(%s): %v", cluster.Name, err)
 	}
+	// will block until the credential is ready
+	kafkaConnection, err := transporter.GetConnCredential(cluster.Name)
+	if err != nil {
+		return nil, err
+	}
 
 	kafkaConfigYaml, err := yaml.Marshal(kafkaConnection)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshalling the kafka config yaml: %w", err)
 	}
+	// the agent mounts the CA of the kafka cluster from the secret, it's named by the built-in kafka if the
+	// transporter doesn't specify it
+	kafkaClusterCASecret := kafkaConnection.CASecretName
+	if kafkaClusterCASecret == "" {
+		kafkaClusterCASecret = operatortrans.GetClusterCASecret(operatortrans.KafkaClusterName)
+	}
+	// the consumer group is named by the cluster if the transporter doesn't scope it, and the agent resumes from the
+	// offsets of the legacy group until the migration is done
+	kafkaConsumerGroup := kafkaConnection.ConsumerGroupID
+	if kafkaConsumerGroup == "" {
+		kafkaConsumerGroup = cluster.Name
+	}
 
 	agentResReq := utils.GetResources(operatorconstants.Agent, mgh.Spec.AdvancedConfig)
 	agentRes := &Resources{}
@@ -228,6 +285,17 @@ func (a *HohAgentAddon) GetValues(cluster *clusterv1.ManagedCluster,
 		log.Error(err, "failed to get election config")
 	}
 
+	// the scram password generated by the strimzi is copied into its own secret, and the agent is rolled out by the
+	// hash once the password is rotated
+	kafkaScramSecret, kafkaScramHash := "", ""
+	if kafkaConnection.SASLMechanism == transport.ScramSha512 &&
+		config.TransporterProtocol() == transport.StrimziTransporter {
+		kafkaScramSecret = kafkaConnection.SASLUsername
+		kafkaScramHash = fmt.Sprintf("%x", sha256.Sum256([]byte(kafkaConnection.SASLPassword)))
+	}
+
+	statusRateLimit, statusBurst := a.getStatusRateLimit(mgh, cluster)
+
 	manifestsConfig := ManifestsConfig{
 		HoHAgentImage:          image,
 		ImagePullPolicy:        string(imagePullPolicy),
@@ -239,11 +307,22 @@ func (a *HohAgentAddon) GetValues(cluster *clusterv1.ManagedCluster,
 		KafkaClientCert:        kafkaConnection.ClientCert,
 		KafkaClientKey:         kafkaConnection.ClientKey,
 		KafkaClientCertSecret:  certificates.AgentCertificateSecretName(),
-		KafkaClusterCASecret:   kafkaConnection.CASecretName,
+		KafkaClusterCASecret:   kafkaClusterCASecret,
+		KafkaSASLMechanism:     kafkaConnection.SASLMechanism,
+		KafkaSASLUsername:      kafkaConnection.SASLUsername,
+		KafkaSASLPassword:      kafkaConnection.SASLPassword,
+		KafkaScramSecret:       kafkaScramSecret,
+		KafkaScramHash:         kafkaScramHash,
 		KafkaConsumerTopic:     clusterTopic.SpecTopic,
+		KafkaConsumerGroup:     kafkaConsumerGroup,
+		KafkaLegacyGroup:       kafkaConnection.LegacyConsumerGroupID,
 		KafkaProducerTopic:     clusterTopic.StatusTopic,
 		MessageCompressionType: string(operatorconstants.GzipCompressType),
-		TransportType:          string(transport.Kafka),
+		PayloadEncoding:        config.GetPayloadEncoding(mgh),
+		KafkaTransactional:     config.IsExactlyOnceDelivery(mgh),
+		StatusRateLimit:        statusRateLimit,
+		StatusBurst:            statusBurst,
+		TransportType:          config.TransportType(),
 		LeaseDuration:          strconv.Itoa(electionConfig.LeaseDuration),
 		RenewDeadline:          strconv.Itoa(electionConfig.RenewDeadline),
 		RetryPeriod:            strconv.Itoa(electionConfig.RetryPeriod),
@@ -256,6 +335,11 @@ func (a *HohAgentAddon) GetValues(cluster *clusterv1.ManagedCluster,
 		EnablePprof:            a.operatorConfig.EnablePprof,
 		Resources:              agentRes,
 	}
+	if tracing := config.GetTracingConfig(mgh); tracing != nil {
+		manifestsConfig.TracingEndpoint = tracing.Endpoint
+		manifestsConfig.TracingInsecure = tracing.Insecure
+		manifestsConfig.TracingSampling = *tracing.SamplingPercentage
+	}
 
 	if err := a.setImagePullSecret(mgh, cluster, &manifestsConfig); err != nil {
 		return nil, err
@@ -274,6 +358,17 @@ func (a *HohAgentAddon) GetValues(cluster *clusterv1.ManagedCluster,
 		}
 	}
 
+	schemaRegistry, err := config.GetSchemaRegistryCredential(a.ctx, a.client, mgh)
+	if err != nil {
+		return nil, err
+	}
+	if schemaRegistry != nil {
+		manifestsConfig.SchemaRegistryURL = schemaRegistry.URL
+		manifestsConfig.SchemaRegistryUsername = schemaRegistry.Username
+		manifestsConfig.SchemaRegistryPassword = schemaRegistry.Password
+		manifestsConfig.SchemaRegistryCACert = schemaRegistry.CACert
+	}
+
 	manifestsConfig.Tolerations = mgh.Spec.Tolerations
 	manifestsConfig.NodeSelector = mgh.Spec.NodeSelector
 
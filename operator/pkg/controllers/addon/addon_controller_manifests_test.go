@@ -5,7 +5,10 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
 
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
 	operatorconstants "github.com/stolostron/multicluster-global-hub/operator/pkg/constants"
 )
@@ -96,3 +99,69 @@ func TestHohAgentAddon_getAgentRestConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestHohAgentAddon_getStatusRateLimit(t *testing.T) {
+	a := &HohAgentAddon{log: ctrl.Log.WithName("test")}
+	tests := []struct {
+		name          string
+		hubRateLimit  *globalhubv1alpha4.KafkaHubRateLimit
+		annotations   map[string]string
+		wantRateLimit string
+		wantBurst     int
+	}{
+		{
+			name:          "no rate limit",
+			wantRateLimit: "",
+			wantBurst:     0,
+		},
+		{
+			name:          "rate limit of the mgh",
+			hubRateLimit:  &globalhubv1alpha4.KafkaHubRateLimit{MessagesPerSecond: 50, Burst: 100},
+			wantRateLimit: "50",
+			wantBurst:     100,
+		},
+		{
+			name:         "rate limit overridden by the cluster",
+			hubRateLimit: &globalhubv1alpha4.KafkaHubRateLimit{MessagesPerSecond: 50, Burst: 100},
+			annotations: map[string]string{
+				operatorconstants.AnnotationClusterStatusRateLimit: "2.5",
+			},
+			wantRateLimit: "2.5",
+			wantBurst:     0,
+		},
+		{
+			name:         "rate limit disabled by the cluster",
+			hubRateLimit: &globalhubv1alpha4.KafkaHubRateLimit{MessagesPerSecond: 50},
+			annotations: map[string]string{
+				operatorconstants.AnnotationClusterStatusRateLimit: "0",
+			},
+			wantRateLimit: "",
+			wantBurst:     0,
+		},
+		{
+			name:         "invalid rate limit of the cluster",
+			hubRateLimit: &globalhubv1alpha4.KafkaHubRateLimit{MessagesPerSecond: 50},
+			annotations: map[string]string{
+				operatorconstants.AnnotationClusterStatusRateLimit: "fast",
+			},
+			wantRateLimit: "50",
+			wantBurst:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+			mgh.Spec.DataLayer.Kafka.HubRateLimit = tt.hubRateLimit
+			cluster := &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "hub1", Annotations: tt.annotations},
+			}
+			gotRateLimit, gotBurst := a.getStatusRateLimit(mgh, cluster)
+			if gotRateLimit != tt.wantRateLimit {
+				t.Errorf("HohAgentAddon.getStatusRateLimit() got = %v, want %v", gotRateLimit, tt.wantRateLimit)
+			}
+			if gotBurst != tt.wantBurst {
+				t.Errorf("HohAgentAddon.getStatusRateLimit() got = %v, want %v", gotBurst, tt.wantBurst)
+			}
+		})
+	}
+}
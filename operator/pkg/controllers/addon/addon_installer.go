@@ -89,7 +89,14 @@ func (r *AddonInstaller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		return ctrl.Result{}, nil
 	}
 
-	return ctrl.Result{}, r.reconclieAddonAndResources(ctx, cluster)
+	if err := r.reconclieAddonAndResources(ctx, cluster); err != nil {
+		return ctrl.Result{}, err
+	}
+	// render the manifests with the latest transport credential, e.g. the rotated scram password
+	if addonManager := config.GetAddonManager(); addonManager != nil {
+		addonManager.Trigger(cluster.Name, operatorconstants.GHClusterManagementAddonName)
+	}
+	return ctrl.Result{}, nil
 }
 
 func (r *AddonInstaller) reconclieAddonAndResources(ctx context.Context, cluster *clusterv1.ManagedCluster) error {
@@ -274,9 +281,8 @@ func (r *AddonInstaller) SetupWithManager(ctx context.Context, mgr ctrl.Manager)
 
 	secretCond := func(obj client.Object) bool {
 		if obj.GetName() == config.GetImagePullSecretName() ||
-			obj.GetName() == constants.GHTransportSecretName ||
-			obj.GetLabels() != nil && obj.GetLabels()["strimzi.io/cluster"] == operatortrans.KafkaClusterName &&
-				obj.GetLabels()["strimzi.io/kind"] == "KafkaUser" {
+			obj.GetName() == config.GetTransportSecretName() ||
+			isKafkaUserSecret(obj) {
 			return true
 		}
 		return false
@@ -321,11 +327,28 @@ func (r *AddonInstaller) SetupWithManager(ctx context.Context, mgr ctrl.Manager)
 			builder.WithPredicates(clusterManagementAddonPred)).
 		// secondary watch for transport credentials or image pull secret
 		Watches(&corev1.Secret{}, // the cache is set in manager
-			handler.EnqueueRequestsFromMapFunc(r.renderAllManifestsHandler),
+			handler.EnqueueRequestsFromMapFunc(r.secretHandler),
 			builder.WithPredicates(secretPred)).
 		Complete(r)
 }
 
+// secretHandler only reconciles the managed hub of the kafka user secret, e.g. the scram password of the hub is
+// rotated, the other secrets are shared by all the managed hubs
+func (r *AddonInstaller) secretHandler(ctx context.Context, obj client.Object) []reconcile.Request {
+	if isKafkaUserSecret(obj) {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{
+			Name: config.GetClusterNameByKafkaUser(obj.GetName()),
+		}}}
+	}
+	return r.renderAllManifestsHandler(ctx, obj)
+}
+
+// isKafkaUserSecret returns true if the secret is generated by the strimzi for the kafka user, it's named by the user
+func isKafkaUserSecret(obj client.Object) bool {
+	return obj.GetLabels() != nil && obj.GetLabels()["strimzi.io/cluster"] == operatortrans.GetKafkaClusterName() &&
+		obj.GetLabels()["strimzi.io/kind"] == "KafkaUser"
+}
+
 func (r *AddonInstaller) renderAllManifestsHandler(
 	ctx context.Context, obj client.Object,
 ) []reconcile.Request {
@@ -0,0 +1,200 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"time"
+
+	"github.com/go-logr/logr"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	certutil "k8s.io/client-go/util/cert"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	operatorconstants "github.com/stolostron/multicluster-global-hub/operator/pkg/constants"
+)
+
+const (
+	// the expiry of the client certificate issued to the managed hub
+	ClientCertNotAfterAnnotation = "global-hub.open-cluster-management.io/client-cert-not-after"
+	// the time the client certificate should be renewed by the registration agent of the managed hub
+	ClientCertRenewTimeAnnotation = "global-hub.open-cluster-management.io/client-cert-renew-time"
+
+	// the registration agent renews the certificate once 80% of its lifetime is elapsed, warn if it isn't renewed
+	// when 90% of the lifetime is elapsed
+	renewLifetimeRatio   = 0.8
+	overdueLifetimeRatio = 0.9
+	// how often the issued certificates are checked for the overdue renewal
+	rotationCheckInterval = time.Hour
+)
+
+// CertificateRotationController tracks the expiry of the client certificates issued to the managed hubs by the
+// client CA. The registration agent of the managed hub renews the certificate ahead of its expiry with a new CSR,
+// which is approved and signed by the addon controller, then the agent reloads the renewed certificate without
+// restarting. The controller records the expiry on the addon, and warns about the certificates that aren't renewed
+// in time or are signed by a previous client CA.
+type CertificateRotationController struct {
+	client.Client
+	log logr.Logger
+}
+
+func NewCertificateRotationController(c client.Client) *CertificateRotationController {
+	return &CertificateRotationController{
+		Client: c,
+		log:    ctrl.Log.WithName("certificate-rotation"),
+	}
+}
+
+func (r *CertificateRotationController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	csr := &certificatesv1.CertificateSigningRequest{}
+	if err := r.Get(ctx, req.NamespacedName, csr); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	clusterName := csr.Labels[clusterv1.ClusterNameLabelKey]
+	cert, err := parseCertificate(csr.Status.Certificate)
+	if clusterName == "" || cert == nil || err != nil {
+		return ctrl.Result{}, err
+	}
+
+	addon := &addonapiv1alpha1.ManagedClusterAddOn{}
+	err = r.Get(ctx, types.NamespacedName{
+		Namespace: clusterName,
+		Name:      operatorconstants.GHManagedClusterAddonName,
+	}, addon)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// only track the latest certificate, the CSR of the previous one might be reconciled after the renewed one
+	notAfter := cert.NotAfter.UTC().Format(time.RFC3339)
+	if current, found := addon.Annotations[ClientCertNotAfterAnnotation]; found {
+		if currentTime, err := time.Parse(time.RFC3339, current); err == nil && !cert.NotAfter.After(currentTime) {
+			return ctrl.Result{}, nil
+		}
+	}
+
+	if addon.Annotations == nil {
+		addon.Annotations = map[string]string{}
+	}
+	addon.Annotations[ClientCertNotAfterAnnotation] = notAfter
+	addon.Annotations[ClientCertRenewTimeAnnotation] = renewTime(cert).UTC().Format(time.RFC3339)
+	if err := r.Update(ctx, addon); err != nil {
+		if errors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	r.log.Info("the client certificate is issued", "cluster", clusterName, "notAfter", notAfter)
+	return ctrl.Result{}, nil
+}
+
+// Start checks the issued certificates periodically, since no CSR is created if the renewal doesn't happen
+func (r *CertificateRotationController) Start(ctx context.Context) error {
+	ticker := time.NewTicker(rotationCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.checkCertificates(ctx); err != nil {
+				r.log.Error(err, "failed to check the client certificates")
+			}
+		}
+	}
+}
+
+func (r *CertificateRotationController) checkCertificates(ctx context.Context) error {
+	csrList := &certificatesv1.CertificateSigningRequestList{}
+	if err := r.List(ctx, csrList); err != nil {
+		return err
+	}
+
+	// the latest certificate of each managed hub
+	latest := map[string]*x509.Certificate{}
+	for i := range csrList.Items {
+		csr := csrList.Items[i]
+		clusterName := csr.Labels[clusterv1.ClusterNameLabelKey]
+		if csr.Spec.SignerName != SignerName || clusterName == "" {
+			continue
+		}
+		cert, err := parseCertificate(csr.Status.Certificate)
+		if err != nil || cert == nil {
+			continue
+		}
+		if existing, found := latest[clusterName]; !found || cert.NotAfter.After(existing.NotAfter) {
+			latest[clusterName] = cert
+		}
+	}
+
+	_, caCertBytes := config.GetClientCA()
+	caCert, _ := parseCertificate(caCertBytes)
+	now := time.Now()
+	for clusterName, cert := range latest {
+		if renewalOverdue(cert, now) {
+			r.log.Info("the client certificate isn't renewed in time, check the registration agent of the managed hub",
+				"cluster", clusterName, "notAfter", cert.NotAfter)
+		}
+		if caCert != nil && !signedBy(cert, caCert) {
+			r.log.Info("the client certificate is signed by a previous client CA, it's renewed by the new one soon",
+				"cluster", clusterName, "notAfter", cert.NotAfter)
+		}
+	}
+	return nil
+}
+
+func (r *CertificateRotationController) SetupWithManager(mgr ctrl.Manager) error {
+	signerPred := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		csr, ok := object.(*certificatesv1.CertificateSigningRequest)
+		return ok && csr.Spec.SignerName == SignerName && len(csr.Status.Certificate) > 0
+	})
+	err := ctrl.NewControllerManagedBy(mgr).Named("certificate-rotation").
+		For(&certificatesv1.CertificateSigningRequest{}, builder.WithPredicates(signerPred,
+			predicate.Funcs{DeleteFunc: func(e event.DeleteEvent) bool { return false }})).
+		Complete(r)
+	if err != nil {
+		return err
+	}
+	return mgr.Add(manager.RunnableFunc(r.Start))
+}
+
+// renewTime is when the registration agent renews the certificate
+func renewTime(cert *x509.Certificate) time.Time {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return cert.NotBefore.Add(time.Duration(float64(lifetime) * renewLifetimeRatio))
+}
+
+// renewalOverdue returns true if the certificate should have been renewed by the registration agent
+func renewalOverdue(cert *x509.Certificate, now time.Time) bool {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return now.After(cert.NotBefore.Add(time.Duration(float64(lifetime) * overdueLifetimeRatio)))
+}
+
+func signedBy(cert, caCert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, caCert.RawSubject) && cert.CheckSignatureFrom(caCert) == nil
+}
+
+func parseCertificate(certBytes []byte) (*x509.Certificate, error) {
+	if len(certBytes) == 0 {
+		return nil, nil
+	}
+	certs, err := certutil.ParseCertsPEM(certBytes)
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
@@ -0,0 +1,43 @@
+package certificates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificateRotation(t *testing.T) {
+	caCert, caKey, err := generateKeyAndCert()
+	assert.Nil(t, err)
+
+	certBytes := Sign(newCSR("test", "cluster1"), caKey, caCert)
+	assert.NotNil(t, certBytes)
+	cert, err := parseCertificate(certBytes)
+	assert.Nil(t, err)
+
+	// the certificate is renewed at 80% of the lifetime
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	assert.WithinDuration(t, cert.NotBefore.Add(lifetime*4/5), renewTime(cert), time.Second)
+
+	assert.False(t, renewalOverdue(cert, time.Now()))
+	assert.False(t, renewalOverdue(cert, renewTime(cert).Add(time.Hour)))
+	assert.True(t, renewalOverdue(cert, cert.NotAfter.Add(-time.Hour)))
+
+	// the certificate is signed by the current client CA
+	ca, err := parseCertificate(caCert)
+	assert.Nil(t, err)
+	assert.True(t, signedBy(cert, ca))
+
+	// the client CA is renewed
+	newCACert, _, err := generateKeyAndCert()
+	assert.Nil(t, err)
+	newCA, err := parseCertificate(newCACert)
+	assert.Nil(t, err)
+	assert.False(t, signedBy(cert, newCA))
+
+	// no certificate is issued yet
+	cert, err = parseCertificate(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, cert)
+}
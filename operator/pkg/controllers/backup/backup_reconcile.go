@@ -36,6 +36,10 @@ const (
 	configmapType = "ConfigMap"
 	mghType       = "MulticlusterGlobalHub"
 	pvcType       = "PersistentVolumeClaim"
+	kafkaType     = "Kafka"
+	nodePoolType  = "KafkaNodePool"
+	topicType     = "KafkaTopic"
+	userType      = "KafkaUser"
 )
 
 var allResourcesBackup = map[string]Backup{
@@ -43,6 +47,10 @@ var allResourcesBackup = map[string]Backup{
 	configmapType: NewConfigmapBackup(),
 	mghType:       NewMghBackup(),
 	pvcType:       NewPvcBackup(),
+	kafkaType:     NewKafkaBackup(kafkaType),
+	nodePoolType:  NewKafkaBackup(nodePoolType),
+	topicType:     NewKafkaBackup(topicType),
+	userType:      NewKafkaBackup(userType),
 }
 
 // As we need to watch mgh, secret, configmap. they should be in the same namespace.
@@ -20,6 +20,7 @@ import (
 	"context"
 	"reflect"
 
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
 	"github.com/go-logr/logr"
 	mchv1 "github.com/stolostron/multiclusterhub-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -35,6 +36,7 @@ import (
 
 	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/hubofhubs/transporter/protocol"
 	"github.com/stolostron/multicluster-global-hub/pkg/constants"
 	"github.com/stolostron/multicluster-global-hub/pkg/utils"
 )
@@ -61,7 +63,7 @@ func NewBackupReconciler(mgr manager.Manager, log logr.Logger) *BackupReconciler
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *BackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).Named("backupController").
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).Named("backupController").
 		For(&globalhubv1alpha4.MulticlusterGlobalHub{},
 			builder.WithPredicates(mghPred)).
 		Watches(&corev1.Secret{},
@@ -75,8 +77,17 @@ func (r *BackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			builder.WithPredicates(pvcPred)).
 		Watches(&mchv1.MultiClusterHub{},
 			mchEventHandler,
-			builder.WithPredicates(mchPred)).
-		Complete(r)
+			builder.WithPredicates(mchPred))
+
+	// the strimzi resources are watched only if the kafka crds are installed when the controller starts
+	if kafkaBackupEnabled() {
+		for _, obj := range []client.Object{
+			&kafkav1beta2.Kafka{}, &kafkav1beta2.KafkaTopic{}, &kafkav1beta2.KafkaUser{},
+		} {
+			controllerBuilder = controllerBuilder.Watches(obj, objEventHandler, builder.WithPredicates(kafkaPred))
+		}
+	}
+	return controllerBuilder.Complete(r)
 }
 
 var mchEventHandler = handler.EnqueueRequestsFromMapFunc(
@@ -107,13 +118,13 @@ var mghPred = predicate.Funcs{
 
 var secretPred = predicate.Funcs{
 	CreateFunc: func(e event.CreateEvent) bool {
-		if !secretList.Has(e.Object.GetName()) {
+		if !isBackupSecret(e.Object.GetName()) {
 			return false
 		}
 		return !utils.HasItem(e.Object.GetLabels(), constants.BackupKey, constants.BackupActivationValue)
 	},
 	UpdateFunc: func(e event.UpdateEvent) bool {
-		if !secretList.Has(e.ObjectNew.GetName()) {
+		if !isBackupSecret(e.ObjectNew.GetName()) {
 			return false
 		}
 		return !utils.HasItem(e.ObjectNew.GetLabels(), constants.BackupKey, constants.BackupActivationValue)
@@ -157,13 +168,39 @@ var mchPred = predicate.Funcs{
 	},
 }
 
+var kafkaPred = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		if !isGlobalHubKafkaResource(e.Object) {
+			return false
+		}
+		return !utils.HasItem(e.Object.GetLabels(), constants.BackupKey, constants.BackupGlobalHubValue)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if !isGlobalHubKafkaResource(e.ObjectNew) {
+			return false
+		}
+		return !utils.HasItem(e.ObjectNew.GetLabels(), constants.BackupKey, constants.BackupGlobalHubValue)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// isGlobalHubKafkaResource returns true if the object is the kafka cluster of the global hub or belongs to it
+func isGlobalHubKafkaResource(obj client.Object) bool {
+	if obj.GetName() == protocol.GetKafkaClusterName() {
+		return true
+	}
+	return utils.HasItem(obj.GetLabels(), strimziClusterLabelKey, protocol.GetKafkaClusterName())
+}
+
 var pvcPred = predicate.Funcs{
 	CreateFunc: func(e event.CreateEvent) bool {
-		// only watch postgres pvc
-		return utils.HasItem(e.Object.GetLabels(), postgresPvcLabelKey, postgresPvcLabelValue)
+		// only watch postgres and kafka pvc
+		return isBackupPvc(e.Object)
 	},
 	UpdateFunc: func(e event.UpdateEvent) bool {
-		return utils.HasItem(e.ObjectNew.GetLabels(), postgresPvcLabelKey, postgresPvcLabelValue)
+		return isBackupPvc(e.ObjectNew)
 	},
 	DeleteFunc: func(e event.DeleteEvent) bool {
 		return false
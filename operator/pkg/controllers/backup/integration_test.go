@@ -379,6 +379,60 @@ var _ = Describe("Backup controller", Ordered, func() {
 			}, timeout, interval).Should(BeTrue())
 		})
 	})
+
+	Context("add backup label to kafka PVC", Ordered, func() {
+		BeforeAll(func() {
+			config.SetKafkaResourceReady(true)
+		})
+		AfterAll(func() {
+			config.SetKafkaResourceReady(false)
+		})
+
+		It("Should create the kafka PVC with backup label", func() {
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "data-kafka-kafka-0",
+					Namespace: mghNamespace,
+					Labels: map[string]string{
+						"strimzi.io/cluster": "kafka",
+						"strimzi.io/kind":    "Kafka",
+					},
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{
+						corev1.ReadWriteOnce,
+					},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse("5Gi"),
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pvc)).Should(Succeed())
+
+			Eventually(func() bool {
+				Expect(k8sClient.Get(ctx, types.NamespacedName{
+					Namespace: mghNamespace,
+					Name:      "data-kafka-kafka-0",
+				}, pvc, &client.GetOptions{})).Should(Succeed())
+
+				return utils.HasItem(pvc.Labels, constants.BackupVolumnKey, constants.BackupGlobalHubValue)
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("Disable backup, backup label should be deleted from kafka pvc", func() {
+			disableBackup()
+			Eventually(func() bool {
+				pvc := &corev1.PersistentVolumeClaim{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{
+					Namespace: mghNamespace,
+					Name:      "data-kafka-kafka-0",
+				}, pvc, &client.GetOptions{})).Should(Succeed())
+				return !utils.HasItem(pvc.Labels, constants.BackupVolumnKey, constants.BackupGlobalHubValue)
+			}, timeout, interval).Should(BeTrue())
+		})
+	})
 })
 
 func disableBackup() {
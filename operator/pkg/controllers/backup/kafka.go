@@ -0,0 +1,145 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/hubofhubs/transporter/protocol"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/utils"
+)
+
+const (
+	strimziClusterLabelKey = "strimzi.io/cluster"
+	strimziKindLabelKey    = "strimzi.io/kind"
+)
+
+// kafkaBackup adds the backup label to the strimzi resources of the built-in kafka cluster, the kafka cluster and its
+// node pools, topics and users are restored before the strimzi operator reconciles them
+type kafkaBackup struct {
+	backupType string
+	gvk        schema.GroupVersionKind
+	labelKey   string
+	labelValue string
+}
+
+func NewKafkaBackup(kind string) *kafkaBackup {
+	return &kafkaBackup{
+		backupType: kind,
+		gvk:        schema.GroupVersionKind{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: kind},
+		labelKey:   constants.BackupKey,
+		labelValue: constants.BackupGlobalHubValue,
+	}
+}
+
+// kafkaBackupEnabled returns true if the built-in kafka is installed by the global hub, the existing kafka cluster is
+// backed up by its owner
+func kafkaBackupEnabled() bool {
+	return config.GetKafkaResourceReady() && config.TransporterProtocol() == transport.StrimziTransporter &&
+		config.GetExistingKafkaCluster() == nil
+}
+
+func (r *kafkaBackup) newObj() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(r.gvk)
+	return obj
+}
+
+func (r *kafkaBackup) AddLabelToOneObj(ctx context.Context,
+	client client.Client,
+	namespace, name string,
+) error {
+	return utils.AddLabel(ctx, client, r.newObj(), namespace, name, r.labelKey, r.labelValue)
+}
+
+func (r *kafkaBackup) AddLabelToAllObjs(ctx context.Context, c client.Client, namespace string) error {
+	names, err := r.listNames(ctx, c, namespace)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		err := utils.AddLabel(ctx, c, r.newObj(), namespace, name, r.labelKey, r.labelValue)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *kafkaBackup) DeleteLabelOfAllObjs(ctx context.Context, c client.Client, namespace string) error {
+	names, err := r.listNames(ctx, c, namespace)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		err := utils.DeleteLabel(ctx, c, r.newObj(), namespace, name, r.labelKey)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listNames returns the names of the resources which belong to the kafka cluster of the global hub
+func (r *kafkaBackup) listNames(ctx context.Context, c client.Client, namespace string) ([]string, error) {
+	if !kafkaBackupEnabled() {
+		return nil, nil
+	}
+	if r.gvk.Kind == "Kafka" {
+		return []string{protocol.GetKafkaClusterName()}, nil
+	}
+
+	objList := &unstructured.UnstructuredList{}
+	objList.SetGroupVersionKind(r.gvk.GroupVersion().WithKind(r.gvk.Kind + "List"))
+	err := c.List(ctx, objList, client.InNamespace(namespace),
+		client.MatchingLabels{strimziClusterLabelKey: protocol.GetKafkaClusterName()})
+	if err != nil {
+		// the kind, e.g. KafkaNodePool, isn't served by the installed strimzi
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := []string{}
+	for _, obj := range objList.Items {
+		names = append(names, obj.GetName())
+	}
+	return names, nil
+}
+
+// kafkaCASecrets are the certificate authorities of the kafka cluster, the agents trust the restored kafka cluster only
+// if they are restored
+func kafkaCASecrets() []string {
+	if !kafkaBackupEnabled() {
+		return nil
+	}
+	clusterName := protocol.GetKafkaClusterName()
+	return []string{
+		clusterName + "-cluster-ca",
+		clusterName + "-cluster-ca-cert",
+		clusterName + "-clients-ca",
+		clusterName + "-clients-ca-cert",
+	}
+}
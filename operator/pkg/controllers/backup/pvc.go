@@ -24,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/hubofhubs/transporter/protocol"
 	"github.com/stolostron/multicluster-global-hub/pkg/constants"
 	"github.com/stolostron/multicluster-global-hub/pkg/utils"
 )
@@ -67,22 +68,11 @@ func (r *pvcBackup) AddLabelToOneObj(ctx context.Context,
 }
 
 func (r *pvcBackup) AddLabelToAllObjs(ctx context.Context, c client.Client, namespace string) error {
-	postgresList := &corev1.PersistentVolumeClaimList{}
-	err := c.List(ctx, postgresList, &client.ListOptions{
-		Namespace: namespace,
-		LabelSelector: labels.SelectorFromSet(
-			labels.Set{
-				constants.PostgresPvcLabelKey: constants.PostgresPvcLabelValue,
-			},
-		),
-	})
+	objs, err := listBackupPvcs(ctx, c, namespace, labels.Set{})
 	if err != nil {
 		return err
 	}
 
-	var objs []corev1.PersistentVolumeClaim
-	objs = append(objs, postgresList.Items...)
-
 	for _, obj := range objs {
 		pvc := &corev1.PersistentVolumeClaim{}
 		if !utils.HasItem(obj.GetLabels(), r.prehookKey, r.labelValue) {
@@ -103,23 +93,11 @@ func (r *pvcBackup) AddLabelToAllObjs(ctx context.Context, c client.Client, name
 }
 
 func (r *pvcBackup) DeleteLabelOfAllObjs(ctx context.Context, c client.Client, namespace string) error {
-	postgresList := &corev1.PersistentVolumeClaimList{}
-	err := c.List(ctx, postgresList, &client.ListOptions{
-		Namespace: namespace,
-		LabelSelector: labels.SelectorFromSet(
-			labels.Set{
-				constants.PostgresPvcLabelKey: constants.PostgresPvcLabelValue,
-				r.labelKey:                    r.labelValue,
-			},
-		),
-	})
+	objs, err := listBackupPvcs(ctx, c, namespace, labels.Set{r.labelKey: r.labelValue})
 	if err != nil {
 		return err
 	}
 
-	var objs []corev1.PersistentVolumeClaim
-	objs = append(objs, postgresList.Items...)
-
 	for _, obj := range objs {
 		pvc := &corev1.PersistentVolumeClaim{}
 		if utils.HasItemKey(obj.GetLabels(), r.prehookKey) {
@@ -137,3 +115,44 @@ func (r *pvcBackup) DeleteLabelOfAllObjs(ctx context.Context, c client.Client, n
 	}
 	return nil
 }
+
+// backupPvcSelectors are the labels of the postgres pvc and the kafka pvc
+func backupPvcSelectors() []labels.Set {
+	selectors := []labels.Set{
+		{constants.PostgresPvcLabelKey: constants.PostgresPvcLabelValue},
+	}
+	if kafkaBackupEnabled() {
+		selectors = append(selectors, labels.Set{
+			strimziClusterLabelKey: protocol.GetKafkaClusterName(),
+			strimziKindLabelKey:    "Kafka",
+		})
+	}
+	return selectors
+}
+
+func isBackupPvc(obj client.Object) bool {
+	for _, selector := range backupPvcSelectors() {
+		if labels.SelectorFromSet(selector).Matches(labels.Set(obj.GetLabels())) {
+			return true
+		}
+	}
+	return false
+}
+
+func listBackupPvcs(ctx context.Context, c client.Client, namespace string,
+	extraLabels labels.Set,
+) ([]corev1.PersistentVolumeClaim, error) {
+	var objs []corev1.PersistentVolumeClaim
+	for _, selector := range backupPvcSelectors() {
+		pvcList := &corev1.PersistentVolumeClaimList{}
+		err := c.List(ctx, pvcList, &client.ListOptions{
+			Namespace:     namespace,
+			LabelSelector: labels.SelectorFromSet(labels.Merge(selector, extraLabels)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, pvcList.Items...)
+	}
+	return objs, nil
+}
@@ -31,8 +31,19 @@ var secretList = sets.NewString(
 	constants.CustomGrafanaIniName,
 	constants.GHTransportSecretName,
 	constants.GHStorageSecretName,
+	constants.GHBuiltInStorageSecretName,
 )
 
+// isBackupSecret returns true if the secret is backed up with the global hub
+func isBackupSecret(name string) bool {
+	for _, caSecret := range kafkaCASecrets() {
+		if name == caSecret {
+			return true
+		}
+	}
+	return secretList.Has(name)
+}
+
 type secretBackup struct {
 	backupType string
 	labelKey   string
@@ -58,7 +69,7 @@ func (r *secretBackup) AddLabelToOneObj(ctx context.Context,
 }
 
 func (r *secretBackup) AddLabelToAllObjs(ctx context.Context, client client.Client, namespace string) error {
-	for name := range secretList {
+	for _, name := range append(secretList.List(), kafkaCASecrets()...) {
 		obj := &corev1.Secret{}
 		err := utils.AddLabel(ctx, client, obj, namespace, name, r.labelKey, r.labelValue)
 		if err != nil {
@@ -69,7 +80,7 @@ func (r *secretBackup) AddLabelToAllObjs(ctx context.Context, client client.Clie
 }
 
 func (r *secretBackup) DeleteLabelOfAllObjs(ctx context.Context, client client.Client, namespace string) error {
-	for name := range secretList {
+	for _, name := range append(secretList.List(), kafkaCASecrets()...) {
 		obj := &corev1.Secret{}
 		err := utils.DeleteLabel(ctx, client, obj, namespace, name, r.labelKey)
 		if err != nil {
@@ -36,8 +36,10 @@ import (
 
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/addon"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/addon/certificates"
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/backup"
 	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 )
 
 var ACMCrds = []string{
@@ -113,6 +115,13 @@ func (r *CrdController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		if err != nil {
 			return ctrl.Result{}, err
 		}
+		// track the expiry of the client certificates signed by the addon controller
+		if config.TransporterProtocol() == transport.StrimziTransporter {
+			err = certificates.NewCertificateRotationController(r.Manager.GetClient()).SetupWithManager(r.Manager)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 		r.addonController = addonController
 	}
 
@@ -31,6 +31,7 @@ import (
 	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	admissionv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -314,6 +315,16 @@ func addGlobalHubControllerWatches(mgr ctrl.Manager, globalHubController control
 		return err
 	}
 
+	if err := globalHubController.Watch(
+		source.Kind(mgr.GetCache(), &batchv1.Job{},
+			handler.TypedEnqueueRequestsFromMapFunc(func(ctx context.Context,
+				j *batchv1.Job,
+			) []reconcile.Request {
+				return []reconcile.Request{{NamespacedName: config.GetMGHNamespacedName()}}
+			}), watchBackupJobPredict())); err != nil {
+		return err
+	}
+
 	if err := globalHubController.Watch(
 		source.Kind(mgr.GetCache(), &rbacv1.ClusterRole{},
 			handler.TypedEnqueueRequestsFromMapFunc(func(ctx context.Context,
@@ -456,10 +467,12 @@ func watchSubscriptionPredict() predicate.TypedPredicate[*subv1alpha1.Subscripti
 
 func watchSecretPredict() predicate.TypedPredicate[*corev1.Secret] {
 	secretCond := func(obj client.Object) bool {
-		if WatchedSecret.Has(obj.GetName()) {
+		if WatchedSecret.Has(obj.GetName()) || obj.GetName() == config.GetTransportSecretName() ||
+			config.IsExternalPostgresSecret(obj.GetName()) || config.IsPostgresCertificateSecret(obj.GetName()) ||
+			config.IsPayloadEncryptionKeySecret(obj.GetName()) {
 			return true
 		}
-		if obj.GetLabels()["strimzi.io/cluster"] == protocol.KafkaClusterName &&
+		if obj.GetLabels()["strimzi.io/cluster"] == protocol.GetKafkaClusterName() &&
 			obj.GetLabels()["strimzi.io/kind"] == "KafkaUser" {
 			return true
 		}
@@ -500,6 +513,25 @@ func watchConfigMapPredict() predicate.TypedPredicate[*corev1.ConfigMap] {
 	}
 }
 
+// watchBackupJobPredict triggers the reconcile when the postgres backup job is finished to update the condition
+func watchBackupJobPredict() predicate.TypedPredicate[*batchv1.Job] {
+	return predicate.TypedFuncs[*batchv1.Job]{
+		CreateFunc: func(e event.TypedCreateEvent[*batchv1.Job]) bool {
+			return false
+		},
+		UpdateFunc: func(e event.TypedUpdateEvent[*batchv1.Job]) bool {
+			if name := e.ObjectNew.GetLabels()["name"]; name != storage.PostgresBackupName &&
+				name != storage.PostgresMaintenanceName {
+				return false
+			}
+			return !equality.Semantic.DeepEqual(e.ObjectOld.Status.Conditions, e.ObjectNew.Status.Conditions)
+		},
+		DeleteFunc: func(e event.TypedDeleteEvent[*batchv1.Job]) bool {
+			return false
+		},
+	}
+}
+
 func watchNamespacePredict() predicate.TypedPredicate[*corev1.Namespace] {
 	return predicate.TypedFuncs[*corev1.Namespace]{
 		CreateFunc: func(e event.TypedCreateEvent[*corev1.Namespace]) bool {
@@ -562,8 +594,10 @@ func watchMutatingWebhookConfigurationPredicate() predicate.TypedPredicate[*admi
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;delete;patch
+// +kubebuilder:rbac:groups="",resources=pods;nodes,verbs=get;list
 // +kubebuilder:rbac:groups="apps",resources=deployments,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups="apps",resources=statefulsets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="batch",resources=cronjobs;jobs,verbs=get;list;watch;create;update;delete;patch
 // +kubebuilder:rbac:groups="route.openshift.io",resources=routes,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=rolebindings,verbs=get;list;watch;create;update;delete
@@ -577,10 +611,16 @@ func watchMutatingWebhookConfigurationPredicate() predicate.TypedPredicate[*admi
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheuses/api,resourceNames=k8s,verbs=get;create;update
 // +kubebuilder:rbac:groups=operators.coreos.com,resources=subscriptions,verbs=get;create;delete;update;list;watch
 // +kubebuilder:rbac:groups=operators.coreos.com,resources=clusterserviceversions,verbs=delete
-// +kubebuilder:rbac:groups=postgres-operator.crunchydata.com,resources=postgresclusters,verbs=get;create;list;watch
-// +kubebuilder:rbac:groups=kafka.strimzi.io,resources=kafkas;kafkatopics;kafkausers,verbs=get;create;list;watch;update;delete
+// +kubebuilder:rbac:groups=operators.coreos.com,resources=installplans,verbs=get;update
+// +kubebuilder:rbac:groups=postgres-operator.crunchydata.com,resources=postgresclusters,verbs=get;create;list;watch;update
+// +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=clusters;scheduledbackups,verbs=get;create;list;watch;update;delete
+// +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=backups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jetstream.nats.io,resources=streams,verbs=get;create;list;watch;update
+// +kubebuilder:rbac:groups=kafka.strimzi.io,resources=kafkabridges;kafkaconnectors;kafkaconnects;kafkanodepools;kafkarebalances;kafkas;kafkatopics;kafkausers,verbs=get;create;list;watch;update;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;create;list;watch;update;delete
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups=image.openshift.io,resources=imagestreams,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -35,6 +35,7 @@ import (
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/renderer"
 	operatorutils "github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
 	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 	"github.com/stolostron/multicluster-global-hub/pkg/utils"
 )
 
@@ -136,8 +137,8 @@ func (r *GrafanaReconciler) Reconcile(ctx context.Context,
 			DatasourceSecretName:  datasourceName,
 			NodeSelector:          mgh.Spec.NodeSelector,
 			Tolerations:           mgh.Spec.Tolerations,
-			EnableKafkaMetrics:    (!config.IsBYOKafka()) && mgh.Spec.EnableMetrics,
-			EnablePostgresMetrics: (!config.IsBYOPostgres()) && mgh.Spec.EnableMetrics,
+			EnableKafkaMetrics:    enableKafkaMetrics(mgh),
+			EnablePostgresMetrics: config.IsPostgresMetricsEnabled(mgh),
 			EnableMetrics:         mgh.Spec.EnableMetrics,
 			Resources:             operatorutils.GetResources(operatorconstants.Grafana, mgh.Spec.AdvancedConfig),
 		}, nil
@@ -184,6 +185,13 @@ func (r *GrafanaReconciler) Reconcile(ctx context.Context,
 	return nil
 }
 
+// enableKafkaMetrics returns true if the kafka dashboards are rendered, the metrics are only exported by the built-in
+// kafka, so the dashboards aren't rendered for the BYO kafka, the existing kafka cluster and the gateway transporters
+func enableKafkaMetrics(mgh *globalhubv1alpha4.MulticlusterGlobalHub) bool {
+	return mgh.Spec.EnableMetrics && config.TransporterProtocol() == transport.StrimziTransporter &&
+		config.GetExistingKafkaCluster() == nil
+}
+
 // generateGranafaIni append the custom grafana.ini to default grafana.ini
 func (r *GrafanaReconciler) generateGrafanaIni(
 	ctx context.Context,
@@ -470,9 +478,11 @@ func (r *GrafanaReconciler) GenerateGrafanaDataSourceSecret(
 		saToken = string(saSecret.Data["token"])
 	}
 
-	datasourceVal, err := GrafanaDataSource(storageConn.ReadonlyUserDatabaseURI, storageConn.CACert, saToken)
+	// the dashboards query the read-only replica if it's set
+	datasourceVal, err := GrafanaDataSource(storageConn.ClientReplicaReadonlyUserDatabaseURI(), storageConn.CACert,
+		saToken)
 	if err != nil {
-		datasourceVal, err = GrafanaDataSource(storageConn.SuperuserDatabaseURI, storageConn.CACert, saToken)
+		datasourceVal, err = GrafanaDataSource(storageConn.ClientSuperuserDatabaseURI(), storageConn.CACert, saToken)
 		if err != nil {
 			return false, err
 		}
@@ -580,10 +590,12 @@ func GrafanaDataSource(databaseURI string, cert []byte, serviceAccountToken stri
 	}
 
 	if len(cert) > 0 {
-		postgresDS.JSONData.SSLMode = objURI.Query().Get("sslmode") // sslmode == "verify-full" || sslmode == "verify-ca"
+		sslMode := objURI.Query().Get("sslmode")
+		postgresDS.JSONData.SSLMode = sslMode // sslmode == "verify-full" || sslmode == "verify-ca"
 		postgresDS.JSONData.TLSAuth = true
 		postgresDS.JSONData.TLSAuthWithCACert = true
-		postgresDS.JSONData.TLSSkipVerify = true
+		// the certificate is verified with the CA, and the host name is also verified for the "verify-full"
+		postgresDS.JSONData.TLSSkipVerify = sslMode != "verify-full" && sslMode != "verify-ca"
 		postgresDS.JSONData.TLSConfigurationMethod = "file-content"
 		postgresDS.SecureJSONData.TLSCACert = string(cert)
 	}
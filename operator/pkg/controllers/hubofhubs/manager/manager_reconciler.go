@@ -76,20 +76,21 @@ func (r *ManagerReconciler) Reconcile(ctx context.Context,
 		imagePullPolicy = mgh.Spec.ImagePullPolicy
 	}
 
-	// dataRetention should at least be 1 month, otherwise it will deleted the current month partitions and records
-	months, err := commonutils.ParseRetentionMonth(mgh.Spec.DataLayer.Postgres.Retention)
+	retentionMonths, err := config.GetDataRetentionMonths(mgh)
 	if err != nil {
 		return fmt.Errorf("failed to parse month retention: %v", err)
 	}
-	if months < 1 {
-		months = 1
-	}
 
-	replicas := int32(1)
-	if mgh.Spec.AvailabilityConfig == v1alpha4.HAHigh {
-		replicas = 2
+	managerJobs, err := config.GetManagerJobs(mgh)
+	if err != nil {
+		return fmt.Errorf("failed to parse the job schedules: %v", err)
 	}
 
+	replicas := config.GetManagerReplicas(mgh)
+	// the status is sharded only if it's enabled explicitly, the replicas of the high availability are on standby
+	statusSharding := config.GetManagerStatusSharding(mgh) && replicas > 1 &&
+		config.TransportType() == string(transport.Kafka)
+
 	transportConn := config.GetTransporterConn()
 	if transportConn == nil {
 		return fmt.Errorf("the transport connection(%s) must not be empty", transportConn)
@@ -116,6 +117,16 @@ func (r *ManagerReconciler) Reconcile(ctx context.Context,
 		return fmt.Errorf("failed to marshall kafka connetion for config: %w", err)
 	}
 
+	schemaRegistry, err := config.GetSchemaRegistryCredential(ctx, r.GetClient(), mgh)
+	if err != nil {
+		return err
+	}
+	if schemaRegistry == nil {
+		schemaRegistry = &config.SchemaRegistryCredential{}
+	}
+
+	encryptionVariables := getEncryptionVariables(mgh)
+
 	managerObjects, err := hohRenderer.Render("manifests", "", func(profile string) (interface{}, error) {
 		return ManagerVariables{
 			Image:              config.GetImage(config.GlobalHubManagerImageKey),
@@ -126,34 +137,76 @@ func (r *ManagerReconciler) Reconcile(ctx context.Context,
 			ProxySessionSecret: proxySessionSecret,
 			DatabaseURL: base64.StdEncoding.EncodeToString(
 				[]byte(storageConn.SuperuserDatabaseURI)),
-			PostgresCACert:         base64.StdEncoding.EncodeToString(storageConn.CACert),
-			TransportConfigSecret:  constants.GHTransportConfigSecret,
-			KafkaConfigYaml:        base64.StdEncoding.EncodeToString(kafkaConfigYaml),
-			KafkaClusterIdentity:   transportConn.ClusterID,
-			KafkaBootstrapServer:   transportConn.BootstrapServer,
-			KafkaConsumerTopic:     config.ManagerStatusTopic(),
-			KafkaProducerTopic:     config.GetSpecTopic(),
-			KafkaCACert:            transportConn.CACert,
-			KafkaClientCert:        transportConn.ClientCert,
-			KafkaClientKey:         transportConn.ClientKey,
-			Namespace:              mgh.Namespace,
-			MessageCompressionType: string(operatorconstants.GzipCompressType),
-			TransportType:          string(transport.Kafka),
-			LeaseDuration:          strconv.Itoa(electionConfig.LeaseDuration),
-			RenewDeadline:          strconv.Itoa(electionConfig.RenewDeadline),
-			RetryPeriod:            strconv.Itoa(electionConfig.RetryPeriod),
-			SchedulerInterval:      config.GetSchedulerInterval(mgh),
-			SkipAuth:               config.SkipAuth(mgh),
-			LaunchJobNames:         config.GetLaunchJobNames(mgh),
-			NodeSelector:           mgh.Spec.NodeSelector,
-			Tolerations:            mgh.Spec.Tolerations,
-			RetentionMonth:         months,
-			StatisticLogInterval:   config.GetStatisticLogInterval(),
-			EnableGlobalResource:   r.operatorConfig.GlobalResourceEnabled,
-			EnablePprof:            r.operatorConfig.EnablePprof,
-			LogLevel:               r.operatorConfig.LogLevel,
-			Resources:              utils.GetResources(operatorconstants.Manager, mgh.Spec.AdvancedConfig),
-			WithACM:                config.IsACMResourceReady(),
+			DatabaseReplicaURL: base64.StdEncoding.EncodeToString(
+				[]byte(storageConn.ReplicaDatabaseURI)),
+			PostgresCACert:          base64.StdEncoding.EncodeToString(storageConn.CACert),
+			DatabaseAuth:            config.GetPostgresAuthentication(),
+			DatabaseIdentity:        config.GetPostgresIdentity(),
+			TransportConfigSecret:   constants.GHTransportConfigSecret,
+			KafkaConfigYaml:         base64.StdEncoding.EncodeToString(kafkaConfigYaml),
+			KafkaClusterIdentity:    transportConn.ClusterID,
+			KafkaBootstrapServer:    transportConn.BootstrapServer,
+			KafkaConsumerTopic:      config.ManagerStatusTopic(),
+			KafkaProducerTopic:      config.GetSpecTopic(),
+			KafkaCACert:             transportConn.CACert,
+			KafkaClientCert:         transportConn.ClientCert,
+			KafkaClientKey:          transportConn.ClientKey,
+			KafkaSASLMechanism:      transportConn.SASLMechanism,
+			KafkaSASLUsername:       transportConn.SASLUsername,
+			KafkaSASLPassword:       transportConn.SASLPassword,
+			SchemaRegistryURL:       schemaRegistry.URL,
+			SchemaRegistryUsername:  schemaRegistry.Username,
+			SchemaRegistryPassword:  schemaRegistry.Password,
+			SchemaRegistryCACert:    schemaRegistry.CACert,
+			Namespace:               mgh.Namespace,
+			MessageCompressionType:  string(operatorconstants.GzipCompressType),
+			PayloadEncoding:         config.GetPayloadEncoding(mgh),
+			KafkaReadCommitted:      config.IsExactlyOnceDelivery(mgh),
+			TransportType:           config.TransportType(),
+			LeaseDuration:           strconv.Itoa(electionConfig.LeaseDuration),
+			RenewDeadline:           strconv.Itoa(electionConfig.RenewDeadline),
+			RetryPeriod:             strconv.Itoa(electionConfig.RetryPeriod),
+			SchedulerInterval:       config.GetSchedulerInterval(mgh),
+			SkipAuth:                config.SkipAuth(mgh),
+			LaunchJobNames:          config.GetLaunchJobNames(mgh),
+			NodeSelector:            mgh.Spec.NodeSelector,
+			Tolerations:             mgh.Spec.Tolerations,
+			RetentionMonth:          retentionMonths.Default,
+			EventRetentionMonth:     retentionMonths.Events,
+			HistoryRetentionMonth:   retentionMonths.ComplianceHistory,
+			RollupRetentionMonth:    retentionMonths.ComplianceRollups,
+			HeartbeatRetentionMonth: retentionMonths.Heartbeats,
+			AuditLogRetentionMonth:  retentionMonths.AuditLogs,
+			AuditLogBackend:         config.GetAuditLogBackend(mgh),
+			StatisticLogInterval:    config.GetStatisticLogInterval(),
+			EnableGlobalResource:    r.operatorConfig.GlobalResourceEnabled,
+			EnableOAuthProxy:        r.operatorConfig.GlobalResourceEnabled && config.IsAPIOAuthProxyEnabled(mgh),
+			EnableAuthorization:     config.IsAPIAuthorizationEnabled(mgh),
+			EnablePprof:             r.operatorConfig.EnablePprof,
+			EnableMetrics:           mgh.Spec.EnableMetrics,
+			LogLevel:                r.operatorConfig.LogLevel,
+			Resources:               utils.GetResources(operatorconstants.Manager, mgh.Spec.AdvancedConfig),
+			WithACM:                 config.IsACMResourceReady(),
+			SearchIndexerURL:        config.GetSearchIndexerURL(mgh),
+			HubEventRateLimit:       config.GetHubEventRateLimit(mgh),
+			EventCoalesceWindow:     config.GetEventCoalesceWindow(mgh),
+			CloudEventsSink:         config.GetCloudEventsSink(mgh),
+			CloudEventsSinkTypes:    config.GetCloudEventsSinkTypes(mgh),
+			SIEMAddress:             config.GetSIEMAddress(mgh),
+			SIEMFormat:              config.GetSIEMFormat(mgh),
+			SIEMFraming:             config.GetSIEMFraming(mgh),
+			APIRateLimit:            config.GetAPIRateLimit(mgh),
+			CanaryHubSelector:       config.GetCanaryHubSelector(mgh),
+			TransportProbeTopic:     getTransportProbeTopic(),
+			ClusterLifecycleTopic:   config.GetClusterLifecycleTopic(mgh),
+			EnableStatusSharding:    statusSharding,
+			ManagerDatabase:         config.GetManagerDatabase(mgh),
+			ManagerJobs:             managerJobs,
+			ComplianceSLOObjective:  config.GetComplianceSLOObjective(mgh),
+			ManagerNotifications:    config.GetManagerNotifications(mgh),
+			EventArchive:            mgh.Spec.DataLayer.Postgres.EventArchive,
+			Tracing:                 config.GetTracingConfig(mgh),
+			EncryptionVariables:     encryptionVariables,
 		}, nil
 	})
 	if err != nil {
@@ -173,7 +226,8 @@ func isMiddlewareUpdated(transportConn *transport.KafkaConnCredential, storageCo
 	if !reflect.DeepEqual(transportConn, transportConnectionCache) {
 		updated = true
 	}
-	if !reflect.DeepEqual(storageConn, storageConnectionCache) {
+	// the manager reads the mounted CA on each new connection, so it isn't restarted when the CA is rotated
+	if !reflect.DeepEqual(withoutCACert(storageConn), withoutCACert(storageConnectionCache)) {
 		updated = true
 	}
 	if updated {
@@ -182,6 +236,15 @@ func isMiddlewareUpdated(transportConn *transport.KafkaConnCredential, storageCo
 	return updated
 }
 
+func withoutCACert(storageConn *config.PostgresConnection) *config.PostgresConnection {
+	if storageConn == nil {
+		return nil
+	}
+	conn := *storageConn
+	conn.CACert = nil
+	return &conn
+}
+
 func setMiddlewareCache(transportConn *transport.KafkaConnCredential, storageConn *config.PostgresConnection) {
 	if transportConn != nil {
 		transportConnectionCache = transportConn
@@ -193,39 +256,126 @@ func setMiddlewareCache(transportConn *transport.KafkaConnCredential, storageCon
 }
 
 type ManagerVariables struct {
-	Image                  string
-	Replicas               int32
-	ProxyImage             string
-	ImagePullSecret        string
-	ImagePullPolicy        string
-	ProxySessionSecret     string
-	DatabaseURL            string
-	PostgresCACert         string
-	TransportConfigSecret  string
-	KafkaConfigYaml        string
-	KafkaClusterIdentity   string
-	KafkaCACert            string
-	KafkaConsumerTopic     string
-	KafkaProducerTopic     string
-	KafkaClientCert        string
-	KafkaClientKey         string
-	KafkaBootstrapServer   string
-	MessageCompressionType string
-	TransportType          string
-	Namespace              string
-	LeaseDuration          string
-	RenewDeadline          string
-	RetryPeriod            string
-	SchedulerInterval      string
-	SkipAuth               bool
-	LaunchJobNames         string
-	NodeSelector           map[string]string
-	Tolerations            []corev1.Toleration
-	RetentionMonth         int
-	StatisticLogInterval   string
-	EnableGlobalResource   bool
-	EnablePprof            bool
-	LogLevel               string
-	Resources              *corev1.ResourceRequirements
-	WithACM                bool
+	Image                   string
+	Replicas                int32
+	ProxyImage              string
+	ImagePullSecret         string
+	ImagePullPolicy         string
+	ProxySessionSecret      string
+	DatabaseURL             string
+	DatabaseReplicaURL      string
+	PostgresCACert          string
+	DatabaseAuth            string
+	DatabaseIdentity        string
+	TransportConfigSecret   string
+	KafkaConfigYaml         string
+	KafkaClusterIdentity    string
+	KafkaCACert             string
+	KafkaConsumerTopic      string
+	KafkaProducerTopic      string
+	KafkaClientCert         string
+	KafkaClientKey          string
+	KafkaBootstrapServer    string
+	KafkaSASLMechanism      string
+	KafkaSASLUsername       string
+	KafkaSASLPassword       string
+	SchemaRegistryURL       string
+	SchemaRegistryUsername  string
+	SchemaRegistryPassword  string
+	SchemaRegistryCACert    string
+	MessageCompressionType  string
+	PayloadEncoding         string
+	KafkaReadCommitted      bool
+	TransportType           string
+	Namespace               string
+	LeaseDuration           string
+	RenewDeadline           string
+	RetryPeriod             string
+	SchedulerInterval       string
+	SkipAuth                bool
+	LaunchJobNames          string
+	NodeSelector            map[string]string
+	Tolerations             []corev1.Toleration
+	RetentionMonth          int
+	EventRetentionMonth     int
+	HistoryRetentionMonth   int
+	RollupRetentionMonth    int
+	HeartbeatRetentionMonth int
+	AuditLogRetentionMonth  int
+	AuditLogBackend         string
+	StatisticLogInterval    string
+	EnableGlobalResource    bool
+	EnableOAuthProxy        bool
+	EnableAuthorization     bool
+	EnablePprof             bool
+	EnableMetrics           bool
+	LogLevel                string
+	Resources               *corev1.ResourceRequirements
+	WithACM                 bool
+	SearchIndexerURL        string
+	HubEventRateLimit       string
+	EventCoalesceWindow     string
+	CloudEventsSink         string
+	CloudEventsSinkTypes    string
+	SIEMAddress             string
+	SIEMFormat              string
+	SIEMFraming             string
+	APIRateLimit            string
+	CanaryHubSelector       string
+	TransportProbeTopic     string
+	ClusterLifecycleTopic   string
+	// EnableStatusSharding splits the partitions of the status topic across the replicas, the other transports are
+	// only received by the leader
+	EnableStatusSharding bool
+	// the connection pool and the query timeouts of the manager
+	ManagerDatabase *v1alpha4.ManagerDatabase
+	// the schedules of the jobs of the manager, the jobs which aren't scheduled run on the defaults of the manager
+	ManagerJobs *v1alpha4.ManagerJobs
+	// the objective of the compliance SLO, the burn rate of the policies and the managed hubs is measured against it
+	ComplianceSLOObjective string
+	// the secret and the config map of the Slack and the Microsoft Teams channels
+	ManagerNotifications *v1alpha4.ManagerNotifications
+	// the object storage of the expired events, they're archived before the data retention prunes them
+	EventArchive *v1alpha4.PostgresEventArchive
+	// the OTLP collector of the spans of the status bundles, the spans aren't exported if it's nil
+	Tracing *v1alpha4.TracingConfig
+	EncryptionVariables
+}
+
+// EncryptionVariables mounts the data keys of the policy payloads to the manager
+type EncryptionVariables struct {
+	EncryptionKeySecret         string
+	EncryptionKeysHash          string
+	EncryptionVaultAddress      string
+	EncryptionVaultTransitMount string
+	EncryptionVaultKeyName      string
+}
+
+func getEncryptionVariables(mgh *v1alpha4.MulticlusterGlobalHub) EncryptionVariables {
+	postgresEncryption := mgh.Spec.DataLayer.Postgres.Encryption
+	if postgresEncryption == nil {
+		return EncryptionVariables{}
+	}
+	variables := EncryptionVariables{
+		EncryptionKeySecret: config.GetPayloadEncryptionKeySecretName(postgresEncryption),
+		EncryptionKeysHash:  config.GetEncryptionKeysHash(),
+	}
+	if postgresEncryption.Vault != nil {
+		variables.EncryptionVaultAddress = postgresEncryption.Vault.Address
+		variables.EncryptionVaultTransitMount = postgresEncryption.Vault.TransitMount
+		variables.EncryptionVaultKeyName = postgresEncryption.Vault.KeyName
+		if variables.EncryptionVaultTransitMount == "" {
+			variables.EncryptionVaultTransitMount = "transit"
+		}
+	}
+	return variables
+}
+
+// getTransportProbeTopic returns the topic of the transport probe, the manager is only granted to write the status
+// topic of the strimzi transporter, so the other transports aren't probed
+func getTransportProbeTopic() string {
+	if config.TransporterProtocol() != transport.StrimziTransporter {
+		return ""
+	}
+	return config.StatusPlaceholderTopic()
 }
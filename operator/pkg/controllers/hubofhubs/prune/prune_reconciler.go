@@ -353,6 +353,11 @@ func (r *PruneReconciler) pruneStrimziResources(ctx context.Context) error {
 	}
 	klog.Infof("kafkaTopic deleted")
 
+	// the kafka cluster and the operator are owned by the customer if the global hub reuses them
+	if config.GetExistingKafkaCluster() != nil {
+		return nil
+	}
+
 	kafka := &kafkav1beta2.Kafka{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      protocol.KafkaClusterName,
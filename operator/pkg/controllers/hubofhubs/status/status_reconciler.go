@@ -16,7 +16,6 @@ import (
 	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
 	operatorconstants "github.com/stolostron/multicluster-global-hub/operator/pkg/constants"
-	"github.com/stolostron/multicluster-global-hub/pkg/utils"
 )
 
 type StatusReconciler struct {
@@ -63,14 +62,17 @@ func (r *StatusReconciler) Reconcile(ctx context.Context, mgh *v1alpha4.Multiclu
 		return err
 	}
 
-	// dataRetention should at least be 1 month, otherwise it will deleted the current month partitions and records
 	// update the MGH status and message of the condition if they are not set or changed
-	months, err := utils.ParseRetentionMonth(mgh.Spec.DataLayer.Postgres.Retention)
+	retentionMonths, err := config.GetDataRetentionMonths(mgh)
 	if err == nil {
-		if months < 1 {
-			months = 1
+		msg := fmt.Sprintf("The data will be kept in the database for %d months.", retentionMonths.Default)
+		if mgh.Spec.DataLayer.Postgres.RetentionPolicy != nil {
+			msg = fmt.Sprintf("The data will be kept in the database for %d months, the events for %d months, "+
+				"the compliance history for %d months, the compliance rollups for %d months, the heartbeats for %d "+
+				"months and the audit logs for %d months.", retentionMonths.Default, retentionMonths.Events,
+				retentionMonths.ComplianceHistory, retentionMonths.ComplianceRollups, retentionMonths.Heartbeats,
+				retentionMonths.AuditLogs)
 		}
-		msg := fmt.Sprintf("The data will be kept in the database for %d months.", months)
 		if err := config.SetConditionDataRetention(ctx, r.Client, mgh, config.CONDITION_STATUS_TRUE, msg); err != nil {
 			return err
 		}
@@ -6,6 +6,14 @@ CREATE TABLE IF NOT EXISTS history.applications (
     deleted boolean DEFAULT false NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS history.applicationsets (
+    id uuid PRIMARY KEY,
+    payload jsonb NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    deleted boolean DEFAULT false NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS history.channels (
     id uuid PRIMARY KEY,
     payload jsonb NOT NULL,
@@ -30,6 +38,14 @@ CREATE TABLE IF NOT EXISTS history.managedclustersets (
     deleted boolean DEFAULT false NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS history.operatorpolicies (
+    id uuid PRIMARY KEY,
+    payload jsonb NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    deleted boolean DEFAULT false NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS history.placementbindings (
     id uuid PRIMARY KEY,
     payload jsonb NOT NULL,
@@ -62,6 +78,14 @@ CREATE TABLE IF NOT EXISTS history.policies (
     deleted boolean DEFAULT false NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS history.policysets (
+    id uuid PRIMARY KEY,
+    payload jsonb NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    deleted boolean DEFAULT false NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS history.subscriptions (
     id uuid PRIMARY KEY,
     payload jsonb NOT NULL,
@@ -86,6 +110,14 @@ CREATE TABLE IF NOT EXISTS spec.applications (
     deleted boolean DEFAULT false NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS spec.applicationsets (
+    id uuid PRIMARY KEY,
+    payload jsonb NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    deleted boolean DEFAULT false NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS spec.channels (
     id uuid PRIMARY KEY,
     payload jsonb NOT NULL,
@@ -128,6 +160,14 @@ CREATE TABLE IF NOT EXISTS spec.managedclustersets (
     deleted boolean DEFAULT false NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS spec.operatorpolicies (
+    id uuid PRIMARY KEY,
+    payload jsonb NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    deleted boolean DEFAULT false NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS spec.placementbindings (
     id uuid PRIMARY KEY,
     payload jsonb NOT NULL,
@@ -160,6 +200,14 @@ CREATE TABLE IF NOT EXISTS spec.policies (
     deleted boolean DEFAULT false NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS spec.policysets (
+    id uuid PRIMARY KEY,
+    payload jsonb NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    deleted boolean DEFAULT false NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS spec.subscriptions (
     id uuid PRIMARY KEY,
     payload jsonb NOT NULL,
@@ -215,6 +263,27 @@ CREATE TABLE IF NOT EXISTS status.subscription_statuses (
     payload jsonb NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS status.argocd_applicationsets (
+    id uuid NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    payload jsonb NOT NULL,
+    PRIMARY KEY (id, leaf_hub_name)
+);
+
+CREATE TABLE IF NOT EXISTS status.policysets (
+    id uuid NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    payload jsonb NOT NULL,
+    PRIMARY KEY (id, leaf_hub_name)
+);
+
+CREATE TABLE IF NOT EXISTS status.operatorpolicies (
+    id uuid NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    payload jsonb NOT NULL,
+    PRIMARY KEY (id, leaf_hub_name)
+);
+
 CREATE UNIQUE INDEX IF NOT EXISTS managed_cluster_sets_tracking_cluster_set_name_and_leaf_hub_name_idx ON spec.managed_cluster_sets_tracking (cluster_set_name, leaf_hub_name);
 
 CREATE INDEX IF NOT EXISTS compliance_leaf_hub_cluster_idx ON status.compliance (leaf_hub_name, cluster_name);
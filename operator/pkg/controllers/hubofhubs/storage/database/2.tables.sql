@@ -58,6 +58,70 @@ CREATE TABLE IF NOT EXISTS status.leaf_hubs (
 );
 CREATE INDEX IF NOT EXISTS leafhub_deleted_at_idx ON status.leaf_hubs (deleted_at);
 
+CREATE TABLE IF NOT EXISTS status.argocd_applications (
+    id uuid NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    payload jsonb NOT NULL,
+    name character varying(254) generated always as (payload -> 'metadata' ->> 'name') stored,
+    namespace character varying(254) generated always as (payload -> 'metadata' ->> 'namespace') stored,
+    health_status character varying(64) generated always as (payload -> 'status' -> 'health' ->> 'status') stored,
+    sync_status character varying(64) generated always as (payload -> 'status' -> 'sync' ->> 'status') stored,
+    PRIMARY KEY (id, leaf_hub_name)
+);
+CREATE INDEX IF NOT EXISTS argocd_applications_leafhub_idx ON status.argocd_applications (leaf_hub_name);
+CREATE INDEX IF NOT EXISTS argocd_applications_status_idx ON status.argocd_applications (health_status, sync_status);
+
+CREATE TABLE IF NOT EXISTS status.managed_cluster_sets (
+    id uuid NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    payload jsonb NOT NULL,
+    name character varying(254) generated always as (payload -> 'metadata' ->> 'name') stored,
+    selector_type character varying(64) generated always as (payload -> 'spec' -> 'clusterSelector' ->> 'selectorType') stored,
+    PRIMARY KEY (id, leaf_hub_name)
+);
+CREATE INDEX IF NOT EXISTS managed_cluster_sets_name_idx ON status.managed_cluster_sets (name);
+
+CREATE TABLE IF NOT EXISTS status.managed_cluster_set_bindings (
+    id uuid NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    payload jsonb NOT NULL,
+    name character varying(254) generated always as (payload -> 'metadata' ->> 'name') stored,
+    namespace character varying(254) generated always as (payload -> 'metadata' ->> 'namespace') stored,
+    cluster_set character varying(254) generated always as (payload -> 'spec' ->> 'clusterSet') stored,
+    PRIMARY KEY (id, leaf_hub_name)
+);
+CREATE INDEX IF NOT EXISTS managed_cluster_set_bindings_cluster_set_idx ON status.managed_cluster_set_bindings (leaf_hub_name, cluster_set);
+
+CREATE TABLE IF NOT EXISTS local_spec.placements (
+    id uuid NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    payload jsonb NOT NULL,
+    name character varying(254) generated always as (payload -> 'metadata' ->> 'name') stored,
+    namespace character varying(254) generated always as (payload -> 'metadata' ->> 'namespace') stored,
+    PRIMARY KEY (id, leaf_hub_name)
+);
+CREATE INDEX IF NOT EXISTS local_placements_name_idx ON local_spec.placements (namespace, name);
+
+CREATE TABLE IF NOT EXISTS local_status.placementdecisions (
+    id uuid NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    payload jsonb NOT NULL,
+    placement_name character varying(254) generated always as (payload -> 'metadata' -> 'labels' ->> 'cluster.open-cluster-management.io/placement') stored,
+    namespace character varying(254) generated always as (payload -> 'metadata' ->> 'namespace') stored,
+    PRIMARY KEY (id, leaf_hub_name)
+);
+CREATE INDEX IF NOT EXISTS local_placementdecisions_placement_idx ON local_status.placementdecisions (leaf_hub_name, namespace, placement_name);
+
+CREATE TABLE IF NOT EXISTS status.addon_placement_scores (
+    id uuid NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    payload jsonb NOT NULL,
+    name character varying(254) generated always as (payload -> 'metadata' ->> 'name') stored,
+    cluster_name character varying(254) generated always as (payload -> 'metadata' ->> 'namespace') stored,
+    PRIMARY KEY (id, leaf_hub_name)
+);
+CREATE INDEX IF NOT EXISTS addon_placement_scores_cluster_idx ON status.addon_placement_scores (leaf_hub_name, cluster_name);
+
 -- Partition tables
 CREATE TABLE IF NOT EXISTS event.managed_clusters (
     event_namespace text NOT NULL,
@@ -71,9 +135,27 @@ CREATE TABLE IF NOT EXISTS event.managed_clusters (
     reporting_instance text, 
     event_type character varying(64) NOT NULL,
     created_at timestamp without time zone DEFAULT now() NOT NULL,
+    coalesced_count integer NOT NULL DEFAULT 0,
     CONSTRAINT managed_clusters_unique_constraint UNIQUE (leaf_hub_name, event_name, created_at)
 ) PARTITION BY RANGE (created_at);
 
+-- the lifecycle events of the managed clusters, the sequence orders the events of each cluster for the consumers of
+-- the feed, e.g. a CMDB
+CREATE TABLE IF NOT EXISTS event.cluster_lifecycle (
+    sequence bigserial NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    cluster_id uuid NOT NULL,
+    cluster_name text NOT NULL,
+    event_type character varying(64) NOT NULL, -- 'Created', 'Imported', 'Detached' or 'Upgraded'
+    version text,
+    previous_version text,
+    created_via text,
+    -- the time of the insert instead of the transaction, the readers of the feed wait for the transactions to commit
+    created_at timestamp without time zone DEFAULT clock_timestamp() NOT NULL,
+    CONSTRAINT cluster_lifecycle_unique_constraint UNIQUE (sequence, created_at)
+) PARTITION BY RANGE (created_at);
+CREATE INDEX IF NOT EXISTS cluster_lifecycle_cluster_idx ON event.cluster_lifecycle (cluster_id, sequence);
+
 CREATE TABLE IF NOT EXISTS event.local_policies (
     event_name text NOT NULL,
     event_namespace text,
@@ -87,6 +169,7 @@ CREATE TABLE IF NOT EXISTS event.local_policies (
     source jsonb,
     created_at timestamp without time zone DEFAULT now() NOT NULL,
     compliance local_status.compliance_type NOT NULL,
+    coalesced_count integer NOT NULL DEFAULT 0,
     CONSTRAINT local_policies_unique_constraint UNIQUE (event_name, count, created_at)
 ) PARTITION BY RANGE (created_at);
 
@@ -101,6 +184,7 @@ CREATE TABLE IF NOT EXISTS event.local_root_policies (
     source jsonb,
     created_at timestamp without time zone DEFAULT now() NOT NULL,
     compliance local_status.compliance_type NOT NULL,
+    coalesced_count integer NOT NULL DEFAULT 0,
     CONSTRAINT local_root_policies_unique_constraint UNIQUE (event_name, count, created_at)
 ) PARTITION BY RANGE (created_at);
 
@@ -125,6 +209,32 @@ CREATE TABLE IF NOT EXISTS history.local_compliance (
     CONSTRAINT local_policies_unique_constraint UNIQUE (leaf_hub_name, policy_id, cluster_id, compliance_date)
 ) PARTITION BY RANGE (compliance_date);
 
+-- the daily and weekly number of the clusters in each compliance state of the policies, they're rolled up from the
+-- history.local_compliance and kept longer than it for the long-term reporting
+CREATE TABLE IF NOT EXISTS history.compliance_rollups (
+    period varchar(16) NOT NULL, -- 'day' or 'week'
+    period_start date NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    policy_id uuid NOT NULL,
+    compliant integer NOT NULL DEFAULT 0,
+    non_compliant integer NOT NULL DEFAULT 0,
+    pending integer NOT NULL DEFAULT 0,
+    unknown integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (period, period_start, leaf_hub_name, policy_id)
+);
+
+-- the daily percentage of the compliant clusters of each policy over the managed hubs, and of each managed hub over its
+-- policies. They're computed from the daily rollups for the compliance SLO metrics of the manager
+CREATE TABLE IF NOT EXISTS history.compliance_percentages (
+    compliance_date date NOT NULL,
+    scope varchar(16) NOT NULL, -- 'policy' or 'hub'
+    scope_key character varying(254) NOT NULL, -- the policy id or the managed hub name
+    compliant integer NOT NULL DEFAULT 0,
+    total integer NOT NULL DEFAULT 0,
+    percentage numeric(5, 2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (compliance_date, scope, scope_key)
+);
+
 CREATE TABLE IF NOT EXISTS history.local_compliance_job_log (
     name varchar(254) NOT NULL,
     start_at timestamp NOT NULL DEFAULT now(),
@@ -141,4 +251,36 @@ CREATE TABLE IF NOT EXISTS status.transport (
     payload jsonb NOT NULL,
     created_at timestamp without time zone DEFAULT now() NOT NULL,
     updated_at timestamp without time zone DEFAULT now() NOT NULL
-);
\ No newline at end of file
+);
+-- audit records of the data purge requests
+CREATE TABLE IF NOT EXISTS history.data_purge_log (
+    id uuid PRIMARY KEY,
+    target_type varchar(32) NOT NULL, -- 'cluster' or 'hub'
+    leaf_hub_name varchar(254) NOT NULL,
+    cluster_name varchar(254),
+    confirmation_token varchar(64) NOT NULL,
+    status varchar(32) NOT NULL DEFAULT 'pending', -- 'pending', 'running', 'completed' or 'failed'
+    requested_by varchar(254),
+    requested_at timestamp NOT NULL DEFAULT now(),
+    confirmed_by varchar(254),
+    completed_at timestamp,
+    deleted_rows jsonb,
+    error TEXT
+);
+CREATE INDEX IF NOT EXISTS data_purge_log_target_idx ON history.data_purge_log (leaf_hub_name, cluster_name);
+-- audit records of the changes of the global resources and the requests of the manager API
+CREATE TABLE IF NOT EXISTS history.audit_logs (
+    source varchar(32) NOT NULL, -- 'spec' or 'api'
+    username varchar(254),
+    groups jsonb,
+    verb varchar(32) NOT NULL,
+    resource varchar(254),
+    namespace varchar(254),
+    name varchar(254),
+    request_uri text,
+    status_code integer,
+    client_ip varchar(64),
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+) PARTITION BY RANGE (created_at);
+CREATE INDEX IF NOT EXISTS audit_logs_username_idx ON history.audit_logs (username, created_at);
+CREATE INDEX IF NOT EXISTS audit_logs_resource_idx ON history.audit_logs (resource, namespace, name);
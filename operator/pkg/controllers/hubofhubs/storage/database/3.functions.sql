@@ -115,10 +115,14 @@ $$;
 
 --- create the monthly partitioned tables function by created_at/compliance_date column
 --- sample: SELECT create_monthly_range_partitioned_table('event.local_root_policies', '2023-08-01');
+--- the table is skipped if it isn't partitioned, e.g. it's converted to the timescaledb hypertable
 CREATE OR REPLACE FUNCTION create_monthly_range_partitioned_table(full_table_name text, input_time text)
 RETURNS VOID AS
 $$ 
 BEGIN 
+    IF NOT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = full_table_name::regclass) THEN
+        RETURN;
+    END IF;
     EXECUTE format('CREATE TABLE IF NOT EXISTS %1$s_%2$s PARTITION OF %1$s FOR VALUES FROM (%3$L) TO (%4$L)',
                    full_table_name, 
                    to_char(input_time::date, 'YYYY_MM'),
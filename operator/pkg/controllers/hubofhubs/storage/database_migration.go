@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+// errSchemaDowngrade is returned if the database has been migrated by a newer operator, the older operator doesn't
+// know how to revert the migrations, so it doesn't touch the database
+var errSchemaDowngrade = errors.New("the database schema is newer than the operator")
+
+// the migration files are named "<version>_<name>.sql", the version is a positive integer
+var migrationFileRegexp = regexp.MustCompile(`^([1-9][0-9]*)_([a-zA-Z0-9._-]+)\.sql$`)
+
+const createMigrationTableSQL = `CREATE TABLE IF NOT EXISTS public.schema_migrations (
+	version bigint PRIMARY KEY,
+	name text NOT NULL,
+	applied_at timestamp without time zone NOT NULL DEFAULT now()
+)`
+
+type migration struct {
+	version int64
+	name    string
+	file    string
+}
+
+// schemaVersion is the version of the database schema, and the migrations which aren't applied to it
+type schemaVersion struct {
+	current int64
+	pending []migration
+}
+
+func (v *schemaVersion) pendingNames() []string {
+	names := []string{}
+	for _, m := range v.pending {
+		names = append(names, fmt.Sprintf("%d_%s", m.version, m.name))
+	}
+	return names
+}
+
+// loadMigrations returns the migrations in the rootDir of the fsys ordered by the version
+func loadMigrations(fsys iofs.FS, rootDir string) ([]migration, error) {
+	entries, err := iofs.ReadDir(fsys, rootDir)
+	if err != nil {
+		return nil, err
+	}
+	migrations := []migration{}
+	versions := map[int64]string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name %s, it should be <version>_<name>.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version of the migration %s: %w", entry.Name(), err)
+		}
+		if existing, ok := versions[version]; ok {
+			return nil, fmt.Errorf("the migrations %s and %s have the same version", existing, entry.Name())
+		}
+		versions[version] = entry.Name()
+		migrations = append(migrations, migration{
+			version: version,
+			name:    matches[2],
+			file:    path.Join(rootDir, entry.Name()),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+// querySchemaVersion returns the version of the database schema and the pending migrations. It returns the
+// errSchemaDowngrade if the database has been migrated to a version which isn't known by the operator
+func querySchemaVersion(ctx context.Context, conn *pgx.Conn, migrations []migration) (*schemaVersion, error) {
+	if _, err := conn.Exec(ctx, createMigrationTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create the schema migrations table: %w", err)
+	}
+	applied := map[int64]bool{}
+	rows, err := conn.Query(ctx, "SELECT version FROM public.schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the applied migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return resolveSchemaVersion(applied, migrations)
+}
+
+func resolveSchemaVersion(applied map[int64]bool, migrations []migration) (*schemaVersion, error) {
+	known := map[int64]bool{}
+	version := &schemaVersion{}
+	for _, m := range migrations {
+		known[m.version] = true
+		if !applied[m.version] {
+			version.pending = append(version.pending, m)
+		}
+	}
+	for v := range applied {
+		if v > version.current {
+			version.current = v
+		}
+		if !known[v] {
+			return version, fmt.Errorf("%w: the migration %d isn't known by the operator", errSchemaDowngrade, v)
+		}
+	}
+	return version, nil
+}
+
+// applyMigrations applies the pending migrations in the order of the versions, each migration is applied and
+// recorded in a transaction, so a failed migration is retried by the next reconciliation
+func applyMigrations(ctx context.Context, conn *pgx.Conn, fsys embed.FS, version *schemaVersion) error {
+	for len(version.pending) > 0 {
+		m := version.pending[0]
+		sqlBytes, err := fsys.ReadFile(m.file)
+		if err != nil {
+			return fmt.Errorf("failed to read the migration %s: %w", m.file, err)
+		}
+		err = conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, string(sqlBytes)); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, "INSERT INTO public.schema_migrations (version, name) VALUES ($1, $2)",
+				m.version, m.name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply the migration %s: %w", m.file, err)
+		}
+		version.current = max(version.current, m.version)
+		version.pending = version.pending[1:]
+	}
+	return nil
+}
+
+// getSchemaVersion returns the version of the database schema, the downgrade is reported to the mgh status
+func (r *StorageReconciler) getSchemaVersion(ctx context.Context, conn *pgx.Conn,
+	mgh *v1alpha4.MulticlusterGlobalHub,
+) (*schemaVersion, error) {
+	migrations, err := loadMigrations(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	version, err := querySchemaVersion(ctx, conn, migrations)
+	if errors.Is(err, errSchemaDowngrade) {
+		if e := r.reportSchemaVersion(ctx, mgh, version); e != nil {
+			r.log.Error(e, "failed to report the database schema version")
+		}
+		if e := config.SetConditionDatabaseSchema(ctx, r.GetClient(), mgh, config.CONDITION_STATUS_FALSE,
+			config.CONDITION_REASON_DATABASE_SCHEMA_BLOCKED, err.Error()); e != nil {
+			r.log.Error(e, "failed to set the database schema condition")
+		}
+	}
+	return version, err
+}
+
+// migrateSchema applies the pending migrations, and reports the version of the schema to the mgh status
+func (r *StorageReconciler) migrateSchema(ctx context.Context, conn *pgx.Conn, mgh *v1alpha4.MulticlusterGlobalHub,
+	version *schemaVersion,
+) error {
+	if len(version.pending) > 0 {
+		r.log.Info("migrate the database schema", "version", version.current, "pending", version.pendingNames())
+	}
+	migrateErr := applyMigrations(ctx, conn, migrationsFS, version)
+	if err := r.reportSchemaVersion(ctx, mgh, version); err != nil {
+		return err
+	}
+	if migrateErr != nil {
+		if err := config.SetConditionDatabaseSchema(ctx, r.GetClient(), mgh, config.CONDITION_STATUS_FALSE,
+			config.CONDITION_REASON_DATABASE_SCHEMA_FAILED, migrateErr.Error()); err != nil {
+			r.log.Error(err, "failed to set the database schema condition")
+		}
+		return migrateErr
+	}
+	return config.SetConditionDatabaseSchema(ctx, r.GetClient(), mgh, config.CONDITION_STATUS_TRUE,
+		config.CONDITION_REASON_DATABASE_SCHEMA_MIGRATED,
+		fmt.Sprintf("the database schema is migrated to the version %d", version.current))
+}
+
+func (r *StorageReconciler) reportSchemaVersion(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub,
+	version *schemaVersion,
+) error {
+	status := &v1alpha4.DatabaseStatus{SchemaVersion: version.current}
+	if len(version.pending) > 0 {
+		status.PendingMigrations = version.pendingNames()
+	}
+	if reflect.DeepEqual(mgh.Status.Database, status) {
+		return nil
+	}
+	mgh.Status.Database = status
+	if err := r.GetClient().Status().Update(ctx, mgh); err != nil {
+		return fmt.Errorf("failed to update the database schema version: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	// the embedded migrations are valid
+	migrations, err := loadMigrations(migrationsFS, "migrations")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	// the migrations are ordered by the version instead of the file name
+	migrations, err = loadMigrations(fstest.MapFS{
+		"migrations/10_add_index.sql":   {Data: []byte("SELECT 10")},
+		"migrations/2_add_column.sql":   {Data: []byte("SELECT 2")},
+		"migrations/1_create_table.sql": {Data: []byte("SELECT 1")},
+	}, "migrations")
+	assert.NoError(t, err)
+	assert.Equal(t, []migration{
+		{version: 1, name: "create_table", file: "migrations/1_create_table.sql"},
+		{version: 2, name: "add_column", file: "migrations/2_add_column.sql"},
+		{version: 10, name: "add_index", file: "migrations/10_add_index.sql"},
+	}, migrations)
+
+	_, err = loadMigrations(fstest.MapFS{
+		"migrations/1_create_table.sql":  {Data: []byte("SELECT 1")},
+		"migrations/1_create_tables.sql": {Data: []byte("SELECT 1")},
+	}, "migrations")
+	assert.Error(t, err)
+
+	_, err = loadMigrations(fstest.MapFS{"migrations/create_table.sql": {Data: []byte("SELECT 1")}}, "migrations")
+	assert.Error(t, err)
+}
+
+func TestResolveSchemaVersion(t *testing.T) {
+	migrations := []migration{{version: 1, name: "a"}, {version: 2, name: "b"}, {version: 3, name: "c"}}
+
+	// a new database
+	version, err := resolveSchemaVersion(map[int64]bool{}, migrations)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), version.current)
+	assert.Equal(t, []string{"1_a", "2_b", "3_c"}, version.pendingNames())
+
+	version, err = resolveSchemaVersion(map[int64]bool{1: true, 2: true}, migrations)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), version.current)
+	assert.Equal(t, []string{"3_c"}, version.pendingNames())
+
+	// the database is migrated by a newer operator
+	version, err = resolveSchemaVersion(map[int64]bool{1: true, 2: true, 3: true, 4: true}, migrations)
+	assert.True(t, errors.Is(err, errSchemaDowngrade))
+	assert.Equal(t, int64(4), version.current)
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+//go:embed tenancy
+var tenancyFS embed.FS
+
+const (
+	// the label of the tenant secrets, the value is the name of the tenant
+	postgresTenantLabel        = "global-hub.open-cluster-management.io/postgres-tenant"
+	postgresTenantSecretPrefix = "multicluster-global-hub-postgres-tenant-"
+	tenantRolePrefix           = "tenant_"
+)
+
+// tenantRole returns the database role of the tenant
+func tenantRole(tenant string) string {
+	return tenantRolePrefix + strings.ReplaceAll(tenant, "-", "_")
+}
+
+// tenantDatabaseURI returns the connection URI of the tenant role, it connects to the same postgres as the given URI
+func tenantDatabaseURI(databaseURI, role, password string) (string, error) {
+	uri, err := url.Parse(databaseURI)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the database uri: %w", err)
+	}
+	uri.User = url.UserPassword(role, password)
+	return uri.String(), nil
+}
+
+// reconcileTenants creates the database roles and the secrets of the tenants in the mgh, the roles only read the rows
+// of their managed hubs by the row-level security. The roles and the secrets of the removed tenants are deleted. The
+// database is only updated when the tenants or the tables are changed
+func (r *StorageReconciler) reconcileTenants(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub,
+	storageConn *config.PostgresConnection,
+) error {
+	tenants := mgh.Spec.DataLayer.Postgres.Tenants
+	if len(tenants) == 0 && r.tenantsHash == "" {
+		// nothing to prune unless the tenants have been reconciled by this operator
+		existing, err := r.listTenantSecrets(ctx, mgh.Namespace)
+		if err != nil || len(existing.Items) == 0 {
+			return err
+		}
+	}
+	// the roles are created with the password, the cloud identity can't grant the tokens to the tenants
+	if authType := config.GetPostgresAuthentication(); authType != string(v1alpha4.PostgresPasswordAuth) {
+		if len(tenants) == 0 {
+			return nil
+		}
+		return fmt.Errorf("the tenants don't support the %s authentication of the postgres", authType)
+	}
+
+	hash, err := tenantsHash(tenants, storageConn.SuperuserDatabaseURI)
+	if err != nil {
+		return err
+	}
+	if hash == r.tenantsHash {
+		return nil
+	}
+
+	conn, err := database.PostgresConnection(ctx, storageConn.SuperuserDatabaseURI, storageConn.CACert,
+		config.GetPostgresAuthentication())
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			r.log.Error(err, "failed to close connection to database")
+		}
+	}()
+
+	if err := applySQL(ctx, conn, tenancyFS, "tenancy", ""); err != nil {
+		return err
+	}
+	if _, err := conn.Exec(ctx, "SELECT ensure_tenant_policies()"); err != nil {
+		return fmt.Errorf("failed to enable the row-level security of the tenants: %w", err)
+	}
+
+	roles := []string{}
+	for _, tenant := range tenants {
+		role := tenantRole(tenant.Name)
+		roles = append(roles, role)
+		password, err := r.ensureTenantSecret(ctx, mgh, tenant.Name, role, storageConn)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.Exec(ctx, "SELECT ensure_tenant($1, $2, $3)", role, password,
+			tenant.ManagedHubs); err != nil {
+			return fmt.Errorf("failed to create the role of the tenant %s: %w", tenant.Name, err)
+		}
+	}
+
+	if err := r.dropStaleTenants(ctx, conn, roles); err != nil {
+		return err
+	}
+	if err := r.pruneTenantSecrets(ctx, mgh.Namespace, tenants); err != nil {
+		return err
+	}
+	r.log.Info("reconciled the database tenants", "roles", roles)
+	r.tenantsHash = hash
+	return nil
+}
+
+func (r *StorageReconciler) dropStaleTenants(ctx context.Context, conn *pgx.Conn, roles []string) error {
+	rows, err := conn.Query(ctx, "SELECT drop_stale_tenants($1)", roles)
+	if err != nil {
+		return fmt.Errorf("failed to drop the roles of the removed tenants: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return err
+		}
+		r.log.Info("dropped the role of the removed tenant", "role", role)
+	}
+	return rows.Err()
+}
+
+// ensureTenantSecret saves the connection of the tenant role into its secret, and returns the password of the role.
+// The password is kept if the secret exists
+func (r *StorageReconciler) ensureTenantSecret(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub,
+	tenant, role string, storageConn *config.PostgresConnection,
+) (string, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: postgresTenantSecretPrefix + tenant, Namespace: mgh.Namespace},
+	}
+	password := ""
+	err := r.GetClient().Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+	if err == nil {
+		password = string(secret.Data["password"])
+	}
+	if password == "" {
+		password = generatePassword(16)
+	}
+	databaseURI, err := tenantDatabaseURI(storageConn.SuperuserDatabaseURI, role, password)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.GetClient(), secret, func() error {
+		secret.Labels = map[string]string{
+			constants.GlobalHubOwnerLabelKey: constants.GHOperatorOwnerLabelVal,
+			postgresTenantLabel:              tenant,
+		}
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{
+			"user":         []byte(role),
+			"password":     []byte(password),
+			"database_uri": []byte(databaseURI),
+			"ca.crt":       storageConn.CACert,
+		}
+		return controllerutil.SetControllerReference(mgh, secret, r.GetScheme())
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save the secret of the tenant %s: %w", tenant, err)
+	}
+	return password, nil
+}
+
+func (r *StorageReconciler) listTenantSecrets(ctx context.Context, namespace string) (*corev1.SecretList, error) {
+	secrets := &corev1.SecretList{}
+	err := r.GetClient().List(ctx, secrets, client.InNamespace(namespace), client.HasLabels{postgresTenantLabel})
+	return secrets, err
+}
+
+// pruneTenantSecrets deletes the secrets of the tenants which are removed from the mgh
+func (r *StorageReconciler) pruneTenantSecrets(ctx context.Context, namespace string,
+	tenants []v1alpha4.PostgresTenant,
+) error {
+	secrets, err := r.listTenantSecrets(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	desired := map[string]bool{}
+	for _, tenant := range tenants {
+		desired[tenant.Name] = true
+	}
+	for i := range secrets.Items {
+		if desired[secrets.Items[i].Labels[postgresTenantLabel]] {
+			continue
+		}
+		if err := r.GetClient().Delete(ctx, &secrets.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the secret of the removed tenant: %w", err)
+		}
+	}
+	return nil
+}
+
+// tenantsHash returns the hash of the tenants and the postgres they're created in
+func tenantsHash(tenants []v1alpha4.PostgresTenant, databaseURI string) (string, error) {
+	data, err := json.Marshal(struct {
+		Tenants     []v1alpha4.PostgresTenant
+		DatabaseURI string
+	}{tenants, databaseURI})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
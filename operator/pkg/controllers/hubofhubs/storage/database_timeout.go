@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+// reconcileStatementTimeout sets the statement timeout of the manager as the default of its role in the database. The
+// default is applied when the postgres starts each session, so it also holds behind a pooler, which shares the
+// sessions across the clients. The database is only updated when the timeout is changed
+func (r *StorageReconciler) reconcileStatementTimeout(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub,
+	storageConn *config.PostgresConnection,
+) error {
+	connConfig, err := pgx.ParseConfig(storageConn.SuperuserDatabaseURI)
+	if err != nil {
+		return fmt.Errorf("failed to parse the database uri: %w", err)
+	}
+	statement, err := statementTimeoutSQL(connConfig.Database, config.GetManagerDatabase(mgh).StatementTimeout)
+	if err != nil {
+		return err
+	}
+	if statement == r.statementTimeoutSQL {
+		return nil
+	}
+
+	conn, err := database.PostgresConnection(ctx, storageConn.SuperuserDatabaseURI, storageConn.CACert,
+		config.GetPostgresAuthentication())
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			r.log.Error(err, "failed to close connection to database")
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, statement); err != nil {
+		return fmt.Errorf("failed to set the statement timeout: %w", err)
+	}
+	r.log.Info("reconciled the statement timeout of the manager", "statement", statement)
+	r.statementTimeoutSQL = statement
+	return nil
+}
+
+// statementTimeoutSQL returns the statement which sets the statement timeout of the current role in the database, the
+// timeout is reset if it isn't set. The manager shares the role with the operator, so the operator resets the timeout
+// of its own sessions
+func statementTimeoutSQL(databaseName, timeout string) (string, error) {
+	role := "ALTER ROLE CURRENT_USER IN DATABASE " + pgx.Identifier{databaseName}.Sanitize()
+	if timeout == "" {
+		return role + " RESET statement_timeout", nil
+	}
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return "", fmt.Errorf("invalid statement timeout %q: %w", timeout, err)
+	}
+	return fmt.Sprintf("%s SET statement_timeout = %d", role, duration.Milliseconds()), nil
+}
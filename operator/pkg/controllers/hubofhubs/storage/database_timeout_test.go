@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementTimeoutSQL(t *testing.T) {
+	statement, err := statementTimeoutSQL("hoh", "1m30s")
+	assert.NoError(t, err)
+	assert.Equal(t, `ALTER ROLE CURRENT_USER IN DATABASE "hoh" SET statement_timeout = 90000`, statement)
+
+	// the timeout of the role is reset once it's removed from the mgh
+	statement, err = statementTimeoutSQL("hoh", "")
+	assert.NoError(t, err)
+	assert.Equal(t, `ALTER ROLE CURRENT_USER IN DATABASE "hoh" RESET statement_timeout`, statement)
+
+	_, err = statementTimeoutSQL("hoh", "30")
+	assert.Error(t, err)
+}
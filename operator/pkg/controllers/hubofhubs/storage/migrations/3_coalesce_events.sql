@@ -0,0 +1,4 @@
+--- Count the identical status events coalesced into the written rows
+ALTER TABLE event.managed_clusters ADD COLUMN IF NOT EXISTS coalesced_count integer NOT NULL DEFAULT 0;
+ALTER TABLE event.local_policies ADD COLUMN IF NOT EXISTS coalesced_count integer NOT NULL DEFAULT 0;
+ALTER TABLE event.local_root_policies ADD COLUMN IF NOT EXISTS coalesced_count integer NOT NULL DEFAULT 0;
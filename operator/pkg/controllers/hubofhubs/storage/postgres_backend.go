@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+// PostgresBackend provisions the built-in postgres of the global hub, and returns the connection of it
+type PostgresBackend interface {
+	EnsurePostgres(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub) (*config.PostgresConnection, error)
+}
+
+// NewPostgresBackend returns the backend of the built-in postgres selected in the mgh
+func NewPostgresBackend(mgh *v1alpha4.MulticlusterGlobalHub, mgr ctrl.Manager, log logr.Logger) PostgresBackend {
+	switch config.GetPostgresBackend(mgh) {
+	case v1alpha4.PostgresCrunchyBackend:
+		return &crunchyBackend{mgr: mgr, log: log}
+	case v1alpha4.PostgresCloudNativePGBackend:
+		return &cloudNativePGBackend{client: mgr.GetClient(), log: log}
+	default:
+		return &statefulSetBackend{mgr: mgr, log: log}
+	}
+}
+
+// statefulSetBackend deploys a single postgres instance with the statefulset
+type statefulSetBackend struct {
+	mgr ctrl.Manager
+	log logr.Logger
+}
+
+func (b *statefulSetBackend) EnsurePostgres(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub) (
+	*config.PostgresConnection, error,
+) {
+	return InitPostgresByStatefulset(ctx, mgh, b.mgr, b.log)
+}
+
+// crunchyBackend subscribes the Crunchy PGO, and provisions a highly available postgres cluster with it
+type crunchyBackend struct {
+	mgr ctrl.Manager
+	log logr.Logger
+}
+
+func (b *crunchyBackend) EnsurePostgres(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub) (
+	*config.PostgresConnection, error,
+) {
+	if err := EnsureCrunchyPostgresSub(ctx, b.mgr.GetClient(), mgh); err != nil {
+		return nil, err
+	}
+	return EnsureCrunchyPostgres(ctx, b.mgr.GetClient(), mgh, b.log)
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"encoding/base64"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/deployer"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/renderer"
+	operatorutils "github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
+)
+
+//go:embed manifests.backup
+var backupPostgresFS embed.FS
+
+const (
+	PostgresBackupName     = "multicluster-global-hub-postgres-backup"
+	defaultBackupSchedule  = "0 2 * * *"
+	defaultBackupPrefix    = "multicluster-global-hub"
+	defaultRetainedBackups = 7
+)
+
+// ensurePostgresBackup deploys the cronjob to back up the database if the backup is enabled in the mgh, otherwise the
+// backup objects are deleted. The result of the latest finished backup job is reported as the DatabaseBackup condition
+func ensurePostgresBackup(ctx context.Context, mgh *globalhubv1alpha4.MulticlusterGlobalHub, mgr ctrl.Manager,
+	conn *config.PostgresConnection,
+) error {
+	c := mgr.GetClient()
+	backup := mgh.Spec.DataLayer.Postgres.Backup
+	if backup == nil {
+		for _, obj := range []client.Object{
+			&batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: PostgresBackupName, Namespace: mgh.Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: PostgresBackupName, Namespace: mgh.Namespace}},
+		} {
+			// the finished jobs are deleted with the cronjob in the background
+			if err := c.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil &&
+				!errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete the postgres backup object %s: %w", obj.GetName(), err)
+			}
+		}
+		if !config.ContainsCondition(mgh, config.CONDITION_TYPE_DATABASE_BACKUP) {
+			return nil
+		}
+		return config.DeleteCondition(ctx, c, mgh, config.CONDITION_TYPE_DATABASE_BACKUP,
+			config.CONDITION_REASON_DATABASE_BACKUP_SUCCEEDED)
+	}
+
+	// the pg_dump can't get the token of the cloud identity, so only the password authentication is supported
+	if authType := config.GetPostgresAuthentication(); authType != string(globalhubv1alpha4.PostgresPasswordAuth) {
+		return config.SetConditionDatabaseBackup(ctx, c, mgh, metav1.ConditionFalse,
+			config.CONDITION_REASON_DATABASE_BACKUP_UNSUPPORTED,
+			fmt.Sprintf("the backup doesn't support the %s authentication of the postgres", authType))
+	}
+
+	backupObjects, err := renderPostgresBackup(mgh, backup, conn)
+	if err != nil {
+		return err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	if err = operatorutils.ManipulateGlobalHubObjects(backupObjects, mgh, deployer.NewHoHDeployer(c), mapper,
+		mgr.GetScheme()); err != nil {
+		return fmt.Errorf("failed to create/update the postgres backup objects: %w", err)
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := c.List(ctx, jobs, client.InNamespace(mgh.Namespace),
+		client.MatchingLabels{"name": PostgresBackupName}); err != nil {
+		return err
+	}
+	status, reason, message := backupCondition(jobs.Items, backup)
+	return config.SetConditionDatabaseBackup(ctx, c, mgh, status, reason, message)
+}
+
+// backupCondition returns the DatabaseBackup condition by the latest finished backup job
+func backupCondition(jobs []batchv1.Job, backup *globalhubv1alpha4.PostgresBackup) (
+	metav1.ConditionStatus, string, string,
+) {
+	latest, latestCondition := latestFinishedJob(jobs)
+	if latest == nil {
+		return metav1.ConditionUnknown, config.CONDITION_REASON_DATABASE_BACKUP_PENDING,
+			fmt.Sprintf("waiting for the first backup at the schedule %q", backupSchedule(backup))
+	}
+	finishedAt := latestCondition.LastTransitionTime.UTC().Format("2006-01-02T15:04:05Z")
+	if latestCondition.Type == batchv1.JobFailed {
+		return metav1.ConditionFalse, config.CONDITION_REASON_DATABASE_BACKUP_FAILED,
+			fmt.Sprintf("the backup job %s failed at %s: %s", latest.Name, finishedAt, latestCondition.Message)
+	}
+	return metav1.ConditionTrue, config.CONDITION_REASON_DATABASE_BACKUP_SUCCEEDED,
+		fmt.Sprintf("the backup job %s uploaded the backup to s3://%s/%s at %s", latest.Name, backup.Bucket,
+			backupPrefix(backup), finishedAt)
+}
+
+// latestFinishedJob returns the latest completed or failed job of the cronjob, and its finished condition
+func latestFinishedJob(jobs []batchv1.Job) (*batchv1.Job, *batchv1.JobCondition) {
+	var latest *batchv1.Job
+	var latestCondition *batchv1.JobCondition
+	for i := range jobs {
+		for j := range jobs[i].Status.Conditions {
+			cond := &jobs[i].Status.Conditions[j]
+			if (cond.Type != batchv1.JobComplete && cond.Type != batchv1.JobFailed) ||
+				cond.Status != corev1.ConditionTrue {
+				continue
+			}
+			if latestCondition == nil || cond.LastTransitionTime.After(latestCondition.LastTransitionTime.Time) {
+				latest, latestCondition = &jobs[i], cond
+			}
+		}
+	}
+	return latest, latestCondition
+}
+
+func renderPostgresBackup(mgh *globalhubv1alpha4.MulticlusterGlobalHub, backup *globalhubv1alpha4.PostgresBackup,
+	conn *config.PostgresConnection,
+) ([]*unstructured.Unstructured, error) {
+	retainedBackups := backup.RetainedBackups
+	if retainedBackups <= 0 {
+		retainedBackups = defaultRetainedBackups
+	}
+	imagePullPolicy := corev1.PullAlways
+	if mgh.Spec.ImagePullPolicy != "" {
+		imagePullPolicy = mgh.Spec.ImagePullPolicy
+	}
+
+	return renderer.NewHoHRenderer(backupPostgresFS).Render("manifests.backup", "",
+		func(profile string) (interface{}, error) {
+			return struct {
+				Namespace             string
+				PostgresImage         string
+				AWSCLIImage           string
+				ImagePullSecret       string
+				ImagePullPolicy       string
+				NodeSelector          map[string]string
+				Tolerations           []corev1.Toleration
+				DatabaseURI           string
+				PostgresCACert        string
+				Schedule              string
+				Bucket                string
+				Prefix                string
+				Endpoint              string
+				Region                string
+				CredentialsSecretName string
+				RetainedBackups       int32
+			}{
+				Namespace:             mgh.GetNamespace(),
+				PostgresImage:         config.GetImage(config.PostgresImageKey),
+				AWSCLIImage:           config.GetImage(config.AWSCLIImageKey),
+				ImagePullSecret:       mgh.Spec.ImagePullSecret,
+				ImagePullPolicy:       string(imagePullPolicy),
+				NodeSelector:          mgh.Spec.NodeSelector,
+				Tolerations:           mgh.Spec.Tolerations,
+				DatabaseURI:           base64.StdEncoding.EncodeToString([]byte(conn.SuperuserDatabaseURI)),
+				PostgresCACert:        base64.StdEncoding.EncodeToString(conn.CACert),
+				Schedule:              backupSchedule(backup),
+				Bucket:                backup.Bucket,
+				Prefix:                backupPrefix(backup),
+				Endpoint:              backup.Endpoint,
+				Region:                backup.Region,
+				CredentialsSecretName: backup.CredentialsSecretName,
+				RetainedBackups:       retainedBackups,
+			}, nil
+		})
+}
+
+func backupSchedule(backup *globalhubv1alpha4.PostgresBackup) string {
+	if backup.Schedule == "" {
+		return defaultBackupSchedule
+	}
+	return backup.Schedule
+}
+
+func backupPrefix(backup *globalhubv1alpha4.PostgresBackup) string {
+	if backup.Prefix == "" {
+		return defaultBackupPrefix
+	}
+	return backup.Prefix
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+const (
+	// the secrets "<cluster>-superuser" and "<cluster>-ca", and the service "<cluster>-rw" are created by the
+	// CloudNativePG operator
+	CloudNativePGClusterName        = "global-hub-postgres"
+	cloudNativePGReadonlySecretName = CloudNativePGClusterName + "-readonly" // #nosec G101
+	cloudNativePGDatabase           = "hoh"
+)
+
+var cloudNativePGClusterGVK = schema.GroupVersionKind{
+	Group:   "postgresql.cnpg.io",
+	Version: "v1",
+	Kind:    "Cluster",
+}
+
+// cloudNativePGBackend provisions a highly available postgres with the CloudNativePG operator, the primary is
+// failed over to a replica by the operator, and the clients always connect to the primary by the "-rw" service
+type cloudNativePGBackend struct {
+	client client.Client
+	log    logr.Logger
+}
+
+func (b *cloudNativePGBackend) EnsurePostgres(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub) (
+	*config.PostgresConnection, error,
+) {
+	if err := b.restoreCloudNativePGCluster(ctx, mgh); err != nil {
+		return nil, err
+	}
+
+	readonlySecret, err := b.ensureReadonlyUserSecret(ctx, mgh)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(cloudNativePGClusterGVK)
+	cluster.SetName(CloudNativePGClusterName)
+	cluster.SetNamespace(mgh.Namespace)
+	_, err = controllerutil.CreateOrUpdate(ctx, b.client, cluster, func() error {
+		return setCloudNativePGCluster(cluster, mgh)
+	})
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, fmt.Errorf("the CloudNativePG operator must be installed for the postgres backend: %w", err)
+		}
+		return nil, fmt.Errorf("failed to ensure the CloudNativePG cluster: %w", err)
+	}
+
+	// the secrets are generated once the cluster is bootstrapped
+	superuserSecret := &corev1.Secret{}
+	if err := b.client.Get(ctx, types.NamespacedName{
+		Name:      CloudNativePGClusterName + "-superuser",
+		Namespace: mgh.Namespace,
+	}, superuserSecret); err != nil {
+		return nil, fmt.Errorf("waiting for the superuser secret of the CloudNativePG cluster: %w", err)
+	}
+	caSecret := &corev1.Secret{}
+	if err := b.client.Get(ctx, types.NamespacedName{
+		Name:      CloudNativePGClusterName + "-ca",
+		Namespace: mgh.Namespace,
+	}, caSecret); err != nil {
+		return nil, fmt.Errorf("waiting for the ca secret of the CloudNativePG cluster: %w", err)
+	}
+	return cloudNativePGConnection(mgh.Namespace, config.GetPostgresInstances(mgh), superuserSecret, readonlySecret,
+		caSecret), nil
+}
+
+// ensureReadonlyUserSecret creates the credential of the readonly user for grafana, the user is managed by the
+// CloudNativePG operator with the secret
+func (b *cloudNativePGBackend) ensureReadonlyUserSecret(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub) (
+	*corev1.Secret, error,
+) {
+	secret := &corev1.Secret{}
+	err := b.client.Get(ctx, types.NamespacedName{Name: cloudNativePGReadonlySecretName, Namespace: mgh.Namespace},
+		secret)
+	if err == nil {
+		return secret, nil
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cloudNativePGReadonlySecretName,
+			Namespace: mgh.Namespace,
+			Labels: map[string]string{
+				constants.GlobalHubOwnerLabelKey: constants.GHOperatorOwnerLabelVal,
+				// the password of the role is reloaded by the operator when the secret is changed
+				"cnpg.io/reload": "true",
+			},
+		},
+		Type: corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte(postgresReadonlyUsername),
+			corev1.BasicAuthPasswordKey: []byte(generatePassword(16)),
+		},
+	}
+	if err := b.client.Create(ctx, secret); err != nil {
+		return nil, fmt.Errorf("failed to create the readonly user secret of the CloudNativePG cluster: %w", err)
+	}
+	return secret, nil
+}
+
+// setCloudNativePGCluster sets the fields of the cluster which are managed by the global hub, the other fields are
+// kept, e.g. the defaults set by the CloudNativePG webhook. The bootstrap is only set on the creation
+func setCloudNativePGCluster(cluster *unstructured.Unstructured, mgh *v1alpha4.MulticlusterGlobalHub) error {
+	labels := cluster.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[constants.GlobalHubOwnerLabelKey] = constants.GHOperatorOwnerLabelVal
+	cluster.SetLabels(labels)
+
+	spec := map[string]interface{}{}
+	if existing, found, _ := unstructured.NestedMap(cluster.Object, "spec"); found {
+		spec = existing
+	}
+	set := func(value interface{}, path ...string) error {
+		return unstructured.SetNestedField(spec, value, path...)
+	}
+	if err := set(int64(config.GetPostgresInstances(mgh)), "instances"); err != nil {
+		return err
+	}
+	if err := set(config.GetPostgresStorageSize(mgh), "storage", "size"); err != nil {
+		return err
+	}
+	if mgh.Spec.DataLayer.StorageClass != "" {
+		if err := set(mgh.Spec.DataLayer.StorageClass, "storage", "storageClass"); err != nil {
+			return err
+		}
+	}
+	// the operator initializes the database schema with the superuser
+	if err := set(true, "enableSuperuserAccess"); err != nil {
+		return err
+	}
+	// the cluster is bootstrapped from the WAL archive if it's created for the restore
+	recovery, err := setCloudNativePGWALArchive(cluster, spec, mgh)
+	if err != nil {
+		return err
+	}
+	if cluster.GetResourceVersion() == "" && !recovery {
+		if err := set(map[string]interface{}{
+			"database": cloudNativePGDatabase,
+			"owner":    cloudNativePGDatabase,
+		}, "bootstrap", "initdb"); err != nil {
+			return err
+		}
+	}
+	if err := set([]interface{}{map[string]interface{}{
+		"name":           postgresReadonlyUsername,
+		"ensure":         "present",
+		"login":          true,
+		"passwordSecret": map[string]interface{}{"name": cloudNativePGReadonlySecretName},
+	}}, "managed", "roles"); err != nil {
+		return err
+	}
+	return unstructured.SetNestedMap(cluster.Object, spec, "spec")
+}
+
+// cloudNativePGConnection returns the connection of the primary of the CloudNativePG cluster, and the connection of
+// the replicas by the "-ro" service if the cluster has more than one instance
+func cloudNativePGConnection(namespace string, instances int32, superuserSecret, readonlySecret,
+	caSecret *corev1.Secret,
+) *config.PostgresConnection {
+	uri := func(secret *corev1.Secret, service string) string {
+		return (&url.URL{
+			Scheme: "postgresql",
+			User: url.UserPassword(string(secret.Data[corev1.BasicAuthUsernameKey]),
+				string(secret.Data[corev1.BasicAuthPasswordKey])),
+			Host:     fmt.Sprintf("%s-%s.%s.svc:5432", CloudNativePGClusterName, service, namespace),
+			Path:     "/" + cloudNativePGDatabase,
+			RawQuery: "sslmode=verify-ca",
+		}).String()
+	}
+	conn := &config.PostgresConnection{
+		SuperuserDatabaseURI:    uri(superuserSecret, "rw"),
+		ReadonlyUserDatabaseURI: uri(readonlySecret, "rw"),
+		CACert:                  caSecret.Data["ca.crt"],
+	}
+	if instances > 1 {
+		conn.ReplicaDatabaseURI = uri(superuserSecret, "ro")
+		conn.ReplicaReadonlyUserDatabaseURI = uri(readonlySecret, "ro")
+	}
+	return conn
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	operatorconstants "github.com/stolostron/multicluster-global-hub/operator/pkg/constants"
+)
+
+func TestGetPostgresBackend(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	assert.Equal(t, globalhubv1alpha4.PostgresStatefulSetBackend, config.GetPostgresBackend(mgh))
+
+	mgh.Spec.DataLayer.Postgres.Backend = globalhubv1alpha4.PostgresCloudNativePGBackend
+	assert.Equal(t, globalhubv1alpha4.PostgresCloudNativePGBackend, config.GetPostgresBackend(mgh))
+
+	// the crunchy annotation takes precedence
+	mgh.Annotations = map[string]string{operatorconstants.AnnotationMGHInstallCrunchyOperator: "true"}
+	assert.Equal(t, globalhubv1alpha4.PostgresCrunchyBackend, config.GetPostgresBackend(mgh))
+}
+
+func TestSetCloudNativePGCluster(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.DataLayer.StorageClass = "gp3"
+	mgh.Spec.DataLayer.Postgres.Instances = 2
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(cloudNativePGClusterGVK)
+	assert.NoError(t, setCloudNativePGCluster(cluster, mgh))
+
+	instances, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "instances")
+	assert.Equal(t, int64(2), instances)
+	size, _, _ := unstructured.NestedString(cluster.Object, "spec", "storage", "size")
+	assert.Equal(t, "25Gi", size)
+	storageClass, _, _ := unstructured.NestedString(cluster.Object, "spec", "storage", "storageClass")
+	assert.Equal(t, "gp3", storageClass)
+	database, _, _ := unstructured.NestedString(cluster.Object, "spec", "bootstrap", "initdb", "database")
+	assert.Equal(t, "hoh", database)
+	roles, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "managed", "roles")
+	assert.Len(t, roles, 1)
+
+	// the fields which aren't managed by the global hub are kept, and the bootstrap isn't changed after the creation
+	cluster.SetResourceVersion("1")
+	assert.NoError(t, unstructured.SetNestedField(cluster.Object, "30s", "spec", "switchoverDelay"))
+	assert.NoError(t, unstructured.SetNestedField(cluster.Object, "app", "spec", "bootstrap", "initdb", "owner"))
+	mgh.Spec.DataLayer.Postgres.Instances = 3
+	assert.NoError(t, setCloudNativePGCluster(cluster, mgh))
+	instances, _, _ = unstructured.NestedInt64(cluster.Object, "spec", "instances")
+	assert.Equal(t, int64(3), instances)
+	switchoverDelay, _, _ := unstructured.NestedString(cluster.Object, "spec", "switchoverDelay")
+	assert.Equal(t, "30s", switchoverDelay)
+	owner, _, _ := unstructured.NestedString(cluster.Object, "spec", "bootstrap", "initdb", "owner")
+	assert.Equal(t, "app", owner)
+}
+
+func TestCloudNativePGConnection(t *testing.T) {
+	newSecret := func(username, password string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: username},
+			Data: map[string][]byte{
+				corev1.BasicAuthUsernameKey: []byte(username),
+				corev1.BasicAuthPasswordKey: []byte(password),
+			},
+		}
+	}
+	caSecret := &corev1.Secret{Data: map[string][]byte{"ca.crt": []byte("ca")}}
+	conn := cloudNativePGConnection("multicluster-global-hub", 1, newSecret("postgres", "p@ss/word"),
+		newSecret(postgresReadonlyUsername, "readonly"), caSecret)
+
+	superuserURI, err := url.Parse(conn.SuperuserDatabaseURI)
+	assert.NoError(t, err)
+	password, _ := superuserURI.User.Password()
+	assert.Equal(t, "p@ss/word", password)
+	assert.Equal(t, "global-hub-postgres-rw.multicluster-global-hub.svc:5432", superuserURI.Host)
+	assert.Equal(t, "verify-ca", superuserURI.Query().Get("sslmode"))
+
+	readonlyURI, err := url.Parse(conn.ReadonlyUserDatabaseURI)
+	assert.NoError(t, err)
+	assert.Equal(t, postgresReadonlyUsername, readonlyURI.User.Username())
+	assert.Equal(t, []byte("ca"), conn.CACert)
+	assert.Empty(t, conn.ReplicaReadonlyUserDatabaseURI)
+
+	// the read-only queries are routed to the replicas
+	conn = cloudNativePGConnection("multicluster-global-hub", 3, newSecret("postgres", "p@ss/word"),
+		newSecret(postgresReadonlyUsername, "readonly"), caSecret)
+	replicaURI, err := url.Parse(conn.ReplicaReadonlyUserDatabaseURI)
+	assert.NoError(t, err)
+	assert.Equal(t, "global-hub-postgres-ro.multicluster-global-hub.svc:5432", replicaURI.Host)
+	assert.Equal(t, postgresReadonlyUsername, replicaURI.User.Username())
+	assert.Equal(t, conn.ReplicaReadonlyUserDatabaseURI, conn.ClientReplicaReadonlyUserDatabaseURI())
+}
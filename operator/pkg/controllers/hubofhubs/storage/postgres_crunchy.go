@@ -42,12 +42,6 @@ var (
 	communityChannel           = "v5"
 	communityPackageName       = "postgresql"
 	communityCatalogSourceName = "community-operators"
-
-	replicas3 int32 = 3
-
-	// postgres storage size: 25Gi should be enough for 18 months data
-	// 5 managed hubs with 300 managed cluster each and 50 policies per managed hub cluster
-	storageSize = "25Gi"
 )
 
 // EnsureCrunchyPostgresSub verifies resources needed for Crunchy Postgres are created
@@ -76,8 +70,15 @@ func EnsureCrunchyPostgresSub(ctx context.Context, c client.Client, mgh *v1alpha
 	return nil
 }
 
-// EnsureCrunchyPostgres verifies PostgresCluster operand is created
-func EnsureCrunchyPostgres(ctx context.Context, c client.Client, log logr.Logger) (*config.PostgresConnection, error) {
+// EnsureCrunchyPostgres verifies PostgresCluster operand is created, and scales its instances by the mgh
+func EnsureCrunchyPostgres(ctx context.Context, c client.Client, mgh *v1alpha4.MulticlusterGlobalHub,
+	log logr.Logger,
+) (*config.PostgresConnection, error) {
+	instances := config.GetPostgresInstances(mgh)
+	// the pgBackRest configuration of the WAL archive is projected into the postgres cluster
+	if err := ensureCrunchyWALArchiveSecret(ctx, c, mgh); err != nil {
+		return nil, err
+	}
 	// store crunchy postgres connection
 	var pgConnection *config.PostgresConnection
 	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 10*time.Minute, true,
@@ -88,10 +89,29 @@ func EnsureCrunchyPostgres(ctx context.Context, c client.Client, log logr.Logger
 				Namespace: utils.GetDefaultNamespace(),
 			}, postgresCluster)
 			if err != nil && errors.IsNotFound(err) {
-				if err := c.Create(ctx, NewPostgresCluster(config.PostgresName, utils.GetDefaultNamespace())); err != nil {
+				postgresCluster = NewPostgresCluster(config.PostgresName, utils.GetDefaultNamespace(),
+					instances, config.GetPostgresStorageSize(mgh))
+				setCrunchyWALArchive(postgresCluster, mgh)
+				if err := c.Create(ctx, postgresCluster); err != nil {
 					log.Info("waiting the postgres cluster to be ready...", "message", err.Error())
 					return false, nil
 				}
+			} else if err == nil {
+				desired := postgresCluster.DeepCopy()
+				if len(desired.Spec.InstanceSets) > 0 {
+					instanceSet := &desired.Spec.InstanceSets[0]
+					if instanceSet.Replicas == nil || *instanceSet.Replicas != instances {
+						instanceSet.Replicas = &instances
+					}
+				}
+				setCrunchyWALArchive(desired, mgh)
+				if !equality.Semantic.DeepEqual(postgresCluster.Spec, desired.Spec) ||
+					!equality.Semantic.DeepEqual(postgresCluster.Annotations, desired.Annotations) {
+					if err := c.Update(ctx, desired); err != nil {
+						log.Info("waiting the postgres cluster to be updated...", "message", err.Error())
+						return false, nil
+					}
+				}
 			}
 
 			pgConnection, err = config.GetPGConnectionFromBuildInPostgres(ctx, c)
@@ -179,7 +199,8 @@ func ExpectedSubscription(existingSubscription *subv1alpha1.Subscription, config
 }
 
 // NewPostgreCluster returns a postgres cluster with desired default values
-func NewPostgresCluster(name, namespace string) *postgresv1beta1.PostgresCluster {
+func NewPostgresCluster(name, namespace string, instances int32, storageSize string,
+) *postgresv1beta1.PostgresCluster {
 	return &postgresv1beta1.PostgresCluster{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
@@ -213,7 +234,7 @@ func NewPostgresCluster(name, namespace string) *postgresv1beta1.PostgresCluster
 			InstanceSets: []postgresv1beta1.PostgresInstanceSetSpec{
 				{
 					Name:     "pgha1",
-					Replicas: &replicas3,
+					Replicas: &instances,
 					DataVolumeClaimSpec: corev1.PersistentVolumeClaimSpec{
 						AccessModes: []corev1.PersistentVolumeAccessMode{
 							corev1.ReadWriteOnce,
@@ -75,7 +75,7 @@ func TestRenderSubscription(t *testing.T) {
 }
 
 func TestNewPostgres(t *testing.T) {
-	kafka := NewPostgresCluster(config.PostgresName, "default")
+	kafka := NewPostgresCluster(config.PostgresName, "default", 3, "25Gi")
 	if kafka.Name != config.PostgresName {
 		t.Errorf("Expected name %s, got %s", config.PostgresName, kafka.Name)
 	}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/encryption"
+)
+
+// ensurePayloadEncryptionKeys validates the data keys of the policy payloads, and generates the key if the secret
+// doesn't exist. The secret isn't owned by the mgh and isn't deleted by the operator, the encrypted payloads can't be
+// read without it. The hash of the keys is cached to restart the manager once the keys are rotated
+func (r *StorageReconciler) ensurePayloadEncryptionKeys(ctx context.Context,
+	mgh *v1alpha4.MulticlusterGlobalHub,
+) error {
+	postgresEncryption := mgh.Spec.DataLayer.Postgres.Encryption
+	if postgresEncryption == nil {
+		config.SetEncryptionKeysHash("")
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.GetClient().Get(ctx, types.NamespacedName{
+		Name:      config.GetPayloadEncryptionKeySecretName(postgresEncryption),
+		Namespace: mgh.Namespace,
+	}, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if errors.IsNotFound(err) {
+		// the wrapped data key is generated by the transit secrets engine of the vault
+		if postgresEncryption.Vault != nil {
+			return fmt.Errorf("the secret %s of the wrapped data key isn't found",
+				config.GetPayloadEncryptionKeySecretName(postgresEncryption))
+		}
+		if secret, err = r.createPayloadEncryptionKey(ctx, mgh.Namespace,
+			config.GetPayloadEncryptionKeySecretName(postgresEncryption)); err != nil {
+			return err
+		}
+	}
+
+	if err := validatePayloadEncryptionKeys(secret, postgresEncryption.Vault != nil); err != nil {
+		return err
+	}
+	config.SetEncryptionKeysHash(encryptionKeysHash(secret))
+	return nil
+}
+
+func (r *StorageReconciler) createPayloadEncryptionKey(ctx context.Context, namespace, name string,
+) (*corev1.Secret, error) {
+	key := make([]byte, encryption.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate the data key: %w", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{encryption.PrimaryKeyFile: key},
+	}
+	if err := r.GetClient().Create(ctx, secret); err != nil {
+		return nil, fmt.Errorf("failed to create the secret of the data key: %w", err)
+	}
+	r.log.Info("generated the data key of the policy payloads", "secret", name)
+	return secret, nil
+}
+
+// validatePayloadEncryptionKeys checks the keys before the manager loads them, the wrapped keys are only checked by
+// the manager
+func validatePayloadEncryptionKeys(secret *corev1.Secret, wrapped bool) error {
+	if len(secret.Data[encryption.PrimaryKeyFile]) == 0 {
+		return fmt.Errorf("the %q of the secret %s is required", encryption.PrimaryKeyFile, secret.Name)
+	}
+	if wrapped {
+		if len(secret.Data[encryption.VaultTokenFile]) == 0 {
+			return fmt.Errorf("the %q of the secret %s is required", encryption.VaultTokenFile, secret.Name)
+		}
+		return nil
+	}
+	for name, value := range secret.Data {
+		if name != encryption.PrimaryKeyFile && !strings.HasPrefix(name, encryption.PreviousKeyFilePrefix) {
+			continue
+		}
+		if len(value) != encryption.KeySize {
+			return fmt.Errorf("the %q of the secret %s must be %d bytes", name, secret.Name, encryption.KeySize)
+		}
+	}
+	return nil
+}
+
+// encryptionKeysHash returns the hash of the secret, the manager is restarted to load the keys once it's changed
+func encryptionKeysHash(secret *corev1.Secret) string {
+	names := make([]string, 0, len(secret.Data))
+	for name := range secret.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hash := sha256.New()
+	for _, name := range names {
+		hash.Write([]byte(name))
+		hash.Write(secret.Data[name])
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database/encryption"
+)
+
+func TestValidatePayloadEncryptionKeys(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "multicluster-global-hub-postgres-encryption"},
+		Data: map[string][]byte{
+			"key":     bytes.Repeat([]byte{1}, encryption.KeySize),
+			"key.old": bytes.Repeat([]byte{2}, encryption.KeySize),
+		},
+	}
+	assert.NoError(t, validatePayloadEncryptionKeys(secret, false))
+	hash := encryptionKeysHash(secret)
+
+	// the manager is restarted once the key is rotated
+	secret.Data["key"] = bytes.Repeat([]byte{3}, encryption.KeySize)
+	assert.NotEqual(t, hash, encryptionKeysHash(secret))
+
+	secret.Data["key.old"] = []byte("short")
+	assert.Error(t, validatePayloadEncryptionKeys(secret, false))
+
+	// the wrapped keys are unwrapped by the manager with the vault token
+	wrapped := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "multicluster-global-hub-postgres-encryption"},
+		Data:       map[string][]byte{"key": []byte("vault:v1:wrapped")},
+	}
+	assert.Error(t, validatePayloadEncryptionKeys(wrapped, true))
+	wrapped.Data["vault-token"] = []byte("token")
+	assert.NoError(t, validatePayloadEncryptionKeys(wrapped, true))
+}
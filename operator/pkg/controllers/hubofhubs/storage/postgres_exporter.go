@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"encoding/base64"
+	"fmt"
+
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/deployer"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/renderer"
+	operatorutils "github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
+)
+
+//go:embed manifests.exporter
+var exporterPostgresFS embed.FS
+
+const PostgresExporterName = "multicluster-global-hub-postgres-exporter"
+
+// standalonePostgresExporter returns true if the postgres_exporter is deployed beside the postgres. The built-in
+// statefulset runs the exporter as its sidecar, so the standalone exporter is only for the other backends, the
+// external and the BYO postgres. The exporter can't get the token of the cloud identity, so it requires the password
+func standalonePostgresExporter(mgh *globalhubv1alpha4.MulticlusterGlobalHub) bool {
+	if !mgh.Spec.EnableMetrics ||
+		config.GetPostgresAuthentication() != string(globalhubv1alpha4.PostgresPasswordAuth) {
+		return false
+	}
+	return config.IsBYOPostgres() ||
+		config.GetPostgresBackend(mgh) != globalhubv1alpha4.PostgresStatefulSetBackend
+}
+
+// ensurePostgresExporter deploys the standalone postgres_exporter and its ServiceMonitor if the metrics are enabled,
+// otherwise the exporter objects are deleted
+func ensurePostgresExporter(ctx context.Context, mgh *globalhubv1alpha4.MulticlusterGlobalHub, mgr ctrl.Manager,
+	conn *config.PostgresConnection,
+) error {
+	c := mgr.GetClient()
+	if !standalonePostgresExporter(mgh) {
+		for _, obj := range []client.Object{
+			&promv1.ServiceMonitor{ObjectMeta: metav1.ObjectMeta{Name: PostgresExporterName, Namespace: mgh.Namespace}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: PostgresExporterName, Namespace: mgh.Namespace}},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: PostgresExporterName, Namespace: mgh.Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: PostgresExporterName, Namespace: mgh.Namespace}},
+		} {
+			// the ServiceMonitor crd doesn't exist if the prometheus operator isn't installed
+			if err := c.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+				return fmt.Errorf("failed to delete the postgres exporter object %s: %w", obj.GetName(), err)
+			}
+		}
+		return nil
+	}
+
+	exporterObjects, err := renderPostgresExporter(mgh, conn)
+	if err != nil {
+		return err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	if err = operatorutils.ManipulateGlobalHubObjects(exporterObjects, mgh, deployer.NewHoHDeployer(c), mapper,
+		mgr.GetScheme()); err != nil {
+		return fmt.Errorf("failed to create/update the postgres exporter objects: %w", err)
+	}
+	return nil
+}
+
+func renderPostgresExporter(mgh *globalhubv1alpha4.MulticlusterGlobalHub, conn *config.PostgresConnection,
+) ([]*unstructured.Unstructured, error) {
+	imagePullPolicy := corev1.PullAlways
+	if mgh.Spec.ImagePullPolicy != "" {
+		imagePullPolicy = mgh.Spec.ImagePullPolicy
+	}
+
+	return renderer.NewHoHRenderer(exporterPostgresFS).Render("manifests.exporter", "",
+		func(profile string) (interface{}, error) {
+			return struct {
+				Namespace             string
+				PostgresExporterImage string
+				ImagePullSecret       string
+				ImagePullPolicy       string
+				NodeSelector          map[string]string
+				Tolerations           []corev1.Toleration
+				DatabaseURI           string
+				PostgresCACert        string
+				ScrapeInterval        string
+			}{
+				Namespace:             mgh.GetNamespace(),
+				PostgresExporterImage: config.GetImage(config.PostgresExporterImageKey),
+				ImagePullSecret:       mgh.Spec.ImagePullSecret,
+				ImagePullPolicy:       string(imagePullPolicy),
+				NodeSelector:          mgh.Spec.NodeSelector,
+				Tolerations:           mgh.Spec.Tolerations,
+				// the exporter connects to the postgres directly, the statistics of the pooler aren't useful
+				DatabaseURI:    base64.StdEncoding.EncodeToString([]byte(conn.SuperuserDatabaseURI)),
+				PostgresCACert: base64.StdEncoding.EncodeToString(conn.CACert),
+				ScrapeInterval: config.GetMetricsScrapeInterval(mgh),
+			}, nil
+		})
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/deployer"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/renderer"
+	operatorutils "github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
+)
+
+//go:embed manifests.maintenance
+var maintenancePostgresFS embed.FS
+
+const (
+	PostgresMaintenanceName    = "multicluster-global-hub-postgres-maintenance"
+	defaultMaintenanceSchedule = "0 3 * * 0"
+	defaultMaintenanceWindow   = 2 * time.Hour
+)
+
+var defaultMaintenanceTasks = []globalhubv1alpha4.PostgresMaintenanceTask{
+	globalhubv1alpha4.PostgresMaintenanceVacuum,
+	globalhubv1alpha4.PostgresMaintenanceAnalyze,
+}
+
+// ensurePostgresMaintenance deploys the cronjob to vacuum, analyze and reindex the database in the maintenance window
+// if the maintenance is enabled in the mgh, otherwise the maintenance objects are deleted. The result of the latest
+// finished maintenance job is reported as the DatabaseMaintenance condition
+func ensurePostgresMaintenance(ctx context.Context, mgh *globalhubv1alpha4.MulticlusterGlobalHub, mgr ctrl.Manager,
+	conn *config.PostgresConnection,
+) error {
+	c := mgr.GetClient()
+	maintenance := mgh.Spec.DataLayer.Postgres.Maintenance
+	if maintenance == nil {
+		for _, obj := range []client.Object{
+			&batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: PostgresMaintenanceName, Namespace: mgh.Namespace}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: PostgresMaintenanceName, Namespace: mgh.Namespace}},
+		} {
+			if err := c.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil &&
+				!errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete the postgres maintenance object %s: %w", obj.GetName(), err)
+			}
+		}
+		if !config.ContainsCondition(mgh, config.CONDITION_TYPE_DATABASE_MAINTENANCE) {
+			return nil
+		}
+		return config.DeleteCondition(ctx, c, mgh, config.CONDITION_TYPE_DATABASE_MAINTENANCE,
+			config.CONDITION_REASON_DATABASE_MAINTENANCE_SUCCEEDED)
+	}
+
+	// the vacuumdb can't get the token of the cloud identity, so only the password authentication is supported
+	if authType := config.GetPostgresAuthentication(); authType != string(globalhubv1alpha4.PostgresPasswordAuth) {
+		return config.SetConditionDatabaseMaintenance(ctx, c, mgh, metav1.ConditionFalse,
+			config.CONDITION_REASON_DATABASE_MAINTENANCE_UNSUPPORTED,
+			fmt.Sprintf("the maintenance doesn't support the %s authentication of the postgres", authType))
+	}
+
+	maintenanceObjects, err := renderPostgresMaintenance(mgh, maintenance, conn)
+	if err != nil {
+		return err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	if err = operatorutils.ManipulateGlobalHubObjects(maintenanceObjects, mgh, deployer.NewHoHDeployer(c), mapper,
+		mgr.GetScheme()); err != nil {
+		return fmt.Errorf("failed to create/update the postgres maintenance objects: %w", err)
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := c.List(ctx, jobs, client.InNamespace(mgh.Namespace),
+		client.MatchingLabels{"name": PostgresMaintenanceName}); err != nil {
+		return err
+	}
+	status, reason, message := maintenanceCondition(jobs.Items, maintenance)
+	return config.SetConditionDatabaseMaintenance(ctx, c, mgh, status, reason, message)
+}
+
+// maintenanceCondition returns the DatabaseMaintenance condition by the latest finished maintenance job, the job
+// stopped by the end of the window isn't a failure, the remaining tables are processed in the next window
+func maintenanceCondition(jobs []batchv1.Job, maintenance *globalhubv1alpha4.PostgresMaintenance) (
+	metav1.ConditionStatus, string, string,
+) {
+	latest, latestCondition := latestFinishedJob(jobs)
+	if latest == nil {
+		return metav1.ConditionUnknown, config.CONDITION_REASON_DATABASE_MAINTENANCE_PENDING,
+			fmt.Sprintf("waiting for the first maintenance window at the schedule %q", maintenanceSchedule(maintenance))
+	}
+	finishedAt := latestCondition.LastTransitionTime.UTC().Format("2006-01-02T15:04:05Z")
+	if latestCondition.Type == batchv1.JobFailed {
+		if latestCondition.Reason == "DeadlineExceeded" {
+			return metav1.ConditionFalse, config.CONDITION_REASON_DATABASE_MAINTENANCE_INCOMPLETE,
+				fmt.Sprintf("the maintenance job %s was stopped by the end of the %s window at %s", latest.Name,
+					maintenanceWindow(maintenance), finishedAt)
+		}
+		return metav1.ConditionFalse, config.CONDITION_REASON_DATABASE_MAINTENANCE_FAILED,
+			fmt.Sprintf("the maintenance job %s failed at %s: %s", latest.Name, finishedAt, latestCondition.Message)
+	}
+	return metav1.ConditionTrue, config.CONDITION_REASON_DATABASE_MAINTENANCE_SUCCEEDED,
+		fmt.Sprintf("the maintenance job %s finished the %v at %s", latest.Name, maintenanceTasks(maintenance),
+			finishedAt)
+}
+
+func renderPostgresMaintenance(mgh *globalhubv1alpha4.MulticlusterGlobalHub,
+	maintenance *globalhubv1alpha4.PostgresMaintenance, conn *config.PostgresConnection,
+) ([]*unstructured.Unstructured, error) {
+	imagePullPolicy := corev1.PullAlways
+	if mgh.Spec.ImagePullPolicy != "" {
+		imagePullPolicy = mgh.Spec.ImagePullPolicy
+	}
+	tasks := maintenanceTasks(maintenance)
+
+	return renderer.NewHoHRenderer(maintenancePostgresFS).Render("manifests.maintenance", "",
+		func(profile string) (interface{}, error) {
+			return struct {
+				Namespace       string
+				PostgresImage   string
+				ImagePullSecret string
+				ImagePullPolicy string
+				NodeSelector    map[string]string
+				Tolerations     []corev1.Toleration
+				DatabaseURI     string
+				PostgresCACert  string
+				Schedule        string
+				WindowSeconds   int64
+				Vacuum          bool
+				Analyze         bool
+				Reindex         bool
+			}{
+				Namespace:       mgh.GetNamespace(),
+				PostgresImage:   config.GetImage(config.PostgresImageKey),
+				ImagePullSecret: mgh.Spec.ImagePullSecret,
+				ImagePullPolicy: string(imagePullPolicy),
+				NodeSelector:    mgh.Spec.NodeSelector,
+				Tolerations:     mgh.Spec.Tolerations,
+				DatabaseURI:     base64.StdEncoding.EncodeToString([]byte(conn.SuperuserDatabaseURI)),
+				PostgresCACert:  base64.StdEncoding.EncodeToString(conn.CACert),
+				Schedule:        maintenanceSchedule(maintenance),
+				WindowSeconds:   int64(maintenanceWindow(maintenance).Seconds()),
+				Vacuum:          slices.Contains(tasks, globalhubv1alpha4.PostgresMaintenanceVacuum),
+				Analyze:         slices.Contains(tasks, globalhubv1alpha4.PostgresMaintenanceAnalyze),
+				Reindex:         slices.Contains(tasks, globalhubv1alpha4.PostgresMaintenanceReindex),
+			}, nil
+		})
+}
+
+func maintenanceSchedule(maintenance *globalhubv1alpha4.PostgresMaintenance) string {
+	if maintenance.Schedule == "" {
+		return defaultMaintenanceSchedule
+	}
+	return maintenance.Schedule
+}
+
+// maintenanceWindow returns the length of the maintenance window, the invalid duration is rejected by the crd, so it
+// falls back to the default window
+func maintenanceWindow(maintenance *globalhubv1alpha4.PostgresMaintenance) time.Duration {
+	window, err := time.ParseDuration(maintenance.Duration)
+	if err != nil || window <= 0 {
+		return defaultMaintenanceWindow
+	}
+	return window
+}
+
+func maintenanceTasks(maintenance *globalhubv1alpha4.PostgresMaintenance) []globalhubv1alpha4.PostgresMaintenanceTask {
+	if len(maintenance.Tasks) == 0 {
+		return defaultMaintenanceTasks
+	}
+	return maintenance.Tasks
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	operatorconstants "github.com/stolostron/multicluster-global-hub/operator/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/deployer"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/renderer"
+	operatorutils "github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
+	"github.com/stolostron/multicluster-global-hub/pkg/utils"
+)
+
+//go:embed manifests.pooler
+var poolerPostgresFS embed.FS
+
+var partialPoolerURI = "@multicluster-global-hub-postgres-pooler." +
+	utils.GetDefaultNamespace() + ".svc:5432/hoh?sslmode=verify-full"
+
+const (
+	defaultPoolerReplicas             = 1
+	defaultPoolerPoolSize             = 20
+	defaultPoolerMaxClientConnections = 1000
+)
+
+// ensurePostgresPooler deploys the pgBouncer in front of the built-in postgres if the connection pooler is enabled,
+// otherwise the pooler objects are deleted. It returns true if the pooler is deployed
+func ensurePostgresPooler(ctx context.Context, mgh *globalhubv1alpha4.MulticlusterGlobalHub, c client.Client,
+	mapper *restmapper.DeferredDiscoveryRESTMapper, scheme *runtime.Scheme, credential *postgresCredential,
+) (bool, error) {
+	pooler := config.GetPostgresConnectionPooler(mgh)
+	certHash, err := certificateHash(ctx, c, mgh.Namespace, postgresCertificateSecretName(mgh, defaultPoolerCertSecret))
+	if err != nil {
+		return false, err
+	}
+	poolerObjects, err := renderPostgresPooler(mgh, pooler, credential, certHash)
+	if err != nil {
+		return false, err
+	}
+
+	if pooler == nil {
+		for _, obj := range poolerObjects {
+			err := c.Delete(ctx, obj)
+			if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+				return false, fmt.Errorf("failed to delete the postgres pooler object %s/%s: %w", obj.GetKind(),
+					obj.GetName(), err)
+			}
+		}
+		return false, nil
+	}
+
+	if err = operatorutils.ManipulateGlobalHubObjects(poolerObjects, mgh, deployer.NewHoHDeployer(c), mapper,
+		scheme); err != nil {
+		return false, fmt.Errorf("failed to create/update the postgres pooler objects: %w", err)
+	}
+	return true, nil
+}
+
+func renderPostgresPooler(mgh *globalhubv1alpha4.MulticlusterGlobalHub,
+	pooler *globalhubv1alpha4.PostgresConnectionPooler, credential *postgresCredential, certHash string,
+) ([]*unstructured.Unstructured, error) {
+	if pooler == nil {
+		pooler = &globalhubv1alpha4.PostgresConnectionPooler{}
+	}
+	replicas := pooler.Replicas
+	if replicas <= 0 {
+		replicas = defaultPoolerReplicas
+	}
+	poolMode := pooler.PoolMode
+	if poolMode == "" {
+		poolMode = globalhubv1alpha4.PostgresTransactionPool
+	}
+	poolSize := pooler.DefaultPoolSize
+	if poolSize <= 0 {
+		poolSize = defaultPoolerPoolSize
+	}
+	maxClientConnections := pooler.MaxClientConnections
+	if maxClientConnections <= 0 {
+		maxClientConnections = defaultPoolerMaxClientConnections
+	}
+	imagePullPolicy := corev1.PullAlways
+	if mgh.Spec.ImagePullPolicy != "" {
+		imagePullPolicy = mgh.Spec.ImagePullPolicy
+	}
+
+	// the pgBouncer doesn't reload the mounted configuration and certificate, so the pods are restarted by the hash of
+	// them
+	configHash := sha256.Sum256([]byte(fmt.Sprintf("%s/%d/%d/%s/%s/%s/%s/%s", poolMode, poolSize,
+		maxClientConnections, credential.postgresAdminUsername, credential.postgresAdminUserPassword,
+		credential.postgresReadonlyUsername, credential.postgresReadonlyUserPassword, certHash)))
+
+	return renderer.NewHoHRenderer(poolerPostgresFS).Render("manifests.pooler", "",
+		func(profile string) (interface{}, error) {
+			return struct {
+				Namespace                    string
+				PgBouncerImage               string
+				ImagePullSecret              string
+				ImagePullPolicy              string
+				NodeSelector                 map[string]string
+				Tolerations                  []corev1.Toleration
+				Replicas                     int32
+				PoolMode                     string
+				DefaultPoolSize              int32
+				MaxClientConnections         int32
+				PostgresAdminUser            string
+				PostgresAdminUserPassword    string
+				PostgresReadonlyUsername     string
+				PostgresReadonlyUserPassword string
+				ConfigHash                   string
+				CertificateSecretName        string
+				CustomCA                     bool
+				Resources                    *corev1.ResourceRequirements
+			}{
+				Namespace:                    mgh.GetNamespace(),
+				PgBouncerImage:               config.GetImage(config.PgBouncerImageKey),
+				ImagePullSecret:              mgh.Spec.ImagePullSecret,
+				ImagePullPolicy:              string(imagePullPolicy),
+				NodeSelector:                 mgh.Spec.NodeSelector,
+				Tolerations:                  mgh.Spec.Tolerations,
+				Replicas:                     replicas,
+				PoolMode:                     string(poolMode),
+				DefaultPoolSize:              poolSize,
+				MaxClientConnections:         maxClientConnections,
+				PostgresAdminUser:            credential.postgresAdminUsername,
+				PostgresAdminUserPassword:    credential.postgresAdminUserPassword,
+				PostgresReadonlyUsername:     credential.postgresReadonlyUsername,
+				PostgresReadonlyUserPassword: credential.postgresReadonlyUserPassword,
+				ConfigHash:                   hex.EncodeToString(configHash[:8]),
+				CertificateSecretName:        postgresCertificateSecretName(mgh, defaultPoolerCertSecret),
+				CustomCA:                     mgh.Spec.DataLayer.Postgres.TLS != nil,
+				Resources: operatorutils.GetResources(operatorconstants.PostgresPooler,
+					mgh.Spec.AdvancedConfig),
+			}, nil
+		})
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestRenderPostgresPooler(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	mgh.Namespace = "multicluster-global-hub"
+	mgh.Spec.AdvancedConfig = &globalhubv1alpha4.AdvancedConfig{
+		Postgres: &globalhubv1alpha4.PostgresCommonSpec{
+			ConnectionPooler: &globalhubv1alpha4.PostgresConnectionPooler{
+				Replicas:        2,
+				DefaultPoolSize: 50,
+			},
+		},
+	}
+	credential := &postgresCredential{
+		postgresAdminUsername:        "postgres",
+		postgresAdminUserPassword:    "admin-password",
+		postgresReadonlyUsername:     "guest",
+		postgresReadonlyUserPassword: "guest-password",
+	}
+
+	objs, err := renderPostgresPooler(mgh, mgh.Spec.AdvancedConfig.Postgres.ConnectionPooler, credential, "")
+	assert.NoError(t, err)
+	kinds := map[string]*unstructured.Unstructured{}
+	for _, obj := range objs {
+		kinds[obj.GetKind()] = obj
+	}
+	for _, kind := range []string{"ServiceAccount", "Secret", "Service", "Deployment"} {
+		assert.Contains(t, kinds, kind)
+	}
+
+	replicas, _, _ := unstructured.NestedInt64(kinds["Deployment"].Object, "spec", "replicas")
+	assert.Equal(t, int64(2), replicas)
+
+	// the defaults are applied to the unset settings
+	pgbouncer, _, _ := unstructured.NestedString(kinds["Secret"].Object, "stringData", "pgbouncer.ini")
+	assert.Contains(t, pgbouncer, "pool_mode = transaction")
+	assert.Contains(t, pgbouncer, "default_pool_size = 50")
+	assert.Contains(t, pgbouncer, "max_client_conn = 1000")
+	userlist, _, _ := unstructured.NestedString(kinds["Secret"].Object, "stringData", "userlist.txt")
+	assert.Contains(t, userlist, `"guest" "guest-password"`)
+
+	// the pods are restarted when the configuration is changed
+	hash, _, _ := unstructured.NestedString(kinds["Deployment"].Object, "spec", "template", "metadata",
+		"annotations", "global-hub.open-cluster-management.io/pooler-config-hash")
+	mgh.Spec.AdvancedConfig.Postgres.ConnectionPooler.PoolMode = globalhubv1alpha4.PostgresSessionPool
+	objs, err = renderPostgresPooler(mgh, mgh.Spec.AdvancedConfig.Postgres.ConnectionPooler, credential, "")
+	assert.NoError(t, err)
+	for _, obj := range objs {
+		if obj.GetKind() == "Deployment" {
+			updatedHash, _, _ := unstructured.NestedString(obj.Object, "spec", "template", "metadata",
+				"annotations", "global-hub.open-cluster-management.io/pooler-config-hash")
+			assert.NotEqual(t, hash, updatedHash)
+		}
+	}
+
+	// the pods are restarted when the certificate is rotated
+	objs, err = renderPostgresPooler(mgh, mgh.Spec.AdvancedConfig.Postgres.ConnectionPooler, credential, "rotated")
+	assert.NoError(t, err)
+	for _, obj := range objs {
+		if obj.GetKind() == "Deployment" {
+			rotatedHash, _, _ := unstructured.NestedString(obj.Object, "spec", "template", "metadata",
+				"annotations", "global-hub.open-cluster-management.io/pooler-config-hash")
+			assert.NotEqual(t, hash, rotatedHash)
+		}
+	}
+
+	// the user-supplied certificate and its CA are mounted to the pooler
+	mgh.Spec.DataLayer.Postgres.TLS = &globalhubv1alpha4.PostgresTLS{CertificateSecretName: "postgres-custom-certs"}
+	objs, err = renderPostgresPooler(mgh, mgh.Spec.AdvancedConfig.Postgres.ConnectionPooler, credential, "")
+	assert.NoError(t, err)
+	for _, obj := range objs {
+		if obj.GetKind() != "Deployment" {
+			continue
+		}
+		volumes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "volumes")
+		secretNames := []string{}
+		for _, volume := range volumes {
+			secretName, _, _ := unstructured.NestedString(volume.(map[string]interface{}), "secret", "secretName")
+			secretNames = append(secretNames, secretName)
+		}
+		assert.Equal(t, []string{"postgres-custom-certs", "postgres-custom-certs"}, secretNames[len(secretNames)-2:])
+	}
+}
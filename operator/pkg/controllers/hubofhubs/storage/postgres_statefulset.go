@@ -25,7 +25,7 @@ import (
 )
 
 var partialPostgresURI = "@multicluster-global-hub-postgres." +
-	utils.GetDefaultNamespace() + ".svc:5432/hoh?sslmode=verify-ca"
+	utils.GetDefaultNamespace() + ".svc:5432/hoh?sslmode=verify-full"
 
 type postgresCredential struct {
 	postgresAdminUsername        string
@@ -47,6 +47,12 @@ func InitPostgresByStatefulset(ctx context.Context, mgh *globalhubv1alpha4.Multi
 		imagePullPolicy = mgh.Spec.ImagePullPolicy
 	}
 
+	// the velero hooks are added to the postgres pod if the cluster backup of the hub is enabled
+	backupEnabled, err := utils.IsBackupEnabled(ctx, mgr.GetClient())
+	if err != nil {
+		return nil, err
+	}
+
 	// get the postgres objects
 	postgresRenderer, postgresDeployer := renderer.NewHoHRenderer(stsPostgresFS), deployer.NewHoHDeployer(mgr.GetClient())
 	postgresObjects, err := postgresRenderer.Render("manifests.sts", "",
@@ -69,6 +75,8 @@ func InitPostgresByStatefulset(ctx context.Context, mgh *globalhubv1alpha4.Multi
 				Resources                    *corev1.ResourceRequirements
 				EnableMetrics                bool
 				EnablePostgresMetrics        bool
+				BackupEnabled                bool
+				CertificateSecretName        string
 			}{
 				Namespace:                    mgh.GetNamespace(),
 				PostgresImage:                config.GetImage(config.PostgresImageKey),
@@ -89,6 +97,8 @@ func InitPostgresByStatefulset(ctx context.Context, mgh *globalhubv1alpha4.Multi
 					mgh.Spec.AdvancedConfig),
 				EnableMetrics:         mgh.Spec.EnableMetrics,
 				EnablePostgresMetrics: (!config.IsBYOPostgres()) && mgh.Spec.EnableMetrics,
+				BackupEnabled:         backupEnabled,
+				CertificateSecretName: postgresCertificateSecretName(mgh, defaultPostgresCertSecret),
 			}, nil
 		})
 	if err != nil {
@@ -106,18 +116,35 @@ func InitPostgresByStatefulset(ctx context.Context, mgh *globalhubv1alpha4.Multi
 		mapper, mgr.GetScheme()); err != nil {
 		return nil, fmt.Errorf("failed to create/update postgres objects: %w", err)
 	}
+	if err := ensurePostgresStorageExpansion(ctx, mgh, mgr, log); err != nil {
+		// the database shouldn't be blocked by the status of the volumes
+		log.Error(err, "failed to expand the postgres volumes")
+	}
+
+	pooled, err := ensurePostgresPooler(ctx, mgh, mgr.GetClient(), mapper, mgr.GetScheme(), credential)
+	if err != nil {
+		return nil, err
+	}
 
 	ca, err := getPostgresCA(ctx, mgh, mgr.GetClient())
 	if err != nil {
 		return nil, err
 	}
-	return &config.PostgresConnection{
+	conn := &config.PostgresConnection{
 		SuperuserDatabaseURI: "postgresql://" + credential.postgresAdminUsername + ":" +
 			credential.postgresAdminUserPassword + partialPostgresURI,
 		ReadonlyUserDatabaseURI: "postgresql://" + credential.postgresReadonlyUsername + ":" +
 			credential.postgresReadonlyUserPassword + partialPostgresURI,
 		CACert: []byte(ca),
-	}, nil
+	}
+	// the certificate of the pooler is also signed by the service ca
+	if pooled {
+		conn.PoolerSuperuserDatabaseURI = "postgresql://" + credential.postgresAdminUsername + ":" +
+			credential.postgresAdminUserPassword + partialPoolerURI
+		conn.PoolerReadonlyUserDatabaseURI = "postgresql://" + credential.postgresReadonlyUsername + ":" +
+			credential.postgresReadonlyUserPassword + partialPoolerURI
+	}
+	return conn, nil
 }
 
 func getPostgresCredential(ctx context.Context, mgh *globalhubv1alpha4.MulticlusterGlobalHub,
@@ -146,6 +173,18 @@ func getPostgresCredential(ctx context.Context, mgh *globalhubv1alpha4.Multiclus
 }
 
 func getPostgresCA(ctx context.Context, mgh *globalhubv1alpha4.MulticlusterGlobalHub, c client.Client) (string, error) {
+	// the CA of the user-supplied certificate
+	if postgresTLS := mgh.Spec.DataLayer.Postgres.TLS; postgresTLS != nil {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{
+			Name:      postgresTLS.CertificateSecretName,
+			Namespace: mgh.Namespace,
+		}, secret); err != nil {
+			return "", err
+		}
+		return string(secret.Data["ca.crt"]), nil
+	}
+
 	ca := &corev1.ConfigMap{}
 	if err := c.Get(ctx, types.NamespacedName{
 		Name:      constants.PostgresCAConfigMap,
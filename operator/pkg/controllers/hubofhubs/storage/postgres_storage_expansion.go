@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	operatorutils "github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
+)
+
+// the label of the volumes created by the volume claim template of the postgres statefulset
+const postgresVolumeLabel = "multicluster-global-hub-postgres"
+
+// ensurePostgresStorageExpansion grows the volumes of the built-in postgres to the storage size of the mgh, if their
+// storage class allows the volume expansion. The volume claim template of the statefulset is immutable, so the volumes
+// are patched directly. The volumes can't be shrunk, so the smaller size is reported to the mgh status instead of
+// being ignored. The volumes aren't cached by the operator, so they're read from the api server directly
+func ensurePostgresStorageExpansion(ctx context.Context, mgh *globalhubv1alpha4.MulticlusterGlobalHub,
+	mgr ctrl.Manager, log logr.Logger,
+) error {
+	desiredSize := config.GetPostgresStorageSize(mgh)
+	desired, err := resource.ParseQuantity(desiredSize)
+	if err != nil {
+		return fmt.Errorf("invalid size %s of the postgres volume: %w", desiredSize, err)
+	}
+
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	err = mgr.GetAPIReader().List(ctx, pvcs, client.InNamespace(mgh.Namespace),
+		client.MatchingLabels{"name": postgresVolumeLabel})
+	if err != nil {
+		return err
+	}
+
+	blocked, expanding := []string{}, []string{}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if desired.Cmp(requested) < 0 {
+			blocked = append(blocked, fmt.Sprintf("the volume %s can't be shrunk from %s to %s", pvc.Name,
+				requested.String(), desiredSize))
+			continue
+		}
+		if desired.Cmp(requested) > 0 {
+			expandable, err := operatorutils.StorageClassExpandable(ctx, mgr.GetAPIReader(), pvc.Spec.StorageClassName)
+			if err != nil {
+				return err
+			}
+			if !expandable {
+				blocked = append(blocked, fmt.Sprintf("the storage class of the volume %s doesn't allow the expansion",
+					pvc.Name))
+				continue
+			}
+			log.Info("expand the postgres volume", "name", pvc.Name, "from", requested.String(), "to", desiredSize)
+			patch := client.MergeFrom(pvc.DeepCopy())
+			if pvc.Spec.Resources.Requests == nil {
+				pvc.Spec.Resources.Requests = corev1.ResourceList{}
+			}
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = desired
+			if err := mgr.GetClient().Patch(ctx, pvc, patch); err != nil {
+				return err
+			}
+			requested = desired
+		}
+		// the file system is resized by the csi driver after the volume, it may require the pod to be restarted
+		capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+		if capacity.Cmp(requested) < 0 {
+			expanding = append(expanding, pvc.Name)
+		}
+	}
+	return setPostgresStorageExpansionCondition(ctx, mgr.GetClient(), mgh, blocked, expanding)
+}
+
+func setPostgresStorageExpansionCondition(ctx context.Context, c client.Client,
+	mgh *globalhubv1alpha4.MulticlusterGlobalHub, blocked, expanding []string,
+) error {
+	switch {
+	case len(blocked) > 0:
+		return config.SetConditionPostgresStorageExpanded(ctx, c, mgh, config.CONDITION_STATUS_FALSE,
+			config.CONDITION_REASON_POSTGRES_STORAGE_BLOCKED, strings.Join(blocked, "; "))
+	case len(expanding) > 0:
+		return config.SetConditionPostgresStorageExpanded(ctx, c, mgh, config.CONDITION_STATUS_FALSE,
+			config.CONDITION_REASON_POSTGRES_STORAGE_EXPANDING, fmt.Sprintf("expanding the postgres volumes: %s",
+				strings.Join(expanding, ", ")))
+	default:
+		// only report the condition once the size of the volumes has been changed
+		if !config.ContainsCondition(mgh, config.CONDITION_TYPE_POSTGRES_STORAGE_EXPANDED) {
+			return nil
+		}
+		return config.SetConditionPostgresStorageExpanded(ctx, c, mgh, config.CONDITION_STATUS_TRUE,
+			config.CONDITION_REASON_POSTGRES_STORAGE_EXPANDED, "the postgres volumes are expanded")
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+//go:embed timescaledb
+var timescaleDBFS embed.FS
+
+const defaultChunkTimeInterval = "7 days"
+
+// hypertables are the time-series tables and their time columns, which are the monthly partitioned tables if the
+// timescaledb isn't enabled
+var hypertables = []struct {
+	name       string
+	timeColumn string
+}{
+	{name: "event.local_policies", timeColumn: "created_at"},
+	{name: "event.local_root_policies", timeColumn: "created_at"},
+	{name: "event.managed_clusters", timeColumn: "created_at"},
+	{name: "history.local_compliance", timeColumn: "compliance_date"},
+}
+
+// ensureTimescaleDB converts the time-series tables to the hypertables if the timescaledb is enabled in the mgh, and
+// sets the retention policy of them. It returns true if any table is converted in this call
+func ensureTimescaleDB(ctx context.Context, conn *pgx.Conn, mgh *v1alpha4.MulticlusterGlobalHub) (bool, error) {
+	if mgh.Spec.DataLayer.Postgres.TimescaleDB == nil {
+		return false, nil
+	}
+	chunkInterval, retentionMonths, err := hypertableIntervals(mgh)
+	if err != nil {
+		return false, err
+	}
+
+	if err := applySQL(ctx, conn, timescaleDBFS, "timescaledb", ""); err != nil {
+		return false, fmt.Errorf("failed to enable the timescaledb, it must be installed and preloaded: %w", err)
+	}
+
+	converted := false
+	for _, table := range hypertables {
+		tableConverted := false
+		retention := fmt.Sprintf("%d months", hypertableRetention(table.name, retentionMonths))
+		err := conn.QueryRow(ctx, "SELECT ensure_hypertable($1, $2, $3::interval, $4::interval)", table.name,
+			table.timeColumn, chunkInterval, retention).Scan(&tableConverted)
+		if err != nil {
+			return false, fmt.Errorf("failed to convert %s to the hypertable: %w", table.name, err)
+		}
+		converted = converted || tableConverted
+	}
+	return converted, nil
+}
+
+// hypertableIntervals returns the chunk time interval as the postgres interval, and the retention months of each data
+// class of the hypertables
+func hypertableIntervals(mgh *v1alpha4.MulticlusterGlobalHub) (string, *config.DataRetentionMonths, error) {
+	chunkInterval := mgh.Spec.DataLayer.Postgres.TimescaleDB.ChunkTimeInterval
+	if chunkInterval == "" {
+		chunkInterval = defaultChunkTimeInterval
+	}
+	retentionMonths, err := config.GetDataRetentionMonths(mgh)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse the retention of the hypertables: %w", err)
+	}
+	return chunkInterval, retentionMonths, nil
+}
+
+// hypertableRetention returns the retention months of the data class of the hypertable
+func hypertableRetention(tableName string, retentionMonths *config.DataRetentionMonths) int {
+	if tableName == "history.local_compliance" {
+		return retentionMonths.ComplianceHistory
+	}
+	return retentionMonths.Events
+}
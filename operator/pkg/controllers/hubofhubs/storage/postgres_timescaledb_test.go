@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestHypertableIntervals(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.DataLayer.Postgres.Retention = "1y6m"
+	mgh.Spec.DataLayer.Postgres.TimescaleDB = &globalhubv1alpha4.PostgresTimescaleDB{}
+
+	chunkInterval, retentionMonths, err := hypertableIntervals(mgh)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultChunkTimeInterval, chunkInterval)
+	assert.Equal(t, 18, hypertableRetention("event.local_policies", retentionMonths))
+
+	mgh.Spec.DataLayer.Postgres.RetentionPolicy = &globalhubv1alpha4.DataRetentionPolicy{ComplianceHistory: "2y"}
+	_, retentionMonths, err = hypertableIntervals(mgh)
+	assert.NoError(t, err)
+	assert.Equal(t, 18, hypertableRetention("event.local_policies", retentionMonths))
+	assert.Equal(t, 24, hypertableRetention("history.local_compliance", retentionMonths))
+
+	mgh.Spec.DataLayer.Postgres.TimescaleDB.ChunkTimeInterval = "1 day"
+	chunkInterval, _, err = hypertableIntervals(mgh)
+	assert.NoError(t, err)
+	assert.Equal(t, "1 day", chunkInterval)
+
+	mgh.Spec.DataLayer.Postgres.Retention = "1s"
+	_, _, err = hypertableIntervals(mgh)
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+const (
+	// the certificates signed by the service ca
+	defaultPostgresCertSecret = "multicluster-global-hub-postgres-certs"
+	defaultPoolerCertSecret   = "multicluster-global-hub-postgres-pooler-certs"
+	// the path of the certificate mounted to the postgres container
+	postgresCertFile = "/opt/app-root/src/certs/tls.crt"
+)
+
+// postgresCertificateSecretName returns the secret of the user-supplied certificate if it's set in the mgh, otherwise
+// the default one signed by the service ca
+func postgresCertificateSecretName(mgh *globalhubv1alpha4.MulticlusterGlobalHub, defaultName string) string {
+	if mgh.Spec.DataLayer.Postgres.TLS != nil {
+		return mgh.Spec.DataLayer.Postgres.TLS.CertificateSecretName
+	}
+	return defaultName
+}
+
+// certificateHash returns the hash of the certificate and the key in the secret, it's empty if the secret isn't
+// issued yet
+func certificateHash(ctx context.Context, c client.Client, namespace, name string) (string, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret)
+	if errors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return secretCertificateHash(secret), nil
+}
+
+func secretCertificateHash(secret *corev1.Secret) string {
+	hash := sha256.Sum256(append(append([]byte{}, secret.Data[corev1.TLSCertKey]...),
+		secret.Data[corev1.TLSPrivateKeyKey]...))
+	return hex.EncodeToString(hash[:8])
+}
+
+// reloadPostgresCertificate reloads the configuration of the built-in postgres once the certificate is rotated, so the
+// new certificate is served without restarting the postgres. The clients keep the established connections, and the new
+// connections are verified with the rotated CA
+func (r *StorageReconciler) reloadPostgresCertificate(ctx context.Context, mgh *globalhubv1alpha4.MulticlusterGlobalHub,
+	storageConn *config.PostgresConnection,
+) error {
+	if mgh.Spec.DataLayer.Postgres.External != nil || config.IsBYOPostgres() ||
+		config.GetPostgresBackend(mgh) != globalhubv1alpha4.PostgresStatefulSetBackend {
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.GetClient().Get(ctx, types.NamespacedName{
+		Namespace: mgh.Namespace,
+		Name:      postgresCertificateSecretName(mgh, defaultPostgresCertSecret),
+	}, secret)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	hash := secretCertificateHash(secret)
+	if hash == r.postgresCertificateHash {
+		return nil
+	}
+
+	conn, err := database.PostgresConnection(ctx, storageConn.SuperuserDatabaseURI, storageConn.CACert,
+		config.GetPostgresAuthentication())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			r.log.Error(err, "failed to close connection to database")
+		}
+	}()
+
+	// the kubelet updates the mounted secret in a while, the configuration is reloaded once it's updated
+	mounted := ""
+	if err := conn.QueryRow(ctx, "SELECT pg_read_file($1)", postgresCertFile).Scan(&mounted); err != nil {
+		return fmt.Errorf("failed to read the certificate of the postgres: %w", err)
+	}
+	if mounted != string(secret.Data[corev1.TLSCertKey]) {
+		return fmt.Errorf("waiting for the certificate %s to be mounted to the postgres", secret.Name)
+	}
+	if _, err := conn.Exec(ctx, "SELECT pg_reload_conf()"); err != nil {
+		return fmt.Errorf("failed to reload the certificate of the postgres: %w", err)
+	}
+	r.log.Info("reloaded the certificate of the postgres", "secret", secret.Name)
+	r.postgresCertificateHash = hash
+	return nil
+}
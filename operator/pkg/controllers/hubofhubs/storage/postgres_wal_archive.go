@@ -0,0 +1,506 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	postgresv1beta1 "github.com/crunchydata/postgres-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+const (
+	// PostgresWALArchiveName is the scheduled base backup of the CloudNativePG cluster, and the pgBackRest
+	// configuration of the crunchy postgres cluster which contains the credentials of the bucket
+	PostgresWALArchiveName         = "multicluster-global-hub-postgres-wal-archive"
+	defaultWALArchivePrefix        = "multicluster-global-hub-wal"
+	defaultWALArchiveRetentionDays = 7
+	defaultBaseBackupSchedule      = "0 1 * * *"
+	// the target time which the postgres cluster is restored to, the restore runs once for each target time
+	postgresRestoredTargetAnnotation = "global-hub.open-cluster-management.io/restored-target-time"
+	// the restored CloudNativePG cluster keeps archiving the WAL to the archive of the cluster it's restored from, the
+	// WAL of the restore is written to a new timeline
+	cloudNativePGSkipEmptyWalArchiveAnnotation = "cnpg.io/skipEmptyWalArchiveCheck"
+	cloudNativePGRecoverySource                = "origin"
+	// the crunchy operator restores the cluster in place once the value of the annotation is changed
+	crunchyRestoreAnnotation = "postgres-operator.crunchydata.com/pgbackrest-restore"
+	crunchyWALArchiveRepo    = "repo2"
+	crunchyS3ConfigKey       = "s3.conf"
+)
+
+var (
+	cloudNativePGScheduledBackupGVK = schema.GroupVersionKind{
+		Group:   "postgresql.cnpg.io",
+		Version: "v1",
+		Kind:    "ScheduledBackup",
+	}
+	cloudNativePGBackupListGVK = schema.GroupVersionKind{
+		Group:   "postgresql.cnpg.io",
+		Version: "v1",
+		Kind:    "BackupList",
+	}
+)
+
+// ensurePostgresWALArchive schedules the base backups of the WAL archive, and reports the state of the archive or of
+// the restore as the DatabaseWALArchive condition. The archive itself is configured in the postgres cluster by the
+// backend, only the "crunchy" and the "cloudnativepg" backends support it
+func (r *StorageReconciler) ensurePostgresWALArchive(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub) error {
+	c := r.GetClient()
+	walArchive := mgh.Spec.DataLayer.Postgres.WALArchive
+	backend := config.GetPostgresBackend(mgh)
+	if walArchive == nil || backend != v1alpha4.PostgresCloudNativePGBackend {
+		scheduledBackup := &unstructured.Unstructured{}
+		scheduledBackup.SetGroupVersionKind(cloudNativePGScheduledBackupGVK)
+		scheduledBackup.SetName(PostgresWALArchiveName)
+		scheduledBackup.SetNamespace(mgh.Namespace)
+		if err := c.Delete(ctx, scheduledBackup); err != nil && !errors.IsNotFound(err) &&
+			!meta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to delete the scheduled backup of the WAL archive: %w", err)
+		}
+	}
+	if walArchive == nil {
+		if !config.ContainsCondition(mgh, config.CONDITION_TYPE_DATABASE_WAL_ARCHIVE) {
+			return nil
+		}
+		return config.DeleteCondition(ctx, c, mgh, config.CONDITION_TYPE_DATABASE_WAL_ARCHIVE,
+			config.CONDITION_REASON_DATABASE_WAL_ARCHIVE_ENABLED)
+	}
+
+	if mgh.Spec.DataLayer.Postgres.External != nil || backend == v1alpha4.PostgresStatefulSetBackend {
+		return config.SetConditionDatabaseWALArchive(ctx, c, mgh, metav1.ConditionFalse,
+			config.CONDITION_REASON_DATABASE_WAL_ARCHIVE_UNSUPPORTED,
+			"the WAL archive is only supported by the crunchy and the cloudnativepg backends of the built-in postgres")
+	}
+
+	var status metav1.ConditionStatus
+	var reason, message string
+	if backend == v1alpha4.PostgresCloudNativePGBackend {
+		if err := ensureCloudNativePGScheduledBackup(ctx, c, mgh, walArchive); err != nil {
+			return err
+		}
+		cluster := &unstructured.Unstructured{}
+		cluster.SetGroupVersionKind(cloudNativePGClusterGVK)
+		if err := c.Get(ctx, types.NamespacedName{Name: CloudNativePGClusterName, Namespace: mgh.Namespace},
+			cluster); err != nil {
+			return err
+		}
+		status, reason, message = cloudNativePGWALArchiveCondition(cluster, walArchive)
+	} else {
+		postgresCluster := &postgresv1beta1.PostgresCluster{}
+		if err := c.Get(ctx, types.NamespacedName{Name: config.PostgresName, Namespace: mgh.Namespace},
+			postgresCluster); err != nil {
+			return err
+		}
+		status, reason, message = crunchyWALArchiveCondition(postgresCluster, walArchive)
+	}
+	// the failed restore is reported by the backend before the cluster is recreated or restored
+	if reason == "" {
+		return nil
+	}
+	return config.SetConditionDatabaseWALArchive(ctx, c, mgh, status, reason, message)
+}
+
+func walArchivePrefix(walArchive *v1alpha4.PostgresWALArchive) string {
+	if walArchive.Prefix == "" {
+		return defaultWALArchivePrefix
+	}
+	return strings.Trim(walArchive.Prefix, "/")
+}
+
+func walArchiveRetentionDays(walArchive *v1alpha4.PostgresWALArchive) int32 {
+	if walArchive.RetentionDays <= 0 {
+		return defaultWALArchiveRetentionDays
+	}
+	return walArchive.RetentionDays
+}
+
+func baseBackupSchedule(walArchive *v1alpha4.PostgresWALArchive) string {
+	if walArchive.BaseBackupSchedule == "" {
+		return defaultBaseBackupSchedule
+	}
+	return walArchive.BaseBackupSchedule
+}
+
+func walArchiveDestination(walArchive *v1alpha4.PostgresWALArchive) string {
+	return fmt.Sprintf("s3://%s/%s", walArchive.Bucket, walArchivePrefix(walArchive))
+}
+
+// cloudNativePGBarmanObjectStore returns the object store of the WAL archive, it's also the source of the recovery
+func cloudNativePGBarmanObjectStore(walArchive *v1alpha4.PostgresWALArchive) map[string]interface{} {
+	objectStore := map[string]interface{}{
+		"destinationPath": walArchiveDestination(walArchive),
+		"serverName":      CloudNativePGClusterName,
+		"s3Credentials": map[string]interface{}{
+			"accessKeyId": map[string]interface{}{
+				"name": walArchive.CredentialsSecretName,
+				"key":  "aws_access_key_id",
+			},
+			"secretAccessKey": map[string]interface{}{
+				"name": walArchive.CredentialsSecretName,
+				"key":  "aws_secret_access_key",
+			},
+		},
+		"wal":  map[string]interface{}{"compression": "gzip"},
+		"data": map[string]interface{}{"compression": "gzip"},
+	}
+	if walArchive.Endpoint != "" {
+		objectStore["endpointURL"] = walArchive.Endpoint
+	}
+	return objectStore
+}
+
+// setCloudNativePGWALArchive sets the WAL archive of the cluster spec, and the recovery from the archive if the
+// cluster is created for the restore
+func setCloudNativePGWALArchive(cluster *unstructured.Unstructured, spec map[string]interface{},
+	mgh *v1alpha4.MulticlusterGlobalHub,
+) (bool, error) {
+	walArchive := mgh.Spec.DataLayer.Postgres.WALArchive
+	if walArchive == nil {
+		unstructured.RemoveNestedField(spec, "backup")
+		return false, nil
+	}
+	if err := unstructured.SetNestedField(spec, map[string]interface{}{
+		"barmanObjectStore": cloudNativePGBarmanObjectStore(walArchive),
+		"retentionPolicy":   fmt.Sprintf("%dd", walArchiveRetentionDays(walArchive)),
+	}, "backup"); err != nil {
+		return false, err
+	}
+
+	if cluster.GetResourceVersion() != "" || walArchive.RestoreTargetTime == "" {
+		return false, nil
+	}
+	if err := unstructured.SetNestedField(spec, map[string]interface{}{
+		"source":   cloudNativePGRecoverySource,
+		"database": cloudNativePGDatabase,
+		"owner":    cloudNativePGDatabase,
+		"recoveryTarget": map[string]interface{}{
+			"targetTime": walArchive.RestoreTargetTime,
+		},
+	}, "bootstrap", "recovery"); err != nil {
+		return false, err
+	}
+	if err := unstructured.SetNestedSlice(spec, []interface{}{map[string]interface{}{
+		"name":              cloudNativePGRecoverySource,
+		"barmanObjectStore": cloudNativePGBarmanObjectStore(walArchive),
+	}}, "externalClusters"); err != nil {
+		return false, err
+	}
+	annotations := cluster.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[postgresRestoredTargetAnnotation] = walArchive.RestoreTargetTime
+	annotations[cloudNativePGSkipEmptyWalArchiveAnnotation] = "enabled"
+	cluster.SetAnnotations(annotations)
+	return true, nil
+}
+
+// restoreCloudNativePGCluster deletes the cluster once the restore target time is changed, the CloudNativePG cluster
+// can only be recovered from the archive when it's bootstrapped, so it's recreated by the next reconcile. The cluster
+// isn't deleted if the archive has no base backup before the target time
+func (b *cloudNativePGBackend) restoreCloudNativePGCluster(ctx context.Context,
+	mgh *v1alpha4.MulticlusterGlobalHub,
+) error {
+	walArchive := mgh.Spec.DataLayer.Postgres.WALArchive
+	if walArchive == nil || walArchive.RestoreTargetTime == "" {
+		return nil
+	}
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(cloudNativePGClusterGVK)
+	err := b.client.Get(ctx, types.NamespacedName{Name: CloudNativePGClusterName, Namespace: mgh.Namespace}, cluster)
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if cluster.GetDeletionTimestamp() != nil {
+		return fmt.Errorf("waiting for the CloudNativePG cluster to be deleted for the restore")
+	}
+	if cluster.GetAnnotations()[postgresRestoredTargetAnnotation] == walArchive.RestoreTargetTime {
+		return nil
+	}
+
+	target, err := time.Parse(time.RFC3339, walArchive.RestoreTargetTime)
+	if err != nil {
+		return config.SetConditionDatabaseWALArchive(ctx, b.client, mgh, metav1.ConditionFalse,
+			config.CONDITION_REASON_DATABASE_RESTORE_FAILED,
+			fmt.Sprintf("the restore target time %q isn't a RFC3339 time", walArchive.RestoreTargetTime))
+	}
+	if target.After(time.Now()) {
+		return config.SetConditionDatabaseWALArchive(ctx, b.client, mgh, metav1.ConditionFalse,
+			config.CONDITION_REASON_DATABASE_RESTORE_FAILED,
+			fmt.Sprintf("the restore target time %s is in the future", walArchive.RestoreTargetTime))
+	}
+	backups := &unstructured.UnstructuredList{}
+	backups.SetGroupVersionKind(cloudNativePGBackupListGVK)
+	if err := b.client.List(ctx, backups, client.InNamespace(mgh.Namespace),
+		client.MatchingLabels{"cnpg.io/cluster": CloudNativePGClusterName}); err != nil {
+		return err
+	}
+	if !hasBaseBackupBefore(backups.Items, target) {
+		return config.SetConditionDatabaseWALArchive(ctx, b.client, mgh, metav1.ConditionFalse,
+			config.CONDITION_REASON_DATABASE_RESTORE_FAILED,
+			fmt.Sprintf("the WAL archive has no completed base backup before the restore target time %s",
+				walArchive.RestoreTargetTime))
+	}
+
+	if err := b.client.Delete(ctx, cluster,
+		client.PropagationPolicy(metav1.DeletePropagationForeground)); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the CloudNativePG cluster for the restore: %w", err)
+	}
+	b.log.Info("deleted the CloudNativePG cluster to restore it from the WAL archive",
+		"targetTime", walArchive.RestoreTargetTime)
+	if err := config.SetConditionDatabaseWALArchive(ctx, b.client, mgh, metav1.ConditionUnknown,
+		config.CONDITION_REASON_DATABASE_RESTORE_IN_PROGRESS,
+		fmt.Sprintf("restoring the database to %s", walArchive.RestoreTargetTime)); err != nil {
+		return err
+	}
+	return fmt.Errorf("waiting for the CloudNativePG cluster to be deleted for the restore")
+}
+
+// hasBaseBackupBefore returns true if one of the CloudNativePG backups is completed before the target time
+func hasBaseBackupBefore(backups []unstructured.Unstructured, target time.Time) bool {
+	for _, backup := range backups {
+		phase, _, _ := unstructured.NestedString(backup.Object, "status", "phase")
+		stoppedAt, _, _ := unstructured.NestedString(backup.Object, "status", "stoppedAt")
+		if phase != "completed" {
+			continue
+		}
+		stopped, err := time.Parse(time.RFC3339, stoppedAt)
+		if err == nil && !stopped.After(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureCloudNativePGScheduledBackup schedules the base backups of the CloudNativePG cluster to the WAL archive, the
+// first base backup is taken once it's created
+func ensureCloudNativePGScheduledBackup(ctx context.Context, c client.Client, mgh *v1alpha4.MulticlusterGlobalHub,
+	walArchive *v1alpha4.PostgresWALArchive,
+) error {
+	scheduledBackup := &unstructured.Unstructured{}
+	scheduledBackup.SetGroupVersionKind(cloudNativePGScheduledBackupGVK)
+	scheduledBackup.SetName(PostgresWALArchiveName)
+	scheduledBackup.SetNamespace(mgh.Namespace)
+	_, err := controllerutil.CreateOrUpdate(ctx, c, scheduledBackup, func() error {
+		labels := scheduledBackup.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[constants.GlobalHubOwnerLabelKey] = constants.GHOperatorOwnerLabelVal
+		scheduledBackup.SetLabels(labels)
+		return unstructured.SetNestedMap(scheduledBackup.Object, map[string]interface{}{
+			// the schedule of the CloudNativePG starts with the seconds
+			"schedule":             "0 " + baseBackupSchedule(walArchive),
+			"immediate":            true,
+			"method":               "barmanObjectStore",
+			"backupOwnerReference": "self",
+			"cluster":              map[string]interface{}{"name": CloudNativePGClusterName},
+		}, "spec")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ensure the scheduled backup of the WAL archive: %w", err)
+	}
+	return nil
+}
+
+// cloudNativePGWALArchiveCondition returns the state of the restore if the cluster is restored to the target time,
+// otherwise it returns the state of the continuous archiving reported by the CloudNativePG operator
+func cloudNativePGWALArchiveCondition(cluster *unstructured.Unstructured,
+	walArchive *v1alpha4.PostgresWALArchive,
+) (metav1.ConditionStatus, string, string) {
+	if walArchive.RestoreTargetTime != "" {
+		if cluster.GetAnnotations()[postgresRestoredTargetAnnotation] != walArchive.RestoreTargetTime {
+			return "", "", ""
+		}
+		phase, _, _ := unstructured.NestedString(cluster.Object, "status", "phase")
+		if phase != "Cluster in healthy state" {
+			return metav1.ConditionUnknown, config.CONDITION_REASON_DATABASE_RESTORE_IN_PROGRESS,
+				fmt.Sprintf("restoring the database to %s: %s", walArchive.RestoreTargetTime, phase)
+		}
+		return metav1.ConditionTrue, config.CONDITION_REASON_DATABASE_RESTORE_SUCCEEDED,
+			fmt.Sprintf("the database is restored to %s, and the WAL is archived to %s", walArchive.RestoreTargetTime,
+				walArchiveDestination(walArchive))
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(cluster.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok || condition["type"] != "ContinuousArchiving" {
+			continue
+		}
+		if condition["status"] == string(metav1.ConditionTrue) {
+			return metav1.ConditionTrue, config.CONDITION_REASON_DATABASE_WAL_ARCHIVE_ENABLED,
+				fmt.Sprintf("the WAL is archived to %s", walArchiveDestination(walArchive))
+		}
+		return metav1.ConditionFalse, config.CONDITION_REASON_DATABASE_WAL_ARCHIVE_PENDING,
+			fmt.Sprintf("the WAL isn't archived to %s: %v", walArchiveDestination(walArchive), condition["message"])
+	}
+	return metav1.ConditionUnknown, config.CONDITION_REASON_DATABASE_WAL_ARCHIVE_PENDING,
+		fmt.Sprintf("waiting for the WAL to be archived to %s", walArchiveDestination(walArchive))
+}
+
+// ensureCrunchyWALArchiveSecret creates the pgBackRest configuration with the credentials of the bucket, it's
+// projected into the pgBackRest containers of the crunchy postgres cluster
+func ensureCrunchyWALArchiveSecret(ctx context.Context, c client.Client, mgh *v1alpha4.MulticlusterGlobalHub) error {
+	walArchive := mgh.Spec.DataLayer.Postgres.WALArchive
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: PostgresWALArchiveName, Namespace: mgh.Namespace}}
+	if walArchive == nil {
+		if err := c.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the pgBackRest configuration of the WAL archive: %w", err)
+		}
+		return nil
+	}
+
+	credentials := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: walArchive.CredentialsSecretName, Namespace: mgh.Namespace},
+		credentials); err != nil {
+		return fmt.Errorf("failed to get the credentials of the WAL archive: %w", err)
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, c, secret, func() error {
+		secret.Labels = map[string]string{constants.GlobalHubOwnerLabelKey: constants.GHOperatorOwnerLabelVal}
+		secret.Data = map[string][]byte{
+			crunchyS3ConfigKey: []byte(fmt.Sprintf("[global]\n%s-s3-key=%s\n%s-s3-key-secret=%s\n",
+				crunchyWALArchiveRepo, credentials.Data["aws_access_key_id"],
+				crunchyWALArchiveRepo, credentials.Data["aws_secret_access_key"])),
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ensure the pgBackRest configuration of the WAL archive: %w", err)
+	}
+	return nil
+}
+
+// setCrunchyWALArchive adds the S3 repository of the WAL archive to the pgBackRest of the crunchy postgres cluster,
+// the first repository is kept for the replicas. The existing cluster is restored in place to the target time
+func setCrunchyWALArchive(postgresCluster *postgresv1beta1.PostgresCluster, mgh *v1alpha4.MulticlusterGlobalHub) {
+	walArchive := mgh.Spec.DataLayer.Postgres.WALArchive
+	pgbackrest := &postgresCluster.Spec.Backups.PGBackRest
+
+	repos := []postgresv1beta1.PGBackRestRepo{}
+	for _, repo := range pgbackrest.Repos {
+		if repo.Name != crunchyWALArchiveRepo {
+			repos = append(repos, repo)
+		}
+	}
+	configuration := []corev1.VolumeProjection{}
+	for _, projection := range pgbackrest.Configuration {
+		if projection.Secret == nil || projection.Secret.Name != PostgresWALArchiveName {
+			configuration = append(configuration, projection)
+		}
+	}
+	for key := range pgbackrest.Global {
+		if strings.HasPrefix(key, crunchyWALArchiveRepo+"-") {
+			delete(pgbackrest.Global, key)
+		}
+	}
+	pgbackrest.Repos = repos
+	pgbackrest.Configuration = configuration
+	if walArchive == nil {
+		return
+	}
+
+	// the endpoint of the pgBackRest is the host of the object storage
+	endpoint := strings.TrimPrefix(strings.TrimPrefix(walArchive.Endpoint, "https://"), "http://")
+	region := walArchive.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("s3.%s.amazonaws.com", region)
+	}
+	pgbackrest.Repos = append(pgbackrest.Repos, postgresv1beta1.PGBackRestRepo{
+		Name: crunchyWALArchiveRepo,
+		S3: &postgresv1beta1.RepoS3{
+			Bucket:   walArchive.Bucket,
+			Endpoint: endpoint,
+			Region:   region,
+		},
+		BackupSchedules: &postgresv1beta1.PGBackRestBackupSchedules{
+			Full: ptr.To(baseBackupSchedule(walArchive)),
+		},
+	})
+	pgbackrest.Configuration = append(pgbackrest.Configuration, corev1.VolumeProjection{
+		Secret: &corev1.SecretProjection{
+			LocalObjectReference: corev1.LocalObjectReference{Name: PostgresWALArchiveName},
+		},
+	})
+	if pgbackrest.Global == nil {
+		pgbackrest.Global = map[string]string{}
+	}
+	pgbackrest.Global[crunchyWALArchiveRepo+"-path"] = "/" + walArchivePrefix(walArchive)
+	pgbackrest.Global[crunchyWALArchiveRepo+"-retention-full-type"] = "time"
+	pgbackrest.Global[crunchyWALArchiveRepo+"-retention-full"] = strconv.Itoa(int(walArchiveRetentionDays(walArchive)))
+	if walArchive.Endpoint != "" {
+		pgbackrest.Global[crunchyWALArchiveRepo+"-s3-uri-style"] = "path"
+	}
+
+	if postgresCluster.ResourceVersion == "" || walArchive.RestoreTargetTime == "" ||
+		postgresCluster.Annotations[crunchyRestoreAnnotation] == walArchive.RestoreTargetTime {
+		return
+	}
+	pgbackrest.Restore = &postgresv1beta1.PGBackRestRestore{
+		Enabled: ptr.To(true),
+		PostgresClusterDataSource: &postgresv1beta1.PostgresClusterDataSource{
+			RepoName: crunchyWALArchiveRepo,
+			Options:  []string{"--type=time", fmt.Sprintf("--target=%q", walArchive.RestoreTargetTime)},
+		},
+	}
+	if postgresCluster.Annotations == nil {
+		postgresCluster.Annotations = map[string]string{}
+	}
+	postgresCluster.Annotations[crunchyRestoreAnnotation] = walArchive.RestoreTargetTime
+}
+
+// crunchyWALArchiveCondition returns the state of the restore if the cluster is restored to the target time,
+// otherwise it returns the state of the S3 repository
+func crunchyWALArchiveCondition(postgresCluster *postgresv1beta1.PostgresCluster,
+	walArchive *v1alpha4.PostgresWALArchive,
+) (metav1.ConditionStatus, string, string) {
+	pgbackrest := postgresCluster.Status.PGBackRest
+	if walArchive.RestoreTargetTime != "" &&
+		postgresCluster.Annotations[crunchyRestoreAnnotation] == walArchive.RestoreTargetTime {
+		if pgbackrest == nil || pgbackrest.Restore == nil || pgbackrest.Restore.ID != walArchive.RestoreTargetTime ||
+			!pgbackrest.Restore.Finished {
+			return metav1.ConditionUnknown, config.CONDITION_REASON_DATABASE_RESTORE_IN_PROGRESS,
+				fmt.Sprintf("restoring the database to %s", walArchive.RestoreTargetTime)
+		}
+		if pgbackrest.Restore.Succeeded == 0 {
+			return metav1.ConditionFalse, config.CONDITION_REASON_DATABASE_RESTORE_FAILED,
+				fmt.Sprintf("failed to restore the database to %s, check the restore job of the postgres cluster",
+					walArchive.RestoreTargetTime)
+		}
+		return metav1.ConditionTrue, config.CONDITION_REASON_DATABASE_RESTORE_SUCCEEDED,
+			fmt.Sprintf("the database is restored to %s, and the WAL is archived to %s", walArchive.RestoreTargetTime,
+				walArchiveDestination(walArchive))
+	}
+
+	if pgbackrest != nil {
+		for _, repo := range pgbackrest.Repos {
+			if repo.Name == crunchyWALArchiveRepo && repo.StanzaCreated {
+				return metav1.ConditionTrue, config.CONDITION_REASON_DATABASE_WAL_ARCHIVE_ENABLED,
+					fmt.Sprintf("the WAL is archived to %s", walArchiveDestination(walArchive))
+			}
+		}
+	}
+	return metav1.ConditionUnknown, config.CONDITION_REASON_DATABASE_WAL_ARCHIVE_PENDING,
+		fmt.Sprintf("waiting for the WAL to be archived to %s", walArchiveDestination(walArchive))
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	postgresv1beta1 "github.com/crunchydata/postgres-operator/pkg/apis/postgres-operator.crunchydata.com/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	globalhubv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+func TestSetCloudNativePGWALArchive(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.DataLayer.Postgres.WALArchive = &globalhubv1alpha4.PostgresWALArchive{
+		Bucket:                "hoh-wal",
+		Endpoint:              "https://minio.example.com",
+		CredentialsSecretName: "wal-credentials",
+	}
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(cloudNativePGClusterGVK)
+	assert.NoError(t, setCloudNativePGCluster(cluster, mgh))
+	destination, _, _ := unstructured.NestedString(cluster.Object,
+		"spec", "backup", "barmanObjectStore", "destinationPath")
+	assert.Equal(t, "s3://hoh-wal/multicluster-global-hub-wal", destination)
+	retention, _, _ := unstructured.NestedString(cluster.Object, "spec", "backup", "retentionPolicy")
+	assert.Equal(t, "7d", retention)
+	_, found, _ := unstructured.NestedMap(cluster.Object, "spec", "bootstrap", "initdb")
+	assert.True(t, found)
+
+	// the cluster is recovered from the archive if it's created for the restore
+	mgh.Spec.DataLayer.Postgres.WALArchive.RestoreTargetTime = "2024-06-15T08:00:00Z"
+	cluster = &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(cloudNativePGClusterGVK)
+	assert.NoError(t, setCloudNativePGCluster(cluster, mgh))
+	_, found, _ = unstructured.NestedMap(cluster.Object, "spec", "bootstrap", "initdb")
+	assert.False(t, found)
+	targetTime, _, _ := unstructured.NestedString(cluster.Object,
+		"spec", "bootstrap", "recovery", "recoveryTarget", "targetTime")
+	assert.Equal(t, "2024-06-15T08:00:00Z", targetTime)
+	externalClusters, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "externalClusters")
+	assert.Len(t, externalClusters, 1)
+	assert.Equal(t, "2024-06-15T08:00:00Z", cluster.GetAnnotations()[postgresRestoredTargetAnnotation])
+
+	// the archive is removed once it's disabled
+	cluster.SetResourceVersion("1")
+	mgh.Spec.DataLayer.Postgres.WALArchive = nil
+	assert.NoError(t, setCloudNativePGCluster(cluster, mgh))
+	_, found, _ = unstructured.NestedMap(cluster.Object, "spec", "backup")
+	assert.False(t, found)
+}
+
+func TestHasBaseBackupBefore(t *testing.T) {
+	newBackup := func(phase, stoppedAt string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"phase": phase, "stoppedAt": stoppedAt},
+		}}
+	}
+	target := time.Date(2024, 6, 15, 8, 0, 0, 0, time.UTC)
+
+	assert.False(t, hasBaseBackupBefore(nil, target))
+	assert.False(t, hasBaseBackupBefore([]unstructured.Unstructured{
+		newBackup("failed", "2024-06-14T01:00:00Z"),
+		newBackup("completed", "2024-06-15T09:00:00Z"),
+	}, target))
+	assert.True(t, hasBaseBackupBefore([]unstructured.Unstructured{
+		newBackup("completed", "2024-06-15T09:00:00Z"),
+		newBackup("completed", "2024-06-15T01:00:00Z"),
+	}, target))
+}
+
+func TestCloudNativePGWALArchiveCondition(t *testing.T) {
+	walArchive := &globalhubv1alpha4.PostgresWALArchive{Bucket: "hoh-wal"}
+	cluster := &unstructured.Unstructured{Object: map[string]interface{}{}}
+
+	_, reason, _ := cloudNativePGWALArchiveCondition(cluster, walArchive)
+	assert.Equal(t, config.CONDITION_REASON_DATABASE_WAL_ARCHIVE_PENDING, reason)
+
+	assert.NoError(t, unstructured.SetNestedSlice(cluster.Object, []interface{}{
+		map[string]interface{}{"type": "ContinuousArchiving", "status": "True"},
+	}, "status", "conditions"))
+	status, reason, _ := cloudNativePGWALArchiveCondition(cluster, walArchive)
+	assert.Equal(t, metav1.ConditionTrue, status)
+	assert.Equal(t, config.CONDITION_REASON_DATABASE_WAL_ARCHIVE_ENABLED, reason)
+
+	// the condition is kept while the cluster isn't recreated for the restore
+	walArchive.RestoreTargetTime = "2024-06-15T08:00:00Z"
+	_, reason, _ = cloudNativePGWALArchiveCondition(cluster, walArchive)
+	assert.Empty(t, reason)
+
+	cluster.SetAnnotations(map[string]string{postgresRestoredTargetAnnotation: "2024-06-15T08:00:00Z"})
+	_, reason, _ = cloudNativePGWALArchiveCondition(cluster, walArchive)
+	assert.Equal(t, config.CONDITION_REASON_DATABASE_RESTORE_IN_PROGRESS, reason)
+
+	assert.NoError(t, unstructured.SetNestedField(cluster.Object, "Cluster in healthy state", "status", "phase"))
+	status, reason, _ = cloudNativePGWALArchiveCondition(cluster, walArchive)
+	assert.Equal(t, metav1.ConditionTrue, status)
+	assert.Equal(t, config.CONDITION_REASON_DATABASE_RESTORE_SUCCEEDED, reason)
+}
+
+func TestSetCrunchyWALArchive(t *testing.T) {
+	mgh := &globalhubv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.DataLayer.Postgres.WALArchive = &globalhubv1alpha4.PostgresWALArchive{
+		Bucket:                "hoh-wal",
+		Endpoint:              "https://minio.example.com",
+		CredentialsSecretName: "wal-credentials",
+		RetentionDays:         14,
+		RestoreTargetTime:     "2024-06-15T08:00:00Z",
+	}
+
+	// the new cluster isn't restored
+	postgresCluster := NewPostgresCluster(config.PostgresName, "multicluster-global-hub", 1, "25Gi")
+	setCrunchyWALArchive(postgresCluster, mgh)
+	pgbackrest := postgresCluster.Spec.Backups.PGBackRest
+	assert.Len(t, pgbackrest.Repos, 2)
+	assert.Equal(t, "repo1", pgbackrest.Repos[0].Name)
+	assert.Equal(t, &postgresv1beta1.RepoS3{
+		Bucket:   "hoh-wal",
+		Endpoint: "minio.example.com",
+		Region:   "us-east-1",
+	}, pgbackrest.Repos[1].S3)
+	assert.Equal(t, "14", pgbackrest.Global["repo2-retention-full"])
+	assert.Equal(t, "/multicluster-global-hub-wal", pgbackrest.Global["repo2-path"])
+	assert.Len(t, pgbackrest.Configuration, 1)
+	assert.Nil(t, pgbackrest.Restore)
+
+	// the existing cluster is restored in place once for the target time
+	postgresCluster.ResourceVersion = "1"
+	setCrunchyWALArchive(postgresCluster, mgh)
+	pgbackrest = postgresCluster.Spec.Backups.PGBackRest
+	assert.Len(t, pgbackrest.Repos, 2)
+	assert.NotNil(t, pgbackrest.Restore)
+	assert.Equal(t, []string{"--type=time", `--target="2024-06-15T08:00:00Z"`}, pgbackrest.Restore.Options)
+	assert.Equal(t, "2024-06-15T08:00:00Z", postgresCluster.Annotations[crunchyRestoreAnnotation])
+
+	// the repository is removed once the archive is disabled
+	mgh.Spec.DataLayer.Postgres.WALArchive = nil
+	setCrunchyWALArchive(postgresCluster, mgh)
+	pgbackrest = postgresCluster.Spec.Backups.PGBackRest
+	assert.Len(t, pgbackrest.Repos, 1)
+	assert.Empty(t, pgbackrest.Configuration)
+	assert.Empty(t, pgbackrest.Global)
+}
@@ -28,9 +28,6 @@ var databaseFS embed.FS
 //go:embed database.old
 var databaseOldFS embed.FS
 
-//go:embed upgrade
-var upgradeFS embed.FS
-
 //go:embed manifests.sts
 var stsPostgresFS embed.FS
 
@@ -40,6 +37,12 @@ type StorageReconciler struct {
 	upgrade                bool
 	databaseReconcileCount int
 	enableGlobalResource   bool
+	// the hash of the certificate served by the built-in postgres
+	postgresCertificateHash string
+	// the hash of the tenants which have been created in the database
+	tenantsHash string
+	// the statement which has set the statement timeout of the manager role
+	statementTimeoutSQL string
 }
 
 func NewStorageReconciler(mgr ctrl.Manager, enableGlobalResource bool) *StorageReconciler {
@@ -64,12 +67,49 @@ func (r *StorageReconciler) Reconcile(ctx context.Context, mgh *v1alpha4.Multicl
 		return fmt.Errorf("database not ready, Error: %v", err)
 	}
 	config.SetDatabaseReady(true)
+
+	if err := r.reloadPostgresCertificate(ctx, mgh, storageConn); err != nil {
+		return fmt.Errorf("failed to reload the postgres certificate, Error: %v", err)
+	}
+
+	if err := ensurePostgresBackup(ctx, mgh, r.Manager, storageConn); err != nil {
+		return fmt.Errorf("failed to reconcile the database backup, Error: %v", err)
+	}
+
+	if err := r.ensurePostgresWALArchive(ctx, mgh); err != nil {
+		return fmt.Errorf("failed to reconcile the WAL archive, Error: %v", err)
+	}
+
+	if err := ensurePostgresMaintenance(ctx, mgh, r.Manager, storageConn); err != nil {
+		return fmt.Errorf("failed to reconcile the database maintenance, Error: %v", err)
+	}
+
+	if err := r.reconcileStatementTimeout(ctx, mgh, storageConn); err != nil {
+		return fmt.Errorf("failed to reconcile the statement timeout of the manager, Error: %v", err)
+	}
+
+	if err := r.reconcileTenants(ctx, mgh, storageConn); err != nil {
+		return fmt.Errorf("failed to reconcile the database tenants, Error: %v", err)
+	}
+
+	if err := r.ensurePayloadEncryptionKeys(ctx, mgh); err != nil {
+		return fmt.Errorf("failed to reconcile the payload encryption keys, Error: %v", err)
+	}
+
+	if err := ensurePostgresExporter(ctx, mgh, r.Manager, storageConn); err != nil {
+		return fmt.Errorf("failed to reconcile the postgres exporter, Error: %v", err)
+	}
 	return nil
 }
 
 func (r *StorageReconciler) ReconcileStorage(ctx context.Context,
 	mgh *v1alpha4.MulticlusterGlobalHub,
 ) (*config.PostgresConnection, error) {
+	// the external postgres of the mgh, the built-in postgres isn't deployed
+	if external := mgh.Spec.DataLayer.Postgres.External; external != nil {
+		return config.GetPGConnectionFromExternalPostgres(ctx, r.GetClient(), external)
+	}
+
 	// support BYO postgres
 	pgConnection, err := config.GetPGConnectionFromGHStorageSecret(ctx, r.GetClient())
 	if err == nil {
@@ -78,25 +118,8 @@ func (r *StorageReconciler) ReconcileStorage(ctx context.Context,
 		return nil, err
 	}
 
-	// then the storage secret is not found
-	// if not-provided postgres secret, create crunchy postgres operator by subscription
-	if config.GetInstallCrunchyOperator(mgh) {
-		err := EnsureCrunchyPostgresSub(ctx, r.GetClient(), mgh)
-		if err != nil {
-			return nil, err
-		}
-		pgConnection, err = EnsureCrunchyPostgres(ctx, r.GetClient(), r.log)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		// create the statefulset postgres and initialize the r.MiddlewareConfig.PgConnection
-		pgConnection, err = InitPostgresByStatefulset(ctx, mgh, r.Manager, r.log)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return pgConnection, nil
+	// then the storage secret is not found, provision the built-in postgres with the backend of the mgh
+	return NewPostgresBackend(mgh, r.Manager, r.log).EnsurePostgres(ctx, mgh)
 }
 
 func (r *StorageReconciler) reconcileDatabase(ctx context.Context, mgh *v1alpha4.MulticlusterGlobalHub) error {
@@ -114,7 +137,8 @@ func (r *StorageReconciler) reconcileDatabase(ctx context.Context, mgh *v1alpha4
 		}
 	}
 
-	conn, err := database.PostgresConnection(ctx, storageConn.SuperuserDatabaseURI, storageConn.CACert)
+	conn, err := database.PostgresConnection(ctx, storageConn.SuperuserDatabaseURI, storageConn.CACert,
+		config.GetPostgresAuthentication())
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %v", err)
 	}
@@ -124,6 +148,11 @@ func (r *StorageReconciler) reconcileDatabase(ctx context.Context, mgh *v1alpha4
 		}
 	}()
 
+	// the upgrade isn't aborted by the statement timeout of the manager, which is set on the same role
+	if _, err := conn.Exec(ctx, "SET statement_timeout = 0"); err != nil {
+		return fmt.Errorf("failed to reset the statement timeout: %w", err)
+	}
+
 	// Check if backup is enabled
 	backupEnabled, err := utils.IsBackupEnabled(ctx, r.GetClient())
 	if err != nil {
@@ -153,6 +182,12 @@ func (r *StorageReconciler) reconcileDatabase(ctx context.Context, mgh *v1alpha4
 	}
 	readonlyUsername := objURI.User.Username()
 
+	// the database isn't touched if it has been migrated by a newer operator
+	schema, err := r.getSchemaVersion(ctx, conn, mgh)
+	if err != nil {
+		return err
+	}
+
 	if err := applySQL(ctx, conn, databaseFS, "database", readonlyUsername); err != nil {
 		return err
 	}
@@ -163,17 +198,27 @@ func (r *StorageReconciler) reconcileDatabase(ctx context.Context, mgh *v1alpha4
 		}
 	}
 
-	if !r.upgrade {
-		err := applySQL(ctx, conn, upgradeFS, "upgrade", readonlyUsername)
-		if err != nil {
-			log.Error(err, "failed to exec the upgrade sql files")
+	if err := r.migrateSchema(ctx, conn, mgh, schema); err != nil {
+		log.Error(err, "failed to migrate the database schema")
+		return err
+	}
+	r.upgrade = true
+
+	converted, err := ensureTimescaleDB(ctx, conn, mgh)
+	if err != nil {
+		return err
+	}
+	// the triggers and the privileges of the converted tables are dropped with the partitioned tables
+	if converted {
+		if err := applySQL(ctx, conn, databaseFS, "database", readonlyUsername); err != nil {
 			return err
 		}
-		r.upgrade = true
 	}
 
 	log.V(7).Info("database initialized")
 	r.databaseReconcileCount++
+	// the row-level security is enabled again for the tables created or converted above
+	r.tenantsHash = ""
 	err = config.SetConditionDatabaseInit(ctx, r.GetClient(), mgh, config.CONDITION_STATUS_TRUE)
 	if err != nil {
 		return config.FailToSetConditionError(config.CONDITION_STATUS_TRUE, err)
@@ -0,0 +1,98 @@
+-- the group role of the tenants, the row-level security of the tables is applied to its members
+DO $$ BEGIN
+    IF NOT EXISTS (SELECT FROM pg_catalog.pg_roles WHERE rolname = 'global_hub_tenant') THEN
+        CREATE ROLE global_hub_tenant NOLOGIN;
+    END IF;
+END $$;
+
+-- the managed hubs which can be read by each tenant role
+CREATE TABLE IF NOT EXISTS public.tenant_hubs (
+    role_name name NOT NULL,
+    leaf_hub_name character varying(254) NOT NULL,
+    PRIMARY KEY (role_name, leaf_hub_name)
+);
+
+-- the managed hubs of the login role, it's the security definer, so the tenants can't read the hubs of the others
+CREATE OR REPLACE FUNCTION public.tenant_leaf_hubs()
+RETURNS SETOF character varying AS
+$$
+    SELECT leaf_hub_name FROM public.tenant_hubs WHERE role_name = session_user;
+$$ LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public;
+
+REVOKE ALL ON FUNCTION public.tenant_leaf_hubs() FROM PUBLIC;
+GRANT EXECUTE ON FUNCTION public.tenant_leaf_hubs() TO global_hub_tenant;
+
+--- enable the row-level security of the tables with the managed hub, and grant the tenants to read them. The owner
+--- of the tables, e.g. the manager, bypasses the row-level security, and the other roles, e.g. the read-only user of
+--- grafana, still read all the rows. The partitions aren't granted, since the policies only apply to their parents
+--- sample: SELECT ensure_tenant_policies();
+CREATE OR REPLACE FUNCTION ensure_tenant_policies()
+RETURNS VOID AS
+$$
+DECLARE
+    t record;
+BEGIN
+    FOR t IN SELECT n.nspname, c.relname FROM pg_class c
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        JOIN pg_attribute a ON a.attrelid = c.oid AND a.attname = 'leaf_hub_name' AND NOT a.attisdropped
+        WHERE n.nspname IN ('status', 'local_status', 'event', 'history', 'local_spec')
+            AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+    LOOP
+        EXECUTE format('GRANT USAGE ON SCHEMA %I TO global_hub_tenant', t.nspname);
+        EXECUTE format('ALTER TABLE %I.%I ENABLE ROW LEVEL SECURITY', t.nspname, t.relname);
+        IF NOT EXISTS (SELECT FROM pg_policies WHERE schemaname = t.nspname AND tablename = t.relname
+                       AND policyname = 'global_hub_all_rows') THEN
+            EXECUTE format('CREATE POLICY global_hub_all_rows ON %I.%I USING (true) WITH CHECK (true)',
+                           t.nspname, t.relname);
+        END IF;
+        IF NOT EXISTS (SELECT FROM pg_policies WHERE schemaname = t.nspname AND tablename = t.relname
+                       AND policyname = 'global_hub_tenant_rows') THEN
+            EXECUTE format('CREATE POLICY global_hub_tenant_rows ON %I.%I AS RESTRICTIVE FOR SELECT '
+                           'TO global_hub_tenant USING (leaf_hub_name IN (SELECT public.tenant_leaf_hubs()))',
+                           t.nspname, t.relname);
+        END IF;
+        EXECUTE format('GRANT SELECT ON %I.%I TO global_hub_tenant', t.nspname, t.relname);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+--- create or update the login role of the tenant, and replace the managed hubs it can read
+--- sample: SELECT ensure_tenant('tenant_team_a', 'password', ARRAY['hub1', 'hub2']);
+CREATE OR REPLACE FUNCTION ensure_tenant(tenant_role text, tenant_password text, leaf_hubs text[])
+RETURNS VOID AS
+$$
+BEGIN
+    IF EXISTS (SELECT FROM pg_catalog.pg_roles WHERE rolname = tenant_role) THEN
+        EXECUTE format('ALTER ROLE %I WITH LOGIN PASSWORD %L', tenant_role, tenant_password);
+    ELSE
+        EXECUTE format('CREATE ROLE %I WITH LOGIN PASSWORD %L', tenant_role, tenant_password);
+    END IF;
+    EXECUTE format('GRANT global_hub_tenant TO %I', tenant_role);
+    EXECUTE format('GRANT CONNECT ON DATABASE %I TO %I', current_database(), tenant_role);
+
+    DELETE FROM public.tenant_hubs WHERE role_name = tenant_role;
+    INSERT INTO public.tenant_hubs (role_name, leaf_hub_name)
+        SELECT DISTINCT tenant_role, hub FROM unnest(leaf_hubs) AS hub;
+END;
+$$ LANGUAGE plpgsql;
+
+--- drop the tenant roles which aren't in the desired roles
+--- sample: SELECT drop_stale_tenants(ARRAY['tenant_team_a']);
+CREATE OR REPLACE FUNCTION drop_stale_tenants(tenant_roles text[])
+RETURNS SETOF text AS
+$$
+DECLARE
+    stale text;
+BEGIN
+    FOR stale IN SELECT r.rolname FROM pg_catalog.pg_roles r
+        JOIN pg_catalog.pg_auth_members m ON m.member = r.oid
+        JOIN pg_catalog.pg_roles g ON g.oid = m.roleid AND g.rolname = 'global_hub_tenant'
+        WHERE r.rolname::text <> ALL (tenant_roles)
+    LOOP
+        DELETE FROM public.tenant_hubs WHERE role_name = stale;
+        EXECUTE format('REVOKE CONNECT ON DATABASE %I FROM %I', current_database(), stale);
+        EXECUTE format('DROP ROLE %I', stale);
+        RETURN NEXT stale;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
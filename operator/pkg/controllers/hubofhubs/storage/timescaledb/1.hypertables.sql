@@ -0,0 +1,55 @@
+-- the timescaledb must be in the shared_preload_libraries of the postgres
+CREATE EXTENSION IF NOT EXISTS timescaledb;
+
+--- convert the monthly partitioned table to the hypertable, and drop the chunks older than the retention
+--- it returns true if the table is converted, the triggers of the converted table are dropped with the partitioned table
+--- sample: SELECT ensure_hypertable('event.local_policies', 'created_at', '7 days', '18 months');
+CREATE OR REPLACE FUNCTION ensure_hypertable(full_table_name text, time_column text, chunk_interval interval,
+    retention interval)
+RETURNS BOOLEAN AS
+$$
+DECLARE
+    table_schema text := split_part(full_table_name, '.', 1);
+    table_name text := split_part(full_table_name, '.', 2);
+    converted boolean := false;
+    constraint_defs text[];
+    index_defs text[];
+    def text;
+BEGIN
+    IF EXISTS (SELECT 1 FROM timescaledb_information.hypertables
+               WHERE hypertable_schema = table_schema AND hypertable_name = table_name) THEN
+        -- the new interval only applies to the chunks created later
+        PERFORM set_chunk_time_interval(full_table_name::regclass, chunk_interval);
+    ELSE
+        -- the constraints and the indexes are recreated with the same names after the partitioned table is dropped
+        SELECT coalesce(array_agg(format('ALTER TABLE %s ADD CONSTRAINT %I %s', full_table_name, conname,
+                                         pg_get_constraintdef(oid))), '{}')
+        INTO constraint_defs
+        FROM pg_constraint WHERE conrelid = full_table_name::regclass AND contype IN ('p', 'u');
+
+        SELECT coalesce(array_agg(replace(pg_get_indexdef(indexrelid), ' ON ONLY ', ' ON ')), '{}')
+        INTO index_defs
+        FROM pg_index WHERE indrelid = full_table_name::regclass
+            AND NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conindid = indexrelid);
+
+        EXECUTE format('CREATE TABLE %1$s_migration (LIKE %1$s INCLUDING DEFAULTS)', full_table_name);
+        EXECUTE format('INSERT INTO %1$s_migration SELECT * FROM %1$s', full_table_name);
+        EXECUTE format('DROP TABLE %s', full_table_name);
+        EXECUTE format('ALTER TABLE %s_migration RENAME TO %I', full_table_name, table_name);
+
+        FOREACH def IN ARRAY constraint_defs LOOP
+            EXECUTE def;
+        END LOOP;
+        FOREACH def IN ARRAY index_defs LOOP
+            EXECUTE def;
+        END LOOP;
+
+        PERFORM create_hypertable(full_table_name::regclass, time_column, chunk_time_interval => chunk_interval,
+                                  migrate_data => true);
+        converted := true;
+    END IF;
+
+    PERFORM remove_retention_policy(full_table_name::regclass, if_exists => true);
+    PERFORM add_retention_policy(full_table_name::regclass, drop_after => retention);
+    RETURN converted;
+END $$ LANGUAGE plpgsql;
@@ -2,8 +2,15 @@ package protocol
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -26,6 +33,9 @@ type BYOTransporter struct {
 // create the transport with secret(BYO case), it should meet the following conditions
 // 1. name: "multicluster-global-hub-transport"
 // 2. properties: "bootstrap_server", "ca.crt", "client.crt" and "client.key"
+// 3. optional properties to authenticate with SASL: "sasl_mechanism", "sasl_username" and "sasl_password", e.g. the
+// AWS_MSK_IAM mechanism with the access key as the username and the secret access key as the password
+// 4. optional properties to authenticate with the API key, e.g. the Confluent Cloud: "api_key" and "api_secret"
 func NewBYOTransporter(ctx context.Context, namespacedName types.NamespacedName,
 	c client.Client,
 ) *BYOTransporter {
@@ -62,7 +72,7 @@ func (s *BYOTransporter) GetConnCredential(clusterName string) (*transport.Kafka
 	if err != nil {
 		return nil, err
 	}
-	return &transport.KafkaConnCredential{
+	credential := &transport.KafkaConnCredential{
 		ClusterID:       string(kafkaSecret.Data[filepath.Join("bootstrap_server")]),
 		BootstrapServer: string(kafkaSecret.Data[filepath.Join("bootstrap_server")]),
 		CACert:          base64.StdEncoding.EncodeToString(kafkaSecret.Data[filepath.Join("ca.crt")]),
@@ -71,5 +81,60 @@ func (s *BYOTransporter) GetConnCredential(clusterName string) (*transport.Kafka
 		// for the byo case, the status topic isn't change by the clusterName
 		StatusTopic: config.GetStatusTopic(""),
 		SpecTopic:   config.GetSpecTopic(),
-	}, nil
+	}
+	if mechanism, ok := kafkaSecret.Data["sasl_mechanism"]; ok && len(mechanism) > 0 {
+		credential.SASLMechanism = string(mechanism)
+		credential.SASLUsername = string(kafkaSecret.Data["sasl_username"])
+		credential.SASLPassword = base64.StdEncoding.EncodeToString(kafkaSecret.Data["sasl_password"])
+	} else if apiKey, ok := kafkaSecret.Data["api_key"]; ok && len(apiKey) > 0 {
+		credential.SASLMechanism = transport.SaslPlain
+		credential.SASLUsername = string(apiKey)
+		credential.SASLPassword = base64.StdEncoding.EncodeToString(kafkaSecret.Data["api_secret"])
+	}
+	return credential, nil
+}
+
+// ValidateConnection verifies the bootstrap servers are reachable over TLS with the ca certificate of the
+// credential, or the system certificates if it isn't set. It succeeds if any of the bootstrap servers is reachable
+func ValidateConnection(conn *transport.KafkaConnCredential, timeout time.Duration) error {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if conn.CACert != "" {
+		caCert, err := base64.StdEncoding.DecodeString(conn.CACert)
+		if err != nil {
+			return fmt.Errorf("failed to decode the ca certificate: %w", err)
+		}
+		if len(caCert) > 0 {
+			certPool := x509.NewCertPool()
+			if !certPool.AppendCertsFromPEM(caCert) {
+				return fmt.Errorf("failed to append the ca certificate")
+			}
+			tlsConfig.RootCAs = certPool
+		}
+	}
+
+	var errs []error
+	for _, server := range strings.Split(conn.BootstrapServer, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		host, _, err := net.SplitHostPort(server)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		serverTLSConfig := tlsConfig.Clone()
+		serverTLSConfig.ServerName = host
+		tlsConn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", server, serverTLSConfig)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		_ = tlsConn.Close()
+		return nil
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("the bootstrap server is empty")
+	}
+	return fmt.Errorf("the kafka cluster %s isn't reachable: %w", conn.BootstrapServer, errors.Join(errs...))
 }
@@ -0,0 +1,67 @@
+package protocol
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+func TestBYOTransporterWithAPIKey(t *testing.T) {
+	fakeClient := fake.NewClientBuilder().WithScheme(config.GetRuntimeScheme()).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "confluent", Namespace: "default"},
+		Data: map[string][]byte{
+			"bootstrap_server": []byte("pkc-12345.us-east-1.aws.confluent.cloud:9092"),
+			"api_key":          []byte("key"),
+			"api_secret":       []byte("secret"),
+		},
+	}).Build()
+
+	trans := NewBYOTransporter(context.Background(), types.NamespacedName{
+		Namespace: "default",
+		Name:      "confluent",
+	}, fakeClient)
+	conn, err := trans.GetConnCredential("")
+	assert.NoError(t, err)
+	assert.Equal(t, transport.SaslPlain, conn.SASLMechanism)
+	assert.Equal(t, "key", conn.SASLUsername)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("secret")), conn.SASLPassword)
+}
+
+func TestValidateConnection(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	// the server is reachable with the ca certificate
+	conn := &transport.KafkaConnCredential{
+		BootstrapServer: "127.0.0.1:1," + address,
+		CACert:          base64.StdEncoding.EncodeToString(caCert),
+	}
+	assert.NoError(t, ValidateConnection(conn, time.Second))
+
+	// the server isn't trusted by the system certificates
+	conn.CACert = ""
+	assert.Error(t, ValidateConnection(conn, time.Second))
+
+	// none of the servers is reachable
+	conn.BootstrapServer = "127.0.0.1:1"
+	assert.Error(t, ValidateConnection(conn, time.Second))
+
+	conn.BootstrapServer = ""
+	assert.Error(t, ValidateConnection(conn, time.Second))
+}
@@ -0,0 +1,371 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	operatorconstants "github.com/stolostron/multicluster-global-hub/operator/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+const (
+	// GRPCGatewayName is the name of the service and the route which expose the grpc server of the manager
+	GRPCGatewayName = "multicluster-global-hub-grpc"
+	GRPCServerPort  = 9444
+	// HTTPGatewayName is the name of the service and the route which expose the https server of the manager
+	HTTPGatewayName = "multicluster-global-hub-http"
+	HTTPServerPort  = 9445
+
+	gatewayCAValidity   = 10 * 365 * 24 * time.Hour
+	gatewayCertValidity = 365 * 24 * time.Hour
+	// the certificates are reissued once they expire within the period, the agents and the manager reload them on
+	// the next handshake
+	gatewayCertRenewBefore = 30 * 24 * time.Hour
+)
+
+// GatewayTransporter transports the bundles over a gateway of the manager instead of kafka, it's the grpc stream or
+// the https long poll. The operator exposes the manager with a passthrough route, and issues the server certificate
+// and the client certificates of the managed hubs by its own CA, so the manager verifies the managed hub of each
+// request by the common name
+type GatewayTransporter struct {
+	ctx           context.Context
+	log           logr.Logger
+	mgh           *operatorv1alpha4.MulticlusterGlobalHub
+	runtimeClient client.Client
+	// the protocol is the name of the container port of the manager, and the suffix of the client secrets
+	protocol    string
+	gatewayName string
+	port        int32
+	host        string
+}
+
+func NewGRPCTransporter(ctx context.Context, mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	c client.Client,
+) *GatewayTransporter {
+	return &GatewayTransporter{
+		log:           ctrl.Log.WithName("grpc-transporter"),
+		ctx:           ctx,
+		mgh:           mgh,
+		runtimeClient: c,
+		protocol:      "grpc",
+		gatewayName:   GRPCGatewayName,
+		port:          GRPCServerPort,
+		host:          mgh.Spec.DataLayer.GRPC.Host,
+	}
+}
+
+func NewHTTPTransporter(ctx context.Context, mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	c client.Client,
+) *GatewayTransporter {
+	return &GatewayTransporter{
+		log:           ctrl.Log.WithName("http-transporter"),
+		ctx:           ctx,
+		mgh:           mgh,
+		runtimeClient: c,
+		protocol:      "https",
+		gatewayName:   HTTPGatewayName,
+		port:          HTTPServerPort,
+		host:          mgh.Spec.DataLayer.HTTP.Host,
+	}
+}
+
+// EnsureUser returns the common name of the client certificate of the managed hub
+func (g *GatewayTransporter) EnsureUser(clusterName string) (string, error) {
+	return clusterName, nil
+}
+
+// EnsureTopic returns the topics for the flags of the agent, the bundles are routed by the manager instead
+func (g *GatewayTransporter) EnsureTopic(clusterName string) (*transport.ClusterTopic, error) {
+	return &transport.ClusterTopic{
+		SpecTopic:   config.GetSpecTopic(),
+		StatusTopic: config.GetStatusTopic(clusterName),
+	}, nil
+}
+
+// Prune deletes the client certificate of the managed hub
+func (g *GatewayTransporter) Prune(clusterName string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      g.clientSecretName(clusterName),
+			Namespace: g.mgh.Namespace,
+		},
+	}
+	if err := g.runtimeClient.Delete(g.ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// GetConnCredential returns the address of the gateway and the certificates, it's the server certificate for the
+// manager if the clusterName is empty, otherwise the client certificate of the managed hub
+func (g *GatewayTransporter) GetConnCredential(clusterName string) (*transport.KafkaConnCredential, error) {
+	host, err := g.gatewayHost()
+	if err != nil {
+		return nil, err
+	}
+	caCert, caKey, err := g.ensureCA()
+	if err != nil {
+		return nil, err
+	}
+
+	var certPEM, keyPEM []byte
+	if clusterName == "" {
+		serviceHost := fmt.Sprintf("%s.%s.svc", g.gatewayName, g.mgh.Namespace)
+		certPEM, keyPEM, err = g.ensureCertificate(g.gatewayName+"-server", caCert, caKey, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: host},
+			DNSNames:    []string{host, serviceHost},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		})
+	} else {
+		certPEM, keyPEM, err = g.ensureCertificate(g.clientSecretName(clusterName), caCert, caKey,
+			&x509.Certificate{
+				Subject:     pkix.Name{CommonName: clusterName},
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	address := fmt.Sprintf("%s:443", host)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw})
+	return &transport.KafkaConnCredential{
+		ClusterID:       address,
+		BootstrapServer: address,
+		CACert:          base64.StdEncoding.EncodeToString(caPEM),
+		ClientCert:      base64.StdEncoding.EncodeToString(certPEM),
+		ClientKey:       base64.StdEncoding.EncodeToString(keyPEM),
+		// the agent mounts the CA of the transport from the same secret as the built-in kafka
+		CASecretName: GetClusterCASecret(KafkaClusterName),
+		StatusTopic:  config.GetStatusTopic(clusterName),
+		SpecTopic:    config.GetSpecTopic(),
+	}, nil
+}
+
+// EnsureGateway exposes the server of the manager with a passthrough route, the TLS is terminated by the
+// manager, so it verifies the client certificates of the agents
+func (g *GatewayTransporter) EnsureGateway() error {
+	labels := map[string]string{
+		"name":                           operatorconstants.GHManagerDeploymentName,
+		constants.GlobalHubOwnerLabelKey: constants.GHOperatorOwnerLabelVal,
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: g.gatewayName, Namespace: g.mgh.Namespace},
+	}
+	_, err := controllerutil.CreateOrUpdate(g.ctx, g.runtimeClient, service, func() error {
+		service.Labels = labels
+		service.Spec.Selector = map[string]string{"name": operatorconstants.GHManagerDeploymentName}
+		service.Spec.Ports = []corev1.ServicePort{{
+			Name:       g.protocol,
+			Port:       g.port,
+			TargetPort: intstr.FromString(g.protocol),
+			Protocol:   corev1.ProtocolTCP,
+		}}
+		return controllerutil.SetControllerReference(g.mgh, service, g.runtimeClient.Scheme())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ensure the %s gateway service: %w", g.protocol, err)
+	}
+
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: g.gatewayName, Namespace: g.mgh.Namespace},
+	}
+	_, err = controllerutil.CreateOrUpdate(g.ctx, g.runtimeClient, route, func() error {
+		route.Labels = labels
+		// keep the host generated by the router if it isn't specified
+		if g.host != "" {
+			route.Spec.Host = g.host
+		}
+		route.Spec.Port = &routev1.RoutePort{TargetPort: intstr.FromString(g.protocol)}
+		route.Spec.TLS = &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough}
+		weight := int32(100)
+		route.Spec.To = routev1.RouteTargetReference{Kind: "Service", Name: g.gatewayName, Weight: &weight}
+		return controllerutil.SetControllerReference(g.mgh, route, g.runtimeClient.Scheme())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ensure the %s gateway route: %w", g.protocol, err)
+	}
+	return nil
+}
+
+func (g *GatewayTransporter) gatewayHost() (string, error) {
+	route := &routev1.Route{}
+	err := g.runtimeClient.Get(g.ctx, types.NamespacedName{Name: g.gatewayName, Namespace: g.mgh.Namespace}, route)
+	if err != nil {
+		return "", err
+	}
+	if route.Spec.Host == "" {
+		return "", fmt.Errorf("the host of the gateway route %s is not generated yet", g.gatewayName)
+	}
+	return route.Spec.Host, nil
+}
+
+// ensureCA returns the self-signed CA of the gateway, it's generated once and kept in the secret
+func (g *GatewayTransporter) ensureCA() (*x509.Certificate, crypto.Signer, error) {
+	caSecretName := g.gatewayName + "-ca"
+	secret := &corev1.Secret{}
+	err := g.runtimeClient.Get(g.ctx, types.NamespacedName{Name: caSecretName, Namespace: g.mgh.Namespace},
+		secret)
+	if err == nil {
+		return parseKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, nil, err
+	}
+
+	certPEM, keyPEM, err := issueCertificate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: caSecretName},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, gatewayCAValidity, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := g.saveKeyPair(caSecretName, certPEM, keyPEM); err != nil {
+		return nil, nil, err
+	}
+	g.log.Info("the CA of the gateway is generated", "secret", caSecretName)
+	return parseKeyPair(certPEM, keyPEM)
+}
+
+// ensureCertificate returns the certificate in the secret, it's reissued by the CA if it's missing, expiring, signed
+// by another CA or for the other hosts
+func (g *GatewayTransporter) ensureCertificate(secretName string, caCert *x509.Certificate, caKey crypto.Signer,
+	template *x509.Certificate,
+) ([]byte, []byte, error) {
+	secret := &corev1.Secret{}
+	err := g.runtimeClient.Get(g.ctx, types.NamespacedName{Name: secretName, Namespace: g.mgh.Namespace}, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	if err == nil {
+		certPEM, keyPEM := secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]
+		if cert, _, err := parseKeyPair(certPEM, keyPEM); err == nil && isCertificateValid(cert, caCert, template,
+			time.Now()) {
+			return certPEM, keyPEM, nil
+		}
+	}
+
+	certPEM, keyPEM, err := issueCertificate(template, gatewayCertValidity, caCert, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := g.saveKeyPair(secretName, certPEM, keyPEM); err != nil {
+		return nil, nil, err
+	}
+	g.log.Info("the gateway certificate is issued", "secret", secretName, "commonName", template.Subject.CommonName)
+	return certPEM, keyPEM, nil
+}
+
+func (g *GatewayTransporter) saveKeyPair(secretName string, certPEM, keyPEM []byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: g.mgh.Namespace},
+	}
+	_, err := controllerutil.CreateOrUpdate(g.ctx, g.runtimeClient, secret, func() error {
+		secret.Labels = map[string]string{constants.GlobalHubOwnerLabelKey: constants.GHOperatorOwnerLabelVal}
+		secret.Type = corev1.SecretTypeTLS
+		secret.Data = map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		}
+		return controllerutil.SetControllerReference(g.mgh, secret, g.runtimeClient.Scheme())
+	})
+	return err
+}
+
+func (g *GatewayTransporter) clientSecretName(clusterName string) string {
+	return fmt.Sprintf("%s-%s-client", clusterName, g.protocol)
+}
+
+// isCertificateValid returns true if the certificate is signed by the CA for the same subject and hosts, and it
+// doesn't expire within the renewal period
+func isCertificateValid(cert, caCert *x509.Certificate, template *x509.Certificate, now time.Time) bool {
+	if cert.CheckSignatureFrom(caCert) != nil {
+		return false
+	}
+	if cert.Subject.CommonName != template.Subject.CommonName || !reflect.DeepEqual(cert.DNSNames, template.DNSNames) {
+		return false
+	}
+	return now.Add(gatewayCertRenewBefore).Before(cert.NotAfter)
+}
+
+// issueCertificate signs the certificate by the CA with a new key, or self-signs it if the CA is nil
+func issueCertificate(template *x509.Certificate, validity time.Duration, caCert *x509.Certificate,
+	caKey crypto.Signer,
+) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	cert := *template
+	cert.SerialNumber = serial
+	cert.NotBefore = time.Now().Add(-time.Hour)
+	cert.NotAfter = time.Now().Add(validity)
+	if cert.KeyUsage == 0 {
+		cert.KeyUsage = x509.KeyUsageDigitalSignature
+	}
+	if caCert == nil {
+		caCert, caKey = &cert, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &cert, caCert, key.Public(), caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+func parseKeyPair(certPEM, keyPEM []byte) (*x509.Certificate, crypto.Signer, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, errors.New("failed to decode the certificate or the key")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
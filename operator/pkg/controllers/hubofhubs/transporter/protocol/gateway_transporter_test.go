@@ -0,0 +1,42 @@
+package protocol
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueGatewayCertificate(t *testing.T) {
+	caCertPEM, caKeyPEM, err := issueCertificate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, gatewayCAValidity, nil, nil)
+	assert.NoError(t, err)
+	caCert, caKey, err := parseKeyPair(caCertPEM, caKeyPEM)
+	assert.NoError(t, err)
+	assert.NoError(t, caCert.CheckSignatureFrom(caCert))
+
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "hub1"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certPEM, keyPEM, err := issueCertificate(template, gatewayCertValidity, caCert, caKey)
+	assert.NoError(t, err)
+	cert, _, err := parseKeyPair(certPEM, keyPEM)
+	assert.NoError(t, err)
+	assert.Equal(t, "hub1", cert.Subject.CommonName)
+
+	now := time.Now()
+	assert.True(t, isCertificateValid(cert, caCert, template, now))
+	// renew the certificate before it expires
+	assert.False(t, isCertificateValid(cert, caCert, template, cert.NotAfter.Add(-gatewayCertRenewBefore)))
+	// reissue the certificate for the other hub
+	assert.False(t, isCertificateValid(cert, caCert, &x509.Certificate{Subject: pkix.Name{CommonName: "hub2"}}, now))
+	// reissue the certificate signed by the other CA
+	assert.False(t, isCertificateValid(cert, cert, template, now))
+}
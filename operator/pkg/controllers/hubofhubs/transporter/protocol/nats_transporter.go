@@ -0,0 +1,170 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+// NatsStreamGVK is the stream of the NATS JetStream controller(nack)
+var NatsStreamGVK = schema.GroupVersionKind{
+	Group:   "jetstream.nats.io",
+	Version: "v1beta2",
+	Kind:    "Stream",
+}
+
+type NatsTransporter struct {
+	ctx           context.Context
+	log           logr.Logger
+	namespace     string
+	natsConfig    operatorv1alpha4.NatsConfig
+	runtimeClient client.Client
+}
+
+// NewNatsTransporter creates the transporter with the NATS JetStream, the topics of the managed hubs are provisioned
+// as the streams with the same subjects. It requires the NATS JetStream controller to be installed
+func NewNatsTransporter(ctx context.Context, mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	c client.Client,
+) *NatsTransporter {
+	return &NatsTransporter{
+		log:           ctrl.Log.WithName("nats-transporter"),
+		ctx:           ctx,
+		namespace:     mgh.Namespace,
+		natsConfig:    *mgh.Spec.DataLayer.Nats,
+		runtimeClient: c,
+	}
+}
+
+// EnsureUser returns an empty user, all the managed hubs share the credential of the NATS server
+func (n *NatsTransporter) EnsureUser(clusterName string) (string, error) {
+	return "", nil
+}
+
+func (n *NatsTransporter) EnsureTopic(clusterName string) (*transport.ClusterTopic, error) {
+	clusterTopic := &transport.ClusterTopic{
+		SpecTopic:   config.GetSpecTopic(),
+		StatusTopic: config.GetStatusTopic(clusterName),
+	}
+	for _, topic := range []string{clusterTopic.SpecTopic, clusterTopic.StatusTopic} {
+		if err := n.ensureStream(topic); err != nil {
+			return nil, err
+		}
+	}
+	return clusterTopic, nil
+}
+
+// Prune keeps the streams like the kafka topics, otherwise the manager fails to consume the removed subjects
+func (n *NatsTransporter) Prune(clusterName string) error {
+	return nil
+}
+
+func (n *NatsTransporter) GetConnCredential(clusterName string) (*transport.KafkaConnCredential, error) {
+	natsSecret := &corev1.Secret{}
+	err := n.runtimeClient.Get(n.ctx, types.NamespacedName{
+		Name:      n.natsConfig.TransportSecretName,
+		Namespace: n.namespace,
+	}, natsSecret)
+	if err != nil {
+		return nil, err
+	}
+	url := string(natsSecret.Data["url"])
+	if url == "" {
+		return nil, fmt.Errorf("the url of the NATS server is not found in the secret %s/%s", n.namespace,
+			n.natsConfig.TransportSecretName)
+	}
+	return &transport.KafkaConnCredential{
+		ClusterID:       url,
+		BootstrapServer: url,
+		CACert:          base64.StdEncoding.EncodeToString(natsSecret.Data["ca.crt"]),
+		StatusTopic:     config.GetStatusTopic(clusterName),
+		SpecTopic:       config.GetSpecTopic(),
+	}, nil
+}
+
+func (n *NatsTransporter) ensureStream(topic string) error {
+	desiredStream := n.newStream(topic)
+
+	existingStream := &unstructured.Unstructured{}
+	existingStream.SetGroupVersionKind(NatsStreamGVK)
+	err := n.runtimeClient.Get(n.ctx, client.ObjectKeyFromObject(desiredStream), existingStream)
+	if errors.IsNotFound(err) {
+		n.log.Info("create the stream", "name", desiredStream.GetName(), "subject", topic)
+		return n.runtimeClient.Create(n.ctx, desiredStream)
+	} else if err != nil {
+		return err
+	}
+
+	// only compare the desired fields, the others are defaulted by the stream CRD
+	existingSpec, _, err := unstructured.NestedMap(existingStream.Object, "spec")
+	if err != nil {
+		return err
+	}
+	desiredSpec := desiredStream.Object["spec"].(map[string]interface{})
+	updated := false
+	for key, val := range desiredSpec {
+		if !isEqualJSON(existingSpec[key], val) {
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		return nil
+	}
+	if existingSpec == nil {
+		existingSpec = map[string]interface{}{}
+	}
+	for key, val := range desiredSpec {
+		existingSpec[key] = val
+	}
+	existingStream.Object["spec"] = existingSpec
+	n.log.Info("update the stream", "name", desiredStream.GetName(), "subject", topic)
+	return n.runtimeClient.Update(n.ctx, existingStream)
+}
+
+func (n *NatsTransporter) newStream(topic string) *unstructured.Unstructured {
+	replicas := int64(1)
+	if n.natsConfig.StreamReplicas > 0 {
+		replicas = int64(n.natsConfig.StreamReplicas)
+	}
+	spec := map[string]interface{}{
+		"name":     GetStreamName(topic),
+		"subjects": []interface{}{topic},
+		"storage":  "file",
+		"replicas": replicas,
+		// the empty max age means the messages are kept without the age limit
+		"maxAge": n.natsConfig.MaxAge,
+	}
+
+	stream := &unstructured.Unstructured{}
+	stream.SetGroupVersionKind(NatsStreamGVK)
+	stream.SetName(GetStreamName(topic))
+	stream.SetNamespace(n.namespace)
+	stream.SetLabels(map[string]string{
+		constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+	})
+	stream.Object["spec"] = spec
+	return stream
+}
+
+// GetStreamName returns the stream name of the topic, the stream name can't contain the '.', '*' and '>'
+func GetStreamName(topic string) string {
+	return strings.NewReplacer(".", "-", "*", "", ">", "").Replace(topic)
+}
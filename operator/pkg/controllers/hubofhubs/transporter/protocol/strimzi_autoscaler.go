@@ -0,0 +1,343 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"github.com/Shopify/sarama"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	operatorconstants "github.com/stolostron/multicluster-global-hub/operator/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+const (
+	// the default consumer group of the manager on the status topics
+	ManagerConsumerGroupID = "multicluster-global-hub-manager"
+
+	// the interval to check the consumer lag of the manager
+	AutoscalingInterval        = 1 * time.Minute
+	DefaultAutoscalingCooldown = 15 * time.Minute
+
+	// the annotations of the kafka cluster record the scaling step, which doubles the broker resources of the mgh
+	// for each step, the time of the last scaling decision, and the time of the last rebalance request
+	autoscalingStepAnnotation      = "global-hub.open-cluster-management.io/autoscaling-step"
+	autoscalingTimeAnnotation      = "global-hub.open-cluster-management.io/autoscaling-time"
+	autoscalingRebalanceAnnotation = "global-hub.open-cluster-management.io/autoscaling-rebalance"
+)
+
+type autoscalingAction string
+
+const (
+	autoscalingNone      autoscalingAction = ""
+	autoscalingScaleUp   autoscalingAction = "ScaleUp"
+	autoscalingScaleDown autoscalingAction = "ScaleDown"
+	autoscalingRebalance autoscalingAction = "Rebalance"
+)
+
+// AutoscalingEvent is the scaling decision of the kafka brokers, it's recorded as an event of the mgh
+type AutoscalingEvent struct {
+	Type    string
+	Reason  string
+	Message string
+}
+
+// getKafkaAutoscaling returns the autoscaling of the kafka brokers, or nil if it isn't enabled
+func getKafkaAutoscaling(mgh *operatorv1alpha4.MulticlusterGlobalHub) *operatorv1alpha4.KafkaAutoscaling {
+	if mgh.Spec.AdvancedConfig == nil || mgh.Spec.AdvancedConfig.Kafka == nil {
+		return nil
+	}
+	return mgh.Spec.AdvancedConfig.Kafka.Autoscaling
+}
+
+// getAutoscalingStep returns the scaling step recorded in the annotations of the kafka cluster
+func getAutoscalingStep(annotations map[string]string) int {
+	step, err := strconv.Atoi(annotations[autoscalingStepAnnotation])
+	if err != nil || step < 0 {
+		return 0
+	}
+	return step
+}
+
+// EnsureAutoscaling scales the resources of the kafka brokers by the consumer lag of the manager. The resources are
+// doubled when the lag exceeds the threshold, until they reach the upper bound, then a rebalance is requested if
+// it's enabled. The resources are halved when the lag drops below half of the threshold. It returns the scaling
+// decision, or nil if nothing is changed
+func (k *strimziTransporter) EnsureAutoscaling(conn *transport.KafkaConnCredential) (*AutoscalingEvent, error) {
+	autoscaling := getKafkaAutoscaling(k.mgh)
+	// the resources of the existing kafka cluster are owned by its operator
+	if autoscaling == nil || k.existingCluster {
+		return nil, nil
+	}
+	cooldown := DefaultAutoscalingCooldown
+	if autoscaling.CooldownPeriod != "" {
+		var err error
+		cooldown, err = time.ParseDuration(autoscaling.CooldownPeriod)
+		if err != nil {
+			return nil, fmt.Errorf("invalid autoscaling cooldown period %s: %w", autoscaling.CooldownPeriod, err)
+		}
+	}
+
+	kafkaCluster := &kafkav1beta2.Kafka{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      k.kafkaClusterName,
+		Namespace: k.kafkaClusterNamespace,
+	}, kafkaCluster)
+	if err != nil {
+		return nil, err
+	}
+	annotations := kafkaCluster.GetAnnotations()
+	lastTime, err := time.Parse(time.RFC3339, annotations[autoscalingTimeAnnotation])
+	if err == nil && time.Since(lastTime) < cooldown {
+		return nil, nil
+	}
+
+	lag, err := getConsumerGroupLag(conn, ManagerConsumerGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the consumer lag of the manager: %w", err)
+	}
+
+	step := getAutoscalingStep(annotations)
+	baseResources := utils.GetResources(operatorconstants.Kafka, k.mgh.Spec.AdvancedConfig)
+	atMax := equalResources(scaleResources(baseResources, autoscaling.MaxResources, step),
+		scaleResources(baseResources, autoscaling.MaxResources, step+1))
+	nextStep, action := nextAutoscalingStep(lag, autoscaling.LagThreshold, step, atMax)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	patchAnnotations := map[string]string{autoscalingTimeAnnotation: now}
+	var autoscalingEvent *AutoscalingEvent
+	switch action {
+	case autoscalingScaleUp, autoscalingScaleDown:
+		patchAnnotations[autoscalingStepAnnotation] = strconv.Itoa(nextStep)
+		autoscalingEvent = &AutoscalingEvent{
+			Type:   corev1.EventTypeNormal,
+			Reason: "Kafka" + string(action),
+			Message: fmt.Sprintf("the consumer lag of the manager is %d with the threshold %d, scale the kafka "+
+				"brokers from the step %d to %d", lag, autoscaling.LagThreshold, step, nextStep),
+		}
+	case autoscalingRebalance:
+		if !autoscaling.Rebalance || k.mgh.Spec.DataLayer.Kafka.CruiseControl == nil {
+			autoscalingEvent = &AutoscalingEvent{
+				Type:   corev1.EventTypeWarning,
+				Reason: "KafkaMaxResourcesReached",
+				Message: fmt.Sprintf("the consumer lag of the manager is %d with the threshold %d, but the kafka "+
+					"brokers reach the max resources", lag, autoscaling.LagThreshold),
+			}
+			break
+		}
+		patchAnnotations[autoscalingRebalanceAnnotation] = now
+		autoscalingEvent = &AutoscalingEvent{
+			Type:   corev1.EventTypeNormal,
+			Reason: "KafkaRebalanceRequested",
+			Message: fmt.Sprintf("the consumer lag of the manager is %d with the threshold %d, and the kafka "+
+				"brokers reach the max resources, request a rebalance", lag, autoscaling.LagThreshold),
+		}
+	default:
+		k.log.V(2).Info("the kafka brokers aren't scaled", "lag", lag, "step", step)
+		return nil, nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": patchAnnotations},
+	})
+	if err != nil {
+		return nil, err
+	}
+	k.log.Info("scale the kafka brokers", "action", action, "lag", lag, "step", step, "nextStep", nextStep)
+	if err := k.runtimeClient.Patch(k.ctx, kafkaCluster, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return nil, err
+	}
+	if action == autoscalingScaleUp || action == autoscalingScaleDown {
+		if err, _ := k.CreateUpdateKafkaCluster(k.mgh); err != nil {
+			return nil, err
+		}
+	}
+	return autoscalingEvent, nil
+}
+
+// nextAutoscalingStep returns the next scaling step and the action by the consumer lag
+func nextAutoscalingStep(lag, threshold int64, step int, atMax bool) (int, autoscalingAction) {
+	switch {
+	case lag > threshold && atMax:
+		return step, autoscalingRebalance
+	case lag > threshold:
+		return step + 1, autoscalingScaleUp
+	case lag < threshold/2 && step > 0:
+		return step - 1, autoscalingScaleDown
+	}
+	return step, autoscalingNone
+}
+
+// getAutoscalingRebalanceTrigger returns the time of the last rebalance requested by the autoscaler
+func (k *strimziTransporter) getAutoscalingRebalanceTrigger() (string, error) {
+	if getKafkaAutoscaling(k.mgh) == nil {
+		return "", nil
+	}
+	kafkaCluster := &kafkav1beta2.Kafka{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      k.kafkaClusterName,
+		Namespace: k.kafkaClusterNamespace,
+	}, kafkaCluster)
+	if err != nil {
+		return "", err
+	}
+	return kafkaCluster.GetAnnotations()[autoscalingRebalanceAnnotation], nil
+}
+
+// getScaledKafkaResources returns the broker resources of the scaling step
+func (k *strimziTransporter) getScaledKafkaResources(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	autoscaling *operatorv1alpha4.KafkaAutoscaling, step int,
+) *kafkav1beta2.KafkaSpecKafkaResources {
+	baseResources := utils.GetResources(operatorconstants.Kafka, mgh.Spec.AdvancedConfig)
+	return k.toKafkaResources(scaleResources(baseResources, autoscaling.MaxResources, step))
+}
+
+// scaleResources doubles the resources for each step, the resources are capped by the max resources, and the ones
+// which aren't in the max resources aren't scaled
+func scaleResources(base *corev1.ResourceRequirements, maxResources *operatorv1alpha4.ResourceRequirements,
+	step int,
+) *corev1.ResourceRequirements {
+	scaled := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{},
+		Limits:   corev1.ResourceList{},
+	}
+	var maxRequests, maxLimits corev1.ResourceList
+	if maxResources != nil {
+		maxRequests, maxLimits = maxResources.Requests, maxResources.Limits
+	}
+	for name, quantity := range base.Requests {
+		scaled.Requests[name] = scaleQuantity(quantity, maxRequests, name, step)
+	}
+	for name, quantity := range base.Limits {
+		scaled.Limits[name] = scaleQuantity(quantity, maxLimits, name, step)
+	}
+	return scaled
+}
+
+func scaleQuantity(quantity resource.Quantity, maxList corev1.ResourceList, name corev1.ResourceName,
+	step int,
+) resource.Quantity {
+	maxQuantity, ok := maxList[name]
+	if !ok || step == 0 {
+		return quantity
+	}
+	scaled := quantity.DeepCopy()
+	for i := 0; i < step && scaled.Cmp(maxQuantity) < 0; i++ {
+		scaled.Add(scaled)
+	}
+	if scaled.Cmp(maxQuantity) > 0 {
+		return maxQuantity.DeepCopy()
+	}
+	return scaled
+}
+
+func equalResources(a, b *corev1.ResourceRequirements) bool {
+	return equalResourceList(a.Requests, b.Requests) && equalResourceList(a.Limits, b.Limits)
+}
+
+func equalResourceList(a, b corev1.ResourceList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, quantity := range a {
+		other, ok := b[name]
+		if !ok || quantity.Cmp(other) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// getConsumerGroupLag returns the total lag of the consumer group on the partitions it has committed to
+func getConsumerGroupLag(conn *transport.KafkaConnCredential, groupID string) (int64, error) {
+	tlsConfig, err := newConnTLSConfig(conn)
+	if err != nil {
+		return 0, err
+	}
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Version = sarama.V2_0_0_0
+	saramaConfig.Net.TLS.Enable = true
+	saramaConfig.Net.TLS.Config = tlsConfig
+
+	kafkaClient, err := sarama.NewClient([]string{conn.BootstrapServer}, saramaConfig)
+	if err != nil {
+		return 0, err
+	}
+	admin, err := sarama.NewClusterAdminFromClient(kafkaClient)
+	if err != nil {
+		_ = kafkaClient.Close()
+		return 0, err
+	}
+	// the client is closed with the admin
+	defer func() {
+		_ = admin.Close()
+	}()
+
+	offsets, err := admin.ListConsumerGroupOffsets(groupID, nil)
+	if err != nil {
+		return 0, err
+	}
+	if offsets.Err != sarama.ErrNoError {
+		return 0, offsets.Err
+	}
+	lag := int64(0)
+	for topic, partitions := range offsets.Blocks {
+		for partition, block := range partitions {
+			// the partition has no committed offset
+			if block.Offset < 0 {
+				continue
+			}
+			newest, err := kafkaClient.GetOffset(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				return 0, err
+			}
+			if newest > block.Offset {
+				lag += newest - block.Offset
+			}
+		}
+	}
+	return lag, nil
+}
+
+// newConnTLSConfig returns the tls config with the CA and the client certificate of the connection
+func newConnTLSConfig(conn *transport.KafkaConnCredential) (*tls.Config, error) {
+	caCert, err := base64.StdEncoding.DecodeString(conn.CACert)
+	if err != nil {
+		return nil, err
+	}
+	clientCert, err := base64.StdEncoding.DecodeString(conn.ClientCert)
+	if err != nil {
+		return nil, err
+	}
+	clientKey, err := base64.StdEncoding.DecodeString(conn.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(clientCert, clientKey)
+	if err != nil {
+		return nil, err
+	}
+	caCertPool := x509.NewCertPool()
+	if !caCertPool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to load the kafka CA certificate")
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      caCertPool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
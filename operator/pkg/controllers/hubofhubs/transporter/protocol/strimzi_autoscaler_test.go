@@ -0,0 +1,76 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestNextAutoscalingStep(t *testing.T) {
+	cases := []struct {
+		name       string
+		lag        int64
+		step       int
+		atMax      bool
+		wantStep   int
+		wantAction autoscalingAction
+	}{
+		{name: "scale up", lag: 2000, step: 0, wantStep: 1, wantAction: autoscalingScaleUp},
+		{name: "rebalance at the max resources", lag: 2000, step: 2, atMax: true, wantStep: 2,
+			wantAction: autoscalingRebalance},
+		{name: "scale down", lag: 100, step: 2, wantStep: 1, wantAction: autoscalingScaleDown},
+		{name: "keep the base resources", lag: 100, step: 0, wantStep: 0, wantAction: autoscalingNone},
+		{name: "keep the step between the thresholds", lag: 800, step: 1, wantStep: 1, wantAction: autoscalingNone},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			step, action := nextAutoscalingStep(c.lag, 1000, c.step, c.atMax)
+			assert.Equal(t, c.wantStep, step)
+			assert.Equal(t, c.wantAction, action)
+		})
+	}
+}
+
+func TestScaleResources(t *testing.T) {
+	base := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("25m"),
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("8Gi"),
+		},
+	}
+	maxResources := &operatorv1alpha4.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("24Gi"),
+		},
+	}
+
+	assert.True(t, equalResources(base, scaleResources(base, maxResources, 0)))
+
+	scaled := scaleResources(base, maxResources, 1)
+	assert.Equal(t, "8Gi", scaled.Requests.Memory().String())
+	assert.Equal(t, "16Gi", scaled.Limits.Memory().String())
+	// the cpu isn't in the max resources
+	assert.Equal(t, "25m", scaled.Requests.Cpu().String())
+
+	// the resources are capped by the max resources
+	scaled = scaleResources(base, maxResources, 2)
+	assert.Equal(t, "16Gi", scaled.Requests.Memory().String())
+	assert.Equal(t, "24Gi", scaled.Limits.Memory().String())
+	assert.True(t, equalResources(scaled, scaleResources(base, maxResources, 3)))
+}
+
+func TestGetAutoscalingStep(t *testing.T) {
+	assert.Equal(t, 0, getAutoscalingStep(nil))
+	assert.Equal(t, 0, getAutoscalingStep(map[string]string{autoscalingStepAnnotation: "invalid"}))
+	assert.Equal(t, 2, getAutoscalingStep(map[string]string{autoscalingStepAnnotation: "2"}))
+}
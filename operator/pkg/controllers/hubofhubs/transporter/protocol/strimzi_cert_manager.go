@@ -0,0 +1,372 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	certutil "k8s.io/client-go/util/cert"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+var CertificateGVK = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+const (
+	ClusterCA = "cluster-ca"
+	ClientsCA = "clients-ca"
+
+	// the strimzi operator renews the certificates signed by the CA once the generations are increased
+	strimziCACertGenerationAnnotation = "strimzi.io/ca-cert-generation"
+	strimziCAKeyGenerationAnnotation  = "strimzi.io/ca-key-generation"
+
+	// the suffix of the secrets issued by the cert-manager
+	certManagerSecretSuffix = "-cert-manager"
+)
+
+// ensureCertManager issues the cluster CA and the clients CA by the cert-manager issuers of the mgh, and copies them
+// into the CA secrets of the strimzi operator. The kafka cluster is configured to use the CAs instead of generating
+// them. The CAs are renewed by the cert-manager, the previous CA certificate is still trusted until it expires, so
+// the clients are able to reconnect before they get the certificates signed by the new CA
+func (k *strimziTransporter) ensureCertManager(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	certManager := mgh.Spec.DataLayer.Kafka.CertManager
+	if certManager == nil {
+		return nil
+	}
+	clientsIssuerRef := certManager.IssuerRef
+	if certManager.ClientsIssuerRef != nil {
+		clientsIssuerRef = *certManager.ClientsIssuerRef
+	}
+	issuerRefs := map[string]operatorv1alpha4.CertManagerIssuerRef{
+		ClusterCA: certManager.IssuerRef,
+		ClientsCA: clientsIssuerRef,
+	}
+	for _, ca := range []string{ClusterCA, ClientsCA} {
+		if err := k.ensureCACertificate(ca, issuerRefs[ca], certManager); err != nil {
+			return fmt.Errorf("failed to ensure the certificate of the %s: %w", ca, err)
+		}
+		if err := k.syncCASecrets(ca); err != nil {
+			return fmt.Errorf("failed to sync the secrets of the %s: %w", ca, err)
+		}
+	}
+	return nil
+}
+
+// ensureCACertificate creates the cert-manager certificate of the CA. The private key isn't rotated by the renewal,
+// so the strimzi operator only needs to renew the certificates signed by the CA
+func (k *strimziTransporter) ensureCACertificate(ca string, issuerRef operatorv1alpha4.CertManagerIssuerRef,
+	certManager *operatorv1alpha4.KafkaCertManager,
+) error {
+	desiredIssuerRef := map[string]interface{}{
+		"name": issuerRef.Name,
+		"kind": "Issuer",
+	}
+	if issuerRef.Kind != "" {
+		desiredIssuerRef["kind"] = issuerRef.Kind
+	}
+	if issuerRef.Group != "" {
+		desiredIssuerRef["group"] = issuerRef.Group
+	}
+	desiredSpec := map[string]interface{}{
+		"isCA":       true,
+		"commonName": fmt.Sprintf("%s-%s", k.kafkaClusterName, ca),
+		"secretName": certManagerSecretName(k.kafkaClusterName, ca),
+		"issuerRef":  desiredIssuerRef,
+		"privateKey": map[string]interface{}{
+			"algorithm":      "RSA",
+			"size":           int64(4096),
+			"rotationPolicy": "Never",
+		},
+		"secretTemplate": map[string]interface{}{
+			"labels": map[string]interface{}{
+				constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+			},
+		},
+	}
+	if certManager.Duration != "" {
+		desiredSpec["duration"] = certManager.Duration
+	}
+	if certManager.RenewBefore != "" {
+		desiredSpec["renewBefore"] = certManager.RenewBefore
+	}
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(CertificateGVK)
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      certManagerSecretName(k.kafkaClusterName, ca),
+		Namespace: k.kafkaClusterNamespace,
+	}, certificate)
+	if errors.IsNotFound(err) {
+		certificate.SetName(certManagerSecretName(k.kafkaClusterName, ca))
+		certificate.SetNamespace(k.kafkaClusterNamespace)
+		certificate.SetLabels(map[string]string{
+			constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+		})
+		certificate.Object["spec"] = desiredSpec
+		k.log.Info("create the certificate of the kafka CA", "name", certificate.GetName())
+		return k.runtimeClient.Create(k.ctx, certificate)
+	} else if err != nil {
+		return err
+	}
+
+	existingSpec, _, _ := unstructured.NestedMap(certificate.Object, "spec")
+	if isEqualJSON(existingSpec, desiredSpec) {
+		return nil
+	}
+	certificate.Object["spec"] = desiredSpec
+	k.log.Info("update the certificate of the kafka CA", "name", certificate.GetName())
+	return k.runtimeClient.Update(k.ctx, certificate)
+}
+
+// syncCASecrets copies the CA issued by the cert-manager to the "<cluster>-<ca>-cert" and "<cluster>-<ca>" secrets
+// of the strimzi operator, and increases the generations once the CA is renewed. If the private key is replaced,
+// the previous certificate is kept as "ca-<expiry>.crt" until it expires
+func (k *strimziTransporter) syncCASecrets(ca string) error {
+	issuedSecret := &corev1.Secret{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      certManagerSecretName(k.kafkaClusterName, ca),
+		Namespace: k.kafkaClusterNamespace,
+	}, issuedSecret)
+	if err != nil {
+		return fmt.Errorf("the %s isn't issued by the cert-manager: %w", ca, err)
+	}
+	caCert, caKey := issuedSecret.Data[corev1.TLSCertKey], issuedSecret.Data[corev1.TLSPrivateKeyKey]
+	if len(caCert) == 0 || len(caKey) == 0 {
+		return fmt.Errorf("the %s isn't issued by the cert-manager", ca)
+	}
+
+	certSecret, err := k.getOrNewCASecret(fmt.Sprintf("%s-%s-cert", k.kafkaClusterName, ca))
+	if err != nil {
+		return err
+	}
+	keySecret, err := k.getOrNewCASecret(fmt.Sprintf("%s-%s", k.kafkaClusterName, ca))
+	if err != nil {
+		return err
+	}
+
+	certData, certChanged := rotateCACertData(certSecret.Data, caCert, time.Now())
+	keyChanged := !bytes.Equal(keySecret.Data["ca.key"], caKey)
+	if !certChanged && !keyChanged {
+		return nil
+	}
+
+	if keyChanged {
+		keySecret.Data = map[string][]byte{"ca.key": caKey}
+		increaseGeneration(keySecret, strimziCAKeyGenerationAnnotation)
+		if err := k.applyCASecret(keySecret); err != nil {
+			return err
+		}
+	}
+	certSecret.Data = certData
+	increaseGeneration(certSecret, strimziCACertGenerationAnnotation)
+	if err := k.applyCASecret(certSecret); err != nil {
+		return err
+	}
+	k.log.Info("the kafka CA is renewed by the cert-manager", "ca", ca, "keyReplaced", keyChanged)
+	return nil
+}
+
+func (k *strimziTransporter) getOrNewCASecret(name string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      name,
+		Namespace: k.kafkaClusterNamespace,
+	}, secret)
+	if errors.IsNotFound(err) {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: k.kafkaClusterNamespace,
+			},
+		}, nil
+	}
+	return secret, err
+}
+
+func (k *strimziTransporter) applyCASecret(secret *corev1.Secret) error {
+	// the strimzi operator only uses the CA secrets with the cluster labels
+	labels := secret.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels["strimzi.io/kind"] = "Kafka"
+	labels["strimzi.io/cluster"] = k.kafkaClusterName
+	labels[constants.GlobalHubOwnerLabelKey] = constants.GlobalHubOwnerLabelVal
+	secret.SetLabels(labels)
+	if secret.ResourceVersion == "" {
+		return k.runtimeClient.Create(k.ctx, secret)
+	}
+	return k.runtimeClient.Update(k.ctx, secret)
+}
+
+// loadCertManagerClusterCA replaces the CA of the connection with the CA certificates of the cluster CA secret, which
+// includes the previous CA during the renewal, and the CA of the issuer
+func (k *strimziTransporter) loadCertManagerClusterCA(credential *transport.KafkaConnCredential) error {
+	certSecret := &corev1.Secret{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      GetClusterCASecret(k.kafkaClusterName),
+		Namespace: k.kafkaClusterNamespace,
+	}, certSecret)
+	if err != nil {
+		return err
+	}
+	issuedSecret := &corev1.Secret{}
+	err = k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      certManagerSecretName(k.kafkaClusterName, ClusterCA),
+		Namespace: k.kafkaClusterNamespace,
+	}, issuedSecret)
+	if err != nil {
+		return err
+	}
+	credential.CACert = base64.StdEncoding.EncodeToString(caBundle(certSecret.Data, issuedSecret.Data["ca.crt"]))
+	return nil
+}
+
+// patchCertManagerCA disables the CAs generated by the strimzi operator if the cert-manager is enabled
+func (k *strimziTransporter) patchCertManagerCA(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	existingKafka := &unstructured.Unstructured{}
+	existingKafka.SetGroupVersionKind(KafkaGVK)
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      k.kafkaClusterName,
+		Namespace: k.kafkaClusterNamespace,
+	}, existingKafka)
+	if err != nil {
+		return err
+	}
+
+	var desiredCA map[string]interface{}
+	if mgh.Spec.DataLayer.Kafka.CertManager != nil {
+		desiredCA = map[string]interface{}{"generateCertificateAuthority": false}
+	}
+	existingClusterCA, _, _ := unstructured.NestedMap(existingKafka.Object, "spec", "clusterCa")
+	existingClientsCA, _, _ := unstructured.NestedMap(existingKafka.Object, "spec", "clientsCa")
+	if isEqualJSON(existingClusterCA, desiredCA) && isEqualJSON(existingClientsCA, desiredCA) {
+		return nil
+	}
+
+	// the nil values remove the settings, so the strimzi operator renews the existing CAs
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"clusterCa": desiredCA,
+			"clientsCa": desiredCA,
+		},
+	}
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	k.log.Info("update the CAs of the kafka cluster", "certManager", desiredCA != nil)
+	return k.runtimeClient.Patch(k.ctx, existingKafka, client.RawPatch(types.MergePatchType, patchData))
+}
+
+func certManagerSecretName(kafkaClusterName, ca string) string {
+	return fmt.Sprintf("%s-%s%s", kafkaClusterName, ca, certManagerSecretSuffix)
+}
+
+// rotateCACertData returns the data of the CA cert secret with the new certificate. The replaced certificate is
+// kept with the key "ca-<expiry>.crt" if it isn't expired, and the expired ones are removed. The other keys, e.g. the
+// "ca.p12" generated by the strimzi operator, are dropped since they're stale once the certificate is replaced
+func rotateCACertData(existing map[string][]byte, caCert []byte, now time.Time) (map[string][]byte, bool) {
+	data := map[string][]byte{}
+	changed := false
+	for key, val := range existing {
+		if key == "ca.crt" || !isCertFile(key) {
+			continue
+		}
+		if certExpired(val, now) {
+			changed = true
+			continue
+		}
+		data[key] = val
+	}
+	data["ca.crt"] = caCert
+
+	previous := existing["ca.crt"]
+	if bytes.Equal(previous, caCert) {
+		return data, changed
+	}
+	if len(previous) > 0 && !certExpired(previous, now) {
+		if certs, err := certutil.ParseCertsPEM(previous); err == nil {
+			data[fmt.Sprintf("ca-%s.crt", certs[0].NotAfter.UTC().Format("2006-01-02T15-04-05Z"))] = previous
+		}
+	}
+	return data, true
+}
+
+// caBundle concatenates the current and the previous CA certificates, and the CA of the issuer
+func caBundle(certData map[string][]byte, issuerCA []byte) []byte {
+	keys := []string{}
+	for key := range certData {
+		if key != "ca.crt" && isCertFile(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	keys = append([]string{"ca.crt"}, keys...)
+
+	bundle := []byte{}
+	for _, key := range keys {
+		bundle = appendPEM(bundle, certData[key])
+	}
+	if !bytes.Contains(bundle, bytes.TrimSpace(issuerCA)) {
+		bundle = appendPEM(bundle, issuerCA)
+	}
+	return bundle
+}
+
+func appendPEM(bundle, cert []byte) []byte {
+	cert = bytes.TrimSpace(cert)
+	if len(cert) == 0 {
+		return bundle
+	}
+	bundle = append(bundle, cert...)
+	return append(bundle, '\n')
+}
+
+func isCertFile(key string) bool {
+	return strings.HasPrefix(key, "ca") && strings.HasSuffix(key, ".crt")
+}
+
+func certExpired(certPEM []byte, now time.Time) bool {
+	certs, err := certutil.ParseCertsPEM(certPEM)
+	if err != nil {
+		return true
+	}
+	return now.After(certs[0].NotAfter)
+}
+
+func increaseGeneration(secret *corev1.Secret, annotation string) {
+	annotations := secret.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	generation := 0
+	if val, found := annotations[annotation]; found {
+		if current, err := strconv.Atoi(val); err == nil {
+			generation = current + 1
+		}
+	}
+	annotations[annotation] = strconv.Itoa(generation)
+	secret.SetAnnotations(annotations)
+}
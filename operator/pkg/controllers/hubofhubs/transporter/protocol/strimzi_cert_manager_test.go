@@ -0,0 +1,100 @@
+package protocol
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRotateCACertData(t *testing.T) {
+	now := time.Now()
+	previousCA := newTestCACert(t, now.Add(24*time.Hour))
+	expiredCA := newTestCACert(t, now.Add(-time.Hour))
+	currentCA := newTestCACert(t, now.Add(48*time.Hour))
+
+	// the first CA issued by the cert-manager
+	data, changed := rotateCACertData(nil, currentCA, now)
+	assert.True(t, changed)
+	assert.Equal(t, map[string][]byte{"ca.crt": currentCA}, data)
+
+	// nothing is changed
+	data, changed = rotateCACertData(data, currentCA, now)
+	assert.False(t, changed)
+	assert.Equal(t, 1, len(data))
+
+	// the previous CA is kept until it expires
+	data, changed = rotateCACertData(map[string][]byte{"ca.crt": previousCA}, currentCA, now)
+	assert.True(t, changed)
+	assert.Equal(t, 2, len(data))
+	assert.Equal(t, currentCA, data["ca.crt"])
+	for key, val := range data {
+		if key != "ca.crt" {
+			assert.True(t, strings.HasPrefix(key, "ca-"))
+			assert.Equal(t, previousCA, val)
+		}
+	}
+
+	// the expired CA is removed
+	data, changed = rotateCACertData(map[string][]byte{
+		"ca.crt":                      currentCA,
+		"ca-2020-01-01T00-00-00Z.crt": expiredCA,
+		"ca.p12":                      []byte("p12"),
+	}, currentCA, now)
+	assert.True(t, changed)
+	assert.Equal(t, map[string][]byte{"ca.crt": currentCA}, data)
+}
+
+func TestCABundle(t *testing.T) {
+	now := time.Now()
+	previousCA := newTestCACert(t, now.Add(24*time.Hour))
+	currentCA := newTestCACert(t, now.Add(48*time.Hour))
+	issuerCA := newTestCACert(t, now.Add(96*time.Hour))
+
+	bundle := caBundle(map[string][]byte{
+		"ca.crt":                      currentCA,
+		"ca-2030-01-01T00-00-00Z.crt": previousCA,
+		"ca.password":                 []byte("password"),
+	}, issuerCA)
+	assert.Equal(t, 3, strings.Count(string(bundle), "BEGIN CERTIFICATE"))
+	assert.True(t, strings.HasPrefix(string(bundle), string(currentCA)))
+
+	// the issuer CA isn't duplicated if the CA is self-signed
+	bundle = caBundle(map[string][]byte{"ca.crt": currentCA}, currentCA)
+	assert.Equal(t, 1, strings.Count(string(bundle), "BEGIN CERTIFICATE"))
+}
+
+func TestIncreaseGeneration(t *testing.T) {
+	secret := &corev1.Secret{}
+	increaseGeneration(secret, strimziCACertGenerationAnnotation)
+	assert.Equal(t, "0", secret.Annotations[strimziCACertGenerationAnnotation])
+	increaseGeneration(secret, strimziCACertGenerationAnnotation)
+	assert.Equal(t, "1", secret.Annotations[strimziCACertGenerationAnnotation])
+	assert.Equal(t, "", secret.Annotations[strimziCAKeyGenerationAnnotation])
+}
+
+func newTestCACert(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(notAfter.UnixNano()),
+		Subject:               pkix.Name{CommonName: "kafka-cluster-ca"},
+		NotBefore:             notAfter.Add(-72 * time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+// LegacyConsumerGroupAnnotation is the consumer group of the agent before the groups are scoped to the managed hubs.
+// The kafka user keeps reading it, so the agent resumes from its offsets, until the annotation is removed
+const LegacyConsumerGroupAnnotation = "global-hub.open-cluster-management.io/legacy-consumer-group"
+
+// legacyConsumerGroup returns the legacy consumer group of the existing kafka user. The users granted with the
+// wildcard group are migrated from the group named by the cluster, which is then kept in the annotation
+func legacyConsumerGroup(kafkaUser *kafkav1beta2.KafkaUser, clusterName string) string {
+	if group, found := kafkaUser.GetAnnotations()[LegacyConsumerGroupAnnotation]; found {
+		return group
+	}
+	if kafkaUser.Spec == nil || kafkaUser.Spec.Authorization == nil {
+		return ""
+	}
+	for _, acl := range kafkaUser.Spec.Authorization.Acls {
+		if acl.Resource.Type == kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResourceTypeGroup &&
+			acl.Resource.Name != nil && *acl.Resource.Name == "*" {
+			return clusterName
+		}
+	}
+	return ""
+}
+
+// getLegacyConsumerGroup returns the legacy consumer group of the managed hub, it's empty once the migration is done
+func (k *strimziTransporter) getLegacyConsumerGroup(clusterName string) (string, error) {
+	kafkaUser := &kafkav1beta2.KafkaUser{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      config.GetKafkaUserName(clusterName),
+		Namespace: k.kafkaClusterNamespace,
+	}, kafkaUser)
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return kafkaUser.GetAnnotations()[LegacyConsumerGroupAnnotation], nil
+}
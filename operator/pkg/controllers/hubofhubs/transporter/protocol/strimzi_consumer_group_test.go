@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"testing"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLegacyConsumerGroup(t *testing.T) {
+	newKafkaUser := func(acls ...kafkav1beta2.KafkaUserSpecAuthorizationAclsElem) *kafkav1beta2.KafkaUser {
+		return &kafkav1beta2.KafkaUser{
+			Spec: &kafkav1beta2.KafkaUserSpec{
+				Authorization: &kafkav1beta2.KafkaUserSpecAuthorization{
+					Type: kafkav1beta2.KafkaUserSpecAuthorizationTypeSimple,
+					Acls: acls,
+				},
+			},
+		}
+	}
+
+	// the user created with the scoped consumer group isn't migrated
+	kafkaUser := newKafkaUser(ConsumeGroupReadACL("global-hub-agent-hub1"), WriteTopicACL("gh-event.hub1"))
+	assert.Equal(t, "", legacyConsumerGroup(kafkaUser, "hub1"))
+
+	// the user granted with the wildcard group is migrated from the group named by the cluster
+	kafkaUser = newKafkaUser(ConsumeGroupReadACL("*"), WriteTopicACL("gh-event.hub1"))
+	assert.Equal(t, "hub1", legacyConsumerGroup(kafkaUser, "hub1"))
+
+	// the legacy group is kept in the annotation once the wildcard is removed
+	kafkaUser = newKafkaUser(ConsumeGroupReadACL("global-hub-agent-hub1"), ConsumeGroupReadACL("hub1"))
+	kafkaUser.ObjectMeta = metav1.ObjectMeta{
+		Annotations: map[string]string{LegacyConsumerGroupAnnotation: "hub1"},
+	}
+	assert.Equal(t, "hub1", legacyConsumerGroup(kafkaUser, "hub1"))
+
+	// the migration is done once the annotation is removed
+	kafkaUser.Annotations = nil
+	assert.Equal(t, "", legacyConsumerGroup(kafkaUser, "hub1"))
+}
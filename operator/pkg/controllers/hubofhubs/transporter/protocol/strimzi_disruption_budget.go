@@ -0,0 +1,57 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"fmt"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+// DefaultMaxUnavailable is the max unavailable pods of the brokers and the zookeeper nodes, the strimzi operator rolls
+// the pods one by one, so the drained nodes shouldn't take down more than one of them either
+const DefaultMaxUnavailable int32 = 1
+
+// setDisruptionBudget renders the pod disruption budgets of the brokers and the zookeeper nodes into the strimzi
+// templates, the strimzi operator creates and updates the PodDisruptionBudget resources with them
+func (k *strimziTransporter) setDisruptionBudget(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	kafkaCluster *kafkav1beta2.Kafka,
+) error {
+	kafkaMaxUnavailable, zookeeperMaxUnavailable := getMaxUnavailable(mgh.Spec.DataLayer.Kafka.DisruptionBudget)
+	budget := map[string]interface{}{
+		"kafka":     disruptionBudgetPatch(kafkaMaxUnavailable),
+		"zookeeper": disruptionBudgetPatch(zookeeperMaxUnavailable),
+	}
+	if err := mergeKafkaSpec(kafkaCluster, budget); err != nil {
+		return fmt.Errorf("failed to merge the pod disruption budgets into the kafka spec: %w", err)
+	}
+	return nil
+}
+
+// getMaxUnavailable returns the max unavailable brokers and zookeeper nodes, they're 1 if they aren't set
+func getMaxUnavailable(budget *operatorv1alpha4.KafkaDisruptionBudget) (int32, int32) {
+	kafkaMaxUnavailable, zookeeperMaxUnavailable := DefaultMaxUnavailable, DefaultMaxUnavailable
+	if budget == nil {
+		return kafkaMaxUnavailable, zookeeperMaxUnavailable
+	}
+	if budget.KafkaMaxUnavailable != nil {
+		kafkaMaxUnavailable = *budget.KafkaMaxUnavailable
+	}
+	if budget.ZookeeperMaxUnavailable != nil {
+		zookeeperMaxUnavailable = *budget.ZookeeperMaxUnavailable
+	}
+	return kafkaMaxUnavailable, zookeeperMaxUnavailable
+}
+
+func disruptionBudgetPatch(maxUnavailable int32) map[string]interface{} {
+	return map[string]interface{}{
+		"template": map[string]interface{}{
+			"podDisruptionBudget": map[string]interface{}{
+				"maxUnavailable": maxUnavailable,
+			},
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestSetDisruptionBudget(t *testing.T) {
+	k := &strimziTransporter{log: ctrl.Log.WithName("test")}
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	maxUnavailable := func(kafkaCluster *kafkav1beta2.Kafka, component string) int64 {
+		data, err := json.Marshal(kafkaCluster.Spec)
+		assert.NoError(t, err)
+		spec := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(data, &spec))
+		value, found, err := unstructured.NestedFieldNoCopy(spec, component, "template", "podDisruptionBudget",
+			"maxUnavailable")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		maxUnavailable, ok := value.(float64)
+		assert.True(t, ok, "the maxUnavailable of the %s isn't a number: %v", component, value)
+		return int64(maxUnavailable)
+	}
+
+	// only one broker and one zookeeper node are evicted at the same time by default
+	kafkaCluster := newDisruptionBudgetKafka()
+	assert.NoError(t, k.setDisruptionBudget(mgh, kafkaCluster))
+	assert.EqualValues(t, 1, maxUnavailable(kafkaCluster, "kafka"))
+	assert.EqualValues(t, 1, maxUnavailable(kafkaCluster, "zookeeper"))
+
+	// the eviction of the brokers is blocked
+	kafkaMaxUnavailable := int32(0)
+	mgh.Spec.DataLayer.Kafka.DisruptionBudget = &operatorv1alpha4.KafkaDisruptionBudget{
+		KafkaMaxUnavailable: &kafkaMaxUnavailable,
+	}
+	kafkaCluster = newDisruptionBudgetKafka()
+	assert.NoError(t, k.setDisruptionBudget(mgh, kafkaCluster))
+	assert.EqualValues(t, 0, maxUnavailable(kafkaCluster, "kafka"))
+	assert.EqualValues(t, 1, maxUnavailable(kafkaCluster, "zookeeper"))
+}
+
+// newDisruptionBudgetKafka returns the kafka with the required listeners and storages, so it's a valid kafka spec
+func newDisruptionBudgetKafka() *kafkav1beta2.Kafka {
+	return &kafkav1beta2.Kafka{
+		Spec: &kafkav1beta2.KafkaSpec{
+			Kafka: kafkav1beta2.KafkaSpecKafka{
+				Replicas: 3,
+				Listeners: []kafkav1beta2.KafkaSpecKafkaListenersElem{
+					{Name: "tls", Port: 9093, Tls: true, Type: kafkav1beta2.KafkaSpecKafkaListenersElemTypeRoute},
+				},
+				Storage: kafkav1beta2.KafkaSpecKafkaStorage{Type: kafkav1beta2.KafkaSpecKafkaStorageTypeEphemeral},
+			},
+			Zookeeper: kafkav1beta2.KafkaSpecZookeeper{
+				Replicas: 3,
+				Storage:  kafkav1beta2.KafkaSpecZookeeperStorage{Type: kafkav1beta2.KafkaSpecZookeeperStorageTypeEphemeral},
+			},
+		},
+	}
+}
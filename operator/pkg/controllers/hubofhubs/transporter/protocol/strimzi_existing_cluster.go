@@ -0,0 +1,82 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"fmt"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/deployer"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+// ensureExistingKafka only renders the global hub topic and user on the kafka cluster of the existing strimzi
+// operator, the subscription and the kafka cluster are owned by the customer
+func (k *strimziTransporter) ensureExistingKafka(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	if !config.GetKafkaResourceReady() {
+		return fmt.Errorf("the kafka crds of the existing strimzi operator are not ready")
+	}
+	kafkaCluster := &kafkav1beta2.Kafka{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      k.kafkaClusterName,
+		Namespace: k.kafkaClusterNamespace,
+	}, kafkaCluster)
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("the existing kafka cluster %s/%s is not found", k.kafkaClusterNamespace,
+			k.kafkaClusterName)
+	} else if err != nil {
+		return err
+	}
+
+	if err := k.renderKafkaResources(mgh); err != nil {
+		return err
+	}
+
+	if !k.waitReady {
+		return nil
+	}
+	return k.kafkaClusterReady()
+}
+
+// deployExistingKafkaObjects deploys the objects without the owner reference, since it can't cross the namespaces.
+// They're labeled like the topics and the users of the managed hubs, so they're pruned once the mgh is deleted
+func deployExistingKafkaObjects(objects []*unstructured.Unstructured, kafkaDeployer deployer.Deployer) error {
+	for _, obj := range objects {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[constants.GlobalHubOwnerLabelKey] = constants.GlobalHubOwnerLabelVal
+		obj.SetLabels(labels)
+		if err := kafkaDeployer.Deploy(obj); err != nil {
+			return fmt.Errorf("failed to create/update the kafka object %s: %w", obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// hubListenerName returns the listener of the managed hubs, it's the listener specified for the existing kafka
+// cluster, otherwise the tls listener of the built-in kafka
+func hubListenerName(mgh *operatorv1alpha4.MulticlusterGlobalHub) string {
+	existing := mgh.Spec.DataLayer.Kafka.ExistingCluster
+	if existing != nil && existing.ListenerName != "" {
+		return existing.ListenerName
+	}
+	return TLSListenerName
+}
+
+// GetKafkaClusterName returns the name of the strimzi kafka cluster, the strimzi labels the secrets of the kafka
+// users with it
+func GetKafkaClusterName() string {
+	if existing := config.GetExistingKafkaCluster(); existing != nil {
+		return existing.Name
+	}
+	return KafkaClusterName
+}
@@ -0,0 +1,33 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestExistingClusterListenerName(t *testing.T) {
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{
+		Spec: operatorv1alpha4.MulticlusterGlobalHubSpec{
+			DataLayer: operatorv1alpha4.DataLayerConfig{
+				Kafka: operatorv1alpha4.KafkaConfig{TLSOnly: true},
+			},
+		},
+	}
+	assert.Equal(t, TLSListenerName, hubListenerName(mgh))
+	assert.Equal(t, InternalTLSListenerName, managerListenerName(mgh))
+
+	// the manager and the managed hubs connect to the same listener of the existing kafka cluster
+	mgh.Spec.DataLayer.Kafka.ExistingCluster = &operatorv1alpha4.KafkaExistingCluster{
+		Name:      "my-cluster",
+		Namespace: "kafka",
+	}
+	assert.Equal(t, TLSListenerName, hubListenerName(mgh))
+	assert.Equal(t, TLSListenerName, managerListenerName(mgh))
+
+	mgh.Spec.DataLayer.Kafka.ExistingCluster.ListenerName = "external"
+	assert.Equal(t, "external", hubListenerName(mgh))
+	assert.Equal(t, "external", managerListenerName(mgh))
+}
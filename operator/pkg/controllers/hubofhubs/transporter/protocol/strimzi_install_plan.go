@@ -0,0 +1,104 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	subv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+// ensureInstallPlanApproval approves the pending install plan of the kafka subscription if all its csvs are in the
+// approved versions, otherwise it reports the install plan to the mgh status, so it's approved manually. The install
+// plans aren't cached by the operator, so they're read from the api server directly
+func (k *strimziTransporter) ensureInstallPlanApproval(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	installPlan, err := k.getPendingInstallPlan(mgh)
+	if err != nil {
+		return err
+	}
+	if installPlan == nil {
+		if !config.ContainsCondition(mgh, config.CONDITION_TYPE_PENDING_INSTALL_PLAN) {
+			return nil
+		}
+		return config.DeleteCondition(k.ctx, k.runtimeClient, mgh, config.CONDITION_TYPE_PENDING_INSTALL_PLAN,
+			config.CONDITION_REASON_PENDING_INSTALL_PLAN)
+	}
+
+	csvNames := installPlan.Spec.ClusterServiceVersionNames
+	approved, err := csvVersionsApproved(csvNames, mgh.Spec.DataLayer.Kafka.Subscription.ApprovedVersions)
+	if err != nil {
+		return err
+	}
+	if approved {
+		k.log.Info("approve the kafka install plan", "name", installPlan.Name, "csvs", csvNames)
+		installPlan.Spec.Approved = true
+		return k.runtimeClient.Update(k.ctx, installPlan)
+	}
+	return config.SetConditionPendingInstallPlan(k.ctx, k.runtimeClient, mgh, fmt.Sprintf(
+		"the install plan %s of the kafka operator %s is waiting for the approval", installPlan.Name,
+		strings.Join(csvNames, ", ")))
+}
+
+// getPendingInstallPlan returns the install plan of the kafka subscription which requires the manual approval, it's
+// nil if the approval is automatic, or the install plan has been approved
+func (k *strimziTransporter) getPendingInstallPlan(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+) (*subv1alpha1.InstallPlan, error) {
+	if k.subInstallPlanApproval != subv1alpha1.ApprovalManual {
+		return nil, nil
+	}
+	sub := &subv1alpha1.Subscription{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{Name: k.subName, Namespace: mgh.Namespace}, sub)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status.InstallPlanRef == nil {
+		return nil, nil
+	}
+
+	installPlan := &subv1alpha1.InstallPlan{}
+	err = k.manager.GetAPIReader().Get(k.ctx, types.NamespacedName{
+		Name:      sub.Status.InstallPlanRef.Name,
+		Namespace: sub.Status.InstallPlanRef.Namespace,
+	}, installPlan)
+	if err != nil {
+		return nil, err
+	}
+	if installPlan.Spec.Approved || installPlan.Status.Phase != subv1alpha1.InstallPlanPhaseRequiresApproval {
+		return nil, nil
+	}
+	return installPlan, nil
+}
+
+// csvVersionsApproved returns true if the versions of all the csvs are in the approved range. The version is parsed
+// from the csv name, e.g. "amqstreams.v2.7.0-2", and its release suffix is ignored
+func csvVersionsApproved(csvNames []string, approvedVersions string) (bool, error) {
+	if approvedVersions == "" || len(csvNames) == 0 {
+		return false, nil
+	}
+	approvedRange, err := semver.ParseRange(approvedVersions)
+	if err != nil {
+		return false, fmt.Errorf("invalid approved versions %q: %w", approvedVersions, err)
+	}
+	for _, csvName := range csvNames {
+		index := strings.Index(csvName, ".v")
+		if index < 0 {
+			return false, nil
+		}
+		version, err := semver.ParseTolerant(csvName[index+2:])
+		if err != nil {
+			return false, nil
+		}
+		version.Pre, version.Build = nil, nil
+		if !approvedRange(version) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,31 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCsvVersionsApproved(t *testing.T) {
+	cases := []struct {
+		name             string
+		csvNames         []string
+		approvedVersions string
+		approved         bool
+	}{
+		{"no approved versions", []string{"amqstreams.v2.7.0-2"}, "", false},
+		{"in the range", []string{"amqstreams.v2.7.0-2"}, ">=2.7.0 <2.8.0", true},
+		{"out of the range", []string{"amqstreams.v2.8.0-0"}, ">=2.7.0 <2.8.0", false},
+		{"community", []string{"strimzi-cluster-operator.v0.40.0"}, "<0.41.0", true},
+		{"any csv out of the range", []string{"amqstreams.v2.7.0-2", "other.v3.0.0"}, "<2.8.0", false},
+		{"no version", []string{"amqstreams"}, "<2.8.0", false},
+	}
+	for _, c := range cases {
+		approved, err := csvVersionsApproved(c.csvNames, c.approvedVersions)
+		assert.NoError(t, err, c.name)
+		assert.Equal(t, c.approved, approved, c.name)
+	}
+
+	_, err := csvVersionsApproved([]string{"amqstreams.v2.7.0-2"}, "latest")
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"fmt"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+// validateJbodVolumes rejects the volumes with the same id, the id 0 is the volume with the storage size of the kafka
+func validateJbodVolumes(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	ids := map[int32]bool{KafkaStorageIdentifier: true}
+	for _, volume := range mgh.Spec.DataLayer.Kafka.Volumes {
+		if ids[volume.ID] {
+			return fmt.Errorf("the id %d of the kafka volumes is duplicated", volume.ID)
+		}
+		ids[volume.ID] = true
+	}
+	return nil
+}
+
+// newJbodVolumes returns the volumes added to the JBOD storage of the brokers, besides the volume 0
+func newJbodVolumes(mgh *operatorv1alpha4.MulticlusterGlobalHub) []kafkav1beta2.KafkaSpecKafkaStorageVolumesElem {
+	volumes := []kafkav1beta2.KafkaSpecKafkaStorageVolumesElem{}
+	for _, volume := range mgh.Spec.DataLayer.Kafka.Volumes {
+		id, size, class := volume.ID, volume.Size, volume.StorageClass
+		if class == "" {
+			class = mgh.Spec.DataLayer.StorageClass
+		}
+		elem := kafkav1beta2.KafkaSpecKafkaStorageVolumesElem{
+			Id:          &id,
+			Size:        &size,
+			Type:        kafkav1beta2.KafkaSpecKafkaStorageVolumesElemTypePersistentClaim,
+			DeleteClaim: &KafkaStorageDeleteClaim,
+		}
+		if class != "" {
+			elem.Class = &class
+		}
+		volumes = append(volumes, elem)
+	}
+	return volumes
+}
+
+// newNodePoolVolumes returns the volumes added to the JBOD storage of the node pools, besides the volume 0
+func newNodePoolVolumes(mgh *operatorv1alpha4.MulticlusterGlobalHub) []interface{} {
+	volumes := []interface{}{}
+	for _, volume := range mgh.Spec.DataLayer.Kafka.Volumes {
+		elem := map[string]interface{}{
+			"id":          int64(volume.ID),
+			"type":        "persistent-claim",
+			"size":        volume.Size,
+			"deleteClaim": KafkaStorageDeleteClaim,
+		}
+		class := volume.StorageClass
+		if class == "" {
+			class = mgh.Spec.DataLayer.StorageClass
+		}
+		if class != "" {
+			elem["class"] = class
+		}
+		volumes = append(volumes, elem)
+	}
+	return volumes
+}
+
+// retainRemovedVolumes keeps the existing volumes which are removed from the desired volumes, the strimzi operator
+// deletes the partitions on the removed volumes, so the volumes are only added by the operator
+func retainRemovedVolumes(existing, desired []kafkav1beta2.KafkaSpecKafkaStorageVolumesElem,
+) []kafkav1beta2.KafkaSpecKafkaStorageVolumesElem {
+	desiredIds := map[int32]bool{}
+	for _, volume := range desired {
+		if volume.Id != nil {
+			desiredIds[*volume.Id] = true
+		}
+	}
+	for _, volume := range existing {
+		if volume.Id != nil && !desiredIds[*volume.Id] {
+			desired = append(desired, volume)
+		}
+	}
+	return desired
+}
+
+// retainRemovedNodePoolVolumes keeps the existing volumes of the node pool which are removed from the desired spec
+func retainRemovedNodePoolVolumes(existingSpec, desiredSpec map[string]interface{}) {
+	existingStorage, ok := existingSpec["storage"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	existingVolumes, _ := existingStorage["volumes"].([]interface{})
+	desiredStorage, ok := desiredSpec["storage"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	desiredVolumes, _ := desiredStorage["volumes"].([]interface{})
+
+	desiredIds := map[string]bool{}
+	for _, volume := range desiredVolumes {
+		if elem, ok := volume.(map[string]interface{}); ok {
+			desiredIds[fmt.Sprint(elem["id"])] = true
+		}
+	}
+	for _, volume := range existingVolumes {
+		if elem, ok := volume.(map[string]interface{}); ok && !desiredIds[fmt.Sprint(elem["id"])] {
+			desiredVolumes = append(desiredVolumes, volume)
+		}
+	}
+	desiredStorage["volumes"] = desiredVolumes
+}
@@ -0,0 +1,56 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestJbodVolumes(t *testing.T) {
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.DataLayer.StorageClass = "gp3"
+	mgh.Spec.DataLayer.Kafka.Volumes = []operatorv1alpha4.KafkaVolume{
+		{ID: 1, Size: "100Gi"},
+		{ID: 2, Size: "200Gi", StorageClass: "io2"},
+	}
+	assert.NoError(t, validateJbodVolumes(mgh))
+
+	volumes := newJbodVolumes(mgh)
+	assert.Len(t, volumes, 2)
+	assert.EqualValues(t, 1, *volumes[0].Id)
+	assert.Equal(t, "100Gi", *volumes[0].Size)
+	assert.Equal(t, "gp3", *volumes[0].Class)
+	assert.Equal(t, "io2", *volumes[1].Class)
+
+	// the volume 2 is removed from the mgh, but it's kept on the brokers
+	mgh.Spec.DataLayer.Kafka.Volumes = mgh.Spec.DataLayer.Kafka.Volumes[:1]
+	volumes = retainRemovedVolumes(volumes, newJbodVolumes(mgh))
+	assert.Len(t, volumes, 2)
+	assert.EqualValues(t, 2, *volumes[1].Id)
+
+	existingSpec := map[string]interface{}{
+		"storage": map[string]interface{}{
+			"volumes": []interface{}{
+				map[string]interface{}{"id": int64(0), "size": "10Gi"},
+				map[string]interface{}{"id": int64(2), "size": "200Gi"},
+			},
+		},
+	}
+	desiredSpec := map[string]interface{}{
+		"storage": map[string]interface{}{
+			"volumes": append([]interface{}{
+				map[string]interface{}{"id": int64(0), "size": "10Gi"},
+			}, newNodePoolVolumes(mgh)...),
+		},
+	}
+	retainRemovedNodePoolVolumes(existingSpec, desiredSpec)
+	desiredVolumes := desiredSpec["storage"].(map[string]interface{})["volumes"].([]interface{})
+	assert.Len(t, desiredVolumes, 3)
+	assert.Equal(t, "200Gi", desiredVolumes[2].(map[string]interface{})["size"])
+
+	// the id 0 is the volume with the storage size of the kafka
+	mgh.Spec.DataLayer.Kafka.Volumes = []operatorv1alpha4.KafkaVolume{{ID: 0, Size: "100Gi"}}
+	assert.Error(t, validateJbodVolumes(mgh))
+}
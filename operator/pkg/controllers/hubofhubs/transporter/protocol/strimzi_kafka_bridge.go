@@ -0,0 +1,118 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"embed"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/deployer"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/renderer"
+	operatorutils "github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
+)
+
+//go:embed bridge
+var bridgeManifests embed.FS
+
+const (
+	KafkaBridgeName     = "global-hub-kafka-bridge"
+	KafkaBridgeUserName = "global-hub-kafka-bridge-user"
+)
+
+// ensureKafkaBridge deploys the kafka bridge with its kafka user, and the oauth proxy in front of the bridge. The
+// objects are deleted if the bridge is removed from the mgh
+func (k *strimziTransporter) ensureKafkaBridge(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	bridgeObjects, err := k.renderKafkaBridge(mgh)
+	if err != nil {
+		return err
+	}
+
+	if mgh.Spec.DataLayer.Kafka.Bridge == nil || k.existingCluster {
+		for _, obj := range bridgeObjects {
+			err := k.runtimeClient.Delete(k.ctx, obj)
+			if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+				return fmt.Errorf("failed to delete the kafka bridge object %s/%s: %w", obj.GetKind(), obj.GetName(), err)
+			}
+		}
+		return nil
+	}
+
+	dc, err := discovery.NewDiscoveryClientForConfig(k.manager.GetConfig())
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	if err = operatorutils.ManipulateGlobalHubObjects(bridgeObjects, mgh,
+		deployer.NewHoHDeployer(k.manager.GetClient()), mapper, k.manager.GetScheme()); err != nil {
+		return fmt.Errorf("failed to create/update the kafka bridge objects: %w", err)
+	}
+	return nil
+}
+
+func (k *strimziTransporter) renderKafkaBridge(mgh *operatorv1alpha4.MulticlusterGlobalHub) (
+	[]*unstructured.Unstructured, error,
+) {
+	proxySessionSecret, err := config.GetOauthSessionSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the session secret of the kafka bridge oauth-proxy: %w", err)
+	}
+	imagePullPolicy := corev1.PullAlways
+	if mgh.Spec.ImagePullPolicy != "" {
+		imagePullPolicy = mgh.Spec.ImagePullPolicy
+	}
+	replicas := int32(1)
+	if bridge := mgh.Spec.DataLayer.Kafka.Bridge; bridge != nil && bridge.Replicas > 0 {
+		replicas = bridge.Replicas
+	}
+	statusTopic, statusTopicPattern := statusTopicACLResource()
+
+	return renderer.NewHoHRenderer(bridgeManifests).Render("bridge", "",
+		func(profile string) (interface{}, error) {
+			return struct {
+				Namespace          string
+				KafkaCluster       string
+				BridgeName         string
+				BridgeUser         string
+				Replicas           int32
+				BootstrapServer    string
+				ClusterCASecret    string
+				SpecTopic          string
+				StatusTopic        string
+				StatusTopicPattern string
+				SessionSecret      string
+				ProxyImage         string
+				ImagePullSecret    string
+				ImagePullPolicy    string
+				NodeSelector       map[string]string
+				Tolerations        []corev1.Toleration
+			}{
+				Namespace:          k.kafkaClusterNamespace,
+				KafkaCluster:       k.kafkaClusterName,
+				BridgeName:         KafkaBridgeName,
+				BridgeUser:         KafkaBridgeUserName,
+				Replicas:           replicas,
+				BootstrapServer:    tlsBootstrapServer(mgh, k.kafkaClusterName),
+				ClusterCASecret:    GetClusterCASecret(k.kafkaClusterName),
+				SpecTopic:          config.GetSpecTopic(),
+				StatusTopic:        statusTopic,
+				StatusTopicPattern: string(statusTopicPattern),
+				SessionSecret:      proxySessionSecret,
+				ProxyImage:         config.GetImage(config.OauthProxyImageKey),
+				ImagePullSecret:    mgh.Spec.ImagePullSecret,
+				ImagePullPolicy:    string(imagePullPolicy),
+				NodeSelector:       mgh.Spec.NodeSelector,
+				Tolerations:        mgh.Spec.Tolerations,
+			}, nil
+		})
+}
@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestRenderKafkaBridge(t *testing.T) {
+	k := &strimziTransporter{
+		kafkaClusterName:      KafkaClusterName,
+		kafkaClusterNamespace: "multicluster-global-hub",
+	}
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.DataLayer.Kafka.Bridge = &operatorv1alpha4.KafkaBridge{Replicas: 2}
+
+	objs, err := k.renderKafkaBridge(mgh)
+	assert.NoError(t, err)
+
+	kinds := map[string]*unstructured.Unstructured{}
+	for _, obj := range objs {
+		kinds[obj.GetKind()] = obj
+	}
+	for _, kind := range []string{
+		"KafkaBridge", "KafkaUser", "ServiceAccount", "Secret", "Service", "Route",
+		"Deployment", "ClusterRole", "ClusterRoleBinding",
+	} {
+		assert.Contains(t, kinds, kind)
+	}
+
+	bridge := kinds["KafkaBridge"]
+	assert.Equal(t, KafkaBridgeName, bridge.GetName())
+	replicas, _, _ := unstructured.NestedInt64(bridge.Object, "spec", "replicas")
+	assert.Equal(t, int64(2), replicas)
+	bootstrapServers, _, _ := unstructured.NestedString(bridge.Object, "spec", "bootstrapServers")
+	assert.Equal(t, "kafka-kafka-tls-bootstrap:9093", bootstrapServers)
+	userSecret, _, _ := unstructured.NestedString(bridge.Object,
+		"spec", "authentication", "certificateAndKey", "secretName")
+	assert.Equal(t, KafkaBridgeUserName, userSecret)
+
+	user := kinds["KafkaUser"]
+	assert.Equal(t, KafkaBridgeUserName, user.GetName())
+	assert.Equal(t, KafkaClusterName, user.GetLabels()["strimzi.io/cluster"])
+	acls, _, _ := unstructured.NestedSlice(user.Object, "spec", "authorization", "acls")
+	assert.Len(t, acls, 3)
+}
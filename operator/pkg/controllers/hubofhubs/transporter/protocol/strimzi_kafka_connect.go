@@ -0,0 +1,313 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+const (
+	KafkaConnectName     = "global-hub-kafka-connect"
+	KafkaConnectUserName = "global-hub-kafka-connect-user"
+
+	// the connectors are managed by the KafkaConnector resources instead of the rest api of the kafka connect
+	strimziUseConnectorResourcesAnnotation = "strimzi.io/use-connector-resources"
+	// the sink connectors consume the topics with the consumer group "connect-<connector name>"
+	kafkaConnectConsumerGroupPrefix = "connect-"
+)
+
+var (
+	KafkaConnectGVK = schema.GroupVersionKind{
+		Group:   "kafka.strimzi.io",
+		Version: "v1beta2",
+		Kind:    "KafkaConnect",
+	}
+	KafkaConnectorGVK = schema.GroupVersionKind{
+		Group:   "kafka.strimzi.io",
+		Version: "v1beta2",
+		Kind:    "KafkaConnector",
+	}
+	KafkaUserGVK = schema.GroupVersionKind{
+		Group:   "kafka.strimzi.io",
+		Version: "v1beta2",
+		Kind:    "KafkaUser",
+	}
+)
+
+// ensureKafkaConnect deploys the kafka connect with its kafka user and the connectors of the mgh, the connectors
+// removed from the mgh are deleted. The kafka connect is deleted if it's removed from the mgh
+func (k *strimziTransporter) ensureKafkaConnect(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	connect := mgh.Spec.DataLayer.Kafka.Connect
+	if connect == nil || k.existingCluster {
+		return k.deleteKafkaConnect()
+	}
+
+	if err := k.ensureConnectObject(k.newKafkaConnectUser()); err != nil {
+		return fmt.Errorf("failed to ensure the kafka user of the kafka connect: %w", err)
+	}
+	if err := k.ensureConnectObject(k.newKafkaConnect(mgh)); err != nil {
+		return fmt.Errorf("failed to ensure the kafka connect: %w", err)
+	}
+
+	desiredNames := map[string]bool{}
+	for _, connector := range connect.Connectors {
+		desiredNames[connector.Name] = true
+		if err := k.ensureConnectObject(k.newKafkaConnector(connector)); err != nil {
+			return fmt.Errorf("failed to ensure the kafka connector %s: %w", connector.Name, err)
+		}
+	}
+	return k.pruneKafkaConnectors(desiredNames)
+}
+
+// ensureConnectObject creates the object, or updates the spec of the existing one if it's changed
+func (k *strimziTransporter) ensureConnectObject(desired *unstructured.Unstructured) error {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(desired.GroupVersionKind())
+	err := k.runtimeClient.Get(k.ctx, client.ObjectKeyFromObject(desired), existing)
+	if errors.IsNotFound(err) {
+		k.log.Info("create the kafka connect resource", "kind", desired.GetKind(), "name", desired.GetName())
+		return k.runtimeClient.Create(k.ctx, desired)
+	} else if err != nil {
+		return err
+	}
+
+	existingSpec, _, _ := unstructured.NestedMap(existing.Object, "spec")
+	if isEqualJSON(existingSpec, desired.Object["spec"]) &&
+		isEqualJSON(existing.GetAnnotations(), desired.GetAnnotations()) {
+		return nil
+	}
+	existing.Object["spec"] = desired.Object["spec"]
+	existing.SetAnnotations(desired.GetAnnotations())
+	k.log.Info("update the kafka connect resource", "kind", desired.GetKind(), "name", desired.GetName())
+	return k.runtimeClient.Update(k.ctx, existing)
+}
+
+// pruneKafkaConnectors deletes the connectors of the global hub which aren't in the desired names
+func (k *strimziTransporter) pruneKafkaConnectors(desiredNames map[string]bool) error {
+	connectors := &unstructured.UnstructuredList{}
+	connectors.SetGroupVersionKind(KafkaConnectorGVK)
+	err := k.runtimeClient.List(k.ctx, connectors, client.InNamespace(k.kafkaClusterNamespace),
+		client.MatchingLabels{
+			"strimzi.io/cluster":             KafkaConnectName,
+			constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+		})
+	if err != nil {
+		return err
+	}
+	for i := range connectors.Items {
+		connector := &connectors.Items[i]
+		if desiredNames[connector.GetName()] {
+			continue
+		}
+		k.log.Info("delete the kafka connector", "name", connector.GetName())
+		if err := k.runtimeClient.Delete(k.ctx, connector); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteKafkaConnect deletes the connectors, the kafka connect and its kafka user
+func (k *strimziTransporter) deleteKafkaConnect() error {
+	if err := k.pruneKafkaConnectors(nil); err != nil {
+		return err
+	}
+	for _, obj := range []struct {
+		gvk  schema.GroupVersionKind
+		name string
+	}{
+		{KafkaConnectGVK, KafkaConnectName},
+		{KafkaUserGVK, KafkaConnectUserName},
+	} {
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(obj.gvk)
+		err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+			Name:      obj.name,
+			Namespace: k.kafkaClusterNamespace,
+		}, existing)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		k.log.Info("delete the kafka connect resource", "kind", obj.gvk.Kind, "name", obj.name)
+		if err := k.runtimeClient.Delete(k.ctx, existing); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// newKafkaConnect returns the kafka connect, it connects to the tls listener with the certificate of its kafka user,
+// and stores the offsets, the configs and the status of the connectors in the topics prefixed with its name
+func (k *strimziTransporter) newKafkaConnect(mgh *operatorv1alpha4.MulticlusterGlobalHub) *unstructured.Unstructured {
+	connect := mgh.Spec.DataLayer.Kafka.Connect
+	replicas := connect.Replicas
+	if replicas == 0 {
+		replicas = 1
+	}
+
+	volumes := []interface{}{}
+	for _, secretName := range connect.SecretNames {
+		volumes = append(volumes, map[string]interface{}{
+			"name": secretName,
+			"secret": map[string]interface{}{
+				"secretName": secretName,
+			},
+		})
+	}
+
+	spec := map[string]interface{}{
+		"replicas":         int64(replicas),
+		"bootstrapServers": tlsBootstrapServer(mgh, k.kafkaClusterName),
+		"tls": map[string]interface{}{
+			"trustedCertificates": []interface{}{
+				map[string]interface{}{
+					"secretName":  GetClusterCASecret(k.kafkaClusterName),
+					"certificate": "ca.crt",
+				},
+			},
+		},
+		"authentication": map[string]interface{}{
+			"type": "tls",
+			"certificateAndKey": map[string]interface{}{
+				"secretName":  KafkaConnectUserName,
+				"certificate": "user.crt",
+				"key":         "user.key",
+			},
+		},
+		"config": map[string]interface{}{
+			"group.id":                          KafkaConnectName,
+			"offset.storage.topic":              KafkaConnectName + "-offsets",
+			"config.storage.topic":              KafkaConnectName + "-configs",
+			"status.storage.topic":              KafkaConnectName + "-status",
+			"offset.storage.replication.factor": int64(-1),
+			"config.storage.replication.factor": int64(-1),
+			"status.storage.replication.factor": int64(-1),
+			"config.providers":                  "directory",
+			"config.providers.directory.class":  "org.apache.kafka.common.config.provider.DirectoryConfigProvider",
+			"key.converter":                     "org.apache.kafka.connect.storage.StringConverter",
+			"value.converter":                   "org.apache.kafka.connect.json.JsonConverter",
+			"value.converter.schemas.enable":    false,
+		},
+	}
+	if connect.Image != "" {
+		spec["image"] = connect.Image
+	}
+	if len(volumes) > 0 {
+		spec["externalConfiguration"] = map[string]interface{}{
+			"volumes": volumes,
+		}
+	}
+
+	kafkaConnect := &unstructured.Unstructured{}
+	kafkaConnect.SetGroupVersionKind(KafkaConnectGVK)
+	kafkaConnect.SetName(KafkaConnectName)
+	kafkaConnect.SetNamespace(k.kafkaClusterNamespace)
+	kafkaConnect.SetLabels(map[string]string{
+		constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+	})
+	kafkaConnect.SetAnnotations(map[string]string{
+		strimziUseConnectorResourcesAnnotation: "true",
+	})
+	kafkaConnect.Object["spec"] = spec
+	return kafkaConnect
+}
+
+// newKafkaConnector returns the connector, the sink connector consumes all the status topics if the topics aren't
+// set in the connector or its config
+func (k *strimziTransporter) newKafkaConnector(connector operatorv1alpha4.KafkaConnector) *unstructured.Unstructured {
+	tasksMax := connector.TasksMax
+	if tasksMax == 0 {
+		tasksMax = 1
+	}
+	connectorConfig := map[string]interface{}{}
+	for key, val := range connector.Config {
+		connectorConfig[key] = val
+	}
+	_, hasTopics := connectorConfig["topics"]
+	_, hasTopicsRegex := connectorConfig["topics.regex"]
+	if connector.Topics != "" {
+		connectorConfig["topics"] = connector.Topics
+	} else if !hasTopics && !hasTopicsRegex {
+		topicRegex, _ := statusTopicRegex(config.GetRawStatusTopic())
+		connectorConfig["topics.regex"] = topicRegex
+	}
+
+	kafkaConnector := &unstructured.Unstructured{}
+	kafkaConnector.SetGroupVersionKind(KafkaConnectorGVK)
+	kafkaConnector.SetName(connector.Name)
+	kafkaConnector.SetNamespace(k.kafkaClusterNamespace)
+	kafkaConnector.SetLabels(map[string]string{
+		// the connector is only deployed to the kafka connect with the cluster label
+		"strimzi.io/cluster":             KafkaConnectName,
+		constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+	})
+	kafkaConnector.Object["spec"] = map[string]interface{}{
+		"class":    connector.Class,
+		"tasksMax": int64(tasksMax),
+		"config":   connectorConfig,
+	}
+	return kafkaConnector
+}
+
+// newKafkaConnectUser returns the kafka user of the kafka connect, it reads the status topics with the consumer
+// groups of the sink connectors, and owns the internal topics and the group of the kafka connect
+func (k *strimziTransporter) newKafkaConnectUser() *unstructured.Unstructured {
+	statusTopic, statusTopicPattern := statusTopicACLResource()
+	acl := func(resourceType, name, patternType string, operations ...interface{}) interface{} {
+		return map[string]interface{}{
+			"host":       "*",
+			"operations": operations,
+			"resource": map[string]interface{}{
+				"type":        resourceType,
+				"name":        name,
+				"patternType": patternType,
+			},
+		}
+	}
+
+	kafkaUser := &unstructured.Unstructured{}
+	kafkaUser.SetGroupVersionKind(KafkaUserGVK)
+	kafkaUser.SetName(KafkaConnectUserName)
+	kafkaUser.SetNamespace(k.kafkaClusterNamespace)
+	kafkaUser.SetLabels(map[string]string{
+		"strimzi.io/cluster":             k.kafkaClusterName,
+		constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+	})
+	kafkaUser.Object["spec"] = map[string]interface{}{
+		"authentication": map[string]interface{}{
+			"type": "tls",
+		},
+		"authorization": map[string]interface{}{
+			"type": "simple",
+			"acls": []interface{}{
+				acl("topic", statusTopic, string(statusTopicPattern), "Describe", "Read"),
+				acl("group", kafkaConnectConsumerGroupPrefix, "prefix", "Read"),
+				acl("topic", KafkaConnectName, "prefix", "All"),
+				acl("group", KafkaConnectName, "literal", "All"),
+			},
+		},
+	}
+	return kafkaUser
+}
+
+// tlsBootstrapServer returns the bootstrap server of the tls listener inside the cluster for the kafka connect and
+// the kafka bridge, it's the internal tls listener in the tls-only mode
+func tlsBootstrapServer(mgh *operatorv1alpha4.MulticlusterGlobalHub, clusterName string) string {
+	if mgh.Spec.DataLayer.Kafka.TLSOnly {
+		return fmt.Sprintf("%s-kafka-bootstrap:%d", clusterName, InternalTLSListenerPort)
+	}
+	return fmt.Sprintf("%s-kafka-%s-bootstrap:%d", clusterName, TLSListenerName, TLSListenerPort)
+}
@@ -0,0 +1,85 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestNewKafkaConnect(t *testing.T) {
+	k := &strimziTransporter{
+		kafkaClusterName:      KafkaClusterName,
+		kafkaClusterNamespace: "multicluster-global-hub",
+	}
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.DataLayer.Kafka.Connect = &operatorv1alpha4.KafkaConnect{
+		Image:       "quay.io/example/kafka-connect-s3:latest",
+		SecretNames: []string{"s3-credentials"},
+	}
+
+	// the kafka connect connects to the tls listener with the single worker by default
+	kafkaConnect := k.newKafkaConnect(mgh)
+	assert.Equal(t, "true", kafkaConnect.GetAnnotations()[strimziUseConnectorResourcesAnnotation])
+	replicas, _, _ := unstructured.NestedInt64(kafkaConnect.Object, "spec", "replicas")
+	assert.Equal(t, int64(1), replicas)
+	bootstrapServers, _, _ := unstructured.NestedString(kafkaConnect.Object, "spec", "bootstrapServers")
+	assert.Equal(t, "kafka-kafka-tls-bootstrap:9093", bootstrapServers)
+	image, _, _ := unstructured.NestedString(kafkaConnect.Object, "spec", "image")
+	assert.Equal(t, "quay.io/example/kafka-connect-s3:latest", image)
+	volumes, _, _ := unstructured.NestedSlice(kafkaConnect.Object, "spec", "externalConfiguration", "volumes")
+	assert.True(t, isEqualJSON(volumes, []interface{}{
+		map[string]interface{}{
+			"name":   "s3-credentials",
+			"secret": map[string]interface{}{"secretName": "s3-credentials"},
+		},
+	}))
+
+	// the internal tls listener in the tls-only mode
+	mgh.Spec.DataLayer.Kafka.TLSOnly = true
+	bootstrapServers, _, _ = unstructured.NestedString(k.newKafkaConnect(mgh).Object, "spec", "bootstrapServers")
+	assert.Equal(t, "kafka-kafka-bootstrap:9095", bootstrapServers)
+}
+
+func TestNewKafkaConnector(t *testing.T) {
+	k := &strimziTransporter{kafkaClusterNamespace: "multicluster-global-hub"}
+
+	// the sink consumes the status topics by default
+	connector := k.newKafkaConnector(operatorv1alpha4.KafkaConnector{
+		Name:  "s3-sink",
+		Class: "io.confluent.connect.s3.S3SinkConnector",
+		Config: map[string]string{
+			"s3.bucket.name": "global-hub-events",
+		},
+	})
+	assert.Equal(t, KafkaConnectName, connector.GetLabels()["strimzi.io/cluster"])
+	tasksMax, _, _ := unstructured.NestedInt64(connector.Object, "spec", "tasksMax")
+	assert.Equal(t, int64(1), tasksMax)
+	connectorConfig, _, _ := unstructured.NestedMap(connector.Object, "spec", "config")
+	assert.Equal(t, "global-hub-events", connectorConfig["s3.bucket.name"])
+	assert.Contains(t, connectorConfig, "topics.regex")
+
+	// the topics of the connector
+	connector = k.newKafkaConnector(operatorv1alpha4.KafkaConnector{
+		Name:     "es-sink",
+		Class:    "io.confluent.connect.elasticsearch.ElasticsearchSinkConnector",
+		TasksMax: 3,
+		Topics:   "gh-event.hub1,gh-event.hub2",
+	})
+	tasksMax, _, _ = unstructured.NestedInt64(connector.Object, "spec", "tasksMax")
+	assert.Equal(t, int64(3), tasksMax)
+	connectorConfig, _, _ = unstructured.NestedMap(connector.Object, "spec", "config")
+	assert.Equal(t, "gh-event.hub1,gh-event.hub2", connectorConfig["topics"])
+	assert.NotContains(t, connectorConfig, "topics.regex")
+
+	// the topics in the config aren't overridden
+	connector = k.newKafkaConnector(operatorv1alpha4.KafkaConnector{
+		Name:   "es-sink",
+		Class:  "io.confluent.connect.elasticsearch.ElasticsearchSinkConnector",
+		Config: map[string]string{"topics.regex": "gh-event\\.hub.+"},
+	})
+	connectorConfig, _, _ = unstructured.NestedMap(connector.Object, "spec", "config")
+	assert.Equal(t, "gh-event\\.hub.+", connectorConfig["topics.regex"])
+}
@@ -6,11 +6,15 @@ package protocol
 import (
 	"context"
 	"embed"
+	"reflect"
+	"strings"
 	"time"
 
 	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -20,6 +24,7 @@ import (
 
 	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	operatorconstants "github.com/stolostron/multicluster-global-hub/operator/pkg/constants"
 	operatorutils "github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 	"github.com/stolostron/multicluster-global-hub/pkg/utils"
@@ -31,6 +36,7 @@ var manifests embed.FS
 // KafkaController reconciles the kafka crd
 type KafkaController struct {
 	ctrl.Manager
+	recorder record.EventRecorder
 }
 
 func (r *KafkaController) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
@@ -62,7 +68,40 @@ func (r *KafkaController) Reconcile(ctx context.Context, request ctrl.Request) (
 	}
 	config.SetTransporterConn(conn)
 
-	return ctrl.Result{}, nil
+	// scale the kafka brokers by the consumer lag of the manager
+	autoscalingEvent, err := trans.EnsureAutoscaling(conn)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if autoscalingEvent != nil {
+		r.recorder.Event(mgh, autoscalingEvent.Type, autoscalingEvent.Reason, autoscalingEvent.Message)
+	}
+
+	// repair or report the kafka topics and the kafka users which are changed out of band
+	if err := trans.EnsureTopicDrift(); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// delete or report the kafka users and the topics of the removed managed hubs
+	if err := trans.EnsureOrphans(); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// rebalance the kafka with the cruise control if the managed hubs are added
+	inProgress, err := trans.EnsureRebalance()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if inProgress {
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	}
+
+	// the consumer lag is checked periodically
+	if getKafkaAutoscaling(mgh) != nil && AutoscalingInterval < TopicDriftInterval {
+		return ctrl.Result{RequeueAfter: AutoscalingInterval}, nil
+	}
+	// the topics and the users are compared periodically, since the changes of the config aren't always watched
+	return ctrl.Result{RequeueAfter: TopicDriftInterval}, nil
 }
 
 var kafkaPred = predicate.Funcs{
@@ -73,7 +112,47 @@ var kafkaPred = predicate.Funcs{
 		return e.ObjectNew.GetGeneration() != e.ObjectOld.GetGeneration()
 	},
 	DeleteFunc: func(e event.DeleteEvent) bool {
-		return e.Object.GetNamespace() == utils.GetDefaultNamespace()
+		return isKafkaNamespace(e.Object.GetNamespace())
+	},
+}
+
+// kafkaUserPred also reconciles the created kafka users, so the kafka is rebalanced when the managed hubs are added
+var kafkaUserPred = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return isKafkaNamespace(e.Object.GetNamespace())
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.ObjectNew.GetGeneration() != e.ObjectOld.GetGeneration()
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return isKafkaNamespace(e.Object.GetNamespace())
+	},
+}
+
+// isKafkaNamespace returns true if the object is in the global hub namespace, or the namespace of the existing kafka
+// cluster which is reused by the global hub
+func isKafkaNamespace(namespace string) bool {
+	if existing := config.GetExistingKafkaCluster(); existing != nil && existing.Namespace == namespace {
+		return true
+	}
+	return namespace == utils.GetDefaultNamespace()
+}
+
+// caSecretPred reconciles the CAs renewed by the cert-manager, so they're synced to the secrets of the strimzi
+var caSecretPred = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return strings.HasSuffix(e.Object.GetName(), certManagerSecretSuffix)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if !strings.HasSuffix(e.ObjectNew.GetName(), certManagerSecretSuffix) {
+			return false
+		}
+		oldSecret, oldOK := e.ObjectOld.(*corev1.Secret)
+		newSecret, newOK := e.ObjectNew.(*corev1.Secret)
+		return !oldOK || !newOK || !reflect.DeepEqual(oldSecret.Data, newSecret.Data)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
 	},
 }
 
@@ -82,7 +161,9 @@ var mghPred = predicate.Funcs{
 		return true
 	},
 	UpdateFunc: func(e event.UpdateEvent) bool {
-		return e.ObjectNew.GetGeneration() != e.ObjectOld.GetGeneration()
+		return e.ObjectNew.GetGeneration() != e.ObjectOld.GetGeneration() ||
+			e.ObjectNew.GetAnnotations()[operatorconstants.AnnotationKafkaRebalance] !=
+				e.ObjectOld.GetAnnotations()[operatorconstants.AnnotationKafkaRebalance]
 	},
 	DeleteFunc: func(e event.DeleteEvent) bool {
 		return false
@@ -90,7 +171,10 @@ var mghPred = predicate.Funcs{
 }
 
 func StartKafkaController(ctx context.Context, mgr ctrl.Manager) (*KafkaController, error) {
-	r := &KafkaController{Manager: mgr}
+	r := &KafkaController{
+		Manager:  mgr,
+		recorder: mgr.GetEventRecorderFor("kafka-autoscaler"),
+	}
 
 	// even if the following controller will reconcile the transport, but it's asynchoronized
 	err := ctrl.NewControllerManagedBy(mgr).
@@ -99,9 +183,11 @@ func StartKafkaController(ctx context.Context, mgr ctrl.Manager) (*KafkaControll
 		Watches(&kafkav1beta2.Kafka{},
 			&handler.EnqueueRequestForObject{}, builder.WithPredicates(kafkaPred)).
 		Watches(&kafkav1beta2.KafkaUser{},
-			&handler.EnqueueRequestForObject{}, builder.WithPredicates(kafkaPred)).
+			&handler.EnqueueRequestForObject{}, builder.WithPredicates(kafkaUserPred)).
 		Watches(&kafkav1beta2.KafkaTopic{},
 			&handler.EnqueueRequestForObject{}, builder.WithPredicates(kafkaPred)).
+		Watches(&corev1.Secret{},
+			&handler.EnqueueRequestForObject{}, builder.WithPredicates(caSecretPred)).
 		Complete(r)
 	if err != nil {
 		return nil, err
@@ -119,7 +205,7 @@ func waitManagerTransportConn(ctx context.Context, trans *strimziTransporter, ka
 	err = wait.PollUntilContextTimeout(ctx, 2*time.Second, 10*time.Minute, true,
 		func(ctx context.Context) (bool, error) {
 			// boostrapServer, clusterId, clusterCA
-			conn, err = trans.getConnCredentailByCluster()
+			conn, err = trans.getConnCredentailByCluster(managerListenerName(trans.mgh))
 			if err != nil {
 				klog.Info("waiting the kafka cluster credential to be ready...", "message", err.Error())
 				return false, err
@@ -0,0 +1,177 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+const interBrokerProtocolVersionKey = "inter.broker.protocol.version"
+
+// KafkaVersions is the supported upgrade path of the built-in kafka, the kafka is upgraded one version at a time
+// and the last one is the KafkaVersion. Append the new version here when bumping the KafkaVersion
+var KafkaVersions = []string{"3.5.0", "3.6.0", KafkaVersion}
+
+type kafkaUpgradeState string
+
+const (
+	kafkaUpgraded       kafkaUpgradeState = "Upgraded"
+	kafkaUpgrading      kafkaUpgradeState = "Upgrading"
+	kafkaUpgradeBlocked kafkaUpgradeState = "Blocked"
+)
+
+// nextKafkaUpgradeStep returns the kafka version and the inter broker protocol version of the next upgrade step.
+// The strimzi upgrade procedure is followed: the kafka version is upgraded first with the previous protocol, then
+// the protocol is upgraded once the brokers are rolled. The next step is only taken when the kafka is ready
+func nextKafkaUpgradeStep(currentVersion, currentProtocol string, ready bool) (
+	string, string, kafkaUpgradeState, error,
+) {
+	targetVersion := KafkaVersions[len(KafkaVersions)-1]
+	if currentVersion == "" {
+		return targetVersion, protocolVersion(targetVersion), kafkaUpgraded, nil
+	}
+
+	index := -1
+	for i, version := range KafkaVersions {
+		if version == currentVersion {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return currentVersion, currentProtocol, kafkaUpgradeBlocked, fmt.Errorf(
+			"the kafka version %s isn't in the supported upgrade path %v", currentVersion, KafkaVersions)
+	}
+	if currentProtocol == "" {
+		currentProtocol = protocolVersion(currentVersion)
+	}
+
+	if currentVersion == targetVersion && currentProtocol == protocolVersion(targetVersion) {
+		return currentVersion, currentProtocol, kafkaUpgraded, nil
+	}
+	if !ready {
+		return currentVersion, currentProtocol, kafkaUpgrading, nil
+	}
+	if currentProtocol != protocolVersion(currentVersion) {
+		return currentVersion, protocolVersion(currentVersion), kafkaUpgrading, nil
+	}
+	return KafkaVersions[index+1], currentProtocol, kafkaUpgrading, nil
+}
+
+// upgradeKafka sets the version and the inter broker protocol version of the next upgrade step to the updated
+// kafka, and reports the progress in the KafkaUpgraded condition of the mgh
+func (k *strimziTransporter) upgradeKafka(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	existingKafka, updatedKafka *kafkav1beta2.Kafka,
+) error {
+	currentVersion := ""
+	if existingKafka.Spec.Kafka.Version != nil {
+		currentVersion = *existingKafka.Spec.Kafka.Version
+	}
+	currentProtocol, err := getKafkaConfigValue(existingKafka.Spec.Kafka.Config, interBrokerProtocolVersionKey)
+	if err != nil {
+		return err
+	}
+
+	version, protocol, state, upgradeErr := nextKafkaUpgradeStep(currentVersion, currentProtocol,
+		kafkaClusterReconciled(existingKafka))
+	updatedKafka.Spec.Kafka.Version = &version
+	updatedKafka.Spec.Kafka.Config, err = setKafkaConfigValue(updatedKafka.Spec.Kafka.Config,
+		interBrokerProtocolVersionKey, protocol)
+	if err != nil {
+		return err
+	}
+
+	if err := k.setKafkaUpgradeCondition(mgh, state, upgradeErr, currentVersion, currentProtocol, version,
+		protocol); err != nil {
+		// the upgrade shouldn't be blocked by the status of the mgh
+		k.log.Error(err, "failed to set the kafka upgrade condition")
+	}
+	return nil
+}
+
+func (k *strimziTransporter) setKafkaUpgradeCondition(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	state kafkaUpgradeState, upgradeErr error, currentVersion, currentProtocol, version, protocol string,
+) error {
+	switch state {
+	case kafkaUpgradeBlocked:
+		k.log.Info("the kafka upgrade is blocked", "message", upgradeErr.Error())
+		return config.SetConditionKafkaUpgraded(k.ctx, k.runtimeClient, mgh, config.CONDITION_STATUS_FALSE,
+			config.CONDITION_REASON_KAFKA_UPGRADE_BLOCKED, upgradeErr.Error())
+	case kafkaUpgrading:
+		k.log.Info("upgrading the kafka", "version", version, "protocol", protocol)
+		return config.SetConditionKafkaUpgraded(k.ctx, k.runtimeClient, mgh, config.CONDITION_STATUS_FALSE,
+			config.CONDITION_REASON_KAFKA_UPGRADING, fmt.Sprintf(
+				"upgrading the kafka from %s (protocol %s) to %s (protocol %s), the target version is %s",
+				currentVersion, currentProtocol, version, protocol, KafkaVersions[len(KafkaVersions)-1]))
+	default:
+		// only report the condition once the kafka has been upgraded by the operator
+		if !config.ContainsCondition(mgh, config.CONDITION_TYPE_KAFKA_UPGRADED) {
+			return nil
+		}
+		return config.SetConditionKafkaUpgraded(k.ctx, k.runtimeClient, mgh, config.CONDITION_STATUS_TRUE,
+			config.CONDITION_REASON_KAFKA_UPGRADED, fmt.Sprintf("the kafka is upgraded to %s", version))
+	}
+}
+
+// kafkaClusterReconciled returns true if the strimzi operator has rolled out the latest spec and the kafka is ready
+func kafkaClusterReconciled(kafkaCluster *kafkav1beta2.Kafka) bool {
+	if kafkaCluster.Status == nil || kafkaCluster.Status.ObservedGeneration == nil ||
+		int64(*kafkaCluster.Status.ObservedGeneration) != kafkaCluster.Generation {
+		return false
+	}
+	for _, condition := range kafkaCluster.Status.Conditions {
+		if condition.Type != nil && *condition.Type == "Ready" &&
+			condition.Status != nil && *condition.Status == string(metav1.ConditionTrue) {
+			return true
+		}
+	}
+	return false
+}
+
+// protocolVersion returns the inter broker protocol version of the kafka version, e.g. "3.7" for "3.7.0"
+func protocolVersion(version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return version
+	}
+	return strings.Join(parts[:2], ".")
+}
+
+func getKafkaConfigValue(kafkaConfig *apiextensions.JSON, key string) (string, error) {
+	if kafkaConfig == nil || len(kafkaConfig.Raw) == 0 {
+		return "", nil
+	}
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(kafkaConfig.Raw, &values); err != nil {
+		return "", fmt.Errorf("failed to unmarshal the kafka config: %w", err)
+	}
+	if value, ok := values[key]; ok {
+		return fmt.Sprintf("%v", value), nil
+	}
+	return "", nil
+}
+
+func setKafkaConfigValue(kafkaConfig *apiextensions.JSON, key, value string) (*apiextensions.JSON, error) {
+	values := map[string]interface{}{}
+	if kafkaConfig != nil && len(kafkaConfig.Raw) > 0 {
+		if err := json.Unmarshal(kafkaConfig.Raw, &values); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the kafka config: %w", err)
+		}
+	}
+	values[key] = value
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return &apiextensions.JSON{Raw: raw}, nil
+}
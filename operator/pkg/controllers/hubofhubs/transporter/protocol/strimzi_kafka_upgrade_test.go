@@ -0,0 +1,106 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextKafkaUpgradeStep(t *testing.T) {
+	cases := []struct {
+		name            string
+		currentVersion  string
+		currentProtocol string
+		ready           bool
+		expectVersion   string
+		expectProtocol  string
+		expectState     kafkaUpgradeState
+		expectErr       bool
+	}{
+		{
+			name:           "new kafka cluster",
+			expectVersion:  KafkaVersion,
+			expectProtocol: protocolVersion(KafkaVersion),
+			expectState:    kafkaUpgraded,
+		},
+		{
+			name:            "upgraded kafka cluster",
+			currentVersion:  KafkaVersion,
+			currentProtocol: protocolVersion(KafkaVersion),
+			ready:           true,
+			expectVersion:   KafkaVersion,
+			expectProtocol:  protocolVersion(KafkaVersion),
+			expectState:     kafkaUpgraded,
+		},
+		{
+			name:            "upgrade the kafka version with the previous protocol",
+			currentVersion:  "3.5.0",
+			currentProtocol: "3.5",
+			ready:           true,
+			expectVersion:   "3.6.0",
+			expectProtocol:  "3.5",
+			expectState:     kafkaUpgrading,
+		},
+		{
+			name:            "upgrade the protocol after the kafka version",
+			currentVersion:  "3.6.0",
+			currentProtocol: "3.5",
+			ready:           true,
+			expectVersion:   "3.6.0",
+			expectProtocol:  "3.6",
+			expectState:     kafkaUpgrading,
+		},
+		{
+			name:           "the protocol defaults to the kafka version",
+			currentVersion: "3.6.0",
+			ready:          true,
+			expectVersion:  KafkaVersion,
+			expectProtocol: "3.6",
+			expectState:    kafkaUpgrading,
+		},
+		{
+			name:            "wait the kafka to be ready",
+			currentVersion:  "3.6.0",
+			currentProtocol: "3.5",
+			ready:           false,
+			expectVersion:   "3.6.0",
+			expectProtocol:  "3.5",
+			expectState:     kafkaUpgrading,
+		},
+		{
+			name:            "unsupported kafka version",
+			currentVersion:  "3.3.1",
+			currentProtocol: "3.3",
+			ready:           true,
+			expectVersion:   "3.3.1",
+			expectProtocol:  "3.3",
+			expectState:     kafkaUpgradeBlocked,
+			expectErr:       true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			version, protocol, state, err := nextKafkaUpgradeStep(tc.currentVersion, tc.currentProtocol, tc.ready)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectVersion, version)
+			assert.Equal(t, tc.expectProtocol, protocol)
+			assert.Equal(t, tc.expectState, state)
+		})
+	}
+}
+
+func TestKafkaConfigValue(t *testing.T) {
+	kafkaConfig, err := setKafkaConfigValue(nil, interBrokerProtocolVersionKey, "3.6")
+	assert.NoError(t, err)
+	value, err := getKafkaConfigValue(kafkaConfig, interBrokerProtocolVersionKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "3.6", value)
+
+	value, err = getKafkaConfigValue(kafkaConfig, "min.insync.replicas")
+	assert.NoError(t, err)
+	assert.Empty(t, value)
+}
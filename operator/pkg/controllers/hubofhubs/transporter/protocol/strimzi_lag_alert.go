@@ -0,0 +1,50 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"regexp"
+	"strings"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+const (
+	DefaultLagWarningThreshold  = 1000
+	DefaultLagCriticalThreshold = 10000
+	DefaultLagAlertFor          = "5m"
+)
+
+// getStatusLagAlert returns the thresholds of the status lag alerts, the unset ones are the defaults
+func getStatusLagAlert(mgh *operatorv1alpha4.MulticlusterGlobalHub) operatorv1alpha4.KafkaStatusLagAlert {
+	lagAlert := operatorv1alpha4.KafkaStatusLagAlert{}
+	if mgh.Spec.DataLayer.Kafka.StatusLagAlert != nil {
+		lagAlert = *mgh.Spec.DataLayer.Kafka.StatusLagAlert
+	}
+	if lagAlert.WarningThreshold <= 0 {
+		lagAlert.WarningThreshold = DefaultLagWarningThreshold
+	}
+	if lagAlert.CriticalThreshold <= 0 {
+		lagAlert.CriticalThreshold = DefaultLagCriticalThreshold
+	}
+	if lagAlert.For == "" {
+		lagAlert.For = DefaultLagAlertFor
+	}
+	return lagAlert
+}
+
+// statusTopicRegex returns the regex of the status topics for the kafka exporter, and the regex which captures the
+// managed hub name from the status topic, e.g. "gh-event\..+" and "gh-event\.(.+)" for the status topic
+// "gh-event.*". If all the managed hubs share the same status topic, the topic is captured instead
+func statusTopicRegex(rawStatusTopic string) (string, string) {
+	if !strings.Contains(rawStatusTopic, "*") {
+		topic := regexp.QuoteMeta(rawStatusTopic)
+		return topic, "(" + topic + ")"
+	}
+	parts := strings.Split(rawStatusTopic, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return strings.Join(parts, ".+"), strings.Join(parts, "(.+)")
+}
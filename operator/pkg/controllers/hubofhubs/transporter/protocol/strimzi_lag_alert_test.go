@@ -0,0 +1,38 @@
+package protocol
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestStatusTopicRegex(t *testing.T) {
+	topicRegex, hubRegex := statusTopicRegex("gh-event.*")
+	assert.Equal(t, `gh-event\..+`, topicRegex)
+	assert.Equal(t, `gh-event\.(.+)`, hubRegex)
+	assert.True(t, regexp.MustCompile("^"+topicRegex+"$").MatchString("gh-event.hub1"))
+	assert.False(t, regexp.MustCompile("^"+topicRegex+"$").MatchString("gh-spec"))
+	assert.Equal(t, "hub1", regexp.MustCompile("^" + hubRegex + "$").FindStringSubmatch("gh-event.hub1")[1])
+
+	// the managed hubs share the status topic
+	topicRegex, hubRegex = statusTopicRegex("gh-status")
+	assert.Equal(t, "gh-status", topicRegex)
+	assert.Equal(t, "(gh-status)", hubRegex)
+}
+
+func TestGetStatusLagAlert(t *testing.T) {
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	assert.Equal(t, operatorv1alpha4.KafkaStatusLagAlert{
+		WarningThreshold:  DefaultLagWarningThreshold,
+		CriticalThreshold: DefaultLagCriticalThreshold,
+		For:               DefaultLagAlertFor,
+	}, getStatusLagAlert(mgh))
+
+	mgh.Spec.DataLayer.Kafka.StatusLagAlert = &operatorv1alpha4.KafkaStatusLagAlert{CriticalThreshold: 50000}
+	lagAlert := getStatusLagAlert(mgh)
+	assert.Equal(t, int64(DefaultLagWarningThreshold), lagAlert.WarningThreshold)
+	assert.Equal(t, int64(50000), lagAlert.CriticalThreshold)
+}
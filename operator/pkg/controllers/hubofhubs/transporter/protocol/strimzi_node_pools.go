@@ -0,0 +1,185 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+var KafkaNodePoolGVK = schema.GroupVersionKind{
+	Group:   "kafka.strimzi.io",
+	Version: "v1beta2",
+	Kind:    "KafkaNodePool",
+}
+
+const (
+	// the kafka cluster uses the node pools instead of the replicas and the storage of the kafka spec
+	NodePoolsAnnotation = "strimzi.io/node-pools"
+	// the existing brokers are migrated to the node pool with the name, so they keep the pod names and the volumes
+	MigrationNodePoolName = "kafka"
+)
+
+// nodePoolsEnabled returns true if the kafka cluster is annotated to use the node pools
+func nodePoolsEnabled(annotations map[string]string) bool {
+	return annotations[NodePoolsAnnotation] == "enabled"
+}
+
+// ensureNodePools creates or updates the node pools of the mgh, and deletes the node pools which are removed from the
+// mgh. The node pools must exist before the kafka cluster is annotated to use them
+func (k *strimziTransporter) ensureNodePools(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	kafkaCluster := &unstructured.Unstructured{}
+	kafkaCluster.SetGroupVersionKind(KafkaGVK)
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      k.kafkaClusterName,
+		Namespace: k.kafkaClusterNamespace,
+	}, kafkaCluster)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	kafkaExists := err == nil
+
+	nodePools := mgh.Spec.DataLayer.Kafka.NodePools
+	if len(nodePools) == 0 {
+		if kafkaExists && nodePoolsEnabled(kafkaCluster.GetAnnotations()) {
+			return fmt.Errorf("the node pools can't be removed from the kafka cluster %s", k.kafkaClusterName)
+		}
+		return nil
+	}
+
+	desiredNames := map[string]bool{}
+	for _, nodePool := range nodePools {
+		desiredNames[nodePool.Name] = true
+	}
+	if kafkaExists && !nodePoolsEnabled(kafkaCluster.GetAnnotations()) && !desiredNames[MigrationNodePoolName] {
+		return fmt.Errorf("the existing brokers are migrated to the node pool %s, which isn't in the node pools",
+			MigrationNodePoolName)
+	}
+	for _, nodePool := range nodePools {
+		if err := k.ensureNodePool(k.newNodePoolSpec(mgh, nodePool), nodePool.Name); err != nil {
+			return fmt.Errorf("failed to ensure the node pool %s: %w", nodePool.Name, err)
+		}
+	}
+
+	// the new kafka cluster is created with the annotation
+	if kafkaExists && !nodePoolsEnabled(kafkaCluster.GetAnnotations()) {
+		k.log.Info("migrate the kafka brokers to the node pools")
+		patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:"enabled"}}}`, NodePoolsAnnotation))
+		if err := k.runtimeClient.Patch(k.ctx, kafkaCluster, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+	}
+
+	existingNodePools := &unstructured.UnstructuredList{}
+	existingNodePools.SetGroupVersionKind(KafkaNodePoolGVK)
+	err = k.runtimeClient.List(k.ctx, existingNodePools, client.InNamespace(k.kafkaClusterNamespace),
+		client.MatchingLabels{
+			"strimzi.io/cluster":             k.kafkaClusterName,
+			constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+		})
+	if err != nil {
+		return err
+	}
+	for i := range existingNodePools.Items {
+		nodePool := &existingNodePools.Items[i]
+		if desiredNames[nodePool.GetName()] {
+			continue
+		}
+		// the strimzi operator scales down the brokers of the pool, the partitions must be moved away before that
+		k.log.Info("delete the kafka node pool", "name", nodePool.GetName())
+		if err := k.runtimeClient.Delete(k.ctx, nodePool); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *strimziTransporter) ensureNodePool(desiredSpec map[string]interface{}, name string) error {
+	nodePool := &unstructured.Unstructured{}
+	nodePool.SetGroupVersionKind(KafkaNodePoolGVK)
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      name,
+		Namespace: k.kafkaClusterNamespace,
+	}, nodePool)
+	if errors.IsNotFound(err) {
+		nodePool.SetName(name)
+		nodePool.SetNamespace(k.kafkaClusterNamespace)
+		nodePool.SetLabels(map[string]string{
+			// It is important to set the cluster label otherwise the node pool will not be used
+			"strimzi.io/cluster":             k.kafkaClusterName,
+			constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+		})
+		nodePool.Object["spec"] = desiredSpec
+		k.log.Info("create the kafka node pool", "name", name)
+		return k.runtimeClient.Create(k.ctx, nodePool)
+	} else if err != nil {
+		return err
+	}
+
+	existingSpec, _, _ := unstructured.NestedMap(nodePool.Object, "spec")
+	retainRemovedNodePoolVolumes(existingSpec, desiredSpec)
+	if isEqualJSON(existingSpec, desiredSpec) {
+		return nil
+	}
+	nodePool.Object["spec"] = desiredSpec
+	k.log.Info("update the kafka node pool", "name", name)
+	return k.runtimeClient.Update(k.ctx, nodePool)
+}
+
+// newNodePoolSpec returns the spec of the broker node pool, the storage and the resources are inherited from the
+// kafka spec if they aren't set in the node pool
+func (k *strimziTransporter) newNodePoolSpec(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	nodePool operatorv1alpha4.KafkaNodePool,
+) map[string]interface{} {
+	storageSize := nodePool.StorageSize
+	if storageSize == "" {
+		storageSize = config.GetKafkaStorageSize(mgh)
+	}
+	volume := map[string]interface{}{
+		"id":          int64(KafkaStorageIdentifier),
+		"type":        "persistent-claim",
+		"size":        storageSize,
+		"deleteClaim": KafkaStorageDeleteClaim,
+	}
+	storageClass := nodePool.StorageClass
+	if storageClass == "" {
+		storageClass = mgh.Spec.DataLayer.StorageClass
+	}
+	if storageClass != "" {
+		volume["class"] = storageClass
+	}
+
+	spec := map[string]interface{}{
+		"replicas": int64(nodePool.Replicas),
+		// the zookeeper based kafka cluster only supports the broker role in the node pools
+		"roles": []interface{}{"broker"},
+		"storage": map[string]interface{}{
+			"type":    "jbod",
+			"volumes": append([]interface{}{volume}, newNodePoolVolumes(mgh)...),
+		},
+	}
+	if nodePool.Resources != nil {
+		resources := map[string]interface{}{}
+		jsonData, err := json.Marshal(nodePool.Resources)
+		if err == nil {
+			err = json.Unmarshal(jsonData, &resources)
+		}
+		if err != nil {
+			k.log.Error(err, "failed to set the resources of the kafka node pool", "name", nodePool.Name)
+		} else {
+			spec["resources"] = resources
+		}
+	}
+	return spec
+}
@@ -0,0 +1,68 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestNewNodePoolSpec(t *testing.T) {
+	k := &strimziTransporter{
+		log:              ctrl.Log.WithName("test"),
+		kafkaClusterName: KafkaClusterName,
+	}
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{
+		Spec: operatorv1alpha4.MulticlusterGlobalHubSpec{
+			DataLayer: operatorv1alpha4.DataLayerConfig{
+				StorageClass: "gp3",
+				Kafka: operatorv1alpha4.KafkaConfig{
+					StorageSize: "20Gi",
+				},
+			},
+		},
+	}
+
+	// the storage is inherited from the kafka
+	spec := k.newNodePoolSpec(mgh, operatorv1alpha4.KafkaNodePool{Name: "kafka", Replicas: 3})
+	assert.True(t, isEqualJSON(spec, map[string]interface{}{
+		"replicas": 3,
+		"roles":    []interface{}{"broker"},
+		"storage": map[string]interface{}{
+			"type": "jbod",
+			"volumes": []interface{}{
+				map[string]interface{}{
+					"id":          0,
+					"type":        "persistent-claim",
+					"size":        "20Gi",
+					"class":       "gp3",
+					"deleteClaim": false,
+				},
+			},
+		},
+	}))
+
+	// the larger brokers with the faster storage
+	spec = k.newNodePoolSpec(mgh, operatorv1alpha4.KafkaNodePool{
+		Name:         "large",
+		Replicas:     2,
+		StorageSize:  "100Gi",
+		StorageClass: "io2",
+		Resources: &operatorv1alpha4.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("8Gi"),
+			},
+		},
+	})
+	assert.Equal(t, int64(2), spec["replicas"])
+	volume := spec["storage"].(map[string]interface{})["volumes"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "100Gi", volume["size"])
+	assert.Equal(t, "io2", volume["class"])
+	assert.True(t, isEqualJSON(spec["resources"], map[string]interface{}{
+		"requests": map[string]interface{}{"memory": "8Gi"},
+	}))
+}
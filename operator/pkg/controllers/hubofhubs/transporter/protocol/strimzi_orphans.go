@@ -0,0 +1,165 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+// KafkaOrphanGracePeriod is the age of the kafka users and the topics before they're treated as orphans, so the
+// resources created right before the managed cluster aren't removed
+const KafkaOrphanGracePeriod = 10 * time.Minute
+
+// kafkaOrphan is the kafka user or the status topic of a managed hub which no longer exists
+type kafkaOrphan struct {
+	kind        string
+	name        string
+	clusterName string
+}
+
+func (o kafkaOrphan) String() string {
+	return fmt.Sprintf("%s %s (%s)", o.kind, o.name, o.clusterName)
+}
+
+// EnsureOrphans sweeps the kafka users and the status topics of the managed hubs whose managed clusters are removed,
+// e.g. the clusters are deleted without detaching them. The orphans are deleted unless the orphanPolicy is "dryRun".
+// The result is reflected to the KafkaOrphans condition
+func (k *strimziTransporter) EnsureOrphans() error {
+	dryRun := k.mgh.Spec.DataLayer.Kafka.OrphanPolicy == operatorv1alpha4.KafkaOrphanDryRun
+
+	managedClusters := &clusterv1.ManagedClusterList{}
+	if err := k.runtimeClient.List(k.ctx, managedClusters); err != nil {
+		return fmt.Errorf("failed to list the managed clusters: %w", err)
+	}
+	clusterNames := map[string]bool{}
+	for _, managedCluster := range managedClusters.Items {
+		clusterNames[managedCluster.Name] = true
+	}
+
+	labels := client.MatchingLabels{
+		"strimzi.io/cluster":             k.kafkaClusterName,
+		constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+	}
+	kafkaUsers := &kafkav1beta2.KafkaUserList{}
+	if err := k.runtimeClient.List(k.ctx, kafkaUsers, client.InNamespace(k.kafkaClusterNamespace), labels); err != nil {
+		return fmt.Errorf("failed to list the kafka users: %w", err)
+	}
+	kafkaTopics := &kafkav1beta2.KafkaTopicList{}
+	if err := k.runtimeClient.List(k.ctx, kafkaTopics, client.InNamespace(k.kafkaClusterNamespace), labels); err != nil {
+		return fmt.Errorf("failed to list the kafka topics: %w", err)
+	}
+
+	orphans := findKafkaOrphans(kafkaUsers.Items, kafkaTopics.Items, clusterNames, time.Now())
+	for _, orphan := range orphans {
+		k.log.Info("found the orphaned kafka resource", "orphan", orphan.String(), "dryRun", dryRun)
+		if dryRun {
+			continue
+		}
+		if err := k.deleteKafkaOrphan(orphan); err != nil {
+			return err
+		}
+	}
+
+	status, reason := metav1.ConditionFalse, config.CONDITION_REASON_KAFKA_NO_ORPHANS
+	message := "no kafka users or topics of the removed managed hubs are left"
+	if len(orphans) > 0 {
+		if dryRun {
+			status, reason = metav1.ConditionTrue, config.CONDITION_REASON_KAFKA_ORPHANS_FOUND
+			message = formatKafkaOrphans(orphans)
+		} else {
+			reason, message = config.CONDITION_REASON_KAFKA_ORPHANS_DELETED, "deleted "+formatKafkaOrphans(orphans)
+		}
+	}
+	// the deleted condition is kept for an interval, otherwise it's replaced right away by the reconciliation
+	// triggered by the deleted resources
+	if len(orphans) == 0 && recentCondition(k.mgh, config.CONDITION_TYPE_KAFKA_ORPHANS,
+		config.CONDITION_REASON_KAFKA_ORPHANS_DELETED) {
+		return nil
+	}
+	return config.SetConditionKafkaOrphans(k.ctx, k.runtimeClient, k.mgh, status, reason, message)
+}
+
+// findKafkaOrphans returns the kafka users and the per-hub status topics of the clusters which aren't in the managed
+// clusters. The resources of the manager, the shared topics and the resources in the grace period are skipped
+func findKafkaOrphans(kafkaUsers []kafkav1beta2.KafkaUser, kafkaTopics []kafkav1beta2.KafkaTopic,
+	clusterNames map[string]bool, now time.Time,
+) []kafkaOrphan {
+	orphans := []kafkaOrphan{}
+	for _, kafkaUser := range kafkaUsers {
+		clusterName := config.GetClusterNameByKafkaUser(kafkaUser.Name)
+		if kafkaUser.Name == DefaultGlobalHubKafkaUserName || config.GetKafkaUserName(clusterName) != kafkaUser.Name ||
+			clusterNames[clusterName] || now.Sub(kafkaUser.CreationTimestamp.Time) < KafkaOrphanGracePeriod {
+			continue
+		}
+		orphans = append(orphans, kafkaOrphan{kind: "KafkaUser", name: kafkaUser.Name, clusterName: clusterName})
+	}
+
+	// the shared status topic is kept until the mgh is removed
+	if !config.IsSharedStatusTopic() {
+		prefix, suffix, _ := strings.Cut(config.GetRawStatusTopic(), "*")
+		for _, kafkaTopic := range kafkaTopics {
+			if kafkaTopic.Name == config.StatusPlaceholderTopic() || kafkaTopic.Name == config.GetSpecTopic() ||
+				!strings.HasPrefix(kafkaTopic.Name, prefix) || !strings.HasSuffix(kafkaTopic.Name, suffix) ||
+				len(kafkaTopic.Name) <= len(prefix)+len(suffix) {
+				continue
+			}
+			clusterName := strings.TrimSuffix(strings.TrimPrefix(kafkaTopic.Name, prefix), suffix)
+			if clusterNames[clusterName] || now.Sub(kafkaTopic.CreationTimestamp.Time) < KafkaOrphanGracePeriod {
+				continue
+			}
+			orphans = append(orphans, kafkaOrphan{kind: "KafkaTopic", name: kafkaTopic.Name, clusterName: clusterName})
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].clusterName != orphans[j].clusterName {
+			return orphans[i].clusterName < orphans[j].clusterName
+		}
+		return orphans[i].kind < orphans[j].kind
+	})
+	return orphans
+}
+
+func (k *strimziTransporter) deleteKafkaOrphan(orphan kafkaOrphan) error {
+	var obj client.Object
+	switch orphan.kind {
+	case "KafkaUser":
+		obj = &kafkav1beta2.KafkaUser{}
+	default:
+		obj = &kafkav1beta2.KafkaTopic{}
+	}
+	obj.SetName(orphan.name)
+	obj.SetNamespace(k.kafkaClusterNamespace)
+	if err := k.runtimeClient.Delete(k.ctx, obj); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the orphaned %s %s: %w", orphan.kind, orphan.name, err)
+	}
+	k.log.Info("deleted the orphaned kafka resource", "orphan", orphan.String())
+	return nil
+}
+
+// formatKafkaOrphans joins the orphans for the condition message, only the first ones are listed
+func formatKafkaOrphans(orphans []kafkaOrphan) string {
+	messages := []string{}
+	for i, orphan := range orphans {
+		if i == maxReportedDrifts {
+			messages = append(messages, fmt.Sprintf("and %d more", len(orphans)-maxReportedDrifts))
+			break
+		}
+		messages = append(messages, orphan.String())
+	}
+	return strings.Join(messages, "; ")
+}
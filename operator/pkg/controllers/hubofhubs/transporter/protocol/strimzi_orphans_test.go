@@ -0,0 +1,66 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+func TestFindKafkaOrphans(t *testing.T) {
+	// the nats transport sets the topics without the kafka cluster
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.DataLayer.Nats = &operatorv1alpha4.NatsConfig{TransportSecretName: "nats"}
+	mgh.Spec.DataLayer.Kafka.KafkaTopics.SpecTopic = "gh-spec"
+	mgh.Spec.DataLayer.Kafka.KafkaTopics.StatusTopic = "gh-event.*"
+	assert.NoError(t, config.SetTransportConfig(context.Background(), nil, mgh))
+
+	now := time.Now()
+	created := metav1.NewTime(now.Add(-2 * KafkaOrphanGracePeriod))
+	newUser := func(name string, creationTimestamp metav1.Time) kafkav1beta2.KafkaUser {
+		return kafkav1beta2.KafkaUser{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: creationTimestamp}}
+	}
+	newTopic := func(name string, creationTimestamp metav1.Time) kafkav1beta2.KafkaTopic {
+		return kafkav1beta2.KafkaTopic{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: creationTimestamp}}
+	}
+	kafkaUsers := []kafkav1beta2.KafkaUser{
+		newUser(DefaultGlobalHubKafkaUserName, created),
+		newUser(KafkaConnectUserName, created),
+		newUser("hub1-kafka-user", created),
+		newUser("hub2-kafka-user", created),
+		// the user is created right before the managed cluster
+		newUser("hub3-kafka-user", metav1.NewTime(now)),
+	}
+	kafkaTopics := []kafkav1beta2.KafkaTopic{
+		newTopic("gh-spec", created),
+		newTopic(config.StatusPlaceholderTopic(), created),
+		newTopic("gh-event.hub1", created),
+		newTopic("gh-event.hub2", created),
+		newTopic("gh-event.hub3", metav1.NewTime(now)),
+	}
+
+	orphans := findKafkaOrphans(kafkaUsers, kafkaTopics, map[string]bool{"hub1": true}, now)
+	assert.Equal(t, []kafkaOrphan{
+		{kind: "KafkaTopic", name: "gh-event.hub2", clusterName: "hub2"},
+		{kind: "KafkaUser", name: "hub2-kafka-user", clusterName: "hub2"},
+	}, orphans)
+}
+
+func TestFormatKafkaOrphans(t *testing.T) {
+	orphan := kafkaOrphan{kind: "KafkaUser", name: "hub1-kafka-user", clusterName: "hub1"}
+	assert.Equal(t, "KafkaUser hub1-kafka-user (hub1)", formatKafkaOrphans([]kafkaOrphan{orphan}))
+
+	orphans := []kafkaOrphan{}
+	for i := 0; i < maxReportedDrifts+3; i++ {
+		orphan.clusterName = fmt.Sprintf("hub%d", i)
+		orphans = append(orphans, orphan)
+	}
+	assert.Contains(t, formatKafkaOrphans(orphans), "; and 3 more")
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"fmt"
+	"strconv"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+const (
+	KafkaRebalanceName = "kafka-rebalance"
+
+	// the annotations record the rebalance trigger and the number of the managed hubs of the last rebalance
+	rebalanceTriggerAnnotation = "global-hub.open-cluster-management.io/rebalance-trigger"
+	rebalanceHubsAnnotation    = "global-hub.open-cluster-management.io/rebalance-hubs"
+
+	// the annotations and the states of the strimzi kafka rebalance
+	strimziRebalanceAnnotation    = "strimzi.io/rebalance"
+	strimziRebalanceRefresh       = "refresh"
+	strimziAutoApprovalAnnotation = "strimzi.io/rebalance-auto-approval"
+	rebalanceStatePendingProposal = "PendingProposal"
+	rebalanceStateRebalancing     = "Rebalancing"
+)
+
+var KafkaRebalanceGVK = schema.GroupVersionKind{
+	Group:   "kafka.strimzi.io",
+	Version: "v1beta2",
+	Kind:    "KafkaRebalance",
+}
+
+// EnsureRebalance requests a rebalance of the kafka cluster with the cruise control when the rebalance annotation of
+// the mgh is changed, or the number of the managed hubs joined since the last rebalance reaches the threshold. The
+// proposal is approved automatically. It returns true if the last rebalance is still in progress, then the caller
+// should check it again later
+func (k *strimziTransporter) EnsureRebalance() (bool, error) {
+	cruiseControl := k.mgh.Spec.DataLayer.Kafka.CruiseControl
+	if cruiseControl == nil || k.existingCluster {
+		return false, nil
+	}
+
+	hubs, err := k.countHubUsers()
+	if err != nil {
+		return false, err
+	}
+	trigger := config.GetKafkaRebalanceTrigger(k.mgh)
+	// the autoscaler requests a rebalance when the brokers reach the max resources
+	autoscalingTrigger, err := k.getAutoscalingRebalanceTrigger()
+	if err != nil {
+		return false, err
+	}
+	if autoscalingTrigger != "" {
+		trigger = fmt.Sprintf("%s/%s", trigger, autoscalingTrigger)
+	}
+
+	rebalance := &unstructured.Unstructured{}
+	rebalance.SetGroupVersionKind(KafkaRebalanceGVK)
+	err = k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      KafkaRebalanceName,
+		Namespace: k.kafkaClusterNamespace,
+	}, rebalance)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	if errors.IsNotFound(err) {
+		if trigger == "" && !thresholdReached(cruiseControl.RebalanceHubThreshold, hubs, 0) {
+			return false, nil
+		}
+		return false, k.createRebalance(trigger, hubs)
+	}
+
+	annotations := rebalance.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	lastHubs, _ := strconv.Atoi(annotations[rebalanceHubsAnnotation])
+	if trigger == annotations[rebalanceTriggerAnnotation] &&
+		!thresholdReached(cruiseControl.RebalanceHubThreshold, hubs, lastHubs) {
+		return false, nil
+	}
+
+	state := getRebalanceState(rebalance)
+	if state == rebalanceStatePendingProposal || state == rebalanceStateRebalancing {
+		k.log.Info("the kafka rebalance is in progress, waiting", "state", state)
+		return true, nil
+	}
+
+	k.log.Info("refresh the kafka rebalance", "hubs", hubs, "lastHubs", lastHubs, "trigger", trigger)
+	annotations[strimziRebalanceAnnotation] = strimziRebalanceRefresh
+	annotations[rebalanceTriggerAnnotation] = trigger
+	annotations[rebalanceHubsAnnotation] = strconv.Itoa(hubs)
+	rebalance.SetAnnotations(annotations)
+	return false, k.runtimeClient.Update(k.ctx, rebalance)
+}
+
+func (k *strimziTransporter) createRebalance(trigger string, hubs int) error {
+	kafkaCluster := &kafkav1beta2.Kafka{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      k.kafkaClusterName,
+		Namespace: k.kafkaClusterNamespace,
+	}, kafkaCluster)
+	if err != nil {
+		return err
+	}
+
+	rebalance := &unstructured.Unstructured{}
+	rebalance.SetGroupVersionKind(KafkaRebalanceGVK)
+	rebalance.SetName(KafkaRebalanceName)
+	rebalance.SetNamespace(k.kafkaClusterNamespace)
+	rebalance.SetLabels(map[string]string{
+		"strimzi.io/cluster": k.kafkaClusterName,
+	})
+	rebalance.SetAnnotations(map[string]string{
+		strimziAutoApprovalAnnotation: "true",
+		rebalanceTriggerAnnotation:    trigger,
+		rebalanceHubsAnnotation:       strconv.Itoa(hubs),
+	})
+	// the full rebalance with the default goals of the cruise control
+	if err := unstructured.SetNestedMap(rebalance.Object, map[string]interface{}{}, "spec"); err != nil {
+		return err
+	}
+	// the rebalance is deleted with the kafka cluster
+	if err := controllerutil.SetOwnerReference(kafkaCluster, rebalance, k.runtimeClient.Scheme()); err != nil {
+		return err
+	}
+
+	k.log.Info("create the kafka rebalance", "hubs", hubs, "trigger", trigger)
+	return k.runtimeClient.Create(k.ctx, rebalance)
+}
+
+// countHubUsers returns the number of the kafka users of the managed hubs, the users of the manager and the kafka
+// connect are excluded
+func (k *strimziTransporter) countHubUsers() (int, error) {
+	kafkaUsers := &kafkav1beta2.KafkaUserList{}
+	err := k.runtimeClient.List(k.ctx, kafkaUsers, client.InNamespace(k.kafkaClusterNamespace),
+		client.MatchingLabels{"strimzi.io/cluster": k.kafkaClusterName})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the kafka users: %w", err)
+	}
+	hubs := 0
+	for _, kafkaUser := range kafkaUsers.Items {
+		if kafkaUser.Name != DefaultGlobalHubKafkaUserName && kafkaUser.Name != KafkaConnectUserName &&
+			kafkaUser.Name != KafkaBridgeUserName {
+			hubs++
+		}
+	}
+	return hubs, nil
+}
+
+func thresholdReached(threshold int32, hubs, lastHubs int) bool {
+	return threshold > 0 && hubs-lastHubs >= int(threshold)
+}
+
+// getRebalanceState returns the type of the ready condition of the kafka rebalance, which is the rebalance state
+func getRebalanceState(rebalance *unstructured.Unstructured) string {
+	conditions, _, _ := unstructured.NestedSlice(rebalance.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["status"] == "True" {
+			state, _ := condition["type"].(string)
+			return state
+		}
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	operatorutils "github.com/stolostron/multicluster-global-hub/operator/pkg/utils"
+)
+
+// ensureStorageExpansion grows the volumes of the brokers and the zookeeper nodes to the sizes of the mgh, if their
+// storage classes allow the volume expansion. The volumes can't be shrunk, so the smaller sizes are reported to the
+// mgh status instead of being ignored. The volumes aren't cached by the operator, so they're read from the api
+// server directly
+func (k *strimziTransporter) ensureStorageExpansion(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	err := k.manager.GetAPIReader().List(k.ctx, pvcs, client.InNamespace(k.kafkaClusterNamespace),
+		client.MatchingLabels{"strimzi.io/cluster": k.kafkaClusterName})
+	if err != nil {
+		return err
+	}
+
+	blocked, expanding := []string{}, []string{}
+	expandableClasses := map[string]bool{}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		desiredSize, ok := k.desiredVolumeSize(mgh, pvc.Name)
+		if !ok {
+			continue
+		}
+		desired, err := resource.ParseQuantity(desiredSize)
+		if err != nil {
+			return fmt.Errorf("invalid size %s of the volume %s: %w", desiredSize, pvc.Name, err)
+		}
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if desired.Cmp(requested) < 0 {
+			blocked = append(blocked, fmt.Sprintf("the volume %s can't be shrunk from %s to %s", pvc.Name,
+				requested.String(), desiredSize))
+			continue
+		}
+		if desired.Cmp(requested) > 0 {
+			expandable, err := k.storageClassExpandable(pvc.Spec.StorageClassName, expandableClasses)
+			if err != nil {
+				return err
+			}
+			if !expandable {
+				blocked = append(blocked, fmt.Sprintf("the storage class of the volume %s doesn't allow the expansion",
+					pvc.Name))
+				continue
+			}
+			k.log.Info("expand the kafka volume", "name", pvc.Name, "from", requested.String(), "to", desiredSize)
+			patch := client.MergeFrom(pvc.DeepCopy())
+			if pvc.Spec.Resources.Requests == nil {
+				pvc.Spec.Resources.Requests = corev1.ResourceList{}
+			}
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = desired
+			if err := k.runtimeClient.Patch(k.ctx, pvc, patch); err != nil {
+				return err
+			}
+			requested = desired
+		}
+		// the file system is resized by the csi driver after the volume, it may require the pod to be restarted
+		capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+		if capacity.Cmp(requested) < 0 {
+			expanding = append(expanding, pvc.Name)
+		}
+	}
+	return k.setStorageExpansionCondition(mgh, blocked, expanding)
+}
+
+func (k *strimziTransporter) setStorageExpansionCondition(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	blocked, expanding []string,
+) error {
+	switch {
+	case len(blocked) > 0:
+		return config.SetConditionKafkaStorageExpanded(k.ctx, k.runtimeClient, mgh, config.CONDITION_STATUS_FALSE,
+			config.CONDITION_REASON_KAFKA_STORAGE_BLOCKED, strings.Join(blocked, "; "))
+	case len(expanding) > 0:
+		return config.SetConditionKafkaStorageExpanded(k.ctx, k.runtimeClient, mgh, config.CONDITION_STATUS_FALSE,
+			config.CONDITION_REASON_KAFKA_STORAGE_EXPANDING, fmt.Sprintf("expanding the kafka volumes: %s",
+				strings.Join(expanding, ", ")))
+	default:
+		// only report the condition once the size of the volumes has been changed
+		if !config.ContainsCondition(mgh, config.CONDITION_TYPE_KAFKA_STORAGE_EXPANDED) {
+			return nil
+		}
+		return config.SetConditionKafkaStorageExpanded(k.ctx, k.runtimeClient, mgh, config.CONDITION_STATUS_TRUE,
+			config.CONDITION_REASON_KAFKA_STORAGE_EXPANDED, "the kafka volumes are expanded")
+	}
+}
+
+// storageClassExpandable returns true if the storage class allows the volume expansion, the classes are cached in
+// the expandableClasses since the volumes of the brokers usually share the same class
+func (k *strimziTransporter) storageClassExpandable(className *string, expandableClasses map[string]bool,
+) (bool, error) {
+	if className == nil || *className == "" {
+		return false, nil
+	}
+	if expandable, ok := expandableClasses[*className]; ok {
+		return expandable, nil
+	}
+	expandable, err := operatorutils.StorageClassExpandable(k.ctx, k.manager.GetAPIReader(), className)
+	if err != nil {
+		return false, err
+	}
+	expandableClasses[*className] = expandable
+	return expandable, nil
+}
+
+// desiredVolumeSize returns the size of the volume in the mgh by its name. The zookeeper volumes are named
+// "data-<cluster>-zookeeper-<index>", and the broker volumes are named "data-<id>-<cluster>-<pool>-<index>", the
+// pool of the brokers without the node pools is "kafka". The volumes removed from the mgh aren't resized
+func (k *strimziTransporter) desiredVolumeSize(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	pvcName string,
+) (string, bool) {
+	clusterName := regexp.QuoteMeta(k.kafkaClusterName)
+	if regexp.MustCompile(fmt.Sprintf(`^data-%s-zookeeper-\d+$`, clusterName)).MatchString(pvcName) {
+		return config.GetKafkaStorageSize(mgh), true
+	}
+	matches := regexp.MustCompile(fmt.Sprintf(`^data-(\d+)-%s-(.+)-\d+$`, clusterName)).FindStringSubmatch(pvcName)
+	if matches == nil {
+		return "", false
+	}
+	id, err := strconv.ParseInt(matches[1], 10, 32)
+	if err != nil {
+		return "", false
+	}
+	if int32(id) != KafkaStorageIdentifier {
+		for _, volume := range mgh.Spec.DataLayer.Kafka.Volumes {
+			if volume.ID == int32(id) {
+				return volume.Size, true
+			}
+		}
+		return "", false
+	}
+	for _, nodePool := range mgh.Spec.DataLayer.Kafka.NodePools {
+		if nodePool.Name == matches[2] && nodePool.StorageSize != "" {
+			return nodePool.StorageSize, true
+		}
+	}
+	return config.GetKafkaStorageSize(mgh), true
+}
@@ -0,0 +1,39 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestDesiredVolumeSize(t *testing.T) {
+	k := &strimziTransporter{kafkaClusterName: KafkaClusterName}
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.DataLayer.Kafka.StorageSize = "20Gi"
+	mgh.Spec.DataLayer.Kafka.Volumes = []operatorv1alpha4.KafkaVolume{{ID: 1, Size: "100Gi"}}
+	mgh.Spec.DataLayer.Kafka.NodePools = []operatorv1alpha4.KafkaNodePool{
+		{Name: "kafka", Replicas: 3},
+		{Name: "large-pool", Replicas: 2, StorageSize: "200Gi"},
+	}
+
+	cases := []struct {
+		pvcName string
+		size    string
+		found   bool
+	}{
+		{"data-kafka-zookeeper-0", "20Gi", true},
+		{"data-0-kafka-kafka-2", "20Gi", true},
+		{"data-1-kafka-kafka-2", "100Gi", true},
+		{"data-0-kafka-large-pool-0", "200Gi", true},
+		// the volume 2 is removed from the mgh
+		{"data-2-kafka-kafka-0", "", false},
+		{"data-postgres-0", "", false},
+	}
+	for _, c := range cases {
+		size, found := k.desiredVolumeSize(mgh, c.pvcName)
+		assert.Equal(t, c.found, found, c.pvcName)
+		assert.Equal(t, c.size, size, c.pvcName)
+	}
+}
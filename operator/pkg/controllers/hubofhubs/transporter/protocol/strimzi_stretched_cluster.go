@@ -0,0 +1,230 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+// the stretched kafka runs 2 brokers and 2 zookeeper nodes on each site, and 1 zookeeper node on the tie-breaker site.
+// Each partition is replicated to the 4 brokers, and the min.insync.replicas is 2, so the producers with acks=all
+// still work with the brokers of the surviving site
+const (
+	StretchedKafkaReplicas     = 4
+	StretchedZookeeperReplicas = 5
+	StretchedReplicationFactor = 4
+	StretchedMinInSyncReplicas = 2
+)
+
+// stretchedTopologyKey returns the node label of the sites of the stretched kafka
+func stretchedTopologyKey(stretched *operatorv1alpha4.KafkaStretchedCluster) string {
+	if stretched.TopologyKey != "" {
+		return stretched.TopologyKey
+	}
+	return ZoneTopologyKey
+}
+
+// validateStretchedCluster rejects the stretched kafka which can't survive the loss of a site
+func validateStretchedCluster(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	stretched := mgh.Spec.DataLayer.Kafka.Stretched
+	if stretched == nil {
+		return nil
+	}
+	if len(mgh.Spec.DataLayer.Kafka.NodePools) > 0 {
+		return fmt.Errorf("the stretched kafka doesn't support the node pools")
+	}
+	if len(stretched.Sites) != 2 || stretched.Sites[0] == stretched.Sites[1] {
+		return fmt.Errorf("the stretched kafka requires two different sites, but got %v", stretched.Sites)
+	}
+	for _, site := range stretched.Sites {
+		if site == stretched.TieBreakerSite {
+			return fmt.Errorf("the tie-breaker site %s must be different from the sites", site)
+		}
+	}
+	return nil
+}
+
+// setStretchedCluster sets the replicas and the replication factors of the stretched kafka, and places the brokers
+// on the sites and the zookeeper nodes on the sites and the tie-breaker site. The placement is merged into the
+// affinity rendered from the node selector of the mgh, so both of them are required
+func (k *strimziTransporter) setStretchedCluster(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	kafkaCluster *kafkav1beta2.Kafka,
+) error {
+	stretched := mgh.Spec.DataLayer.Kafka.Stretched
+	if stretched == nil {
+		return nil
+	}
+	kafkaCluster.Spec.Kafka.Replicas = StretchedKafkaReplicas
+	kafkaCluster.Spec.Zookeeper.Replicas = StretchedZookeeperReplicas
+
+	brokerConfig := map[string]interface{}{}
+	if err := json.Unmarshal(kafkaCluster.Spec.Kafka.Config.Raw, &brokerConfig); err != nil {
+		return fmt.Errorf("failed to unmarshal the kafka config: %w", err)
+	}
+	brokerConfig["default.replication.factor"] = StretchedReplicationFactor
+	brokerConfig["offsets.topic.replication.factor"] = StretchedReplicationFactor
+	brokerConfig["transaction.state.log.replication.factor"] = StretchedReplicationFactor
+	brokerConfig["min.insync.replicas"] = StretchedMinInSyncReplicas
+	brokerConfig["transaction.state.log.min.isr"] = StretchedMinInSyncReplicas
+	brokerConfigData, err := json.Marshal(brokerConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the kafka config: %w", err)
+	}
+	kafkaCluster.Spec.Kafka.Config = &apiextensions.JSON{Raw: brokerConfigData}
+
+	topologyKey := stretchedTopologyKey(stretched)
+	zookeeperSites := append(append([]string{}, stretched.Sites...), stretched.TieBreakerSite)
+	placement := map[string]interface{}{
+		"kafka":     siteAffinityPatch(siteNodeSelectorTerms(mgh, topologyKey, stretched.Sites)),
+		"zookeeper": siteAffinityPatch(siteNodeSelectorTerms(mgh, topologyKey, zookeeperSites)),
+	}
+	if err := mergeKafkaSpec(kafkaCluster, placement); err != nil {
+		return fmt.Errorf("failed to merge the placement of the stretched kafka into the kafka spec: %w", err)
+	}
+	return nil
+}
+
+// siteNodeSelectorTerms requires the nodes of the sites, and the nodes matched by the node selector of the mgh
+func siteNodeSelectorTerms(mgh *operatorv1alpha4.MulticlusterGlobalHub, topologyKey string,
+	sites []string,
+) []corev1.NodeSelectorTerm {
+	nodeSelectorReqs := []corev1.NodeSelectorRequirement{
+		{
+			Key:      topologyKey,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   sites,
+		},
+	}
+	keys := make([]string, 0, len(mgh.Spec.NodeSelector))
+	for key := range mgh.Spec.NodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		nodeSelectorReqs = append(nodeSelectorReqs, corev1.NodeSelectorRequirement{
+			Key:      key,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{mgh.Spec.NodeSelector[key]},
+		})
+	}
+	return []corev1.NodeSelectorTerm{{MatchExpressions: nodeSelectorReqs}}
+}
+
+func siteAffinityPatch(nodeSelectorTerms []corev1.NodeSelectorTerm) map[string]interface{} {
+	return map[string]interface{}{
+		"template": map[string]interface{}{
+			"pod": map[string]interface{}{
+				"affinity": map[string]interface{}{
+					"nodeAffinity": map[string]interface{}{
+						"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+							"nodeSelectorTerms": nodeSelectorTerms,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ensureKafkaTopology reports the running brokers and zookeeper nodes of each site to the mgh status. The pods and
+// the nodes aren't cached by the operator, so they're read from the api server directly
+func (k *strimziTransporter) ensureKafkaTopology(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	stretched := mgh.Spec.DataLayer.Kafka.Stretched
+	if stretched == nil {
+		if !config.ContainsCondition(mgh, config.CONDITION_TYPE_KAFKA_TOPOLOGY) {
+			return nil
+		}
+		return config.DeleteCondition(k.ctx, k.runtimeClient, mgh, config.CONDITION_TYPE_KAFKA_TOPOLOGY,
+			config.CONDITION_REASON_KAFKA_STRETCHED)
+	}
+
+	topologyKey := stretchedTopologyKey(stretched)
+	kafkaSites, err := k.countPodsBySite("kafka", topologyKey)
+	if err != nil {
+		return err
+	}
+	zookeeperSites, err := k.countPodsBySite("zookeeper", topologyKey)
+	if err != nil {
+		return err
+	}
+
+	status, reason := metav1.ConditionTrue, config.CONDITION_REASON_KAFKA_STRETCHED
+	if !stretchedTopologyReady(stretched, kafkaSites, zookeeperSites) {
+		status, reason = metav1.ConditionFalse, config.CONDITION_REASON_KAFKA_SITE_DEGRADED
+	}
+	return config.SetConditionKafkaTopology(k.ctx, k.runtimeClient, mgh, status, reason,
+		fmt.Sprintf("kafka: %s; zookeeper: %s", formatSiteCounts(kafkaSites), formatSiteCounts(zookeeperSites)))
+}
+
+// countPodsBySite counts the running pods of the strimzi component by the topology label of their nodes
+func (k *strimziTransporter) countPodsBySite(component, topologyKey string) (map[string]int, error) {
+	pods := &corev1.PodList{}
+	err := k.manager.GetAPIReader().List(k.ctx, pods, client.InNamespace(k.kafkaClusterNamespace),
+		client.MatchingLabels{
+			"strimzi.io/cluster": k.kafkaClusterName,
+			"strimzi.io/name":    fmt.Sprintf("%s-%s", k.kafkaClusterName, component),
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	sites := map[string]int{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Spec.NodeName == "" {
+			continue
+		}
+		node := &corev1.Node{}
+		if err := k.manager.GetAPIReader().Get(k.ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+			return nil, err
+		}
+		site, ok := node.Labels[topologyKey]
+		if !ok {
+			site = "unlabeled"
+		}
+		sites[site]++
+	}
+	return sites, nil
+}
+
+// stretchedTopologyReady returns true if both sites run the brokers, and all the sites run the zookeeper nodes
+func stretchedTopologyReady(stretched *operatorv1alpha4.KafkaStretchedCluster,
+	kafkaSites, zookeeperSites map[string]int,
+) bool {
+	for _, site := range stretched.Sites {
+		if kafkaSites[site] == 0 || zookeeperSites[site] == 0 {
+			return false
+		}
+	}
+	return zookeeperSites[stretched.TieBreakerSite] > 0
+}
+
+// formatSiteCounts formats the pod counts of the sites in order, e.g. "site-a=2,site-b=2"
+func formatSiteCounts(sites map[string]int) string {
+	if len(sites) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(sites))
+	for name := range sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	counts := make([]string, 0, len(names))
+	for _, name := range names {
+		counts = append(counts, fmt.Sprintf("%s=%d", name, sites[name]))
+	}
+	return strings.Join(counts, ",")
+}
@@ -0,0 +1,89 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"github.com/stretchr/testify/assert"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestSetStretchedCluster(t *testing.T) {
+	k := &strimziTransporter{log: ctrl.Log.WithName("test")}
+	kafkaCluster := &kafkav1beta2.Kafka{
+		Spec: &kafkav1beta2.KafkaSpec{
+			Kafka: kafkav1beta2.KafkaSpecKafka{
+				Config:   &apiextensions.JSON{Raw: []byte(`{"min.insync.replicas": 2, "num.io.threads": 16}`)},
+				Replicas: 3,
+				Listeners: []kafkav1beta2.KafkaSpecKafkaListenersElem{
+					{Name: "tls", Port: 9093, Tls: true, Type: kafkav1beta2.KafkaSpecKafkaListenersElemTypeRoute},
+				},
+				Storage: kafkav1beta2.KafkaSpecKafkaStorage{Type: kafkav1beta2.KafkaSpecKafkaStorageTypeEphemeral},
+			},
+			Zookeeper: kafkav1beta2.KafkaSpecZookeeper{
+				Replicas: 3,
+				Storage:  kafkav1beta2.KafkaSpecZookeeperStorage{Type: kafkav1beta2.KafkaSpecZookeeperStorageTypeEphemeral},
+			},
+		},
+	}
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	mgh.Spec.NodeSelector = map[string]string{"node-role.kubernetes.io/infra": ""}
+
+	// the kafka cluster isn't changed if it isn't stretched
+	assert.NoError(t, k.setStretchedCluster(mgh, kafkaCluster))
+	assert.EqualValues(t, 3, kafkaCluster.Spec.Kafka.Replicas)
+
+	mgh.Spec.DataLayer.Kafka.Stretched = &operatorv1alpha4.KafkaStretchedCluster{
+		Sites:          []string{"site-a", "site-b"},
+		TieBreakerSite: "site-c",
+	}
+	assert.NoError(t, validateStretchedCluster(mgh))
+	assert.NoError(t, k.setStretchedCluster(mgh, kafkaCluster))
+	assert.EqualValues(t, StretchedKafkaReplicas, kafkaCluster.Spec.Kafka.Replicas)
+	assert.EqualValues(t, StretchedZookeeperReplicas, kafkaCluster.Spec.Zookeeper.Replicas)
+
+	config := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(kafkaCluster.Spec.Kafka.Config.Raw, &config))
+	assert.EqualValues(t, StretchedReplicationFactor, config["default.replication.factor"])
+	assert.EqualValues(t, StretchedMinInSyncReplicas, config["min.insync.replicas"])
+	assert.EqualValues(t, 16, config["num.io.threads"])
+
+	// the brokers are placed on the sites, and the zookeeper nodes also on the tie-breaker site
+	if !assert.NotNil(t, kafkaCluster.Spec.Kafka.Template) || !assert.NotNil(t, kafkaCluster.Spec.Zookeeper.Template) {
+		return
+	}
+	brokerTerms := kafkaCluster.Spec.Kafka.Template.Pod.Affinity.NodeAffinity.
+		RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Len(t, brokerTerms, 1)
+	assert.Equal(t, ZoneTopologyKey, *brokerTerms[0].MatchExpressions[0].Key)
+	assert.Equal(t, []string{"site-a", "site-b"}, brokerTerms[0].MatchExpressions[0].Values)
+	assert.Equal(t, "node-role.kubernetes.io/infra", *brokerTerms[0].MatchExpressions[1].Key)
+	zookeeperTerms := kafkaCluster.Spec.Zookeeper.Template.Pod.Affinity.NodeAffinity.
+		RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Equal(t, []string{"site-a", "site-b", "site-c"}, zookeeperTerms[0].MatchExpressions[0].Values)
+
+	// the tie-breaker site must be a different failure domain
+	mgh.Spec.DataLayer.Kafka.Stretched.TieBreakerSite = "site-b"
+	assert.Error(t, validateStretchedCluster(mgh))
+}
+
+func TestStretchedTopologyReady(t *testing.T) {
+	stretched := &operatorv1alpha4.KafkaStretchedCluster{
+		Sites:          []string{"site-a", "site-b"},
+		TieBreakerSite: "site-c",
+	}
+	kafkaSites := map[string]int{"site-a": 2, "site-b": 2}
+	zookeeperSites := map[string]int{"site-a": 2, "site-b": 2, "site-c": 1}
+	assert.True(t, stretchedTopologyReady(stretched, kafkaSites, zookeeperSites))
+	assert.Equal(t, "site-a=2,site-b=2,site-c=1", formatSiteCounts(zookeeperSites))
+
+	// the site-b is lost
+	kafkaSites = map[string]int{"site-a": 2}
+	zookeeperSites = map[string]int{"site-a": 2, "site-c": 1}
+	assert.False(t, stretchedTopologyReady(stretched, kafkaSites, zookeeperSites))
+	assert.Equal(t, "none", formatSiteCounts(map[string]int{}))
+}
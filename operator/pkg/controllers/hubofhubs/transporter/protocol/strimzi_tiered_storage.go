@@ -0,0 +1,151 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+var KafkaGVK = schema.GroupVersionKind{
+	Group:   "kafka.strimzi.io",
+	Version: "v1beta2",
+	Kind:    "Kafka",
+}
+
+// ensureTieredStorage patches the tiered storage of the mgh to the kafka cluster. The tiered storage isn't in the
+// kafka api of the strimzi client, so it's merged into the kafka cluster with the json merge patch
+func (k *strimziTransporter) ensureTieredStorage(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	existingKafka := &unstructured.Unstructured{}
+	existingKafka.SetGroupVersionKind(KafkaGVK)
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      k.kafkaClusterName,
+		Namespace: k.kafkaClusterNamespace,
+	}, existingKafka)
+	if err != nil {
+		return err
+	}
+
+	desiredTieredStorage, desiredEnv, err := k.newTieredStorage(mgh)
+	if err != nil {
+		return err
+	}
+
+	existingTieredStorage, _, _ := unstructured.NestedMap(existingKafka.Object, "spec", "kafka", "tieredStorage")
+	existingEnv, _, _ := unstructured.NestedSlice(existingKafka.Object, "spec", "kafka", "template",
+		"kafkaContainer", "env")
+	if isEqualJSON(existingTieredStorage, desiredTieredStorage) && isEqualJSON(existingEnv, desiredEnv) {
+		return nil
+	}
+
+	// the nil values remove the tiered storage from the kafka cluster
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"kafka": map[string]interface{}{
+				"tieredStorage": desiredTieredStorage,
+				"template": map[string]interface{}{
+					"kafkaContainer": map[string]interface{}{
+						"env": desiredEnv,
+					},
+				},
+			},
+		},
+	}
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	k.log.Info("update the tiered storage of the kafka cluster", "enabled", desiredTieredStorage != nil)
+	return k.runtimeClient.Patch(k.ctx, existingKafka, client.RawPatch(types.MergePatchType, patchData))
+}
+
+// newTieredStorage returns the tiered storage of the kafka cluster and the environment variables of the brokers
+// from the credentials secret, both are nil if the tiered storage isn't enabled
+func (k *strimziTransporter) newTieredStorage(mgh *operatorv1alpha4.MulticlusterGlobalHub) (
+	map[string]interface{}, []interface{}, error,
+) {
+	tieredStorage := mgh.Spec.DataLayer.Kafka.TieredStorage
+	if tieredStorage == nil {
+		return nil, nil, nil
+	}
+
+	remoteStorageManager := map[string]interface{}{
+		"className": tieredStorage.ClassName,
+	}
+	if tieredStorage.ClassPath != "" {
+		remoteStorageManager["classPath"] = tieredStorage.ClassPath
+	}
+	if len(tieredStorage.Config) > 0 {
+		config := map[string]interface{}{}
+		for key, val := range tieredStorage.Config {
+			config[key] = val
+		}
+		remoteStorageManager["config"] = config
+	}
+	desiredTieredStorage := map[string]interface{}{
+		"type":                 "custom",
+		"remoteStorageManager": remoteStorageManager,
+	}
+
+	if tieredStorage.CredentialsSecretName == "" {
+		return desiredTieredStorage, nil, nil
+	}
+	secret := &corev1.Secret{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      tieredStorage.CredentialsSecretName,
+		Namespace: mgh.Namespace,
+	}, secret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the credentials secret of the tiered storage: %w", err)
+	}
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	env := []interface{}{}
+	for _, key := range keys {
+		env = append(env, map[string]interface{}{
+			"name": key,
+			"valueFrom": map[string]interface{}{
+				"secretKeyRef": map[string]interface{}{
+					"name": secret.Name,
+					"key":  key,
+				},
+			},
+		})
+	}
+	return desiredTieredStorage, env, nil
+}
+
+// isEqualJSON compares the objects after the json round trip, so the typed values and the values read from the
+// api server are compared by the content
+func isEqualJSON(existing, desired interface{}) bool {
+	existingData, err := json.Marshal(existing)
+	if err != nil {
+		return false
+	}
+	desiredData, err := json.Marshal(desired)
+	if err != nil {
+		return false
+	}
+	var existingObj, desiredObj interface{}
+	if err := json.Unmarshal(existingData, &existingObj); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(desiredData, &desiredObj); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(existingObj, desiredObj)
+}
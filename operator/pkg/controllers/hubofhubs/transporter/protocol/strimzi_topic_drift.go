@@ -0,0 +1,321 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+const (
+	// TopicDriftInterval is the period to compare the kafka topics and the kafka users of the managed hubs with the
+	// desired ones, besides the changes of them
+	TopicDriftInterval = 10 * time.Minute
+	// the drifts in the condition message are limited, the others are only counted
+	maxReportedDrifts = 10
+)
+
+// topicDrift is a field of the kafka topic or the kafka user which is different from the desired one
+type topicDrift struct {
+	kind     string
+	name     string
+	field    string
+	existing string
+	desired  string
+}
+
+func (d topicDrift) String() string {
+	return fmt.Sprintf("%s %s %s: %s (desired: %s)", d.kind, d.name, d.field, d.existing, d.desired)
+}
+
+// EnsureTopicDrift compares the kafka topics and the kafka users of the managed hubs with the desired ones, the drifts
+// are repaired unless the topicDriftPolicy is "report". The result is reflected to the TopicConfigDrift condition
+func (k *strimziTransporter) EnsureTopicDrift() error {
+	repair := k.mgh.Spec.DataLayer.Kafka.TopicDriftPolicy != operatorv1alpha4.KafkaTopicDriftReport
+
+	clusterNames, err := k.listHubClusterNames()
+	if err != nil {
+		return err
+	}
+	drifts := []topicDrift{}
+	checkedTopics := map[string]bool{}
+	for _, clusterName := range clusterNames {
+		topicDrifts, err := k.detectTopicDrift(clusterName, checkedTopics, repair)
+		if err != nil {
+			return fmt.Errorf("failed to check the kafka topics of the managed hub %s: %w", clusterName, err)
+		}
+		drifts = append(drifts, topicDrifts...)
+		userDrifts, err := k.detectUserDrift(clusterName, repair)
+		if err != nil {
+			return fmt.Errorf("failed to check the kafka user of the managed hub %s: %w", clusterName, err)
+		}
+		drifts = append(drifts, userDrifts...)
+	}
+
+	status, reason := metav1.ConditionFalse, config.CONDITION_REASON_TOPIC_CONFIG_IN_SYNC
+	message := "the kafka topics and the kafka users of the managed hubs are in sync"
+	if len(drifts) > 0 {
+		for _, drift := range drifts {
+			k.log.Info("the kafka resource is drifted", "drift", drift.String(), "repaired", repair)
+		}
+		if repair {
+			reason, message = config.CONDITION_REASON_TOPIC_CONFIG_REPAIRED, "repaired "+formatTopicDrifts(drifts)
+		} else {
+			status, reason = metav1.ConditionTrue, config.CONDITION_REASON_TOPIC_CONFIG_DRIFTED
+			message = formatTopicDrifts(drifts)
+		}
+	}
+	// the repaired condition is kept for an interval, otherwise it's replaced right away by the reconciliation
+	// triggered by the repaired resources
+	if len(drifts) == 0 && recentlyRepaired(k.mgh) {
+		return nil
+	}
+	return config.SetConditionTopicConfigDrift(k.ctx, k.runtimeClient, k.mgh, status, reason, message)
+}
+
+// recentlyRepaired returns true if the drifts are repaired in the last interval
+func recentlyRepaired(mgh *operatorv1alpha4.MulticlusterGlobalHub) bool {
+	return recentCondition(mgh, config.CONDITION_TYPE_TOPIC_CONFIG_DRIFT, config.CONDITION_REASON_TOPIC_CONFIG_REPAIRED)
+}
+
+// recentCondition returns true if the condition is transitioned to the reason in the last interval
+func recentCondition(mgh *operatorv1alpha4.MulticlusterGlobalHub, conditionType, reason string) bool {
+	for _, condition := range mgh.Status.Conditions {
+		if condition.Type == conditionType && condition.Reason == reason {
+			return time.Since(condition.LastTransitionTime.Time) < TopicDriftInterval
+		}
+	}
+	return false
+}
+
+// listHubClusterNames returns the managed hubs by their kafka users
+func (k *strimziTransporter) listHubClusterNames() ([]string, error) {
+	kafkaUsers := &kafkav1beta2.KafkaUserList{}
+	err := k.runtimeClient.List(k.ctx, kafkaUsers, client.InNamespace(k.kafkaClusterNamespace),
+		client.MatchingLabels{
+			"strimzi.io/cluster":             k.kafkaClusterName,
+			constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal,
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the kafka users: %w", err)
+	}
+	clusterNames := []string{}
+	for _, kafkaUser := range kafkaUsers.Items {
+		if kafkaUser.Name == DefaultGlobalHubKafkaUserName ||
+			config.GetKafkaUserName(config.GetClusterNameByKafkaUser(kafkaUser.Name)) != kafkaUser.Name {
+			continue
+		}
+		clusterNames = append(clusterNames, config.GetClusterNameByKafkaUser(kafkaUser.Name))
+	}
+	sort.Strings(clusterNames)
+	return clusterNames, nil
+}
+
+// detectTopicDrift compares the config and the partitions of the topics of the managed hub, the shared topics are
+// only checked once. The topics which aren't created yet are skipped
+func (k *strimziTransporter) detectTopicDrift(clusterName string, checkedTopics map[string]bool, repair bool) (
+	[]topicDrift, error,
+) {
+	clusterTopic := k.getClusterTopic(clusterName)
+	topics := []struct {
+		name        string
+		class       string
+		topicConfig *operatorv1alpha4.KafkaTopicConfig
+	}{
+		{clusterTopic.SpecTopic, SpecTopicClass, k.mgh.Spec.DataLayer.Kafka.SpecTopicConfig},
+		{clusterTopic.StatusTopic, StatusTopicClass, k.mgh.Spec.DataLayer.Kafka.StatusTopicConfig},
+	}
+
+	drifts := []topicDrift{}
+	for _, topic := range topics {
+		if checkedTopics[topic.name] {
+			continue
+		}
+		checkedTopics[topic.name] = true
+
+		kafkaTopic := &kafkav1beta2.KafkaTopic{}
+		err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+			Name:      topic.name,
+			Namespace: k.kafkaClusterNamespace,
+		}, kafkaTopic)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if kafkaTopic.Spec == nil {
+			kafkaTopic.Spec = &kafkav1beta2.KafkaTopicSpec{}
+		}
+
+		var tieredStorage *operatorv1alpha4.KafkaTieredStorage
+		if topic.class == StatusTopicClass {
+			tieredStorage = k.mgh.Spec.DataLayer.Kafka.TieredStorage
+		}
+		desiredTopic, err := k.newKafkaTopic(topic.name, topic.class, topic.topicConfig, tieredStorage)
+		if err != nil {
+			return nil, err
+		}
+
+		topicDrifts := []topicDrift{}
+		if !topicConfigEqual(kafkaTopic.Spec.Config, desiredTopic.Spec.Config) {
+			topicDrifts = append(topicDrifts, topicDrift{
+				kind:     "KafkaTopic",
+				name:     topic.name,
+				field:    "config",
+				existing: rawConfigString(kafkaTopic.Spec.Config),
+				desired:  rawConfigString(desiredTopic.Spec.Config),
+			})
+			kafkaTopic.Spec.Config = desiredTopic.Spec.Config
+		}
+		// the partitions can't be decreased, so only the missing partitions are drifts
+		if kafkaTopic.Spec.Partitions == nil || *kafkaTopic.Spec.Partitions < *desiredTopic.Spec.Partitions {
+			existing := "unset"
+			if kafkaTopic.Spec.Partitions != nil {
+				existing = fmt.Sprintf("%d", *kafkaTopic.Spec.Partitions)
+			}
+			topicDrifts = append(topicDrifts, topicDrift{
+				kind:     "KafkaTopic",
+				name:     topic.name,
+				field:    "partitions",
+				existing: existing,
+				desired:  fmt.Sprintf("%d", *desiredTopic.Spec.Partitions),
+			})
+			kafkaTopic.Spec.Partitions = desiredTopic.Spec.Partitions
+		}
+
+		if repair && len(topicDrifts) > 0 {
+			if err := k.runtimeClient.Update(k.ctx, kafkaTopic); err != nil {
+				return nil, err
+			}
+		}
+		drifts = append(drifts, topicDrifts...)
+	}
+	return drifts, nil
+}
+
+// detectUserDrift compares the acls of the kafka user of the managed hub, the user which isn't created yet is skipped
+func (k *strimziTransporter) detectUserDrift(clusterName string, repair bool) ([]topicDrift, error) {
+	kafkaUser := &kafkav1beta2.KafkaUser{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      config.GetKafkaUserName(clusterName),
+		Namespace: k.kafkaClusterNamespace,
+	}, kafkaUser)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if kafkaUser.Spec == nil {
+		kafkaUser.Spec = &kafkav1beta2.KafkaUserSpec{}
+	}
+
+	desiredKafkaUser := k.newHubKafkaUser(clusterName, kafkaUser)
+	existingACLs := []kafkav1beta2.KafkaUserSpecAuthorizationAclsElem{}
+	if kafkaUser.Spec.Authorization != nil {
+		existingACLs = kafkaUser.Spec.Authorization.Acls
+	}
+	missing, unexpected := diffACLs(existingACLs, desiredKafkaUser.Spec.Authorization.Acls)
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return nil, nil
+	}
+
+	drift := topicDrift{
+		kind:     "KafkaUser",
+		name:     kafkaUser.Name,
+		field:    "acls",
+		existing: "unexpected [" + strings.Join(unexpected, ", ") + "]",
+		desired:  "missing [" + strings.Join(missing, ", ") + "]",
+	}
+	if repair {
+		kafkaUser.Spec.Authorization = desiredKafkaUser.Spec.Authorization
+		if err := k.runtimeClient.Update(k.ctx, kafkaUser); err != nil {
+			return nil, err
+		}
+	}
+	return []topicDrift{drift}, nil
+}
+
+// diffACLs returns the desired acls which are missing from the existing ones, and the existing acls which aren't
+// desired
+func diffACLs(existing, desired []kafkav1beta2.KafkaUserSpecAuthorizationAclsElem) ([]string, []string) {
+	existingSet, desiredSet := map[string]bool{}, map[string]bool{}
+	for _, acl := range existing {
+		existingSet[aclString(acl)] = true
+	}
+	for _, acl := range desired {
+		desiredSet[aclString(acl)] = true
+	}
+	missing, unexpected := []string{}, []string{}
+	for acl := range desiredSet {
+		if !existingSet[acl] {
+			missing = append(missing, acl)
+		}
+	}
+	for acl := range existingSet {
+		if !desiredSet[acl] {
+			unexpected = append(unexpected, acl)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	return missing, unexpected
+}
+
+// aclString returns the readable acl, e.g. "Describe,Read topic/gh-spec(literal)", the operations are sorted so the
+// acls are compared regardless of the order
+func aclString(acl kafkav1beta2.KafkaUserSpecAuthorizationAclsElem) string {
+	operations := []string{}
+	for _, operation := range acl.Operations {
+		operations = append(operations, string(operation))
+	}
+	sort.Strings(operations)
+	name, patternType := "*", "literal"
+	if acl.Resource.Name != nil {
+		name = *acl.Resource.Name
+	}
+	if acl.Resource.PatternType != nil {
+		patternType = string(*acl.Resource.PatternType)
+	}
+	return fmt.Sprintf("%s %s/%s(%s)", strings.Join(operations, ","), acl.Resource.Type, name, patternType)
+}
+
+// rawConfigString returns the topic config in the compact json
+func rawConfigString(topicConfig *apiextensions.JSON) string {
+	if topicConfig == nil || len(topicConfig.Raw) == 0 {
+		return "{}"
+	}
+	buffer := &bytes.Buffer{}
+	if err := json.Compact(buffer, topicConfig.Raw); err != nil {
+		return string(topicConfig.Raw)
+	}
+	return buffer.String()
+}
+
+// formatTopicDrifts joins the drifts into the message of the condition
+func formatTopicDrifts(drifts []topicDrift) string {
+	messages := []string{}
+	for i, drift := range drifts {
+		if i == maxReportedDrifts {
+			messages = append(messages, fmt.Sprintf("and %d more", len(drifts)-maxReportedDrifts))
+			break
+		}
+		messages = append(messages, drift.String())
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,76 @@
+package protocol
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+)
+
+func TestDiffACLs(t *testing.T) {
+	desired := []kafkav1beta2.KafkaUserSpecAuthorizationAclsElem{
+		ConsumeGroupReadACL("global-hub-agent-hub1"),
+		ReadTopicACL("gh-spec", false),
+		WriteTopicACL("gh-status.hub1"),
+	}
+
+	// the order of the acls and their operations doesn't matter
+	readACL := ReadTopicACL("gh-spec", false)
+	readACL.Operations = []kafkav1beta2.KafkaUserSpecAuthorizationAclsElemOperationsElem{
+		kafkav1beta2.KafkaUserSpecAuthorizationAclsElemOperationsElemRead,
+		kafkav1beta2.KafkaUserSpecAuthorizationAclsElemOperationsElemDescribe,
+	}
+	existing := []kafkav1beta2.KafkaUserSpecAuthorizationAclsElem{
+		WriteTopicACL("gh-status.hub1"),
+		readACL,
+		ConsumeGroupReadACL("global-hub-agent-hub1"),
+	}
+	missing, unexpected := diffACLs(existing, desired)
+	assert.Empty(t, missing)
+	assert.Empty(t, unexpected)
+
+	// the acl of the spec topic is widened to all the topics
+	existing[1] = ReadTopicACL("gh-", true)
+	missing, unexpected = diffACLs(existing, desired)
+	assert.Equal(t, []string{"Describe,Read topic/gh-spec(literal)"}, missing)
+	assert.Equal(t, []string{"Describe,Read topic/gh-(prefix)"}, unexpected)
+}
+
+func TestFormatTopicDrifts(t *testing.T) {
+	drift := topicDrift{
+		kind:     "KafkaTopic",
+		name:     "gh-status.hub1",
+		field:    "partitions",
+		existing: "1",
+		desired:  "3",
+	}
+	assert.Equal(t, "KafkaTopic gh-status.hub1 partitions: 1 (desired: 3)", formatTopicDrifts([]topicDrift{drift}))
+
+	drifts := []topicDrift{}
+	for i := 0; i < maxReportedDrifts+2; i++ {
+		drift.name = fmt.Sprintf("gh-status.hub%d", i)
+		drifts = append(drifts, drift)
+	}
+	assert.Contains(t, formatTopicDrifts(drifts), "; and 2 more")
+}
+
+func TestRecentlyRepaired(t *testing.T) {
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	assert.False(t, recentlyRepaired(mgh))
+
+	mgh.Status.Conditions = []metav1.Condition{{
+		Type:               config.CONDITION_TYPE_TOPIC_CONFIG_DRIFT,
+		Reason:             config.CONDITION_REASON_TOPIC_CONFIG_REPAIRED,
+		LastTransitionTime: metav1.Now(),
+	}}
+	assert.True(t, recentlyRepaired(mgh))
+
+	mgh.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-TopicDriftInterval))
+	assert.False(t, recentlyRepaired(mgh))
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"strings"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+)
+
+// pruneStaleStatusTopics deletes the status topics of the previous layout once the sharing mode or the status topic
+// is changed. The agents are redeployed with the current status topic and resend the full status on startup, so the
+// bundles left in the stale topics aren't needed by the manager. The cluster lifecycle topic isn't a status topic, so
+// it's kept
+func (k *strimziTransporter) pruneStaleStatusTopics(mgh *v1alpha4.MulticlusterGlobalHub) error {
+	kafkaTopics := &kafkav1beta2.KafkaTopicList{}
+	err := k.runtimeClient.List(k.ctx, kafkaTopics, client.InNamespace(k.kafkaClusterNamespace),
+		client.MatchingLabels{constants.GlobalHubOwnerLabelKey: constants.GlobalHubOwnerLabelVal})
+	if err != nil {
+		return err
+	}
+	for i := range kafkaTopics.Items {
+		kafkaTopic := &kafkaTopics.Items[i]
+		if kafkaTopic.Name == config.GetClusterLifecycleTopic(mgh) ||
+			!isStaleStatusTopic(kafkaTopic.Name, config.GetSpecTopic(), config.GetRawStatusTopic()) {
+			continue
+		}
+		k.log.Info("delete the status topic of the previous layout", "topic", kafkaTopic.Name)
+		if err := k.runtimeClient.Delete(k.ctx, kafkaTopic); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isStaleStatusTopic returns true if the topic isn't the spec topic and doesn't belong to the current status topic
+// layout, e.g. "gh-event.hub1" is stale for the shared "gh-event", and "gh-event" is stale for the per-hub "gh-event.*"
+func isStaleStatusTopic(topicName, specTopic, rawStatusTopic string) bool {
+	if topicName == specTopic {
+		return false
+	}
+	if !strings.Contains(rawStatusTopic, "*") {
+		return topicName != rawStatusTopic
+	}
+	return !strings.HasPrefix(topicName, strings.Replace(rawStatusTopic, "*", "", -1))
+}
@@ -0,0 +1,29 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsStaleStatusTopic(t *testing.T) {
+	cases := []struct {
+		name           string
+		topicName      string
+		rawStatusTopic string
+		stale          bool
+	}{
+		{"spec topic", "gh-spec", "gh-event", false},
+		{"shared topic", "gh-event", "gh-event", false},
+		{"per-hub topic in shared mode", "gh-event.hub1", "gh-event", true},
+		{"per-hub topic", "gh-event.hub1", "gh-event.*", false},
+		{"per-hub placeholder topic", "gh-event.global-hub", "gh-event.*", false},
+		{"shared topic in per-hub mode", "gh-event", "gh-event.*", true},
+		{"renamed per-hub topic", "gh-status.hub1", "gh-event.*", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.stale, isStaleStatusTopic(c.topicName, "gh-spec", c.rawStatusTopic))
+		})
+	}
+}
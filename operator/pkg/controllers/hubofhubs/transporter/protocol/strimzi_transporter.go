@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,6 +26,7 @@ import (
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/klog"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -48,6 +51,19 @@ const (
 	// Global hub kafkaUser name
 	DefaultGlobalHubKafkaUserName = "global-hub-kafka-user"
 
+	// the plaintext listener inside the cluster, it's removed in the tls-only mode
+	PlainListenerName = "plain"
+	PlainListenerPort = 9092
+	// the listener for the agents which authenticate with the client certificate
+	TLSListenerName = "tls"
+	TLSListenerPort = 9093
+	// the listener for the agents which authenticate with scram-sha-512
+	ScramListenerName = "scram"
+	ScramListenerPort = 9094
+	// the mutual tls listener inside the cluster, the manager connects to it in the tls-only mode
+	InternalTLSListenerName = "internaltls"
+	InternalTLSListenerPort = 9095
+
 	// subscription - common
 	DefaultKafkaSubName           = "strimzi-kafka-operator"
 	DefaultInstallPlanApproval    = subv1alpha1.ApprovalAutomatic
@@ -62,6 +78,13 @@ const (
 	CommunityChannel           = "strimzi-0.40.x"
 	CommunityPackageName       = "strimzi-kafka-operator"
 	CommunityCatalogSourceName = "community-operators"
+
+	// the spec topic is compacted, only the latest spec of each resource is needed by the managed hubs
+	SpecTopicClass = "spec"
+	// the status topics are the append-only events of the managed hubs, the old messages are deleted by the time
+	StatusTopicClass = "status"
+	// DefaultStatusTopicRetentionMs is the "retention.ms" of the status topics if it isn't set, which is 7 days
+	DefaultStatusTopicRetentionMs int64 = 7 * 24 * 60 * 60 * 1000
 )
 
 var (
@@ -84,22 +107,27 @@ type strimziTransporter struct {
 	kafkaClusterNamespace string
 
 	// subscription properties
-	subName              string
-	subCommunity         bool
-	subChannel           string
-	subCatalogSourceName string
-	subPackageName       string
+	subName                   string
+	subCommunity              bool
+	subChannel                string
+	subCatalogSourceName      string
+	subCatalogSourceNamespace string
+	subPackageName            string
+	subInstallPlanApproval    subv1alpha1.Approval
 
 	// global hub config
 	mgh           *operatorv1alpha4.MulticlusterGlobalHub
 	runtimeClient client.Client
 	manager       ctrl.Manager
+	// the secrets of the kafka users and the clients CA are read by it, the secrets out of the global hub namespace
+	// aren't cached by the manager
+	secretReader client.Reader
+	// only manage the topics and the users on the kafka cluster of an existing strimzi operator
+	existingCluster bool
 
 	// wait until kafka cluster status is ready when initialize
-	waitReady bool
-	enableTLS bool
-	// default is false, to create topic for each managed hub
-	sharedTopics           bool
+	waitReady              bool
+	enableTLS              bool
 	topicPartitionReplicas int32
 }
 
@@ -114,21 +142,29 @@ func NewStrimziTransporter(mgr ctrl.Manager, mgh *operatorv1alpha4.MulticlusterG
 		kafkaClusterName:      KafkaClusterName,
 		kafkaClusterNamespace: mgh.Namespace,
 
-		subName:              DefaultKafkaSubName,
-		subCommunity:         false,
-		subChannel:           DefaultAMQChannel,
-		subPackageName:       DefaultAMQPackageName,
-		subCatalogSourceName: DefaultCatalogSourceName,
+		subName:                   DefaultKafkaSubName,
+		subCommunity:              false,
+		subChannel:                DefaultAMQChannel,
+		subPackageName:            DefaultAMQPackageName,
+		subCatalogSourceName:      DefaultCatalogSourceName,
+		subCatalogSourceNamespace: DefaultCatalogSourceNamespace,
+		subInstallPlanApproval:    DefaultInstallPlanApproval,
 
 		waitReady:              true,
 		enableTLS:              true,
-		sharedTopics:           false,
 		topicPartitionReplicas: DefaultPartitionReplicas,
 
 		manager:       mgr,
 		runtimeClient: mgr.GetClient(),
+		secretReader:  mgr.GetClient(),
 		mgh:           mgh,
 	}
+	if existing := mgh.Spec.DataLayer.Kafka.ExistingCluster; existing != nil {
+		k.kafkaClusterName = existing.Name
+		k.kafkaClusterNamespace = existing.Namespace
+		k.secretReader = mgr.GetAPIReader()
+		k.existingCluster = true
+	}
 	// apply options
 	for _, opt := range opts {
 		opt(k)
@@ -139,10 +175,14 @@ func NewStrimziTransporter(mgr ctrl.Manager, mgh *operatorv1alpha4.MulticlusterG
 		k.subPackageName = CommunityPackageName
 		k.subCatalogSourceName = CommunityCatalogSourceName
 	}
+	k.setSubscriptionConfig(mgh.Spec.DataLayer.Kafka.Subscription)
 
 	if mgh.Spec.AvailabilityConfig == operatorv1alpha4.HABasic {
 		k.topicPartitionReplicas = 1
 	}
+	if mgh.Spec.DataLayer.Kafka.Stretched != nil {
+		k.topicPartitionReplicas = StretchedReplicationFactor
+	}
 
 	err := k.ensureKafka(k.mgh)
 	if err != nil {
@@ -150,7 +190,11 @@ func NewStrimziTransporter(mgr ctrl.Manager, mgh *operatorv1alpha4.MulticlusterG
 	}
 
 	// use the client ca to sign the csr for the managed hubs
-	if err := config.SetClientCA(k.ctx, mgh.Namespace, KafkaClusterName, k.runtimeClient); err != nil {
+	clientCANamespace, clientCAName := mgh.Namespace, KafkaClusterName
+	if k.existingCluster {
+		clientCANamespace, clientCAName = k.kafkaClusterNamespace, k.kafkaClusterName
+	}
+	if err := config.SetClientCA(k.ctx, clientCANamespace, clientCAName, k.secretReader); err != nil {
 		return nil, err
 	}
 	return k, err
@@ -190,20 +234,60 @@ func WithWaitReady(wait bool) KafkaOption {
 // ensureKafka the kafka subscription, cluster, metrics, global hub user and topic
 func (k *strimziTransporter) ensureKafka(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
 	k.log.Info("reconcile global hub kafka transport...")
+	if k.existingCluster {
+		return k.ensureExistingKafka(mgh)
+	}
+	if err := validateStretchedCluster(mgh); err != nil {
+		return err
+	}
+	if err := validateJbodVolumes(mgh); err != nil {
+		return err
+	}
 	err := k.ensureSubscription(mgh)
 	if err != nil {
 		return err
 	}
+	// the kafka crds aren't installed until the install plan is approved, so it's handled before waiting for them
+	if err := k.ensureInstallPlanApproval(mgh); err != nil {
+		k.log.Error(err, "failed to handle the kafka install plan approval")
+	}
 	err = wait.PollUntilContextTimeout(k.ctx, 2*time.Second, 30*time.Second, true,
 		func(ctx context.Context) (bool, error) {
 			if !config.GetKafkaResourceReady() {
 				return false, fmt.Errorf("the kafka crds is not ready")
 			}
+			// the CAs must be issued before the kafka cluster is created, otherwise they're generated by strimzi
+			err = k.ensureCertManager(mgh)
+			if err != nil {
+				k.log.Info("the kafka CAs are not issued by the cert-manager, retrying...", "message", err.Error())
+				return false, nil
+			}
+			// the node pools must exist before the kafka cluster uses them
+			err = k.ensureNodePools(mgh)
+			if err != nil {
+				k.log.Info("the kafka node pools are not created, retrying...", "message", err.Error())
+				return false, nil
+			}
 			err, _ = k.CreateUpdateKafkaCluster(mgh)
 			if err != nil {
 				k.log.Info("the kafka cluster is not created, retrying...", "message", err.Error())
 				return false, nil
 			}
+			err = k.patchCertManagerCA(mgh)
+			if err != nil {
+				k.log.Info("the kafka CAs are not configured, retrying...", "message", err.Error())
+				return false, nil
+			}
+			err = k.ensureTieredStorage(mgh)
+			if err != nil {
+				k.log.Info("the kafka tiered storage is not configured, retrying...", "message", err.Error())
+				return false, nil
+			}
+			err = k.ensureZoneAwareness(mgh)
+			if err != nil {
+				k.log.Info("the kafka zone awareness is not configured, retrying...", "message", err.Error())
+				return false, nil
+			}
 			// kafka metrics, monitor, global hub kafkaTopic and kafkaUser
 			err = k.renderKafkaResources(mgh)
 			if err != nil {
@@ -225,18 +309,47 @@ func (k *strimziTransporter) ensureKafka(mgh *operatorv1alpha4.MulticlusterGloba
 		return err
 	}
 
+	if err := k.ensureKafkaTopology(mgh); err != nil {
+		// the transport shouldn't be blocked by the status of the mgh
+		k.log.Error(err, "failed to set the kafka topology condition")
+	}
+	if err := k.ensureStorageExpansion(mgh); err != nil {
+		k.log.Error(err, "failed to expand the kafka volumes")
+	}
+	if err := k.ensureKafkaConnect(mgh); err != nil {
+		k.log.Error(err, "failed to ensure the kafka connect")
+	}
+	if err := k.ensureKafkaBridge(mgh); err != nil {
+		k.log.Error(err, "failed to ensure the kafka bridge")
+	}
 	return nil
 }
 
+// statusTopicACLResource returns the name and the pattern type of the status topics in the acls of the kafka users,
+// the status topics with the wildcard are matched by the prefix
+func statusTopicACLResource() (string, kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResourcePatternType) {
+	if strings.Contains(config.GetRawStatusTopic(), "*") {
+		return strings.Replace(config.GetRawStatusTopic(), "*", "", -1),
+			kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResourcePatternTypePrefix
+	}
+	return config.GetRawStatusTopic(), kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResourcePatternTypeLiteral
+}
+
 // renderKafkaMetricsResources renders the kafka podmonitor and metrics, and kafkaUser and kafkaTopic for global hub
 func (k *strimziTransporter) renderKafkaResources(mgh *v1alpha4.MulticlusterGlobalHub) error {
-	statusTopic := config.GetRawStatusTopic()
-	statusPlaceholderTopic := config.GetRawStatusTopic()
-	topicParttern := kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResourcePatternTypeLiteral
-	if strings.Contains(config.GetRawStatusTopic(), "*") {
-		statusTopic = strings.Replace(config.GetRawStatusTopic(), "*", "", -1)
-		statusPlaceholderTopic = strings.Replace(config.GetRawStatusTopic(), "*", "global-hub", -1)
-		topicParttern = kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResourcePatternTypePrefix
+	statusTopic, topicParttern := statusTopicACLResource()
+	statusPlaceholderTopic := config.StatusPlaceholderTopic()
+	statusTopicConfig := getTopicConfig(StatusTopicClass, mgh.Spec.DataLayer.Kafka.StatusTopicConfig,
+		mgh.Spec.DataLayer.Kafka.TieredStorage)
+	statusTopicRegex, statusHubRegex := statusTopicRegex(config.GetRawStatusTopic())
+	lagAlert := getStatusLagAlert(mgh)
+	topicReplicas := DefaultPartitionReplicas
+	if mgh.Spec.DataLayer.Kafka.Stretched != nil && !k.existingCluster {
+		topicReplicas = StretchedReplicationFactor
+	}
+	namespace, kafkaCluster := mgh.GetNamespace(), KafkaClusterName
+	if k.existingCluster {
+		namespace, kafkaCluster = k.kafkaClusterNamespace, k.kafkaClusterName
 	}
 	// render the kafka objects
 	kafkaRenderer, kafkaDeployer := renderer.NewHoHRenderer(manifests), deployer.NewHoHDeployer(k.manager.GetClient())
@@ -251,24 +364,47 @@ func (k *strimziTransporter) renderKafkaResources(mgh *v1alpha4.MulticlusterGlob
 				StatusTopic            string
 				StatusTopicParttern    string
 				StatusPlaceholderTopic string
+				ClusterLifecycleTopic  string
+				StatusTopicConfig      map[string]interface{}
 				TopicPartition         int32
 				TopicReplicas          int32
+				ManagerConsumerGroup   string
+				StatusTopicRegex       string
+				StatusHubRegex         string
+				LagWarningThreshold    int64
+				LagCriticalThreshold   int64
+				LagAlertFor            string
 			}{
-				EnableMetrics:          mgh.Spec.EnableMetrics,
-				Namespace:              mgh.GetNamespace(),
-				KafkaCluster:           KafkaClusterName,
+				// the metrics of the existing kafka cluster are owned by its operator
+				EnableMetrics:          mgh.Spec.EnableMetrics && !k.existingCluster,
+				Namespace:              namespace,
+				KafkaCluster:           kafkaCluster,
 				GlobalHubKafkaUser:     DefaultGlobalHubKafkaUserName,
 				SpecTopic:              config.GetSpecTopic(),
 				StatusTopic:            statusTopic,
 				StatusTopicParttern:    string(topicParttern),
 				StatusPlaceholderTopic: statusPlaceholderTopic,
-				TopicPartition:         DefaultPartition,
-				TopicReplicas:          DefaultPartitionReplicas,
+				ClusterLifecycleTopic:  config.GetClusterLifecycleTopic(mgh),
+				StatusTopicConfig:      statusTopicConfig,
+				TopicPartition:         *getTopicPartitions(mgh.Spec.DataLayer.Kafka.StatusTopicConfig),
+				TopicReplicas:          topicReplicas,
+				ManagerConsumerGroup:   ManagerConsumerGroupID,
+				StatusTopicRegex:       statusTopicRegex,
+				StatusHubRegex:         statusHubRegex,
+				LagWarningThreshold:    lagAlert.WarningThreshold,
+				LagCriticalThreshold:   lagAlert.CriticalThreshold,
+				LagAlertFor:            lagAlert.For,
 			}, nil
 		})
 	if err != nil {
 		return fmt.Errorf("failed to render kafka manifests: %w", err)
 	}
+	if err := k.pruneStaleStatusTopics(mgh); err != nil {
+		return fmt.Errorf("failed to prune the status topics of the previous layout: %w", err)
+	}
+	if k.existingCluster {
+		return deployExistingKafkaObjects(kafkaObjects, kafkaDeployer)
+	}
 	// create restmapper for deployer to find GVR
 	dc, err := discovery.NewDiscoveryClientForConfig(k.manager.GetConfig())
 	if err != nil {
@@ -286,27 +422,26 @@ func (k *strimziTransporter) renderKafkaResources(mgh *v1alpha4.MulticlusterGlob
 // EnsureUser to reconcile the kafkaUser's setting(authn and authz)
 func (k *strimziTransporter) EnsureUser(clusterName string) (string, error) {
 	userName := config.GetKafkaUserName(clusterName)
-	clusterTopic := k.getClusterTopic(clusterName)
-
-	authnType := kafkav1beta2.KafkaUserSpecAuthenticationTypeTlsExternal
-	simpleACLs := []kafkav1beta2.KafkaUserSpecAuthorizationAclsElem{
-		ConsumeGroupReadACL(),
-		ReadTopicACL(clusterTopic.SpecTopic, false),
-		WriteTopicACL(clusterTopic.StatusTopic),
-	}
-
-	desiredKafkaUser := k.newKafkaUser(userName, authnType, simpleACLs)
 
 	kafkaUser := &kafkav1beta2.KafkaUser{}
 	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
 		Name:      userName,
 		Namespace: k.kafkaClusterNamespace,
 	}, kafkaUser)
-	if errors.IsNotFound(err) {
+	if err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+	userExists := err == nil
+
+	var existingKafkaUser *kafkav1beta2.KafkaUser
+	if userExists {
+		existingKafkaUser = kafkaUser
+	}
+	desiredKafkaUser := k.newHubKafkaUser(clusterName, existingKafkaUser)
+
+	if !userExists {
 		klog.Infof("create the kafakUser: %s", userName)
 		return userName, k.runtimeClient.Create(k.ctx, desiredKafkaUser, &client.CreateOptions{})
-	} else if err != nil {
-		return "", err
 	}
 
 	updatedKafkaUser := &kafkav1beta2.KafkaUser{}
@@ -315,7 +450,11 @@ func (k *strimziTransporter) EnsureUser(clusterName string) (string, error) {
 		return "", err
 	}
 
-	if !equality.Semantic.DeepDerivative(updatedKafkaUser.Spec, kafkaUser.Spec) {
+	// the quotas are owned by the mgh, so remove them from the kafka user once they're removed from the mgh
+	updatedKafkaUser.Spec.Quotas = desiredKafkaUser.Spec.Quotas
+
+	if !equality.Semantic.DeepDerivative(updatedKafkaUser.Spec, kafkaUser.Spec) ||
+		!equality.Semantic.DeepEqual(updatedKafkaUser.Spec.Quotas, kafkaUser.Spec.Quotas) {
 		klog.Infof("update the kafkaUser: %s", userName)
 		if err = k.runtimeClient.Update(k.ctx, updatedKafkaUser); err != nil {
 			return "", err
@@ -324,19 +463,73 @@ func (k *strimziTransporter) EnsureUser(clusterName string) (string, error) {
 	return userName, nil
 }
 
+// newHubKafkaUser returns the desired kafka user of the managed hub, the existing kafka user is nil if it isn't
+// created yet
+func (k *strimziTransporter) newHubKafkaUser(clusterName string,
+	existingKafkaUser *kafkav1beta2.KafkaUser,
+) *kafkav1beta2.KafkaUser {
+	clusterTopic := k.getClusterTopic(clusterName)
+	authnType := kafkav1beta2.KafkaUserSpecAuthenticationTypeTlsExternal
+	if k.scramEnabled(k.mgh) {
+		authnType = kafkav1beta2.KafkaUserSpecAuthenticationTypeScramSha512
+	}
+	simpleACLs := []kafkav1beta2.KafkaUserSpecAuthorizationAclsElem{
+		ConsumeGroupReadACL(config.GetConsumerGroupID(clusterName)),
+		ReadTopicACL(clusterTopic.SpecTopic, false),
+		WriteTopicACL(clusterTopic.StatusTopic),
+	}
+	if config.IsExactlyOnceDelivery(k.mgh) {
+		// the agent uses the cluster name as the transactional id of the status producer
+		simpleACLs = append(simpleACLs, TransactionalIDWriteACL(clusterName))
+	}
+	legacyGroup := ""
+	if existingKafkaUser != nil {
+		legacyGroup = legacyConsumerGroup(existingKafkaUser, clusterName)
+	}
+	if legacyGroup != "" {
+		simpleACLs = append(simpleACLs, ConsumeGroupReadACL(legacyGroup))
+	}
+
+	desiredKafkaUser := k.newKafkaUser(config.GetKafkaUserName(clusterName), authnType, simpleACLs)
+	desiredKafkaUser.Spec.Quotas = k.getKafkaUserQuotas(clusterName)
+	if legacyGroup != "" {
+		desiredKafkaUser.Annotations = map[string]string{LegacyConsumerGroupAnnotation: legacyGroup}
+	}
+	return desiredKafkaUser
+}
+
 func (k *strimziTransporter) EnsureTopic(clusterName string) (*transport.ClusterTopic, error) {
 	clusterTopic := k.getClusterTopic(clusterName)
 
 	topicNames := []string{clusterTopic.SpecTopic, clusterTopic.StatusTopic}
+	topicClasses := map[string]string{
+		clusterTopic.SpecTopic:   SpecTopicClass,
+		clusterTopic.StatusTopic: StatusTopicClass,
+	}
+	topicConfigs := map[string]*operatorv1alpha4.KafkaTopicConfig{
+		clusterTopic.SpecTopic:   k.mgh.Spec.DataLayer.Kafka.SpecTopicConfig,
+		clusterTopic.StatusTopic: k.mgh.Spec.DataLayer.Kafka.StatusTopicConfig,
+	}
 
 	for _, topicName := range topicNames {
+		// only the status topics are offloaded to the tiered storage
+		var tieredStorage *operatorv1alpha4.KafkaTieredStorage
+		if topicName == clusterTopic.StatusTopic {
+			tieredStorage = k.mgh.Spec.DataLayer.Kafka.TieredStorage
+		}
+		desiredTopic, err := k.newKafkaTopic(topicName, topicClasses[topicName], topicConfigs[topicName],
+			tieredStorage)
+		if err != nil {
+			return nil, err
+		}
+
 		kafkaTopic := &kafkav1beta2.KafkaTopic{}
-		err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		err = k.runtimeClient.Get(k.ctx, types.NamespacedName{
 			Name:      topicName,
 			Namespace: k.kafkaClusterNamespace,
 		}, kafkaTopic)
 		if errors.IsNotFound(err) {
-			if e := k.runtimeClient.Create(k.ctx, k.newKafkaTopic(topicName)); e != nil {
+			if e := k.runtimeClient.Create(k.ctx, desiredTopic); e != nil {
 				return nil, e
 			}
 			continue // reconcile the next topic
@@ -345,7 +538,6 @@ func (k *strimziTransporter) EnsureTopic(clusterName string) (*transport.Cluster
 		}
 
 		// update the topic
-		desiredTopic := k.newKafkaTopic(topicName)
 
 		updatedTopic := &kafkav1beta2.KafkaTopic{}
 		err = utils.MergeObjects(kafkaTopic, desiredTopic, updatedTopic)
@@ -354,6 +546,17 @@ func (k *strimziTransporter) EnsureTopic(clusterName string) (*transport.Cluster
 		}
 		// Kafka do not support change exitsting kafaka topic replica directly.
 		updatedTopic.Spec.Replicas = kafkaTopic.Spec.Replicas
+		// Kafka only supports increasing the partitions, keep the existing partitions if they're more than desired
+		if kafkaTopic.Spec.Partitions != nil && *kafkaTopic.Spec.Partitions > *desiredTopic.Spec.Partitions {
+			k.log.Info("the partitions of the topic can't be decreased", "topic", topicName,
+				"existing", *kafkaTopic.Spec.Partitions, "desired", *desiredTopic.Spec.Partitions)
+			updatedTopic.Spec.Partitions = kafkaTopic.Spec.Partitions
+		}
+		// the config is owned by the mgh, so the removed settings are also removed from the existing topic
+		updatedTopic.Spec.Config = kafkaTopic.Spec.Config
+		if !topicConfigEqual(kafkaTopic.Spec.Config, desiredTopic.Spec.Config) {
+			updatedTopic.Spec.Config = desiredTopic.Spec.Config
+		}
 
 		if !equality.Semantic.DeepDerivative(updatedTopic.Spec, kafkaTopic.Spec) {
 			if err = k.runtimeClient.Update(k.ctx, updatedTopic); err != nil {
@@ -382,7 +585,7 @@ func (k *strimziTransporter) Prune(clusterName string) error {
 	}
 
 	// only delete the topic when removing the CR, otherwise the manager throws error like "Unknown topic or partition"
-	// if k.sharedTopics || !strings.Contains(config.GetRawStatusTopic(), "*") {
+	// if config.IsSharedStatusTopic() {
 	// 	return nil
 	// }
 
@@ -416,7 +619,7 @@ func (k *strimziTransporter) getClusterTopic(clusterName string) *transport.Clus
 // the username is the kafkauser, it's the same as the secret name
 func (k *strimziTransporter) GetConnCredential(clusterName string) (*transport.KafkaConnCredential, error) {
 	// bootstrapServer, clusterId, clusterCA
-	credential, err := k.getConnCredentailByCluster()
+	credential, err := k.getConnCredentailByCluster(hubListenerName(k.mgh))
 	if err != nil {
 		return nil, err
 	}
@@ -429,6 +632,19 @@ func (k *strimziTransporter) GetConnCredential(clusterName string) (*transport.K
 	credential.StatusTopic = config.GetStatusTopic(clusterName)
 	credential.SpecTopic = config.GetSpecTopic()
 
+	// consumer group
+	credential.ConsumerGroupID = config.GetConsumerGroupID(clusterName)
+	credential.LegacyConsumerGroupID, err = k.getLegacyConsumerGroup(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	if k.scramEnabled(k.mgh) {
+		if err := k.loadScramCredential(config.GetKafkaUserName(clusterName), credential); err != nil {
+			return nil, err
+		}
+	}
+
 	// don't need to load the client cert/key from the kafka user, since it use the external kafkaUser
 	// userName := config.GetKafkaUserName(clusterName)
 	// if !k.enableTLS {
@@ -448,7 +664,7 @@ func GetClusterCASecret(clusterName string) string {
 // loadUserCredentail add credential with client cert, and key
 func (k *strimziTransporter) loadUserCredentail(kafkaUserName string, credential *transport.KafkaConnCredential) error {
 	kafkaUserSecret := &corev1.Secret{}
-	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+	err := k.secretReader.Get(k.ctx, types.NamespacedName{
 		Name:      kafkaUserName,
 		Namespace: k.kafkaClusterNamespace,
 	}, kafkaUserSecret)
@@ -460,8 +676,69 @@ func (k *strimziTransporter) loadUserCredentail(kafkaUserName string, credential
 	return nil
 }
 
-// getConnCredentailByCluster gets credential with clusterId, bootstrapServer, and serverCA
-func (k *strimziTransporter) getConnCredentailByCluster() (*transport.KafkaConnCredential, error) {
+// loadScramCredential replaces the client certificate with the username and password generated by the user operator,
+// and the bootstrap server with the scram listener
+func (k *strimziTransporter) loadScramCredential(kafkaUserName string,
+	credential *transport.KafkaConnCredential,
+) error {
+	kafkaCluster := &kafkav1beta2.Kafka{}
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      k.kafkaClusterName,
+		Namespace: k.kafkaClusterNamespace,
+	}, kafkaCluster)
+	if err != nil {
+		return err
+	}
+	scramListener := getListenerStatus(kafkaCluster, ScramListenerName)
+	if scramListener == nil || scramListener.BootstrapServers == nil {
+		return fmt.Errorf("the scram listener of the kafka cluster %s is not ready", kafkaCluster.Name)
+	}
+
+	kafkaUserSecret := &corev1.Secret{}
+	err = k.secretReader.Get(k.ctx, types.NamespacedName{
+		Name:      kafkaUserName,
+		Namespace: k.kafkaClusterNamespace,
+	}, kafkaUserSecret)
+	if err != nil {
+		return fmt.Errorf("failed to get the password of the kafka user %s: %w", kafkaUserName, err)
+	}
+
+	credential.BootstrapServer = *scramListener.BootstrapServers
+	credential.ClientSecretName = ""
+	credential.SASLMechanism = transport.ScramSha512
+	credential.SASLUsername = kafkaUserName
+	credential.SASLPassword = base64.StdEncoding.EncodeToString(kafkaUserSecret.Data["password"])
+	return nil
+}
+
+// getListenerStatus returns the status of the kafka listener with the name, or nil if it isn't exposed yet. The
+// listeners are looked up by the name, since the plain listener doesn't exist in the tls-only mode
+func getListenerStatus(kafkaCluster *kafkav1beta2.Kafka, name string) *kafkav1beta2.KafkaStatusListenersElem {
+	if kafkaCluster.Status == nil {
+		return nil
+	}
+	for i, listener := range kafkaCluster.Status.Listeners {
+		if listener.Name != nil && *listener.Name == name {
+			return &kafkaCluster.Status.Listeners[i]
+		}
+	}
+	return nil
+}
+
+// managerListenerName returns the listener of the manager, it connects to the internal mutual tls listener instead of
+// the external one in the tls-only mode
+func managerListenerName(mgh *operatorv1alpha4.MulticlusterGlobalHub) string {
+	if mgh.Spec.DataLayer.Kafka.ExistingCluster != nil {
+		return hubListenerName(mgh)
+	}
+	if mgh.Spec.DataLayer.Kafka.TLSOnly {
+		return InternalTLSListenerName
+	}
+	return TLSListenerName
+}
+
+// getConnCredentailByCluster gets credential with clusterId, bootstrapServer, and serverCA of the listener
+func (k *strimziTransporter) getConnCredentailByCluster(listenerName string) (*transport.KafkaConnCredential, error) {
 	kafkaCluster := &kafkav1beta2.Kafka{}
 	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
 		Name:      k.kafkaClusterName,
@@ -481,10 +758,21 @@ func (k *strimziTransporter) getConnCredentailByCluster() (*transport.KafkaConnC
 			if kafkaCluster.Status.ClusterId != nil {
 				clusterIdentity = *kafkaCluster.Status.ClusterId
 			}
+			listener := getListenerStatus(kafkaCluster, listenerName)
+			if listener == nil || listener.BootstrapServers == nil || len(listener.Certificates) == 0 {
+				return nil, fmt.Errorf("the %s listener of the kafka cluster %s is not ready", listenerName,
+					kafkaCluster.Name)
+			}
 			credential := &transport.KafkaConnCredential{
 				ClusterID:       clusterIdentity,
-				BootstrapServer: *kafkaCluster.Status.Listeners[1].BootstrapServers,
-				CACert:          base64.StdEncoding.EncodeToString([]byte(kafkaCluster.Status.Listeners[1].Certificates[0])),
+				BootstrapServer: *listener.BootstrapServers,
+				CACert:          base64.StdEncoding.EncodeToString([]byte(listener.Certificates[0])),
+			}
+			// trust both the current and the previous cluster CA during the renewal by the cert-manager
+			if k.mgh.Spec.DataLayer.Kafka.CertManager != nil && !k.existingCluster {
+				if err := k.loadCertManagerClusterCA(credential); err != nil {
+					return nil, err
+				}
 			}
 			return credential, nil
 		}
@@ -492,7 +780,13 @@ func (k *strimziTransporter) getConnCredentailByCluster() (*transport.KafkaConnC
 	return nil, fmt.Errorf("kafka cluster %s/%s is not ready", k.kafkaClusterNamespace, k.kafkaClusterName)
 }
 
-func (k *strimziTransporter) newKafkaTopic(topicName string) *kafkav1beta2.KafkaTopic {
+func (k *strimziTransporter) newKafkaTopic(topicName, topicClass string,
+	topicConfig *operatorv1alpha4.KafkaTopicConfig, tieredStorage *operatorv1alpha4.KafkaTieredStorage,
+) (*kafkav1beta2.KafkaTopic, error) {
+	topicConfigData, err := json.Marshal(getTopicConfig(topicClass, topicConfig, tieredStorage))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the config of the topic %s: %w", topicName, err)
+	}
 	return &kafkav1beta2.KafkaTopic{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      topicName,
@@ -504,13 +798,74 @@ func (k *strimziTransporter) newKafkaTopic(topicName string) *kafkav1beta2.Kafka
 			},
 		},
 		Spec: &kafkav1beta2.KafkaTopicSpec{
-			Partitions: &DefaultPartition,
+			Partitions: getTopicPartitions(topicConfig),
 			Replicas:   &k.topicPartitionReplicas,
-			Config: &apiextensions.JSON{Raw: []byte(`{
-				"cleanup.policy": "compact"
-			}`)},
+			Config:     &apiextensions.JSON{Raw: topicConfigData},
 		},
+	}, nil
+}
+
+// getTopicConfig returns the kafka config of the topic from the mgh. The spec topic is compacted by default, and the
+// status topics are deleted after the DefaultStatusTopicRetentionMs by default. The remote storage is only enabled
+// for the topics with the "delete" cleanup policy, kafka doesn't support offloading the compacted topics
+func getTopicConfig(topicClass string, topicConfig *operatorv1alpha4.KafkaTopicConfig,
+	tieredStorage *operatorv1alpha4.KafkaTieredStorage,
+) map[string]interface{} {
+	if topicConfig == nil {
+		topicConfig = &operatorv1alpha4.KafkaTopicConfig{}
 	}
+	cleanupPolicy := topicConfig.CleanupPolicy
+	if cleanupPolicy == "" {
+		cleanupPolicy = "compact"
+		if topicClass == StatusTopicClass {
+			cleanupPolicy = "delete"
+		}
+	}
+	kafkaConfig := map[string]interface{}{
+		"cleanup.policy": cleanupPolicy,
+	}
+	if tieredStorage != nil && cleanupPolicy == "delete" {
+		kafkaConfig["remote.storage.enable"] = true
+		if tieredStorage.LocalRetentionMs != nil {
+			kafkaConfig["local.retention.ms"] = *tieredStorage.LocalRetentionMs
+		}
+	}
+	if topicConfig.RetentionMs != nil {
+		kafkaConfig["retention.ms"] = *topicConfig.RetentionMs
+	} else if topicClass == StatusTopicClass && strings.Contains(cleanupPolicy, "delete") {
+		kafkaConfig["retention.ms"] = DefaultStatusTopicRetentionMs
+	}
+	if topicConfig.RetentionBytes != nil {
+		kafkaConfig["retention.bytes"] = *topicConfig.RetentionBytes
+	}
+	if topicConfig.SegmentBytes != nil {
+		kafkaConfig["segment.bytes"] = *topicConfig.SegmentBytes
+	}
+	return kafkaConfig
+}
+
+// getTopicPartitions returns the partitions of the topic from the mgh, the default value is 1
+func getTopicPartitions(topicConfig *operatorv1alpha4.KafkaTopicConfig) *int32 {
+	if topicConfig == nil || topicConfig.Partitions == nil {
+		return &DefaultPartition
+	}
+	partitions := *topicConfig.Partitions
+	return &partitions
+}
+
+// topicConfigEqual compares the content of the topic configs, regardless of the format of the raw json
+func topicConfigEqual(existing, desired *apiextensions.JSON) bool {
+	if existing == nil || desired == nil {
+		return existing == desired
+	}
+	existingConfig, desiredConfig := map[string]interface{}{}, map[string]interface{}{}
+	if err := json.Unmarshal(existing.Raw, &existingConfig); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(desired.Raw, &desiredConfig); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(existingConfig, desiredConfig)
 }
 
 func (k *strimziTransporter) newKafkaUser(
@@ -540,6 +895,53 @@ func (k *strimziTransporter) newKafkaUser(
 	}
 }
 
+// scramEnabled returns whether the agents authenticate with scram-sha-512 instead of the client certificate
+func (k *strimziTransporter) scramEnabled(mgh *operatorv1alpha4.MulticlusterGlobalHub) bool {
+	return mgh.Spec.DataLayer.Kafka.HubAuthentication == operatorv1alpha4.KafkaAuthenticationScramSha512
+}
+
+// getKafkaUserQuotas returns the quotas of the managed hub kafka user, so that a noisy hub is throttled by the
+// brokers instead of flooding the status topic. The producer byte rate of the mgh is overridden by the annotation of
+// the managed cluster
+func (k *strimziTransporter) getKafkaUserQuotas(clusterName string) *kafkav1beta2.KafkaUserSpecQuotas {
+	quotas := &kafkav1beta2.KafkaUserSpecQuotas{}
+	if hubQuotas := k.mgh.Spec.DataLayer.Kafka.HubQuotas; hubQuotas != nil {
+		quotas.ProducerByteRate = hubQuotas.ProducerByteRate
+		quotas.ConsumerByteRate = hubQuotas.ConsumerByteRate
+		quotas.RequestPercentage = hubQuotas.RequestPercentage
+	}
+	if producerByteRate := k.getClusterProducerByteRate(clusterName); producerByteRate != nil {
+		quotas.ProducerByteRate = producerByteRate
+	}
+	if quotas.ProducerByteRate == nil && quotas.ConsumerByteRate == nil && quotas.RequestPercentage == nil {
+		return nil
+	}
+	return quotas
+}
+
+// getClusterProducerByteRate returns the producer byte rate in the annotation of the managed cluster, it's nil if the
+// annotation isn't set or invalid
+func (k *strimziTransporter) getClusterProducerByteRate(clusterName string) *int32 {
+	cluster := &clusterv1.ManagedCluster{}
+	if err := k.runtimeClient.Get(k.ctx, types.NamespacedName{Name: clusterName}, cluster); err != nil {
+		if !errors.IsNotFound(err) {
+			k.log.Error(err, "failed to get the managed cluster", "name", clusterName)
+		}
+		return nil
+	}
+	val, ok := cluster.Annotations[operatorconstants.AnnotationClusterProducerByteRate]
+	if !ok {
+		return nil
+	}
+	producerByteRate, err := strconv.ParseInt(val, 10, 32)
+	if err != nil || producerByteRate <= 0 {
+		k.log.Info("ignore the invalid producer byte rate", "cluster", clusterName, "value", val)
+		return nil
+	}
+	rate := int32(producerByteRate)
+	return &rate
+}
+
 // waits for kafka cluster to be ready and returns nil if kafka cluster ready
 func (k *strimziTransporter) kafkaClusterReady() error {
 	k.log.Info("waiting the kafka cluster instance to be ready...")
@@ -602,7 +1004,11 @@ func (k *strimziTransporter) CreateUpdateKafkaCluster(mgh *operatorv1alpha4.Mult
 	}, existingKafka)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return k.runtimeClient.Create(k.ctx, k.newKafkaCluster(mgh)), true
+			kafkaCluster, err := k.newKafkaCluster(mgh)
+			if err != nil {
+				return err, false
+			}
+			return k.runtimeClient.Create(k.ctx, kafkaCluster), true
 		}
 		return err, false
 	}
@@ -612,7 +1018,10 @@ func (k *strimziTransporter) CreateUpdateKafkaCluster(mgh *operatorv1alpha4.Mult
 		return nil, false
 	}
 
-	desiredKafka := k.newKafkaCluster(mgh)
+	desiredKafka, err := k.newKafkaCluster(mgh)
+	if err != nil {
+		return err, false
+	}
 
 	updatedKafka := &kafkav1beta2.Kafka{}
 	err = utils.MergeObjects(existingKafka, desiredKafka, updatedKafka)
@@ -622,6 +1031,22 @@ func (k *strimziTransporter) CreateUpdateKafkaCluster(mgh *operatorv1alpha4.Mult
 
 	updatedKafka.Spec.Kafka.MetricsConfig = desiredKafka.Spec.Kafka.MetricsConfig
 	updatedKafka.Spec.Zookeeper.MetricsConfig = desiredKafka.Spec.Zookeeper.MetricsConfig
+	updatedKafka.Spec.CruiseControl = desiredKafka.Spec.CruiseControl
+	updatedKafka.Spec.KafkaExporter = desiredKafka.Spec.KafkaExporter
+	updatedKafka.Spec.Kafka.Storage.Volumes = retainRemovedVolumes(existingKafka.Spec.Kafka.Storage.Volumes,
+		updatedKafka.Spec.Kafka.Storage.Volumes)
+
+	// the broker resources are scaled up from the resources of the mgh by the autoscaler
+	if autoscaling := getKafkaAutoscaling(mgh); autoscaling != nil {
+		if step := getAutoscalingStep(existingKafka.Annotations); step > 0 {
+			updatedKafka.Spec.Kafka.Resources = k.getScaledKafkaResources(mgh, autoscaling, step)
+		}
+	}
+
+	// the kafka version is owned by the upgrade steps, instead of being replaced with the latest version directly
+	if err := k.upgradeKafka(mgh, existingKafka, updatedKafka); err != nil {
+		return err, false
+	}
 
 	if !reflect.DeepEqual(updatedKafka.Spec, existingKafka.Spec) {
 		return k.runtimeClient.Update(k.ctx, updatedKafka), true
@@ -633,6 +1058,12 @@ func (k *strimziTransporter) getKafkaResources(
 	mgh *operatorv1alpha4.MulticlusterGlobalHub,
 ) *kafkav1beta2.KafkaSpecKafkaResources {
 	kafkaRes := utils.GetResources(operatorconstants.Kafka, mgh.Spec.AdvancedConfig)
+	return k.toKafkaResources(kafkaRes)
+}
+
+func (k *strimziTransporter) toKafkaResources(
+	kafkaRes *corev1.ResourceRequirements,
+) *kafkav1beta2.KafkaSpecKafkaResources {
 	kafkaSpecRes := &kafkav1beta2.KafkaSpecKafkaResources{}
 	jsonData, err := json.Marshal(kafkaRes)
 	if err != nil {
@@ -663,7 +1094,8 @@ func (k *strimziTransporter) getZookeeperResources(
 	return zookeeperSpecRes
 }
 
-func (k *strimziTransporter) newKafkaCluster(mgh *operatorv1alpha4.MulticlusterGlobalHub) *kafkav1beta2.Kafka {
+func (k *strimziTransporter) newKafkaCluster(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+) (*kafkav1beta2.Kafka, error) {
 	storageSize := config.GetKafkaStorageSize(mgh)
 	kafkaSpecKafkaStorageVolumesElem := kafkav1beta2.KafkaSpecKafkaStorageVolumesElem{
 		Id:          &KafkaStorageIdentifier,
@@ -702,14 +1134,14 @@ func (k *strimziTransporter) newKafkaCluster(mgh *operatorv1alpha4.MulticlusterG
 }`)},
 				Listeners: []kafkav1beta2.KafkaSpecKafkaListenersElem{
 					{
-						Name: "plain",
-						Port: 9092,
+						Name: PlainListenerName,
+						Port: PlainListenerPort,
 						Tls:  false,
 						Type: kafkav1beta2.KafkaSpecKafkaListenersElemTypeInternal,
 					},
 					{
-						Name: "tls",
-						Port: 9093,
+						Name: TLSListenerName,
+						Port: TLSListenerPort,
 						Tls:  true,
 						Type: kafkav1beta2.KafkaSpecKafkaListenersElemTypeRoute,
 						Authentication: &kafkav1beta2.KafkaSpecKafkaListenersElemAuthentication{
@@ -724,9 +1156,9 @@ func (k *strimziTransporter) newKafkaCluster(mgh *operatorv1alpha4.MulticlusterG
 				Replicas: 3,
 				Storage: kafkav1beta2.KafkaSpecKafkaStorage{
 					Type: kafkav1beta2.KafkaSpecKafkaStorageTypeJbod,
-					Volumes: []kafkav1beta2.KafkaSpecKafkaStorageVolumesElem{
+					Volumes: append([]kafkav1beta2.KafkaSpecKafkaStorageVolumesElem{
 						kafkaSpecKafkaStorageVolumesElem,
-					},
+					}, newJbodVolumes(mgh)...),
 				},
 				Version: &KafkaVersion,
 			},
@@ -742,12 +1174,151 @@ func (k *strimziTransporter) newKafkaCluster(mgh *operatorv1alpha4.MulticlusterG
 		},
 	}
 
+	// the replicas and the storage of the kafka spec are ignored by the strimzi operator if the node pools are used
+	if len(mgh.Spec.DataLayer.Kafka.NodePools) > 0 {
+		kafkaCluster.Annotations = map[string]string{NodePoolsAnnotation: "enabled"}
+	}
+
+	// replace the plaintext listener with the mutual tls one, so all the traffic in the cluster is encrypted
+	if mgh.Spec.DataLayer.Kafka.TLSOnly {
+		kafkaCluster.Spec.Kafka.Listeners[0] = kafkav1beta2.KafkaSpecKafkaListenersElem{
+			Name: InternalTLSListenerName,
+			Port: InternalTLSListenerPort,
+			Tls:  true,
+			Type: kafkav1beta2.KafkaSpecKafkaListenersElemTypeInternal,
+			Authentication: &kafkav1beta2.KafkaSpecKafkaListenersElemAuthentication{
+				Type: kafkav1beta2.KafkaSpecKafkaListenersElemAuthenticationTypeTls,
+			},
+		}
+	}
+
+	if k.scramEnabled(mgh) {
+		kafkaCluster.Spec.Kafka.Listeners = append(kafkaCluster.Spec.Kafka.Listeners,
+			kafkav1beta2.KafkaSpecKafkaListenersElem{
+				Name: ScramListenerName,
+				Port: ScramListenerPort,
+				Tls:  true,
+				Type: kafkav1beta2.KafkaSpecKafkaListenersElemTypeRoute,
+				Authentication: &kafkav1beta2.KafkaSpecKafkaListenersElemAuthentication{
+					Type: kafkav1beta2.KafkaSpecKafkaListenersElemAuthenticationTypeScramSha512,
+				},
+			})
+	}
+
+	if mgh.Spec.DataLayer.Kafka.CruiseControl != nil {
+		kafkaCluster.Spec.CruiseControl = &kafkav1beta2.KafkaSpecCruiseControl{}
+	}
+
+	k.setExternalListener(mgh, kafkaCluster)
 	k.setAffinity(mgh, kafkaCluster)
 	k.setTolerations(mgh, kafkaCluster)
 	k.setMetricsConfig(mgh, kafkaCluster)
 	k.setImagePullSecret(mgh, kafkaCluster)
+	if err := k.setStretchedCluster(mgh, kafkaCluster); err != nil {
+		return nil, err
+	}
+	if err := k.setDisruptionBudget(mgh, kafkaCluster); err != nil {
+		return nil, err
+	}
+	// the override is merged at last, so it takes precedence over the rendered settings
+	if err := k.setSpecOverride(mgh, kafkaCluster); err != nil {
+		return nil, err
+	}
+
+	return kafkaCluster, nil
+}
+
+// setExternalListener exposes the tls and scram listeners with the external listener type of the mgh, the route is
+// used by default. The scram listener uses the "scram-" prefixed hosts and the node ports after the tls listener
+func (k *strimziTransporter) setExternalListener(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	kafkaCluster *kafkav1beta2.Kafka,
+) {
+	externalListener := mgh.Spec.DataLayer.Kafka.ExternalListener
+	if externalListener == nil {
+		return
+	}
+	listenerType := externalListener.Type
+	if listenerType == "" {
+		listenerType = operatorv1alpha4.KafkaListenerRoute
+	}
 
-	return kafkaCluster
+	replicas := int(kafkaCluster.Spec.Kafka.Replicas)
+	for i, listener := range kafkaCluster.Spec.Kafka.Listeners {
+		if listener.Type == kafkav1beta2.KafkaSpecKafkaListenersElemTypeInternal {
+			continue
+		}
+		hostPrefix := ""
+		nodePort := int(externalListener.NodePort)
+		if listener.Name == ScramListenerName {
+			hostPrefix = "scram-"
+			if nodePort > 0 {
+				nodePort += replicas + 1
+			}
+		}
+		configuration, err := newListenerConfiguration(externalListener, listenerType, hostPrefix, nodePort, replicas)
+		if err != nil {
+			k.log.Error(err, "failed to set the configuration of the kafka listener", "listener", listener.Name)
+			continue
+		}
+		kafkaCluster.Spec.Kafka.Listeners[i].Type = kafkav1beta2.KafkaSpecKafkaListenersElemType(listenerType)
+		kafkaCluster.Spec.Kafka.Listeners[i].Configuration = configuration
+	}
+}
+
+// newListenerConfiguration renders the bootstrap and the per-broker configuration of the external listener
+func newListenerConfiguration(externalListener *operatorv1alpha4.KafkaExternalListener,
+	listenerType operatorv1alpha4.KafkaListenerType, hostPrefix string, nodePort, replicas int,
+) (*kafkav1beta2.KafkaSpecKafkaListenersElemConfiguration, error) {
+	if listenerType == operatorv1alpha4.KafkaListenerIngress && externalListener.Host == "" {
+		return nil, fmt.Errorf("the host is required by the ingress listener")
+	}
+
+	bootstrap := map[string]interface{}{}
+	brokers := []map[string]interface{}{}
+	for id := 0; id < replicas; id++ {
+		brokers = append(brokers, map[string]interface{}{"broker": id})
+	}
+	if len(externalListener.Annotations) > 0 {
+		bootstrap["annotations"] = externalListener.Annotations
+		for _, broker := range brokers {
+			broker["annotations"] = externalListener.Annotations
+		}
+	}
+
+	switch listenerType {
+	case operatorv1alpha4.KafkaListenerNodePort:
+		if nodePort > 0 {
+			bootstrap["nodePort"] = nodePort
+			for id, broker := range brokers {
+				broker["nodePort"] = nodePort + id + 1
+			}
+		}
+	case operatorv1alpha4.KafkaListenerRoute, operatorv1alpha4.KafkaListenerIngress:
+		if externalListener.Host != "" {
+			bootstrap["host"] = hostPrefix + externalListener.Host
+			for id, broker := range brokers {
+				broker["host"] = fmt.Sprintf("%sbroker-%d-%s", hostPrefix, id, externalListener.Host)
+			}
+		}
+	}
+
+	configuration := map[string]interface{}{
+		"bootstrap": bootstrap,
+		"brokers":   brokers,
+	}
+	if listenerType == operatorv1alpha4.KafkaListenerIngress && externalListener.IngressClass != "" {
+		configuration["class"] = externalListener.IngressClass
+	}
+
+	jsonData, err := json.Marshal(configuration)
+	if err != nil {
+		return nil, err
+	}
+	listenerConfiguration := &kafkav1beta2.KafkaSpecKafkaListenersElemConfiguration{}
+	if err := json.Unmarshal(jsonData, listenerConfiguration); err != nil {
+		return nil, err
+	}
+	return listenerConfiguration, nil
 }
 
 // set metricsConfig for kafka cluster based on the mgh enableMetrics
@@ -777,6 +1348,15 @@ func (k *strimziTransporter) setMetricsConfig(mgh *operatorv1alpha4.Multicluster
 		}
 		kafkaCluster.Spec.Kafka.MetricsConfig = kafkaMetricsConfig
 		kafkaCluster.Spec.Zookeeper.MetricsConfig = zookeeperMetricsConfig
+
+		// the kafka exporter exposes the consumer lag of the manager on the status topics, it's scraped by the
+		// podmonitor of the kafka resources
+		groupRegex := regexp.QuoteMeta(ManagerConsumerGroupID)
+		topicRegex, _ := statusTopicRegex(config.GetRawStatusTopic())
+		kafkaCluster.Spec.KafkaExporter = &kafkav1beta2.KafkaSpecKafkaExporter{
+			GroupRegex: &groupRegex,
+			TopicRegex: &topicRegex,
+		}
 	}
 }
 
@@ -980,6 +1560,85 @@ func (k *strimziTransporter) setImagePullSecret(mgh *operatorv1alpha4.Multiclust
 	}
 }
 
+// setSpecOverride merges the spec override of the mgh onto the kafka cluster with the JSON merge patch, the same as
+// the image pull secret. The invalid override is returned, so it's reported by the reconciliation instead of being
+// ignored
+func (k *strimziTransporter) setSpecOverride(mgh *operatorv1alpha4.MulticlusterGlobalHub,
+	kafkaCluster *kafkav1beta2.Kafka,
+) error {
+	specOverride := getKafkaSpecOverride(mgh)
+	if specOverride == nil || len(specOverride.Raw) == 0 {
+		return nil
+	}
+	if err := mergeKafkaSpecJSON(kafkaCluster, specOverride.Raw); err != nil {
+		return fmt.Errorf("failed to merge the spec override into the kafka spec: %w", err)
+	}
+	return nil
+}
+
+// mergeKafkaSpec merges the patch onto the kafka spec with the JSON merge patch, so the rendered settings are set
+// into the nested templates whether or not they're initialized. The lists in the patch replace the rendered ones
+func mergeKafkaSpec(kafkaCluster *kafkav1beta2.Kafka, patch interface{}) error {
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	return mergeKafkaSpecJSON(kafkaCluster, patchData)
+}
+
+// mergeKafkaSpecJSON merges the JSON patch onto the kafka spec. The nulls of the unset fields are removed from the
+// rendered spec first, otherwise the merge patch treats them as deletions and the required fields are dropped
+func mergeKafkaSpecJSON(kafkaCluster *kafkav1beta2.Kafka, patchData []byte) error {
+	renderedKafkaJson, err := json.Marshal(kafkaCluster.Spec)
+	if err != nil {
+		return err
+	}
+	rendered := map[string]interface{}{}
+	if err := json.Unmarshal(renderedKafkaJson, &rendered); err != nil {
+		return err
+	}
+	renderedKafkaJson, err = json.Marshal(pruneNulls(rendered))
+	if err != nil {
+		return err
+	}
+	patchedData, err := jsonpatch.MergePatch(renderedKafkaJson, patchData)
+	if err != nil {
+		return err
+	}
+	updatedKafkaSpec := &kafkav1beta2.KafkaSpec{}
+	if err := json.Unmarshal(patchedData, updatedKafkaSpec); err != nil {
+		return err
+	}
+	kafkaCluster.Spec = updatedKafkaSpec
+	return nil
+}
+
+// pruneNulls removes the null values from the nested maps and lists
+func pruneNulls(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			if item == nil {
+				delete(typed, key)
+				continue
+			}
+			typed[key] = pruneNulls(item)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = pruneNulls(item)
+		}
+	}
+	return value
+}
+
+func getKafkaSpecOverride(mgh *operatorv1alpha4.MulticlusterGlobalHub) *apiextensions.JSON {
+	if mgh.Spec.AdvancedConfig == nil || mgh.Spec.AdvancedConfig.Kafka == nil {
+		return nil
+	}
+	return mgh.Spec.AdvancedConfig.Kafka.SpecOverride
+}
+
 // create/ update the kafka subscription
 func (k *strimziTransporter) ensureSubscription(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
 	// get subscription
@@ -1010,6 +1669,26 @@ func (k *strimziTransporter) ensureSubscription(mgh *operatorv1alpha4.Multiclust
 }
 
 // newSubscription returns an CrunchyPostgres subscription with desired default values
+// setSubscriptionConfig overrides the channel, the catalog source and the install plan approval of the subscription
+// with the mgh, e.g. the catalog source of the mirrored registry in the disconnected cluster
+func (k *strimziTransporter) setSubscriptionConfig(subscription *operatorv1alpha4.KafkaSubscription) {
+	if subscription == nil {
+		return
+	}
+	if subscription.Channel != "" {
+		k.subChannel = subscription.Channel
+	}
+	if subscription.CatalogSource != "" {
+		k.subCatalogSourceName = subscription.CatalogSource
+	}
+	if subscription.CatalogSourceNamespace != "" {
+		k.subCatalogSourceNamespace = subscription.CatalogSourceNamespace
+	}
+	if subscription.InstallPlanApproval != "" {
+		k.subInstallPlanApproval = subv1alpha1.Approval(subscription.InstallPlanApproval)
+	}
+}
+
 func (k *strimziTransporter) newSubscription(mgh *operatorv1alpha4.MulticlusterGlobalHub) *subv1alpha1.Subscription {
 	labels := map[string]string{
 		"installer.name":                 mgh.Name,
@@ -1034,10 +1713,10 @@ func (k *strimziTransporter) newSubscription(mgh *operatorv1alpha4.MulticlusterG
 		},
 		Spec: &subv1alpha1.SubscriptionSpec{
 			Channel:                k.subChannel,
-			InstallPlanApproval:    DefaultInstallPlanApproval,
+			InstallPlanApproval:    k.subInstallPlanApproval,
 			Package:                k.subPackageName,
 			CatalogSource:          k.subCatalogSourceName,
-			CatalogSourceNamespace: DefaultCatalogSourceNamespace,
+			CatalogSourceNamespace: k.subCatalogSourceNamespace,
 			Config:                 subConfig,
 		},
 	}
@@ -1061,6 +1740,24 @@ func WriteTopicACL(topicName string) kafkav1beta2.KafkaUserSpecAuthorizationAcls
 	return writeAcl
 }
 
+// TransactionalIDWriteACL allows the producer to send the messages in the transactions of the transactional id
+func TransactionalIDWriteACL(transactionalID string) kafkav1beta2.KafkaUserSpecAuthorizationAclsElem {
+	host := "*"
+	patternType := kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResourcePatternTypeLiteral
+	return kafkav1beta2.KafkaUserSpecAuthorizationAclsElem{
+		Host: &host,
+		Resource: kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResource{
+			Type:        kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResourceTypeTransactionalId,
+			Name:        &transactionalID,
+			PatternType: &patternType,
+		},
+		Operations: []kafkav1beta2.KafkaUserSpecAuthorizationAclsElemOperationsElem{
+			kafkav1beta2.KafkaUserSpecAuthorizationAclsElemOperationsElemDescribe,
+			kafkav1beta2.KafkaUserSpecAuthorizationAclsElemOperationsElemWrite,
+		},
+	}
+}
+
 func ReadTopicACL(topicName string, prefixParttern bool) kafkav1beta2.KafkaUserSpecAuthorizationAclsElem {
 	host := "*"
 	patternType := kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResourcePatternTypeLiteral
@@ -1082,9 +1779,10 @@ func ReadTopicACL(topicName string, prefixParttern bool) kafkav1beta2.KafkaUserS
 	}
 }
 
-func ConsumeGroupReadACL() kafkav1beta2.KafkaUserSpecAuthorizationAclsElem {
+// ConsumeGroupReadACL allows the consumer to join the consumer group and commit the offsets, the group is literal
+// instead of the wildcard, so a managed hub can't read the offsets of the others
+func ConsumeGroupReadACL(consumerGroup string) kafkav1beta2.KafkaUserSpecAuthorizationAclsElem {
 	host := "*"
-	consumerGroup := "*"
 	consumerPatternType := kafkav1beta2.KafkaUserSpecAuthorizationAclsElemResourcePatternTypeLiteral
 	consumerAcl := kafkav1beta2.KafkaUserSpecAuthorizationAclsElem{
 		Host: &host,
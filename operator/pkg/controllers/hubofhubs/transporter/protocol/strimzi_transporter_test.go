@@ -0,0 +1,182 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	"github.com/stretchr/testify/assert"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestGetListenerStatus(t *testing.T) {
+	kafkaCluster := &kafkav1beta2.Kafka{}
+	assert.Nil(t, getListenerStatus(kafkaCluster, TLSListenerName))
+
+	// the plain listener is replaced with the internal tls listener in the tls-only mode
+	internalTLS, tls := InternalTLSListenerName, TLSListenerName
+	internalServer := "kafka-kafka-bootstrap.multicluster-global-hub.svc:9095"
+	routeServer := "kafka-kafka-tls-bootstrap-multicluster-global-hub.apps.example.com:443"
+	kafkaCluster.Status = &kafkav1beta2.KafkaStatus{
+		Listeners: []kafkav1beta2.KafkaStatusListenersElem{
+			{Name: &internalTLS, BootstrapServers: &internalServer},
+			{Name: &tls, BootstrapServers: &routeServer},
+		},
+	}
+	assert.Equal(t, routeServer, *getListenerStatus(kafkaCluster, TLSListenerName).BootstrapServers)
+	assert.Equal(t, internalServer, *getListenerStatus(kafkaCluster, InternalTLSListenerName).BootstrapServers)
+	assert.Nil(t, getListenerStatus(kafkaCluster, PlainListenerName))
+}
+
+func TestManagerListenerName(t *testing.T) {
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	assert.Equal(t, TLSListenerName, managerListenerName(mgh))
+
+	mgh.Spec.DataLayer.Kafka.TLSOnly = true
+	assert.Equal(t, InternalTLSListenerName, managerListenerName(mgh))
+}
+
+func TestSetSpecOverride(t *testing.T) {
+	k := &strimziTransporter{log: ctrl.Log.WithName("test")}
+	storageSize := "10Gi"
+	newKafkaCluster := func() *kafkav1beta2.Kafka {
+		return &kafkav1beta2.Kafka{
+			Spec: &kafkav1beta2.KafkaSpec{
+				Kafka: kafkav1beta2.KafkaSpecKafka{
+					Config: &apiextensions.JSON{Raw: []byte(`{"min.insync.replicas": 2}`)},
+					Listeners: []kafkav1beta2.KafkaSpecKafkaListenersElem{{
+						Name: "tls",
+						Port: 9093,
+						Tls:  true,
+						Type: kafkav1beta2.KafkaSpecKafkaListenersElemTypeRoute,
+					}},
+					Replicas: 3,
+					Storage: kafkav1beta2.KafkaSpecKafkaStorage{
+						Type: kafkav1beta2.KafkaSpecKafkaStorageTypeJbod,
+						Volumes: []kafkav1beta2.KafkaSpecKafkaStorageVolumesElem{{
+							Id:   &KafkaStorageIdentifier,
+							Size: &storageSize,
+							Type: kafkav1beta2.KafkaSpecKafkaStorageVolumesElemTypePersistentClaim,
+						}},
+					},
+				},
+				Zookeeper: kafkav1beta2.KafkaSpecZookeeper{
+					Replicas: 3,
+					Storage: kafkav1beta2.KafkaSpecZookeeperStorage{
+						Type: kafkav1beta2.KafkaSpecZookeeperStorageTypePersistentClaim,
+						Size: &storageSize,
+					},
+				},
+			},
+		}
+	}
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+
+	// the kafka cluster isn't changed without the override
+	kafkaCluster := newKafkaCluster()
+	assert.NoError(t, k.setSpecOverride(mgh, kafkaCluster))
+	assert.Equal(t, newKafkaCluster(), kafkaCluster)
+
+	// the broker config is merged, and the override takes precedence over the rendered settings
+	mgh.Spec.AdvancedConfig = &operatorv1alpha4.AdvancedConfig{
+		Kafka: &operatorv1alpha4.KafkaCommonSpec{
+			SpecOverride: &apiextensions.JSON{
+				Raw: []byte(`{"kafka": {"replicas": 5, "config": {"num.io.threads": 16}}}`),
+			},
+		},
+	}
+	assert.NoError(t, k.setSpecOverride(mgh, kafkaCluster))
+	assert.EqualValues(t, 5, kafkaCluster.Spec.Kafka.Replicas)
+	config := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(kafkaCluster.Spec.Kafka.Config.Raw, &config))
+	assert.EqualValues(t, 2, config["min.insync.replicas"])
+	assert.EqualValues(t, 16, config["num.io.threads"])
+	assert.Equal(t, storageSize, *kafkaCluster.Spec.Kafka.Storage.Volumes[0].Size)
+
+	assert.Len(t, kafkaCluster.Spec.Kafka.Listeners, 1)
+
+	// the invalid override is returned, and the kafka cluster isn't changed
+	mgh.Spec.AdvancedConfig.Kafka.SpecOverride.Raw = []byte(`{"kafka": {"replicas": "five"}}`)
+	kafkaCluster = newKafkaCluster()
+	assert.Error(t, k.setSpecOverride(mgh, kafkaCluster))
+	assert.Equal(t, newKafkaCluster(), kafkaCluster)
+}
+
+func TestSetSubscriptionConfig(t *testing.T) {
+	k := &strimziTransporter{
+		subName:                   DefaultKafkaSubName,
+		subChannel:                DefaultAMQChannel,
+		subPackageName:            DefaultAMQPackageName,
+		subCatalogSourceName:      DefaultCatalogSourceName,
+		subCatalogSourceNamespace: DefaultCatalogSourceNamespace,
+		subInstallPlanApproval:    DefaultInstallPlanApproval,
+	}
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{}
+	mgh.Namespace = "multicluster-global-hub"
+
+	// the defaults are used without the subscription config
+	k.setSubscriptionConfig(mgh.Spec.DataLayer.Kafka.Subscription)
+	sub := k.newSubscription(mgh)
+	assert.Equal(t, DefaultAMQChannel, sub.Spec.Channel)
+	assert.Equal(t, DefaultCatalogSourceName, sub.Spec.CatalogSource)
+	assert.Equal(t, DefaultInstallPlanApproval, sub.Spec.InstallPlanApproval)
+
+	// the mirrored catalog source of the disconnected cluster
+	mgh.Spec.DataLayer.Kafka.Subscription = &operatorv1alpha4.KafkaSubscription{
+		CatalogSource:       "mirrored-operators",
+		InstallPlanApproval: "Manual",
+	}
+	k.setSubscriptionConfig(mgh.Spec.DataLayer.Kafka.Subscription)
+	sub = k.newSubscription(mgh)
+	assert.Equal(t, DefaultAMQChannel, sub.Spec.Channel)
+	assert.Equal(t, "mirrored-operators", sub.Spec.CatalogSource)
+	assert.Equal(t, DefaultCatalogSourceNamespace, sub.Spec.CatalogSourceNamespace)
+	assert.Equal(t, "Manual", string(sub.Spec.InstallPlanApproval))
+}
+
+func TestGetTopicConfig(t *testing.T) {
+	retentionMs := int64(86400000)
+	cases := []struct {
+		name        string
+		topicClass  string
+		topicConfig *operatorv1alpha4.KafkaTopicConfig
+		expected    map[string]interface{}
+	}{
+		{
+			name:       "the spec topic is compacted by default",
+			topicClass: SpecTopicClass,
+			expected:   map[string]interface{}{"cleanup.policy": "compact"},
+		},
+		{
+			name:       "the status topics are deleted by the time by default",
+			topicClass: StatusTopicClass,
+			expected: map[string]interface{}{
+				"cleanup.policy": "delete",
+				"retention.ms":   DefaultStatusTopicRetentionMs,
+			},
+		},
+		{
+			name:        "the status topics are compacted",
+			topicClass:  StatusTopicClass,
+			topicConfig: &operatorv1alpha4.KafkaTopicConfig{CleanupPolicy: "compact"},
+			expected:    map[string]interface{}{"cleanup.policy": "compact"},
+		},
+		{
+			name:        "the retention of the status topics",
+			topicClass:  StatusTopicClass,
+			topicConfig: &operatorv1alpha4.KafkaTopicConfig{CleanupPolicy: "compact,delete", RetentionMs: &retentionMs},
+			expected: map[string]interface{}{
+				"cleanup.policy": "compact,delete",
+				"retention.ms":   retentionMs,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getTopicConfig(tc.topicClass, tc.topicConfig, nil))
+		})
+	}
+}
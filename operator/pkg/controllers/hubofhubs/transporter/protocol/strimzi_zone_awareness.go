@@ -0,0 +1,108 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+// ZoneTopologyKey is the node label of the availability zones
+const ZoneTopologyKey = "topology.kubernetes.io/zone"
+
+// ensureZoneAwareness patches the rack and the topology spread constraints of the brokers and the zookeeper nodes
+// to the kafka cluster. The rack isn't in the kafka api of the strimzi client, so it's merged into the kafka cluster
+// with the json merge patch like the tiered storage
+func (k *strimziTransporter) ensureZoneAwareness(mgh *operatorv1alpha4.MulticlusterGlobalHub) error {
+	existingKafka := &unstructured.Unstructured{}
+	existingKafka.SetGroupVersionKind(KafkaGVK)
+	err := k.runtimeClient.Get(k.ctx, types.NamespacedName{
+		Name:      k.kafkaClusterName,
+		Namespace: k.kafkaClusterNamespace,
+	}, existingKafka)
+	if err != nil {
+		return err
+	}
+
+	desiredRack, desiredKafkaSpread, desiredZookeeperSpread := k.newZoneAwareness(mgh)
+
+	existingRack, _, _ := unstructured.NestedMap(existingKafka.Object, "spec", "kafka", "rack")
+	existingKafkaSpread, _, _ := unstructured.NestedSlice(existingKafka.Object, "spec", "kafka", "template", "pod",
+		"topologySpreadConstraints")
+	existingZookeeperSpread, _, _ := unstructured.NestedSlice(existingKafka.Object, "spec", "zookeeper", "template",
+		"pod", "topologySpreadConstraints")
+	if isEqualJSON(existingRack, desiredRack) && isEqualJSON(existingKafkaSpread, desiredKafkaSpread) &&
+		isEqualJSON(existingZookeeperSpread, desiredZookeeperSpread) {
+		return nil
+	}
+
+	// the nil values remove the rack and the constraints from the kafka cluster
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"kafka": map[string]interface{}{
+				"rack": desiredRack,
+				"template": map[string]interface{}{
+					"pod": map[string]interface{}{
+						"topologySpreadConstraints": desiredKafkaSpread,
+					},
+				},
+			},
+			"zookeeper": map[string]interface{}{
+				"template": map[string]interface{}{
+					"pod": map[string]interface{}{
+						"topologySpreadConstraints": desiredZookeeperSpread,
+					},
+				},
+			},
+		},
+	}
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	k.log.Info("update the zone awareness of the kafka cluster", "enabled", desiredRack != nil)
+	return k.runtimeClient.Patch(k.ctx, existingKafka, client.RawPatch(types.MergePatchType, patchData))
+}
+
+// newZoneAwareness returns the rack of the brokers, and the topology spread constraints of the brokers and the
+// zookeeper nodes, all of them are nil if the zone awareness isn't enabled. The stretched kafka is spread across its
+// sites by their topology key
+func (k *strimziTransporter) newZoneAwareness(mgh *operatorv1alpha4.MulticlusterGlobalHub) (
+	map[string]interface{}, []interface{}, []interface{},
+) {
+	topologyKey := ZoneTopologyKey
+	if stretched := mgh.Spec.DataLayer.Kafka.Stretched; stretched != nil {
+		topologyKey = stretchedTopologyKey(stretched)
+	} else if !mgh.Spec.DataLayer.Kafka.ZoneAware {
+		return nil, nil, nil
+	}
+	rack := map[string]interface{}{
+		"topologyKey": topologyKey,
+	}
+	return rack, k.zoneSpreadConstraints("kafka", topologyKey), k.zoneSpreadConstraints("zookeeper", topologyKey)
+}
+
+// zoneSpreadConstraints spreads the pods of the strimzi component evenly across the zones, the nodes without the
+// zone label aren't used
+func (k *strimziTransporter) zoneSpreadConstraints(component, topologyKey string) []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"maxSkew":           int64(1),
+			"topologyKey":       topologyKey,
+			"whenUnsatisfiable": "DoNotSchedule",
+			"labelSelector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{
+					"strimzi.io/cluster": k.kafkaClusterName,
+					"strimzi.io/name":    fmt.Sprintf("%s-%s", k.kafkaClusterName, component),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	operatorv1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+)
+
+func TestNewZoneAwareness(t *testing.T) {
+	k := &strimziTransporter{kafkaClusterName: KafkaClusterName}
+	mgh := &operatorv1alpha4.MulticlusterGlobalHub{
+		Spec: operatorv1alpha4.MulticlusterGlobalHubSpec{
+			DataLayer: operatorv1alpha4.DataLayerConfig{
+				Kafka: operatorv1alpha4.KafkaConfig{},
+			},
+		},
+	}
+
+	// the zone awareness is disabled by default
+	rack, kafkaSpread, zookeeperSpread := k.newZoneAwareness(mgh)
+	assert.Nil(t, rack)
+	assert.Nil(t, kafkaSpread)
+	assert.Nil(t, zookeeperSpread)
+
+	mgh.Spec.DataLayer.Kafka.ZoneAware = true
+	rack, kafkaSpread, zookeeperSpread = k.newZoneAwareness(mgh)
+	assert.Equal(t, ZoneTopologyKey, rack["topologyKey"])
+
+	assert.True(t, isEqualJSON(kafkaSpread, []interface{}{
+		map[string]interface{}{
+			"maxSkew":           1,
+			"topologyKey":       "topology.kubernetes.io/zone",
+			"whenUnsatisfiable": "DoNotSchedule",
+			"labelSelector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{
+					"strimzi.io/cluster": "kafka",
+					"strimzi.io/name":    "kafka-kafka",
+				},
+			},
+		},
+	}))
+	selector := zookeeperSpread[0].(map[string]interface{})["labelSelector"].(map[string]interface{})
+	matchLabels := selector["matchLabels"].(map[string]interface{})
+	assert.Equal(t, "kafka-zookeeper", matchLabels["strimzi.io/name"])
+}
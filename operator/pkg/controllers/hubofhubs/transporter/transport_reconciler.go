@@ -2,6 +2,7 @@ package transporter
 
 import (
 	"context"
+	"time"
 
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -9,10 +10,12 @@ import (
 	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/hubofhubs/transporter/protocol"
-	"github.com/stolostron/multicluster-global-hub/pkg/constants"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 )
 
+// the timeout to verify the external kafka cluster is reachable
+const connectionTimeout = 10 * time.Second
+
 type TransportReconciler struct {
 	ctrl.Manager
 	kafkaController *protocol.KafkaController
@@ -42,7 +45,7 @@ func (r *TransportReconciler) Reconcile(ctx context.Context, mgh *v1alpha4.Multi
 	case transport.SecretTransporter:
 		trans = protocol.NewBYOTransporter(ctx, types.NamespacedName{
 			Namespace: mgh.Namespace,
-			Name:      constants.GHTransportSecretName,
+			Name:      config.GetTransportSecretName(),
 		}, r.GetClient())
 		config.SetTransporter(trans)
 		// all of hubs will get the same credential
@@ -50,7 +53,42 @@ func (r *TransportReconciler) Reconcile(ctx context.Context, mgh *v1alpha4.Multi
 		if err != nil {
 			return err
 		}
+		// the kafka authenticated with SASL is usually outside the cluster, e.g. the Confluent Cloud, verify it's
+		// reachable before distributing the credential
+		if conn.SASLMechanism != "" {
+			if err := protocol.ValidateConnection(conn, connectionTimeout); err != nil {
+				return err
+			}
+		}
+		config.SetTransporterConn(conn)
+	case transport.NatsTransporter:
+		trans = protocol.NewNatsTransporter(ctx, mgh, r.GetClient())
+		config.SetTransporter(trans)
+		// all of hubs will get the same credential
+		conn, err := trans.GetConnCredential("")
+		if err != nil {
+			return err
+		}
 		config.SetTransporterConn(conn)
+	case transport.GRPCTransporter:
+		return r.reconcileGateway(protocol.NewGRPCTransporter(ctx, mgh, r.GetClient()))
+	case transport.HTTPTransporter:
+		return r.reconcileGateway(protocol.NewHTTPTransporter(ctx, mgh, r.GetClient()))
+	}
+	return nil
+}
+
+// reconcileGateway exposes the manager for the agents, the manager gets the server certificate, and each hub gets
+// its own client certificate
+func (r *TransportReconciler) reconcileGateway(gatewayTransporter *protocol.GatewayTransporter) error {
+	if err := gatewayTransporter.EnsureGateway(); err != nil {
+		return err
+	}
+	config.SetTransporter(gatewayTransporter)
+	conn, err := gatewayTransporter.GetConnCredential("")
+	if err != nil {
+		return err
 	}
+	config.SetTransporterConn(conn)
 	return nil
 }
@@ -126,6 +126,8 @@ func (d *HoHDeployer) deployStatefulSet(desiredObj, existingObj *unstructured.Un
 	if err != nil {
 		return err
 	}
+	// the volume claim templates are immutable, the volumes are expanded by patching the claims instead
+	desiredSTS.Spec.VolumeClaimTemplates = existingSTS.Spec.VolumeClaimTemplates
 
 	if !apiequality.Semantic.DeepDerivative(desiredSTS.Spec, existingSTS.Spec) ||
 		!apiequality.Semantic.DeepDerivative(desiredSTS.GetLabels(), existingSTS.GetLabels()) ||
@@ -29,6 +29,7 @@ import (
 	subv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -315,6 +316,14 @@ func GetResources(component string, advanced *v1alpha4.AdvancedConfig) *corev1.R
 			setResourcesFromCR(advanced.Postgres.Resources, requests, limits)
 		}
 
+	case constants.PostgresPooler:
+		requests[corev1.ResourceName(corev1.ResourceMemory)] = resource.MustParse(constants.PostgresPoolerMemoryRequest)
+		requests[corev1.ResourceName(corev1.ResourceCPU)] = resource.MustParse(constants.PostgresPoolerCPURequest)
+		limits[corev1.ResourceName(corev1.ResourceMemory)] = resource.MustParse(constants.PostgresPoolerMemoryLimit)
+		if advanced != nil && advanced.Postgres != nil && advanced.Postgres.ConnectionPooler != nil {
+			setResourcesFromCR(advanced.Postgres.ConnectionPooler.Resources, requests, limits)
+		}
+
 	case constants.Manager:
 		requests[corev1.ResourceName(corev1.ResourceMemory)] = resource.MustParse(constants.ManagerMemoryRequest)
 		requests[corev1.ResourceName(corev1.ResourceCPU)] = resource.MustParse(constants.ManagerCPURequest)
@@ -486,3 +495,16 @@ func ManipulateGlobalHubObjects(objects []*unstructured.Unstructured,
 
 	return nil
 }
+
+// StorageClassExpandable returns true if the storage class allows the volume expansion, the volumes without the
+// storage class are provisioned statically, so they can't be expanded
+func StorageClassExpandable(ctx context.Context, reader client.Reader, className *string) (bool, error) {
+	if className == nil || *className == "" {
+		return false, nil
+	}
+	storageClass := &storagev1.StorageClass{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: *className}, storageClass); err != nil {
+		return false, err
+	}
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
@@ -21,11 +21,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	v1alpha4 "github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
@@ -404,8 +407,10 @@ func Test_GetResources(t *testing.T) {
 			component: constants.Postgres,
 			advanced: func(resReq *v1alpha4.ResourceRequirements) *v1alpha4.AdvancedConfig {
 				return &v1alpha4.AdvancedConfig{
-					Postgres: &v1alpha4.CommonSpec{
-						Resources: resReq,
+					Postgres: &v1alpha4.PostgresCommonSpec{
+						CommonSpec: v1alpha4.CommonSpec{
+							Resources: resReq,
+						},
 					},
 				}
 			},
@@ -444,8 +449,10 @@ func Test_GetResources(t *testing.T) {
 			component: constants.Manager,
 			advanced: func(resReq *v1alpha4.ResourceRequirements) *v1alpha4.AdvancedConfig {
 				return &v1alpha4.AdvancedConfig{
-					Manager: &v1alpha4.CommonSpec{
-						Resources: resReq,
+					Manager: &v1alpha4.ManagerCommonSpec{
+						CommonSpec: v1alpha4.CommonSpec{
+							Resources: resReq,
+						},
 					},
 				}
 			},
@@ -464,8 +471,10 @@ func Test_GetResources(t *testing.T) {
 			component: constants.Kafka,
 			advanced: func(resReq *v1alpha4.ResourceRequirements) *v1alpha4.AdvancedConfig {
 				return &v1alpha4.AdvancedConfig{
-					Kafka: &v1alpha4.CommonSpec{
-						Resources: resReq,
+					Kafka: &v1alpha4.KafkaCommonSpec{
+						CommonSpec: v1alpha4.CommonSpec{
+							Resources: resReq,
+						},
 					},
 				}
 			},
@@ -530,3 +539,34 @@ func Test_GetResources(t *testing.T) {
 		})
 	}
 }
+
+func TestStorageClassExpandable(t *testing.T) {
+	fakeClient := fake.NewClientBuilder().WithScheme(config.GetRuntimeScheme()).WithObjects(
+		&storagev1.StorageClass{
+			ObjectMeta:           metav1.ObjectMeta{Name: "gp3-csi"},
+			AllowVolumeExpansion: ptr.To(true),
+		},
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "local-storage"},
+		},
+	).Build()
+
+	cases := []struct {
+		className  *string
+		expandable bool
+	}{
+		{ptr.To("gp3-csi"), true},
+		{ptr.To("local-storage"), false},
+		// the statically provisioned volumes
+		{ptr.To(""), false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		expandable, err := StorageClassExpandable(context.Background(), fakeClient, c.className)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expandable, expandable)
+	}
+
+	_, err := StorageClassExpandable(context.Background(), fakeClient, ptr.To("missing"))
+	assert.Error(t, err)
+}
@@ -10,6 +10,8 @@ const (
 	ManagerDeploymentName = "multicluster-global-hub-manager"
 	// AgentDeploymentName define the global hub agent deployment name
 	AgentDeploymentName = "multicluster-global-hub-agent"
+	// GHManagedClusterAddonName is the name of the addon which deploys the global hub agent to the managed hub
+	GHManagedClusterAddonName = "multicluster-global-hub-controller"
 
 	// GHAgentConfigCMName is the name of configmap that stores important global hub settings
 	// eg. aggregationLevel and enableLocalPolicy.
@@ -94,6 +96,12 @@ const (
 	ManagedClusterManagedByAnnotation = "global-hub.open-cluster-management.io/managed-by"
 	// identify the resource is from the global hub cluster
 	OriginOwnerReferenceAnnotation = "global-hub.open-cluster-management.io/origin-ownerreference-uid"
+	// select the managed hubs which the global resource is propagated to, the value is a label selector of the managed
+	// hub clusters. Only the ApplicationSet supports it, the resource is propagated to all the managed hubs if absent
+	ManagedHubSelectorAnnotation = "global-hub.open-cluster-management.io/managed-hub-selector"
+	// the parameters substituted into the global resource for each managed hub, the value is a JSON object from the
+	// managed hub name to its parameters, e.g. {"hub1": {"env": "prod"}}
+	ManagedHubParametersAnnotation = "global-hub.open-cluster-management.io/managed-hub-parameters"
 )
 
 // store all the finalizers
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BatchSize is the number of the rows written by one multi-row statement. It keeps the bind parameters of the
+// statement under the limit of the postgres (65535) for the status tables
+const BatchSize = 1000
+
+// UpsertInBatches writes the rows with the multi-row "INSERT ... ON CONFLICT DO UPDATE" statements, the tx is expected
+// to be the transaction of the bundle, so the rows of a bundle are committed together
+func UpsertInBatches[T any](tx *gorm.DB, rows []T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		UpdateAll: true,
+	}).CreateInBatches(rows, BatchSize).Error
+}
+
+// Chunk splits the values into the batches of the BatchSize, e.g. for the "IN" conditions of the batch statements
+func Chunk[T any](values []T) [][]T {
+	chunks := make([][]T, 0, (len(values)+BatchSize-1)/BatchSize)
+	for start := 0; start < len(values); start += BatchSize {
+		chunks = append(chunks, values[start:min(start+BatchSize, len(values))])
+	}
+	return chunks
+}
@@ -0,0 +1,20 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+func TestChunk(t *testing.T) {
+	assert.Empty(t, database.Chunk([]string{}))
+
+	values := make([]int, 2*database.BatchSize+1)
+	chunks := database.Chunk(values)
+	assert.Len(t, chunks, 3)
+	assert.Len(t, chunks[0], database.BatchSize)
+	assert.Len(t, chunks[1], database.BatchSize)
+	assert.Len(t, chunks[2], 1)
+}
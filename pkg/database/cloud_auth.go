@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	transportconfig "github.com/stolostron/multicluster-global-hub/pkg/transport/config"
+)
+
+// the authentication types of the database, the tokens of the cloud identity are used as the password
+const (
+	PasswordAuth = "password"
+	AWSIAMAuth   = "aws-iam"
+	AzureADAuth  = "azure-ad"
+)
+
+const (
+	rdsSigningName    = "rds-db"
+	rdsSigningAction  = "connect"
+	rdsTokenExpires   = 15 * time.Minute
+	azureTokenRenewal = 5 * time.Minute
+	azureTokenTimeout = 10 * time.Second
+	azureDefaultHost  = "https://login.microsoftonline.com/"
+	// the scope of the azure database for postgresql
+	azurePostgresScope       = "https://ossrdbms-aad.database.windows.net/.default"
+	azureClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+var (
+	// the endpoint of RDS, e.g. demo.abcdef.us-east-1.rds.amazonaws.com
+	rdsRegionRegex = regexp.MustCompile(`\.([a-z0-9-]+)\.rds\.amazonaws\.com`)
+
+	// the azure token is shared by the connections until it's about to expire
+	azureTokenMutex      sync.Mutex
+	azureToken           string
+	azureTokenExpiration time.Time
+)
+
+// ValidateAuthType returns an error if the authentication type isn't supported
+func ValidateAuthType(authType string) error {
+	switch authType {
+	case "", PasswordAuth, AWSIAMAuth, AzureADAuth:
+		return nil
+	default:
+		return fmt.Errorf("unsupported database authentication: %s", authType)
+	}
+}
+
+// BeforeConnect returns the hook to set the token of the cloud identity as the password of each new connection, so
+// the pool never connects with an expired token. It returns nil for the password authentication
+func BeforeConnect(authType string) func(context.Context, *pgx.ConnConfig) error {
+	switch authType {
+	case AWSIAMAuth:
+		return func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+			endpoint := net.JoinHostPort(connConfig.Host, fmt.Sprintf("%d", connConfig.Port))
+			region, err := GetRDSRegion(connConfig.Host)
+			if err != nil {
+				return err
+			}
+			credentials, err := transportconfig.GetAWSCredentials("", "")
+			if err != nil {
+				return err
+			}
+			connConfig.Password, err = GenerateRDSIAMToken(endpoint, region, connConfig.User, credentials,
+				time.Now().UTC())
+			return err
+		}
+	case AzureADAuth:
+		return func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+			token, err := getAzureADToken(ctx)
+			if err != nil {
+				return err
+			}
+			connConfig.Password = token
+			return nil
+		}
+	default:
+		return nil
+	}
+}
+
+// GetRDSRegion returns the AWS_REGION environment variable, or parses the region from the RDS endpoint
+func GetRDSRegion(host string) (string, error) {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region, nil
+	}
+	matches := rdsRegionRegex.FindStringSubmatch(host)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("failed to get the aws region from the database host: %s", host)
+	}
+	return matches[1], nil
+}
+
+// GenerateRDSIAMToken returns the RDS IAM token of the database user, the token is the connect url of the endpoint
+// "host:port" presigned with the AWS signature version 4, without the scheme
+func GenerateRDSIAMToken(endpoint, region, dbUser string, credentials *transportconfig.AWSCredentials,
+	signingTime time.Time,
+) (string, error) {
+	if region == "" {
+		return "", errors.New("the aws region must be set")
+	}
+	if dbUser == "" {
+		return "", errors.New("the database user must be set")
+	}
+	return transportconfig.PresignAWSURL(endpoint, rdsSigningName, region, map[string]string{
+		"Action": rdsSigningAction,
+		"DBUser": dbUser,
+	}, credentials, signingTime, rdsTokenExpires), nil
+}
+
+// getAzureADToken exchanges the federated token of the azure workload identity for the access token of the azure
+// database for postgresql, the AZURE_CLIENT_ID, AZURE_TENANT_ID and AZURE_FEDERATED_TOKEN_FILE are injected by the
+// workload identity webhook
+func getAzureADToken(ctx context.Context) (string, error) {
+	azureTokenMutex.Lock()
+	defer azureTokenMutex.Unlock()
+
+	if azureToken != "" && time.Until(azureTokenExpiration) > azureTokenRenewal {
+		return azureToken, nil
+	}
+
+	clientID := os.Getenv("AZURE_CLIENT_ID")
+	tenantID := os.Getenv("AZURE_TENANT_ID")
+	tokenFile := os.Getenv("AZURE_FEDERATED_TOKEN_FILE")
+	if clientID == "" || tenantID == "" || tokenFile == "" {
+		return "", errors.New("the AZURE_CLIENT_ID, AZURE_TENANT_ID and AZURE_FEDERATED_TOKEN_FILE must be set")
+	}
+	assertion, err := os.ReadFile(filepath.Clean(tokenFile))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the azure federated token: %w", err)
+	}
+	authorityHost := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authorityHost == "" {
+		authorityHost = azureDefaultHost
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", clientID)
+	form.Set("client_assertion_type", azureClientAssertionType)
+	form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	form.Set("scope", azurePostgresScope)
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(authorityHost, "/"), tenantID)
+
+	ctx, cancel := context.WithTimeout(ctx, azureTokenTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the azure ad token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the azure ad token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get the azure ad token: %s: %s", resp.Status, body)
+	}
+
+	token := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to parse the azure ad token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("the access token isn't found in the response of the azure ad")
+	}
+	azureToken = token.AccessToken
+	azureTokenExpiration = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return azureToken, nil
+}
@@ -16,6 +16,10 @@ const (
 const (
 	// ManagedClustersTableName table name of managed clusters.
 	ManagedClustersTableName = "managed_clusters"
+	// ManagedClusterSetsTableName table name of managed cluster sets.
+	ManagedClusterSetsTableName = "managed_cluster_sets"
+	// ManagedClusterSetBindingsTableName table name of managed cluster set bindings.
+	ManagedClusterSetBindingsTableName = "managed_cluster_set_bindings"
 
 	// ComplianceTableName table name of policy compliance status.
 	ComplianceTableName = "compliance"
@@ -23,11 +27,19 @@ const (
 	MinimalComplianceTable = "aggregated_compliance"
 	// LocalPolicySpecTableName table name of local policy spec.
 	LocalPolicySpecTableName = "policies"
+	// PolicySetsTableName table name of the policysets propagated from the global hub.
+	PolicySetsTableName = "policysets"
+	// OperatorPoliciesTableName table name of the operator policies propagated from the global hub.
+	OperatorPoliciesTableName = "operatorpolicies"
 
 	// SubscriptionStatusesTableName table name of subscription-statuses.
 	SubscriptionStatusesTableName = "subscription_statuses"
 	// SubscriptionReportsTableName table name of subscription-reports.
 	SubscriptionReportsTableName = "subscription_reports"
+	// ArgoApplicationsTableName table name of the ArgoCD applications.
+	ArgoApplicationsTableName = "argocd_applications"
+	// ArgoApplicationSetsTableName table name of the ArgoCD applicationsets propagated from the global hub.
+	ArgoApplicationSetsTableName = "argocd_applicationsets"
 
 	// PlacementRulesTableName table name of placement-rules.
 	PlacementRulesTableName = "placementrules"
@@ -35,6 +47,8 @@ const (
 	PlacementsTableName = "placements"
 	// PlacementDecisionsTableName table name of placement-decisions.
 	PlacementDecisionsTableName = "placementdecisions"
+	// AddOnPlacementScoresTableName table name of the addon placement scores of the managed clusters.
+	AddOnPlacementScoresTableName = "addon_placement_scores"
 
 	// LeafHubHeartbeatsTableName table name for LH heartbeats.
 	LeafHubHeartbeatsTableName = "leaf_hub_heartbeats"
@@ -68,6 +82,22 @@ const (
 	Pending ComplianceStatus = "pending"
 )
 
+// periods of the compliance rollups.
+const (
+	// RollupPeriodDay is the daily compliance rollup.
+	RollupPeriodDay = "day"
+	// RollupPeriodWeek is the weekly compliance rollup, the weeks start on Monday.
+	RollupPeriodWeek = "week"
+)
+
+// scopes of the compliance percentages.
+const (
+	// ComplianceScopePolicy is the percentage of the compliant clusters of the policy over the managed hubs.
+	ComplianceScopePolicy = "policy"
+	// ComplianceScopeHub is the percentage of the compliant clusters of the managed hub over its policies.
+	ComplianceScopeHub = "hub"
+)
+
 // unique db types.
 const (
 	// UUID unique type.
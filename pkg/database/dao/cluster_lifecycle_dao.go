@@ -0,0 +1,39 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+// ClusterLifecycleSettleDelay is how long the lifecycle events are held back from the readers. The sequence of an
+// event is taken before its transaction commits, so an event of the smaller sequence might be visible after the ones
+// of the larger sequences, the delay keeps the readers from skipping it with their cursors
+const ClusterLifecycleSettleDelay = 5 * time.Second
+
+// ClusterLifecycleFilter selects the lifecycle events after the sequence, the empty fields aren't filtered
+type ClusterLifecycleFilter struct {
+	After       int64
+	Limit       int
+	LeafHubName string
+	ClusterName string
+}
+
+// ListClusterLifecycleEvents returns the settled lifecycle events of the managed clusters in the order of the sequence
+func ListClusterLifecycleEvents(tx *gorm.DB, filter *ClusterLifecycleFilter) ([]models.ClusterLifecycleEvent, error) {
+	query := tx.Where("sequence > ?", filter.After).
+		Where("created_at <= clock_timestamp()::timestamp - make_interval(secs => ?)",
+			ClusterLifecycleSettleDelay.Seconds())
+	if filter.LeafHubName != "" {
+		query = query.Where("leaf_hub_name = ?", filter.LeafHubName)
+	}
+	if filter.ClusterName != "" {
+		query = query.Where("cluster_name = ?", filter.ClusterName)
+	}
+
+	events := []models.ClusterLifecycleEvent{}
+	err := query.Order("sequence").Limit(filter.Limit).Find(&events).Error
+	return events, err
+}
@@ -3,9 +3,11 @@ package dao
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"gorm.io/gorm"
 
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
 	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
 )
 
@@ -26,7 +28,7 @@ func (dao *GenericDao) GetIdToVersionByHub(hubName string) (map[string]string, e
 	sqlTemplate := fmt.Sprintf(
 		`SELECT 
 			id AS key, 
-			payload->'metadata'->>'resourceVersion AS resource_version' 
+			payload->'metadata'->>'resourceVersion' AS resource_version
 		FROM 
 			%s 
 		WHERE 
@@ -45,30 +47,62 @@ func (dao *GenericDao) GetIdToVersionByHub(hubName string) (map[string]string, e
 	return idToVersionMap, nil
 }
 
-func (dao *GenericDao) Insert(hubName, id string, obj interface{}) error {
-	sqlTemplate := fmt.Sprintf(
-		`INSERT INTO %s (id, leaf_hub_name, payload) VALUES ($1::uuid, $2, $3::jsonb)`, dao.table)
-
-	payload, err := json.Marshal(obj)
-	if err != nil {
-		return err
-	}
-	return dao.tx.Exec(sqlTemplate, id, hubName, payload).Error
+// BatchInsert inserts the objects of the hub with the multi-row "INSERT" statements, the key of the objects is the id
+func (dao *GenericDao) BatchInsert(hubName string, objects map[string]interface{}) error {
+	return dao.batchExec(objects, func(rows []string, args []interface{}) (string, []interface{}) {
+		return fmt.Sprintf(`INSERT INTO %s (id, leaf_hub_name, payload) VALUES %s`, dao.table,
+			strings.Join(rows, ", ")), args
+	}, func(id string, payload []byte) (string, []interface{}) {
+		return "(?::uuid, ?, ?::jsonb)", []interface{}{id, hubName, string(payload)}
+	})
 }
 
-func (dao *GenericDao) Update(hubName, id string, obj interface{}) error {
-	sqlTemplate := fmt.Sprintf(
-		`UPDATE %s SET payload = $1::jsonb WHERE leaf_hub_name = $2 AND id = $3::uuid`, dao.table)
+// BatchUpdate updates the payload of the objects with the multi-row "UPDATE ... FROM (VALUES ...)" statements
+func (dao *GenericDao) BatchUpdate(hubName string, objects map[string]interface{}) error {
+	return dao.batchExec(objects, func(rows []string, args []interface{}) (string, []interface{}) {
+		return fmt.Sprintf(`UPDATE %s AS t SET payload = v.payload FROM (VALUES %s) AS v(id, payload)
+			WHERE t.leaf_hub_name = ? AND t.id = v.id`, dao.table, strings.Join(rows, ", ")), append(args, hubName)
+	}, func(id string, payload []byte) (string, []interface{}) {
+		return "(?::uuid, ?::jsonb)", []interface{}{id, string(payload)}
+	})
+}
 
-	payload, err := json.Marshal(obj)
-	if err != nil {
-		return err
+// BatchDelete deletes the objects of the hub by the ids
+func (dao *GenericDao) BatchDelete(hubName string, ids []string) error {
+	sqlTemplate := fmt.Sprintf(`DELETE FROM %s WHERE leaf_hub_name = ? AND id IN ?`, dao.table)
+	for _, batchIds := range database.Chunk(ids) {
+		if err := dao.tx.Exec(sqlTemplate, hubName, batchIds).Error; err != nil {
+			return err
+		}
 	}
-	return dao.tx.Exec(sqlTemplate, payload, hubName, id).Error
+	return nil
 }
 
-func (dao *GenericDao) Delete(hubName, id string) error {
-	sqlTemplate := fmt.Sprintf(
-		`DELETE FROM %s WHERE leaf_hub_name = $1 AND id = $2::uuid`, dao.table)
-	return dao.tx.Exec(sqlTemplate, hubName, id).Error
+// batchExec marshals the objects, and runs the statement built by the rows for each batch of the objects
+func (dao *GenericDao) batchExec(objects map[string]interface{},
+	statement func(rows []string, args []interface{}) (string, []interface{}),
+	row func(id string, payload []byte) (string, []interface{}),
+) error {
+	ids := make([]string, 0, len(objects))
+	for id := range objects {
+		ids = append(ids, id)
+	}
+	for _, batchIds := range database.Chunk(ids) {
+		rows := make([]string, 0, len(batchIds))
+		args := []interface{}{}
+		for _, id := range batchIds {
+			payload, err := json.Marshal(objects[id])
+			if err != nil {
+				return err
+			}
+			rowSQL, rowArgs := row(id, payload)
+			rows = append(rows, rowSQL)
+			args = append(args, rowArgs...)
+		}
+		sql, sqlArgs := statement(rows, args)
+		if err := dao.tx.Exec(sql, sqlArgs...).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
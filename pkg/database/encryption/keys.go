@@ -0,0 +1,150 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the files of the mounted key secret
+const (
+	// PrimaryKeyFile is the data key which encrypts the new payloads
+	PrimaryKeyFile = "key"
+	// PreviousKeyFilePrefix is the prefix of the previous data keys, e.g. "key.2024-05"
+	PreviousKeyFilePrefix = "key."
+	VaultTokenFile        = "vault-token"
+	VaultCAFile           = "vault-ca.crt"
+
+	vaultRequestTimeout = 30 * time.Second
+)
+
+// VaultConfig is the transit secrets engine of the HashiCorp Vault which wraps the data keys
+type VaultConfig struct {
+	Address      string
+	TransitMount string
+	KeyName      string
+}
+
+// LoadKeyring reads the data keys from the mounted secret directory. The keys are the plain keys, or the keys wrapped
+// by the transit secrets engine of the Vault if the vault is set
+func LoadKeyring(ctx context.Context, keyDir string, vault *VaultConfig) (*Keyring, error) {
+	entries, err := os.ReadDir(keyDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the data keys: %w", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		// the mounted secret contains the hidden "..data" directory and the symbolic links to it
+		if strings.HasPrefix(entry.Name(), PreviousKeyFilePrefix) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{PrimaryKeyFile}, names...)
+
+	var unwrapper *vaultUnwrapper
+	if vault != nil {
+		if unwrapper, err = newVaultUnwrapper(keyDir, vault); err != nil {
+			return nil, err
+		}
+	}
+	keys := [][]byte{}
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Clean(filepath.Join(keyDir, name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the data key %s: %w", name, err)
+		}
+		key := content
+		if unwrapper != nil {
+			if key, err = unwrapper.unwrap(ctx, strings.TrimSpace(string(content))); err != nil {
+				return nil, fmt.Errorf("failed to unwrap the data key %s: %w", name, err)
+			}
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(keys[0], keys[1:]...)
+}
+
+type vaultUnwrapper struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+func newVaultUnwrapper(keyDir string, vault *VaultConfig) (*vaultUnwrapper, error) {
+	token, err := os.ReadFile(filepath.Clean(filepath.Join(keyDir, VaultTokenFile)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the vault token: %w", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	caCert, err := os.ReadFile(filepath.Clean(filepath.Join(keyDir, VaultCAFile)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read the vault CA: %w", err)
+	}
+	if len(caCert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("failed to parse the vault CA")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	mount := vault.TransitMount
+	if mount == "" {
+		mount = "transit"
+	}
+	return &vaultUnwrapper{
+		client: &http.Client{Transport: transport, Timeout: vaultRequestTimeout},
+		url: fmt.Sprintf("%s/v1/%s/decrypt/%s", strings.TrimSuffix(vault.Address, "/"),
+			strings.Trim(mount, "/"), vault.KeyName),
+		token: strings.TrimSpace(string(token)),
+	}, nil
+}
+
+// unwrap decrypts the wrapped data key, e.g. "vault:v1:...", with the transit key
+func (v *vaultUnwrapper) unwrap(ctx context.Context, wrapped string) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"ciphertext": wrapped})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", v.token)
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, respBody)
+	}
+
+	result := struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse the response of the vault: %w", err)
+	}
+	// the plaintext of the transit engine is base64 encoded
+	return base64.StdEncoding.DecodeString(result.Data.Plaintext)
+}
@@ -0,0 +1,44 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadKeyringWithVault(t *testing.T) {
+	key := bytes.Repeat([]byte{3}, KeySize)
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/transit/decrypt/global-hub" || r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		request := map[string]string{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request["ciphertext"] != "vault:v1:wrapped" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"plaintext":"` + base64.StdEncoding.EncodeToString(key) + `"}}`))
+	}))
+	defer vault.Close()
+
+	keyDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(keyDir, PrimaryKeyFile), []byte("vault:v1:wrapped\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(keyDir, VaultTokenFile), []byte("token"), 0o600))
+
+	keyring, err := LoadKeyring(context.Background(), keyDir, &VaultConfig{Address: vault.URL, KeyName: "global-hub"})
+	assert.NoError(t, err)
+	assert.Equal(t, KeyID(key), keyring.primaryID)
+
+	_, err = LoadKeyring(context.Background(), keyDir, &VaultConfig{Address: vault.URL, KeyName: "other"})
+	assert.ErrorContains(t, err, "403")
+}
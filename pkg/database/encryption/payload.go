@@ -0,0 +1,300 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// KeySize is the size of the data keys, the payloads are encrypted with the AES-256-GCM
+const KeySize = 32
+
+// encryptedPayloadField is the field of the encrypted payload which holds the sealed fields of the object
+const encryptedPayloadField = "encryptedPayload"
+
+// the fields kept in plain text, the dashboards and the queries of the manager filter the payloads by the metadata
+var plainFields = map[string]bool{
+	"apiVersion": true,
+	"kind":       true,
+	"metadata":   true,
+}
+
+// sensitiveAnnotations are sealed with the other fields, the last applied configuration is a copy of the whole object
+var sensitiveAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+}
+
+// the tables whose payloads are encrypted, the policy templates may contain the credentials
+var encryptedTables = map[string]bool{
+	"spec.policies":       true,
+	"local_spec.policies": true,
+}
+
+var (
+	keyring      *Keyring
+	keyringMutex sync.RWMutex
+)
+
+// IsEncryptedTable returns true if the payloads of the table are encrypted once the keyring is set
+func IsEncryptedTable(schema, table string) bool {
+	return encryptedTables[schema+"."+table]
+}
+
+// SetKeyring sets the keyring of the payloads, the payloads are stored in plain text if it's nil
+func SetKeyring(k *Keyring) {
+	keyringMutex.Lock()
+	defer keyringMutex.Unlock()
+	keyring = k
+}
+
+func getKeyring() *Keyring {
+	keyringMutex.RLock()
+	defer keyringMutex.RUnlock()
+	return keyring
+}
+
+// EncryptPayload seals the payload with the primary key of the keyring, the payload is returned as it is if the
+// keyring isn't set
+func EncryptPayload(payload []byte) ([]byte, error) {
+	k := getKeyring()
+	if k == nil {
+		return payload, nil
+	}
+	return k.Encrypt(payload)
+}
+
+// DecryptPayload opens the sealed fields of the payload, the payloads stored before the encryption is enabled are
+// returned as they are
+func DecryptPayload(payload []byte) ([]byte, error) {
+	if !IsEncrypted(payload) {
+		return payload, nil
+	}
+	k := getKeyring()
+	if k == nil {
+		return nil, errors.New("the payload is encrypted, but the encryption key isn't configured")
+	}
+	return k.Decrypt(payload)
+}
+
+// IsEncrypted returns true if the payload contains the sealed fields
+func IsEncrypted(payload []byte) bool {
+	if !bytes.Contains(payload, []byte(`"`+encryptedPayloadField+`"`)) {
+		return false
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return false
+	}
+	_, ok := fields[encryptedPayloadField]
+	return ok
+}
+
+// Keyring is the data keys of the payloads, the primary key encrypts the new payloads, and the previous keys only
+// decrypt the payloads stored before the key is rotated
+type Keyring struct {
+	primaryID string
+	aeads     map[string]cipher.AEAD
+}
+
+// NewKeyring creates the keyring with the primary key and the previous keys
+func NewKeyring(primary []byte, previous ...[]byte) (*Keyring, error) {
+	k := &Keyring{aeads: map[string]cipher.AEAD{}}
+	for i, key := range append([][]byte{primary}, previous...) {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("the size of the data key must be %d bytes, got %d", KeySize, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		id := KeyID(key)
+		if i == 0 {
+			k.primaryID = id
+		}
+		k.aeads[id] = aead
+	}
+	return k, nil
+}
+
+// KeyID identifies the data key without revealing it, it's stored with the sealed fields to find the key to open them
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// sealedFields is the value of the encryptedPayload field
+type sealedFields struct {
+	KeyID string `json:"keyID"`
+	// Data is the nonce followed by the ciphertext of the sealed object
+	Data []byte `json:"data"`
+}
+
+// sealedObject is the plain text of the sealed fields
+type sealedObject struct {
+	Fields      map[string]json.RawMessage `json:"fields,omitempty"`
+	Annotations map[string]string          `json:"annotations,omitempty"`
+}
+
+// Encrypt seals the fields of the object except the apiVersion, the kind and the metadata. The metadata.uid is the
+// additional data of the cipher, so the sealed fields can't be moved to the payload of another object
+func (k *Keyring) Encrypt(payload []byte) ([]byte, error) {
+	if len(payload) == 0 || IsEncrypted(payload) {
+		return payload, nil
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse the payload: %w", err)
+	}
+	metadata, err := parseMetadata(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := sealedObject{Fields: map[string]json.RawMessage{}}
+	for name, value := range fields {
+		if !plainFields[name] {
+			sealed.Fields[name] = value
+			delete(fields, name)
+		}
+	}
+	annotations := map[string]string{}
+	if raw, ok := metadata["annotations"]; ok {
+		if err := json.Unmarshal(raw, &annotations); err != nil {
+			return nil, fmt.Errorf("failed to parse the annotations: %w", err)
+		}
+	}
+	for _, name := range sensitiveAnnotations {
+		if value, ok := annotations[name]; ok {
+			if sealed.Annotations == nil {
+				sealed.Annotations = map[string]string{}
+			}
+			sealed.Annotations[name] = value
+			delete(annotations, name)
+		}
+	}
+	if sealed.Annotations != nil {
+		if err := setMetadataField(metadata, "annotations", annotations); err != nil {
+			return nil, err
+		}
+		if fields["metadata"], err = json.Marshal(metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	plaintext, err := json.Marshal(sealed)
+	if err != nil {
+		return nil, err
+	}
+	aead := k.aeads[k.primaryID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate the nonce: %w", err)
+	}
+	data := aead.Seal(nonce, nonce, plaintext, additionalData(metadata))
+	if fields[encryptedPayloadField], err = json.Marshal(sealedFields{KeyID: k.primaryID, Data: data}); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// Decrypt opens the sealed fields and merges them back to the object
+func (k *Keyring) Decrypt(payload []byte) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse the payload: %w", err)
+	}
+	raw, ok := fields[encryptedPayloadField]
+	if !ok {
+		return payload, nil
+	}
+	delete(fields, encryptedPayloadField)
+	sealed := sealedFields{}
+	if err := json.Unmarshal(raw, &sealed); err != nil {
+		return nil, fmt.Errorf("failed to parse the encrypted payload: %w", err)
+	}
+	metadata, err := parseMetadata(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, ok := k.aeads[sealed.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("the data key %s of the payload isn't found", sealed.KeyID)
+	}
+	if len(sealed.Data) < aead.NonceSize() {
+		return nil, errors.New("the encrypted payload is too short")
+	}
+	nonce, ciphertext := sealed.Data[:aead.NonceSize()], sealed.Data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData(metadata))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the payload with the data key %s: %w", sealed.KeyID, err)
+	}
+	object := sealedObject{}
+	if err := json.Unmarshal(plaintext, &object); err != nil {
+		return nil, fmt.Errorf("failed to parse the decrypted payload: %w", err)
+	}
+
+	for name, value := range object.Fields {
+		fields[name] = value
+	}
+	if len(object.Annotations) > 0 {
+		annotations := map[string]string{}
+		if raw, ok := metadata["annotations"]; ok {
+			if err := json.Unmarshal(raw, &annotations); err != nil {
+				return nil, fmt.Errorf("failed to parse the annotations: %w", err)
+			}
+		}
+		for name, value := range object.Annotations {
+			annotations[name] = value
+		}
+		if err := setMetadataField(metadata, "annotations", annotations); err != nil {
+			return nil, err
+		}
+		if fields["metadata"], err = json.Marshal(metadata); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+func parseMetadata(fields map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	metadata := map[string]json.RawMessage{}
+	if raw, ok := fields["metadata"]; ok {
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse the metadata: %w", err)
+		}
+	}
+	return metadata, nil
+}
+
+func setMetadataField(metadata map[string]json.RawMessage, name string, value map[string]string) error {
+	if len(value) == 0 {
+		delete(metadata, name)
+		return nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	metadata[name] = raw
+	return nil
+}
+
+func additionalData(metadata map[string]json.RawMessage) []byte {
+	uid := ""
+	if raw, ok := metadata["uid"]; ok {
+		_ = json.Unmarshal(raw, &uid)
+	}
+	return []byte(uid)
+}
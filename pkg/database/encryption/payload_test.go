@@ -0,0 +1,108 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const policyPayload = `{
+	"apiVersion": "policy.open-cluster-management.io/v1",
+	"kind": "Policy",
+	"metadata": {
+		"name": "policy-secret",
+		"namespace": "default",
+		"uid": "a4e3d0a6-6b0c-4b0b-8f5e-1d3c1c1e0c3a",
+		"annotations": {
+			"policy.open-cluster-management.io/standards": "NIST SP 800-53",
+			"kubectl.kubernetes.io/last-applied-configuration": "{\"spec\":{\"password\":\"secret\"}}"
+		}
+	},
+	"spec": {"disabled": false, "policy-templates": [{"objectDefinition": {"password": "secret"}}]},
+	"status": {"compliant": "Compliant"}
+}`
+
+func TestEncryptPayload(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+	keyring, err := NewKeyring(key)
+	assert.NoError(t, err)
+
+	encrypted, err := keyring.Encrypt([]byte(policyPayload))
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, string(encrypted), "password")
+
+	// the metadata is still queried by the dashboards
+	object := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(encrypted, &object))
+	assert.Equal(t, "Policy", object["kind"])
+	assert.Nil(t, object["spec"])
+	metadata := object["metadata"].(map[string]interface{})
+	assert.Equal(t, "policy-secret", metadata["name"])
+	assert.Equal(t, map[string]interface{}{"policy.open-cluster-management.io/standards": "NIST SP 800-53"},
+		metadata["annotations"])
+
+	decrypted, err := keyring.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.JSONEq(t, policyPayload, string(decrypted))
+
+	// the payload can't be moved to another object
+	moved := bytes.Replace(encrypted, []byte("a4e3d0a6"), []byte("b4e3d0a6"), 1)
+	_, err = keyring.Decrypt(moved)
+	assert.Error(t, err)
+
+	// the payloads of the previous key are decrypted after the key is rotated
+	rotated, err := NewKeyring(bytes.Repeat([]byte{2}, KeySize), key)
+	assert.NoError(t, err)
+	decrypted, err = rotated.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.JSONEq(t, policyPayload, string(decrypted))
+
+	// the key is removed from the keyring
+	removed, err := NewKeyring(bytes.Repeat([]byte{2}, KeySize))
+	assert.NoError(t, err)
+	_, err = removed.Decrypt(encrypted)
+	assert.ErrorContains(t, err, KeyID(key))
+
+	_, err = NewKeyring([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestDecryptPayload(t *testing.T) {
+	defer SetKeyring(nil)
+
+	// the payloads are kept in plain text without the keyring
+	payload, err := EncryptPayload([]byte(policyPayload))
+	assert.NoError(t, err)
+	assert.Equal(t, policyPayload, string(payload))
+
+	keyDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(keyDir, PrimaryKeyFile), bytes.Repeat([]byte{1}, KeySize), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(keyDir, "key.previous"), bytes.Repeat([]byte{2}, KeySize), 0o600))
+	keyring, err := LoadKeyring(context.Background(), keyDir, nil)
+	assert.NoError(t, err)
+	assert.Len(t, keyring.aeads, 2)
+	SetKeyring(keyring)
+
+	encrypted, err := EncryptPayload([]byte(policyPayload))
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+
+	// the payloads stored before the encryption is enabled are still readable
+	decrypted, err := DecryptPayload([]byte(policyPayload))
+	assert.NoError(t, err)
+	assert.Equal(t, policyPayload, string(decrypted))
+
+	decrypted, err = DecryptPayload(encrypted)
+	assert.NoError(t, err)
+	assert.JSONEq(t, policyPayload, string(decrypted))
+
+	SetKeyring(nil)
+	_, err = DecryptPayload(encrypted)
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the gorm serializer of the encrypted payload columns, e.g.
+// `gorm:"column:payload;type:jsonb;serializer:encrypted_payload"`
+const SerializerName = "encrypted_payload"
+
+func init() {
+	schema.RegisterSerializer(SerializerName, PayloadSerializer{})
+}
+
+// PayloadSerializer encrypts the payload before it's written by gorm, and decrypts it once it's read, so the callers
+// of the models don't handle the encryption
+type PayloadSerializer struct{}
+
+// Scan implements the serializer interface
+func (PayloadSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType).Elem()
+	if dbValue != nil {
+		var payload []byte
+		switch v := dbValue.(type) {
+		case []byte:
+			payload = v
+		case string:
+			payload = []byte(v)
+		default:
+			return fmt.Errorf("failed to scan the payload: %#v", dbValue)
+		}
+		decrypted, err := DecryptPayload(payload)
+		if err != nil {
+			return err
+		}
+		fieldValue = reflect.ValueOf(decrypted).Convert(field.FieldType)
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+// Value implements the serializer interface
+func (PayloadSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value,
+	fieldValue interface{},
+) (interface{}, error) {
+	value := reflect.ValueOf(fieldValue)
+	if !value.IsValid() || value.Len() == 0 {
+		return nil, nil
+	}
+	encrypted, err := EncryptPayload(value.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return string(encrypted), nil
+}
@@ -3,13 +3,20 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	stdlog "log"
 	"net/url"
+	"os"
 	"sync"
+	"time"
 
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
 	_ "github.com/lib/pq"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -33,13 +40,23 @@ var (
 	log      = ctrl.Log.WithName("database-controller")
 	lockConn *sql.Conn
 	ctx      = context.Background()
+	// the gorm instance of the read-only replica, it's nil if the replica isn't set
+	replicaGormDB *gorm.DB
 )
 
 type DatabaseConfig struct {
-	URL        string
+	URL string
+	// ReplicaURL is the read-only replica of the database, the read-only queries of the APIs are routed to it
+	ReplicaURL string
 	Dialect    string
 	CaCertPath string
 	PoolSize   int
+	// AuthType is the authentication of the database, e.g. the "aws-iam" or "azure-ad" token, the default is password
+	AuthType string
+	// MaxIdleConns is the maximum number of the idle connections, the default of the database/sql is used if it's 0
+	MaxIdleConns int
+	// SlowQueryThreshold logs the queries which run longer than it, the default threshold of gorm is used if it's 0
+	SlowQueryThreshold time.Duration
 }
 
 func InitGormInstance(config *DatabaseConfig) error {
@@ -55,11 +72,40 @@ func InitGormInstance(config *DatabaseConfig) error {
 		if err != nil {
 			return err
 		}
-		sqlDB.SetMaxOpenConns(config.PoolSize)
+		setPoolSize(sqlDB, config)
+	}
+	if replicaGormDB == nil && config.ReplicaURL != "" {
+		initReplicaGormInstance(config)
 	}
 	return nil
 }
 
+// initReplicaGormInstance connects to the read-only replica. The replica isn't required by the manager, so the
+// read-only queries are routed to the primary if it isn't available
+func initReplicaGormInstance(config *DatabaseConfig) {
+	replicaConfig := *config
+	replicaConfig.URL = config.ReplicaURL
+	replicaDB, replicaSqlDB, err := NewGormConn(&replicaConfig)
+	if err == nil {
+		if err = replicaSqlDB.PingContext(ctx); err != nil {
+			_ = replicaSqlDB.Close()
+		}
+	}
+	if err != nil {
+		log.Error(err, "failed to connect to the read-only replica, the primary is used for the read-only queries")
+		return
+	}
+	setPoolSize(replicaSqlDB, config)
+	replicaGormDB = replicaDB
+}
+
+func setPoolSize(db *sql.DB, config *DatabaseConfig) {
+	db.SetMaxOpenConns(config.PoolSize)
+	if config.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	}
+}
+
 func NewGormConn(config *DatabaseConfig) (*gorm.DB, *sql.DB, error) {
 	var err error
 	if config.Dialect != PostgresDialect {
@@ -71,25 +117,51 @@ func NewGormConn(config *DatabaseConfig) (*gorm.DB, *sql.DB, error) {
 		return nil, nil, err
 	}
 
-	sqlDBConn, err := sql.Open(config.Dialect, urlObj.String())
+	sqlDBConn, err := openSqlDB(urlObj.String(), config)
 	if err != nil {
 		log.Error(err, "failed to open database connection")
 		return nil, nil, err
 	}
+	gormConfig := &gorm.Config{
+		PrepareStmt:          false,
+		FullSaveAssociations: false,
+	}
+	if config.SlowQueryThreshold > 0 {
+		gormConfig.Logger = logger.New(stdlog.New(os.Stdout, "\r\n", stdlog.LstdFlags), logger.Config{
+			SlowThreshold: config.SlowQueryThreshold,
+			LogLevel:      logger.Warn,
+		})
+	}
 	gormDBconn, err := gorm.Open(postgres.New(postgres.Config{
 		Conn:                 sqlDBConn,
 		PreferSimpleProtocol: true,
-	}), &gorm.Config{
-		PrepareStmt:          false,
-		FullSaveAssociations: false,
-	})
+	}), gormConfig)
 	if err != nil {
 		log.Error(err, "failed to open gorm connection")
+		// the pool isn't returned, close it so the retries don't leak the connections
+		_ = sqlDBConn.Close()
 		return nil, nil, err
 	}
 	return gormDBconn, sqlDBConn, nil
 }
 
+// openSqlDB opens the database with the lib/pq driver for the password authentication, otherwise it's opened with the
+// pgx driver, which sets the token of the cloud identity on each new connection
+func openSqlDB(databaseURL string, config *DatabaseConfig) (*sql.DB, error) {
+	if err := ValidateAuthType(config.AuthType); err != nil {
+		return nil, err
+	}
+	beforeConnect := BeforeConnect(config.AuthType)
+	if beforeConnect == nil {
+		return sql.Open(config.Dialect, databaseURL)
+	}
+	connConfig, err := pgx.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return stdlib.OpenDB(*connConfig, stdlib.OptionBeforeConnect(beforeConnect)), nil
+}
+
 func GetGorm() *gorm.DB {
 	if gormDB == nil {
 		log.Error(nil, "gorm connection is not initialized")
@@ -98,6 +170,15 @@ func GetGorm() *gorm.DB {
 	return gormDB
 }
 
+// GetReadonlyGorm returns the gorm instance of the read-only replica for the queries which don't write the database,
+// e.g. the list APIs. It's the primary instance if the replica isn't set or isn't available
+func GetReadonlyGorm() *gorm.DB {
+	if replicaGormDB != nil {
+		return replicaGormDB
+	}
+	return GetGorm()
+}
+
 func GetSqlDb() *sql.DB {
 	if sqlDB == nil {
 		log.Error(nil, "sqlDb connection is not initialized")
@@ -156,6 +237,35 @@ func Unlock(lockConn *sql.Conn) {
 	}
 }
 
+// hubLockClassID is the first key of the advisory locks of the managed hubs. The locks of the two int keys don't
+// conflict with the lock of the bigint key, which is used by the backup
+const hubLockClassID = 2
+
+// LockHub takes the advisory lock of the managed hub on a dedicated connection, so the bundles of the hub are written
+// by one manager replica at a time, e.g. while its partition is moved to another replica. The returned function
+// releases the lock and the connection
+func LockHub(ctx context.Context, leafHubName string) (func(), error) {
+	conn, err := GetSqlDb().Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the connection of the hub lock: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1, hashtext($2))", hubLockClassID,
+		leafHubName); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to lock the hub %s: %w", leafHubName, err)
+	}
+	return func() {
+		// the lock is released even if the context is canceled
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1, hashtext($2))",
+			hubLockClassID, leafHubName); err != nil {
+			log.Error(err, "failed to unlock the hub, discard the connection", "hub", leafHubName)
+			// the lock is held until the session ends, so the connection isn't returned to the pool
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		_ = conn.Close()
+	}, nil
+}
+
 // Close the sql.DB connection
 func CloseGorm(sqlConn *sql.DB) {
 	if sqlConn != nil {
@@ -171,10 +281,12 @@ func completePostgres(postgresUri string, caCertPath string) (*url.URL, error) {
 	if err != nil {
 		return nil, err
 	}
-	// only support verify-ca or disable(for test)
+	// only support verify-ca, verify-full or disable(for test). The lib/pq reads the sslrootcert on each new
+	// connection, so the rotated CA is used without restarting the process
 	query := urlObj.Query()
 	_, ok := utils.Validate(caCertPath)
-	if query.Get("sslmode") == "verify-ca" && ok {
+	sslMode := query.Get("sslmode")
+	if (sslMode == "verify-ca" || sslMode == "verify-full") && ok {
 		query.Set("sslrootcert", caCertPath)
 	} else {
 		query.Add("sslmode", "disable")
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	// registers the serializer of the encrypted payload columns
+	_ "github.com/stolostron/multicluster-global-hub/pkg/database/encryption"
+)
 
 type ResourceVersion struct {
 	Key             string `gorm:"column:key"`
@@ -68,3 +68,33 @@ type ManagedClusterEvent struct {
 func (ManagedClusterEvent) TableName() string {
 	return "event.managed_clusters"
 }
+
+// the types of the lifecycle events of the managed clusters
+const (
+	// ClusterCreated is a cluster provisioned by the managed hub, e.g. with the hive or the hypershift
+	ClusterCreated = "Created"
+	// ClusterImported is an existing cluster imported to the managed hub
+	ClusterImported = "Imported"
+	// ClusterDetached is a cluster removed from the managed hub
+	ClusterDetached = "Detached"
+	// ClusterUpgraded is a cluster whose version is changed
+	ClusterUpgraded = "Upgraded"
+)
+
+// ClusterLifecycleEvent is a change of the lifecycle of a managed cluster, the sequence is increased with each event,
+// so the events of a cluster are ordered by it
+type ClusterLifecycleEvent struct {
+	Sequence        int64     `gorm:"column:sequence;default:(-)" json:"sequence"`
+	LeafHubName     string    `gorm:"column:leaf_hub_name;type:varchar(254);not null" json:"leafHubName"`
+	ClusterID       string    `gorm:"column:cluster_id;type:uuid;not null" json:"clusterId"`
+	ClusterName     string    `gorm:"column:cluster_name;type:text;not null" json:"clusterName"`
+	EventType       string    `gorm:"column:event_type;type:varchar(64);not null" json:"type"`
+	Version         string    `gorm:"column:version;type:text" json:"version,omitempty"`
+	PreviousVersion string    `gorm:"column:previous_version;type:text" json:"previousVersion,omitempty"`
+	CreatedVia      string    `gorm:"column:created_via;type:text" json:"createdVia,omitempty"`
+	CreatedAt       time.Time `gorm:"column:created_at;default:clock_timestamp();not null" json:"createdAt"`
+}
+
+func (ClusterLifecycleEvent) TableName() string {
+	return "event.cluster_lifecycle"
+}
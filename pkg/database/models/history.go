@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
 
 type LocalComplianceJobLog struct {
 	Name     string    `gorm:"column:name"`
@@ -16,6 +20,36 @@ func (LocalComplianceJobLog) TableName() string {
 	return "history.local_compliance_job_log"
 }
 
+// ComplianceRollup is the number of the clusters in each compliance state of the policy in the day or the week
+type ComplianceRollup struct {
+	Period       string    `gorm:"column:period;primaryKey"`
+	PeriodStart  time.Time `gorm:"type:date;column:period_start;primaryKey"`
+	LeafHubName  string    `gorm:"column:leaf_hub_name;primaryKey"`
+	PolicyID     string    `gorm:"column:policy_id;primaryKey"`
+	Compliant    int       `gorm:"column:compliant"`
+	NonCompliant int       `gorm:"column:non_compliant"`
+	Pending      int       `gorm:"column:pending"`
+	Unknown      int       `gorm:"column:unknown"`
+}
+
+func (ComplianceRollup) TableName() string {
+	return "history.compliance_rollups"
+}
+
+// CompliancePercentage is the percentage of the compliant clusters of the policy or the managed hub in the day
+type CompliancePercentage struct {
+	ComplianceDate time.Time `gorm:"type:date;column:compliance_date;primaryKey"`
+	Scope          string    `gorm:"column:scope;primaryKey"`
+	ScopeKey       string    `gorm:"column:scope_key;primaryKey"`
+	Compliant      int       `gorm:"column:compliant"`
+	Total          int       `gorm:"column:total"`
+	Percentage     float64   `gorm:"column:percentage"`
+}
+
+func (CompliancePercentage) TableName() string {
+	return "history.compliance_percentages"
+}
+
 type LocalComplianceHistory struct {
 	PolicyID                   string    `gorm:"column:policy_id"`
 	ClusterID                  string    `gorm:"olumn:cluster_id"`
@@ -29,3 +63,44 @@ type LocalComplianceHistory struct {
 func (LocalComplianceHistory) TableName() string {
 	return "history.local_compliance"
 }
+
+type DataPurgeLog struct {
+	ID                string         `gorm:"column:id;primaryKey"`
+	TargetType        string         `gorm:"column:target_type;not null"`
+	LeafHubName       string         `gorm:"column:leaf_hub_name;not null"`
+	ClusterName       string         `gorm:"column:cluster_name"`
+	ConfirmationToken string         `gorm:"column:confirmation_token;not null"`
+	Status            string         `gorm:"column:status;not null"`
+	RequestedBy       string         `gorm:"column:requested_by"`
+	RequestedAt       time.Time      `gorm:"column:requested_at;default:current_timestamp"`
+	ConfirmedBy       string         `gorm:"column:confirmed_by"`
+	CompletedAt       *time.Time     `gorm:"column:completed_at"`
+	DeletedRows       datatypes.JSON `gorm:"column:deleted_rows;type:jsonb"`
+	Error             string         `gorm:"column:error"`
+}
+
+func (DataPurgeLog) TableName() string {
+	return "history.data_purge_log"
+}
+
+// AuditLog records who changed a global resource, or who requested the manager API
+type AuditLog struct {
+	// Source is "spec" for the changes of the global resources, or "api" for the requests of the manager API
+	Source   string         `gorm:"column:source;not null" json:"source"`
+	Username string         `gorm:"column:username" json:"username"`
+	Groups   datatypes.JSON `gorm:"column:groups;type:jsonb" json:"groups,omitempty"`
+	// Verb is the operation of the admission request, e.g. CREATE, or the method of the API request, e.g. GET
+	Verb      string `gorm:"column:verb;not null" json:"verb"`
+	Resource  string `gorm:"column:resource" json:"resource,omitempty"`
+	Namespace string `gorm:"column:namespace" json:"namespace,omitempty"`
+	Name      string `gorm:"column:name" json:"name,omitempty"`
+	// RequestURI is the path and the query of the API request
+	RequestURI string    `gorm:"column:request_uri" json:"requestURI,omitempty"`
+	StatusCode int       `gorm:"column:status_code" json:"statusCode,omitempty"`
+	ClientIP   string    `gorm:"column:client_ip" json:"clientIP,omitempty"`
+	CreatedAt  time.Time `gorm:"column:created_at;default:now();not null" json:"createdAt"`
+}
+
+func (AuditLog) TableName() string {
+	return "history.audit_logs"
+}
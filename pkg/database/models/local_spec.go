@@ -9,7 +9,7 @@ import (
 
 type LocalSpecPolicy struct {
 	LeafHubName    string         `gorm:"column:leaf_hub_name"`
-	Payload        datatypes.JSON `gorm:"column:payload;type:jsonb"`
+	Payload        datatypes.JSON `gorm:"column:payload;type:jsonb;serializer:encrypted_payload"`
 	PolicyID       string         `gorm:"column:policy_id;primaryKey"`
 	PolicyName     string         `gorm:"column:policy_name;default:(-)"`
 	PolicyStandard string         `gorm:"column:policy_standard;default:(-)"`
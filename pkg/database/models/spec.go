@@ -32,7 +32,7 @@ func (ManagedClusterLabel) TableName() string {
 
 type SpecPolicy struct {
 	ID        string         `gorm:"column:id;primaryKey"`
-	Payload   datatypes.JSON `gorm:"column:payload;type:jsonb"`
+	Payload   datatypes.JSON `gorm:"column:payload;type:jsonb;serializer:encrypted_payload"`
 	CreatedAt time.Time      `gorm:"column:created_at;autoCreateTime:true"`
 	UpdatedAt time.Time      `gorm:"column:updated_at;autoUpdateTime:true"`
 	Deleted   bool           `gorm:"column:deleted"`
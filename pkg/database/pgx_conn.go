@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 
@@ -14,11 +15,21 @@ import (
 
 const errMessageFileNotFound = "no such file or directory"
 
-func PostgresConnection(ctx context.Context, URI string, cert []byte) (*pgx.Conn, error) {
+// PostgresConnection connects to the postgres, the token of the cloud identity is used as the password if the authType
+// is "aws-iam" or "azure-ad"
+func PostgresConnection(ctx context.Context, URI string, cert []byte, authType string) (*pgx.Conn, error) {
 	config, err := GetPostgresConfig(URI, cert)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get database config: %w", err)
 	}
+	if err := ValidateAuthType(authType); err != nil {
+		return nil, err
+	}
+	if beforeConnect := BeforeConnect(authType); beforeConnect != nil {
+		if err := beforeConnect(ctx, config); err != nil {
+			return nil, fmt.Errorf("failed to get the database token: %w", err)
+		}
+	}
 
 	conn, err := pgx.ConnectConfig(ctx, config)
 	if err != nil {
@@ -39,17 +50,26 @@ func GetPostgresConfig(URI string, cert []byte) (*pgx.ConnConfig, error) {
 			RootCAs: caCertPool,
 			// nolint:gosec
 			InsecureSkipVerify: true, // #nosec G402
+			VerifyConnection: verifyConnection(URI, config.Host, func() ([]byte, error) {
+				return cert, nil
+			}),
 		}
 	}
 	return config, nil
 }
 
-// PostgresConnPool returns a new postgres connection pool. size < 0 means deafult size.
-func PostgresConnPool(ctx context.Context, databaseURI string, certPath string, size int32) (*pgxpool.Pool, error) {
+// PostgresConnPool returns a new postgres connection pool. size < 0 means deafult size. The token of the cloud identity
+// is refreshed before each new connection of the pool if the authType is "aws-iam" or "azure-ad"
+func PostgresConnPool(ctx context.Context, databaseURI string, certPath string, size int32, authType string,
+) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(databaseURI)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get postgres pool config: %w", err)
 	}
+	if err := ValidateAuthType(authType); err != nil {
+		return nil, err
+	}
+	config.BeforeConnect = BeforeConnect(authType)
 
 	cert, err := os.ReadFile(certPath) // #nosec G304
 	if err != nil && !strings.Contains(err.Error(), errMessageFileNotFound) {
@@ -61,6 +81,10 @@ func PostgresConnPool(ctx context.Context, databaseURI string, certPath string,
 		config.ConnConfig.TLSConfig = &tls.Config{
 			RootCAs:            caCertPool,
 			InsecureSkipVerify: true, // #nosec G402
+			// the CA is read on each new connection, so the rotated CA is used without restarting the process
+			VerifyConnection: verifyConnection(databaseURI, config.ConnConfig.Host, func() ([]byte, error) {
+				return os.ReadFile(certPath) // #nosec G304
+			}),
 		}
 	}
 
@@ -75,3 +99,36 @@ func PostgresConnPool(ctx context.Context, databaseURI string, certPath string,
 
 	return dbConnectionPool, nil
 }
+
+// verifyConnection verifies the certificate of the server with the CA and the host name if the sslmode is
+// "verify-full". The other modes are unchanged, the certificate isn't verified by the client
+func verifyConnection(databaseURI, host string, loadCA func() ([]byte, error),
+) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		uri, err := url.Parse(databaseURI)
+		if err != nil || uri.Query().Get("sslmode") != "verify-full" {
+			return nil
+		}
+		if len(state.PeerCertificates) == 0 {
+			return fmt.Errorf("no certificate is presented by the database server %s", host)
+		}
+		ca, err := loadCA()
+		if err != nil {
+			return fmt.Errorf("unable to read database cert file: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(ca) {
+			return fmt.Errorf("no valid CA certificate to verify the database server %s", host)
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range state.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err = state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			DNSName:       host,
+		})
+		return err
+	}
+}
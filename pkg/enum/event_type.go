@@ -10,6 +10,13 @@ const (
 	ManagedClusterType      EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.managedcluster"
 	SubscriptionReportType  EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.subscription.report"
 	SubscriptionStatusType  EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.subscription.status"
+	ArgoApplicationType     EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.argocd.application"
+	//nolint: go:S103
+	ArgoApplicationSetType EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.argocd.applicationset"
+	//nolint: go:S103
+	ManagedClusterSetType EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.managedclusterset"
+	//nolint: go:S103
+	ManagedClusterSetBindingType EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.managedclustersetbinding"
 
 	// used by the local resources
 	//nolint: go:S103
@@ -25,6 +32,8 @@ const (
 
 	DeltaComplianceType EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.policy.deltacompliance"
 	MiniComplianceType  EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.policy.minicompliance"
+	PolicySetType       EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.policyset"
+	OperatorPolicyType  EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.operatorpolicy"
 
 	// used to send kube events
 	//nolint: go:S103
@@ -38,4 +47,10 @@ const (
 	LocalPlacementRuleSpecType EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.placementrule.localspec"
 	PlacementRuleSpecType      EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.placementrule.spec"
 	PlacementSpecType          EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.placement.spec"
+	//nolint: go:S103
+	LocalPlacementSpecType EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.placement.localspec"
+	//nolint: go:S103
+	LocalPlacementDecisionType EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.placementdecision.local"
+	//nolint: go:S103
+	AddOnPlacementScoreType EventType = "io.open-cluster-management.operator.multiclusterglobalhubs.addonplacementscore"
 )
@@ -0,0 +1,82 @@
+package statistics
+
+import (
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// the metrics of the bundles received from the managed hubs, the type is the event type without the common prefix
+var (
+	BundleSizeHistogramVec = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "multicluster_global_hub_bundle_size_bytes",
+			Help:    "The size of the bundles received from the transport, the chunks are assembled into one bundle.",
+			Buckets: prometheus.ExponentialBuckets(1024, 4, 8), // 1KiB to 16MiB
+		},
+		[]string{"type"},
+	)
+	BundleDecodeHistogramVec = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "multicluster_global_hub_bundle_decode_duration_seconds",
+			Help: "The time of decoding the avro or protobuf bundles received from the transport, the JSON bundles " +
+				"are decoded by the handlers.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 4, 8), // 0.5ms to 8s
+		},
+		[]string{"type"},
+	)
+	BundleDatabaseHistogramVec = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "multicluster_global_hub_bundle_database_duration_seconds",
+			Help:    "The time of writing the bundles to the database, including the retries, by the result.",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2.5, 10), // 5ms to 19s
+		},
+		[]string{"type", "result"},
+	)
+	HubDatabaseSecondsCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "multicluster_global_hub_hub_database_seconds_total",
+			Help: "The total time of writing the bundles of the managed hub to the database.",
+		},
+		[]string{"hub"},
+	)
+	BundleLagGaugeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "multicluster_global_hub_bundle_lag_seconds",
+			Help: "The time from sending the last bundle of the type on the managed hub until it's written to the " +
+				"database.",
+		},
+		[]string{"hub", "type"},
+	)
+)
+
+// ObserveReceivedBundle records the size of the received bundle and the time of decoding it
+func ObserveReceivedBundle(evt *cloudevents.Event, size int, decodeDuration time.Duration) {
+	bundleType := bundleTypeLabel(evt)
+	BundleSizeHistogramVec.WithLabelValues(bundleType).Observe(float64(size))
+	BundleDecodeHistogramVec.WithLabelValues(bundleType).Observe(decodeDuration.Seconds())
+}
+
+// observeDatabaseBundle records the time of writing the bundle to the database, and the lag of the hub once the
+// bundle is written
+func observeDatabaseBundle(evt *cloudevents.Event, duration time.Duration, err error) {
+	bundleType := bundleTypeLabel(evt)
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	BundleDatabaseHistogramVec.WithLabelValues(bundleType, result).Observe(duration.Seconds())
+	HubDatabaseSecondsCounterVec.WithLabelValues(evt.Source()).Add(duration.Seconds())
+	// the time is set by the producer of the agent, so the lag includes the clock skew of the managed hub
+	if err == nil && !evt.Time().IsZero() {
+		BundleLagGaugeVec.WithLabelValues(evt.Source(), bundleType).Set(time.Since(evt.Time()).Seconds())
+	}
+}
+
+func bundleTypeLabel(evt *cloudevents.Event) string {
+	return strings.TrimPrefix(evt.Type(), enum.EventTypePrefix)
+}
@@ -0,0 +1,31 @@
+package statistics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+func TestObserveDatabaseBundle(t *testing.T) {
+	evt := cloudevents.NewEvent()
+	evt.SetType(string(enum.ManagedClusterType))
+	evt.SetSource("hub1")
+	evt.SetTime(time.Now().Add(-time.Minute))
+
+	observeDatabaseBundle(&evt, 2*time.Second, errors.New("failed to write"))
+	assert.Equal(t, 1, testutil.CollectAndCount(BundleDatabaseHistogramVec))
+	assert.Equal(t, 0, testutil.CollectAndCount(BundleLagGaugeVec), "the lag isn't updated by the failed bundle")
+
+	observeDatabaseBundle(&evt, time.Second, nil)
+	assert.Equal(t, 2, testutil.CollectAndCount(BundleDatabaseHistogramVec))
+	assert.Equal(t, float64(3), testutil.ToFloat64(HubDatabaseSecondsCounterVec.WithLabelValues("hub1")))
+	lag := testutil.ToFloat64(BundleLagGaugeVec.WithLabelValues("hub1", "managedcluster"))
+	assert.GreaterOrEqual(t, lag, float64(60))
+	assert.Less(t, lag, float64(120))
+}
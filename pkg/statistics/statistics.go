@@ -85,6 +85,7 @@ func (s *Statistics) IncrementNumberOfConflations() {
 
 // AddDatabaseMetrics adds database metrics of the specific event type.
 func (s *Statistics) AddDatabaseMetrics(evt *cloudevents.Event, duration time.Duration, err error) {
+	observeDatabaseBundle(evt, duration, err)
 	eventMetrics, ok := s.eventMetrics[evt.Type()]
 	if !ok {
 		return
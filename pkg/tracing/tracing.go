@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// the W3C trace context is propagated in the extensions of the cloudevents, so it's carried by the kafka headers
+	TraceParentExtension = "traceparent"
+	TraceStateExtension  = "tracestate"
+	// ReceivedTimeExtension is the time the manager received the bundle, the conflation span starts from it
+	ReceivedTimeExtension = "receivedtime"
+
+	tracerName = "github.com/stolostron/multicluster-global-hub"
+)
+
+// TracingConfig is the configuration of exporting the spans to the OTLP collector
+type TracingConfig struct {
+	// Endpoint is the host:port of the OTLP gRPC collector, the spans aren't exported if it's empty
+	Endpoint string
+	Insecure bool
+	// SamplingPercentage is the percentage of the traces started by the agents to be sampled, the manager follows the
+	// sampling decision of the agents
+	SamplingPercentage int
+}
+
+// Init sets the global tracer provider which exports the spans of the service to the OTLP collector, the returned
+// function flushes the pending spans and stops the provider
+func Init(ctx context.Context, serviceName string, config *TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if config == nil || config.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if config.SamplingPercentage < 0 || config.SamplingPercentage > 100 {
+		return nil, fmt.Errorf("the tracing sampling percentage %d isn't between 0 and 100", config.SamplingPercentage)
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(float64(config.SamplingPercentage)/100))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// StartSendSpan starts the span of producing the event to the transport, and propagates its context in the event
+func StartSendSpan(ctx context.Context, evt *cloudevents.Event) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "send "+evt.Type(),
+		trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(eventAttributes(evt)...))
+	otel.GetTextMapPropagator().Inject(ctx, &eventCarrier{evt})
+	return ctx, span
+}
+
+// StartReceiveSpan starts the span of consuming the event from the transport as the child of the send span
+func StartReceiveSpan(evt *cloudevents.Event) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), &eventCarrier{evt})
+	return otel.Tracer(tracerName).Start(ctx, "receive "+evt.Type(),
+		trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(eventAttributes(evt)...))
+}
+
+// EndReceiveSpan ends the receive span, and propagates its context and the received time in the event to the spans
+// of the conflation and the database
+func EndReceiveSpan(ctx context.Context, evt *cloudevents.Event, span trace.Span, err error) {
+	EndSpan(span, err)
+	if !span.SpanContext().IsValid() {
+		return
+	}
+	// the sampling decision is propagated as well, so the manager doesn't start a new trace for the event
+	otel.GetTextMapPropagator().Inject(ctx, &eventCarrier{evt})
+	if span.SpanContext().IsSampled() {
+		evt.SetExtension(ReceivedTimeExtension, types.Timestamp{Time: time.Now()})
+	}
+}
+
+// StartCommitSpan records the time the event waited in the conflation unit, and starts the span of writing the event
+// to the database
+func StartCommitSpan(ctx context.Context, evt *cloudevents.Event) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, &eventCarrier{evt})
+	tracer := otel.Tracer(tracerName)
+	if receivedTime, err := types.ToTime(evt.Extensions()[ReceivedTimeExtension]); err == nil {
+		_, conflationSpan := tracer.Start(ctx, "conflate "+evt.Type(), trace.WithTimestamp(receivedTime),
+			trace.WithAttributes(eventAttributes(evt)...))
+		conflationSpan.End()
+	}
+	return tracer.Start(ctx, "commit "+evt.Type(), trace.WithAttributes(eventAttributes(evt)...))
+}
+
+// EndSpan ends the span, the error is recorded as the status of the span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func eventAttributes(evt *cloudevents.Event) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("cloudevents.event_id", evt.ID()),
+		attribute.String("cloudevents.event_source", evt.Source()),
+		attribute.String("cloudevents.event_type", evt.Type()),
+	}
+}
+
+// eventCarrier reads and writes the trace context from the extensions of the cloudevent
+type eventCarrier struct {
+	evt *cloudevents.Event
+}
+
+func (c *eventCarrier) Get(key string) string {
+	val, ok := c.evt.Extensions()[key]
+	if !ok {
+		return ""
+	}
+	str, err := types.ToString(val)
+	if err != nil {
+		return ""
+	}
+	return str
+}
+
+func (c *eventCarrier) Set(key string, value string) {
+	c.evt.SetExtension(key, value)
+}
+
+func (c *eventCarrier) Keys() []string {
+	return []string{TraceParentExtension, TraceStateExtension}
+}
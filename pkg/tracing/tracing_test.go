@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPropagateTraceInEvent(t *testing.T) {
+	_, err := Init(context.Background(), "test", nil)
+	require.NoError(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	evt := cloudevents.NewEvent()
+	evt.SetID("1")
+	evt.SetSource("hub1")
+	evt.SetType("io.open-cluster-management.operator.multiclusterglobalhubs.managedcluster")
+
+	_, span := StartSendSpan(context.Background(), &evt)
+	EndSpan(span, nil)
+	assert.Contains(t, evt.Extensions(), TraceParentExtension)
+	assert.NotContains(t, evt.Extensions(), ReceivedTimeExtension)
+
+	ctx, span := StartReceiveSpan(&evt)
+	EndReceiveSpan(ctx, &evt, span, nil)
+	assert.Contains(t, evt.Extensions(), ReceivedTimeExtension)
+
+	_, span = StartCommitSpan(context.Background(), &evt)
+	EndSpan(span, errors.New("failed to write"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	send, receive, conflate, commit := spans[0], spans[1], spans[2], spans[3]
+	for _, s := range spans[1:] {
+		assert.Equal(t, send.SpanContext().TraceID(), s.SpanContext().TraceID())
+	}
+	assert.Equal(t, send.SpanContext().SpanID(), receive.Parent().SpanID())
+	assert.Equal(t, receive.SpanContext().SpanID(), conflate.Parent().SpanID())
+	assert.Equal(t, receive.SpanContext().SpanID(), commit.Parent().SpanID())
+	assert.Equal(t, codes.Error, commit.Status().Code)
+}
+
+func TestReceiveUnsampledEvent(t *testing.T) {
+	_, err := Init(context.Background(), "test", &TracingConfig{})
+	require.NoError(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.NeverSample()))))
+
+	evt := cloudevents.NewEvent()
+	ctx, span := StartReceiveSpan(&evt)
+	EndReceiveSpan(ctx, &evt, span, nil)
+	assert.Contains(t, evt.Extensions(), TraceParentExtension)
+	assert.NotContains(t, evt.Extensions(), ReceivedTimeExtension)
+	assert.Empty(t, recorder.Ended())
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package avro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+const (
+	// ContentType is the datacontenttype of the avro encoded bundles
+	ContentType = "application/avro"
+	// BundleSubject registers the schema with the record name strategy, so that all the topics share the subject
+	BundleSubject = "io.openclustermanagement.globalhub.Bundle"
+	// BundleCompatibility allows the manager and the agents of the different releases to read the bundles of each
+	// other, the schema can only be evolved by adding or removing the fields with the default values
+	BundleCompatibility = "FULL"
+
+	// the confluent wire format: the magic byte, the 4 bytes schema id and the avro binary
+	magicByte    = byte(0)
+	headerLength = 5
+)
+
+// BundleSchema is the avro schema of the envelope of the bundles, the data is the JSON payload of the bundle
+const BundleSchema = `{
+  "type": "record",
+  "name": "Bundle",
+  "namespace": "io.openclustermanagement.globalhub",
+  "fields": [
+    {"name": "type", "type": "string"},
+    {"name": "formatVersion", "type": "int", "default": 1},
+    {"name": "data", "type": "bytes"}
+  ]
+}`
+
+// Bundle is the record of the BundleSchema
+type Bundle struct {
+	Type          string
+	FormatVersion int32
+	Data          []byte
+}
+
+// Codec encodes the data of the cloudevents with the BundleSchema registered in the schema registry
+type Codec struct {
+	registry *RegistryClient
+	mutex    sync.Mutex
+	schemaID int
+	// the schema ids which are verified as the bundle schema
+	knownIDs map[int]bool
+}
+
+func NewCodec(registryConfig *transport.SchemaRegistryConfig) (*Codec, error) {
+	registry, err := NewRegistryClient(registryConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{registry: registry, knownIDs: map[int]bool{}}, nil
+}
+
+// Encode replaces the data of the event with the avro encoded bundle, the schema is registered at the first time
+func (c *Codec) Encode(evt *cloudevents.Event) error {
+	schemaID, err := c.registerSchema()
+	if err != nil {
+		return err
+	}
+	payload := make([]byte, headerLength)
+	payload[0] = magicByte
+	binary.BigEndian.PutUint32(payload[1:headerLength], uint32(schemaID))
+	payload = append(payload, EncodeBundle(&Bundle{
+		Type:          evt.Type(),
+		FormatVersion: int32(transport.GetFormatVersion(evt)),
+		Data:          evt.Data(),
+	})...)
+
+	if err := evt.SetData(ContentType, payload); err != nil {
+		return fmt.Errorf("failed to set the avro data: %w", err)
+	}
+	evt.SetDataSchema(c.registry.SchemaURL(schemaID))
+	return nil
+}
+
+// Decode replaces the avro encoded data of the event with the JSON payload of the bundle, the event isn't changed
+// if it isn't encoded with avro
+func (c *Codec) Decode(evt *cloudevents.Event) error {
+	if evt.DataContentType() != ContentType {
+		return nil
+	}
+	payload := evt.Data()
+	if len(payload) < headerLength || payload[0] != magicByte {
+		return errors.New("the avro data doesn't start with the magic byte")
+	}
+	schemaID := int(binary.BigEndian.Uint32(payload[1:headerLength]))
+	if err := c.verifySchema(schemaID); err != nil {
+		return err
+	}
+	bundle, err := DecodeBundle(payload[headerLength:])
+	if err != nil {
+		return err
+	}
+	if err := evt.SetData(cloudevents.ApplicationJSON, bundle.Data); err != nil {
+		return fmt.Errorf("failed to set the decoded data: %w", err)
+	}
+	evt.SetDataSchema("")
+	return nil
+}
+
+func (c *Codec) registerSchema() (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.schemaID > 0 {
+		return c.schemaID, nil
+	}
+	if err := c.registry.SetCompatibility(BundleSubject, BundleCompatibility); err != nil {
+		return 0, err
+	}
+	schemaID, err := c.registry.Register(BundleSubject, BundleSchema)
+	if err != nil {
+		return 0, err
+	}
+	c.schemaID = schemaID
+	c.knownIDs[schemaID] = true
+	return schemaID, nil
+}
+
+// verifySchema makes sure the writer schema is a version of the bundle schema
+func (c *Codec) verifySchema(schemaID int) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.knownIDs[schemaID] {
+		return nil
+	}
+	schema, err := c.registry.GetSchema(schemaID)
+	if err != nil {
+		return err
+	}
+	record := struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	}{}
+	if err := json.Unmarshal([]byte(schema), &record); err != nil {
+		return fmt.Errorf("failed to parse the schema %d: %w", schemaID, err)
+	}
+	if fmt.Sprintf("%s.%s", record.Namespace, record.Name) != BundleSubject {
+		return fmt.Errorf("the schema %d isn't the bundle schema: %s.%s", schemaID, record.Namespace, record.Name)
+	}
+	c.knownIDs[schemaID] = true
+	return nil
+}
+
+// EncodeBundle returns the avro binary of the bundle, the fields are encoded in the order of the schema
+func EncodeBundle(bundle *Bundle) []byte {
+	buf := &bytes.Buffer{}
+	writeBytes(buf, []byte(bundle.Type))
+	writeLong(buf, int64(bundle.FormatVersion))
+	writeBytes(buf, bundle.Data)
+	return buf.Bytes()
+}
+
+// DecodeBundle parses the avro binary of the bundle
+func DecodeBundle(data []byte) (*Bundle, error) {
+	reader := bytes.NewReader(data)
+	bundleType, err := readBytes(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the bundle type: %w", err)
+	}
+	formatVersion, err := binary.ReadVarint(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the format version: %w", err)
+	}
+	bundleData, err := readBytes(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the bundle data: %w", err)
+	}
+	return &Bundle{
+		Type:          string(bundleType),
+		FormatVersion: int32(formatVersion),
+		Data:          bundleData,
+	}, nil
+}
+
+// writeLong writes the zigzag varint, which is the same as the avro int and long
+func writeLong(buf *bytes.Buffer, val int64) {
+	varint := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutVarint(varint, val)
+	buf.Write(varint[:n])
+}
+
+func writeBytes(buf *bytes.Buffer, val []byte) {
+	writeLong(buf, int64(len(val)))
+	buf.Write(val)
+}
+
+func readBytes(reader *bytes.Reader) ([]byte, error) {
+	length, err := binary.ReadVarint(reader)
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 || length > int64(reader.Len()) {
+		return nil, fmt.Errorf("invalid length %d", length)
+	}
+	val := make([]byte, length)
+	if _, err := reader.Read(val); err != nil && length > 0 {
+		return nil, err
+	}
+	return val, nil
+}
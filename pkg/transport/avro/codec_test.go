@@ -0,0 +1,119 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+// fakeRegistry serves the subset of the schema registry API used by the codec
+type fakeRegistry struct {
+	mutex         sync.Mutex
+	schemas       map[int]string
+	compatibility map[string]string
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	body := map[string]string{}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	switch {
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/config/"):
+		f.compatibility[strings.TrimPrefix(r.URL.Path, "/config/")] = body["compatibility"]
+		_ = json.NewEncoder(w).Encode(body)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/subjects/"):
+		for id, schema := range f.schemas {
+			if schema == body["schema"] {
+				_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+				return
+			}
+		}
+		id := len(f.schemas) + 1
+		f.schemas[id] = body["schema"]
+		_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/schemas/ids/"):
+		var id int
+		_, _ = fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/schemas/ids/"), "%d", &id)
+		schema, ok := f.schemas[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"schema": schema})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestBundleEncoding(t *testing.T) {
+	bundle := &Bundle{
+		Type:          "io.open-cluster-management.operator.multiclusterglobalhubs.policy.localspec",
+		FormatVersion: 2,
+		Data:          []byte(`{"objects":[]}`),
+	}
+	decoded, err := DecodeBundle(EncodeBundle(bundle))
+	assert.NoError(t, err)
+	assert.Equal(t, bundle, decoded)
+
+	// the empty data
+	bundle.Data = []byte{}
+	decoded, err = DecodeBundle(EncodeBundle(bundle))
+	assert.NoError(t, err)
+	assert.Equal(t, bundle, decoded)
+
+	// the truncated data
+	_, err = DecodeBundle(EncodeBundle(bundle)[:3])
+	assert.Error(t, err)
+}
+
+func TestCodec(t *testing.T) {
+	registry := &fakeRegistry{schemas: map[int]string{}, compatibility: map[string]string{}}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	codec, err := NewCodec(&transport.SchemaRegistryConfig{URL: server.URL})
+	assert.NoError(t, err)
+
+	evt := cloudevents.NewEvent()
+	evt.SetType("test.bundle")
+	evt.SetSource("hub1")
+	evt.SetExtension(transport.FormatVersionKey, transport.CurrentFormatVersion)
+	payload := []byte(`{"clusters":["cluster1","cluster2"]}`)
+	assert.NoError(t, evt.SetData(cloudevents.ApplicationJSON, payload))
+
+	assert.NoError(t, codec.Encode(&evt))
+	assert.Equal(t, ContentType, evt.DataContentType())
+	assert.Equal(t, server.URL+"/schemas/ids/1", evt.DataSchema())
+	assert.Equal(t, BundleCompatibility, registry.compatibility[BundleSubject])
+	assert.Equal(t, byte(0), evt.Data()[0])
+
+	// the consumer verifies the schema with the registry
+	consumerCodec, err := NewCodec(&transport.SchemaRegistryConfig{URL: server.URL})
+	assert.NoError(t, err)
+	assert.NoError(t, consumerCodec.Decode(&evt))
+	assert.Equal(t, cloudevents.ApplicationJSON, evt.DataContentType())
+	assert.Equal(t, "", evt.DataSchema())
+	assert.Equal(t, payload, evt.Data())
+
+	// the JSON event isn't changed
+	assert.NoError(t, consumerCodec.Decode(&evt))
+	assert.Equal(t, payload, evt.Data())
+
+	// the schema isn't the bundle schema
+	registry.schemas[2] = `{"type":"record","name":"Other","namespace":"io.example","fields":[]}`
+	otherEvt := cloudevents.NewEvent()
+	assert.NoError(t, otherEvt.SetData(ContentType, []byte{0, 0, 0, 0, 2, 0}))
+	assert.Error(t, consumerCodec.Decode(&otherEvt))
+}
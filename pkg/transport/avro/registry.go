@@ -0,0 +1,139 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package avro
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+const registryContentType = "application/vnd.schemaregistry.v1+json"
+
+// RegistryClient talks to the schema registry with the Confluent compatible API, which is also served by Apicurio
+// under the "/apis/ccompat/v7" path
+type RegistryClient struct {
+	url        string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func NewRegistryClient(registryConfig *transport.SchemaRegistryConfig) (*RegistryClient, error) {
+	client := &RegistryClient{
+		url:      strings.TrimSuffix(registryConfig.URL, "/"),
+		username: registryConfig.Username,
+	}
+	if registryConfig.PasswordPath != "" {
+		password, err := os.ReadFile(filepath.Clean(registryConfig.PasswordPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the schema registry password: %w", err)
+		}
+		client.password = strings.TrimSpace(string(password))
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if registryConfig.CACertPath != "" {
+		caCert, err := os.ReadFile(filepath.Clean(registryConfig.CACertPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the schema registry ca certificate: %w", err)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to append the schema registry ca certificate")
+		}
+		tlsConfig.RootCAs = certPool
+	}
+	client.httpClient = &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	return client, nil
+}
+
+// Register registers the schema under the subject, and returns the schema id. The existing id is returned if the
+// schema is already registered. The registry rejects the schema if it isn't compatible with the previous versions
+func (r *RegistryClient) Register(subject, schema string) (int, error) {
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, err
+	}
+	result := struct {
+		ID int `json:"id"`
+	}{}
+	path := fmt.Sprintf("/subjects/%s/versions", url.PathEscape(subject))
+	if err := r.do(http.MethodPost, path, body, &result); err != nil {
+		return 0, fmt.Errorf("failed to register the schema of the subject %s: %w", subject, err)
+	}
+	return result.ID, nil
+}
+
+// SetCompatibility sets the compatibility level of the subject, e.g. BACKWARD, FORWARD or FULL
+func (r *RegistryClient) SetCompatibility(subject, compatibility string) error {
+	body, err := json.Marshal(map[string]string{"compatibility": compatibility})
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/config/%s", url.PathEscape(subject))
+	if err := r.do(http.MethodPut, path, body, nil); err != nil {
+		return fmt.Errorf("failed to set the compatibility of the subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// GetSchema returns the schema of the id
+func (r *RegistryClient) GetSchema(id int) (string, error) {
+	result := struct {
+		Schema string `json:"schema"`
+	}{}
+	if err := r.do(http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &result); err != nil {
+		return "", fmt.Errorf("failed to get the schema %d: %w", id, err)
+	}
+	return result.Schema, nil
+}
+
+// SchemaURL returns the url of the schema id, which is set as the dataschema of the cloudevents
+func (r *RegistryClient) SchemaURL(id int) string {
+	return fmt.Sprintf("%s/schemas/ids/%d", r.url, id)
+}
+
+func (r *RegistryClient) do(method, path string, body []byte, result interface{}) error {
+	req, err := http.NewRequest(method, r.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", registryContentType)
+	req.Header.Set("Accept", registryContentType)
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, result)
+}
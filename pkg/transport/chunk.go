@@ -0,0 +1,24 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package transport
+
+import (
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// IsSubsequentChunk returns true if the event is a chunk of the bundle, but not the first one. The offset of each
+// chunk is the end of it in the bundle, so the offset of the first chunk is its size. The sequence of the chunk is
+// used instead if the producer sends it
+func IsSubsequentChunk(evt *cloudevents.Event) bool {
+	if index, found := evt.Extensions()[ChunkIndexKey]; found {
+		return fmt.Sprint(index) != "0"
+	}
+	offset, found := evt.Extensions()[ChunkOffsetKey]
+	if !found {
+		return false
+	}
+	return fmt.Sprint(offset) != fmt.Sprint(len(evt.Data()))
+}
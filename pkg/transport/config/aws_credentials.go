@@ -0,0 +1,222 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	awsSigningAlgo = "AWS4-HMAC-SHA256"
+	// the assumed credentials are renewed before they expire, so the presigned tokens are still valid
+	awsCredentialsRenewal   = 5 * time.Minute
+	awsWebIdentityDuration  = time.Hour
+	awsDefaultRoleSession   = "multicluster-global-hub"
+	awsSTSRequestTimeout    = 10 * time.Second
+	awsSTSAPIVersion        = "2011-06-15"
+	awsWebIdentityActionArg = "AssumeRoleWithWebIdentity"
+)
+
+// AWSCredentials is the IAM credential to sign the AWS authentication tokens, e.g. the MSK or the RDS IAM token
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+var (
+	// awsSTSEndpoint returns the endpoint of the AWS STS, the regional endpoint is used if the region is set
+	awsSTSEndpoint = func(region string) string {
+		if region == "" {
+			return "https://sts.amazonaws.com"
+		}
+		return fmt.Sprintf("https://sts.%s.amazonaws.com", region)
+	}
+
+	// the credentials assumed with the web identity token are cached until they're about to expire
+	webIdentityMutex       sync.Mutex
+	webIdentityCredentials *AWSCredentials
+	webIdentityExpiration  time.Time
+)
+
+// GetAWSCredentials returns the access key and the secret key from the SASL username and password, and falls back to
+// the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables. The credentials are
+// assumed with the web identity token if the AWS_ROLE_ARN and AWS_WEB_IDENTITY_TOKEN_FILE are set instead, e.g. by
+// the IAM roles for the service accounts
+func GetAWSCredentials(accessKeyID, secretKeyPath string) (*AWSCredentials, error) {
+	if accessKeyID != "" && secretKeyPath != "" {
+		secretKey, err := os.ReadFile(filepath.Clean(secretKeyPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the aws secret access key: %w", err)
+		}
+		return &AWSCredentials{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: strings.TrimSpace(string(secretKey)),
+		}, nil
+	}
+	credentials := &AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if credentials.AccessKeyID != "" && credentials.SecretAccessKey != "" {
+		return credentials, nil
+	}
+	if os.Getenv("AWS_ROLE_ARN") != "" && os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" {
+		return getWebIdentityCredentials()
+	}
+	return nil, errors.New("the aws access key and secret access key, or the web identity must be set")
+}
+
+// assumeRoleWithWebIdentityResponse is the response of the AssumeRoleWithWebIdentity action of the AWS STS
+type assumeRoleWithWebIdentityResponse struct {
+	Credentials struct {
+		AccessKeyID     string    `xml:"AccessKeyId"`
+		SecretAccessKey string    `xml:"SecretAccessKey"`
+		SessionToken    string    `xml:"SessionToken"`
+		Expiration      time.Time `xml:"Expiration"`
+	} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+// getWebIdentityCredentials assumes the AWS_ROLE_ARN with the token of the AWS_WEB_IDENTITY_TOKEN_FILE, the request
+// isn't signed since the token is the credential
+func getWebIdentityCredentials() (*AWSCredentials, error) {
+	webIdentityMutex.Lock()
+	defer webIdentityMutex.Unlock()
+
+	if webIdentityCredentials != nil && time.Until(webIdentityExpiration) > awsCredentialsRenewal {
+		return webIdentityCredentials, nil
+	}
+
+	token, err := os.ReadFile(filepath.Clean(os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the aws web identity token: %w", err)
+	}
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = awsDefaultRoleSession
+	}
+	query := url.Values{}
+	query.Set("Action", awsWebIdentityActionArg)
+	query.Set("Version", awsSTSAPIVersion)
+	query.Set("RoleArn", os.Getenv("AWS_ROLE_ARN"))
+	query.Set("RoleSessionName", sessionName)
+	query.Set("WebIdentityToken", strings.TrimSpace(string(token)))
+	query.Set("DurationSeconds", fmt.Sprintf("%d", int(awsWebIdentityDuration.Seconds())))
+
+	httpClient := &http.Client{Timeout: awsSTSRequestTimeout}
+	resp, err := httpClient.PostForm(awsSTSEndpoint(os.Getenv("AWS_REGION"))+"/", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assume the aws role with the web identity: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response of the aws sts: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to assume the aws role with the web identity: %s: %s", resp.Status, body)
+	}
+
+	assumed := &assumeRoleWithWebIdentityResponse{}
+	if err := xml.Unmarshal(body, assumed); err != nil {
+		return nil, fmt.Errorf("failed to parse the response of the aws sts: %w", err)
+	}
+	if assumed.Credentials.AccessKeyID == "" || assumed.Credentials.SecretAccessKey == "" {
+		return nil, errors.New("the credentials aren't found in the response of the aws sts")
+	}
+	webIdentityCredentials = &AWSCredentials{
+		AccessKeyID:     assumed.Credentials.AccessKeyID,
+		SecretAccessKey: assumed.Credentials.SecretAccessKey,
+		SessionToken:    assumed.Credentials.SessionToken,
+	}
+	webIdentityExpiration = assumed.Credentials.Expiration
+	return webIdentityCredentials, nil
+}
+
+// PresignAWSURL returns the url of the host, whose query is presigned with the AWS signature version 4, e.g.
+// "kafka.us-east-1.amazonaws.com/?Action=...&X-Amz-Signature=...". The scheme isn't included, the MSK token is an
+// https url while the RDS token isn't
+func PresignAWSURL(host, service, region string, params map[string]string, credentials *AWSCredentials,
+	signingTime time.Time, expires time.Duration,
+) string {
+	amzDate := signingTime.Format("20060102T150405Z")
+	date := signingTime.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     awsSigningAlgo,
+		"X-Amz-Credential":    fmt.Sprintf("%s/%s", credentials.AccessKeyID, scope),
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       fmt.Sprintf("%d", int(expires.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	for key, val := range params {
+		query[key] = val
+	}
+	if credentials.SessionToken != "" {
+		query["X-Amz-Security-Token"] = credentials.SessionToken
+	}
+	canonicalQuery := canonicalQueryString(query)
+
+	emptyPayloadHash := sha256.Sum256([]byte{})
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		"/",
+		canonicalQuery,
+		fmt.Sprintf("host:%s\n", host),
+		"host",
+		hex.EncodeToString(emptyPayloadHash[:]),
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		awsSigningAlgo,
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return fmt.Sprintf("%s/?%s&X-Amz-Signature=%s", host, canonicalQuery, signature)
+}
+
+func canonicalQueryString(query map[string]string) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", awsURIEncode(key), awsURIEncode(query[key])))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEncode encodes everything except the unreserved characters of RFC 3986
+func awsURIEncode(val string) string {
+	return strings.ReplaceAll(url.QueryEscape(val), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWebIdentityCredentials(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "AssumeRoleWithWebIdentity", r.Form.Get("Action"))
+		assert.Equal(t, "arn:aws:iam::123456789012:role/global-hub", r.Form.Get("RoleArn"))
+		assert.Equal(t, "multicluster-global-hub", r.Form.Get("RoleSessionName"))
+		assert.Equal(t, "web-identity-token", r.Form.Get("WebIdentityToken"))
+		fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse>
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>ASIAEXAMPLE</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>session</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	stsEndpoint := awsSTSEndpoint
+	defer func() {
+		awsSTSEndpoint = stsEndpoint
+		webIdentityCredentials = nil
+	}()
+	awsSTSEndpoint = func(region string) string { return server.URL }
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.Nil(t, os.WriteFile(tokenFile, []byte("web-identity-token\n"), 0o600))
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_ROLE_SESSION_NAME", "")
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/global-hub")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+
+	credentials, err := GetAWSCredentials("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, &AWSCredentials{
+		AccessKeyID:     "ASIAEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+	}, credentials)
+
+	// the credentials are cached until they're about to expire
+	_, err = GetAWSCredentials("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, requests)
+}
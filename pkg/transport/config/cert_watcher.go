@@ -0,0 +1,74 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+// CertificateCheckInterval is how often the client certificate files are checked for the rotation
+const CertificateCheckInterval = time.Minute
+
+// WatchClientCertificate notifies the returned channel once the client certificate and key are rotated, e.g. the
+// mounted secret is updated with the renewed certificate. The kafka client only loads the certificate when it's
+// created, so it must be recreated to reconnect to the brokers with the new certificate. The channel is never
+// notified if the client doesn't authenticate with the certificate
+func WatchClientCertificate(ctx context.Context, kafkaConfig *transport.KafkaConfig,
+	interval time.Duration,
+) <-chan struct{} {
+	rotated := make(chan struct{}, 1)
+	if !kafkaConfig.EnableTLS || kafkaConfig.SASLMechanism != "" || kafkaConfig.ClientCertPath == "" ||
+		kafkaConfig.ClientKeyPath == "" {
+		return rotated
+	}
+
+	digest, err := certificateDigest(kafkaConfig.ClientCertPath, kafkaConfig.ClientKeyPath)
+	if err != nil {
+		klog.Warningf("failed to read the client certificate: %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				current, err := certificateDigest(kafkaConfig.ClientCertPath, kafkaConfig.ClientKeyPath)
+				if err != nil || current == digest {
+					continue
+				}
+				// the files might be in the middle of the update, wait until the key matches the certificate
+				if _, err := tls.LoadX509KeyPair(kafkaConfig.ClientCertPath, kafkaConfig.ClientKeyPath); err != nil {
+					continue
+				}
+				digest = current
+				klog.Infof("the client certificate is rotated: %s", kafkaConfig.ClientCertPath)
+				select {
+				case rotated <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return rotated
+}
+
+func certificateDigest(certPath, keyPath string) ([sha256.Size]byte, error) {
+	certBytes, err := os.ReadFile(filepath.Clean(certPath))
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	keyBytes, err := os.ReadFile(filepath.Clean(keyPath))
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(append(certBytes, keyBytes...)), nil
+}
@@ -59,6 +59,69 @@ func TestConfluentConfig(t *testing.T) {
 	}
 }
 
+func TestConfluentConfigWithSASL(t *testing.T) {
+	kafkaConfig := &transport.KafkaConfig{
+		BootstrapServer:  "localhost:9094",
+		EnableTLS:        true,
+		CaCertPath:       "/tmp/sasl-ca.crt",
+		SASLMechanism:    transport.ScramSha512,
+		SASLUsername:     "hub1",
+		SASLPasswordPath: "/tmp/sasl-password",
+	}
+	assert.Nil(t, os.WriteFile(kafkaConfig.CaCertPath, []byte("cadata"), 0o644))
+
+	// the password isn't mounted
+	_ = os.Remove(kafkaConfig.SASLPasswordPath)
+	_, err := GetConfluentConfigMap(kafkaConfig, true)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, os.WriteFile(kafkaConfig.SASLPasswordPath, []byte("password"), 0o644))
+	configMap, err := GetConfluentConfigMap(kafkaConfig, true)
+	assert.Nil(t, err)
+
+	protocol, _ := configMap.Get("security.protocol", "")
+	assert.Equal(t, "sasl_ssl", protocol)
+	mechanism, _ := configMap.Get("sasl.mechanism", "")
+	assert.Equal(t, transport.ScramSha512, mechanism)
+	password, _ := configMap.Get("sasl.password", "")
+	assert.Equal(t, "password", password)
+	certLocation, _ := configMap.Get("ssl.certificate.location", "")
+	assert.Equal(t, "", certLocation)
+}
+
+func TestConfluentConfigWithTransactions(t *testing.T) {
+	kafkaConfig := &transport.KafkaConfig{
+		BootstrapServer: "localhost:9092",
+		ProducerConfig:  &transport.KafkaProducerConfig{TransactionalID: "hub1"},
+		ConsumerConfig:  &transport.KafkaConsumerConfig{ConsumerID: "manager", ReadCommitted: true},
+	}
+	producerConfigMap, err := GetConfluentConfigMap(kafkaConfig, true)
+	assert.Nil(t, err)
+	transactionalID, _ := producerConfigMap.Get("transactional.id", "")
+	assert.Equal(t, "hub1", transactionalID)
+	idempotence, _ := producerConfigMap.Get("enable.idempotence", false)
+	assert.Equal(t, true, idempotence)
+	acks, _ := producerConfigMap.Get("acks", "")
+	assert.Equal(t, "all", acks)
+
+	consumerConfigMap, err := GetConfluentConfigMap(kafkaConfig, false)
+	assert.Nil(t, err)
+	isolationLevel, _ := consumerConfigMap.Get("isolation.level", "")
+	assert.Equal(t, "read_committed", isolationLevel)
+	autoCommit, _ := consumerConfigMap.Get("enable.auto.commit", "")
+	assert.Equal(t, "true", autoCommit)
+	SetManualCommitConfig(consumerConfigMap)
+	autoCommit, _ = consumerConfigMap.Get("enable.auto.commit", "")
+	assert.Equal(t, "false", autoCommit)
+
+	// the producer isn't transactional without the transactional id
+	kafkaConfig.ProducerConfig.TransactionalID = ""
+	producerConfigMap, err = GetConfluentConfigMap(kafkaConfig, true)
+	assert.Nil(t, err)
+	transactionalID, _ = producerConfigMap.Get("transactional.id", "")
+	assert.Equal(t, "", transactionalID)
+}
+
 func TestGetSaramaConfig(t *testing.T) {
 	kafkaConfig := &transport.KafkaConfig{
 		EnableTLS:      false,
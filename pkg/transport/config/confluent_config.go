@@ -37,6 +37,15 @@ func SetProducerConfig(kafkaConfigMap *kafkav2.ConfigMap) {
 	_ = kafkaConfigMap.SetKey("go.events.channel.size", 1000)
 }
 
+// SetTransactionalProducerConfig enables the idempotence and the transactions of the producer, the messages aren't
+// duplicated by the retries, and the consumers with the read_committed isolation level skip the aborted messages
+func SetTransactionalProducerConfig(kafkaConfigMap *kafkav2.ConfigMap, transactionalID string) {
+	_ = kafkaConfigMap.SetKey("enable.idempotence", true)
+	_ = kafkaConfigMap.SetKey("acks", "all")
+	_ = kafkaConfigMap.SetKey("retries", "2147483647")
+	_ = kafkaConfigMap.SetKey("transactional.id", transactionalID)
+}
+
 func SetConsumerConfig(kafkaConfigMap *kafkav2.ConfigMap, groupId string) {
 	_ = kafkaConfigMap.SetKey("enable.auto.commit", "true")
 	_ = kafkaConfigMap.SetKey("auto.offset.reset", "earliest")
@@ -44,6 +53,12 @@ func SetConsumerConfig(kafkaConfigMap *kafkav2.ConfigMap, groupId string) {
 	_ = kafkaConfigMap.SetKey("go.events.channel.size", 1000)
 }
 
+// SetManualCommitConfig disables the auto commit of the consumer, so the offsets are only committed explicitly once
+// the messages are handled, e.g. the bundles are written to the database
+func SetManualCommitConfig(kafkaConfigMap *kafkav2.ConfigMap) {
+	_ = kafkaConfigMap.SetKey("enable.auto.commit", "false")
+}
+
 func SetTLSByLocation(kafkaConfigMap *kafkav2.ConfigMap, caCertPath, certPath, keyPath string) error {
 	_, validCA := utils.Validate(caCertPath)
 	if !validCA {
@@ -84,18 +99,59 @@ func SetTLSByLocation(kafkaConfigMap *kafkav2.ConfigMap, caCertPath, certPath, k
 	return nil
 }
 
+// SetSASLByLocation authenticates the client with the username and password over the tls connection. The ca
+// certificate is optional for the kafka with the public certificates, e.g. the Confluent Cloud
+func SetSASLByLocation(kafkaConfigMap *kafkav2.ConfigMap, caCertPath, mechanism, username, passwordPath string) error {
+	password, err := os.ReadFile(filepath.Clean(passwordPath))
+	if err != nil {
+		return fmt.Errorf("failed to read the sasl password: %w", err)
+	}
+	if username == "" || len(password) == 0 {
+		return errors.New("the sasl username and password must be set")
+	}
+
+	_ = kafkaConfigMap.SetKey("security.protocol", "sasl_ssl")
+	if _, validCA := utils.Validate(caCertPath); validCA {
+		_ = kafkaConfigMap.SetKey("ssl.ca.location", caCertPath)
+	}
+	_ = kafkaConfigMap.SetKey("sasl.mechanism", mechanism)
+	_ = kafkaConfigMap.SetKey("sasl.username", username)
+	_ = kafkaConfigMap.SetKey("sasl.password", string(password))
+	return nil
+}
+
 // https://github.com/confluentinc/librdkafka/blob/master/CONFIGURATION.md
 func GetConfluentConfigMap(kafkaConfig *transport.KafkaConfig, producer bool) (*kafkav2.ConfigMap, error) {
 	kafkaConfigMap := GetBasicConfigMap()
 	_ = kafkaConfigMap.SetKey("bootstrap.servers", kafkaConfig.BootstrapServer)
 	if producer {
 		SetProducerConfig(kafkaConfigMap)
+		if kafkaConfig.ProducerConfig != nil && kafkaConfig.ProducerConfig.TransactionalID != "" {
+			SetTransactionalProducerConfig(kafkaConfigMap, kafkaConfig.ProducerConfig.TransactionalID)
+		}
 	} else {
 		SetConsumerConfig(kafkaConfigMap, kafkaConfig.ConsumerConfig.ConsumerID)
+		if kafkaConfig.ConsumerConfig.ReadCommitted {
+			_ = kafkaConfigMap.SetKey("isolation.level", "read_committed")
+		}
 	}
 	if !kafkaConfig.EnableTLS {
 		return kafkaConfigMap, nil
 	}
+	if kafkaConfig.SASLMechanism == transport.AwsMskIam {
+		if err := SetMSKIAMByLocation(kafkaConfigMap, kafkaConfig.CaCertPath); err != nil {
+			return nil, err
+		}
+		return kafkaConfigMap, nil
+	}
+	if kafkaConfig.SASLMechanism != "" {
+		err := SetSASLByLocation(kafkaConfigMap, kafkaConfig.CaCertPath, kafkaConfig.SASLMechanism,
+			kafkaConfig.SASLUsername, kafkaConfig.SASLPasswordPath)
+		if err != nil {
+			return nil, err
+		}
+		return kafkaConfigMap, nil
+	}
 	err := SetTLSByLocation(kafkaConfigMap, kafkaConfig.CaCertPath, kafkaConfig.ClientCertPath, kafkaConfig.ClientKeyPath)
 	if err != nil {
 		return nil, err
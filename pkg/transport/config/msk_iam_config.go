@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	kafkav2 "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"k8s.io/klog/v2"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/utils"
+)
+
+const (
+	mskSigningName    = "kafka-cluster"
+	mskSigningAction  = "kafka-cluster:Connect"
+	mskTokenExpires   = 15 * time.Minute
+	mskTokenRefresh   = 5 * time.Minute
+	mskUserAgent      = "multicluster-global-hub"
+	mskTokenPrincipal = "multicluster-global-hub"
+)
+
+// the bootstrap server of MSK, e.g. b-1.demo.abcdef.c2.kafka.us-east-1.amazonaws.com:9098
+var mskRegionRegex = regexp.MustCompile(`\.kafka\.([a-z0-9-]+)\.amazonaws\.com`)
+
+// OAuthBearerClient is the confluent producer or consumer which authenticates with the OAUTHBEARER token
+type OAuthBearerClient interface {
+	SetOAuthBearerToken(oauthBearerToken kafkav2.OAuthBearerToken) error
+	SetOAuthBearerTokenFailure(errstr string) error
+}
+
+// SetMSKIAMByLocation authenticates the client with the AWS IAM token over the tls connection, the token is set on
+// the client by StartMSKIAMTokenRefresher. The ca certificate is optional since MSK uses the public certificates
+func SetMSKIAMByLocation(kafkaConfigMap *kafkav2.ConfigMap, caCertPath string) error {
+	_ = kafkaConfigMap.SetKey("security.protocol", "sasl_ssl")
+	_ = kafkaConfigMap.SetKey("sasl.mechanism", "OAUTHBEARER")
+	if _, validCA := utils.Validate(caCertPath); validCA {
+		_ = kafkaConfigMap.SetKey("ssl.ca.location", caCertPath)
+	}
+	return nil
+}
+
+// StartMSKIAMTokenRefresher sets the MSK IAM token on the client, and refreshes it before it expires. The access key
+// is the SASL username and the secret key is the SASL password, or they're loaded from the AWS environment variables
+// or the web identity
+func StartMSKIAMTokenRefresher(client OAuthBearerClient, kafkaConfig *transport.KafkaConfig) error {
+	region, err := GetMSKRegion(kafkaConfig.BootstrapServer)
+	if err != nil {
+		return err
+	}
+	if err := setMSKIAMToken(client, kafkaConfig, region); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(mskTokenRefresh)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := setMSKIAMToken(client, kafkaConfig, region); err != nil {
+				klog.Errorf("failed to refresh the MSK IAM token: %v", err)
+				_ = client.SetOAuthBearerTokenFailure(err.Error())
+			}
+		}
+	}()
+	return nil
+}
+
+func setMSKIAMToken(client OAuthBearerClient, kafkaConfig *transport.KafkaConfig, region string) error {
+	// reload the credential every time, so the rotated secret is picked up
+	credentials, err := GetAWSCredentials(kafkaConfig.SASLUsername, kafkaConfig.SASLPasswordPath)
+	if err != nil {
+		return err
+	}
+	token, expiration, err := GenerateMSKIAMToken(region, credentials, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return client.SetOAuthBearerToken(kafkav2.OAuthBearerToken{
+		TokenValue: token,
+		Expiration: expiration,
+		Principal:  mskTokenPrincipal,
+	})
+}
+
+// GetMSKRegion returns the AWS_REGION environment variable, or parses the region from the MSK bootstrap server
+func GetMSKRegion(bootstrapServer string) (string, error) {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region, nil
+	}
+	matches := mskRegionRegex.FindStringSubmatch(bootstrapServer)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("failed to get the aws region from the bootstrap server: %s", bootstrapServer)
+	}
+	return matches[1], nil
+}
+
+// GenerateMSKIAMToken returns the MSK IAM token and its expiration. The token is the base64 encoded url of the
+// kafka-cluster:Connect action, which is presigned with the AWS signature version 4
+func GenerateMSKIAMToken(region string, credentials *AWSCredentials, signingTime time.Time) (string, time.Time,
+	error,
+) {
+	if region == "" {
+		return "", time.Time{}, errors.New("the aws region must be set")
+	}
+	host := fmt.Sprintf("kafka.%s.amazonaws.com", region)
+	presignedURL := PresignAWSURL(host, mskSigningName, region, map[string]string{"Action": mskSigningAction},
+		credentials, signingTime, mskTokenExpires)
+	signedURL := fmt.Sprintf("https://%s&User-Agent=%s", presignedURL, awsURIEncode(mskUserAgent))
+	return base64.RawURLEncoding.EncodeToString([]byte(signedURL)), signingTime.Add(mskTokenExpires), nil
+}
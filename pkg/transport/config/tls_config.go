@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// NewMutualTLSServerConfig requires the client certificates issued by the CA, the server certificate is loaded on
+// each handshake, so the rotated certificate is served without restarting the server
+func NewMutualTLSServerConfig(caCertPath, certPath, keyPath string) (*tls.Config, error) {
+	caPool, err := LoadCertPool(caCertPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tls.LoadX509KeyPair(certPath, keyPath); err != nil {
+		return nil, fmt.Errorf("failed to load the server certificate: %w", err)
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  caPool,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+			return &cert, err
+		},
+	}, nil
+}
+
+// NewMutualTLSClientConfig verifies the server with the CA, the client certificate is loaded on each handshake, so
+// the rotated certificate is used once the client reconnects
+func NewMutualTLSClientConfig(caCertPath, certPath, keyPath string) (*tls.Config, error) {
+	caPool, err := LoadCertPool(caCertPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tls.LoadX509KeyPair(certPath, keyPath); err != nil {
+		return nil, fmt.Errorf("failed to load the client certificate: %w", err)
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    caPool,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+			return &cert, err
+		},
+	}, nil
+}
+
+func LoadCertPool(caCertPath string) (*x509.CertPool, error) {
+	caCert, err := os.ReadFile(filepath.Clean(caCertPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA certificate: %w", err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no CA certificate found in %s", caCertPath)
+	}
+	return caPool, nil
+}
+
+// PeerCommonName returns the common name of the verified client certificate of the connection
+func PeerCommonName(state *tls.ConnectionState) (string, error) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", fmt.Errorf("no verified client certificate found")
+	}
+	commonName := state.VerifiedChains[0][0].Subject.CommonName
+	if commonName == "" {
+		return "", fmt.Errorf("the common name of the client certificate is empty")
+	}
+	return commonName, nil
+}
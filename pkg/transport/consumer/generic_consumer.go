@@ -7,6 +7,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	kafka_confluent "github.com/cloudevents/sdk-go/protocol/kafka_confluent/v2"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
@@ -19,12 +21,20 @@ import (
 
 	"github.com/stolostron/multicluster-global-hub/pkg/database"
 	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+	"github.com/stolostron/multicluster-global-hub/pkg/statistics"
+	"github.com/stolostron/multicluster-global-hub/pkg/tracing"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport/avro"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport/config"
+	grpctransport "github.com/stolostron/multicluster-global-hub/pkg/transport/grpc"
+	httptransport "github.com/stolostron/multicluster-global-hub/pkg/transport/http"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport/protobuf"
 )
 
 var transportID string
 
+const legacyGroupOffsetsTimeout = 30 * time.Second
+
 type GenericConsumer struct {
 	log                  logr.Logger
 	client               cloudevents.Client
@@ -33,6 +43,17 @@ type GenericConsumer struct {
 	consumeTopics        []string
 	clusterIdentity      string
 	enableDatabaseOffset bool
+	// decode the avro encoded bundles if the schema registry is configured
+	avroCodec *avro.Codec
+	// recreate the kafka consumer once the client certificate is rotated
+	tranConfig  *transport.TransportConfig
+	certRotated <-chan struct{}
+	// called when the partitions are assigned to or revoked from the kafka consumer of the consumer group
+	rebalanceCallback kafka.RebalanceCb
+	// the offsets aren't committed automatically, they're committed by CommitOffsets once the events are handled
+	manualCommit  bool
+	kafkaConsumer *kafka.Consumer
+	consumerLock  sync.Mutex
 }
 
 type GenericConsumeOption func(*GenericConsumer) error
@@ -44,21 +65,76 @@ func EnableDatabaseOffset(enableOffset bool) GenericConsumeOption {
 	}
 }
 
+// WithRebalanceCallback sets the callback of the rebalances of the kafka consumer group, e.g. to finish the bundles of
+// the revoked partitions before they're consumed by another replica. The partitions are assigned or revoked by the
+// consumer after the callback returns
+func WithRebalanceCallback(callback kafka.RebalanceCb) GenericConsumeOption {
+	return func(c *GenericConsumer) error {
+		c.rebalanceCallback = callback
+		return nil
+	}
+}
+
+// EnableManualCommit disables the auto commit of the kafka consumer, the offsets are committed to the consumer group
+// by CommitOffsets, e.g. once the bundles are written to the database
+func EnableManualCommit() GenericConsumeOption {
+	return func(c *GenericConsumer) error {
+		c.manualCommit = true
+		return nil
+	}
+}
+
 func NewGenericConsumer(tranConfig *transport.TransportConfig, topics []string,
 	opts ...GenericConsumeOption,
 ) (*GenericConsumer, error) {
 	log := ctrl.Log.WithName(fmt.Sprintf("%s-consumer", tranConfig.TransportType))
+	c := &GenericConsumer{
+		log:                  log,
+		eventChan:            make(chan *cloudevents.Event),
+		assembler:            newMessageAssembler(),
+		enableDatabaseOffset: false,
+		consumeTopics:        topics,
+		tranConfig:           tranConfig,
+		certRotated:          make(chan struct{}),
+	}
+	// the options are applied before the receiver is created, since the rebalance callback is set on the receiver
+	if err := c.applyOptions(opts...); err != nil {
+		return nil, err
+	}
+
 	var receiver interface{}
 	var err error
 	var clusterIdentity string
 	switch tranConfig.TransportType {
 	case string(transport.Kafka):
 		log.Info("transport consumer with cloudevents-kafka receiver")
-		receiver, err = getConfluentReceiverProtocol(tranConfig, topics)
+		receiver, err = c.getConfluentReceiverProtocol()
 		if err != nil {
 			return nil, err
 		}
 		clusterIdentity = tranConfig.KafkaConfig.ClusterIdentity
+	case string(transport.GRPC):
+		log.Info("transport consumer with grpc receiver")
+		if tranConfig.GRPCConfig.Listen {
+			receiver, err = grpctransport.GetServer(tranConfig.GRPCConfig)
+		} else {
+			receiver, err = grpctransport.GetClient(tranConfig.GRPCConfig)
+		}
+		if err != nil {
+			return nil, err
+		}
+		clusterIdentity = tranConfig.GRPCConfig.ServerAddress
+	case string(transport.HTTP):
+		log.Info("transport consumer with http receiver")
+		if tranConfig.HTTPConfig.Listen {
+			receiver, err = httptransport.GetServer(tranConfig.HTTPConfig)
+		} else {
+			receiver, err = httptransport.GetClient(tranConfig.HTTPConfig)
+		}
+		if err != nil {
+			return nil, err
+		}
+		clusterIdentity = tranConfig.HTTPConfig.ServerAddress
 	case string(transport.Chan):
 		log.Info("transport consumer with go chan receiver")
 		if tranConfig.Extends == nil {
@@ -77,22 +153,21 @@ func NewGenericConsumer(tranConfig *transport.TransportConfig, topics []string,
 		return nil, fmt.Errorf("transport-type - %s is not a valid option", tranConfig.TransportType)
 	}
 
-	client, err := cloudevents.NewClient(receiver, client.WithPollGoroutines(1))
+	c.client, err = cloudevents.NewClient(receiver, client.WithPollGoroutines(1))
 	if err != nil {
 		return nil, err
 	}
+	c.clusterIdentity = clusterIdentity
 
-	c := &GenericConsumer{
-		log:                  log,
-		client:               client,
-		clusterIdentity:      clusterIdentity,
-		eventChan:            make(chan *cloudevents.Event),
-		assembler:            newMessageAssembler(),
-		enableDatabaseOffset: false,
-		consumeTopics:        topics,
+	if tranConfig.TransportType == string(transport.Kafka) {
+		c.certRotated = config.WatchClientCertificate(context.Background(), tranConfig.KafkaConfig,
+			config.CertificateCheckInterval)
 	}
-	if err := c.applyOptions(opts...); err != nil {
-		return nil, err
+	if registryConfig := tranConfig.SchemaRegistryConfig; registryConfig != nil && registryConfig.URL != "" {
+		c.avroCodec, err = avro.NewCodec(registryConfig)
+		if err != nil {
+			return nil, err
+		}
 	}
 	transportID = clusterIdentity
 	return c, nil
@@ -108,6 +183,39 @@ func (c *GenericConsumer) applyOptions(opts ...GenericConsumeOption) error {
 }
 
 func (c *GenericConsumer) Start(ctx context.Context) error {
+	for {
+		receiveCtx, cancel := context.WithCancel(ctx)
+		rotated := make(chan bool, 1)
+		go func() {
+			select {
+			case <-c.certRotated:
+				rotated <- true
+				cancel()
+			case <-receiveCtx.Done():
+				rotated <- false
+			}
+		}()
+		err := c.receive(receiveCtx)
+		cancel()
+		if !<-rotated || err != nil || ctx.Err() != nil {
+			return err
+		}
+
+		// the previous consumer is closed once the receiver is stopped, recreate it with the rotated certificate
+		// and resume from the committed offsets
+		receiver, err := c.getConfluentReceiverProtocol()
+		if err != nil {
+			return fmt.Errorf("failed to recreate the consumer with the rotated certificate: %w", err)
+		}
+		c.client, err = cloudevents.NewClient(receiver, client.WithPollGoroutines(1))
+		if err != nil {
+			return err
+		}
+		c.log.Info("recreated the consumer with the rotated client certificate")
+	}
+}
+
+func (c *GenericConsumer) receive(ctx context.Context) error {
 	receiveContext := ctx
 	if c.enableDatabaseOffset {
 		offsets, err := getInitOffset(c.clusterIdentity)
@@ -118,6 +226,15 @@ func (c *GenericConsumer) Start(ctx context.Context) error {
 		if len(offsets) > 0 {
 			receiveContext = kafka_confluent.WithTopicPartitionOffsets(ctx, offsets)
 		}
+	} else if legacyGroupID := c.legacyConsumerGroupID(); legacyGroupID != "" {
+		offsets, err := getLegacyGroupOffsets(c.tranConfig.KafkaConfig, legacyGroupID)
+		if err != nil {
+			// the spec topic is compacted, so the consumer still gets the latest bundles from the earliest offsets
+			c.log.Error(err, "failed to get the offsets of the legacy consumer group", "group", legacyGroupID)
+		} else if len(offsets) > 0 {
+			c.log.Info("resume from the legacy consumer group", "group", legacyGroupID, "offsets", offsets)
+			receiveContext = kafka_confluent.WithTopicPartitionOffsets(ctx, offsets)
+		}
 	}
 
 	err := c.client.StartReceiver(receiveContext, func(ctx context.Context, event cloudevents.Event) ceprotocol.Result {
@@ -125,14 +242,18 @@ func (c *GenericConsumer) Start(ctx context.Context) error {
 
 		chunk, isChunk := c.assembler.messageChunk(event)
 		if !isChunk {
-			c.eventChan <- &event
+			c.sendEvent(&event)
 			return ceprotocol.ResultACK
 		}
 		if payload := c.assembler.assemble(chunk); payload != nil {
-			if err := event.SetData(cloudevents.ApplicationJSON, payload); err != nil {
+			dataContentType := event.DataContentType()
+			if dataContentType == "" {
+				dataContentType = cloudevents.ApplicationJSON
+			}
+			if err := event.SetData(dataContentType, payload); err != nil {
 				c.log.Error(err, "failed the set the assembled data to event")
 			} else {
-				c.eventChan <- &event
+				c.sendEvent(&event)
 			}
 		}
 		return ceprotocol.ResultACK
@@ -144,10 +265,90 @@ func (c *GenericConsumer) Start(ctx context.Context) error {
 	return nil
 }
 
+// sendEvent decodes the avro or protobuf encoded event before sending it to the event channel
+func (c *GenericConsumer) sendEvent(event *cloudevents.Event) {
+	size, start := len(event.Data()), time.Now()
+	spanCtx, span := tracing.StartReceiveSpan(event)
+	if err := protobuf.Decode(event); err != nil {
+		tracing.EndReceiveSpan(spanCtx, event, span, err)
+		c.log.Error(err, "failed to decode the protobuf event", "type", event.Type())
+		return
+	}
+	if c.avroCodec != nil {
+		if err := c.avroCodec.Decode(event); err != nil {
+			tracing.EndReceiveSpan(spanCtx, event, span, err)
+			c.log.Error(err, "failed to decode the avro event", "type", event.Type())
+			return
+		}
+	}
+	statistics.ObserveReceivedBundle(event, size, time.Since(start))
+	tracing.EndReceiveSpan(spanCtx, event, span, nil)
+	c.eventChan <- event
+}
+
 func (c *GenericConsumer) EventChan() chan *cloudevents.Event {
 	return c.eventChan
 }
 
+// legacyConsumerGroupID returns the legacy consumer group of the kafka consumer, the consumer resumes from its offsets
+// once the consumer group is renamed
+func (c *GenericConsumer) legacyConsumerGroupID() string {
+	if c.tranConfig.TransportType != string(transport.Kafka) || c.tranConfig.KafkaConfig == nil ||
+		c.tranConfig.KafkaConfig.ConsumerConfig == nil {
+		return ""
+	}
+	return c.tranConfig.KafkaConfig.ConsumerConfig.LegacyConsumerID
+}
+
+// getLegacyGroupOffsets returns the offsets committed by the legacy consumer group if the consumer group hasn't
+// committed any, so the renamed group doesn't replay the topics from the earliest offsets
+func getLegacyGroupOffsets(kafkaConfig *transport.KafkaConfig, legacyGroupID string) ([]kafka.TopicPartition, error) {
+	configMap, err := config.GetConfluentConfigMap(kafkaConfig, false)
+	if err != nil {
+		return nil, err
+	}
+	// the admin client is created from the consumer, since the consumer properties aren't valid for the admin client
+	consumer, err := kafka.NewConsumer(configMap)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = consumer.Close()
+	}()
+	admin, err := kafka.NewAdminClientFromConsumer(consumer)
+	if err != nil {
+		return nil, err
+	}
+	defer admin.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), legacyGroupOffsetsTimeout)
+	defer cancel()
+	committed, err := listCommittedOffsets(ctx, admin, kafkaConfig.ConsumerConfig.ConsumerID)
+	if err != nil || len(committed) > 0 {
+		return nil, err
+	}
+	return listCommittedOffsets(ctx, admin, legacyGroupID)
+}
+
+// listCommittedOffsets returns the partitions which the consumer group has committed the offsets to
+func listCommittedOffsets(ctx context.Context, admin *kafka.AdminClient, groupID string) (
+	[]kafka.TopicPartition, error,
+) {
+	result, err := admin.ListConsumerGroupOffsets(ctx, []kafka.ConsumerGroupTopicPartitions{{Group: groupID}})
+	if err != nil {
+		return nil, err
+	}
+	committed := []kafka.TopicPartition{}
+	for _, group := range result.ConsumerGroupsTopicPartitions {
+		for _, partition := range group.Partitions {
+			if partition.Error == nil && partition.Offset >= 0 {
+				committed = append(committed, partition)
+			}
+		}
+	}
+	return committed, nil
+}
+
 func getInitOffset(kafkaClusterIdentity string) ([]kafka.TopicPartition, error) {
 	db := database.GetGorm()
 	var positions []models.Transport
@@ -187,14 +388,95 @@ func getInitOffset(kafkaClusterIdentity string) ([]kafka.TopicPartition, error)
 // 		transportConfig.KafkaConfig.ConsumerConfig.ConsumerTopic)
 // }
 
-func getConfluentReceiverProtocol(transportConfig *transport.TransportConfig, topics []string) (interface{}, error) {
+func (c *GenericConsumer) getConfluentReceiverProtocol() (interface{}, error) {
+	transportConfig := c.tranConfig
 	configMap, err := config.GetConfluentConfigMap(transportConfig.KafkaConfig, false)
 	if err != nil {
 		return nil, err
 	}
+	options := []kafka_confluent.Option{kafka_confluent.WithReceiverTopics(c.consumeTopics)}
+	if c.rebalanceCallback != nil {
+		options = append(options, kafka_confluent.WithRebalanceCallBack(c.rebalanceCallback))
+	}
+	if c.manualCommit {
+		config.SetManualCommitConfig(configMap)
+	}
+
+	// the consumer is created here since it's used out of the receiver, e.g. to commit the offsets or to pause the
+	// partitions
+	consumer, err := kafka.NewConsumer(configMap)
+	if err != nil {
+		return nil, err
+	}
+	if transportConfig.KafkaConfig.SASLMechanism == transport.AwsMskIam {
+		// the IAM token must be set on the consumer before it connects to the brokers
+		if err := config.StartMSKIAMTokenRefresher(consumer, transportConfig.KafkaConfig); err != nil {
+			_ = consumer.Close()
+			return nil, err
+		}
+	}
+	c.consumerLock.Lock()
+	c.kafkaConsumer = consumer
+	c.consumerLock.Unlock()
+	return kafka_confluent.New(append(options, kafka_confluent.WithReceiver(consumer))...)
+}
+
+// CommitOffsets commits the offsets of the partitions assigned to the consumer to the consumer group, the offsets of
+// the other partitions are skipped since they're committed by the consumers they're assigned to
+func (c *GenericConsumer) CommitOffsets(offsets []kafka.TopicPartition) error {
+	c.consumerLock.Lock()
+	defer c.consumerLock.Unlock()
+	if c.kafkaConsumer == nil || c.kafkaConsumer.IsClosed() {
+		return fmt.Errorf("the kafka consumer isn't running")
+	}
+	assignment, err := c.kafkaConsumer.Assignment()
+	if err != nil {
+		return fmt.Errorf("failed to get the assigned partitions: %w", err)
+	}
+	assigned := assignedOffsets(assignment, offsets)
+	if len(assigned) == 0 {
+		return nil
+	}
+	_, err = c.kafkaConsumer.CommitOffsets(assigned)
+	return err
+}
+
+// PausePartitions stops fetching the messages of the partitions until they're resumed. The messages which are fetched
+// but not received yet are fetched again once the partitions are resumed, so no message is lost while it's paused
+func (c *GenericConsumer) PausePartitions(partitions []kafka.TopicPartition) error {
+	c.consumerLock.Lock()
+	defer c.consumerLock.Unlock()
+	if c.kafkaConsumer == nil || c.kafkaConsumer.IsClosed() {
+		return fmt.Errorf("the kafka consumer isn't running")
+	}
+	return c.kafkaConsumer.Pause(partitions)
+}
+
+// ResumePartitions resumes fetching the messages of the paused partitions
+func (c *GenericConsumer) ResumePartitions(partitions []kafka.TopicPartition) error {
+	c.consumerLock.Lock()
+	defer c.consumerLock.Unlock()
+	if c.kafkaConsumer == nil || c.kafkaConsumer.IsClosed() {
+		return fmt.Errorf("the kafka consumer isn't running")
+	}
+	return c.kafkaConsumer.Resume(partitions)
+}
 
-	return kafka_confluent.New(kafka_confluent.WithConfigMap(configMap),
-		kafka_confluent.WithReceiverTopics(topics))
+// assignedOffsets returns the offsets of the assigned partitions
+func assignedOffsets(assignment, offsets []kafka.TopicPartition) []kafka.TopicPartition {
+	assigned := map[string]bool{}
+	for _, tp := range assignment {
+		if tp.Topic != nil {
+			assigned[fmt.Sprintf("%s@%d", *tp.Topic, tp.Partition)] = true
+		}
+	}
+	result := []kafka.TopicPartition{}
+	for _, tp := range offsets {
+		if tp.Topic != nil && assigned[fmt.Sprintf("%s@%d", *tp.Topic, tp.Partition)] {
+			result = append(result, tp)
+		}
+	}
+	return result
 }
 
 func TransportID() string {
@@ -84,3 +84,17 @@ func generateTransport(ownerIdentity string, topic string, offset int64) models.
 		Payload: payload,
 	}
 }
+
+func TestAssignedOffsets(t *testing.T) {
+	topic, otherTopic := "status", "spec"
+	assignment := []kafka.TopicPartition{{Topic: &topic, Partition: 0}, {Topic: &topic, Partition: 2}}
+	offsets := []kafka.TopicPartition{
+		{Topic: &topic, Partition: 0, Offset: 10},
+		{Topic: &topic, Partition: 1, Offset: 20},
+		{Topic: &otherTopic, Partition: 2, Offset: 30},
+	}
+	// the offsets of the partitions which have been revoked aren't committed
+	assert.Equal(t, []kafka.TopicPartition{{Topic: &topic, Partition: 0, Offset: 10}},
+		assignedOffsets(assignment, offsets))
+	assert.Empty(t, assignedOffsets(nil, offsets))
+}
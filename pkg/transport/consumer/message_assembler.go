@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"sort"
 	"sync"
+	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/cloudevents/sdk-go/v2/types"
@@ -16,12 +17,19 @@ import (
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 )
 
+// DefaultChunkReassemblyTimeout is the time to wait for the missing chunks of a bundle, the chunks received are
+// dropped once it's expired, so a bundle which is never completed doesn't leak the memory
+const DefaultChunkReassemblyTimeout = 10 * time.Minute
+
 // messageChunk represents a chunk of a transport message.
 type messageChunk struct {
 	id     string
 	offset int
 	size   int
-	bytes  []byte
+	// the sequence and the total of the chunks, they're -1 if the producer doesn't send the sequence headers
+	index int
+	count int
+	bytes []byte
 }
 
 // messageChunksCollection holds a collection of chunks and maintains it until completion.
@@ -31,16 +39,20 @@ type messageChunksCollection struct {
 	accumulatedSize int
 	chunks          map[int]*messageChunk
 	orderedOffsets  []int
+	totalCount      int
+	lastUpdated     time.Time
 	lock            sync.Mutex
 }
 
-func newMessageChunksCollection(id string, size int) *messageChunksCollection {
+func newMessageChunksCollection(id string, size, count int) *messageChunksCollection {
 	return &messageChunksCollection{
 		id:              id,
 		totalSize:       size,
 		accumulatedSize: 0,
 		chunks:          make(map[int]*messageChunk),
 		orderedOffsets:  make([]int, 0),
+		totalCount:      count,
+		lastUpdated:     time.Now(),
 		lock:            sync.Mutex{},
 	}
 }
@@ -57,6 +69,19 @@ func (collection *messageChunksCollection) add(chunk *messageChunk) {
 	collection.chunks[chunk.offset] = chunk
 	collection.orderedOffsets = append(collection.orderedOffsets, chunk.offset)
 	collection.accumulatedSize += len(chunk.bytes)
+	collection.lastUpdated = time.Now()
+}
+
+// completed returns true if all the chunks are received, it's decided by the count of the chunks if the producer sends
+// the sequence headers, otherwise by the size of the bundle
+func (collection *messageChunksCollection) completed() bool {
+	collection.lock.Lock()
+	defer collection.lock.Unlock()
+
+	if collection.totalCount > 0 {
+		return len(collection.chunks) >= collection.totalCount
+	}
+	return collection.totalSize <= collection.accumulatedSize
 }
 
 func (collection *messageChunksCollection) collect() ([]byte, error) {
@@ -79,6 +104,7 @@ type messageAssembler struct {
 	log                logr.Logger
 	lock               sync.Mutex
 	chunkCollectionMap map[string]*messageChunksCollection
+	reassemblyTimeout  time.Duration
 }
 
 func newMessageAssembler() *messageAssembler {
@@ -86,6 +112,7 @@ func newMessageAssembler() *messageAssembler {
 		log:                ctrl.Log.WithName("consumer-assembler"),
 		lock:               sync.Mutex{},
 		chunkCollectionMap: make(map[string]*messageChunksCollection),
+		reassemblyTimeout:  DefaultChunkReassemblyTimeout,
 	}
 }
 
@@ -94,15 +121,19 @@ func (assembler *messageAssembler) assemble(chunk *messageChunk) []byte {
 	assembler.lock.Lock()
 	defer assembler.lock.Unlock()
 
+	assembler.dropExpiredCollections()
+
 	chunkCollection, found := assembler.chunkCollectionMap[chunk.id] // chunk.id: PlacementRule
 	if !found {
-		chunkCollection = newMessageChunksCollection(chunk.id, chunk.size)
+		chunkCollection = newMessageChunksCollection(chunk.id, chunk.size, chunk.count)
 		assembler.chunkCollectionMap[chunk.id] = chunkCollection
 	}
 
 	chunkCollection.add(chunk)
+	assembler.log.V(4).Info("received the chunk", "id", chunk.id, "index", chunk.index, "count", chunk.count,
+		"offset", chunk.offset, "size", chunk.size)
 
-	if chunkCollection.totalSize <= chunkCollection.accumulatedSize {
+	if chunkCollection.completed() {
 		// delete collection from map
 		defer delete(assembler.chunkCollectionMap, chunkCollection.id)
 
@@ -119,6 +150,22 @@ func (assembler *messageAssembler) assemble(chunk *messageChunk) []byte {
 	return nil
 }
 
+// dropExpiredCollections drops the chunks of the bundles which aren't completed in the reassembly timeout, e.g. the
+// producer is restarted in the middle of a bundle, the bundle is sent again with a new id
+func (assembler *messageAssembler) dropExpiredCollections() {
+	for id, collection := range assembler.chunkCollectionMap {
+		collection.lock.Lock()
+		expired := time.Since(collection.lastUpdated) > assembler.reassemblyTimeout
+		received, total := len(collection.chunks), collection.totalCount
+		collection.lock.Unlock()
+		if expired {
+			assembler.log.Info("drop the incomplete event data", "id", id, "receivedChunks", received,
+				"totalChunks", total, "timeout", assembler.reassemblyTimeout)
+			delete(assembler.chunkCollectionMap, id)
+		}
+	}
+}
+
 func (assembler *messageAssembler) messageChunk(e cloudevents.Event) (*messageChunk, bool) {
 	offset, err := types.ToInteger(e.Extensions()[transport.ChunkOffsetKey])
 	if err != nil {
@@ -130,10 +177,21 @@ func (assembler *messageAssembler) messageChunk(e cloudevents.Event) (*messageCh
 		return nil, false
 	}
 
+	// the sequence headers are optional for the compatibility with the previous producers
+	index, count := -1, -1
+	if val, err := types.ToInteger(e.Extensions()[transport.ChunkIndexKey]); err == nil {
+		index = int(val)
+	}
+	if val, err := types.ToInteger(e.Extensions()[transport.ChunkCountKey]); err == nil {
+		count = int(val)
+	}
+
 	return &messageChunk{
 		id:     e.ID(),
 		offset: int(offset),
 		size:   int(size),
+		index:  index,
+		count:  count,
 		bytes:  e.Data(),
 	}, true
 }
@@ -0,0 +1,60 @@
+package consumer
+
+import (
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+func newChunkEvent(id string, data []byte, size, offset, index, count int) cloudevents.Event {
+	e := cloudevents.NewEvent()
+	e.SetID(id)
+	e.SetType("test")
+	e.SetSource("test")
+	_ = e.SetData(cloudevents.ApplicationJSON, data)
+	e.SetExtension(transport.ChunkSizeKey, size)
+	e.SetExtension(transport.ChunkOffsetKey, offset)
+	if count > 0 {
+		e.SetExtension(transport.ChunkIndexKey, index)
+		e.SetExtension(transport.ChunkCountKey, count)
+	}
+	return e
+}
+
+func TestMessageAssembler(t *testing.T) {
+	assembler := newMessageAssembler()
+
+	// the chunks are assembled in order of the offsets, even if they're received out of order
+	events := []cloudevents.Event{
+		newChunkEvent("bundle1", []byte("world"), 10, 10, 1, 2),
+		newChunkEvent("bundle1", []byte("world"), 10, 10, 1, 2),
+		newChunkEvent("bundle1", []byte("hello"), 10, 5, 0, 2),
+	}
+	var payload []byte
+	for _, e := range events {
+		chunk, isChunk := assembler.messageChunk(e)
+		assert.True(t, isChunk)
+		payload = assembler.assemble(chunk)
+	}
+	assert.Equal(t, "helloworld", string(payload))
+	assert.Empty(t, assembler.chunkCollectionMap)
+
+	// the chunks without the sequence headers are completed by the size
+	chunk, _ := assembler.messageChunk(newChunkEvent("bundle2", []byte("hello"), 5, 5, 0, 0))
+	assert.Equal(t, -1, chunk.count)
+	assert.Equal(t, "hello", string(assembler.assemble(chunk)))
+
+	// the incomplete bundle is dropped once the reassembly timeout is expired
+	assembler.reassemblyTimeout = 100 * time.Millisecond
+	chunk, _ = assembler.messageChunk(newChunkEvent("bundle3", []byte("hello"), 10, 5, 0, 2))
+	assert.Nil(t, assembler.assemble(chunk))
+	time.Sleep(200 * time.Millisecond)
+	chunk, _ = assembler.messageChunk(newChunkEvent("bundle4", []byte("hello"), 10, 5, 0, 2))
+	assert.Nil(t, assembler.assemble(chunk))
+	assert.NotContains(t, assembler.chunkCollectionMap, "bundle3")
+	assert.Contains(t, assembler.chunkCollectionMap, "bundle4")
+}
@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"strconv"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+const (
+	// FormatVersionKey is the key used for the bundle format version header, it's set by both the manager and the
+	// agents, so that each side can tell whether it's able to parse the bundles of the other side.
+	FormatVersionKey = "extformatversion"
+
+	// CurrentFormatVersion is the bundle format version sent by this release, it must be increased once the
+	// payload of an existing bundle is changed in an incompatible way.
+	CurrentFormatVersion = 2
+	// MinFormatVersion is the oldest bundle format version this release can parse. The bundles without the header
+	// are sent by the releases before the negotiation is introduced, they're treated as the format version 1.
+	MinFormatVersion = 1
+)
+
+// GetFormatVersion returns the bundle format version of the event.
+func GetFormatVersion(evt *cloudevents.Event) int {
+	val, found := evt.Extensions()[FormatVersionKey]
+	if !found {
+		return MinFormatVersion
+	}
+	switch version := val.(type) {
+	case int32:
+		return int(version)
+	case int:
+		return version
+	case string:
+		if i, err := strconv.Atoi(version); err == nil {
+			return i
+		}
+	}
+	return 0
+}
+
+// IsFormatVersionSupported returns whether this release is able to parse the bundles of the format version.
+func IsFormatVersionSupported(version int) bool {
+	return version >= MinFormatVersion && version <= CurrentFormatVersion
+}
@@ -0,0 +1,24 @@
+package transport_test
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+func TestGetFormatVersion(t *testing.T) {
+	evt := cloudevents.NewEvent()
+	assert.Equal(t, transport.MinFormatVersion, transport.GetFormatVersion(&evt))
+
+	evt.SetExtension(transport.FormatVersionKey, transport.CurrentFormatVersion)
+	assert.Equal(t, transport.CurrentFormatVersion, transport.GetFormatVersion(&evt))
+	assert.True(t, transport.IsFormatVersionSupported(transport.GetFormatVersion(&evt)))
+
+	// the header is a string once it's received from kafka
+	evt.SetExtension(transport.FormatVersionKey, "99")
+	assert.Equal(t, 99, transport.GetFormatVersion(&evt))
+	assert.False(t, transport.IsFormatVersionSupported(transport.GetFormatVersion(&evt)))
+}
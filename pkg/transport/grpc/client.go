@@ -0,0 +1,158 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	ceprotocol "github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport/config"
+)
+
+const (
+	reconnectInterval = 5 * time.Second
+	keepaliveTime     = 30 * time.Second
+	keepaliveTimeout  = 10 * time.Second
+)
+
+var (
+	clientMutex sync.Mutex
+	client      *Client
+)
+
+// Client is the agent side of the gRPC transport. It keeps a stream to the server of the manager, and reopens the
+// stream once it's broken, the server replays the latest spec bundles on the new stream
+type Client struct {
+	log      logr.Logger
+	conn     *grpc.ClientConn
+	incoming chan binding.Message
+	// guard the stream, the messages can't be sent on the same stream concurrently
+	mutex  sync.Mutex
+	stream grpc.ClientStream
+}
+
+// GetClient returns the client of the process, it connects to the server on the first call, so all the producers
+// and the consumer of the agent share the same stream
+func GetClient(grpcConfig *transport.GRPCConfig) (*Client, error) {
+	clientMutex.Lock()
+	defer clientMutex.Unlock()
+	if client != nil {
+		return client, nil
+	}
+
+	tlsConfig, err := config.NewMutualTLSClientConfig(grpcConfig.CACertPath, grpcConfig.CertPath,
+		grpcConfig.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(grpcConfig.ServerAddress,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(eventCodec{})),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                keepaliveTime,
+			Timeout:             keepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the grpc client of %s: %w", grpcConfig.ServerAddress, err)
+	}
+	c := &Client{
+		log:      ctrl.Log.WithName("grpc-client"),
+		conn:     conn,
+		incoming: make(chan binding.Message),
+	}
+	go c.run(context.Background())
+	client = c
+	return c, nil
+}
+
+// run opens the stream and receives the spec events until the stream is broken, then reopens it
+func (c *Client) run(ctx context.Context) {
+	for {
+		if err := c.receive(ctx); err != nil {
+			c.log.Info("the stream is broken, reconnecting", "error", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
+
+func (c *Client) receive(ctx context.Context) error {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.conn.NewStream(streamCtx, &streamDesc, streamMethod)
+	if err != nil {
+		return err
+	}
+	c.setStream(stream)
+	defer c.setStream(nil)
+	c.log.Info("the stream is opened")
+
+	for {
+		evt := &cloudevents.Event{}
+		if err := stream.RecvMsg(evt); err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("the stream is closed by the server")
+			}
+			return err
+		}
+		select {
+		case c.incoming <- binding.ToMessage(evt):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Client) setStream(stream grpc.ClientStream) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.stream = stream
+}
+
+// Send implements the protocol.Sender of the cloudevents, the event isn't delivered if the stream isn't opened
+func (c *Client) Send(ctx context.Context, m binding.Message, transformers ...binding.Transformer) error {
+	evt, err := binding.ToEvent(ctx, m, transformers...)
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.stream == nil {
+		return errors.New("the stream to the grpc server isn't opened")
+	}
+	return c.stream.SendMsg(evt)
+}
+
+// Receive implements the protocol.Receiver of the cloudevents
+func (c *Client) Receive(ctx context.Context) (binding.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, io.EOF
+	case m := <-c.incoming:
+		return m, nil
+	}
+}
+
+var (
+	_ ceprotocol.Sender   = (*Client)(nil)
+	_ ceprotocol.Receiver = (*Client)(nil)
+)
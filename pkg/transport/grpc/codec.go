@@ -0,0 +1,65 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"google.golang.org/grpc"
+)
+
+const (
+	serviceName = "globalhub.transport.v1.Transport"
+	// streamMethod is the only method of the service, the agent opens a bidirectional stream to the manager, the
+	// status bundles are sent by the agent and the spec bundles are sent by the manager on the same stream
+	streamMethod = "/" + serviceName + "/Stream"
+)
+
+// eventCodec marshals the cloudevents in the structured JSON mode, the bundle payload has been encoded by the
+// generic producer already, so the messages of the stream don't need the protobuf definitions
+type eventCodec struct{}
+
+func (eventCodec) Marshal(v any) ([]byte, error) {
+	evt, ok := v.(*cloudevents.Event)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return json.Marshal(evt)
+}
+
+func (eventCodec) Unmarshal(data []byte, v any) error {
+	evt, ok := v.(*cloudevents.Event)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	return json.Unmarshal(data, evt)
+}
+
+func (eventCodec) Name() string {
+	return "cloudevents+json"
+}
+
+var streamDesc = grpc.StreamDesc{
+	StreamName:    "Stream",
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+// serviceDesc is the hand written descriptor of the transport service, the handler is the *Server
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*any)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    streamDesc.StreamName,
+			ServerStreams: streamDesc.ServerStreams,
+			ClientStreams: streamDesc.ClientStreams,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				return srv.(*Server).serveStream(stream)
+			},
+		},
+	},
+}
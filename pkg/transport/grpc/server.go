@@ -0,0 +1,226 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	ceprotocol "github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport/config"
+)
+
+var (
+	serverMutex sync.Mutex
+	server      *Server
+)
+
+// Server is the manager side of the gRPC transport. It accepts the streams of the agents, and identifies the managed
+// hub of each stream by the common name of its client certificate. The spec events are routed to the managed hub of
+// the event source, or to all the managed hubs if the source is "broadcast"
+type Server struct {
+	log      logr.Logger
+	server   *grpc.Server
+	incoming chan binding.Message
+	mutex    sync.Mutex
+	streams  map[string]*hubStream
+	// the latest spec bundle of each type and source, which is replayed once the agent connects, so the managed hub
+	// doesn't miss the spec changes sent while it's disconnected
+	latest map[string]map[string][]*cloudevents.Event
+}
+
+type hubStream struct {
+	stream grpc.ServerStream
+	// the messages can't be sent on the same stream concurrently
+	mutex sync.Mutex
+}
+
+func (h *hubStream) send(evt *cloudevents.Event) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.stream.SendMsg(evt)
+}
+
+// GetServer returns the server of the process, it's started on the first call, so the producer and the consumer of
+// the manager share the streams of the agents
+func GetServer(grpcConfig *transport.GRPCConfig) (*Server, error) {
+	serverMutex.Lock()
+	defer serverMutex.Unlock()
+	if server != nil {
+		return server, nil
+	}
+
+	tlsConfig, err := config.NewMutualTLSServerConfig(grpcConfig.CACertPath, grpcConfig.CertPath,
+		grpcConfig.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", grpcConfig.ServerAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", grpcConfig.ServerAddress, err)
+	}
+	s := &Server{
+		log:      ctrl.Log.WithName("grpc-server"),
+		incoming: make(chan binding.Message),
+		streams:  map[string]*hubStream{},
+		latest:   map[string]map[string][]*cloudevents.Event{},
+	}
+	s.server = grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)), grpc.ForceServerCodec(eventCodec{}),
+		// allow the keepalive pings of the agents, the idle streams are closed by the load balancers otherwise
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             keepaliveTime / 2,
+			PermitWithoutStream: true,
+		}))
+	s.server.RegisterService(&serviceDesc, s)
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			s.log.Error(err, "the grpc server is stopped")
+		}
+	}()
+	s.log.Info("the grpc server is started", "address", grpcConfig.ServerAddress)
+	server = s
+	return s, nil
+}
+
+// Send implements the protocol.Sender of the cloudevents, the event isn't delivered if the managed hub isn't
+// connected, it's replayed once the agent connects
+func (s *Server) Send(ctx context.Context, m binding.Message, transformers ...binding.Transformer) error {
+	evt, err := binding.ToEvent(ctx, m, transformers...)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cacheEvent(evt)
+	var errs []error
+	for hubName, stream := range s.streams {
+		if evt.Source() != transport.Broadcast && evt.Source() != hubName {
+			continue
+		}
+		if err := stream.send(evt); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send the event to the hub %s: %w", hubName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Receive implements the protocol.Receiver of the cloudevents
+func (s *Server) Receive(ctx context.Context) (binding.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, io.EOF
+	case m := <-s.incoming:
+		return m, nil
+	}
+}
+
+// cacheEvent keeps the latest bundle of the event type, including all the chunks of the bundle
+func (s *Server) cacheEvent(evt *cloudevents.Event) {
+	events, ok := s.latest[evt.Source()]
+	if !ok {
+		events = map[string][]*cloudevents.Event{}
+		s.latest[evt.Source()] = events
+	}
+	if transport.IsSubsequentChunk(evt) {
+		events[evt.Type()] = append(events[evt.Type()], evt)
+	} else {
+		events[evt.Type()] = []*cloudevents.Event{evt}
+	}
+}
+
+func (s *Server) serveStream(stream grpc.ServerStream) error {
+	hubName, err := peerHubName(stream.Context())
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	hs := &hubStream{stream: stream}
+	if err := s.register(hubName, hs); err != nil {
+		return err
+	}
+	defer s.unregister(hubName, hs)
+	s.log.Info("the agent is connected", "hub", hubName)
+
+	for {
+		evt := &cloudevents.Event{}
+		if err := stream.RecvMsg(evt); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		// the agent can only send the bundles of its own managed hub
+		if evt.Source() != hubName {
+			s.log.Info("drop the event from the other source", "hub", hubName, "source", evt.Source(),
+				"type", evt.Type())
+			continue
+		}
+		select {
+		case s.incoming <- binding.ToMessage(evt):
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// register replaces the previous stream of the managed hub, and replays the latest spec bundles to it
+func (s *Server) register(hubName string, hs *hubStream) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.streams[hubName] = hs
+	for _, source := range []string{transport.Broadcast, hubName} {
+		for _, events := range s.latest[source] {
+			for _, evt := range events {
+				if err := hs.send(evt); err != nil {
+					delete(s.streams, hubName)
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) unregister(hubName string, hs *hubStream) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.streams[hubName] == hs {
+		delete(s.streams, hubName)
+		s.log.Info("the agent is disconnected", "hub", hubName)
+	}
+}
+
+// peerHubName returns the common name of the verified client certificate, which is the name of the managed hub
+func peerHubName(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", errors.New("no peer found in the stream")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return "", errors.New("the stream isn't authenticated with TLS")
+	}
+	return config.PeerCommonName(&tlsInfo.State)
+}
+
+var (
+	_ ceprotocol.Sender   = (*Server)(nil)
+	_ ceprotocol.Receiver = (*Server)(nil)
+)
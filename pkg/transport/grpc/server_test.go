@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+func TestEventCodec(t *testing.T) {
+	evt := cloudevents.NewEvent()
+	evt.SetID("1")
+	evt.SetType("managedclusters")
+	evt.SetSource("hub1")
+	evt.SetExtension(transport.ChunkOffsetKey, 3)
+	assert.NoError(t, evt.SetData(cloudevents.ApplicationJSON, []byte(`{"a":1}`)))
+
+	data, err := eventCodec{}.Marshal(&evt)
+	assert.NoError(t, err)
+	received := &cloudevents.Event{}
+	assert.NoError(t, eventCodec{}.Unmarshal(data, received))
+	assert.Equal(t, evt.Type(), received.Type())
+	assert.Equal(t, evt.Source(), received.Source())
+	assert.Equal(t, evt.Data(), received.Data())
+	assert.EqualValues(t, 3, received.Extensions()[transport.ChunkOffsetKey])
+
+	_, err = eventCodec{}.Marshal("invalid")
+	assert.Error(t, err)
+}
+
+func TestCacheEvent(t *testing.T) {
+	s := &Server{latest: map[string]map[string][]*cloudevents.Event{}}
+	newChunk := func(data string, offset int) *cloudevents.Event {
+		evt := cloudevents.NewEvent()
+		evt.SetType("policies")
+		evt.SetSource(transport.Broadcast)
+		evt.SetExtension(transport.ChunkOffsetKey, offset)
+		_ = evt.SetData(cloudevents.ApplicationJSON, []byte(data))
+		return &evt
+	}
+
+	s.cacheEvent(newChunk("abc", 3))
+	s.cacheEvent(newChunk("de", 5))
+	assert.Len(t, s.latest[transport.Broadcast]["policies"], 2)
+
+	// the first chunk of the next bundle replaces the previous bundle
+	s.cacheEvent(newChunk("fg", 2))
+	assert.Len(t, s.latest[transport.Broadcast]["policies"], 1)
+
+	evt := cloudevents.NewEvent()
+	evt.SetType("policies")
+	evt.SetSource("hub1")
+	s.cacheEvent(&evt)
+	s.cacheEvent(&evt)
+	assert.Len(t, s.latest["hub1"]["policies"], 1)
+}
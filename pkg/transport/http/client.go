@@ -0,0 +1,176 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	ceprotocol "github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport/config"
+)
+
+const (
+	retryInterval = 5 * time.Second
+	// the request timeout is longer than the poll timeout of the server
+	requestTimeout = pollTimeout + 30*time.Second
+)
+
+var (
+	clientMutex sync.Mutex
+	client      *Client
+)
+
+// Client is the agent side of the HTTP transport. It posts the status events to the manager, and long polls the spec
+// events with the cursor of the last poll, the proxy is read from the HTTPS_PROXY environment variable
+type Client struct {
+	log        logr.Logger
+	httpClient *http.Client
+	eventsURL  string
+	incoming   chan binding.Message
+}
+
+// GetClient returns the client of the process, it starts polling on the first call, so all the producers and the
+// consumer of the agent share the same client
+func GetClient(httpConfig *transport.HTTPConfig) (*Client, error) {
+	clientMutex.Lock()
+	defer clientMutex.Unlock()
+	if client != nil {
+		return client, nil
+	}
+
+	tlsConfig, err := config.NewMutualTLSClientConfig(httpConfig.CACertPath, httpConfig.CertPath,
+		httpConfig.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	// clone the default transport to keep the proxy from the environment and the HTTP/2
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	httpTransport.TLSClientConfig = tlsConfig
+
+	c := &Client{
+		log:        ctrl.Log.WithName("http-client"),
+		httpClient: &http.Client{Transport: httpTransport, Timeout: requestTimeout},
+		eventsURL:  (&url.URL{Scheme: "https", Host: httpConfig.ServerAddress, Path: EventsPath}).String(),
+		incoming:   make(chan binding.Message),
+	}
+	go c.run(context.Background())
+	client = c
+	return c, nil
+}
+
+// run polls the spec events until the context is done, the cursor is kept across the failed polls
+func (c *Client) run(ctx context.Context) {
+	cursor := ""
+	for {
+		next, err := c.poll(ctx, cursor)
+		if err == nil {
+			cursor = next
+			continue
+		}
+		c.log.Info("failed to poll the spec events, retrying", "error", err.Error())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// poll requests the spec events after the cursor, and returns the cursor of the received events
+func (c *Client) poll(ctx context.Context, cursor string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.eventsURL, nil)
+	if err != nil {
+		return cursor, err
+	}
+	if cursor != "" {
+		req.URL.RawQuery = url.Values{cursorParam: []string{cursor}}.Encode()
+	}
+	req.Header.Set("Accept", batchContentType)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return cursor, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return resp.Header.Get(cursorHeader), nil
+	case http.StatusOK:
+	default:
+		return cursor, responseError(resp)
+	}
+
+	events := []*cloudevents.Event{}
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return cursor, fmt.Errorf("failed to decode the spec events: %w", err)
+	}
+	for _, evt := range events {
+		select {
+		case c.incoming <- binding.ToMessage(evt):
+		case <-ctx.Done():
+			return cursor, ctx.Err()
+		}
+	}
+	return resp.Header.Get(cursorHeader), nil
+}
+
+// Send implements the protocol.Sender of the cloudevents, it posts the event in the structured mode
+func (c *Client) Send(ctx context.Context, m binding.Message, transformers ...binding.Transformer) error {
+	evt, err := binding.ToEvent(ctx, m, transformers...)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.eventsURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", eventContentType)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// Receive implements the protocol.Receiver of the cloudevents
+func (c *Client) Receive(ctx context.Context) (binding.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, io.EOF
+	case m := <-c.incoming:
+		return m, nil
+	}
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("unexpected response %s: %s", resp.Status, bytes.TrimSpace(body))
+}
+
+var (
+	_ ceprotocol.Sender   = (*Client)(nil)
+	_ ceprotocol.Receiver = (*Client)(nil)
+)
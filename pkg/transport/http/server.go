@@ -0,0 +1,254 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	ceprotocol "github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport/config"
+)
+
+const (
+	// EventsPath is the endpoint of the manager, the agents post the status events to it, and long poll the spec
+	// events from it
+	EventsPath = "/global-hub/v1/events"
+	// the cursor of the spec events which have been received by the agent, it's returned by each poll
+	cursorHeader = "Global-Hub-Cursor"
+	cursorParam  = "cursor"
+
+	eventContentType = "application/cloudevents+json"
+	batchContentType = "application/cloudevents-batch+json"
+
+	// the poll returns no content if there isn't any new spec event in the period
+	pollTimeout   = 30 * time.Second
+	maxEventBytes = 4 * 1024 * 1024
+)
+
+var (
+	serverMutex sync.Mutex
+	server      *Server
+)
+
+// Server is the manager side of the HTTP transport. The agents post the status events in the structured mode of the
+// CloudEvents HTTP binding, and long poll the spec events, so the managed hubs only need the egress to the manager,
+// which can be through the HTTPS proxies. The managed hub of each request is identified by the common name of the
+// client certificate
+type Server struct {
+	log      logr.Logger
+	incoming chan binding.Message
+	// the epoch of the cursors, the agents receive all the spec events again once the manager restarts
+	epoch  string
+	mutex  sync.Mutex
+	seq    uint64
+	latest map[string]map[string][]sequencedEvent
+	// updated is closed once a spec event is sent, which wakes up the polls
+	updated chan struct{}
+}
+
+type sequencedEvent struct {
+	seq   uint64
+	event *cloudevents.Event
+}
+
+// GetServer returns the server of the process, it's started on the first call, so the producer and the consumer of
+// the manager share the same endpoint
+func GetServer(httpConfig *transport.HTTPConfig) (*Server, error) {
+	serverMutex.Lock()
+	defer serverMutex.Unlock()
+	if server != nil {
+		return server, nil
+	}
+
+	tlsConfig, err := config.NewMutualTLSServerConfig(httpConfig.CACertPath, httpConfig.CertPath,
+		httpConfig.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		log:      ctrl.Log.WithName("http-server"),
+		incoming: make(chan binding.Message),
+		epoch:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		latest:   map[string]map[string][]sequencedEvent{},
+		updated:  make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(EventsPath, s.handleEvents)
+	httpServer := &http.Server{
+		Addr:              httpConfig.ServerAddress,
+		Handler:           mux,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		// the certificate is served by the TLS config
+		if err := httpServer.ListenAndServeTLS("", ""); err != nil {
+			s.log.Error(err, "the http server is stopped")
+		}
+	}()
+	s.log.Info("the http server is started", "address", httpConfig.ServerAddress)
+	server = s
+	return s, nil
+}
+
+// Send implements the protocol.Sender of the cloudevents, the spec event is kept until the next bundle of the same
+// type and source, and it's received by the next poll of the managed hubs
+func (s *Server) Send(ctx context.Context, m binding.Message, transformers ...binding.Transformer) error {
+	evt, err := binding.ToEvent(ctx, m, transformers...)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seq++
+	events, ok := s.latest[evt.Source()]
+	if !ok {
+		events = map[string][]sequencedEvent{}
+		s.latest[evt.Source()] = events
+	}
+	if transport.IsSubsequentChunk(evt) {
+		events[evt.Type()] = append(events[evt.Type()], sequencedEvent{seq: s.seq, event: evt})
+	} else {
+		events[evt.Type()] = []sequencedEvent{{seq: s.seq, event: evt}}
+	}
+	close(s.updated)
+	s.updated = make(chan struct{})
+	return nil
+}
+
+// Receive implements the protocol.Receiver of the cloudevents
+func (s *Server) Receive(ctx context.Context) (binding.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, io.EOF
+	case m := <-s.incoming:
+		return m, nil
+	}
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	hubName, err := config.PeerCommonName(r.TLS)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		s.receiveEvent(w, r, hubName)
+	case http.MethodGet:
+		s.pollEvents(w, r, hubName)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) receiveEvent(w http.ResponseWriter, r *http.Request, hubName string) {
+	evt := &cloudevents.Event{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEventBytes)).Decode(evt); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode the event: %v", err), http.StatusBadRequest)
+		return
+	}
+	// the agent can only send the bundles of its own managed hub
+	if evt.Source() != hubName {
+		http.Error(w, fmt.Sprintf("the source %s isn't the hub %s", evt.Source(), hubName), http.StatusForbidden)
+		return
+	}
+	select {
+	case s.incoming <- binding.ToMessage(evt):
+		w.WriteHeader(http.StatusAccepted)
+	case <-r.Context().Done():
+	}
+}
+
+// pollEvents returns the spec events of the managed hub after the cursor, or waits until there is any
+func (s *Server) pollEvents(w http.ResponseWriter, r *http.Request, hubName string) {
+	after := s.parseCursor(r.URL.Query().Get(cursorParam))
+	timer := time.NewTimer(pollTimeout)
+	defer timer.Stop()
+	for {
+		s.mutex.Lock()
+		events, last := s.eventsAfter(hubName, after)
+		updated := s.updated
+		s.mutex.Unlock()
+
+		if len(events) > 0 {
+			w.Header().Set(cursorHeader, s.formatCursor(last))
+			w.Header().Set("Content-Type", batchContentType)
+			if err := json.NewEncoder(w).Encode(events); err != nil {
+				s.log.Info("failed to write the spec events", "hub", hubName, "error", err.Error())
+			}
+			return
+		}
+		select {
+		case <-updated:
+		case <-timer.C:
+			w.Header().Set(cursorHeader, s.formatCursor(after))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// eventsAfter returns the spec events to the managed hub and the broadcast ones after the sequence in order
+func (s *Server) eventsAfter(hubName string, after uint64) ([]*cloudevents.Event, uint64) {
+	var sequenced []sequencedEvent
+	for _, source := range []string{transport.Broadcast, hubName} {
+		for _, events := range s.latest[source] {
+			for _, evt := range events {
+				if evt.seq > after {
+					sequenced = append(sequenced, evt)
+				}
+			}
+		}
+	}
+	sort.Slice(sequenced, func(i, j int) bool { return sequenced[i].seq < sequenced[j].seq })
+	events := make([]*cloudevents.Event, 0, len(sequenced))
+	last := after
+	for _, evt := range sequenced {
+		events = append(events, evt.event)
+		last = evt.seq
+	}
+	return events, last
+}
+
+func (s *Server) formatCursor(seq uint64) string {
+	return fmt.Sprintf("%s.%d", s.epoch, seq)
+}
+
+// parseCursor returns the sequence of the cursor, it's 0 if the cursor is issued by the previous manager
+func (s *Server) parseCursor(cursor string) uint64 {
+	epoch, seq, found := strings.Cut(cursor, ".")
+	if !found || epoch != s.epoch {
+		return 0
+	}
+	after, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return after
+}
+
+var (
+	_ ceprotocol.Sender   = (*Server)(nil)
+	_ ceprotocol.Receiver = (*Server)(nil)
+)
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+func TestEventsAfter(t *testing.T) {
+	s := &Server{
+		epoch:   "e1",
+		latest:  map[string]map[string][]sequencedEvent{},
+		updated: make(chan struct{}),
+	}
+	send := func(source, eventType string) {
+		evt := cloudevents.NewEvent()
+		evt.SetType(eventType)
+		evt.SetSource(source)
+		assert.NoError(t, s.Send(context.Background(), binding.ToMessage(&evt)))
+	}
+
+	updated := s.updated
+	send(transport.Broadcast, "policies")
+	send("hub1", "placements")
+	send("hub2", "placements")
+	// the poll is waken up by the sent event
+	<-updated
+
+	events, last := s.eventsAfter("hub1", 0)
+	assert.Len(t, events, 2)
+	assert.Equal(t, transport.Broadcast, events[0].Source())
+	assert.Equal(t, "hub1", events[1].Source())
+	assert.Equal(t, uint64(2), last)
+
+	// the newer bundle replaces the previous one of the same type
+	send(transport.Broadcast, "policies")
+	events, last = s.eventsAfter("hub1", last)
+	assert.Len(t, events, 1)
+	assert.Equal(t, uint64(4), last)
+
+	events, last = s.eventsAfter("hub1", last)
+	assert.Empty(t, events)
+	assert.Equal(t, uint64(4), last)
+}
+
+func TestParseCursor(t *testing.T) {
+	s := &Server{epoch: "e1"}
+	assert.Equal(t, uint64(5), s.parseCursor(s.formatCursor(5)))
+	assert.Equal(t, uint64(0), s.parseCursor(""))
+	// the cursor of the previous manager
+	assert.Equal(t, uint64(0), s.parseCursor("e0.5"))
+	assert.Equal(t, uint64(0), s.parseCursor("e1.invalid"))
+}
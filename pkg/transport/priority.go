@@ -0,0 +1,49 @@
+package transport
+
+import (
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+const (
+	// PriorityKey is the key used for the processing priority header of the bundle.
+	PriorityKey = "extpriority"
+
+	// PriorityHigh is the priority of the bundles which report the health of the hubs and the compliance of the fleet,
+	// the manager processes them ahead of the bulk bundles when there is a backlog.
+	PriorityHigh = "high"
+	// PriorityBulk is the priority of the other bundles, like the kube events.
+	PriorityBulk = "bulk"
+)
+
+var highPriorityEventTypes = map[enum.EventType]struct{}{
+	enum.HubClusterHeartbeatType:     {},
+	enum.HubClusterInfoType:          {},
+	enum.ManagedClusterType:          {},
+	enum.LocalComplianceType:         {},
+	enum.LocalCompleteComplianceType: {},
+	enum.ComplianceType:              {},
+	enum.CompleteComplianceType:      {},
+	enum.DeltaComplianceType:         {},
+	enum.MiniComplianceType:          {},
+}
+
+// PriorityOf returns the default priority of the event type.
+func PriorityOf(eventType string) string {
+	if _, ok := highPriorityEventTypes[enum.EventType(eventType)]; ok {
+		return PriorityHigh
+	}
+	return PriorityBulk
+}
+
+// GetPriority returns the priority in the event header, and falls back to the default priority of the event type
+// if the header isn't set, e.g. the event is sent by an agent of the previous release.
+func GetPriority(evt *cloudevents.Event) string {
+	if priority, ok := evt.Extensions()[PriorityKey].(string); ok {
+		if priority == PriorityHigh || priority == PriorityBulk {
+			return priority
+		}
+	}
+	return PriorityOf(evt.Type())
+}
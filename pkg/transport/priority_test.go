@@ -0,0 +1,29 @@
+package transport_test
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+func TestGetPriority(t *testing.T) {
+	heartbeat := cloudevents.NewEvent()
+	heartbeat.SetType(string(enum.HubClusterHeartbeatType))
+	assert.Equal(t, transport.PriorityHigh, transport.GetPriority(&heartbeat))
+
+	event := cloudevents.NewEvent()
+	event.SetType(string(enum.ManagedClusterEventType))
+	assert.Equal(t, transport.PriorityBulk, transport.GetPriority(&event))
+
+	// the header overrides the default priority of the event type
+	event.SetExtension(transport.PriorityKey, transport.PriorityHigh)
+	assert.Equal(t, transport.PriorityHigh, transport.GetPriority(&event))
+
+	// the unknown priority falls back to the default priority of the event type
+	event.SetExtension(transport.PriorityKey, "unknown")
+	assert.Equal(t, transport.PriorityBulk, transport.GetPriority(&event))
+}
@@ -6,6 +6,9 @@ package producer
 import (
 	"context"
 	"fmt"
+	"math"
+	"sync"
+	"time"
 
 	kafka_confluent "github.com/cloudevents/sdk-go/protocol/kafka_confluent/v2"
 	"github.com/cloudevents/sdk-go/protocol/kafka_sarama/v2"
@@ -13,25 +16,52 @@ import (
 	"github.com/cloudevents/sdk-go/v2/protocol/gochan"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/stolostron/multicluster-global-hub/pkg/tracing"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport/avro"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport/config"
+	grpctransport "github.com/stolostron/multicluster-global-hub/pkg/transport/grpc"
+	httptransport "github.com/stolostron/multicluster-global-hub/pkg/transport/http"
+	"github.com/stolostron/multicluster-global-hub/pkg/transport/protobuf"
 )
 
 const (
 	MaxMessageKBLimit    = 1024
 	DefaultMessageKBSize = 960
+	// the timeout to init the transactions with the transaction coordinator
+	transactionInitTimeout = 60 * time.Second
 )
 
 type GenericProducer struct {
 	log              logr.Logger
 	client           cloudevents.Client
+	clientMutex      sync.RWMutex
 	messageSizeLimit int
+	// encode the bundles with avro if the schema registry is configured
+	avroCodec *avro.Codec
+	// encode the bundles with protobuf instead of JSON
+	protobufEncoding bool
+	// send the chunks of each bundle in a kafka transaction if it's set
+	transactionalProducer *kafka.Producer
+	transactionMutex      sync.Mutex
+	// recreate the kafka producer once the client certificate is rotated
+	transportConfig *transport.TransportConfig
+	defaultTopic    string
+	kafkaProtocol   *kafka_confluent.Protocol
+	certRotated     <-chan struct{}
+	// throttle the messages sent to the transport, it's nil if the producer isn't throttled
+	rateLimiter *rate.Limiter
 }
 
 func NewGenericProducer(transportConfig *transport.TransportConfig, defaultTopic string) (*GenericProducer, error) {
 	var sender interface{}
+	var kafkaProtocol *kafka_confluent.Protocol
+	var transactionalProducer *kafka.Producer
+	var certRotated <-chan struct{}
+	var rateLimiter *rate.Limiter
 	var err error
 	messageSize := DefaultMessageKBSize * 1000
 	log := ctrl.Log.WithName(fmt.Sprintf("%s-producer", transportConfig.TransportType))
@@ -41,17 +71,24 @@ func NewGenericProducer(transportConfig *transport.TransportConfig, defaultTopic
 		if transportConfig.KafkaConfig.ProducerConfig.MessageSizeLimitKB > 0 {
 			messageSize = transportConfig.KafkaConfig.ProducerConfig.MessageSizeLimitKB * 1000
 		}
-		kafkaProtocol, err := getConfluentSenderProtocol(transportConfig, defaultTopic)
+		kafkaProtocol, transactionalProducer, err = newKafkaSender(log, transportConfig, defaultTopic)
 		if err != nil {
 			return nil, err
 		}
-
-		eventChan, err := kafkaProtocol.Events()
+		sender = kafkaProtocol
+		certRotated = config.WatchClientCertificate(context.Background(), transportConfig.KafkaConfig,
+			config.CertificateCheckInterval)
+		rateLimiter = newRateLimiter(transportConfig.KafkaConfig.ProducerConfig)
+	case string(transport.GRPC):
+		sender, err = getGRPCProtocol(transportConfig.GRPCConfig)
+		if err != nil {
+			return nil, err
+		}
+	case string(transport.HTTP):
+		sender, err = getHTTPProtocol(transportConfig.HTTPConfig)
 		if err != nil {
 			return nil, err
 		}
-		handleProducerEvents(log, eventChan)
-		sender = kafkaProtocol
 	case string(transport.Chan): // this go chan protocol is only use for test
 		if transportConfig.Extends == nil {
 			transportConfig.Extends = make(map[string]interface{})
@@ -69,24 +106,156 @@ func NewGenericProducer(transportConfig *transport.TransportConfig, defaultTopic
 		return nil, err
 	}
 
-	return &GenericProducer{
-		log:              log,
-		client:           client,
-		messageSizeLimit: messageSize,
-	}, nil
+	genericProducer := &GenericProducer{
+		log:                   log,
+		client:                client,
+		messageSizeLimit:      messageSize,
+		transactionalProducer: transactionalProducer,
+		transportConfig:       transportConfig,
+		defaultTopic:          defaultTopic,
+		kafkaProtocol:         kafkaProtocol,
+		certRotated:           certRotated,
+		rateLimiter:           rateLimiter,
+	}
+	switch transportConfig.PayloadEncoding {
+	case "", transport.JSONPayloadEncoding:
+	case transport.ProtobufPayloadEncoding:
+		genericProducer.protobufEncoding = true
+	default:
+		return nil, fmt.Errorf("payload encoding - %s is not a valid option", transportConfig.PayloadEncoding)
+	}
+	if registryConfig := transportConfig.SchemaRegistryConfig; registryConfig != nil && registryConfig.URL != "" {
+		genericProducer.avroCodec, err = avro.NewCodec(registryConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return genericProducer, nil
+}
+
+// newKafkaSender returns the kafka protocol, and the kafka producer if the transactions are enabled
+func newKafkaSender(log logr.Logger, transportConfig *transport.TransportConfig, defaultTopic string) (
+	*kafka_confluent.Protocol, *kafka.Producer, error,
+) {
+	kafkaProtocol, kafkaProducer, err := getConfluentSenderProtocol(transportConfig, defaultTopic)
+	if err != nil {
+		return nil, nil, err
+	}
+	eventChan, err := kafkaProtocol.Events()
+	if err != nil {
+		return nil, nil, err
+	}
+	handleProducerEvents(log, eventChan)
+	if transportConfig.KafkaConfig.ProducerConfig.TransactionalID == "" {
+		return kafkaProtocol, nil, nil
+	}
+	return kafkaProtocol, kafkaProducer, nil
 }
 
-func (p *GenericProducer) SendEvent(ctx context.Context, evt cloudevents.Event) error {
+// newRateLimiter returns the limiter of the messages sent by the producer, it's nil if the rate limit isn't set
+func newRateLimiter(producerConfig *transport.KafkaProducerConfig) *rate.Limiter {
+	if producerConfig.MessageRateLimit <= 0 {
+		return nil
+	}
+	burst := producerConfig.MessageBurst
+	if burst <= 0 {
+		burst = int(math.Ceil(producerConfig.MessageRateLimit))
+	}
+	return rate.NewLimiter(rate.Limit(producerConfig.MessageRateLimit), burst)
+}
+
+// reconnectIfCertRotated recreates the kafka producer with the rotated client certificate, the messages of the
+// previous producer are flushed before it's closed
+func (p *GenericProducer) reconnectIfCertRotated(ctx context.Context) error {
+	select {
+	case <-p.certRotated:
+	default:
+		return nil
+	}
+	// wait for the transaction in progress
+	p.transactionMutex.Lock()
+	defer p.transactionMutex.Unlock()
+	p.clientMutex.Lock()
+	defer p.clientMutex.Unlock()
+
+	// close the previous producer first, so the transactional id isn't fenced by the new one in the middle of a flush
+	if err := p.kafkaProtocol.Close(ctx); err != nil {
+		p.log.Error(err, "failed to close the previous producer")
+	}
+	kafkaProtocol, transactionalProducer, err := newKafkaSender(p.log, p.transportConfig, p.defaultTopic)
+	if err != nil {
+		return fmt.Errorf("failed to recreate the producer with the rotated certificate: %w", err)
+	}
+	client, err := cloudevents.NewClient(kafkaProtocol, cloudevents.WithTimeNow(), cloudevents.WithUUIDs())
+	if err != nil {
+		return err
+	}
+	p.client = client
+	p.kafkaProtocol = kafkaProtocol
+	p.transactionalProducer = transactionalProducer
+	p.log.Info("recreated the producer with the rotated client certificate")
+	return nil
+}
+
+func (p *GenericProducer) SendEvent(ctx context.Context, evt cloudevents.Event) (err error) {
+	if err := p.reconnectIfCertRotated(ctx); err != nil {
+		return err
+	}
+
+	// the trace context is propagated before encoding, since it's kept in the extensions
+	ctx, span := tracing.StartSendSpan(ctx, &evt)
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
+
 	// message key
 	evtCtx := ctx
 	if kafka_confluent.MessageKeyFrom(ctx) == "" {
 		evtCtx = kafka_confluent.WithMessageKey(ctx, evt.Type())
 	}
 
+	// format version
+	evt.SetExtension(transport.FormatVersionKey, transport.CurrentFormatVersion)
+
+	// priority
+	if _, found := evt.Extensions()[transport.PriorityKey]; !found {
+		evt.SetExtension(transport.PriorityKey, transport.PriorityOf(evt.Type()))
+	}
+
 	// data
+	if p.avroCodec != nil {
+		if err := p.avroCodec.Encode(&evt); err != nil {
+			return fmt.Errorf("failed to encode the event with avro: %w", err)
+		}
+	} else if p.protobufEncoding {
+		if err := protobuf.Encode(&evt); err != nil {
+			return fmt.Errorf("failed to encode the event with protobuf: %w", err)
+		}
+	}
+	dataContentType := evt.DataContentType()
+	if dataContentType == "" {
+		dataContentType = cloudevents.ApplicationJSON
+	}
 	payloadBytes := evt.Data()
 	chunks := p.splitPayloadIntoChunks(payloadBytes)
+	if p.transactionalProducer != nil {
+		return p.sendInTransaction(evtCtx, func() error {
+			return p.sendChunks(evtCtx, evt, dataContentType, payloadBytes, chunks)
+		})
+	}
+	return p.sendChunks(evtCtx, evt, dataContentType, payloadBytes, chunks)
+}
+
+func (p *GenericProducer) sendChunks(evtCtx context.Context, evt cloudevents.Event, dataContentType string,
+	payloadBytes []byte, chunks [][]byte,
+) error {
+	p.clientMutex.RLock()
+	defer p.clientMutex.RUnlock()
+
 	if len(chunks) == 1 {
+		if err := p.throttle(evtCtx); err != nil {
+			return err
+		}
 		if ret := p.client.Send(evtCtx, evt); cloudevents.IsUndelivered(ret) {
 			return fmt.Errorf("failed to send event to transport: %v", ret)
 		}
@@ -94,13 +263,18 @@ func (p *GenericProducer) SendEvent(ctx context.Context, evt cloudevents.Event)
 	}
 
 	chunkOffset := 0
-	for _, chunk := range chunks {
+	for i, chunk := range chunks {
 		evt.SetExtension(transport.ChunkSizeKey, len(payloadBytes))
 		chunkOffset += len(chunk)
 		evt.SetExtension(transport.ChunkOffsetKey, chunkOffset)
-		if err := evt.SetData(cloudevents.ApplicationJSON, chunk); err != nil {
+		evt.SetExtension(transport.ChunkIndexKey, i)
+		evt.SetExtension(transport.ChunkCountKey, len(chunks))
+		if err := evt.SetData(dataContentType, chunk); err != nil {
 			return fmt.Errorf("failed to set cloudevents data: %v", evt)
 		}
+		if err := p.throttle(evtCtx); err != nil {
+			return err
+		}
 		if result := p.client.Send(evtCtx, evt); cloudevents.IsUndelivered(result) {
 			return fmt.Errorf("failed to send events to transport: %v", result)
 		}
@@ -108,6 +282,46 @@ func (p *GenericProducer) SendEvent(ctx context.Context, evt cloudevents.Event)
 	return nil
 }
 
+// throttle waits until the message is allowed to be sent by the rate limiter
+func (p *GenericProducer) throttle(ctx context.Context) error {
+	if p.rateLimiter == nil {
+		return nil
+	}
+	if err := p.rateLimiter.Wait(ctx); err != nil {
+		return fmt.Errorf("failed to wait for the rate limiter: %w", err)
+	}
+	return nil
+}
+
+// sendInTransaction sends the messages in a kafka transaction, so the consumers with the read_committed isolation
+// level either read all the chunks of the bundle or none of them. The transaction is aborted if any chunk isn't sent
+func (p *GenericProducer) sendInTransaction(ctx context.Context, send func() error) error {
+	p.transactionMutex.Lock()
+	defer p.transactionMutex.Unlock()
+
+	if err := p.transactionalProducer.BeginTransaction(); err != nil {
+		return fmt.Errorf("failed to begin the transaction: %w", err)
+	}
+	if err := send(); err != nil {
+		p.abortTransaction(ctx)
+		return err
+	}
+	// the commit flushes the messages of the transaction
+	if err := p.transactionalProducer.CommitTransaction(ctx); err != nil {
+		if kafkaErr, ok := err.(kafka.Error); ok && kafkaErr.TxnRequiresAbort() {
+			p.abortTransaction(ctx)
+		}
+		return fmt.Errorf("failed to commit the transaction: %w", err)
+	}
+	return nil
+}
+
+func (p *GenericProducer) abortTransaction(ctx context.Context) {
+	if err := p.transactionalProducer.AbortTransaction(ctx); err != nil {
+		p.log.Error(err, "failed to abort the transaction")
+	}
+}
+
 func (p *GenericProducer) splitPayloadIntoChunks(payload []byte) [][]byte {
 	var chunk []byte
 	chunks := make([][]byte, 0, len(payload)/(p.messageSizeLimit)+1)
@@ -125,6 +339,24 @@ func (p *GenericProducer) SetDataLimit(size int) {
 	p.messageSizeLimit = size
 }
 
+// getGRPCProtocol returns the server for the manager, and the client for the agent, which are shared by all the
+// producers and the consumer of the process
+func getGRPCProtocol(grpcConfig *transport.GRPCConfig) (interface{}, error) {
+	if grpcConfig.Listen {
+		return grpctransport.GetServer(grpcConfig)
+	}
+	return grpctransport.GetClient(grpcConfig)
+}
+
+// getHTTPProtocol returns the server for the manager, and the client for the agent, which are shared by all the
+// producers and the consumer of the process
+func getHTTPProtocol(httpConfig *transport.HTTPConfig) (interface{}, error) {
+	if httpConfig.Listen {
+		return httptransport.GetServer(httpConfig)
+	}
+	return httptransport.GetClient(httpConfig)
+}
+
 func getSaramaSenderProtocol(transportConfig *transport.TransportConfig, defaultTopic string) (interface{}, error) {
 	saramaConfig, err := config.GetSaramaConfig(transportConfig.KafkaConfig)
 	if err != nil {
@@ -141,14 +373,45 @@ func getSaramaSenderProtocol(transportConfig *transport.TransportConfig, default
 	return sender, nil
 }
 
+// getConfluentSenderProtocol returns the cloudevents protocol, and the kafka producer if it's created here instead of
+// the protocol, e.g. the producer of the MSK IAM token or the transactions
 func getConfluentSenderProtocol(transportConfig *transport.TransportConfig,
 	defaultTopic string,
-) (*kafka_confluent.Protocol, error) {
-	configMap, err := config.GetConfluentConfigMap(transportConfig.KafkaConfig, true)
+) (*kafka_confluent.Protocol, *kafka.Producer, error) {
+	kafkaConfig := transportConfig.KafkaConfig
+	configMap, err := config.GetConfluentConfigMap(kafkaConfig, true)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if kafkaConfig.SASLMechanism != transport.AwsMskIam && kafkaConfig.ProducerConfig.TransactionalID == "" {
+		protocol, err := kafka_confluent.New(kafka_confluent.WithConfigMap(configMap),
+			kafka_confluent.WithSenderTopic(defaultTopic))
+		return protocol, nil, err
+	}
+
+	producer, err := kafka.NewProducer(configMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kafkaConfig.SASLMechanism == transport.AwsMskIam {
+		// the IAM token must be set on the producer before it connects to the brokers
+		if err := config.StartMSKIAMTokenRefresher(producer, kafkaConfig); err != nil {
+			producer.Close()
+			return nil, nil, err
+		}
+	}
+	if kafkaConfig.ProducerConfig.TransactionalID != "" {
+		// fence the previous producer with the same transactional id, and abort its pending transaction
+		ctx, cancel := context.WithTimeout(context.Background(), transactionInitTimeout)
+		defer cancel()
+		if err := producer.InitTransactions(ctx); err != nil {
+			producer.Close()
+			return nil, nil, fmt.Errorf("failed to init the transactions: %w", err)
+		}
 	}
-	return kafka_confluent.New(kafka_confluent.WithConfigMap(configMap), kafka_confluent.WithSenderTopic(defaultTopic))
+	protocol, err := kafka_confluent.New(kafka_confluent.WithSender(producer),
+		kafka_confluent.WithSenderTopic(defaultTopic))
+	return protocol, producer, err
 }
 
 func handleProducerEvents(log logr.Logger, eventChan chan kafka.Event) {
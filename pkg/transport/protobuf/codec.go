@@ -0,0 +1,456 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package protobuf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+// ContentType is the datacontenttype of the protobuf encoded bundles, it's sent as the "content-type" header of the
+// kafka messages, so the consumers can decode the bundles without being configured with the encoding
+const ContentType = "application/protobuf"
+
+// The bundle is encoded with the following messages, the JSON payload is converted into a tree of the values, and
+// the object keys and the strings are interned, which removes the repeated keys, labels and conditions of the
+// thousands of the objects in a status bundle
+//
+//	message Bundle {
+//	  string type = 1;
+//	  int32 format_version = 2;
+//	  repeated string strings = 3;
+//	  Value data = 4;
+//	  bytes raw = 5;   // the payload which isn't a JSON document
+//	}
+//	message Value {
+//	  oneof kind {
+//	    bool null_value = 1;
+//	    bool bool_value = 2;
+//	    double number_value = 3;
+//	    sint64 integer_value = 4;
+//	    uint32 string_value = 5;   // the index of the strings
+//	    List list_value = 6;
+//	    Object object_value = 7;
+//	  }
+//	}
+//	message List { repeated Value values = 1; }
+//	message Object { repeated Field fields = 1; }
+//	message Field { uint32 key = 1; Value value = 2; }   // the key is the index of the strings
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+
+	bundleType          = 1
+	bundleFormatVersion = 2
+	bundleStrings       = 3
+	bundleData          = 4
+	bundleRaw           = 5
+
+	valueNull    = 1
+	valueBool    = 2
+	valueNumber  = 3
+	valueInteger = 4
+	valueString  = 5
+	valueList    = 6
+	valueObject  = 7
+
+	listValues   = 1
+	objectFields = 1
+	fieldKey     = 1
+	fieldValue   = 2
+)
+
+// Bundle is the decoded Bundle message, the Data is the JSON payload
+type Bundle struct {
+	Type          string
+	FormatVersion int32
+	Data          []byte
+}
+
+// Encode replaces the JSON data of the event with the protobuf encoded bundle
+func Encode(evt *cloudevents.Event) error {
+	payload, err := EncodeBundle(&Bundle{
+		Type:          evt.Type(),
+		FormatVersion: int32(transport.GetFormatVersion(evt)),
+		Data:          evt.Data(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := evt.SetData(ContentType, payload); err != nil {
+		return fmt.Errorf("failed to set the protobuf data: %w", err)
+	}
+	return nil
+}
+
+// Decode replaces the protobuf encoded data of the event with the JSON payload, the event isn't changed if it isn't
+// encoded with protobuf
+func Decode(evt *cloudevents.Event) error {
+	if evt.DataContentType() != ContentType {
+		return nil
+	}
+	bundle, err := DecodeBundle(evt.Data())
+	if err != nil {
+		return err
+	}
+	if err := evt.SetData(cloudevents.ApplicationJSON, bundle.Data); err != nil {
+		return fmt.Errorf("failed to set the decoded data: %w", err)
+	}
+	return nil
+}
+
+// EncodeBundle returns the Bundle message, the data is kept as the raw bytes if it isn't a JSON document
+func EncodeBundle(bundle *Bundle) ([]byte, error) {
+	encoder := &encoder{stringIndex: map[string]uint64{}}
+	var data []byte
+	if len(bundle.Data) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(bundle.Data))
+		decoder.UseNumber()
+		value, err := encoder.appendValue(make([]byte, 0, len(bundle.Data)/2), decoder)
+		if err == nil {
+			// the trailing data isn't allowed
+			if _, err = decoder.Token(); err == io.EOF {
+				data = value
+			}
+		}
+	}
+
+	buf := []byte{}
+	if bundle.Type != "" {
+		buf = appendBytes(buf, bundleType, []byte(bundle.Type))
+	}
+	if bundle.FormatVersion != 0 {
+		buf = appendVarint(buf, bundleFormatVersion, uint64(bundle.FormatVersion))
+	}
+	if data == nil {
+		if len(bundle.Data) > 0 {
+			buf = appendBytes(buf, bundleRaw, bundle.Data)
+		}
+		return buf, nil
+	}
+	for _, str := range encoder.strings {
+		buf = appendBytes(buf, bundleStrings, []byte(str))
+	}
+	return appendBytes(buf, bundleData, data), nil
+}
+
+// DecodeBundle parses the Bundle message and converts the values into the JSON payload
+func DecodeBundle(payload []byte) (*Bundle, error) {
+	bundle := &Bundle{}
+	strs := []string{}
+	var data []byte
+	err := readFields(payload, func(num, wireType int, val uint64, fieldBytes []byte) error {
+		switch {
+		case num == bundleType && wireType == wireBytes:
+			bundle.Type = string(fieldBytes)
+		case num == bundleFormatVersion && wireType == wireVarint:
+			bundle.FormatVersion = int32(val)
+		case num == bundleStrings && wireType == wireBytes:
+			strs = append(strs, string(fieldBytes))
+		case num == bundleData && wireType == wireBytes:
+			data = fieldBytes
+		case num == bundleRaw && wireType == wireBytes:
+			bundle.Data = fieldBytes
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the bundle: %w", err)
+	}
+	if data == nil {
+		return bundle, nil
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, 2*len(payload)))
+	if err := decodeValue(buf, data, strs); err != nil {
+		return nil, fmt.Errorf("failed to read the bundle data: %w", err)
+	}
+	bundle.Data = buf.Bytes()
+	return bundle, nil
+}
+
+type encoder struct {
+	strings     []string
+	stringIndex map[string]uint64
+}
+
+func (e *encoder) index(str string) uint64 {
+	index, ok := e.stringIndex[str]
+	if !ok {
+		index = uint64(len(e.strings))
+		e.strings = append(e.strings, str)
+		e.stringIndex[str] = index
+	}
+	return index
+}
+
+// appendValue reads the next JSON value from the decoder and appends the fields of the Value message to the buf
+func (e *encoder) appendValue(buf []byte, decoder *json.Decoder) ([]byte, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch val := token.(type) {
+	case nil:
+		buf = appendVarint(buf, valueNull, 1)
+	case bool:
+		buf = appendVarint(buf, valueBool, boolToUint64(val))
+	case json.Number:
+		if integer, err := val.Int64(); err == nil {
+			buf = appendVarint(buf, valueInteger, uint64((integer<<1)^(integer>>63)))
+		} else {
+			number, err := val.Float64()
+			if err != nil {
+				return nil, err
+			}
+			buf = appendTag(buf, valueNumber, wireFixed64)
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(number))
+		}
+	case string:
+		buf = appendVarint(buf, valueString, e.index(val))
+	case json.Delim:
+		switch val {
+		case '[':
+			buf, err = appendMessage(buf, valueList, func(list []byte) ([]byte, error) {
+				for decoder.More() {
+					if list, err = appendMessage(list, listValues, func(item []byte) ([]byte, error) {
+						return e.appendValue(item, decoder)
+					}); err != nil {
+						return nil, err
+					}
+				}
+				return list, nil
+			})
+		case '{':
+			buf, err = appendMessage(buf, valueObject, func(object []byte) ([]byte, error) {
+				for decoder.More() {
+					keyToken, err := decoder.Token()
+					if err != nil {
+						return nil, err
+					}
+					key, ok := keyToken.(string)
+					if !ok {
+						return nil, fmt.Errorf("unexpected object key: %v", keyToken)
+					}
+					if object, err = appendMessage(object, objectFields, func(field []byte) ([]byte, error) {
+						field = appendVarint(field, fieldKey, e.index(key))
+						return appendMessage(field, fieldValue, func(item []byte) ([]byte, error) {
+							return e.appendValue(item, decoder)
+						})
+					}); err != nil {
+						return nil, err
+					}
+				}
+				return object, nil
+			})
+		default:
+			return nil, fmt.Errorf("unexpected delimiter: %v", val)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// decodeValue writes the JSON of the Value message into the buffer
+func decodeValue(buf *bytes.Buffer, data []byte, strs []string) error {
+	written := false
+	err := readFields(data, func(num, wireType int, val uint64, fieldBytes []byte) error {
+		if written {
+			return errors.New("the value has more than one kind")
+		}
+		written = true
+		switch {
+		case num == valueNull && wireType == wireVarint:
+			buf.WriteString("null")
+		case num == valueBool && wireType == wireVarint:
+			buf.WriteString(strconv.FormatBool(val != 0))
+		case num == valueNumber && wireType == wireFixed64:
+			buf.WriteString(strconv.FormatFloat(math.Float64frombits(val), 'g', -1, 64))
+		case num == valueInteger && wireType == wireVarint:
+			buf.WriteString(strconv.FormatInt(int64(val>>1)^-int64(val&1), 10))
+		case num == valueString && wireType == wireVarint:
+			return writeString(buf, val, strs)
+		case num == valueList && wireType == wireBytes:
+			return decodeList(buf, fieldBytes, strs)
+		case num == valueObject && wireType == wireBytes:
+			return decodeObject(buf, fieldBytes, strs)
+		default:
+			return fmt.Errorf("unexpected field %d of the wire type %d", num, wireType)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !written {
+		return errors.New("the value is empty")
+	}
+	return nil
+}
+
+func decodeList(buf *bytes.Buffer, data []byte, strs []string) error {
+	buf.WriteByte('[')
+	first := true
+	err := readFields(data, func(num, wireType int, val uint64, fieldBytes []byte) error {
+		if num != listValues || wireType != wireBytes {
+			return fmt.Errorf("unexpected list field %d", num)
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		return decodeValue(buf, fieldBytes, strs)
+	})
+	if err != nil {
+		return err
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+func decodeObject(buf *bytes.Buffer, data []byte, strs []string) error {
+	buf.WriteByte('{')
+	first := true
+	err := readFields(data, func(num, wireType int, val uint64, fieldBytes []byte) error {
+		if num != objectFields || wireType != wireBytes {
+			return fmt.Errorf("unexpected object field %d", num)
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		keyIndex, value := uint64(0), []byte(nil)
+		err := readFields(fieldBytes, func(num, wireType int, val uint64, fieldBytes []byte) error {
+			switch {
+			case num == fieldKey && wireType == wireVarint:
+				keyIndex = val
+			case num == fieldValue && wireType == wireBytes:
+				value = fieldBytes
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeString(buf, keyIndex, strs); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		return decodeValue(buf, value, strs)
+	})
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeString(buf *bytes.Buffer, index uint64, strs []string) error {
+	if index >= uint64(len(strs)) {
+		return fmt.Errorf("the string index %d is out of range", index)
+	}
+	quoted, err := json.Marshal(strs[index])
+	if err != nil {
+		return err
+	}
+	buf.Write(quoted)
+	return nil
+}
+
+// readFields calls the handler with the number, the wire type and the value of each field of the message, the val
+// is set for the varint and fixed64 fields, and the fieldBytes is set for the length delimited fields
+func readFields(data []byte, handler func(num, wireType int, val uint64, fieldBytes []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("invalid tag")
+		}
+		data = data[n:]
+		num, wireType := int(tag>>3), int(tag&7)
+
+		var val uint64
+		var fieldBytes []byte
+		switch wireType {
+		case wireVarint:
+			val, n = binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid varint of the field %d", num)
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return fmt.Errorf("invalid fixed64 of the field %d", num)
+			}
+			val, data = binary.LittleEndian.Uint64(data[:8]), data[8:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("invalid length of the field %d", num)
+			}
+			fieldBytes, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			return fmt.Errorf("unsupported wire type %d of the field %d", wireType, num)
+		}
+		if err := handler(num, wireType, val, fieldBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendTag(buf []byte, num, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(num)<<3|uint64(wireType))
+}
+
+func appendVarint(buf []byte, num int, val uint64) []byte {
+	return binary.AppendUvarint(appendTag(buf, num, wireVarint), val)
+}
+
+func appendBytes(buf []byte, num int, val []byte) []byte {
+	buf = binary.AppendUvarint(appendTag(buf, num, wireBytes), uint64(len(val)))
+	return append(buf, val...)
+}
+
+// appendMessage appends the embedded message written by the appendFields, the length is reserved with one byte and
+// the message is shifted if the length takes more bytes, so the message doesn't need to be copied from the buffer
+func appendMessage(buf []byte, num int, appendFields func([]byte) ([]byte, error)) ([]byte, error) {
+	buf = append(appendTag(buf, num, wireBytes), 0)
+	start := len(buf)
+	buf, err := appendFields(buf)
+	if err != nil {
+		return nil, err
+	}
+	length := uint64(len(buf) - start)
+	lengthSize := len(binary.AppendUvarint(nil, length))
+	if lengthSize > 1 {
+		buf = append(buf, make([]byte, lengthSize-1)...)
+		copy(buf[start+lengthSize-1:], buf[start:len(buf)-lengthSize+1])
+	}
+	binary.PutUvarint(buf[start-1:], length)
+	return buf, nil
+}
+
+func boolToUint64(val bool) uint64 {
+	if val {
+		return 1
+	}
+	return 0
+}
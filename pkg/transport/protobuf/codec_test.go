@@ -0,0 +1,147 @@
+package protobuf
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+func TestBundleEncoding(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+	}{
+		{"object", `{"a":1,"b":[1.5,-3,"x<y",null,true,false,{"a":{}}],"c":[]}`},
+		{"string", `"hub1"`},
+		{"number", `-9223372036854775808`},
+		{"float", `{"big":1e+300}`},
+		{"repeated keys", `[{"name":"c1","labels":{"k":"v"}},{"name":"c2","labels":{"k":"v"}}]`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := EncodeBundle(&Bundle{Type: "test", FormatVersion: 2, Data: []byte(tc.data)})
+			assert.NoError(t, err)
+			bundle, err := DecodeBundle(payload)
+			assert.NoError(t, err)
+			assert.Equal(t, "test", bundle.Type)
+			assert.Equal(t, int32(2), bundle.FormatVersion)
+			assert.JSONEq(t, tc.data, string(bundle.Data))
+		})
+	}
+
+	// the payload which isn't a JSON document is kept as it is
+	for _, data := range []string{"not json", `{"a":1} trailing`} {
+		payload, err := EncodeBundle(&Bundle{Type: "test", Data: []byte(data)})
+		assert.NoError(t, err)
+		bundle, err := DecodeBundle(payload)
+		assert.NoError(t, err)
+		assert.Equal(t, data, string(bundle.Data))
+	}
+
+	// the large value takes more than one byte of the length
+	large := fmt.Sprintf(`{"data":"%0200d"}`, 1)
+	payload, err := EncodeBundle(&Bundle{Data: []byte(large)})
+	assert.NoError(t, err)
+	bundle, err := DecodeBundle(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, large, string(bundle.Data))
+
+	// the truncated payload
+	_, err = DecodeBundle(payload[:len(payload)-1])
+	assert.Error(t, err)
+}
+
+func TestCodec(t *testing.T) {
+	evt := cloudevents.NewEvent()
+	evt.SetType("test.bundle")
+	evt.SetSource("hub1")
+	evt.SetExtension(transport.FormatVersionKey, transport.CurrentFormatVersion)
+	payload := []byte(`{"clusters":["cluster1","cluster2"]}`)
+	assert.NoError(t, evt.SetData(cloudevents.ApplicationJSON, payload))
+
+	assert.NoError(t, Encode(&evt))
+	assert.Equal(t, ContentType, evt.DataContentType())
+
+	assert.NoError(t, Decode(&evt))
+	assert.Equal(t, cloudevents.ApplicationJSON, evt.DataContentType())
+	assert.Equal(t, payload, evt.Data())
+
+	// the JSON event isn't changed
+	assert.NoError(t, Decode(&evt))
+	assert.Equal(t, payload, evt.Data())
+}
+
+// statusBundle returns the JSON payload of a status bundle with the managed clusters
+func statusBundle(clusters int) []byte {
+	objects := make([]map[string]interface{}, 0, clusters)
+	for i := 0; i < clusters; i++ {
+		name := fmt.Sprintf("cluster%d", i)
+		objects = append(objects, map[string]interface{}{
+			"apiVersion": "cluster.open-cluster-management.io/v1",
+			"kind":       "ManagedCluster",
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"uid":    fmt.Sprintf("3f1b8f7c-2a4e-4d1a-9b1e-%012d", i),
+				"labels": map[string]string{"cloud": "Amazon", "vendor": "OpenShift", "name": name},
+			},
+			"spec": map[string]interface{}{"hubAcceptsClient": true, "leaseDurationSeconds": 60},
+			"status": map[string]interface{}{
+				"conditions": []map[string]string{
+					{
+						"type": "ManagedClusterConditionAvailable", "status": "True",
+						"reason": "ManagedClusterAvailable", "lastTransitionTime": "2024-05-24T00:00:00Z",
+					},
+					{
+						"type": "ManagedClusterJoined", "status": "True",
+						"reason": "ManagedClusterJoined", "lastTransitionTime": "2024-05-24T00:00:00Z",
+					},
+				},
+				"version":     map[string]string{"kubernetes": "v1.29.5"},
+				"allocatable": map[string]string{"cpu": "12", "memory": "48Gi"},
+			},
+		})
+	}
+	data, _ := json.Marshal(objects)
+	return data
+}
+
+// BenchmarkJSONBundle measures the JSON bundle of the 5000 clusters from the producer to the consumer
+func BenchmarkJSONBundle(b *testing.B) {
+	data := statusBundle(5000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		objects := []map[string]interface{}{}
+		if err := json.Unmarshal(data, &objects); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := json.Marshal(objects); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(data)), "bytes/bundle")
+}
+
+// BenchmarkProtobufBundle measures the protobuf bundle of the 5000 clusters from the producer to the consumer
+func BenchmarkProtobufBundle(b *testing.B) {
+	data := statusBundle(5000)
+	payload, err := EncodeBundle(&Bundle{Data: data})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		payload, err := EncodeBundle(&Bundle{Data: data})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := DecodeBundle(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(payload)), "bytes/bundle")
+}
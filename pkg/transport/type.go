@@ -8,9 +8,11 @@ const (
 	// GenericSpecTopic   = "gh-spec"
 	// GenericStatusTopic = "gh-event"
 
-	Broadcast      = "broadcast" // Broadcast can be used as destination when a bundle should be broadcasted.
-	ChunkSizeKey   = "extsize"   // ChunkSizeKey is the key used for total bundle size header.
-	ChunkOffsetKey = "extoffset" // ChunkOffsetKey is the key used for message fragment offset header.
+	Broadcast      = "broadcast"     // Broadcast can be used as destination when a bundle should be broadcasted.
+	ChunkSizeKey   = "extsize"       // ChunkSizeKey is the key used for total bundle size header.
+	ChunkOffsetKey = "extoffset"     // ChunkOffsetKey is the key used for message fragment offset header.
+	ChunkIndexKey  = "extchunkindex" // ChunkIndexKey is the key used for message fragment sequence header.
+	ChunkCountKey  = "extchunkcount" // ChunkCountKey is the key used for total message fragments header.
 
 	// Deprecated
 	// CompressionType is the key used for compression type header.
@@ -20,13 +22,36 @@ const (
 	DestinationKey         = "destination"
 )
 
-// indicate the transport type, only support kafka or go chan
+// indicate the transport type, only support kafka, grpc or go chan
 type TransportType string
 
 const (
 	// transportType values
 	Kafka TransportType = "kafka"
 	Chan  TransportType = "chan"
+	// GRPC streams the bundles between the agents and the manager over the mTLS connections, for the environments
+	// which prohibit exposing the kafka cluster to the managed hubs
+	GRPC TransportType = "grpc"
+	// HTTP posts the status bundles to the manager and long polls the spec bundles in the CloudEvents HTTP binding,
+	// for the managed hubs which only have the egress through the HTTPS proxies
+	HTTP TransportType = "http"
+)
+
+// the encoding of the bundle payload, the consumers decode the payload by the content type of the messages, so the
+// producers can switch the encoding without the consumers being reconfigured
+const (
+	JSONPayloadEncoding     = "json"
+	ProtobufPayloadEncoding = "protobuf"
+)
+
+// the SASL mechanism for the client which doesn't authenticate with the certificate
+const (
+	ScramSha512 = "SCRAM-SHA-512"
+	// SaslPlain authenticates with the API key and secret, e.g. the Confluent Cloud
+	SaslPlain = "PLAIN"
+	// AwsMskIam authenticates with the AWS MSK by the IAM credential, it's the OAUTHBEARER mechanism of the kafka
+	// client with the token signed by the access key
+	AwsMskIam = "AWS_MSK_IAM"
 )
 
 // transport protocol
@@ -38,14 +63,58 @@ const (
 	StrimziTransporter TransportProtocol = iota
 	// the kafka cluster is created by customer, and the transport secret will be shared between clusters
 	SecretTransporter
+	// the streams are provisioned on the NATS JetStream by the nats jetstream controller, instead of the kafka topics
+	NatsTransporter
+	// the agents stream the bundles to the gRPC gateway of the manager, the certificates are issued by the operator
+	GRPCTransporter
+	// the agents post and poll the bundles on the HTTPS endpoint of the manager, the certificates are issued by the
+	// operator
+	HTTPTransporter
 )
 
 type TransportConfig struct {
 	TransportType          string
 	MessageCompressionType string
-	CommitterInterval      time.Duration
-	KafkaConfig            *KafkaConfig
-	Extends                map[string]interface{}
+	// PayloadEncoding is the encoding of the bundles sent by the producer, "json" or "protobuf". The avro encoding of
+	// the SchemaRegistryConfig takes precedence
+	PayloadEncoding      string
+	CommitterInterval    time.Duration
+	KafkaConfig          *KafkaConfig
+	GRPCConfig           *GRPCConfig
+	HTTPConfig           *HTTPConfig
+	SchemaRegistryConfig *SchemaRegistryConfig
+	Extends              map[string]interface{}
+}
+
+// SchemaRegistryConfig is the schema registry of the avro encoded bundles, the bundles are encoded with JSON if the
+// URL isn't set
+type SchemaRegistryConfig struct {
+	URL          string
+	Username     string
+	PasswordPath string
+	CACertPath   string
+}
+
+// GRPCConfig is the gRPC transport, the manager listens on the server address, and the agents dial it with the
+// client certificates, whose common names are the names of the managed hubs
+type GRPCConfig struct {
+	ServerAddress string
+	// Listen is set for the manager, which serves the agents instead of dialing the server address
+	Listen     bool
+	CACertPath string
+	CertPath   string
+	KeyPath    string
+}
+
+// HTTPConfig is the HTTP transport, the manager serves the events endpoint on the server address, and the agents
+// reach it with the client certificates, through the proxy of the HTTPS_PROXY environment variable if it's set
+type HTTPConfig struct {
+	ServerAddress string
+	// Listen is set for the manager, which serves the agents instead of requesting the server address
+	Listen     bool
+	CACertPath string
+	CertPath   string
+	KeyPath    string
 }
 
 // Kafka Config
@@ -56,18 +125,33 @@ type KafkaConfig struct {
 	ClientCertPath  string
 	ClientKeyPath   string
 	EnableTLS       bool
-	Topics          *ClusterTopic
-	ProducerConfig  *KafkaProducerConfig
-	ConsumerConfig  *KafkaConsumerConfig
+	// authenticate with SASL instead of the client certificate if the mechanism is set, e.g. SCRAM-SHA-512
+	SASLMechanism    string
+	SASLUsername     string
+	SASLPasswordPath string
+	Topics           *ClusterTopic
+	ProducerConfig   *KafkaProducerConfig
+	ConsumerConfig   *KafkaConsumerConfig
 }
 
 type KafkaProducerConfig struct {
 	ProducerID         string
 	MessageSizeLimitKB int
+	// the bundles are sent in the kafka transactions with the idempotent producer if the transactional id is set,
+	// the id must be unique and stable across the restarts of the producer
+	TransactionalID string
+	// throttle the messages per second sent by the producer if it's set, so a hub replaying its events after
+	// reconnecting doesn't saturate the transport. The burst is the messages sent at once, the default is the rate
+	MessageRateLimit float64
+	MessageBurst     int
 }
 
 type KafkaConsumerConfig struct {
 	ConsumerID string
+	// resume from the offsets committed by the legacy consumer group if the consumer group hasn't committed any
+	LegacyConsumerID string
+	// only read the messages of the committed transactions
+	ReadCommitted bool
 }
 
 // topics
@@ -90,6 +174,14 @@ type KafkaConnCredential struct {
 	// the following fields are only for the agent of built-in kafka
 	CASecretName     string `yaml:"ca.secret,omitempty"`
 	ClientSecretName string `yaml:"client.secret,omitempty"`
+	// the following fields are only for the agent which authenticates with SASL instead of the client certificate
+	SASLMechanism string `yaml:"sasl.mechanism,omitempty"`
+	SASLUsername  string `yaml:"sasl.username,omitempty"`
+	SASLPassword  string `yaml:"sasl.password,omitempty"`
+	// the following fields are only for the agent of built-in kafka, the agent resumes from the offsets of the legacy
+	// consumer group once the consumer group is renamed
+	ConsumerGroupID       string `yaml:"consumer.group.id,omitempty"`
+	LegacyConsumerGroupID string `yaml:"legacy.consumer.group.id,omitempty"`
 }
 
 type EventPosition struct {
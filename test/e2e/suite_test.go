@@ -111,7 +111,8 @@ var _ = BeforeSuite(func() {
 		Get(ctx, "multicluster-global-hub-storage", metav1.GetOptions{})
 	Expect(err).Should(Succeed())
 	err = database.InitGormInstance(&database.DatabaseConfig{
-		URL:      strings.Replace(string(databaseSecret.Data["database_uri"]), "sslmode=verify-ca", "sslmode=require", -1),
+		URL: strings.NewReplacer("sslmode=verify-ca", "sslmode=require", "sslmode=verify-full", "sslmode=require").
+			Replace(string(databaseSecret.Data["database_uri"])),
 		Dialect:  database.PostgresDialect,
 		PoolSize: 5,
 	})
@@ -197,6 +197,73 @@ var _ = Describe("Nonk8s API Server", Ordered, func() {
 		Expect(w2.Body.String()).Should(MatchJSON(
 			fmt.Sprintf(managedClusterListFormatStr, mc1, mc2)))
 
+		By("Check the managedclusters can be listed with hub and status")
+		wh := httptest.NewRecorder()
+		reqh, err := http.NewRequest("GET",
+			"/global-hub-api/v1/managedclusters?hub=hub1&status=Unknown", nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(wh, reqh)
+		Expect(wh.Code).To(Equal(200))
+		Expect(wh.Body.String()).Should(MatchJSON(
+			fmt.Sprintf(managedClusterListFormatStr, mc1, mc2)))
+
+		for _, query := range []string{"hub=hub2", "status=Available", "hub=hub2,hub3&status=Unknown"} {
+			wf := httptest.NewRecorder()
+			reqf, err := http.NewRequest("GET", "/global-hub-api/v1/managedclusters?"+query, nil)
+			Expect(err).ToNot(HaveOccurred())
+			router.ServeHTTP(wf, reqf)
+			Expect(wf.Code).To(Equal(200))
+			Expect(wf.Body.String()).Should(MatchJSON(`
+			{
+			"kind": "ManagedClusterList",
+			"apiVersion": "cluster.open-cluster-management.io/v1",
+			"metadata": {},
+			"items": []
+			}`))
+		}
+
+		wi := httptest.NewRecorder()
+		reqi, err := http.NewRequest("GET", "/global-hub-api/v1/managedclusters?status=Ready", nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(wi, reqi)
+		Expect(wi.Code).To(Equal(400))
+
+		By("Check the managedclusters can be paged with sortBy and fields")
+		listPage := func(query string) map[string]interface{} {
+			wp := httptest.NewRecorder()
+			reqp, err := http.NewRequest("GET", "/global-hub-api/v1/managedclusters?"+query, nil)
+			Expect(err).ToNot(HaveOccurred())
+			router.ServeHTTP(wp, reqp)
+			Expect(wp.Code).To(Equal(200))
+			page := map[string]interface{}{}
+			Expect(json.Unmarshal(wp.Body.Bytes(), &page)).To(Succeed())
+			return page
+		}
+		page := listPage("limit=1&sortBy=-metadata.name&fields=metadata.name")
+		Expect(page["items"]).To(Equal([]interface{}{map[string]interface{}{
+			"kind":       "ManagedCluster",
+			"apiVersion": "cluster.open-cluster-management.io/v1",
+			"metadata":   map[string]interface{}{"name": "mc2"},
+		}}))
+		nextToken := page["metadata"].(map[string]interface{})["continue"].(string)
+		Expect(nextToken).NotTo(BeEmpty())
+
+		page = listPage("limit=1&sortBy=-metadata.name&fields=metadata.name&continue=" + nextToken)
+		Expect(page["items"]).To(Equal([]interface{}{map[string]interface{}{
+			"kind":       "ManagedCluster",
+			"apiVersion": "cluster.open-cluster-management.io/v1",
+			"metadata":   map[string]interface{}{"name": "mc1"},
+		}}))
+		Expect(page["metadata"]).NotTo(HaveKey("continue"))
+
+		for _, query := range []string{"sortBy=spec.hubAcceptsClient", "limit=a", "continue=" + nextToken} {
+			wp := httptest.NewRecorder()
+			reqp, err := http.NewRequest("GET", "/global-hub-api/v1/managedclusters?"+query, nil)
+			Expect(err).ToNot(HaveOccurred())
+			router.ServeHTTP(wp, reqp)
+			Expect(wp.Code).To(Equal(400))
+		}
+
 		By("Check the managedcclusters can be listed as table")
 		// mclTable := `
 		// {
@@ -0,0 +1,187 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package nonk8sapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+var _ = Describe("ArgoCD applications API", Ordered, func() {
+	var db *gorm.DB
+	var router *gin.Engine
+	hubName := "argocd-hub"
+
+	BeforeAll(func() {
+		var err error
+		err = database.InitGormInstance(&database.DatabaseConfig{
+			URL:        testPostgres.URI,
+			Dialect:    database.PostgresDialect,
+			CaCertPath: "ca-cert-path",
+			PoolSize:   2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		db = database.GetGorm()
+
+		router, err = nonk8sapi.SetupRouter(&nonk8sapi.NonK8sAPIServerConfig{
+			ServerBasePath: "/global-hub-api/v1",
+			ClusterAPIURL:  testAuthServer.URL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create a healthy application and a degraded multi-source application")
+		err = db.Exec(`INSERT INTO status.argocd_applications (id,leaf_hub_name,payload) VALUES (?, ?, ?), (?, ?, ?);`,
+			uuid.New().String(), hubName, `{"kind":"Application","metadata":{"name":"guestbook",
+			"namespace":"openshift-gitops"},"spec":{"project":"default","source":{"repoURL":
+			"https://github.com/argoproj/argocd-example-apps.git","path":"guestbook","targetRevision":"HEAD"},
+			"destination":{"server":"https://kubernetes.default.svc","namespace":"guestbook"}},"status":{"health":
+			{"status":"Healthy"},"sync":{"status":"Synced","revision":"53e28ff"},"operationState":{"phase":
+			"Succeeded"},"reconciledAt":"2024-05-21T02:00:00Z"}}`,
+			uuid.New().String(), hubName, `{"kind":"Application","metadata":{"name":"monitoring",
+			"namespace":"openshift-gitops"},"spec":{"project":"infra","sources":[{"repoURL":
+			"https://prometheus-community.github.io/helm-charts","chart":"prometheus","targetRevision":"25.0.0"}],
+			"destination":{"name":"in-cluster","namespace":"monitoring"}},"status":{"health":{"status":"Degraded"},
+			"sync":{"status":"OutOfSync","revisions":["25.0.0"]},"operationState":{"phase":"Failed",
+			"message":"one or more objects failed to apply"}}}`).Error
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	get := func(url string, expectedCode int) map[string]interface{} {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(expectedCode))
+
+		result := map[string]interface{}{}
+		if expectedCode == http.StatusOK {
+			Expect(json.Unmarshal(w.Body.Bytes(), &result)).To(Succeed())
+		}
+		return result
+	}
+
+	It("Should list the applications with the summary of the status", func() {
+		list := get("/global-hub-api/v1/argocdapplications?leafHubName="+hubName, http.StatusOK)
+		Expect(list["applications"]).To(HaveLen(2))
+		Expect(list["healthSummary"]).To(HaveKeyWithValue("Healthy", BeEquivalentTo(1)))
+		Expect(list["healthSummary"]).To(HaveKeyWithValue("Degraded", BeEquivalentTo(1)))
+		Expect(list["syncSummary"]).To(HaveKeyWithValue("OutOfSync", BeEquivalentTo(1)))
+
+		application := list["applications"].([]interface{})[0].(map[string]interface{})
+		Expect(application).To(HaveKeyWithValue("name", "guestbook"))
+		Expect(application).To(HaveKeyWithValue("repoURL", "https://github.com/argoproj/argocd-example-apps.git"))
+		Expect(application).To(HaveKeyWithValue("destinationServer", "https://kubernetes.default.svc"))
+		Expect(application).To(HaveKeyWithValue("revision", "53e28ff"))
+		Expect(application).To(HaveKeyWithValue("reconciledAt", "2024-05-21T02:00:00Z"))
+	})
+
+	It("Should list the applications of the status", func() {
+		list := get("/global-hub-api/v1/argocdapplications?leafHubName="+hubName+"&health=Degraded", http.StatusOK)
+		Expect(list["applications"]).To(HaveLen(1))
+		application := list["applications"].([]interface{})[0].(map[string]interface{})
+		Expect(application).To(HaveKeyWithValue("name", "monitoring"))
+		Expect(application).To(HaveKeyWithValue("path", "prometheus"))
+		Expect(application).To(HaveKeyWithValue("destinationServer", "in-cluster"))
+		Expect(application).To(HaveKeyWithValue("revision", "25.0.0"))
+		Expect(application).To(HaveKeyWithValue("operationPhase", "Failed"))
+
+		list = get("/global-hub-api/v1/argocdapplications?leafHubName="+hubName+"&sync=Unknown", http.StatusOK)
+		Expect(list["applications"]).To(BeEmpty())
+	})
+})
+
+var _ = Describe("ArgoCD applicationsets API", Ordered, func() {
+	var db *gorm.DB
+	var router *gin.Engine
+	applicationSetID := uuid.New().String()
+
+	BeforeAll(func() {
+		var err error
+		err = database.InitGormInstance(&database.DatabaseConfig{
+			URL:        testPostgres.URI,
+			Dialect:    database.PostgresDialect,
+			CaCertPath: "ca-cert-path",
+			PoolSize:   2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		db = database.GetGorm()
+
+		router, err = nonk8sapi.SetupRouter(&nonk8sapi.NonK8sAPIServerConfig{
+			ServerBasePath: "/global-hub-api/v1",
+			ClusterAPIURL:  testAuthServer.URL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create a global applicationset and a deleted one")
+		err = db.Exec(`INSERT INTO spec.applicationsets (id,payload,deleted) VALUES (?, ?, false), (?, ?, true);`,
+			applicationSetID, `{"kind":"ApplicationSet","metadata":{"name":"guestbook","namespace":"openshift-gitops"}}`,
+			uuid.New().String(), `{"kind":"ApplicationSet","metadata":{"name":"legacy","namespace":"openshift-gitops"}}`,
+		).Error
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Report the applicationset from a healthy hub, a progressing hub and a failed hub")
+		err = db.Exec(`INSERT INTO status.argocd_applicationsets (id,leaf_hub_name,payload) VALUES (?, ?, ?),
+			(?, ?, ?), (?, ?, ?);`,
+			applicationSetID, "appset-hub1", `{"status":{"resources":[{"name":"guestbook-cluster1","health":
+			{"status":"Healthy"},"status":"Synced"},{"name":"guestbook-cluster2","health":{"status":"Healthy"},
+			"status":"Synced"}]}}`,
+			applicationSetID, "appset-hub2", `{"status":{"resources":[{"name":"guestbook-cluster3","health":
+			{"status":"Progressing"},"status":"OutOfSync"}]}}`,
+			applicationSetID, "appset-hub3", `{"status":{"conditions":[{"type":"ErrorOccurred","status":"True",
+			"message":"failed to get the clusters"}]}}`).Error
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	list := func(url string) []map[string]interface{} {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		result := []map[string]interface{}{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &result)).To(Succeed())
+		return result
+	}
+
+	It("Should list the rollout status of the applicationset on the hubs", func() {
+		applicationSets := list("/global-hub-api/v1/argocdapplicationsets")
+		Expect(applicationSets).To(HaveLen(1))
+		Expect(applicationSets[0]).To(HaveKeyWithValue("id", applicationSetID))
+		Expect(applicationSets[0]).To(HaveKeyWithValue("name", "guestbook"))
+		Expect(applicationSets[0]["rolloutSummary"]).To(HaveKeyWithValue("Healthy", BeEquivalentTo(1)))
+		Expect(applicationSets[0]["rolloutSummary"]).To(HaveKeyWithValue("Progressing", BeEquivalentTo(1)))
+		Expect(applicationSets[0]["rolloutSummary"]).To(HaveKeyWithValue("Error", BeEquivalentTo(1)))
+
+		hubs := applicationSets[0]["hubs"].([]interface{})
+		Expect(hubs).To(HaveLen(3))
+		hub := hubs[0].(map[string]interface{})
+		Expect(hub).To(HaveKeyWithValue("leafHubName", "appset-hub1"))
+		Expect(hub).To(HaveKeyWithValue("applications", BeEquivalentTo(2)))
+		Expect(hub).To(HaveKeyWithValue("healthy", BeEquivalentTo(2)))
+		Expect(hub).To(HaveKeyWithValue("synced", BeEquivalentTo(2)))
+		hub = hubs[2].(map[string]interface{})
+		Expect(hub).To(HaveKeyWithValue("status", "Error"))
+		Expect(hub).To(HaveKeyWithValue("message", "failed to get the clusters"))
+	})
+
+	It("Should list the rollout status of the applicationset on the hub", func() {
+		applicationSets := list("/global-hub-api/v1/argocdapplicationsets?leafHubName=appset-hub2")
+		Expect(applicationSets).To(HaveLen(1))
+		hubs := applicationSets[0]["hubs"].([]interface{})
+		Expect(hubs).To(HaveLen(1))
+		Expect(hubs[0]).To(HaveKeyWithValue("status", "Progressing"))
+		Expect(hubs[0]).To(HaveKeyWithValue("synced", BeEquivalentTo(0)))
+	})
+})
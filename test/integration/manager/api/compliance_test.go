@@ -0,0 +1,157 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package nonk8sapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+var _ = Describe("Policy compliance API", Ordered, func() {
+	var db *gorm.DB
+	var router *gin.Engine
+	hubName := "compliance-hub"
+	cluster1ID := uuid.New().String()
+	cluster2ID := uuid.New().String()
+	policyID := uuid.New().String()
+	now := time.Now().UTC()
+
+	BeforeAll(func() {
+		var err error
+		err = database.InitGormInstance(&database.DatabaseConfig{
+			URL:        testPostgres.URI,
+			Dialect:    database.PostgresDialect,
+			CaCertPath: "ca-cert-path",
+			PoolSize:   2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		db = database.GetGorm()
+
+		router, err = nonk8sapi.SetupRouter(&nonk8sapi.NonK8sAPIServerConfig{
+			ServerBasePath: "/global-hub-api/v1",
+			ClusterAPIURL:  testAuthServer.URL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create the policy, the compliance of two clusters and the compliance history of yesterday")
+		err = db.Exec(`INSERT INTO local_spec.policies (policy_id,leaf_hub_name,payload) VALUES (?, ?, ?);`,
+			policyID, hubName, `{"kind":"Policy","metadata":{"name":"compliance-policy","namespace":"default"}}`).Error
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Exec(`INSERT INTO local_status.compliance (policy_id,cluster_id,cluster_name,leaf_hub_name,
+			compliance,error) VALUES (?, ?, 'cluster1', ?, 'compliant', 'none'), (?, ?, 'cluster2', ?,
+			'non_compliant', 'none');`, policyID, cluster1ID, hubName, policyID, cluster2ID, hubName).Error
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Exec(`INSERT INTO history.local_compliance (policy_id,cluster_id,leaf_hub_name,compliance,
+			compliance_date) VALUES (?, ?, ?, 'compliant', CURRENT_DATE - INTERVAL '1 day'), (?, ?, ?,
+			'compliant', CURRENT_DATE - INTERVAL '1 day');`,
+			policyID, cluster1ID, hubName, policyID, cluster2ID, hubName).Error
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	get := func(url string, expectedCode int) map[string]interface{} {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(expectedCode))
+
+		result := map[string]interface{}{}
+		if expectedCode == http.StatusOK {
+			Expect(json.Unmarshal(w.Body.Bytes(), &result)).To(Succeed())
+		}
+		return result
+	}
+
+	It("Should list the current compliance of the policies", func() {
+		list := get("/global-hub-api/v1/compliance?leafHubName="+hubName, http.StatusOK)
+		Expect(list["policies"]).To(HaveLen(1))
+		policy := list["policies"].([]interface{})[0].(map[string]interface{})
+		Expect(policy).To(HaveKeyWithValue("policyName", "compliance-policy"))
+		Expect(policy).To(HaveKeyWithValue("compliance", "non_compliant"))
+		Expect(policy["summary"]).To(HaveKeyWithValue("compliant", BeEquivalentTo(1)))
+		Expect(policy["summary"]).To(HaveKeyWithValue("non_compliant", BeEquivalentTo(1)))
+		Expect(policy).NotTo(HaveKey("clusters"))
+	})
+
+	It("Should get the compliance of the policy on each cluster", func() {
+		policy := get("/global-hub-api/v1/compliance/"+policyID, http.StatusOK)
+		Expect(policy["clusters"]).To(HaveLen(2))
+
+		policy = get("/global-hub-api/v1/compliance/"+policyID+"?compliance=non_compliant", http.StatusOK)
+		Expect(policy["clusters"]).To(HaveLen(1))
+		cluster := policy["clusters"].([]interface{})[0].(map[string]interface{})
+		Expect(cluster).To(HaveKeyWithValue("clusterName", "cluster2"))
+	})
+
+	It("Should get the compliance of the policy as of the time", func() {
+		policy := get("/global-hub-api/v1/compliance/"+policyID+"?time="+
+			now.Add(-1*time.Minute).Format(time.RFC3339), http.StatusOK)
+		Expect(policy).To(HaveKeyWithValue("compliance", "compliant"))
+		Expect(policy["summary"]).To(HaveKeyWithValue("compliant", BeEquivalentTo(2)))
+	})
+
+	It("Should get the compliance trend from the rollups", func() {
+		err := db.Exec(`INSERT INTO history.compliance_rollups (period,period_start,leaf_hub_name,policy_id,compliant,
+			non_compliant) VALUES ('week', '2024-05-20', ?, ?, 1, 1), ('week', '2024-05-27', ?, ?, 2, 0),
+			('day', '2024-05-27', ?, ?, 2, 0);`, hubName, policyID, hubName, policyID, hubName, policyID).Error
+		Expect(err).NotTo(HaveOccurred())
+
+		trend := get("/global-hub-api/v1/compliancetrend?period=week&from=2024-05-01&to=2024-06-01&leafHubName="+
+			hubName, http.StatusOK)
+		Expect(trend).To(HaveKeyWithValue("period", "week"))
+		Expect(trend["points"]).To(HaveLen(2))
+		point := trend["points"].([]interface{})[0].(map[string]interface{})
+		Expect(point).To(HaveKeyWithValue("periodStart", "2024-05-20"))
+		Expect(point["summary"]).To(HaveKeyWithValue("non_compliant", BeEquivalentTo(1)))
+
+		trend = get("/global-hub-api/v1/compliancetrend?from=2024-05-01&to=2024-06-01&policyID="+policyID,
+			http.StatusOK)
+		Expect(trend["points"]).To(HaveLen(1))
+
+		get("/global-hub-api/v1/compliancetrend?period=month", http.StatusBadRequest)
+		get("/global-hub-api/v1/compliancetrend?from=2024-06-01&to=2024-05-01", http.StatusBadRequest)
+	})
+
+	It("Should get the compliance percentages of the policies and the managed hubs", func() {
+		err := db.Exec(`INSERT INTO history.compliance_percentages (compliance_date,scope,scope_key,compliant,total,
+			percentage) VALUES ('2024-05-27', 'policy', ?, 1, 2, 50), ('2024-05-28', 'policy', ?, 2, 2, 100),
+			('2024-05-28', 'hub', ?, 2, 2, 100);`, policyID, policyID, hubName).Error
+		Expect(err).NotTo(HaveOccurred())
+
+		percentages := get("/global-hub-api/v1/compliancepercentages?from=2024-05-01&to=2024-06-01&policyID="+
+			policyID, http.StatusOK)
+		Expect(percentages).To(HaveKeyWithValue("groupBy", "policy"))
+		Expect(percentages["series"]).To(HaveLen(1))
+		series := percentages["series"].([]interface{})[0].(map[string]interface{})
+		Expect(series).To(HaveKeyWithValue("key", policyID))
+		Expect(series["points"]).To(HaveLen(2))
+		point := series["points"].([]interface{})[0].(map[string]interface{})
+		Expect(point).To(HaveKeyWithValue("date", "2024-05-27"))
+		Expect(point).To(HaveKeyWithValue("percentage", BeEquivalentTo(50)))
+
+		percentages = get("/global-hub-api/v1/compliancepercentages?groupBy=hub&from=2024-05-01&to=2024-06-01",
+			http.StatusOK)
+		Expect(percentages["series"]).To(HaveLen(1))
+
+		get("/global-hub-api/v1/compliancepercentages?groupBy=cluster", http.StatusBadRequest)
+		get("/global-hub-api/v1/compliancepercentages?policyID=invalid", http.StatusBadRequest)
+	})
+
+	It("Should reject the invalid requests", func() {
+		get("/global-hub-api/v1/compliance/"+uuid.New().String(), http.StatusNotFound)
+		get("/global-hub-api/v1/compliance/invalid", http.StatusBadRequest)
+		get("/global-hub-api/v1/compliance?time=yesterday", http.StatusBadRequest)
+	})
+})
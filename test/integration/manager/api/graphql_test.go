@@ -0,0 +1,105 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package nonk8sapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+var _ = Describe("GraphQL API", Ordered, func() {
+	var db *gorm.DB
+	var router *gin.Engine
+	hubName := "graphql-hub"
+	clusterID := uuid.New().String()
+	policyID := uuid.New().String()
+
+	BeforeAll(func() {
+		var err error
+		err = database.InitGormInstance(&database.DatabaseConfig{
+			URL:        testPostgres.URI,
+			Dialect:    database.PostgresDialect,
+			CaCertPath: "ca-cert-path",
+			PoolSize:   2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		db = database.GetGorm()
+
+		router, err = nonk8sapi.SetupRouter(&nonk8sapi.NonK8sAPIServerConfig{
+			ServerBasePath:       "/global-hub-api/v1",
+			ClusterAPIURL:        testAuthServer.URL,
+			GraphQLMaxDepth:      4,
+			GraphQLMaxComplexity: 1000,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create the cluster, the policy and the compliance of the policy on the cluster")
+		err = db.Exec(`INSERT INTO status.managed_clusters (cluster_id,leaf_hub_name,payload,error) VALUES (?, ?, ?,
+			'none');`, clusterID, hubName, `{"kind":"ManagedCluster","metadata":{"name":"graphql-cluster",
+			"labels":{"env":"dev"}},"status":{"conditions":[{"type":"ManagedClusterConditionAvailable",
+			"status":"True"}]}}`).Error
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Exec(`INSERT INTO local_spec.policies (policy_id,leaf_hub_name,payload) VALUES (?, ?, ?);`,
+			policyID, hubName, `{"kind":"Policy","metadata":{"name":"graphql-policy","namespace":"default"}}`).Error
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Exec(`INSERT INTO local_status.compliance (policy_id,cluster_id,cluster_name,leaf_hub_name,
+			compliance,error) VALUES (?, ?, 'graphql-cluster', ?, 'non_compliant', 'none');`,
+			policyID, clusterID, hubName).Error
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	query := func(body string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/global-hub-api/v1/graphql", bytes.NewBufferString(body))
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+
+		result := map[string]interface{}{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &result)).To(Succeed())
+		return w.Code, result
+	}
+
+	It("Should query the clusters with the compliance and the policies in one request", func() {
+		code, result := query(`{
+			"query": "query ($hub: String) { managedClusters(hub: $hub) { name labels available compliance { compliance policy { name namespace } } } }",
+			"variables": {"hub": "graphql-hub"}
+		}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(result).NotTo(HaveKey("errors"))
+		data := result["data"].(map[string]interface{})
+		Expect(data["managedClusters"]).To(HaveLen(1))
+		cluster := data["managedClusters"].([]interface{})[0].(map[string]interface{})
+		Expect(cluster).To(HaveKeyWithValue("name", "graphql-cluster"))
+		Expect(cluster).To(HaveKeyWithValue("labels", map[string]interface{}{"env": "dev"}))
+		Expect(cluster).To(HaveKeyWithValue("available", "True"))
+		Expect(cluster["compliance"]).To(HaveLen(1))
+		compliance := cluster["compliance"].([]interface{})[0].(map[string]interface{})
+		Expect(compliance).To(HaveKeyWithValue("compliance", "non_compliant"))
+		Expect(compliance).To(HaveKeyWithValue("policy", map[string]interface{}{
+			"name":      "graphql-policy",
+			"namespace": "default",
+		}))
+	})
+
+	It("Should reject the queries over the limits", func() {
+		code, result := query(`{"query": "{ policies { compliance { cluster { compliance { compliance } } } } }"}`)
+		Expect(code).To(Equal(http.StatusBadRequest))
+		Expect(result["errors"]).To(ConsistOf(HaveKeyWithValue("message", ContainSubstring("depth"))))
+
+		code, result = query(`{"query": "{ policies(limit: 100) { compliance(limit: 100) { compliance } } }"}`)
+		Expect(code).To(Equal(http.StatusBadRequest))
+		Expect(result["errors"]).To(ConsistOf(HaveKeyWithValue("message", ContainSubstring("complexity"))))
+	})
+})
@@ -0,0 +1,125 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package nonk8sapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+type lifecycleFeed struct {
+	Events []struct {
+		Sequence        int64  `json:"sequence"`
+		LeafHubName     string `json:"leafHubName"`
+		ClusterName     string `json:"clusterName"`
+		Type            string `json:"type"`
+		Version         string `json:"version"`
+		PreviousVersion string `json:"previousVersion"`
+	} `json:"events"`
+	LastSequence int64 `json:"lastSequence"`
+}
+
+var _ = Describe("Managed cluster lifecycle API", Ordered, func() {
+	var db *gorm.DB
+	var router *gin.Engine
+	var start int64
+	hub := "lifecycle-hub1"
+	clusterID := uuid.New().String()
+
+	BeforeAll(func() {
+		var err error
+		err = database.InitGormInstance(&database.DatabaseConfig{
+			URL:        testPostgres.URI,
+			Dialect:    database.PostgresDialect,
+			CaCertPath: "ca-cert-path",
+			PoolSize:   2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		db = database.GetGorm()
+
+		router, err = nonk8sapi.SetupRouter(&nonk8sapi.NonK8sAPIServerConfig{
+			ServerBasePath: "/global-hub-api/v1",
+			ClusterAPIURL:  testAuthServer.URL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = db.Raw(`SELECT COALESCE(MAX(sequence), 0) FROM event.cluster_lifecycle`).Row().Scan(&start)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Import and upgrade the cluster, and create another cluster")
+		err = db.Exec(`INSERT INTO event.cluster_lifecycle (leaf_hub_name,cluster_id,cluster_name,event_type,version,
+			previous_version,created_at) VALUES
+			(?, ?, 'lifecycle-cluster1', 'Imported', '4.14.10', '', now() - interval '3 minutes'),
+			(?, ?, 'lifecycle-cluster2', 'Created', '4.15.2', '', now() - interval '2 minutes'),
+			(?, ?, 'lifecycle-cluster1', 'Upgraded', '4.15.2', '4.14.10', now() - interval '1 minute');`,
+			hub, clusterID, hub, uuid.New().String(), hub, clusterID).Error
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Detach the cluster just now, the event isn't settled yet")
+		err = db.Exec(`INSERT INTO event.cluster_lifecycle (leaf_hub_name,cluster_id,cluster_name,event_type,version)
+			VALUES (?, ?, 'lifecycle-cluster1', 'Detached', '4.15.2');`, hub, clusterID).Error
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	get := func(url string, expectedCode int, result interface{}) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(expectedCode))
+
+		if expectedCode == http.StatusOK {
+			Expect(json.Unmarshal(w.Body.Bytes(), result)).To(Succeed())
+		}
+	}
+
+	It("Should read the settled events in the order of the sequence", func() {
+		feed := lifecycleFeed{}
+		get("/global-hub-api/v1/managedclusterlifecycle?leafHubName="+hub, http.StatusOK, &feed)
+		Expect(feed.Events).To(HaveLen(3))
+		Expect(feed.Events[0].Type).To(Equal("Imported"))
+		Expect(feed.Events[1].Type).To(Equal("Created"))
+		Expect(feed.Events[2].Type).To(Equal("Upgraded"))
+		Expect(feed.Events[2].PreviousVersion).To(Equal("4.14.10"))
+		Expect(feed.LastSequence).To(Equal(feed.Events[2].Sequence))
+	})
+
+	It("Should page the feed with the last sequence", func() {
+		feed := lifecycleFeed{}
+		get("/global-hub-api/v1/managedclusterlifecycle?limit=2&leafHubName="+hub+"&after="+
+			strconv.FormatInt(start, 10), http.StatusOK, &feed)
+		Expect(feed.Events).To(HaveLen(2))
+
+		next := lifecycleFeed{}
+		get("/global-hub-api/v1/managedclusterlifecycle?limit=2&leafHubName="+hub+"&after="+
+			strconv.FormatInt(feed.LastSequence, 10), http.StatusOK, &next)
+		Expect(next.Events).To(HaveLen(1))
+		Expect(next.Events[0].Type).To(Equal("Upgraded"))
+		Expect(next.Events[0].Sequence).To(BeNumerically(">", feed.LastSequence))
+	})
+
+	It("Should filter the events of the cluster", func() {
+		feed := lifecycleFeed{}
+		get("/global-hub-api/v1/managedclusterlifecycle?cluster=lifecycle-cluster2", http.StatusOK, &feed)
+		Expect(feed.Events).To(HaveLen(1))
+		Expect(feed.Events[0].Type).To(Equal("Created"))
+	})
+
+	It("Should return the error for the invalid cursor or limit", func() {
+		get("/global-hub-api/v1/managedclusterlifecycle?after=abc", http.StatusBadRequest, nil)
+		get("/global-hub-api/v1/managedclusterlifecycle?limit=0", http.StatusBadRequest, nil)
+		get("/global-hub-api/v1/managedclusterlifecycle?limit=10000", http.StatusBadRequest, nil)
+	})
+})
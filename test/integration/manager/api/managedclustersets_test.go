@@ -0,0 +1,127 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package nonk8sapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+var _ = Describe("Managed cluster sets API", Ordered, func() {
+	var db *gorm.DB
+	var router *gin.Engine
+	hub1 := "clusterset-hub1"
+	hub2 := "clusterset-hub2"
+
+	BeforeAll(func() {
+		var err error
+		err = database.InitGormInstance(&database.DatabaseConfig{
+			URL:        testPostgres.URI,
+			Dialect:    database.PostgresDialect,
+			CaCertPath: "ca-cert-path",
+			PoolSize:   2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		db = database.GetGorm()
+
+		router, err = nonk8sapi.SetupRouter(&nonk8sapi.NonK8sAPIServerConfig{
+			ServerBasePath: "/global-hub-api/v1",
+			ClusterAPIURL:  testAuthServer.URL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create the production set on both hubs, it's selected by the set label on hub1 and the env label on hub2")
+		err = db.Exec(`INSERT INTO status.managed_cluster_sets (id,leaf_hub_name,payload) VALUES (?, ?, ?),
+			(?, ?, ?), (?, ?, ?);`,
+			uuid.New().String(), hub1, `{"kind":"ManagedClusterSet","metadata":{"name":"production"},"spec":
+			{"clusterSelector":{"selectorType":"ExclusiveClusterSetLabel"}}}`,
+			uuid.New().String(), hub1, `{"kind":"ManagedClusterSet","metadata":{"name":"global"},"spec":
+			{"clusterSelector":{"selectorType":"LabelSelector","labelSelector":{}}}}`,
+			uuid.New().String(), hub2, `{"kind":"ManagedClusterSet","metadata":{"name":"production"},"spec":
+			{"clusterSelector":{"selectorType":"LabelSelector","labelSelector":{"matchLabels":{"env":"prod"}}}}}`,
+		).Error
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Bind the production set to the namespace of hub1")
+		err = db.Exec(`INSERT INTO status.managed_cluster_set_bindings (id,leaf_hub_name,payload) VALUES (?, ?, ?);`,
+			uuid.New().String(), hub1, `{"kind":"ManagedClusterSetBinding","metadata":{"name":"production",
+			"namespace":"app"},"spec":{"clusterSet":"production"}}`).Error
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create the managed clusters of the hubs")
+		clusters := map[string][]string{
+			hub1: {
+				`{"kind":"ManagedCluster","metadata":{"name":"clusterset-cluster1","labels":
+				{"cluster.open-cluster-management.io/clusterset":"production"}}}`,
+				`{"kind":"ManagedCluster","metadata":{"name":"clusterset-cluster2"}}`,
+			},
+			hub2: {
+				`{"kind":"ManagedCluster","metadata":{"name":"clusterset-cluster3","labels":{"env":"prod"}}}`,
+				`{"kind":"ManagedCluster","metadata":{"name":"clusterset-cluster4","labels":{"env":"dev"}}}`,
+			},
+		}
+		for hub, payloads := range clusters {
+			for _, payload := range payloads {
+				err = db.Exec(`INSERT INTO status.managed_clusters (cluster_id,leaf_hub_name,payload,error)
+					VALUES (?, ?, ?, 'none');`, uuid.New().String(), hub, payload).Error
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+	})
+
+	get := func(url string, expectedCode int, result interface{}) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(expectedCode))
+
+		if expectedCode == http.StatusOK {
+			Expect(json.Unmarshal(w.Body.Bytes(), result)).To(Succeed())
+		}
+	}
+
+	It("Should list the cluster sets with the members of all the hubs", func() {
+		clusterSets := []map[string]interface{}{}
+		get("/global-hub-api/v1/managedclustersets", http.StatusOK, &clusterSets)
+		Expect(clusterSets).To(HaveLen(2))
+
+		Expect(clusterSets[0]).To(HaveKeyWithValue("name", "global"))
+		Expect(clusterSets[0]).To(HaveKeyWithValue("clusterCount", BeEquivalentTo(2)))
+
+		production := clusterSets[1]
+		Expect(production).To(HaveKeyWithValue("name", "production"))
+		Expect(production).To(HaveKeyWithValue("clusterCount", BeEquivalentTo(2)))
+		hubs := production["hubs"].([]interface{})
+		Expect(hubs).To(HaveLen(2))
+		Expect(hubs[0]).To(HaveKeyWithValue("leafHubName", hub1))
+		Expect(hubs[0]).To(HaveKeyWithValue("selectorType", "ExclusiveClusterSetLabel"))
+		Expect(hubs[0]).To(HaveKeyWithValue("clusters", []interface{}{"clusterset-cluster1"}))
+		Expect(hubs[0]).To(HaveKeyWithValue("boundNamespaces", []interface{}{"app"}))
+		Expect(hubs[1]).To(HaveKeyWithValue("leafHubName", hub2))
+		Expect(hubs[1]).To(HaveKeyWithValue("selectorType", "LabelSelector"))
+		Expect(hubs[1]).To(HaveKeyWithValue("clusters", []interface{}{"clusterset-cluster3"}))
+		Expect(hubs[1]).To(HaveKeyWithValue("boundNamespaces", BeEmpty()))
+	})
+
+	It("Should get the cluster set of the hub", func() {
+		production := map[string]interface{}{}
+		get("/global-hub-api/v1/managedclusterset/production?leafHubName="+hub2, http.StatusOK, &production)
+		Expect(production).To(HaveKeyWithValue("clusterCount", BeEquivalentTo(1)))
+		Expect(production["hubs"]).To(HaveLen(1))
+
+		get("/global-hub-api/v1/managedclusterset/global?leafHubName="+hub2, http.StatusNotFound, nil)
+		get("/global-hub-api/v1/managedclusterset/unknown", http.StatusNotFound, nil)
+	})
+})
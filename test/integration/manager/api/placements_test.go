@@ -0,0 +1,140 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package nonk8sapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+var _ = Describe("Placement explanation API", Ordered, func() {
+	var db *gorm.DB
+	var router *gin.Engine
+	hub1 := "placement-hub1"
+	hub2 := "placement-hub2"
+
+	BeforeAll(func() {
+		var err error
+		err = database.InitGormInstance(&database.DatabaseConfig{
+			URL:        testPostgres.URI,
+			Dialect:    database.PostgresDialect,
+			CaCertPath: "ca-cert-path",
+			PoolSize:   2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		db = database.GetGorm()
+
+		router, err = nonk8sapi.SetupRouter(&nonk8sapi.NonK8sAPIServerConfig{
+			ServerBasePath: "/global-hub-api/v1",
+			ClusterAPIURL:  testAuthServer.URL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create the placement of the production set on both hubs")
+		placement := `{"kind":"Placement","metadata":{"name":"placement1","namespace":"app"},"spec":
+			{"clusterSets":["production"],"predicates":[{"requiredClusterSelector":{"labelSelector":
+			{"matchLabels":{"env":"prod"}}}}],"prioritizerPolicy":{"mode":"Exact","configurations":[{
+			"scoreCoordinate":{"type":"AddOn","addOn":{"resourceName":"resource-usage","scoreName":"cpu"}},
+			"weight":2}]}}}`
+		err = db.Exec(`INSERT INTO local_spec.placements (id,leaf_hub_name,payload) VALUES (?, ?, ?), (?, ?, ?);`,
+			uuid.New().String(), hub1, placement, uuid.New().String(), hub2, placement).Error
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Select the cluster of hub1 by the placement")
+		err = db.Exec(`INSERT INTO local_status.placementdecisions (id,leaf_hub_name,payload) VALUES (?, ?, ?);`,
+			uuid.New().String(), hub1, `{"kind":"PlacementDecision","metadata":{"name":"placement1-decision-1",
+			"namespace":"app","labels":{"cluster.open-cluster-management.io/placement":"placement1"}},
+			"status":{"decisions":[{"clusterName":"placement-cluster1","reason":""}]}}`).Error
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create the production set and bind it to the namespace of the placement on both hubs")
+		for _, hub := range []string{hub1, hub2} {
+			err = db.Exec(`INSERT INTO status.managed_cluster_sets (id,leaf_hub_name,payload) VALUES (?, ?, ?);`,
+				uuid.New().String(), hub, `{"kind":"ManagedClusterSet","metadata":{"name":"production"},"spec":
+				{"clusterSelector":{"selectorType":"ExclusiveClusterSetLabel"}}}`).Error
+			Expect(err).NotTo(HaveOccurred())
+			err = db.Exec(`INSERT INTO status.managed_cluster_set_bindings (id,leaf_hub_name,payload)
+				VALUES (?, ?, ?);`, uuid.New().String(), hub, `{"kind":"ManagedClusterSetBinding","metadata":
+				{"name":"production","namespace":"app"},"spec":{"clusterSet":"production"}}`).Error
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		By("Create the managed clusters, the cluster of hub2 isn't in the production set")
+		err = db.Exec(`INSERT INTO status.managed_clusters (cluster_id,leaf_hub_name,payload,error)
+			VALUES (?, ?, ?, 'none'), (?, ?, ?, 'none');`,
+			uuid.New().String(), hub1, `{"kind":"ManagedCluster","metadata":{"name":"placement-cluster1","labels":
+			{"cluster.open-cluster-management.io/clusterset":"production","env":"prod"}}}`,
+			uuid.New().String(), hub2, `{"kind":"ManagedCluster","metadata":{"name":"placement-cluster1","labels":
+			{"cluster.open-cluster-management.io/clusterset":"dev","env":"prod"}}}`,
+		).Error
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create the addon placement score of the cluster of hub1")
+		err = db.Exec(`INSERT INTO status.addon_placement_scores (id,leaf_hub_name,payload) VALUES (?, ?, ?);`,
+			uuid.New().String(), hub1, `{"kind":"AddOnPlacementScore","metadata":{"name":"resource-usage",
+			"namespace":"placement-cluster1"},"status":{"scores":[{"name":"cpu","value":80}]}}`).Error
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	get := func(url string, expectedCode int, result interface{}) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(expectedCode))
+
+		if expectedCode == http.StatusOK {
+			Expect(json.Unmarshal(w.Body.Bytes(), result)).To(Succeed())
+		}
+	}
+
+	It("Should explain the placement on all the hubs of the cluster", func() {
+		explanations := []map[string]interface{}{}
+		get("/global-hub-api/v1/placement/app/placement1/explanation?cluster=placement-cluster1", http.StatusOK,
+			&explanations)
+		Expect(explanations).To(HaveLen(2))
+
+		Expect(explanations[0]).To(HaveKeyWithValue("leafHubName", hub1))
+		Expect(explanations[0]).To(HaveKeyWithValue("global", false))
+		Expect(explanations[0]).To(HaveKeyWithValue("selected", true))
+		Expect(explanations[0]).To(HaveKeyWithValue("reason", "the cluster is selected by the placement"))
+		scores := explanations[0]["scores"].([]interface{})
+		Expect(scores).To(HaveLen(1))
+		Expect(scores[0]).To(HaveKeyWithValue("prioritizer", "resource-usage/cpu"))
+		Expect(scores[0]).To(HaveKeyWithValue("weight", BeEquivalentTo(2)))
+		Expect(scores[0]).To(HaveKeyWithValue("score", BeEquivalentTo(80)))
+
+		Expect(explanations[1]).To(HaveKeyWithValue("leafHubName", hub2))
+		Expect(explanations[1]).To(HaveKeyWithValue("selected", false))
+		Expect(explanations[1]).To(HaveKeyWithValue("reason",
+			"the cluster isn't a member of the cluster sets production"))
+		steps := explanations[1]["steps"].([]interface{})
+		Expect(steps[0]).To(HaveKeyWithValue("name", "ClusterSets"))
+		Expect(steps[0]).To(HaveKeyWithValue("passed", false))
+	})
+
+	It("Should explain the placement of the hub", func() {
+		explanations := []map[string]interface{}{}
+		get("/global-hub-api/v1/placement/app/placement1/explanation?cluster=placement-cluster1&leafHubName="+hub2,
+			http.StatusOK, &explanations)
+		Expect(explanations).To(HaveLen(1))
+		Expect(explanations[0]).To(HaveKeyWithValue("leafHubName", hub2))
+	})
+
+	It("Should return the error if the placement or the cluster isn't found", func() {
+		get("/global-hub-api/v1/placement/app/placement1/explanation", http.StatusBadRequest, nil)
+		get("/global-hub-api/v1/placement/app/unknown/explanation?cluster=placement-cluster1", http.StatusNotFound, nil)
+		get("/global-hub-api/v1/placement/app/placement1/explanation?cluster=unknown", http.StatusNotFound, nil)
+	})
+})
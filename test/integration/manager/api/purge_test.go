@@ -0,0 +1,197 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package nonk8sapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+var _ = Describe("Data purge API", Ordered, func() {
+	var db *gorm.DB
+	var router *gin.Engine
+	hubName := "purge-hub"
+	clusterID := uuid.New().String()
+	clusterName := "purge-cluster"
+
+	BeforeAll(func() {
+		var err error
+		err = database.InitGormInstance(&database.DatabaseConfig{
+			URL:        testPostgres.URI,
+			Dialect:    database.PostgresDialect,
+			CaCertPath: "ca-cert-path",
+			PoolSize:   2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		db = database.GetGorm()
+
+		router, err = nonk8sapi.SetupRouter(&nonk8sapi.NonK8sAPIServerConfig{
+			ServerBasePath: "/global-hub-api/v1",
+			ClusterAPIURL:  testAuthServer.URL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create the data of the managed cluster")
+		err = db.Exec(`INSERT INTO status.managed_clusters (cluster_id,leaf_hub_name,payload,error)
+			VALUES (?, ?, ?, 'none');`, clusterID, hubName,
+			`{"kind":"ManagedCluster","metadata":{"name":"`+clusterName+`"}}`).Error
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Exec(`INSERT INTO local_status.compliance (policy_id,cluster_name,leaf_hub_name,error,compliance,
+			cluster_id) VALUES (?, ?, ?, 'none', 'compliant', ?);`, uuid.New().String(), clusterName, hubName,
+			clusterID).Error
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should purge the data of the managed cluster with the confirmation token", func() {
+		By("Request the purge")
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/global-hub-api/v1/purges", bytes.NewBufferString(
+			`{"type":"cluster","leafHubName":"`+hubName+`","clusterName":"`+clusterName+`"}`))
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+
+		response := map[string]interface{}{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &response)).To(Succeed())
+		Expect(response["status"]).To(Equal("pending"))
+		purgeID := response["id"].(string)
+		token := response["confirmationToken"].(string)
+		Expect(token).NotTo(BeEmpty())
+
+		By("Confirm the purge with a wrong token")
+		w = httptest.NewRecorder()
+		req, err = http.NewRequest("POST", "/global-hub-api/v1/purge/"+purgeID+"/confirm",
+			bytes.NewBufferString(`{"token":"invalid"}`))
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+
+		By("Confirm the purge with the token")
+		w = httptest.NewRecorder()
+		req, err = http.NewRequest("POST", "/global-hub-api/v1/purge/"+purgeID+"/confirm",
+			bytes.NewBufferString(`{"token":"`+token+`"}`))
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var count int64
+		Expect(db.Raw(`SELECT count(*) FROM status.managed_clusters WHERE cluster_id = ?`, clusterID).
+			Scan(&count).Error).To(Succeed())
+		Expect(count).To(BeZero())
+		Expect(db.Raw(`SELECT count(*) FROM local_status.compliance WHERE cluster_id = ?`, clusterID).
+			Scan(&count).Error).To(Succeed())
+		Expect(count).To(BeZero())
+
+		By("Check the audit record of the purge")
+		purgeLog := &models.DataPurgeLog{}
+		Expect(db.Where("id = ?", purgeID).First(purgeLog).Error).To(Succeed())
+		Expect(purgeLog.Status).To(Equal("completed"))
+		Expect(purgeLog.ConfirmedBy).To(Equal("kube:admin"))
+
+		By("The purge can't be confirmed again")
+		w = httptest.NewRecorder()
+		req, err = http.NewRequest("POST", "/global-hub-api/v1/purge/"+purgeID+"/confirm",
+			bytes.NewBufferString(`{"token":"`+token+`"}`))
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusConflict))
+	})
+
+	It("Should purge the data of the managed hub from all the tables", func() {
+		purgedHub := "purged-hub"
+		purgedClusterID := uuid.New().String()
+		policyID := uuid.New().String()
+
+		By("Create the data of the managed hub, including the status of the global resources")
+		for _, statement := range []string{
+			`INSERT INTO status.leaf_hubs (leaf_hub_name,cluster_id,payload) VALUES (@hub, @cluster, '{}')`,
+			`INSERT INTO status.managed_clusters (cluster_id,leaf_hub_name,payload,error) VALUES (@cluster, @hub,
+				'{"kind":"ManagedCluster","metadata":{"name":"purged-cluster"}}', 'none')`,
+			`INSERT INTO local_spec.policies (policy_id,leaf_hub_name,payload) VALUES (@policy, @hub,
+				'{"kind":"Policy","metadata":{"name":"purged-policy","namespace":"default"}}')`,
+			`INSERT INTO local_status.compliance (policy_id,cluster_id,cluster_name,leaf_hub_name,compliance,error)
+				VALUES (@policy, @cluster, 'purged-cluster', @hub, 'compliant', 'none')`,
+			`INSERT INTO event.local_policies (event_name,policy_id,cluster_id,cluster_name,leaf_hub_name,message,
+				reason,compliance) VALUES ('purged-event', @policy, @cluster, 'purged-cluster', @hub, 'msg', 'reason',
+				'compliant')`,
+			`INSERT INTO status.compliance (policy_id,cluster_id,cluster_name,leaf_hub_name,compliance,error)
+				VALUES (@policy, @cluster, 'purged-cluster', @hub, 'compliant', 'none')`,
+			`INSERT INTO status.aggregated_compliance (policy_id,leaf_hub_name,applied_clusters,
+				non_compliant_clusters) VALUES (@policy, @hub, 1, 0)`,
+			`INSERT INTO status.policysets (id,leaf_hub_name,payload) VALUES (@policy, @hub, '{}')`,
+			`INSERT INTO status.operatorpolicies (id,leaf_hub_name,payload) VALUES (@policy, @hub, '{}')`,
+			`INSERT INTO status.argocd_applicationsets (id,leaf_hub_name,payload) VALUES (@policy, @hub, '{}')`,
+			`INSERT INTO status.placements (id,leaf_hub_name,payload) VALUES (@policy, @hub, '{}')`,
+			`INSERT INTO local_spec.placementrules (id,leaf_hub_name,payload) VALUES (@policy, @hub, '{}')`,
+			`INSERT INTO history.audit_logs (source,verb,resource,request_uri) VALUES ('api', 'PATCH',
+				'/global-hub-api/v1/managedcluster/:clusterID', '/global-hub-api/v1/managedcluster/' || @cluster)`,
+			`INSERT INTO history.audit_logs (source,verb,resource,request_uri) VALUES ('api', 'GET',
+				'/global-hub-api/v1/compliance', '/global-hub-api/v1/compliance?leafHubName=' || @hub || '&limit=10')`,
+			// the audit logs of the other hubs and the global resources are kept
+			`INSERT INTO history.audit_logs (source,verb,resource,request_uri) VALUES ('api', 'GET',
+				'/global-hub-api/v1/compliance', '/global-hub-api/v1/compliance?leafHubName=' || @hub || '-2')`,
+			`INSERT INTO history.audit_logs (source,verb,resource,namespace,name) VALUES ('spec', 'CREATE',
+				'policies.policy.open-cluster-management.io', 'default', @hub)`,
+		} {
+			Expect(db.Exec(statement, map[string]interface{}{
+				"hub": purgedHub, "cluster": purgedClusterID, "policy": policyID,
+			}).Error).To(Succeed())
+		}
+
+		By("Request and confirm the purge of the managed hub")
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/global-hub-api/v1/purges", bytes.NewBufferString(
+			`{"type":"hub","leafHubName":"`+purgedHub+`"}`))
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+
+		response := map[string]interface{}{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &response)).To(Succeed())
+		w = httptest.NewRecorder()
+		req, err = http.NewRequest("POST", "/global-hub-api/v1/purge/"+response["id"].(string)+"/confirm",
+			bytes.NewBufferString(`{"token":"`+response["confirmationToken"].(string)+`"}`))
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		By("Check no table holds the rows of the managed hub except the audit record of the purge")
+		var tables []string
+		Expect(db.Raw(`SELECT c.table_schema || '.' || c.table_name FROM information_schema.columns c
+			JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+			WHERE c.column_name = 'leaf_hub_name' AND t.table_type = 'BASE TABLE'
+				AND NOT (c.table_schema = 'history' AND c.table_name = 'data_purge_log')`).
+			Scan(&tables).Error).To(Succeed())
+		Expect(tables).NotTo(BeEmpty())
+		for _, table := range tables {
+			var count int64
+			Expect(db.Raw(`SELECT count(*) FROM `+table+` WHERE leaf_hub_name = ?`, purgedHub).
+				Scan(&count).Error).To(Succeed())
+			Expect(count).To(BeZero(), "the table %s still holds the rows of the hub", table)
+		}
+
+		By("Check the audit logs of the API calls on the managed hub are purged")
+		var requestURIs []string
+		Expect(db.Raw(`SELECT coalesce(request_uri, name) FROM history.audit_logs
+			WHERE request_uri LIKE '%' || ? || '%' OR name = ?`, purgedHub, purgedHub).
+			Scan(&requestURIs).Error).To(Succeed())
+		Expect(requestURIs).To(ConsistOf("/global-hub-api/v1/compliance?leafHubName="+purgedHub+"-2", purgedHub))
+		var count int64
+		Expect(db.Raw(`SELECT count(*) FROM history.audit_logs WHERE request_uri LIKE '%' || ?`, purgedClusterID).
+			Scan(&count).Error).To(Succeed())
+		Expect(count).To(BeZero())
+	})
+})
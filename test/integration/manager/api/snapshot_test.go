@@ -0,0 +1,157 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package nonk8sapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/nonk8sapi"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+)
+
+var _ = Describe("Fleet snapshot API", Ordered, func() {
+	var db *gorm.DB
+	var router *gin.Engine
+	hubName := "snapshot-hub"
+	clusterID := uuid.New().String()
+	policyID := uuid.New().String()
+	now := time.Now().UTC()
+
+	BeforeAll(func() {
+		var err error
+		err = database.InitGormInstance(&database.DatabaseConfig{
+			URL:        testPostgres.URI,
+			Dialect:    database.PostgresDialect,
+			CaCertPath: "ca-cert-path",
+			PoolSize:   2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		db = database.GetGorm()
+
+		router, err = nonk8sapi.SetupRouter(&nonk8sapi.NonK8sAPIServerConfig{
+			ServerBasePath: "/global-hub-api/v1",
+			ClusterAPIURL:  testAuthServer.URL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Create the cluster, the compliance history of yesterday and the policy event of an hour ago")
+		err = db.Exec(`INSERT INTO status.managed_clusters (cluster_id,leaf_hub_name,payload,error,created_at)
+			VALUES (?, ?, ?, 'none', ?);`, clusterID, hubName,
+			`{"kind":"ManagedCluster","metadata":{"name":"snapshot-cluster"}}`, now.Add(-48*time.Hour)).Error
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Exec(`INSERT INTO history.local_compliance (policy_id,cluster_id,leaf_hub_name,compliance,
+			compliance_date) VALUES (?, ?, ?, 'non_compliant', CURRENT_DATE - INTERVAL '1 day');`,
+			policyID, clusterID, hubName).Error
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Exec(`INSERT INTO event.local_policies (event_name,policy_id,cluster_id,cluster_name,leaf_hub_name,
+			message,reason,compliance,created_at) VALUES ('snapshot-event', ?, ?, 'snapshot-cluster', ?, 'msg',
+			'reason', 'compliant', ?);`, policyID, clusterID, hubName, now.Add(-1*time.Hour)).Error
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	getSnapshot := func(pointInTime time.Time) map[string]interface{} {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/global-hub-api/v1/snapshot?leafHubName="+hubName+"&time="+
+			pointInTime.Format(time.RFC3339), nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		snapshot := map[string]interface{}{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &snapshot)).To(Succeed())
+		return snapshot
+	}
+
+	It("Should return the compliance state as of the time", func() {
+		snapshot := getSnapshot(now.Add(-2 * time.Hour))
+		Expect(snapshot["managedClusters"]).To(HaveLen(1))
+		Expect(snapshot["summary"]).To(HaveKeyWithValue("non_compliant", BeEquivalentTo(1)))
+
+		snapshot = getSnapshot(now)
+		Expect(snapshot["summary"]).To(HaveKeyWithValue("compliant", BeEquivalentTo(1)))
+		Expect(snapshot["summary"]).NotTo(HaveKey("non_compliant"))
+	})
+
+	It("Should not return the compliance state reported after the time on the same day", func() {
+		sameDayHub := "snapshot-same-day-hub"
+		sameDayClusterID := uuid.New().String()
+		midnight := now.Truncate(24 * time.Hour)
+
+		By("Create the compliant history of yesterday and the non compliant event after the midnight of today")
+		err := db.Exec(`INSERT INTO status.managed_clusters (cluster_id,leaf_hub_name,payload,error,created_at)
+			VALUES (?, ?, ?, 'none', ?);`, sameDayClusterID, sameDayHub,
+			`{"kind":"ManagedCluster","metadata":{"name":"same-day-cluster"}}`, now.Add(-48*time.Hour)).Error
+		Expect(err).NotTo(HaveOccurred())
+		err = db.Exec(`INSERT INTO history.local_compliance (policy_id,cluster_id,leaf_hub_name,compliance,
+			compliance_date) VALUES (?, ?, ?, 'compliant', ?::date - INTERVAL '1 day');`,
+			policyID, sameDayClusterID, sameDayHub, midnight).Error
+		Expect(err).NotTo(HaveOccurred())
+		// the event also updates the history of today to non compliant by the trigger
+		err = db.Exec(`INSERT INTO event.local_policies (event_name,policy_id,cluster_id,cluster_name,leaf_hub_name,
+			message,reason,compliance,created_at) VALUES ('same-day-event', ?, ?, 'same-day-cluster', ?, 'msg',
+			'reason', 'non_compliant', ?);`, policyID, sameDayClusterID, sameDayHub, midnight.Add(time.Hour)).Error
+		Expect(err).NotTo(HaveOccurred())
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/global-hub-api/v1/snapshot?leafHubName="+sameDayHub+"&time="+
+			midnight.Format(time.RFC3339), nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		snapshot := map[string]interface{}{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &snapshot)).To(Succeed())
+		Expect(snapshot["summary"]).To(HaveKeyWithValue("compliant", BeEquivalentTo(1)))
+		Expect(snapshot["summary"]).NotTo(HaveKey("non_compliant"))
+	})
+
+	It("Should return the compliance state of the cluster whose latest history is older", func() {
+		staleHub := "snapshot-stale-hub"
+		staleClusterID, freshClusterID := uuid.New().String(), uuid.New().String()
+
+		By("Create the history of 3 days ago of a cluster and the history of yesterday of another cluster")
+		for _, cluster := range []struct{ id, name, compliance, days string }{
+			{staleClusterID, "stale-cluster", "non_compliant", "3"},
+			{freshClusterID, "fresh-cluster", "compliant", "1"},
+		} {
+			err := db.Exec(`INSERT INTO status.managed_clusters (cluster_id,leaf_hub_name,payload,error,created_at)
+				VALUES (?, ?, ?, 'none', ?);`, cluster.id, staleHub,
+				`{"kind":"ManagedCluster","metadata":{"name":"`+cluster.name+`"}}`, now.Add(-96*time.Hour)).Error
+			Expect(err).NotTo(HaveOccurred())
+			err = db.Exec(`INSERT INTO history.local_compliance (policy_id,cluster_id,leaf_hub_name,compliance,
+				compliance_date) VALUES (?, ?, ?, ?, CURRENT_DATE - ?::integer);`,
+				policyID, cluster.id, staleHub, cluster.compliance, cluster.days).Error
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/global-hub-api/v1/snapshot?leafHubName="+staleHub+"&time="+
+			now.Format(time.RFC3339), nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		snapshot := map[string]interface{}{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &snapshot)).To(Succeed())
+		Expect(snapshot["summary"]).To(HaveKeyWithValue("compliant", BeEquivalentTo(1)))
+		Expect(snapshot["summary"]).To(HaveKeyWithValue("non_compliant", BeEquivalentTo(1)))
+	})
+
+	It("Should reject the invalid time", func() {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/global-hub-api/v1/snapshot?time=yesterday", nil)
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+	})
+})
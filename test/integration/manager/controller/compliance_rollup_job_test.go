@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/go-co-op/gocron"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/cronjob/task"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+// go test ./test/integration/manager/controller -v -ginkgo.focus "ComplianceRollup"
+var _ = Describe("ComplianceRollup", Ordered, func() {
+	policyID := "00000000-0000-0000-0000-000000000081"
+	hubName := "rollup-hub"
+
+	getRollups := func(period string) []models.ComplianceRollup {
+		rollups := []models.ComplianceRollup{}
+		Expect(db.Where("leaf_hub_name = ? AND period = ?", hubName, period).Order("period_start").
+			Find(&rollups).Error).To(Succeed())
+		return rollups
+	}
+
+	It("roll up the compliance history into the daily and weekly rollups", func() {
+		By("Create the compliance history of two clusters on the Monday and the Tuesday of the last week")
+		err := db.Exec(`
+		INSERT INTO history.local_compliance (policy_id, cluster_id, leaf_hub_name, compliance, compliance_date)
+		VALUES
+		(?, '00000081-0000-0000-0000-000000000001', ?, 'compliant', date_trunc('week', CURRENT_DATE) - INTERVAL '7 day'),
+		(?, '00000081-0000-0000-0000-000000000002', ?, 'compliant', date_trunc('week', CURRENT_DATE) - INTERVAL '7 day'),
+		(?, '00000081-0000-0000-0000-000000000001', ?, 'compliant', date_trunc('week', CURRENT_DATE) - INTERVAL '6 day'),
+		(?, '00000081-0000-0000-0000-000000000002', ?, 'non_compliant', date_trunc('week', CURRENT_DATE) - INTERVAL '6 day')
+		`, policyID, hubName, policyID, hubName, policyID, hubName, policyID, hubName).Error
+		Expect(err).ToNot(HaveOccurred())
+
+		By("Run the rollup job")
+		s := gocron.NewScheduler(time.UTC)
+		_, err = s.Every(1).Day().DoWithJobDetails(task.ComplianceRollup, ctx)
+		Expect(err).ToNot(HaveOccurred())
+		s.StartAsync()
+		defer s.Clear()
+
+		By("Check the daily rollups")
+		Eventually(func() int {
+			return len(getRollups(database.RollupPeriodDay))
+		}, 10*time.Second, time.Second).Should(Equal(2))
+		daily := getRollups(database.RollupPeriodDay)
+		Expect(daily[0].Compliant).To(Equal(2))
+		Expect(daily[0].NonCompliant).To(Equal(0))
+		Expect(daily[1].Compliant).To(Equal(1))
+		Expect(daily[1].NonCompliant).To(Equal(1))
+
+		By("Check the weekly rollup counts each cluster once by its worst compliance")
+		weekly := getRollups(database.RollupPeriodWeek)
+		Expect(weekly).To(HaveLen(1))
+		Expect(weekly[0].PeriodStart.Weekday()).To(Equal(time.Monday))
+		Expect(weekly[0].PeriodStart.Format(task.DateFormat)).To(Equal(daily[0].PeriodStart.Format(task.DateFormat)))
+		Expect(weekly[0].Compliant).To(Equal(1))
+		Expect(weekly[0].NonCompliant).To(Equal(1))
+
+		By("Check the daily compliance percentages of the policy and the hub")
+		percentages := []models.CompliancePercentage{}
+		Expect(db.Where("scope_key IN ?", []string{policyID, hubName}).Order("compliance_date, scope").
+			Find(&percentages).Error).To(Succeed())
+		Expect(percentages).To(HaveLen(4))
+		Expect(percentages[0].Scope).To(Equal(database.ComplianceScopeHub))
+		Expect(percentages[0].Percentage).To(Equal(float64(100)))
+		Expect(percentages[3].Scope).To(Equal(database.ComplianceScopePolicy))
+		Expect(percentages[3].Compliant).To(Equal(1))
+		Expect(percentages[3].Total).To(Equal(2))
+		Expect(percentages[3].Percentage).To(Equal(float64(50)))
+	})
+
+	It("update the rollups with the history added after the last run", func() {
+		By("Create the compliance history of today")
+		err := db.Exec(`
+		INSERT INTO history.local_compliance (policy_id, cluster_id, leaf_hub_name, compliance, compliance_date)
+		VALUES
+		(?, '00000081-0000-0000-0000-000000000001', ?, 'pending', CURRENT_DATE),
+		(?, '00000081-0000-0000-0000-000000000002', ?, 'compliant', CURRENT_DATE)
+		`, policyID, hubName, policyID, hubName).Error
+		Expect(err).ToNot(HaveOccurred())
+
+		s := gocron.NewScheduler(time.UTC)
+		_, err = s.Every(1).Day().DoWithJobDetails(task.ComplianceRollup, ctx)
+		Expect(err).ToNot(HaveOccurred())
+		s.StartAsync()
+		defer s.Clear()
+
+		By("Check the rollups of today and this week are added")
+		Eventually(func() int {
+			return len(getRollups(database.RollupPeriodWeek))
+		}, 10*time.Second, time.Second).Should(Equal(2))
+		daily := getRollups(database.RollupPeriodDay)
+		Expect(daily).To(HaveLen(3))
+		Expect(daily[2].Pending).To(Equal(1))
+		Expect(daily[2].Compliant).To(Equal(1))
+
+		weekly := getRollups(database.RollupPeriodWeek)
+		Expect(weekly[0].NonCompliant).To(Equal(1))
+		Expect(weekly[1].Pending).To(Equal(1))
+		Expect(weekly[1].Compliant).To(Equal(1))
+	})
+})
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/archive"
+	"github.com/stolostron/multicluster-global-hub/manager/pkg/cronjob/task"
+	"github.com/stolostron/multicluster-global-hub/pkg/database/models"
+)
+
+var _ = Describe("event archive of the data retention job", Ordered, func() {
+	var server *httptest.Server
+	var lock sync.Mutex
+	uploaded := map[string][]byte{}
+	available := false
+
+	now := time.Now()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	retentionMonth := 18
+	archivedMonth := currentMonth.AddDate(0, -retentionMonth-3, 0)
+	partitionName := "local_policies_" + archivedMonth.Format(task.PartitionDateFormat)
+	eventName := "policy-archive." + uuid.New().String()
+
+	BeforeAll(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			if !available {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			uploaded[r.URL.Path] = body
+		}))
+
+		By("Create the expired partition with an event")
+		Expect(createPartitionTable("event.local_policies", archivedMonth)).To(Succeed())
+		err := db.Exec(`INSERT INTO event.local_policies (event_name, policy_id, cluster_id, leaf_hub_name, message,
+			compliance, created_at) VALUES (?, ?, ?, 'hub1', 'the policy is archived', 'non_compliant', ?)`,
+			eventName, uuid.New().String(), uuid.New().String(), archivedMonth.AddDate(0, 0, 1)).Error
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterAll(func() {
+		server.Close()
+	})
+
+	runDataRetention := func() {
+		archiver, err := archive.NewPartitionArchiver(&archive.ArchiveConfig{
+			Bucket:          "archives",
+			Endpoint:        server.URL,
+			AccessKeyID:     "key",
+			SecretAccessKey: "secret",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		s := gocron.NewScheduler(time.UTC)
+		_, err = s.Every(1).Week().DoWithJobDetails(task.DataRetention, ctx,
+			task.RetentionPolicy{Default: retentionMonth, Archiver: archiver})
+		Expect(err).NotTo(HaveOccurred())
+		s.StartAsync()
+		DeferCleanup(s.Clear)
+	}
+
+	partitionExists := func() (bool, error) {
+		exists := false
+		err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_tables WHERE schemaname = 'event' AND tablename = ?)",
+			partitionName).Scan(&exists).Error
+		return exists, err
+	}
+
+	It("should keep the partition if it isn't archived", func() {
+		startTime := time.Now()
+		runDataRetention()
+
+		Eventually(func() error {
+			logs := []models.DataRetentionJobLog{}
+			if err := db.Where("table_name = ? AND end_at > ?", "event.local_policies", startTime).
+				Find(&logs).Error; err != nil {
+				return err
+			}
+			for _, log := range logs {
+				if log.Error != "none" {
+					return nil
+				}
+			}
+			return fmt.Errorf("the failed archival isn't logged")
+		}, 10*time.Second, 1*time.Second).Should(Succeed())
+
+		Expect(partitionExists()).To(BeTrue())
+	})
+
+	It("should archive the partition before it's pruned", func() {
+		lock.Lock()
+		available = true
+		lock.Unlock()
+		runDataRetention()
+
+		Eventually(func() (bool, error) {
+			return partitionExists()
+		}, 10*time.Second, 1*time.Second).Should(BeFalse())
+
+		lock.Lock()
+		defer lock.Unlock()
+		object, ok := uploaded["/archives/multicluster-global-hub-events/event.local_policies/"+partitionName+".csv.gz"]
+		Expect(ok).To(BeTrue())
+		reader, err := gzip.NewReader(bytes.NewReader(object))
+		Expect(err).NotTo(HaveOccurred())
+		content, err := io.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(HavePrefix("event_name,"))
+		Expect(string(content)).To(ContainSubstring(eventName))
+		Expect(string(content)).To(ContainSubstring("the policy is archived"))
+	})
+})
@@ -37,13 +37,28 @@ var _ = Describe("scheduler", func() {
 		managerConfig.SchedulerInterval = "second"
 		Expect(cronjob.AddSchedulerToManager(ctx, mgr, managerConfig, false)).To(Succeed())
 
+		// the schedules override the defaults and the scheduler interval
+		managerConfig.JobSchedules = &config.JobSchedules{
+			ComplianceHistory: "30 23 * * *",
+			ComplianceRollup:  "0 2 * * *",
+			DataRetention:     "0 3 * * 0",
+		}
+		Expect(cronjob.AddSchedulerToManager(ctx, mgr, managerConfig, false)).To(Succeed())
+
+		managerConfig.JobSchedules.DataRetention = "0 25 * * *"
+		err := cronjob.AddSchedulerToManager(ctx, mgr, managerConfig, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(task.RetentionTaskName))
+		managerConfig.JobSchedules = nil
+
 		scheduler := gocron.NewScheduler(time.Local)
-		_, err := scheduler.Every(1).Day().At("00:00").Tag(task.LocalComplianceTaskName).DoWithJobDetails(
+		_, err = scheduler.Every(1).Day().At("00:00").Tag(task.LocalComplianceTaskName).DoWithJobDetails(
 			task.LocalComplianceHistory, ctx)
 		Expect(err).To(Succeed())
 
 		_, err = scheduler.Every(1).Month(1, 15, 28).At("00:00").Tag(task.RetentionTaskName).
-			DoWithJobDetails(task.DataRetention, ctx, managerConfig.DatabaseConfig.DataRetention)
+			DoWithJobDetails(task.DataRetention, ctx,
+				task.RetentionPolicy{Default: managerConfig.DatabaseConfig.DataRetention})
 		Expect(err).To(Succeed())
 
 		globalScheduler := cronjob.NewGlobalHubScheduler(scheduler,
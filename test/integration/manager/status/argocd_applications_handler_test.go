@@ -0,0 +1,112 @@
+package status
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/bundle/generic"
+	eventversion "github.com/stolostron/multicluster-global-hub/pkg/bundle/version"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// go test /test/integration/manager/status -v -ginkgo.focus "ArgoApplicationHandler"
+var _ = Describe("ArgoApplicationHandler", Ordered, func() {
+	leafHubName := "hub1"
+	version := eventversion.NewVersion()
+	application := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata": map[string]interface{}{
+			"name":            "guestbook",
+			"namespace":       "openshift-gitops",
+			"uid":             "4bd8a2c6-64ed-4a4c-9ec4-1d2c7d7a3a11",
+			"resourceVersion": "1",
+		},
+		"spec": map[string]interface{}{
+			"project": "default",
+			"source": map[string]interface{}{
+				"repoURL":        "https://github.com/argoproj/argocd-example-apps.git",
+				"path":           "guestbook",
+				"targetRevision": "HEAD",
+			},
+		},
+		"status": map[string]interface{}{
+			"health": map[string]interface{}{"status": "Healthy"},
+			"sync":   map[string]interface{}{"status": "Synced", "revision": "53e28ff"},
+		},
+	}}
+
+	syncApplications := func(applications ...*unstructured.Unstructured) {
+		version.Incr()
+		data := generic.GenericObjectBundle{}
+		for _, application := range applications {
+			data = append(data, application)
+		}
+		evt := ToCloudEvent(leafHubName, string(enum.ArgoApplicationType), version, data)
+		Expect(producer.SendEvent(ctx, *evt)).Should(Succeed())
+	}
+
+	queryStatus := func() (string, string, error) {
+		var healthStatus, syncStatus string
+		err := database.GetGorm().Raw(fmt.Sprintf(`SELECT health_status, sync_status FROM %s.%s
+			WHERE leaf_hub_name = ? AND name = ?`, database.StatusSchema, database.ArgoApplicationsTableName),
+			leafHubName, application.GetName()).Row().Scan(&healthStatus, &syncStatus)
+		return healthStatus, syncStatus, err
+	}
+
+	It("should be able to sync the argocd application", func() {
+		syncApplications(application)
+
+		Eventually(func() error {
+			healthStatus, syncStatus, err := queryStatus()
+			if err != nil {
+				return err
+			}
+			if healthStatus != "Healthy" || syncStatus != "Synced" {
+				return fmt.Errorf("unexpected status of the application: %s, %s", healthStatus, syncStatus)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+
+	It("should be able to update the status of the argocd application", func() {
+		updated := application.DeepCopy()
+		updated.SetResourceVersion("2")
+		Expect(unstructured.SetNestedField(updated.Object, "Degraded", "status", "health", "status")).To(Succeed())
+		Expect(unstructured.SetNestedField(updated.Object, "OutOfSync", "status", "sync", "status")).To(Succeed())
+		syncApplications(updated)
+
+		Eventually(func() error {
+			healthStatus, syncStatus, err := queryStatus()
+			if err != nil {
+				return err
+			}
+			if healthStatus != "Degraded" || syncStatus != "OutOfSync" {
+				return fmt.Errorf("unexpected status of the application: %s, %s", healthStatus, syncStatus)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+
+	It("should be able to delete the argocd application", func() {
+		syncApplications()
+
+		Eventually(func() error {
+			var count int64
+			err := database.GetGorm().Raw(fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE leaf_hub_name = ?",
+				database.StatusSchema, database.ArgoApplicationsTableName), leafHubName).Scan(&count).Error
+			if err != nil {
+				return err
+			}
+			if count != 0 {
+				return fmt.Errorf("the application isn't deleted")
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,120 @@
+package status
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/bundle/generic"
+	eventversion "github.com/stolostron/multicluster-global-hub/pkg/bundle/version"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// go test /test/integration/manager/status -v -ginkgo.focus "ArgoApplicationSetHandler"
+var _ = Describe("ArgoApplicationSetHandler", Ordered, func() {
+	leafHubName := "hub1"
+	globalApplicationSetID := "0b3a4e9d-7d7f-4a53-8b8a-3f6c1d9e2b10"
+	version := eventversion.NewVersion()
+	applicationSet := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "ApplicationSet",
+		"metadata": map[string]interface{}{
+			"name":            "guestbook",
+			"namespace":       "openshift-gitops",
+			"uid":             "7e1f5d2a-0c3b-4f8e-9a6d-5b4c3a2e1f00",
+			"resourceVersion": "1",
+			"annotations": map[string]interface{}{
+				constants.OriginOwnerReferenceAnnotation: globalApplicationSetID,
+			},
+		},
+		"status": map[string]interface{}{
+			"resources": []interface{}{
+				map[string]interface{}{
+					"name":   "guestbook-cluster1",
+					"health": map[string]interface{}{"status": "Progressing"},
+					"status": "OutOfSync",
+				},
+			},
+		},
+	}}
+
+	syncApplicationSets := func(applicationSets ...*unstructured.Unstructured) {
+		version.Incr()
+		data := generic.GenericObjectBundle{}
+		for _, applicationSet := range applicationSets {
+			data = append(data, applicationSet)
+		}
+		evt := ToCloudEvent(leafHubName, string(enum.ArgoApplicationSetType), version, data)
+		Expect(producer.SendEvent(ctx, *evt)).Should(Succeed())
+	}
+
+	// the row is identified by the uid of the applicationset on the global hub
+	queryStatus := func() (string, error) {
+		var syncStatus string
+		err := database.GetGorm().Raw(fmt.Sprintf(`SELECT payload -> 'status' -> 'resources' -> 0 ->> 'status'
+			FROM %s.%s WHERE leaf_hub_name = ? AND id = ?`, database.StatusSchema,
+			database.ArgoApplicationSetsTableName), leafHubName, globalApplicationSetID).Row().Scan(&syncStatus)
+		return syncStatus, err
+	}
+
+	It("should be able to sync the argocd applicationset", func() {
+		syncApplicationSets(applicationSet)
+
+		Eventually(func() error {
+			syncStatus, err := queryStatus()
+			if err != nil {
+				return err
+			}
+			if syncStatus != "OutOfSync" {
+				return fmt.Errorf("unexpected status of the applicationset: %s", syncStatus)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+
+	It("should be able to update the status of the argocd applicationset", func() {
+		updated := applicationSet.DeepCopy()
+		updated.SetResourceVersion("2")
+		Expect(unstructured.SetNestedSlice(updated.Object, []interface{}{
+			map[string]interface{}{
+				"name":   "guestbook-cluster1",
+				"health": map[string]interface{}{"status": "Healthy"},
+				"status": "Synced",
+			},
+		}, "status", "resources")).To(Succeed())
+		syncApplicationSets(updated)
+
+		Eventually(func() error {
+			syncStatus, err := queryStatus()
+			if err != nil {
+				return err
+			}
+			if syncStatus != "Synced" {
+				return fmt.Errorf("unexpected status of the applicationset: %s", syncStatus)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+
+	It("should be able to delete the argocd applicationset", func() {
+		syncApplicationSets()
+
+		Eventually(func() error {
+			var count int64
+			err := database.GetGorm().Raw(fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE leaf_hub_name = ?",
+				database.StatusSchema, database.ArgoApplicationSetsTableName), leafHubName).Scan(&count).Error
+			if err != nil {
+				return err
+			}
+			if count != 0 {
+				return fmt.Errorf("the applicationset isn't deleted")
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+})
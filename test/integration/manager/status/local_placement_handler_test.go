@@ -0,0 +1,107 @@
+package status
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clustersv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/bundle/generic"
+	eventversion "github.com/stolostron/multicluster-global-hub/pkg/bundle/version"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// go test /test/integration/manager/status -v -ginkgo.focus "LocalPlacementHandler"
+var _ = Describe("LocalPlacementHandler", Ordered, func() {
+	leafHubName := "hub1"
+
+	sync := func(eventType enum.EventType, objects ...client.Object) {
+		version := eventversion.NewVersion()
+		version.Incr()
+		data := generic.GenericObjectBundle{}
+		data = append(data, objects...)
+		evt := ToCloudEvent(leafHubName, string(eventType), version, data)
+		Expect(producer.SendEvent(ctx, *evt)).Should(Succeed())
+	}
+
+	It("should be able to sync the local placement and its decision", func() {
+		sync(enum.LocalPlacementSpecType, &clusterv1beta1.Placement{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "placement1",
+				Namespace:       "app",
+				UID:             "3b8f1c2a-6e4d-4f7a-9c15-8d2e7a6b4f90",
+				ResourceVersion: "1",
+			},
+			Spec: clusterv1beta1.PlacementSpec{ClusterSets: []string{"production"}},
+		})
+		sync(enum.LocalPlacementDecisionType, &clusterv1beta1.PlacementDecision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "placement1-decision-1",
+				Namespace:       "app",
+				UID:             "a1c94e67-2d3b-4b58-8f0e-5c7d9b2a6e31",
+				ResourceVersion: "1",
+				Labels:          map[string]string{clusterv1beta1.PlacementLabel: "placement1"},
+			},
+			Status: clusterv1beta1.PlacementDecisionStatus{
+				Decisions: []clusterv1beta1.ClusterDecision{{ClusterName: "cluster1"}},
+			},
+		})
+
+		Eventually(func() error {
+			var name string
+			err := database.GetGorm().Raw(fmt.Sprintf(`SELECT name FROM %s.%s WHERE leaf_hub_name = ? AND namespace = ?`,
+				database.LocalSpecSchema, database.PlacementsTableName), leafHubName, "app").Row().Scan(&name)
+			if err != nil {
+				return err
+			}
+			if name != "placement1" {
+				return fmt.Errorf("unexpected name of the placement: %s", name)
+			}
+
+			var placementName string
+			err = database.GetGorm().Raw(fmt.Sprintf(`SELECT placement_name FROM %s.%s
+				WHERE leaf_hub_name = ? AND namespace = ?`, database.LocalStatusSchema,
+				database.PlacementDecisionsTableName), leafHubName, "app").Row().Scan(&placementName)
+			if err != nil {
+				return err
+			}
+			if placementName != "placement1" {
+				return fmt.Errorf("unexpected placement of the decision: %s", placementName)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+
+	It("should be able to sync the addon placement score of the cluster", func() {
+		sync(enum.AddOnPlacementScoreType, &clustersv1alpha1.AddOnPlacementScore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "resource-usage",
+				Namespace:       "cluster1",
+				UID:             "5e2d7f41-9b6a-4c83-a0d5-1f8e3c7b2a64",
+				ResourceVersion: "1",
+			},
+			Status: clustersv1alpha1.AddOnPlacementScoreStatus{
+				Scores: []clustersv1alpha1.AddOnPlacementScoreItem{{Name: "cpu", Value: 80}},
+			},
+		})
+
+		Eventually(func() error {
+			var name string
+			err := database.GetGorm().Raw(fmt.Sprintf(`SELECT name FROM %s.%s WHERE leaf_hub_name = ? AND cluster_name = ?`,
+				database.StatusSchema, database.AddOnPlacementScoresTableName), leafHubName, "cluster1").Row().Scan(&name)
+			if err != nil {
+				return err
+			}
+			if name != "resource-usage" {
+				return fmt.Errorf("unexpected name of the score: %s", name)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+})
@@ -65,4 +65,128 @@ var _ = Describe("ManagedClusterHandler", Ordered, func() {
 			return fmt.Errorf("not found expected resource on the table")
 		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
 	})
+
+	It("should be able to sync the managed clusters of a large hub in batches", func() {
+		leafHubName := "hub-large"
+		version := eventversion.NewVersion()
+
+		newClusterBundle := func(count int) generic.GenericObjectBundle {
+			data := generic.GenericObjectBundle{}
+			for i := 0; i < count; i++ {
+				data = append(data, &clusterv1.ManagedCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            fmt.Sprintf("cluster-%d", i),
+						ResourceVersion: "1",
+					},
+					Status: clusterv1.ManagedClusterStatus{
+						ClusterClaims: []clusterv1.ManagedClusterClaim{
+							{
+								Name:  "id.k8s.io",
+								Value: fmt.Sprintf("3f406177-34b2-4852-88dd-%012d", i),
+							},
+						},
+					},
+				})
+			}
+			return data
+		}
+		countClusters := func() (int64, error) {
+			var count int64
+			err := database.GetGorm().Model(&models.ManagedCluster{}).
+				Where("leaf_hub_name = ?", leafHubName).Count(&count).Error
+			return count, err
+		}
+
+		By("Sync the bundle with more clusters than the batch size")
+		version.Incr()
+		evt := ToCloudEvent(leafHubName, string(enum.ManagedClusterType), version,
+			newClusterBundle(database.BatchSize+1))
+		Expect(producer.SendEvent(ctx, *evt)).Should(Succeed())
+		Eventually(func() error {
+			count, err := countClusters()
+			if err != nil {
+				return err
+			}
+			if count != int64(database.BatchSize+1) {
+				return fmt.Errorf("expected %d clusters, got %d", database.BatchSize+1, count)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+
+		By("Delete the clusters which aren't in the bundle")
+		version.Incr()
+		evt = ToCloudEvent(leafHubName, string(enum.ManagedClusterType), version, newClusterBundle(10))
+		Expect(producer.SendEvent(ctx, *evt)).Should(Succeed())
+		Eventually(func() error {
+			count, err := countClusters()
+			if err != nil {
+				return err
+			}
+			if count != 10 {
+				return fmt.Errorf("expected 10 clusters, got %d", count)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+
+	It("should be able to record the lifecycle events of the managed clusters", func() {
+		leafHubName := "hub-lifecycle"
+		clusterID := "7c1e4a52-9d3f-4b6e-8a27-5f0d2c9e1b83"
+		version := eventversion.NewVersion()
+
+		sync := func(clusters ...*clusterv1.ManagedCluster) {
+			version.Incr()
+			data := generic.GenericObjectBundle{}
+			for _, cluster := range clusters {
+				data = append(data, cluster)
+			}
+			evt := ToCloudEvent(leafHubName, string(enum.ManagedClusterType), version, data)
+			Expect(producer.SendEvent(ctx, *evt)).Should(Succeed())
+		}
+		newCluster := func(openshiftVersion string) *clusterv1.ManagedCluster {
+			return &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "lifecycle-cluster",
+					ResourceVersion: openshiftVersion,
+					Annotations:     map[string]string{"open-cluster-management/created-via": "hive"},
+				},
+				Status: clusterv1.ManagedClusterStatus{
+					ClusterClaims: []clusterv1.ManagedClusterClaim{
+						{Name: "id.k8s.io", Value: clusterID},
+						{Name: "version.openshift.io", Value: openshiftVersion},
+					},
+				},
+			}
+		}
+		expectEvents := func(expected ...string) {
+			Eventually(func() error {
+				events := []models.ClusterLifecycleEvent{}
+				err := database.GetGorm().Where("leaf_hub_name = ?", leafHubName).Order("sequence").
+					Find(&events).Error
+				if err != nil {
+					return err
+				}
+				eventTypes := []string{}
+				for _, event := range events {
+					eventTypes = append(eventTypes, event.EventType)
+				}
+				if fmt.Sprint(eventTypes) != fmt.Sprint(expected) {
+					return fmt.Errorf("expected the events %v, got %v", expected, eventTypes)
+				}
+				return nil
+			}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+		}
+
+		By("Create the cluster with the hive")
+		sync(newCluster("4.14.10"))
+		expectEvents(models.ClusterCreated)
+
+		By("Upgrade the cluster")
+		sync(newCluster("4.15.2"))
+		expectEvents(models.ClusterCreated, models.ClusterUpgraded)
+
+		By("Detach the cluster")
+		sync()
+		expectEvents(models.ClusterCreated, models.ClusterUpgraded, models.ClusterDetached)
+	})
 })
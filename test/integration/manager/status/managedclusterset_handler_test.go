@@ -0,0 +1,136 @@
+package status
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/bundle/generic"
+	eventversion "github.com/stolostron/multicluster-global-hub/pkg/bundle/version"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// go test /test/integration/manager/status -v -ginkgo.focus "ManagedClusterSetHandler"
+var _ = Describe("ManagedClusterSetHandler", Ordered, func() {
+	leafHubName := "hub1"
+	setVersion := eventversion.NewVersion()
+	bindingVersion := eventversion.NewVersion()
+	clusterSet := &clusterv1beta2.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "production",
+			UID:             "0d1f4c3e-5d0b-4a2f-8f64-6c2f0e7b9a21",
+			ResourceVersion: "1",
+		},
+		Spec: clusterv1beta2.ManagedClusterSetSpec{
+			ClusterSelector: clusterv1beta2.ManagedClusterSelector{
+				SelectorType: clusterv1beta2.ExclusiveClusterSetLabel,
+			},
+		},
+	}
+	binding := &clusterv1beta2.ManagedClusterSetBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "production",
+			Namespace:       "app",
+			UID:             "7c6a1b52-3f1e-4d8b-9a0e-2b5f6d4c8e13",
+			ResourceVersion: "1",
+		},
+		Spec: clusterv1beta2.ManagedClusterSetBindingSpec{
+			ClusterSet: "production",
+		},
+	}
+
+	sync := func(eventType enum.EventType, version *eventversion.Version, objects ...client.Object) {
+		version.Incr()
+		data := generic.GenericObjectBundle{}
+		data = append(data, objects...)
+		evt := ToCloudEvent(leafHubName, string(eventType), version, data)
+		Expect(producer.SendEvent(ctx, *evt)).Should(Succeed())
+	}
+
+	count := func(table string) (int64, error) {
+		var count int64
+		err := database.GetGorm().Raw(fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE leaf_hub_name = ?",
+			database.StatusSchema, table), leafHubName).Scan(&count).Error
+		return count, err
+	}
+
+	It("should be able to sync the managed cluster set and its binding", func() {
+		sync(enum.ManagedClusterSetType, setVersion, clusterSet)
+		sync(enum.ManagedClusterSetBindingType, bindingVersion, binding)
+
+		Eventually(func() error {
+			var selectorType string
+			err := database.GetGorm().Raw(fmt.Sprintf(`SELECT selector_type FROM %s.%s
+				WHERE leaf_hub_name = ? AND name = ?`, database.StatusSchema, database.ManagedClusterSetsTableName),
+				leafHubName, clusterSet.Name).Row().Scan(&selectorType)
+			if err != nil {
+				return err
+			}
+			if selectorType != string(clusterv1beta2.ExclusiveClusterSetLabel) {
+				return fmt.Errorf("unexpected selector type of the cluster set: %s", selectorType)
+			}
+
+			var namespace string
+			err = database.GetGorm().Raw(fmt.Sprintf(`SELECT namespace FROM %s.%s
+				WHERE leaf_hub_name = ? AND cluster_set = ?`, database.StatusSchema,
+				database.ManagedClusterSetBindingsTableName), leafHubName, clusterSet.Name).Row().Scan(&namespace)
+			if err != nil {
+				return err
+			}
+			if namespace != binding.Namespace {
+				return fmt.Errorf("unexpected namespace of the binding: %s", namespace)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+
+	It("should be able to update the selector of the managed cluster set", func() {
+		updated := clusterSet.DeepCopy()
+		updated.ResourceVersion = "2"
+		updated.Spec.ClusterSelector = clusterv1beta2.ManagedClusterSelector{
+			SelectorType:  clusterv1beta2.LabelSelector,
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+		}
+		sync(enum.ManagedClusterSetType, setVersion, updated)
+
+		Eventually(func() error {
+			var selectorType string
+			err := database.GetGorm().Raw(fmt.Sprintf(`SELECT selector_type FROM %s.%s
+				WHERE leaf_hub_name = ? AND name = ?`, database.StatusSchema, database.ManagedClusterSetsTableName),
+				leafHubName, clusterSet.Name).Row().Scan(&selectorType)
+			if err != nil {
+				return err
+			}
+			if selectorType != string(clusterv1beta2.LabelSelector) {
+				return fmt.Errorf("unexpected selector type of the cluster set: %s", selectorType)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+
+	It("should be able to delete the managed cluster set and its binding", func() {
+		sync(enum.ManagedClusterSetBindingType, bindingVersion)
+		sync(enum.ManagedClusterSetType, setVersion)
+
+		Eventually(func() error {
+			for _, table := range []string{
+				database.ManagedClusterSetsTableName, database.ManagedClusterSetBindingsTableName,
+			} {
+				rows, err := count(table)
+				if err != nil {
+					return err
+				}
+				if rows != 0 {
+					return fmt.Errorf("the rows of %s aren't deleted", table)
+				}
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,111 @@
+package status
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policyv1beta1 "open-cluster-management.io/governance-policy-propagator/api/v1beta1"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/bundle/generic"
+	eventversion "github.com/stolostron/multicluster-global-hub/pkg/bundle/version"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/pkg/database"
+	"github.com/stolostron/multicluster-global-hub/pkg/enum"
+)
+
+// go test /test/integration/manager/status -v -ginkgo.focus "PolicySetHandler"
+var _ = Describe("PolicySetHandler", Ordered, func() {
+	leafHubName := "hub1"
+	globalPolicySetID := "5d0c8f3e-2a41-4b6c-9e7d-1f2a3b4c5d60"
+	version := eventversion.NewVersion()
+	policySet := &policyv1beta1.PolicySet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: policyv1beta1.GroupVersion.String(),
+			Kind:       "PolicySet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pci",
+			Namespace:       "default",
+			UID:             "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c60",
+			ResourceVersion: "1",
+			Annotations: map[string]string{
+				constants.OriginOwnerReferenceAnnotation: globalPolicySetID,
+			},
+		},
+		Status: policyv1beta1.PolicySetStatus{
+			Compliant: "NonCompliant",
+		},
+	}
+
+	syncPolicySets := func(policySets ...*policyv1beta1.PolicySet) {
+		version.Incr()
+		data := generic.GenericObjectBundle{}
+		for _, policySet := range policySets {
+			data = append(data, policySet)
+		}
+		evt := ToCloudEvent(leafHubName, string(enum.PolicySetType), version, data)
+		Expect(producer.SendEvent(ctx, *evt)).Should(Succeed())
+	}
+
+	// the row is identified by the uid of the policyset on the global hub
+	queryCompliance := func() (string, error) {
+		var compliant string
+		err := database.GetGorm().Raw(fmt.Sprintf(`SELECT payload -> 'status' ->> 'compliant'
+			FROM %s.%s WHERE leaf_hub_name = ? AND id = ?`, database.StatusSchema,
+			database.PolicySetsTableName), leafHubName, globalPolicySetID).Row().Scan(&compliant)
+		return compliant, err
+	}
+
+	It("should be able to sync the policyset", func() {
+		syncPolicySets(policySet)
+
+		Eventually(func() error {
+			compliant, err := queryCompliance()
+			if err != nil {
+				return err
+			}
+			if compliant != "NonCompliant" {
+				return fmt.Errorf("unexpected compliance of the policyset: %s", compliant)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+
+	It("should be able to update the compliance of the policyset", func() {
+		updated := policySet.DeepCopy()
+		updated.SetResourceVersion("2")
+		updated.Status.Compliant = "Compliant"
+		syncPolicySets(updated)
+
+		Eventually(func() error {
+			compliant, err := queryCompliance()
+			if err != nil {
+				return err
+			}
+			if compliant != "Compliant" {
+				return fmt.Errorf("unexpected compliance of the policyset: %s", compliant)
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+
+	It("should be able to delete the policyset", func() {
+		syncPolicySets()
+
+		Eventually(func() error {
+			var count int64
+			err := database.GetGorm().Raw(fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE leaf_hub_name = ?",
+				database.StatusSchema, database.PolicySetsTableName), leafHubName).Scan(&count).Error
+			if err != nil {
+				return err
+			}
+			if count != 0 {
+				return fmt.Errorf("the policyset isn't deleted")
+			}
+			return nil
+		}, 30*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
+	})
+})
@@ -93,6 +93,9 @@ var _ = BeforeSuite(func() {
 		StatisticsConfig: &statistics.StatisticsConfig{
 			LogInterval: "10s",
 		},
+		DatabaseConfig: &config.DatabaseConfig{
+			ProbeInterval: 10 * time.Second,
+		},
 		EnableGlobalResource: true,
 	}
 
@@ -16,7 +16,6 @@ package hubofhubs
 import (
 	"context"
 	"os"
-	"path/filepath"
 	"testing"
 	"time"
 
@@ -38,6 +37,7 @@ import (
 	"github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/hubofhubs/transporter/protocol"
 	"github.com/stolostron/multicluster-global-hub/pkg/constants"
 	"github.com/stolostron/multicluster-global-hub/test/integration/utils/testpostgres"
+	"github.com/stolostron/multicluster-global-hub/test/integration/utils/testtransporter"
 )
 
 var (
@@ -64,21 +64,12 @@ var _ = BeforeSuite(func() {
 	ctx, cancel = context.WithCancel(context.TODO())
 
 	By("bootstrapping test environment")
-	testEnv = &envtest.Environment{
-		ControlPlane: envtest.ControlPlane{},
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "..", "..", "..", "operator", "config", "crd", "bases"),
-			filepath.Join("..", "..", "..", "manifest", "crd"),
-		},
-		ErrorIfCRDPathMissing: true,
-	}
+	var err error
+	testEnv, err = testtransporter.NewTestEnvironment()
+	Expect(err).NotTo(HaveOccurred())
 	config.SetKafkaResourceReady(true)
 	config.SetACMResourceReady(true)
 
-	testEnv.ControlPlane.GetAPIServer().Configure().Set("disable-admission-plugins",
-		"ServiceAccount,MutatingAdmissionWebhook,ValidatingAdmissionWebhook")
-
-	var err error
 	// cfg is defined in this file globally.
 	cfg, err = testEnv.Start()
 	Expect(err).NotTo(HaveOccurred())
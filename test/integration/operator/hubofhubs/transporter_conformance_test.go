@@ -0,0 +1,117 @@
+package hubofhubs
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/config"
+	"github.com/stolostron/multicluster-global-hub/operator/pkg/controllers/hubofhubs/transporter/protocol"
+	"github.com/stolostron/multicluster-global-hub/pkg/constants"
+	"github.com/stolostron/multicluster-global-hub/test/integration/utils/testtransporter"
+)
+
+// go test ./test/integration/operator/hubofhubs -ginkgo.focus "transporter conformance" -v
+var _ = Describe("transporter conformance", Ordered, func() {
+	var mgh *v1alpha4.MulticlusterGlobalHub
+	var namespace string
+	BeforeAll(func() {
+		namespace = fmt.Sprintf("namespace-%s", rand.String(6))
+		Expect(runtimeClient.Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: namespace,
+			},
+		})).To(Succeed())
+		mgh = &v1alpha4.MulticlusterGlobalHub{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-mgh",
+				Namespace: namespace,
+			},
+			Spec: v1alpha4.MulticlusterGlobalHubSpec{},
+		}
+		Expect(runtimeClient.Create(ctx, mgh)).To(Succeed())
+		Expect(config.SetMulticlusterGlobalHubConfig(ctx, mgh, nil)).To(Succeed())
+	})
+
+	It("should pass the conformance checks with the BYO transporter", func() {
+		Expect(runtimeClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      constants.GHTransportSecretName,
+				Namespace: namespace,
+			},
+			Data: map[string][]byte{
+				"bootstrap_server": []byte("localhost:test"),
+				"ca.crt":           []byte("ca.crt"),
+				"client.crt":       []byte("client.crt"),
+				"client.key":       []byte("client.key"),
+			},
+		})).To(Succeed())
+
+		trans := protocol.NewBYOTransporter(ctx, types.NamespacedName{
+			Namespace: namespace,
+			Name:      constants.GHTransportSecretName,
+		}, runtimeClient)
+		Expect(testtransporter.VerifyTransporter(trans, "hub1")).To(Succeed())
+	})
+
+	It("should pass the conformance checks with the strimzi transporter", func() {
+		Expect(testtransporter.UpdateKafkaClusterReady(runtimeClient, namespace)).To(Succeed())
+
+		trans, err := protocol.NewStrimziTransporter(runtimeManager, mgh,
+			protocol.WithNamespacedName(types.NamespacedName{
+				Name:      protocol.KafkaClusterName,
+				Namespace: namespace,
+			}),
+			protocol.WithWaitReady(false),
+		)
+		Expect(err).To(Succeed())
+		Expect(testtransporter.VerifyTransporter(trans, "hub1")).To(Succeed())
+	})
+
+	It("should pass the conformance checks with the nats transporter", func() {
+		Expect(runtimeClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nats-transport",
+				Namespace: namespace,
+			},
+			Data: map[string][]byte{
+				"url":    []byte("nats://nats.nats.svc:4222"),
+				"ca.crt": []byte("ca.crt"),
+			},
+		})).To(Succeed())
+
+		natsMgh := mgh.DeepCopy()
+		natsMgh.Spec.DataLayer.Nats = &v1alpha4.NatsConfig{
+			TransportSecretName: "nats-transport",
+			StreamReplicas:      1,
+		}
+		trans := protocol.NewNatsTransporter(ctx, natsMgh, runtimeClient)
+		Expect(testtransporter.VerifyTransporter(trans, "hub1")).To(Succeed())
+
+		stream := &unstructured.Unstructured{}
+		stream.SetGroupVersionKind(protocol.NatsStreamGVK)
+		Expect(runtimeClient.Get(ctx, types.NamespacedName{
+			Name:      protocol.GetStreamName(config.GetStatusTopic("hub1")),
+			Namespace: namespace,
+		}, stream)).To(Succeed())
+		subjects, _, err := unstructured.NestedStringSlice(stream.Object, "spec", "subjects")
+		Expect(err).To(Succeed())
+		Expect(subjects).To(Equal([]string{config.GetStatusTopic("hub1")}))
+	})
+
+	AfterAll(func() {
+		Expect(runtimeClient.Delete(ctx, mgh)).To(Succeed())
+		Expect(runtimeClient.Delete(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: namespace,
+			},
+		})).To(Succeed())
+	})
+})
@@ -1,7 +1,7 @@
 package hubofhubs
 
 import (
-	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -11,14 +11,12 @@ import (
 	. "github.com/onsi/gomega"
 	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	corev1 "k8s.io/api/core/v1"
-	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
-	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/stolostron/multicluster-global-hub/operator/apis/v1alpha4"
@@ -29,6 +27,7 @@ import (
 	"github.com/stolostron/multicluster-global-hub/pkg/constants"
 	"github.com/stolostron/multicluster-global-hub/pkg/transport"
 	"github.com/stolostron/multicluster-global-hub/pkg/utils"
+	"github.com/stolostron/multicluster-global-hub/test/integration/utils/testtransporter"
 )
 
 // go test ./test/integration/operator/hubofhubs -ginkgo.focus "transporter" -v
@@ -162,7 +161,7 @@ var _ = Describe("transporter", Ordered, func() {
 		}, 10*time.Second, 100*time.Millisecond).ShouldNot(HaveOccurred())
 
 		// update the kafka resource to make it ready
-		err = UpdateKafkaClusterReady(runtimeClient, mgh.Namespace)
+		err = testtransporter.UpdateKafkaClusterReady(runtimeClient, mgh.Namespace)
 		Expect(err).To(Succeed())
 
 		// verify the metrics resources and pod monitor
@@ -227,15 +226,17 @@ var _ = Describe("transporter", Ordered, func() {
 		customMemoryRequest := "1Mi"
 		customMemoryLimit := "2Mi"
 		mgh.Spec.AdvancedConfig = &v1alpha4.AdvancedConfig{
-			Kafka: &v1alpha4.CommonSpec{
-				Resources: &v1alpha4.ResourceRequirements{
-					Limits: corev1.ResourceList{
-						corev1.ResourceName(corev1.ResourceCPU):    resource.MustParse(customCPULimit),
-						corev1.ResourceName(corev1.ResourceMemory): resource.MustParse(customMemoryLimit),
-					},
-					Requests: corev1.ResourceList{
-						corev1.ResourceName(corev1.ResourceMemory): resource.MustParse(customMemoryRequest),
-						corev1.ResourceName(corev1.ResourceCPU):    resource.MustParse(customCPURequest),
+			Kafka: &v1alpha4.KafkaCommonSpec{
+				CommonSpec: v1alpha4.CommonSpec{
+					Resources: &v1alpha4.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceName(corev1.ResourceCPU):    resource.MustParse(customCPULimit),
+							corev1.ResourceName(corev1.ResourceMemory): resource.MustParse(customMemoryLimit),
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceName(corev1.ResourceMemory): resource.MustParse(customMemoryRequest),
+							corev1.ResourceName(corev1.ResourceCPU):    resource.MustParse(customCPURequest),
+						},
 					},
 				},
 			},
@@ -427,167 +428,322 @@ var _ = Describe("transporter", Ordered, func() {
 		Expect(err).To(Succeed())
 	})
 
-	AfterAll(func() {
-		err := runtimeClient.Delete(ctx, mgh)
+	It("should authenticate the managed hubs with scram-sha-512", func() {
+		mgh.Spec.DataLayer.Kafka.HubAuthentication = v1alpha4.KafkaAuthenticationScramSha512
+		trans, err := protocol.NewStrimziTransporter(
+			runtimeManager,
+			mgh,
+			protocol.WithNamespacedName(types.NamespacedName{
+				Name:      protocol.KafkaClusterName,
+				Namespace: mgh.Namespace,
+			}),
+			protocol.WithWaitReady(false),
+		)
 		Expect(err).To(Succeed())
 
-		err = runtimeClient.Delete(ctx, &corev1.Namespace{
+		// the scram listener is added to the kafka cluster
+		kafka := &kafkav1beta2.Kafka{}
+		Expect(runtimeClient.Get(ctx, types.NamespacedName{
+			Namespace: mgh.Namespace,
+			Name:      protocol.KafkaClusterName,
+		}, kafka)).To(Succeed())
+		Expect(kafka.Spec.Kafka.Listeners).To(HaveLen(3))
+		// the scram listener is appended after the plain and tls listeners
+		Expect(kafka.Spec.Kafka.Listeners[2].Name).To(Equal(protocol.ScramListenerName))
+		Expect(kafka.Spec.Kafka.Listeners[2].Authentication.Type).To(
+			Equal(kafkav1beta2.KafkaSpecKafkaListenersElemAuthenticationTypeScramSha512))
+
+		clusterName := "hub2"
+		userName, err := trans.EnsureUser(clusterName)
+		Expect(err).To(Succeed())
+		kafkaUser := &kafkav1beta2.KafkaUser{}
+		Expect(runtimeClient.Get(ctx, types.NamespacedName{
+			Namespace: mgh.Namespace,
+			Name:      userName,
+		}, kafkaUser)).To(Succeed())
+		Expect(kafkaUser.Spec.Authentication.Type).To(
+			Equal(kafkav1beta2.KafkaUserSpecAuthenticationTypeScramSha512))
+
+		// simulate the strimzi operator to expose the scram listener and generate the password
+		scramBootstrapServer := "kafka-kafka-scram-bootstrap.multicluster-global-hub.svc:9094"
+		scramListener := protocol.ScramListenerName
+		kafka.Status.Listeners = append(kafka.Status.Listeners, kafkav1beta2.KafkaStatusListenersElem{
+			Name:             &scramListener,
+			BootstrapServers: &scramBootstrapServer,
+		})
+		Expect(runtimeClient.Status().Update(ctx, kafka)).To(Succeed())
+		Expect(runtimeClient.Create(ctx, &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: namespace,
+				Name:      userName,
+				Namespace: mgh.Namespace,
 			},
-		})
+			Data: map[string][]byte{"password": []byte("scram-password")},
+		})).To(Succeed())
+
+		conn, err := trans.GetConnCredential(clusterName)
 		Expect(err).To(Succeed())
+		Expect(conn.BootstrapServer).To(Equal(scramBootstrapServer))
+		Expect(conn.SASLMechanism).To(Equal(transport.ScramSha512))
+		Expect(conn.SASLUsername).To(Equal(userName))
+		Expect(conn.SASLPassword).To(Equal(base64.StdEncoding.EncodeToString([]byte("scram-password"))))
+		Expect(conn.ClientSecretName).To(BeEmpty())
+
+		Expect(trans.Prune(clusterName)).To(Succeed())
+		mgh.Spec.DataLayer.Kafka.HubAuthentication = ""
 	})
-})
 
-func UpdateKafkaClusterReady(c client.Client, ns string) error {
-	kafkaVersion := "3.5.0"
-	kafkaClusterName := "kafka"
-	globalHubKafkaUser := "global-hub-kafka-user"
-	clientCa := "kafka-clients-ca-cert"
-	clientCaCert := "kafka-clients-ca"
-
-	readyCondition := "Ready"
-	trueCondition := "True"
-	bootServer := "kafka-kafka-bootstrap.multicluster-global-hub.svc:9092"
-	statusClusterId := "MXpoZsJTRD2DDiVUh3Rsqg"
-
-	statusKafkaCluster := &kafkav1beta2.Kafka{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: ns,
-			Name:      kafkaClusterName,
-		},
-		Spec: &kafkav1beta2.KafkaSpec{
-			Kafka: kafkav1beta2.KafkaSpecKafka{
-				Replicas: 1,
-				Storage: kafkav1beta2.KafkaSpecKafkaStorage{
-					Type: "ephemeral",
-				},
-				Listeners: []kafkav1beta2.KafkaSpecKafkaListenersElem{
-					{
-						Name: "plain",
-						Port: 9092,
-						Type: "internal",
-					},
-				},
-				Config: &apiextensions.JSON{Raw: []byte(`{
-"default.replication.factor": 3
-}`)},
-				Version: &kafkaVersion,
-			},
-			Zookeeper: kafkav1beta2.KafkaSpecZookeeper{
-				Replicas: 1,
-				Storage: kafkav1beta2.KafkaSpecZookeeperStorage{
-					Type: "ephemeral",
-				},
-			},
-		},
-		Status: &kafkav1beta2.KafkaStatus{
-			ClusterId: &statusClusterId,
-			Listeners: []kafkav1beta2.KafkaStatusListenersElem{
-				{
-					BootstrapServers: &bootServer,
-				},
-				{
-					BootstrapServers: &bootServer,
-					Certificates: []string{
-						"cert",
-					},
-				},
-			},
-			Conditions: []kafkav1beta2.KafkaStatusConditionsElem{
-				{
-					Type:   &readyCondition,
-					Status: &trueCondition,
-				},
-			},
-		},
-	}
-
-	err := wait.PollImmediate(1*time.Second, 1*time.Minute, func() (bool, error) {
-		existkafkaCluster := &kafkav1beta2.Kafka{}
-		err := c.Get(context.Background(), types.NamespacedName{
-			Name:      kafkaClusterName,
-			Namespace: ns,
-		}, existkafkaCluster)
-		if err != nil {
-			if errors.IsNotFound(err) {
-				if e := c.Create(context.Background(), statusKafkaCluster); e != nil {
-					klog.Errorf("Failed to create kafka cluster, error: %v", e)
-					return false, nil
-				}
-			} else {
-				klog.Errorf("Failed to get Kafka cluster, error:%v", err)
+	It("should expose the kafka with the external listener type", func() {
+		mgh.Spec.DataLayer.Kafka.ExternalListener = &v1alpha4.KafkaExternalListener{
+			Type:        v1alpha4.KafkaListenerNodePort,
+			Annotations: map[string]string{"external-dns.alpha.kubernetes.io/hostname": "kafka.example.com"},
+			NodePort:    32000,
+		}
+		_, err := protocol.NewStrimziTransporter(
+			runtimeManager,
+			mgh,
+			protocol.WithNamespacedName(types.NamespacedName{
+				Name:      protocol.KafkaClusterName,
+				Namespace: mgh.Namespace,
+			}),
+			protocol.WithWaitReady(false),
+		)
+		Expect(err).To(Succeed())
+
+		kafka := &kafkav1beta2.Kafka{}
+		Expect(runtimeClient.Get(ctx, types.NamespacedName{
+			Namespace: mgh.Namespace,
+			Name:      protocol.KafkaClusterName,
+		}, kafka)).To(Succeed())
+		found := false
+		for _, listener := range kafka.Spec.Kafka.Listeners {
+			if listener.Name != "tls" {
+				continue
 			}
-			return false, nil
+			found = true
+			Expect(string(listener.Type)).To(Equal(string(v1alpha4.KafkaListenerNodePort)))
+			Expect(listener.Configuration).NotTo(BeNil())
+			Expect(listener.Configuration.Bootstrap).NotTo(BeNil())
+			Expect(*listener.Configuration.Bootstrap.NodePort).To(BeEquivalentTo(32000))
+			Expect(listener.Configuration.Brokers).To(HaveLen(3))
+			Expect(*listener.Configuration.Brokers[2].NodePort).To(BeEquivalentTo(32003))
 		}
-		existkafkaCluster.Status = &kafkav1beta2.KafkaStatus{
-			Listeners: []kafkav1beta2.KafkaStatusListenersElem{
-				{
-					BootstrapServers: &bootServer,
-				},
-				{
-					BootstrapServers: &bootServer,
-					Certificates: []string{
-						"cert",
-					},
-				},
-			},
-			Conditions: []kafkav1beta2.KafkaStatusConditionsElem{
-				{
-					Type:   &readyCondition,
-					Status: &trueCondition,
-				},
-			},
+		Expect(found).To(BeTrue())
+		mgh.Spec.DataLayer.Kafka.ExternalListener = nil
+	})
+
+	It("should rebalance the kafka with the cruise control", func() {
+		mgh.Spec.DataLayer.Kafka.CruiseControl = &v1alpha4.KafkaCruiseControl{RebalanceHubThreshold: 1}
+		trans, err := protocol.NewStrimziTransporter(
+			runtimeManager,
+			mgh,
+			protocol.WithNamespacedName(types.NamespacedName{
+				Name:      protocol.KafkaClusterName,
+				Namespace: mgh.Namespace,
+			}),
+			protocol.WithWaitReady(false),
+		)
+		Expect(err).To(Succeed())
+
+		kafka := &kafkav1beta2.Kafka{}
+		Expect(runtimeClient.Get(ctx, types.NamespacedName{
+			Namespace: mgh.Namespace,
+			Name:      protocol.KafkaClusterName,
+		}, kafka)).To(Succeed())
+		Expect(kafka.Spec.CruiseControl).NotTo(BeNil())
+
+		// the rebalance is requested once the managed hub joins
+		clusterName := "hub3"
+		_, err = trans.EnsureUser(clusterName)
+		Expect(err).To(Succeed())
+		inProgress, err := trans.EnsureRebalance()
+		Expect(err).To(Succeed())
+		Expect(inProgress).To(BeFalse())
+
+		rebalance := &unstructured.Unstructured{}
+		rebalance.SetGroupVersionKind(protocol.KafkaRebalanceGVK)
+		Expect(runtimeClient.Get(ctx, types.NamespacedName{
+			Namespace: mgh.Namespace,
+			Name:      protocol.KafkaRebalanceName,
+		}, rebalance)).To(Succeed())
+		Expect(rebalance.GetAnnotations()).To(HaveKeyWithValue("strimzi.io/rebalance-auto-approval", "true"))
+		Expect(rebalance.GetLabels()).To(HaveKeyWithValue("strimzi.io/cluster", protocol.KafkaClusterName))
+
+		// the rebalance isn't refreshed without new managed hubs
+		inProgress, err = trans.EnsureRebalance()
+		Expect(err).To(Succeed())
+		Expect(inProgress).To(BeFalse())
+		Expect(runtimeClient.Get(ctx, client.ObjectKeyFromObject(rebalance), rebalance)).To(Succeed())
+		Expect(rebalance.GetAnnotations()).NotTo(HaveKey("strimzi.io/rebalance"))
+
+		// the annotation of the mgh refreshes the rebalance
+		mgh.SetAnnotations(map[string]string{"mgh-kafka-rebalance": "1"})
+		inProgress, err = trans.EnsureRebalance()
+		Expect(err).To(Succeed())
+		Expect(inProgress).To(BeFalse())
+		Expect(runtimeClient.Get(ctx, client.ObjectKeyFromObject(rebalance), rebalance)).To(Succeed())
+		Expect(rebalance.GetAnnotations()).To(HaveKeyWithValue("strimzi.io/rebalance", "refresh"))
+
+		Expect(trans.Prune(clusterName)).To(Succeed())
+		Expect(runtimeClient.Delete(ctx, rebalance)).To(Succeed())
+		mgh.SetAnnotations(nil)
+		mgh.Spec.DataLayer.Kafka.CruiseControl = nil
+	})
+
+	It("should apply the topic config to the kafka topics", func() {
+		retentionMs := int64(86400000)
+		mgh.Spec.DataLayer.Kafka.StatusTopicConfig = &v1alpha4.KafkaTopicConfig{
+			CleanupPolicy: "delete",
+			RetentionMs:   &retentionMs,
 		}
-		err = c.Status().Update(context.Background(), existkafkaCluster)
-		if err != nil {
-			klog.Errorf("Failed to update Kafka cluster, error:%v", err)
-			return false, nil
+		trans, err := protocol.NewStrimziTransporter(
+			runtimeManager,
+			mgh,
+			protocol.WithNamespacedName(types.NamespacedName{
+				Name:      protocol.KafkaClusterName,
+				Namespace: mgh.Namespace,
+			}),
+			protocol.WithWaitReady(false),
+		)
+		Expect(err).To(Succeed())
+
+		clusterName := "hub4"
+		clusterTopic, err := trans.EnsureTopic(clusterName)
+		Expect(err).To(Succeed())
+
+		getTopicConfig := func(topicName string) map[string]interface{} {
+			kafkaTopic := &kafkav1beta2.KafkaTopic{}
+			Expect(runtimeClient.Get(ctx, types.NamespacedName{
+				Namespace: mgh.Namespace,
+				Name:      topicName,
+			}, kafkaTopic)).To(Succeed())
+			topicConfig := map[string]interface{}{}
+			Expect(json.Unmarshal(kafkaTopic.Spec.Config.Raw, &topicConfig)).To(Succeed())
+			return topicConfig
 		}
-		return true, nil
+		Expect(getTopicConfig(clusterTopic.SpecTopic)).To(Equal(map[string]interface{}{"cleanup.policy": "compact"}))
+		Expect(getTopicConfig(clusterTopic.StatusTopic)).To(Equal(map[string]interface{}{
+			"cleanup.policy": "delete",
+			"retention.ms":   float64(retentionMs),
+		}))
+
+		// the changes are applied to the existing topic
+		mgh.Spec.DataLayer.Kafka.StatusTopicConfig = &v1alpha4.KafkaTopicConfig{CleanupPolicy: "compact,delete"}
+		_, err = trans.EnsureTopic(clusterName)
+		Expect(err).To(Succeed())
+		Expect(getTopicConfig(clusterTopic.StatusTopic)).To(Equal(map[string]interface{}{
+			"cleanup.policy": "compact,delete",
+			"retention.ms":   float64(protocol.DefaultStatusTopicRetentionMs),
+		}))
+
+		// the status topics are deleted by the time by default
+		mgh.Spec.DataLayer.Kafka.StatusTopicConfig = nil
+		_, err = trans.EnsureTopic(clusterName)
+		Expect(err).To(Succeed())
+		Expect(getTopicConfig(clusterTopic.StatusTopic)).To(Equal(map[string]interface{}{
+			"cleanup.policy": "delete",
+			"retention.ms":   float64(protocol.DefaultStatusTopicRetentionMs),
+		}))
+		Expect(getTopicConfig(clusterTopic.SpecTopic)).To(Equal(map[string]interface{}{"cleanup.policy": "compact"}))
 	})
 
-	err = createSecret(c, ns, globalHubKafkaUser, map[string][]byte{
-		"user.crt": []byte("usercrt"),
-		"user.key": []byte("userkey"),
+	It("should offload the status topics to the tiered storage", func() {
+		localRetentionMs := int64(3600000)
+		mgh.Spec.DataLayer.Kafka.StatusTopicConfig = &v1alpha4.KafkaTopicConfig{CleanupPolicy: "delete"}
+		mgh.Spec.DataLayer.Kafka.TieredStorage = &v1alpha4.KafkaTieredStorage{
+			ClassName:        "io.aiven.kafka.tieredstorage.RemoteStorageManager",
+			Config:           map[string]string{"storage.s3.bucket.name": "global-hub"},
+			LocalRetentionMs: &localRetentionMs,
+		}
+		trans, err := protocol.NewStrimziTransporter(
+			runtimeManager,
+			mgh,
+			protocol.WithNamespacedName(types.NamespacedName{
+				Name:      protocol.KafkaClusterName,
+				Namespace: mgh.Namespace,
+			}),
+			protocol.WithWaitReady(false),
+		)
+		Expect(err).To(Succeed())
+
+		clusterTopic, err := trans.EnsureTopic("hub6")
+		Expect(err).To(Succeed())
+
+		getTopicConfig := func(topicName string) map[string]interface{} {
+			kafkaTopic := &kafkav1beta2.KafkaTopic{}
+			Expect(runtimeClient.Get(ctx, types.NamespacedName{
+				Namespace: mgh.Namespace,
+				Name:      topicName,
+			}, kafkaTopic)).To(Succeed())
+			topicConfig := map[string]interface{}{}
+			Expect(json.Unmarshal(kafkaTopic.Spec.Config.Raw, &topicConfig)).To(Succeed())
+			return topicConfig
+		}
+		Expect(getTopicConfig(clusterTopic.StatusTopic)).To(Equal(map[string]interface{}{
+			"cleanup.policy":        "delete",
+			"retention.ms":          float64(protocol.DefaultStatusTopicRetentionMs),
+			"remote.storage.enable": true,
+			"local.retention.ms":    float64(localRetentionMs),
+		}))
+		// the spec topic is kept in the kafka volumes
+		Expect(getTopicConfig(clusterTopic.SpecTopic)).To(Equal(map[string]interface{}{"cleanup.policy": "compact"}))
+
+		mgh.Spec.DataLayer.Kafka.StatusTopicConfig = nil
+		mgh.Spec.DataLayer.Kafka.TieredStorage = nil
 	})
-	if err != nil {
-		return err
-	}
 
-	err = createSecret(c, ns, clientCa, map[string][]byte{
-		"ca.key": []byte("cakey"),
+	It("should only increase the partitions of the status topic", func() {
+		partitions := int32(6)
+		mgh.Spec.DataLayer.Kafka.StatusTopicConfig = &v1alpha4.KafkaTopicConfig{Partitions: &partitions}
+		trans, err := protocol.NewStrimziTransporter(
+			runtimeManager,
+			mgh,
+			protocol.WithNamespacedName(types.NamespacedName{
+				Name:      protocol.KafkaClusterName,
+				Namespace: mgh.Namespace,
+			}),
+			protocol.WithWaitReady(false),
+		)
+		Expect(err).To(Succeed())
+
+		clusterName := "hub5"
+		clusterTopic, err := trans.EnsureTopic(clusterName)
+		Expect(err).To(Succeed())
+
+		getPartitions := func(topicName string) int32 {
+			kafkaTopic := &kafkav1beta2.KafkaTopic{}
+			Expect(runtimeClient.Get(ctx, types.NamespacedName{
+				Namespace: mgh.Namespace,
+				Name:      topicName,
+			}, kafkaTopic)).To(Succeed())
+			return *kafkaTopic.Spec.Partitions
+		}
+		Expect(getPartitions(clusterTopic.StatusTopic)).To(Equal(int32(6)))
+		Expect(getPartitions(clusterTopic.SpecTopic)).To(Equal(protocol.DefaultPartition))
+
+		// increase the partitions
+		partitions = 12
+		_, err = trans.EnsureTopic(clusterName)
+		Expect(err).To(Succeed())
+		Expect(getPartitions(clusterTopic.StatusTopic)).To(Equal(int32(12)))
+
+		// the partitions aren't decreased
+		partitions = 3
+		_, err = trans.EnsureTopic(clusterName)
+		Expect(err).To(Succeed())
+		Expect(getPartitions(clusterTopic.StatusTopic)).To(Equal(int32(12)))
+
+		mgh.Spec.DataLayer.Kafka.StatusTopicConfig = nil
 	})
-	if err != nil {
-		return err
-	}
 
-	err = createSecret(c, ns, clientCaCert, map[string][]byte{
-		"ca.crt": []byte("cacert"),
+	AfterAll(func() {
+		err := runtimeClient.Delete(ctx, mgh)
+		Expect(err).To(Succeed())
+
+		err = runtimeClient.Delete(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: namespace,
+			},
+		})
+		Expect(err).To(Succeed())
 	})
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func createSecret(c client.Client, ns, name string, data map[string][]byte) error {
-	clientCaCertSecret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: ns,
-			Name:      name,
-		},
-		Data: data,
-	}
-	err := c.Get(context.Background(), client.ObjectKeyFromObject(clientCaCertSecret), clientCaCertSecret)
-	if errors.IsNotFound(err) {
-		e := c.Create(context.Background(), clientCaCertSecret)
-		if e != nil {
-			return e
-		}
-	} else if err != nil {
-		return err
-	}
-	return nil
-}
+})
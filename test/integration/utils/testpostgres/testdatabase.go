@@ -51,7 +51,7 @@ func InitDatabase(uri string) error {
 		fmt.Printf("script %s executed successfully.\n", file.Name())
 	}
 
-	sqlDir = filepath.Join(dirname, "operator", "pkg", "controllers", "hubofhubs", "storage", "upgrade")
+	sqlDir = filepath.Join(dirname, "operator", "pkg", "controllers", "hubofhubs", "storage", "migrations")
 	upgradeFiles, err := os.ReadDir(sqlDir)
 	if err != nil {
 		return err
@@ -0,0 +1,70 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package testtransporter
+
+import (
+	"fmt"
+
+	"github.com/stolostron/multicluster-global-hub/pkg/transport"
+)
+
+// VerifyTransporter runs the conformance checks of the transport.Transporter interface for the managed hub. Every
+// transporter implementation (BYO, strimzi, ...) is expected to pass them:
+//  1. EnsureTopic returns the spec and status topics, and it's idempotent
+//  2. EnsureUser is idempotent
+//  3. GetConnCredential returns the bootstrap server and the same topics as EnsureTopic
+//  4. Prune is idempotent, and the resources can be ensured again after pruning
+func VerifyTransporter(trans transport.Transporter, clusterName string) error {
+	clusterTopic, err := trans.EnsureTopic(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to ensure the topic: %w", err)
+	}
+	if clusterTopic == nil || clusterTopic.SpecTopic == "" || clusterTopic.StatusTopic == "" {
+		return fmt.Errorf("the spec and status topics should be returned, but got %v", clusterTopic)
+	}
+	updatedTopic, err := trans.EnsureTopic(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to ensure the existing topic: %w", err)
+	}
+	if *updatedTopic != *clusterTopic {
+		return fmt.Errorf("the topic should be the same after updating, want %v, but got %v", clusterTopic,
+			updatedTopic)
+	}
+
+	userName, err := trans.EnsureUser(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to ensure the user: %w", err)
+	}
+	updatedUserName, err := trans.EnsureUser(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to ensure the existing user: %w", err)
+	}
+	if updatedUserName != userName {
+		return fmt.Errorf("the user should be the same after updating, want %s, but got %s", userName,
+			updatedUserName)
+	}
+
+	conn, err := trans.GetConnCredential(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to get the connection credential: %w", err)
+	}
+	if conn == nil || conn.BootstrapServer == "" {
+		return fmt.Errorf("the connection credential should contain the bootstrap server, but got %v", conn)
+	}
+	if conn.SpecTopic != clusterTopic.SpecTopic {
+		return fmt.Errorf("the spec topic of the credential should be %s, but got %s", clusterTopic.SpecTopic,
+			conn.SpecTopic)
+	}
+
+	if err := trans.Prune(clusterName); err != nil {
+		return fmt.Errorf("failed to prune the cluster: %w", err)
+	}
+	if err := trans.Prune(clusterName); err != nil {
+		return fmt.Errorf("failed to prune the pruned cluster: %w", err)
+	}
+	if _, err := trans.EnsureUser(clusterName); err != nil {
+		return fmt.Errorf("failed to ensure the user after pruning: %w", err)
+	}
+	return trans.Prune(clusterName)
+}
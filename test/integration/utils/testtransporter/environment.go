@@ -0,0 +1,35 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package testtransporter
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// NewTestEnvironment returns the envtest environment with the global hub and the fake strimzi CRDs installed, so the
+// transporters can be verified against a real API server without a kafka cluster
+func NewTestEnvironment() (*envtest.Environment, error) {
+	_, currentFile, _, ok := runtime.Caller(0)
+	if !ok {
+		return nil, fmt.Errorf("failed to get current dir: no caller information")
+	}
+	rootDir := strings.Replace(currentFile, "test/integration/utils/testtransporter/environment.go", "", 1)
+
+	testEnv := &envtest.Environment{
+		ControlPlane: envtest.ControlPlane{},
+		CRDDirectoryPaths: []string{
+			filepath.Join(rootDir, "operator", "config", "crd", "bases"),
+			filepath.Join(rootDir, "test", "manifest", "crd"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	testEnv.ControlPlane.GetAPIServer().Configure().Set("disable-admission-plugins",
+		"ServiceAccount,MutatingAdmissionWebhook,ValidatingAdmissionWebhook")
+	return testEnv, nil
+}
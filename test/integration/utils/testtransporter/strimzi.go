@@ -0,0 +1,183 @@
+// Copyright (c) 2024 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package testtransporter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kafkav1beta2 "github.com/RedHatInsights/strimzi-client-go/apis/kafka.strimzi.io/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UpdateKafkaClusterReady simulates the strimzi operator: it creates the kafka cluster if it doesn't exist, reports
+// it as ready with the listeners, and creates the secrets of the global hub kafka user and the clients ca
+func UpdateKafkaClusterReady(c client.Client, ns string) error {
+	kafkaVersion := "3.5.0"
+	kafkaClusterName := "kafka"
+	globalHubKafkaUser := "global-hub-kafka-user"
+	clientCa := fmt.Sprintf("%s-clients-ca", kafkaClusterName)
+	clientCaCert := fmt.Sprintf("%s-clients-ca-cert", kafkaClusterName)
+
+	readyCondition := "Ready"
+	trueCondition := "True"
+	bootServer := "kafka-kafka-bootstrap.multicluster-global-hub.svc:9092"
+	statusClusterId := "MXpoZsJTRD2DDiVUh3Rsqg"
+	plainListener := "plain"
+	tlsListener := "tls"
+
+	statusKafkaCluster := &kafkav1beta2.Kafka{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      kafkaClusterName,
+		},
+		Spec: &kafkav1beta2.KafkaSpec{
+			Kafka: kafkav1beta2.KafkaSpecKafka{
+				Replicas: 1,
+				Storage: kafkav1beta2.KafkaSpecKafkaStorage{
+					Type: "ephemeral",
+				},
+				Listeners: []kafkav1beta2.KafkaSpecKafkaListenersElem{
+					{
+						Name: "plain",
+						Port: 9092,
+						Type: "internal",
+					},
+				},
+				Config: &apiextensions.JSON{Raw: []byte(`{
+"default.replication.factor": 3
+}`)},
+				Version: &kafkaVersion,
+			},
+			Zookeeper: kafkav1beta2.KafkaSpecZookeeper{
+				Replicas: 1,
+				Storage: kafkav1beta2.KafkaSpecZookeeperStorage{
+					Type: "ephemeral",
+				},
+			},
+		},
+		Status: &kafkav1beta2.KafkaStatus{
+			ClusterId: &statusClusterId,
+			Listeners: []kafkav1beta2.KafkaStatusListenersElem{
+				{
+					Name:             &plainListener,
+					BootstrapServers: &bootServer,
+				},
+				{
+					Name:             &tlsListener,
+					BootstrapServers: &bootServer,
+					Certificates: []string{
+						"cert",
+					},
+				},
+			},
+			Conditions: []kafkav1beta2.KafkaStatusConditionsElem{
+				{
+					Type:   &readyCondition,
+					Status: &trueCondition,
+				},
+			},
+		},
+	}
+
+	err := wait.PollImmediate(1*time.Second, 1*time.Minute, func() (bool, error) {
+		existkafkaCluster := &kafkav1beta2.Kafka{}
+		err := c.Get(context.Background(), types.NamespacedName{
+			Name:      kafkaClusterName,
+			Namespace: ns,
+		}, existkafkaCluster)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				if e := c.Create(context.Background(), statusKafkaCluster); e != nil {
+					klog.Errorf("Failed to create kafka cluster, error: %v", e)
+					return false, nil
+				}
+			} else {
+				klog.Errorf("Failed to get Kafka cluster, error:%v", err)
+			}
+			return false, nil
+		}
+		existkafkaCluster.Status = &kafkav1beta2.KafkaStatus{
+			Listeners: []kafkav1beta2.KafkaStatusListenersElem{
+				{
+					Name:             &plainListener,
+					BootstrapServers: &bootServer,
+				},
+				{
+					Name:             &tlsListener,
+					BootstrapServers: &bootServer,
+					Certificates: []string{
+						"cert",
+					},
+				},
+			},
+			Conditions: []kafkav1beta2.KafkaStatusConditionsElem{
+				{
+					Type:   &readyCondition,
+					Status: &trueCondition,
+				},
+			},
+		}
+		err = c.Status().Update(context.Background(), existkafkaCluster)
+		if err != nil {
+			klog.Errorf("Failed to update Kafka cluster, error:%v", err)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to make the kafka cluster ready: %w", err)
+	}
+
+	err = createSecret(c, ns, globalHubKafkaUser, map[string][]byte{
+		"user.crt": []byte("usercrt"),
+		"user.key": []byte("userkey"),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = createSecret(c, ns, clientCa, map[string][]byte{
+		"ca.key": []byte("cakey"),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = createSecret(c, ns, clientCaCert, map[string][]byte{
+		"ca.crt": []byte("cacert"),
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func createSecret(c client.Client, ns, name string, data map[string][]byte) error {
+	clientCaCertSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+		Data: data,
+	}
+	err := c.Get(context.Background(), client.ObjectKeyFromObject(clientCaCertSecret), clientCaCertSecret)
+	if errors.IsNotFound(err) {
+		e := c.Create(context.Background(), clientCaCertSecret)
+		if e != nil {
+			return e
+		}
+	} else if err != nil {
+		return err
+	}
+	return nil
+}